@@ -57,6 +57,12 @@ func (e *Expression) resolve(index int, msg Message, escaped, legacy bool) []byt
 	}
 	var buf bytes.Buffer
 	for _, r := range e.resolvers {
+		// Static segments are written directly in order to avoid converting
+		// them to a byte slice on every call.
+		if s, ok := r.(StaticResolver); ok {
+			buf.WriteString(string(s))
+			continue
+		}
 		buf.Write(r.ResolveBytes(index, msg, escaped, legacy))
 	}
 	return buf.Bytes()
@@ -69,7 +75,10 @@ func (e *Expression) NumDynamicExpressions() int {
 }
 
 // Bytes returns a byte slice representing the expression resolved for a message
-// of a batch.
+// of a batch. Unlike String the result is not converted, and an expression
+// consisting only of a query such as `${! content() }` returns the raw bytes of
+// the message without copying them, which makes Bytes preferable when the
+// result is written to a sink. The result must therefore not be modified.
 func (e *Expression) Bytes(index int, msg Message) []byte {
 	if len(e.resolvers) == 0 {
 		return []byte(e.static)
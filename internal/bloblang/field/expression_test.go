@@ -467,3 +467,51 @@ func TestLegacyExpressions(t *testing.T) {
 		})
 	}
 }
+
+func contentExpression(t testing.TB, prefix string) *Expression {
+	t.Helper()
+
+	fn, err := query.InitFunction("content")
+	require.NoError(t, err)
+	if prefix == "" {
+		return NewExpression(NewQueryResolver(fn))
+	}
+	return NewExpression(StaticResolver(prefix), NewQueryResolver(fn))
+}
+
+func TestExpressionBytesNoCopy(t *testing.T) {
+	payload := []byte("hello world")
+	msg := message.New([][]byte{payload})
+
+	res := contentExpression(t, "").Bytes(0, msg)
+	require.Len(t, res, len(payload))
+	assert.True(t, &res[0] == &payload[0], "expected contents not to be copied")
+
+	assert.Equal(t, "foo hello world", string(contentExpression(t, "foo ").Bytes(0, msg)))
+}
+
+func BenchmarkExpressionContent10MB(b *testing.B) {
+	msg := message.New([][]byte{make([]byte, 10*1024*1024)})
+
+	for _, test := range []struct {
+		name   string
+		prefix string
+	}{
+		{name: "query only", prefix: ""},
+		{name: "with static prefix", prefix: "foo "},
+	} {
+		e := contentExpression(b, test.prefix)
+		b.Run(test.name+" bytes", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = e.Bytes(0, msg)
+			}
+		})
+		b.Run(test.name+" string", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = e.String(0, msg)
+			}
+		})
+	}
+}
@@ -64,6 +64,7 @@ type Executor struct {
 	annotation string
 	input      []rune
 	maps       map[string]query.Function
+	params     []string
 	statements []Statement
 }
 
@@ -72,7 +73,21 @@ type Executor struct {
 // is an optional slice pointing to the parsed expression that created the
 // executor.
 func NewExecutor(annotation string, input []rune, maps map[string]query.Function, statements ...Statement) *Executor {
-	return &Executor{annotation, input, maps, statements}
+	return &Executor{
+		annotation: annotation,
+		input:      input,
+		maps:       maps,
+		statements: statements,
+	}
+}
+
+// NewMapExecutor initialises a new mapping executor for a named map that
+// declares a list of parameters, which are set as variables from the arguments
+// given when the map is applied.
+func NewMapExecutor(annotation string, input []rune, maps map[string]query.Function, params []string, statements ...Statement) *Executor {
+	e := NewExecutor(annotation, input, maps, statements...)
+	e.params = params
+	return e
 }
 
 // Annotation returns a string annotation that describes the mapping executor.
@@ -85,6 +100,11 @@ func (e *Executor) Maps() map[string]query.Function {
 	return e.maps
 }
 
+// Params returns the names of the parameters declared by a named map.
+func (e *Executor) Params() []string {
+	return e.params
+}
+
 // QueryPart executes the bloblang mapping on a particular message index of a
 // batch. The message is parsed as a JSON document in order to provide the
 // mapping context. The result of the mapping is expected to be a boolean value
@@ -0,0 +1,227 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+)
+
+//------------------------------------------------------------------------------
+
+// Lint describes a potential problem with a mapping that does not prevent it
+// from being parsed or executed.
+type Lint struct {
+	Line int
+	What string
+}
+
+// Lint returns a list of potential problems found within a mapping, such as
+// unused variables, unreachable statements and methods executed on literal
+// values of an unsupported type.
+func (e *Executor) Lint() []Lint {
+	var lints []Lint
+	lints = append(lints, e.lintUnusedVariables()...)
+	lints = append(lints, e.lintUnreachable()...)
+	lints = append(lints, e.lintLiteralMethods()...)
+	sortLints(lints)
+	return lints
+}
+
+// LintSample returns the same lints as Lint along with best-effort warnings for
+// any paths of the mapped document that are referenced by the mapping but are
+// not present within a sample document.
+func (e *Executor) LintSample(sample interface{}) []Lint {
+	lints := e.Lint()
+	lints = append(lints, e.lintSamplePaths(sample)...)
+	sortLints(lints)
+	return lints
+}
+
+func sortLints(lints []Lint) {
+	sort.SliceStable(lints, func(i, j int) bool {
+		return lints[i].Line < lints[j].Line
+	})
+}
+
+func (e *Executor) lineOf(stmt Statement) int {
+	if len(e.input) == 0 || len(stmt.input) == 0 {
+		return 0
+	}
+	line, _ := LineAndColOf(e.input, stmt.input)
+	return line
+}
+
+func (e *Executor) statementTargets(stmt Statement) []query.TargetPath {
+	_, paths := stmt.query.QueryTargets(query.TargetsContext{
+		Maps: e.maps,
+	})
+	return paths
+}
+
+//------------------------------------------------------------------------------
+
+func (e *Executor) lintUnusedVariables() []Lint {
+	used := map[string]struct{}{}
+	for _, stmt := range e.statements {
+		for _, p := range e.statementTargets(stmt) {
+			if p.Type == query.TargetVariable && len(p.Path) > 0 {
+				used[p.Path[0]] = struct{}{}
+			}
+		}
+	}
+	for _, m := range e.maps {
+		_, paths := m.QueryTargets(query.TargetsContext{Maps: e.maps})
+		for _, p := range paths {
+			if p.Type == query.TargetVariable && len(p.Path) > 0 {
+				used[p.Path[0]] = struct{}{}
+			}
+		}
+	}
+
+	var lints []Lint
+	reported := map[string]struct{}{}
+	for _, stmt := range e.statements {
+		target := stmt.assignment.Target()
+		if target.Type != TargetVariable || len(target.Path) == 0 {
+			continue
+		}
+		name := target.Path[0]
+		if _, exists := used[name]; exists {
+			continue
+		}
+		if _, exists := reported[name]; exists {
+			continue
+		}
+		reported[name] = struct{}{}
+		lints = append(lints, Lint{
+			Line: e.lineOf(stmt),
+			What: fmt.Sprintf("variable %v is assigned but never used", name),
+		})
+	}
+	return lints
+}
+
+func isRootDeletion(stmt Statement) bool {
+	target := stmt.assignment.Target()
+	if target.Type != TargetValue || len(target.Path) > 0 {
+		return false
+	}
+	lit, ok := stmt.query.(*query.Literal)
+	if !ok {
+		return false
+	}
+	_, isDelete := lit.Value.(query.Delete)
+	return isDelete
+}
+
+func isRootAssignment(stmt Statement) bool {
+	target := stmt.assignment.Target()
+	return target.Type == TargetValue && len(target.Path) == 0
+}
+
+func (e *Executor) lintUnreachable() []Lint {
+	var lints []Lint
+	for i, stmt := range e.statements {
+		if stmt.query.Annotation() == "function throw" {
+			if i+1 < len(e.statements) {
+				lints = append(lints, Lint{
+					Line: e.lineOf(e.statements[i+1]),
+					What: fmt.Sprintf("statement is unreachable as line %v always throws an error", e.lineOf(stmt)),
+				})
+			}
+			return lints
+		}
+		if !isRootDeletion(stmt) {
+			continue
+		}
+		for _, next := range e.statements[i+1:] {
+			if isRootAssignment(next) {
+				break
+			}
+			if next.assignment.Target().Type == TargetValue {
+				lints = append(lints, Lint{
+					Line: e.lineOf(next),
+					What: fmt.Sprintf("assignment has no effect as the root was deleted on line %v", e.lineOf(stmt)),
+				})
+				break
+			}
+		}
+	}
+	return lints
+}
+
+func (e *Executor) lintLiteralMethods() []Lint {
+	var lints []Lint
+	for _, stmt := range e.statements {
+		if !query.IsLiteralMethodChain(stmt.query) {
+			continue
+		}
+		_, err := stmt.query.Exec(query.FunctionContext{
+			Maps: e.maps,
+			Vars: map[string]interface{}{},
+		})
+		var tErr *query.TypeError
+		if err != nil && errors.As(err, &tErr) {
+			lints = append(lints, Lint{
+				Line: e.lineOf(stmt),
+				What: fmt.Sprintf("%v cannot be executed on a literal value: %v", stmt.query.Annotation(), err),
+			})
+		}
+	}
+	return lints
+}
+
+//------------------------------------------------------------------------------
+
+func samplePathExists(v interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		child, exists := t[path[0]]
+		if !exists {
+			return false
+		}
+		return samplePathExists(child, path[1:])
+	case []interface{}:
+		// The path could be relative to any element, e.g. within a map_each,
+		// and therefore we check whether any element contains it.
+		for _, ele := range t {
+			if samplePathExists(ele, path) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func (e *Executor) lintSamplePaths(sample interface{}) []Lint {
+	var lints []Lint
+	reported := map[string]struct{}{}
+	for _, stmt := range e.statements {
+		for _, p := range e.statementTargets(stmt) {
+			if p.Type != query.TargetValue || len(p.Path) == 0 {
+				continue
+			}
+			if samplePathExists(sample, p.Path) {
+				continue
+			}
+			pathStr := query.SliceToDotPath(p.Path...)
+			if _, exists := reported[pathStr]; exists {
+				continue
+			}
+			reported[pathStr] = struct{}{}
+			lints = append(lints, Lint{
+				Line: e.lineOf(stmt),
+				What: fmt.Sprintf("path this.%v is not present within the sample input", pathStr),
+			})
+		}
+	}
+	return lints
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,95 @@
+package mapping_test
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMappingLint(t *testing.T) {
+	tests := map[string]struct {
+		mapping string
+		sample  interface{}
+		lints   []mapping.Lint
+	}{
+		"no problems": {
+			mapping: `let foo = this.foo
+root.bar = $foo.uppercase()`,
+		},
+		"unused variable": {
+			mapping: `root = this
+let foo = this.foo
+let bar = this.bar
+root.bar = $bar`,
+			lints: []mapping.Lint{
+				{Line: 2, What: "variable foo is assigned but never used"},
+			},
+		},
+		"variable used in meta": {
+			mapping: `let foo = this.foo
+meta bar = $foo`,
+		},
+		"assignment after deleted": {
+			mapping: `root = deleted()
+meta foo = "bar"
+root.foo = "bar"
+root.bar = "baz"`,
+			lints: []mapping.Lint{
+				{Line: 3, What: "assignment has no effect as the root was deleted on line 1"},
+			},
+		},
+		"root reassigned after deleted": {
+			mapping: `root = deleted()
+root = this
+root.foo = "bar"`,
+		},
+		"statement after throw": {
+			mapping: `root = this
+root.foo = throw("nope")
+root.bar = "baz"`,
+			lints: []mapping.Lint{
+				{Line: 3, What: "statement is unreachable as line 2 always throws an error"},
+			},
+		},
+		"method on bad literal": {
+			mapping: `root.foo = [ "foo" ].uppercase()
+root.bar = "bar".uppercase()`,
+			lints: []mapping.Lint{
+				{Line: 1, What: `method uppercase cannot be executed on a literal value: expected string value, got array from array literal`},
+			},
+		},
+		"sample paths": {
+			mapping: `root.a = this.foo
+root.b = this.feild
+root.c = this.items.map_each(ele -> ele.name)`,
+			sample: map[string]interface{}{
+				"foo": "bar",
+				"items": []interface{}{
+					map[string]interface{}{"name": "a"},
+				},
+			},
+			lints: []mapping.Lint{
+				{Line: 2, What: "path this.feild is not present within the sample input"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			exec, err := bloblang.NewMapping("", test.mapping)
+			require.NoError(t, err)
+
+			var lints []mapping.Lint
+			if test.sample != nil {
+				lints = exec.LintSample(test.sample)
+			} else {
+				lints = exec.Lint()
+			}
+			assert.Equal(t, test.lints, lints)
+		})
+	}
+}
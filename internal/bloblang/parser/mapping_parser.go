@@ -48,6 +48,48 @@ func ParseMapping(filepath, expr string, pCtx Context) (*mapping.Executor, *Erro
 
 //------------------------------------------------------------------------------'
 
+// mapCall describes a call site of the apply method with a literal map name,
+// which is checked against the parameters of the map once it is declared.
+type mapCall struct {
+	name  string
+	nArgs int
+	input []rune
+}
+
+// mapCalls collects the apply call sites of a mapping. A nil *mapCalls is valid
+// and discards calls.
+type mapCalls struct {
+	calls []mapCall
+}
+
+func (m *mapCalls) add(name string, nArgs int, input []rune) {
+	if m == nil {
+		return
+	}
+	m.calls = append(m.calls, mapCall{name: name, nArgs: nArgs, input: input})
+}
+
+// check returns an error at the position of the first call site where the
+// number of arguments does not match the parameters of a declared map.
+func (m *mapCalls) check(maps map[string]query.Function) *Error {
+	for _, c := range m.calls {
+		fn, exists := maps[c.name]
+		if !exists {
+			continue
+		}
+		var params []string
+		if p, ok := fn.(interface {
+			Params() []string
+		}); ok {
+			params = p.Params()
+		}
+		if len(params) != c.nArgs {
+			return NewFatalError(c.input, query.NewMapArgsError(c.name, params, c.nArgs))
+		}
+	}
+	return nil
+}
+
 func parseExecutor(baseDir string, pCtx Context) Func {
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
@@ -57,6 +99,11 @@ func parseExecutor(baseDir string, pCtx Context) Func {
 		maps := map[string]query.Function{}
 		statements := []mapping.Statement{}
 
+		// Calls are collected for this file only, as maps are scoped to the
+		// mapping that declares or imports them.
+		pCtx := pCtx
+		pCtx.mapCalls = &mapCalls{}
+
 		statement := OneOf(
 			importParser(baseDir, maps, pCtx),
 			mapParser(maps, pCtx),
@@ -98,6 +145,9 @@ func parseExecutor(baseDir string, pCtx Context) Func {
 				statements = append(statements, mStmt)
 			}
 		}
+		if err := pCtx.mapCalls.check(maps); err != nil {
+			return Fail(err, input)
+		}
 		return Success(mapping.NewExecutor("", input, maps, statements...), res.Remaining)
 	}
 }
@@ -245,6 +295,17 @@ func importParser(baseDir string, maps map[string]query.Function, pCtx Context)
 	}
 }
 
+func mapParamsParser() Func {
+	whitespace := DiscardAll(OneOf(SpacesAndTabs(), NewlineAllowComment()))
+	return DelimitedPattern(
+		Expect(Sequence(Char('('), whitespace), "map parameters"),
+		MustBe(Expect(varNameParser(), "parameter name")),
+		MustBe(Expect(Sequence(Discard(SpacesAndTabs()), Char(','), whitespace), "comma")),
+		MustBe(Expect(Sequence(whitespace, Char(')')), "closing bracket")),
+		false,
+	)
+}
+
 func mapParser(maps map[string]query.Function, pCtx Context) Func {
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
@@ -263,6 +324,7 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 				"map name",
 			),
 		),
+		Optional(mapParamsParser()),
 		SpacesAndTabs(),
 		DelimitedPattern(
 			Sequence(
@@ -295,18 +357,31 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 
 		seqSlice := res.Payload.([]interface{})
 		ident := seqSlice[2].(string)
-		stmtSlice := seqSlice[4].([]interface{})
+		stmtSlice := seqSlice[5].([]interface{})
 
 		if _, exists := maps[ident]; exists {
 			return Fail(NewFatalError(input, fmt.Errorf("map name collision: %v", ident)), input)
 		}
 
+		var params []string
+		if paramSlice, ok := seqSlice[3].([]interface{}); ok {
+			seen := map[string]struct{}{}
+			for _, p := range paramSlice {
+				param := p.(string)
+				if _, exists := seen[param]; exists {
+					return Fail(NewFatalError(input, fmt.Errorf("duplicate parameter of map %v: %v", ident, param)), input)
+				}
+				seen[param] = struct{}{}
+				params = append(params, param)
+			}
+		}
+
 		statements := make([]mapping.Statement, len(stmtSlice))
 		for i, v := range stmtSlice {
 			statements[i] = v.(mapping.Statement)
 		}
 
-		maps[ident] = mapping.NewExecutor("map "+ident, input, maps, statements...)
+		maps[ident] = mapping.NewMapExecutor("map "+ident, input, maps, params, statements...)
 
 		return Success(ident, res.Remaining)
 	}
@@ -23,10 +23,22 @@ func TestMappingErrors(t *testing.T) {
 	badMapFile := filepath.Join(dir, "bad_map.blobl")
 	noMapsFile := filepath.Join(dir, "no_maps.blobl")
 	goodMapFile := filepath.Join(dir, "good_map.blobl")
+	paramMapFile := filepath.Join(dir, "param_map.blobl")
+	badParamMapFile := filepath.Join(dir, "bad_param_map.blobl")
 
 	require.NoError(t, ioutil.WriteFile(badMapFile, []byte(`not a map bruh`), 0777))
 	require.NoError(t, ioutil.WriteFile(noMapsFile, []byte(`foo = "this is valid but has no maps"`), 0777))
 	require.NoError(t, ioutil.WriteFile(goodMapFile, []byte(`map foo { foo = "this is valid" }`), 0777))
+	require.NoError(t, ioutil.WriteFile(paramMapFile, []byte(`map foo(a, b) {
+  root = [ $a, $b ]
+}`), 0777))
+	require.NoError(t, ioutil.WriteFile(badParamMapFile, []byte(`map foo(a, b) {
+  root = [ $a, $b ]
+}
+
+map bar {
+  root = this.apply("foo", "a")
+}`), 0777))
 
 	tests := map[string]struct {
 		mapping string
@@ -154,6 +166,61 @@ import "%v"
 foo = bar.apply("foo")`, goodMapFile),
 			err: fmt.Sprintf(`line 3 char 1: map name collisions from import '%v': [foo]`, goodMapFile),
 		},
+		"map missing argument": {
+			mapping: `map foo(a) {
+  root = $a
+}
+root = this.apply("foo")`,
+			err: `line 4 char 13: map foo expects 1 argument (a) but received 0`,
+		},
+		"map extra arguments": {
+			mapping: `map foo {
+  root = this
+}
+root.bar = this.apply("foo", 1, 2)`,
+			err: `line 4 char 17: map foo expects 0 arguments but received 2`,
+		},
+		"map called before declaration": {
+			mapping: `root = this.apply("foo", 1)
+map foo(a, b) {
+  root = $a
+}`,
+			err: `line 1 char 13: map foo expects 2 arguments (a, b) but received 1`,
+		},
+		"map called within map": {
+			mapping: `map foo(a) {
+  root = $a
+}
+map bar {
+  root = this.apply("foo")
+}
+root = this.apply("bar")`,
+			err: `line 5 char 15: map foo expects 1 argument (a) but received 0`,
+		},
+		"map duplicate parameters": {
+			mapping: `map foo(a, a) {
+  root = $a
+}`,
+			err: `line 1 char 1: duplicate parameter of map foo: a`,
+		},
+		"map bad parameters": {
+			mapping: `map foo(a, {
+  root = $a
+}`,
+			err: `line 1 char 12: required: expected parameter name`,
+		},
+		"bad arguments to imported map": {
+			mapping: fmt.Sprintf(`import "%v"
+
+root = this.apply("foo", 1)`, paramMapFile),
+			err: `line 3 char 13: map foo expects 2 arguments (a, b) but received 1`,
+		},
+		"bad arguments within imported map": {
+			mapping: fmt.Sprintf(`import "%v"
+
+root = this.apply("bar")`, badParamMapFile),
+			err: fmt.Sprintf(`line 1 char 1: failed to parse import '%v': line 6 char 15: map foo expects 2 arguments (a, b) but received 1`, badParamMapFile),
+		},
 		"quotes at root": {
 			mapping: `
 "root.something" = 5 + 2`,
@@ -188,6 +255,11 @@ func TestMappings(t *testing.T) {
   nested = this
 }`), 0777))
 
+	paramMapFile := filepath.Join(dir, "param_map.blobl")
+	require.NoError(t, ioutil.WriteFile(paramMapFile, []byte(`map redact(fields) {
+  root = this.map_each(kv -> if $fields.contains(kv.key) { "REDACTED" } else { kv.value })
+}`), 0777))
+
 	directMapFile := filepath.Join(dir, "direct_map.blobl")
 	require.NoError(t, ioutil.WriteFile(directMapFile, []byte(`root.nested = this`), 0777))
 
@@ -469,6 +541,51 @@ root = this.apply("foo")`, goodMapFile),
 				Content: `{"foo":"this is valid","nested":{"outter":{"inner":"hello world"}}}`,
 			},
 		},
+		"test map parameters": {
+			mapping: `map foo(a, b) {
+  let c = "c"
+  root.a = $a
+  root.b = $b
+  root.c = $c
+  root.value = this
+}
+let a = "outer"
+root.first = this.apply("foo", this.name, 1)
+root.second = this.apply("foo", [ "b" ], { "a": $a })`,
+			input: []part{
+				{Content: `{"name":"hello world"}`},
+			},
+			output: part{
+				Content: `{"first":{"a":"hello world","b":1,"c":"c","value":{"name":"hello world"}},"second":{"a":["b"],"b":{"a":"outer"},"c":"c","value":{"name":"hello world"}}}`,
+			},
+		},
+		"test nested map parameters": {
+			mapping: `map inner(prefix) {
+  root = $prefix + this
+}
+map outer(prefix) {
+  root.inner = this.apply("inner", $prefix + "inner ")
+  root.prefix = $prefix
+}
+root = this.name.apply("outer", "outer ")`,
+			input: []part{
+				{Content: `{"name":"foo"}`},
+			},
+			output: part{
+				Content: `{"inner":"outer inner foo","prefix":"outer "}`,
+			},
+		},
+		"test imported map parameters": {
+			mapping: fmt.Sprintf(`import "%v"
+
+root = this.apply("redact", [ "ssn" ])`, paramMapFile),
+			input: []part{
+				{Content: `{"name":"foo","ssn":"123"}`},
+			},
+			output: part{
+				Content: `{"name":"foo","ssn":"REDACTED"}`,
+			},
+		},
 		"test directly imported map": {
 			mapping: fmt.Sprintf(`from "%v"`, directMapFile),
 			input: []part{
@@ -517,3 +634,30 @@ root = this.apply("foo")`, goodMapFile),
 		})
 	}
 }
+
+func TestMappingDynamicMapArguments(t *testing.T) {
+	exec, perr := ParseMapping("", `map foo(a) {
+  root = $a
+}
+root = this.apply(this.map, "bar")`, Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	})
+	require.Nil(t, perr)
+
+	res, err := exec.MapPart(0, message.New([][]byte{[]byte(`{"map":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(res.Get()))
+
+	exec, perr = ParseMapping("", `map foo(a, b) {
+  root = $a
+}
+root = this.apply(this.map, "bar")`, Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	})
+	require.Nil(t, perr)
+
+	_, err = exec.MapPart(0, message.New([][]byte{[]byte(`{"map":"foo"}`)}))
+	assert.EqualError(t, err, "failed assignment (line 4): map foo expects 2 arguments (a, b) but received 1")
+}
@@ -291,6 +291,14 @@ func methodParser(fn query.Function, pCtx Context) Func {
 		targetMethod := seqSlice[0].(string)
 		args := seqSlice[1].([]interface{})
 
+		if targetMethod == "apply" && len(args) > 0 {
+			if lit, ok := args[0].(*query.Literal); ok {
+				if name, ok := lit.Value.(string); ok {
+					pCtx.mapCalls.add(name, len(args)-1, input)
+				}
+			}
+		}
+
 		method, err := pCtx.InitMethod(targetMethod, fn, args...)
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
@@ -20,6 +20,7 @@ type Context struct {
 	Functions    FunctionSet
 	Methods      MethodSet
 	namedContext *namedContext
+	mapCalls     *mapCalls
 }
 
 type namedContext struct {
@@ -3,9 +3,9 @@ package query
 // ExampleSpec provides a mapping example and some input/output results to
 // display.
 type ExampleSpec struct {
-	Mapping string
-	Summary string
-	Results [][2]string
+	Mapping string      `json:"mapping"`
+	Summary string      `json:"summary"`
+	Results [][2]string `json:"results"`
 }
 
 // NewExampleSpec creates a new example spec.
@@ -50,26 +50,42 @@ var (
 	FunctionCategoryPlugin      FunctionCategory = "Plugin"
 )
 
+// ParamSpec describes a single named parameter of a function or method.
+type ParamSpec struct {
+	// Name of the parameter.
+	Name string `json:"name"`
+
+	// Description of the parameter (in markdown).
+	Description string `json:"description"`
+
+	// Type of values accepted by the parameter.
+	Type string `json:"type"`
+}
+
 // FunctionSpec describes a Bloblang function.
 type FunctionSpec struct {
 	// The release status of the function.
-	Status Status
+	Status Status `json:"status"`
 
 	// A category to place the function within.
-	Category FunctionCategory
+	Category FunctionCategory `json:"category"`
 
 	// Name of the function (as it appears in config).
-	Name string
+	Name string `json:"name"`
 
 	// Description of the functions purpose (in markdown).
-	Description string
+	Description string `json:"description"`
+
+	// Params describes the parameters of the function when they are
+	// explicitly defined.
+	Params []ParamSpec `json:"params,omitempty"`
 
 	// Examples shows general usage for the function.
-	Examples []ExampleSpec
+	Examples []ExampleSpec `json:"examples,omitempty"`
 
 	// Impure indicates that a function accesses or interacts with the
 	// environment, and is therefore unsafe.
-	Impure bool
+	Impure bool `json:"impure"`
 }
 
 // NewFunctionSpec creates a new function spec.
@@ -137,27 +153,31 @@ var (
 // MethodCatSpec describes how a method behaves in the context of a given
 // category.
 type MethodCatSpec struct {
-	Category    MethodCategory
-	Description string
-	Examples    []ExampleSpec
+	Category    MethodCategory `json:"category"`
+	Description string         `json:"description"`
+	Examples    []ExampleSpec  `json:"examples,omitempty"`
 }
 
 // MethodSpec describes a Bloblang method.
 type MethodSpec struct {
 	// The release status of the function.
-	Status Status
+	Status Status `json:"status"`
 
 	// Name of the method (as it appears in config).
-	Name string
+	Name string `json:"name"`
 
 	// Description of the method purpose (in markdown).
-	Description string
+	Description string `json:"description"`
+
+	// Params describes the parameters of the method when they are explicitly
+	// defined.
+	Params []ParamSpec `json:"params,omitempty"`
 
 	// Examples shows general usage for the method.
-	Examples []ExampleSpec
+	Examples []ExampleSpec `json:"examples,omitempty"`
 
 	// Categories that this method fits within.
-	Categories []MethodCatSpec
+	Categories []MethodCatSpec `json:"categories,omitempty"`
 }
 
 // NewMethodSpec creates a new method spec.
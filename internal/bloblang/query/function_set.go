@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"sync"
 )
 
 // FunctionSet contains an explicit set of functions to be available in a
 // Bloblang query.
 type FunctionSet struct {
+	mut          sync.RWMutex
 	constructors map[string]FunctionCtor
 	specs        []FunctionSpec
 }
@@ -29,6 +31,8 @@ func (f *FunctionSet) Add(spec FunctionSpec, ctor FunctionCtor, autoResolveFunct
 	if autoResolveFunctionArgs {
 		ctor = functionWithAutoResolvedFunctionArgs("function "+spec.Name, ctor)
 	}
+	f.mut.Lock()
+	defer f.mut.Unlock()
 	if _, exists := f.constructors[spec.Name]; exists {
 		return fmt.Errorf("conflicting function name: %v", spec.Name)
 	}
@@ -39,11 +43,17 @@ func (f *FunctionSet) Add(spec FunctionSpec, ctor FunctionCtor, autoResolveFunct
 
 // Docs returns a slice of function specs, which document each function.
 func (f *FunctionSet) Docs() []FunctionSpec {
-	return f.specs
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+	specs := make([]FunctionSpec, len(f.specs))
+	copy(specs, f.specs)
+	return specs
 }
 
 // List returns a slice of function names in alphabetical order.
 func (f *FunctionSet) List() []string {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
 	functionNames := make([]string, 0, len(f.constructors))
 	for k := range f.constructors {
 		functionNames = append(functionNames, k)
@@ -55,7 +65,9 @@ func (f *FunctionSet) List() []string {
 // Init attempts to initialize a function of the set by name and zero or more
 // arguments.
 func (f *FunctionSet) Init(name string, args ...interface{}) (Function, error) {
+	f.mut.RLock()
 	ctor, exists := f.constructors[name]
+	f.mut.RUnlock()
 	if !exists {
 		return nil, badFunctionErr(name)
 	}
@@ -66,6 +78,9 @@ func (f *FunctionSet) Init(name string, args ...interface{}) (Function, error) {
 // Without creates a clone of the function set that can be mutated in isolation,
 // where a variadic list of functions will be excluded from the set.
 func (f *FunctionSet) Without(functions ...string) *FunctionSet {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+
 	excludeMap := make(map[string]struct{}, len(functions))
 	for _, k := range functions {
 		excludeMap[k] = struct{}{}
@@ -84,14 +99,14 @@ func (f *FunctionSet) Without(functions ...string) *FunctionSet {
 			specs = append(specs, v)
 		}
 	}
-	return &FunctionSet{constructors, specs}
+	return &FunctionSet{constructors: constructors, specs: specs}
 }
 
 // OnlyPure creates a clone of the function set that can be mutated in
 // isolation, where all impure functions are removed.
 func (f *FunctionSet) OnlyPure() *FunctionSet {
 	var excludes []string
-	for _, v := range f.specs {
+	for _, v := range f.Docs() {
 		if v.Impure {
 			excludes = append(excludes, v.Name)
 		}
@@ -103,7 +118,7 @@ func (f *FunctionSet) OnlyPure() *FunctionSet {
 // isolation, where all message access functions are removed.
 func (f *FunctionSet) NoMessage() *FunctionSet {
 	var excludes []string
-	for _, v := range f.specs {
+	for _, v := range f.Docs() {
 		if v.Category == FunctionCategoryMessage {
 			excludes = append(excludes, v.Name)
 		}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"time"
 
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/gofrs/uuid"
@@ -178,6 +179,64 @@ var _ = registerSimpleFunction(
 
 //------------------------------------------------------------------------------
 
+// originBatch returns the batch that the mapped message originated from, which
+// is the batch being mapped unless the message was selected from a wider batch
+// by a processor such as select.
+func originBatch(ctx FunctionContext) (MessageBatch, int) {
+	if batch, index, ok := imessage.GetOriginBatch(ctx.MsgBatch.Get(ctx.Index)); ok {
+		return batch, index
+	}
+	return ctx.MsgBatch, ctx.Index
+}
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "origin_batch_index",
+		"Returns the index of the mapped message within the batch it originated from. When executed within the processors of a [`select`](/docs/components/processors/select) processor this is the index of the message within the batch that the `select` processor received, otherwise it is the same as [`batch_index`](#batch_index).",
+		NewExampleSpec("",
+			`root.is_first = origin_batch_index() == 0`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		_, index := originBatch(ctx)
+		return int64(index), nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "origin_batch_size",
+		"Returns the size of the batch that the mapped message originated from. When executed within the processors of a [`select`](/docs/components/processors/select) processor this is the size of the batch that the `select` processor received, otherwise it is the same as [`batch_size`](#batch_size).",
+		NewExampleSpec("",
+			`root.trailer.count = origin_batch_size()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		batch, _ := originBatch(ctx)
+		return int64(batch.Len()), nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "origin_batch_bytes",
+		"Returns the total size in bytes of the raw contents of the messages of the batch that the mapped message originated from. When executed within the processors of a [`select`](/docs/components/processors/select) processor this is the total size of the batch that the `select` processor received, otherwise it is the total size of the batch being mapped.",
+		NewExampleSpec("",
+			`root.trailer.bytes = origin_batch_bytes()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		batch, _ := originBatch(ctx)
+		var total int64
+		for i := 0; i < batch.Len(); i++ {
+			total += int64(len(batch.Get(i).Get()))
+		}
+		return total, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleFunction(
 	NewFunctionSpec(
 		FunctionCategoryMessage, "content",
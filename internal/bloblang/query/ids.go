@@ -0,0 +1,366 @@
+package query
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var errULIDOverflow = errors.New("ulid entropy overflowed within a millisecond")
+
+// ulidGenerator creates ULIDs that increase monotonically within the process,
+// when several are generated within the same millisecond the random component
+// of the previous ULID is incremented.
+type ulidGenerator struct {
+	mut         sync.Mutex
+	lastMs      uint64
+	lastEntropy [10]byte
+}
+
+func (g *ulidGenerator) next(now time.Time) ([16]byte, error) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	var id [16]byte
+	ms := uint64(now.UnixNano() / int64(time.Millisecond))
+	if ms <= g.lastMs {
+		// Either within the same millisecond or the clock has gone backwards,
+		// in both cases the previous timestamp is kept and the entropy is
+		// incremented in order to remain sortable.
+		ms = g.lastMs
+		i := len(g.lastEntropy) - 1
+		for ; i >= 0; i-- {
+			g.lastEntropy[i]++
+			if g.lastEntropy[i] != 0 {
+				break
+			}
+		}
+		if i < 0 {
+			return id, errULIDOverflow
+		}
+	} else if _, err := rand.Read(g.lastEntropy[:]); err != nil {
+		return id, err
+	}
+	g.lastMs = ms
+
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (8 * (5 - i)))
+	}
+	copy(id[6:], g.lastEntropy[:])
+	return id, nil
+}
+
+var globalULIDGenerator = &ulidGenerator{}
+
+// NewULID returns the Crockford base32 representation of a new ULID from the
+// same process wide generator as the ulid function.
+func NewULID() (string, error) {
+	id, err := globalULIDGenerator.next(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return encodeULID(id), nil
+}
+
+func encodeULID(id [16]byte) string {
+	n := new(big.Int).SetBytes(id[:])
+	out := make([]byte, 26)
+	mod, base := new(big.Int), big.NewInt(32)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = crockfordAlphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+func parseULIDTime(s string) (time.Time, error) {
+	if len(s) != 26 {
+		return time.Time{}, fmt.Errorf("expected ulid of 26 characters, got %v", len(s))
+	}
+	if s[0] > '7' {
+		return time.Time{}, errors.New("ulid timestamp overflows 48 bits")
+	}
+	var ms uint64
+	for i, c := range strings.ToUpper(s) {
+		switch c {
+		case 'I', 'L':
+			c = '1'
+		case 'O':
+			c = '0'
+		}
+		v := strings.IndexRune(crockfordAlphabet, c)
+		if v == -1 {
+			return time.Time{}, fmt.Errorf("invalid ulid character: %q", c)
+		}
+		// Only the first ten characters encode the timestamp.
+		if i < 10 {
+			ms = ms<<5 | uint64(v)
+		}
+	}
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC(), nil
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	ksuidEpoch    = 1400000000
+	base62Chars   = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEncodedN = 27
+)
+
+func newKSUID(now time.Time) (string, error) {
+	var id [20]byte
+	ts := uint32(now.Unix() - ksuidEpoch)
+	id[0], id[1], id[2], id[3] = byte(ts>>24), byte(ts>>16), byte(ts>>8), byte(ts)
+	if _, err := rand.Read(id[4:]); err != nil {
+		return "", err
+	}
+
+	n := new(big.Int).SetBytes(id[:])
+	out := make([]byte, ksuidEncodedN)
+	mod, base := new(big.Int), big.NewInt(62)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62Chars[mod.Int64()]
+	}
+	return string(out), nil
+}
+
+func parseKSUIDTime(s string) (time.Time, error) {
+	if len(s) != ksuidEncodedN {
+		return time.Time{}, fmt.Errorf("expected ksuid of %v characters, got %v", ksuidEncodedN, len(s))
+	}
+	n, base := new(big.Int), big.NewInt(62)
+	for _, c := range s {
+		v := strings.IndexRune(base62Chars, c)
+		if v == -1 {
+			return time.Time{}, fmt.Errorf("invalid ksuid character: %q", c)
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(v)))
+	}
+	if n.BitLen() > 160 {
+		return time.Time{}, errors.New("ksuid value overflows 160 bits")
+	}
+	ts := new(big.Int).Rsh(n, 128).Int64()
+	return time.Unix(ts+ksuidEpoch, 0).UTC(), nil
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	snowflakeEpochMs  = 1288834974657
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSeq   = 1<<snowflakeSeqBits - 1
+)
+
+// snowflakeGenerator creates snowflake IDs for a node, which consist of a
+// millisecond timestamp, the node ID and a sequence number that is incremented
+// for each ID generated within the same millisecond.
+type snowflakeGenerator struct {
+	mut    sync.Mutex
+	node   int64
+	lastMs int64
+	seq    int64
+}
+
+func (g *snowflakeGenerator) next() int64 {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	ms := time.Now().UnixNano()/int64(time.Millisecond) - snowflakeEpochMs
+	if ms < g.lastMs {
+		// Keep IDs sortable when the clock goes backwards.
+		ms = g.lastMs
+	}
+	if ms == g.lastMs {
+		g.seq = (g.seq + 1) & snowflakeMaxSeq
+		if g.seq == 0 {
+			// The sequence is exhausted, so wait for the next millisecond.
+			for ms <= g.lastMs {
+				time.Sleep(time.Microsecond * 100)
+				ms = time.Now().UnixNano()/int64(time.Millisecond) - snowflakeEpochMs
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.lastMs = ms
+	return ms<<(snowflakeNodeBits+snowflakeSeqBits) | g.node<<snowflakeSeqBits | g.seq
+}
+
+var (
+	snowflakeGenerators    = map[int64]*snowflakeGenerator{}
+	snowflakeGeneratorsMut sync.Mutex
+)
+
+// getSnowflakeGenerator returns the generator of a node, which is shared by
+// all mappings within the process in order to avoid collisions.
+func getSnowflakeGenerator(node int64) *snowflakeGenerator {
+	snowflakeGeneratorsMut.Lock()
+	defer snowflakeGeneratorsMut.Unlock()
+
+	g, exists := snowflakeGenerators[node]
+	if !exists {
+		g = &snowflakeGenerator{node: node, lastMs: -1}
+		snowflakeGenerators[node] = g
+	}
+	return g
+}
+
+func defaultSnowflakeNode() int64 {
+	hostname, _ := os.Hostname()
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hostname))
+	return int64(h.Sum32() % (snowflakeMaxNode + 1))
+}
+
+func parseSnowflakeTime(id int64) time.Time {
+	ms := id>>(snowflakeNodeBits+snowflakeSeqBits) + snowflakeEpochMs
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ulid",
+		"Generates a new [ULID](https://github.com/ulid/spec) each time it is invoked and prints its Crockford base32 string representation. ULIDs begin with a millisecond timestamp and are therefore lexicographically sortable by the time they were generated. ULIDs generated within the same millisecond by a Benthos process, including across pipeline threads, increment the random component of the previous ULID and are therefore strictly increasing and never collide within the process. Collisions between processes require two ULIDs to be generated within the same millisecond with the same 80 bits of randomness.",
+		NewExampleSpec("", `root.id = ulid()`),
+	),
+	func(_ FunctionContext) (interface{}, error) {
+		id, err := NewULID()
+		if err != nil {
+			return nil, err
+		}
+		return id, nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ksuid",
+		"Generates a new [KSUID](https://github.com/segmentio/ksuid) each time it is invoked and prints its base62 string representation. KSUIDs begin with a timestamp in seconds and are therefore lexicographically sortable by the second they were generated, but KSUIDs generated within the same second are not ordered. Collisions require two KSUIDs to be generated within the same second with the same 128 bits of randomness.",
+		NewExampleSpec("", `root.id = ksuid()`),
+	),
+	func(_ FunctionContext) (interface{}, error) {
+		return newKSUID(time.Now())
+	},
+)
+
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "snowflake",
+		"Generates a new 64-bit snowflake ID each time it is invoked, which consists of a millisecond timestamp, a node ID between 0 and 1023 and a sequence number. IDs are therefore numerically sortable by the time they were generated. The node ID defaults to a hash of the hostname and can be set with an optional argument. IDs generated by a Benthos process with the same node ID, including across pipeline threads, are strictly increasing and never collide, but processes sharing a node ID must be avoided as their IDs may collide. Up to 4096 IDs can be generated per node each millisecond, beyond which generating an ID blocks until the next millisecond.",
+		NewExampleSpec("", `root.id = snowflake()`),
+		NewExampleSpec("", `root.id = snowflake(12)`),
+	),
+	true, func(args ...interface{}) (Function, error) {
+		node := defaultSnowflakeNode()
+		if len(args) > 0 {
+			node = args[0].(int64)
+		}
+		if node < 0 || node > snowflakeMaxNode {
+			return nil, fmt.Errorf("node id must be between 0 and %v, got %v", snowflakeMaxNode, node)
+		}
+		gen := getSnowflakeGenerator(node)
+		return ClosureFunction("function snowflake", func(_ FunctionContext) (interface{}, error) {
+			return gen.next(), nil
+		}, nil), nil
+	},
+	ExpectOneOrZeroArgs(),
+	ExpectIntArg(0),
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_ulid", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Attempts to parse a string as a [ULID](https://github.com/ulid/spec) and outputs its embedded timestamp as a string following ISO 8601, which can then be fed into `format_timestamp`.",
+		NewExampleSpec("",
+			`root.created_at = this.id.parse_ulid()`,
+			`{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}`,
+			`{"created_at":"2016-07-30T23:54:10.259Z"}`,
+		),
+	),
+	func(args ...interface{}) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			t, err := parseULIDTime(s)
+			if err != nil {
+				return nil, err
+			}
+			return t.Format(time.RFC3339Nano), nil
+		}), nil
+	},
+	true,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_ksuid", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Attempts to parse a string as a [KSUID](https://github.com/segmentio/ksuid) and outputs its embedded timestamp as a string following ISO 8601, which can then be fed into `format_timestamp`.",
+		NewExampleSpec("",
+			`root.created_at = this.id.parse_ksuid()`,
+			`{"id":"0ujtsYcgvSTl8PAuAdqWYSMnLOv"}`,
+			`{"created_at":"2017-10-10T04:00:47Z"}`,
+		),
+	),
+	func(args ...interface{}) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			t, err := parseKSUIDTime(s)
+			if err != nil {
+				return nil, err
+			}
+			return t.Format(time.RFC3339Nano), nil
+		}), nil
+	},
+	true,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_snowflake", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Attempts to parse a number, or a string containing a number, as a snowflake ID generated by the function `snowflake` and outputs its embedded timestamp as a string following ISO 8601, which can then be fed into `format_timestamp`.",
+		NewExampleSpec("",
+			`root.created_at = this.id.parse_snowflake()`,
+			`{"id":"1382971839098404864"}`,
+			`{"created_at":"2021-04-16T08:19:15.472Z"}`,
+		),
+	),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			id, err := IToInt(v)
+			if err != nil {
+				return nil, err
+			}
+			if id < 0 {
+				return nil, errors.New("snowflake ids must not be negative")
+			}
+			return parseSnowflakeTime(id).Format(time.RFC3339Nano), nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(0),
+)
+
+//------------------------------------------------------------------------------
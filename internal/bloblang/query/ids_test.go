@@ -0,0 +1,170 @@
+package query
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateConcurrently calls a function from several goroutines in parallel,
+// as pipeline threads would, and returns the results of each goroutine in the
+// order they were generated.
+func generateConcurrently(t *testing.T, fn Function) [][]interface{} {
+	t.Helper()
+
+	results := make([][]interface{}, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				v, err := fn.Exec(FunctionContext{})
+				require.NoError(t, err)
+				results[i] = append(results[i], v)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestULIDConcurrent(t *testing.T) {
+	fn, err := InitFunction("ulid")
+	require.NoError(t, err)
+
+	before := time.Now().Add(-time.Millisecond)
+	seen := map[string]struct{}{}
+	for _, ids := range generateConcurrently(t, fn) {
+		var last string
+		for _, v := range ids {
+			id := v.(string)
+			require.Len(t, id, 26)
+
+			_, exists := seen[id]
+			require.False(t, exists, id)
+			seen[id] = struct{}{}
+
+			require.Greater(t, id, last)
+			last = id
+
+			ts, err := parseULIDTime(id)
+			require.NoError(t, err)
+			assert.False(t, ts.Before(before.Truncate(time.Millisecond)), ts)
+		}
+	}
+}
+
+func TestULIDMonotonicWithinMillisecond(t *testing.T) {
+	gen := &ulidGenerator{}
+	now := time.Now()
+
+	var last string
+	for i := 0; i < 1000; i++ {
+		id, err := gen.next(now)
+		require.NoError(t, err)
+
+		str := encodeULID(id)
+		require.Greater(t, str, last)
+		last = str
+	}
+
+	// A clock going backwards retains the previous timestamp.
+	id, err := gen.next(now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Greater(t, encodeULID(id), last)
+
+	ts, err := parseULIDTime(encodeULID(id))
+	require.NoError(t, err)
+	assert.Equal(t, now.Truncate(time.Millisecond).UnixNano(), ts.UnixNano())
+}
+
+func TestULIDParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"01ARZ3NDEKTSV4RRFFQ69G5FA",
+		"81ARZ3NDEKTSV4RRFFQ69G5FAV",
+		"01ARZ3NDEKTSV4RRFFQ69G5FAU",
+	} {
+		_, err := parseULIDTime(s)
+		assert.Error(t, err, s)
+	}
+
+	ts, err := parseULIDTime("01arz3ndektsv4rrffq69g5fav")
+	require.NoError(t, err)
+	assert.Equal(t, "2016-07-30T23:54:10.259Z", ts.Format(time.RFC3339Nano))
+}
+
+func TestKSUID(t *testing.T) {
+	fn, err := InitFunction("ksuid")
+	require.NoError(t, err)
+
+	before := time.Now().Truncate(time.Second)
+	seen := map[string]struct{}{}
+	for _, ids := range generateConcurrently(t, fn) {
+		for _, v := range ids {
+			id := v.(string)
+			require.Len(t, id, 27)
+
+			_, exists := seen[id]
+			require.False(t, exists, id)
+			seen[id] = struct{}{}
+
+			ts, err := parseKSUIDTime(id)
+			require.NoError(t, err)
+			assert.False(t, ts.Before(before), ts)
+		}
+	}
+
+	_, err = parseKSUIDTime("zzzzzzzzzzzzzzzzzzzzzzzzzzz")
+	assert.Error(t, err)
+
+	_, err = parseKSUIDTime("0ujtsYcgvSTl8PAuAdqWYSMnLO!")
+	assert.Error(t, err)
+}
+
+func TestSnowflakeConcurrent(t *testing.T) {
+	fn, err := InitFunction("snowflake", int64(5))
+	require.NoError(t, err)
+
+	// Functions with the same node share a generator.
+	fnDupe, err := InitFunction("snowflake", int64(5))
+	require.NoError(t, err)
+
+	before := time.Now().Add(-time.Millisecond)
+	seen := map[int64]struct{}{}
+	for _, ids := range append(generateConcurrently(t, fn), generateConcurrently(t, fnDupe)...) {
+		var last int64
+		for _, v := range ids {
+			id := v.(int64)
+
+			_, exists := seen[id]
+			require.False(t, exists, id)
+			seen[id] = struct{}{}
+
+			require.Greater(t, id, last)
+			last = id
+
+			assert.Equal(t, int64(5), (id>>snowflakeSeqBits)&snowflakeMaxNode)
+			assert.False(t, parseSnowflakeTime(id).Before(before.Truncate(time.Millisecond)))
+		}
+	}
+}
+
+func TestSnowflakeNodes(t *testing.T) {
+	_, err := InitFunction("snowflake", int64(1024))
+	require.Error(t, err)
+
+	_, err = InitFunction("snowflake", int64(-1))
+	require.Error(t, err)
+
+	fn, err := InitFunction("snowflake")
+	require.NoError(t, err)
+
+	v, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultSnowflakeNode(), (v.(int64)>>snowflakeSeqBits)&snowflakeMaxNode)
+}
@@ -70,6 +70,31 @@ func registerMethod(spec MethodSpec, autoResolveFunctionArgs bool, ctor MethodCt
 	return struct{}{}
 }
 
+type simpleMethodFunction struct {
+	Function
+	target Function
+}
+
+// IsLiteralMethodChain returns true if a function is a chain of one or more
+// simple methods executed on a literal value, and therefore its result does
+// not depend on the context in which it is executed.
+func IsLiteralMethodChain(fn Function) bool {
+	m, ok := fn.(simpleMethodFunction)
+	if !ok {
+		return false
+	}
+	for {
+		switch t := m.target.(type) {
+		case *Literal:
+			return true
+		case simpleMethodFunction:
+			m = t
+		default:
+			return false
+		}
+	}
+}
+
 type simpleMethod func(v interface{}, ctx FunctionContext) (interface{}, error)
 
 type simpleMethodConstructor func(args ...interface{}) (simpleMethod, error)
@@ -81,17 +106,20 @@ func registerSimpleMethod(spec MethodSpec, ctor simpleMethodConstructor, autoRes
 			if err != nil {
 				return nil, err
 			}
-			return ClosureFunction("method "+spec.Name, func(ctx FunctionContext) (interface{}, error) {
-				v, err := target.Exec(ctx)
-				if err != nil {
-					return nil, err
-				}
-				res, err := fn(v, ctx)
-				if err != nil {
-					return nil, ErrFrom(err, target)
-				}
-				return res, nil
-			}, target.QueryTargets), nil
+			return simpleMethodFunction{
+				Function: ClosureFunction("method "+spec.Name, func(ctx FunctionContext) (interface{}, error) {
+					v, err := target.Exec(ctx)
+					if err != nil {
+						return nil, err
+					}
+					res, err := fn(v, ctx)
+					if err != nil {
+						return nil, ErrFrom(err, target)
+					}
+					return res, nil
+				}, target.QueryTargets),
+				target: target,
+			}, nil
 		},
 		autoResolveFunctionArgs,
 		checks...,
@@ -3,11 +3,13 @@ package query
 import (
 	"fmt"
 	"sort"
+	"sync"
 )
 
 // MethodSet contains an explicit set of methods to be available in a Bloblang
 // query.
 type MethodSet struct {
+	mut          sync.RWMutex
 	constructors map[string]MethodCtor
 	specs        []MethodSpec
 }
@@ -25,6 +27,8 @@ func (m *MethodSet) Add(spec MethodSpec, ctor MethodCtor, autoResolveFunctionArg
 	if autoResolveFunctionArgs {
 		ctor = methodWithAutoResolvedFunctionArgs("method "+spec.Name, ctor)
 	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
 	if _, exists := m.constructors[spec.Name]; exists {
 		return fmt.Errorf("conflicting method name: %v", spec.Name)
 	}
@@ -35,11 +39,17 @@ func (m *MethodSet) Add(spec MethodSpec, ctor MethodCtor, autoResolveFunctionArg
 
 // Docs returns a slice of method specs, which document each method.
 func (m *MethodSet) Docs() []MethodSpec {
-	return m.specs
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	specs := make([]MethodSpec, len(m.specs))
+	copy(specs, m.specs)
+	return specs
 }
 
 // List returns a slice of method names in alphabetical order.
 func (m *MethodSet) List() []string {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
 	methodNames := make([]string, 0, len(m.constructors))
 	for k := range m.constructors {
 		methodNames = append(methodNames, k)
@@ -51,7 +61,9 @@ func (m *MethodSet) List() []string {
 // Init attempts to initialize a method of the set by name from a target
 // function and zero or more arguments.
 func (m *MethodSet) Init(name string, target Function, args ...interface{}) (Function, error) {
+	m.mut.RLock()
 	ctor, exists := m.constructors[name]
+	m.mut.RUnlock()
 	if !exists {
 		return nil, badMethodErr(name)
 	}
@@ -62,6 +74,9 @@ func (m *MethodSet) Init(name string, target Function, args ...interface{}) (Fun
 // Without creates a clone of the method set that can be mutated in isolation,
 // where a variadic list of methods will be excluded from the set.
 func (m *MethodSet) Without(methods ...string) *MethodSet {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+
 	excludeMap := make(map[string]struct{}, len(methods))
 	for _, k := range methods {
 		excludeMap[k] = struct{}{}
@@ -80,7 +95,7 @@ func (m *MethodSet) Without(methods ...string) *MethodSet {
 			specs = append(specs, v)
 		}
 	}
-	return &MethodSet{constructors, specs}
+	return &MethodSet{constructors: constructors, specs: specs}
 }
 
 //------------------------------------------------------------------------------
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/Jeffail/gabs/v2"
 )
@@ -11,7 +12,7 @@ import (
 var _ = registerMethod(
 	NewMethodSpec(
 		"apply",
-		"Apply a declared map on a value.",
+		"Apply a declared map on a value. Any further arguments are passed to the parameters of the map, which are available as variables within it.",
 		NewExampleSpec("",
 			`map thing {
   root.inner = this.first
@@ -32,14 +33,37 @@ root.foo = null.apply("create_foo")`,
 			`{"id":"1234"}`,
 			`{"foo":{"name":"a foo","purpose":"to be a foo"},"id":"1234"}`,
 		),
+		NewExampleSpec("Maps can declare parameters, where the arguments following the name of the map are assigned to variables of the same name.",
+			`map redact(fields, replacement) {
+  root = this.map_each(kv -> if $fields.contains(kv.key) { $replacement } else { kv.value })
+}
+
+root.user = this.user.apply("redact", ["ssn", "dob"], "REDACTED")`,
+			`{"user":{"name":"foo","ssn":"123-45-6789","dob":"1990-01-01"}}`,
+			`{"user":{"dob":"REDACTED","name":"foo","ssn":"REDACTED"}}`,
+		),
 	),
 	true, applyMethod,
-	ExpectNArgs(1),
+	ExpectAtLeastOneArg(),
 	ExpectStringArg(0),
 )
 
+// NewMapArgsError returns an error for when a map is applied with a number of
+// arguments that does not match its parameters.
+func NewMapArgsError(name string, params []string, received int) error {
+	expected := fmt.Sprintf("%v arguments", len(params))
+	if len(params) == 1 {
+		expected = "1 argument"
+	}
+	if len(params) > 0 {
+		expected += fmt.Sprintf(" (%v)", strings.Join(params, ", "))
+	}
+	return fmt.Errorf("map %v expects %v but received %v", name, expected, received)
+}
+
 func applyMethod(target Function, args ...interface{}) (Function, error) {
 	targetMap := args[0].(string)
+	mapArgs := args[1:]
 
 	return ClosureFunction("map "+targetMap, func(ctx FunctionContext) (interface{}, error) {
 		res, err := target.Exec(ctx)
@@ -56,8 +80,21 @@ func applyMethod(target Function, args ...interface{}) (Function, error) {
 			return nil, fmt.Errorf("map %v was not found", targetMap)
 		}
 
+		var params []string
+		if p, ok := m.(interface {
+			Params() []string
+		}); ok {
+			params = p.Params()
+		}
+		if len(mapArgs) != len(params) {
+			return nil, NewMapArgsError(targetMap, params, len(mapArgs))
+		}
+
 		// ISOLATED VARIABLES
-		ctx.Vars = map[string]interface{}{}
+		ctx.Vars = make(map[string]interface{}, len(params))
+		for i, name := range params {
+			ctx.Vars[name] = mapArgs[i]
+		}
 		return m.Exec(ctx)
 	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
 		mapFn, ok := ctx.Maps[targetMap]
@@ -0,0 +1,53 @@
+package budget
+
+import (
+	"context"
+)
+
+// Concurrency is a semaphore that bounds the number of simultaneous operations,
+// such as outstanding requests, that may be held across the logical threads of
+// a component. A nil *Concurrency is valid and never blocks.
+type Concurrency struct {
+	slots chan struct{}
+}
+
+// NewConcurrency creates a semaphore that permits a maximum number of
+// simultaneous operations.
+func NewConcurrency(max int) *Concurrency {
+	return &Concurrency{
+		slots: make(chan struct{}, max),
+	}
+}
+
+// Acquire blocks until a slot is available and returns a function that
+// releases it, which must be called exactly once. An error is returned if the
+// context is cancelled before a slot is acquired.
+func (c *Concurrency) Acquire(ctx context.Context) (func(), error) {
+	if c == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-c.slots
+	}, nil
+}
+
+// InUse returns the number of slots currently acquired.
+func (c *Concurrency) InUse() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.slots)
+}
+
+// Max returns the maximum number of slots that may be acquired.
+func (c *Concurrency) Max() int {
+	if c == nil {
+		return 0
+	}
+	return cap(c.slots)
+}
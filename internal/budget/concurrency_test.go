@@ -0,0 +1,72 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyAcquire(t *testing.T) {
+	c := NewConcurrency(2)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	releaseA, err := c.Acquire(ctx)
+	require.NoError(t, err)
+	releaseB, err := c.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.InUse())
+
+	acquired := make(chan func(), 1)
+	go func() {
+		release, err := c.Acquire(ctx)
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired a slot whilst all slots are in use")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	releaseA()
+	var releaseC func()
+	select {
+	case releaseC = <-acquired:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a slot")
+	}
+	assert.Equal(t, 2, c.InUse())
+
+	releaseB()
+	releaseC()
+	assert.Equal(t, 0, c.InUse())
+}
+
+func TestConcurrencyAcquireCancelled(t *testing.T) {
+	c := NewConcurrency(1)
+
+	release, err := c.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+
+	_, err = c.Acquire(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, c.InUse())
+}
+
+func TestConcurrencyNil(t *testing.T) {
+	var c *Concurrency
+	release, err := c.Acquire(context.Background())
+	require.NoError(t, err)
+	release()
+	assert.Equal(t, 0, c.InUse())
+}
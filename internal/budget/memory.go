@@ -0,0 +1,126 @@
+// Package budget provides service wide limits on the resources held by the
+// components of a Benthos instance.
+package budget
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// Config contains configuration fields for service wide resource limits.
+type Config struct {
+	MaxMemoryBytes int64 `json:"max_memory_bytes" yaml:"max_memory_bytes"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		MaxMemoryBytes: 0,
+	}
+}
+
+// Spec returns the field specs of resource limits.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldInt("max_memory_bytes", "The approximate maximum number of bytes of messages that may be held in flight across all inputs, where zero disables the limit. Once the limit is reached inputs stop reading messages until acknowledgements free enough space. The size of each message is approximated as the length of its contents plus a fixed overhead, and the limit may be exceeded by up to one batch per input. Whilst the limit is reached each input also holds one further batch that has been read ahead of the limit but is not yet counted against it, which is forwarded once space is available.", 1073741824).HasDefault(0),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// PartOverhead is the fixed number of bytes added to the length of the contents
+// of each message part when approximating its size, which accounts for
+// metadata and the structures that hold it.
+const PartOverhead = 256
+
+// MessageSize returns the approximate number of bytes held by a message batch.
+func MessageSize(msg types.Message) int64 {
+	var size int64
+	for i := 0; i < msg.Len(); i++ {
+		size += int64(len(msg.Get(i).Get())) + PartOverhead
+	}
+	return size
+}
+
+//------------------------------------------------------------------------------
+
+// Memory tracks the approximate number of bytes of messages held across
+// components and blocks further reads once a limit is reached. A nil *Memory
+// is valid and never blocks.
+type Memory struct {
+	max   int64
+	gauge metrics.StatGauge
+
+	mut      sync.Mutex
+	used     int64
+	freedSig chan struct{}
+}
+
+// NewMemory creates a memory budget with a limit in bytes, where the number of
+// bytes currently accounted for is reported by a gauge.
+func NewMemory(maxBytes int64, gauge metrics.StatGauge) *Memory {
+	return &Memory{
+		max:      maxBytes,
+		gauge:    gauge,
+		freedSig: make(chan struct{}),
+	}
+}
+
+// Wait blocks until the number of bytes accounted for is below the limit, or
+// until the context is cancelled.
+func (m *Memory) Wait(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	for {
+		m.mut.Lock()
+		if m.used < m.max {
+			m.mut.Unlock()
+			return nil
+		}
+		freedSig := m.freedSig
+		m.mut.Unlock()
+
+		select {
+		case <-freedSig:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Add accounts for a number of bytes and returns a function that releases
+// them, which must be called exactly once.
+func (m *Memory) Add(n int64) func() {
+	if m == nil {
+		return func() {}
+	}
+
+	m.mut.Lock()
+	m.used += n
+	m.gauge.Set(m.used)
+	m.mut.Unlock()
+
+	return func() {
+		m.mut.Lock()
+		m.used -= n
+		m.gauge.Set(m.used)
+		close(m.freedSig)
+		m.freedSig = make(chan struct{})
+		m.mut.Unlock()
+	}
+}
+
+// Used returns the number of bytes currently accounted for.
+func (m *Memory) Used() int64 {
+	if m == nil {
+		return 0
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.used
+}
@@ -0,0 +1,71 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageSize(t *testing.T) {
+	msg := message.New([][]byte{[]byte("hello"), []byte("world!")})
+	assert.Equal(t, int64(11+2*PartOverhead), MessageSize(msg))
+}
+
+func TestMemoryWait(t *testing.T) {
+	stats := metrics.NewLocal()
+	m := NewMemory(100, stats.GetGauge("used"))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, m.Wait(ctx))
+	releaseA := m.Add(60)
+	require.NoError(t, m.Wait(ctx))
+	releaseB := m.Add(60)
+	assert.Equal(t, int64(120), m.Used())
+	assert.Equal(t, int64(120), stats.GetCounters()["used"])
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- m.Wait(ctx)
+	}()
+
+	select {
+	case <-waitErr:
+		t.Fatal("wait returned whilst budget is exhausted")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	releaseA()
+	select {
+	case err := <-waitErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	releaseB()
+	assert.Equal(t, int64(0), m.Used())
+	assert.Equal(t, int64(0), stats.GetCounters()["used"])
+}
+
+func TestMemoryWaitCancelled(t *testing.T) {
+	m := NewMemory(10, metrics.Noop().GetGauge("used"))
+	m.Add(10)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+	assert.Error(t, m.Wait(ctx))
+}
+
+func TestMemoryNil(t *testing.T) {
+	var m *Memory
+	require.NoError(t, m.Wait(context.Background()))
+	m.Add(100)()
+	assert.Equal(t, int64(0), m.Used())
+}
@@ -0,0 +1,339 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	rmetrics "runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/buffer"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/pipeline"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// DefaultSample is the message generated when a sample is not provided.
+var DefaultSample = []byte(`{"id":"b0f4c2a1","name":"benthos","tags":["foo","bar"],"value":10.5}`)
+
+const defaultDuration = time.Second * 10
+
+// latencySamples is the maximum number of latencies retained by each
+// generator, beyond which latencies are sampled.
+const latencySamples = 10000
+
+// Options describes how a benchmark is run.
+type Options struct {
+	Duration       time.Duration
+	Sample         [][]byte
+	BatchSize      int
+	ProcessorsOnly bool
+}
+
+// Results contains the measurements of a benchmark.
+type Results struct {
+	Duration          float64 `json:"duration_seconds"`
+	Messages          int64   `json:"messages"`
+	Bytes             int64   `json:"bytes"`
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	MBPerSecond       float64 `json:"mb_per_second"`
+	LatencyP50        float64 `json:"latency_p50_ms"`
+	LatencyP99        float64 `json:"latency_p99_ms"`
+	AllocsPerMessage  float64 `json:"allocs_per_message"`
+	BytesPerMessage   float64 `json:"alloc_bytes_per_message"`
+}
+
+//------------------------------------------------------------------------------
+
+// latencyReservoir retains a uniform sample of observed latencies.
+type latencyReservoir struct {
+	seen    int64
+	samples []time.Duration
+	rnd     *rand.Rand
+}
+
+func newLatencyReservoir(seed int64) *latencyReservoir {
+	return &latencyReservoir{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (l *latencyReservoir) add(d time.Duration) {
+	l.seen++
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+	if i := l.rnd.Int63n(l.seen); i < latencySamples {
+		l.samples[i] = d
+	}
+}
+
+// percentiles returns the 50th and 99th percentiles of the latencies of all
+// reservoirs in milliseconds.
+func percentiles(reservoirs []*latencyReservoir) (p50, p99 float64) {
+	var all []time.Duration
+	for _, r := range reservoirs {
+		all = append(all, r.samples...)
+	}
+	if len(all) == 0 {
+		return 0, 0
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i] < all[j]
+	})
+	at := func(p float64) float64 {
+		i := int(p * float64(len(all)-1))
+		return float64(all[i]) / float64(time.Millisecond)
+	}
+	return at(0.5), at(0.99)
+}
+
+//------------------------------------------------------------------------------
+
+// allocStats reads the cumulative count and size of heap allocations from the
+// runtime.
+func allocStats() (objects, bytes uint64) {
+	samples := []rmetrics.Sample{
+		{Name: "/gc/heap/allocs:objects"},
+		{Name: "/gc/heap/allocs:bytes"},
+	}
+	rmetrics.Read(samples)
+	if samples[0].Value.Kind() == rmetrics.KindUint64 {
+		objects = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == rmetrics.KindUint64 {
+		bytes = samples[1].Value.Uint64()
+	}
+	return
+}
+
+// generator creates messages by cycling through sample payloads.
+type generator struct {
+	sample    [][]byte
+	batchSize int
+	next      int
+}
+
+func (g *generator) message() types.Message {
+	parts := make([][]byte, g.batchSize)
+	for i := range parts {
+		parts[i] = g.sample[g.next%len(g.sample)]
+		g.next++
+	}
+	return message.New(parts)
+}
+
+func messageBytes(msg types.Message) (n int64) {
+	_ = msg.Iter(func(_ int, p types.Part) error {
+		n += int64(len(p.Get()))
+		return nil
+	})
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// Run benchmarks the buffer and pipeline sections of a config, or only the
+// processors of the pipeline, by feeding them generated messages and counting
+// the messages that come out the other end.
+func Run(ctx context.Context, conf config.Type, opts Options) (Results, error) {
+	if opts.Duration <= 0 {
+		return Results{}, errors.New("duration must be greater than zero")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1
+	}
+	if len(opts.Sample) == 0 {
+		opts.Sample = [][]byte{DefaultSample}
+	}
+
+	mgr, err := manager.NewV2(conf.ResourceConfig, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		return Results{}, fmt.Errorf("failed to create resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	if opts.ProcessorsOnly {
+		return runProcessors(ctx, conf, mgr, opts)
+	}
+	return runPipeline(ctx, conf, mgr, opts)
+}
+
+func summarise(elapsed time.Duration, msgs, bytes int64, reservoirs []*latencyReservoir, objects, allocBytes uint64) Results {
+	res := Results{
+		Duration: elapsed.Seconds(),
+		Messages: msgs,
+		Bytes:    bytes,
+	}
+	if secs := elapsed.Seconds(); secs > 0 {
+		res.MessagesPerSecond = float64(msgs) / secs
+		res.MBPerSecond = float64(bytes) / 1e6 / secs
+	}
+	res.LatencyP50, res.LatencyP99 = percentiles(reservoirs)
+	if msgs > 0 {
+		res.AllocsPerMessage = float64(objects) / float64(msgs)
+		res.BytesPerMessage = float64(allocBytes) / float64(msgs)
+	}
+	return res
+}
+
+// runProcessors executes the processors of the pipeline directly against
+// generated messages, where latency is the time taken to process each batch.
+func runProcessors(ctx context.Context, conf config.Type, mgr types.Manager, opts Options) (Results, error) {
+	procs := make([]types.Processor, len(conf.Pipeline.Processors))
+	for i, pConf := range conf.Pipeline.Processors {
+		var err error
+		if procs[i], err = processor.New(pConf, mgr, log.Noop(), metrics.Noop()); err != nil {
+			return Results{}, fmt.Errorf("failed to create processor '%v': %w", pConf.Type, err)
+		}
+	}
+	defer func() {
+		for _, p := range procs {
+			p.CloseAsync()
+		}
+		for _, p := range procs {
+			_ = p.WaitForClose(time.Second * 10)
+		}
+	}()
+
+	ctx, done := context.WithTimeout(ctx, opts.Duration)
+	defer done()
+
+	gen := &generator{sample: opts.Sample, batchSize: opts.BatchSize}
+	reservoir := newLatencyReservoir(1)
+
+	var msgs, bytes int64
+	startObjects, startBytes := allocStats()
+	start := time.Now()
+	for ctx.Err() == nil {
+		msg := gen.message()
+
+		t := time.Now()
+		outMsgs, _ := processor.ExecuteAll(procs, msg)
+		reservoir.add(time.Since(t))
+
+		for _, m := range outMsgs {
+			msgs += int64(m.Len())
+			bytes += messageBytes(m)
+		}
+	}
+	elapsed := time.Since(start)
+	endObjects, endBytes := allocStats()
+
+	return summarise(elapsed, msgs, bytes, []*latencyReservoir{reservoir}, endObjects-startObjects, endBytes-startBytes), nil
+}
+
+// runPipeline runs the buffer and pipeline of a config, feeding them generated
+// transactions from multiple goroutines and acknowledging the transactions
+// that reach the end with a counting sink. Latency is the time taken for a
+// transaction to be acknowledged.
+func runPipeline(ctx context.Context, conf config.Type, mgr types.Manager, opts Options) (Results, error) {
+	tranChan := make(chan types.Transaction)
+	outChan := (<-chan types.Transaction)(tranChan)
+
+	var closers []types.Closable
+	if conf.Buffer.Type != buffer.TypeNone {
+		buf, err := buffer.New(conf.Buffer, mgr, log.Noop(), metrics.Noop())
+		if err != nil {
+			return Results{}, fmt.Errorf("failed to create buffer: %w", err)
+		}
+		if err = buf.Consume(outChan); err != nil {
+			return Results{}, err
+		}
+		outChan = buf.TransactionChan()
+		closers = append(closers, buf)
+	}
+	if len(conf.Pipeline.Processors) > 0 {
+		pipe, err := pipeline.New(conf.Pipeline, mgr, log.Noop(), metrics.Noop())
+		if err != nil {
+			return Results{}, fmt.Errorf("failed to create pipeline: %w", err)
+		}
+		if err = pipe.Consume(outChan); err != nil {
+			return Results{}, err
+		}
+		outChan = pipe.TransactionChan()
+		closers = append(closers, pipe)
+	}
+	defer func() {
+		for _, c := range closers {
+			c.CloseAsync()
+		}
+		for _, c := range closers {
+			_ = c.WaitForClose(time.Second * 10)
+		}
+	}()
+
+	var msgs, bytes int64
+	sinkDone := make(chan struct{})
+	go func() {
+		defer close(sinkDone)
+		for tran := range outChan {
+			atomic.AddInt64(&msgs, int64(tran.Payload.Len()))
+			atomic.AddInt64(&bytes, messageBytes(tran.Payload))
+			tran.ResponseChan <- response.NewAck()
+		}
+	}()
+
+	ctx, done := context.WithTimeout(ctx, opts.Duration)
+	defer done()
+
+	workers := runtime.GOMAXPROCS(0) * 2
+	reservoirs := make([]*latencyReservoir, workers)
+
+	startObjects, startBytes := allocStats()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		reservoirs[i] = newLatencyReservoir(int64(i))
+		gen := &generator{
+			sample:    opts.Sample,
+			batchSize: opts.BatchSize,
+			next:      i,
+		}
+		go func(reservoir *latencyReservoir) {
+			defer wg.Done()
+			resChan := make(chan types.Response)
+			for ctx.Err() == nil {
+				t := time.Now()
+				select {
+				case tranChan <- types.NewTransaction(gen.message(), resChan):
+				case <-ctx.Done():
+					return
+				}
+				// The response must be awaited even after the benchmark
+				// ends in order to avoid blocking the pipeline.
+				<-resChan
+				reservoir.add(time.Since(t))
+			}
+		}(reservoirs[i])
+	}
+	wg.Wait()
+	close(tranChan)
+
+	select {
+	case <-sinkDone:
+	case <-time.After(time.Second * 10):
+		return Results{}, errors.New("timed out waiting for the pipeline to drain")
+	}
+	elapsed := time.Since(start)
+	endObjects, endBytes := allocStats()
+
+	return summarise(elapsed, atomic.LoadInt64(&msgs), atomic.LoadInt64(&bytes), reservoirs, endObjects-startObjects, endBytes-startBytes), nil
+}
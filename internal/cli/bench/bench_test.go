@@ -0,0 +1,110 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/buffer"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func benchConfig(mapping string) config.Type {
+	conf := config.New()
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Bloblang = processor.BloblangConfig(mapping)
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+	return conf
+}
+
+func TestBenchRun(t *testing.T) {
+	memBuffer := buffer.NewConfig()
+	memBuffer.Type = buffer.TypeMemory
+
+	tests := map[string]struct {
+		buffer         buffer.Config
+		processorsOnly bool
+	}{
+		"pipeline": {
+			buffer: buffer.NewConfig(),
+		},
+		"buffered pipeline": {
+			buffer: memBuffer,
+		},
+		"processors only": {
+			buffer:         buffer.NewConfig(),
+			processorsOnly: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := benchConfig(`root = if content() == "drop me" { deleted() } else { content().uppercase() }`)
+			conf.Buffer = test.buffer
+
+			res, err := Run(context.Background(), conf, Options{
+				Duration:       time.Millisecond * 200,
+				Sample:         [][]byte{[]byte("foo"), []byte("drop me"), []byte("barbaz")},
+				BatchSize:      3,
+				ProcessorsOnly: test.processorsOnly,
+			})
+			require.NoError(t, err)
+
+			assert.Greater(t, res.Messages, int64(0))
+			assert.Greater(t, res.MessagesPerSecond, float64(0))
+			assert.Greater(t, res.MBPerSecond, float64(0))
+			assert.GreaterOrEqual(t, res.LatencyP99, res.LatencyP50)
+			assert.Greater(t, res.AllocsPerMessage, float64(0))
+
+			// Dropped messages are not counted, leaving two messages of
+			// three and six bytes in each batch.
+			assert.Equal(t, int64(0), res.Messages%2, res.Messages)
+			assert.Equal(t, res.Messages/2*9, res.Bytes)
+		})
+	}
+}
+
+func TestBenchRunErrors(t *testing.T) {
+	_, err := Run(context.Background(), benchConfig(`root = this`), Options{})
+	assert.Error(t, err)
+
+	_, err = Run(context.Background(), benchConfig(`root = this.(`), Options{
+		Duration: time.Millisecond,
+	})
+	assert.Error(t, err)
+}
+
+func TestBenchReadSample(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "sample.jsonl")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{\"a\":1}\n\n{\"b\":2}\n"), 0o644))
+
+	sample, err := readSample(path)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}, sample)
+
+	emptyPath := filepath.Join(dir, "empty.jsonl")
+	require.NoError(t, ioutil.WriteFile(emptyPath, nil, 0o644))
+	_, err = readSample(emptyPath)
+	assert.Error(t, err)
+}
+
+func TestBenchPrintResults(t *testing.T) {
+	var buf bytes.Buffer
+	printResults(&buf, Results{
+		Duration:          2,
+		Messages:          200,
+		MessagesPerSecond: 100,
+	})
+	assert.Contains(t, buf.String(), "messages:")
+	assert.Contains(t, buf.String(), "100.0 msg/sec")
+}
@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for benchmarking the throughput of a
+// config.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Measure the throughput of the pipeline of a config",
+		Description: `
+   Runs the buffer and pipeline sections of a config for a duration, replacing
+   the input with a generator of synthetic messages and the output with a sink
+   that counts the messages it receives, and then reports the throughput, the
+   latency of processing and the allocations per message:
+
+   benthos bench -c ./config.yaml --duration 30s
+   benthos bench -c ./config.yaml --sample ./events.jsonl --format json
+   benthos bench -c ./config.yaml --processors-only
+
+   When a sample file is provided each line of the file becomes a message, and
+   the lines are replayed in a loop. With --processors-only the processors of
+   the pipeline are executed directly, which excludes the overhead of the
+   buffer and pipeline threads from the measurements.`[4:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "a path to the config to benchmark",
+			},
+			&cli.StringSliceFlag{
+				Name:    "resources",
+				Aliases: []string{"r"},
+				Usage:   "pull in extra resources from a file, which can be referenced by the config",
+			},
+			&cli.StringSliceFlag{
+				Name:    "set",
+				Aliases: []string{"s"},
+				Usage:   "set a field (identified by a dot path) in the config, e.g. pipeline.threads=4",
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Value: defaultDuration,
+				Usage: "the duration to run the benchmark for",
+			},
+			&cli.StringFlag{
+				Name:  "sample",
+				Usage: "a path to a file of messages separated by line breaks to replay",
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Value: 1,
+				Usage: "the number of messages within each generated batch",
+			},
+			&cli.BoolFlag{
+				Name:  "processors-only",
+				Usage: "execute the processors of the pipeline directly",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "the format of the results, options are text or json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != "text" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Format not recognised: %v\n", format)
+				os.Exit(1)
+			}
+
+			conf := config.New()
+			rdr := iconfig.NewReader(c.String("config"), c.StringSlice("resources"), iconfig.OptAddOverrides(c.StringSlice("set")...))
+			if _, err := rdr.Read(&conf); err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var sample [][]byte
+			if path := c.String("sample"); path != "" {
+				var err error
+				if sample, err = readSample(path); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to read sample: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			ctx, done := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer done()
+
+			res, err := Run(ctx, conf, Options{
+				Duration:       c.Duration("duration"),
+				Sample:         sample,
+				BatchSize:      c.Int("batch-size"),
+				ProcessorsOnly: c.Bool("processors-only"),
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Benchmark error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if format == "json" {
+				resBytes, _ := json.Marshal(res)
+				fmt.Println(string(resBytes))
+			} else {
+				printResults(os.Stdout, res)
+			}
+			os.Exit(0)
+			return nil
+		},
+	}
+}
+
+// readSample reads the non-empty lines of a file as messages.
+func readSample(path string) ([][]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sample [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			sample = append(sample, append([]byte(nil), line...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(sample) == 0 {
+		return nil, fmt.Errorf("sample file %v is empty", path)
+	}
+	return sample, nil
+}
+
+func printResults(w io.Writer, res Results) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "duration:\t%.2fs\n", res.Duration)
+	fmt.Fprintf(tw, "messages:\t%v\n", res.Messages)
+	fmt.Fprintf(tw, "throughput:\t%.1f msg/sec\n", res.MessagesPerSecond)
+	fmt.Fprintf(tw, "bandwidth:\t%.2f MB/sec\n", res.MBPerSecond)
+	fmt.Fprintf(tw, "latency p50:\t%.3fms\n", res.LatencyP50)
+	fmt.Fprintf(tw, "latency p99:\t%.3fms\n", res.LatencyP99)
+	fmt.Fprintf(tw, "allocations:\t%.1f allocs/msg, %.0f bytes/msg\n", res.AllocsPerMessage, res.BytesPerMessage)
+	tw.Flush()
+}
@@ -0,0 +1,346 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/component"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+const defaultTimeout = time.Second * 10
+
+// probeKey is the key read from caches in order to check them, which is not
+// expected to exist.
+const probeKey = "benthos_check_probe"
+
+// connectedPollPeriod is the period between checks of whether a component has
+// connected.
+const connectedPollPeriod = time.Millisecond * 10
+
+// Options describes how the components of a config are checked.
+type Options struct {
+	Timeout time.Duration
+}
+
+// Result describes the outcome of checking a single component.
+type Result struct {
+	Component string  `json:"component"`
+	Type      string  `json:"type"`
+	OK        bool    `json:"ok"`
+	SelfTest  bool    `json:"self_test"`
+	Latency   float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report contains the results of checking every component of a config.
+type Report struct {
+	Results []Result `json:"results"`
+	Failed  int      `json:"failed"`
+}
+
+//------------------------------------------------------------------------------
+
+type checkFn func(ctx context.Context) (selfTested bool, err error)
+
+type pendingCheck struct {
+	component string
+	typeStr   string
+	resource  bool
+	fn        checkFn
+}
+
+// Run constructs each input, output, cache and rate limit of a config and
+// checks that it is able to connect to its target without consuming or
+// producing messages. Components that implement component.SelfTester are also
+// asked to run their self test once connected.
+func Run(ctx context.Context, conf config.Type, opts Options) (Report, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	started := time.Now()
+	mgr, err := manager.NewV2(conf.ResourceConfig, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to create resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(opts.Timeout)
+	}()
+
+	checks := []pendingCheck{
+		{
+			component: "input",
+			typeStr:   conf.Input.Type,
+			fn: func(ctx context.Context) (bool, error) {
+				return checkNewInput(ctx, conf.Input, mgr, opts.Timeout)
+			},
+		},
+		{
+			component: "output",
+			typeStr:   conf.Output.Type,
+			fn: func(ctx context.Context) (bool, error) {
+				return checkNewOutput(ctx, conf.Output, mgr, opts.Timeout)
+			},
+		},
+	}
+	checks = append(checks, resourceChecks(conf.ResourceConfig, mgr)...)
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c pendingCheck) {
+			defer wg.Done()
+
+			// Resources are constructed, and therefore begin connecting, along
+			// with the manager and so their latencies are measured from then.
+			cStarted := time.Now()
+			if c.resource {
+				cStarted = started
+			}
+
+			cCtx, done := context.WithTimeout(ctx, opts.Timeout)
+			defer done()
+
+			selfTested, err := c.fn(cCtx)
+			res := Result{
+				Component: c.component,
+				Type:      c.typeStr,
+				OK:        err == nil,
+				SelfTest:  selfTested,
+				Latency:   float64(time.Since(cStarted)) / float64(time.Millisecond),
+			}
+			if err != nil {
+				res.Error = err.Error()
+			}
+			results[i] = res
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Results: results}
+	for _, res := range results {
+		if !res.OK {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+//------------------------------------------------------------------------------
+
+func resourceChecks(conf manager.ResourceConfig, mgr *manager.Type) []pendingCheck {
+	var checks []pendingCheck
+
+	inputTypes := map[string]string{}
+	for k, v := range conf.Manager.Inputs {
+		inputTypes[k] = v.Type
+	}
+	for _, v := range conf.ResourceInputs {
+		inputTypes[v.Label] = v.Type
+	}
+	for _, name := range sortedKeys(inputTypes) {
+		name := name
+		checks = append(checks, pendingCheck{
+			component: "resource.input." + name,
+			typeStr:   inputTypes[name],
+			resource:  true,
+			fn: func(ctx context.Context) (bool, error) {
+				in, err := mgr.GetInput(name)
+				if err != nil {
+					return false, err
+				}
+				return checkConnected(ctx, in)
+			},
+		})
+	}
+
+	// Disabled resources are never constructed and so aren't checked.
+	outputTypes := map[string]string{}
+	for k, v := range conf.Manager.Outputs {
+		if v.Enabled {
+			outputTypes[k] = v.Type
+		}
+	}
+	for _, v := range conf.ResourceOutputs {
+		if v.Enabled {
+			outputTypes[v.Label] = v.Type
+		}
+	}
+	for _, name := range sortedKeys(outputTypes) {
+		name := name
+		checks = append(checks, pendingCheck{
+			component: "resource.output." + name,
+			typeStr:   outputTypes[name],
+			resource:  true,
+			fn: func(ctx context.Context) (bool, error) {
+				out, err := mgr.GetOutput(name)
+				if err != nil {
+					return false, err
+				}
+				return checkConnected(ctx, out)
+			},
+		})
+	}
+
+	cacheTypes := map[string]string{}
+	for k, v := range conf.Manager.Caches {
+		if v.Enabled {
+			cacheTypes[k] = v.Type
+		}
+	}
+	for _, v := range conf.ResourceCaches {
+		if v.Enabled {
+			cacheTypes[v.Label] = v.Type
+		}
+	}
+	for _, name := range sortedKeys(cacheTypes) {
+		name := name
+		checks = append(checks, pendingCheck{
+			component: "resource.cache." + name,
+			typeStr:   cacheTypes[name],
+			resource:  true,
+			fn: func(ctx context.Context) (bool, error) {
+				c, err := mgr.GetCache(name)
+				if err != nil {
+					return false, err
+				}
+				return withContext(ctx, func() (bool, error) {
+					if _, err := c.Get(probeKey); err != nil && !errors.Is(err, types.ErrKeyNotFound) {
+						return false, err
+					}
+					return selfTest(ctx, c)
+				})
+			},
+		})
+	}
+
+	rateLimitTypes := map[string]string{}
+	for k, v := range conf.Manager.RateLimits {
+		if v.Enabled {
+			rateLimitTypes[k] = v.Type
+		}
+	}
+	for _, v := range conf.ResourceRateLimits {
+		if v.Enabled {
+			rateLimitTypes[v.Label] = v.Type
+		}
+	}
+	for _, name := range sortedKeys(rateLimitTypes) {
+		name := name
+		checks = append(checks, pendingCheck{
+			component: "resource.rate_limit." + name,
+			typeStr:   rateLimitTypes[name],
+			resource:  true,
+			fn: func(ctx context.Context) (bool, error) {
+				r, err := mgr.GetRateLimit(name)
+				if err != nil {
+					return false, err
+				}
+				return withContext(ctx, func() (bool, error) {
+					if _, err := r.Access(); err != nil {
+						return false, err
+					}
+					return selfTest(ctx, r)
+				})
+			},
+		})
+	}
+
+	return checks
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//------------------------------------------------------------------------------
+
+func checkNewInput(ctx context.Context, conf input.Config, mgr types.Manager, timeout time.Duration) (bool, error) {
+	in, err := input.New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		in.CloseAsync()
+		_ = in.WaitForClose(timeout)
+	}()
+	return checkConnected(ctx, in)
+}
+
+func checkNewOutput(ctx context.Context, conf output.Config, mgr types.Manager, timeout time.Duration) (bool, error) {
+	out, err := output.New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		return false, err
+	}
+	tranChan := make(chan types.Transaction)
+	if err := out.Consume(tranChan); err != nil {
+		return false, err
+	}
+	defer func() {
+		out.CloseAsync()
+		_ = out.WaitForClose(timeout)
+	}()
+	return checkConnected(ctx, out)
+}
+
+// checkConnected waits for a component to connect and then runs its self test
+// when it has one.
+func checkConnected(ctx context.Context, c interface{ Connected() bool }) (bool, error) {
+	ticker := time.NewTicker(connectedPollPeriod)
+	defer ticker.Stop()
+	for !c.Connected() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false, errors.New("timed out waiting for a connection")
+		}
+	}
+	return selfTest(ctx, c)
+}
+
+// selfTest runs the self test of a component and returns whether it has one.
+func selfTest(ctx context.Context, c interface{}) (bool, error) {
+	err := component.SelfTest(ctx, c)
+	if errors.Is(err, component.ErrSelfTestUnsupported) {
+		return false, nil
+	}
+	return true, err
+}
+
+// withContext runs a check that is unable to observe the cancellation of a
+// context and abandons it when the context is cancelled.
+func withContext(ctx context.Context, fn func() (bool, error)) (bool, error) {
+	type result struct {
+		selfTested bool
+		err        error
+	}
+	resChan := make(chan result, 1)
+	go func() {
+		selfTested, err := fn()
+		resChan <- result{selfTested, err}
+	}()
+	select {
+	case res := <-resChan:
+		return res.selfTested, res.err
+	case <-ctx.Done():
+		return false, errors.New("timed out waiting for a response")
+	}
+}
@@ -0,0 +1,91 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRunPasses(t *testing.T) {
+	conf := config.New()
+	conf.Input.Type = input.TypeGenerate
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Output.Type = output.TypeDrop
+
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "foocache"
+	cacheConf.Type = cache.TypeMemory
+	conf.ResourceCaches = append(conf.ResourceCaches, cacheConf)
+
+	rlConf := ratelimit.NewConfig()
+	rlConf.Label = "foolimit"
+	rlConf.Type = ratelimit.TypeLocal
+	conf.ResourceRateLimits = append(conf.ResourceRateLimits, rlConf)
+
+	report, err := Run(context.Background(), conf, Options{Timeout: time.Second * 5})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, report.Failed)
+	require.Len(t, report.Results, 4)
+
+	var components []string
+	for _, res := range report.Results {
+		assert.True(t, res.OK, res.Error)
+		components = append(components, res.Component)
+	}
+	assert.Equal(t, []string{
+		"input", "output", "resource.cache.foocache", "resource.rate_limit.foolimit",
+	}, components)
+}
+
+func TestCheckRunFails(t *testing.T) {
+	conf := config.New()
+	conf.Input.Type = input.TypeGenerate
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Output.Type = output.TypeKafka
+	conf.Output.Kafka.Addresses = []string{"127.0.0.1:1"}
+	conf.Output.Kafka.Topic = "foo"
+
+	report, err := Run(context.Background(), conf, Options{Timeout: time.Millisecond * 500})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 2)
+	assert.True(t, report.Results[0].OK)
+	assert.False(t, report.Results[1].OK)
+	assert.Equal(t, "kafka", report.Results[1].Type)
+	assert.NotEmpty(t, report.Results[1].Error)
+}
+
+type fakeSelfTester struct {
+	err error
+}
+
+func (f fakeSelfTester) Connected() bool {
+	return true
+}
+
+func (f fakeSelfTester) SelfTest(ctx context.Context) error {
+	return f.err
+}
+
+func TestCheckConnectedSelfTest(t *testing.T) {
+	ctx := context.Background()
+
+	selfTested, err := checkConnected(ctx, fakeSelfTester{})
+	assert.True(t, selfTested)
+	assert.NoError(t, err)
+
+	selfTested, err = checkConnected(ctx, fakeSelfTester{err: errors.New("nope")})
+	assert.True(t, selfTested)
+	assert.EqualError(t, err, "nope")
+}
@@ -0,0 +1,120 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for checking that the components of a
+// config are able to connect.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "Check that the components of a config are able to connect",
+		Description: `
+   Constructs the input and output of a config along with each input, output,
+   cache and rate limit resource, and checks that each one is able to connect
+   to its target without processing any messages. Caches are probed with a
+   read of a key that is not expected to exist and rate limits are accessed
+   once. Components that support a self test, such as the aws_s3 and kafka
+   outputs, also verify that their bucket or topic is reachable:
+
+   benthos check -c ./config.yaml
+   benthos check -c ./config.yaml -r ./resources.yaml --timeout 30s
+   benthos check -c ./config.yaml --format json
+
+   Inputs begin reading once connected, but any messages they read are never
+   acknowledged. The command exits with a status code of 1 when any check
+   fails.`[4:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "a path to the config to check",
+			},
+			&cli.StringSliceFlag{
+				Name:    "resources",
+				Aliases: []string{"r"},
+				Usage:   "pull in extra resources from a file, which are also checked",
+			},
+			&cli.StringSliceFlag{
+				Name:    "set",
+				Aliases: []string{"s"},
+				Usage:   "set a field (identified by a dot path) in the config, e.g. output.kafka.topic=foo",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: defaultTimeout,
+				Usage: "the maximum period to wait for each component to pass its check",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "the format of the report, options are text or json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != "text" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Format not recognised: %v\n", format)
+				os.Exit(1)
+			}
+
+			conf := config.New()
+			rdr := iconfig.NewReader(c.String("config"), c.StringSlice("resources"), iconfig.OptAddOverrides(c.StringSlice("set")...))
+			if _, err := rdr.Read(&conf); err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			ctx, done := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer done()
+
+			report, err := Run(ctx, conf, Options{
+				Timeout: c.Duration("timeout"),
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Check error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if format == "json" {
+				reportBytes, _ := json.Marshal(report)
+				fmt.Println(string(reportBytes))
+			} else {
+				printReport(os.Stdout, report)
+			}
+			if report.Failed > 0 {
+				os.Exit(1)
+			}
+			os.Exit(0)
+			return nil
+		},
+	}
+}
+
+func printReport(w io.Writer, report Report) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tTYPE\tSTATUS\tLATENCY\tERROR")
+	for _, res := range report.Results {
+		status := "ok"
+		if !res.OK {
+			status = "failed"
+		} else if res.SelfTest {
+			status = "ok (self test)"
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%.1fms\t%v\n", res.Component, res.Type, status, res.Latency, res.Error)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%v of %v checks failed\n", report.Failed, len(report.Results))
+}
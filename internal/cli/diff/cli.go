@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for printing the differences between
+// two configs.
+func CliCommand(testSuffix string) *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Print the differences between two Benthos configs",
+		Description: `
+   Parses two configs, populating default values and removing the fields of
+   unused components, and prints each field that differs between them along
+   with its old and new value:
+
+   benthos diff ./old.yaml ./new.yaml
+   benthos diff --ignore-paths 'metrics.*' ./old.yaml ./new.yaml
+   benthos diff ./old_streams ./new_streams
+
+   Formatting, comments and the order of fields are ignored. When both paths
+   are directories they are loaded as streams mode configs and the streams
+   that were added, removed or changed are printed.
+
+   Exits with a status code 1 if there are any differences, and 2 if either
+   config could not be read.`[4:],
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "ignore-paths",
+				Usage: "ignore fields that match a dot path glob pattern, where a wildcard matches within a single segment of a path, e.g. 'output.*.max_in_flight'",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 2 {
+				fmt.Fprintln(os.Stderr, "Expected two config paths")
+				os.Exit(2)
+			}
+			lines, err := Paths(c.Args().Get(0), c.Args().Get(1), c.StringSlice("ignore-paths"), testSuffix)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Diff error: %v\n", err)
+				os.Exit(2)
+			}
+			for _, l := range lines {
+				fmt.Println(l)
+			}
+			if len(lines) > 0 {
+				os.Exit(1)
+			}
+			os.Exit(0)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,233 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	strmmgr "github.com/Jeffail/benthos/v3/lib/stream/manager"
+	"gopkg.in/yaml.v3"
+)
+
+const absentValue = "<none>"
+
+// escapePathSegment escapes a segment of a dot path so that keys containing
+// dots can be distinguished from nested fields.
+func escapePathSegment(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), ".", "~1")
+}
+
+func joinPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// flattenNode walks a YAML node and adds the value of each leaf to a map keyed
+// by its dot path. Empty mappings and sequences are treated as leaves.
+func flattenNode(prefix string, node *yaml.Node, into map[string]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			flattenNode(prefix, node.Content[0], into)
+		}
+	case yaml.AliasNode:
+		flattenNode(prefix, node.Alias, into)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			into[prefix] = "{}"
+			return
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			flattenNode(joinPath(prefix, escapePathSegment(node.Content[i].Value)), node.Content[i+1], into)
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			into[prefix] = "[]"
+			return
+		}
+		for i, v := range node.Content {
+			flattenNode(joinPath(prefix, strconv.Itoa(i)), v, into)
+		}
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			into[prefix] = `""`
+		} else {
+			into[prefix] = node.Value
+		}
+	}
+}
+
+// pathIgnored returns true if a dot path, or any of its parents, matches any
+// of a list of glob patterns where a wildcard matches within a single segment
+// of the path.
+func pathIgnored(p string, ignorePatterns []string) bool {
+	segments := strings.Split(p, ".")
+	for _, pattern := range ignorePatterns {
+		pattern = strings.ReplaceAll(pattern, ".", "/")
+		for i := 1; i <= len(segments); i++ {
+			if matched, _ := path.Match(pattern, strings.Join(segments[:i], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diffNodes returns a sorted line for each leaf path that differs between two
+// YAML nodes, of the form `path: old -> new`.
+func diffNodes(prefix string, from, to *yaml.Node, ignorePatterns []string) []string {
+	fromFlat, toFlat := map[string]string{}, map[string]string{}
+	flattenNode("", from, fromFlat)
+	flattenNode("", to, toFlat)
+
+	paths := map[string]struct{}{}
+	for k := range fromFlat {
+		paths[k] = struct{}{}
+	}
+	for k := range toFlat {
+		paths[k] = struct{}{}
+	}
+
+	var lines []string
+	for p := range paths {
+		fromV, fromExists := fromFlat[p]
+		toV, toExists := toFlat[p]
+		if fromExists && toExists && fromV == toV {
+			continue
+		}
+		if pathIgnored(p, ignorePatterns) {
+			continue
+		}
+		if !fromExists {
+			fromV = absentValue
+		}
+		if !toExists {
+			toV = absentValue
+		}
+		lines = append(lines, fmt.Sprintf("%v%v: %v -> %v", prefix, p, fromV, toV))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+//------------------------------------------------------------------------------
+
+// normalisedConfig reads a config file and returns it as a YAML node with all
+// default values populated and unused component fields removed.
+func normalisedConfig(path string) (*yaml.Node, error) {
+	conf := config.New()
+	if _, err := iconfig.NewReader(path, nil).Read(&conf); err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := node.Encode(conf); err != nil {
+		return nil, err
+	}
+	if err := config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
+		RemoveTypeField: true,
+	}); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return &node, nil
+}
+
+// normalisedStreams reads a directory of stream configs and returns each
+// stream as a YAML node with all default values populated and unused
+// component fields removed.
+func normalisedStreams(path, testSuffix string) (map[string]*yaml.Node, error) {
+	confs := map[string]stream.Config{}
+	if _, err := strmmgr.LoadStreamConfigsFromPath(path, testSuffix, confs); err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*yaml.Node, len(confs))
+	for id, conf := range confs {
+		var node yaml.Node
+		if err := node.Encode(conf); err != nil {
+			return nil, err
+		}
+		if err := stream.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
+			RemoveTypeField: true,
+		}); err != nil {
+			return nil, fmt.Errorf("stream '%v': %w", id, err)
+		}
+		nodes[id] = &node
+	}
+	return nodes, nil
+}
+
+// Paths returns a line describing each difference between two configs, or two
+// directories of stream configs, after both have been normalised. Paths that
+// match any of the ignore patterns are omitted.
+func Paths(fromPath, toPath string, ignorePatterns []string, testSuffix string) ([]string, error) {
+	fromInfo, err := os.Stat(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	toInfo, err := os.Stat(toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fromInfo.IsDir() && !toInfo.IsDir() {
+		fromNode, err := normalisedConfig(fromPath)
+		if err != nil {
+			return nil, err
+		}
+		toNode, err := normalisedConfig(toPath)
+		if err != nil {
+			return nil, err
+		}
+		return diffNodes("", fromNode, toNode, ignorePatterns), nil
+	}
+	if !fromInfo.IsDir() || !toInfo.IsDir() {
+		return nil, fmt.Errorf("expected two config files or two directories of stream configs, but only one of '%v' and '%v' is a directory", fromPath, toPath)
+	}
+
+	fromStreams, err := normalisedStreams(fromPath, testSuffix)
+	if err != nil {
+		return nil, err
+	}
+	toStreams, err := normalisedStreams(toPath, testSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]struct{}{}
+	for id := range fromStreams {
+		ids[id] = struct{}{}
+	}
+	for id := range toStreams {
+		ids[id] = struct{}{}
+	}
+	sortedIDs := make([]string, 0, len(ids))
+	for id := range ids {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Strings(sortedIDs)
+
+	var lines []string
+	for _, id := range sortedIDs {
+		fromNode, fromExists := fromStreams[id]
+		toNode, toExists := toStreams[id]
+		switch {
+		case !fromExists:
+			lines = append(lines, fmt.Sprintf("stream '%v' was added", id))
+		case !toExists:
+			lines = append(lines, fmt.Sprintf("stream '%v' was removed", id))
+		default:
+			lines = append(lines, diffNodes(fmt.Sprintf("stream '%v': ", id), fromNode, toNode, ignorePatterns)...)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,126 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestDiffConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"old.yaml": `
+# This comment is ignored
+input:
+  kafka:
+    addresses: [ foo:9092 ]
+    topics: [ bar ]
+output:
+  kafka:
+    addresses: [ foo:9092 ]
+    topic: baz
+`,
+		"new.yaml": `
+output:
+  kafka:
+    topic: baz
+    max_in_flight: 64
+    addresses: [ foo:9092 ]
+input:
+  kafka:
+    topics: [ bar ]
+    addresses: [ "${BENTHOS_TEST_DIFF_ADDR:foo:9093}" ]
+`,
+	})
+
+	oldPath, newPath := filepath.Join(dir, "old.yaml"), filepath.Join(dir, "new.yaml")
+
+	lines, err := Paths(oldPath, newPath, nil, "_benthos_test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"input.kafka.addresses.0: foo:9092 -> foo:9093",
+		"output.kafka.max_in_flight: 1 -> 64",
+	}, lines)
+
+	lines, err = Paths(oldPath, newPath, []string{"input.*.addresses"}, "_benthos_test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"output.kafka.max_in_flight: 1 -> 64",
+	}, lines)
+
+	lines, err = Paths(oldPath, oldPath, nil, "_benthos_test")
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+
+	_, err = Paths(oldPath, dir, nil, "_benthos_test")
+	require.Error(t, err)
+}
+
+func TestDiffStreamDirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"old/foo.yaml": `
+input:
+  generate:
+    mapping: 'root = "foo"'
+`,
+		"old/bar.yaml": `
+input:
+  generate:
+    mapping: 'root = "bar"'
+`,
+		"new/foo.yaml": `
+input:
+  generate:
+    mapping: 'root = "foo"'
+    interval: 5s
+`,
+		"new/baz.yaml": `
+input:
+  generate:
+    mapping: 'root = "baz"'
+`,
+	})
+
+	lines, err := Paths(filepath.Join(dir, "old"), filepath.Join(dir, "new"), nil, "_benthos_test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"stream 'bar' was removed",
+		"stream 'baz' was added",
+		"stream 'foo': input.generate.interval: 1s -> 5s",
+	}, lines)
+}
+
+func TestDiffPathIgnored(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string
+		ignored  bool
+	}{
+		{path: "output.kafka.max_in_flight", patterns: []string{"output"}, ignored: true},
+		{path: "output.kafka.max_in_flight", patterns: []string{"output.*.max_in_flight"}, ignored: true},
+		{path: "output.kafka.max_in_flight", patterns: []string{"output.*"}, ignored: true},
+		{path: "output.kafka.max_in_flight", patterns: []string{"input", "*.kafka"}, ignored: true},
+		{path: "output.kafka.max_in_flight", patterns: []string{"output.*.topic"}, ignored: false},
+		{path: "output.kafka.max_in_flight", patterns: []string{"out"}, ignored: false},
+		{path: "output.kafka.max_in_flight", patterns: nil, ignored: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.ignored, pathIgnored(test.path, test.patterns), "%v: %v", test.path, test.patterns)
+	}
+}
@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for replaying the messages archived by
+// a tee output.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "replay",
+		Usage: "Replay the messages archived by a tee output",
+		Description: `
+   Runs a config with a tee output, but instead of the configured input reads
+   the messages that the tee archived within a time range, and writes them only
+   to the child output of the tee. Replayed messages have the metadata field
+   replayed set to true, and the time range is expanded to whole hours:
+
+   benthos replay -c ./config.yaml --from 2021-04-05T10:00:00Z --to 2021-04-05T14:00:00Z
+   benthos replay -c ./streams/foo.yaml -r ./resources.yaml --codec lines --from ... --to ...
+
+   The process exits once all archived messages of the time range have been
+   replayed.`[4:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "a path to the config to replay",
+			},
+			&cli.StringSliceFlag{
+				Name:    "resources",
+				Aliases: []string{"r"},
+				Usage:   "pull in extra resources from a file, which can be referenced by the config",
+			},
+			&cli.StringSliceFlag{
+				Name:    "set",
+				Aliases: []string{"s"},
+				Usage:   "set a field (identified by a dot path) in the config, e.g. pipeline.threads=4",
+			},
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "the start of the time range to replay in RFC3339 format",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "the end of the time range to replay in RFC3339 format",
+			},
+			&cli.StringFlag{
+				Name:  "codec",
+				Value: "all-bytes",
+				Usage: "the codec used to read archived objects, matching the codecs of the aws_s3 input",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			from, err := time.Parse(time.RFC3339, c.String("from"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to parse --from: %v\n", err)
+				os.Exit(1)
+			}
+			to, err := time.Parse(time.RFC3339, c.String("to"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to parse --to: %v\n", err)
+				os.Exit(1)
+			}
+
+			conf := config.New()
+			rdr := iconfig.NewReader(c.String("config"), c.StringSlice("resources"), iconfig.OptAddOverrides(c.StringSlice("set")...))
+			if _, err := rdr.Read(&conf); err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := Apply(&conf, c.String("codec"), from, to); err != nil {
+				fmt.Fprintf(os.Stderr, "Replay error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(run(conf))
+			return nil
+		},
+	}
+}
+
+func run(conf config.Type) int {
+	logger, err := log.NewV2(os.Stdout, conf.Logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		return 1
+	}
+
+	exitTimeout := time.Second * 20
+	if tout := conf.SystemCloseTimeout; len(tout) > 0 {
+		if exitTimeout, err = time.ParseDuration(tout); err != nil {
+			logger.Errorf("Failed to parse shutdown timeout period string: %v\n", err)
+			return 1
+		}
+	}
+
+	mgr, err := manager.NewV2(conf.ResourceConfig, types.NoopMgr(), logger, metrics.Noop())
+	if err != nil {
+		logger.Errorf("Failed to create resources: %v\n", err)
+		return 1
+	}
+	defer func() {
+		mgr.CloseAsync()
+		if err := mgr.WaitForClose(exitTimeout); err != nil {
+			logger.Warnf("Resources failed to close cleanly: %v\n", err)
+		}
+	}()
+
+	closedChan := make(chan struct{})
+	strm, err := stream.New(
+		conf.Config,
+		stream.OptSetLogger(logger),
+		stream.OptSetStats(metrics.Noop()),
+		stream.OptSetManager(mgr),
+		stream.OptOnClose(func() {
+			close(closedChan)
+		}),
+	)
+	if err != nil {
+		logger.Errorf("Replay closing due to: %v\n", err)
+		return 1
+	}
+	logger.Infoln("Replaying archived messages, use CTRL+C to close.")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case <-sigChan:
+		logger.Infoln("Received SIGTERM, the replay is closing.")
+		exitCode = 1
+	case <-closedChan:
+		logger.Infoln("Finished replaying archived messages.")
+	}
+	if err := strm.Stop(exitTimeout); err != nil {
+		logger.Errorf("Failed to close cleanly: %v\n", err)
+		return 1
+	}
+	return exitCode
+}
@@ -0,0 +1,117 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+)
+
+// ReplayedMetaKey is the metadata key that is set to true on all replayed
+// messages.
+const ReplayedMetaKey = "replayed"
+
+var partitionPathRegexp = regexp.MustCompile(`^\$\{!\s*meta\(\s*"` + output.TeePartitionMetaKey + `"\s*\)\s*\}`)
+
+// archivePrefix returns the static prefix of an archive path, which must be
+// followed by the partition of the archive.
+func archivePrefix(path string) (string, error) {
+	i := strings.Index(path, "${!")
+	if i == -1 || !partitionPathRegexp.MatchString(path[i:]) {
+		return "", fmt.Errorf("archive path '%v' must begin with an optional prefix followed by the interpolation ${! meta(\"%v\") }", path, output.TeePartitionMetaKey)
+	}
+	return path[:i], nil
+}
+
+// partitions returns the archive partitions that cover a time range.
+func partitions(from, to time.Time) []string {
+	var parts []string
+	for t := from.UTC().Truncate(time.Hour); t.Before(to); t = t.Add(time.Hour) {
+		parts = append(parts, t.Format(output.TeePartitionFormat))
+	}
+	return parts
+}
+
+// archiveInputs returns an input config for each partition of the archive of a
+// tee output within a time range.
+func archiveInputs(archive output.Config, codec string, from, to time.Time) ([]input.Config, error) {
+	var path string
+	switch archive.Type {
+	case output.TypeAWSS3:
+		path = archive.AWSS3.Path
+	case output.TypeGCPCloudStorage:
+		path = archive.GCPCloudStorage.Path
+	default:
+		return nil, fmt.Errorf("archive output type '%v' cannot be replayed, expected %v or %v", archive.Type, output.TypeAWSS3, output.TypeGCPCloudStorage)
+	}
+
+	prefix, err := archivePrefix(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []input.Config
+	for _, part := range partitions(from, to) {
+		inConf := input.NewConfig()
+		switch archive.Type {
+		case output.TypeAWSS3:
+			inConf.Type = input.TypeAWSS3
+			inConf.AWSS3.Config = archive.AWSS3.Config
+			inConf.AWSS3.Bucket = archive.AWSS3.Bucket
+			inConf.AWSS3.ForcePathStyleURLs = archive.AWSS3.ForcePathStyleURLs
+			inConf.AWSS3.Prefix = prefix + part + "/"
+			inConf.AWSS3.Codec = codec
+		case output.TypeGCPCloudStorage:
+			inConf.Type = input.TypeGCPCloudStorage
+			inConf.GCPCloudStorage.Bucket = archive.GCPCloudStorage.Bucket
+			inConf.GCPCloudStorage.Prefix = prefix + part + "/"
+			inConf.GCPCloudStorage.Codec = codec
+		}
+		inputs = append(inputs, inConf)
+	}
+	return inputs, nil
+}
+
+// Apply modifies a config with a tee output such that it reads the messages
+// archived by the tee within a time range instead of its input, and writes them
+// only to the child output of the tee. Replayed messages are given the metadata
+// field replayed with the value true.
+func Apply(conf *config.Type, codec string, from, to time.Time) error {
+	if !from.Before(to) {
+		return errors.New("the start of the time range must be before the end")
+	}
+	if conf.Output.Type != output.TypeTee {
+		return fmt.Errorf("replay requires an output of type %v, found %v", output.TypeTee, conf.Output.Type)
+	}
+	tee := conf.Output.Tee
+	if tee.Output == nil || tee.Archive == nil {
+		return errors.New("replay requires a tee output with both a child output and an archive")
+	}
+
+	inputs, err := archiveInputs(*tee.Archive, codec, from, to)
+	if err != nil {
+		return err
+	}
+
+	flagConf := processor.NewConfig()
+	flagConf.Type = processor.TypeBloblang
+	flagConf.Bloblang = processor.BloblangConfig(fmt.Sprintf("meta %v = \"true\"", ReplayedMetaKey))
+
+	inConf := input.NewConfig()
+	inConf.Type = input.TypeSequence
+	inConf.Sequence.Inputs = inputs
+	inConf.Processors = []processor.Config{flagConf}
+
+	outConf := *tee.Output
+	outConf.Processors = append(append([]processor.Config{}, conf.Output.Processors...), outConf.Processors...)
+
+	conf.Input = inConf
+	conf.Output = outConf
+	return nil
+}
@@ -0,0 +1,142 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func teeConfig(archive output.Config) config.Type {
+	child := output.NewConfig()
+	child.Type = output.TypeKafka
+
+	conf := config.New()
+	conf.Output.Type = output.TypeTee
+	conf.Output.Tee.Output = &child
+	conf.Output.Tee.Archive = &archive
+	return conf
+}
+
+func TestApplyS3(t *testing.T) {
+	archive := output.NewConfig()
+	archive.Type = output.TypeAWSS3
+	archive.AWSS3.Bucket = "foo"
+	archive.AWSS3.Region = "eu-west-1"
+	archive.AWSS3.Path = `events/${! meta("tee_partition") }/${! uuid_v4() }.json`
+
+	teeProc := processor.NewConfig()
+	teeProc.Type = processor.TypeBloblang
+	teeProc.Bloblang = "root = this"
+
+	conf := teeConfig(archive)
+	conf.Output.Processors = []processor.Config{teeProc}
+
+	from, err := time.Parse(time.RFC3339, "2021-04-05T10:30:00Z")
+	require.NoError(t, err)
+	to, err := time.Parse(time.RFC3339, "2021-04-05T12:15:00+01:00")
+	require.NoError(t, err)
+
+	require.NoError(t, Apply(&conf, "lines", from, to))
+
+	assert.Equal(t, input.TypeSequence, conf.Input.Type)
+	require.Len(t, conf.Input.Processors, 1)
+	assert.Equal(t, `meta replayed = "true"`, string(conf.Input.Processors[0].Bloblang))
+
+	var prefixes []string
+	for _, in := range conf.Input.Sequence.Inputs {
+		assert.Equal(t, input.TypeAWSS3, in.Type)
+		assert.Equal(t, "foo", in.AWSS3.Bucket)
+		assert.Equal(t, "eu-west-1", in.AWSS3.Region)
+		assert.Equal(t, "lines", in.AWSS3.Codec)
+		prefixes = append(prefixes, in.AWSS3.Prefix)
+	}
+	assert.Equal(t, []string{
+		"events/2021/04/05/10/",
+		"events/2021/04/05/11/",
+	}, prefixes)
+
+	assert.Equal(t, output.TypeKafka, conf.Output.Type)
+	require.Len(t, conf.Output.Processors, 1)
+	assert.Equal(t, "root = this", string(conf.Output.Processors[0].Bloblang))
+}
+
+func TestApplyGCP(t *testing.T) {
+	archive := output.NewConfig()
+	archive.Type = output.TypeGCPCloudStorage
+	archive.GCPCloudStorage.Bucket = "foo"
+	archive.GCPCloudStorage.Path = `${!meta("tee_partition")}/${! uuid_v4() }.json`
+
+	conf := teeConfig(archive)
+
+	from, err := time.Parse(time.RFC3339, "2021-04-05T23:00:00Z")
+	require.NoError(t, err)
+
+	require.NoError(t, Apply(&conf, "all-bytes", from, from.Add(time.Minute)))
+	require.Len(t, conf.Input.Sequence.Inputs, 1)
+	assert.Equal(t, input.TypeGCPCloudStorage, conf.Input.Sequence.Inputs[0].Type)
+	assert.Equal(t, "2021/04/05/23/", conf.Input.Sequence.Inputs[0].GCPCloudStorage.Prefix)
+}
+
+func TestApplyErrors(t *testing.T) {
+	from, err := time.Parse(time.RFC3339, "2021-04-05T10:00:00Z")
+	require.NoError(t, err)
+	to := from.Add(time.Hour)
+
+	s3Archive := func(path string) output.Config {
+		archive := output.NewConfig()
+		archive.Type = output.TypeAWSS3
+		archive.AWSS3.Path = path
+		return archive
+	}
+
+	fileArchive := output.NewConfig()
+	fileArchive.Type = output.TypeFile
+
+	tests := map[string]struct {
+		conf config.Type
+		from time.Time
+		to   time.Time
+		err  string
+	}{
+		"not a tee": {
+			conf: config.New(),
+			from: from, to: to,
+			err: "replay requires an output of type tee",
+		},
+		"bad time range": {
+			conf: teeConfig(s3Archive(`${! meta("tee_partition") }/foo`)),
+			from: to, to: from,
+			err: "the start of the time range must be before the end",
+		},
+		"unsupported archive": {
+			conf: teeConfig(fileArchive),
+			from: from, to: to,
+			err: "archive output type 'file' cannot be replayed",
+		},
+		"no partition": {
+			conf: teeConfig(s3Archive(`foo/${! uuid_v4() }`)),
+			from: from, to: to,
+			err: "must begin with an optional prefix followed by the interpolation",
+		},
+		"static path": {
+			conf: teeConfig(s3Archive(`foo/bar.json`)),
+			from: from, to: to,
+			err: "must begin with an optional prefix followed by the interpolation",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := Apply(&test.conf, "all-bytes", test.from, test.to)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package resources
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for interacting with the resources of
+// a running Benthos instance.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "resources",
+		Usage: "Interact with the resources of a running Benthos instance",
+		Description: `
+   Allows exporting the contents of resources from a running Benthos instance
+   via its HTTP server.
+
+   benthos resources export --address localhost:4195 foo > ./foo_dump.jsonl`[4:],
+		Subcommands: []*cli.Command{
+			exportCliCommand(),
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package resources
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func exportURL(address, name string) (string, error) {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse address: %w", err)
+	}
+	u.Path = path.Join("/", u.Path, "resources", "caches", url.PathEscape(name), "export")
+	return u.String(), nil
+}
+
+func export(address, name string, w io.Writer) error {
+	u, err := exportURL(address, name)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("request failed with status %v: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func exportCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export the contents of a cache resource as newline delimited JSON",
+		Description: `
+   Streams the contents of a cache resource from a running Benthos instance,
+   where each line is a JSON document containing the key, value, value size
+   and remaining TTL of an item. Caches that do not support being exported,
+   such as redis and memcached, result in an error.
+
+   benthos resources export foo
+   benthos resources export --address localhost:4195 --output ./foo.jsonl foo
+
+   An export can be used to prepopulate a memory cache with the field
+   init_from_file.`[4:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "address",
+				Value: "localhost:4195",
+				Usage: "The address of the HTTP server of the Benthos instance.",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Value:   "",
+				Usage:   "An optional file path to write the export to instead of stdout.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				fmt.Fprintln(os.Stderr, "Expected exactly one cache resource name")
+				os.Exit(1)
+			}
+
+			var w io.Writer = os.Stdout
+			if outPath := c.String("output"); outPath != "" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+					os.Exit(1)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if err := export(c.String("address"), c.Args().First(), w); err != nil {
+				fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
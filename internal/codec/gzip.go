@@ -0,0 +1,212 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The ways in which a corrupt member of a gzip stream can be handled.
+const (
+	// GzipCorruptionFail returns an error when a corrupt member is
+	// encountered.
+	GzipCorruptionFail = "fail"
+
+	// GzipCorruptionTruncate ends the stream without an error when a corrupt
+	// member is encountered, keeping any data decompressed up to the point of
+	// corruption.
+	GzipCorruptionTruncate = "truncate"
+
+	// GzipCorruptionSkipMember discards a corrupt member and resumes from the
+	// next member of the stream.
+	GzipCorruptionSkipMember = "skip_member"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// NewGzipReader returns a reader of the decompressed contents of a gzip stream
+// consisting of one or more concatenated members. The argument onCorruption
+// determines how a corrupt member is handled, and must be one of fail,
+// truncate or skip_member. The function onCorruptMember, if not nil, is called
+// each time a corrupt member is truncated or skipped.
+//
+// When skipping corrupt members the decompressed contents of each member are
+// buffered in memory until the member is verified, and the next member is
+// found by scanning for a gzip header.
+func NewGzipReader(r io.Reader, onCorruption string, onCorruptMember func()) (io.Reader, error) {
+	switch onCorruption {
+	case GzipCorruptionFail:
+		return gzip.NewReader(r)
+	case GzipCorruptionTruncate, GzipCorruptionSkipMember:
+	default:
+		return nil, fmt.Errorf("gzip corruption handling not recognised: %v", onCorruption)
+	}
+	if onCorruptMember == nil {
+		onCorruptMember = func() {}
+	}
+	rec := &recordingReader{
+		r:      r,
+		record: onCorruption == GzipCorruptionSkipMember,
+	}
+	return &gzipMemberReader{
+		skip:      onCorruption == GzipCorruptionSkipMember,
+		onCorrupt: onCorruptMember,
+		rec:       rec,
+		br:        bufio.NewReader(rec),
+	}, nil
+}
+
+// recordingReader optionally records the bytes read from an underlying reader
+// so that they can be read again after a corrupt gzip member.
+type recordingReader struct {
+	r      io.Reader
+	record bool
+	buf    []byte
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.record && n > 0 {
+		r.buf = append(r.buf, p[:n]...)
+	}
+	return n, err
+}
+
+type gzipMemberReader struct {
+	skip      bool
+	onCorrupt func()
+
+	rec *recordingReader
+	br  *bufio.Reader
+	gz  *gzip.Reader
+
+	inMember  bool
+	resyncing bool
+	pending   bytes.Buffer
+	err       error
+}
+
+// startMember prepares the gzip reader for the next member of the stream,
+// returning io.EOF once the stream is fully consumed.
+func (g *gzipMemberReader) startMember() error {
+	if _, err := g.br.Peek(1); err != nil {
+		return err
+	}
+
+	// Discard recorded bytes that belong to the previous member, leaving the
+	// bytes that have been read ahead into the buffered reader.
+	if consumed := len(g.rec.buf) - g.br.Buffered(); consumed > 0 {
+		g.rec.buf = append(g.rec.buf[:0], g.rec.buf[consumed:]...)
+	}
+
+	var err error
+	if g.gz == nil {
+		g.gz, err = gzip.NewReader(g.br)
+	} else {
+		err = g.gz.Reset(g.br)
+	}
+	if err != nil {
+		return err
+	}
+	g.gz.Multistream(false)
+	g.inMember = true
+	return nil
+}
+
+// resync skips over the first byte of a corrupt member and then scans the
+// stream for the header of the next member.
+func (g *gzipMemberReader) resync() error {
+	remaining := append([]byte(nil), g.rec.buf...)
+	if len(remaining) > 0 {
+		remaining = remaining[1:]
+	}
+	g.rec.r = io.MultiReader(bytes.NewReader(remaining), g.rec.r)
+	g.rec.buf = nil
+	g.br.Reset(g.rec)
+
+	for {
+		b, err := g.br.Peek(len(gzipMagic))
+		if bytes.Equal(b, gzipMagic) {
+			return nil
+		}
+		if err != nil {
+			return io.EOF
+		}
+		if _, err = g.br.Discard(1); err != nil {
+			return io.EOF
+		}
+	}
+}
+
+func (g *gzipMemberReader) corrupted() {
+	g.inMember = false
+	if !g.resyncing {
+		g.onCorrupt()
+	}
+	if !g.skip {
+		g.err = io.EOF
+		return
+	}
+	g.resyncing = true
+	g.err = g.resync()
+}
+
+// nextSkippedMember decompresses the next member of the stream into the
+// pending buffer, skipping over any corrupt members before it.
+func (g *gzipMemberReader) nextSkippedMember() {
+	for g.err == nil {
+		if err := g.startMember(); err != nil {
+			if errors.Is(err, io.EOF) && !g.resyncing {
+				g.err = io.EOF
+				return
+			}
+			g.corrupted()
+			continue
+		}
+		if _, err := io.Copy(&g.pending, g.gz); err != nil {
+			g.pending.Reset()
+			g.corrupted()
+			continue
+		}
+		g.inMember = false
+		g.resyncing = false
+		return
+	}
+}
+
+func (g *gzipMemberReader) Read(p []byte) (int, error) {
+	for {
+		if g.pending.Len() > 0 {
+			return g.pending.Read(p)
+		}
+		if g.err != nil {
+			return 0, g.err
+		}
+		if g.skip {
+			g.nextSkippedMember()
+			continue
+		}
+		if !g.inMember {
+			if err := g.startMember(); err != nil {
+				if errors.Is(err, io.EOF) {
+					g.err = io.EOF
+				} else {
+					g.corrupted()
+				}
+				continue
+			}
+		}
+		n, err := g.gz.Read(p)
+		if errors.Is(err, io.EOF) {
+			g.inMember = false
+		} else if err != nil {
+			g.corrupted()
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipMember(t testing.TB, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func gzipMemberLines(prefix string, n int) string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		lines = append(lines, fmt.Sprintf("%v line %v", prefix, i))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// gzipCorruptFixture returns a gzip stream of three members where the middle
+// member is corrupt, along with the contents of the first and last members.
+func gzipCorruptFixture(t testing.TB) (stream []byte, first, last string) {
+	t.Helper()
+
+	first = gzipMemberLines("first", 100)
+	last = gzipMemberLines("last", 100)
+
+	middle := gzipMember(t, gzipMemberLines("middle", 100))
+	middle[len(middle)/2] ^= 0xff
+
+	stream = append(stream, gzipMember(t, first)...)
+	stream = append(stream, middle...)
+	stream = append(stream, gzipMember(t, last)...)
+	return
+}
+
+func readGzip(t testing.TB, data []byte, onCorruption string) (string, int, error) {
+	t.Helper()
+
+	corrupt := 0
+	r, err := NewGzipReader(bytes.NewReader(data), onCorruption, func() {
+		corrupt++
+	})
+	require.NoError(t, err)
+
+	out, err := ioutil.ReadAll(r)
+	return string(out), corrupt, err
+}
+
+func TestGzipReaderMultipleMembers(t *testing.T) {
+	var stream []byte
+	var exp string
+	for i := 0; i < 3; i++ {
+		data := gzipMemberLines(fmt.Sprintf("member %v", i), 50)
+		stream = append(stream, gzipMember(t, data)...)
+		exp += data
+	}
+
+	for _, mode := range []string{GzipCorruptionFail, GzipCorruptionTruncate, GzipCorruptionSkipMember} {
+		out, corrupt, err := readGzip(t, stream, mode)
+		require.NoError(t, err, mode)
+		assert.Equal(t, exp, out, mode)
+		assert.Equal(t, 0, corrupt, mode)
+	}
+}
+
+func TestGzipReaderCorruptMember(t *testing.T) {
+	stream, first, last := gzipCorruptFixture(t)
+
+	_, _, err := readGzip(t, stream, GzipCorruptionFail)
+	assert.Error(t, err)
+
+	out, corrupt, err := readGzip(t, stream, GzipCorruptionTruncate)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, first), out)
+	assert.NotContains(t, out, "last line")
+	assert.Equal(t, 1, corrupt)
+
+	out, corrupt, err = readGzip(t, stream, GzipCorruptionSkipMember)
+	require.NoError(t, err)
+	assert.Equal(t, first+last, out)
+	assert.Equal(t, 1, corrupt)
+}
+
+func TestGzipReaderCorruptFinalMember(t *testing.T) {
+	first := gzipMemberLines("first", 10)
+	second := gzipMember(t, gzipMemberLines("second", 10))
+
+	stream := append(gzipMember(t, first), second[:len(second)/2]...)
+
+	out, corrupt, err := readGzip(t, stream, GzipCorruptionSkipMember)
+	require.NoError(t, err)
+	assert.Equal(t, first, out)
+	assert.Equal(t, 1, corrupt)
+
+	stream = append(gzipMember(t, first), []byte("trailing garbage")...)
+
+	out, corrupt, err = readGzip(t, stream, GzipCorruptionSkipMember)
+	require.NoError(t, err)
+	assert.Equal(t, first, out)
+	assert.Equal(t, 1, corrupt)
+}
+
+func TestGzipReaderBadOption(t *testing.T) {
+	_, err := NewGzipReader(bytes.NewReader(nil), "nope", nil)
+	assert.Error(t, err)
+
+	for _, codec := range []string{
+		"gzip:nope/lines",
+		"gzip:on_corruption=nope/lines",
+	} {
+		_, err := GetReader(codec, NewReaderConfig())
+		assert.Error(t, err, codec)
+	}
+}
+
+func TestGzipCodecSkipMember(t *testing.T) {
+	stream, _, _ := gzipCorruptFixture(t)
+
+	var exp []string
+	for _, prefix := range []string{"first", "last"} {
+		for i := 0; i < 100; i++ {
+			exp = append(exp, fmt.Sprintf("%v line %v", prefix, i))
+		}
+	}
+	testReaderSuite(t, "gzip:on_corruption=skip_member/lines", "", stream, exp...)
+}
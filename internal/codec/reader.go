@@ -2,15 +2,18 @@ package codec
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -23,7 +26,7 @@ import (
 
 // ReaderDocs is a static field documentation for input codecs.
 var ReaderDocs = docs.FieldCommon(
-	"codec", "The way in which the bytes of a data source should be converted into discrete messages, codecs are useful for specifying how large files or contiunous streams of data might be processed in small chunks rather than loading it all in memory. It's possible to consume lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. Codecs can be chained with `/`, for example a gzip compressed CSV file can be consumed with the codec `gzip/csv`.", "lines", "delim:\t", "delim:foobar", "gzip/csv",
+	"codec", "The way in which the bytes of a data source should be converted into discrete messages, codecs are useful for specifying how large files or contiunous streams of data might be processed in small chunks rather than loading it all in memory. It's possible to consume lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. Codecs can be chained with `/`, for example a gzip compressed CSV file can be consumed with the codec `gzip/csv`. Messages consumed from files within an archive have the metadata field `archive_path` set to the path of the file within the archive. A `path_filter` may contain `/` and therefore must be the last codec of a chain, e.g. `gzip/tar:path_filter=data/*.json`.", "lines", "delim:\t", "delim:foobar", "gzip/csv",
 ).HasAnnotatedOptions(
 	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
 	"all-bytes", "Consume the entire file as a single binary message.",
@@ -31,9 +34,14 @@ var ReaderDocs = docs.FieldCommon(
 	"csv", "Consume structured rows as comma separated values, the first row must be a header row.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
 	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
+	"gzip:on_corruption=x", "Decompress a gzip file consisting of one or more members, where a corrupt member is handled according to x, which is one of `fail`, `truncate` or `skip_member`. With `truncate` the data decompressed up to the point of corruption is kept and the remainder of the file is ignored, and with `skip_member` a corrupt member is discarded and decompression resumes from the next member.",
+	"json_array", "Consume a JSON array, where each element of the array is consumed as a message. Elements are decoded one at a time and so the array as a whole is never decoded in memory.",
 	"lines", "Consume the file in segments divided by linebreaks.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"tar:path_filter=x", "Parse the file as a tar archive, and consume each file of the archive with a path matching the glob pattern x as a message. The contents of other files are skipped without being read where the source supports seeking.",
+	"zip", "Parse the file as a zip archive, and consume each file of the archive as a message.",
+	"zip:path_filter=x", "Parse the file as a zip archive, and consume each file of the archive with a path matching the glob pattern x as a message. Other files are skipped without being decompressed.",
 )
 
 //------------------------------------------------------------------------------
@@ -130,14 +138,30 @@ func chainPartIntoReaderCtor(first ReaderConstructor, second readerReaderConstru
 	}
 }
 
-func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
+// splitCodecs splits a chain of codecs by `/`, where the options of an archive
+// codec consume the remainder of the chain as they may contain paths.
+func splitCodecs(codec string) []string {
 	codecs := strings.Split(codec, "/")
+	for i, c := range codecs {
+		if strings.HasPrefix(c, "tar:") || strings.HasPrefix(c, "zip:") {
+			return append(codecs[:i], strings.Join(codecs[i:], "/"))
+		}
+	}
+	return codecs
+}
+
+func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
+	codecs := splitCodecs(codec)
 
 	var ioCtor ioReaderConstructor
 	var partCtor ReaderConstructor
 
 	for i, codec := range codecs {
-		if tmpIOCtor, ok := ioReader(codec, conf); ok {
+		tmpIOCtor, ok, err := ioReader(codec, conf)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
 			if partCtor != nil {
 				return nil, fmt.Errorf("unable to follow codec '%v' with '%v'", codecs[i-1], codec)
 			}
@@ -178,7 +202,7 @@ func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
 	return partCtor, nil
 }
 
-func ioReader(codec string, conf ReaderConfig) (ioReaderConstructor, bool) {
+func ioReader(codec string, conf ReaderConfig) (ioReaderConstructor, bool, error) {
 	if codec == "gzip" {
 		return func(_ string, r io.ReadCloser) (io.ReadCloser, error) {
 			g, err := gzip.NewReader(r)
@@ -187,9 +211,34 @@ func ioReader(codec string, conf ReaderConfig) (ioReaderConstructor, bool) {
 				return nil, err
 			}
 			return g, nil
-		}, true
+		}, true, nil
 	}
-	return nil, false
+	if strings.HasPrefix(codec, "gzip:") {
+		onCorruption := strings.TrimPrefix(codec, "gzip:on_corruption=")
+		switch onCorruption {
+		case GzipCorruptionFail, GzipCorruptionTruncate, GzipCorruptionSkipMember:
+		default:
+			return nil, false, fmt.Errorf("invalid gzip codec option: %v", strings.TrimPrefix(codec, "gzip:"))
+		}
+		return func(_ string, r io.ReadCloser) (io.ReadCloser, error) {
+			g, err := NewGzipReader(r, onCorruption, nil)
+			if err != nil {
+				r.Close()
+				return nil, err
+			}
+			return &gzipReadCloser{Reader: g, c: r}, nil
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+type gzipReadCloser struct {
+	io.Reader
+	c io.Closer
+}
+
+func (g *gzipReadCloser) Close() error {
+	return g.c.Close()
 }
 
 func readerReader(codec string, conf ReaderConfig) (readerReaderConstructor, bool) {
@@ -215,8 +264,27 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
 			return newCSVReader(r, fn)
 		}, true, nil
-	case "tar":
-		return newTarReader, true, nil
+	case "json_array":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newJSONArrayReader(r, fn)
+		}, true, nil
+	}
+	for _, archive := range []string{"tar", "zip"} {
+		if codec != archive && !strings.HasPrefix(codec, archive+":") {
+			continue
+		}
+		filter, err := parseArchiveOptions(strings.TrimPrefix(strings.TrimPrefix(codec, archive), ":"))
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %v codec: %w", archive, err)
+		}
+		if archive == "zip" {
+			return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+				return newZipReader(filter, r, fn)
+			}, true, nil
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newTarReader(filter, r, fn)
+		}, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -270,6 +338,8 @@ func autoCodec(conf ReaderConfig) ReaderConstructor {
 			codec = "tar"
 		case ".tgz":
 			codec = "gzip/tar"
+		case ".zip":
+			codec = "zip"
 		}
 		if strings.HasSuffix(path, ".tar.gzip") {
 			codec = "gzip/tar"
@@ -480,6 +550,105 @@ func (a *csvReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type jsonArrayReader struct {
+	dec       *json.Decoder
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	started  bool
+	finished bool
+	pending  int32
+}
+
+func newJSONArrayReader(r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	return &jsonArrayReader{
+		dec:       json.NewDecoder(r),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *jsonArrayReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+// next decodes the next element of the array, returning io.EOF once the end of
+// the array is reached. An empty source is treated as an empty array.
+func (a *jsonArrayReader) next() ([]byte, error) {
+	if a.finished {
+		return nil, io.EOF
+	}
+	if !a.started {
+		a.started = true
+		t, err := a.dec.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := t.(json.Delim); !ok || d != '[' {
+			return nil, fmt.Errorf("expected the start of a JSON array, found: %v", t)
+		}
+	}
+	if !a.dec.More() {
+		if _, err := a.dec.Token(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	var raw json.RawMessage
+	if err := a.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func (a *jsonArrayReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	b, err := a.next()
+	if err != nil {
+		if err == io.EOF {
+			a.finished = true
+		} else {
+			_ = a.sourceAck(ctx, err)
+		}
+		return nil, nil, err
+	}
+
+	a.pending++
+	return []types.Part{message.NewPart(b)}, a.ack, nil
+}
+
+func (a *jsonArrayReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type customDelimReader struct {
 	buf       *bufio.Scanner
 	r         io.ReadCloser
@@ -657,21 +826,68 @@ func (a *chunkerReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+// archivePathFilter matches the paths of files within an archive against a
+// glob pattern. Patterns without a `/` are matched against the base name of
+// the file only, and a nil filter matches all files.
+type archivePathFilter struct {
+	pattern   string
+	matchBase bool
+}
+
+func parseArchiveOptions(options string) (*archivePathFilter, error) {
+	if options == "" {
+		return nil, nil
+	}
+	pattern := strings.TrimPrefix(options, "path_filter=")
+	if pattern == options {
+		return nil, fmt.Errorf("option not recognised: %v", options)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid path_filter: %w", err)
+	}
+	return &archivePathFilter{
+		pattern:   pattern,
+		matchBase: !strings.Contains(pattern, "/"),
+	}, nil
+}
+
+func (f *archivePathFilter) match(name string) bool {
+	if f == nil {
+		return true
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if f.matchBase {
+		name = path.Base(name)
+	}
+	matched, _ := path.Match(f.pattern, name)
+	return matched
+}
+
+func newArchivePart(name string, b []byte) types.Part {
+	p := message.NewPart(b)
+	p.Metadata().Set("archive_path", name)
+	return p
+}
+
+//------------------------------------------------------------------------------
+
 type tarReader struct {
 	buf       *tar.Reader
 	r         io.ReadCloser
 	sourceAck ReaderAckFn
+	filter    *archivePathFilter
 
 	mut      sync.Mutex
 	finished bool
 	pending  int32
 }
 
-func newTarReader(path string, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+func newTarReader(filter *archivePathFilter, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
 	return &tarReader{
 		buf:       tar.NewReader(r),
 		r:         r,
 		sourceAck: ackOnce(ackFn),
+		filter:    filter,
 	}, nil
 }
 
@@ -691,7 +907,12 @@ func (a *tarReader) ack(ctx context.Context, err error) error {
 }
 
 func (a *tarReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
-	_, err := a.buf.Next()
+	// The tar reader skips the contents of files that aren't read, seeking over
+	// them when the underlying reader supports it.
+	hdr, err := a.buf.Next()
+	for err == nil && !a.filter.match(hdr.Name) {
+		hdr, err = a.buf.Next()
+	}
 
 	a.mut.Lock()
 	defer a.mut.Unlock()
@@ -703,7 +924,7 @@ func (a *tarReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error)
 			return nil, nil, err
 		}
 		a.pending++
-		return []types.Part{message.NewPart(fileBuf.Bytes())}, a.ack, nil
+		return []types.Part{newArchivePart(hdr.Name, fileBuf.Bytes())}, a.ack, nil
 	}
 
 	if err == io.EOF {
@@ -729,6 +950,118 @@ func (a *tarReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type zipReader struct {
+	files     []*zip.File
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+// zipReaderAt provides random access to the contents of a zip archive, which
+// requires the archive to be buffered in memory unless the source is seekable.
+func zipReaderAt(r io.Reader) (io.ReaderAt, int64, error) {
+	if rs, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err == nil {
+			_, err = rs.Seek(0, io.SeekStart)
+		}
+		return rs, size, err
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(b), int64(len(b)), nil
+}
+
+func newZipReader(filter *archivePathFilter, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	ra, size, err := zipReaderAt(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*zip.File
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() && filter.match(f.Name) {
+			files = append(files, f)
+		}
+	}
+	return &zipReader{
+		files:     files,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *zipReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *zipReader) readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (a *zipReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if len(a.files) == 0 {
+		a.finished = true
+		return nil, nil, io.EOF
+	}
+
+	f := a.files[0]
+	b, err := a.readFile(f)
+	if err != nil {
+		_ = a.sourceAck(ctx, err)
+		return nil, nil, err
+	}
+	a.files = a.files[1:]
+	a.pending++
+	return []types.Part{newArchivePart(f.Name, b)}, a.ack, nil
+}
+
+func (a *zipReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type multipartReader struct {
 	child Reader
 }
@@ -2,12 +2,15 @@ package codec
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -289,6 +292,39 @@ func TestCSVReader(t *testing.T) {
 	testReaderSuite(t, "csv", "", data)
 }
 
+func TestJSONArrayReader(t *testing.T) {
+	data := []byte(`[{"id":1}, "foo" ,
+  [1,2],null]`)
+	testReaderSuite(t, "json_array", "", data, `{"id":1}`, `"foo"`, `[1,2]`, `null`)
+
+	data = []byte(` [ ] `)
+	testReaderSuite(t, "json_array", "", data)
+
+	data = []byte("")
+	testReaderSuite(t, "json_array", "", data)
+}
+
+func TestJSONArrayReaderErrors(t *testing.T) {
+	for _, data := range []string{`{"id":1}`, `[{"id":1},{"id":`} {
+		ctor, err := GetReader("json_array", NewReaderConfig())
+		require.NoError(t, err)
+
+		var ack error
+		r, err := ctor("", noopCloser{bytes.NewReader([]byte(data)), false}, func(ctx context.Context, err error) error {
+			ack = err
+			return nil
+		})
+		require.NoError(t, err)
+
+		for err == nil {
+			_, _, err = r.Next(context.Background())
+		}
+		assert.NotEqual(t, io.EOF, err, data)
+		assert.Error(t, ack, data)
+		require.NoError(t, r.Close(context.Background()))
+	}
+}
+
 func TestAutoReader(t *testing.T) {
 	data := []byte("col1,col2,col3\nfoo1,bar1,baz1\nfoo2,bar2,baz2\nfoo3,bar3,baz3")
 	testReaderSuite(
@@ -448,6 +484,117 @@ func TestTarGzipReaderOld(t *testing.T) {
 	testReaderSuite(t, "auto", "foo.tgz", gzipBuf.Bytes(), input...)
 }
 
+var archiveTestFiles = []struct {
+	name     string
+	contents string
+}{
+	{name: "data/first.json", contents: `{"id":1}`},
+	{name: "data/notes.txt", contents: "not json"},
+	{name: "other/second.json", contents: `{"id":2}`},
+	{name: "data/nested/third.json", contents: `{"id":3}`},
+}
+
+func testArchiveReaderFilter(t *testing.T, codec string, data []byte, expectedPaths ...string) {
+	t.Helper()
+
+	ctor, err := GetReader(codec, NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	var paths []string
+	for {
+		p, ackFn, err := r.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+		require.Len(t, p, 1)
+		paths = append(paths, p[0].Metadata().Get("archive_path"))
+	}
+	assert.Equal(t, expectedPaths, paths, codec)
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestTarReaderPathFilter(t *testing.T) {
+	var gzipBuf bytes.Buffer
+
+	zw := gzip.NewWriter(&gzipBuf)
+	tw := tar.NewWriter(zw)
+	for _, f := range archiveTestFiles {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: f.name,
+			Mode: 0600,
+			Size: int64(len(f.contents)),
+		}))
+		_, err := tw.Write([]byte(f.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	testArchiveReaderFilter(t, "gzip/tar", gzipBuf.Bytes(), "data/first.json", "data/notes.txt", "other/second.json", "data/nested/third.json")
+	testArchiveReaderFilter(t, "gzip/tar:path_filter=*.json", gzipBuf.Bytes(), "data/first.json", "other/second.json", "data/nested/third.json")
+	testArchiveReaderFilter(t, "gzip/tar:path_filter=data/*.json", gzipBuf.Bytes(), "data/first.json")
+	testArchiveReaderFilter(t, "gzip/tar:path_filter=nope/*", gzipBuf.Bytes())
+}
+
+func TestArchiveReaderBadOptions(t *testing.T) {
+	for _, codec := range []string{
+		"tar:nope",
+		"zip:path_filter=[",
+		"gzip/tar:path_filter=data/[",
+	} {
+		_, err := GetReader(codec, NewReaderConfig())
+		assert.Error(t, err, codec)
+	}
+}
+
+func TestZipReader(t *testing.T) {
+	input := []string{
+		"first document",
+		"second document",
+		"third document",
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for i := range input {
+		w, err := zw.Create(fmt.Sprintf("testfile%v", i))
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(input[i]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	testReaderSuite(t, "zip", "", zipBuf.Bytes(), input...)
+	testReaderSuite(t, "auto", "foo.zip", zipBuf.Bytes(), input...)
+}
+
+func TestZipReaderPathFilter(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	_, err := zw.Create("data/")
+	require.NoError(t, err)
+	for _, f := range archiveTestFiles {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(f.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	testArchiveReaderFilter(t, "zip", zipBuf.Bytes(), "data/first.json", "data/notes.txt", "other/second.json", "data/nested/third.json")
+	testArchiveReaderFilter(t, "zip:path_filter=*.json", zipBuf.Bytes(), "data/first.json", "other/second.json", "data/nested/third.json")
+	testArchiveReaderFilter(t, "zip:path_filter=data/*", zipBuf.Bytes(), "data/first.json", "data/notes.txt")
+}
+
 func strsFromParts(ps []types.Part) []string {
 	var strs []string
 	for _, part := range ps {
@@ -658,3 +805,41 @@ func TestMultipartLinesReader(t *testing.T) {
 	data = []byte("")
 	testReaderSuite(t, "lines/multipart", "", data)
 }
+
+func TestZipReaderSeekable(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for _, f := range archiveTestFiles {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(f.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	filePath := filepath.Join(t.TempDir(), "foo.zip")
+	require.NoError(t, os.WriteFile(filePath, zipBuf.Bytes(), 0o600))
+
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+
+	ctor, err := GetReader("zip:path_filter=other/*.json", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor(filePath, file, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	p, ackFn, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, ackFn(context.Background(), nil))
+	require.Len(t, p, 1)
+	assert.Equal(t, `{"id":2}`, string(p[0].Get()))
+	assert.Equal(t, "other/second.json", p[0].Metadata().Get("archive_path"))
+
+	_, _, err = r.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	require.NoError(t, r.Close(context.Background()))
+}
@@ -189,6 +189,10 @@ func (a *v2ToV1Cache) Delete(key string) error {
 	return err
 }
 
+func (a *v2ToV1Cache) Export(ctx context.Context, fn func(item ExportItem) error) error {
+	return Export(ctx, a.c, fn)
+}
+
 func (a *v2ToV1Cache) CloseAsync() {
 	go func() {
 		if err := a.c.Close(context.Background()); err == nil {
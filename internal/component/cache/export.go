@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrExportNotSupported is returned by caches that are unable to iterate their
+// contents.
+var ErrExportNotSupported = errors.New("cache does not support exporting its contents")
+
+// ExportItem describes a single item of a cache export.
+type ExportItem struct {
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+	ValueSize int    `json:"value_size"`
+
+	// TTLRemaining is a duration string describing how long the item has left
+	// before it expires, and is empty when the item does not expire.
+	TTLRemaining string `json:"ttl_remaining,omitempty"`
+}
+
+// NewExportItem creates an export item from a key, value and an optional
+// remaining TTL.
+func NewExportItem(key string, value []byte, ttlRemaining *time.Duration) ExportItem {
+	item := ExportItem{
+		Key:       key,
+		Value:     value,
+		ValueSize: len(value),
+	}
+	if ttlRemaining != nil {
+		item.TTLRemaining = ttlRemaining.String()
+	}
+	return item
+}
+
+// TTL returns the remaining TTL of the item, or nil if the item does not
+// expire.
+func (e ExportItem) TTL() (*time.Duration, error) {
+	if e.TTLRemaining == "" {
+		return nil, nil
+	}
+	ttl, err := time.ParseDuration(e.TTLRemaining)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ttl_remaining of key '%v': %w", e.Key, err)
+	}
+	return &ttl, nil
+}
+
+// Exporter is an optional interface implemented by caches that are able to
+// iterate their contents.
+type Exporter interface {
+	// Export calls a closure for each item currently held within the cache,
+	// and stops when the closure returns an error.
+	Export(ctx context.Context, fn func(item ExportItem) error) error
+}
+
+// Export attempts to iterate the contents of a cache, returning
+// ErrExportNotSupported if the cache does not implement Exporter.
+func Export(ctx context.Context, c interface{}, fn func(item ExportItem) error) error {
+	e, ok := c.(Exporter)
+	if !ok {
+		return ErrExportNotSupported
+	}
+	return e.Export(ctx, fn)
+}
+
+// WriteExport streams the contents of a cache to a writer as newline delimited
+// JSON documents.
+func WriteExport(ctx context.Context, c interface{}, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return Export(ctx, c, func(item ExportItem) error {
+		return enc.Encode(item)
+	})
+}
+
+// ReadExport reads a stream of newline delimited JSON documents written by
+// WriteExport and calls a closure for each item.
+func ReadExport(r io.Reader, fn func(item ExportItem) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var item ExportItem
+		if err := dec.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode cache export: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package input
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InFlightStats describes the messages of an input that have been read but not
+// yet acknowledged.
+type InFlightStats struct {
+	// Count is the number of messages in flight.
+	Count int64
+
+	// Oldest is the time at which the oldest message in flight was read, and
+	// is zero when no messages are in flight.
+	Oldest time.Time
+}
+
+// OldestAge returns the period since the oldest message in flight was read, or
+// zero if there are no messages in flight.
+func (s InFlightStats) OldestAge() time.Duration {
+	if s.Oldest.IsZero() {
+		return 0
+	}
+	return time.Since(s.Oldest)
+}
+
+// Merge returns the combined stats of two sets of in flight messages.
+func (s InFlightStats) Merge(other InFlightStats) InFlightStats {
+	s.Count += other.Count
+	if s.Oldest.IsZero() || (!other.Oldest.IsZero() && other.Oldest.Before(s.Oldest)) {
+		s.Oldest = other.Oldest
+	}
+	return s
+}
+
+// InFlightReporter is implemented by inputs, and components wrapping them, that
+// track the messages they have read that are yet to be acknowledged.
+type InFlightReporter interface {
+	InFlight() InFlightStats
+}
+
+//------------------------------------------------------------------------------
+
+// inFlightShards is the number of independently locked shards of a tracker,
+// which reduces contention between concurrent reads and acknowledgements.
+const inFlightShards = 16
+
+type inFlightItem struct {
+	readAt time.Time
+	count  int64
+	index  int
+}
+
+type inFlightHeap []*inFlightItem
+
+func (h inFlightHeap) Len() int           { return len(h) }
+func (h inFlightHeap) Less(i, j int) bool { return h[i].readAt.Before(h[j].readAt) }
+
+func (h inFlightHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *inFlightHeap) Push(x interface{}) {
+	item := x.(*inFlightItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *inFlightHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+type inFlightShard struct {
+	mut   sync.Mutex
+	count int64
+	items inFlightHeap
+
+	// Prevents false sharing of adjacent shards.
+	_ [64]byte
+}
+
+// InFlightTracker tracks the number of messages read by an input that are yet
+// to be acknowledged, along with the time at which the oldest was read.
+type InFlightTracker struct {
+	next   uint32
+	shards [inFlightShards]inFlightShard
+}
+
+// NewInFlightTracker returns a tracker with no messages in flight.
+func NewInFlightTracker() *InFlightTracker {
+	return &InFlightTracker{}
+}
+
+// Add registers a batch of messages as in flight, and returns a function to be
+// called once the batch has been acknowledged. The returned function must be
+// called exactly once.
+func (t *InFlightTracker) Add(count int) func() {
+	shard := &t.shards[atomic.AddUint32(&t.next, 1)%inFlightShards]
+	item := &inFlightItem{
+		readAt: time.Now(),
+		count:  int64(count),
+	}
+
+	shard.mut.Lock()
+	heap.Push(&shard.items, item)
+	shard.count += item.count
+	shard.mut.Unlock()
+
+	return func() {
+		shard.mut.Lock()
+		heap.Remove(&shard.items, item.index)
+		shard.count -= item.count
+		shard.mut.Unlock()
+	}
+}
+
+// InFlight returns the stats of messages currently in flight.
+func (t *InFlightTracker) InFlight() InFlightStats {
+	var stats InFlightStats
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mut.Lock()
+		shardStats := InFlightStats{Count: shard.count}
+		if len(shard.items) > 0 {
+			shardStats.Oldest = shard.items[0].readAt
+		}
+		shard.mut.Unlock()
+		stats = stats.Merge(shardStats)
+	}
+	return stats
+}
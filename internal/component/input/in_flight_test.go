@@ -0,0 +1,69 @@
+package input
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	tracker := NewInFlightTracker()
+	assert.Equal(t, InFlightStats{}, tracker.InFlight())
+
+	doneFirst := tracker.Add(2)
+	first := tracker.InFlight()
+	assert.Equal(t, int64(2), first.Count)
+	assert.False(t, first.Oldest.IsZero())
+
+	<-time.After(time.Millisecond * 5)
+	doneSecond := tracker.Add(3)
+	assert.Equal(t, InFlightStats{Count: 5, Oldest: first.Oldest}, tracker.InFlight())
+
+	doneFirst()
+	second := tracker.InFlight()
+	assert.Equal(t, int64(3), second.Count)
+	assert.True(t, second.Oldest.After(first.Oldest))
+
+	doneSecond()
+	assert.Equal(t, InFlightStats{}, tracker.InFlight())
+	assert.Equal(t, time.Duration(0), tracker.InFlight().OldestAge())
+}
+
+func TestInFlightTrackerParallel(t *testing.T) {
+	tracker := NewInFlightTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dones []func()
+			for j := 0; j < 100; j++ {
+				dones = append(dones, tracker.Add(1))
+			}
+			for _, done := range dones {
+				done()
+			}
+		}()
+	}
+
+	release := tracker.Add(1)
+	wg.Wait()
+	assert.Equal(t, int64(1), tracker.InFlight().Count)
+
+	release()
+	assert.Equal(t, InFlightStats{}, tracker.InFlight())
+}
+
+func TestInFlightStatsMerge(t *testing.T) {
+	older := time.Now().Add(-time.Minute)
+	newer := time.Now()
+
+	assert.Equal(t, InFlightStats{Count: 3, Oldest: older}, InFlightStats{Count: 1, Oldest: newer}.Merge(InFlightStats{Count: 2, Oldest: older}))
+	assert.Equal(t, InFlightStats{Count: 3, Oldest: older}, InFlightStats{Count: 1, Oldest: older}.Merge(InFlightStats{Count: 2, Oldest: newer}))
+	assert.Equal(t, InFlightStats{Count: 1, Oldest: newer}, InFlightStats{}.Merge(InFlightStats{Count: 1, Oldest: newer}))
+	assert.Equal(t, InFlightStats{Count: 1, Oldest: newer}, InFlightStats{Count: 1, Oldest: newer}.Merge(InFlightStats{}))
+	assert.GreaterOrEqual(t, int64(InFlightStats{Count: 1, Oldest: older}.OldestAge()), int64(time.Minute))
+}
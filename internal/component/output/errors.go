@@ -0,0 +1,149 @@
+package output
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// ErrorClass describes whether a failed write is worth attempting again.
+type ErrorClass int
+
+// Error classes that writers can attach to errors returned from writes.
+const (
+	// ErrorClassRetryable is an error that might be resolved by trying the
+	// write again, and is the class of all errors that aren't classified.
+	ErrorClassRetryable ErrorClass = iota
+
+	// ErrorClassThrottled is an error caused by the target rejecting the write
+	// due to rate limits or load, which should be tried again after backing
+	// off.
+	ErrorClassThrottled
+
+	// ErrorClassTerminal is an error that will not be resolved by attempting
+	// the same write again, such as an authorization failure or a payload that
+	// the target considers invalid.
+	ErrorClassTerminal
+)
+
+// String returns the name of an error class as used within configs.
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassThrottled:
+		return "throttled"
+	case ErrorClassTerminal:
+		return "terminal"
+	}
+	return "retryable"
+}
+
+// ClassifiedError wraps an error with a class describing whether the write
+// that caused it is worth attempting again.
+type ClassifiedError struct {
+	class ErrorClass
+	err   error
+}
+
+// NewClassifiedError wraps an error with an explicit class. Returns nil if the
+// provided error is nil.
+func NewClassifiedError(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{class: class, err: err}
+}
+
+// NewTerminalError wraps an error in order to mark it as terminal, meaning
+// attempting the same write again is expected to fail.
+func NewTerminalError(err error) error {
+	return NewClassifiedError(ErrorClassTerminal, err)
+}
+
+// NewThrottledError wraps an error in order to mark it as the result of the
+// target throttling writes.
+func NewThrottledError(err error) error {
+	return NewClassifiedError(ErrorClassThrottled, err)
+}
+
+// Class returns the class of the error.
+func (e *ClassifiedError) Class() ErrorClass {
+	return e.class
+}
+
+// Error returns the message of the underlying error.
+func (e *ClassifiedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ClassifiedError) Unwrap() error {
+	return e.err
+}
+
+// ClassifyError returns the class of an error returned by a write. Errors that
+// haven't been classified are considered retryable.
+//
+// When the error is a batch error with individually failed messages the batch
+// is only considered terminal when all failed messages are terminal, and is
+// considered throttled when any are throttled, since the remaining messages
+// could still be delivered by attempting the write again.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassRetryable
+	}
+
+	var wErr batch.WalkableError
+	if errors.As(err, &wErr) && wErr.IndexedErrors() > 0 {
+		class, seen := ErrorClassTerminal, false
+		wErr.WalkParts(func(_ int, _ types.Part, pErr error) bool {
+			if pErr == nil {
+				return true
+			}
+			seen = true
+			switch classifyUnwrapped(pErr) {
+			case ErrorClassThrottled:
+				class = ErrorClassThrottled
+			case ErrorClassRetryable:
+				if class == ErrorClassTerminal {
+					class = ErrorClassRetryable
+				}
+			}
+			return true
+		})
+		if seen {
+			return class
+		}
+	}
+	return classifyUnwrapped(err)
+}
+
+func classifyUnwrapped(err error) ErrorClass {
+	var cErr *ClassifiedError
+	if errors.As(err, &cErr) {
+		return cErr.class
+	}
+	return ErrorClassRetryable
+}
+
+//------------------------------------------------------------------------------
+
+// ForceRetryOn is a list of patterns that, when contained within the message
+// of an error classified as terminal, cause the error to be retried anyway.
+type ForceRetryOn []string
+
+// Classify returns the class of an error after applying the overrides.
+func (f ForceRetryOn) Classify(err error) ErrorClass {
+	class := ClassifyError(err)
+	if class != ErrorClassTerminal {
+		return class
+	}
+	errStr := err.Error()
+	for _, p := range f {
+		if p == ErrorClassTerminal.String() || strings.Contains(errStr, p) {
+			return ErrorClassRetryable
+		}
+	}
+	return class
+}
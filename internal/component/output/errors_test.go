@@ -0,0 +1,82 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	plainErr := errors.New("nope")
+	msg := message.New([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+
+	tests := []struct {
+		name  string
+		err   error
+		class ErrorClass
+	}{
+		{
+			name:  "unclassified",
+			err:   plainErr,
+			class: ErrorClassRetryable,
+		},
+		{
+			name:  "terminal",
+			err:   NewTerminalError(plainErr),
+			class: ErrorClassTerminal,
+		},
+		{
+			name:  "wrapped throttled",
+			err:   fmt.Errorf("failed: %w", NewThrottledError(plainErr)),
+			class: ErrorClassThrottled,
+		},
+		{
+			name:  "batch without indexed errors",
+			err:   batch.NewError(msg, NewTerminalError(plainErr)),
+			class: ErrorClassTerminal,
+		},
+		{
+			name: "batch all terminal",
+			err: batch.NewError(msg, plainErr).
+				Failed(0, NewTerminalError(plainErr)).
+				Failed(2, NewTerminalError(plainErr)),
+			class: ErrorClassTerminal,
+		},
+		{
+			name: "batch partially terminal",
+			err: batch.NewError(msg, NewTerminalError(plainErr)).
+				Failed(0, NewTerminalError(plainErr)).
+				Failed(1, plainErr),
+			class: ErrorClassRetryable,
+		},
+		{
+			name: "batch partially throttled",
+			err: batch.NewError(msg, plainErr).
+				Failed(0, plainErr).
+				Failed(1, NewThrottledError(plainErr)).
+				Failed(2, NewTerminalError(plainErr)),
+			class: ErrorClassThrottled,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.class, ClassifyError(test.err), test.name)
+	}
+
+	assert.Nil(t, NewTerminalError(nil))
+	assert.True(t, errors.Is(NewTerminalError(plainErr), plainErr))
+}
+
+func TestForceRetryOn(t *testing.T) {
+	terminalErr := NewTerminalError(errors.New("request failed with 403 Forbidden"))
+
+	assert.Equal(t, ErrorClassTerminal, ForceRetryOn(nil).Classify(terminalErr))
+	assert.Equal(t, ErrorClassTerminal, ForceRetryOn{"404"}.Classify(terminalErr))
+	assert.Equal(t, ErrorClassRetryable, ForceRetryOn{"404", "403"}.Classify(terminalErr))
+	assert.Equal(t, ErrorClassRetryable, ForceRetryOn{"terminal"}.Classify(terminalErr))
+	assert.Equal(t, ErrorClassThrottled, ForceRetryOn{"403"}.Classify(NewThrottledError(errors.New("403"))))
+}
@@ -70,6 +70,10 @@ func NewV2ToV1Processor(typeStr string, p V2, stats metrics.Type) types.Processo
 }
 
 func (a *v2ToV1Processor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return a.ProcessMessageWithContext(context.Background(), msg)
+}
+
+func (a *v2ToV1Processor) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	a.mCount.Incr(1)
 
 	newParts := make([]types.Part, 0, msg.Len())
@@ -85,7 +89,11 @@ func (a *v2ToV1Processor) ProcessMessage(msg types.Message) ([]types.Message, ty
 			)
 		}
 
-		nextParts, err := a.p.Process(context.Background(), part)
+		nextParts, err := a.p.Process(ctx, part)
+		if err != nil && ctx.Err() != nil {
+			span.Finish()
+			return ctx.Err()
+		}
 		if err != nil {
 			newPart := part.Copy()
 			a.mErr.Incr(1)
@@ -106,6 +114,9 @@ func (a *v2ToV1Processor) ProcessMessage(msg types.Message) ([]types.Message, ty
 		}
 		return nil
 	})
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
 
 	if len(newParts) == 0 {
 		return nil, response.NewAck()
@@ -161,6 +172,10 @@ func NewV2BatchedToV1Processor(typeStr string, p V2Batched, stats metrics.Type)
 }
 
 func (a *v2BatchedToV1Processor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return a.ProcessMessageWithContext(context.Background(), msg)
+}
+
+func (a *v2BatchedToV1Processor) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	a.mCount.Incr(1)
 
 	newMsg, spans := tracing.WithChildSpans(a.typeStr, msg)
@@ -172,7 +187,11 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg types.Message) ([]types.Mess
 
 	var outputBatches []types.Message
 
-	batches, err := a.p.ProcessBatch(context.Background(), parts)
+	batches, err := a.p.ProcessBatch(ctx, parts)
+	if err != nil && ctx.Err() != nil {
+		tracing.FinishSpans(newMsg)
+		return nil, response.NewError(ctx.Err())
+	}
 	if err != nil {
 		a.mErr.Incr(1)
 		for i, p := range parts {
@@ -0,0 +1,29 @@
+// Package component contains interfaces shared by components of all types.
+package component
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSelfTestUnsupported is returned by SelfTest when a component, or the
+// component it wraps, does not implement SelfTester.
+var ErrSelfTestUnsupported = errors.New("component does not support self tests")
+
+// SelfTester is an optional interface implemented by components that are able
+// to verify that they can reach and authenticate with the services they
+// connect to without reading or writing any messages, for example by fetching
+// the metadata of a topic or bucket. Components that wrap another component
+// should implement it by calling SelfTest on the wrapped component.
+type SelfTester interface {
+	SelfTest(ctx context.Context) error
+}
+
+// SelfTest runs the self test of a component when it implements SelfTester,
+// and otherwise returns ErrSelfTestUnsupported.
+func SelfTest(ctx context.Context, c interface{}) error {
+	if t, ok := c.(SelfTester); ok {
+		return t.SelfTest(ctx)
+	}
+	return ErrSelfTestUnsupported
+}
@@ -3,9 +3,13 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	ifilepath "github.com/Jeffail/benthos/v3/internal/filepath"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/manager"
 	"github.com/Jeffail/gabs/v2"
@@ -18,9 +22,19 @@ type Reader struct {
 	mainPath      string
 	resourcePaths []string
 	overrides     []string
+
+	mainResources []resourceKey
+	resourceFiles map[string]resourceFile
+}
+
+// resourceFile is the last read state of a resource file.
+type resourceFile struct {
+	modTime time.Time
+	conf    manager.ResourceConfig
 }
 
-// NewReader creates a new config reader.
+// NewReader creates a new config reader. Resource paths may contain glob
+// patterns and directories, which are resolved each time resources are read.
 func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader {
 	r := &Reader{
 		mainPath:      mainPath,
@@ -108,22 +122,126 @@ func (r *Reader) readMain(conf *config.Type) (lints []string, err error) {
 		}
 	}
 
-	err = rawNode.Decode(conf)
+	if err = rawNode.Decode(conf); err != nil {
+		err = docs.PositionErrorsFromYAML(&rawNode, err)
+	}
 	return
 }
 
+// expandResourcePaths resolves glob patterns and directories within a list of
+// resource paths to the files they contain. Directories are walked recursively
+// and only files with a .yaml or .yml extension are included.
+func expandResourcePaths(paths []string) ([]string, error) {
+	globbed, err := ifilepath.Globs(paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource glob pattern: %w", err)
+	}
+
+	var expanded []string
+	seen := map[string]struct{}{}
+	add := func(path string) {
+		if _, exists := seen[path]; !exists {
+			expanded = append(expanded, path)
+			seen[path] = struct{}{}
+		}
+	}
+
+	for _, path := range globbed {
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			// Missing files are reported when they're read.
+			add(path)
+			continue
+		}
+		if err := filepath.Walk(path, func(p string, info os.FileInfo, werr error) error {
+			if werr != nil {
+				return werr
+			}
+			if info.IsDir() {
+				return nil
+			}
+			switch filepath.Ext(p) {
+			case ".yaml", ".yml":
+				add(p)
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to walk resource directory: %w", err)
+		}
+	}
+	return expanded, nil
+}
+
+// resourceSources returns the source of each resource currently known by the
+// reader, excluding those of a given resource file.
+func (r *Reader) resourceSources(excludePath string) map[resourceKey]string {
+	mainSource := r.mainPath
+	if mainSource == "" {
+		mainSource = "the main config"
+	}
+
+	sources := map[resourceKey]string{}
+	for _, k := range r.mainResources {
+		sources[k] = mainSource
+	}
+	for path, file := range r.resourceFiles {
+		if path == excludePath {
+			continue
+		}
+		for k := range resourceConfigsByKey(&file.conf) {
+			sources[k] = path
+		}
+	}
+	return sources
+}
+
+// checkResourceDuplicates returns an error if a resource of a file shares a
+// label with a resource of another source, and otherwise adds the resources of
+// the file to the sources.
+func checkResourceDuplicates(sources map[resourceKey]string, path string, conf *manager.ResourceConfig) error {
+	keys := resourceConfigsByKey(conf)
+	for k := range keys {
+		if src, exists := sources[k]; exists && src != path {
+			return fmt.Errorf("%v is defined in both %v and %v", k, src, path)
+		}
+	}
+	for k := range keys {
+		sources[k] = path
+	}
+	return nil
+}
+
 func (r *Reader) readResources(conf *config.Type) (lints []string, err error) {
-	for _, path := range r.resourcePaths {
+	var paths []string
+	if paths, err = expandResourcePaths(r.resourcePaths); err != nil {
+		return
+	}
+
+	for k := range resourceConfigsByKey(&conf.ResourceConfig) {
+		r.mainResources = append(r.mainResources, k)
+	}
+	r.resourceFiles = map[string]resourceFile{}
+
+	sources := r.resourceSources("")
+	for _, path := range paths {
+		var modTime time.Time
+		if info, serr := os.Stat(path); serr == nil {
+			modTime = info.ModTime()
+		}
+
 		rconf := manager.NewResourceConfig()
 		var rLints []string
 		if rLints, err = readResource(path, &rconf); err != nil {
 			return
 		}
 		lints = append(lints, rLints...)
+		if err = checkResourceDuplicates(sources, path, &rconf); err != nil {
+			return
+		}
 		if err = conf.ResourceConfig.AddFrom(&rconf); err != nil {
 			err = fmt.Errorf("%v: %w", path, err)
 			return
 		}
+		r.resourceFiles[path] = resourceFile{modTime: modTime, conf: rconf}
 	}
 	return
 }
@@ -150,7 +268,9 @@ func readResource(path string, conf *manager.ResourceConfig) (lints []string, er
 		}
 	}
 
-	err = rawNode.Decode(conf)
+	if err = rawNode.Decode(conf); err != nil {
+		err = docs.PositionErrorsFromYAML(&rawNode, err)
+	}
 	return
 }
 
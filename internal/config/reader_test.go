@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -54,7 +55,7 @@ func TestSetOverrideErrors(t *testing.T) {
 		{
 			name:  "cant set that",
 			input: "input=meow",
-			err:   "yaml: unmarshal errors",
+			err:   "input: cannot unmarshal !!str `meow` into input.confAlias",
 		},
 	}
 
@@ -160,6 +161,108 @@ cache_resources:
 	assert.Equal(t, 13, conf.ResourceCaches[1].Memory.TTL)
 }
 
+func TestResourcesDirectoriesAndGlobs(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test_resources")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "nested"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "b"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "res1.yaml"), []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 12
+`), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "nested", "res2.yml"), []byte(`
+rate_limit_resources:
+  - label: bar
+    local:
+      count: 10
+`), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "notes.txt"), []byte(`not a resource file`), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b", "res3.yaml"), []byte(`
+cache_resources:
+  - label: baz
+    memory:
+      ttl: 13
+`), 0644))
+
+	conf := config.New()
+	rdr := iconfig.NewReader("", []string{
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "b", "*.yaml"),
+	})
+
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	require.Len(t, conf.ResourceCaches, 2)
+	assert.Equal(t, "foo", conf.ResourceCaches[0].Label)
+	assert.Equal(t, "baz", conf.ResourceCaches[1].Label)
+
+	require.Len(t, conf.ResourceRateLimits, 1)
+	assert.Equal(t, "bar", conf.ResourceRateLimits[0].Label)
+}
+
+func TestResourcesDuplicateLabels(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test_resources")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	fullPath := filepath.Join(dir, "main.yaml")
+	require.NoError(t, os.WriteFile(fullPath, []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 12
+`), 0644))
+
+	resourceOnePath := filepath.Join(dir, "res1.yaml")
+	require.NoError(t, os.WriteFile(resourceOnePath, []byte(`
+processor_resources:
+  - label: bar
+    bloblang: 'root = this'
+`), 0644))
+
+	resourceTwoPath := filepath.Join(dir, "res2.yaml")
+	require.NoError(t, os.WriteFile(resourceTwoPath, []byte(`
+processor_resources:
+  - label: bar
+    bloblang: 'root = this.uppercase()'
+`), 0644))
+
+	resourceThreePath := filepath.Join(dir, "res3.yaml")
+	require.NoError(t, os.WriteFile(resourceThreePath, []byte(`
+resources:
+  caches:
+    foo:
+      memory:
+        ttl: 13
+`), 0644))
+
+	conf := config.New()
+	_, err = iconfig.NewReader(fullPath, []string{resourceOnePath, resourceTwoPath}).Read(&conf)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("processor resource 'bar' is defined in both %v and %v", resourceOnePath, resourceTwoPath), err.Error())
+
+	conf = config.New()
+	_, err = iconfig.NewReader(fullPath, []string{resourceThreePath}).Read(&conf)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("cache resource 'foo' is defined in both %v and %v", fullPath, resourceThreePath), err.Error())
+}
+
 func TestLints(t *testing.T) {
 	dir, err := os.MkdirTemp("", "test_resources")
 	require.NoError(t, err)
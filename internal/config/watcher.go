@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+)
+
+// ResourceStore is implemented by resource managers that are able to replace
+// resources whilst running.
+type ResourceStore interface {
+	StoreCache(ctx context.Context, name string, conf cache.Config) error
+	StoreProcessor(ctx context.Context, name string, conf processor.Config) error
+	StoreRateLimit(ctx context.Context, name string, conf ratelimit.Config) error
+}
+
+// resourceStoreTimeout is the maximum period of time to wait for a resource to
+// be replaced, which includes waiting for current accesses of the previous
+// resource to finish and for it to close.
+const resourceStoreTimeout = time.Second * 30
+
+type resourceKey struct {
+	kind string
+	name string
+}
+
+func (k resourceKey) String() string {
+	return fmt.Sprintf("%v resource '%v'", k.kind, k.name)
+}
+
+// resourceConfigsByKey returns the config of each resource defined within a
+// resource config, including those of the deprecated resources field.
+func resourceConfigsByKey(conf *manager.ResourceConfig) map[resourceKey]interface{} {
+	confs := map[resourceKey]interface{}{}
+	for k, v := range conf.Manager.Inputs {
+		confs[resourceKey{"input", k}] = v
+	}
+	for k, v := range conf.Manager.Conditions {
+		confs[resourceKey{"condition", k}] = v
+	}
+	for k, v := range conf.Manager.Processors {
+		confs[resourceKey{"processor", k}] = v
+	}
+	for k, v := range conf.Manager.Outputs {
+		confs[resourceKey{"output", k}] = v
+	}
+	for k, v := range conf.Manager.Caches {
+		confs[resourceKey{"cache", k}] = v
+	}
+	for k, v := range conf.Manager.RateLimits {
+		confs[resourceKey{"rate limit", k}] = v
+	}
+	for k, v := range conf.Manager.Plugins {
+		confs[resourceKey{"plugin", k}] = v
+	}
+	for _, v := range conf.ResourceInputs {
+		confs[resourceKey{"input", v.Label}] = v
+	}
+	for _, v := range conf.ResourceProcessors {
+		confs[resourceKey{"processor", v.Label}] = v
+	}
+	for _, v := range conf.ResourceOutputs {
+		confs[resourceKey{"output", v.Label}] = v
+	}
+	for _, v := range conf.ResourceCaches {
+		confs[resourceKey{"cache", v.Label}] = v
+	}
+	for _, v := range conf.ResourceRateLimits {
+		confs[resourceKey{"rate limit", v.Label}] = v
+	}
+	return confs
+}
+
+//------------------------------------------------------------------------------
+
+// WatchResources polls the resource files of the reader for changes until the
+// context is cancelled, and must only be called after Read. When a file
+// changes the caches, processors and rate limits it defines are replaced
+// within the store. A resource is only replaced once all current accesses of
+// it have finished, and components pick up the new resource on their next
+// access.
+//
+// Changes to other resource types and the removal of resources are logged, as
+// they only take effect after a restart.
+func (r *Reader) WatchResources(ctx context.Context, interval time.Duration, store ResourceStore, logger log.Modular) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		r.reloadResources(ctx, store, logger)
+	}
+}
+
+func (r *Reader) reloadResources(ctx context.Context, store ResourceStore, logger log.Modular) {
+	paths, err := expandResourcePaths(r.resourcePaths)
+	if err != nil {
+		logger.Errorf("Failed to reload resources: %v\n", err)
+		return
+	}
+
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		seen[path] = struct{}{}
+
+		prev, exists := r.resourceFiles[path]
+		if exists && prev.modTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		// The modification time is updated even when the file fails to load
+		// so that it isn't reattempted until it changes again.
+		next := resourceFile{modTime: info.ModTime(), conf: prev.conf}
+
+		rconf := manager.NewResourceConfig()
+		lints, err := readResource(path, &rconf)
+		if err == nil {
+			err = checkResourceDuplicates(r.resourceSources(path), path, &rconf)
+		}
+		if err != nil {
+			logger.Errorf("Failed to reload resource file: %v\n", err)
+			r.resourceFiles[path] = next
+			continue
+		}
+		for _, lint := range lints {
+			logger.Warnln(lint)
+		}
+
+		logger.Infof("Reloading resource file '%v'\n", path)
+		updateResources(ctx, path, &prev.conf, &rconf, store, logger)
+
+		next.conf = rconf
+		r.resourceFiles[path] = next
+	}
+
+	for path := range r.resourceFiles {
+		if _, exists := seen[path]; !exists {
+			logger.Warnf("Resource file '%v' was removed, the resources it defined remain active until a restart\n", path)
+			delete(r.resourceFiles, path)
+		}
+	}
+}
+
+func updateResources(
+	ctx context.Context,
+	path string,
+	prev, next *manager.ResourceConfig,
+	store ResourceStore,
+	logger log.Modular,
+) {
+	prevConfs := resourceConfigsByKey(prev)
+	nextConfs := resourceConfigsByKey(next)
+
+	for k, v := range nextConfs {
+		if pv, exists := prevConfs[k]; exists && reflect.DeepEqual(pv, v) {
+			continue
+		}
+
+		sctx, done := context.WithTimeout(ctx, resourceStoreTimeout)
+		var err error
+		switch c := v.(type) {
+		case cache.Config:
+			err = store.StoreCache(sctx, k.name, c)
+		case processor.Config:
+			err = store.StoreProcessor(sctx, k.name, c)
+		case ratelimit.Config:
+			err = store.StoreRateLimit(sctx, k.name, c)
+		default:
+			done()
+			logger.Warnf("Changes to %v of file '%v' require a restart to take effect\n", k, path)
+			continue
+		}
+		done()
+
+		if err != nil {
+			logger.Errorf("Failed to replace %v of file '%v': %v\n", k, path, err)
+		} else {
+			logger.Infof("Replaced %v of file '%v'\n", k, path)
+		}
+	}
+
+	for k := range prevConfs {
+		if _, exists := nextConfs[k]; !exists {
+			logger.Warnf("Removal of %v from file '%v' requires a restart to take effect\n", k, path)
+		}
+	}
+}
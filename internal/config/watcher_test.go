@@ -0,0 +1,194 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockResourceStore struct {
+	mut        sync.Mutex
+	caches     map[string]cache.Config
+	processors map[string]processor.Config
+	rateLimits map[string]ratelimit.Config
+}
+
+func newMockResourceStore() *mockResourceStore {
+	return &mockResourceStore{
+		caches:     map[string]cache.Config{},
+		processors: map[string]processor.Config{},
+		rateLimits: map[string]ratelimit.Config{},
+	}
+}
+
+func (m *mockResourceStore) StoreCache(ctx context.Context, name string, conf cache.Config) error {
+	m.mut.Lock()
+	m.caches[name] = conf
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockResourceStore) StoreProcessor(ctx context.Context, name string, conf processor.Config) error {
+	m.mut.Lock()
+	m.processors[name] = conf
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockResourceStore) StoreRateLimit(ctx context.Context, name string, conf ratelimit.Config) error {
+	m.mut.Lock()
+	m.rateLimits[name] = conf
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockResourceStore) stored() (caches, processors, rateLimits []string) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for k := range m.caches {
+		caches = append(caches, k)
+	}
+	for k := range m.processors {
+		processors = append(processors, k)
+	}
+	for k := range m.rateLimits {
+		rateLimits = append(rateLimits, k)
+	}
+	return
+}
+
+func writeResourceFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestWatchResources(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test_watch_resources")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	tStarted := time.Now()
+
+	resourceOnePath := filepath.Join(dir, "res1.yaml")
+	writeResourceFile(t, resourceOnePath, `
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 12
+  - label: bar
+    memory:
+      ttl: 13
+input_resources:
+  - label: baz
+    generate:
+      mapping: 'root = "hello world"'
+`, tStarted)
+
+	resourceTwoPath := filepath.Join(dir, "res2.yaml")
+	writeResourceFile(t, resourceTwoPath, `
+processor_resources:
+  - label: buz
+    bloblang: 'root = this'
+`, tStarted)
+
+	conf := config.New()
+	rdr := iconfig.NewReader("", []string{dir})
+
+	_, err = rdr.Read(&conf)
+	require.NoError(t, err)
+
+	store := newMockResourceStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		rdr.WatchResources(ctx, time.Millisecond*10, store, log.Noop())
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-watchDone
+	})
+
+	// Only the changed cache is replaced, and changes to the input are
+	// ignored.
+	writeResourceFile(t, resourceOnePath, `
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 20
+  - label: bar
+    memory:
+      ttl: 13
+input_resources:
+  - label: baz
+    generate:
+      mapping: 'root = "hello world 2"'
+`, tStarted.Add(time.Second))
+
+	assert.Eventually(t, func() bool {
+		caches, _, _ := store.stored()
+		return len(caches) == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	// A new file within the directory adds its resources.
+	writeResourceFile(t, filepath.Join(dir, "res3.yaml"), `
+rate_limit_resources:
+  - label: quz
+    local:
+      count: 10
+`, tStarted.Add(time.Second))
+
+	assert.Eventually(t, func() bool {
+		_, _, rateLimits := store.stored()
+		return len(rateLimits) == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	// A change that introduces a duplicate label is rejected.
+	writeResourceFile(t, resourceTwoPath, `
+processor_resources:
+  - label: buz
+    bloblang: 'root = this.uppercase()'
+cache_resources:
+  - label: bar
+    memory:
+      ttl: 14
+`, tStarted.Add(time.Second))
+
+	// A valid change is applied.
+	writeResourceFile(t, filepath.Join(dir, "res4.yaml"), `
+processor_resources:
+  - label: qux
+    bloblang: 'root = this'
+`, tStarted.Add(time.Second))
+
+	assert.Eventually(t, func() bool {
+		_, processors, _ := store.stored()
+		return len(processors) == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	caches, processors, rateLimits := store.stored()
+	assert.Equal(t, []string{"foo"}, caches)
+	assert.Equal(t, []string{"qux"}, processors)
+	assert.Equal(t, []string{"quz"}, rateLimits)
+
+	store.mut.Lock()
+	assert.Equal(t, 20, store.caches["foo"].Memory.TTL)
+	store.mut.Unlock()
+}
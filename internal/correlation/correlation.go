@@ -0,0 +1,113 @@
+// Package correlation stamps messages with generated IDs stored as metadata
+// in order to correlate them across logs and downstream systems without a
+// tracer backend.
+package correlation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// Config contains configuration fields for stamping messages with correlation
+// IDs.
+type Config struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Key     string `json:"key" yaml:"key"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled: false,
+		Key:     "correlation_id",
+	}
+}
+
+// Spec returns the field specs of correlation ID config.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether messages should be stamped with a correlation ID as they are read by the input.").HasDefault(false),
+		docs.FieldString("key", "The metadata key to store correlation IDs under. Messages that already have a value for this key keep it.").HasDefault("correlation_id"),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type keyCtxType int
+
+// keyCtx is the context key under which the metadata key of correlation IDs is
+// attached to message parts, which allows components to find the ID of a part
+// without knowing the config of the stream it belongs to.
+const keyCtx keyCtxType = 0
+
+// Stamp returns a shallow copy of a message where each part without a value
+// for the metadata key is given a new ULID.
+func Stamp(key string, msg types.Message) (types.Message, error) {
+	newMsg := msg.Copy()
+	parts := make([]types.Part, 0, newMsg.Len())
+	if err := newMsg.Iter(func(i int, p types.Part) error {
+		if p.Metadata().Get(key) == "" {
+			id, err := query.NewULID()
+			if err != nil {
+				return err
+			}
+			p.Metadata().Set(key, id)
+		}
+		ctx := context.WithValue(message.GetContext(p), keyCtx, key)
+		parts = append(parts, message.WithContext(ctx, p))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	newMsg.SetAll(parts)
+	return newMsg, nil
+}
+
+// Get returns the metadata key and correlation ID of a message part, or empty
+// strings if the part was not stamped or the ID has since been removed.
+func Get(p types.Part) (key, id string) {
+	key, _ = message.GetContext(p).Value(keyCtx).(string)
+	if key == "" {
+		return "", ""
+	}
+	if id = p.Metadata().Get(key); id == "" {
+		return "", ""
+	}
+	return key, id
+}
+
+// Fields returns log fields containing the correlation IDs of a message, where
+// the IDs of a batch are joined by commas. Returns nil if no parts of the
+// message have a correlation ID.
+func Fields(msg types.Message) map[string]string {
+	var key string
+	var ids []string
+	msg.Iter(func(i int, p types.Part) error {
+		k, id := Get(p)
+		if id == "" {
+			return nil
+		}
+		key = k
+		ids = append(ids, id)
+		return nil
+	})
+	if len(ids) == 0 {
+		return nil
+	}
+	return map[string]string{key: strings.Join(ids, ",")}
+}
+
+// WithFields returns a logger with the correlation IDs of a message added as
+// fields, or the logger unchanged if the message has none.
+func WithFields(l log.Modular, msg types.Message) log.Modular {
+	if fields := Fields(msg); fields != nil {
+		return log.WithFields(l, fields)
+	}
+	return l
+}
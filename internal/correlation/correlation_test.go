@@ -0,0 +1,56 @@
+package correlation
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStamp(t *testing.T) {
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(1).Metadata().Set("cid", "existing")
+
+	stamped, err := Stamp("cid", msg)
+	require.NoError(t, err)
+	require.Equal(t, 2, stamped.Len())
+
+	key, id := Get(stamped.Get(0))
+	assert.Equal(t, "cid", key)
+	assert.Len(t, id, 26)
+
+	key, id = Get(stamped.Get(1))
+	assert.Equal(t, "cid", key)
+	assert.Equal(t, "existing", id)
+
+	// The original message is not modified.
+	assert.Equal(t, "", msg.Get(0).Metadata().Get("cid"))
+
+	// The key is carried along with copies of the parts.
+	_, copiedID := Get(stamped.Copy().Get(0))
+	assert.Equal(t, stamped.Get(0).Metadata().Get("cid"), copiedID)
+
+	assert.Equal(t, map[string]string{
+		"cid": stamped.Get(0).Metadata().Get("cid") + ",existing",
+	}, Fields(stamped))
+}
+
+func TestStampUnique(t *testing.T) {
+	a, err := Stamp("cid", message.New([][]byte{[]byte("foo")}))
+	require.NoError(t, err)
+	b, err := Stamp("cid", message.New([][]byte{[]byte("foo")}))
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a.Get(0).Metadata().Get("cid"), b.Get(0).Metadata().Get("cid"))
+}
+
+func TestGetUnstamped(t *testing.T) {
+	msg := message.New([][]byte{[]byte("foo")})
+	msg.Get(0).Metadata().Set("correlation_id", "bar")
+
+	key, id := Get(msg.Get(0))
+	assert.Equal(t, "", key)
+	assert.Equal(t, "", id)
+	assert.Nil(t, Fields(msg))
+}
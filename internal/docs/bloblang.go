@@ -21,9 +21,13 @@ func LintBloblangMapping(ctx LintContext, line, col int, v interface{}) []Lint {
 	if str == "" {
 		return nil
 	}
-	_, err := bloblang.NewMapping("", str)
+	exec, err := bloblang.NewMapping("", str)
 	if err == nil {
-		return nil
+		var lints []Lint
+		for _, l := range exec.Lint() {
+			lints = append(lints, NewLintWarning(line+l.Line, l.What))
+		}
+		return lints
 	}
 	if mErr, ok := err.(*parser.Error); ok {
 		bline, bcol := parser.LineAndColOf([]rune(str), mErr.Input)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/interop/plugins"
 	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
 )
 
 const labelExpression = `^[a-z0-9_]+$`
@@ -61,6 +62,62 @@ var labelField = FieldString(
 	return nil
 })
 
+var cacheRetriesField = FieldAdvanced(
+	"retries", "Retry cache operations that fail due to transient errors such as timeouts or connection resets. Operations that fail because a key does not exist or already exists are never retried.",
+).WithChildren(
+	FieldInt("max_retries", "The maximum number of retries to attempt for a failed operation. Set to zero in order to disable retries.").HasDefault(0),
+	FieldAdvanced("backoff", "Control time intervals between retry attempts.").WithChildren(
+		FieldString("initial_interval", "The initial period to wait between retry attempts.").HasDefault("100ms"),
+		FieldString("max_interval", "The maximum period to wait between retry attempts.").HasDefault("1s"),
+		FieldString("max_elapsed_time", "The maximum period to wait before retry attempts are abandoned. If zero then no limit is used.").HasDefault("5s"),
+	),
+).OmitWhen(func(field, parent interface{}) (string, bool) {
+	gObj := gabs.Wrap(field)
+	switch t := gObj.S("max_retries").Data().(type) {
+	case int:
+		if t != 0 {
+			return "", false
+		}
+	case int64:
+		if t != 0 {
+			return "", false
+		}
+	case uint64:
+		if t != 0 {
+			return "", false
+		}
+	case float64:
+		if t != 0 {
+			return "", false
+		}
+	case nil:
+	default:
+		return "", false
+	}
+	return "field retries is disabled and can be removed", true
+}).AtVersion("3.50.0")
+
+var processorSharedField = FieldBool(
+	"shared", "When set on a processor resource all components that reference the resource process messages through it one at a time, instead of accessing it in parallel.",
+).OmitWhen(func(field, parent interface{}) (string, bool) {
+	if b, ok := field.(bool); ok && !b {
+		return "field shared is disabled and can be removed", true
+	}
+	return "", false
+}).Advanced().AtVersion("3.50.0")
+
+var enabledField = FieldBool(
+	"enabled", "Whether the component is enabled. Disabled components are skipped when a config is built, but their config is still linted.",
+).HasDefault(true).Advanced().AtVersion("3.50.0")
+
+// The enabled field isn't omitted with an OmitWhen func as an explicit value of
+// true (usually from an environment variable) shouldn't result in a lint error,
+// therefore the default value is omitted when sanitising only.
+func isEnabledDefault(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
 func reservedFieldsByType(t Type) map[string]FieldSpec {
 	m := map[string]FieldSpec{
 		"type":   FieldString("type", ""),
@@ -83,6 +140,20 @@ func reservedFieldsByType(t Type) map[string]FieldSpec {
 	}[t]; isLabelType {
 		m["label"] = labelField
 	}
+	if _, isEnabledType := map[Type]struct{}{
+		TypeProcessor: {},
+		TypeOutput:    {},
+		TypeCache:     {},
+		TypeRateLimit: {},
+	}[t]; isEnabledType {
+		m["enabled"] = enabledField
+	}
+	if t == TypeCache {
+		m["retries"] = cacheRetriesField
+	}
+	if t == TypeProcessor {
+		m["shared"] = processorSharedField
+	}
 	return m
 }
 
@@ -217,6 +288,9 @@ func SanitiseComponentConfig(componentType Type, raw interface{}, filter FieldFi
 		if _, omit := spec.shouldOmit(v, m); omit {
 			delete(m, k)
 		}
+		if k == "enabled" && isEnabledDefault(v) {
+			delete(m, k)
+		}
 	}
 
 	for name, fieldSpec := range reservedFields {
@@ -233,6 +307,13 @@ type SanitiseConfig struct {
 	ForExample       bool
 	Filter           FieldFilter
 	DocsProvider     Provider
+
+	// ExpandComponent is an optional function that replaces the config of a
+	// component, such as a template, with the config it expands into. The node
+	// provided contains only the field of the component name and any reserved
+	// fields (label, processors, etc). The function returns nil when the
+	// component is not expanded.
+	ExpandComponent func(cType Type, name string, node *yaml.Node) (*yaml.Node, error)
 }
 
 // GetDocs attempts to obtain documentation for a component implementation from
@@ -259,6 +259,25 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		}
 	}
 
+	if conf.ExpandComponent != nil {
+		reservedFields := reservedFieldsByType(cType)
+		componentNode := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i].Value
+			if _, isReserved := reservedFields[key]; key == name || isReserved {
+				componentNode.Content = append(componentNode.Content, node.Content[i], node.Content[i+1])
+			}
+		}
+		expanded, err := conf.ExpandComponent(cType, name, componentNode)
+		if err != nil {
+			return err
+		}
+		if expanded != nil {
+			*node = *unwrapDocumentNode(expanded)
+			return SanitiseYAML(cType, node, conf)
+		}
+	}
+
 	cSpec, exists := conf.GetDocs(name, cType)
 	if !exists {
 		return fmt.Errorf("failed to obtain docs for %v type %v", cType, name)
@@ -308,6 +327,12 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 			if _, omit := spec.shouldOmitYAML(nil, node.Content[i+1], node); omit {
 				continue
 			}
+			if node.Content[i].Value == "enabled" {
+				var enabled interface{}
+				if err := node.Content[i+1].Decode(&enabled); err == nil && isEnabledDefault(enabled) {
+					continue
+				}
+			}
 			if err := spec.SanitiseYAML(node.Content[i+1], conf); err != nil {
 				return err
 			}
@@ -785,19 +810,19 @@ func (f FieldSpec) YAMLToValue(node *yaml.Node, conf ToValueConfig) (interface{}
 	case FieldTypeInt:
 		var i int
 		if err := node.Decode(&i); err != nil {
-			return nil, err
+			return passiveScalarFallback(node, conf, err)
 		}
 		return i, nil
 	case FieldTypeFloat:
 		var f float64
 		if err := node.Decode(&f); err != nil {
-			return nil, err
+			return passiveScalarFallback(node, conf, err)
 		}
 		return f, nil
 	case FieldTypeBool:
 		var b bool
 		if err := node.Decode(&b); err != nil {
-			return nil, err
+			return passiveScalarFallback(node, conf, err)
 		}
 		return b, nil
 	case FieldTypeObject:
@@ -817,6 +842,20 @@ func (f FieldSpec) YAMLToValue(node *yaml.Node, conf ToValueConfig) (interface{}
 	return node, nil
 }
 
+// When a scalar field fails to decode into its documented type and we're in
+// passive mode then the value is decoded as it is, which allows custom linters
+// to inspect and report the mismatched type.
+func passiveScalarFallback(node *yaml.Node, conf ToValueConfig, err error) (interface{}, error) {
+	if !conf.Passive {
+		return nil, err
+	}
+	var v interface{}
+	if derr := node.Decode(&v); derr != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // YAMLToMap converts a yaml node into a generic map structure by referencing
 // expected fields, adding default values to the map when the node does not
 // contain them.
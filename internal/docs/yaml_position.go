@@ -0,0 +1,169 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrorAtNode returns an error for a config node that is suitable for
+// returning from an UnmarshalYAML method. The error is reported at the line of
+// the node along with any other errors found within the same document, rather
+// than aborting the decoding of the document.
+func ErrorAtNode(node *yaml.Node, err error) error {
+	var tErr *yaml.TypeError
+	if errors.As(err, &tErr) {
+		return tErr
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "line ") {
+		msg = fmt.Sprintf("line %v: %v", node.Line, msg)
+	}
+	return &yaml.TypeError{Errors: []string{msg}}
+}
+
+//------------------------------------------------------------------------------
+
+// PositionError describes a problem found at a position within a YAML
+// document, including the path of the node from the root of the document.
+type PositionError struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+// Error returns a human readable description of the error and its position.
+// Nodes that were not parsed from a document, such as those created from
+// overrides, have no position and therefore only the path is reported.
+func (p PositionError) Error() string {
+	var prefix string
+	if p.Line > 0 {
+		prefix = fmt.Sprintf("line %v: ", p.Line)
+		if p.Column > 0 {
+			prefix = fmt.Sprintf("line %v column %v: ", p.Line, p.Column)
+		}
+	}
+	if p.Path != "" {
+		prefix += p.Path + ": "
+	}
+	return prefix + p.Message
+}
+
+// PositionErrors is a list of problems found within a YAML document.
+type PositionErrors []PositionError
+
+// Error returns a human readable description of each error, one per line.
+func (p PositionErrors) Error() string {
+	errStrs := make([]string, len(p))
+	for i, e := range p {
+		errStrs[i] = e.Error()
+	}
+	return strings.Join(errStrs, "\n")
+}
+
+var (
+	yamlErrLineRegexp      = regexp.MustCompile("^line ([0-9]+): (?s)(.*)$")
+	yamlErrUnmarshalRegexp = regexp.MustCompile("^cannot unmarshal (![^ ]+)(?: `([^`]*)`)? into")
+)
+
+// PositionErrorsFromYAML converts the errors returned when decoding a YAML
+// document into PositionErrors, where the column and path of each error are
+// resolved from the node reported at its line. Errors that are not reported at
+// a line are returned unchanged.
+func PositionErrorsFromYAML(root *yaml.Node, err error) error {
+	var tErr *yaml.TypeError
+	if !errors.As(err, &tErr) {
+		return err
+	}
+
+	pErrs := make(PositionErrors, 0, len(tErr.Errors))
+	for _, e := range tErr.Errors {
+		matches := yamlErrLineRegexp.FindStringSubmatch(e)
+		if matches == nil {
+			return err
+		}
+		line, _ := strconv.Atoi(matches[1])
+		pErr := PositionError{
+			Line:    line,
+			Message: matches[2],
+		}
+
+		var tag, value string
+		if uMatches := yamlErrUnmarshalRegexp.FindStringSubmatch(pErr.Message); uMatches != nil {
+			tag, value = uMatches[1], uMatches[2]
+		}
+		if path, node := findYAMLNodeAtLine(root, line, tag, value); node != nil {
+			pErr.Column = node.Column
+			pErr.Path = path
+		}
+		pErrs = append(pErrs, pErr)
+	}
+	return pErrs
+}
+
+// findYAMLNodeAtLine walks a YAML document breadth first and returns the path
+// and node of the shallowest value that begins at a line. When a tag is
+// provided only nodes that match it are considered and the deepest match is
+// returned instead, as that is the value that failed to decode. When a value is
+// provided it must also match, where a value ending with an ellipsis is matched
+// as a prefix.
+func findYAMLNodeAtLine(root *yaml.Node, line int, tag, value string) (string, *yaml.Node) {
+	type pathNode struct {
+		path []string
+		node *yaml.Node
+	}
+
+	valuePrefix := strings.TrimSuffix(value, "...")
+	matches := func(n *yaml.Node) bool {
+		if n.Line != line {
+			return false
+		}
+		if tag != "" && n.ShortTag() != tag {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		if valuePrefix != value {
+			return strings.HasPrefix(n.Value, valuePrefix)
+		}
+		return n.Value == value
+	}
+
+	var match *pathNode
+	queue := []pathNode{{node: unwrapDocumentNode(root)}}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if next.node == nil {
+			continue
+		}
+		if matches(next.node) {
+			if tag == "" {
+				return strings.Join(next.path, "."), next.node
+			}
+			match = &next
+		}
+		switch next.node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i < len(next.node.Content)-1; i += 2 {
+				path := append(append([]string{}, next.path...), next.node.Content[i].Value)
+				queue = append(queue, pathNode{path: path, node: next.node.Content[i+1]})
+			}
+		case yaml.SequenceNode:
+			for i, child := range next.node.Content {
+				path := append(append([]string{}, next.path...), strconv.Itoa(i))
+				queue = append(queue, pathNode{path: path, node: child})
+			}
+		}
+	}
+	if match != nil {
+		return strings.Join(match.path, "."), match.node
+	}
+	return "", nil
+}
@@ -0,0 +1,89 @@
+package docs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type positionTestChild struct {
+	Name string `yaml:"name"`
+}
+
+type positionTestCustom struct{}
+
+func (p *positionTestCustom) UnmarshalYAML(value *yaml.Node) error {
+	return docs.ErrorAtNode(value, errors.New("custom failure"))
+}
+
+type positionTestConfig struct {
+	Children []positionTestChild `yaml:"children"`
+	Custom   *positionTestCustom `yaml:"custom"`
+	Count    int                 `yaml:"count"`
+}
+
+func TestPositionErrorsFromYAML(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errStr string
+	}{
+		{
+			name: "nested flow map",
+			input: `
+children:
+  - name: foo
+  - name: { a: b }
+`,
+			errStr: "line 4 column 11: children.1.name: cannot unmarshal !!map into string",
+		},
+		{
+			name: "scalar value",
+			input: `
+count: nope
+`,
+			errStr: "line 2 column 8: count: cannot unmarshal !!str `nope` into int",
+		},
+		{
+			name: "custom error",
+			input: `
+children: []
+custom:
+  foo: bar
+`,
+			errStr: "line 4 column 3: custom: custom failure",
+		},
+		{
+			name: "multiple errors",
+			input: `
+count: [ 1 ]
+children:
+  - name: [ 2 ]
+`,
+			errStr: "line 2 column 8: count: cannot unmarshal !!seq into int\nline 4 column 11: children.0.name: cannot unmarshal !!seq into string",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			var conf positionTestConfig
+			err := docs.PositionErrorsFromYAML(&node, node.Decode(&conf))
+			require.Error(t, err)
+			assert.EqualError(t, err, test.errStr)
+		})
+	}
+}
+
+func TestPositionErrorsFromYAMLPassthrough(t *testing.T) {
+	err := errors.New("not a yaml error")
+	assert.Equal(t, err, docs.PositionErrorsFromYAML(&yaml.Node{}, err))
+	assert.NoError(t, docs.PositionErrorsFromYAML(&yaml.Node{}, nil))
+}
@@ -1186,3 +1186,54 @@ processors:
 		})
 	}
 }
+
+func TestYAMLSanitationExpandComponent(t *testing.T) {
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name: "testyamlsanitexpandtarget",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldCommon("target1", ""),
+		),
+	})
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`type: testyamlsanitexpandsource
+testyamlsanitexpandsource:
+    source1: foo
+someotherinput:
+    ignore: me please
+processors: []
+`), &node))
+
+	var seen string
+	err := docs.SanitiseYAML(docs.TypeInput, node.Content[0], docs.SanitiseConfig{
+		RemoveTypeField: true,
+		ExpandComponent: func(cType docs.Type, name string, node *yaml.Node) (*yaml.Node, error) {
+			if name != "testyamlsanitexpandsource" {
+				return nil, nil
+			}
+			nodeBytes, err := yaml.Marshal(node)
+			require.NoError(t, err)
+			seen = string(nodeBytes)
+
+			var expanded yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(`testyamlsanitexpandtarget:
+    target1: bar
+`), &expanded))
+			return &expanded, nil
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `type: testyamlsanitexpandsource
+testyamlsanitexpandsource:
+    source1: foo
+processors: []
+`, seen)
+
+	resBytes, err := yaml.Marshal(node.Content[0])
+	require.NoError(t, err)
+	assert.Equal(t, `testyamlsanitexpandtarget:
+    target1: bar
+`, string(resBytes))
+}
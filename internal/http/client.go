@@ -10,7 +10,6 @@ import (
 	"net"
 	"net/http"
 	"net/textproto"
-	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,7 +22,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	httputil "github.com/Jeffail/benthos/v3/lib/util/http"
 	"github.com/Jeffail/benthos/v3/lib/util/http/client"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
 	"github.com/opentracing/opentracing-go"
 	olog "github.com/opentracing/opentracing-go/log"
@@ -52,6 +53,7 @@ type Client struct {
 	mErr           metrics.StatCounter
 	mErrReq        metrics.StatCounter
 	mErrReqTimeout metrics.StatCounter
+	mErrProxy      metrics.StatCounter
 	mErrRes        metrics.StatCounter
 	mLimited       metrics.StatCounter
 	mLimitFor      metrics.StatCounter
@@ -64,6 +66,8 @@ type Client struct {
 
 	oauthClientCtx    context.Context
 	oauthClientCancel func()
+
+	releaseTransport func()
 }
 
 // NewClient creates a new http client that sends and receives Benthos messages.
@@ -85,6 +89,10 @@ func NewClient(conf client.Config, opts ...func(*Client)) (*Client, error) {
 		headers:   map[string]*field.Expression{},
 		host:      nil,
 	}
+	if err = httputil.ValidateCompression(conf.Compression); err != nil {
+		return nil, err
+	}
+
 	h.oauthClientCtx, h.oauthClientCancel = context.WithCancel(context.Background())
 	h.client = conf.OAuth2.Client(h.oauthClientCtx)
 
@@ -95,41 +103,22 @@ func NewClient(conf client.Config, opts ...func(*Client)) (*Client, error) {
 		}
 	}
 
-	if h.conf.TLS.Enabled {
-		tlsConf, err := h.conf.TLS.Get()
-		if err != nil {
-			return nil, err
-		}
-		if tlsConf != nil {
-			if c, ok := http.DefaultTransport.(*http.Transport); ok {
-				cloned := c.Clone()
-				cloned.TLSClientConfig = tlsConf
-				h.client.Transport = cloned
-			} else {
-				h.client.Transport = &http.Transport{
-					TLSClientConfig: tlsConf,
-				}
-			}
-		}
+	tr, releaseTransport, err := client.SharedTransport(h.conf.TLS, h.conf.Proxy())
+	if err != nil {
+		return nil, err
+	}
+	if tr != nil {
+		h.client.Transport = tr
 	}
+	h.releaseTransport = releaseTransport
 
-	if h.conf.ProxyURL != "" {
-		proxyURL, err := url.Parse(h.conf.ProxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
-		}
-		if h.client.Transport != nil {
-			if tr, ok := h.client.Transport.(*http.Transport); ok {
-				tr.Proxy = http.ProxyURL(proxyURL)
-			} else {
-				return nil, fmt.Errorf("unable to apply proxy_url to transport, unexpected type %T", h.client.Transport)
-			}
-		} else {
-			h.client.Transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
+	// The transport is released if the client fails to build.
+	built := false
+	defer func() {
+		if !built {
+			releaseTransport()
 		}
-	}
+	}()
 
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
@@ -161,6 +150,7 @@ func NewClient(conf client.Config, opts ...func(*Client)) (*Client, error) {
 	h.mErr = h.stats.GetCounter("error")
 	h.mErrReq = h.stats.GetCounter("error.request")
 	h.mErrReqTimeout = h.stats.GetCounter("request_timeout")
+	h.mErrProxy = h.stats.GetCounter("error.proxy")
 	h.mErrRes = h.stats.GetCounter("error.response")
 	h.mLimited = h.stats.GetCounter("rate_limit.count")
 	h.mLimitFor = h.stats.GetCounter("rate_limit.total_ms")
@@ -195,6 +185,7 @@ func NewClient(conf client.Config, opts ...func(*Client)) (*Client, error) {
 		throttle.OptMaxExponentPeriod(maxBackoff),
 	)
 
+	built = true
 	return &h, nil
 }
 
@@ -231,6 +222,17 @@ func OptSetRoundTripper(rt http.RoundTripper) func(*Client) {
 
 //------------------------------------------------------------------------------
 
+// incrErrType increments the metric of a request error that occurred before a
+// response was received, where failures of a proxy are counted separately
+// from timeouts.
+func (h *Client) incrErrType(err error) {
+	if proxy.IsError(err) {
+		h.mErrProxy.Incr(1)
+	} else if nErr, ok := err.(net.Error); ok && nErr.Timeout() {
+		h.mErrReqTimeout.Incr(1)
+	}
+}
+
 func (h *Client) incrCode(code int) {
 	h.codesMut.RLock()
 	ctr, exists := h.mCodes[code]
@@ -287,10 +289,11 @@ func (h *Client) waitForAccess(ctx context.Context) bool {
 func (h *Client) CreateRequest(sendMsg, refMsg types.Message) (req *http.Request, err error) {
 	var overrideContentType string
 	var body io.Reader
+	var bodyBytes []byte
 
 	if sendMsg != nil && sendMsg.Len() == 1 {
-		if msgBytes := sendMsg.Get(0).Get(); len(msgBytes) > 0 {
-			body = bytes.NewBuffer(msgBytes)
+		if bodyBytes = sendMsg.Get(0).Get(); len(bodyBytes) > 0 {
+			body = bytes.NewBuffer(bodyBytes)
 		}
 	} else if sendMsg != nil && sendMsg.Len() > 1 {
 		buf := &bytes.Buffer{}
@@ -315,6 +318,7 @@ func (h *Client) CreateRequest(sendMsg, refMsg types.Message) (req *http.Request
 		writer.Close()
 		overrideContentType = writer.FormDataContentType()
 
+		bodyBytes = buf.Bytes()
 		body = buf
 	}
 
@@ -333,6 +337,11 @@ func (h *Client) CreateRequest(sendMsg, refMsg types.Message) (req *http.Request
 		req.Header.Del("Content-Type")
 		req.Header.Add("Content-Type", overrideContentType)
 	}
+	if len(bodyBytes) > 0 {
+		if _, err = client.CompressRequestBody(h.conf.Compression, req, bodyBytes); err != nil {
+			return
+		}
+	}
 
 	err = h.conf.Config.Sign(req)
 	return
@@ -505,9 +514,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg types.Messa
 
 	res, err = h.client.Do(req.WithContext(ctx))
 	if err != nil {
-		if err, ok := err.(net.Error); ok && err.Timeout() {
-			h.mErrReqTimeout.Incr(1)
-		}
+		h.incrErrType(err)
 	} else {
 		h.incrCode(res.StatusCode)
 		if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
@@ -515,10 +522,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg types.Messa
 			if retryStrat == noRetry {
 				numRetries = 0
 			}
-			err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-			if res.Body != nil {
-				res.Body.Close()
-			}
+			err = client.UnexpectedResponse(res, h.conf.ErrorBodyLimit)
 		}
 	}
 
@@ -548,13 +552,10 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg types.Messa
 				if retryStrat == noRetry {
 					j = 0
 				}
-				err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-				if res.Body != nil {
-					res.Body.Close()
-				}
+				err = client.UnexpectedResponse(res, h.conf.ErrorBodyLimit)
 			}
-		} else if err, ok := err.(net.Error); ok && err.Timeout() {
-			h.mErrReqTimeout.Incr(1)
+		} else {
+			h.incrErrType(err)
 		}
 		i++
 	}
@@ -587,5 +588,6 @@ func (h *Client) Send(ctx context.Context, sendMsg, refMsg types.Message) (types
 // Close the client.
 func (h *Client) Close(ctx context.Context) error {
 	h.oauthClientCancel()
+	h.releaseTransport()
 	return nil
 }
@@ -0,0 +1,93 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnixPrefix is the prefix of addresses that refer to a unix domain socket.
+const UnixPrefix = "unix://"
+
+// IsUnix returns true if an address refers to a unix domain socket.
+func IsUnix(address string) bool {
+	return strings.HasPrefix(address, UnixPrefix)
+}
+
+// ParseFileMode parses an octal file mode string such as `0660`, an empty
+// string results in a zero mode.
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse file mode '%v': %w", mode, err)
+	}
+	return os.FileMode(m), nil
+}
+
+// Config describes how a listener should be created.
+type Config struct {
+	// Address is either a TCP address or a path to a unix domain socket
+	// prefixed with unix://.
+	Address string
+
+	// SocketMode sets the permissions of a unix domain socket file when
+	// non-zero.
+	SocketMode os.FileMode
+
+	// ProxyProtocol indicates that connections begin with a PROXY protocol v1
+	// or v2 header, which is used as the remote address of the connection.
+	ProxyProtocol bool
+}
+
+// Listen creates a listener from a config. When the address refers to a unix
+// domain socket a stale socket file left behind by a previous process is
+// removed, and the socket file is removed again when the listener is closed.
+func Listen(conf Config) (net.Listener, error) {
+	var ln net.Listener
+	var err error
+	if IsUnix(conf.Address) {
+		if ln, err = listenUnix(strings.TrimPrefix(conf.Address, UnixPrefix), conf.SocketMode); err != nil {
+			return nil, err
+		}
+	} else if ln, err = net.Listen("tcp", conf.Address); err != nil {
+		return nil, err
+	}
+	if conf.ProxyProtocol {
+		ln = &proxyListener{Listener: ln}
+	}
+	return ln, nil
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, derr := net.DialTimeout("unix", path, time.Second); derr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket %v is already in use", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket %v: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(true)
+	if mode != 0 {
+		if err = os.Chmod(path, mode); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to set permissions of unix socket %v: %w", path, err)
+		}
+	}
+	return ln, nil
+}
@@ -0,0 +1,102 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProxyHeaderV1(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nGET / HTTP/1.1\r\n"))
+	addr, err := readProxyHeader(r)
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.0.1:56324", addr.String())
+
+	rest, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "GET / HTTP/1.1\r\n", string(rest))
+
+	addr, err = readProxyHeader(bufio.NewReader(strings.NewReader("PROXY UNKNOWN\r\n")))
+	require.NoError(t, err)
+	assert.Nil(t, addr)
+
+	_, err = readProxyHeader(bufio.NewReader(strings.NewReader("GET / HTTP/1.1\r\n")))
+	assert.Equal(t, ErrNoProxyHeader, err)
+}
+
+func TestReadProxyHeaderV2(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(proxyV2Signature)
+	buf.WriteByte(0x21)
+	buf.WriteByte(0x11)
+	lenBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(lenBytes, 12)
+	buf.Write(lenBytes)
+	buf.Write(net.ParseIP("10.0.0.5").To4())
+	buf.Write(net.ParseIP("10.0.0.6").To4())
+	portBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBytes, 4321)
+	buf.Write(portBytes)
+	binary.BigEndian.PutUint16(portBytes, 80)
+	buf.Write(portBytes)
+	buf.WriteString("hello")
+
+	r := bufio.NewReader(&buf)
+	addr, err := readProxyHeader(r)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.5:4321", addr.String())
+
+	rest, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(rest))
+}
+
+func TestListenUnixProxyProtocol(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benthos.sock")
+
+	// A stale socket file should be cleaned up.
+	require.NoError(t, ioutil.WriteFile(path, nil, 0o600))
+
+	ln, err := Listen(Config{
+		Address:       UnixPrefix + path,
+		SocketMode:    0o660,
+		ProxyProtocol: true,
+	})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.RemoteAddr))
+	})}
+	go server.Serve(ln)
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("PROXY TCP4 1.2.3.4 5.6.7.8 1111 80\r\nGET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	require.NoError(t, err)
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.4:1111", string(body))
+	conn.Close()
+
+	require.NoError(t, server.Close())
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,181 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoProxyHeader is returned when a connection does not begin with a PROXY
+// protocol header.
+var ErrNoProxyHeader = errors.New("connection does not begin with a PROXY protocol header")
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+const proxyHeaderTimeout = time.Second * 5
+
+type proxyListener struct {
+	net.Listener
+}
+
+func (p *proxyListener) Accept() (net.Conn, error) {
+	conn, err := p.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}, nil
+}
+
+// proxyConn parses a PROXY protocol header the first time the connection is
+// read from or its remote address is requested. Parsing is deferred until then
+// so that a slow client does not block the accept loop.
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+func (p *proxyConn) init() {
+	p.once.Do(func() {
+		_ = p.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+		p.remoteAddr, p.err = readProxyHeader(p.reader)
+		_ = p.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (p *proxyConn) Read(b []byte) (int, error) {
+	p.init()
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.reader.Read(b)
+}
+
+func (p *proxyConn) RemoteAddr() net.Addr {
+	p.init()
+	if p.remoteAddr != nil {
+		return p.remoteAddr
+	}
+	return p.Conn.RemoteAddr()
+}
+
+//------------------------------------------------------------------------------
+
+// readProxyHeader consumes a PROXY protocol v1 or v2 header from a reader and
+// returns the source address it describes. A nil address is returned when the
+// header does not describe a source, e.g. health checks from the proxy itself.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	sig, err := r.Peek(len(proxyV2Signature))
+	if err == nil && bytes.Equal(sig, proxyV2Signature) {
+		return readProxyHeaderV2(r)
+	}
+	if len(sig) >= 6 && string(sig[:6]) == "PROXY " {
+		return readProxyHeaderV1(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNoProxyHeader
+}
+
+func readProxyHeaderV1(r *bufio.Reader) (net.Addr, error) {
+	// The maximum length of a v1 header is 107 bytes including the CRLF.
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("PROXY protocol v1 header exceeds maximum length")
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) < 2 {
+		return nil, errors.New("malformed PROXY protocol v1 header")
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol v1 family: %v", fields[1])
+	}
+	if len(fields) != 6 {
+		return nil, errors.New("malformed PROXY protocol v1 header")
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 source address: %v", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 source port: %v", fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyHeaderV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if version := header[12] >> 4; version != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version: %v", version)
+	}
+	command := header[12] & 0x0f
+	family := header[13]
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	// A LOCAL command indicates the connection was established by the proxy
+	// itself and therefore the real remote address should be used.
+	if command == 0x0 {
+		return nil, nil
+	}
+	if command != 0x1 {
+		return nil, fmt.Errorf("unsupported PROXY protocol v2 command: %v", command)
+	}
+
+	switch family >> 4 {
+	case 0x1:
+		if len(payload) < 12 {
+			return nil, errors.New("malformed PROXY protocol v2 IPv4 addresses")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x2:
+		if len(payload) < 36 {
+			return nil, errors.New("malformed PROXY protocol v2 IPv6 addresses")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	}
+	return nil, nil
+}
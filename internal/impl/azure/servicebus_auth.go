@@ -0,0 +1,407 @@
+package azure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	sbDomainSuffix       = ".servicebus.windows.net"
+	sbResourceURI        = "https://servicebus.azure.net/"
+	sbCBSAddress         = "$cbs"
+	sbTokenTypeSAS       = "servicebus.windows.net:sastoken"
+	sbTokenTypeJWT       = "jwt"
+	sbSASTokenTTL        = time.Hour
+	sbTokenRefreshWithin = time.Minute * 5
+)
+
+// serviceBusConnString contains the fields of a Service Bus connection string.
+type serviceBusConnString struct {
+	host                string
+	keyName             string
+	key                 string
+	signature           string
+	entityPath          string
+	signatureExpiration time.Time
+}
+
+// parseServiceBusConnectionString extracts the namespace host, credentials and
+// optional entity path from a Service Bus connection string.
+func parseServiceBusConnectionString(input string) (c serviceBusConnString, err error) {
+	parts := map[string]string{}
+	for _, pair := range strings.Split(input, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		equalDex := strings.IndexByte(pair, '=')
+		if equalDex <= 0 {
+			return c, fmt.Errorf("invalid connection segment %q", pair)
+		}
+		parts[strings.ToLower(strings.TrimSpace(pair[:equalDex]))] = strings.TrimSpace(pair[equalDex+1:])
+	}
+
+	endpoint, exists := parts["endpoint"]
+	if !exists {
+		return c, errors.New("connection string is missing an endpoint")
+	}
+	var u *url.URL
+	if u, err = url.Parse(endpoint); err != nil {
+		return c, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+	if c.host = u.Host; c.host == "" {
+		return c, fmt.Errorf("endpoint %q does not contain a host", endpoint)
+	}
+
+	c.keyName = parts["sharedaccesskeyname"]
+	c.key = parts["sharedaccesskey"]
+	c.signature = parts["sharedaccesssignature"]
+	c.entityPath = parts["entitypath"]
+
+	if c.signature != "" {
+		if c.signatureExpiration, err = sasExpiration(c.signature); err != nil {
+			return c, err
+		}
+	} else if c.keyName == "" || c.key == "" {
+		return c, errors.New("connection string must contain either a shared access key name and key, or a shared access signature")
+	}
+	return c, nil
+}
+
+// sasExpiration extracts the expiry time of a shared access signature.
+func sasExpiration(signature string) (time.Time, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(signature, "SharedAccessSignature "))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse shared access signature: %w", err)
+	}
+	se, err := strconv.ParseInt(values.Get("se"), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse shared access signature expiry: %w", err)
+	}
+	return time.Unix(se, 0), nil
+}
+
+// serviceBusNamespaceHost returns the fully qualified host of a namespace,
+// which can be given either as a name or a host.
+func serviceBusNamespaceHost(namespace string) string {
+	if strings.Contains(namespace, ".") {
+		return namespace
+	}
+	return namespace + sbDomainSuffix
+}
+
+//------------------------------------------------------------------------------
+
+// serviceBusToken is a token used to authorise access to an entity.
+type serviceBusToken struct {
+	tokenType string
+	value     string
+	expires   time.Time
+}
+
+// serviceBusTokenProvider provides tokens for authorising access to entities.
+type serviceBusTokenProvider interface {
+	getToken(ctx context.Context, audience string) (serviceBusToken, error)
+}
+
+// sasKeyProvider signs tokens with a shared access key.
+type sasKeyProvider struct {
+	keyName string
+	key     string
+	ttl     time.Duration
+}
+
+func (s sasKeyProvider) getToken(ctx context.Context, audience string) (serviceBusToken, error) {
+	expires := time.Now().Add(s.ttl)
+	return serviceBusToken{
+		tokenType: sbTokenTypeSAS,
+		value:     signSAS(s.keyName, s.key, audience, expires),
+		expires:   expires,
+	}, nil
+}
+
+// signSAS creates a shared access signature for a resource that is valid until
+// an expiry time.
+func signSAS(keyName, key, resource string, expires time.Time) string {
+	encodedResource := url.QueryEscape(resource)
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write([]byte(encodedResource + "\n" + expiry))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return fmt.Sprintf(
+		"SharedAccessSignature sr=%v&sig=%v&se=%v&skn=%v",
+		encodedResource, url.QueryEscape(signature), expiry, url.QueryEscape(keyName),
+	)
+}
+
+// sasStaticProvider provides a shared access signature that was generated
+// elsewhere and can't be renewed.
+type sasStaticProvider struct {
+	signature string
+	expires   time.Time
+}
+
+func (s sasStaticProvider) getToken(ctx context.Context, audience string) (serviceBusToken, error) {
+	if time.Now().After(s.expires) {
+		return serviceBusToken{}, errors.New("shared access signature has expired")
+	}
+	return serviceBusToken{
+		tokenType: sbTokenTypeSAS,
+		value:     s.signature,
+		expires:   s.expires,
+	}, nil
+}
+
+// managedIdentityProvider obtains Azure Active Directory tokens for a managed
+// identity.
+type managedIdentityProvider struct {
+	spt *adal.ServicePrincipalToken
+}
+
+func newManagedIdentityProvider(clientID string) (*managedIdentityProvider, error) {
+	endpoint, err := adal.GetMSIEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain managed identity endpoint: %w", err)
+	}
+	var spt *adal.ServicePrincipalToken
+	if clientID != "" {
+		spt, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(endpoint, sbResourceURI, clientID)
+	} else {
+		spt, err = adal.NewServicePrincipalTokenFromMSI(endpoint, sbResourceURI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create managed identity token: %w", err)
+	}
+	return &managedIdentityProvider{spt: spt}, nil
+}
+
+func (m *managedIdentityProvider) getToken(ctx context.Context, audience string) (serviceBusToken, error) {
+	if err := m.spt.EnsureFreshWithContext(ctx); err != nil {
+		return serviceBusToken{}, fmt.Errorf("failed to refresh managed identity token: %w", err)
+	}
+	token := m.spt.Token()
+	return serviceBusToken{
+		tokenType: sbTokenTypeJWT,
+		value:     token.OAuthToken(),
+		expires:   token.Expires(),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ServiceBusAuth contains the namespace and credentials used to connect to
+// Azure Service Bus.
+type ServiceBusAuth struct {
+	// Host is the fully qualified host of the namespace.
+	Host string
+
+	// EntityPath is the queue or topic specified by the connection string,
+	// which is empty if none was specified.
+	EntityPath string
+
+	provider serviceBusTokenProvider
+}
+
+// NewServiceBusAuth creates Service Bus credentials from either a connection
+// string or, when the connection string is empty, a namespace that is accessed
+// with a managed identity. The client ID of a user assigned managed identity is
+// optional.
+func NewServiceBusAuth(connectionString, namespace, managedIdentityClientID string) (*ServiceBusAuth, error) {
+	if connectionString != "" {
+		c, err := parseServiceBusConnectionString(connectionString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse connection string: %w", err)
+		}
+		a := &ServiceBusAuth{Host: c.host, EntityPath: c.entityPath}
+		if c.signature != "" {
+			a.provider = sasStaticProvider{signature: c.signature, expires: c.signatureExpiration}
+		} else {
+			a.provider = sasKeyProvider{keyName: c.keyName, key: c.key, ttl: sbSASTokenTTL}
+		}
+		return a, nil
+	}
+	if namespace == "" {
+		return nil, errors.New("either a connection string or a namespace must be specified")
+	}
+	provider, err := newManagedIdentityProvider(managedIdentityClientID)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceBusAuth{Host: serviceBusNamespaceHost(namespace), provider: provider}, nil
+}
+
+// Dial opens a connection to the namespace, where access to entities must then
+// be authorised with a ServiceBusCBS.
+func (a *ServiceBusAuth) Dial() (*amqp.Client, error) {
+	return amqp.Dial("amqps://"+a.Host, amqp.ConnSASLAnonymous(), amqp.ConnServerHostname(a.Host))
+}
+
+// NewCBS creates a ServiceBusCBS for authorising access to entities over a
+// session of a connection opened with Dial.
+func (a *ServiceBusAuth) NewCBS(session *amqp.Session, log log.Modular) *ServiceBusCBS {
+	c := &ServiceBusCBS{
+		host:      a.Host,
+		session:   session,
+		provider:  a.provider,
+		log:       log,
+		expiries:  map[string]time.Time{},
+		closeChan: make(chan struct{}),
+	}
+	go c.refreshLoop()
+	return c
+}
+
+//------------------------------------------------------------------------------
+
+// ServiceBusCBS authorises access to the entities of a connection using
+// claims-based security, where a token is put to the CBS node of the
+// connection for each entity and is renewed before it expires.
+type ServiceBusCBS struct {
+	host     string
+	session  *amqp.Session
+	provider serviceBusTokenProvider
+	log      log.Modular
+
+	mut      sync.Mutex
+	expiries map[string]time.Time
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func (c *ServiceBusCBS) audience(entity string) string {
+	return "amqp://" + c.host + "/" + entity
+}
+
+// Authorise puts a token for an entity, which is then renewed automatically
+// until Close is called.
+func (c *ServiceBusCBS) Authorise(ctx context.Context, entity string) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.putTokenLocked(ctx, entity)
+}
+
+func (c *ServiceBusCBS) putTokenLocked(ctx context.Context, entity string) error {
+	audience := c.audience(entity)
+	token, err := c.provider.getToken(ctx, audience)
+	if err != nil {
+		return err
+	}
+	if err = putToken(ctx, c.session, audience, token); err != nil {
+		return fmt.Errorf("failed to authorise entity '%v': %w", entity, err)
+	}
+	c.expiries[entity] = token.expires
+	return nil
+}
+
+func (c *ServiceBusCBS) refreshLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closeChan:
+			return
+		}
+
+		c.mut.Lock()
+		for entity, expires := range c.expiries {
+			if time.Until(expires) > sbTokenRefreshWithin {
+				continue
+			}
+			ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+			if err := c.putTokenLocked(ctx, entity); err != nil {
+				c.log.Errorf("Failed to renew token: %v\n", err)
+			}
+			done()
+		}
+		c.mut.Unlock()
+	}
+}
+
+// Close stops renewing the tokens of authorised entities.
+func (c *ServiceBusCBS) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+}
+
+// putToken sends a token to the CBS node of a session and waits for the
+// response.
+func putToken(ctx context.Context, session *amqp.Session, audience string, token serviceBusToken) error {
+	replyTo := "cbs-" + uuid.Must(uuid.NewV4()).String()
+
+	sender, err := session.NewSender(amqp.LinkTargetAddress(sbCBSAddress))
+	if err != nil {
+		return err
+	}
+	defer sender.Close(context.Background())
+
+	receiver, err := session.NewReceiver(
+		amqp.LinkSourceAddress(sbCBSAddress),
+		amqp.LinkTargetAddress(replyTo),
+	)
+	if err != nil {
+		return err
+	}
+	defer receiver.Close(context.Background())
+
+	msgID := uuid.Must(uuid.NewV4()).String()
+	if err = sender.Send(ctx, &amqp.Message{
+		Value: token.value,
+		Properties: &amqp.MessageProperties{
+			MessageID: msgID,
+			ReplyTo:   replyTo,
+		},
+		ApplicationProperties: map[string]interface{}{
+			"operation":  "put-token",
+			"type":       token.tokenType,
+			"name":       audience,
+			"expiration": token.expires,
+		},
+	}); err != nil {
+		return err
+	}
+
+	res, err := receiver.Receive(ctx)
+	if err != nil {
+		return err
+	}
+	_ = res.Accept(ctx)
+
+	code, description := cbsStatus(res)
+	if code < 200 || code >= 300 {
+		return fmt.Errorf("put-token rejected with status %v: %v", code, description)
+	}
+	return nil
+}
+
+// cbsStatus extracts the status code and description of a CBS response.
+func cbsStatus(res *amqp.Message) (int, string) {
+	var code int
+	switch c := res.ApplicationProperties["status-code"].(type) {
+	case int32:
+		code = int(c)
+	case int64:
+		code = int(c)
+	case int:
+		code = c
+	}
+	description, _ := res.ApplicationProperties["status-description"].(string)
+	return code, description
+}
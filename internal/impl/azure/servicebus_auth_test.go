@@ -0,0 +1,93 @@
+package azure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServiceBusConnectionString(t *testing.T) {
+	c, err := parseServiceBusConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=YmFyYmF6==;EntityPath=orders")
+	require.NoError(t, err)
+	assert.Equal(t, "foo.servicebus.windows.net", c.host)
+	assert.Equal(t, "RootManageSharedAccessKey", c.keyName)
+	assert.Equal(t, "YmFyYmF6==", c.key)
+	assert.Equal(t, "orders", c.entityPath)
+
+	sig := signSAS("foo", "bar", "amqp://foo.servicebus.windows.net/orders", time.Unix(1700000000, 0))
+	c, err = parseServiceBusConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessSignature=" + sig)
+	require.NoError(t, err)
+	assert.Equal(t, sig, c.signature)
+	assert.Equal(t, time.Unix(1700000000, 0), c.signatureExpiration)
+	assert.Equal(t, "", c.entityPath)
+
+	for _, bad := range []string{
+		"SharedAccessKeyName=foo;SharedAccessKey=bar",
+		"Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo",
+		"Endpoint=sb://foo.servicebus.windows.net/;nope",
+		"Endpoint=foo;SharedAccessKeyName=foo;SharedAccessKey=bar",
+	} {
+		_, err = parseServiceBusConnectionString(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSignSAS(t *testing.T) {
+	expires := time.Unix(1700000000, 0)
+	resource := "amqp://foo.servicebus.windows.net/orders"
+
+	sig := signSAS("RootManageSharedAccessKey", "bar", resource, expires)
+	require.True(t, strings.HasPrefix(sig, "SharedAccessSignature "))
+
+	values, err := url.ParseQuery(strings.TrimPrefix(sig, "SharedAccessSignature "))
+	require.NoError(t, err)
+	assert.Equal(t, resource, values.Get("sr"))
+	assert.Equal(t, "1700000000", values.Get("se"))
+	assert.Equal(t, "RootManageSharedAccessKey", values.Get("skn"))
+
+	mac := hmac.New(sha256.New, []byte("bar"))
+	_, _ = mac.Write([]byte(url.QueryEscape(resource) + "\n1700000000"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), values.Get("sig"))
+
+	actual, err := sasExpiration(sig)
+	require.NoError(t, err)
+	assert.Equal(t, expires, actual)
+}
+
+func TestSASProviders(t *testing.T) {
+	ctx := context.Background()
+
+	token, err := sasKeyProvider{keyName: "foo", key: "bar", ttl: time.Hour}.getToken(ctx, "amqp://foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, sbTokenTypeSAS, token.tokenType)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.expires, time.Minute)
+
+	_, err = sasStaticProvider{signature: "foo", expires: time.Now().Add(-time.Minute)}.getToken(ctx, "amqp://foo/bar")
+	assert.EqualError(t, err, "shared access signature has expired")
+
+	token, err = sasStaticProvider{signature: "foo", expires: time.Now().Add(time.Minute)}.getToken(ctx, "amqp://foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", token.value)
+}
+
+func TestNewServiceBusAuth(t *testing.T) {
+	auth, err := NewServiceBusAuth("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar;EntityPath=orders", "ignored", "")
+	require.NoError(t, err)
+	assert.Equal(t, "foo.servicebus.windows.net", auth.Host)
+	assert.Equal(t, "orders", auth.EntityPath)
+	assert.IsType(t, sasKeyProvider{}, auth.provider)
+
+	_, err = NewServiceBusAuth("", "", "")
+	assert.Error(t, err)
+
+	assert.Equal(t, "foo.servicebus.windows.net", serviceBusNamespaceHost("foo"))
+	assert.Equal(t, "foo.servicebus.chinacloudapi.cn", serviceBusNamespaceHost("foo.servicebus.chinacloudapi.cn"))
+}
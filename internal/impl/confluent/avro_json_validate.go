@@ -0,0 +1,328 @@
+package confluent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+type avroNamedType struct {
+	def       map[string]interface{}
+	namespace string
+}
+
+// avroJSONValidator checks that documents parsed from standard JSON, where
+// unions are not wrapped in objects naming their type, conform to an Avro
+// schema, and reports the path of the first incompatibility found.
+type avroJSONValidator struct {
+	root  interface{}
+	named map[string]avroNamedType
+}
+
+func newAvroJSONValidator(schema string) (*avroJSONValidator, error) {
+	if _, err := goavro.NewCodec(schema); err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	var root interface{}
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	v := &avroJSONValidator{
+		root:  root,
+		named: map[string]avroNamedType{},
+	}
+	v.register(root, "")
+	return v, nil
+}
+
+// avroFullName returns the full name of a named type along with the namespace
+// that applies to the types nested within it.
+func avroFullName(def map[string]interface{}, namespace string) (string, string) {
+	name, _ := def["name"].(string)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name, name[:i]
+	}
+	if ns, ok := def["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name, namespace
+	}
+	return namespace + "." + name, namespace
+}
+
+func (v *avroJSONValidator) register(s interface{}, namespace string) {
+	switch t := s.(type) {
+	case []interface{}:
+		for _, branch := range t {
+			v.register(branch, namespace)
+		}
+	case map[string]interface{}:
+		typeStr, isStr := t["type"].(string)
+		if !isStr {
+			v.register(t["type"], namespace)
+			return
+		}
+		switch typeStr {
+		case "record", "error", "enum", "fixed":
+			full, ns := avroFullName(t, namespace)
+			v.named[full] = avroNamedType{def: t, namespace: ns}
+			if fields, ok := t["fields"].([]interface{}); ok {
+				for _, f := range fields {
+					if fObj, ok := f.(map[string]interface{}); ok {
+						v.register(fObj["type"], ns)
+					}
+				}
+			}
+		case "array":
+			v.register(t["items"], namespace)
+		case "map":
+			v.register(t["values"], namespace)
+		}
+	}
+}
+
+func (v *avroJSONValidator) resolve(name, namespace string) (avroNamedType, bool) {
+	if !strings.Contains(name, ".") && namespace != "" {
+		if n, exists := v.named[namespace+"."+name]; exists {
+			return n, true
+		}
+	}
+	n, exists := v.named[name]
+	return n, exists
+}
+
+// Validate returns an error describing the first incompatibility between a
+// document and the schema.
+func (v *avroJSONValidator) Validate(doc interface{}) error {
+	return v.check(v.root, "", doc, "")
+}
+
+func avroPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func avroPathStr(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
+
+func avroTypeName(s interface{}) string {
+	switch t := s.(type) {
+	case string:
+		return t
+	case []interface{}:
+		names := make([]string, len(t))
+		for i, branch := range t {
+			names[i] = avroTypeName(branch)
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	case map[string]interface{}:
+		if name, ok := t["name"].(string); ok {
+			return name
+		}
+		return avroTypeName(t["type"])
+	}
+	return fmt.Sprintf("%v", s)
+}
+
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case float64, float32, int, int64, int32, uint64, json.Number:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonInteger returns the value of a number when it is integral.
+func jsonInteger(value interface{}) (int64, bool) {
+	switch t := value.(type) {
+	case int:
+		return int64(t), true
+	case int32:
+		return int64(t), true
+	case int64:
+		return t, true
+	case uint64:
+		if t > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(t), true
+	case float64:
+		if t != math.Trunc(t) || t > math.MaxInt64 || t < math.MinInt64 {
+			return 0, false
+		}
+		return int64(t), true
+	case json.Number:
+		i, err := strconv.ParseInt(t.String(), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func (v *avroJSONValidator) mismatch(s interface{}, value interface{}, path string) error {
+	return fmt.Errorf("%v: expected %v, found %v", avroPathStr(path), avroTypeName(s), jsonKind(value))
+}
+
+func (v *avroJSONValidator) checkPrimitive(typeStr, namespace string, value interface{}, path string) error {
+	ok := false
+	switch typeStr {
+	case "null":
+		ok = value == nil
+	case "boolean":
+		_, ok = value.(bool)
+	case "int":
+		var i int64
+		if i, ok = jsonInteger(value); ok && (i > math.MaxInt32 || i < math.MinInt32) {
+			return fmt.Errorf("%v: value %v overflows int", avroPathStr(path), i)
+		}
+	case "long":
+		_, ok = jsonInteger(value)
+	case "float", "double":
+		ok = jsonKind(value) == "number"
+	case "string", "bytes":
+		_, ok = value.(string)
+	default:
+		named, exists := v.resolve(typeStr, namespace)
+		if !exists {
+			return fmt.Errorf("%v: unknown type %v", avroPathStr(path), typeStr)
+		}
+		return v.check(named.def, named.namespace, value, path)
+	}
+	if !ok {
+		return v.mismatch(typeStr, value, path)
+	}
+	return nil
+}
+
+func (v *avroJSONValidator) check(s interface{}, namespace string, value interface{}, path string) error {
+	switch t := s.(type) {
+	case string:
+		return v.checkPrimitive(t, namespace, value, path)
+	case []interface{}:
+		for _, branch := range t {
+			if err := v.check(branch, namespace, value, path); err == nil {
+				return nil
+			}
+		}
+		return v.mismatch(t, value, path)
+	case map[string]interface{}:
+		typeStr, isStr := t["type"].(string)
+		if !isStr {
+			return v.check(t["type"], namespace, value, path)
+		}
+		switch typeStr {
+		case "record", "error":
+			_, ns := avroFullName(t, namespace)
+			return v.checkRecord(t, ns, value, path)
+		case "enum":
+			str, ok := value.(string)
+			if !ok {
+				return v.mismatch(t, value, path)
+			}
+			symbols, _ := t["symbols"].([]interface{})
+			for _, sym := range symbols {
+				if sym == str {
+					return nil
+				}
+			}
+			return fmt.Errorf("%v: value %q is not a symbol of enum %v", avroPathStr(path), str, avroTypeName(t))
+		case "array":
+			arr, ok := value.([]interface{})
+			if !ok {
+				return v.mismatch("array", value, path)
+			}
+			for i, e := range arr {
+				if err := v.check(t["items"], namespace, e, avroPath(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "map":
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return v.mismatch("map", value, path)
+			}
+			for _, k := range sortedKeys(obj) {
+				if err := v.check(t["values"], namespace, obj[k], avroPath(path, k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "fixed":
+			str, ok := value.(string)
+			if !ok {
+				return v.mismatch(t, value, path)
+			}
+			if size, _ := t["size"].(float64); len(str) != int(size) {
+				return fmt.Errorf("%v: expected %v bytes for fixed %v, found %v", avroPathStr(path), size, avroTypeName(t), len(str))
+			}
+			return nil
+		}
+		// Primitive types with attributes such as logical types.
+		return v.checkPrimitive(typeStr, namespace, value, path)
+	}
+	return fmt.Errorf("%v: unsupported schema %v", avroPathStr(path), s)
+}
+
+func (v *avroJSONValidator) checkRecord(def map[string]interface{}, namespace string, value interface{}, path string) error {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return v.mismatch(def, value, path)
+	}
+	fields, _ := def["fields"].([]interface{})
+	known := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		field, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := field["name"].(string)
+		known[name] = struct{}{}
+		fieldValue, exists := obj[name]
+		if !exists {
+			if _, hasDefault := field["default"]; hasDefault {
+				continue
+			}
+			return fmt.Errorf("%v: missing required field", avroPath(path, name))
+		}
+		if err := v.check(field["type"], namespace, fieldValue, avroPath(path, name)); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(obj) {
+		if _, exists := known[k]; !exists {
+			return fmt.Errorf("%v: field is not defined by record %v", avroPath(path, k), avroTypeName(def))
+		}
+	}
+	return nil
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
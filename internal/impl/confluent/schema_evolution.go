@@ -0,0 +1,347 @@
+package confluent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/public/x/service"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func schemaEvolutionConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Integration", "Utility").
+		Version("3.50.0").
+		Summary("Validates that messages conform to the latest schema registered for a subject within a Confluent Schema Registry service.").
+		Description(`
+This processor is intended as a guard placed before outputs that write to topics governed by a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html), and checks the JSON contents of each message against the latest version of the schema of a subject without encoding it. Both Avro and JSON Schema types are supported.
+
+The latest schema is cached and fetched again once the `+"`refresh_interval`"+` has passed. If the registry cannot be reached during a refresh then the cached schema continues to be used, and messages only fail due to the registry being unavailable when no schema has been obtained yet.
+
+Avro schemas are checked against standard JSON documents, where values of union types are not wrapped in an object naming their type. Fields that are missing from a document must have a default value in the schema, and fields that are not defined by the schema are considered incompatible.
+
+### Error Handling
+
+A message that does not conform to the schema remains unchanged, and is flagged as having failed with an error describing the specific incompatibility, where it can be handled, dropped or routed elsewhere using the patterns outlined [here](/docs/configuration/error_handling).
+
+When `+"`soft_fail`"+` is set to `+"`true`"+` messages are never flagged, and incompatibilities are instead counted with the metric `+"`violations`"+` and logged at debug level. This is useful for observing the effect of a guard before enforcing it.`).
+		Field(service.NewStringField("url").Description("The base URL of the schema registry service.")).
+		Field(service.NewStringField("subject").
+			Description("The subject of the schema to validate messages against.").
+			Example("orders-value")).
+		Field(service.NewStringField("refresh_interval").
+			Description("The period after which the latest schema of the subject is fetched again.").
+			Default("5m")).
+		Field(service.NewBoolField("soft_fail").
+			Description("Pass messages that do not conform to the schema without flagging them as having failed, and only emit metrics and logs.").
+			Default(false)).
+		Field(service.NewTLSField("tls")).
+		Example(
+			"Route Incompatible Messages",
+			`Here we check messages against the latest schema of the subject of a topic before writing them, and route any that are incompatible to a separate output:`,
+			`
+pipeline:
+  processors:
+    - schema_evolution:
+        url: http://localhost:8081
+        subject: orders-value
+
+output:
+  switch:
+    cases:
+      - check: errored()
+        output:
+          file:
+            path: ./incompatible.jsonl
+            codec: lines
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: orders
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_evolution", schemaEvolutionConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaEvolutionFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func newSchemaEvolutionFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*schemaEvolution, error) {
+	urlStr, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := conf.FieldString("subject")
+	if err != nil {
+		return nil, err
+	}
+	refreshStr, err := conf.FieldString("refresh_interval")
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := time.ParseDuration(refreshStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse refresh_interval: %w", err)
+	}
+	softFail, err := conf.FieldBool("soft_fail")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	return newSchemaEvolution(urlStr, subject, refresh, softFail, tlsConf, mgr)
+}
+
+//------------------------------------------------------------------------------
+
+// schemaRetryPeriod is the longest period to wait before trying to refresh a
+// schema again after the registry failed to respond.
+const schemaRetryPeriod = time.Second * 10
+
+type schemaValidator func(doc interface{}) error
+
+type latestSchema struct {
+	id        int
+	version   int
+	validate  schemaValidator
+	refreshAt time.Time
+}
+
+type schemaEvolution struct {
+	surl     string
+	subject  string
+	refresh  time.Duration
+	softFail bool
+	client   *http.Client
+
+	schema     *latestSchema
+	cacheMut   sync.RWMutex
+	requestMut sync.Mutex
+
+	logger      *service.Logger
+	mViolations *service.MetricCounter
+}
+
+func newSchemaEvolution(
+	urlStr, subject string,
+	refresh time.Duration,
+	softFail bool,
+	tlsConf *tls.Config,
+	mgr *service.Resources,
+) (*schemaEvolution, error) {
+	if _, err := url.Parse(urlStr); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if subject == "" {
+		return nil, errors.New("a subject must be specified")
+	}
+	if refresh <= 0 {
+		return nil, errors.New("refresh_interval must be greater than zero")
+	}
+	return &schemaEvolution{
+		surl:        strings.TrimSuffix(urlStr, "/") + "/subjects/" + url.PathEscape(subject) + "/versions/latest",
+		subject:     subject,
+		refresh:     refresh,
+		softFail:    softFail,
+		client:      newSchemaRegistryHTTPClient(tlsConf),
+		logger:      mgr.Logger(),
+		mViolations: mgr.Metrics().NewCounter("violations"),
+	}, nil
+}
+
+func (s *schemaEvolution) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	schema, err := s.getSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.validate(schema, msg); err != nil {
+		s.mViolations.Incr(1)
+		if s.softFail {
+			s.logger.Debugf("Message does not conform to schema of subject '%v': %v", s.subject, err)
+			return service.MessageBatch{msg}, nil
+		}
+		return nil, err
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *schemaEvolution) validate(schema *latestSchema, msg *service.Message) error {
+	doc, err := msg.AsStructured()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+	if err := schema.validate(doc); err != nil {
+		return fmt.Errorf("message does not conform to version %v of subject '%v': %w", schema.version, s.subject, err)
+	}
+	return nil
+}
+
+func (s *schemaEvolution) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func newJSONSchemaValidator(schema string) (schemaValidator, error) {
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json schema: %w", err)
+	}
+	return func(doc interface{}) error {
+		result, err := compiled.Validate(gojsonschema.NewGoLoader(doc))
+		if err != nil {
+			return err
+		}
+		if result.Valid() {
+			return nil
+		}
+		var errStr string
+		for i, desc := range result.Errors() {
+			if i > 0 {
+				errStr += "\n"
+			}
+			description := strings.ToLower(desc.Description())
+			if property := desc.Details()["property"]; property != nil {
+				description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+			}
+			errStr += desc.Field() + " " + description
+		}
+		return errors.New(errStr)
+	}, nil
+}
+
+func (s *schemaEvolution) getSchema(ctx context.Context) (*latestSchema, error) {
+	s.cacheMut.RLock()
+	schema := s.schema
+	s.cacheMut.RUnlock()
+	if schema != nil && time.Now().Before(schema.refreshAt) {
+		return schema, nil
+	}
+
+	s.requestMut.Lock()
+	defer s.requestMut.Unlock()
+
+	// We might've been beaten to making the request, so check once more whilst
+	// within the request lock.
+	s.cacheMut.RLock()
+	schema = s.schema
+	s.cacheMut.RUnlock()
+	if schema != nil && time.Now().Before(schema.refreshAt) {
+		return schema, nil
+	}
+
+	latest, err := s.fetchSchema(ctx, schema)
+	if err != nil {
+		if schema == nil {
+			return nil, err
+		}
+		s.logger.Errorf("Failed to refresh schema of subject '%v', continuing to use version %v: %v", s.subject, schema.version, err)
+
+		retry := schemaRetryPeriod
+		if retry > s.refresh {
+			retry = s.refresh
+		}
+		latest = &latestSchema{
+			id:        schema.id,
+			version:   schema.version,
+			validate:  schema.validate,
+			refreshAt: time.Now().Add(retry),
+		}
+	} else if schema == nil || schema.id != latest.id {
+		s.logger.Infof("Validating messages against version %v of subject '%v'", latest.version, s.subject)
+	}
+
+	s.cacheMut.Lock()
+	s.schema = latest
+	s.cacheMut.Unlock()
+	return latest, nil
+}
+
+// fetchSchema obtains the latest schema of the subject, reusing the validator
+// of the current schema when the schema hasn't changed.
+func (s *schemaEvolution) fetchSchema(ctx context.Context, current *latestSchema) (*latestSchema, error) {
+	ctx, done := context.WithTimeout(ctx, time.Second*5)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.surl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for latest schema of subject '%v': %w", s.subject, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("subject '%v' not found by registry", s.subject)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed for latest schema of subject '%v' with status %v", s.subject, res.StatusCode)
+	}
+
+	resBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for latest schema of subject '%v': %w", s.subject, err)
+	}
+
+	resPayload := struct {
+		ID         int    `json:"id"`
+		Version    int    `json:"version"`
+		SchemaType string `json:"schemaType"`
+		Schema     string `json:"schema"`
+	}{}
+	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
+		return nil, fmt.Errorf("failed to parse response for latest schema of subject '%v': %w", s.subject, err)
+	}
+
+	latest := &latestSchema{
+		id:        resPayload.ID,
+		version:   resPayload.Version,
+		refreshAt: time.Now().Add(s.refresh),
+	}
+	if current != nil && current.id == latest.id {
+		latest.validate = current.validate
+		return latest, nil
+	}
+
+	switch resPayload.SchemaType {
+	case "", "AVRO":
+		var v *avroJSONValidator
+		if v, err = newAvroJSONValidator(resPayload.Schema); err == nil {
+			latest.validate = v.Validate
+		}
+	case "JSON":
+		latest.validate, err = newJSONSchemaValidator(resPayload.Schema)
+	default:
+		err = fmt.Errorf("schema type %v not supported", resPayload.SchemaType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load version %v of subject '%v': %w", latest.version, s.subject, err)
+	}
+	return latest, nil
+}
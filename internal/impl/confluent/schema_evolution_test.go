@@ -0,0 +1,262 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/public/x/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func latestSchemaPayload(t *testing.T, id, version int, schemaType, schema string) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]interface{}{
+		"subject":    "foo-value",
+		"id":         id,
+		"version":    version,
+		"schemaType": schemaType,
+		"schema":     schema,
+	})
+	require.NoError(t, err)
+	return b
+}
+
+const testEvolutionAvroSchema = `{
+	"namespace": "foo.namespace.com",
+	"type": "record",
+	"name": "identity",
+	"fields": [
+		{ "name": "Name", "type": "string" },
+		{ "name": "Age", "type": "int", "default": 0 },
+		{ "name": "Tags", "type": { "type": "array", "items": "string" }, "default": [] },
+		{ "name": "Address", "type": ["null", {
+			"type": "record",
+			"name": "address",
+			"fields": [
+				{ "name": "City", "type": "string" },
+				{ "name": "Kind", "type": { "type": "enum", "name": "kind", "symbols": ["HOME", "WORK"] } }
+			]
+		}], "default": null },
+		{ "name": "Previous", "type": ["null", "address"], "default": null }
+	]
+}`
+
+func TestAvroJSONValidator(t *testing.T) {
+	v, err := newAvroJSONValidator(testEvolutionAvroSchema)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		input  string
+		errStr string
+	}{
+		{
+			name:  "minimal",
+			input: `{"Name":"foo"}`,
+		},
+		{
+			name:  "full",
+			input: `{"Name":"foo","Age":30,"Tags":["a","b"],"Address":{"City":"bar","Kind":"HOME"},"Previous":{"City":"baz","Kind":"WORK"}}`,
+		},
+		{
+			name:   "not an object",
+			input:  `["foo"]`,
+			errStr: "root: expected identity, found array",
+		},
+		{
+			name:   "missing field",
+			input:  `{"Age":30}`,
+			errStr: "Name: missing required field",
+		},
+		{
+			name:   "wrong type",
+			input:  `{"Name":"foo","Age":"30"}`,
+			errStr: "Age: expected int, found string",
+		},
+		{
+			name:   "fractional int",
+			input:  `{"Name":"foo","Age":30.5}`,
+			errStr: "Age: expected int, found number",
+		},
+		{
+			name:   "int overflow",
+			input:  `{"Name":"foo","Age":3000000000}`,
+			errStr: "Age: value 3000000000 overflows int",
+		},
+		{
+			name:   "array element",
+			input:  `{"Name":"foo","Tags":["a",2]}`,
+			errStr: "Tags.1: expected string, found number",
+		},
+		{
+			name:   "union mismatch",
+			input:  `{"Name":"foo","Address":"bar"}`,
+			errStr: "Address: expected [null, address], found string",
+		},
+		{
+			name:   "bad enum",
+			input:  `{"Name":"foo","Previous":{"City":"baz","Kind":"SHED"}}`,
+			errStr: `Previous: expected [null, address], found object`,
+		},
+		{
+			name:   "unknown field",
+			input:  `{"Name":"foo","Nickname":"bar"}`,
+			errStr: "Nickname: field is not defined by record identity",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(test.input), &doc))
+			err := v.Validate(doc)
+			if test.errStr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.errStr)
+			}
+		})
+	}
+
+	// Errors within a single union branch are reported precisely
+	enumV, err := newAvroJSONValidator(`{"type":"record","name":"foo","fields":[{"name":"kind","type":{"type":"enum","name":"kind","symbols":["A","B"]}}]}`)
+	require.NoError(t, err)
+	assert.EqualError(t, enumV.Validate(map[string]interface{}{"kind": "C"}), `kind: value "C" is not a symbol of enum kind`)
+}
+
+func TestSchemaEvolutionAvro(t *testing.T) {
+	var mut sync.Mutex
+	requests := 0
+	payload := latestSchemaPayload(t, 3, 1, "", testEvolutionAvroSchema)
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		if path != "/subjects/foo-value/versions/latest" {
+			return nil, nil
+		}
+		requests++
+		return payload, nil
+	})
+
+	proc, err := newSchemaEvolution(urlStr, "foo-value", time.Hour, false, nil, service.MockResources())
+	require.NoError(t, err)
+
+	outMsgs, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"Name":"foo"}`)))
+	require.NoError(t, err)
+	require.Len(t, outMsgs, 1)
+	b, err := outMsgs[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"Name":"foo"}`, string(b))
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"Name":5}`)))
+	assert.EqualError(t, err, "message does not conform to version 1 of subject 'foo-value': Name: expected string, found number")
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`not json`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse message as JSON")
+
+	mut.Lock()
+	assert.Equal(t, 1, requests)
+	mut.Unlock()
+
+	softProc, err := newSchemaEvolution(urlStr, "foo-value", time.Hour, true, nil, service.MockResources())
+	require.NoError(t, err)
+
+	outMsgs, err = softProc.Process(context.Background(), service.NewMessage([]byte(`{"Name":5}`)))
+	require.NoError(t, err)
+	require.Len(t, outMsgs, 1)
+	b, err = outMsgs[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"Name":5}`, string(b))
+}
+
+func TestSchemaEvolutionJSONSchemaRefresh(t *testing.T) {
+	var mut sync.Mutex
+	var payload []byte
+	var fail bool
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		if fail {
+			return nil, errors.New("nope")
+		}
+		if path != "/subjects/foo-value/versions/latest" {
+			return nil, nil
+		}
+		return payload, nil
+	})
+	setPayload := func(b []byte, f bool) {
+		mut.Lock()
+		payload, fail = b, f
+		mut.Unlock()
+	}
+
+	setPayload(latestSchemaPayload(t, 4, 1, "JSON", `{
+	"type": "object",
+	"properties": { "id": { "type": "integer" } },
+	"required": ["id"]
+}`), false)
+
+	proc, err := newSchemaEvolution(urlStr, "foo-value", time.Millisecond, false, nil, service.MockResources())
+	require.NoError(t, err)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"id":5}`)))
+	require.NoError(t, err)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"id":"5"}`)))
+	assert.EqualError(t, err, "message does not conform to version 1 of subject 'foo-value': id invalid type. expected: integer, given: string")
+
+	setPayload(latestSchemaPayload(t, 5, 2, "JSON", `{
+	"type": "object",
+	"properties": { "id": { "type": "string" } },
+	"required": ["id"]
+}`), false)
+	<-time.After(time.Millisecond * 5)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"id":"5"}`)))
+	require.NoError(t, err)
+
+	// A failed refresh continues to use the cached schema
+	setPayload(nil, true)
+	<-time.After(time.Millisecond * 5)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"id":5}`)))
+	assert.EqualError(t, err, "message does not conform to version 2 of subject 'foo-value': id invalid type. expected: string, given: integer")
+}
+
+func TestSchemaEvolutionRegistryErrors(t *testing.T) {
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/protobuf/versions/latest":
+			return latestSchemaPayload(t, 1, 1, "PROTOBUF", `syntax = "proto3";`), nil
+		case "/subjects/broken/versions/latest":
+			return nil, errors.New("nope")
+		}
+		return nil, nil
+	})
+
+	tests := []struct {
+		subject string
+		errStr  string
+	}{
+		{subject: "missing", errStr: "subject 'missing' not found by registry"},
+		{subject: "broken", errStr: "request failed for latest schema of subject 'broken' with status 400"},
+		{subject: "protobuf", errStr: "failed to load version 1 of subject 'protobuf': schema type PROTOBUF not supported"},
+	}
+
+	for _, test := range tests {
+		proc, err := newSchemaEvolution(urlStr, test.subject, time.Hour, false, nil, service.MockResources())
+		require.NoError(t, err)
+
+		_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{}`)))
+		assert.EqualError(t, err, test.errStr, test.subject)
+	}
+}
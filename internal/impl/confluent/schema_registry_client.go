@@ -0,0 +1,25 @@
+package confluent
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// newSchemaRegistryHTTPClient returns an HTTP client for communicating with a
+// schema registry, configured with custom TLS settings when provided.
+func newSchemaRegistryHTTPClient(tlsConf *tls.Config) *http.Client {
+	if tlsConf == nil {
+		return http.DefaultClient
+	}
+	client := &http.Client{}
+	if c, ok := http.DefaultTransport.(*http.Transport); ok {
+		cloned := c.Clone()
+		cloned.TLSClientConfig = tlsConf
+		client.Transport = cloned
+	} else {
+		client.Transport = &http.Transport{
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return client
+}
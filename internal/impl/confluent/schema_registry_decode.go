@@ -80,19 +80,7 @@ func newSchemaRegistryDecoder(urlStr string, tlsConf *tls.Config, logger *servic
 		logger:  logger,
 	}
 
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
-	}
+	s.client = newSchemaRegistryHTTPClient(tlsConf)
 
 	go func() {
 		for {
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/codec"
@@ -25,6 +26,9 @@ import (
 
 func init() {
 	bundle.AllInputs.Add(bundle.InputConstructorFromSimple(func(c input.Config, nm bundle.NewManagement) (input.Type, error) {
+		if c.GCPCloudStorage.PubSub.Subscription != "" && c.GCPCloudStorage.PubSub.Project == "" {
+			return nil, errors.New("pubsub.project must be set when a pubsub.subscription is specified")
+		}
 		r, err := newGCPCloudStorageInput(c.GCPCloudStorage, nm.Logger(), nm.Metrics())
 		if err != nil {
 			return nil, err
@@ -44,8 +48,16 @@ func init() {
 			string(input.CategoryGCP),
 		},
 		Summary: `
-Downloads objects within a Google Cloud Storage bucket, optionally filtered by a prefix.`,
+Downloads objects within a Google Cloud Storage bucket, optionally filtered by a prefix, either by listing the bucket or by consuming object notifications from a Pub/Sub subscription.`,
 		Description: `
+## Streaming Objects on Upload with Pub/Sub
+
+By default this input lists the objects of a bucket once and shuts down after they have all been consumed. Alternatively, a bucket can be configured to publish [object change notifications](https://cloud.google.com/storage/docs/pubsub-notifications) to a Pub/Sub topic, and when the fields ` + "`pubsub.project` and `pubsub.subscription`" + ` are set Benthos consumes those notifications instead and only downloads the objects they reference, running until it is shut down.
+
+Only notifications with the event type ` + "`OBJECT_FINALIZE`" + ` trigger a download, all other notifications are acknowledged and ignored. When the field ` + "`bucket`" + ` is set notifications of objects from other buckets are also ignored, as are objects that do not match the ` + "`prefix`" + `.
+
+A notification is not acknowledged until all messages extracted from the object it references have been sent onwards, which ensures at-least-once crash resiliency. Please make sure that the acknowledgement deadline of the subscription is long enough for objects to be processed, otherwise the same objects might be consumed multiple times.
+
 ## Downloading Large Files
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
@@ -71,10 +83,15 @@ You can access these metadata fields using [function interpolation](/docs/config
 By default Benthos will use a shared credentials file when connecting to GCP
 services. You can find out more [in this document](/docs/guides/gcp).`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldCommon("bucket", "The name of the bucket from which to download objects."),
+			docs.FieldCommon("bucket", "The name of the bucket from which to download objects. If the field `pubsub.subscription` is specified this field is optional."),
 			docs.FieldCommon("prefix", "An optional path prefix, if set only objects with the prefix are consumed."),
 			codec.ReaderDocs,
 			docs.FieldAdvanced("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed."),
+			docs.FieldCommon("pubsub", "Consume object notifications from a Pub/Sub subscription in order to trigger object downloads.").WithChildren(
+				docs.FieldCommon("project", "The project ID of the subscription."),
+				docs.FieldCommon("subscription", "An optional subscription to consume object notifications from. When specified the notifications received control which objects are downloaded."),
+				docs.FieldAdvanced("max_outstanding_messages", "The maximum number of notifications that can be pending at a given time."),
+			).AtVersion("3.50.0"),
 		).ChildDefaultAndTypesFromStruct(input.NewGCPCloudStorageConfig()),
 	})
 }
@@ -84,17 +101,23 @@ const (
 )
 
 type gcpCloudStorageObjectTarget struct {
-	key   string
-	ackFn func(context.Context, error) error
+	key    string
+	bucket string
+	ackFn  func(context.Context, error) error
 }
 
-func newGCPCloudStorageObjectTarget(key string, ackFn codec.ReaderAckFn) *gcpCloudStorageObjectTarget {
+func newGCPCloudStorageObjectTarget(key, bucket string, ackFn codec.ReaderAckFn) *gcpCloudStorageObjectTarget {
 	if ackFn == nil {
 		ackFn = func(context.Context, error) error {
 			return nil
 		}
 	}
-	return &gcpCloudStorageObjectTarget{key: key, ackFn: ackFn}
+	return &gcpCloudStorageObjectTarget{key: key, bucket: bucket, ackFn: ackFn}
+}
+
+type gcpCloudStorageObjectTargetReader interface {
+	Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error)
+	Close(ctx context.Context) error
 }
 
 //------------------------------------------------------------------------------
@@ -156,7 +179,7 @@ func newGCPCloudStorageTargetReader(
 		}
 
 		ackFn := deleteGCPCloudStorageObjectAckFn(bucket, obj.Name, conf.DeleteObjects, nil)
-		staticKeys.pending = append(staticKeys.pending, newGCPCloudStorageObjectTarget(obj.Name, ackFn))
+		staticKeys.pending = append(staticKeys.pending, newGCPCloudStorageObjectTarget(obj.Name, conf.Bucket, ackFn))
 	}
 
 	if len(staticKeys.pending) > 0 {
@@ -179,7 +202,7 @@ func (r *gcpCloudStorageTargetReader) Pop(ctx context.Context) (*gcpCloudStorage
 			}
 
 			ackFn := deleteGCPCloudStorageObjectAckFn(r.bucket, obj.Name, r.conf.DeleteObjects, nil)
-			r.pending = append(r.pending, newGCPCloudStorageObjectTarget(obj.Name, ackFn))
+			r.pending = append(r.pending, newGCPCloudStorageObjectTarget(obj.Name, r.conf.Bucket, ackFn))
 		}
 	}
 	if len(r.pending) == 0 {
@@ -196,13 +219,127 @@ func (r gcpCloudStorageTargetReader) Close(context.Context) error {
 
 //------------------------------------------------------------------------------
 
+// gcpCloudStorageNotificationTarget extracts the bucket and key of the object
+// referenced by a Pub/Sub object notification, returning false if the
+// notification should not trigger a download.
+func gcpCloudStorageNotificationTarget(conf input.GCPCloudStorageConfig, attrs map[string]string) (bucket, key string, ok bool) {
+	if attrs["eventType"] != "OBJECT_FINALIZE" {
+		return "", "", false
+	}
+	if bucket, key = attrs["bucketId"], attrs["objectId"]; bucket == "" || key == "" {
+		return "", "", false
+	}
+	if conf.Bucket != "" && bucket != conf.Bucket {
+		return "", "", false
+	}
+	if !strings.HasPrefix(key, conf.Prefix) {
+		return "", "", false
+	}
+	return bucket, key, true
+}
+
+type gcpCloudStoragePubSubTargetReader struct {
+	conf   input.GCPCloudStorageConfig
+	log    log.Modular
+	client *storage.Client
+	pubsub *pubsub.Client
+
+	msgsChan  chan *pubsub.Message
+	closeFunc context.CancelFunc
+}
+
+func newGCPCloudStoragePubSubTargetReader(
+	ctx context.Context,
+	conf input.GCPCloudStorageConfig,
+	log log.Modular,
+	client *storage.Client,
+) (*gcpCloudStoragePubSubTargetReader, error) {
+	psClient, err := pubsub.NewClient(ctx, conf.PubSub.Project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pubsub client: %v", err)
+	}
+
+	sub := psClient.Subscription(conf.PubSub.Subscription)
+	sub.ReceiveSettings.MaxOutstandingMessages = conf.PubSub.MaxOutstandingMessages
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	msgsChan := make(chan *pubsub.Message)
+
+	go func() {
+		rerr := sub.Receive(subCtx, func(ctx context.Context, m *pubsub.Message) {
+			select {
+			case msgsChan <- m:
+			case <-ctx.Done():
+				m.Nack()
+			}
+		})
+		if rerr != nil && rerr != context.Canceled {
+			log.Errorf("Subscription error: %v\n", rerr)
+		}
+		close(msgsChan)
+	}()
+
+	log.Infof("Receiving object notifications from project '%v' and subscription '%v'\n", conf.PubSub.Project, conf.PubSub.Subscription)
+	return &gcpCloudStoragePubSubTargetReader{
+		conf:      conf,
+		log:       log,
+		client:    client,
+		pubsub:    psClient,
+		msgsChan:  msgsChan,
+		closeFunc: cancel,
+	}, nil
+}
+
+func (r *gcpCloudStoragePubSubTargetReader) Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error) {
+	for {
+		var m *pubsub.Message
+		var open bool
+		select {
+		case m, open = <-r.msgsChan:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !open {
+			return nil, types.ErrNotConnected
+		}
+
+		bucket, key, ok := gcpCloudStorageNotificationTarget(r.conf, m.Attributes)
+		if !ok {
+			r.log.Tracef("Ignoring object notification '%v' of event type '%v'\n", m.ID, m.Attributes["eventType"])
+			m.Ack()
+			continue
+		}
+
+		ackFn := deleteGCPCloudStorageObjectAckFn(
+			r.client.Bucket(bucket), key, r.conf.DeleteObjects,
+			func(_ context.Context, err error) error {
+				if err != nil {
+					r.log.Debugf("Returning object notification to the subscription due to error: %v\n", err)
+					m.Nack()
+				} else {
+					m.Ack()
+				}
+				return nil
+			},
+		)
+		return newGCPCloudStorageObjectTarget(key, bucket, ackFn), nil
+	}
+}
+
+func (r *gcpCloudStoragePubSubTargetReader) Close(context.Context) error {
+	r.closeFunc()
+	return r.pubsub.Close()
+}
+
+//------------------------------------------------------------------------------
+
 // gcpCloudStorage is a benthos reader.Type implementation that reads messages
 // from a Google Cloud Storage bucket.
 type gcpCloudStorageInput struct {
 	conf input.GCPCloudStorageConfig
 
 	objectScannerCtor codec.ReaderConstructor
-	keyReader         *gcpCloudStorageTargetReader
+	keyReader         gcpCloudStorageObjectTargetReader
 
 	objectMut sync.Mutex
 	object    *gcpCloudStoragePendingObject
@@ -240,7 +377,16 @@ func (g *gcpCloudStorageInput) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	g.keyReader, err = newGCPCloudStorageTargetReader(ctx, g.conf, g.log, g.client.Bucket(g.conf.Bucket))
+	if g.keyReader != nil {
+		_ = g.keyReader.Close(ctx)
+		g.keyReader = nil
+	}
+
+	if g.conf.PubSub.Subscription != "" {
+		g.keyReader, err = newGCPCloudStoragePubSubTargetReader(ctx, g.conf, g.log, g.client)
+	} else {
+		g.keyReader, err = newGCPCloudStorageTargetReader(ctx, g.conf, g.log, g.client.Bucket(g.conf.Bucket))
+	}
 	return err
 }
 
@@ -254,7 +400,7 @@ func (g *gcpCloudStorageInput) getObjectTarget(ctx context.Context) (*gcpCloudSt
 		return nil, err
 	}
 
-	objReference := g.client.Bucket(g.conf.Bucket).Object(target.key)
+	objReference := g.client.Bucket(target.bucket).Object(target.key)
 
 	objAttributes, err := objReference.Attrs(ctx)
 	if err != nil {
@@ -361,6 +507,11 @@ func (g *gcpCloudStorageInput) CloseAsync() {
 			g.object = nil
 		}
 
+		if g.keyReader != nil {
+			g.keyReader.Close(context.Background())
+			g.keyReader = nil
+		}
+
 		if g.client != nil {
 			g.client.Close()
 			g.client = nil
@@ -0,0 +1,85 @@
+package gcp
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPCloudStorageNotificationTarget(t *testing.T) {
+	tests := map[string]struct {
+		bucket    string
+		prefix    string
+		attrs     map[string]string
+		expBucket string
+		expKey    string
+		expOk     bool
+	}{
+		"finalize event": {
+			attrs: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.txt",
+			},
+			expBucket: "foo",
+			expKey:    "bar/baz.txt",
+			expOk:     true,
+		},
+		"delete event": {
+			attrs: map[string]string{
+				"eventType": "OBJECT_DELETE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.txt",
+			},
+		},
+		"missing object": {
+			attrs: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+			},
+		},
+		"matching bucket and prefix": {
+			bucket: "foo",
+			prefix: "bar/",
+			attrs: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.txt",
+			},
+			expBucket: "foo",
+			expKey:    "bar/baz.txt",
+			expOk:     true,
+		},
+		"different bucket": {
+			bucket: "qux",
+			attrs: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.txt",
+			},
+		},
+		"different prefix": {
+			prefix: "qux/",
+			attrs: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.txt",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := input.NewGCPCloudStorageConfig()
+			conf.Bucket = test.bucket
+			conf.Prefix = test.prefix
+
+			bucket, key, ok := gcpCloudStorageNotificationTarget(conf, test.attrs)
+			assert.Equal(t, test.expOk, ok)
+			assert.Equal(t, test.expBucket, bucket)
+			assert.Equal(t, test.expKey, key)
+		})
+	}
+}
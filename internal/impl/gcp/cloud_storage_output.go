@@ -2,6 +2,7 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gofrs/uuid"
 )
 
 func init() {
@@ -59,6 +61,21 @@ Metadata fields on messages will be sent as headers, in order to mutate these va
 By default Benthos will use a shared credentials file when connecting to GCP
 services. You can find out more [in this document](/docs/guides/gcp).
 
+### Appending to Objects
+
+Objects in Google Cloud Storage can't be modified once written, and therefore by
+default a message written to the path of an existing object replaces it. When
+the field `+"`collision_mode`"+` is set to `+"`append`"+` each message is instead
+uploaded as a temporary object, which is then [composed](https://cloud.google.com/storage/docs/composing-objects)
+onto the end of the object at the target path before being deleted. If the
+target object does not yet exist it is created with the contents of the
+message.
+
+The compose operation is not atomic with respect to other writers of the same
+object, and therefore messages written concurrently to the same path might be
+lost. When appending it's recommended to keep `+"`max_in_flight`"+` at `+"`1`"+`
+unless messages in flight are guaranteed to target different paths.
+
 ### Batching
 
 It's common to want to upload messages to Google Cloud Storage as batched
@@ -109,6 +126,9 @@ output:
 			).IsInterpolated(),
 			docs.FieldCommon("content_type", "The content type to set for each object.").IsInterpolated(),
 			docs.FieldAdvanced("content_encoding", "An optional content encoding to set for each object.").IsInterpolated(),
+			docs.FieldCommon("collision_mode", "Determines how a message is written when an object already exists at its path.").HasOptions(
+				"overwrite", "append",
+			).AtVersion("3.50.0"),
 			docs.FieldAdvanced("chunk_size", "An optional chunk size which controls the maximum number of bytes of the object that the Writer will attempt to send to the server in a single request. If ChunkSize is set to zero, chunking will be disabled."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
@@ -138,6 +158,11 @@ func newGCPCloudStorageOutput(
 	log log.Modular,
 	stats metrics.Type,
 ) (*gcpCloudStorageOutput, error) {
+	switch conf.CollisionMode {
+	case "overwrite", "append":
+	default:
+		return nil, fmt.Errorf("collision mode not recognised: %v", conf.CollisionMode)
+	}
 	g := &gcpCloudStorageOutput{
 		conf:  conf,
 		log:   log,
@@ -191,20 +216,63 @@ func (g *gcpCloudStorageOutput) WriteWithContext(ctx context.Context, msg types.
 			return nil
 		})
 
-		w := client.Bucket(g.conf.Bucket).Object(g.path.String(i, msg)).NewWriter(ctx)
-		w.ChunkSize = g.conf.ChunkSize
-		w.ContentType = g.contentType.String(i, msg)
-		w.ContentEncoding = g.contentEncoding.String(i, msg)
-		w.Metadata = metadata
-		_, err := w.Write(p.Get())
+		bucket := client.Bucket(g.conf.Bucket)
+		target := bucket.Object(g.path.String(i, msg))
+		contentType := g.contentType.String(i, msg)
+		contentEncoding := g.contentEncoding.String(i, msg)
+
+		if g.conf.CollisionMode != "append" {
+			return g.writeObject(ctx, target, contentType, contentEncoding, metadata, p.Get())
+		}
+
+		tmpUUID, err := uuid.NewV4()
 		if err != nil {
 			return err
 		}
+		tmp := bucket.Object(target.ObjectName() + ".benthos-append-" + tmpUUID.String())
+		if err := g.writeObject(ctx, tmp, contentType, contentEncoding, metadata, p.Get()); err != nil {
+			return err
+		}
+		defer func() {
+			if err := tmp.Delete(context.Background()); err != nil {
+				g.log.Warnf("Failed to delete temporary object '%v': %v\n", tmp.ObjectName(), err)
+			}
+		}()
 
-		return w.Close()
+		sources := []*storage.ObjectHandle{tmp}
+		if _, err := target.Attrs(ctx); err == nil {
+			sources = []*storage.ObjectHandle{target, tmp}
+		} else if !errors.Is(err, storage.ErrObjectNotExist) {
+			return err
+		}
+
+		c := target.ComposerFrom(sources...)
+		c.ContentType = contentType
+		c.ContentEncoding = contentEncoding
+		c.Metadata = metadata
+		_, err = c.Run(ctx)
+		return err
 	})
 }
 
+func (g *gcpCloudStorageOutput) writeObject(
+	ctx context.Context,
+	obj *storage.ObjectHandle,
+	contentType, contentEncoding string,
+	metadata map[string]string,
+	data []byte,
+) error {
+	w := obj.NewWriter(ctx)
+	w.ChunkSize = g.conf.ChunkSize
+	w.ContentType = contentType
+	w.ContentEncoding = contentEncoding
+	w.Metadata = metadata
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (g *gcpCloudStorageOutput) CloseAsync() {
 	go func() {
@@ -0,0 +1,201 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bundle"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/impl/grpc/keepalive"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	bundle.AllOutputs.Add(bundle.OutputConstructorFromSimple(func(c output.Config, nm bundle.NewManagement) (output.Type, error) {
+		w, err := newClientOutput(c.GRPCClient, nm.Logger(), nm.Metrics())
+		if err != nil {
+			return nil, err
+		}
+		o, err := output.NewAsyncWriter(output.TypeGRPCClient, c.GRPCClient.MaxInFlight, w, nm.Logger(), nm.Metrics())
+		if err != nil {
+			return nil, err
+		}
+		return output.OnlySinglePayloads(o), nil
+	}), docs.ComponentSpec{
+		Name:    output.TypeGRPCClient,
+		Type:    docs.TypeOutput,
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Sends messages as unary gRPC calls to a generic Benthos service, such as the one
+served by the ` + "[`grpc_server`](/docs/components/inputs/grpc_server)" + ` input.`,
+		Description: `
+Each message is sent with a call to the method ` + "`Send`" + ` of the service
+` + "`benthos.v1.Benthos`" + `, including its metadata, and a write is only
+considered successful once the call returns without an error. The service
+definition can be found [in the repository](https://github.com/Jeffail/benthos/blob/master/internal/impl/grpc/benthos.proto).
+
+Calls that fail with the status ` + "`RESOURCE_EXHAUSTED`" + ` are classified as
+throttled, and calls that fail with the statuses ` + "`INVALID_ARGUMENT`" + `,
+` + "`PERMISSION_DENIED`" + `, ` + "`UNAUTHENTICATED`" + ` or
+` + "`UNIMPLEMENTED`" + ` are classified as terminal.
+
+### Responses
+
+When ` + "`propagate_response`" + ` is enabled the responses returned within the
+` + "`Ack`" + ` of a call are [propagated back](/docs/guides/sync_responses) to
+the input.`,
+		Categories: []string{
+			string(output.CategoryNetwork),
+		},
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldCommon("address", "The address of the server to connect to."),
+			docs.FieldCommon("timeout", "The maximum period to wait for a call to complete."),
+			btls.FieldSpec(),
+			docs.FieldAdvanced("max_message_size", "The maximum size in bytes of messages sent or received by the client."),
+			keepalive.ClientFieldSpec(),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("propagate_response", "Whether responses from the server should be [propagated back](/docs/guides/sync_responses) to the input."),
+		).ChildDefaultAndTypesFromStruct(output.NewGRPCClientConfig()),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type clientOutput struct {
+	conf     output.GRPCClientConfig
+	timeout  time.Duration
+	dialOpts []grpc.DialOption
+
+	log   log.Modular
+	stats metrics.Type
+
+	connMut sync.RWMutex
+	conn    *grpc.ClientConn
+}
+
+func newClientOutput(conf output.GRPCClientConfig, log log.Modular, stats metrics.Type) (*clientOutput, error) {
+	c := &clientOutput{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	var err error
+	if conf.Timeout != "" {
+		if c.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+
+	if c.dialOpts, err = conf.Keepalive.Options(); err != nil {
+		return nil, err
+	}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		c.dialOpts = append(c.dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
+	} else {
+		c.dialOpts = append(c.dialOpts, grpc.WithInsecure())
+	}
+	if conf.MaxMessageSize > 0 {
+		c.dialOpts = append(c.dialOpts, grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(conf.MaxMessageSize),
+			grpc.MaxCallSendMsgSize(conf.MaxMessageSize),
+		))
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (c *clientOutput) ConnectWithContext(ctx context.Context) error {
+	c.connMut.Lock()
+	defer c.connMut.Unlock()
+
+	if c.conn != nil {
+		return nil
+	}
+
+	conn, err := grpc.DialContext(ctx, c.conf.Address, c.dialOpts...)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	c.log.Infof("Sending gRPC calls to: %v\n", c.conf.Address)
+	return nil
+}
+
+func classifyStatusError(err error) error {
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+		return ioutput.NewThrottledError(err)
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+		return ioutput.NewTerminalError(err)
+	}
+	return err
+}
+
+func (c *clientOutput) WriteWithContext(ctx context.Context, msg types.Message) error {
+	c.connMut.RLock()
+	conn := c.conn
+	c.connMut.RUnlock()
+	if conn == nil {
+		return types.ErrNotConnected
+	}
+
+	if c.timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, c.timeout)
+		defer done()
+	}
+
+	return msg.Iter(func(i int, p types.Part) error {
+		ack := newAck()
+		if err := conn.Invoke(ctx, sendMethod, partToProto(p), ack); err != nil {
+			return classifyStatusError(err)
+		}
+		if !c.conf.PropagateResponse {
+			return nil
+		}
+		if responses := ackResponses(ack); len(responses) > 0 {
+			resMsg := message.New(nil)
+			for _, res := range responses {
+				resMsg.Append(message.WithContext(message.GetContext(p), res))
+			}
+			if err := roundtrip.SetAsResponse(resMsg); err != nil {
+				c.log.Debugf("Failed to propagate response: %v\n", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (c *clientOutput) CloseAsync() {
+	c.connMut.Lock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	c.connMut.Unlock()
+}
+
+func (c *clientOutput) WaitForClose(timeout time.Duration) error {
+	return nil
+}
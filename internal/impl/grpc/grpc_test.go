@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testServerAndClient(t *testing.T, propagate bool) (*serverInput, *clientOutput) {
+	t.Helper()
+
+	sConf := input.NewGRPCServerConfig()
+	sConf.Address = "127.0.0.1:0"
+	s, err := newServerInput(sConf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		s.CloseAsync()
+		assert.NoError(t, s.WaitForClose(time.Second*5))
+	})
+
+	cConf := output.NewGRPCClientConfig()
+	cConf.Address = s.listener.Addr().String()
+	cConf.PropagateResponse = propagate
+	c, err := newClientOutput(cConf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, c.ConnectWithContext(context.Background()))
+	t.Cleanup(func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second))
+	})
+	return s, c
+}
+
+func consumeOne(t *testing.T, s *serverInput, fn func(msg types.Message) types.Response) {
+	t.Helper()
+	go func() {
+		select {
+		case ts, open := <-s.TransactionChan():
+			if !open {
+				t.Error("Transaction chan closed")
+				return
+			}
+			res := fn(ts.Payload)
+			select {
+			case ts.ResponseChan <- res:
+			case <-time.After(time.Second * 5):
+				t.Error("Timed out sending response")
+			}
+		case <-time.After(time.Second * 5):
+			t.Error("Timed out waiting for message")
+		}
+	}()
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	s, c := testServerAndClient(t, false)
+
+	consumeOne(t, s, func(msg types.Message) types.Response {
+		require.Equal(t, 1, msg.Len())
+		p := msg.Get(0)
+		assert.Equal(t, "hello world", string(p.Get()))
+		assert.Equal(t, "bar", p.Metadata().Get("foo"))
+		assert.NotEmpty(t, p.Metadata().Get("grpc_peer"))
+		return response.NewAck()
+	})
+
+	msg := message.New([][]byte{[]byte("hello world")})
+	msg.Get(0).Metadata().Set("foo", "bar")
+	require.NoError(t, c.WriteWithContext(context.Background(), msg))
+}
+
+func TestGRPCDeliveryError(t *testing.T) {
+	s, c := testServerAndClient(t, false)
+
+	consumeOne(t, s, func(msg types.Message) types.Response {
+		return response.NewError(errors.New("nope"))
+	})
+
+	err := c.WriteWithContext(context.Background(), message.New([][]byte{[]byte("hello world")}))
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGRPCSyncResponse(t *testing.T) {
+	s, c := testServerAndClient(t, true)
+
+	consumeOne(t, s, func(msg types.Message) types.Response {
+		resMsg := msg.Copy()
+		resMsg.Get(0).Set([]byte("hello back"))
+		resMsg.Get(0).Metadata().Set("baz", "buz")
+		assert.NoError(t, roundtrip.SetAsResponse(resMsg))
+		return response.NewAck()
+	})
+
+	msg := message.New([][]byte{[]byte("hello world")})
+	store := roundtrip.NewResultStore()
+	roundtrip.AddResultStore(msg, store)
+	require.NoError(t, c.WriteWithContext(context.Background(), msg))
+
+	results := store.Get()
+	require.Len(t, results, 1)
+	require.Equal(t, 1, results[0].Len())
+	assert.Equal(t, "hello back", string(results[0].Get(0).Get()))
+	assert.Equal(t, "buz", results[0].Get(0).Metadata().Get("baz"))
+}
+
+func TestGRPCClassifyStatusError(t *testing.T) {
+	assert.Equal(t, ioutput.ErrorClassThrottled, ioutput.ClassifyError(classifyStatusError(status.Error(codes.ResourceExhausted, "slow down"))))
+	assert.Equal(t, ioutput.ErrorClassTerminal, ioutput.ClassifyError(classifyStatusError(status.Error(codes.PermissionDenied, "denied"))))
+	assert.Equal(t, ioutput.ErrorClassRetryable, ioutput.ClassifyError(classifyStatusError(status.Error(codes.Unavailable, "unavailable"))))
+}
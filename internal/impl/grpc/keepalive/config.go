@@ -0,0 +1,114 @@
+// Package keepalive contains the keepalive configuration of gRPC components.
+package keepalive
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// ServerConfig contains the keepalive configuration of a gRPC server.
+type ServerConfig struct {
+	Time                string `json:"time" yaml:"time"`
+	Timeout             string `json:"timeout" yaml:"timeout"`
+	MinTime             string `json:"min_time" yaml:"min_time"`
+	PermitWithoutStream bool   `json:"permit_without_stream" yaml:"permit_without_stream"`
+}
+
+// NewServerConfig creates a new ServerConfig with default values.
+func NewServerConfig() ServerConfig {
+	return ServerConfig{
+		Time:                "2h",
+		Timeout:             "20s",
+		MinTime:             "5m",
+		PermitWithoutStream: false,
+	}
+}
+
+// Options returns the gRPC server options that apply the keepalive config.
+func (c ServerConfig) Options() ([]grpc.ServerOption, error) {
+	var params keepalive.ServerParameters
+	var policy keepalive.EnforcementPolicy
+	var err error
+	if params.Time, err = parseDuration("time", c.Time); err != nil {
+		return nil, err
+	}
+	if params.Timeout, err = parseDuration("timeout", c.Timeout); err != nil {
+		return nil, err
+	}
+	if policy.MinTime, err = parseDuration("min_time", c.MinTime); err != nil {
+		return nil, err
+	}
+	policy.PermitWithoutStream = c.PermitWithoutStream
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(params),
+		grpc.KeepaliveEnforcementPolicy(policy),
+	}, nil
+}
+
+// ServerFieldSpec returns the documentation spec of a ServerConfig.
+func ServerFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("keepalive", "Keepalive settings of connections to the server.").WithChildren(
+		docs.FieldString("time", "The period after which the server pings an idle connection to check whether it is still alive."),
+		docs.FieldString("timeout", "The period to wait for a response to a ping before the connection is closed."),
+		docs.FieldString("min_time", "The minimum period that clients must wait between pings, connections of clients that ping more frequently are closed."),
+		docs.FieldBool("permit_without_stream", "Whether clients are allowed to ping when there are no active calls."),
+	)
+}
+
+//------------------------------------------------------------------------------
+
+// ClientConfig contains the keepalive configuration of a gRPC client.
+type ClientConfig struct {
+	Time                string `json:"time" yaml:"time"`
+	Timeout             string `json:"timeout" yaml:"timeout"`
+	PermitWithoutStream bool   `json:"permit_without_stream" yaml:"permit_without_stream"`
+}
+
+// NewClientConfig creates a new ClientConfig with default values.
+func NewClientConfig() ClientConfig {
+	return ClientConfig{
+		Time:                "",
+		Timeout:             "20s",
+		PermitWithoutStream: false,
+	}
+}
+
+// Options returns the gRPC dial options that apply the keepalive config.
+func (c ClientConfig) Options() ([]grpc.DialOption, error) {
+	if c.Time == "" {
+		return nil, nil
+	}
+	var params keepalive.ClientParameters
+	var err error
+	if params.Time, err = parseDuration("time", c.Time); err != nil {
+		return nil, err
+	}
+	if params.Timeout, err = parseDuration("timeout", c.Timeout); err != nil {
+		return nil, err
+	}
+	params.PermitWithoutStream = c.PermitWithoutStream
+	return []grpc.DialOption{grpc.WithKeepaliveParams(params)}, nil
+}
+
+// ClientFieldSpec returns the documentation spec of a ClientConfig.
+func ClientFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("keepalive", "Keepalive settings of the connection to the server.").WithChildren(
+		docs.FieldString("time", "The period after which the client pings an idle connection to check whether it is still alive. When empty pings are disabled. Servers reject pings more frequent than their configured minimum, which is five minutes by default.", "", "10m"),
+		docs.FieldString("timeout", "The period to wait for a response to a ping before the connection is closed."),
+		docs.FieldBool("permit_without_stream", "Whether to ping when there are no active calls."),
+	)
+}
+
+//------------------------------------------------------------------------------
+
+func parseDuration(field, str string) (time.Duration, error) {
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse keepalive %v: %w", field, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The descriptors of benthos.proto are constructed here rather than generated
+// by protoc, and must be kept in sync with the file.
+const (
+	protoFileName = "benthos/v1/benthos.proto"
+	serviceName   = "benthos.v1.Benthos"
+	sendMethod    = "/" + serviceName + "/Send"
+)
+
+var (
+	messageDesc protoreflect.MessageDescriptor
+	ackDesc     protoreflect.MessageDescriptor
+
+	// The gzipped file descriptor, used by the reflection service.
+	fileDescGZIP []byte
+)
+
+func init() {
+	str := func(s string) *string { return &s }
+	num := func(i int32) *int32 { return &i }
+	label := func(l descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto_Label { return &l }
+	typ := func(t descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto_Type { return &t }
+
+	fdProto := &descriptorpb.FileDescriptorProto{
+		Name:    str(protoFileName),
+		Package: str("benthos.v1"),
+		Syntax:  str("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: str("BenthosMessage"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name: str("payload"), JsonName: str("payload"), Number: num(1),
+						Label: label(descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+						Type:  typ(descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+					},
+					{
+						Name: str("metadata"), JsonName: str("metadata"), Number: num(2),
+						Label:    label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+						Type:     typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE),
+						TypeName: str(".benthos.v1.BenthosMessage.MetadataEntry"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: str("MetadataEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name: str("key"), JsonName: str("key"), Number: num(1),
+								Label: label(descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+								Type:  typ(descriptorpb.FieldDescriptorProto_TYPE_STRING),
+							},
+							{
+								Name: str("value"), JsonName: str("value"), Number: num(2),
+								Label: label(descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+								Type:  typ(descriptorpb.FieldDescriptorProto_TYPE_STRING),
+							},
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+			{
+				Name: str("Ack"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name: str("responses"), JsonName: str("responses"), Number: num(1),
+						Label:    label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+						Type:     typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE),
+						TypeName: str(".benthos.v1.BenthosMessage"),
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: str("Benthos"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       str("Send"),
+						InputType:  str(".benthos.v1.BenthosMessage"),
+						OutputType: str(".benthos.v1.Ack"),
+					},
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(fdProto, nil)
+	if err != nil {
+		panic(fmt.Sprintf("failed to build benthos.proto descriptor: %v", err))
+	}
+	messageDesc = fd.Messages().ByName("BenthosMessage")
+	ackDesc = fd.Messages().ByName("Ack")
+
+	raw, err := proto.Marshal(fdProto)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal benthos.proto descriptor: %v", err))
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(raw)
+	_ = zw.Close()
+	fileDescGZIP = buf.Bytes()
+}
+
+//------------------------------------------------------------------------------
+
+// benthosServer is implemented by the handlers of the Benthos service.
+type benthosServer interface {
+	Send(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error)
+}
+
+func sendHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := dynamicpb.NewMessage(messageDesc)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(benthosServer).Send(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: sendMethod,
+	}
+	return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(benthosServer).Send(ctx, req.(*dynamicpb.Message))
+	})
+}
+
+var benthosServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*benthosServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    sendHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: fileDescGZIP,
+}
+
+//------------------------------------------------------------------------------
+
+func newAck() *dynamicpb.Message {
+	return dynamicpb.NewMessage(ackDesc)
+}
+
+// partToProto converts a message part into a BenthosMessage.
+func partToProto(p types.Part) *dynamicpb.Message {
+	m := dynamicpb.NewMessage(messageDesc)
+	m.Set(messageDesc.Fields().ByName("payload"), protoreflect.ValueOfBytes(p.Get()))
+	metaMap := m.Mutable(messageDesc.Fields().ByName("metadata")).Map()
+	_ = p.Metadata().Iter(func(k, v string) error {
+		metaMap.Set(protoreflect.ValueOfString(k).MapKey(), protoreflect.ValueOfString(v))
+		return nil
+	})
+	return m
+}
+
+// partFromProto converts a BenthosMessage into a message part.
+func partFromProto(m protoreflect.Message) types.Part {
+	part := message.NewPart(m.Get(messageDesc.Fields().ByName("payload")).Bytes())
+	m.Get(messageDesc.Fields().ByName("metadata")).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		part.Metadata().Set(k.String(), v.String())
+		return true
+	})
+	return part
+}
+
+// ackResponses returns the synchronous responses contained within an Ack.
+func ackResponses(ack *dynamicpb.Message) []types.Part {
+	list := ack.Get(ackDesc.Fields().ByName("responses")).List()
+	parts := make([]types.Part, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		parts[i] = partFromProto(list.Get(i).Message())
+	}
+	return parts
+}
+
+// addAckResponse appends a synchronous response to an Ack.
+func addAckResponse(ack *dynamicpb.Message, p types.Part) {
+	list := ack.Mutable(ackDesc.Fields().ByName("responses")).List()
+	list.Append(protoreflect.ValueOfMessage(partToProto(p)))
+}
@@ -0,0 +1,329 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bundle"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/impl/grpc/keepalive"
+	"github.com/Jeffail/benthos/v3/internal/shutdown"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func init() {
+	bundle.AllInputs.Add(bundle.InputConstructorFromSimple(func(c input.Config, nm bundle.NewManagement) (input.Type, error) {
+		return newServerInput(c.GRPCServer, nm.Logger(), nm.Metrics())
+	}), docs.ComponentSpec{
+		Name:    input.TypeGRPCServer,
+		Type:    docs.TypeInput,
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Receive messages from unary gRPC calls of a generic Benthos service.`,
+		Description: `
+The server implements the service ` + "`benthos.v1.Benthos`" + `, which has a
+single method ` + "`Send`" + ` that accepts a message consisting of a payload and
+metadata, and returns once the message has been delivered by the output of the
+pipeline. The service definition can be found [in the repository](https://github.com/Jeffail/benthos/blob/master/internal/impl/grpc/benthos.proto),
+and the ` + "[`grpc_client`](/docs/components/outputs/grpc_client)" + ` output
+speaks the same contract, allowing Benthos instances to be linked together.
+
+When a message fails to be delivered the call returns an error with the status
+` + "`UNAVAILABLE`" + `, and when a message isn't delivered within the
+` + "`timeout`" + ` the call returns an error with the status
+` + "`DEADLINE_EXCEEDED`" + `.
+
+### Responses
+
+It's possible to return a response for each message received using
+[synchronous responses](/docs/guides/sync_responses), in which case the
+resulting messages are returned within the ` + "`responses`" + ` field of the
+` + "`Ack`" + `.
+
+### Reflection
+
+When ` + "`reflection`" + ` is enabled the server also implements the gRPC
+reflection service, which allows tools such as
+[grpcurl](https://github.com/fullstorydev/grpcurl) to discover the service
+without a copy of its definition:
+
+` + "```sh" + `
+grpcurl -plaintext -d '{"payload":"aGVsbG8=","metadata":{"foo":"bar"}}' localhost:4196 benthos.v1.Benthos/Send
+` + "```" + `
+
+### Metadata
+
+This input adds the metadata of the received message to the resulting message,
+as well as the following metadata fields:
+
+` + "```text" + `
+- grpc_peer
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Categories: []string{
+			string(input.CategoryNetwork),
+		},
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldCommon("address", "The address to listen from."),
+			docs.FieldCommon("timeout", "The maximum period to wait for a received message to be delivered before the call fails."),
+			docs.FieldCommon("tls", "Serve calls over TLS, which is enabled when both a certificate and key file are provided.").WithChildren(
+				docs.FieldString("cert_file", "A path to a certificate file."),
+				docs.FieldString("key_file", "A path to the key file of the certificate."),
+				docs.FieldString("client_cas_file", "An optional path to a file of certificate authorities, in which case clients must present a certificate signed by one of the authorities (mutual TLS)."),
+			),
+			docs.FieldAdvanced("max_message_size", "The maximum size in bytes of messages received or sent by the server."),
+			keepalive.ServerFieldSpec(),
+			docs.FieldBool("reflection", "Whether to serve the gRPC reflection service, which allows clients to discover the service.").Advanced(),
+		).ChildDefaultAndTypesFromStruct(input.NewGRPCServerConfig()),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type serverInput struct {
+	conf    input.GRPCServerConfig
+	timeout time.Duration
+
+	server   *grpc.Server
+	listener net.Listener
+
+	log   log.Modular
+	stats metrics.Type
+
+	mRcvd      metrics.StatCounter
+	mPartsRcvd metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mErr       metrics.StatCounter
+	mTimeout   metrics.StatCounter
+	mLatency   metrics.StatTimer
+
+	handlers     sync.WaitGroup
+	transactions chan types.Transaction
+	shutSig      *shutdown.Signaller
+}
+
+func serverTLSConfig(conf input.GRPCServerTLSConfig) (*tls.Config, error) {
+	if conf.CertFile == "" && conf.KeyFile == "" {
+		if conf.ClientCAsFile != "" {
+			return nil, errors.New("a certificate and key file must be provided alongside client_cas_file")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if conf.ClientCAsFile != "" {
+		caBytes, err := ioutil.ReadFile(conf.ClientCAsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client_cas_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, errors.New("failed to parse certificates of client_cas_file")
+		}
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConf, nil
+}
+
+func newServerInput(conf input.GRPCServerConfig, log log.Modular, stats metrics.Type) (*serverInput, error) {
+	s := &serverInput{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mRcvd:      stats.GetCounter("batch.received"),
+		mPartsRcvd: stats.GetCounter("received"),
+		mSucc:      stats.GetCounter("send.success"),
+		mErr:       stats.GetCounter("send.error"),
+		mTimeout:   stats.GetCounter("timeout"),
+		mLatency:   stats.GetTimer("latency"),
+
+		transactions: make(chan types.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+
+	var err error
+	if conf.Timeout != "" {
+		if s.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+
+	opts, err := conf.Keepalive.Options()
+	if err != nil {
+		return nil, err
+	}
+	if conf.MaxMessageSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(conf.MaxMessageSize), grpc.MaxSendMsgSize(conf.MaxMessageSize))
+	}
+	tlsConf, err := serverTLSConfig(conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
+	if s.listener, err = net.Listen("tcp", conf.Address); err != nil {
+		return nil, err
+	}
+
+	s.server = grpc.NewServer(opts...)
+	s.server.RegisterService(&benthosServiceDesc, s)
+	if conf.Reflection {
+		reflection.Register(s.server)
+	}
+
+	go s.loop()
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *serverInput) loop() {
+	defer func() {
+		s.handlers.Wait()
+		close(s.transactions)
+		s.shutSig.ShutdownComplete()
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.log.Infof("Receiving gRPC calls at: %v\n", s.listener.Addr())
+		serveErr <- s.server.Serve(s.listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			s.log.Errorf("Server error: %v\n", err)
+		}
+	case <-s.shutSig.CloseAtLeisureChan():
+	}
+
+	// Stop accepting calls but allow pending calls to complete, which are
+	// forcefully cancelled if we're asked to close immediately.
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-s.shutSig.CloseNowChan():
+		s.server.Stop()
+		<-stopped
+	}
+}
+
+// Send implements the Send method of the Benthos service.
+func (s *serverInput) Send(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error) {
+	s.handlers.Add(1)
+	defer s.handlers.Done()
+
+	part := partFromProto(req)
+	if p, ok := peer.FromContext(ctx); ok {
+		part.Metadata().Set("grpc_peer", p.Addr.String())
+	}
+	msg := message.New(nil)
+	msg.Append(part)
+
+	store := roundtrip.NewResultStore()
+	roundtrip.AddResultStore(msg, store)
+
+	s.mRcvd.Incr(1)
+	s.mPartsRcvd.Incr(1)
+
+	if s.timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, s.timeout)
+		defer done()
+	}
+
+	resChan := make(chan types.Response, 1)
+	select {
+	case s.transactions <- types.NewTransaction(msg, resChan):
+	case <-ctx.Done():
+		s.mTimeout.Incr(1)
+		return nil, status.Error(codes.DeadlineExceeded, "message was not consumed in time")
+	case <-s.shutSig.CloseAtLeisureChan():
+		return nil, status.Error(codes.Unavailable, "server closing")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			s.mErr.Incr(1)
+			return nil, status.Error(codes.Unavailable, res.Error().Error())
+		}
+	case <-ctx.Done():
+		s.mTimeout.Incr(1)
+		return nil, status.Error(codes.DeadlineExceeded, "message was not delivered in time")
+	}
+	s.mLatency.Timing(time.Since(msg.CreatedAt()).Nanoseconds())
+	s.mSucc.Incr(1)
+
+	ack := newAck()
+	for _, resMsg := range store.Get() {
+		_ = resMsg.Iter(func(i int, p types.Part) error {
+			addAckResponse(ack, p)
+			return nil
+		})
+	}
+	return ack, nil
+}
+
+// TransactionChan returns a transactions channel for consuming messages from
+// this input.
+func (s *serverInput) TransactionChan() <-chan types.Transaction {
+	return s.transactions
+}
+
+// Connected returns true as the server is always listening.
+func (s *serverInput) Connected() bool {
+	return true
+}
+
+// CloseAsync shuts down the server and stops processing calls.
+func (s *serverInput) CloseAsync() {
+	s.shutSig.CloseAtLeisure()
+}
+
+// WaitForClose blocks until the server has closed down.
+func (s *serverInput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		s.shutSig.CloseNow()
+		return types.ErrTimeout
+	}
+	return nil
+}
@@ -39,8 +39,11 @@ func init() {
 		Categories: []string{
 			string(output.CategoryServices),
 		},
-		Summary:     `Inserts items into a MongoDB collection.`,
-		Description: ioutput.Description(true, true, ""),
+		Summary: `Inserts items into a MongoDB collection.`,
+		Description: ioutput.Description(true, true, `
+Each batch of messages is written with a single bulk write. When `+"`ordered`"+` is true the operations of a batch are executed in order and the bulk write stops at the first failed operation, otherwise the remaining operations are attempted regardless of failures.
+
+Operations that fail are mapped back to the messages of the batch so that only those messages are retried. Operations that fail due to duplicate key errors, or messages that fail to be mapped into an operation, are considered terminal as attempting them again would not succeed.`),
 		Config: docs.FieldComponent().WithChildren(
 			client.ConfigDocs().Add(
 				docs.FieldCommon(
@@ -72,6 +75,18 @@ func init() {
 						"except insert-one. It is used to improve performance of finding the documents in the mongodb.",
 					mapExamples()...,
 				).Linter(docs.LintBloblangMapping),
+				docs.FieldCommon(
+					"upsert",
+					"Whether the operations replace-one and update-one should insert a new document when the filter does not match an existing document.",
+				).HasDefault(false).AtVersion("3.50.0"),
+				docs.FieldAdvanced(
+					"ordered",
+					"Whether the operations of a batch should be executed in order, where the bulk write stops at the first operation that fails. Unordered bulk writes can be executed in parallel by the server and attempt all operations regardless of failures.",
+				).HasDefault(true).AtVersion("3.50.0"),
+				docs.FieldAdvanced(
+					"max_time_ms",
+					"The maximum time in milliseconds that a bulk write is allowed to take, after which it is abandoned and the batch is attempted again. Set to zero for no limit.",
+				).HasDefault(0).AtVersion("3.50.0"),
 				docs.FieldCommon(
 					"max_in_flight",
 					"The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
@@ -124,7 +139,7 @@ func NewWriter(
 	var filterNeeded, documentNeeded bool
 	var hintAllowed bool
 
-	if _, ok := documentMapOps[conf.Operation]; !ok {
+	if _, ok := documentMapOps[conf.Operation]; !ok || conf.Operation == "find-one" {
 		return nil, fmt.Errorf("mongodb operation '%s' unknown: must be insert-one, delete-one, delete-many, replace-one, or update-one", conf.Operation)
 	}
 
@@ -164,6 +179,14 @@ func NewWriter(
 		return nil, fmt.Errorf("mongodb hint_map not allowed for '%s' operation", conf.Operation)
 	}
 
+	if conf.Upsert && conf.Operation != "replace-one" && conf.Operation != "update-one" {
+		return nil, fmt.Errorf("mongodb upsert not allowed for '%s' operation", conf.Operation)
+	}
+
+	if conf.MaxTimeMS < 0 {
+		return nil, errors.New("mongodb max_time_ms must not be negative")
+	}
+
 	if db.wcTimeout, err = time.ParseDuration(conf.WriteConcern.WTimeout); err != nil {
 		return nil, fmt.Errorf("failed to parse write concern wtimeout string: %v", err)
 	}
@@ -219,11 +242,12 @@ func (m *Writer) ConnectWithContext(ctx context.Context) error {
 		writeconcern.J(m.conf.WriteConcern.J),
 		writeconcern.WTimeout(m.wcTimeout))
 
-	w, err := strconv.Atoi(m.conf.WriteConcern.W)
-	if err != nil {
-		writeconcern.WTagSet(m.conf.WriteConcern.W)
-	} else {
-		writeconcern.W(w)(writeConcern)
+	if wStr := m.conf.WriteConcern.W; wStr != "" {
+		if w, err := strconv.Atoi(wStr); err != nil {
+			writeconcern.WTagSet(wStr)(writeConcern)
+		} else {
+			writeconcern.W(w)(writeConcern)
+		}
 	}
 
 	// This does some validation so we don't have to
@@ -249,7 +273,10 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 		return types.ErrNotConnected
 	}
 
+	// The batch index of each write model, as messages that fail to be mapped
+	// are excluded from the bulk write.
 	var writeModels []mongo.WriteModel
+	var modelIndexes []int
 	err := writer.IterateBatchedSend(msg, func(i int, _ types.Part) error {
 		var err error
 		var filterVal, documentVal types.Part
@@ -260,50 +287,50 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 
 		if filterValWanted {
 			if filterVal, err = m.filterMap.MapPart(i, msg); err != nil {
-				return fmt.Errorf("failed to execute filter_map: %v", err)
+				return ioutput.NewTerminalError(fmt.Errorf("failed to execute filter_map: %v", err))
 			}
 		}
 
 		if (filterVal != nil || !filterValWanted) && documentValWanted {
 			if documentVal, err = m.documentMap.MapPart(i, msg); err != nil {
-				return fmt.Errorf("failed to execute document_map: %v", err)
+				return ioutput.NewTerminalError(fmt.Errorf("failed to execute document_map: %v", err))
 			}
 		}
 
 		if filterVal == nil && filterValWanted {
-			return fmt.Errorf("failed to generate filterVal")
+			return ioutput.NewTerminalError(errors.New("failed to generate filterVal"))
 		}
 
 		if documentVal == nil && documentValWanted {
-			return fmt.Errorf("failed to generate documentVal")
+			return ioutput.NewTerminalError(errors.New("failed to generate documentVal"))
 		}
 
 		var docJSON, filterJSON, hintJSON interface{}
 
 		if filterValWanted {
 			if filterJSON, err = filterVal.JSON(); err != nil {
-				return err
+				return ioutput.NewTerminalError(err)
 			}
 		}
 
 		if documentValWanted {
 			if docJSON, err = documentVal.JSON(); err != nil {
-				return err
+				return ioutput.NewTerminalError(err)
 			}
 		}
 
 		if m.hintMap != nil {
 			hintVal, err := m.hintMap.MapPart(i, msg)
 			if err != nil {
-				return fmt.Errorf("failed to execute hint_map: %v", err)
+				return ioutput.NewTerminalError(fmt.Errorf("failed to execute hint_map: %v", err))
 			}
 			if hintJSON, err = hintVal.JSON(); err != nil {
-				return err
+				return ioutput.NewTerminalError(err)
 			}
 		}
 
 		var writeModel mongo.WriteModel
-		upsertFalse := false
+		upsert := m.conf.Upsert
 		switch m.conf.Operation {
 		case "insert-one":
 			writeModel = &mongo.InsertOneModel{
@@ -321,14 +348,14 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 			}
 		case "replace-one":
 			writeModel = &mongo.ReplaceOneModel{
-				Upsert:      &upsertFalse,
+				Upsert:      &upsert,
 				Filter:      filterJSON,
 				Replacement: docJSON,
 				Hint:        hintJSON,
 			}
 		case "update-one":
 			writeModel = &mongo.UpdateOneModel{
-				Upsert: &upsertFalse,
+				Upsert: &upsert,
 				Filter: filterJSON,
 				Update: docJSON,
 				Hint:   hintJSON,
@@ -337,6 +364,7 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 
 		if writeModel != nil {
 			writeModels = append(writeModels, writeModel)
+			modelIndexes = append(modelIndexes, i)
 		}
 		return nil
 	})
@@ -349,8 +377,14 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 	}
 
 	if len(writeModels) > 0 {
-		if _, err = collection.BulkWrite(ctx, writeModels); err != nil {
-			return err
+		if m.conf.MaxTimeMS > 0 {
+			var done func()
+			ctx, done = context.WithTimeout(ctx, time.Duration(m.conf.MaxTimeMS)*time.Millisecond)
+			defer done()
+		}
+		opts := options.BulkWrite().SetOrdered(m.conf.Ordered)
+		if _, err = collection.BulkWrite(ctx, writeModels, opts); err != nil {
+			return bulkWriteError(msg, batchErr, modelIndexes, m.conf.Ordered, err)
 		}
 	}
 
@@ -360,6 +394,56 @@ func (m *Writer) WriteWithContext(ctx context.Context, msg types.Message) error
 	return nil
 }
 
+// Duplicate key error codes returned by MongoDB, which indicate that the write
+// conflicts with an existing document and would fail again if retried.
+var duplicateKeyCodes = map[int]struct{}{
+	11000: {},
+	11001: {},
+	12582: {},
+}
+
+// bulkWriteError maps the write errors of a failed bulk write back to the
+// messages of the batch that they originated from. The batch error batchErr, if
+// not nil, contains messages that already failed prior to the bulk write, and
+// modelIndexes is the batch index of each write model of the bulk write.
+//
+// When the bulk write is ordered the server stops at the first failed
+// operation, and therefore all subsequent messages are also failed.
+func bulkWriteError(msg types.Message, batchErr *ibatch.Error, modelIndexes []int, ordered bool, err error) error {
+	var bwErr mongo.BulkWriteException
+	if !errors.As(err, &bwErr) || bwErr.WriteConcernError != nil || len(bwErr.WriteErrors) == 0 {
+		return err
+	}
+
+	if batchErr == nil {
+		batchErr = ibatch.NewError(msg, err)
+	}
+
+	firstFailed := len(modelIndexes)
+	for _, wErr := range bwErr.WriteErrors {
+		if wErr.Index < 0 || wErr.Index >= len(modelIndexes) {
+			// The error can't be attributed to a message, so the whole batch
+			// is failed.
+			return err
+		}
+		var pErr error = wErr.WriteError
+		if _, exists := duplicateKeyCodes[wErr.Code]; exists {
+			pErr = ioutput.NewTerminalError(pErr)
+		}
+		batchErr.Failed(modelIndexes[wErr.Index], pErr)
+		if wErr.Index < firstFailed {
+			firstFailed = wErr.Index
+		}
+	}
+
+	if ordered {
+		for _, i := range modelIndexes[firstFailed+1:] {
+			batchErr.Failed(i, errors.New("operation not attempted due to a prior failure of an ordered bulk write"))
+		}
+	}
+	return batchErr
+}
+
 // CloseAsync begins cleaning up resources used by this writer asynchronously.
 func (m *Writer) CloseAsync() {
 	go func() {
@@ -0,0 +1,127 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWriterConfigValidation(t *testing.T) {
+	newConf := func() output.MongoDBConfig {
+		conf := output.NewMongoDBConfig()
+		conf.MongoConfig.URL = "mongodb://localhost:27017"
+		conf.MongoConfig.Database = "foo"
+		conf.MongoConfig.Collection = "bar"
+		conf.WriteConcern.WTimeout = "1s"
+		conf.FilterMap = "root.id = this.id"
+		conf.DocumentMap = "root = this"
+		return conf
+	}
+
+	conf := newConf()
+	conf.Upsert = true
+	_, err := NewWriter(conf, log.Noop(), metrics.Noop())
+	assert.NoError(t, err)
+
+	conf = newConf()
+	conf.Operation = "delete-one"
+	conf.DocumentMap = ""
+	conf.Upsert = true
+	_, err = NewWriter(conf, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "mongodb upsert not allowed for 'delete-one' operation")
+
+	conf = newConf()
+	conf.Operation = "find-one"
+	_, err = NewWriter(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = newConf()
+	conf.MaxTimeMS = -1
+	_, err = NewWriter(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func bulkWriteException(codes ...int) mongo.BulkWriteException {
+	var bwErr mongo.BulkWriteException
+	for i := 0; i < len(codes); i += 2 {
+		bwErr.WriteErrors = append(bwErr.WriteErrors, mongo.BulkWriteError{
+			WriteError: mongo.WriteError{
+				Index:   codes[i],
+				Code:    codes[i+1],
+				Message: "nope",
+			},
+		})
+	}
+	return bwErr
+}
+
+func failedIndexes(t *testing.T, err error) map[int]ioutput.ErrorClass {
+	t.Helper()
+
+	var bErr *ibatch.Error
+	require.True(t, errors.As(err, &bErr), err)
+
+	failed := map[int]ioutput.ErrorClass{}
+	bErr.WalkParts(func(i int, _ types.Part, pErr error) bool {
+		if pErr != nil {
+			failed[i] = ioutput.ClassifyError(pErr)
+		}
+		return true
+	})
+	return failed
+}
+
+func TestBulkWriteErrorUnordered(t *testing.T) {
+	msg := message.New([][]byte{
+		[]byte("a"), []byte("b"), []byte("c"), []byte("d"),
+	})
+
+	// The message at index 1 failed to map and was not part of the write.
+	batchErr := ibatch.NewError(msg, errors.New("mapping failed"))
+	batchErr.Failed(1, ioutput.NewTerminalError(errors.New("mapping failed")))
+
+	err := bulkWriteError(msg, batchErr, []int{0, 2, 3}, false, bulkWriteException(0, 11000, 2, 2))
+	assert.Equal(t, map[int]ioutput.ErrorClass{
+		0: ioutput.ErrorClassTerminal,
+		1: ioutput.ErrorClassTerminal,
+		3: ioutput.ErrorClassRetryable,
+	}, failedIndexes(t, err))
+}
+
+func TestBulkWriteErrorOrdered(t *testing.T) {
+	msg := message.New([][]byte{
+		[]byte("a"), []byte("b"), []byte("c"), []byte("d"),
+	})
+
+	err := bulkWriteError(msg, nil, []int{0, 1, 2, 3}, true, bulkWriteException(1, 11000))
+	assert.Equal(t, map[int]ioutput.ErrorClass{
+		1: ioutput.ErrorClassTerminal,
+		2: ioutput.ErrorClassRetryable,
+		3: ioutput.ErrorClassRetryable,
+	}, failedIndexes(t, err))
+	assert.Equal(t, ioutput.ErrorClassRetryable, ioutput.ClassifyError(err))
+}
+
+func TestBulkWriteErrorWholeBatch(t *testing.T) {
+	msg := message.New([][]byte{[]byte("a"), []byte("b")})
+
+	connErr := errors.New("connection lost")
+	assert.Equal(t, connErr, bulkWriteError(msg, nil, []int{0, 1}, true, connErr))
+
+	bwErr := bulkWriteException(0, 11000)
+	bwErr.WriteConcernError = &mongo.WriteConcernError{Message: "timed out"}
+	assert.Equal(t, bwErr, bulkWriteError(msg, nil, []int{0, 1}, true, bwErr))
+
+	bwErr = bulkWriteException(5, 2)
+	assert.Equal(t, bwErr, bulkWriteError(msg, nil, []int{0, 1}, true, bwErr))
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/nats-io/nats.go"
+)
+
+// Config contains configuration params for NATS authentication methods that
+// can't be expressed within connection URLs.
+type Config struct {
+	NKeyFile            string `json:"nkey_file" yaml:"nkey_file"`
+	UserCredentialsFile string `json:"user_credentials_file" yaml:"user_credentials_file"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		NKeyFile:            "",
+		UserCredentialsFile: "",
+	}
+}
+
+// Options returns a slice of NATS connection options that apply the configured
+// authentication methods. The auth material files are read when the options are
+// created in order to surface unreadable files early, and the credentials file
+// is read again for each connection attempt so that it can be rotated.
+func (c Config) Options() ([]nats.Option, error) {
+	var opts []nats.Option
+	if c.NKeyFile != "" {
+		opt, err := nats.NkeyOptionFromSeed(c.NKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nkey_file '%v': %w", c.NKeyFile, err)
+		}
+		opts = append(opts, opt)
+	}
+	if c.UserCredentialsFile != "" {
+		if _, err := ioutil.ReadFile(c.UserCredentialsFile); err != nil {
+			return nil, fmt.Errorf("failed to read user_credentials_file '%v': %w", c.UserCredentialsFile, err)
+		}
+		opts = append(opts, nats.UserCredentials(c.UserCredentialsFile))
+	}
+	return opts, nil
+}
+
+// FieldSpec returns documentation for authentication fields.
+func FieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("auth", "Optional configuration of NATS authentication parameters, used in place of or alongside credentials within the connection URLs.").AtVersion("3.50.0").WithChildren(
+		docs.FieldString(
+			"nkey_file", "An optional file containing a NKey seed, used for [NKey authentication](https://docs.nats.io/developing-with-nats/security/nkey).",
+			"./seed.nk",
+		).HasDefault(""),
+		docs.FieldString(
+			"user_credentials_file", "An optional file containing user credentials, consisting of a JWT and a NKey seed, used for [decentralized JWT authentication](https://docs.nats.io/developing-with-nats/security/creds).",
+			"./user.creds",
+		).HasDefault(""),
+	)
+}
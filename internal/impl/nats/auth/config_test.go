@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jwt"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runServer(t *testing.T, opts *server.Options) string {
+	t.Helper()
+
+	opts.Host = "127.0.0.1"
+	opts.Port = -1
+	opts.NoLog = true
+	opts.NoSigs = true
+
+	srv, err := server.NewServer(opts)
+	require.NoError(t, err)
+
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(time.Second*5))
+
+	return srv.ClientURL()
+}
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, content, 0o600))
+	return path
+}
+
+func connect(t *testing.T, url string, conf Config) error {
+	t.Helper()
+
+	opts, err := conf.Options()
+	require.NoError(t, err)
+
+	conn, err := nats.Connect(url, opts...)
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+func TestNKeyAuth(t *testing.T) {
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	pubKey, err := user.PublicKey()
+	require.NoError(t, err)
+
+	seed, err := user.Seed()
+	require.NoError(t, err)
+
+	url := runServer(t, &server.Options{
+		Nkeys: []*server.NkeyUser{{Nkey: pubKey}},
+	})
+
+	conf := NewConfig()
+	assert.Error(t, connect(t, url, conf))
+
+	conf.NKeyFile = writeFile(t, "user.nk", seed)
+	assert.NoError(t, connect(t, url, conf))
+
+	other, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	otherSeed, err := other.Seed()
+	require.NoError(t, err)
+
+	conf.NKeyFile = writeFile(t, "other.nk", otherSeed)
+	assert.Error(t, connect(t, url, conf))
+}
+
+func TestUserCredentialsAuth(t *testing.T) {
+	operator, err := nkeys.CreateOperator()
+	require.NoError(t, err)
+
+	operatorPub, err := operator.PublicKey()
+	require.NoError(t, err)
+
+	account, err := nkeys.CreateAccount()
+	require.NoError(t, err)
+
+	accountPub, err := account.PublicKey()
+	require.NoError(t, err)
+
+	accountJWT, err := jwt.NewAccountClaims(accountPub).Encode(operator)
+	require.NoError(t, err)
+
+	resolver := &server.MemAccResolver{}
+	require.NoError(t, resolver.Store(accountPub, accountJWT))
+
+	url := runServer(t, &server.Options{
+		TrustedKeys:     []string{operatorPub},
+		AccountResolver: resolver,
+	})
+
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	userPub, err := user.PublicKey()
+	require.NoError(t, err)
+
+	userSeed, err := user.Seed()
+	require.NoError(t, err)
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(account)
+	require.NoError(t, err)
+
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	require.NoError(t, err)
+
+	conf := NewConfig()
+	assert.Error(t, connect(t, url, conf))
+
+	conf.UserCredentialsFile = writeFile(t, "user.creds", creds)
+	assert.NoError(t, connect(t, url, conf))
+}
+
+func TestUnreadableFiles(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope")
+
+	conf := NewConfig()
+	conf.NKeyFile = missing
+
+	_, err := conf.Options()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read nkey_file '"+missing+"'")
+
+	conf = NewConfig()
+	conf.NKeyFile = writeFile(t, "bad.nk", []byte("not a seed"))
+
+	_, err = conf.Options()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read nkey_file")
+
+	conf = NewConfig()
+	conf.UserCredentialsFile = missing
+
+	_, err = conf.Options()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read user_credentials_file '"+missing+"'")
+}
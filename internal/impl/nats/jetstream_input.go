@@ -10,6 +10,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
@@ -28,7 +29,11 @@ func init() {
 		if a, err = newJetStreamReader(c.NATSJetStream, nm.Logger(), nm.Metrics()); err != nil {
 			return nil, err
 		}
-		return input.NewAsyncReader(input.TypeNATSStream, false, a, nm.Logger(), nm.Metrics())
+		nackOpt, err := input.AsyncReaderNackBackoff(c.NATSJetStream.NackBackoff)
+		if err != nil {
+			return nil, err
+		}
+		return input.NewAsyncReader(input.TypeNATSStream, false, a, nm.Logger(), nm.Metrics(), nackOpt)
 	}), docs.ComponentSpec{
 		Name:    input.TypeNATSJetStream,
 		Type:    docs.TypeInput,
@@ -70,6 +75,8 @@ You can access these metadata fields using
 			),
 			docs.FieldAdvanced("max_ack_pending", "The maximum number of outstanding acks to be allowed before consuming is halted."),
 			btls.FieldSpec(),
+			auth.FieldSpec(),
+			reader.NackBackoffFieldSpec(),
 		).ChildDefaultAndTypesFromStruct(input.NewNATSJetStreamConfig()),
 	})
 }
@@ -81,6 +88,7 @@ type jetStreamReader struct {
 	conf       input.NATSJetStreamConfig
 	deliverOpt nats.SubOpt
 	tlsConf    *tls.Config
+	authOpts   []nats.Option
 
 	stats metrics.Type
 	log   log.Modular
@@ -106,6 +114,9 @@ func newJetStreamReader(conf input.NATSJetStreamConfig, log log.Modular, stats m
 			return nil, err
 		}
 	}
+	if j.authOpts, err = conf.Auth.Options(); err != nil {
+		return nil, err
+	}
 	switch conf.Deliver {
 	case "all":
 		j.deliverOpt = nats.DeliverAll()
@@ -146,6 +157,7 @@ func (j *jetStreamReader) ConnectWithContext(ctx context.Context) error {
 	if j.tlsConf != nil {
 		opts = append(opts, nats.Secure(j.tlsConf))
 	}
+	opts = append(opts, j.authOpts...)
 	if natsConn, err = nats.Connect(j.urls, opts...); err != nil {
 		return err
 	}
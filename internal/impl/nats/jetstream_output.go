@@ -12,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -52,6 +53,7 @@ func init() {
 			docs.FieldCommon("subject", "A subject to write to.").IsInterpolated(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			btls.FieldSpec(),
+			auth.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewNATSJetStreamConfig()),
 	})
 }
@@ -59,9 +61,10 @@ func init() {
 //------------------------------------------------------------------------------
 
 type jetStreamOutput struct {
-	urls    string
-	conf    output.NATSJetStreamConfig
-	tlsConf *tls.Config
+	urls     string
+	conf     output.NATSJetStreamConfig
+	tlsConf  *tls.Config
+	authOpts []nats.Option
 
 	subjectStr *field.Expression
 
@@ -89,6 +92,9 @@ func newJetStreamOutput(conf output.NATSJetStreamConfig, log log.Modular, stats
 			return nil, err
 		}
 	}
+	if j.authOpts, err = conf.Auth.Options(); err != nil {
+		return nil, err
+	}
 	if j.subjectStr, err = bloblang.NewField(conf.Subject); err != nil {
 		return nil, fmt.Errorf("subject expression: %w", err)
 	}
@@ -119,6 +125,7 @@ func (j *jetStreamOutput) ConnectWithContext(ctx context.Context) error {
 	if j.tlsConf != nil {
 		opts = append(opts, nats.Secure(j.tlsConf))
 	}
+	opts = append(opts, j.authOpts...)
 	if natsConn, err = nats.Connect(j.urls, opts...); err != nil {
 		return err
 	}
@@ -0,0 +1,395 @@
+package wasm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/public/x/service"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const hostModuleName = "benthos_wasm"
+
+func wasmProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Experimental().
+		Categories("Mapping", "Utility").
+		Version("3.50.0").
+		Summary("Executes a function exported by a WebAssembly module for each message.").
+		Description(`
+The module is loaded either from a file path or from a base64 encoded string, and is compiled once when the processor is created. Instances of the module are pooled and reused across messages, with a new instance only being created when all existing instances are busy.
+
+### ABI
+
+The module MUST export its linear memory as `+"`memory`"+`, and an allocation function `+"`allocate(size i32) -> i32`"+` that returns a pointer to a region of guest memory of at least `+"`size`"+` bytes. If the module also exports `+"`deallocate(ptr i32, size i32)`"+` then it will be called in order to free any buffers written by the host once the function call has completed.
+
+For each message the processor writes the raw contents of the message and a JSON object containing its metadata into guest memory, and then calls the configured function with the signature `+"`process(content_ptr i32, content_len i32, meta_ptr i32, meta_len i32) -> i64`"+`. The result is a pointer to the new contents of the message in the upper 32 bits and its length in the lower 32 bits.
+
+The module may import the host function `+"`benthos_wasm.set_error(ptr i32, len i32)`"+` in order to flag the message as having failed, where the provided buffer is used as the error message. Failed messages are left unchanged and can be handled using the patterns outlined [here](/docs/configuration/error_handling).
+
+Modules compiled against WASI are supported, and a reactor initialisation function `+"`_initialize`"+` will be called for each new instance if it is exported.
+
+### Performance
+
+Modules are only executed within the thread that calls them, and therefore the number of module instances is at most the number of parallel processing threads in your pipeline. If a call exceeds the configured `+"`timeout`"+` it is aborted, the instance is discarded, and an error is returned for the message.`).
+		Field(service.NewStringField("path").
+			Description("A path to a WebAssembly module to load.").
+			Example("./transforms/enrich.wasm").
+			Default("")).
+		Field(service.NewStringField("module").
+			Description("A base64 encoded WebAssembly module to load as an alternative to `path`.").
+			Advanced().
+			Default("")).
+		Field(service.NewStringField("function").
+			Description("The name of the exported function to call for each message.").
+			Default("process")).
+		Field(service.NewStringField("timeout").
+			Description("The maximum period of time that a single function call is allowed to execute for before it is aborted.").
+			Default("5s")).
+		Field(service.NewIntField("memory_limit_pages").
+			Description("The maximum number of 64KiB pages of memory that each instance of the module is allowed to allocate.").
+			Advanced().
+			Default(1024)).
+		Example(
+			"Run a Transform",
+			`Given a module compiled from any language targeting WebAssembly that exports the functions described in the ABI above, we can execute it within a pipeline like this:`,
+			`
+pipeline:
+  processors:
+    - wasm:
+        path: ./transforms/redact.wasm
+        function: process
+        timeout: 100ms
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"wasm", wasmProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newWasmProcessorFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func newWasmProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*wasmProcessor, error) {
+	pathStr, err := conf.FieldString("path")
+	if err != nil {
+		return nil, err
+	}
+	moduleStr, err := conf.FieldString("module")
+	if err != nil {
+		return nil, err
+	}
+
+	var moduleBytes []byte
+	switch {
+	case pathStr != "" && moduleStr != "":
+		return nil, errors.New("only one of path or module can be set")
+	case pathStr != "":
+		if moduleBytes, err = ioutil.ReadFile(pathStr); err != nil {
+			return nil, fmt.Errorf("failed to read module: %w", err)
+		}
+	case moduleStr != "":
+		if moduleBytes, err = base64.StdEncoding.DecodeString(moduleStr); err != nil {
+			return nil, fmt.Errorf("failed to decode module: %w", err)
+		}
+	default:
+		return nil, errors.New("either a path or module must be set")
+	}
+
+	funcName, err := conf.FieldString("function")
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutStr, err := conf.FieldString("timeout")
+	if err != nil {
+		return nil, err
+	}
+	var timeout time.Duration
+	if timeoutStr != "" {
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+
+	memLimit, err := conf.FieldInt("memory_limit_pages")
+	if err != nil {
+		return nil, err
+	}
+	return newWasmProcessor(moduleBytes, funcName, timeout, uint32(memLimit), mgr)
+}
+
+//------------------------------------------------------------------------------
+
+type callStateKey struct{}
+
+// callState is stored within the context of each guest function call in order
+// for host functions to communicate back to the processor.
+type callState struct {
+	err error
+}
+
+type wasmProcessor struct {
+	funcName string
+	timeout  time.Duration
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	modConf  wazero.ModuleConfig
+
+	poolMut sync.Mutex
+	pool    []api.Module
+	closed  bool
+
+	mCalls      *service.MetricCounter
+	mInstances  *service.MetricCounter
+	mTimeouts   *service.MetricCounter
+	mGuestError *service.MetricCounter
+}
+
+func newWasmProcessor(moduleBytes []byte, funcName string, timeout time.Duration, memLimitPages uint32, mgr *service.Resources) (*wasmProcessor, error) {
+	ctx := context.Background()
+
+	rConf := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if memLimitPages > 0 {
+		rConf = rConf.WithMemoryLimitPages(memLimitPages)
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, rConf)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	if _, err := runtime.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+			state, ok := ctx.Value(callStateKey{}).(*callState)
+			if !ok {
+				return
+			}
+			errBytes, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				state.err = errors.New("guest error message out of range")
+				return
+			}
+			state.err = errors.New(string(errBytes))
+		}).
+		Export("set_error").
+		Instantiate(ctx); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, moduleBytes)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	if _, exists := compiled.ExportedFunctions()[funcName]; !exists {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("module does not export function '%v'", funcName)
+	}
+	if _, exists := compiled.ExportedFunctions()["allocate"]; !exists {
+		_ = runtime.Close(ctx)
+		return nil, errors.New("module does not export function 'allocate'")
+	}
+
+	w := &wasmProcessor{
+		funcName: funcName,
+		timeout:  timeout,
+
+		runtime:  runtime,
+		compiled: compiled,
+		modConf:  wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"),
+
+		mCalls:      mgr.Metrics().NewCounter("calls"),
+		mInstances:  mgr.Metrics().NewCounter("instances"),
+		mTimeouts:   mgr.Metrics().NewCounter("timeouts"),
+		mGuestError: mgr.Metrics().NewCounter("guest_error"),
+	}
+
+	// Create an initial instance in order to catch instantiation errors early.
+	mod, err := w.newInstance(ctx)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	w.pool = append(w.pool, mod)
+	return w, nil
+}
+
+func (w *wasmProcessor) newInstance(ctx context.Context) (api.Module, error) {
+	mod, err := w.runtime.InstantiateModule(ctx, w.compiled, w.modConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	if mod.Memory() == nil {
+		_ = mod.Close(ctx)
+		return nil, errors.New("module does not export memory")
+	}
+	w.mInstances.Incr(1)
+	return mod, nil
+}
+
+func (w *wasmProcessor) acquire(ctx context.Context) (api.Module, error) {
+	w.poolMut.Lock()
+	if w.closed {
+		w.poolMut.Unlock()
+		return nil, errors.New("processor is closed")
+	}
+	if l := len(w.pool); l > 0 {
+		mod := w.pool[l-1]
+		w.pool = w.pool[:l-1]
+		w.poolMut.Unlock()
+		return mod, nil
+	}
+	w.poolMut.Unlock()
+	return w.newInstance(ctx)
+}
+
+func (w *wasmProcessor) release(mod api.Module) {
+	w.poolMut.Lock()
+	defer w.poolMut.Unlock()
+	if w.closed {
+		_ = mod.Close(context.Background())
+		return
+	}
+	w.pool = append(w.pool, mod)
+}
+
+// writeGuest allocates a buffer within guest memory and writes the provided
+// bytes to it.
+func writeGuest(ctx context.Context, mod api.Module, b []byte) (uint32, error) {
+	res, err := mod.ExportedFunction("allocate").Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, fmt.Errorf("allocate call failed: %w", err)
+	}
+	if len(res) != 1 {
+		return 0, errors.New("allocate returned an unexpected number of results")
+	}
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, b) {
+		return 0, fmt.Errorf("allocated buffer %v of size %v is out of range of memory", ptr, len(b))
+	}
+	return ptr, nil
+}
+
+func (w *wasmProcessor) call(ctx context.Context, mod api.Module, content, meta []byte) ([]byte, error) {
+	contentPtr, err := writeGuest(ctx, mod, content)
+	if err != nil {
+		return nil, err
+	}
+	metaPtr, err := writeGuest(ctx, mod, meta)
+	if err != nil {
+		return nil, err
+	}
+	if dealloc := mod.ExportedFunction("deallocate"); dealloc != nil {
+		defer func() {
+			_, _ = dealloc.Call(ctx, uint64(contentPtr), uint64(len(content)))
+			_, _ = dealloc.Call(ctx, uint64(metaPtr), uint64(len(meta)))
+		}()
+	}
+
+	res, err := mod.ExportedFunction(w.funcName).Call(
+		ctx,
+		uint64(contentPtr), uint64(len(content)),
+		uint64(metaPtr), uint64(len(meta)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, fmt.Errorf("function '%v' returned an unexpected number of results", w.funcName)
+	}
+
+	resPtr, resLen := uint32(res[0]>>32), uint32(res[0])
+	resBytes, ok := mod.Memory().Read(resPtr, resLen)
+	if !ok {
+		return nil, fmt.Errorf("result buffer %v of size %v is out of range of memory", resPtr, resLen)
+	}
+
+	// The returned slice is a view of guest memory, which will be reused by
+	// subsequent calls.
+	resCopy := make([]byte, len(resBytes))
+	copy(resCopy, resBytes)
+	return resCopy, nil
+}
+
+func (w *wasmProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	content, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	metaObj := map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		metaObj[k] = v
+		return nil
+	})
+	meta, err := json.Marshal(metaObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise metadata: %w", err)
+	}
+
+	mod, err := w.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &callState{}
+	callCtx := context.WithValue(ctx, callStateKey{}, state)
+	if w.timeout > 0 {
+		var done func()
+		callCtx, done = context.WithTimeout(callCtx, w.timeout)
+		defer done()
+	}
+
+	w.mCalls.Incr(1)
+	resBytes, err := w.call(callCtx, mod, content, meta)
+	if err != nil {
+		// Once a call has failed the state of the instance is unknown (and if
+		// it was aborted it will have been closed) and so we discard it.
+		_ = mod.Close(context.Background())
+		if callCtx.Err() != nil && ctx.Err() == nil {
+			w.mTimeouts.Incr(1)
+			return nil, fmt.Errorf("function '%v' exceeded timeout of %v", w.funcName, w.timeout)
+		}
+		return nil, fmt.Errorf("function '%v' failed: %w", w.funcName, err)
+	}
+	w.release(mod)
+
+	if state.err != nil {
+		w.mGuestError.Incr(1)
+		return nil, state.err
+	}
+
+	newMsg := msg.Copy()
+	newMsg.SetBytes(resBytes)
+	return service.MessageBatch{newMsg}, nil
+}
+
+func (w *wasmProcessor) Close(ctx context.Context) error {
+	w.poolMut.Lock()
+	w.closed = true
+	w.pool = nil
+	w.poolMut.Unlock()
+	return w.runtime.Close(ctx)
+}
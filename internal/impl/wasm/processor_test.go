@@ -0,0 +1,166 @@
+package wasm
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/public/x/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testModule is a hand assembled module equivalent to the following:
+//
+//	(module
+//	  (import "benthos_wasm" "set_error" (func $set_error (param i32 i32)))
+//	  (memory (export "memory") 1)
+//	  (global $heap (mut i32) (i32.const 1024))
+//	  (func (export "allocate") (param i32) (result i32)
+//	    global.get $heap
+//	    global.get $heap local.get 0 i32.add global.set $heap)
+//	  (func (export "echo") (param i32 i32 i32 i32) (result i64)
+//	    local.get 0 i64.extend_i32_u i64.const 32 i64.shl
+//	    local.get 1 i64.extend_i32_u i64.or)
+//	  (func (export "meta") (param i32 i32 i32 i32) (result i64)
+//	    local.get 2 i64.extend_i32_u i64.const 32 i64.shl
+//	    local.get 3 i64.extend_i32_u i64.or)
+//	  (func (export "fail") (param i32 i32 i32 i32) (result i64)
+//	    local.get 0 local.get 1 call $set_error i64.const 0)
+//	  (func (export "spin") (param i32 i32 i32 i32) (result i64)
+//	    (loop br 0) i64.const 0))
+var testModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x13, 0x03, 0x60, 0x01, 0x7f, 0x01, 0x7f,
+	0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7e, 0x60, 0x02, 0x7f, 0x7f, 0x00, 0x02, 0x1a, 0x01,
+	0x0c, 0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x5f, 0x77, 0x61, 0x73, 0x6d, 0x09, 0x73, 0x65,
+	0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x00, 0x02, 0x03, 0x06, 0x05, 0x00, 0x01, 0x01, 0x01,
+	0x01, 0x05, 0x03, 0x01, 0x00, 0x01, 0x06, 0x07, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b, 0x07,
+	0x31, 0x06, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00, 0x08, 0x61, 0x6c, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x65, 0x00, 0x01, 0x04, 0x65, 0x63, 0x68, 0x6f, 0x00, 0x02, 0x04, 0x6d, 0x65,
+	0x74, 0x61, 0x00, 0x03, 0x04, 0x66, 0x61, 0x69, 0x6c, 0x00, 0x04, 0x04, 0x73, 0x70, 0x69, 0x6e,
+	0x00, 0x05, 0x0a, 0x3c, 0x05, 0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00,
+	0x0b, 0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b, 0x0c, 0x00,
+	0x20, 0x02, 0xad, 0x42, 0x20, 0x86, 0x20, 0x03, 0xad, 0x84, 0x0b, 0x0a, 0x00, 0x20, 0x00, 0x20,
+	0x01, 0x10, 0x00, 0x42, 0x00, 0x0b, 0x09, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b,
+}
+
+func testProcessor(t *testing.T, funcName string, timeout time.Duration) *wasmProcessor {
+	t.Helper()
+
+	proc, err := newWasmProcessor(testModule, funcName, timeout, 0, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+	return proc
+}
+
+func TestWasmProcessorEcho(t *testing.T) {
+	proc := testProcessor(t, "echo", time.Second)
+
+	for _, input := range []string{"hello world", "", "another message"} {
+		inMsg := service.NewMessage([]byte(input))
+		inMsg.MetaSet("foo", "bar")
+
+		res, err := proc.Process(context.Background(), inMsg)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		b, err := res[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, input, string(b))
+
+		v, _ := res[0].MetaGet("foo")
+		assert.Equal(t, "bar", v)
+	}
+}
+
+func TestWasmProcessorMetadata(t *testing.T) {
+	proc := testProcessor(t, "meta", time.Second)
+
+	inMsg := service.NewMessage([]byte("hello world"))
+	inMsg.MetaSet("foo", "bar")
+	inMsg.MetaSet("baz", "buz")
+
+	res, err := proc.Process(context.Background(), inMsg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"baz":"buz","foo":"bar"}`, string(b))
+}
+
+func TestWasmProcessorGuestError(t *testing.T) {
+	proc := testProcessor(t, "fail", time.Second)
+
+	_, err := proc.Process(context.Background(), service.NewMessage([]byte("this went wrong")))
+	require.EqualError(t, err, "this went wrong")
+
+	// The instance should still be usable after a guest error.
+	proc.funcName = "echo"
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestWasmProcessorTimeout(t *testing.T) {
+	proc := testProcessor(t, "spin", time.Millisecond*50)
+
+	_, err := proc.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded timeout")
+
+	// A fresh instance should replace the aborted one.
+	proc.funcName = "echo"
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestWasmProcessorBadConfig(t *testing.T) {
+	_, err := newWasmProcessor(testModule, "nope", time.Second, 0, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not export function 'nope'")
+
+	_, err = newWasmProcessor([]byte("not a module"), "echo", time.Second, 0, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile module")
+}
+
+func TestWasmProcessorStream(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(testModule)
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+generate:
+  count: 3
+  interval: ""
+  mapping: 'root = "hello world"'
+`))
+	require.NoError(t, b.AddProcessorYAML(`
+wasm:
+  module: `+encoded+`
+  function: echo
+`))
+
+	var outMsgs []string
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		outMsgs = append(outMsgs, string(b))
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+	require.NoError(t, strm.Run(context.Background()))
+
+	assert.Equal(t, []string{"hello world", "hello world", "hello world"}, outMsgs)
+}
@@ -0,0 +1,21 @@
+package interop
+
+import (
+	"net/http"
+
+	"github.com/Jeffail/benthos/v3/lib/api"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// RegisterEndpointSpec attempts to register an HTTP endpoint along with a spec
+// describing its operations. If the manager does not support endpoint specs
+// then the endpoint is registered with its path and description only.
+func RegisterEndpointSpec(mgr types.Manager, spec api.EndpointSpec, h http.HandlerFunc) {
+	if nm, ok := mgr.(interface {
+		RegisterEndpointSpec(spec api.EndpointSpec, h http.HandlerFunc)
+	}); ok {
+		nm.RegisterEndpointSpec(spec, h)
+		return
+	}
+	mgr.RegisterEndpoint(spec.Path, spec.Description, h)
+}
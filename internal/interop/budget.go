@@ -0,0 +1,31 @@
+package interop
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// GetMemoryBudget attempts to obtain the service wide memory budget of message
+// bytes from a manager, returning nil when either the manager does not support
+// it or no limit is configured.
+func GetMemoryBudget(mgr types.Manager) *budget.Memory {
+	if m, ok := mgr.(interface {
+		MemoryBudget() *budget.Memory
+	}); ok {
+		return m.MemoryBudget()
+	}
+	return nil
+}
+
+// GetConcurrencyLimit attempts to obtain a semaphore with a maximum number of
+// slots from a manager that is shared by all instances of the current component
+// label. If the manager does not support shared limits then a semaphore is
+// created that is exclusive to the caller.
+func GetConcurrencyLimit(mgr types.Manager, max int) *budget.Concurrency {
+	if m, ok := mgr.(interface {
+		ConcurrencyLimit(max int) *budget.Concurrency
+	}); ok {
+		return m.ConcurrencyLimit(max)
+	}
+	return budget.NewConcurrency(max)
+}
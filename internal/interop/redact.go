@@ -0,0 +1,18 @@
+package interop
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// GetRedactor attempts to obtain the redactor of message contents from a
+// manager, returning nil when either the manager does not support redaction or
+// no redaction rules are configured.
+func GetRedactor(mgr types.Manager) *redact.Redactor {
+	if m, ok := mgr.(interface {
+		Redactor() *redact.Redactor
+	}); ok {
+		return m.Redactor()
+	}
+	return nil
+}
@@ -77,6 +77,24 @@ func AccessInput(ctx context.Context, mgr types.Manager, name string, fn func(ty
 	return errors.New("manager does not support input resources")
 }
 
+// ErrInputSubscriptionsUnsupported is returned by SubscribeInput when the
+// manager does not support subscribing to input resources.
+var ErrInputSubscriptionsUnsupported = errors.New("manager does not support input resource subscriptions")
+
+// SubscribeInput attempts to subscribe to an input resource by a unique
+// identifier, returning a channel that receives every message consumed by the
+// input along with a function that cancels the subscription. Returns
+// ErrInputSubscriptionsUnsupported if the manager does not support
+// subscriptions, in which case the input should be accessed directly.
+func SubscribeInput(ctx context.Context, mgr types.Manager, name string) (<-chan types.Transaction, func(), error) {
+	if nm, ok := mgr.(interface {
+		SubscribeInput(name string) (<-chan types.Transaction, func(), error)
+	}); ok {
+		return nm.SubscribeInput(name)
+	}
+	return nil, nil, ErrInputSubscriptionsUnsupported
+}
+
 // ProbeOutput checks whether an output resource has been configured, and
 // returns an error if not.
 func ProbeOutput(ctx context.Context, mgr types.Manager, name string) error {
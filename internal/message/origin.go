@@ -0,0 +1,48 @@
+package message
+
+import (
+	"context"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+type originKeyType int
+
+const originKey originKeyType = iota
+
+type originBatch struct {
+	batch types.Message
+	index int
+}
+
+// WithOriginBatch returns a message part with a reference to the batch that it
+// originated from and its index within that batch. This allows processors that
+// execute on a subset of a batch to expose the wider batch to their children.
+func WithOriginBatch(p types.Part, batch types.Message, index int) types.Part {
+	ctx := context.WithValue(message.GetContext(p), originKey, &originBatch{
+		batch: batch,
+		index: index,
+	})
+	return message.WithContext(ctx, p)
+}
+
+// WithoutOriginBatch returns a message part with any reference to an origin
+// batch removed.
+func WithoutOriginBatch(p types.Part) types.Part {
+	ctx := message.GetContext(p)
+	if v, _ := ctx.Value(originKey).(*originBatch); v == nil {
+		return p
+	}
+	return message.WithContext(context.WithValue(ctx, originKey, (*originBatch)(nil)), p)
+}
+
+// GetOriginBatch returns the batch that a message part originated from and its
+// index within that batch. Returns false if the part has no origin batch.
+func GetOriginBatch(p types.Part) (types.Message, int, bool) {
+	v, _ := message.GetContext(p).Value(originKey).(*originBatch)
+	if v == nil {
+		return nil, 0, false
+	}
+	return v.batch, v.index, true
+}
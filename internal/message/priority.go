@@ -0,0 +1,42 @@
+package message
+
+import (
+	"context"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+type priorityKeyType int
+
+const priorityKey priorityKeyType = iota
+
+// WithPriority returns a copy of a message part with an integer priority
+// attached to it. Priorities are held within the context of the part and are
+// therefore not visible as metadata, but are preserved when the part is copied.
+func WithPriority(p types.Part, priority int) types.Part {
+	ctx := message.GetContext(p)
+	return message.WithContext(context.WithValue(ctx, priorityKey, priority), p)
+}
+
+// GetPriority returns the priority of a message part, or zero if a priority
+// has not been assigned.
+func GetPriority(p types.Part) int {
+	if v, ok := message.GetContext(p).Value(priorityKey).(int); ok {
+		return v
+	}
+	return 0
+}
+
+// GetBatchPriority returns the highest priority of all parts of a message, or
+// zero if the message is empty.
+func GetBatchPriority(msg types.Message) int {
+	priority := 0
+	_ = msg.Iter(func(i int, p types.Part) error {
+		if pri := GetPriority(p); i == 0 || pri > priority {
+			priority = pri
+		}
+		return nil
+	})
+	return priority
+}
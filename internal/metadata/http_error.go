@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// HTTP error metadata keys set on messages that failed due to an unexpected
+// HTTP response.
+const (
+	HTTPStatusKey    = "http_status"
+	HTTPErrorBodyKey = "http_error_body"
+)
+
+// SetHTTPError sets the http_status and http_error_body metadata fields of a
+// message part when an error was caused by an unexpected HTTP response. The
+// body field is only set when the body of the response was captured, and the
+// part is left unchanged when the error was not caused by a response.
+func SetHTTPError(p types.Part, err error) {
+	var resErr types.ErrUnexpectedHTTPRes
+	if err == nil || !errors.As(err, &resErr) {
+		return
+	}
+	p.Metadata().Set(HTTPStatusKey, strconv.Itoa(resErr.Code))
+	if len(resErr.Body) > 0 {
+		p.Metadata().Set(HTTPErrorBodyKey, resErr.BodyString())
+	}
+}
+
+// CopyHTTPError copies the http_status and http_error_body metadata fields from
+// one message part to another when they are set.
+func CopyHTTPError(dst, src types.Part) {
+	for _, k := range []string{HTTPStatusKey, HTTPErrorBodyKey} {
+		if v := src.Metadata().Get(k); v != "" {
+			dst.Metadata().Set(k, v)
+		}
+	}
+}
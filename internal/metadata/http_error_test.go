@@ -0,0 +1,37 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHTTPError(t *testing.T) {
+	part := message.NewPart(nil)
+	SetHTTPError(part, errors.New("not an http error"))
+	assert.Equal(t, "", part.Metadata().Get(HTTPStatusKey))
+
+	SetHTTPError(part, fmt.Errorf("wrapped: %w", types.ErrUnexpectedHTTPRes{
+		Code: 400,
+		S:    "400 Bad Request",
+	}))
+	assert.Equal(t, "400", part.Metadata().Get(HTTPStatusKey))
+	assert.Equal(t, "", part.Metadata().Get(HTTPErrorBodyKey))
+
+	SetHTTPError(part, types.ErrUnexpectedHTTPRes{
+		Code: 422,
+		S:    "422 Unprocessable Entity",
+		Body: []byte{0x00, 0xff},
+	})
+	assert.Equal(t, "422", part.Metadata().Get(HTTPStatusKey))
+	assert.Equal(t, "AP8=", part.Metadata().Get(HTTPErrorBodyKey))
+
+	dst := message.NewPart(nil)
+	CopyHTTPError(dst, part)
+	assert.Equal(t, "422", dst.Metadata().Get(HTTPStatusKey))
+	assert.Equal(t, "AP8=", dst.Metadata().Get(HTTPErrorBodyKey))
+}
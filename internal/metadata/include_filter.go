@@ -0,0 +1,92 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// IncludeFilterDocs returns the docs specs of the fields of an
+// IncludeFilterConfig.
+func IncludeFilterDocs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("include_prefixes", "Provide a list of explicit metadata key prefixes to match against.").Array().HasDefault([]string{}),
+		docs.FieldString("include_patterns", "Provide a list of explicit metadata key regular expression (re2) patterns to match against.").Array().HasDefault([]string{}),
+	}
+}
+
+// IncludeFilterConfig describes a set of rules for selecting metadata keys,
+// where a key is selected if it matches any of the prefixes or patterns.
+type IncludeFilterConfig struct {
+	IncludePrefixes []string `json:"include_prefixes" yaml:"include_prefixes"`
+	IncludePatterns []string `json:"include_patterns" yaml:"include_patterns"`
+}
+
+// NewIncludeFilterConfig returns an IncludeFilterConfig struct with default
+// values, which matches no keys.
+func NewIncludeFilterConfig() IncludeFilterConfig {
+	return IncludeFilterConfig{
+		IncludePrefixes: []string{},
+		IncludePatterns: []string{},
+	}
+}
+
+// IncludeFilter selects metadata keys that match a set of prefixes and
+// patterns.
+type IncludeFilter struct {
+	includePrefixes []string
+	includePatterns []*regexp.Regexp
+}
+
+// NewIncludeFilter attempts to construct an IncludeFilter from a config.
+func NewIncludeFilter(conf IncludeFilterConfig) (*IncludeFilter, error) {
+	f := &IncludeFilter{
+		includePrefixes: conf.IncludePrefixes,
+	}
+	for _, pattern := range conf.IncludePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regexp %q: %w", pattern, err)
+		}
+		f.includePatterns = append(f.includePatterns, re)
+	}
+	return f, nil
+}
+
+// IsSet returns true if the filter has any rules configured, and therefore
+// could match keys.
+func (f *IncludeFilter) IsSet() bool {
+	return len(f.includePrefixes) > 0 || len(f.includePatterns) > 0
+}
+
+// Match returns true if a metadata key matches any of the rules of the filter.
+func (f *IncludeFilter) Match(key string) bool {
+	for _, prefix := range f.includePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, re := range f.includePatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Iter calls a closure for each metadata key value pair of a message part that
+// matches the filter.
+func (f *IncludeFilter) Iter(p types.Part, fn func(k, v string) error) error {
+	if !f.IsSet() {
+		return nil
+	}
+	return p.Metadata().Iter(func(k, v string) error {
+		if !f.Match(k) {
+			return nil
+		}
+		return fn(k, v)
+	})
+}
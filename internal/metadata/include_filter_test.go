@@ -0,0 +1,56 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncludeFilter(t *testing.T) {
+	conf := NewIncludeFilterConfig()
+	conf.IncludePrefixes = []string{"foo_"}
+	conf.IncludePatterns = []string{"^ba[rz]$"}
+
+	f, err := NewIncludeFilter(conf)
+	require.NoError(t, err)
+	assert.True(t, f.IsSet())
+
+	part := message.NewPart(nil)
+	for _, k := range []string{"foo_a", "a_foo", "bar", "baz", "barz"} {
+		part.Metadata().Set(k, k+"_value")
+	}
+
+	matched := map[string]string{}
+	require.NoError(t, f.Iter(part, func(k, v string) error {
+		matched[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]string{
+		"foo_a": "foo_a_value",
+		"bar":   "bar_value",
+		"baz":   "baz_value",
+	}, matched)
+}
+
+func TestIncludeFilterEmpty(t *testing.T) {
+	f, err := NewIncludeFilter(NewIncludeFilterConfig())
+	require.NoError(t, err)
+	assert.False(t, f.IsSet())
+
+	part := message.NewPart(nil)
+	part.Metadata().Set("foo", "bar")
+	require.NoError(t, f.Iter(part, func(k, v string) error {
+		t.Errorf("Unexpected key: %v", k)
+		return nil
+	}))
+}
+
+func TestIncludeFilterBadPattern(t *testing.T) {
+	conf := NewIncludeFilterConfig()
+	conf.IncludePatterns = []string{"("}
+
+	_, err := NewIncludeFilter(conf)
+	require.Error(t, err)
+}
@@ -0,0 +1,236 @@
+// Package redact provides a mechanism for masking sensitive parts of message
+// contents before they are serialised for observability purposes, such as logs
+// and trace captures. Redaction is never applied to the messages themselves.
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/gabs/v2"
+)
+
+// Redaction modes.
+const (
+	ModeMask    = "mask"
+	ModePartial = "partial"
+	ModeHash    = "hash"
+)
+
+const (
+	maskString   = "********"
+	partialChars = 4
+)
+
+// RuleConfig describes a rule that identifies a sensitive part of a message
+// and how it should be masked.
+type RuleConfig struct {
+	Path  string `json:"path" yaml:"path"`
+	Regex string `json:"regex" yaml:"regex"`
+	Mode  string `json:"mode" yaml:"mode"`
+}
+
+// NewRuleConfig returns a RuleConfig with default values.
+func NewRuleConfig() RuleConfig {
+	return RuleConfig{
+		Path:  "",
+		Regex: "",
+		Mode:  ModeMask,
+	}
+}
+
+// Spec returns the field specs of a redaction rule.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("path", "A [dot path](/docs/configuration/field_paths) identifying a field of structured (JSON) message contents to redact, where the segment `*` matches any key of an object or any index of an array.", "user.password", "cards.*.number").HasDefault(""),
+		docs.FieldString("regex", "A regular expression where any matches within message contents are redacted.", `\b\d{3}-\d{2}-\d{4}\b`).HasDefault(""),
+		docs.FieldString("mode", "How matched values are redacted.").HasAnnotatedOptions(
+			ModeMask, "Replace the value entirely with asterisks.",
+			ModePartial, "Replace all but the last four characters of the value with asterisks.",
+			ModeHash, "Replace the value with the hex encoded SHA-256 hash of it, allowing values to be correlated without being revealed.",
+		).HasDefault(ModeMask),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type pathRule struct {
+	path []string
+	mode string
+}
+
+type regexRule struct {
+	re   *regexp.Regexp
+	mode string
+}
+
+// Redactor applies a set of redaction rules to copies of message contents.
+// A nil *Redactor is valid and leaves contents unchanged.
+type Redactor struct {
+	paths   []pathRule
+	regexes []regexRule
+}
+
+// New creates a redactor from a list of rules.
+func New(rules []RuleConfig) (*Redactor, error) {
+	r := &Redactor{}
+	for i, rule := range rules {
+		switch rule.Mode {
+		case ModeMask, ModePartial, ModeHash:
+		default:
+			return nil, fmt.Errorf("rule %v: redaction mode not recognised: %v", i, rule.Mode)
+		}
+		if (rule.Path == "") == (rule.Regex == "") {
+			return nil, fmt.Errorf("rule %v: exactly one of path or regex must be specified", i)
+		}
+		if rule.Path != "" {
+			r.paths = append(r.paths, pathRule{
+				path: gabs.DotPathToSlice(rule.Path),
+				mode: rule.Mode,
+			})
+			continue
+		}
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("rule %v: failed to compile regex: %w", i, err)
+		}
+		r.regexes = append(r.regexes, regexRule{re: re, mode: rule.Mode})
+	}
+	return r, nil
+}
+
+// Value returns a redacted form of a single value according to a mode.
+func Value(mode, v string) string {
+	switch mode {
+	case ModePartial:
+		if len(v) <= partialChars {
+			return maskString
+		}
+		return strings.Repeat("*", len(v)-partialChars) + v[len(v)-partialChars:]
+	case ModeHash:
+		h := sha256.Sum256([]byte(v))
+		return hex.EncodeToString(h[:])
+	}
+	return maskString
+}
+
+// Bytes returns a redacted copy of raw message contents. Path rules are
+// applied when the contents are valid JSON, and regex rules are applied to
+// the contents regardless of their format.
+func (r *Redactor) Bytes(b []byte) []byte {
+	if r == nil || (len(r.paths) == 0 && len(r.regexes) == 0) {
+		return b
+	}
+	if len(r.paths) > 0 {
+		b = r.redactJSON(b)
+	}
+	for _, rule := range r.regexes {
+		mode := rule.mode
+		b = rule.re.ReplaceAllFunc(b, func(match []byte) []byte {
+			return []byte(Value(mode, string(match)))
+		})
+	}
+	return b
+}
+
+// String returns a redacted copy of a string, following the same rules as
+// Bytes.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return string(r.Bytes([]byte(s)))
+}
+
+// Metadata returns a redacted copy of a map of metadata values, where only
+// regex rules are applied.
+func (r *Redactor) Metadata(m map[string]string) map[string]string {
+	if r == nil || len(r.regexes) == 0 || m == nil {
+		return m
+	}
+	newM := make(map[string]string, len(m))
+	for k, v := range m {
+		for _, rule := range r.regexes {
+			mode := rule.mode
+			v = rule.re.ReplaceAllStringFunc(v, func(match string) string {
+				return Value(mode, match)
+			})
+		}
+		newM[k] = v
+	}
+	return newM
+}
+
+func (r *Redactor) redactJSON(b []byte) []byte {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return b
+	}
+
+	// Parsing the contents again yields a structure that is not shared with
+	// the message, and therefore can be modified freely.
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+
+	var root interface{}
+	if err := dec.Decode(&root); err != nil || dec.More() {
+		return b
+	}
+	var changed bool
+	for _, rule := range r.paths {
+		var ruleChanged bool
+		root, ruleChanged = redactPath(root, rule.path, rule.mode)
+		changed = changed || ruleChanged
+	}
+	if !changed {
+		return b
+	}
+	newB, err := json.Marshal(root)
+	if err != nil {
+		return b
+	}
+	return newB
+}
+
+func redactPath(v interface{}, path []string, mode string) (interface{}, bool) {
+	if len(path) == 0 {
+		switch t := v.(type) {
+		case nil:
+			return nil, false
+		case string:
+			return Value(mode, t), true
+		case json.Number:
+			return Value(mode, t.String()), true
+		case bool:
+			return Value(mode, strconv.FormatBool(t)), true
+		}
+		// Structured values are masked entirely regardless of the mode.
+		return maskString, true
+	}
+
+	var changed, childChanged bool
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if path[0] == "*" || path[0] == k {
+				t[k], childChanged = redactPath(child, path[1:], mode)
+				changed = changed || childChanged
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				t[i], childChanged = redactPath(child, path[1:], mode)
+				changed = changed || childChanged
+			}
+		}
+	}
+	return v, changed
+}
@@ -0,0 +1,121 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactorBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []RuleConfig
+		input  string
+		output string
+	}{
+		{
+			name:   "mask path",
+			rules:  []RuleConfig{{Path: "user.password", Mode: ModeMask}},
+			input:  `{"user":{"name":"foo","password":"hunter2"}}`,
+			output: `{"user":{"name":"foo","password":"********"}}`,
+		},
+		{
+			name:   "partial wildcard path",
+			rules:  []RuleConfig{{Path: "cards.*.number", Mode: ModePartial}},
+			input:  `{"cards":[{"number":"4111111111111111"},{"number":5500000000000004},{"number":"123"}]}`,
+			output: `{"cards":[{"number":"************1111"},{"number":"************0004"},{"number":"********"}]}`,
+		},
+		{
+			name:   "hash path",
+			rules:  []RuleConfig{{Path: "email", Mode: ModeHash}},
+			input:  `{"email":"foo@example.com"}`,
+			output: `{"email":"` + Value(ModeHash, "foo@example.com") + `"}`,
+		},
+		{
+			name:   "structured value",
+			rules:  []RuleConfig{{Path: "user", Mode: ModeHash}},
+			input:  `{"user":{"name":"foo"}}`,
+			output: `{"user":"********"}`,
+		},
+		{
+			name:   "missing path",
+			rules:  []RuleConfig{{Path: "user.password", Mode: ModeMask}},
+			input:  `{ "user": "foo" }`,
+			output: `{ "user": "foo" }`,
+		},
+		{
+			name:   "path of non-json",
+			rules:  []RuleConfig{{Path: "user.password", Mode: ModeMask}},
+			input:  `user.password: hunter2`,
+			output: `user.password: hunter2`,
+		},
+		{
+			name: "regex",
+			rules: []RuleConfig{
+				{Regex: `\b\d{3}-\d{2}-\d{4}\b`, Mode: ModePartial},
+				{Regex: `secret`, Mode: ModeMask},
+			},
+			input:  `ssn 123-45-6789 and 987-65-4321 are secret`,
+			output: `ssn *******6789 and *******4321 are ********`,
+		},
+		{
+			name: "path and regex",
+			rules: []RuleConfig{
+				{Path: "a", Mode: ModeMask},
+				{Regex: `bar`, Mode: ModeMask},
+			},
+			input:  `{"a":"foo","b":"bar"}`,
+			output: `{"a":"********","b":"********"}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(test.rules)
+			require.NoError(t, err)
+
+			input := []byte(test.input)
+			assert.Equal(t, test.output, string(r.Bytes(input)))
+			assert.Equal(t, test.input, string(input))
+		})
+	}
+}
+
+func TestRedactorMetadata(t *testing.T) {
+	r, err := New([]RuleConfig{
+		{Path: "foo", Mode: ModeMask},
+		{Regex: `token=\w+`, Mode: ModeMask},
+	})
+	require.NoError(t, err)
+
+	meta := map[string]string{
+		"foo": "bar",
+		"url": "/path?token=abc123",
+	}
+	assert.Equal(t, map[string]string{
+		"foo": "bar",
+		"url": "/path?********",
+	}, r.Metadata(meta))
+	assert.Equal(t, "/path?token=abc123", meta["url"])
+}
+
+func TestRedactorNil(t *testing.T) {
+	var r *Redactor
+	assert.Equal(t, "foo", string(r.Bytes([]byte("foo"))))
+	assert.Equal(t, "foo", r.String("foo"))
+	assert.Equal(t, map[string]string{"foo": "bar"}, r.Metadata(map[string]string{"foo": "bar"}))
+}
+
+func TestRedactorConfigErrors(t *testing.T) {
+	for _, rules := range [][]RuleConfig{
+		{{Path: "foo", Mode: "nope"}},
+		{{Mode: ModeMask}},
+		{{Path: "foo", Regex: "foo", Mode: ModeMask}},
+		{{Regex: "(", Mode: ModeMask}},
+	} {
+		_, err := New(rules)
+		assert.Error(t, err, rules)
+	}
+}
@@ -0,0 +1,156 @@
+// Package runtimestats emits metrics describing the Go runtime of a Benthos
+// instance, and allows the sampling rates of runtime profiles to be changed
+// whilst the instance is running.
+package runtimestats
+
+import (
+	"math"
+	"runtime"
+	rmetrics "runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+const schedLatenciesMetric = "/sched/latencies:seconds"
+
+// Collector periodically samples the Go runtime and emits the results as
+// metrics under the namespace runtime.
+type Collector struct {
+	goroutines   metrics.StatGauge
+	heapInUse    metrics.StatGauge
+	gcCount      metrics.StatCounter
+	gcPause      metrics.StatTimer
+	schedLatency metrics.StatGauge
+
+	lastNumGC   uint32
+	schedSample []rmetrics.Sample
+	lastSched   []uint64
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// New creates a collector that samples the Go runtime once immediately and
+// then once every period until it is closed.
+func New(stats metrics.Type, period time.Duration) *Collector {
+	c := &Collector{
+		goroutines:   stats.GetGauge("runtime.goroutines"),
+		heapInUse:    stats.GetGauge("runtime.heap.in_use_bytes"),
+		gcCount:      stats.GetCounter("runtime.gc.count"),
+		gcPause:      stats.GetTimer("runtime.gc.pause_ns"),
+		schedLatency: stats.GetGauge("runtime.scheduler.latency_p99_ns"),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	c.lastNumGC = m.NumGC
+
+	for _, desc := range rmetrics.All() {
+		if desc.Name == schedLatenciesMetric {
+			c.schedSample = []rmetrics.Sample{{Name: schedLatenciesMetric}}
+			break
+		}
+	}
+
+	c.Collect()
+	go c.loop(period)
+	return c
+}
+
+func (c *Collector) loop(period time.Duration) {
+	defer close(c.closedChan)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Collect()
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+// Collect samples the Go runtime and updates the metrics of the collector.
+func (c *Collector) Collect() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	c.goroutines.Set(int64(runtime.NumGoroutine()))
+	c.heapInUse.Set(int64(m.HeapInuse))
+
+	// The pause durations of the most recent garbage collections are kept in a
+	// circular buffer, which might have wrapped since the last collection.
+	bufLen := uint32(len(m.PauseNs))
+	newGCs := m.NumGC - c.lastNumGC
+	if newGCs > 0 {
+		c.gcCount.Incr(int64(newGCs))
+	}
+	if newGCs > bufLen {
+		newGCs = bufLen
+	}
+	for i := uint32(0); i < newGCs; i++ {
+		c.gcPause.Timing(int64(m.PauseNs[(m.NumGC-1-i)%bufLen]))
+	}
+	c.lastNumGC = m.NumGC
+
+	if c.schedSample != nil {
+		c.collectSchedLatency()
+	}
+}
+
+// collectSchedLatency sets the scheduler latency gauge to the 99th percentile
+// of the time goroutines spent waiting to run since the last collection.
+func (c *Collector) collectSchedLatency() {
+	rmetrics.Read(c.schedSample)
+	if c.schedSample[0].Value.Kind() != rmetrics.KindFloat64Histogram {
+		return
+	}
+	hist := c.schedSample[0].Value.Float64Histogram()
+
+	counts := make([]uint64, len(hist.Counts))
+	var total uint64
+	for i, count := range hist.Counts {
+		if i < len(c.lastSched) {
+			count -= c.lastSched[i]
+		}
+		counts[i] = count
+		total += count
+	}
+	c.lastSched = append(c.lastSched[:0], hist.Counts...)
+
+	if total == 0 {
+		c.schedLatency.Set(0)
+		return
+	}
+
+	threshold := uint64(math.Ceil(float64(total) * 0.99))
+	var seen uint64
+	for i, count := range counts {
+		if seen += count; seen < threshold {
+			continue
+		}
+		// Use the upper bound of the bucket unless it is unbounded.
+		bound := hist.Buckets[i+1]
+		if math.IsInf(bound, 1) {
+			bound = hist.Buckets[i]
+		}
+		c.schedLatency.Set(int64(bound * float64(time.Second)))
+		return
+	}
+}
+
+// Close stops the collector and waits for it to finish.
+func (c *Collector) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+	<-c.closedChan
+}
@@ -0,0 +1,37 @@
+package runtimestats
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	stats := metrics.NewLocal()
+
+	c := New(stats, time.Hour)
+	defer c.Close()
+
+	counters := stats.GetCounters()
+	assert.Greater(t, counters["runtime.goroutines"], int64(0))
+	assert.Greater(t, counters["runtime.heap.in_use_bytes"], int64(0))
+	assert.Contains(t, counters, "runtime.scheduler.latency_p99_ns")
+
+	runtime.GC()
+	runtime.GC()
+	c.Collect()
+
+	counters = stats.GetCounters()
+	assert.GreaterOrEqual(t, counters["runtime.gc.count"], int64(2))
+	assert.Contains(t, stats.GetTimings(), "runtime.gc.pause_ns")
+}
+
+func TestCollectorClose(t *testing.T) {
+	c := New(metrics.Noop(), time.Millisecond)
+	<-time.After(time.Millisecond * 10)
+	c.Close()
+	c.Close()
+}
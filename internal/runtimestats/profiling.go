@@ -0,0 +1,158 @@
+package runtimestats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProfileRates describes the sampling rates of the runtime profiles that are
+// disabled or coarse by default.
+type ProfileRates struct {
+	// MemProfileRate is the average number of bytes allocated between each
+	// sampled allocation of the heap and allocs profiles.
+	MemProfileRate int `json:"mem_profile_rate"`
+
+	// BlockProfileRate is the average number of nanoseconds spent blocked
+	// between each sampled event of the block profile, where zero disables the
+	// profile.
+	BlockProfileRate int `json:"block_profile_rate"`
+
+	// MutexProfileFraction is the inverse of the fraction of mutex contention
+	// events sampled by the mutex profile, where zero disables the profile.
+	MutexProfileFraction int `json:"mutex_profile_fraction"`
+}
+
+// Changes to the profile rates are serialised, along with any pending restore
+// of previous rates. The rate of the block profile cannot be read from the
+// runtime and is therefore tracked here, which assumes that it is only ever
+// changed with SetProfileRates.
+var (
+	profileMut       sync.Mutex
+	blockProfileRate int
+	restoreTimer     *time.Timer
+	pendingRestore   ProfileRates
+)
+
+func currentRates() ProfileRates {
+	return ProfileRates{
+		MemProfileRate:       runtime.MemProfileRate,
+		BlockProfileRate:     blockProfileRate,
+		MutexProfileFraction: runtime.SetMutexProfileFraction(-1),
+	}
+}
+
+func applyRates(rates ProfileRates) {
+	runtime.MemProfileRate = rates.MemProfileRate
+	runtime.SetBlockProfileRate(rates.BlockProfileRate)
+	blockProfileRate = rates.BlockProfileRate
+	runtime.SetMutexProfileFraction(rates.MutexProfileFraction)
+}
+
+// GetProfileRates returns the current sampling rates of runtime profiles.
+func GetProfileRates() ProfileRates {
+	profileMut.Lock()
+	defer profileMut.Unlock()
+	return currentRates()
+}
+
+// SetProfileRates changes the sampling rates of runtime profiles. When
+// restoreAfter is greater than zero the previous rates are restored once it
+// has elapsed, which allows expensive profiling to be enabled for a short
+// capture window. Setting the rates again before a pending restore cancels it,
+// and the rates restored are those that preceded the first change.
+func SetProfileRates(rates ProfileRates, restoreAfter time.Duration) {
+	profileMut.Lock()
+	defer profileMut.Unlock()
+
+	previous := currentRates()
+	if restoreTimer != nil {
+		if restoreTimer.Stop() {
+			previous = pendingRestore
+		}
+		restoreTimer = nil
+	}
+
+	applyRates(rates)
+	if restoreAfter <= 0 {
+		return
+	}
+
+	pendingRestore = previous
+	var timer *time.Timer
+	timer = time.AfterFunc(restoreAfter, func() {
+		profileMut.Lock()
+		defer profileMut.Unlock()
+		if restoreTimer != timer {
+			return
+		}
+		applyRates(pendingRestore)
+		restoreTimer = nil
+	})
+	restoreTimer = timer
+}
+
+//------------------------------------------------------------------------------
+
+// HandleProfileRates is an HTTP handler that responds with the current
+// sampling rates of runtime profiles as JSON. A POST request changes the rates
+// specified by the query parameters mem_profile_rate, block_profile_rate and
+// mutex_profile_fraction, and when the query parameter duration is set the
+// previous rates are restored once the duration has elapsed.
+func HandleProfileRates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "POST":
+		rates, restoreAfter, err := ratesFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		SetProfileRates(rates, restoreAfter)
+	default:
+		http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resBytes, err := json.Marshal(GetProfileRates())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resBytes)
+}
+
+func ratesFromRequest(r *http.Request) (rates ProfileRates, restoreAfter time.Duration, err error) {
+	rates = GetProfileRates()
+	query := r.URL.Query()
+
+	for _, f := range []struct {
+		name  string
+		value *int
+	}{
+		{"mem_profile_rate", &rates.MemProfileRate},
+		{"block_profile_rate", &rates.BlockProfileRate},
+		{"mutex_profile_fraction", &rates.MutexProfileFraction},
+	} {
+		v := query.Get(f.name)
+		if v == "" {
+			continue
+		}
+		if *f.value, err = strconv.Atoi(v); err != nil || *f.value < 0 {
+			err = fmt.Errorf("%v must be a non-negative integer: %v", f.name, v)
+			return
+		}
+	}
+
+	if v := query.Get("duration"); v != "" {
+		if restoreAfter, err = time.ParseDuration(v); err != nil {
+			err = fmt.Errorf("failed to parse duration: %v", err)
+		}
+	}
+	return
+}
@@ -0,0 +1,82 @@
+package runtimestats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetProfileRates(t *testing.T) {
+	initial := GetProfileRates()
+	defer SetProfileRates(initial, 0)
+
+	enabled := ProfileRates{
+		MemProfileRate:       1,
+		BlockProfileRate:     1,
+		MutexProfileFraction: 1,
+	}
+	SetProfileRates(enabled, 0)
+	assert.Equal(t, enabled, GetProfileRates())
+
+	SetProfileRates(initial, 0)
+	assert.Equal(t, initial, GetProfileRates())
+}
+
+func TestSetProfileRatesRestore(t *testing.T) {
+	initial := GetProfileRates()
+	defer SetProfileRates(initial, 0)
+
+	SetProfileRates(ProfileRates{MemProfileRate: 1}, time.Hour)
+	SetProfileRates(ProfileRates{MemProfileRate: 2, BlockProfileRate: 5}, time.Millisecond*10)
+	assert.Equal(t, ProfileRates{MemProfileRate: 2, BlockProfileRate: 5}, GetProfileRates())
+
+	// The restored rates precede the first change.
+	assert.Eventually(t, func() bool {
+		return GetProfileRates() == initial
+	}, time.Second, time.Millisecond*5)
+}
+
+func TestHandleProfileRates(t *testing.T) {
+	initial := GetProfileRates()
+	defer SetProfileRates(initial, 0)
+
+	call := func(method, query string) (int, ProfileRates) {
+		rec := httptest.NewRecorder()
+		HandleProfileRates(rec, httptest.NewRequest(method, "/debug/pprof/rates"+query, nil))
+
+		var rates ProfileRates
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rates))
+		}
+		return rec.Code, rates
+	}
+
+	code, rates := call("GET", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, initial, rates)
+
+	code, rates = call("POST", "?block_profile_rate=10&mutex_profile_fraction=5")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ProfileRates{
+		MemProfileRate:       initial.MemProfileRate,
+		BlockProfileRate:     10,
+		MutexProfileFraction: 5,
+	}, rates)
+
+	for _, query := range []string{
+		"?mem_profile_rate=nope",
+		"?block_profile_rate=-1",
+		"?duration=nope",
+	} {
+		code, _ = call("POST", query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+
+	code, _ = call("DELETE", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
@@ -57,7 +57,11 @@ func (c FieldConfig) FieldSpec() (docs.FieldSpec, error) {
 	if c.Type == nil {
 		return f, errors.New("missing type field")
 	}
-	f = f.HasType(docs.FieldType(*c.Type))
+	if *c.Type == "bloblang" {
+		f = f.HasType(docs.FieldTypeString).Linter(docs.LintBloblangMapping)
+	} else {
+		f = f.HasType(docs.FieldType(*c.Type))
+	}
 	if c.Kind != nil {
 		switch *c.Kind {
 		case "map":
@@ -100,6 +104,68 @@ func (c Config) ComponentSpec() (docs.ComponentSpec, error) {
 	}, nil
 }
 
+// checkValue returns an error when the value of a field parsed from a config
+// does not match the type of the field. Values of string fields are not checked
+// as scalars of any type can be decoded as strings.
+func (c FieldConfig) checkValue(v interface{}) error {
+	if c.Kind != nil {
+		switch *c.Kind {
+		case "map":
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			for k, e := range m {
+				if err := c.checkScalar(e); err != nil {
+					return fmt.Errorf("key %v: %w", k, err)
+				}
+			}
+			return nil
+		case "list":
+			s, ok := v.([]interface{})
+			if !ok {
+				return nil
+			}
+			for i, e := range s {
+				if err := c.checkScalar(e); err != nil {
+					return fmt.Errorf("index %v: %w", i, err)
+				}
+			}
+			return nil
+		}
+	}
+	return c.checkScalar(v)
+}
+
+func (c FieldConfig) checkScalar(v interface{}) error {
+	if c.Type == nil || v == nil {
+		return nil
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		// Structural mismatches are already caught by the standard linter.
+		return nil
+	}
+	var valid bool
+	switch *c.Type {
+	case "int":
+		_, valid = v.(int)
+	case "float":
+		switch v.(type) {
+		case int, float64:
+			valid = true
+		}
+	case "bool":
+		_, valid = v.(bool)
+	default:
+		return nil
+	}
+	if !valid {
+		return fmt.Errorf("expected %v value, got %v", *c.Type, v)
+	}
+	return nil
+}
+
 func (c Config) compile() (*compiled, error) {
 	spec, err := c.ComponentSpec()
 	if err != nil {
@@ -119,7 +185,14 @@ func (c Config) compile() (*compiled, error) {
 			return nil, fmt.Errorf("parse metrics mapping: %w", err)
 		}
 	}
-	return &compiled{spec, mapping, metricsMapping}, nil
+	tmpl := &compiled{
+		spec:           spec,
+		fields:         c.Fields,
+		mapping:        mapping,
+		metricsMapping: metricsMapping,
+	}
+	tmpl.spec.Config = tmpl.spec.Config.Linter(tmpl.lint)
+	return tmpl, nil
 }
 
 func diffYAMLNodesAsJSON(expNode, actNode *yaml.Node) (string, error) {
@@ -210,8 +283,12 @@ func FieldConfigSpec() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldString("name", "The name of the field."),
 		docs.FieldString("description", "A description of the field.").HasDefault(""),
-		docs.FieldString("type", "The scalar type of the field.").HasOptions(
-			"string", "int", "float", "bool",
+		docs.FieldString("type", "The scalar type of the field.").HasAnnotatedOptions(
+			"string", "standard string type",
+			"int", "standard integer type",
+			"float", "standard float type",
+			"bool", "a boolean true/false",
+			"bloblang", "a string containing a [Bloblang mapping](/docs/guides/bloblang/about), which is linted as such when a config using the template is linted",
 		).LintOptions(),
 		docs.FieldString("kind", "The kind of the field.").HasOptions(
 			"scalar", "map", "list",
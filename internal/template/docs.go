@@ -32,6 +32,12 @@ A template is defined in a YAML file that can be imported when Benthos runs usin
 benthos -t "./templates/*.yaml" -c ./config.yaml
 ` + "```" + `
 
+When a config is linted, either at startup or with ` + "`benthos lint`" + `, the fields of template components are checked against their types and the config that each template expands into is linted as well. In order to see the configs that template components expand into you can use the ` + "`echo`" + ` subcommand with the flag ` + "`--expand-templates`" + `:
+
+` + "```sh" + `
+benthos -t "./templates/*.yaml" -c ./config.yaml echo --expand-templates
+` + "```" + `
+
 You can see examples of templates, including some that are included as part of the standard Benthos distribution, at [https://github.com/Jeffail/benthos/tree/master/template](https://github.com/Jeffail/benthos/tree/master/template).
 
 ## Fields
@@ -88,6 +88,7 @@ func InitTemplates(templatesPaths ...string) ([]string, error) {
 // Compiled is a template that has been compiled from a config.
 type compiled struct {
 	spec           docs.ComponentSpec
+	fields         []FieldConfig
 	mapping        *mapping.Executor
 	metricsMapping *metrics.Mapping
 }
@@ -99,7 +100,10 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid config for template component: %w", err)
 	}
+	return c.expand(generic)
+}
 
+func (c *compiled) expand(generic map[string]interface{}) (*yaml.Node, error) {
 	msg := message.New(nil)
 	part := message.NewPart(nil)
 	if err := part.SetJSON(generic); err != nil {
@@ -125,11 +129,148 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 	return &resultNode, nil
 }
 
+// lint checks the fields of a template component config against their types
+// and, when they're valid, lints the config that the template expands into.
+func (c *compiled) lint(ctx docs.LintContext, line, col int, value interface{}) []docs.Lint {
+	generic, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var lints []docs.Lint
+	for _, field := range c.fields {
+		if err := field.checkValue(generic[field.Name]); err != nil {
+			lints = append(lints, docs.NewLintError(line, fmt.Sprintf("field %v: %v", field.Name, err)))
+		}
+	}
+	if len(lints) > 0 {
+		return lints
+	}
+
+	var node yaml.Node
+	if err := node.Encode(generic); err != nil {
+		return nil
+	}
+	for _, l := range c.spec.Config.Children.LintYAML(ctx, &node) {
+		if l.Level == docs.LintError {
+			// Problems with the fields themselves are reported by the standard
+			// linter, and so we only lint the expanded config when they're
+			// valid.
+			return nil
+		}
+	}
+	if generic, err := c.spec.Config.Children.YAMLToMap(&node, docs.ToValueConfig{}); err == nil {
+		expanded, err := c.expand(generic)
+		if err != nil {
+			return []docs.Lint{docs.NewLintError(line, err.Error())}
+		}
+		expandedCtx := docs.LintContext{
+			Labels:       map[string]int{},
+			DocsProvider: ctx.DocsProvider,
+		}
+		for _, l := range docs.LintYAML(expandedCtx, c.spec.Type, expanded) {
+			l.Line, l.Column = line, 0
+			l.What = fmt.Sprintf("expanded config: %v", l.What)
+			lints = append(lints, l)
+		}
+	}
+	return lints
+}
+
 //------------------------------------------------------------------------------
 
+var registeredTemplates = struct {
+	sync.RWMutex
+	byType map[docs.Type]map[string]*compiled
+}{
+	byType: map[docs.Type]map[string]*compiled{},
+}
+
+func getTemplate(cType docs.Type, name string) *compiled {
+	registeredTemplates.RLock()
+	defer registeredTemplates.RUnlock()
+	return registeredTemplates.byType[cType][name]
+}
+
+// ExpandYAML replaces the config of a template component with the config that
+// the template expands into, where the reserved fields of the original config
+// (such as the label and processors) are merged into the result. Processors are
+// combined in the same order as when the component is constructed. If the
+// component is not a registered template then nil is returned.
+//
+// This function is compatible with the ExpandComponent field of
+// docs.SanitiseConfig.
+func ExpandYAML(cType docs.Type, name string, node *yaml.Node) (*yaml.Node, error) {
+	tmpl := getTemplate(cType, name)
+	if tmpl == nil {
+		return nil, nil
+	}
+
+	var fieldsNode *yaml.Node
+	var reserved []*yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case name, "plugin":
+			fieldsNode = node.Content[i+1]
+		case "type":
+		default:
+			reserved = append(reserved, node.Content[i], node.Content[i+1])
+		}
+	}
+	if fieldsNode == nil {
+		fieldsNode = &yaml.Node{Kind: yaml.MappingNode}
+	}
+
+	expanded, err := tmpl.ExpandToNode(fieldsNode)
+	if err != nil {
+		return nil, fmt.Errorf("template %v: %w", name, err)
+	}
+
+	for i := 0; i < len(reserved)-1; i += 2 {
+		key, value := reserved[i], reserved[i+1]
+		existing := mappingValue(expanded, key.Value)
+		switch {
+		case existing == nil:
+			expanded.Content = append(expanded.Content, key, value)
+		case key.Value == "processors" && cType == docs.TypeInput:
+			// Tempate processors inserted _before_ configured processors.
+			existing.Content = append(existing.Content, value.Content...)
+		case key.Value == "processors" && cType == docs.TypeOutput:
+			// Tempate processors inserted _after_ configured processors.
+			existing.Content = append(append([]*yaml.Node{}, value.Content...), existing.Content...)
+		case key.Value == "label" && value.Value != "":
+			*existing = *value
+		}
+	}
+	return expanded, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // RegisterTemplate attempts to add a template component to the global list of
 // component types.
 func registerTemplate(tmpl *compiled) error {
+	if err := registerTemplateComponent(tmpl); err != nil {
+		return err
+	}
+
+	registeredTemplates.Lock()
+	defer registeredTemplates.Unlock()
+	if registeredTemplates.byType[tmpl.spec.Type] == nil {
+		registeredTemplates.byType[tmpl.spec.Type] = map[string]*compiled{}
+	}
+	registeredTemplates.byType[tmpl.spec.Type][tmpl.spec.Name] = tmpl
+	return nil
+}
+
+func registerTemplateComponent(tmpl *compiled) error {
 	switch tmpl.spec.Type {
 	case docs.TypeCache:
 		return registerCacheTemplate(tmpl, bundle.AllCaches)
@@ -1,14 +1,17 @@
 package template_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"testing"
 
+	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/template"
 	_ "github.com/Jeffail/benthos/v3/public/components/all"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestTemplateTesting(t *testing.T) {
@@ -28,3 +31,154 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func initTestTemplate(t *testing.T, conf string) {
+	t.Helper()
+
+	tmplPath := filepath.Join(t.TempDir(), "template.yaml")
+	require.NoError(t, ioutil.WriteFile(tmplPath, []byte(conf), 0o644))
+
+	lints, err := template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+	require.Empty(t, lints)
+}
+
+func TestTemplateLintFields(t *testing.T) {
+	initTestTemplate(t, `
+name: lint_fields_test
+type: input
+fields:
+  - name: topic
+    type: string
+  - name: partitions
+    type: int
+    default: 1
+  - name: ratio
+    type: float
+    default: 0.5
+  - name: tags
+    type: bool
+    kind: list
+    default: []
+  - name: filter
+    type: bloblang
+    default: root = this
+  - name: input_type
+    type: string
+    default: generate
+mapping: |
+  root = {
+    "label": this.topic,
+    this.input_type: {
+      "mapping": this.filter,
+      "interval": "1s",
+      "count": this.partitions,
+    }
+  }
+`)
+
+	tests := map[string]struct {
+		config string
+		lints  []string
+	}{
+		"valid": {
+			config: `
+lint_fields_test:
+  topic: foo
+  partitions: 3
+  ratio: 2
+  tags: [ true, false ]
+`,
+		},
+		"bad types": {
+			config: `
+lint_fields_test:
+  topic: foo
+  partitions: nope
+  ratio: "1.5"
+  tags: [ true, nah ]
+`,
+			lints: []string{
+				"line 3: field partitions: expected int value, got nope",
+				"line 3: field ratio: expected float value, got 1.5",
+				"line 3: field tags: index 1: expected bool value, got nah",
+			},
+		},
+		"bad mapping": {
+			config: `
+lint_fields_test:
+  topic: foo
+  filter: root = (
+`,
+			lints: []string{
+				"line 5: line 1 char 9: expected query\n  |\n1 | root = (\n  |         ^---",
+			},
+		},
+		"bad expanded config": {
+			config: `
+lint_fields_test:
+  topic: foo
+  input_type: nope
+`,
+			lints: []string{
+				"line 3: expanded config: unable to infer component type",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), &node))
+
+			var lints []string
+			for _, l := range docs.LintYAML(docs.NewLintContext(), docs.TypeInput, &node) {
+				lints = append(lints, fmt.Sprintf("line %v: %v", l.Line, l.What))
+			}
+			assert.ElementsMatch(t, test.lints, lints)
+		})
+	}
+}
+
+func TestTemplateExpandYAML(t *testing.T) {
+	initTestTemplate(t, `
+name: expand_yaml_test
+type: output
+fields:
+  - name: path
+    type: string
+mapping: |
+  root.file.path = this.path
+  root.processors = [ { "bloblang": "root = content().uppercase()" } ]
+`)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+label: foo
+expand_yaml_test:
+  path: ./out.txt
+processors:
+  - bloblang: root = content().lowercase()
+`), &node))
+
+	expanded, err := template.ExpandYAML(docs.TypeOutput, "expand_yaml_test", node.Content[0])
+	require.NoError(t, err)
+
+	var result interface{}
+	require.NoError(t, expanded.Decode(&result))
+	assert.Equal(t, map[string]interface{}{
+		"label": "foo",
+		"file": map[string]interface{}{
+			"path": "./out.txt",
+		},
+		"processors": []interface{}{
+			map[string]interface{}{"bloblang": "root = content().lowercase()"},
+			map[string]interface{}{"bloblang": "root = content().uppercase()"},
+		},
+	}, result)
+
+	expanded, err = template.ExpandYAML(docs.TypeOutput, "file", node.Content[0])
+	require.NoError(t, err)
+	assert.Nil(t, expanded)
+}
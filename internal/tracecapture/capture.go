@@ -0,0 +1,286 @@
+// Package tracecapture provides a mechanism for capturing snapshots of messages
+// as they pass through the components of a pipeline, which is useful for
+// debugging which component changed a message.
+package tracecapture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// Capture modes.
+const (
+	ModeFirst = "first"
+	ModeLast  = "last"
+)
+
+// Config contains fields that determine which messages are captured and how
+// much of their contents are stored.
+type Config struct {
+	// Limit is the number of messages to capture.
+	Limit int
+
+	// Mode is either ModeFirst, where the first messages to be consumed are
+	// captured, or ModeLast, where the most recently consumed messages are
+	// kept.
+	Mode string
+
+	// MaxBytes is the maximum number of bytes of each message to store, where
+	// contents that exceed this limit are truncated. Zero means no limit.
+	MaxBytes int
+}
+
+// NewConfig returns a Config with default values, where nothing is captured.
+func NewConfig() Config {
+	return Config{
+		Limit:    0,
+		Mode:     ModeFirst,
+		MaxBytes: 4096,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Snapshot is the state of a message after it has passed through a component.
+type Snapshot struct {
+	Component string            `json:"component"`
+	Timestamp time.Time         `json:"timestamp"`
+	Content   string            `json:"content"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Trace is a captured message and its snapshots, in the order in which they
+// were taken.
+type Trace struct {
+	ID        uint64     `json:"id"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+type traceKeyType int
+
+const traceKey traceKeyType = iota
+
+// Capture records snapshots of messages as they pass through components. A
+// message is assigned to a trace when it is consumed by an input and each
+// component that it subsequently passes through adds a snapshot to the trace.
+type Capture struct {
+	limit    int
+	keepLast bool
+	maxBytes int
+	redactor *redact.Redactor
+
+	mut    sync.Mutex
+	nextID uint64
+	traces map[uint64]*Trace
+	order  []uint64
+}
+
+// New creates a capture from a config.
+func New(conf Config) (*Capture, error) {
+	if conf.Limit <= 0 {
+		return nil, fmt.Errorf("capture limit must be greater than zero, got %v", conf.Limit)
+	}
+	if conf.MaxBytes < 0 {
+		return nil, fmt.Errorf("capture max bytes must not be negative, got %v", conf.MaxBytes)
+	}
+	var keepLast bool
+	switch conf.Mode {
+	case ModeFirst:
+	case ModeLast:
+		keepLast = true
+	default:
+		return nil, fmt.Errorf("capture mode not recognised: %v", conf.Mode)
+	}
+	return &Capture{
+		limit:    conf.Limit,
+		keepLast: keepLast,
+		maxBytes: conf.MaxBytes,
+		traces:   map[uint64]*Trace{},
+	}, nil
+}
+
+// WithRedactor sets a redactor to be applied to the contents and metadata of
+// messages before they are captured.
+func (c *Capture) WithRedactor(r *redact.Redactor) *Capture {
+	c.redactor = r
+	return c
+}
+
+func (c *Capture) snapshot(component string, p types.Part) Snapshot {
+	s := Snapshot{
+		Component: component,
+		Timestamp: time.Now(),
+	}
+	content := c.redactor.Bytes(p.Get())
+	if c.maxBytes > 0 && len(content) > c.maxBytes {
+		content = content[:c.maxBytes]
+		s.Truncated = true
+	}
+	s.Content = string(content)
+	_ = p.Metadata().Iter(func(k, v string) error {
+		if s.Metadata == nil {
+			s.Metadata = map[string]string{}
+		}
+		s.Metadata[k] = v
+		return nil
+	})
+	s.Metadata = c.redactor.Metadata(s.Metadata)
+	return s
+}
+
+// Begin records a snapshot of a message part that has been consumed by an
+// input. If the part is not yet part of a trace and the capture has room then
+// a new trace is started, and the part is returned with the trace attached.
+func (c *Capture) Begin(component string, p types.Part) types.Part {
+	ctx := message.GetContext(p)
+	if _, exists := ctx.Value(traceKey).(uint64); exists {
+		c.Record(component, p)
+		return p
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if !c.keepLast && c.nextID >= uint64(c.limit) {
+		return p
+	}
+
+	id := c.nextID
+	c.nextID++
+
+	c.traces[id] = &Trace{
+		ID:        id,
+		Snapshots: []Snapshot{c.snapshot(component, p)},
+	}
+	c.order = append(c.order, id)
+	if len(c.order) > c.limit {
+		delete(c.traces, c.order[0])
+		c.order = c.order[1:]
+	}
+	return message.WithContext(context.WithValue(ctx, traceKey, id), p)
+}
+
+// Record adds a snapshot of a message part to its trace, if it has one.
+func (c *Capture) Record(component string, p types.Part) {
+	id, exists := message.GetContext(p).Value(traceKey).(uint64)
+	if !exists {
+		return
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if t, exists := c.traces[id]; exists {
+		t.Snapshots = append(t.Snapshots, c.snapshot(component, p))
+	}
+}
+
+// Traces returns the captured traces in the order in which they were started.
+func (c *Capture) Traces() []Trace {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	traces := make([]Trace, 0, len(c.order))
+	for _, id := range c.order {
+		t := c.traces[id]
+		traces = append(traces, Trace{
+			ID:        t.ID,
+			Snapshots: append([]Snapshot(nil), t.Snapshots...),
+		})
+	}
+	return traces
+}
+
+// Handler is an HTTP handler that responds with the captured traces as JSON.
+func (c *Capture) Handler(w http.ResponseWriter, r *http.Request) {
+	resBytes, err := json.Marshal(c.Traces())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resBytes)
+}
+
+// WriteFile writes the captured traces as JSON to a file.
+func (c *Capture) WriteFile(path string) error {
+	resBytes, err := json.MarshalIndent(c.Traces(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, resBytes, 0644)
+}
+
+//------------------------------------------------------------------------------
+
+type beginProcessor struct {
+	capture   *Capture
+	component string
+}
+
+// InputProcessor returns a processor that begins traces for the messages that
+// pass through it, and should therefore be executed directly after an input.
+func (c *Capture) InputProcessor(component string) types.Processor {
+	return &beginProcessor{capture: c, component: component}
+}
+
+func (b *beginProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	parts := make([]types.Part, msg.Len())
+	_ = msg.Iter(func(i int, p types.Part) error {
+		parts[i] = b.capture.Begin(b.component, p)
+		return nil
+	})
+	newMsg := msg.Copy()
+	newMsg.SetAll(parts)
+	return []types.Message{newMsg}, nil
+}
+
+func (b *beginProcessor) CloseAsync() {}
+
+func (b *beginProcessor) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type recordProcessor struct {
+	capture   *Capture
+	component string
+	types.Processor
+}
+
+// WrapProcessor returns a processor that records a snapshot of each message
+// resulting from the provided processor.
+func (c *Capture) WrapProcessor(component string, p types.Processor) types.Processor {
+	return &recordProcessor{capture: c, component: component, Processor: p}
+}
+
+func (r *recordProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	msgs, res := r.Processor.ProcessMessage(msg)
+	r.record(msgs)
+	return msgs, res
+}
+
+func (r *recordProcessor) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	msgs, res := processor.ProcessWithContext(ctx, r.Processor, msg)
+	r.record(msgs)
+	return msgs, res
+}
+
+func (r *recordProcessor) record(msgs []types.Message) {
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p types.Part) error {
+			r.capture.Record(r.component, p)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package tracecapture
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upperProc struct{}
+
+func (upperProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	newMsg := msg.Copy()
+	_ = newMsg.Iter(func(i int, p types.Part) error {
+		p.Set(append([]byte("UPPER "), p.Get()...))
+		p.Metadata().Set("upper", "true")
+		return nil
+	})
+	return []types.Message{newMsg}, nil
+}
+
+func (upperProc) CloseAsync() {}
+
+func (upperProc) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type ctxProc struct {
+	upperProc
+	ctx context.Context
+}
+
+func (c *ctxProc) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	c.ctx = ctx
+	return c.ProcessMessage(msg)
+}
+
+func contents(t Trace) []string {
+	var c []string
+	for _, s := range t.Snapshots {
+		c = append(c, s.Component+": "+s.Content)
+	}
+	return c
+}
+
+func runMessages(t *testing.T, c *Capture, inputs ...string) {
+	t.Helper()
+
+	input := c.InputProcessor("foo")
+	proc := c.WrapProcessor("bar", upperProc{})
+	for _, in := range inputs {
+		msgs, res := input.ProcessMessage(message.New([][]byte{[]byte(in)}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+
+		msgs, res = proc.ProcessMessage(msgs[0])
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+	}
+}
+
+func TestCaptureFirst(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 2
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	runMessages(t, c, "first", "second", "third")
+
+	traces := c.Traces()
+	require.Len(t, traces, 2)
+	assert.Equal(t, uint64(0), traces[0].ID)
+	assert.Equal(t, []string{"foo: first", "bar: UPPER first"}, contents(traces[0]))
+	assert.Equal(t, map[string]string{"upper": "true"}, traces[0].Snapshots[1].Metadata)
+	assert.Equal(t, uint64(1), traces[1].ID)
+	assert.Equal(t, []string{"foo: second", "bar: UPPER second"}, contents(traces[1]))
+}
+
+func TestCaptureWithContext(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 1
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	input := c.InputProcessor("foo")
+	inner := &ctxProc{}
+	proc := c.WrapProcessor("bar", inner)
+
+	msgs, res := input.ProcessMessage(message.New([][]byte{[]byte("hello")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "baz")
+
+	msgs, res = processor.ProcessWithContext(ctx, proc, msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	require.NotNil(t, inner.ctx)
+	assert.Equal(t, "baz", inner.ctx.Value(ctxKey{}))
+
+	traces := c.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, []string{"foo: hello", "bar: UPPER hello"}, contents(traces[0]))
+}
+
+func TestCaptureLast(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 2
+	conf.Mode = ModeLast
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	runMessages(t, c, "first", "second", "third")
+
+	traces := c.Traces()
+	require.Len(t, traces, 2)
+	assert.Equal(t, uint64(1), traces[0].ID)
+	assert.Equal(t, []string{"foo: second", "bar: UPPER second"}, contents(traces[0]))
+	assert.Equal(t, uint64(2), traces[1].ID)
+	assert.Equal(t, []string{"foo: third", "bar: UPPER third"}, contents(traces[1]))
+}
+
+func TestCaptureRedaction(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 1
+
+	r, err := redact.New([]redact.RuleConfig{
+		{Regex: `secret`, Mode: redact.ModeMask},
+		{Regex: `true`, Mode: redact.ModeHash},
+	})
+	require.NoError(t, err)
+
+	c, err := New(conf)
+	require.NoError(t, err)
+	c = c.WithRedactor(r)
+
+	input := c.InputProcessor("foo")
+	proc := c.WrapProcessor("bar", upperProc{})
+
+	msgs, res := input.ProcessMessage(message.New([][]byte{[]byte("a secret")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	msgs, res = proc.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "UPPER a secret", string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "true", msgs[0].Get(0).Metadata().Get("upper"))
+
+	traces := c.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, []string{"foo: a ********", "bar: UPPER a ********"}, contents(traces[0]))
+	assert.Equal(t, map[string]string{"upper": redact.Value(redact.ModeHash, "true")}, traces[0].Snapshots[1].Metadata)
+}
+
+func TestCaptureTruncate(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 1
+	conf.MaxBytes = 5
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	runMessages(t, c, "hello world")
+
+	traces := c.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, []string{"foo: hello", "bar: UPPER"}, contents(traces[0]))
+	assert.True(t, traces[0].Snapshots[0].Truncated)
+	assert.True(t, traces[0].Snapshots[1].Truncated)
+}
+
+func TestCaptureBatch(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 10
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	input := c.InputProcessor("foo")
+	msgs, res := input.ProcessMessage(message.New([][]byte{[]byte("a"), []byte("b")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	// A second input hop adds to the existing traces.
+	msgs, res = c.InputProcessor("baz").ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	traces := c.Traces()
+	require.Len(t, traces, 2)
+	assert.Equal(t, []string{"foo: a", "baz: a"}, contents(traces[0]))
+	assert.Equal(t, []string{"foo: b", "baz: b"}, contents(traces[1]))
+}
+
+func TestCaptureOutputs(t *testing.T) {
+	conf := NewConfig()
+	conf.Limit = 1
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	runMessages(t, c, "hello world")
+
+	rec := httptest.NewRecorder()
+	c.Handler(rec, httptest.NewRequest("GET", "/debug/traces", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var traces []Trace
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &traces))
+	require.Len(t, traces, 1)
+	assert.Equal(t, []string{"foo: hello world", "bar: UPPER hello world"}, contents(traces[0]))
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	require.NoError(t, c.WriteFile(path))
+
+	fileBytes, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	traces = nil
+	require.NoError(t, json.Unmarshal(fileBytes, &traces))
+	require.Len(t, traces, 1)
+	assert.Equal(t, []string{"foo: hello world", "bar: UPPER hello world"}, contents(traces[0]))
+}
+
+func TestCaptureBadConfig(t *testing.T) {
+	conf := NewConfig()
+	_, err := New(conf)
+	require.Error(t, err)
+
+	conf.Limit = 1
+	conf.Mode = "nope"
+	_, err = New(conf)
+	require.Error(t, err)
+
+	conf.Mode = ModeFirst
+	conf.MaxBytes = -1
+	_, err = New(conf)
+	require.Error(t, err)
+}
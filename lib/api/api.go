@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"runtime"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/http/listener"
+	"github.com/Jeffail/benthos/v3/internal/runtimestats"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/gorilla/mux"
@@ -22,25 +25,29 @@ import (
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string `json:"address" yaml:"address"`
-	Enabled        bool   `json:"enabled" yaml:"enabled"`
-	ReadTimeout    string `json:"read_timeout" yaml:"read_timeout"`
-	RootPath       string `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool   `json:"debug_endpoints" yaml:"debug_endpoints"`
-	CertFile       string `json:"cert_file" yaml:"cert_file"`
-	KeyFile        string `json:"key_file" yaml:"key_file"`
+	Address           string `json:"address" yaml:"address"`
+	Enabled           bool   `json:"enabled" yaml:"enabled"`
+	ReadTimeout       string `json:"read_timeout" yaml:"read_timeout"`
+	RootPath          string `json:"root_path" yaml:"root_path"`
+	DebugEndpoints    bool   `json:"debug_endpoints" yaml:"debug_endpoints"`
+	CertFile          string `json:"cert_file" yaml:"cert_file"`
+	KeyFile           string `json:"key_file" yaml:"key_file"`
+	SocketPermissions string `json:"socket_permissions" yaml:"socket_permissions"`
+	ProxyProtocol     bool   `json:"proxy_protocol" yaml:"proxy_protocol"`
 }
 
 // NewConfig creates a new API config with default values.
 func NewConfig() Config {
 	return Config{
-		Address:        "0.0.0.0:4195",
-		Enabled:        true,
-		ReadTimeout:    "5s",
-		RootPath:       "/benthos",
-		DebugEndpoints: false,
-		CertFile:       "",
-		KeyFile:        "",
+		Address:           "0.0.0.0:4195",
+		Enabled:           true,
+		ReadTimeout:       "5s",
+		RootPath:          "/benthos",
+		DebugEndpoints:    false,
+		CertFile:          "",
+		KeyFile:           "",
+		SocketPermissions: "",
+		ProxyProtocol:     false,
 	}
 }
 
@@ -69,6 +76,7 @@ func OptWithTLS(tls *tls.Config) OptFunc {
 type Type struct {
 	conf         Config
 	endpoints    map[string]string
+	specs        map[string]EndpointSpec
 	endpointsMut sync.Mutex
 
 	ctx    context.Context
@@ -77,8 +85,9 @@ type Type struct {
 	handlers    map[string]http.HandlerFunc
 	handlersMut sync.RWMutex
 
-	mux    *mux.Router
-	server *http.Server
+	mux        *mux.Router
+	server     *http.Server
+	socketMode os.FileMode
 }
 
 // New creates a new Benthos HTTP API.
@@ -104,18 +113,24 @@ func New(
 		}
 	}
 
+	socketMode, err := listener.ParseFileMode(conf.SocketPermissions)
+	if err != nil {
+		return nil, err
+	}
+
 	if tout := conf.ReadTimeout; len(tout) > 0 {
-		var err error
 		if server.ReadTimeout, err = time.ParseDuration(tout); err != nil {
 			return nil, fmt.Errorf("failed to parse read timeout string: %v", err)
 		}
 	}
 	t := &Type{
-		conf:      conf,
-		endpoints: map[string]string{},
-		handlers:  map[string]http.HandlerFunc{},
-		mux:       handler,
-		server:    server,
+		conf:       conf,
+		endpoints:  map[string]string{},
+		specs:      map[string]EndpointSpec{},
+		handlers:   map[string]http.HandlerFunc{},
+		mux:        handler,
+		server:     server,
+		socketMode: socketMode,
 	}
 	t.ctx, t.cancel = context.WithCancel(context.Background())
 
@@ -173,6 +188,23 @@ func New(
 		}
 	}
 
+	handleOpenAPI := func(w http.ResponseWriter, r *http.Request) {
+		t.endpointsMut.Lock()
+		specs := make([]EndpointSpec, 0, len(t.specs))
+		for _, spec := range t.specs {
+			specs = append(specs, spec)
+		}
+		t.endpointsMut.Unlock()
+
+		resBytes, err := OpenAPI("Benthos", version, specs)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	}
+
 	if t.conf.DebugEndpoints {
 		t.RegisterEndpoint(
 			"/debug/config/json", "DEBUG: Returns the loaded config as JSON.",
@@ -187,21 +219,46 @@ func New(
 			handleStackTrace,
 		)
 		t.RegisterEndpoint(
-			"/debug/pprof/profile", "DEBUG: Responds with a pprof-formatted cpu profile.",
-			pprof.Profile,
-		)
-		t.RegisterEndpoint(
-			"/debug/pprof/heap", "DEBUG: Responds with a pprof-formatted heap profile.",
+			"/debug/pprof/", "DEBUG: Responds with an index of the available pprof profiles.",
 			pprof.Index,
 		)
 		t.RegisterEndpoint(
-			"/debug/pprof/block", "DEBUG: Responds with a pprof-formatted block profile.",
-			pprof.Index,
+			"/debug/pprof/cmdline", "DEBUG: Responds with the command line of the running service.",
+			pprof.Cmdline,
 		)
 		t.RegisterEndpoint(
-			"/debug/pprof/mutex", "DEBUG: Responds with a pprof-formatted mutex profile.",
-			pprof.Index,
+			"/debug/pprof/profile", "DEBUG: Responds with a pprof-formatted cpu profile.",
+			pprof.Profile,
 		)
+		for _, p := range []struct {
+			name, desc string
+		}{
+			{"allocs", "DEBUG: Responds with a pprof-formatted profile of past memory allocations."},
+			{"block", "DEBUG: Responds with a pprof-formatted block profile."},
+			{"goroutine", "DEBUG: Responds with a pprof-formatted profile of the stack traces of all current goroutines."},
+			{"heap", "DEBUG: Responds with a pprof-formatted heap profile."},
+			{"mutex", "DEBUG: Responds with a pprof-formatted mutex profile."},
+			{"threadcreate", "DEBUG: Responds with a pprof-formatted profile of the stack traces that led to the creation of new OS threads."},
+		} {
+			// The index handler only serves named profiles at the root path,
+			// so they're registered explicitly in order to work with the
+			// root_path prefix.
+			t.RegisterEndpoint("/debug/pprof/"+p.name, p.desc, pprof.Handler(p.name).ServeHTTP)
+		}
+		t.RegisterEndpointSpec(NewEndpointSpec(
+			"/debug/pprof/rates",
+			"DEBUG: Returns the sampling rates of the memory, block and mutex profiles as JSON, a POST request changes the rates specified by the query parameters mem_profile_rate, block_profile_rate and mutex_profile_fraction, and restores the previous rates after the query parameter duration when set.",
+			EndpointOperation{
+				Method:               "GET",
+				Summary:              "Returns the sampling rates of runtime profiles.",
+				ResponseContentTypes: []string{"application/json"},
+			},
+			EndpointOperation{
+				Method:               "POST",
+				Summary:              "Changes the sampling rates of runtime profiles, optionally for a limited duration.",
+				ResponseContentTypes: []string{"application/json"},
+			},
+		), runtimestats.HandleProfileRates)
 		t.RegisterEndpoint(
 			"/debug/pprof/symbol", "DEBUG: looks up the program counters listed"+
 				" in the request, responding with a table mapping program"+
@@ -220,6 +277,7 @@ func New(
 	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
 	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion)
 	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints)
+	t.RegisterEndpoint("/docs/openapi.json", "Returns an OpenAPI document describing the endpoints of this service.", handleOpenAPI)
 
 	// If we want to expose a JSON stats endpoint we register the endpoints.
 	if wHandlerFunc, ok := stats.(metrics.WithHandlerFunc); ok {
@@ -243,10 +301,20 @@ func New(
 // RegisterEndpoint registers a http.HandlerFunc under a path with a
 // description that will be displayed under the /endpoints path.
 func (t *Type) RegisterEndpoint(path, desc string, handler http.HandlerFunc) {
+	t.RegisterEndpointSpec(NewEndpointSpec(path, desc), handler)
+}
+
+// RegisterEndpointSpec registers a http.HandlerFunc under the path of an
+// endpoint spec, which is included in the OpenAPI document of the service
+// served under the /docs/openapi.json path.
+func (t *Type) RegisterEndpointSpec(spec EndpointSpec, handler http.HandlerFunc) {
+	path := spec.Path
+
 	t.endpointsMut.Lock()
 	defer t.endpointsMut.Unlock()
 
-	t.endpoints[path] = desc
+	t.endpoints[path] = spec.Description
+	t.specs[path] = spec
 
 	t.handlersMut.Lock()
 	defer t.handlersMut.Unlock()
@@ -270,13 +338,21 @@ func (t *Type) ListenAndServe() error {
 		<-t.ctx.Done()
 		return nil
 	}
+	ln, err := listener.Listen(listener.Config{
+		Address:       t.conf.Address,
+		SocketMode:    t.socketMode,
+		ProxyProtocol: t.conf.ProxyProtocol,
+	})
+	if err != nil {
+		return err
+	}
 	if t.server.TLSConfig != nil {
-		return t.server.ListenAndServeTLS("", "")
+		return t.server.ServeTLS(ln, "", "")
 	}
 	if len(t.conf.CertFile) > 0 {
-		return t.server.ListenAndServeTLS(t.conf.CertFile, t.conf.KeyFile)
+		return t.server.ServeTLS(ln, t.conf.CertFile, t.conf.KeyFile)
 	}
-	return t.server.ListenAndServe()
+	return t.server.Serve(ln)
 }
 
 // Shutdown attempts to close the http server.
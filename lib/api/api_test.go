@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugEndpoints(t *testing.T) {
+	conf := NewConfig()
+	conf.Enabled = false
+	conf.DebugEndpoints = true
+
+	a, err := New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		"/debug/pprof/",
+		"/debug/pprof/allocs",
+		"/debug/pprof/goroutine",
+		"/debug/pprof/heap",
+		"/debug/pprof/rates",
+		"/benthos/debug/pprof/heap",
+		"/benthos/debug/pprof/rates",
+	} {
+		rec := httptest.NewRecorder()
+		a.mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
+
+	// Named profiles are served under the root path prefix rather than the
+	// profile index.
+	rec := httptest.NewRecorder()
+	a.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/benthos/debug/pprof/heap", nil))
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+
+	conf.DebugEndpoints = false
+	a, err = New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	a.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/rates", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -6,7 +6,7 @@ import "github.com/Jeffail/benthos/v3/internal/docs"
 func Spec() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldBool("enabled", "Whether to enable to HTTP server.").HasDefault(true),
-		docs.FieldString("address", "The address to bind to.").HasDefault("0.0.0.0:4195"),
+		docs.FieldString("address", "The address to bind to. A unix domain socket can be used by specifying a path prefixed with `unix://`.").HasDefault("0.0.0.0:4195"),
 		docs.FieldString(
 			"root_path", "Specifies a general prefix for all endpoints, this can help isolate the service endpoints when using a reverse proxy with other shared services. All endpoints will still be registered at the root as well as behind the prefix, e.g. with a root_path set to `/foo` the endpoint `/version` will be accessible from both `/version` and `/foo/version`.",
 		).HasDefault("/benthos"),
@@ -15,6 +15,8 @@ func Spec() docs.FieldSpecs {
 		).HasDefault(false),
 		docs.FieldString("cert_file", "An optional certificate file for enabling TLS.").Advanced().HasDefault(""),
 		docs.FieldString("key_file", "An optional key file for enabling TLS.").Advanced().HasDefault(""),
+		docs.FieldString("socket_permissions", "An optional octal file mode to set on the socket file when the `address` refers to a unix domain socket.", "0660").Advanced().HasDefault("").AtVersion("3.50.0"),
+		docs.FieldBool("proxy_protocol", "Whether connections begin with a [PROXY protocol](https://www.haproxy.org/download/2.4/doc/proxy-protocol.txt) v1 or v2 header, in which case the source address of the header is used as the remote address of requests. Connections without a header are rejected.").Advanced().HasDefault(false).AtVersion("3.50.0"),
 		docs.FieldDeprecated("read_timeout"),
 	}
 }
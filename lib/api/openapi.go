@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// EndpointSpec describes an HTTP endpoint and the operations that it supports,
+// and is used in order to generate an OpenAPI document of the service.
+type EndpointSpec struct {
+	Path        string
+	Description string
+
+	// Operations lists the methods supported by the endpoint. When empty the
+	// endpoint is assumed to support GET requests only.
+	Operations []EndpointOperation
+}
+
+// EndpointOperation describes a method supported by an HTTP endpoint.
+type EndpointOperation struct {
+	Method               string
+	Summary              string
+	RequestContentTypes  []string
+	ResponseContentTypes []string
+}
+
+// NewEndpointSpec creates an endpoint spec with a path, description and an
+// optional list of operations.
+func NewEndpointSpec(path, desc string, ops ...EndpointOperation) EndpointSpec {
+	return EndpointSpec{
+		Path:        path,
+		Description: desc,
+		Operations:  ops,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type openAPIDoc struct {
+	OpenAPI string                            `json:"openapi"`
+	Info    openAPIInfo                       `json:"info"`
+	Paths   map[string]map[string]openAPIOper `json:"paths"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOper struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []openAPIParam             `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParam struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+type openAPIBody struct {
+	Content map[string]struct{} `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string              `json:"description"`
+	Content     map[string]struct{} `json:"content,omitempty"`
+}
+
+// openAPIPath converts a path that may contain parameters of the form
+// `{foo:regexp}` into an OpenAPI path, and returns the names of the
+// parameters.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			continue
+		}
+		name := s[1 : len(s)-1]
+		if colon := strings.Index(name, ":"); colon >= 0 {
+			name = name[:colon]
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func contentTypes(types []string) map[string]struct{} {
+	if len(types) == 0 {
+		return nil
+	}
+	m := make(map[string]struct{}, len(types))
+	for _, t := range types {
+		m[t] = struct{}{}
+	}
+	return m
+}
+
+// OpenAPI generates an OpenAPI 3 document in JSON format that describes a set
+// of endpoints.
+func OpenAPI(title, version string, specs []EndpointSpec) ([]byte, error) {
+	if version == "" {
+		version = "unknown"
+	}
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:   title,
+			Version: version,
+		},
+		Paths: map[string]map[string]openAPIOper{},
+	}
+
+	sorted := make([]EndpointSpec, len(specs))
+	copy(sorted, specs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	for _, spec := range sorted {
+		path, paramNames := openAPIPath(spec.Path)
+		var params []openAPIParam
+		for _, name := range paramNames {
+			params = append(params, openAPIParam{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   map[string]string{"type": "string"},
+			})
+		}
+
+		ops := spec.Operations
+		if len(ops) == 0 {
+			ops = []EndpointOperation{{Method: "GET"}}
+		}
+
+		pathItem := map[string]openAPIOper{}
+		for _, op := range ops {
+			oper := openAPIOper{
+				Summary:     op.Summary,
+				Description: spec.Description,
+				Parameters:  params,
+				Responses: map[string]openAPIResponse{
+					"default": {
+						Description: "The response of the endpoint.",
+						Content:     contentTypes(op.ResponseContentTypes),
+					},
+				},
+			}
+			if oper.Summary == "" {
+				oper.Summary = spec.Description
+				oper.Description = ""
+			}
+			if ct := contentTypes(op.RequestContentTypes); ct != nil {
+				oper.RequestBody = &openAPIBody{Content: ct}
+			}
+			pathItem[strings.ToLower(op.Method)] = oper
+		}
+		doc.Paths[path] = pathItem
+	}
+
+	return json.Marshal(doc)
+}
+
+// OpenAPIHandler returns an HTTP handler that responds with an OpenAPI 3
+// document describing a static set of endpoints.
+func OpenAPIHandler(title, version string, specs ...EndpointSpec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resBytes, err := OpenAPI(title, version, specs)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIGeneration(t *testing.T) {
+	docBytes, err := OpenAPI("foo", "", []EndpointSpec{
+		NewEndpointSpec("/ping", "Ping me."),
+		NewEndpointSpec(
+			"/things/{id:[a-z]+}", "Do things.",
+			EndpointOperation{
+				Method:               "POST",
+				Summary:              "Create a thing.",
+				RequestContentTypes:  []string{"application/json"},
+				ResponseContentTypes: []string{"text/plain"},
+			},
+			EndpointOperation{
+				Method: "DELETE",
+			},
+		),
+	})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+  "openapi": "3.0.3",
+  "info": {"title": "foo", "version": "unknown"},
+  "paths": {
+    "/ping": {
+      "get": {
+        "summary": "Ping me.",
+        "responses": {"default": {"description": "The response of the endpoint."}}
+      }
+    },
+    "/things/{id}": {
+      "post": {
+        "summary": "Create a thing.",
+        "description": "Do things.",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "requestBody": {"content": {"application/json": {}}},
+        "responses": {"default": {"description": "The response of the endpoint.", "content": {"text/plain": {}}}}
+      },
+      "delete": {
+        "summary": "Do things.",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"default": {"description": "The response of the endpoint."}}
+      }
+    }
+  }
+}`, string(docBytes))
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	conf := NewConfig()
+	conf.Enabled = false
+
+	a, err := New("1.2.3", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	a.RegisterEndpointSpec(NewEndpointSpec(
+		"/foo", "Foo things.",
+		EndpointOperation{Method: "PUT"},
+	), func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	a.mux.ServeHTTP(rec, httptest.NewRequest("GET", "/docs/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		Info  map[string]string                 `json:"info"`
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+
+	assert.Equal(t, "1.2.3", doc.Info["version"])
+	assert.Contains(t, doc.Paths["/foo"], "put")
+	assert.Contains(t, doc.Paths["/ping"], "get")
+	assert.Contains(t, doc.Paths, "/docs/openapi.json")
+}
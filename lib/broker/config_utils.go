@@ -2,6 +2,9 @@ package broker
 
 import (
 	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -21,6 +24,22 @@ func GetGenericType(boxedConfig interface{}) string {
 	return ""
 }
 
+// HasDittoNode returns true if any of a list of config nodes, other than the
+// first, has a ditto type.
+func HasDittoNode(nodes []*yaml.Node) bool {
+	for i, node := range nodes {
+		if i == 0 || node.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j < len(node.Content)-1; j += 2 {
+			if node.Content[j].Value == "type" && strings.HasPrefix(node.Content[j+1].Value, "ditto") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RemoveGenericType removes the type of a generically parsed config structure.
 func RemoveGenericType(boxedConfig interface{}) {
 	switch unboxed := boxedConfig.(type) {
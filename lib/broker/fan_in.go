@@ -1,8 +1,10 @@
 package broker
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/input"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -16,6 +18,9 @@ type FanIn struct {
 
 	transactions chan types.Transaction
 
+	inputs          []types.Producer
+	labels          []string
+	ignoreFailed    bool
 	closables       []types.Closable
 	inputClosedChan chan int
 	inputMap        map[int]struct{}
@@ -24,9 +29,10 @@ type FanIn struct {
 }
 
 // NewFanIn creates a new FanIn type by providing inputs.
-func NewFanIn(inputs []types.Producer, stats metrics.Type) (*FanIn, error) {
+func NewFanIn(inputs []types.Producer, stats metrics.Type, options ...func(*FanIn)) (*FanIn, error) {
 	i := &FanIn{
-		stats: stats,
+		stats:  stats,
+		inputs: inputs,
 
 		transactions: make(chan types.Transaction),
 
@@ -36,6 +42,9 @@ func NewFanIn(inputs []types.Producer, stats metrics.Type) (*FanIn, error) {
 		closables:  []types.Closable{},
 		closedChan: make(chan struct{}),
 	}
+	for _, opt := range options {
+		opt(i)
+	}
 
 	for n, input := range inputs {
 		if closable, ok := input.(types.Closable); ok {
@@ -74,19 +83,89 @@ func (i *FanIn) TransactionChan() <-chan types.Transaction {
 }
 
 // Connected returns a boolean indicating whether this output is currently
-// connected to its target.
+// connected to its target. When failed children are ignored the broker is
+// considered connected as long as at least one child is connected.
 func (i *FanIn) Connected() bool {
+	statuses := i.ChildStatuses()
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, s := range statuses {
+		if s.Connected && i.ignoreFailed {
+			return true
+		}
+		if !s.Connected && !i.ignoreFailed {
+			return false
+		}
+	}
+	return !i.ignoreFailed
+}
+
+// ChildStatuses returns the connection status of each child input that reports
+// one.
+func (i *FanIn) ChildStatuses() []ChildStatus {
 	type connector interface {
 		Connected() bool
 	}
-	for _, in := range i.closables {
-		if c, ok := in.(connector); ok {
-			if !c.Connected() {
-				return false
-			}
+	var statuses []ChildStatus
+	for n, in := range i.inputs {
+		c, ok := in.(connector)
+		if !ok {
+			continue
+		}
+		label := strconv.Itoa(n)
+		if n < len(i.labels) && i.labels[n] != "" {
+			label = i.labels[n]
+		}
+		statuses = append(statuses, ChildStatus{
+			Label:     label,
+			Connected: c.Connected(),
+		})
+	}
+	return statuses
+}
+
+// InFlight returns the combined stats of messages that have been read and are
+// yet to be acknowledged by each child input that reports them.
+func (i *FanIn) InFlight() input.InFlightStats {
+	var stats input.InFlightStats
+	for _, in := range i.inputs {
+		if r, ok := in.(input.InFlightReporter); ok {
+			stats = stats.Merge(r.InFlight())
 		}
 	}
-	return true
+	return stats
+}
+
+//------------------------------------------------------------------------------
+
+// ChildStatus describes the connection status of a child of a broker.
+type ChildStatus struct {
+	Label     string
+	Connected bool
+}
+
+// ChildStatusReporter is implemented by brokers, and components wrapping them,
+// that are able to report the connection status of each child.
+type ChildStatusReporter interface {
+	ChildStatuses() []ChildStatus
+}
+
+// OptFanInChildLabels sets the labels used to identify each child input when
+// reporting their statuses, in the same order as the inputs.
+func OptFanInChildLabels(labels []string) func(*FanIn) {
+	return func(i *FanIn) {
+		i.labels = labels
+	}
+}
+
+// OptFanInIgnoreFailedChildren sets whether the broker should report itself as
+// connected when at least one child input is connected, rather than requiring
+// all of them to be.
+func OptFanInIgnoreFailedChildren(ignore bool) func(*FanIn) {
+	return func(i *FanIn) {
+		i.ignoreFailed = ignore
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package broker
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -250,3 +251,52 @@ func BenchmarkBasicFanIn(b *testing.B) {
 }
 
 //------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+
+type mockConnectedInput struct {
+	MockInputType
+	connected bool
+}
+
+func (m *mockConnectedInput) Connected() bool {
+	return m.connected
+}
+
+func TestFanInChildStatuses(t *testing.T) {
+	inputs := []*mockConnectedInput{
+		{MockInputType: MockInputType{TChan: make(chan types.Transaction)}, connected: true},
+		{MockInputType: MockInputType{TChan: make(chan types.Transaction)}, connected: false},
+	}
+
+	for _, ignore := range []bool{false, true} {
+		fanIn, err := NewFanIn(
+			[]types.Producer{inputs[0], inputs[1]}, metrics.Noop(),
+			OptFanInChildLabels([]string{"foo", ""}),
+			OptFanInIgnoreFailedChildren(ignore),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if exp, act := []ChildStatus{
+			{Label: "foo", Connected: true},
+			{Label: "1", Connected: false},
+		}, fanIn.ChildStatuses(); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong child statuses: %v != %v", act, exp)
+		}
+		if exp, act := ignore, fanIn.Connected(); exp != act {
+			t.Errorf("Wrong connected state with ignore %v: %v != %v", ignore, act, exp)
+		}
+
+		inputs[0].connected = false
+		if fanIn.Connected() {
+			t.Error("Expected broker to be disconnected with no connected children")
+		}
+		inputs[0].connected = true
+	}
+
+	for _, in := range inputs {
+		in.CloseAsync()
+	}
+}
@@ -0,0 +1,353 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// ShardKeyFunc returns the key of a message part of a batch, which determines
+// the output that the part is sent to.
+type ShardKeyFunc func(index int, msg types.Message) string
+
+// Sharded is a broker that implements types.Consumer and sends each message to
+// a single output chosen by consistently hashing a key of the message. Outputs
+// are identified by their index, and therefore appending or removing the last
+// output only remaps roughly 1/N of the keys.
+//
+// Messages of a key normally assigned to an output that is not connected are
+// either spilled to the next output for that key, or sent regardless and
+// blocked until the output recovers.
+type Sharded struct {
+	stats         metrics.Type
+	outputsPrefix string
+
+	keyFn ShardKeyFunc
+	spill bool
+
+	maxInFlight  int
+	transactions <-chan types.Transaction
+
+	outputTSChans []chan types.Transaction
+	outputs       []types.Output
+	outputSeeds   []uint64
+
+	ctx        context.Context
+	close      func()
+	closedChan chan struct{}
+}
+
+// NewSharded creates a new Sharded type by providing outputs and a function
+// that extracts a key from each message.
+func NewSharded(outputs []types.Output, keyFn ShardKeyFunc, stats metrics.Type) (*Sharded, error) {
+	ctx, done := context.WithCancel(context.Background())
+	s := &Sharded{
+		stats:         stats,
+		outputsPrefix: "broker.outputs",
+		keyFn:         keyFn,
+		spill:         true,
+		maxInFlight:   1,
+		transactions:  nil,
+		outputs:       outputs,
+		closedChan:    make(chan struct{}),
+		ctx:           ctx,
+		close:         done,
+	}
+	if len(outputs) == 0 {
+		return nil, errors.New("missing outputs")
+	}
+	if keyFn == nil {
+		return nil, errors.New("missing key function")
+	}
+	s.outputTSChans = make([]chan types.Transaction, len(s.outputs))
+	s.outputSeeds = make([]uint64, len(s.outputs))
+	for i := range s.outputTSChans {
+		s.outputSeeds[i] = hashString(fmt.Sprintf("%v", i))
+		s.outputTSChans[i] = make(chan types.Transaction)
+		if err := s.outputs[i].Consume(s.outputTSChans[i]); err != nil {
+			return nil, err
+		}
+		if mif, ok := output.GetMaxInFlight(s.outputs[i]); ok && mif > s.maxInFlight {
+			s.maxInFlight = mif
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// WithMaxInFlight sets the maximum number of in-flight messages this broker
+// supports. This must be set before calling Consume.
+func (s *Sharded) WithMaxInFlight(i int) *Sharded {
+	if i < 1 {
+		i = 1
+	}
+	s.maxInFlight = i
+	return s
+}
+
+// WithSpill sets whether messages assigned to an output that is not connected
+// are spilled to the next output for their key, or whether they are sent to
+// the output regardless. This must be set before calling Consume.
+func (s *Sharded) WithSpill(spill bool) *Sharded {
+	s.spill = spill
+	return s
+}
+
+// WithOutputMetricsPrefix changes the prefix used for counter metrics showing
+// the messages routed to an output.
+func (s *Sharded) WithOutputMetricsPrefix(prefix string) *Sharded {
+	s.outputsPrefix = prefix
+	return s
+}
+
+// Consume assigns a new messages channel for the broker to read.
+func (s *Sharded) Consume(ts <-chan types.Transaction) error {
+	if s.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	s.transactions = ts
+
+	go s.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (s *Sharded) Connected() bool {
+	for _, out := range s.outputs {
+		if !out.Connected() {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxInFlight returns the maximum number of in flight messages permitted by the
+// output. This value can be used to determine a sensible value for parent
+// outputs, but should not be relied upon as part of dispatcher logic.
+func (s *Sharded) MaxInFlight() (int, bool) {
+	return s.maxInFlight, true
+}
+
+//------------------------------------------------------------------------------
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix64 is the finalizer of the SplitMix64 generator, which is used in order to
+// evenly distribute the combined hashes of keys and outputs.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// target returns the index of the output a key is assigned to using rendezvous
+// hashing, where the output with the highest weight for a key is chosen. When
+// spilling is enabled outputs that are not connected are skipped, unless no
+// outputs are connected.
+func (s *Sharded) target(key string) int {
+	keyHash := hashString(key)
+
+	chosen, chosenWeight := -1, uint64(0)
+	fallback, fallbackWeight := 0, uint64(0)
+	for i, seed := range s.outputSeeds {
+		weight := mix64(keyHash ^ seed)
+		if weight >= fallbackWeight {
+			fallback, fallbackWeight = i, weight
+		}
+		if s.spill && !s.outputs[i].Connected() {
+			continue
+		}
+		if chosen == -1 || weight >= chosenWeight {
+			chosen, chosenWeight = i, weight
+		}
+	}
+	if chosen == -1 {
+		return fallback
+	}
+	return chosen
+}
+
+// loop is an internal loop that brokers incoming messages to many outputs.
+func (s *Sharded) loop() {
+	var (
+		wg        = sync.WaitGroup{}
+		mMsgsRcvd = s.stats.GetCounter("count")
+		mRouted   = []metrics.StatCounter{}
+		mErrs     = []metrics.StatCounter{}
+	)
+
+	defer func() {
+		wg.Wait()
+		for _, c := range s.outputTSChans {
+			close(c)
+		}
+		closeAllOutputs(s.outputs)
+		close(s.closedChan)
+	}()
+
+	for i := range s.outputs {
+		mRouted = append(mRouted, s.stats.GetCounter(fmt.Sprintf("%v.%v.routed", s.outputsPrefix, i)))
+		mErrs = append(mErrs, s.stats.GetCounter(fmt.Sprintf("%v.%v.failed", s.outputsPrefix, i)))
+	}
+
+	dispatch := func(group *imessage.SortGroup, sourceMessage types.Message, outputTargets [][]types.Part) error {
+		var dwg sync.WaitGroup
+		var errLock sync.Mutex
+		var generalErr error
+		var batchErr *batch.Error
+
+		setErrForPart := func(part types.Part, err error) {
+			errLock.Lock()
+			defer errLock.Unlock()
+
+			index := group.GetIndex(part)
+			if index == -1 {
+				generalErr = err
+				return
+			}
+			if batchErr == nil {
+				batchErr = batch.NewError(sourceMessage, err)
+			}
+			batchErr.Failed(index, err)
+		}
+
+		for target, parts := range outputTargets {
+			if len(parts) == 0 {
+				continue
+			}
+			dwg.Add(1)
+			msgCopy, i := message.New(nil), target
+			msgCopy.SetAll(parts)
+
+			go func() {
+				defer dwg.Done()
+
+				resChan := make(chan types.Response)
+				select {
+				case s.outputTSChans[i] <- types.NewTransaction(msgCopy, resChan):
+				case <-s.ctx.Done():
+					errLock.Lock()
+					generalErr = types.ErrTypeClosed
+					errLock.Unlock()
+					return
+				}
+				select {
+				case res := <-resChan:
+					if res.Error() == nil {
+						return
+					}
+					mErrs[i].Incr(1)
+					if bErr, ok := res.Error().(*batch.Error); ok {
+						bErr.WalkParts(func(_ int, p types.Part, e error) bool {
+							if e != nil {
+								setErrForPart(p, e)
+							}
+							return true
+						})
+					} else {
+						_ = msgCopy.Iter(func(_ int, p types.Part) error {
+							setErrForPart(p, res.Error())
+							return nil
+						})
+					}
+				case <-s.ctx.Done():
+					errLock.Lock()
+					generalErr = types.ErrTypeClosed
+					errLock.Unlock()
+				}
+			}()
+		}
+
+		dwg.Wait()
+		if generalErr != nil {
+			return generalErr
+		}
+		if batchErr != nil {
+			return batchErr
+		}
+		return nil
+	}
+
+	sendLoop := func() {
+		defer wg.Done()
+		for {
+			var open bool
+			var tran types.Transaction
+
+			select {
+			case tran, open = <-s.transactions:
+				if !open {
+					return
+				}
+			case <-s.ctx.Done():
+				return
+			}
+			mMsgsRcvd.Incr(1)
+
+			group, trackedMsg := imessage.NewSortGroup(tran.Payload)
+
+			outputTargets := make([][]types.Part, len(s.outputs))
+			_ = trackedMsg.Iter(func(i int, p types.Part) error {
+				target := s.target(s.keyFn(i, trackedMsg))
+				mRouted[target].Incr(1)
+				outputTargets[target] = append(outputTargets[target], p.Copy())
+				return nil
+			})
+
+			var res types.Response = response.NewAck()
+			if err := dispatch(group, trackedMsg, outputTargets); err != nil {
+				res = response.NewError(err)
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}
+
+	// Max in flight
+	for i := 0; i < s.maxInFlight; i++ {
+		wg.Add(1)
+		go sendLoop()
+	}
+}
+
+// CloseAsync shuts down the Sharded broker and stops processing requests.
+func (s *Sharded) CloseAsync() {
+	s.close()
+}
+
+// WaitForClose blocks until the Sharded broker has closed down.
+func (s *Sharded) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
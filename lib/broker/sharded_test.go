@@ -0,0 +1,175 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ types.Consumer = &Sharded{}
+var _ types.Closable = &Sharded{}
+
+type disconnectedMockOutput struct {
+	MockOutputType
+}
+
+func (m *disconnectedMockOutput) Connected() bool {
+	return false
+}
+
+func shardKeyByContent(i int, msg types.Message) string {
+	return string(msg.Get(i).Get())
+}
+
+func TestShardedDoubleClose(t *testing.T) {
+	oTM, err := NewSharded([]types.Output{&MockOutputType{}}, shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+
+	// This shouldn't cause a panic
+	oTM.CloseAsync()
+	oTM.CloseAsync()
+}
+
+func TestShardedTargets(t *testing.T) {
+	outputs := []types.Output{}
+	for i := 0; i < 10; i++ {
+		outputs = append(outputs, &MockOutputType{})
+	}
+
+	s, err := NewSharded(outputs, shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+
+	sFewer, err := NewSharded(outputs[:9], shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+
+	counts := make([]int, len(outputs))
+	remapped := 0
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key-%v", i)
+		target := s.target(key)
+		assert.Equal(t, target, s.target(key), key)
+		counts[target]++
+
+		if target != sFewer.target(key) {
+			remapped++
+			assert.Equal(t, 9, target, key)
+		}
+	}
+
+	// Keys should be spread roughly evenly across outputs.
+	for i, c := range counts {
+		assert.InDelta(t, 1000, c, 200, "output %v", i)
+	}
+
+	// Only the keys of the removed output should be remapped.
+	assert.Equal(t, counts[9], remapped)
+}
+
+func TestShardedSpill(t *testing.T) {
+	outputs := []types.Output{
+		&MockOutputType{},
+		&disconnectedMockOutput{},
+		&MockOutputType{},
+	}
+
+	s, err := NewSharded(outputs, shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+
+	sBlock, err := NewSharded(outputs, shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+	sBlock = sBlock.WithSpill(false)
+
+	blocked := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%v", i)
+		assert.NotEqual(t, 1, s.target(key), key)
+		if sBlock.target(key) == 1 {
+			blocked++
+		}
+	}
+	assert.Greater(t, blocked, 0)
+}
+
+func TestShardedBatch(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}}
+	outputs := []types.Output{}
+	for _, o := range mockOutputs {
+		outputs = append(outputs, o)
+	}
+
+	s, err := NewSharded(outputs, shardKeyByContent, metrics.Noop())
+	require.NoError(t, err)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(readChan))
+
+	var contents [][]byte
+	for i := 0; i < 20; i++ {
+		contents = append(contents, []byte(fmt.Sprintf("hello world %v", i)))
+	}
+
+	select {
+	case readChan <- types.NewTransaction(message.New(contents), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	errFailed := errors.New("failed")
+	received := map[string]int{}
+	for len(received) < len(contents) {
+		var ts types.Transaction
+		var target int
+		select {
+		case ts = <-mockOutputs[0].TChan:
+		case ts = <-mockOutputs[1].TChan:
+			target = 1
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		_ = ts.Payload.Iter(func(i int, p types.Part) error {
+			assert.Equal(t, target, s.target(string(p.Get())))
+			received[string(p.Get())] = target
+			return nil
+		})
+
+		// Messages sent to the second output fail.
+		var res types.Response = response.NewAck()
+		if target == 1 {
+			res = response.NewError(errFailed)
+		}
+		select {
+		case ts.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		bErr, ok := res.Error().(*batch.Error)
+		require.True(t, ok, res.Error())
+		bErr.WalkParts(func(i int, p types.Part, err error) bool {
+			if received[string(p.Get())] == 1 {
+				assert.Equal(t, errFailed, err, i)
+			} else {
+				assert.NoError(t, err, i)
+			}
+			return true
+		})
+	case <-time.After(time.Second):
+		t.Fatal("Timed out responding to broker")
+	}
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second))
+}
@@ -7,8 +7,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
+	"github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -116,6 +121,7 @@ func (t *Try) loop() {
 	var (
 		wg        = sync.WaitGroup{}
 		mMsgsRcvd = t.stats.GetCounter("count")
+		mSent     = []metrics.StatCounter{}
 		mErrs     = []metrics.StatCounter{}
 	)
 
@@ -129,6 +135,7 @@ func (t *Try) loop() {
 	}()
 
 	for i := range t.outputs {
+		mSent = append(mSent, t.stats.GetCounter(fmt.Sprintf("%v.%v.sent", t.outputsPrefix, i)))
 		mErrs = append(mErrs, t.stats.GetCounter(fmt.Sprintf("%v.%v.failed", t.outputsPrefix, i)))
 	}
 
@@ -148,39 +155,44 @@ func (t *Try) loop() {
 			}
 			mMsgsRcvd.Incr(1)
 
-			rChan := make(chan types.Response)
-			select {
-			case t.outputTSChans[0] <- types.NewTransaction(tran.Payload, rChan):
-			case <-t.ctx.Done():
-				return
-			}
+			group, pending := imessage.NewSortGroup(tran.Payload)
+			partErrs := map[int]error{}
 
-			var res types.Response
-			var lOpen bool
+			var err error
+			for i := 0; i < len(t.outputTSChans) && pending.Len() > 0; i++ {
+				if i > 0 {
+					pending = withFallbackErrors(group, pending, partErrs)
+				}
 
-		triesLoop:
-			for i := 1; i <= len(t.outputTSChans); i++ {
+				rChan := make(chan types.Response)
 				select {
-				case res, lOpen = <-rChan:
-					if !lOpen {
-						return
-					}
-					if res.Error() != nil {
-						mErrs[i-1].Incr(1)
-					} else {
-						break triesLoop
-					}
+				case t.outputTSChans[i] <- types.NewTransaction(pending, rChan):
 				case <-t.ctx.Done():
 					return
 				}
 
-				if i < len(t.outputTSChans) {
-					select {
-					case t.outputTSChans[i] <- types.NewTransaction(tran.Payload, rChan):
-					case <-t.ctx.Done():
+				var res types.Response
+				select {
+				case res, open = <-rChan:
+					if !open {
 						return
 					}
+				case <-t.ctx.Done():
+					return
+				}
+
+				if err = res.Error(); err == nil {
+					mSent[i].Incr(1)
+					pending = message.New(nil)
+					break
 				}
+				mErrs[i].Incr(1)
+				pending = t.failedParts(group, pending, err, partErrs)
+			}
+
+			var res types.Response = response.NewAck()
+			if pending.Len() > 0 {
+				res = response.NewError(t.remainingError(group, tran.Payload, pending, err, partErrs))
 			}
 			select {
 			case tran.ResponseChan <- res:
@@ -197,6 +209,80 @@ func (t *Try) loop() {
 	}
 }
 
+// failedParts returns a message containing only the parts of a sent message
+// that failed, recording the error of each part against its original index.
+func (t *Try) failedParts(group *imessage.SortGroup, sent types.Message, err error, partErrs map[int]error) types.Message {
+	if bErr, ok := err.(batch.WalkableError); ok && bErr.IndexedErrors() > 0 {
+		failed := message.New(nil)
+		failedErrs := map[int]error{}
+		bErr.WalkParts(func(_ int, p types.Part, pErr error) bool {
+			if pErr == nil {
+				return true
+			}
+			index := group.GetIndex(p)
+			if index < 0 {
+				// The output has lost track of the original messages, and
+				// therefore the whole batch must be considered failed.
+				failed = nil
+				return false
+			}
+			failedErrs[index] = pErr
+			failed.Append(p)
+			return true
+		})
+		if failed != nil {
+			for k, v := range failedErrs {
+				partErrs[k] = v
+			}
+			return failed
+		}
+	}
+
+	failed := message.New(nil)
+	_ = sent.Iter(func(_ int, p types.Part) error {
+		partErrs[group.GetIndex(p)] = err
+		failed.Append(p)
+		return nil
+	})
+	return failed
+}
+
+// remainingError creates an error for the parts that failed to be sent to all
+// outputs, where only a subset of the original batch failed the error
+// identifies those parts individually.
+func (t *Try) remainingError(group *imessage.SortGroup, source, remaining types.Message, err error, partErrs map[int]error) error {
+	if remaining.Len() == source.Len() {
+		if _, ok := err.(batch.WalkableError); !ok {
+			return err
+		}
+	}
+	bErr := batch.NewError(source, err)
+	_ = remaining.Iter(func(_ int, p types.Part) error {
+		if index := group.GetIndex(p); index >= 0 {
+			bErr.Failed(index, partErrs[index])
+		}
+		return nil
+	})
+	return bErr
+}
+
+// withFallbackErrors returns copies of the parts of a message with the error
+// from the previous attempt of each part set as the metadata field
+// fallback_error.
+func withFallbackErrors(group *imessage.SortGroup, msg types.Message, partErrs map[int]error) types.Message {
+	newMsg := message.New(nil)
+	_ = msg.Iter(func(_ int, p types.Part) error {
+		p = p.Copy()
+		if err := partErrs[group.GetIndex(p)]; err != nil {
+			p.Metadata().Set("fallback_error", err.Error())
+			metadata.SetHTTPError(p, err)
+		}
+		newMsg.Append(p)
+		return nil
+	})
+	return newMsg
+}
+
 // CloseAsync shuts down the Try broker and stops processing requests.
 func (t *Try) CloseAsync() {
 	t.close()
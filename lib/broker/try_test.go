@@ -8,10 +8,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var _ types.Consumer = &Try{}
@@ -385,3 +388,93 @@ func TestTryAllFailParallel(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestTryPartialBatch(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}}
+	outputs := []types.Output{}
+	for _, o := range mockOutputs {
+		outputs = append(outputs, o)
+	}
+
+	stats := metrics.NewLocal()
+	oTM, err := NewTry(outputs, stats)
+	require.NoError(t, err)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, oTM.Consume(readChan))
+
+	errFirst, errSecond := errors.New("first failed"), errors.New("second failed")
+
+	for _, secondFails := range []bool{false, true} {
+		select {
+		case readChan <- types.NewTransaction(message.New([][]byte{
+			[]byte("foo"), []byte("bar"), []byte("baz"),
+		}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker send")
+		}
+
+		var ts types.Transaction
+		select {
+		case ts = <-mockOutputs[0].TChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		require.Equal(t, 3, ts.Payload.Len())
+		select {
+		case ts.ResponseChan <- response.NewError(batch.NewError(ts.Payload, errFirst).Failed(1, errFirst)):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+
+		// Only the failed message is sent to the next output.
+		select {
+		case ts = <-mockOutputs[1].TChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(ts.Payload))
+		assert.Equal(t, "first failed", ts.Payload.Get(0).Metadata().Get("fallback_error"))
+
+		var res types.Response = response.NewAck()
+		if secondFails {
+			res = response.NewError(errSecond)
+		}
+		select {
+		case ts.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+
+		select {
+		case res = <-resChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+		if !secondFails {
+			assert.NoError(t, res.Error())
+			continue
+		}
+		bErr, ok := res.Error().(*batch.Error)
+		require.True(t, ok, res.Error())
+		bErr.WalkParts(func(i int, p types.Part, err error) bool {
+			if i == 1 {
+				assert.Equal(t, errSecond, err)
+				assert.Equal(t, "bar", string(p.Get()))
+				assert.Empty(t, p.Metadata().Get("fallback_error"))
+			} else {
+				assert.NoError(t, err, i)
+			}
+			return true
+		})
+	}
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(2), counters["broker.outputs.0.failed"])
+	assert.Equal(t, int64(1), counters["broker.outputs.1.sent"])
+	assert.Equal(t, int64(1), counters["broker.outputs.1.failed"])
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second))
+}
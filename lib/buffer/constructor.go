@@ -63,6 +63,7 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each buffer type.
 const (
+	TypeDelay  = "delay"
 	TypeMemory = "memory"
 	TypeNone   = "none"
 )
@@ -72,6 +73,7 @@ const (
 // Config is the all encompassing configuration struct for all buffer types.
 type Config struct {
 	Type   string       `json:"type" yaml:"type"`
+	Delay  DelayConfig  `json:"delay" yaml:"delay"`
 	Memory MemoryConfig `json:"memory" yaml:"memory"`
 	None   struct{}     `json:"none" yaml:"none"`
 }
@@ -80,6 +82,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Type:   "none",
+		Delay:  NewDelayConfig(),
 		Memory: NewMemoryConfig(),
 		None:   struct{}{},
 	}
@@ -119,11 +122,11 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if aliased.Type, _, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeBuffer, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	*conf = Config(aliased)
@@ -93,8 +93,9 @@ func TestSanitise(t *testing.T) {
 	exp = `{` +
 		`"type":"memory",` +
 		`"memory":{` +
-		`"batch_policy":{"byte_size":0,"check":"","count":0,"enabled":false,"period":"","processors":[]},` +
-		`"limit":20` +
+		`"batch_policy":{"byte_size":0,"check":"","count":0,"enabled":false,"flush_priority":0,"period":"","processors":[]},` +
+		`"limit":20,` +
+		`"priority":{"enabled":false,"starvation_ratio":0}` +
 		`}` +
 		`}`
 
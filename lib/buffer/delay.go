@@ -0,0 +1,635 @@
+package buffer
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDelay] = TypeSpec{
+		constructor: NewDelay,
+		Summary: `
+Parks messages until a release time calculated for each message, and then
+releases them in time order into the rest of the pipeline without blocking the
+consumption of other messages.`,
+		Description: `
+Unlike other buffers this buffer does not acknowledge messages at the input
+level, instead acknowledgements are withheld until a message has been released
+and delivered by the output layer, and therefore delivery guarantees are
+preserved. However, this means that the input must be able to have many
+messages pending acknowledgement at the same time, and any messages that are
+parked when Benthos shuts down are not acknowledged and will therefore be
+redelivered by inputs that support it.
+
+This is a buffer rather than a processor because processors return their results
+to the pipeline thread that called them, and therefore a processor that parked
+messages would block that thread in the same way as the ` + "`sleep`" + ` processor.
+As a buffer, messages continue to be consumed whilst others are parked, and are
+released to the processing threads in the order of their release times rather
+than the order in which they were consumed.
+
+The release time of each message is determined by the interpolated field
+` + "`release`" + `, which can either resolve to a duration string (e.g. ` + "`5m`" + `),
+which is added to the time at which the message was received, or an absolute
+timestamp as either an RFC3339 string or a unix timestamp in seconds. When a
+batch of messages is consumed the batch is released at the latest release time
+of its messages. If the release time cannot be resolved the message is released
+immediately and an error is logged.
+
+### Capacity
+
+The maximum number of messages that can be parked at any given time is set with
+` + "`max_parked`" + `, and the field ` + "`overflow`" + ` determines what happens
+when a message is consumed that would exceed this limit.
+
+Messages are held in memory by default, but by setting ` + "`spill.directory`" + `
+the contents of parked messages exceeding ` + "`spill.max_in_memory`" + ` are
+written to disk until they are released. Spilled messages are written in a
+binary format to files that are only readable by the owner of the Benthos
+process, and are removed when Benthos shuts down, as they are redelivered by the
+input.
+
+### Metrics
+
+The gauge ` + "`delay.parked`" + ` tracks the number of parked messages, and the
+counters ` + "`delay.released`" + `, ` + "`delay.rejected`" + ` and
+` + "`delay.spilled`" + ` track messages as they are released, rejected due to
+overflow and spilled to disk respectively.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldString(
+				"release", "A duration or timestamp that determines when each message is released.",
+				`${! meta("retry_in") }`, `${! this.send_at }`, "5m",
+			).IsInterpolated(),
+			docs.FieldInt("max_parked", "The maximum number of messages that can be parked at any given time."),
+			docs.FieldString("overflow", "The behaviour when a message is consumed that would exceed `max_parked`.").HasAnnotatedOptions(
+				"block", "Stop consuming messages, applying back pressure upstream, until a parked message is released.",
+				"reject", "Reject the message with an error so that it is redelivered by the input.",
+			),
+			docs.FieldAdvanced("spill", "Optionally write the contents of parked messages to disk in order to reduce memory usage.").WithChildren(
+				docs.FieldString("directory", "A directory to write parked messages to, if empty messages are never spilled."),
+				docs.FieldInt("max_in_memory", "The number of parked messages to hold in memory before the contents of further messages are written to disk."),
+			),
+		},
+		Version: "3.50.0",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DelaySpillConfig contains configuration fields for writing the contents of
+// messages parked within a delay buffer to disk.
+type DelaySpillConfig struct {
+	Directory   string `json:"directory" yaml:"directory"`
+	MaxInMemory int    `json:"max_in_memory" yaml:"max_in_memory"`
+}
+
+// DelayConfig is config values for a delay buffer.
+type DelayConfig struct {
+	Release   string           `json:"release" yaml:"release"`
+	MaxParked int              `json:"max_parked" yaml:"max_parked"`
+	Overflow  string           `json:"overflow" yaml:"overflow"`
+	Spill     DelaySpillConfig `json:"spill" yaml:"spill"`
+}
+
+// NewDelayConfig creates a DelayConfig with default values.
+func NewDelayConfig() DelayConfig {
+	return DelayConfig{
+		Release:   "",
+		MaxParked: 1000,
+		Overflow:  "block",
+		Spill: DelaySpillConfig{
+			Directory:   "",
+			MaxInMemory: 1000,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ErrDelayOverflow is returned to the input when a delay buffer rejects a
+// message due to being at capacity.
+var ErrDelayOverflow = errors.New("delay buffer is at capacity")
+
+type parkedTran struct {
+	releaseAt time.Time
+	seq       uint64
+
+	tran      types.Transaction
+	spillPath string
+}
+
+type parkedHeap []*parkedTran
+
+func (h parkedHeap) Len() int { return len(h) }
+
+func (h parkedHeap) Less(i, j int) bool {
+	if h[i].releaseAt.Equal(h[j].releaseAt) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].releaseAt.Before(h[j].releaseAt)
+}
+
+func (h parkedHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *parkedHeap) Push(x interface{}) {
+	*h = append(*h, x.(*parkedTran))
+}
+
+func (h *parkedHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+//------------------------------------------------------------------------------
+
+// Delay is a buffer that parks transactions until a release time and then
+// forwards them in time order, propagating acknowledgements back to the input.
+type Delay struct {
+	conf    DelayConfig
+	log     log.Modular
+	release *field.Expression
+
+	spillDir  string
+	spillSeq  uint64
+	inMemory  int
+	parked    parkedHeap
+	seq       uint64
+	parkedMut sync.Mutex
+	spaceCond *sync.Cond
+
+	notifyChan  chan struct{}
+	messagesIn  <-chan types.Transaction
+	messagesOut chan types.Transaction
+
+	consuming   int32
+	stopConsume chan struct{}
+	consumeDone chan struct{}
+	closeOnce   sync.Once
+	closeChan   chan struct{}
+	closed      chan struct{}
+
+	mParked   metrics.StatGauge
+	mReleased metrics.StatCounter
+	mRejected metrics.StatCounter
+	mSpilled  metrics.StatCounter
+}
+
+// NewDelay creates a buffer that parks messages until a release time.
+func NewDelay(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.Delay.Release == "" {
+		return nil, errors.New("a release expression must be provided")
+	}
+	if conf.Delay.MaxParked <= 0 {
+		return nil, fmt.Errorf("max_parked must be greater than zero, got %v", conf.Delay.MaxParked)
+	}
+	switch conf.Delay.Overflow {
+	case "block", "reject":
+	default:
+		return nil, fmt.Errorf("overflow behaviour not recognised: %v", conf.Delay.Overflow)
+	}
+
+	release, err := bloblang.NewField(conf.Delay.Release)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release expression: %v", err)
+	}
+
+	d := &Delay{
+		conf:        conf.Delay,
+		log:         log,
+		release:     release,
+		notifyChan:  make(chan struct{}, 1),
+		messagesOut: make(chan types.Transaction),
+		stopConsume: make(chan struct{}),
+		consumeDone: make(chan struct{}),
+		closeChan:   make(chan struct{}),
+		closed:      make(chan struct{}),
+		mParked:     stats.GetGauge("delay.parked"),
+		mReleased:   stats.GetCounter("delay.released"),
+		mRejected:   stats.GetCounter("delay.rejected"),
+		mSpilled:    stats.GetCounter("delay.spilled"),
+	}
+	d.spaceCond = sync.NewCond(&d.parkedMut)
+
+	if conf.Delay.Spill.Directory != "" {
+		if err := os.MkdirAll(conf.Delay.Spill.Directory, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+		if d.spillDir, err = ioutil.TempDir(conf.Delay.Spill.Directory, "benthos_delay_"); err != nil {
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+	}
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *Delay) releaseTime(msg types.Message, received time.Time) time.Time {
+	var latest time.Time
+	for i := 0; i < msg.Len(); i++ {
+		str := d.release.String(i, msg)
+
+		var t time.Time
+		if dur, err := time.ParseDuration(str); err == nil {
+			t = received.Add(dur)
+		} else if ts, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			t = ts
+		} else if secs, err := strconv.ParseInt(str, 10, 64); err == nil {
+			t = time.Unix(secs, 0)
+		} else {
+			d.log.Errorf("Failed to parse release time '%v', releasing message immediately\n", str)
+			t = received
+		}
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+func writeSpillUint32(w *bufio.Writer, v int) error {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	_, err := w.Write(b[:])
+	return err
+}
+
+func writeSpillBytes(w *bufio.Writer, b []byte) error {
+	if err := writeSpillUint32(w, len(b)); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// writeSpilled writes a message as the number of parts followed by each part,
+// where a part consists of the number of metadata pairs followed by each key
+// and value and then the contents of the part. All counts and lengths are
+// unsigned 32 bit big endian integers, and each key, value and content is
+// prefixed with its length.
+func writeSpilled(w *bufio.Writer, msg types.Message) error {
+	if err := writeSpillUint32(w, msg.Len()); err != nil {
+		return err
+	}
+	return msg.Iter(func(i int, part types.Part) error {
+		var meta []string
+		_ = part.Metadata().Iter(func(k, v string) error {
+			meta = append(meta, k, v)
+			return nil
+		})
+		if err := writeSpillUint32(w, len(meta)/2); err != nil {
+			return err
+		}
+		for _, kv := range meta {
+			if err := writeSpillBytes(w, []byte(kv)); err != nil {
+				return err
+			}
+		}
+		return writeSpillBytes(w, part.Get())
+	})
+}
+
+var errBadSpillBytes = errors.New("spilled message is malformed")
+
+func readSpillUint32(b []byte) (uint32, []byte, error) {
+	if len(b) < 4 {
+		return 0, nil, errBadSpillBytes
+	}
+	return binary.BigEndian.Uint32(b), b[4:], nil
+}
+
+func readSpillBytes(b []byte) ([]byte, []byte, error) {
+	l, b, err := readSpillUint32(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint32(len(b)) < l {
+		return nil, nil, errBadSpillBytes
+	}
+	return b[:l:l], b[l:], nil
+}
+
+func readSpilled(b []byte) (types.Message, error) {
+	numParts, b, err := readSpillUint32(b)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.New(nil)
+	for i := uint32(0); i < numParts; i++ {
+		var numMeta uint32
+		if numMeta, b, err = readSpillUint32(b); err != nil {
+			return nil, err
+		}
+		part := message.NewPart(nil)
+		for j := uint32(0); j < numMeta; j++ {
+			var k, v []byte
+			if k, b, err = readSpillBytes(b); err != nil {
+				return nil, err
+			}
+			if v, b, err = readSpillBytes(b); err != nil {
+				return nil, err
+			}
+			part.Metadata().Set(string(k), string(v))
+		}
+		var content []byte
+		if content, b, err = readSpillBytes(b); err != nil {
+			return nil, err
+		}
+		part.Set(content)
+		msg.Append(part)
+	}
+	if len(b) > 0 {
+		return nil, errBadSpillBytes
+	}
+	return msg, nil
+}
+
+func (d *Delay) spill(p *parkedTran) error {
+	path := filepath.Join(d.spillDir, strconv.FormatUint(atomic.AddUint64(&d.spillSeq, 1), 10))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err = writeSpilled(w, p.tran.Payload); err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	p.spillPath = path
+	p.tran = types.NewTransaction(nil, p.tran.ResponseChan)
+	return nil
+}
+
+func (d *Delay) unspill(p *parkedTran) (types.Transaction, error) {
+	b, err := ioutil.ReadFile(p.spillPath)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	msg, err := readSpilled(b)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	_ = os.Remove(p.spillPath)
+	return types.NewTransaction(msg, p.tran.ResponseChan), nil
+}
+
+func (d *Delay) notify() {
+	select {
+	case d.notifyChan <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Delay) reject(tran types.Transaction) {
+	d.mRejected.Incr(1)
+	go func() {
+		select {
+		case tran.ResponseChan <- response.NewError(ErrDelayOverflow):
+		case <-d.closeChan:
+		}
+	}()
+}
+
+// park adds a transaction to the heap, blocking until there is capacity when
+// the overflow behaviour is block. Returns false if the buffer was closed
+// whilst waiting.
+func (d *Delay) park(tran types.Transaction) bool {
+	p := &parkedTran{
+		releaseAt: d.releaseTime(tran.Payload, time.Now()),
+		tran:      tran,
+	}
+
+	d.parkedMut.Lock()
+	for len(d.parked) >= d.conf.MaxParked {
+		if d.conf.Overflow == "reject" {
+			d.parkedMut.Unlock()
+			d.reject(tran)
+			return true
+		}
+		select {
+		case <-d.closeChan:
+			d.parkedMut.Unlock()
+			return false
+		default:
+		}
+		d.spaceCond.Wait()
+	}
+
+	if d.spillDir != "" && d.inMemory >= d.conf.Spill.MaxInMemory {
+		if err := d.spill(p); err != nil {
+			d.log.Errorf("Failed to spill parked message to disk, holding it in memory instead: %v\n", err)
+		} else {
+			d.mSpilled.Incr(1)
+		}
+	}
+	if p.spillPath == "" {
+		d.inMemory++
+	}
+
+	d.seq++
+	p.seq = d.seq
+	heap.Push(&d.parked, p)
+	d.mParked.Set(int64(len(d.parked)))
+	d.parkedMut.Unlock()
+
+	d.notify()
+	return true
+}
+
+// next blocks until the next parked transaction is due for release, returning
+// false if the buffer is closed or if it has stopped consuming and no parked
+// transactions remain.
+func (d *Delay) next() (*parkedTran, bool) {
+	for {
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		var consumeDone <-chan struct{}
+
+		d.parkedMut.Lock()
+		if len(d.parked) > 0 {
+			until := time.Until(d.parked[0].releaseAt)
+			if until <= 0 {
+				p := heap.Pop(&d.parked).(*parkedTran)
+				if p.spillPath == "" {
+					d.inMemory--
+				}
+				d.mParked.Set(int64(len(d.parked)))
+				d.spaceCond.Signal()
+				d.parkedMut.Unlock()
+				return p, true
+			}
+			timer = time.NewTimer(until)
+			timerChan = timer.C
+		} else {
+			select {
+			case <-d.consumeDone:
+				d.parkedMut.Unlock()
+				return nil, false
+			default:
+			}
+			consumeDone = d.consumeDone
+		}
+		d.parkedMut.Unlock()
+
+		select {
+		case <-timerChan:
+		case <-d.notifyChan:
+		case <-consumeDone:
+		case <-d.closeChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil, false
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (d *Delay) consumeLoop() {
+	defer func() {
+		close(d.consumeDone)
+		d.notify()
+	}()
+
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-d.messagesIn:
+			if !open {
+				return
+			}
+		case <-d.stopConsume:
+			return
+		case <-d.closeChan:
+			return
+		}
+		if !d.park(tran) {
+			return
+		}
+	}
+}
+
+func (d *Delay) releaseLoop() {
+	defer func() {
+		close(d.messagesOut)
+		if d.spillDir != "" {
+			_ = os.RemoveAll(d.spillDir)
+		}
+		close(d.closed)
+	}()
+
+	for {
+		p, ok := d.next()
+		if !ok {
+			return
+		}
+
+		tran := p.tran
+		if p.spillPath != "" {
+			var err error
+			if tran, err = d.unspill(p); err != nil {
+				d.log.Errorf("Failed to read spilled message from disk: %v\n", err)
+				go func(resChan chan<- types.Response) {
+					select {
+					case resChan <- response.NewError(err):
+					case <-d.closeChan:
+					}
+				}(p.tran.ResponseChan)
+				continue
+			}
+		}
+
+		select {
+		case d.messagesOut <- tran:
+			d.mReleased.Incr(1)
+		case <-d.closeChan:
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a messages channel for the buffer to read.
+func (d *Delay) Consume(msgs <-chan types.Transaction) error {
+	if !atomic.CompareAndSwapInt32(&d.consuming, 0, 1) {
+		return types.ErrAlreadyStarted
+	}
+	d.messagesIn = msgs
+	go d.consumeLoop()
+	go d.releaseLoop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// buffer.
+func (d *Delay) TransactionChan() <-chan types.Transaction {
+	return d.messagesOut
+}
+
+// StopConsuming instructs the buffer to stop consuming messages and close once
+// all parked messages have been released.
+func (d *Delay) StopConsuming() {
+	if atomic.CompareAndSwapInt32(&d.consuming, 1, 2) {
+		close(d.stopConsume)
+	}
+}
+
+// CloseAsync shuts down the buffer and abandons any parked messages.
+func (d *Delay) CloseAsync() {
+	d.closeOnce.Do(func() {
+		close(d.closeChan)
+		d.parkedMut.Lock()
+		d.spaceCond.Broadcast()
+		d.parkedMut.Unlock()
+	})
+}
+
+// WaitForClose blocks until the buffer has closed down.
+func (d *Delay) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-d.closed:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,246 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDelayTestMsg(content, delay string) types.Message {
+	msg := message.New([][]byte{[]byte(content)})
+	msg.Get(0).Metadata().Set("delay", delay)
+	return msg
+}
+
+func TestDelayBufferReleaseOrder(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDelay
+	conf.Delay.Release = `${! meta("delay") }`
+
+	buf, err := NewDelay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, buf.Consume(tChan))
+
+	resChans := map[string]chan types.Response{}
+	started := time.Now()
+	for _, v := range [][2]string{
+		{"third", "300ms"},
+		{"first", "100ms"},
+		{"second", "200ms"},
+	} {
+		resChan := make(chan types.Response)
+		resChans[v[0]] = resChan
+		select {
+		case tChan <- types.NewTransaction(newDelayTestMsg(v[0], v[1]), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	for _, exp := range []string{"first", "second", "third"} {
+		var tran types.Transaction
+		select {
+		case tran = <-buf.TransactionChan():
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		assert.Equal(t, exp, string(tran.Payload.Get(0).Get()))
+
+		// The acknowledgement must be propagated back to the original origin.
+		go func() {
+			tran.ResponseChan <- response.NewAck()
+		}()
+		select {
+		case res := <-resChans[exp]:
+			assert.NoError(t, res.Error())
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(started)), int64(300*time.Millisecond))
+
+	buf.StopConsuming()
+	require.NoError(t, buf.WaitForClose(time.Second*5))
+}
+
+func TestDelayBufferAbsoluteTimestamp(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDelay
+	conf.Delay.Release = `${! meta("delay") }`
+
+	d, err := NewDelay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	buf := d.(*Delay)
+
+	now := time.Now()
+	assert.Equal(t, now.Add(time.Minute), buf.releaseTime(newDelayTestMsg("foo", "1m"), now))
+	assert.Equal(t, time.Unix(1600000000, 0), buf.releaseTime(newDelayTestMsg("foo", "1600000000"), now))
+
+	ts, err := time.Parse(time.RFC3339, "2021-07-12T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, ts, buf.releaseTime(newDelayTestMsg("foo", "2021-07-12T10:00:00Z"), now))
+
+	assert.Equal(t, now, buf.releaseTime(newDelayTestMsg("foo", "nope"), now))
+
+	batch := newDelayTestMsg("foo", "1m")
+	batch.Append(newDelayTestMsg("bar", "2m").Get(0))
+	assert.Equal(t, now.Add(2*time.Minute), buf.releaseTime(batch, now))
+}
+
+func TestDelayBufferOverflowReject(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDelay
+	conf.Delay.Release = "1h"
+	conf.Delay.MaxParked = 1
+	conf.Delay.Overflow = "reject"
+
+	buf, err := NewDelay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, buf.Consume(tChan))
+
+	firstResChan, secondResChan := make(chan types.Response), make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(newDelayTestMsg("first", ""), firstResChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case tChan <- types.NewTransaction(newDelayTestMsg("second", ""), secondResChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case res := <-secondResChan:
+		assert.Equal(t, ErrDelayOverflow, res.Error())
+	case <-firstResChan:
+		t.Fatal("unexpected response to parked message")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	buf.CloseAsync()
+	require.NoError(t, buf.WaitForClose(time.Second*5))
+}
+
+func TestDelayBufferSpill(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDelay
+	conf.Delay.Release = `${! meta("delay") }`
+	conf.Delay.Spill.Directory = t.TempDir()
+	conf.Delay.Spill.MaxInMemory = 1
+
+	buf, err := NewDelay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, buf.Consume(tChan))
+
+	for _, v := range [][2]string{
+		{"first", "50ms"},
+		{"second", "100ms"},
+		{"third", "150ms"},
+	} {
+		select {
+		case tChan <- types.NewTransaction(newDelayTestMsg(v[0], v[1]), make(chan types.Response, 1)):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	for _, exp := range [][2]string{
+		{"first", "50ms"},
+		{"second", "100ms"},
+		{"third", "150ms"},
+	} {
+		select {
+		case tran := <-buf.TransactionChan():
+			assert.Equal(t, exp[0], string(tran.Payload.Get(0).Get()))
+			assert.Equal(t, exp[1], tran.Payload.Get(0).Metadata().Get("delay"))
+			tran.ResponseChan <- response.NewAck()
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	buf.StopConsuming()
+	require.NoError(t, buf.WaitForClose(time.Second*5))
+}
+
+func TestDelaySpillEncoding(t *testing.T) {
+	msg := message.New([][]byte{[]byte("foo"), []byte(""), []byte("bar")})
+	msg.Get(0).Metadata().Set("a", "b")
+	msg.Get(0).Metadata().Set("c", "")
+	msg.Get(2).Metadata().Set("d", "e")
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	require.NoError(t, writeSpilled(w, msg))
+	require.NoError(t, w.Flush())
+
+	b := buf.Bytes()
+	res, err := readSpilled(b)
+	require.NoError(t, err)
+	assert.Equal(t, message.GetAllBytes(msg), message.GetAllBytes(res))
+	for i := 0; i < msg.Len(); i++ {
+		assert.Equal(t, metadataToMap(msg.Get(i)), metadataToMap(res.Get(i)), i)
+	}
+
+	for i := 0; i < len(b); i++ {
+		_, err = readSpilled(b[:i])
+		assert.Equal(t, errBadSpillBytes, err, i)
+	}
+	_, err = readSpilled(append(b, 0))
+	assert.Equal(t, errBadSpillBytes, err)
+}
+
+func metadataToMap(p types.Part) map[string]string {
+	m := map[string]string{}
+	_ = p.Metadata().Iter(func(k, v string) error {
+		m[k] = v
+		return nil
+	})
+	return m
+}
+
+func TestDelaySpillFilePermissions(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDelay
+	conf.Delay.Release = "1s"
+	conf.Delay.Spill.Directory = t.TempDir()
+
+	buf, err := NewDelay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	d := buf.(*Delay)
+
+	p := &parkedTran{tran: types.NewTransaction(newDelayTestMsg("foo", "1s"), nil)}
+	require.NoError(t, d.spill(p))
+
+	info, err := os.Stat(p.spillPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	tran, err := d.unspill(p)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "1s", tran.Payload.Get(0).Metadata().Get("delay"))
+
+	_, err = os.Stat(p.spillPath)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.RemoveAll(d.spillDir))
+}
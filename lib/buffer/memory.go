@@ -2,8 +2,10 @@ package buffer
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/buffer/parallel"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
@@ -33,7 +35,27 @@ significantly below the amount of RAM available.
 ### Batching
 
 It is possible to batch up messages sent from this buffer using a
-[batch policy](/docs/configuration/batching#batch-policy).`,
+[batch policy](/docs/configuration/batching#batch-policy).
+
+### Priority
+
+When ` + "`priority.enabled`" + ` is set to ` + "`true`" + ` messages are read from
+the buffer in order of their priority, with messages of a higher priority being
+flushed before any of a lower priority that are queued. Messages of equal
+priority are flushed in the order in which they were received. Priorities are
+assigned with the [` + "`priority`" + ` processor](/docs/components/processors/priority)
+placed within the processors of an input, since processors of the pipeline are
+executed after the buffer, and messages without a priority default to zero. A batch of messages takes the
+highest priority of its parts.
+
+With strict priority ordering a constant stream of high priority messages can
+starve those of a lower priority indefinitely. In order to avoid this the field
+` + "`priority.starvation_ratio`" + ` can be set to a number of consecutive
+prioritised reads after which the oldest message of the buffer is flushed
+regardless of its priority.
+
+The counter metric ` + "`priority.read`" + `, labelled by ` + "`priority`" + `,
+tracks the number of messages read from the buffer of each priority.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("limit", "The maximum buffer size (in bytes) to allow before applying backpressure upstream."),
 			docs.FieldCommon("batch_policy", "Optionally configure a policy to flush buffered messages in batches.").WithChildren(
@@ -41,6 +63,10 @@ It is possible to batch up messages sent from this buffer using a
 					docs.FieldCommon("enabled", "Whether to batch messages as they are flushed."),
 				}, batch.FieldSpec().Children...)...,
 			),
+			docs.FieldAdvanced("priority", "Optionally flush buffered messages in order of priority.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to flush messages in order of priority."),
+				docs.FieldCommon("starvation_ratio", "The number of consecutive reads of higher priority messages after which the oldest message is read regardless of its priority. Set to zero for strict priority ordering."),
+			).AtVersion("3.50.0"),
 		},
 	}
 }
@@ -53,10 +79,18 @@ type EnabledBatchPolicyConfig struct {
 	batch.PolicyConfig `json:",inline" yaml:",inline"`
 }
 
+// MemoryPriorityConfig contains configuration fields for flushing messages
+// from a memory buffer in order of priority.
+type MemoryPriorityConfig struct {
+	Enabled         bool `json:"enabled" yaml:"enabled"`
+	StarvationRatio int  `json:"starvation_ratio" yaml:"starvation_ratio"`
+}
+
 // MemoryConfig is config values for a purely memory based ring buffer type.
 type MemoryConfig struct {
 	Limit       int                      `json:"limit" yaml:"limit"`
 	BatchPolicy EnabledBatchPolicyConfig `json:"batch_policy" yaml:"batch_policy"`
+	Priority    MemoryPriorityConfig     `json:"priority" yaml:"priority"`
 }
 
 // NewMemoryConfig creates a new MemoryConfig with default values.
@@ -67,6 +101,10 @@ func NewMemoryConfig() MemoryConfig {
 			Enabled:      false,
 			PolicyConfig: batch.NewPolicyConfig(),
 		},
+		Priority: MemoryPriorityConfig{
+			Enabled:         false,
+			StarvationRatio: 0,
+		},
 	}
 }
 
@@ -74,7 +112,17 @@ func NewMemoryConfig() MemoryConfig {
 
 // NewMemory creates a buffer held in memory.
 func NewMemory(config Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	wrap := NewParallelWrapper(config, parallel.NewMemory(config.Memory.Limit), log, stats)
+	var buf Parallel = parallel.NewMemory(config.Memory.Limit)
+	if config.Memory.Priority.Enabled {
+		if config.Memory.Priority.StarvationRatio < 0 {
+			return nil, fmt.Errorf("priority starvation_ratio must not be negative, got %v", config.Memory.Priority.StarvationRatio)
+		}
+		buf = &priorityMetrics{
+			Parallel: parallel.NewPriorityMemory(config.Memory.Limit, config.Memory.Priority.StarvationRatio),
+			mRead:    stats.GetCounterVec("priority.read", []string{"priority"}),
+		}
+	}
+	wrap := NewParallelWrapper(config, buf, log, stats)
 	if !config.Memory.BatchPolicy.Enabled {
 		return wrap, nil
 	}
@@ -86,3 +134,20 @@ func NewMemory(config Config, mgr types.Manager, log log.Modular, stats metrics.
 }
 
 //------------------------------------------------------------------------------
+
+// priorityMetrics wraps a parallel buffer and tracks the number of messages read
+// from it by priority.
+type priorityMetrics struct {
+	Parallel
+	mRead metrics.StatCounterVec
+}
+
+func (p *priorityMetrics) NextMessage() (types.Message, parallel.AckFunc, error) {
+	msg, ackFn, err := p.Parallel.NextMessage()
+	if err == nil {
+		p.mRead.With(strconv.Itoa(imessage.GetBatchPriority(msg))).Incr(1)
+	}
+	return msg, ackFn, err
+}
+
+//------------------------------------------------------------------------------
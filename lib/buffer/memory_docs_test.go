@@ -29,7 +29,11 @@ func TestMemorySanit(t *testing.T) {
         byte_size: 0
         period: ""
         check: ""
+        flush_priority: 0
         processors: []
+    priority:
+        enabled: false
+        starvation_ratio: 0
 `
 
 	b, err := yaml.Marshal(node)
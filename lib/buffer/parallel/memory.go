@@ -3,6 +3,7 @@ package parallel
 import (
 	"sync"
 
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -12,9 +13,14 @@ import (
 // consumers to read and purge messages from the buffer asynchronously.
 type Memory struct {
 	messages     []types.Message
+	priorities   []int
 	bytes        int
 	pendingBytes int
 
+	prioritised     bool
+	starvationRatio int
+	skipped         int
+
 	cap  int
 	cond *sync.Cond
 
@@ -30,6 +36,21 @@ func NewMemory(capacity int) *Memory {
 	}
 }
 
+// NewPriorityMemory creates a memory based parallel buffer where messages with
+// a higher priority are read before those with a lower priority, and messages
+// of equal priority are read in the order in which they were added.
+//
+// When starvationRatio is greater than zero the oldest message in the buffer is
+// read after that many consecutive reads of newer, higher priority messages,
+// which prevents low priority messages from being starved indefinitely. A
+// starvationRatio of zero results in strict priority ordering.
+func NewPriorityMemory(capacity, starvationRatio int) *Memory {
+	m := NewMemory(capacity)
+	m.prioritised = true
+	m.starvationRatio = starvationRatio
+	return m
+}
+
 //------------------------------------------------------------------------------
 
 // NextMessage reads the next oldest message, the message is preserved until the
@@ -45,10 +66,19 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 		return nil, nil, types.ErrTypeClosed
 	}
 
-	msg := m.messages[0]
-
-	m.messages[0] = nil
-	m.messages = m.messages[1:]
+	var msg types.Message
+	if index := m.nextIndex(); index == 0 {
+		msg = m.messages[0]
+		m.messages[0] = nil
+		m.messages = m.messages[1:]
+		if m.prioritised {
+			m.priorities = m.priorities[1:]
+		}
+	} else {
+		msg = m.messages[index]
+		m.messages = append(m.messages[:index], m.messages[index+1:]...)
+		m.priorities = append(m.priorities[:index], m.priorities[index+1:]...)
+	}
 
 	messageSize := 0
 	msg.Iter(func(i int, b types.Part) error {
@@ -71,6 +101,9 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 			m.bytes -= messageSize
 		} else {
 			m.messages = append([]types.Message{msg}, m.messages...)
+			if m.prioritised {
+				m.priorities = append([]int{imessage.GetBatchPriority(msg)}, m.priorities...)
+			}
 		}
 		m.cond.Broadcast()
 
@@ -81,6 +114,27 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 	}, nil
 }
 
+// nextIndex returns the index of the next message to be read, which is always
+// the oldest message unless priorities are enabled. Must be called whilst
+// holding the lock and with at least one message queued.
+func (m *Memory) nextIndex() int {
+	if !m.prioritised {
+		return 0
+	}
+	index, highest := 0, m.priorities[0]
+	for i, p := range m.priorities[1:] {
+		if p > highest {
+			index, highest = i+1, p
+		}
+	}
+	if index == 0 || (m.starvationRatio > 0 && m.skipped >= m.starvationRatio) {
+		m.skipped = 0
+		return 0
+	}
+	m.skipped++
+	return index
+}
+
 // PushMessage adds a new message to the stack. Returns the backlog in bytes.
 func (m *Memory) PushMessage(msg types.Message) (int, error) {
 	extraBytes := 0
@@ -93,6 +147,11 @@ func (m *Memory) PushMessage(msg types.Message) (int, error) {
 		return 0, types.ErrMessageTooLarge
 	}
 
+	var priority int
+	if m.prioritised {
+		priority = imessage.GetBatchPriority(msg)
+	}
+
 	m.cond.L.Lock()
 
 	if m.closed {
@@ -109,6 +168,9 @@ func (m *Memory) PushMessage(msg types.Message) (int, error) {
 	}
 
 	m.messages = append(m.messages, msg.DeepCopy())
+	if m.prioritised {
+		m.priorities = append(m.priorities, priority)
+	}
 	m.bytes += extraBytes
 
 	backlog := m.bytes
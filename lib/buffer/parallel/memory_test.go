@@ -3,10 +3,12 @@ package parallel
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -322,3 +324,94 @@ func TestMemoryRejectLargeMessage(t *testing.T) {
 		t.Errorf("Unexpected error: %v != %v", exp, actual)
 	}
 }
+
+func TestMemoryPriority(t *testing.T) {
+	tests := []struct {
+		name            string
+		starvationRatio int
+		priorities      []int
+		expected        []string
+	}{
+		{
+			name:       "strict",
+			priorities: []int{0, 0, 5, 1, 5, 0},
+			expected:   []string{"2", "4", "3", "0", "1", "5"},
+		},
+		{
+			name:            "starvation ratio",
+			starvationRatio: 2,
+			priorities:      []int{0, 5, 5, 5, 5, 1},
+			expected:        []string{"1", "2", "0", "3", "4", "5"},
+		},
+		{
+			name:            "starvation ratio resets on oldest",
+			starvationRatio: 1,
+			priorities:      []int{5, 0, 5, 0},
+			expected:        []string{"0", "2", "1", "3"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			block := NewPriorityMemory(100000, test.starvationRatio)
+			for i, p := range test.priorities {
+				part := imessage.WithPriority(message.NewPart([]byte(fmt.Sprintf("%v", i))), p)
+				msg := message.New(nil)
+				msg.Append(part)
+				if _, err := block.PushMessage(msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var actual []string
+			for range test.expected {
+				m, ackFunc, err := block.NextMessage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, string(m.Get(0).Get()))
+				if _, err := ackFunc(true); err != nil {
+					t.Error(err)
+				}
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Wrong order of messages: %v != %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestMemoryPriorityNack(t *testing.T) {
+	block := NewPriorityMemory(100000, 0)
+	for i, p := range []int{0, 5} {
+		msg := message.New(nil)
+		msg.Append(imessage.WithPriority(message.NewPart([]byte(fmt.Sprintf("%v", i))), p))
+		if _, err := block.PushMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, ackFunc, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "1", string(m.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	if _, err = ackFunc(false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"1", "0"} {
+		if m, ackFunc, err = block.NextMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if act := string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if _, err = ackFunc(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -24,8 +24,9 @@ import (
 
 func init() {
 	Constructors[TypeAWSDynamoDB] = TypeSpec{
-		constructor: NewAWSDynamoDB,
-		Version:     "3.36.0",
+		constructor:       NewAWSDynamoDB,
+		SupportsPerKeyTTL: true,
+		Version:           "3.36.0",
 		Summary: `
 Stores key/value pairs as a single document in a DynamoDB table. The key is
 stored as a string value and used as the table hash key. The value is stored as
@@ -33,7 +34,9 @@ a binary value using the ` + "`data_key`" + ` field name.`,
 		Description: `
 A prefix can be specified to allow multiple cache types to share a single
 DynamoDB table. An optional TTL duration (` + "`ttl`" + `) and field
-(` + "`ttl_key`" + `) can be specified if the backing table has TTL enabled.
+(` + "`ttl_key`" + `) can be specified if the backing table has TTL enabled. Since
+DynamoDB removes expired items lazily, items with an expired TTL that still
+exist within the table are treated as though they do not exist.
 
 Strong read consistency can be enabled using the ` + "`consistent_read`" + `
 configuration field.
@@ -49,14 +52,15 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("hash_key", "The key of the table column to store item keys within."),
 			docs.FieldCommon("data_key", "The key of the table column to store item values within."),
 			docs.FieldAdvanced("consistent_read", "Whether to use strongly consistent reads on Get commands."),
-			docs.FieldAdvanced("ttl", "An optional TTL to set for items, calculated from the moment the item is cached."),
-			docs.FieldAdvanced("ttl_key", "The column key to place the TTL value within."),
+			docs.FieldAdvanced("ttl", "An optional default TTL to set for items, calculated from the moment the item is cached. TTLs are only written when a `ttl_key` is specified."),
+			docs.FieldAdvanced("ttl_key", "The column key to place the TTL value within, which should match the TTL attribute configured on the table."),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
 	}
 
 	Constructors[TypeDynamoDB] = TypeSpec{
-		constructor: NewDynamoDB,
-		Status:      docs.StatusDeprecated,
+		constructor:       NewDynamoDB,
+		SupportsPerKeyTTL: true,
+		Status:            docs.StatusDeprecated,
 		Summary: `
 Stores key/value pairs as a single document in a DynamoDB table. The key is
 stored as a string value and used as the table hash key. The value is stored as
@@ -68,7 +72,9 @@ This cache has been renamed to ` + "[`aws_dynamodb`](/docs/components/caches/aws
 
 A prefix can be specified to allow multiple cache types to share a single
 DynamoDB table. An optional TTL duration (` + "`ttl`" + `) and field
-(` + "`ttl_key`" + `) can be specified if the backing table has TTL enabled.
+(` + "`ttl_key`" + `) can be specified if the backing table has TTL enabled. Since
+DynamoDB removes expired items lazily, items with an expired TTL that still
+exist within the table are treated as though they do not exist.
 
 Strong read consistency can be enabled using the ` + "`consistent_read`" + `
 configuration field.
@@ -84,8 +90,8 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("hash_key", "The key of the table column to store item keys within."),
 			docs.FieldCommon("data_key", "The key of the table column to store item values within."),
 			docs.FieldAdvanced("consistent_read", "Whether to use strongly consistent reads on Get commands."),
-			docs.FieldAdvanced("ttl", "An optional TTL to set for items, calculated from the moment the item is cached."),
-			docs.FieldAdvanced("ttl_key", "The column key to place the TTL value within."),
+			docs.FieldAdvanced("ttl", "An optional default TTL to set for items, calculated from the moment the item is cached. TTLs are only written when a `ttl_key` is specified."),
+			docs.FieldAdvanced("ttl_key", "The column key to place the TTL value within, which should match the TTL attribute configured on the table."),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
 	}
 }
@@ -184,11 +190,38 @@ func NewDynamoDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.
 }
 
 func newDynamoDB(conf DynamoDBConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
+	sess, err := conf.GetSessionWithFailover(session.LogFailover(log, stats.GetCounter("failover")))
+	if err != nil {
+		return nil, err
+	}
+
+	client := dynamodb.New(sess)
+	out, err := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(conf.Table),
+	})
+	if err != nil {
+		return nil, err
+	} else if out == nil ||
+		out.Table == nil ||
+		out.Table.TableStatus == nil ||
+		*out.Table.TableStatus != dynamodb.TableStatusActive {
+		return nil, fmt.Errorf("table '%s' must be active", conf.Table)
+	}
+
+	d, err := newDynamoDBFromClient(client, conf, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newDynamoDBFromClient(client dynamodbiface.DynamoDBAPI, conf DynamoDBConfig, log log.Modular, stats metrics.Type) (*DynamoDB, error) {
 	d := DynamoDB{
-		conf:  conf,
-		log:   log,
-		stats: stats,
-		table: aws.String(conf.Table),
+		client: client,
+		conf:   conf,
+		log:    log,
+		stats:  stats,
+		table:  aws.String(conf.Table),
 
 		mLatency:         stats.GetTimer("latency"),
 		mGetCount:        stats.GetCounter("get.count"),
@@ -229,24 +262,7 @@ func newDynamoDB(conf DynamoDBConfig, mgr types.Manager, log log.Modular, stats
 		d.ttl = ttl
 	}
 
-	sess, err := d.conf.GetSession()
-	if err != nil {
-		return nil, err
-	}
-
-	d.client = dynamodb.New(sess)
-	out, err := d.client.DescribeTable(&dynamodb.DescribeTableInput{
-		TableName: d.table,
-	})
-	if err != nil {
-		return nil, err
-	} else if out == nil ||
-		out.Table == nil ||
-		out.Table.TableStatus == nil ||
-		*out.Table.TableStatus != dynamodb.TableStatusActive {
-		return nil, fmt.Errorf("table '%s' must be active", d.conf.Table)
-	}
-
+	var err error
 	if d.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
@@ -317,11 +333,38 @@ func (d *DynamoDB) get(key string) ([]byte, error) {
 		d.log.Debugf("key not found: %s", key)
 		return nil, types.ErrKeyNotFound
 	}
+	if d.expired(res.Item) {
+		d.log.Debugf("key expired: %s", key)
+		return nil, types.ErrKeyNotFound
+	}
 	return val.B, nil
 }
 
+// expired returns whether an item has a TTL value that has passed, as DynamoDB
+// may take a while to remove expired items.
+func (d *DynamoDB) expired(item map[string]*dynamodb.AttributeValue) bool {
+	if d.conf.TTLKey == "" {
+		return false
+	}
+	ttlVal, ok := item[d.conf.TTLKey]
+	if !ok || ttlVal.N == nil {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(*ttlVal.N, 10, 64)
+	if err != nil {
+		return false
+	}
+	return expiresAt <= time.Now().Unix()
+}
+
 // Set attempts to set the value of a key.
 func (d *DynamoDB) Set(key string, value []byte) error {
+	return d.SetWithTTL(key, value, nil)
+}
+
+// SetWithTTL attempts to set the value of a key with a TTL that overrides the
+// configured default.
+func (d *DynamoDB) SetWithTTL(key string, value []byte, ttl *time.Duration) error {
 	d.mSetCount.Incr(1)
 
 	tStarted := time.Now()
@@ -331,7 +374,7 @@ func (d *DynamoDB) Set(key string, value []byte) error {
 		d.boffPool.Put(boff)
 	}()
 
-	_, err := d.client.PutItem(d.putItemInput(key, value))
+	_, err := d.client.PutItem(d.putItemInput(key, value, ttl))
 	for err != nil {
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -339,7 +382,7 @@ func (d *DynamoDB) Set(key string, value []byte) error {
 		}
 		time.Sleep(wait)
 		d.mSetRetry.Incr(1)
-		_, err = d.client.PutItem(d.putItemInput(key, value))
+		_, err = d.client.PutItem(d.putItemInput(key, value, ttl))
 	}
 	if err == nil {
 		d.mSetSuccess.Incr(1)
@@ -357,6 +400,19 @@ func (d *DynamoDB) Set(key string, value []byte) error {
 // SetMulti attempts to set the value of multiple keys, if any keys fail to be
 // set an error is returned.
 func (d *DynamoDB) SetMulti(items map[string][]byte) error {
+	sitems := make(map[string]types.CacheTTLItem, len(items))
+	for k, v := range items {
+		sitems[k] = types.CacheTTLItem{
+			Value: v,
+		}
+	}
+	return d.SetMultiWithTTL(sitems)
+}
+
+// SetMultiWithTTL attempts to set the value of multiple keys with TTLs that
+// override the configured default, if any keys fail to be set an error is
+// returned.
+func (d *DynamoDB) SetMultiWithTTL(items map[string]types.CacheTTLItem) error {
 	d.mSetMultiCount.Incr(1)
 
 	tStarted := time.Now()
@@ -370,7 +426,7 @@ func (d *DynamoDB) SetMulti(items map[string][]byte) error {
 	for k, v := range items {
 		writeReqs = append(writeReqs, &dynamodb.WriteRequest{
 			PutRequest: &dynamodb.PutRequest{
-				Item: d.putItemInput(k, v).Item,
+				Item: d.putItemInput(k, v.Value, v.TTL).Item,
 			},
 		})
 	}
@@ -418,6 +474,13 @@ func (d *DynamoDB) SetMulti(items map[string][]byte) error {
 // Add attempts to set the value of a key only if the key does not already exist
 // and returns an error if the key already exists.
 func (d *DynamoDB) Add(key string, value []byte) error {
+	return d.AddWithTTL(key, value, nil)
+}
+
+// AddWithTTL attempts to set the value of a key with a TTL that overrides the
+// configured default, only if the key does not already exist, and returns an
+// error if the key already exists.
+func (d *DynamoDB) AddWithTTL(key string, value []byte, ttl *time.Duration) error {
 	d.mAddCount.Incr(1)
 
 	tStarted := time.Now()
@@ -427,7 +490,7 @@ func (d *DynamoDB) Add(key string, value []byte) error {
 		d.boffPool.Put(boff)
 	}()
 
-	err := d.add(key, value)
+	err := d.add(key, value, ttl)
 	for err != nil && err != types.ErrKeyAlreadyExists {
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -435,7 +498,7 @@ func (d *DynamoDB) Add(key string, value []byte) error {
 		}
 		time.Sleep(wait)
 		d.mAddRetry.Incr(1)
-		err = d.add(key, value)
+		err = d.add(key, value, ttl)
 	}
 	if err == nil {
 		d.mAddSuccess.Incr(1)
@@ -452,16 +515,22 @@ func (d *DynamoDB) Add(key string, value []byte) error {
 	return err
 }
 
-func (d *DynamoDB) add(key string, value []byte) error {
-	input := d.putItemInput(key, value)
+func (d *DynamoDB) add(key string, value []byte, ttl *time.Duration) error {
+	input := d.putItemInput(key, value, ttl)
 
-	expr, err := expression.NewBuilder().
-		WithCondition(expression.AttributeNotExists(expression.Name(d.conf.HashKey))).
-		Build()
+	cond := expression.AttributeNotExists(expression.Name(d.conf.HashKey))
+	if d.conf.TTLKey != "" {
+		// Items that have expired but are yet to be removed by DynamoDB can be
+		// overwritten.
+		cond = cond.Or(expression.Name(d.conf.TTLKey).LessThanEqual(expression.Value(time.Now().Unix())))
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
 	if err != nil {
 		return err
 	}
 	input.ExpressionAttributeNames = expr.Names()
+	input.ExpressionAttributeValues = expr.Values()
 	input.ConditionExpression = expr.Condition()
 
 	if _, err = d.client.PutItem(input); err != nil {
@@ -521,8 +590,9 @@ func (d *DynamoDB) delete(key string) error {
 	return err
 }
 
-// putItemInput creates a generic put item input for use in Set and Add operations
-func (d *DynamoDB) putItemInput(key string, value []byte) *dynamodb.PutItemInput {
+// putItemInput creates a generic put item input for use in Set and Add
+// operations, where a nil TTL results in the configured default being used.
+func (d *DynamoDB) putItemInput(key string, value []byte, ttl *time.Duration) *dynamodb.PutItemInput {
 	input := dynamodb.PutItemInput{
 		Item: map[string]*dynamodb.AttributeValue{
 			d.conf.HashKey: {
@@ -535,9 +605,13 @@ func (d *DynamoDB) putItemInput(key string, value []byte) *dynamodb.PutItemInput
 		TableName: d.table,
 	}
 
-	if d.ttl != 0 && d.conf.TTLKey != "" {
+	itemTTL := d.ttl
+	if ttl != nil {
+		itemTTL = *ttl
+	}
+	if itemTTL != 0 && d.conf.TTLKey != "" {
 		input.Item[d.conf.TTLKey] = &dynamodb.AttributeValue{
-			N: aws.String(strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)),
+			N: aws.String(strconv.FormatInt(time.Now().Add(itemTTL).Unix(), 10)),
 		}
 	}
 
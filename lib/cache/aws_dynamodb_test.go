@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	getOutput *dynamodb.GetItemOutput
+	getInputs []*dynamodb.GetItemInput
+	putErr    error
+	putInputs []*dynamodb.PutItemInput
+}
+
+func (m *mockDynamoDB) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	m.getInputs = append(m.getInputs, input)
+	return m.getOutput, nil
+}
+
+func (m *mockDynamoDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	m.putInputs = append(m.putInputs, input)
+	if m.putErr != nil {
+		return nil, m.putErr
+	}
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func newMockDynamoDBCache(t *testing.T, client *mockDynamoDB, confFn func(c *DynamoDBConfig)) *DynamoDB {
+	t.Helper()
+
+	conf := NewDynamoDBConfig()
+	conf.Table = "foo"
+	conf.HashKey = "id"
+	conf.DataKey = "data"
+	conf.MaxRetries = 0
+	if confFn != nil {
+		confFn(&conf)
+	}
+
+	d, err := newDynamoDBFromClient(client, conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	return d
+}
+
+func TestDynamoDBGetExpired(t *testing.T) {
+	client := &mockDynamoDB{}
+	d := newMockDynamoDBCache(t, client, func(c *DynamoDBConfig) {
+		c.ConsistentRead = true
+		c.TTLKey = "ttl"
+	})
+
+	client.getOutput = &dynamodb.GetItemOutput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"id":   {S: aws.String("foo")},
+			"data": {B: []byte("bar")},
+			"ttl":  {N: aws.String(strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))},
+		},
+	}
+	v, err := d.Get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(v))
+	assert.True(t, *client.getInputs[0].ConsistentRead)
+
+	client.getOutput.Item["ttl"] = &dynamodb.AttributeValue{
+		N: aws.String(strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)),
+	}
+	_, err = d.Get("foo")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+}
+
+func TestDynamoDBSetTTL(t *testing.T) {
+	client := &mockDynamoDB{}
+	d := newMockDynamoDBCache(t, client, func(c *DynamoDBConfig) {
+		c.TTL = "1h"
+		c.TTLKey = "ttl"
+	})
+
+	ttlOf := func(input *dynamodb.PutItemInput) time.Duration {
+		t.Helper()
+		require.Contains(t, input.Item, "ttl")
+		expiresAt, err := strconv.ParseInt(*input.Item["ttl"].N, 10, 64)
+		require.NoError(t, err)
+		return time.Until(time.Unix(expiresAt, 0)).Round(time.Minute)
+	}
+
+	require.NoError(t, d.Set("foo", []byte("bar")))
+	assert.Equal(t, time.Hour, ttlOf(client.putInputs[0]))
+
+	keyTTL := time.Minute * 10
+	require.NoError(t, d.SetWithTTL("foo", []byte("bar"), &keyTTL))
+	assert.Equal(t, keyTTL, ttlOf(client.putInputs[1]))
+
+	require.NoError(t, d.AddWithTTL("foo", []byte("bar"), &keyTTL))
+	assert.Equal(t, keyTTL, ttlOf(client.putInputs[2]))
+	assert.Equal(t, "(attribute_not_exists (#0)) OR (#1 <= :0)", *client.putInputs[2].ConditionExpression)
+	assert.Equal(t, "ttl", *client.putInputs[2].ExpressionAttributeNames["#1"])
+}
+
+func TestDynamoDBAddExists(t *testing.T) {
+	client := &mockDynamoDB{
+		putErr: awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "nope", nil),
+	}
+	d := newMockDynamoDBCache(t, client, nil)
+
+	assert.Equal(t, types.ErrKeyAlreadyExists, d.Add("foo", []byte("bar")))
+	assert.Equal(t, "attribute_not_exists (#0)", *client.putInputs[0].ConditionExpression)
+	assert.Nil(t, client.putInputs[0].ExpressionAttributeValues)
+}
@@ -161,7 +161,7 @@ func newS3(conf S3Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse timeout: %v", err)
 	}
-	sess, err := conf.GetSession(func(c *aws.Config) {
+	sess, err := conf.GetSessionWithFailover(sess.LogFailover(log, stats.GetCounter("failover")), func(c *aws.Config) {
 		c.S3ForcePathStyle = aws.Bool(conf.ForcePathStyleURLs)
 	})
 	if err != nil {
@@ -101,6 +101,7 @@ type Config struct {
 	AWSDynamoDB DynamoDBConfig   `json:"aws_dynamodb" yaml:"aws_dynamodb"`
 	AWSS3       S3Config         `json:"aws_s3" yaml:"aws_s3"`
 	DynamoDB    DynamoDBConfig   `json:"dynamodb" yaml:"dynamodb"`
+	Enabled     bool             `json:"enabled" yaml:"enabled"`
 	File        FileConfig       `json:"file" yaml:"file"`
 	Memcached   MemcachedConfig  `json:"memcached" yaml:"memcached"`
 	Memory      MemoryConfig     `json:"memory" yaml:"memory"`
@@ -108,6 +109,7 @@ type Config struct {
 	Multilevel  MultilevelConfig `json:"multilevel" yaml:"multilevel"`
 	Plugin      interface{}      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 	Redis       RedisConfig      `json:"redis" yaml:"redis"`
+	Retries     RetriesConfig    `json:"retries" yaml:"retries"`
 	Ristretto   RistrettoConfig  `json:"ristretto" yaml:"ristretto"`
 	S3          S3Config         `json:"s3" yaml:"s3"`
 }
@@ -120,6 +122,7 @@ func NewConfig() Config {
 		AWSDynamoDB: NewDynamoDBConfig(),
 		AWSS3:       NewS3Config(),
 		DynamoDB:    NewDynamoDBConfig(),
+		Enabled:     true,
 		File:        NewFileConfig(),
 		Memcached:   NewMemcachedConfig(),
 		Memory:      NewMemoryConfig(),
@@ -127,6 +130,7 @@ func NewConfig() Config {
 		Multilevel:  NewMultilevelConfig(),
 		Plugin:      nil,
 		Redis:       NewRedisConfig(),
+		Retries:     NewRetriesConfig(),
 		Ristretto:   NewRistrettoConfig(),
 		S3:          NewS3Config(),
 	}
@@ -172,23 +176,23 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var spec docs.ComponentSpec
 	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeCache, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if spec.Plugin {
 		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 			conf := spec.confConstructor()
 			if err = pluginNode.Decode(conf); err != nil {
-				return fmt.Errorf("line %v: %v", value.Line, err)
+				return docs.ErrorAtNode(value, err)
 			}
 			aliased.Plugin = conf
 		} else {
@@ -300,14 +304,14 @@ func New(
 		if err != nil {
 			return nil, fmt.Errorf("failed to create cache '%v': %v", conf.Type, err)
 		}
-		return cache, nil
+		return WrapRetries(conf, cache, log, stats)
 	}
 	if c, ok := pluginSpecs[conf.Type]; ok {
 		rl, err := c.constructor(conf, mgr, log, stats)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create cache '%v': %v", conf.Type, err)
 		}
-		return rl, nil
+		return WrapRetries(conf, rl, log, stats)
 	}
 	return nil, types.ErrInvalidCacheType
 }
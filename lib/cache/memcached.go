@@ -1,8 +1,12 @@
 package cache
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -10,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
@@ -22,12 +27,27 @@ func init() {
 		Summary: `
 Connects to a cluster of memcached services, a prefix can be specified to allow
 multiple cache types to share a memcached cluster under different namespaces.`,
+		Description: `
+### Authentication
+
+Servers that require authentication can be accessed by setting the fields
+` + "`username` and `password`" + `. Since this cache communicates using the
+memcached text protocol the credentials are sent using the authentication
+command of the text protocol, which requires memcached v1.5.15 or later started
+with an authentication file (` + "`-Y`" + `). Servers that only accept
+authentication over the binary protocol via SASL are not supported.
+
+Authentication is attempted each time a connection is established, and
+connections that fail to authenticate result in an error naming the server.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldString("addresses", "A list of addresses of memcached servers to use.").Array(),
 			docs.FieldCommon("prefix", "An optional string to prefix item keys with in order to prevent collisions with similar services."),
 			docs.FieldCommon("ttl", "A TTL in seconds to set for items, after this period keys will be removed."),
 			docs.FieldAdvanced("retries", "The maximum number of retry attempts to make before abandoning a request."),
 			docs.FieldAdvanced("retry_period", "The duration to wait between retry attempts."),
+			btls.FieldSpec().AtVersion("3.50.0"),
+			docs.FieldAdvanced("username", "An optional username to authenticate with.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("password", "An optional password to authenticate with.").AtVersion("3.50.0"),
 		},
 	}
 }
@@ -36,11 +56,14 @@ multiple cache types to share a memcached cluster under different namespaces.`,
 
 // MemcachedConfig is a config struct for a memcached connection.
 type MemcachedConfig struct {
-	Addresses   []string `json:"addresses" yaml:"addresses"`
-	Prefix      string   `json:"prefix" yaml:"prefix"`
-	TTL         int32    `json:"ttl" yaml:"ttl"`
-	Retries     int      `json:"retries" yaml:"retries"`
-	RetryPeriod string   `json:"retry_period" yaml:"retry_period"`
+	Addresses   []string    `json:"addresses" yaml:"addresses"`
+	Prefix      string      `json:"prefix" yaml:"prefix"`
+	TTL         int32       `json:"ttl" yaml:"ttl"`
+	Retries     int         `json:"retries" yaml:"retries"`
+	RetryPeriod string      `json:"retry_period" yaml:"retry_period"`
+	TLS         btls.Config `json:"tls" yaml:"tls"`
+	Username    string      `json:"username" yaml:"username"`
+	Password    string      `json:"password" yaml:"password"`
 }
 
 // NewMemcachedConfig returns a MemcachedConfig with default values.
@@ -51,6 +74,9 @@ func NewMemcachedConfig() MemcachedConfig {
 		TTL:         300,
 		Retries:     3,
 		RetryPeriod: "500ms",
+		TLS:         btls.NewConfig(),
+		Username:    "",
+		Password:    "",
 	}
 }
 
@@ -109,6 +135,26 @@ func NewMemcached(
 			return nil, fmt.Errorf("failed to parse retry period string: %v", err)
 		}
 	}
+
+	mc := memcache.New(addresses...)
+	if conf.Memcached.TLS.Enabled {
+		tlsConf, err := conf.Memcached.TLS.Get()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tls config: %w", err)
+		}
+		mc.DialContext = tlsDialContext(tlsConf)
+	}
+	if conf.Memcached.Username != "" || conf.Memcached.Password != "" {
+		if conf.Memcached.Username == "" {
+			return nil, errors.New("a username must be provided alongside a password")
+		}
+		dialContext := mc.DialContext
+		if dialContext == nil {
+			dialContext = (&net.Dialer{}).DialContext
+		}
+		mc.DialContext = authDialContext(dialContext, conf.Memcached.Username, conf.Memcached.Password)
+	}
+
 	return &Memcached{
 		conf:  conf,
 		log:   log,
@@ -139,10 +185,71 @@ func NewMemcached(
 		mDelLatency:    stats.GetTimer("delete.latency"),
 
 		retryPeriod: retryPeriod,
-		mc:          memcache.New(addresses...),
+		mc:          mc,
 	}, nil
 }
 
+// tlsDialContext returns a dial function that establishes TLS connections,
+// where handshake failures are returned with the address of the server in
+// order to distinguish them from regular connection errors.
+func tlsDialContext(tlsConf *tls.Config) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialer := &tls.Dialer{Config: tlsConf}
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to establish TLS connection to %v: %w", address, err)
+		}
+		return conn, nil
+	}
+}
+
+// authDialContext returns a dial function that authenticates each connection
+// it establishes using the authentication command of the text protocol.
+func authDialContext(
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error),
+	username, password string,
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		if err = memcachedAuth(conn, username, password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate with %v: %w", address, err)
+		}
+		_ = conn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
+
+// memcachedAuth sends credentials over a connection, where the key and flags of
+// the set command are ignored by the server.
+func memcachedAuth(conn net.Conn, username, password string) error {
+	creds := username + " " + password
+	if _, err := fmt.Fprintf(conn, "set auth 0 0 %d\r\n%s\r\n", len(creds), creds); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	switch line = strings.TrimSpace(line); line {
+	case "STORED":
+		return nil
+	case "ERROR":
+		return errors.New("server does not support authentication")
+	}
+	return errors.New(line)
+}
+
 //------------------------------------------------------------------------------
 
 // getItemFor returns a memcache.Item object ready to be stored in memcache
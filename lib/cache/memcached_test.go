@@ -0,0 +1,287 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"Benthos"}},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+// startTLSMemcached runs a minimal memcached server over TLS that stores every
+// item it is sent and reports all keys as missing.
+func startTLSMemcached(t *testing.T, certPEM, keyPEM []byte) string {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch {
+					case strings.HasPrefix(line, "set "):
+						if _, err = r.ReadString('\n'); err != nil {
+							return
+						}
+						_, err = conn.Write([]byte("STORED\r\n"))
+					case strings.HasPrefix(line, "get"):
+						_, err = conn.Write([]byte("END\r\n"))
+					default:
+						_, err = conn.Write([]byte("ERROR\r\n"))
+					}
+					if err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestMemcachedTLS(t *testing.T) {
+	certPEM, keyPEM := createTestCert(t)
+	addr := startTLSMemcached(t, certPEM, keyPEM)
+
+	conf := NewConfig()
+	conf.Type = TypeMemcached
+	conf.Memcached.Addresses = []string{addr}
+	conf.Memcached.Retries = 0
+	conf.Memcached.TLS.Enabled = true
+	conf.Memcached.TLS.RootCAs = string(certPEM)
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set("foo", []byte("bar")))
+
+	_, err = c.Get("foo")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+}
+
+func TestMemcachedTLSErrors(t *testing.T) {
+	certPEM, keyPEM := createTestCert(t)
+	addr := startTLSMemcached(t, certPEM, keyPEM)
+
+	tests := map[string]func(conf *Config){
+		"unknown authority": func(conf *Config) {},
+		"wrong server name": func(conf *Config) {
+			conf.Memcached.TLS.RootCAs = string(certPEM)
+			conf.Memcached.TLS.ServerName = "nope.example.com"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeMemcached
+			conf.Memcached.Addresses = []string{addr}
+			conf.Memcached.Retries = 0
+			conf.Memcached.TLS.Enabled = true
+			fn(&conf)
+
+			c, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			err = c.Set("foo", []byte("bar"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to establish TLS connection to "+addr)
+		})
+	}
+}
+
+func TestMemcachedTLSBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeMemcached
+	conf.Memcached.TLS.Enabled = true
+	conf.Memcached.TLS.RootCAs = "not a cert"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "root_cas")
+}
+
+// startAuthMemcached runs a minimal memcached server that requires clients to
+// authenticate before storing items, and reports all keys as missing.
+func startAuthMemcached(t *testing.T, username, password string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := false
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch {
+					case strings.HasPrefix(line, "set "):
+						var data string
+						if data, err = r.ReadString('\n'); err != nil {
+							return
+						}
+						if authed {
+							_, err = conn.Write([]byte("STORED\r\n"))
+						} else if strings.TrimSpace(data) == username+" "+password {
+							authed = true
+							_, err = conn.Write([]byte("STORED\r\n"))
+						} else {
+							_, err = conn.Write([]byte("CLIENT_ERROR authentication failure\r\n"))
+						}
+					case !authed:
+						_, err = conn.Write([]byte("CLIENT_ERROR unauthenticated\r\n"))
+					case strings.HasPrefix(line, "get"):
+						_, err = conn.Write([]byte("END\r\n"))
+					default:
+						_, err = conn.Write([]byte("ERROR\r\n"))
+					}
+					if err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestMemcachedAuth(t *testing.T) {
+	addr := startAuthMemcached(t, "foo", "bar")
+
+	conf := NewConfig()
+	conf.Type = TypeMemcached
+	conf.Memcached.Addresses = []string{addr}
+	conf.Memcached.Retries = 0
+	conf.Memcached.Username = "foo"
+	conf.Memcached.Password = "bar"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set("foo", []byte("bar")))
+
+	_, err = c.Get("foo")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+}
+
+func TestMemcachedAuthErrors(t *testing.T) {
+	addr := startAuthMemcached(t, "foo", "bar")
+
+	conf := NewConfig()
+	conf.Type = TypeMemcached
+	conf.Memcached.Addresses = []string{addr}
+	conf.Memcached.Retries = 0
+	conf.Memcached.Username = "foo"
+	conf.Memcached.Password = "nope"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = c.Set("foo", []byte("bar"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to authenticate with "+addr+": CLIENT_ERROR authentication failure")
+
+	conf.Memcached.Username = ""
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a username must be provided alongside a password")
+}
+
+func TestMemcachedAuthTLS(t *testing.T) {
+	certPEM, keyPEM := createTestCert(t)
+	addr := startTLSMemcached(t, certPEM, keyPEM)
+
+	conf := NewConfig()
+	conf.Type = TypeMemcached
+	conf.Memcached.Addresses = []string{addr}
+	conf.Memcached.Retries = 0
+	conf.Memcached.TLS.Enabled = true
+	conf.Memcached.TLS.RootCAs = string(certPEM)
+	conf.Memcached.Username = "foo"
+	conf.Memcached.Password = "bar"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set("foo", []byte("bar")))
+}
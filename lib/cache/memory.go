@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -43,7 +44,22 @@ memory:
 ` + "```" + `
 
 These values can be overridden during execution, at which point the configured
-TTL is respected as usual.`,
+TTL is respected as usual.
+
+### Exporting
+
+The contents of a memory cache resource can be exported as newline delimited
+JSON documents with a ` + "`GET`" + ` request to the endpoint
+` + "`/resources/caches/{name}/export`" + `, or with the command
+` + "`benthos resources export`" + `. Each document contains the key, the value
+(base64 encoded), the size of the value and the remaining TTL of an item:
+
+` + "```sh" + `
+benthos resources export --address localhost:4195 foo > ./foo_dump.jsonl
+` + "```" + `
+
+The field ` + "`init_from_file`" + ` can then be used in order to prepopulate a
+cache from such an export, where the remaining TTL of each item is preserved.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("ttl", "The TTL of each item in seconds. After this period an item will be eligible for removal during the next compaction."),
 			docs.FieldCommon("compaction_interval", "The period of time to wait before each compaction, at which point expired items are removed."),
@@ -56,6 +72,7 @@ TTL is respected as usual.`,
 					"The Human League": "1977",
 				},
 			).Map(),
+			docs.FieldString("init_from_file", "An optional path to a file of newline delimited JSON documents, as produced by a cache export, to prepopulate the cache with on initialization. Items that have expired are skipped.", "./cache_dump.jsonl").Advanced().AtVersion("3.50.0"),
 		},
 	}
 }
@@ -67,6 +84,7 @@ type MemoryConfig struct {
 	TTL                int               `json:"ttl" yaml:"ttl"`
 	CompactionInterval string            `json:"compaction_interval" yaml:"compaction_interval"`
 	InitValues         map[string]string `json:"init_values" yaml:"init_values"`
+	InitFromFile       string            `json:"init_from_file" yaml:"init_from_file"`
 	Shards             int               `json:"shards" yaml:"shards"`
 }
 
@@ -76,6 +94,7 @@ func NewMemoryConfig() MemoryConfig {
 		TTL:                300, // 5 Mins
 		CompactionInterval: "60s",
 		InitValues:         map[string]string{},
+		InitFromFile:       "",
 		Shards:             1,
 	}
 }
@@ -170,6 +189,12 @@ func NewMemory(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 		}
 	}
 
+	if conf.Memory.InitFromFile != "" {
+		if err := m.initFromFile(conf.Memory.InitFromFile); err != nil {
+			return nil, err
+		}
+	}
+
 	for k, v := range conf.Memory.InitValues {
 		m.getShard(k).items[k] = item{
 			value: []byte(v),
@@ -180,6 +205,32 @@ func NewMemory(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 	return cache.NewV2ToV1Cache(m, stats), nil
 }
 
+func (m *memoryV2) initFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open init_from_file: %w", err)
+	}
+	defer f.Close()
+
+	return cache.ReadExport(f, func(e cache.ExportItem) error {
+		ttl, err := e.TTL()
+		if err != nil {
+			return err
+		}
+		shard := m.getShard(e.Key)
+		i := item{value: e.Value}
+		if ttl != nil {
+			if *ttl <= 0 {
+				return nil
+			}
+			// Backdate the item so that its remaining TTL is preserved.
+			i.ts = time.Now().Add(*ttl - shard.ttl)
+		}
+		shard.items[e.Key] = i
+		return nil
+	})
+}
+
 type memoryV2 struct {
 	shards []*shard
 }
@@ -242,6 +293,43 @@ func (m *memoryV2) Delete(_ context.Context, key string) error {
 	return nil
 }
 
+func (m *memoryV2) Export(ctx context.Context, fn func(item cache.ExportItem) error) error {
+	for _, shard := range m.shards {
+		// Only the keys are copied up front so that large caches are streamed
+		// rather than duplicated in memory, and so that writes aren't blocked
+		// for the duration of the export.
+		shard.RLock()
+		keys := make([]string, 0, len(shard.items))
+		for k := range shard.items {
+			keys = append(keys, k)
+		}
+		shard.RUnlock()
+
+		for _, k := range keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			shard.RLock()
+			i, exists := shard.items[k]
+			shard.RUnlock()
+			if !exists || shard.isExpired(i) {
+				continue
+			}
+
+			var ttlRemaining *time.Duration
+			if shard.compInterval > 0 && !i.ts.IsZero() {
+				remaining := shard.ttl - time.Since(i.ts)
+				ttlRemaining = &remaining
+			}
+			if err := fn(cache.NewExportItem(k, i.value, ttlRemaining)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (m *memoryV2) Close(context.Context) error {
 	return nil
 }
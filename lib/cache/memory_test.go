@@ -1,10 +1,15 @@
 package cache
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -147,6 +152,83 @@ func TestMemoryCacheInitValues(t *testing.T) {
 	}
 }
 
+func TestMemoryCacheExportRoundTrip(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.Shards = 3
+	conf.Memory.InitValues = map[string]string{
+		"static": "forever",
+	}
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("key%v", i), []byte(fmt.Sprintf("value%v", i))))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.WriteExport(context.Background(), c, &buf))
+
+	var items []cache.ExportItem
+	require.NoError(t, cache.ReadExport(bytes.NewReader(buf.Bytes()), func(item cache.ExportItem) error {
+		items = append(items, item)
+		return nil
+	}))
+	require.Len(t, items, 11)
+	for _, item := range items {
+		assert.Equal(t, len(item.Value), item.ValueSize)
+		ttl, err := item.TTL()
+		require.NoError(t, err)
+		if item.Key == "static" {
+			assert.Nil(t, ttl)
+		} else {
+			require.NotNil(t, ttl)
+			assert.Greater(t, int64(*ttl), int64(290*time.Second))
+		}
+	}
+
+	dumpPath := filepath.Join(t.TempDir(), "dump.jsonl")
+	require.NoError(t, ioutil.WriteFile(dumpPath, buf.Bytes(), 0o644))
+
+	conf = NewConfig()
+	conf.Type = "memory"
+	conf.Memory.InitFromFile = dumpPath
+
+	c, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		v, err := c.Get(fmt.Sprintf("key%v", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("value%v", i), string(v))
+	}
+	v, err := c.Get("static")
+	require.NoError(t, err)
+	assert.Equal(t, "forever", string(v))
+}
+
+func TestMemoryCacheInitFromFileExpired(t *testing.T) {
+	dumpPath := filepath.Join(t.TempDir(), "dump.jsonl")
+	require.NoError(t, ioutil.WriteFile(dumpPath, []byte(`{"key":"foo","value":"YmFy","value_size":3,"ttl_remaining":"10s"}
+{"key":"baz","value":"YnV6","value_size":3,"ttl_remaining":"-1s"}
+`), 0o644))
+
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.InitFromFile = dumpPath
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	v, err := c.Get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(v))
+
+	_, err = c.Get("baz")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+}
+
 func TestMemoryCacheCompactionOnRead(t *testing.T) {
 	testLog := log.Noop()
 
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/component/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// RetriesConfig contains configuration fields for retrying cache operations
+// that fail due to transient errors.
+type RetriesConfig struct {
+	MaxRetries uint64          `json:"max_retries" yaml:"max_retries"`
+	Backoff    retries.Backoff `json:"backoff" yaml:"backoff"`
+}
+
+// NewRetriesConfig returns a RetriesConfig with default values, where retries
+// are disabled.
+func NewRetriesConfig() RetriesConfig {
+	return RetriesConfig{
+		MaxRetries: 0,
+		Backoff: retries.Backoff{
+			InitialInterval: "100ms",
+			MaxInterval:     "1s",
+			MaxElapsedTime:  "5s",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WrapRetries wraps a cache so that operations failing due to transient errors
+// are retried according to the retries field of a cache config. If retries
+// are disabled then the cache is returned unchanged.
+func WrapRetries(conf Config, c types.Cache, log log.Modular, stats metrics.Type) (types.Cache, error) {
+	if conf.Retries.MaxRetries == 0 {
+		return c, nil
+	}
+	rConf := retries.Config{
+		MaxRetries: conf.Retries.MaxRetries,
+		Backoff:    conf.Retries.Backoff,
+	}
+	boffCtor, err := rConf.GetCtor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse retries: %w", err)
+	}
+	return &retryingCache{
+		cache:     c,
+		backoff:   boffCtor,
+		log:       log,
+		closeChan: make(chan struct{}),
+		mRetry:    stats.GetCounter("retry"),
+		mExceeded: stats.GetCounter("retry.exhausted"),
+	}, nil
+}
+
+// retryingCache wraps a cache and retries operations that fail for reasons
+// other than the existence or absence of a key.
+type retryingCache struct {
+	cache   types.Cache
+	backoff func() backoff.BackOff
+	log     log.Modular
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	mRetry    metrics.StatCounter
+	mExceeded metrics.StatCounter
+}
+
+func isRetryableErr(err error) bool {
+	return !errors.Is(err, types.ErrKeyNotFound) &&
+		!errors.Is(err, types.ErrKeyAlreadyExists) &&
+		!errors.Is(err, types.ErrTypeClosed)
+}
+
+func (r *retryingCache) retry(op string, fn func() error) error {
+	err := fn()
+	if err == nil || !isRetryableErr(err) {
+		return err
+	}
+
+	boff := r.backoff()
+	for {
+		nextSleep := boff.NextBackOff()
+		if nextSleep == backoff.Stop {
+			r.mExceeded.Incr(1)
+			return err
+		}
+
+		r.log.Debugf("Retrying failed %v operation in %v: %v\n", op, nextSleep, err)
+		select {
+		case <-time.After(nextSleep):
+		case <-r.closeChan:
+			return err
+		}
+
+		r.mRetry.Incr(1)
+		if err = fn(); err == nil || !isRetryableErr(err) {
+			return err
+		}
+	}
+}
+
+// Get attempts to locate and return a cached value by its key.
+func (r *retryingCache) Get(key string) (value []byte, err error) {
+	err = r.retry("get", func() error {
+		var gErr error
+		value, gErr = r.cache.Get(key)
+		return gErr
+	})
+	return
+}
+
+// Set attempts to set the value of a key.
+func (r *retryingCache) Set(key string, value []byte) error {
+	return r.retry("set", func() error {
+		return r.cache.Set(key, value)
+	})
+}
+
+// SetWithTTL attempts to set the value of a key with a TTL, the TTL is ignored
+// if the underlying cache does not support them.
+func (r *retryingCache) SetWithTTL(key string, value []byte, ttl *time.Duration) error {
+	cttl, ok := r.cache.(types.CacheWithTTL)
+	if !ok {
+		return r.Set(key, value)
+	}
+	return r.retry("set", func() error {
+		return cttl.SetWithTTL(key, value, ttl)
+	})
+}
+
+// SetMulti attempts to set the value of multiple keys.
+func (r *retryingCache) SetMulti(items map[string][]byte) error {
+	return r.retry("set", func() error {
+		return r.cache.SetMulti(items)
+	})
+}
+
+// SetMultiWithTTL attempts to set the value of multiple keys with TTLs, the
+// TTLs are ignored if the underlying cache does not support them.
+func (r *retryingCache) SetMultiWithTTL(items map[string]types.CacheTTLItem) error {
+	cttl, ok := r.cache.(types.CacheWithTTL)
+	if !ok {
+		sitems := make(map[string][]byte, len(items))
+		for k, v := range items {
+			sitems[k] = v.Value
+		}
+		return r.SetMulti(sitems)
+	}
+	return r.retry("set", func() error {
+		return cttl.SetMultiWithTTL(items)
+	})
+}
+
+// Add attempts to set the value of a key only if the key does not already
+// exist.
+func (r *retryingCache) Add(key string, value []byte) error {
+	return r.retry("add", func() error {
+		return r.cache.Add(key, value)
+	})
+}
+
+// AddWithTTL attempts to set the value of a key only if the key does not
+// already exist, with a TTL that is ignored if the underlying cache does not
+// support them.
+func (r *retryingCache) AddWithTTL(key string, value []byte, ttl *time.Duration) error {
+	cttl, ok := r.cache.(types.CacheWithTTL)
+	if !ok {
+		return r.Add(key, value)
+	}
+	return r.retry("add", func() error {
+		return cttl.AddWithTTL(key, value, ttl)
+	})
+}
+
+// Delete attempts to remove a key.
+func (r *retryingCache) Delete(key string) error {
+	return r.retry("delete", func() error {
+		return r.cache.Delete(key)
+	})
+}
+
+// Export iterates the contents of the underlying cache, returning an error if
+// it does not support exports.
+func (r *retryingCache) Export(ctx context.Context, fn func(item cache.ExportItem) error) error {
+	return cache.Export(ctx, r.cache, fn)
+}
+
+// CloseAsync shuts down the cache and aborts any pending retries.
+func (r *retryingCache) CloseAsync() {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	r.cache.CloseAsync()
+}
+
+// WaitForClose blocks until the cache has closed down.
+func (r *retryingCache) WaitForClose(timeout time.Duration) error {
+	return r.cache.WaitForClose(timeout)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flakyCache struct {
+	types.Cache
+	failures int
+	calls    int
+	err      error
+}
+
+func (f *flakyCache) call() error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *flakyCache) Get(key string) ([]byte, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+	return f.Cache.Get(key)
+}
+
+func (f *flakyCache) Set(key string, value []byte) error {
+	if err := f.call(); err != nil {
+		return err
+	}
+	return f.Cache.Set(key, value)
+}
+
+func (f *flakyCache) Add(key string, value []byte) error {
+	if err := f.call(); err != nil {
+		return err
+	}
+	return f.Cache.Add(key, value)
+}
+
+func newFlakyCache(t *testing.T, failures int, err error) *flakyCache {
+	t.Helper()
+	c, err2 := NewMemory(NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err2)
+	return &flakyCache{Cache: c, failures: failures, err: err}
+}
+
+func retriesTestConf(maxRetries uint64) Config {
+	conf := NewConfig()
+	conf.Retries.MaxRetries = maxRetries
+	conf.Retries.Backoff.InitialInterval = "1ms"
+	conf.Retries.Backoff.MaxInterval = "1ms"
+	return conf
+}
+
+func TestRetriesDisabled(t *testing.T) {
+	flaky := newFlakyCache(t, 1, errors.New("connection reset"))
+
+	c, err := WrapRetries(NewConfig(), flaky, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, flaky, c)
+}
+
+func TestRetriesTransientErrors(t *testing.T) {
+	flaky := newFlakyCache(t, 2, errors.New("connection reset"))
+
+	stats := metrics.NewLocal()
+	c, err := WrapRetries(retriesTestConf(3), flaky, log.Noop(), stats)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set("foo", []byte("bar")))
+	assert.Equal(t, 3, flaky.calls)
+
+	flaky.calls = 0
+	v, err := c.Get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(v))
+	assert.Equal(t, 3, flaky.calls)
+
+	assert.Equal(t, int64(4), stats.GetCounters()["retry"])
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	flaky := newFlakyCache(t, 10, errors.New("connection reset"))
+
+	stats := metrics.NewLocal()
+	c, err := WrapRetries(retriesTestConf(2), flaky, log.Noop(), stats)
+	require.NoError(t, err)
+
+	assert.EqualError(t, c.Set("foo", []byte("bar")), "connection reset")
+	assert.Equal(t, 3, flaky.calls)
+	assert.Equal(t, int64(2), stats.GetCounters()["retry"])
+	assert.Equal(t, int64(1), stats.GetCounters()["retry.exhausted"])
+}
+
+func TestRetriesKeyErrorsNotRetried(t *testing.T) {
+	flaky := newFlakyCache(t, 0, nil)
+
+	c, err := WrapRetries(retriesTestConf(3), flaky, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = c.Get("foo")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+	assert.Equal(t, 1, flaky.calls)
+
+	require.NoError(t, c.Add("foo", []byte("bar")))
+	flaky.calls = 0
+	assert.Equal(t, types.ErrKeyAlreadyExists, c.Add("foo", []byte("baz")))
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestRetriesClose(t *testing.T) {
+	flaky := newFlakyCache(t, 10, errors.New("connection reset"))
+
+	conf := NewConfig()
+	conf.Retries.MaxRetries = 10
+	conf.Retries.Backoff.InitialInterval = "1h"
+	conf.Retries.Backoff.MaxInterval = "1h"
+	conf.Retries.Backoff.MaxElapsedTime = "0s"
+
+	c, err := WrapRetries(conf, flaky, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- c.Set("foo", []byte("bar"))
+	}()
+
+	<-time.After(time.Millisecond * 50)
+	c.CloseAsync()
+
+	select {
+	case err := <-errChan:
+		assert.EqualError(t, err, "connection reset")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.NoError(t, c.WaitForClose(time.Second))
+}
+
+func TestRetriesSanitised(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeMemory
+
+	sanit, err := conf.Sanitised(false)
+	require.NoError(t, err)
+	assert.NotContains(t, sanit, "retries")
+
+	conf.Retries.MaxRetries = 3
+	sanit, err = conf.Sanitised(false)
+	require.NoError(t, err)
+	assert.Contains(t, sanit, "retries")
+}
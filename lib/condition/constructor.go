@@ -152,12 +152,12 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 	aliased := confAlias(NewConfig())
 
 	if err := value.Decode(&aliased); err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var raw interface{}
 	if err := value.Decode(&raw); err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 	if typeCandidates := config.GetInferenceCandidates(raw); len(typeCandidates) > 0 {
 		var inferredType string
@@ -178,12 +178,12 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 	if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 		confBytes, err := yaml.Marshal(aliased.Plugin)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 
 		conf := spec.confConstructor()
 		if err = yaml.Unmarshal(confBytes, conf); err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		aliased.Plugin = conf
 	} else {
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/budget"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/redact"
 	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/condition"
@@ -23,11 +25,13 @@ type Type struct {
 	HTTP                   api.Config `json:"http" yaml:"http"`
 	stream.Config          `json:",inline" yaml:",inline"`
 	manager.ResourceConfig `json:",inline" yaml:",inline"`
-	Logger                 log.Config     `json:"logger" yaml:"logger"`
-	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
-	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
-	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Tests                  []interface{}  `json:"tests,omitempty" yaml:"tests,omitempty"`
+	Logger                 log.Config          `json:"logger" yaml:"logger"`
+	Metrics                metrics.Config      `json:"metrics" yaml:"metrics"`
+	Tracer                 tracer.Config       `json:"tracer" yaml:"tracer"`
+	SystemCloseTimeout     string              `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	Redaction              []redact.RuleConfig `json:"redaction,omitempty" yaml:"redaction,omitempty"`
+	ResourceLimits         budget.Config       `json:"resource_limits" yaml:"resource_limits"`
+	Tests                  []interface{}       `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // New returns a new configuration with default values.
@@ -40,6 +44,8 @@ func New() Type {
 		Metrics:            metrics.NewConfig(),
 		Tracer:             tracer.NewConfig(),
 		SystemCloseTimeout: "20s",
+		Redaction:          nil,
+		ResourceLimits:     budget.NewConfig(),
 		Tests:              nil,
 	}
 }
@@ -58,6 +64,8 @@ type SanitisedConfig struct {
 	Metrics            interface{} `json:"metrics" yaml:"metrics"`
 	Tracer             interface{} `json:"tracer" yaml:"tracer"`
 	SystemCloseTimeout interface{} `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	Redaction          interface{} `json:"redaction,omitempty" yaml:"redaction,omitempty"`
+	ResourceLimits     interface{} `json:"resource_limits" yaml:"resource_limits"`
 	Tests              interface{} `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
@@ -158,6 +166,8 @@ func (c Type) sanitised(skipDeprecated bool) (*SanitisedConfig, error) {
 		Metrics:            metConf,
 		Tracer:             tracConf,
 		SystemCloseTimeout: c.SystemCloseTimeout,
+		Redaction:          c.Redaction,
+		ResourceLimits:     c.ResourceLimits,
 		Tests:              c.Tests,
 	}, nil
 }
@@ -218,6 +228,19 @@ func AddExamples(conf *Type, examples ...string) {
 
 //------------------------------------------------------------------------------
 
+// Unmarshal parses a YAML configuration into a structure, where decoding errors
+// are reported with the line, column and path of the offending field.
+func Unmarshal(configBytes []byte, config *Type) error {
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(configBytes, &rawNode); err != nil {
+		return err
+	}
+	if err := rawNode.Decode(config); err != nil {
+		return docs.PositionErrorsFromYAML(&rawNode, err)
+	}
+	return nil
+}
+
 // Read will attempt to read a configuration file path into a structure. Returns
 // an array of lint messages or an error.
 func Read(path string, replaceEnvs bool, config *Type) ([]string, error) {
@@ -226,7 +249,7 @@ func Read(path string, replaceEnvs bool, config *Type) ([]string, error) {
 		return nil, err
 	}
 
-	if err := yaml.Unmarshal(configBytes, config); err != nil {
+	if err := Unmarshal(configBytes, config); err != nil {
 		return nil, err
 	}
 
@@ -135,3 +135,59 @@ func TestExampleGen(t *testing.T) {
 		t.Errorf("Unexpected conf value: %v != %v", act, exp)
 	}
 }
+
+func TestUnmarshalErrorPositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errStr string
+	}{
+		{
+			name: "nested processor field",
+			input: `
+pipeline:
+  processors:
+    - noop: {}
+    - branch:
+        processors:
+          - http:
+              url: http://localhost
+              headers:
+                foo: { bar: baz }
+`,
+			errStr: "line 10 column 22: pipeline.processors.1.branch.processors.0.http.headers.foo: cannot unmarshal !!map into string",
+		},
+		{
+			name: "broker output field",
+			input: `
+output:
+  broker:
+    outputs:
+      - drop: {}
+      - file:
+          path: [ a ]
+`,
+			errStr: "line 7 column 17: output.broker.outputs.1.file.path: cannot unmarshal !!seq into string",
+		},
+		{
+			name: "broker input type inference",
+			input: `
+input:
+  broker:
+    inputs:
+      - stdin: {}
+      - nope: {}
+`,
+			errStr: "line 6 column 9: input.broker.inputs.1: unable to infer input type, candidates were: [nope]",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := config.New()
+			err := config.Unmarshal([]byte(test.input), &conf)
+			assert.EqualError(t, err, test.errStr)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/budget"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/redact"
 	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
@@ -20,6 +22,8 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("metrics", "A mechanism for exporting metrics.").HasType(docs.FieldTypeMetrics),
 		docs.FieldCommon("tracer", "A mechanism for exporting traces.").HasType(docs.FieldTypeTracer),
 		docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
+		docs.FieldAdvanced("redaction", "A list of rules identifying sensitive parts of messages, which are masked whenever message contents are serialised for observability purposes, such as by the `log` processor or trace captures. Messages themselves are never modified. For more information [check out the redaction docs](/docs/configuration/redaction).").Array().WithChildren(redact.Spec()...).HasDefault([]interface{}{}).AtVersion("3.50.0"),
+		docs.FieldAdvanced("resource_limits", "Service wide limits on the resources held by components.").WithChildren(budget.Spec()...).AtVersion("3.50.0"),
 		docs.FieldCommon("tests", "Optional unit tests for the config, to be run with the `benthos test` subcommand.").Array().HasType(docs.FieldTypeUnknown).HasDefault([]interface{}{}),
 	}...)
 
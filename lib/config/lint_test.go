@@ -152,6 +152,52 @@ tests:
 `,
 			lints: nil,
 		},
+		{
+			name: "shared processor resource",
+			conf: `pipeline:
+  threads: 4
+  processors:
+    - resource: foo
+processor_resources:
+  - label: foo
+    shared: true
+    throttle:
+      period: 1s
+`,
+			lints: nil,
+		},
+		{
+			name: "explicitly enabled components",
+			conf: `pipeline:
+  processors:
+    - enabled: true
+      bloblang: root = this
+cache_resources:
+  - label: foo
+    enabled: true
+    memory: {}
+`,
+			lints: nil,
+		},
+		{
+			name: "disabled components are linted",
+			conf: `pipeline:
+  processors:
+    - enabled: false
+      bloblang: root = this
+      nope: nah
+output:
+  broker:
+    outputs:
+      - enabled: false
+        file:
+          nope: nah
+`,
+			lints: []string{
+				"line 5: field nope is invalid when the component type is bloblang (processor)",
+				"line 11: field nope not recognised",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -83,6 +83,7 @@ then the declaration passively verifies that they match the target fields.`,
 			docs.FieldCommon("prefetch_count", "The maximum number of pending messages to have consumed at a time."),
 			docs.FieldAdvanced("prefetch_size", "The maximum amount of pending messages measured in bytes to have consumed at a time."),
 			tls.FieldSpec(),
+			reader.NackBackoffFieldSpec(),
 			func() docs.FieldSpec {
 				b := batch.FieldSpec()
 				b.IsDeprecated = true
@@ -105,7 +106,11 @@ func NewAMQP09(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 		return nil, err
 	}
 	a = reader.NewAsyncBundleUnacks(a)
-	return NewAsyncReader(TypeAMQP09, true, a, log, stats)
+	nackOpt, err := AsyncReaderNackBackoff(conf.AMQP09.NackBackoff)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeAMQP09, true, a, log, stats, nackOpt)
 }
 
 //------------------------------------------------------------------------------
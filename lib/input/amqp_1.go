@@ -44,6 +44,7 @@ You can access these metadata fields using
 			docs.FieldAdvanced("azure_renew_lock", "Experimental: Azure service bus specific option to renew lock if processing takes more then configured lock time").AtVersion("3.45.0"),
 			tls.FieldSpec(),
 			sasl.FieldSpec(),
+			reader.NackBackoffFieldSpec(),
 		},
 	}
 }
@@ -58,7 +59,11 @@ func NewAMQP1(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 		return nil, err
 	}
 	a = reader.NewAsyncBundleUnacks(a)
-	return NewAsyncReader(TypeAMQP1, true, a, log, stats)
+	nackOpt, err := AsyncReaderNackBackoff(conf.AMQP1.NackBackoff)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeAMQP1, true, a, log, stats, nackOpt)
 }
 
 //------------------------------------------------------------------------------
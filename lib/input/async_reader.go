@@ -6,6 +6,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
+	"github.com/Jeffail/benthos/v3/internal/component/input"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -29,6 +31,11 @@ type AsyncReader struct {
 	typeStr string
 	reader  reader.Async
 
+	nackBackoffMut sync.Mutex
+	nackBackoff    backoff.BackOff
+
+	inFlight *input.InFlightTracker
+
 	stats metrics.Type
 	log   log.Modular
 
@@ -36,6 +43,21 @@ type AsyncReader struct {
 	shutSig      *shutdown.Signaller
 }
 
+// AsyncReaderOpt is an optional functional argument for NewAsyncReader.
+type AsyncReaderOpt func(r *AsyncReader)
+
+// AsyncReaderNackBackoff returns an option that delays the rejection of
+// messages that failed to be delivered according to a nack backoff config.
+func AsyncReaderNackBackoff(conf reader.NackBackoffConfig) (AsyncReaderOpt, error) {
+	boff, err := conf.NewBackOff()
+	if err != nil {
+		return nil, err
+	}
+	return func(r *AsyncReader) {
+		r.nackBackoff = boff
+	}, nil
+}
+
 // NewAsyncReader creates a new AsyncReader input type.
 func NewAsyncReader(
 	typeStr string,
@@ -43,6 +65,7 @@ func NewAsyncReader(
 	r reader.Async,
 	log log.Modular,
 	stats metrics.Type,
+	opts ...AsyncReaderOpt,
 ) (Type, error) {
 	boff := backoff.NewExponentialBackOff()
 	boff.InitialInterval = time.Millisecond * 100
@@ -54,11 +77,15 @@ func NewAsyncReader(
 		allowSkipAcks: allowSkipAcks,
 		typeStr:       typeStr,
 		reader:        r,
+		inFlight:      input.NewInFlightTracker(),
 		log:           log,
 		stats:         stats,
 		transactions:  make(chan types.Transaction),
 		shutSig:       shutdown.NewSignaller(),
 	}
+	for _, opt := range opts {
+		opt(rdr)
+	}
 
 	go rdr.loop()
 	return rdr, nil
@@ -66,6 +93,42 @@ func NewAsyncReader(
 
 //------------------------------------------------------------------------------
 
+// nextNackDelay returns the period to wait before propagating a rejection, the
+// delay grows with consecutive rejections and is reset by a successful ack.
+func (r *AsyncReader) nextNackDelay(res types.Response) time.Duration {
+	if r.nackBackoff == nil {
+		return 0
+	}
+	r.nackBackoffMut.Lock()
+	defer r.nackBackoffMut.Unlock()
+	if res.Error() == nil {
+		r.nackBackoff.Reset()
+		return 0
+	}
+	return r.nackBackoff.NextBackOff()
+}
+
+// reportInFlight periodically updates the in flight gauges until the reader
+// has closed, as the age of the oldest message grows without any activity.
+func (r *AsyncReader) reportInFlight() {
+	var (
+		mInFlight       = r.stats.GetGauge("in_flight.count")
+		mInFlightOldest = r.stats.GetGauge("in_flight.oldest_age_ms")
+	)
+	for {
+		stats := r.inFlight.InFlight()
+		mInFlight.Set(stats.Count)
+		mInFlightOldest.Set(stats.OldestAge().Milliseconds())
+		select {
+		case <-time.After(time.Second):
+		case <-r.shutSig.HasClosedChan():
+			mInFlight.Set(0)
+			mInFlightOldest.Set(0)
+			return
+		}
+	}
+}
+
 func (r *AsyncReader) loop() {
 	// Metrics paths
 	var (
@@ -77,6 +140,7 @@ func (r *AsyncReader) loop() {
 		mFailedConn = r.stats.GetCounter("connection.failed")
 		mLostConn   = r.stats.GetCounter("connection.lost")
 		mLatency    = r.stats.GetTimer("latency")
+		mNackDelay  = r.stats.GetCounter("nack.delayed")
 	)
 
 	defer func() {
@@ -92,6 +156,7 @@ func (r *AsyncReader) loop() {
 		r.shutSig.ShutdownComplete()
 	}()
 	mRunning.Incr(1)
+	go r.reportInFlight()
 
 	pendingAcks := sync.WaitGroup{}
 	defer func() {
@@ -168,11 +233,13 @@ func (r *AsyncReader) loop() {
 			r.log.Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
 
+		inFlightDone := r.inFlight.Add(msg.Len())
 		resChan := make(chan types.Response)
 		tracing.InitSpans("input_"+r.typeStr, msg)
 		select {
 		case r.transactions <- types.NewTransaction(msg, resChan):
 		case <-r.shutSig.CloseAtLeisureChan():
+			inFlightDone()
 			return
 		}
 
@@ -183,6 +250,7 @@ func (r *AsyncReader) loop() {
 			rChan chan types.Response,
 		) {
 			defer pendingAcks.Done()
+			defer inFlightDone()
 
 			var res types.Response
 			var open bool
@@ -204,6 +272,15 @@ func (r *AsyncReader) loop() {
 			mLatency.Timing(time.Since(m.CreatedAt()).Nanoseconds())
 			tracing.FinishSpans(m)
 
+			if delay := r.nextNackDelay(res); delay > 0 {
+				mNackDelay.Incr(1)
+				r.log.Debugf("Delaying rejection of message by %v\n", delay)
+				select {
+				case <-time.After(delay):
+				case <-r.shutSig.CloseNowChan():
+				}
+			}
+
 			ackCtx, ackDone := r.shutSig.CloseNowCtx(context.Background())
 			if err = aFn(ackCtx, res); err != nil {
 				r.log.Errorf("Failed to acknowledge message: %v\n", err)
@@ -225,6 +302,34 @@ func (r *AsyncReader) Connected() bool {
 	return atomic.LoadInt32(&r.connected) == 1
 }
 
+// SelfTest runs the self test of the underlying reader, which verifies that it
+// is able to reach and authenticate with its target without reading messages.
+func (r *AsyncReader) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, r.reader)
+}
+
+// InFlight returns the stats of messages that have been read and are yet to be
+// acknowledged.
+func (r *AsyncReader) InFlight() input.InFlightStats {
+	return r.inFlight.InFlight()
+}
+
+// PauseConsumption suspends consumption at the source when supported by the
+// underlying reader.
+func (r *AsyncReader) PauseConsumption() {
+	if p, ok := r.reader.(Pauser); ok {
+		p.PauseConsumption()
+	}
+}
+
+// ResumeConsumption resumes consumption at the source when supported by the
+// underlying reader.
+func (r *AsyncReader) ResumeConsumption() {
+	if p, ok := r.reader.(Pauser); ok {
+		p.ResumeConsumption()
+	}
+}
+
 // CloseAsync shuts down the AsyncReader input and stops processing requests.
 func (r *AsyncReader) CloseAsync() {
 	r.shutSig.CloseAtLeisure()
@@ -17,6 +17,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -798,3 +800,220 @@ func TestAsyncReaderSkipAcksALO(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestAsyncReaderNackBackoff(t *testing.T) {
+	expErr := errors.New("test error")
+
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []types.Message{
+		message.New([][]byte{[]byte("foo")}),
+		message.New([][]byte{[]byte("bar")}),
+	}
+
+	nackConf := reader.NewNackBackoffConfig()
+	nackConf.Enabled = true
+	nackConf.InitialInterval = "500ms"
+
+	nackOpt, err := AsyncReaderNackBackoff(nackConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAsyncReader(
+		"foo", true, readerImpl,
+		log.Noop(), metrics.Noop(), nackOpt,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case readerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	var tsOne, tsTwo types.Transaction
+	for _, ts := range []*types.Transaction{&tsOne, &tsTwo} {
+		select {
+		case readerImpl.readChan <- nil:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case *ts = <-r.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	ackRcvd := func(i int) error {
+		readerImpl.ackMut.Lock()
+		defer readerImpl.ackMut.Unlock()
+		return readerImpl.ackRcvd[i]
+	}
+
+	nackSent := time.Now()
+	select {
+	case tsOne.ResponseChan <- response.NewError(expErr):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case tsTwo.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// The ack of the second message is not blocked by the delayed rejection.
+	select {
+	case readerImpl.ackChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	if err := ackRcvd(1); err != nil {
+		t.Errorf("Unexpected ack result: %v", err)
+	}
+	if err := ackRcvd(0); err == expErr {
+		t.Error("Expected rejection to be delayed")
+	}
+
+	select {
+	case readerImpl.ackChan <- nil:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out")
+	}
+	if err := ackRcvd(0); err != expErr {
+		t.Errorf("Wrong response received: %v != %v", err, expErr)
+	}
+	if waited := time.Since(nackSent); waited < time.Millisecond*500 {
+		t.Errorf("Rejection was not delayed: %v", waited)
+	}
+
+	r.CloseAsync()
+	close(readerImpl.readChan)
+	close(readerImpl.connChan)
+
+	if err = r.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsyncReaderNackBackoffCloseNow(t *testing.T) {
+	readerImpl := newMockAsyncReader()
+
+	nackConf := reader.NewNackBackoffConfig()
+	nackConf.Enabled = true
+	nackConf.InitialInterval = "1h"
+	nackConf.MaxInterval = "1h"
+
+	nackOpt, err := AsyncReaderNackBackoff(nackConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAsyncReader(
+		"foo", true, readerImpl,
+		log.Noop(), metrics.Noop(), nackOpt,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case readerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case readerImpl.readChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	var ts types.Transaction
+	select {
+	case ts = <-r.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case ts.ResponseChan <- response.NewError(errors.New("test error")):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	go func() {
+		select {
+		case readerImpl.ackChan <- nil:
+		case <-time.After(time.Second * 5):
+		}
+	}()
+
+	// Closing should abort the pending delay rather than wait an hour.
+	r.CloseAsync()
+	close(readerImpl.readChan)
+	close(readerImpl.connChan)
+
+	if err = r.WaitForClose(time.Second * 3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsyncReaderInFlight(t *testing.T) {
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []types.Message{
+		message.New([][]byte{[]byte("foo"), []byte("bar")}),
+		message.New([][]byte{[]byte("baz")}),
+	}
+
+	in, err := NewAsyncReader("foo", true, readerImpl, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	r := in.(*AsyncReader)
+
+	assert.Equal(t, int64(0), r.InFlight().Count)
+	assert.True(t, r.InFlight().Oldest.IsZero())
+
+	go func() {
+		readerImpl.connChan <- nil
+		for i := 0; i < 2; i++ {
+			readerImpl.readChan <- nil
+		}
+	}()
+
+	var resChans []chan<- types.Response
+	for i := 0; i < 2; i++ {
+		select {
+		case ts := <-r.TransactionChan():
+			resChans = append(resChans, ts.ResponseChan)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	stats := r.InFlight()
+	assert.Equal(t, int64(3), stats.Count)
+	assert.False(t, stats.Oldest.IsZero())
+
+	go func() {
+		readerImpl.ackChan <- nil
+	}()
+	resChans[0] <- response.NewAck()
+	assert.Eventually(t, func() bool {
+		return r.InFlight().Count == 1
+	}, time.Second, time.Millisecond*10)
+
+	go func() {
+		readerImpl.ackChan <- nil
+	}()
+	resChans[1] <- response.NewAck()
+	assert.Eventually(t, func() bool {
+		stats := r.InFlight()
+		return stats.Count == 0 && stats.Oldest.IsZero()
+	}, time.Second, time.Millisecond*10)
+
+	r.CloseAsync()
+	close(readerImpl.readChan)
+	close(readerImpl.connChan)
+	require.NoError(t, r.WaitForClose(time.Second))
+}
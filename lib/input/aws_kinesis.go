@@ -15,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/aws/aws-sdk-go/aws"
@@ -49,6 +50,12 @@ Benthos will not store a consumed sequence unless it is acknowledged at the outp
 
 It's possible to configure Benthos to create the DynamoDB table required for coordination if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key ` + "`StreamID`" + ` and a string RANGE key ` + "`ShardID`" + `. 
 
+## KCL Compatibility
+
+Setting the field ` + "`dynamodb.kcl_compatibility`" + ` to ` + "`true`" + ` causes Benthos to read and write leases using the table schema of the [Kinesis Client Library (KCL) v2](https://docs.aws.amazon.com/streams/latest/dev/shared-throughput-kcl-consumers.html), where the table has a string HASH key ` + "`leaseKey`" + ` containing the shard ID. This allows Benthos and KCL workers of the same application to share shards of a stream, which is useful when migrating consumers from one to the other.
+
+In this mode only a single balanced stream can be consumed, as KCL lease tables are scoped to one stream. Shards are only consumed once all of their parent shards have been consumed to the end, and finished shards are marked with a checkpoint of ` + "`SHARD_END`" + ` rather than being removed. KCL workers consider a lease expired when its counter has not changed within their failover time, therefore ` + "`commit_period`" + ` must be shorter than the failover time of any KCL workers sharing the table.
+
 ## Batching
 
 Use the ` + "`batching`" + ` fields to configure an optional [batching policy](/docs/configuration/batching#batch-policy). Each stream shard will be batched separately in order to ensure that acknowledgements aren't contaminated. Any other batching mechanism will stall with this input due its sequential transaction model.`,
@@ -123,7 +130,7 @@ type kinesisReader struct {
 	boffPool    sync.Pool
 
 	svc          kinesisiface.KinesisAPI
-	checkpointer *awsKinesisCheckpointer
+	checkpointer awsKinesisLeaseStore
 
 	streamShards    map[string][]string
 	balancedStreams []string
@@ -204,6 +211,9 @@ func newKinesisReader(
 			}
 		}
 	}
+	if conf.DynamoDB.KCLCompatibility && len(k.balancedStreams) != 1 {
+		return nil, errors.New("kcl_compatibility requires exactly one balanced stream, as a KCL lease table can only be used by a single stream")
+	}
 	if k.commitPeriod, err = time.ParseDuration(k.conf.CommitPeriod); err != nil {
 		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
 	}
@@ -230,7 +240,13 @@ func (k *kinesisReader) getIter(streamID, shardID, sequence string) (string, err
 		iterType = kinesis.ShardIteratorTypeLatest
 	}
 	var startingSequence *string
-	if len(sequence) > 0 {
+	switch sequence {
+	case "":
+	case kclCheckpointTrimHorizon:
+		iterType = kinesis.ShardIteratorTypeTrimHorizon
+	case kclCheckpointLatest:
+		iterType = kinesis.ShardIteratorTypeLatest
+	default:
 		iterType = kinesis.ShardIteratorTypeAfterSequenceNumber
 		startingSequence = &sequence
 	}
@@ -385,10 +401,10 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 			}
 
 			wg.Done()
-			k.log.Debugf("Closing stream '%v' shard '%v' as client '%v'%v\n", streamID, shardID, k.checkpointer.clientID, reason)
+			k.log.Debugf("Closing stream '%v' shard '%v' as client '%v'%v\n", streamID, shardID, k.clientID, reason)
 		}()
 
-		k.log.Debugf("Consuming stream '%v' shard '%v' as client '%v'\n", streamID, shardID, k.checkpointer.clientID)
+		k.log.Debugf("Consuming stream '%v' shard '%v' as client '%v'\n", streamID, shardID, k.clientID)
 
 		for {
 			var err error
@@ -532,6 +548,11 @@ func (k *kinesisReader) runBalancedShards() {
 				StreamName: aws.String(streamID),
 			})
 
+			var consumableShards map[string]struct{}
+			if err == nil {
+				consumableShards, err = k.checkpointer.ConsumableShards(k.ctx, streamID, shardsRes.Shards)
+			}
+
 			var clientClaims map[string][]awsKinesisClientClaim
 			if err == nil {
 				clientClaims, err = k.checkpointer.AllClaims(k.ctx, streamID)
@@ -544,12 +565,9 @@ func (k *kinesisReader) runBalancedShards() {
 				continue
 			}
 
-			totalShards := len(shardsRes.Shards)
-			unclaimedShards := make(map[string]string, totalShards)
-			for _, s := range shardsRes.Shards {
-				if !isShardFinished(s) {
-					unclaimedShards[*s.ShardId] = ""
-				}
+			unclaimedShards := make(map[string]string, len(consumableShards))
+			for shardID := range consumableShards {
+				unclaimedShards[shardID] = ""
 			}
 			for clientID, claims := range clientClaims {
 				for _, claim := range claims {
@@ -694,13 +712,23 @@ func (k *kinesisReader) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := k.conf.GetSession()
+	sess, err := k.conf.GetSessionWithFailover(session.LogFailover(k.log, k.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
+	// Requests made by both the kinesis client and the checkpointer are
+	// instrumented.
+	sess = sess.Copy()
+	instrument.Register(&sess.Handlers, k.stats)
+
 	svc := kinesis.New(sess)
-	checkpointer, err := newAWSKinesisCheckpointer(sess, k.clientID, k.conf.DynamoDB, k.leasePeriod, k.commitPeriod)
+	var checkpointer awsKinesisLeaseStore
+	if k.conf.DynamoDB.KCLCompatibility {
+		checkpointer, err = newAWSKinesisKCLCheckpointer(sess, k.clientID, k.conf.DynamoDB, k.leasePeriod, k.commitPeriod, k.conf.StartFromOldest)
+	} else {
+		checkpointer, err = newAWSKinesisCheckpointer(sess, k.clientID, k.conf.DynamoDB, k.leasePeriod, k.commitPeriod)
+	}
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
 )
 
 //------------------------------------------------------------------------------
@@ -24,6 +25,7 @@ var dynamoDBCheckpointFields = docs.FieldSpecs{
 	docs.FieldAdvanced("billing_mode", "When creating the table determines the billing mode.").HasOptions("PROVISIONED", "PAY_PER_REQUEST"),
 	docs.FieldAdvanced("read_capacity_units", "Set the provisioned read capacity when creating the table with a `billing_mode` of `PROVISIONED`."),
 	docs.FieldAdvanced("write_capacity_units", "Set the provisioned write capacity when creating the table with a `billing_mode` of `PROVISIONED`."),
+	docs.FieldAdvanced("kcl_compatibility", "Whether to read and write leases using the lease table schema of the Kinesis Client Library (KCL) v2, allowing Benthos to share a consumer group with KCL based applications. When enabled only a single balanced stream can be consumed. [Read more](#kcl-compatibility).").AtVersion("3.50.0"),
 }
 
 // DynamoDBCheckpointConfig contains configuration parameters for a DynamoDB
@@ -34,6 +36,7 @@ type DynamoDBCheckpointConfig struct {
 	ReadCapacityUnits  int64  `json:"read_capacity_units" yaml:"read_capacity_units"`
 	WriteCapacityUnits int64  `json:"write_capacity_units" yaml:"write_capacity_units"`
 	BillingMode        string `json:"billing_mode" yaml:"billing_mode"`
+	KCLCompatibility   bool   `json:"kcl_compatibility" yaml:"kcl_compatibility"`
 }
 
 // NewDynamoDBCheckpointConfig returns a DynamoDBCheckpoint config struct with
@@ -45,6 +48,7 @@ func NewDynamoDBCheckpointConfig() DynamoDBCheckpointConfig {
 		ReadCapacityUnits:  0,
 		WriteCapacityUnits: 0,
 		BillingMode:        "PAY_PER_REQUEST",
+		KCLCompatibility:   false,
 	}
 }
 
@@ -55,6 +59,17 @@ var (
 	ErrLeaseNotAcquired = errors.New("the shard could not be leased due to a collision")
 )
 
+// awsKinesisLeaseStore is implemented by the checkpoint stores of the kinesis
+// input, and is used for claiming, balancing and checkpointing shards.
+type awsKinesisLeaseStore interface {
+	ConsumableShards(ctx context.Context, streamID string, shards []*kinesis.Shard) (map[string]struct{}, error)
+	AllClaims(ctx context.Context, streamID string) (map[string][]awsKinesisClientClaim, error)
+	Claim(ctx context.Context, streamID, shardID, fromClientID string) (string, error)
+	Checkpoint(ctx context.Context, streamID, shardID, sequenceNumber string, final bool) (bool, error)
+	Yield(ctx context.Context, streamID, shardID, sequenceNumber string) error
+	Delete(ctx context.Context, streamID, shardID string) error
+}
+
 // awsKinesisCheckpointer manages the shard checkpointing for a given client
 // identifier.
 type awsKinesisCheckpointer struct {
@@ -183,6 +198,18 @@ func (k *awsKinesisCheckpointer) getCheckpoint(ctx context.Context, streamID, sh
 
 //------------------------------------------------------------------------------
 
+// ConsumableShards returns the IDs of shards from a listing that are ready to
+// be consumed, which are those that have not yet been closed.
+func (k *awsKinesisCheckpointer) ConsumableShards(ctx context.Context, streamID string, shards []*kinesis.Shard) (map[string]struct{}, error) {
+	consumable := make(map[string]struct{}, len(shards))
+	for _, s := range shards {
+		if !isShardFinished(s) {
+			consumable[*s.ShardId] = struct{}{}
+		}
+	}
+	return consumable, nil
+}
+
 // awsKinesisClientClaim represents a shard claimed by a client.
 type awsKinesisClientClaim struct {
 	ShardID      string
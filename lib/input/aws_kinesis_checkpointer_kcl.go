@@ -0,0 +1,484 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+// Sentinel checkpoint values used by the Kinesis Client Library in place of a
+// sequence number.
+const (
+	kclCheckpointTrimHorizon = "TRIM_HORIZON"
+	kclCheckpointLatest      = "LATEST"
+	kclCheckpointShardEnd    = "SHARD_END"
+)
+
+//------------------------------------------------------------------------------
+
+// kclLease contains the fields of a KCL v2 lease that we care about.
+type kclLease struct {
+	Key          string
+	Owner        string
+	Counter      int64
+	Checkpoint   string
+	ParentShards []string
+}
+
+// kclObservation records when a change to the counter of a lease was last
+// observed by this client.
+type kclObservation struct {
+	counter  int64
+	observed time.Time
+}
+
+// awsKinesisKCLCheckpointer manages shard leases using the lease table schema of
+// the Kinesis Client Library v2, which allows shards to be shared with KCL
+// workers of the same application.
+//
+// KCL leases do not contain a timeout, instead a lease is considered expired
+// when its counter has not changed for a period of time. Since the lease key is
+// the shard ID alone a table can only be used for a single stream.
+type awsKinesisKCLCheckpointer struct {
+	conf DynamoDBCheckpointConfig
+
+	clientID        string
+	leaseDuration   time.Duration
+	commitPeriod    time.Duration
+	startFromOldest bool
+	svc             dynamodbiface.DynamoDBAPI
+
+	observedMut sync.Mutex
+	observed    map[string]kclObservation
+}
+
+// newAWSKinesisKCLCheckpointer creates a new KCL compatible DynamoDB
+// checkpointer from an AWS session and a configuration struct.
+func newAWSKinesisKCLCheckpointer(
+	session *session.Session,
+	clientID string,
+	conf DynamoDBCheckpointConfig,
+	leaseDuration time.Duration,
+	commitPeriod time.Duration,
+	startFromOldest bool,
+) (*awsKinesisKCLCheckpointer, error) {
+	c := &awsKinesisKCLCheckpointer{
+		conf:            conf,
+		leaseDuration:   leaseDuration,
+		commitPeriod:    commitPeriod,
+		startFromOldest: startFromOldest,
+		svc:             dynamodb.New(session),
+		clientID:        clientID,
+		observed:        map[string]kclObservation{},
+	}
+
+	if err := c.ensureTableExists(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (k *awsKinesisKCLCheckpointer) ensureTableExists() error {
+	_, err := k.svc.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(k.conf.Table),
+	})
+	if err == nil {
+		return nil
+	}
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		return err
+	}
+	if !k.conf.Create {
+		return fmt.Errorf("target table %v does not exist", k.conf.Table)
+	}
+
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("leaseKey"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String(k.conf.BillingMode),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("leaseKey"), KeyType: aws.String("HASH")},
+		},
+		TableName: aws.String(k.conf.Table),
+	}
+	if k.conf.BillingMode == "PROVISIONED" {
+		input.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(k.conf.ReadCapacityUnits),
+			WriteCapacityUnits: aws.Int64(k.conf.WriteCapacityUnits),
+		}
+	}
+	if _, err = k.svc.CreateTable(input); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	return nil
+}
+
+func kclLeaseFromItem(item map[string]*dynamodb.AttributeValue) (kclLease, error) {
+	var l kclLease
+	if s, ok := item["leaseKey"]; ok && s.S != nil {
+		l.Key = *s.S
+	}
+	if l.Key == "" {
+		return l, errors.New("failed to extract lease key from lease")
+	}
+	if s, ok := item["leaseOwner"]; ok && s.S != nil {
+		l.Owner = *s.S
+	}
+	if s, ok := item["leaseCounter"]; ok && s.N != nil {
+		var err error
+		if l.Counter, err = strconv.ParseInt(*s.N, 10, 64); err != nil {
+			return l, fmt.Errorf("failed to parse lease counter: %w", err)
+		}
+	}
+	if s, ok := item["checkpoint"]; ok && s.S != nil {
+		l.Checkpoint = *s.S
+	}
+	if s, ok := item["parentShardId"]; ok {
+		for _, p := range s.SS {
+			if p != nil {
+				l.ParentShards = append(l.ParentShards, *p)
+			}
+		}
+	}
+	return l, nil
+}
+
+// leases returns all leases of the table keyed by their shard ID.
+func (k *awsKinesisKCLCheckpointer) leases(ctx context.Context) (map[string]kclLease, error) {
+	leases := map[string]kclLease{}
+	var scanErr error
+
+	if err := k.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:      aws.String(k.conf.Table),
+		ConsistentRead: aws.Bool(true),
+	}, func(page *dynamodb.ScanOutput, last bool) bool {
+		for _, i := range page.Items {
+			var l kclLease
+			if l, scanErr = kclLeaseFromItem(i); scanErr != nil {
+				return false
+			}
+			leases[l.Key] = l
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return leases, scanErr
+}
+
+// kclLeaseIsBlocked returns true if the lease has a parent shard that has not
+// yet been consumed to the end. Parents without a lease are assumed to be
+// finished, as their leases are removed once the shard has been consumed and
+// has expired.
+func kclLeaseIsBlocked(l kclLease, leases map[string]kclLease) bool {
+	for _, p := range l.ParentShards {
+		if pl, exists := leases[p]; exists && pl.Checkpoint != kclCheckpointShardEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsumableShards creates leases for any shards of the listing that do not yet
+// have one, and then returns the IDs of shards that are ready to be consumed.
+//
+// Following KCL semantics a shard is ready to be consumed when it has not yet
+// been consumed to the end, and all of its parent shards have been. Closed
+// shards that have not been consumed to the end are therefore still consumed,
+// and child shards of a reshard are only consumed once their parents are
+// finished.
+func (k *awsKinesisKCLCheckpointer) ConsumableShards(ctx context.Context, streamID string, shards []*kinesis.Shard) (map[string]struct{}, error) {
+	leases, err := k.leases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range shards {
+		if _, exists := leases[*s.ShardId]; exists {
+			continue
+		}
+		var l kclLease
+		if l, err = k.createLease(ctx, s, shards); err != nil {
+			return nil, err
+		}
+		leases[l.Key] = l
+	}
+
+	consumable := map[string]struct{}{}
+	for _, s := range shards {
+		l := leases[*s.ShardId]
+		if l.Checkpoint == kclCheckpointShardEnd || kclLeaseIsBlocked(l, leases) {
+			continue
+		}
+		consumable[l.Key] = struct{}{}
+	}
+	return consumable, nil
+}
+
+// createLease attempts to create a new lease for a shard, which is a no-op when
+// a lease already exists (as it might've been created by another client in the
+// meantime).
+func (k *awsKinesisKCLCheckpointer) createLease(ctx context.Context, shard *kinesis.Shard, shards []*kinesis.Shard) (kclLease, error) {
+	l := kclLease{
+		Key:        *shard.ShardId,
+		Checkpoint: kclCheckpointLatest,
+	}
+	if k.startFromOldest {
+		l.Checkpoint = kclCheckpointTrimHorizon
+	}
+
+	for _, p := range []*string{shard.ParentShardId, shard.AdjacentParentShardId} {
+		if p == nil || *p == "" {
+			continue
+		}
+		l.ParentShards = append(l.ParentShards, *p)
+		for _, s := range shards {
+			if *s.ShardId == *p {
+				// The children of a shard we know about must be consumed from
+				// the beginning in order to avoid losing data.
+				l.Checkpoint = kclCheckpointTrimHorizon
+			}
+		}
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"leaseKey":                     {S: aws.String(l.Key)},
+		"leaseCounter":                 {N: aws.String("0")},
+		"checkpoint":                   {S: aws.String(l.Checkpoint)},
+		"checkpointSubSequenceNumber":  {N: aws.String("0")},
+		"ownerSwitchesSinceCheckpoint": {N: aws.String("0")},
+	}
+	if len(l.ParentShards) > 0 {
+		item["parentShardId"] = &dynamodb.AttributeValue{SS: aws.StringSlice(l.ParentShards)}
+	}
+
+	if _, err := k.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(k.conf.Table),
+		ConditionExpression: aws.String("attribute_not_exists(leaseKey)"),
+		Item:                item,
+	}); err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return l, nil
+		}
+		return l, fmt.Errorf("failed to create lease for shard '%v': %w", l.Key, err)
+	}
+	return l, nil
+}
+
+// AllClaims returns a map of client IDs to shards claimed by that client. The
+// lease timeout of each claim is calculated from the last time a change to the
+// lease counter was observed by this client.
+//
+// Leases of shards that are either finished or waiting on a parent shard are
+// excluded as they should not be considered for balancing.
+func (k *awsKinesisKCLCheckpointer) AllClaims(ctx context.Context, streamID string) (map[string][]awsKinesisClientClaim, error) {
+	leases, err := k.leases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	k.observedMut.Lock()
+	defer k.observedMut.Unlock()
+
+	now := time.Now()
+	clientClaims := make(map[string][]awsKinesisClientClaim)
+	for key, l := range leases {
+		obs, exists := k.observed[key]
+		if !exists || obs.counter != l.Counter {
+			obs = kclObservation{counter: l.Counter, observed: now}
+			k.observed[key] = obs
+		}
+		if l.Owner == "" || l.Checkpoint == kclCheckpointShardEnd || kclLeaseIsBlocked(l, leases) {
+			continue
+		}
+		clientClaims[l.Owner] = append(clientClaims[l.Owner], awsKinesisClientClaim{
+			ShardID:      key,
+			LeaseTimeout: obs.observed.Add(k.leaseDuration),
+		})
+	}
+	for key := range k.observed {
+		if _, exists := leases[key]; !exists {
+			delete(k.observed, key)
+		}
+	}
+	return clientClaims, nil
+}
+
+// Claim attempts to claim a shard lease. If fromClientID is specified the lease
+// is stolen from that particular client, and the operation fails if a different
+// client has it claimed. The lease counter is incremented in either case so
+// that KCL workers observe the change of ownership.
+//
+// If fromClientID is specified this call will claim the lease but block for a
+// commit period before reacquiring the checkpoint. This allows the client we're
+// claiming from to notice the theft and yield its latest checkpoint.
+func (k *awsKinesisKCLCheckpointer) Claim(ctx context.Context, streamID, shardID, fromClientID string) (string, error) {
+	initialCheckpoint := kclCheckpointLatest
+	if k.startFromOldest {
+		initialCheckpoint = kclCheckpointTrimHorizon
+	}
+
+	expressionAttributeValues := map[string]*dynamodb.AttributeValue{
+		":new_owner":          {S: &k.clientID},
+		":one":                {N: aws.String("1")},
+		":zero":               {N: aws.String("0")},
+		":initial_checkpoint": {S: &initialCheckpoint},
+	}
+
+	conditionalExpression := "attribute_not_exists(leaseOwner)"
+	if len(fromClientID) > 0 {
+		conditionalExpression = "leaseOwner = :old_owner"
+		expressionAttributeValues[":old_owner"] = &dynamodb.AttributeValue{
+			S: &fromClientID,
+		}
+	}
+
+	res, err := k.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		ReturnValues:        aws.String("ALL_NEW"),
+		TableName:           aws.String(k.conf.Table),
+		ConditionExpression: aws.String(conditionalExpression),
+		UpdateExpression: aws.String("SET leaseOwner = :new_owner, " +
+			"leaseCounter = if_not_exists(leaseCounter, :zero) + :one, " +
+			"ownerSwitchesSinceCheckpoint = if_not_exists(ownerSwitchesSinceCheckpoint, :zero) + :one, " +
+			"checkpoint = if_not_exists(checkpoint, :initial_checkpoint), " +
+			"checkpointSubSequenceNumber = if_not_exists(checkpointSubSequenceNumber, :zero)"),
+		ExpressionAttributeValues: expressionAttributeValues,
+		Key: map[string]*dynamodb.AttributeValue{
+			"leaseKey": {S: &shardID},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok {
+			if awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				return "", ErrLeaseNotAcquired
+			}
+		}
+		return "", err
+	}
+
+	l, err := kclLeaseFromItem(res.Attributes)
+	if err != nil {
+		return "", err
+	}
+	k.observe(l)
+
+	if len(fromClientID) > 0 {
+		select {
+		case <-time.After(k.commitPeriod + time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		rawItem, err := k.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(k.conf.Table),
+			ConsistentRead: aws.Bool(true),
+			Key: map[string]*dynamodb.AttributeValue{
+				"leaseKey": {S: &shardID},
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		if l, err = kclLeaseFromItem(rawItem.Item); err != nil {
+			return "", err
+		}
+	}
+	return l.Checkpoint, nil
+}
+
+func (k *awsKinesisKCLCheckpointer) observe(l kclLease) {
+	k.observedMut.Lock()
+	k.observed[l.Key] = kclObservation{counter: l.Counter, observed: time.Now()}
+	k.observedMut.Unlock()
+}
+
+// Checkpoint attempts to set the checkpoint of a shard lease and renews it by
+// incrementing the lease counter. Returns a boolean indicating whether this
+// lease is still owned by the client.
+//
+// If final is true the lease owner is removed, indicating that this client is
+// finished with the shard.
+func (k *awsKinesisKCLCheckpointer) Checkpoint(ctx context.Context, streamID, shardID, sequenceNumber string, final bool) (bool, error) {
+	expressionAttributeValues := map[string]*dynamodb.AttributeValue{
+		":owner": {S: &k.clientID},
+		":one":   {N: aws.String("1")},
+	}
+
+	updateExpression := "SET leaseCounter = leaseCounter + :one"
+	if len(sequenceNumber) > 0 {
+		updateExpression += ", checkpoint = :checkpoint, checkpointSubSequenceNumber = :zero, ownerSwitchesSinceCheckpoint = :zero"
+		expressionAttributeValues[":checkpoint"] = &dynamodb.AttributeValue{S: &sequenceNumber}
+		expressionAttributeValues[":zero"] = &dynamodb.AttributeValue{N: aws.String("0")}
+	}
+	if final {
+		updateExpression += " REMOVE leaseOwner"
+	}
+
+	res, err := k.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		ReturnValues:              aws.String("ALL_NEW"),
+		TableName:                 aws.String(k.conf.Table),
+		ConditionExpression:       aws.String("leaseOwner = :owner"),
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: expressionAttributeValues,
+		Key: map[string]*dynamodb.AttributeValue{
+			"leaseKey": {S: &shardID},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok {
+			if awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				return false, nil
+			}
+		}
+		return false, err
+	}
+	if l, err := kclLeaseFromItem(res.Attributes); err == nil {
+		k.observe(l)
+	}
+	return true, nil
+}
+
+// Yield updates the checkpoint of an existing lease and no other fields. This
+// should be done after a non-final checkpoint indicates that the lease has been
+// stolen and allows the thief to start with the latest checkpoint.
+func (k *awsKinesisKCLCheckpointer) Yield(ctx context.Context, streamID, shardID, sequenceNumber string) error {
+	if sequenceNumber == "" {
+		// Nothing to present to the thief
+		return nil
+	}
+
+	_, err := k.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(k.conf.Table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"leaseKey": {S: &shardID},
+		},
+		ConditionExpression: aws.String("attribute_exists(leaseKey)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":checkpoint": {S: &sequenceNumber},
+		},
+		UpdateExpression: aws.String("SET checkpoint = :checkpoint"),
+	})
+	return err
+}
+
+// Delete marks a lease as finished by setting its checkpoint to SHARD_END,
+// this should be called when a shard is emptied. Unlike the default schema the
+// lease is retained, which is how KCL workers (and other clients) determine
+// that the child shards of the lease are ready to be consumed.
+func (k *awsKinesisKCLCheckpointer) Delete(ctx context.Context, streamID, shardID string) error {
+	_, err := k.Checkpoint(ctx, streamID, shardID, kclCheckpointShardEnd, true)
+	return err
+}
@@ -0,0 +1,180 @@
+package input
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockKCLDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	items        []map[string]*dynamodb.AttributeValue
+	putInputs    []*dynamodb.PutItemInput
+	updateInputs []*dynamodb.UpdateItemInput
+	updateOutput map[string]*dynamodb.AttributeValue
+	updateErr    error
+}
+
+func (m *mockKCLDynamoDB) ScanPagesWithContext(ctx aws.Context, input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool, opts ...request.Option) error {
+	fn(&dynamodb.ScanOutput{Items: m.items}, true)
+	return nil
+}
+
+func (m *mockKCLDynamoDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	m.putInputs = append(m.putInputs, input)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockKCLDynamoDB) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	m.updateInputs = append(m.updateInputs, input)
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	return &dynamodb.UpdateItemOutput{Attributes: m.updateOutput}, nil
+}
+
+func kclTestLease(key, owner, counter, checkpoint string, parents ...string) map[string]*dynamodb.AttributeValue {
+	item := map[string]*dynamodb.AttributeValue{
+		"leaseKey":     {S: aws.String(key)},
+		"leaseCounter": {N: aws.String(counter)},
+		"checkpoint":   {S: aws.String(checkpoint)},
+	}
+	if owner != "" {
+		item["leaseOwner"] = &dynamodb.AttributeValue{S: aws.String(owner)}
+	}
+	if len(parents) > 0 {
+		item["parentShardId"] = &dynamodb.AttributeValue{SS: aws.StringSlice(parents)}
+	}
+	return item
+}
+
+func newMockKCLCheckpointer(svc *mockKCLDynamoDB) *awsKinesisKCLCheckpointer {
+	return &awsKinesisKCLCheckpointer{
+		conf:            NewDynamoDBCheckpointConfig(),
+		clientID:        "benthos",
+		leaseDuration:   time.Minute,
+		commitPeriod:    time.Millisecond,
+		startFromOldest: false,
+		svc:             svc,
+		observed:        map[string]kclObservation{},
+	}
+}
+
+func TestKCLCheckpointerConsumableShards(t *testing.T) {
+	svc := &mockKCLDynamoDB{
+		items: []map[string]*dynamodb.AttributeValue{
+			kclTestLease("shard-0", "", "5", kclCheckpointShardEnd),
+			kclTestLease("shard-2", "kcl-worker", "3", "1234"),
+			kclTestLease("shard-3", "kcl-worker", "3", kclCheckpointTrimHorizon, "shard-2"),
+		},
+	}
+	c := newMockKCLCheckpointer(svc)
+
+	shards := []*kinesis.Shard{
+		{ShardId: aws.String("shard-0")},
+		{ShardId: aws.String("shard-1"), ParentShardId: aws.String("shard-0")},
+		{ShardId: aws.String("shard-2")},
+		{ShardId: aws.String("shard-3"), ParentShardId: aws.String("shard-2")},
+		{ShardId: aws.String("shard-4")},
+	}
+
+	consumable, err := c.ConsumableShards(context.Background(), "foo", shards)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"shard-1": {},
+		"shard-2": {},
+		"shard-4": {},
+	}, consumable)
+
+	require.Len(t, svc.putInputs, 2)
+
+	assert.Equal(t, "shard-1", *svc.putInputs[0].Item["leaseKey"].S)
+	assert.Equal(t, kclCheckpointTrimHorizon, *svc.putInputs[0].Item["checkpoint"].S)
+	assert.Equal(t, []*string{aws.String("shard-0")}, svc.putInputs[0].Item["parentShardId"].SS)
+	assert.Equal(t, "attribute_not_exists(leaseKey)", *svc.putInputs[0].ConditionExpression)
+
+	assert.Equal(t, "shard-4", *svc.putInputs[1].Item["leaseKey"].S)
+	assert.Equal(t, kclCheckpointLatest, *svc.putInputs[1].Item["checkpoint"].S)
+	assert.NotContains(t, svc.putInputs[1].Item, "parentShardId")
+}
+
+func TestKCLCheckpointerAllClaims(t *testing.T) {
+	svc := &mockKCLDynamoDB{
+		items: []map[string]*dynamodb.AttributeValue{
+			kclTestLease("shard-0", "kcl-worker", "5", kclCheckpointShardEnd),
+			kclTestLease("shard-1", "kcl-worker", "3", "1234", "shard-0"),
+			kclTestLease("shard-2", "benthos", "3", "2345"),
+			kclTestLease("shard-3", "", "3", "3456"),
+		},
+	}
+	c := newMockKCLCheckpointer(svc)
+
+	claims, err := c.AllClaims(context.Background(), "foo")
+	require.NoError(t, err)
+	require.Len(t, claims, 2)
+	require.Len(t, claims["kcl-worker"], 1)
+	require.Len(t, claims["benthos"], 1)
+
+	firstTimeout := claims["kcl-worker"][0].LeaseTimeout
+	assert.Equal(t, "shard-1", claims["kcl-worker"][0].ShardID)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), firstTimeout, time.Second)
+
+	// An unchanged counter should not extend the lease.
+	<-time.After(time.Millisecond * 10)
+	claims, err = c.AllClaims(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, firstTimeout, claims["kcl-worker"][0].LeaseTimeout)
+
+	// Whereas an incremented counter should.
+	svc.items[1] = kclTestLease("shard-1", "kcl-worker", "4", "1234", "shard-0")
+	claims, err = c.AllClaims(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.True(t, claims["kcl-worker"][0].LeaseTimeout.After(firstTimeout))
+}
+
+func TestKCLCheckpointerClaimAndCheckpoint(t *testing.T) {
+	svc := &mockKCLDynamoDB{
+		updateOutput: kclTestLease("shard-1", "benthos", "4", "1234"),
+	}
+	c := newMockKCLCheckpointer(svc)
+
+	seq, err := c.Claim(context.Background(), "foo", "shard-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "1234", seq)
+	require.Len(t, svc.updateInputs, 1)
+	assert.Equal(t, "attribute_not_exists(leaseOwner)", *svc.updateInputs[0].ConditionExpression)
+	assert.Equal(t, "benthos", *svc.updateInputs[0].ExpressionAttributeValues[":new_owner"].S)
+
+	owned, err := c.Checkpoint(context.Background(), "foo", "shard-1", "2345", false)
+	require.NoError(t, err)
+	assert.True(t, owned)
+	require.Len(t, svc.updateInputs, 2)
+	assert.Equal(t, "leaseOwner = :owner", *svc.updateInputs[1].ConditionExpression)
+	assert.Equal(t, "2345", *svc.updateInputs[1].ExpressionAttributeValues[":checkpoint"].S)
+	assert.NotContains(t, *svc.updateInputs[1].UpdateExpression, "REMOVE leaseOwner")
+
+	require.NoError(t, c.Delete(context.Background(), "foo", "shard-1"))
+	require.Len(t, svc.updateInputs, 3)
+	assert.Equal(t, kclCheckpointShardEnd, *svc.updateInputs[2].ExpressionAttributeValues[":checkpoint"].S)
+	assert.Contains(t, *svc.updateInputs[2].UpdateExpression, "REMOVE leaseOwner")
+
+	svc.updateErr = awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "nope", nil)
+
+	_, err = c.Claim(context.Background(), "foo", "shard-1", "kcl-worker")
+	assert.Equal(t, ErrLeaseNotAcquired, err)
+	assert.Equal(t, "leaseOwner = :old_owner", *svc.updateInputs[3].ConditionExpression)
+
+	owned, err = c.Checkpoint(context.Background(), "foo", "shard-1", "3456", false)
+	require.NoError(t, err)
+	assert.False(t, owned)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
@@ -30,10 +31,23 @@ import (
 func init() {
 	Constructors[TypeAWSS3] = TypeSpec{
 		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-			var r reader.Async
-			var err error
-			if r, err = newAmazonS3(conf.AWSS3, log, stats); err != nil {
-				return nil, err
+			if conf.AWSS3.SQS.ParallelReads < 1 {
+				return nil, errors.New("sqs.parallel_reads must be at least 1")
+			}
+			if conf.AWSS3.SQS.ParallelReads > 1 && conf.AWSS3.SQS.URL == "" {
+				return nil, errors.New("sqs.parallel_reads requires an sqs.url to be specified")
+			}
+			var readers []reader.Async
+			for i := 0; i < conf.AWSS3.SQS.ParallelReads; i++ {
+				r, err := newAmazonS3(conf.AWSS3, log, stats)
+				if err != nil {
+					return nil, err
+				}
+				readers = append(readers, r)
+			}
+			r := readers[0]
+			if len(readers) > 1 {
+				r = reader.NewAsyncParallel(readers, log, stats)
 			}
 			// If we're not pulling events directly from an SQS queue then
 			// there's no concept of propagating nacks upstream, therefore wrap
@@ -53,10 +67,14 @@ A common pattern for consuming S3 objects is to emit upload notification events
 
 Benthos is able to follow this pattern when you configure an ` + "`sqs.url`" + `, where it consumes events from SQS and only downloads object keys received within those events. In order for this to work Benthos needs to know where within the event the key and bucket names can be found, specified as [dot paths](/docs/configuration/field_paths) with the fields ` + "`sqs.key_path` and `sqs.bucket_path`" + `. The default values for these fields should already be correct when following the guide above.
 
-If your notification events are being routed to SQS via an SNS topic then the events will be enveloped by SNS, in which case you also need to specify the field ` + "`sqs.envelope_path`" + `, which in the case of SNS to SQS will usually be ` + "`Message`" + `.
+If your notification events are being routed to SQS via an SNS topic then the events will be enveloped by SNS, these envelopes are detected and unwrapped automatically. Events that are wrapped in other forms of envelope can be unwrapped by specifying the field ` + "`sqs.envelope_path`" + `, which is a path to a string field containing the enveloped event.
 
 When using SQS please make sure you have sensible values for ` + "`sqs.max_messages`" + ` and also the visibility timeout of the queue itself. When Benthos consumes an S3 object the SQS message that triggered it is not deleted until the S3 object has been sent onwards. This ensures at-least-once crash resiliency, but also means that if the S3 object takes longer to process than the visibility timeout of your queue then the same objects might be processed multiple times.
 
+### Parallel Reads
+
+When consuming from SQS a single loop receives notifications and downloads the objects they reference one at a time, which can cap throughput well below what the pipeline and output are capable of. Setting ` + "`sqs.parallel_reads`" + ` to a value greater than one runs that many loops concurrently, each tracking the acknowledgements of its own notifications. Objects from each loop are interleaved and therefore the order in which they are consumed is not preserved, the metric ` + "`parallel_reads.<n>.received`" + ` counts the messages received by each loop.
+
 ## Downloading Large Files
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
@@ -77,6 +95,7 @@ This input adds the following metadata fields to each message:
 - s3_content_type
 - s3_content_encoding
 - All user defined metadata
+- s3_tag_<key> for each object tag (when ` + "`fetch_object_tags`" + ` is enabled)
 ` + "```" + `
 
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata). Note that user defined metadata is case insensitive within AWS, and it is likely that the keys will be received in a capitalized form, if you wish to make them consistent you can map all metadata keys to lower or uppercase using a Bloblang mapping such as ` + "`meta = meta().map_each_key(key -> key.lowercase())`" + `.`,
@@ -88,19 +107,21 @@ You can access these metadata fields using [function interpolation](/docs/config
 			}, sess.FieldSpecs()...),
 			docs.FieldAdvanced("force_path_style_urls", "Forces the client API to use path style URLs for downloading keys, which is often required when connecting to custom endpoints."),
 			docs.FieldAdvanced("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed."),
+			docs.FieldAdvanced("fetch_object_tags", "Whether to fetch the tags of each downloaded object and add them to messages as metadata, where the key of each tag is prefixed with `s3_tag_`. This requires an additional request for each object.").AtVersion("3.50.0"),
 			codec.ReaderDocs,
 			docs.FieldCommon("sqs", "Consume SQS messages in order to trigger key downloads.").WithChildren(
 				docs.FieldCommon("url", "An optional SQS URL to connect to. When specified this queue will control which objects are downloaded."),
 				docs.FieldAdvanced("endpoint", "A custom endpoint to use when connecting to SQS."),
 				docs.FieldCommon("key_path", "A [dot path](/docs/configuration/field_paths) whereby object keys are found in SQS messages."),
 				docs.FieldCommon("bucket_path", "A [dot path](/docs/configuration/field_paths) whereby the bucket name can be found in SQS messages."),
-				docs.FieldCommon("envelope_path", "A [dot path](/docs/configuration/field_paths) of a field to extract an enveloped JSON payload for further extracting the key and bucket from SQS messages. This is specifically useful when subscribing an SQS queue to an SNS topic that receives bucket events.", "Message"),
+				docs.FieldCommon("envelope_path", "A [dot path](/docs/configuration/field_paths) of a field to extract an enveloped JSON payload for further extracting the key and bucket from SQS messages. SNS envelopes are unwrapped automatically when this field is empty, so it is only required for custom envelopes.", "Message"),
 				docs.FieldAdvanced(
 					"delay_period",
 					"An optional period of time to wait from when a notification was originally sent to when the target key download is attempted.",
 					"10s", "5m",
 				),
 				docs.FieldAdvanced("max_messages", "The maximum number of SQS messages to consume from each request."),
+				docs.FieldAdvanced("parallel_reads", "The number of loops to run concurrently, each receiving SQS messages and downloading the objects they reference. When greater than one the order in which objects are consumed is not preserved.").AtVersion("3.50.0"),
 			),
 		),
 		Categories: []Category{
@@ -114,25 +135,27 @@ You can access these metadata fields using [function interpolation](/docs/config
 
 // AWSS3SQSConfig contains configuration for hooking up the S3 input with an SQS queue.
 type AWSS3SQSConfig struct {
-	URL          string `json:"url" yaml:"url"`
-	Endpoint     string `json:"endpoint" yaml:"endpoint"`
-	EnvelopePath string `json:"envelope_path" yaml:"envelope_path"`
-	KeyPath      string `json:"key_path" yaml:"key_path"`
-	BucketPath   string `json:"bucket_path" yaml:"bucket_path"`
-	DelayPeriod  string `json:"delay_period" yaml:"delay_period"`
-	MaxMessages  int64  `json:"max_messages" yaml:"max_messages"`
+	URL           string `json:"url" yaml:"url"`
+	Endpoint      string `json:"endpoint" yaml:"endpoint"`
+	EnvelopePath  string `json:"envelope_path" yaml:"envelope_path"`
+	KeyPath       string `json:"key_path" yaml:"key_path"`
+	BucketPath    string `json:"bucket_path" yaml:"bucket_path"`
+	DelayPeriod   string `json:"delay_period" yaml:"delay_period"`
+	MaxMessages   int64  `json:"max_messages" yaml:"max_messages"`
+	ParallelReads int    `json:"parallel_reads" yaml:"parallel_reads"`
 }
 
 // NewAWSS3SQSConfig creates a new AWSS3SQSConfig with default values.
 func NewAWSS3SQSConfig() AWSS3SQSConfig {
 	return AWSS3SQSConfig{
-		URL:          "",
-		Endpoint:     "",
-		EnvelopePath: "",
-		KeyPath:      "Records.*.s3.object.key",
-		BucketPath:   "Records.*.s3.bucket.name",
-		DelayPeriod:  "",
-		MaxMessages:  10,
+		URL:           "",
+		Endpoint:      "",
+		EnvelopePath:  "",
+		KeyPath:       "Records.*.s3.object.key",
+		BucketPath:    "Records.*.s3.bucket.name",
+		DelayPeriod:   "",
+		MaxMessages:   10,
+		ParallelReads: 1,
 	}
 }
 
@@ -144,6 +167,7 @@ type AWSS3Config struct {
 	Prefix             string         `json:"prefix" yaml:"prefix"`
 	ForcePathStyleURLs bool           `json:"force_path_style_urls" yaml:"force_path_style_urls"`
 	DeleteObjects      bool           `json:"delete_objects" yaml:"delete_objects"`
+	FetchObjectTags    bool           `json:"fetch_object_tags" yaml:"fetch_object_tags"`
 	SQS                AWSS3SQSConfig `json:"sqs" yaml:"sqs"`
 }
 
@@ -156,6 +180,7 @@ func NewAWSS3Config() AWSS3Config {
 		Codec:              "all-bytes",
 		ForcePathStyleURLs: false,
 		DeleteObjects:      false,
+		FetchObjectTags:    false,
 		SQS:                NewAWSS3SQSConfig(),
 	}
 }
@@ -360,6 +385,19 @@ func digStrsFromSlices(slice []interface{}) []string {
 	return strs
 }
 
+// snsEnvelopeMessage returns the enveloped message of an SNS notification, and
+// a boolean indicating whether the document is an SNS notification.
+func snsEnvelopeMessage(gObj *gabs.Container) (string, bool) {
+	if t, _ := gObj.S("Type").Data().(string); t != "Notification" {
+		return "", false
+	}
+	if _, exists := gObj.S("TopicArn").Data().(string); !exists {
+		return "", false
+	}
+	msg, exists := gObj.S("Message").Data().(string)
+	return msg, exists
+}
+
 func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, error) {
 	gObj, err := gabs.ParseJSON([]byte(*sqsMsg))
 	if err != nil {
@@ -375,6 +413,10 @@ func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, er
 		} else {
 			return nil, fmt.Errorf("expected string at envelope path, found %T", d)
 		}
+	} else if str, ok := snsEnvelopeMessage(gObj); ok {
+		if gObj, err = gabs.ParseJSON([]byte(str)); err != nil {
+			return nil, fmt.Errorf("failed to parse SNS enveloped message: %v", err)
+		}
 	}
 
 	var keys []string
@@ -566,6 +608,7 @@ type awsS3 struct {
 type s3PendingObject struct {
 	target    *s3ObjectTarget
 	obj       *s3.GetObjectOutput
+	tags      []*s3.Tag
 	extracted int
 	scanner   codec.Reader
 }
@@ -613,7 +656,7 @@ func (a *awsS3) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession(func(c *aws.Config) {
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")), func(c *aws.Config) {
 		c.S3ForcePathStyle = aws.Bool(a.conf.ForcePathStyleURLs)
 	})
 	if err != nil {
@@ -622,12 +665,14 @@ func (a *awsS3) ConnectWithContext(ctx context.Context) error {
 
 	a.session = sess
 	a.s3 = s3.New(sess)
+	instrument.Register(&a.s3.Handlers, a.stats)
 	if a.conf.SQS.URL != "" {
 		sqsSess := sess.Copy()
 		if len(a.conf.SQS.Endpoint) > 0 {
 			sqsSess.Config.Endpoint = &a.conf.SQS.Endpoint
 		}
 		a.sqs = sqs.New(sqsSess)
+		instrument.Register(&a.sqs.Handlers, a.stats)
 	}
 
 	if a.keyReader, err = a.getTargetReader(ctx); err != nil {
@@ -667,6 +712,12 @@ func s3MsgFromParts(p *s3PendingObject, parts []types.Part) types.Message {
 				meta.Set(k, *v)
 			}
 		}
+		// Tags are prefixed so that they cannot overwrite the fields above.
+		for _, tag := range p.tags {
+			if tag.Key != nil && tag.Value != nil {
+				meta.Set("s3_tag_"+*tag.Key, *tag.Value)
+			}
+		}
 		return nil
 	})
 	return msg
@@ -706,6 +757,18 @@ func (a *awsS3) getObjectTarget(ctx context.Context) (*s3PendingObject, error) {
 		target: target,
 		obj:    obj,
 	}
+	if a.conf.FetchObjectTags {
+		tagging, err := a.s3.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+			Bucket: aws.String(target.bucket),
+			Key:    aws.String(target.key),
+		})
+		if err != nil {
+			obj.Body.Close()
+			_ = target.ackFn(ctx, err)
+			return nil, fmt.Errorf("failed to fetch object tags: %w", err)
+		}
+		object.tags = tagging.TagSet
+	}
 	if object.scanner, err = a.objectScannerCtor(target.key, obj.Body, target.ackFn); err != nil {
 		_ = target.ackFn(ctx, err)
 		return nil, err
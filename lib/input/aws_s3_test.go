@@ -0,0 +1,103 @@
+package input
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSS3ParseObjectPaths(t *testing.T) {
+	event := `{"Records":[{"s3":{"bucket":{"name":"foo"},"object":{"key":"bar%2Fbaz.txt"}}}]}`
+
+	snsBytes, err := json.Marshal(map[string]interface{}{
+		"Type":     "Notification",
+		"TopicArn": "arn:aws:sns:eu-west-1:000000000000:buckets",
+		"Message":  event,
+	})
+	require.NoError(t, err)
+
+	customBytes, err := json.Marshal(map[string]interface{}{
+		"Wrapped": map[string]interface{}{
+			"Body": event,
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		envelopePath string
+		input        string
+		errContains  string
+	}{
+		{
+			name:  "plain event",
+			input: event,
+		},
+		{
+			name:  "sns enveloped event",
+			input: string(snsBytes),
+		},
+		{
+			name:         "custom enveloped event",
+			envelopePath: "Wrapped.Body",
+			input:        string(customBytes),
+		},
+		{
+			name:         "custom envelope missing",
+			envelopePath: "Wrapped.Body",
+			input:        event,
+			errContains:  "expected string at envelope path",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewAWSS3Config()
+			conf.SQS.EnvelopePath = test.envelopePath
+
+			r := newSQSTargetReader(conf, log.Noop(), nil, nil)
+			objects, err := r.parseObjectPaths(&test.input)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, objects, 1)
+			assert.Equal(t, "foo", objects[0].bucket)
+			assert.Equal(t, "bar/baz.txt", objects[0].key)
+		})
+	}
+}
+
+func TestAWSS3MsgFromPartsTags(t *testing.T) {
+	p := &s3PendingObject{
+		target: &s3ObjectTarget{key: "foo.txt", bucket: "foobucket"},
+		obj: &s3.GetObjectOutput{
+			ContentType: aws.String("text/plain"),
+		},
+		tags: []*s3.Tag{
+			{Key: aws.String("s3_key"), Value: aws.String("not the key")},
+			{Key: aws.String("owner"), Value: aws.String("bar")},
+		},
+	}
+
+	msg := s3MsgFromParts(p, []types.Part{message.NewPart([]byte("hello"))})
+	require.Equal(t, 1, msg.Len())
+
+	meta := msg.Get(0).Metadata()
+	assert.Equal(t, "foo.txt", meta.Get("s3_key"))
+	assert.Equal(t, "foobucket", meta.Get("s3_bucket"))
+	assert.Equal(t, "text/plain", meta.Get("s3_content_type"))
+	assert.Equal(t, "not the key", meta.Get("s3_tag_s3_key"))
+	assert.Equal(t, "bar", meta.Get("s3_tag_owner"))
+	assert.Equal(t, "", meta.Get("owner"))
+}
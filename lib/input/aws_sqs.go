@@ -2,6 +2,9 @@ package input
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -27,9 +31,23 @@ func init() {
 	Constructors[TypeAWSSQS] = TypeSpec{
 		Status: docs.StatusStable,
 		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-			r, err := newAWSSQS(conf.AWSSQS, log, stats)
-			if err != nil {
-				return nil, err
+			if conf.AWSSQS.ParallelReads < 1 {
+				return nil, errors.New("parallel_reads must be at least 1")
+			}
+			if conf.AWSSQS.OrderedAcks && conf.AWSSQS.ParallelReads > 1 {
+				return nil, errors.New("ordered_acks cannot be used with parallel_reads greater than 1")
+			}
+			var readers []reader.Async
+			for i := 0; i < conf.AWSSQS.ParallelReads; i++ {
+				r, err := newAWSSQS(conf.AWSSQS, log, stats)
+				if err != nil {
+					return nil, err
+				}
+				readers = append(readers, r)
+			}
+			r := readers[0]
+			if len(readers) > 1 {
+				r = reader.NewAsyncParallel(readers, log, stats)
 			}
 			return NewAsyncReader(TypeAWSSQS, false, r, log, stats)
 		}),
@@ -51,14 +69,51 @@ This input adds the following metadata fields to each message:
 - sqs_message_id
 - sqs_receipt_handle
 - sqs_approximate_receive_count
+- sqs_sent_timestamp_unix
 - All message attributes
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Parallel Reads
+
+By default a single loop receives messages from the queue, which can cap throughput well below what the pipeline and output are capable of. Setting ` + "`parallel_reads`" + ` to a value greater than one runs that many receive loops concurrently, each tracking the acknowledgements of its own messages. Messages from each loop are interleaved and therefore the order in which they are consumed is not preserved, the metric ` + "`parallel_reads.<n>.received`" + ` counts the messages received by each loop.
+
+### Ordered Acknowledgements
+
+When consuming from a FIFO queue messages can be acknowledged out of order, for
+example when the processing of a later message completes before an earlier one,
+which breaks the ordering of a message group when the earlier message fails and
+is redelivered. Setting ` + "`ordered_acks` to `true`" + ` tracks the messages
+of each message group in the order they were consumed and only deletes a message
+once all earlier messages of its group have also been acknowledged. When a
+message fails it is made visible again along with all later messages of its
+group, regardless of whether they have already been acknowledged. Messages
+without a message group ID are acknowledged as normal.
+
+The number of messages tracked is bounded by ` + "`ordered_acks_max_pending`" + `,
+once reached no further messages are consumed until earlier messages are
+resolved. If a message group makes no progress within
+` + "`ordered_acks_stall_timeout`" + ` an error is logged, the counter metric
+` + "`ordered_acks.stalled`" + ` is incremented, and all tracked messages of the
+group are made visible again so that the group is redelivered from its oldest
+message. Messages still being processed at that point are delivered again.
+
+Acknowledged messages that are waiting for an earlier message of their group
+remain in flight, and therefore SQS makes them visible again once the visibility
+timeout of the queue elapses, after which they are redelivered. The visibility
+timeout of the queue should therefore be greater than the time taken to process
+an entire message group, and ` + "`ordered_acks_stall_timeout`" + ` should be
+less than the visibility timeout so that stalled groups are redelivered by
+Benthos in order.`,
 		FieldSpecs: append(docs.FieldSpecs{
 			docs.FieldCommon("url", "The SQS URL to consume from."),
 			docs.FieldAdvanced("delete_message", "Whether to delete the consumed message once it is acked. Disabling allows you to handle the deletion using a different mechanism."),
+			docs.FieldAdvanced("parallel_reads", "The number of receive loops to run concurrently. When greater than one the order of consumed messages is not preserved.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("ordered_acks", "Whether messages of a FIFO queue should only be deleted once all earlier messages of their message group have been acknowledged. Cannot be used with `parallel_reads` greater than one.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("ordered_acks_max_pending", "The maximum number of messages to track when `ordered_acks` is enabled, once reached no further messages are consumed until earlier messages are resolved.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("ordered_acks_stall_timeout", "The maximum period of time a message group can go without progress when `ordered_acks` is enabled before all of its tracked messages are made visible again.").AtVersion("3.50.0"),
 		}, sess.FieldSpecs()...),
 		Categories: []Category{
 			CategoryServices,
@@ -74,6 +129,11 @@ type AWSSQSConfig struct {
 	sess.Config   `json:",inline" yaml:",inline"`
 	URL           string `json:"url" yaml:"url"`
 	DeleteMessage bool   `json:"delete_message" yaml:"delete_message"`
+	ParallelReads int    `json:"parallel_reads" yaml:"parallel_reads"`
+
+	OrderedAcks             bool   `json:"ordered_acks" yaml:"ordered_acks"`
+	OrderedAcksMaxPending   int    `json:"ordered_acks_max_pending" yaml:"ordered_acks_max_pending"`
+	OrderedAcksStallTimeout string `json:"ordered_acks_stall_timeout" yaml:"ordered_acks_stall_timeout"`
 }
 
 // NewAWSSQSConfig creates a new Config with default values.
@@ -82,6 +142,11 @@ func NewAWSSQSConfig() AWSSQSConfig {
 		Config:        sess.NewConfig(),
 		URL:           "",
 		DeleteMessage: true,
+		ParallelReads: 1,
+
+		OrderedAcks:             false,
+		OrderedAcksMaxPending:   1000,
+		OrderedAcksStallTimeout: "5m",
 	}
 }
 
@@ -98,12 +163,17 @@ type awsSQS struct {
 	nackMessagesChan chan sqsMessageHandle
 	closeSignal      *shutdown.Signaller
 
+	ordered      *sqsOrderedAcks
+	stallTimeout time.Duration
+
 	log   log.Modular
 	stats metrics.Type
+
+	mStalled metrics.StatCounter
 }
 
 func newAWSSQS(conf AWSSQSConfig, log log.Modular, stats metrics.Type) (*awsSQS, error) {
-	return &awsSQS{
+	a := &awsSQS{
 		conf:             conf,
 		log:              log,
 		stats:            stats,
@@ -111,7 +181,16 @@ func newAWSSQS(conf AWSSQSConfig, log log.Modular, stats metrics.Type) (*awsSQS,
 		ackMessagesChan:  make(chan sqsMessageHandle),
 		nackMessagesChan: make(chan sqsMessageHandle),
 		closeSignal:      shutdown.NewSignaller(),
-	}, nil
+		mStalled:         stats.GetCounter("ordered_acks.stalled"),
+	}
+	if conf.OrderedAcks {
+		var err error
+		if a.stallTimeout, err = time.ParseDuration(conf.OrderedAcksStallTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse ordered_acks_stall_timeout: %v", err)
+		}
+		a.ordered = newSQSOrderedAcks(conf.OrderedAcksMaxPending)
+	}
+	return a, nil
 }
 
 // ConnectWithContext attempts to establish a connection to the target SQS
@@ -121,12 +200,13 @@ func (a *awsSQS) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	a.sqs = sqs.New(sess)
+	instrument.Register(&a.sqs.Handlers, a.stats)
 	a.session = sess
 
 	var wg sync.WaitGroup
@@ -193,6 +273,13 @@ ackLoop:
 				flushNacks()
 			}
 		case <-flushTimer.C:
+			if a.ordered != nil {
+				if stalled, reset := a.ordered.Stalled(a.stallTimeout); stalled > 0 {
+					a.mStalled.Incr(int64(stalled))
+					a.log.Errorf("Detected %v message groups without progress for longer than %v, %v messages will be redelivered\n", stalled, a.stallTimeout, len(reset))
+					pendingNacks = append(pendingNacks, reset...)
+				}
+			}
 			flushAcks()
 			flushNacks()
 		case <-a.closeSignal.CloseAtLeisureChan():
@@ -254,6 +341,15 @@ func (a *awsSQS) readLoop(wg *sync.WaitGroup) {
 	}
 
 	for {
+		if len(pendingMsgs) == 0 && a.ordered != nil && a.ordered.Full() {
+			select {
+			case <-a.ordered.FreedChan():
+			case <-time.After(time.Second):
+			case <-a.closeSignal.CloseAtLeisureChan():
+				return
+			}
+			continue
+		}
 		if len(pendingMsgs) == 0 {
 			getMsgs()
 			if len(pendingMsgs) == 0 {
@@ -344,6 +440,11 @@ func addSQSMetadata(p types.Part, sqsMsg *sqs.Message) {
 	if rCountStr := sqsMsg.Attributes["ApproximateReceiveCount"]; rCountStr != nil {
 		meta.Set("sqs_approximate_receive_count", *rCountStr)
 	}
+	if sentStr := sqsMsg.Attributes["SentTimestamp"]; sentStr != nil {
+		if sentMillis, err := strconv.ParseInt(*sentStr, 10, 64); err == nil {
+			meta.Set("sqs_sent_timestamp_unix", strconv.FormatInt(sentMillis/1000, 10))
+		}
+	}
 	for k, v := range sqsMsg.MessageAttributes {
 		if v.StringValue != nil {
 			meta.Set(k, *v.StringValue)
@@ -386,6 +487,26 @@ func (a *awsSQS) ReadWithContext(ctx context.Context) (types.Message, reader.Asy
 	if next.ReceiptHandle != nil {
 		mHandle.receiptHandle = *next.ReceiptHandle
 	}
+
+	var entry *sqsOrderedEntry
+	if a.ordered != nil && mHandle.receiptHandle != "" {
+		if group := next.Attributes[sqs.MessageSystemAttributeNameMessageGroupId]; group != nil {
+			entry = a.ordered.Add(*group, mHandle)
+		}
+	}
+	if entry != nil {
+		return msg, func(rctx context.Context, res types.Response) error {
+			if res.Error() == nil {
+				ready := a.ordered.Ack(entry)
+				if !a.conf.DeleteMessage {
+					return nil
+				}
+				return a.sendHandles(rctx, a.ackMessagesChan, a.deleteMessages, ready)
+			}
+			return a.sendHandles(rctx, a.nackMessagesChan, a.resetMessages, a.ordered.Nack(entry))
+		}, nil
+	}
+
 	return msg, func(rctx context.Context, res types.Response) error {
 		if mHandle.receiptHandle == "" {
 			return nil
@@ -416,6 +537,26 @@ func (a *awsSQS) ReadWithContext(ctx context.Context) (types.Message, reader.Asy
 	}, nil
 }
 
+// sendHandles passes message handles to the ack loop, or applies them directly
+// when the ack loop is shutting down.
+func (a *awsSQS) sendHandles(
+	ctx context.Context,
+	handlesChan chan<- sqsMessageHandle,
+	fallback func(context.Context, ...sqsMessageHandle) error,
+	handles []sqsMessageHandle,
+) error {
+	for i, h := range handles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.closeSignal.CloseAtLeisureChan():
+			return fallback(ctx, handles[i:]...)
+		case handlesChan <- h:
+		}
+	}
+	return nil
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *awsSQS) CloseAsync() {
 	a.closeSignal.CloseAtLeisure()
@@ -0,0 +1,170 @@
+package input
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// sqsOrderedEntry is a message consumed from a FIFO queue that is tracked
+// within its message group until it can be deleted in order.
+type sqsOrderedEntry struct {
+	group   string
+	handle  sqsMessageHandle
+	acked   bool
+	removed bool
+}
+
+type sqsOrderedGroup struct {
+	entries      []*sqsOrderedEntry
+	lastProgress time.Time
+}
+
+// sqsOrderedAcks tracks the messages of each message group in the order in
+// which they were consumed, and only releases a message for deletion once all
+// earlier messages of its group have also been acknowledged.
+type sqsOrderedAcks struct {
+	mut        sync.Mutex
+	groups     map[string]*sqsOrderedGroup
+	pending    int
+	maxPending int
+	freedChan  chan struct{}
+}
+
+func newSQSOrderedAcks(maxPending int) *sqsOrderedAcks {
+	return &sqsOrderedAcks{
+		groups:     map[string]*sqsOrderedGroup{},
+		maxPending: maxPending,
+		freedChan:  make(chan struct{}, 1),
+	}
+}
+
+// Full returns true when the number of tracked messages has reached the
+// configured maximum, in which case no further messages should be consumed
+// until FreedChan is signalled.
+func (s *sqsOrderedAcks) Full() bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.maxPending > 0 && s.pending >= s.maxPending
+}
+
+// FreedChan returns a channel that is signalled whenever tracked messages are
+// released.
+func (s *sqsOrderedAcks) FreedChan() <-chan struct{} {
+	return s.freedChan
+}
+
+// Add begins tracking a message at the end of its group.
+func (s *sqsOrderedAcks) Add(group string, handle sqsMessageHandle) *sqsOrderedEntry {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	g, exists := s.groups[group]
+	if !exists {
+		g = &sqsOrderedGroup{lastProgress: time.Now()}
+		s.groups[group] = g
+	}
+	e := &sqsOrderedEntry{group: group, handle: handle}
+	g.entries = append(g.entries, e)
+	s.pending++
+	return e
+}
+
+// release must be called with the mutex held after entries have been removed
+// from tracking.
+func (s *sqsOrderedAcks) release(n int) {
+	if n == 0 {
+		return
+	}
+	s.pending -= n
+	select {
+	case s.freedChan <- struct{}{}:
+	default:
+	}
+}
+
+// Ack marks a message as acknowledged and returns the messages of its group
+// that are now ready to be deleted, which are the leading acknowledged
+// messages of the group.
+func (s *sqsOrderedAcks) Ack(e *sqsOrderedEntry) []sqsMessageHandle {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if e.removed {
+		return nil
+	}
+	e.acked = true
+
+	g := s.groups[e.group]
+	var ready []sqsMessageHandle
+	for len(g.entries) > 0 && g.entries[0].acked {
+		g.entries[0].removed = true
+		ready = append(ready, g.entries[0].handle)
+		g.entries = g.entries[1:]
+	}
+	if len(ready) > 0 {
+		g.lastProgress = time.Now()
+	}
+	if len(g.entries) == 0 {
+		delete(s.groups, e.group)
+	}
+	s.release(len(ready))
+	return ready
+}
+
+// Nack removes a message along with all later messages of its group from
+// tracking and returns them, as they must all be redelivered in order to
+// preserve the ordering of the group.
+func (s *sqsOrderedAcks) Nack(e *sqsOrderedEntry) []sqsMessageHandle {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if e.removed {
+		return nil
+	}
+
+	g := s.groups[e.group]
+	var reset []sqsMessageHandle
+	for i, ge := range g.entries {
+		if ge != e {
+			continue
+		}
+		for _, te := range g.entries[i:] {
+			te.removed = true
+			reset = append(reset, te.handle)
+		}
+		g.entries = g.entries[:i]
+		break
+	}
+	g.lastProgress = time.Now()
+	if len(g.entries) == 0 {
+		delete(s.groups, e.group)
+	}
+	s.release(len(reset))
+	return reset
+}
+
+// Stalled removes all groups that have not made progress within the timeout
+// from tracking and returns the number of stalled groups along with their
+// messages, which should be redelivered.
+func (s *sqsOrderedAcks) Stalled(timeout time.Duration) (int, []sqsMessageHandle) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var stalled int
+	var reset []sqsMessageHandle
+	for k, g := range s.groups {
+		if time.Since(g.lastProgress) < timeout {
+			continue
+		}
+		stalled++
+		for _, e := range g.entries {
+			e.removed = true
+			reset = append(reset, e.handle)
+		}
+		delete(s.groups, k)
+	}
+	s.release(len(reset))
+	return stalled, reset
+}
@@ -0,0 +1,97 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sqsTestHandles(ids ...string) []sqsMessageHandle {
+	var handles []sqsMessageHandle
+	for _, id := range ids {
+		handles = append(handles, sqsMessageHandle{id: id, receiptHandle: id + "-receipt"})
+	}
+	return handles
+}
+
+func TestSQSOrderedAcksInOrder(t *testing.T) {
+	s := newSQSOrderedAcks(10)
+
+	a1 := s.Add("a", sqsTestHandles("a1")[0])
+	b1 := s.Add("b", sqsTestHandles("b1")[0])
+	a2 := s.Add("a", sqsTestHandles("a2")[0])
+	a3 := s.Add("a", sqsTestHandles("a3")[0])
+
+	assert.Empty(t, s.Ack(a3))
+	assert.Empty(t, s.Ack(a2))
+	assert.Equal(t, sqsTestHandles("b1"), s.Ack(b1))
+	assert.Equal(t, sqsTestHandles("a1", "a2", "a3"), s.Ack(a1))
+
+	assert.Empty(t, s.groups)
+	assert.Equal(t, 0, s.pending)
+}
+
+func TestSQSOrderedAcksNackTail(t *testing.T) {
+	s := newSQSOrderedAcks(10)
+
+	a1 := s.Add("a", sqsTestHandles("a1")[0])
+	a2 := s.Add("a", sqsTestHandles("a2")[0])
+	a3 := s.Add("a", sqsTestHandles("a3")[0])
+	a4 := s.Add("a", sqsTestHandles("a4")[0])
+
+	assert.Empty(t, s.Ack(a3))
+	assert.Equal(t, sqsTestHandles("a2", "a3", "a4"), s.Nack(a2))
+
+	// Messages already nacked as part of the tail are ignored.
+	assert.Empty(t, s.Ack(a4))
+	assert.Empty(t, s.Nack(a3))
+
+	assert.Equal(t, sqsTestHandles("a1"), s.Ack(a1))
+	assert.Empty(t, s.groups)
+	assert.Equal(t, 0, s.pending)
+}
+
+func TestSQSOrderedAcksBounded(t *testing.T) {
+	s := newSQSOrderedAcks(2)
+
+	a1 := s.Add("a", sqsTestHandles("a1")[0])
+	assert.False(t, s.Full())
+
+	s.Add("b", sqsTestHandles("b1")[0])
+	assert.True(t, s.Full())
+
+	assert.Equal(t, sqsTestHandles("a1"), s.Ack(a1))
+	assert.False(t, s.Full())
+
+	select {
+	case <-s.FreedChan():
+	default:
+		t.Error("Expected freed signal")
+	}
+}
+
+func TestSQSOrderedAcksStalled(t *testing.T) {
+	s := newSQSOrderedAcks(10)
+
+	a1 := s.Add("a", sqsTestHandles("a1")[0])
+	a2 := s.Add("a", sqsTestHandles("a2")[0])
+	b1 := s.Add("b", sqsTestHandles("b1")[0])
+
+	assert.Empty(t, s.Ack(a2))
+
+	stalled, reset := s.Stalled(time.Hour)
+	assert.Equal(t, 0, stalled)
+	assert.Empty(t, reset)
+
+	s.groups["a"].lastProgress = time.Now().Add(-time.Hour * 2)
+
+	stalled, reset = s.Stalled(time.Hour)
+	assert.Equal(t, 1, stalled)
+	assert.Equal(t, sqsTestHandles("a1", "a2"), reset)
+
+	// Late acknowledgements of a stalled group are ignored.
+	assert.Empty(t, s.Ack(a1))
+	assert.Equal(t, sqsTestHandles("b1"), s.Ack(b1))
+	assert.Equal(t, 0, s.pending)
+}
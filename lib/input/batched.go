@@ -0,0 +1,145 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeBatched] = TypeSpec{
+		constructor: fromSimpleConstructor(NewBatched),
+		Status:      docs.StatusExperimental,
+		Version:     "3.50.0",
+		Summary: `
+Consumes data from a child input and applies a batching policy to the stream.`,
+		Description: `
+Batching at the input level is useful when reading from inputs that do not
+support batching natively, where batch-aware processors such as
+` + "[`archive`](/docs/components/processors/archive)" + ` are required early in
+the pipeline. Any processors configured on this input are executed on the
+resulting batches.
+
+Messages are acknowledged at the child input once the batch that they belong to
+has been acknowledged. When the input shuts down any pending partial batch is
+flushed before closing.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Archive HTTP Requests",
+				Summary: `
+Here we batch messages received by an ` + "`http_server`" + ` input into batches of
+up to 100 messages, or whatever has been received within a second, and archive
+each batch as a single JSON array:`,
+				Config: `
+input:
+  batched:
+    input:
+      http_server:
+        path: /post
+    batching:
+      count: 100
+      period: 1s
+  processors:
+    - archive:
+        format: json_array
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("input", "The child input to consume from.").HasType(docs.FieldTypeInput),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BatchedConfig contains configuration values for the Batched input type.
+type BatchedConfig struct {
+	Input    *Config            `json:"input" yaml:"input"`
+	Batching batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewBatchedConfig creates a new BatchedConfig with default values.
+func NewBatchedConfig() BatchedConfig {
+	return BatchedConfig{
+		Input:    nil,
+		Batching: batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyBatchedConfig struct {
+	Input    interface{}        `json:"input" yaml:"input"`
+	Batching batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (b BatchedConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyBatchedConfig{
+		Input:    b.Input,
+		Batching: b.Batching,
+	}
+	if b.Input == nil {
+		dummy.Input = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (b BatchedConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyBatchedConfig{
+		Input:    b.Input,
+		Batching: b.Batching,
+	}
+	if b.Input == nil {
+		dummy.Input = struct{}{}
+	}
+	return dummy, nil
+}
+
+//------------------------------------------------------------------------------
+
+// NewBatched creates a new Batched input type.
+func NewBatched(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.Batched.Input == nil {
+		return nil, errors.New("cannot create batched input without a child")
+	}
+	if conf.Batched.Batching.IsNoop() {
+		return nil, errors.New("cannot create batched input without a batching policy")
+	}
+
+	child, err := New(*conf.Batched.Input, mgr, log, stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input '%v': %v", conf.Batched.Input.Type, err)
+	}
+
+	bMgr, bLog, bStats := interop.LabelChild("batching", mgr, log, stats)
+	policy, err := batch.NewPolicy(conf.Batched.Batching, bMgr, bLog, bStats)
+	if err != nil {
+		child.CloseAsync()
+		return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+	}
+
+	return NewBatcher(policy, child, log, stats), nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,150 @@
+package input_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatchedInprocConf(count int) input.Config {
+	childConf := input.NewConfig()
+	childConf.Type = input.TypeInproc
+	childConf.Inproc = "foo"
+
+	conf := input.NewConfig()
+	conf.Type = input.TypeBatched
+	conf.Batched.Input = &childConf
+	conf.Batched.Batching.Count = count
+	return conf
+}
+
+func readBatch(t *testing.T, in input.Type) types.Transaction {
+	t.Helper()
+	select {
+	case tran, open := <-in.TransactionChan():
+		require.True(t, open)
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return types.Transaction{}
+}
+
+func TestBatchedAcks(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tranChan := make(chan types.Transaction)
+	mgr.SetPipe("foo", tranChan)
+
+	in, err := input.New(newBatchedInprocConf(3), mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	sendMsgs := func(contents ...string) []chan types.Response {
+		var resChans []chan types.Response
+		for _, c := range contents {
+			resChan := make(chan types.Response)
+			select {
+			case tranChan <- types.NewTransaction(message.New([][]byte{[]byte(c)}), resChan):
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+			resChans = append(resChans, resChan)
+		}
+		return resChans
+	}
+
+	readRes := func(resChans []chan types.Response) []error {
+		var errs []error
+		for _, resChan := range resChans {
+			select {
+			case res := <-resChan:
+				errs = append(errs, res.Error())
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+		}
+		return errs
+	}
+
+	resChans := sendMsgs("foo", "bar", "baz")
+	tran := readBatch(t, in)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, message.GetAllBytes(tran.Payload))
+
+	errTest := errors.New("test err")
+	tran.ResponseChan <- response.NewError(errTest)
+	assert.Equal(t, []error{errTest, errTest, errTest}, readRes(resChans))
+
+	resChans = sendMsgs("qux")
+
+	// Give the message time to reach the batcher before shutting down.
+	<-time.After(time.Millisecond * 100)
+	in.CloseAsync()
+
+	// The partial batch is flushed during shut down.
+	tran = readBatch(t, in)
+	assert.Equal(t, [][]byte{[]byte("qux")}, message.GetAllBytes(tran.Payload))
+
+	tran.ResponseChan <- response.NewAck()
+	assert.Equal(t, []error{nil}, readRes(resChans))
+
+	require.NoError(t, in.WaitForClose(time.Second*5))
+}
+
+func TestBatchedProcessors(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	genConf := input.NewConfig()
+	genConf.Type = input.TypeGenerate
+	genConf.Generate.Mapping = `root = "hello world"`
+	genConf.Generate.Interval = ""
+	genConf.Generate.Count = 4
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Bloblang = `root = batch_size()`
+
+	conf := input.NewConfig()
+	conf.Type = input.TypeBatched
+	conf.Batched.Input = &genConf
+	conf.Batched.Batching.Count = 2
+	conf.Processors = append(conf.Processors, procConf)
+
+	in, err := input.New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		tran := readBatch(t, in)
+		assert.Equal(t, [][]byte{[]byte("2"), []byte("2")}, message.GetAllBytes(tran.Payload))
+		tran.ResponseChan <- response.NewAck()
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+}
+
+func TestBatchedBadConfig(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.Type = input.TypeBatched
+	_, err = input.New(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to create input 'batched': cannot create batched input without a child")
+
+	conf = newBatchedInprocConf(0)
+	_, err = input.New(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to create input 'batched': cannot create batched input without a batching policy")
+}
@@ -17,6 +17,8 @@ import (
 
 // Batcher wraps an input with a batch policy.
 type Batcher struct {
+	StatusForwarder
+
 	stats metrics.Type
 	log   log.Modular
 
@@ -36,6 +38,8 @@ func NewBatcher(
 	stats metrics.Type,
 ) Type {
 	b := Batcher{
+		StatusForwarder: NewStatusForwarder(child),
+
 		stats:       stats,
 		log:         log,
 		child:       child,
@@ -0,0 +1,158 @@
+package input
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeBenthosLogs] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, logger log.Modular, stats metrics.Type) (Type, error) {
+			return NewAsyncReader(TypeBenthosLogs, true, newBenthosLogsReader(log.DefaultEventHub(), stats), logger, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Consumes the logs emitted by this instance of Benthos as structured JSON
+documents, allowing them to be processed and delivered by a pipeline.`,
+		Description: `
+Logs are only published to this input when the field ` + "`logger.output`" + ` is
+set to either ` + "`benthos_logs`" + ` or ` + "`both`" + `, and each log is a
+JSON document in the format set by ` + "`logger.format`" + `, where formats
+that aren't structured are published as ` + "`json`" + `.
+
+Logging never blocks on this input. Each ` + "`benthos_logs`" + ` input buffers
+up to ` + "`logger.buffer_size`" + ` logs, beyond which the oldest logs are
+dropped and counted by the metric ` + "`dropped`" + `. Logs emitted before any
+` + "`benthos_logs`" + ` input has started are buffered and delivered to the
+first input.
+
+Since the components of the pipeline consuming logs also emit logs it's
+advisable to avoid logging each message within that pipeline, as this creates
+a feedback loop that fills the buffer.
+
+Logs are not redelivered, and therefore messages that are rejected by the
+output of the pipeline are lost.`,
+		config: docs.FieldComponent().HasType(docs.FieldTypeObject),
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Logs to Loki",
+				Summary: `
+Here we publish logs to a stream that delivers them to Loki, labelled by their
+level:`,
+				Config: `
+logger:
+  level: INFO
+  output: both
+
+input:
+  benthos_logs: {}
+
+output:
+  http_client:
+    url: http://localhost:3100/loki/api/v1/push
+    verb: POST
+    headers:
+      Content-Type: application/json
+    batching:
+      count: 100
+      period: 1s
+      processors:
+        - bloblang_batch: |
+            root = [{
+              "streams": this.map_each(log -> {
+                "stream": { "level": log.level },
+                "values": [[ timestamp_unix_nano().string(), log.string() ]]
+              })
+            }]
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BenthosLogsConfig contains configuration fields for the benthos_logs input
+// type.
+type BenthosLogsConfig struct{}
+
+// NewBenthosLogsConfig creates a new BenthosLogsConfig with default values.
+func NewBenthosLogsConfig() BenthosLogsConfig {
+	return BenthosLogsConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+type benthosLogsReader struct {
+	hub *log.EventHub
+
+	subMut sync.Mutex
+	sub    *log.EventSubscription
+
+	mDropped metrics.StatCounter
+}
+
+func newBenthosLogsReader(hub *log.EventHub, stats metrics.Type) *benthosLogsReader {
+	return &benthosLogsReader{
+		hub:      hub,
+		mDropped: stats.GetCounter("dropped"),
+	}
+}
+
+func (b *benthosLogsReader) ConnectWithContext(ctx context.Context) error {
+	b.subMut.Lock()
+	defer b.subMut.Unlock()
+
+	if b.sub == nil {
+		b.sub = b.hub.Subscribe()
+	}
+	return nil
+}
+
+func (b *benthosLogsReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	b.subMut.Lock()
+	sub := b.sub
+	b.subMut.Unlock()
+
+	if sub == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	event, dropped, err := sub.Read(ctx)
+	if dropped > 0 {
+		b.mDropped.Incr(dropped)
+	}
+	if err != nil {
+		return nil, nil, types.ErrTimeout
+	}
+	return message.New([][]byte{event}), func(context.Context, types.Response) error {
+		return nil
+	}, nil
+}
+
+func (b *benthosLogsReader) CloseAsync() {
+	b.subMut.Lock()
+	if b.sub != nil {
+		b.sub.Close()
+		b.sub = nil
+	}
+	b.subMut.Unlock()
+}
+
+func (b *benthosLogsReader) WaitForClose(time.Duration) error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenthosLogs(t *testing.T) {
+	hub := log.NewEventHub(2)
+	hub.Publish([]byte(`{"message":"before"}`))
+
+	stats := metrics.NewLocal()
+	in, err := NewAsyncReader(TypeBenthosLogs, true, newBenthosLogsReader(hub, stats), log.Noop(), stats)
+	require.NoError(t, err)
+
+	readMsg := func() string {
+		t.Helper()
+		select {
+		case tran := <-in.TransactionChan():
+			select {
+			case tran.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+			return string(tran.Payload.Get(0).Get())
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return ""
+	}
+
+	assert.Equal(t, `{"message":"before"}`, readMsg())
+
+	hub.Publish([]byte(`{"message":"after"}`))
+	assert.Equal(t, `{"message":"after"}`, readMsg())
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+
+	// Publishing once the input has closed must not block.
+	for i := 0; i < 10; i++ {
+		hub.Publish([]byte(`{"message":"closed"}`))
+	}
+}
+
+func TestBenthosLogsConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBenthosLogs
+
+	in, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+}
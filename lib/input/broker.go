@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
@@ -13,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/pipeline"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"gopkg.in/yaml.v3"
 )
@@ -72,6 +74,14 @@ with a broker using the ` + "`batching`" + ` fields. When doing this the feeds
 from all child inputs are combined. Some inputs do not support broker based
 batching and specify this in their documentation.
 
+### Labels
+
+Child inputs can be given a ` + "`label`" + `, which identifies them within logs and metrics. Messages consumed by a labelled child are also given the metadata field ` + "`input_label`" + ` containing its label, which allows downstream components such as a [` + "`switch`" + ` output](/docs/components/outputs/switch) to route messages by their source. This field is added after any processors of the child input, and before any processors of the broker.
+
+### Failed Children
+
+By default the broker is only considered connected when all of its children are connected, which is reflected by the ` + "`/ready`" + ` endpoint. Setting ` + "`ignore_failed_children`" + ` to ` + "`true`" + ` instead considers the broker connected as long as at least one child is connected, so that a single failing source does not mark the whole stream as unready. In either case the ` + "`/ready`" + ` endpoint lists any children that are not connected.
+
 ### Processors
 
 It is possible to configure [processors](/docs/components/processors/about) at
@@ -85,6 +95,7 @@ child nodes processors.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("copies", "Whatever is specified within `inputs` will be created this many times."),
 			docs.FieldCommon("inputs", "A list of inputs to create.").Array().HasType(docs.FieldTypeInput),
+			docs.FieldAdvanced("ignore_failed_children", "Whether the broker should be considered connected as long as at least one child input is connected, rather than requiring all of them to be.").AtVersion("3.50.0"),
 			batch.FieldSpec(),
 		},
 	}
@@ -94,17 +105,19 @@ child nodes processors.`,
 
 // BrokerConfig contains configuration fields for the Broker input type.
 type BrokerConfig struct {
-	Copies   int                `json:"copies" yaml:"copies"`
-	Inputs   brokerInputList    `json:"inputs" yaml:"inputs"`
-	Batching batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Copies               int                `json:"copies" yaml:"copies"`
+	Inputs               brokerInputList    `json:"inputs" yaml:"inputs"`
+	IgnoreFailedChildren bool               `json:"ignore_failed_children" yaml:"ignore_failed_children"`
+	Batching             batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies:   1,
-		Inputs:   brokerInputList{},
-		Batching: batch.NewPolicyConfig(),
+		Copies:               1,
+		Inputs:               brokerInputList{},
+		IgnoreFailedChildren: false,
+		Batching:             batch.NewPolicyConfig(),
 	}
 }
 
@@ -131,9 +144,22 @@ func (b *brokerInputList) UnmarshalJSON(bytes []byte) error {
 
 // UnmarshalYAML ensures that when parsing configs that are in a map or slice
 // the default values are still applied.
-func (b *brokerInputList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+func (b *brokerInputList) UnmarshalYAML(value *yaml.Node) error {
+	// When there are no ditto configs each child is decoded directly from its
+	// node so that errors are reported at their original positions.
+	if value.Kind == yaml.SequenceNode && !broker.HasDittoNode(value.Content) {
+		confs := make([]Config, len(value.Content))
+		for i, child := range value.Content {
+			if err := child.Decode(&confs[i]); err != nil {
+				return err
+			}
+		}
+		*b = confs
+		return nil
+	}
+
 	genericInputs := []interface{}{}
-	if err := unmarshal(&genericInputs); err != nil {
+	if err := value.Decode(&genericInputs); err != nil {
 		return err
 	}
 
@@ -242,27 +268,37 @@ func newBrokerHasBatchProcessor(
 	var err error
 	var b Type
 	if lInputs == 1 {
-		if b, err = newHasBatchProcessor(hasBatchProc, conf.Broker.Inputs[0], mgr, log, stats, pipelines...); err != nil {
+		iConf := conf.Broker.Inputs[0]
+		if b, err = newHasBatchProcessor(hasBatchProc, iConf, mgr, log, stats, brokerLabelPipelines(iConf.Label, log, stats, pipelines)...); err != nil {
 			return nil, err
 		}
 	} else {
 		inputs := make([]types.Producer, lInputs)
+		labels := make([]string, lInputs)
 
 		for j := 0; j < conf.Broker.Copies; j++ {
 			for i, iConf := range conf.Broker.Inputs {
+				n := len(conf.Broker.Inputs)*j + i
 				iMgr, iLog, iStats := interop.LabelChild(fmt.Sprintf("broker.inputs.%v", i), mgr, log, stats)
 				iStats = metrics.Combine(stats, iStats)
-				inputs[len(conf.Broker.Inputs)*j+i], err = newHasBatchProcessor(
+				inputs[n], err = newHasBatchProcessor(
 					hasBatchProc, iConf, iMgr, iLog, iStats,
-					pipelines...,
+					brokerLabelPipelines(iConf.Label, iLog, iStats, pipelines)...,
 				)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create input '%v' type '%v': %v", i, iConf.Type, err)
 				}
+				if labels[n] = iConf.Label; labels[n] == "" {
+					labels[n] = fmt.Sprintf("broker.inputs.%v", i)
+				}
 			}
 		}
 
-		if b, err = broker.NewFanIn(inputs, stats); err != nil {
+		if b, err = broker.NewFanIn(
+			inputs, stats,
+			broker.OptFanInChildLabels(labels),
+			broker.OptFanInIgnoreFailedChildren(conf.Broker.IgnoreFailedChildren),
+		); err != nil {
 			return nil, err
 		}
 	}
@@ -280,4 +316,40 @@ func newBrokerHasBatchProcessor(
 	return NewBatcher(policy, b, log, stats), nil
 }
 
+// brokerLabelPipelines prepends a pipeline to a list of pipeline constructors
+// that adds the metadata field input_label to messages when a label is set.
+func brokerLabelPipelines(
+	label string,
+	log log.Modular,
+	stats metrics.Type,
+	pipelines []types.PipelineConstructorFunc,
+) []types.PipelineConstructorFunc {
+	if label == "" {
+		return pipelines
+	}
+	return append([]types.PipelineConstructorFunc{func(i *int) (types.Pipeline, error) {
+		return pipeline.NewProcessor(log, stats, &brokerLabelProcessor{label: label}), nil
+	}}, pipelines...)
+}
+
+// brokerLabelProcessor sets the metadata field input_label of all messages.
+type brokerLabelProcessor struct {
+	label string
+}
+
+func (p *brokerLabelProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	newMsg := msg.Copy()
+	newMsg.Iter(func(i int, part types.Part) error {
+		part.Metadata().Set("input_label", p.label)
+		return nil
+	})
+	return []types.Message{newMsg}, nil
+}
+
+func (p *brokerLabelProcessor) CloseAsync() {}
+
+func (p *brokerLabelProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
 //------------------------------------------------------------------------------
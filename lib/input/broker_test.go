@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v3"
 
 	_ "github.com/Jeffail/benthos/v3/public/components/all"
@@ -401,3 +405,50 @@ func TestBrokerConfigDittoZeroed(t *testing.T) {
 		t.Errorf("Unexpected value from config: %v != %v", exp, actual)
 	}
 }
+
+func TestBrokerInputLabelMetadata(t *testing.T) {
+	conf := input.NewConfig()
+	conf.Type = input.TypeBroker
+
+	fooConf := input.NewConfig()
+	fooConf.Type = input.TypeGenerate
+	fooConf.Label = "foo"
+	fooConf.Generate.Mapping = `root = "foo"`
+	fooConf.Generate.Interval = ""
+	fooConf.Generate.Count = 1
+
+	barConf := input.NewConfig()
+	barConf.Type = input.TypeGenerate
+	barConf.Generate.Mapping = `root = "bar"`
+	barConf.Generate.Interval = ""
+	barConf.Generate.Count = 1
+
+	conf.Broker.Inputs = append(conf.Broker.Inputs, fooConf, barConf)
+
+	in, err := input.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second))
+	}()
+
+	labels := map[string]string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case tran := <-in.TransactionChan():
+			part := tran.Payload.Get(0)
+			labels[string(part.Get())] = part.Metadata().Get("input_label")
+			select {
+			case tran.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"foo": "foo",
+		"bar": "",
+	}, labels)
+}
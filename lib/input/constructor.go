@@ -210,6 +210,8 @@ const (
 	TypeAWSSQS            = "aws_sqs"
 	TypeAzureBlobStorage  = "azure_blob_storage"
 	TypeAzureQueueStorage = "azure_queue_storage"
+	TypeBatched           = "batched"
+	TypeBenthosLogs       = "benthos_logs"
 	TypeBloblang          = "bloblang"
 	TypeBroker            = "broker"
 	TypeCSVFile           = "csv"
@@ -219,6 +221,7 @@ const (
 	TypeGCPCloudStorage   = "gcp_cloud_storage"
 	TypeGCPPubSub         = "gcp_pubsub"
 	TypeGenerate          = "generate"
+	TypeGRPCServer        = "grpc_server"
 	TypeHDFS              = "hdfs"
 	TypeHTTPClient        = "http_client"
 	TypeHTTPServer        = "http_server"
@@ -268,6 +271,8 @@ type Config struct {
 	AWSSQS            AWSSQSConfig                 `json:"aws_sqs" yaml:"aws_sqs"`
 	AzureBlobStorage  AzureBlobStorageConfig       `json:"azure_blob_storage" yaml:"azure_blob_storage"`
 	AzureQueueStorage AzureQueueStorageConfig      `json:"azure_queue_storage" yaml:"azure_queue_storage"`
+	Batched           BatchedConfig                `json:"batched" yaml:"batched"`
+	BenthosLogs       BenthosLogsConfig            `json:"benthos_logs" yaml:"benthos_logs"`
 	Bloblang          BloblangConfig               `json:"bloblang" yaml:"bloblang"`
 	Broker            BrokerConfig                 `json:"broker" yaml:"broker"`
 	CSVFile           CSVFileConfig                `json:"csv" yaml:"csv"`
@@ -277,6 +282,7 @@ type Config struct {
 	GCPCloudStorage   GCPCloudStorageConfig        `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
 	GCPPubSub         reader.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
 	Generate          BloblangConfig               `json:"generate" yaml:"generate"`
+	GRPCServer        GRPCServerConfig             `json:"grpc_server" yaml:"grpc_server"`
 	HDFS              reader.HDFSConfig            `json:"hdfs" yaml:"hdfs"`
 	HTTPClient        HTTPClientConfig             `json:"http_client" yaml:"http_client"`
 	HTTPServer        HTTPServerConfig             `json:"http_server" yaml:"http_server"`
@@ -327,6 +333,8 @@ func NewConfig() Config {
 		AWSSQS:            NewAWSSQSConfig(),
 		AzureBlobStorage:  NewAzureBlobStorageConfig(),
 		AzureQueueStorage: NewAzureQueueStorageConfig(),
+		Batched:           NewBatchedConfig(),
+		BenthosLogs:       NewBenthosLogsConfig(),
 		Bloblang:          NewBloblangConfig(),
 		Broker:            NewBrokerConfig(),
 		CSVFile:           NewCSVFileConfig(),
@@ -336,6 +344,7 @@ func NewConfig() Config {
 		GCPCloudStorage:   NewGCPCloudStorageConfig(),
 		GCPPubSub:         reader.NewGCPPubSubConfig(),
 		Generate:          NewBloblangConfig(),
+		GRPCServer:        NewGRPCServerConfig(),
 		HDFS:              reader.NewHDFSConfig(),
 		HTTPClient:        NewHTTPClientConfig(),
 		HTTPServer:        NewHTTPServerConfig(),
@@ -413,23 +422,23 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var spec docs.ComponentSpec
 	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeInput, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if spec.Plugin {
 		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 			conf := spec.confConstructor()
 			if err = pluginNode.Decode(conf); err != nil {
-				return fmt.Errorf("line %v: %v", value.Line, err)
+				return docs.ErrorAtNode(value, err)
 			}
 			aliased.Plugin = conf
 		} else {
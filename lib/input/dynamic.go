@@ -161,17 +161,33 @@ func NewDynamic(
 		return err
 	})
 
-	mgr.RegisterEndpoint(
+	interop.RegisterEndpointSpec(mgr, api.NewEndpointSpec(
 		path.Join(conf.Dynamic.Prefix, "/inputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic inputs. For"+
 			" more information read the `dynamic` input type documentation.",
-		dynAPI.HandleCRUD,
-	)
-	mgr.RegisterEndpoint(
+		api.EndpointOperation{
+			Method:              "POST",
+			Summary:             "Create or replace a dynamic input.",
+			RequestContentTypes: []string{"application/json", "application/yaml"},
+		},
+		api.EndpointOperation{
+			Method:               "GET",
+			Summary:              "Read the configuration of a dynamic input.",
+			ResponseContentTypes: []string{"application/json"},
+		},
+		api.EndpointOperation{
+			Method:  "DELETE",
+			Summary: "Delete a dynamic input.",
+		},
+	), dynAPI.HandleCRUD)
+	interop.RegisterEndpointSpec(mgr, api.NewEndpointSpec(
 		path.Join(conf.Dynamic.Prefix, "/inputs"),
 		"Get a map of running input identifiers with their current uptimes.",
-		dynAPI.HandleList,
-	)
+		api.EndpointOperation{
+			Method:               "GET",
+			ResponseContentTypes: []string{"application/json"},
+		},
+	), dynAPI.HandleList)
 
 	return fanIn, nil
 }
@@ -144,3 +144,73 @@ func TestFileMultiPartDeprecated(t *testing.T) {
 		t.Error("Timed out waiting for channel close")
 	}
 }
+
+func TestFileOutOfOrderAcks(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "benthos_file_test")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Remove(tmpfile.Name())
+	})
+
+	messages := []string{
+		"first message",
+		"second message",
+		"third message",
+	}
+
+	for _, msg := range messages {
+		tmpfile.Write([]byte(msg))
+		tmpfile.Write([]byte("\n"))
+	}
+
+	conf := NewConfig()
+	conf.File.Paths = []string{tmpfile.Name()}
+	conf.File.DeleteOnFinish = true
+
+	f, err := NewFile(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	defer func() {
+		f.CloseAsync()
+		assert.NoError(t, f.WaitForClose(time.Second))
+	}()
+
+	// All messages of the file can be in flight at once, and acknowledged in
+	// any order.
+	var pending []types.Transaction
+	for _, exp := range messages {
+		select {
+		case ts, open := <-f.TransactionChan():
+			require.True(t, open)
+			assert.Equal(t, exp, string(ts.Payload.Get(0).Get()))
+			pending = append(pending, ts)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		select {
+		case pending[i].ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+		if i > 0 {
+			// The file is only finished once every message derived from it
+			// has been acknowledged.
+			_, err := os.Stat(tmpfile.Name())
+			require.NoError(t, err)
+		}
+	}
+
+	select {
+	case _, open := <-f.TransactionChan():
+		require.False(t, open)
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for channel close")
+	}
+
+	_, err = os.Stat(tmpfile.Name())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,6 +1,8 @@
 package input
 
 import (
+	"errors"
+
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -26,11 +28,53 @@ This input adds the following metadata fields to each message:
 
 ` + "``` text" + `
 - gcp_pubsub_publish_time_unix
+- gcp_pubsub_delivery_attempt
 - All message attributes
 ` + "```" + `
 
+The field ` + "`gcp_pubsub_delivery_attempt`" + ` is only set when the
+subscription has a dead-letter policy, and can be used to route messages that
+repeatedly fail to be processed before Pub/Sub forwards them to the dead-letter
+topic:
+
+` + "```yaml" + `
+output:
+  switch:
+    cases:
+      - check: meta("gcp_pubsub_delivery_attempt").number().catch(0) >= 4
+        output:
+          resource: poison_messages
+      - output:
+          resource: main_output
+` + "```" + `
+
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Flow Control
+
+The fields ` + "`max_outstanding_messages`" + ` and
+` + "`max_outstanding_bytes`" + ` limit the number of messages that have been
+received but not yet acknowledged. Messages that are waiting to be processed
+have their ack deadlines extended automatically until ` + "`max_extension`" + `
+has passed since they were received, and so these limits should be low enough
+for the pipeline to process all outstanding messages within that period in
+order to avoid redeliveries.
+
+By default messages are streamed from the subscription and the client library
+may hold more messages in memory than ` + "`max_outstanding_messages`" + `.
+Setting ` + "`sync`" + ` to ` + "`true`" + ` uses synchronous pulls instead,
+where no more than ` + "`max_outstanding_messages`" + ` are received at a time,
+which makes back pressure deterministic at the cost of throughput.
+
+### Parallel Reads
+
+By default a single subscriber receives messages from the subscription. Setting
+` + "`parallel_reads`" + ` to a value greater than one runs that many subscribers
+concurrently, each tracking the acknowledgements of its own messages. Messages
+from each subscriber are interleaved and therefore the order in which they are
+consumed is not preserved, the metric ` + "`parallel_reads.<n>.received`" + `
+counts the messages received by each subscriber.`,
 		Categories: []Category{
 			CategoryServices,
 			CategoryGCP,
@@ -40,6 +84,10 @@ You can access these metadata fields using
 			docs.FieldCommon("subscription", "The target subscription ID."),
 			docs.FieldCommon("max_outstanding_messages", "The maximum number of outstanding pending messages to be consumed at a given time."),
 			docs.FieldCommon("max_outstanding_bytes", "The maximum number of outstanding pending messages to be consumed measured in bytes."),
+			docs.FieldAdvanced("max_extension", "The maximum period for which the ack deadline of a received message is automatically extended while it is waiting to be processed.", "10m", "1h").AtVersion("3.50.0"),
+			docs.FieldAdvanced("max_extension_period", "The maximum period by which the ack deadline of a message is extended at a time, which bounds the time before a message is redelivered when the deadline fails to be extended. When empty the period is derived from the observed processing times of messages.", "30s").AtVersion("3.50.0"),
+			docs.FieldAdvanced("sync", "Whether to receive messages with synchronous pulls, where no more than `max_outstanding_messages` are held in memory at a time.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("parallel_reads", "The number of subscribers to run concurrently. When greater than one the order of consumed messages is not preserved.").AtVersion("3.50.0"),
 			func() docs.FieldSpec {
 				b := batch.FieldSpec()
 				b.IsDeprecated = true
@@ -59,11 +107,22 @@ func NewGCPPubSub(conf Config, mgr types.Manager, log log.Modular, stats metrics
 		log.Warnf("Field '%v.max_batch_count' is deprecated, use '%v.batching.count' instead.\n", conf.Type, conf.Type)
 		conf.GCPPubSub.Batching.Count = conf.GCPPubSub.MaxBatchCount
 	}
-	var c reader.Async
-	var err error
-	if c, err = reader.NewGCPPubSub(conf.GCPPubSub, log, stats); err != nil {
-		return nil, err
+	if conf.GCPPubSub.ParallelReads < 1 {
+		return nil, errors.New("parallel_reads must be at least 1")
 	}
+	var readers []reader.Async
+	for i := 0; i < conf.GCPPubSub.ParallelReads; i++ {
+		r, err := reader.NewGCPPubSub(conf.GCPPubSub, log, stats)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, r)
+	}
+	c := readers[0]
+	if len(readers) > 1 {
+		c = reader.NewAsyncParallel(readers, log, stats)
+	}
+	var err error
 	if c, err = reader.NewAsyncBatcher(conf.GCPPubSub.Batching, c, mgr, log, stats); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,40 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/impl/grpc/keepalive"
+)
+
+// GRPCServerTLSConfig contains TLS configuration for the gRPC server input
+// type.
+type GRPCServerTLSConfig struct {
+	CertFile      string `json:"cert_file" yaml:"cert_file"`
+	KeyFile       string `json:"key_file" yaml:"key_file"`
+	ClientCAsFile string `json:"client_cas_file" yaml:"client_cas_file"`
+}
+
+// GRPCServerConfig contains configuration fields for the gRPC server input
+// type.
+type GRPCServerConfig struct {
+	Address        string                 `json:"address" yaml:"address"`
+	Timeout        string                 `json:"timeout" yaml:"timeout"`
+	TLS            GRPCServerTLSConfig    `json:"tls" yaml:"tls"`
+	MaxMessageSize int                    `json:"max_message_size" yaml:"max_message_size"`
+	Keepalive      keepalive.ServerConfig `json:"keepalive" yaml:"keepalive"`
+	Reflection     bool                   `json:"reflection" yaml:"reflection"`
+}
+
+// NewGRPCServerConfig creates a new GRPCServerConfig with default values.
+func NewGRPCServerConfig() GRPCServerConfig {
+	return GRPCServerConfig{
+		Address: "0.0.0.0:4196",
+		Timeout: "5s",
+		TLS: GRPCServerTLSConfig{
+			CertFile:      "",
+			KeyFile:       "",
+			ClientCAsFile: "",
+		},
+		MaxMessageSize: 4194304,
+		Keepalive:      keepalive.NewServerConfig(),
+		Reflection:     false,
+	}
+}
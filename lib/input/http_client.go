@@ -60,7 +60,7 @@ If you enable streaming then Benthos will consume the body of the response as a
 ### Pagination
 
 This input supports interpolation functions in the ` + "`url` and `headers`" + ` fields where data from the previous successfully consumed message (if there was one) can be referenced. This can be used in order to support basic levels of pagination. However, in cases where pagination depends on logic it is recommended that you use an ` + "[`http` processor](/docs/components/processors/http) instead, often combined with a [`generate` input](/docs/components/inputs/generate)" + ` in order to schedule the processor.`,
-		FieldSpecs: httpClientSpecs(),
+		config: client.ComponentSpec(httpClientSpecs()),
 		Categories: []Category{
 			CategoryNetwork,
 		},
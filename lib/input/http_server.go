@@ -9,8 +9,10 @@ import (
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/textproto"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,7 +22,10 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/http/listener"
 	"github.com/Jeffail/benthos/v3/internal/interop"
+	imetadata "github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/metadata"
@@ -33,6 +38,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/opentracing/opentracing-go"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 //------------------------------------------------------------------------------
@@ -47,6 +54,10 @@ which is enabled when key and cert files are specified.`,
 You can leave the 'address' config field blank in order to use the instance wide
 HTTP server.
 
+When a custom ` + "`address`" + ` is specified the endpoints of this input are
+described by an [OpenAPI 3](https://swagger.io/specification/) document served
+at the path ` + "`/docs/openapi.json`" + `.
+
 The field ` + "`rate_limit`" + ` allows you to specify an optional
 ` + "[`rate_limit` resource](/docs/components/rate_limits/about)" + `, which
 will be applied to each HTTP request made and each websocket payload received.
@@ -63,6 +74,15 @@ customise headers with the ` + "`sync_response` field `headers`" + `, which can
 also use [function interpolation](/docs/configuration/interpolation#bloblang-queries)
 in the value based on the response message contents.
 
+Metadata of the response message can also be returned as headers by listing
+prefixes or patterns of the metadata keys within the ` + "`sync_response`" + `
+field ` + "`metadata_headers`" + `.
+
+When a response consists of multiple messages they are returned as a multipart
+message by default, or with ` + "`batch_mode` set to `concatenate`" + ` their
+payloads are combined into a single body. Only messages that originated from
+the same request are returned.
+
 ### Endpoints
 
 The following fields specify endpoints that are registered for sending messages, and support path parameters of the form ` + "`/{foo}`" + `, which are added to ingested messages as metadata:
@@ -95,6 +115,7 @@ This input adds the following metadata fields to each message:
 
 ` + "``` text" + `
 - http_server_user_agent
+- http_server_remote_ip
 - http_server_request_path
 - http_server_verb
 - All headers (only first values are taken)
@@ -106,7 +127,7 @@ This input adds the following metadata fields to each message:
 You can access these metadata fields using
 [function interpolation](/docs/configuration/interpolation#metadata).`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("address", "An alternative address to host from. If left empty the service wide address is used."),
+			docs.FieldCommon("address", "An alternative address to host from. If left empty the service wide address is used. A unix domain socket can be used by specifying a path prefixed with `unix://`.", "0.0.0.0:4196", "unix:///var/run/benthos.sock"),
 			docs.FieldCommon("path", "The endpoint path to listen for POST requests."),
 			docs.FieldCommon("ws_path", "The endpoint path to create websocket connections from."),
 			docs.FieldAdvanced("ws_welcome_message", "An optional message to deliver to fresh websocket connections."),
@@ -116,6 +137,10 @@ You can access these metadata fields using
 			docs.FieldCommon("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by."),
 			docs.FieldAdvanced("cert_file", "Only valid with a custom `address`."),
 			docs.FieldAdvanced("key_file", "Only valid with a custom `address`."),
+			docs.FieldString("socket_permissions", "An optional octal file mode to set on the socket file when a custom `address` refers to a unix domain socket.", "0660").Advanced().AtVersion("3.50.0"),
+			docs.FieldBool("proxy_protocol", "Whether connections to a custom `address` begin with a [PROXY protocol](https://www.haproxy.org/download/2.4/doc/proxy-protocol.txt) v1 or v2 header, in which case the source address of the header is used as the remote address of requests. Connections without a header are rejected.").Advanced().AtVersion("3.50.0"),
+			docs.FieldString("compress_response", "The algorithm used to compress responses, which is only applied when it is listed within the `Accept-Encoding` header of the request. Compressed request bodies are decompressed automatically based on their `Content-Encoding` header.").HasOptions(httputil.CompressionAlgorithms...).Advanced().AtVersion("3.50.0"),
+			docs.FieldBool("h2c", "Whether a custom `address` without TLS should also accept HTTP/2 requests over cleartext (h2c), both with prior knowledge and by upgrading HTTP/1.1 connections.").Advanced().AtVersion("3.50.0"),
 			docs.FieldAdvanced("sync_response", "Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").WithChildren(
 				docs.FieldCommon(
 					"status",
@@ -125,6 +150,11 @@ You can access these metadata fields using
 				docs.FieldString("headers", "Specify headers to return with synchronous responses.").IsInterpolated().Map().HasDefault(map[string]string{
 					"Content-Type": "application/octet-stream",
 				}),
+				docs.FieldAdvanced("metadata_headers", "Specify criteria for which metadata values are added to the response as headers. When a response is returned as a multipart batch the matching metadata of each message is added to the headers of its own part.").WithChildren(imetadata.IncludeFilterDocs()...).AtVersion("3.50.0"),
+				docs.FieldString("batch_mode", "Determines how a response consisting of multiple messages is returned. Only messages that originated from the same request are included within a response, messages of a batch that originated from other requests are ignored and a warning is logged.").HasAnnotatedOptions(
+					"multipart", "Return each message as a part of a multipart response, where the `Content-Type` header is resolved for each message individually.",
+					"concatenate", "Return the payloads of each message concatenated into a single body, where headers are resolved from the first message.",
+				).Advanced().AtVersion("3.50.0"),
 			),
 		},
 		Categories: []Category{
@@ -138,8 +168,10 @@ You can access these metadata fields using
 // HTTPServerResponseConfig provides config fields for customising the response
 // given from successful requests.
 type HTTPServerResponseConfig struct {
-	Status  string            `json:"status" yaml:"status"`
-	Headers map[string]string `json:"headers" yaml:"headers"`
+	Status          string                        `json:"status" yaml:"status"`
+	Headers         map[string]string             `json:"headers" yaml:"headers"`
+	MetadataHeaders imetadata.IncludeFilterConfig `json:"metadata_headers" yaml:"metadata_headers"`
+	BatchMode       string                        `json:"batch_mode" yaml:"batch_mode"`
 }
 
 // NewHTTPServerResponseConfig creates a new HTTPServerConfig with default values.
@@ -149,6 +181,8 @@ func NewHTTPServerResponseConfig() HTTPServerResponseConfig {
 		Headers: map[string]string{
 			"Content-Type": "application/octet-stream",
 		},
+		MetadataHeaders: imetadata.NewIncludeFilterConfig(),
+		BatchMode:       "multipart",
 	}
 }
 
@@ -164,6 +198,10 @@ type HTTPServerConfig struct {
 	RateLimit          string                   `json:"rate_limit" yaml:"rate_limit"`
 	CertFile           string                   `json:"cert_file" yaml:"cert_file"`
 	KeyFile            string                   `json:"key_file" yaml:"key_file"`
+	SocketPermissions  string                   `json:"socket_permissions" yaml:"socket_permissions"`
+	ProxyProtocol      bool                     `json:"proxy_protocol" yaml:"proxy_protocol"`
+	CompressResponse   string                   `json:"compress_response" yaml:"compress_response"`
+	H2C                bool                     `json:"h2c" yaml:"h2c"`
 	Response           HTTPServerResponseConfig `json:"sync_response" yaml:"sync_response"`
 }
 
@@ -178,11 +216,15 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		AllowedVerbs: []string{
 			"POST",
 		},
-		Timeout:   "5s",
-		RateLimit: "",
-		CertFile:  "",
-		KeyFile:   "",
-		Response:  NewHTTPServerResponseConfig(),
+		Timeout:           "5s",
+		RateLimit:         "",
+		CertFile:          "",
+		KeyFile:           "",
+		SocketPermissions: "",
+		ProxyProtocol:     false,
+		CompressResponse:  "gzip",
+		H2C:               false,
+		Response:          NewHTTPServerResponseConfig(),
 	}
 }
 
@@ -201,12 +243,14 @@ type HTTPServer struct {
 	log   log.Modular
 	mgr   types.Manager
 
-	mux     *http.ServeMux
-	server  *http.Server
-	timeout time.Duration
+	mux        *http.ServeMux
+	server     *http.Server
+	socketMode os.FileMode
+	timeout    time.Duration
 
 	responseStatus  *field.Expression
 	responseHeaders map[string]*field.Expression
+	metaHeaders     *imetadata.IncludeFilter
 
 	handlerWG    sync.WaitGroup
 	transactions chan types.Transaction
@@ -241,11 +285,18 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 	if len(conf.HTTPServer.Address) > 0 {
 		mux = http.NewServeMux()
 		server = &http.Server{Addr: conf.HTTPServer.Address, Handler: mux}
+		if conf.HTTPServer.H2C {
+			server.Handler = h2c.NewHandler(mux, &http2.Server{})
+		}
+	}
+
+	socketMode, err := listener.ParseFileMode(conf.HTTPServer.SocketPermissions)
+	if err != nil {
+		return nil, err
 	}
 
 	var timeout time.Duration
 	if len(conf.HTTPServer.Timeout) > 0 {
-		var err error
 		if timeout, err = time.ParseDuration(conf.HTTPServer.Timeout); err != nil {
 			return nil, fmt.Errorf("failed to parse timeout string: %v", err)
 		}
@@ -258,6 +309,9 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 	if len(verbs) == 0 {
 		return nil, errors.New("must provide at least one allowed verb")
 	}
+	if err := httputil.ValidateCompression(conf.HTTPServer.CompressResponse); err != nil {
+		return nil, err
+	}
 
 	h := HTTPServer{
 		running:         1,
@@ -267,6 +321,7 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		mgr:             mgr,
 		mux:             mux,
 		server:          server,
+		socketMode:      socketMode,
 		timeout:         timeout,
 		responseHeaders: map[string]*field.Expression{},
 		transactions:    make(chan types.Transaction),
@@ -291,7 +346,6 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		mAsyncSucc:     stats.GetCounter("send.async_success"),
 	}
 
-	var err error
 	if h.responseStatus, err = bloblang.NewField(h.conf.Response.Status); err != nil {
 		return nil, fmt.Errorf("failed to parse response status expression: %v", err)
 	}
@@ -300,26 +354,37 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 			return nil, fmt.Errorf("failed to parse response header '%v' expression: %v", k, err)
 		}
 	}
+	if h.metaHeaders, err = imetadata.NewIncludeFilter(h.conf.Response.MetadataHeaders); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata headers filter: %w", err)
+	}
+	switch h.conf.Response.BatchMode {
+	case "multipart", "concatenate":
+	default:
+		return nil, fmt.Errorf("sync response batch mode not recognised: %v", h.conf.Response.BatchMode)
+	}
 
-	postHdlr := httputil.GzipHandler(h.postHandler)
+	postSpec, wsSpec := h.endpointSpecs()
+	postHdlr := httputil.CompressHandler(h.conf.CompressResponse, h.postHandler)
 	wsHdlr := httputil.GzipHandler(h.wsHandler)
 	if mux != nil {
+		var specs []api.EndpointSpec
 		if len(h.conf.Path) > 0 {
 			mux.HandleFunc(h.conf.Path, postHdlr)
+			specs = append(specs, postSpec)
 		}
 		if len(h.conf.WSPath) > 0 {
 			mux.HandleFunc(h.conf.WSPath, wsHdlr)
+			specs = append(specs, wsSpec)
+		}
+		if h.conf.Path != httpServerOpenAPIPath && h.conf.WSPath != httpServerOpenAPIPath {
+			mux.HandleFunc(httpServerOpenAPIPath, api.OpenAPIHandler("Benthos http_server input", "", specs...))
 		}
 	} else {
 		if len(h.conf.Path) > 0 {
-			mgr.RegisterEndpoint(
-				h.conf.Path, "Post a message into Benthos.", postHdlr,
-			)
+			interop.RegisterEndpointSpec(mgr, postSpec, postHdlr)
 		}
 		if len(h.conf.WSPath) > 0 {
-			mgr.RegisterEndpoint(
-				h.conf.WSPath, "Post messages via websocket into Benthos.", wsHdlr,
-			)
+			interop.RegisterEndpointSpec(mgr, wsSpec, wsHdlr)
 		}
 	}
 
@@ -335,6 +400,47 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 
 //------------------------------------------------------------------------------
 
+// httpServerOpenAPIPath is the path that an OpenAPI document is served from
+// when the server is hosted on a custom address.
+const httpServerOpenAPIPath = "/docs/openapi.json"
+
+func (h *HTTPServer) endpointSpecs() (postSpec, wsSpec api.EndpointSpec) {
+	resContentType := "*/*"
+	if ct, exists := h.conf.Response.Headers["Content-Type"]; exists && !strings.Contains(ct, "${!") {
+		resContentType = ct
+	}
+
+	var postOps []api.EndpointOperation
+	for _, verb := range h.conf.AllowedVerbs {
+		postOps = append(postOps, api.EndpointOperation{
+			Method:               verb,
+			Summary:              "Send a message, or a batch of messages as a multipart request, into the pipeline and receive a synchronous response.",
+			RequestContentTypes:  []string{"*/*"},
+			ResponseContentTypes: []string{resContentType},
+		})
+	}
+	postSpec = api.NewEndpointSpec(h.conf.Path, "Post a message into Benthos.", postOps...)
+	wsSpec = api.NewEndpointSpec(
+		h.conf.WSPath, "Post messages via websocket into Benthos.",
+		api.EndpointOperation{
+			Method:  "GET",
+			Summary: "Upgrade to a websocket connection where each payload received is sent into the pipeline.",
+		},
+	)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// remoteIP returns the IP of the client of a request, falling back to the full
+// remote address when it does not contain a port, e.g. unix domain sockets.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
 func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (types.Message, error) {
 	msg := message.New(nil)
 
@@ -348,8 +454,14 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (types.Message,
 		return nil, err
 	}
 
+	body, err := httputil.Decompress(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
 	if strings.HasPrefix(mediaType, "multipart/") {
-		mr := multipart.NewReader(r.Body, params["boundary"])
+		mr := multipart.NewReader(body, params["boundary"])
 		for {
 			var p *multipart.Part
 			if p, err = mr.NextPart(); err != nil {
@@ -366,7 +478,7 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (types.Message,
 		}
 	} else {
 		var msgBytes []byte
-		if msgBytes, err = ioutil.ReadAll(r.Body); err != nil {
+		if msgBytes, err = ioutil.ReadAll(body); err != nil {
 			return nil, err
 		}
 		msg.Append(message.NewPart(msgBytes))
@@ -374,6 +486,7 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (types.Message,
 
 	meta := metadata.New(nil)
 	meta.Set("http_server_user_agent", r.UserAgent())
+	meta.Set("http_server_remote_ip", remoteIP(r))
 	meta.Set("http_server_request_path", r.URL.Path)
 	meta.Set("http_server_verb", r.Method)
 	for k, v := range r.Header {
@@ -508,6 +621,10 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		for k, v := range h.responseHeaders {
 			w.Header().Set(k, v.String(0, responseMsg))
 		}
+		_ = h.metaHeaders.Iter(responseMsg.Get(0), func(k, v string) error {
+			w.Header().Set(k, v)
+			return nil
+		})
 
 		statusCode := 200
 		if statusCodeStr := h.responseStatus.String(0, responseMsg); statusCodeStr != "200" {
@@ -518,8 +635,12 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		if plen := responseMsg.Len(); plen == 1 {
-			payload := responseMsg.Get(0).Get()
+		if plen := responseMsg.Len(); plen == 1 || h.conf.Response.BatchMode == "concatenate" {
+			var payload []byte
+			responseMsg.Iter(func(i int, p types.Part) error {
+				payload = append(payload, p.Get()...)
+				return nil
+			})
 			if w.Header().Get("Content-Type") == "" {
 				w.Header().Set("Content-Type", http.DetectContentType(payload))
 			}
@@ -541,6 +662,10 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 				} else {
 					mimeHeader.Set("Content-Type", http.DetectContentType(payload))
 				}
+				_ = h.metaHeaders.Iter(responseMsg.Get(i), func(k, v string) error {
+					mimeHeader.Set(k, v)
+					return nil
+				})
 
 				var part io.Writer
 				if part, merr = writer.CreatePart(mimeHeader); merr == nil {
@@ -627,6 +752,7 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 
 		meta := msg.Get(0).Metadata()
 		meta.Set("http_server_user_agent", r.UserAgent())
+		meta.Set("http_server_remote_ip", remoteIP(r))
 		for k, v := range r.Header {
 			if len(v) > 0 {
 				meta.Set(k, v[0])
@@ -710,13 +836,22 @@ func (h *HTTPServer) loop() {
 
 	if h.server != nil {
 		go func() {
+			ln, err := listener.Listen(listener.Config{
+				Address:       h.conf.Address,
+				SocketMode:    h.socketMode,
+				ProxyProtocol: h.conf.ProxyProtocol,
+			})
+			if err != nil {
+				h.log.Errorf("Server error: %v\n", err)
+				return
+			}
 			if len(h.conf.KeyFile) > 0 || len(h.conf.CertFile) > 0 {
 				h.log.Infof(
 					"Receiving HTTPS messages at: https://%s\n",
 					h.conf.Address+h.conf.Path,
 				)
-				if err := h.server.ListenAndServeTLS(
-					h.conf.CertFile, h.conf.KeyFile,
+				if err := h.server.ServeTLS(
+					ln, h.conf.CertFile, h.conf.KeyFile,
 				); err != http.ErrServerClosed {
 					h.log.Errorf("Server error: %v\n", err)
 				}
@@ -725,7 +860,7 @@ func (h *HTTPServer) loop() {
 					"Receiving HTTP messages at: http://%s\n",
 					h.conf.Address+h.conf.Path,
 				)
-				if err := h.server.ListenAndServe(); err != http.ErrServerClosed {
+				if err := h.server.Serve(ln); err != http.ErrServerClosed {
 					h.log.Errorf("Server error: %v\n", err)
 				}
 			}
@@ -2,16 +2,19 @@ package input_test
 
 import (
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
 	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -25,10 +28,13 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	httputil "github.com/Jeffail/benthos/v3/lib/util/http"
+	"github.com/Jeffail/benthos/v3/lib/util/http/client"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 
 	_ "github.com/Jeffail/benthos/v3/public/components/all"
 )
@@ -873,6 +879,85 @@ func TestHTTPSyncResponseMultipart(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHTTPSyncResponseConcatenateMetadataHeaders(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Response.BatchMode = "concatenate"
+	conf.HTTPServer.Response.MetadataHeaders.IncludePrefixes = []string{"x-"}
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	input := []string{"foo", "bar"}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		hdr, body, err := createMultipart(input, "text/plain")
+		require.NoError(t, err)
+
+		res, err := http.Post(server.URL+"/testpost", hdr, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, 200, res.StatusCode)
+
+		resBytes, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "FOO\nBAR\n", string(resBytes))
+		assert.Equal(t, "first", res.Header.Get("x-foo"))
+		assert.Equal(t, "", res.Header.Get("y-foo"))
+	}()
+
+	var ts types.Transaction
+	select {
+	case ts = <-h.TransactionChan():
+		require.Equal(t, len(input), ts.Payload.Len())
+		resMsg := message.New(nil)
+		ts.Payload.Iter(func(i int, p types.Part) error {
+			p = p.Copy()
+			p.Set([]byte(strings.ToUpper(string(p.Get())) + "\n"))
+			p.Metadata().Set("x-foo", []string{"first", "second"}[i])
+			p.Metadata().Set("y-foo", "nope")
+			resMsg.Append(p)
+			return nil
+		})
+
+		// Parts originating from other requests must not be included.
+		otherMsg := message.New([][]byte{[]byte("other")})
+		roundtrip.AddResultStore(otherMsg, roundtrip.NewResultStore())
+		resMsg.Append(otherMsg.Get(0))
+
+		ignored, err := roundtrip.StoreResponse(resMsg)
+		require.NoError(t, err)
+		assert.Equal(t, 1, ignored)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for response")
+	}
+
+	h.CloseAsync()
+	err = h.WaitForClose(time.Second * 5)
+	require.NoError(t, err)
+
+	wg.Wait()
+}
+
 func TestHTTPSyncResponseHeadersStatus(t *testing.T) {
 	t.Parallel()
 
@@ -993,3 +1078,169 @@ func TestHTTPSyncResponseHeadersStatus(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestHTTPServerCompressionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, algo := range []string{"gzip", "zstd"} {
+		algo := algo
+		t.Run(algo, func(t *testing.T) {
+			reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+			mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			conf := input.NewConfig()
+			conf.HTTPServer.Path = "/testpost"
+			conf.HTTPServer.CompressResponse = algo
+
+			h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+			defer func() {
+				h.CloseAsync()
+				assert.NoError(t, h.WaitForClose(time.Second))
+			}()
+
+			server := httptest.NewServer(reg.mut)
+			defer server.Close()
+
+			clientConf := client.NewConfig()
+			clientConf.URL = server.URL + "/testpost"
+			clientConf.Compression = algo
+			clientConf.Headers["Accept-Encoding"] = algo
+
+			cl, err := client.New(clientConf)
+			require.NoError(t, err)
+			defer cl.CloseAsync()
+
+			for _, parts := range [][][]byte{
+				{[]byte("hello world")},
+				{[]byte("hello"), []byte("world")},
+			} {
+				resChan := make(chan *http.Response)
+				go func(parts [][]byte) {
+					res, err := cl.Do(message.New(parts))
+					require.NoError(t, err)
+					resChan <- res
+				}(parts)
+
+				var ts types.Transaction
+				select {
+				case ts = <-h.TransactionChan():
+					require.Equal(t, len(parts), ts.Payload.Len())
+					for i, p := range parts {
+						assert.Equal(t, string(p), string(ts.Payload.Get(i).Get()))
+					}
+					ts.Payload.Get(0).Set([]byte("response"))
+					roundtrip.SetAsResponse(ts.Payload)
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for message")
+				}
+				select {
+				case ts.ResponseChan <- response.NewAck():
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for response")
+				}
+
+				var res *http.Response
+				select {
+				case res = <-resChan:
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for client response")
+				}
+
+				assert.Equal(t, algo, res.Header.Get("Content-Encoding"))
+				body, err := httputil.Decompress(res.Header.Get("Content-Encoding"), res.Body)
+				require.NoError(t, err)
+				resBytes, err := ioutil.ReadAll(body)
+				require.NoError(t, err)
+				res.Body.Close()
+				assert.Contains(t, string(resBytes), "response")
+			}
+		})
+	}
+}
+
+func TestHTTPServerBadContentEncoding(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		h.CloseAsync()
+		assert.NoError(t, h.WaitForClose(time.Second))
+	}()
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL+"/testpost", bytes.NewReader([]byte("not gzip")))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestHTTPServerH2C(t *testing.T) {
+	t.Parallel()
+
+	// Reserve a free port for the custom address of the server.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Address = addr
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.H2C = true
+
+	h, err := input.NewHTTPServer(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		h.CloseAsync()
+		assert.NoError(t, h.WaitForClose(time.Second))
+	}()
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	go func() {
+		select {
+		case ts := <-h.TransactionChan():
+			assert.Equal(t, "hello world", string(ts.Payload.Get(0).Get()))
+			select {
+			case ts.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second * 5):
+				t.Error("Timed out waiting for response")
+			}
+		case <-time.After(time.Second * 5):
+			t.Error("Timed out waiting for message")
+		}
+	}()
+
+	var res *http.Response
+	require.Eventually(t, func() bool {
+		res, err = client.Post("http://"+addr+"/testpost", "text/plain", strings.NewReader("hello world"))
+		return err == nil
+	}, time.Second*5, time.Millisecond*50)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, res.ProtoMajor)
+}
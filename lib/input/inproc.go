@@ -7,6 +7,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -25,7 +26,9 @@ feedback loops can lead to deadlocks in your message flow.
 It is possible to connect multiple inputs to the same inproc ID, resulting in
 messages dispatching in a round-robin fashion to connected inputs. However, only
 one output can assume an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+collision occurs. Inputs connect to both
+` + "[`inproc`](/docs/components/outputs/inproc)" + ` and
+` + "[`inproc_buffered`](/docs/components/outputs/inproc_buffered)" + ` outputs.`,
 		Categories: []Category{
 			CategoryUtility,
 		},
@@ -105,6 +108,12 @@ func (i *Inproc) loop() {
 	mRunning.Incr(1)
 
 	var inprocChan <-chan types.Transaction
+	tracker, _ := i.mgr.(pipeConsumerTracker)
+	defer func() {
+		if inprocChan != nil && tracker != nil {
+			tracker.PipeConsumerDisconnected(i.pipe)
+		}
+	}()
 
 messageLoop:
 	for atomic.LoadInt32(&i.running) == 1 {
@@ -125,11 +134,17 @@ messageLoop:
 				}
 			}
 			mConn.Incr(1)
+			if tracker != nil {
+				tracker.PipeConsumerConnected(i.pipe)
+			}
 		}
 		select {
 		case t, open := <-inprocChan:
 			if !open {
 				mLostConn.Incr(1)
+				if tracker != nil {
+					tracker.PipeConsumerDisconnected(i.pipe)
+				}
 				inprocChan = nil
 				continue messageLoop
 			}
@@ -139,6 +154,12 @@ messageLoop:
 			select {
 			case i.transactions <- t:
 			case <-i.closeChan:
+				// Fail the message so that the producer is free to retry it
+				// rather than waiting indefinitely.
+				select {
+				case t.ResponseChan <- response.NewError(types.ErrTypeClosed):
+				case <-time.After(time.Second):
+				}
 				return
 			}
 		case <-i.closeChan:
@@ -147,6 +168,13 @@ messageLoop:
 	}
 }
 
+// pipeConsumerTracker is implemented by managers that track the consumers of
+// named pipes.
+type pipeConsumerTracker interface {
+	PipeConsumerConnected(name string)
+	PipeConsumerDisconnected(name string)
+}
+
 // TransactionChan returns a transactions channel for consuming messages from
 // this input type.
 func (i *Inproc) TransactionChan() <-chan types.Transaction {
@@ -7,8 +7,11 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -64,3 +67,44 @@ func TestInprocDryRunNoConn(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestInprocCloseFailsPending(t *testing.T) {
+	t.Parallel()
+
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	pipe := make(chan types.Transaction)
+	mgr.SetPipe("foo", pipe)
+
+	conf := input.NewConfig()
+	conf.Inproc = "foo"
+
+	ip, err := input.NewInproc(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	resChan := make(chan types.Response)
+	select {
+	case pipe <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	assert.Equal(t, map[string]manager.PipeState{
+		"foo": {Producer: true, Consumers: 1},
+	}, mgr.PipeStates())
+
+	// The message is never consumed from the input, and should therefore be
+	// failed once the input closes.
+	ip.CloseAsync()
+	select {
+	case res := <-resChan:
+		assert.Equal(t, types.ErrTypeClosed, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	require.NoError(t, ip.WaitForClose(time.Second))
+
+	assert.Equal(t, map[string]manager.PipeState{
+		"foo": {Producer: true},
+	}, mgr.PipeStates())
+}
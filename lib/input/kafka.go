@@ -3,12 +3,14 @@ package input
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/component/input"
@@ -50,9 +52,19 @@ This input adds the following metadata fields to each message:
 - kafka_offset
 - kafka_lag
 - kafka_timestamp_unix
+- kafka_header_base64_* (for headers with values that aren't valid UTF-8)
 - All existing message headers (version 0.11+)
 ` + "```" + `
 
+Header values that aren't valid UTF-8, such as binary trace identifiers, are
+also added base64 encoded under the header key prefixed with
+` + "`kafka_header_base64_`" + `, as these values would otherwise be mangled
+when metadata is serialised as text. The original bytes can be restored with
+the mapping ` + "`meta(\"kafka_header_base64_<key>\").decode(\"base64\")`" + `,
+for example within the ` + "[`headers_mapping`](/docs/components/outputs/kafka#headers_mapping)" + `
+field of a ` + "`kafka`" + ` output. When a message contains multiple headers
+with the same key only the last value is added as metadata.
+
 The field ` + "`kafka_lag`" + ` is the calculated difference between the high water mark offset of the partition at the time of ingestion and the current message offset.
 
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
@@ -75,6 +87,11 @@ You can access these metadata fields using [function interpolation](/docs/config
 			docs.FieldCommon("consumer_group", "An identifier for the consumer group of the connection. This field can be explicitly made empty in order to disable stored offsets for the consumed topic partitions."),
 			docs.FieldCommon("client_id", "An identifier for the client connection."),
 			docs.FieldAdvanced("start_from_oldest", "If an offset is not found for a topic partition, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset."),
+			docs.FieldAdvanced(
+				"start_from_timestamp", "If an offset is not found for a topic partition, consume from the first message with a timestamp at or after this time, and then continue consuming new messages as they arrive. The timestamp can be either an RFC3339 string or a unix timestamp in seconds. If no message exists after the timestamp the partition is consumed from the latest offset. Takes precedence over `start_from_oldest` when set. The rate at which the backlog is consumed is bounded by `checkpoint_limit`.",
+				"2021-06-01T00:00:00Z", "1622505600",
+			).AtVersion("3.50.0"),
+			docs.FieldAdvanced("start_from_timestamp_force", "Whether the offset resolved from `start_from_timestamp` should override offsets already committed by the consumer group. The override is only applied the first time each topic partition is consumed after startup.").AtVersion("3.50.0"),
 			docs.FieldCommon(
 				"checkpoint_limit", "EXPERIMENTAL: The maximum number of messages of the same topic and partition that can be processed at a given time. Increasing this limit enables parallel processing and batching at the output level to work on individual partitions. Any given offset will not be committed unless all messages under that offset are delivered in order to preserve at least once delivery guarantees.",
 			).AtVersion("3.33.0"),
@@ -82,9 +99,7 @@ You can access these metadata fields using [function interpolation](/docs/config
 			docs.FieldAdvanced("max_processing_period", "A maximum estimate for the time taken to process a message, this is used for tuning consumer group synchronization."),
 			input.ExtractTracingSpanMappingDocs,
 			docs.FieldAdvanced("group", "Tuning parameters for consumer group synchronization.").WithChildren(
-				docs.FieldAdvanced("session_timeout", "A period after which a consumer of the group is kicked after no heartbeats."),
-				docs.FieldAdvanced("heartbeat_interval", "A period in which heartbeats should be sent out."),
-				docs.FieldAdvanced("rebalance_timeout", "A period after which rebalancing is abandoned if unresolved."),
+				reader.KafkaBalancedGroupFieldSpecs()...,
 			),
 			docs.FieldAdvanced("fetch_buffer_cap", "The maximum number of unprocessed messages to fetch at a given time."),
 			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
@@ -173,12 +188,18 @@ type kafkaReader struct {
 	rebalanceTimeout  time.Duration
 	maxProcPeriod     time.Duration
 
+	startFromTimestamp time.Time
+	forcedTimestamps   map[string]map[int32]struct{}
+	groupClient        sarama.Client
+
 	// Connection resources
 	cMut            sync.Mutex
 	consumerCloseFn context.CancelFunc
 	consumerDoneCtx context.Context
 	msgChan         chan asyncMessage
 	session         offsetMarker
+	pauser          kafkaPauser
+	paused          bool
 
 	mRebalanced metrics.StatCounter
 
@@ -311,6 +332,13 @@ func newKafkaReader(
 			return nil, fmt.Errorf("failed to parse max processing period string: %v", err)
 		}
 	}
+	if ts := conf.StartFromTimestamp; len(ts) > 0 {
+		var err error
+		if k.startFromTimestamp, err = parseStartTimestamp(ts); err != nil {
+			return nil, err
+		}
+		k.forcedTimestamps = map[string]map[int32]struct{}{}
+	}
 	if conf.ConsumerGroup == "" && len(k.balancedTopics) > 0 {
 		return nil, errors.New("a consumer group must be specified when consuming balanced topics")
 	}
@@ -319,11 +347,69 @@ func newKafkaReader(
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if err = conf.Group.ApplyTo(&sarama.Config{Version: k.version}); err != nil {
+		return nil, err
+	}
 	return &k, nil
 }
 
 //------------------------------------------------------------------------------
 
+func parseStartTimestamp(ts string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse start from timestamp, expected RFC3339 or unix seconds: %v", err)
+	}
+	return t, nil
+}
+
+// useTimestampOffset returns true if the offset of a topic partition should be
+// resolved from the configured start timestamp, which is the case when there is
+// no committed offset, or when forced and the partition hasn't been forced yet.
+func (k *kafkaReader) useTimestampOffset(topic string, partition int32, committed bool) bool {
+	if k.startFromTimestamp.IsZero() {
+		return false
+	}
+	if !committed {
+		return true
+	}
+	if !k.conf.StartFromTimestampForce {
+		return false
+	}
+	parts, exists := k.forcedTimestamps[topic]
+	if !exists {
+		parts = map[int32]struct{}{}
+		k.forcedTimestamps[topic] = parts
+	}
+	if _, forced := parts[partition]; forced {
+		return false
+	}
+	parts[partition] = struct{}{}
+	return true
+}
+
+// timestampOffset resolves the offset of the first message of a topic partition
+// with a timestamp at or after the configured start timestamp, falling back to
+// the newest offset when there are no such messages.
+func (k *kafkaReader) timestampOffset(client sarama.Client, topic string, partition int32) (int64, error) {
+	offset, err := client.GetOffset(topic, partition, k.startFromTimestamp.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve offset from timestamp for topic %v partition %v: %v", topic, partition, err)
+	}
+	if offset < 0 {
+		if offset, err = client.GetOffset(topic, partition, sarama.OffsetNewest); err != nil {
+			return 0, fmt.Errorf("failed to resolve newest offset for topic %v partition %v: %v", topic, partition, err)
+		}
+	}
+	k.log.Infof("Resolved start timestamp %v for topic %v partition %v to offset %v\n", k.startFromTimestamp.Format(time.RFC3339), topic, partition, offset)
+	return offset, nil
+}
+
+//------------------------------------------------------------------------------
+
 func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(context.Context, chan<- asyncMessage, types.Message, int64) bool {
 	cp := checkpoint.NewCapped(int64(k.conf.CheckpointLimit))
 	return func(ctx context.Context, c chan<- asyncMessage, msg types.Message, offset int64) bool {
@@ -413,6 +499,9 @@ func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) types.Part {
 	meta := part.Metadata()
 	for _, hdr := range data.Headers {
 		meta.Set(string(hdr.Key), string(hdr.Value))
+		if !utf8.Valid(hdr.Value) {
+			meta.Set("kafka_header_base64_"+string(hdr.Key), base64.StdEncoding.EncodeToString(hdr.Value))
+		}
 	}
 
 	lag := highestOffset - data.Offset - 1
@@ -450,6 +539,35 @@ func (k *kafkaReader) closeGroupAndConsumers() {
 	})
 }
 
+// kafkaPauser is implemented by both sarama consumers and consumer groups.
+type kafkaPauser interface {
+	Pause(partitions map[string][]int32)
+	PauseAll()
+	ResumeAll()
+}
+
+// PauseConsumption stops fetching messages from all consumed partitions,
+// including those claimed after a rebalance, without leaving the consumer
+// group.
+func (k *kafkaReader) PauseConsumption() {
+	k.cMut.Lock()
+	defer k.cMut.Unlock()
+	k.paused = true
+	if k.pauser != nil {
+		k.pauser.PauseAll()
+	}
+}
+
+// ResumeConsumption resumes fetching messages from all consumed partitions.
+func (k *kafkaReader) ResumeConsumption() {
+	k.cMut.Lock()
+	defer k.cMut.Unlock()
+	k.paused = false
+	if k.pauser != nil {
+		k.pauser.ResumeAll()
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // ConnectWithContext establishes a kafkaReader connection.
@@ -472,6 +590,9 @@ func (k *kafkaReader) ConnectWithContext(ctx context.Context) error {
 	config.Consumer.Group.Heartbeat.Interval = k.heartbeatInterval
 	config.Consumer.Group.Rebalance.Timeout = k.rebalanceTimeout
 	config.ChannelBufferSize = k.conf.FetchBufferCap
+	if err := k.conf.Group.ApplyTo(config); err != nil {
+		return err
+	}
 
 	if config.Net.ReadTimeout <= k.sessionTimeout {
 		config.Net.ReadTimeout = k.sessionTimeout * 2
@@ -69,9 +69,7 @@ You can access these metadata fields using
 			docs.FieldAdvanced("commit_period", "The period of time between each commit of the current partition offsets. Offsets are always committed during shutdown."),
 			docs.FieldAdvanced("max_processing_period", "A maximum estimate for the time taken to process a message, this is used for tuning consumer group synchronization."),
 			docs.FieldAdvanced("group", "Tuning parameters for consumer group synchronization.").WithChildren(
-				docs.FieldAdvanced("session_timeout", "A period after which a consumer of the group is kicked after no heartbeats."),
-				docs.FieldAdvanced("heartbeat_interval", "A period in which heartbeats should be sent out."),
-				docs.FieldAdvanced("rebalance_timeout", "A period after which rebalancing is abandoned if unresolved."),
+				reader.KafkaBalancedGroupFieldSpecs()...,
 			),
 			docs.FieldAdvanced("fetch_buffer_cap", "The maximum number of unprocessed messages to fetch at a given time."),
 			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
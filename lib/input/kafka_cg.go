@@ -2,6 +2,7 @@ package input
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
@@ -12,6 +13,11 @@ import (
 
 // Setup is run at the beginning of a new session, before ConsumeClaim.
 func (k *kafkaReader) Setup(sesh sarama.ConsumerGroupSession) error {
+	if !k.startFromTimestamp.IsZero() {
+		if err := k.resetClaimsToTimestamp(sesh); err != nil {
+			return err
+		}
+	}
 	k.cMut.Lock()
 	k.session = sesh
 	k.cMut.Unlock()
@@ -19,6 +25,50 @@ func (k *kafkaReader) Setup(sesh sarama.ConsumerGroupSession) error {
 	return nil
 }
 
+// resetClaimsToTimestamp sets the offsets of claimed topic partitions to the
+// offsets resolved from the configured start timestamp where appropriate.
+func (k *kafkaReader) resetClaimsToTimestamp(sesh sarama.ConsumerGroupSession) error {
+	coordinator, err := k.groupClient.Coordinator(k.conf.ConsumerGroup)
+	if err != nil {
+		return err
+	}
+
+	offsetGetReq := sarama.OffsetFetchRequest{
+		Version:       k.offsetVersion(),
+		ConsumerGroup: k.conf.ConsumerGroup,
+	}
+	for topic, parts := range sesh.Claims() {
+		for _, part := range parts {
+			offsetGetReq.AddPartition(topic, part)
+		}
+	}
+	offsetRes, err := coordinator.FetchOffset(&offsetGetReq)
+	if err != nil {
+		return fmt.Errorf("failed to acquire offsets from broker: %v", err)
+	}
+
+	for topic, parts := range sesh.Claims() {
+		for _, part := range parts {
+			committed := false
+			if block := offsetRes.GetBlock(topic, part); block != nil && block.Err == sarama.ErrNoError {
+				committed = block.Offset > 0
+			}
+			if !k.useTimestampOffset(topic, part, committed) {
+				continue
+			}
+			offset, err := k.timestampOffset(k.groupClient, topic, part)
+			if err != nil {
+				return err
+			}
+			// Only one of these calls takes effect depending on whether the
+			// resolved offset is before or after the current offset.
+			sesh.ResetOffset(topic, part, offset, "")
+			sesh.MarkOffset(topic, part, offset, "")
+		}
+	}
+	return nil
+}
+
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have
 // exited but before the offsets are committed for the very last time.
 func (k *kafkaReader) Cleanup(sesh sarama.ConsumerGroupSession) error {
@@ -36,6 +86,13 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 	k.log.Debugf("Consuming messages from topic '%v' partition '%v'\n", topic, partition)
 	defer k.log.Debugf("Stopped consuming messages from topic '%v' partition '%v'\n", topic, partition)
 
+	// Partitions claimed while paused must be paused individually.
+	k.cMut.Lock()
+	if k.paused && k.pauser != nil {
+		k.pauser.Pause(map[string][]int32{topic: {partition}})
+	}
+	k.cMut.Unlock()
+
 	latestOffset := claim.InitialOffset()
 	batchPolicy, err := batch.NewPolicy(k.conf.Batching, k.mgr, k.log, k.stats)
 	if err != nil {
@@ -90,11 +147,19 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 //------------------------------------------------------------------------------
 
 func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.Config) error {
+	client, err := sarama.NewClient(k.addresses, config)
+	if err != nil {
+		return err
+	}
+
 	// Start a new consumer group
-	group, err := sarama.NewConsumerGroup(k.addresses, k.conf.ConsumerGroup, config)
+	group, err := sarama.NewConsumerGroupFromClient(k.conf.ConsumerGroup, client)
 	if err != nil {
+		client.Close()
 		return err
 	}
+	k.groupClient = client
+	k.pauser = group
 
 	// Handle errors
 	go func() {
@@ -144,8 +209,10 @@ func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.
 		k.log.Debugln("Closing consumer group")
 
 		group.Close()
+		client.Close()
 
 		k.cMut.Lock()
+		k.pauser = nil
 		if k.msgChan != nil {
 			close(k.msgChan)
 			k.msgChan = nil
@@ -184,7 +184,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 		// checkpointer that uses it already does this, but it's not
 		// particularly clear, hence this comment.
 		fn: func(topic string, partition int32, offset int64, metadata string) {
-			offsetPutReq.AddBlock(topic, partition, offset, time.Now().Unix(), metadata)
+			offsetPutReq.AddBlock(topic, partition, offset, 0, time.Now().Unix(), metadata)
 		},
 	}
 
@@ -199,10 +199,12 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 			if k.conf.StartFromOldest {
 				offset = sarama.OffsetOldest
 			}
+			committed := false
 			if block := offsetRes.GetBlock(topic, partition); block != nil {
 				if block.Err == sarama.ErrNoError {
 					if block.Offset > 0 {
 						offset = block.Offset
+						committed = true
 					}
 				} else {
 					k.log.Debugf("Failed to acquire offset for topic %v partition %v: %v\n", topic, partition, block.Err)
@@ -210,6 +212,12 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 			} else {
 				k.log.Debugf("Failed to acquire offset for topic %v partition %v\n", topic, partition)
 			}
+			if k.useTimestampOffset(topic, partition, committed) {
+				if offset, err = k.timestampOffset(client, topic, partition); err != nil {
+					doneFn()
+					return err
+				}
+			}
 
 			var partConsumer sarama.PartitionConsumer
 			if partConsumer, err = consumer.ConsumePartition(topic, partition, offset); err != nil {
@@ -261,6 +269,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 		consumerWG.Done()
 
 		k.cMut.Lock()
+		k.pauser = nil
 		if k.msgChan != nil {
 			close(k.msgChan)
 			k.msgChan = nil
@@ -273,6 +282,10 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 		client.Close()
 	}()
 
+	if k.paused {
+		consumer.PauseAll()
+	}
+	k.pauser = consumer
 	k.consumerCloseFn = doneFn
 	k.consumerDoneCtx = doneCtx
 	k.session = offsetTracker
@@ -5,7 +5,9 @@ import (
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKafkaBadParams(t *testing.T) {
@@ -44,3 +46,69 @@ func TestKafkaBadParams(t *testing.T) {
 		})
 	}
 }
+
+func TestKafkaStartTimestamp(t *testing.T) {
+	ts, err := parseStartTimestamp("1622505600")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1622505600), ts.Unix())
+
+	ts, err = parseStartTimestamp("2021-06-01T00:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1622505600), ts.Unix())
+
+	_, err = parseStartTimestamp("nope")
+	require.Error(t, err)
+
+	conf := NewConfig()
+	conf.Type = TypeKafka
+	conf.Kafka.Addresses = []string{"example.com:1234"}
+	conf.Kafka.Topics = []string{"foo"}
+	conf.Kafka.StartFromTimestamp = "nope"
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse start from timestamp")
+}
+
+func TestKafkaUseTimestampOffset(t *testing.T) {
+	conf := NewConfig().Kafka
+	conf.Topics = []string{"foo"}
+	conf.StartFromTimestamp = "1622505600"
+
+	k, err := newKafkaReader(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.True(t, k.useTimestampOffset("foo", 0, false))
+	assert.False(t, k.useTimestampOffset("foo", 0, true))
+
+	conf.StartFromTimestampForce = true
+	k, err = newKafkaReader(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.True(t, k.useTimestampOffset("foo", 0, true))
+	assert.False(t, k.useTimestampOffset("foo", 0, true))
+	assert.True(t, k.useTimestampOffset("foo", 1, true))
+	assert.True(t, k.useTimestampOffset("foo", 0, false))
+}
+
+func TestKafkaDataToPartHeaders(t *testing.T) {
+	part := dataToPart(10, &sarama.ConsumerMessage{
+		Value:     []byte("hello"),
+		Key:       []byte("foo"),
+		Topic:     "bar",
+		Partition: 2,
+		Offset:    5,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("text"), Value: []byte("baz")},
+			{Key: []byte("trace_id"), Value: []byte{0x00, 0xff, 0x10}},
+		},
+	})
+
+	meta := part.Metadata()
+	assert.Equal(t, "baz", meta.Get("text"))
+	assert.Equal(t, "", meta.Get("kafka_header_base64_text"))
+	assert.Equal(t, string([]byte{0x00, 0xff, 0x10}), meta.Get("trace_id"))
+	assert.Equal(t, "AP8Q", meta.Get("kafka_header_base64_trace_id"))
+	assert.Equal(t, "foo", meta.Get("kafka_key"))
+	assert.Equal(t, "4", meta.Get("kafka_lag"))
+}
@@ -2,6 +2,7 @@ package input
 
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -38,6 +39,8 @@ You can access these metadata fields using
 			docs.FieldCommon("subject", "A subject to consume from."),
 			docs.FieldAdvanced("prefetch_count", "The maximum number of messages to pull at a time."),
 			tls.FieldSpec(),
+			auth.FieldSpec(),
+			reader.NackBackoffFieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -53,7 +56,11 @@ func NewNATS(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if err != nil {
 		return nil, err
 	}
-	return NewAsyncReader(TypeNATS, true, reader.NewAsyncPreserver(n), log, stats)
+	nackOpt, err := AsyncReaderNackBackoff(conf.NATS.NackBackoff)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeNATS, true, reader.NewAsyncPreserver(n), log, stats, nackOpt)
 }
 
 //------------------------------------------------------------------------------
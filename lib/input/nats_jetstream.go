@@ -1,6 +1,8 @@
 package input
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/nats-io/nats.go"
 )
@@ -8,13 +10,15 @@ import (
 // NATSJetStreamConfig contains configuration fields for the NATS Jetstream
 // input type.
 type NATSJetStreamConfig struct {
-	URLs          []string   `json:"urls" yaml:"urls"`
-	Subject       string     `json:"subject" yaml:"subject"`
-	Queue         string     `json:"queue" yaml:"queue"`
-	Durable       string     `json:"durable" yaml:"durable"`
-	Deliver       string     `json:"deliver" yaml:"deliver"`
-	MaxAckPending int        `json:"max_ack_pending" yaml:"max_ack_pending"`
-	TLS           tls.Config `json:"tls" yaml:"tls"`
+	URLs          []string                 `json:"urls" yaml:"urls"`
+	Subject       string                   `json:"subject" yaml:"subject"`
+	Queue         string                   `json:"queue" yaml:"queue"`
+	Durable       string                   `json:"durable" yaml:"durable"`
+	Deliver       string                   `json:"deliver" yaml:"deliver"`
+	MaxAckPending int                      `json:"max_ack_pending" yaml:"max_ack_pending"`
+	TLS           tls.Config               `json:"tls" yaml:"tls"`
+	Auth          auth.Config              `json:"auth" yaml:"auth"`
+	NackBackoff   reader.NackBackoffConfig `json:"nack_backoff" yaml:"nack_backoff"`
 }
 
 // NewNATSJetStreamConfig creates a new NATSJetstreamConfig with default values.
@@ -25,5 +29,7 @@ func NewNATSJetStreamConfig() NATSJetStreamConfig {
 		MaxAckPending: 1024,
 		Deliver:       "all",
 		TLS:           tls.NewConfig(),
+		Auth:          auth.NewConfig(),
+		NackBackoff:   reader.NewNackBackoffConfig(),
 	}
 }
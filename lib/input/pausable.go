@@ -0,0 +1,218 @@
+package input
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Pauser is implemented by inputs, and components wrapping them, that are able
+// to suspend consumption at the source (such as by pausing the partitions of a
+// Kafka consumer group) rather than relying on back pressure alone.
+type Pauser interface {
+	PauseConsumption()
+	ResumeConsumption()
+}
+
+// Pausable wraps an input and stops forwarding transactions while it is
+// paused, which results in back pressure on the input. If the input implements
+// Pauser then consumption is also suspended at the source.
+type Pausable struct {
+	Type
+	StatusForwarder
+
+	stateMut    sync.Mutex
+	paused      bool
+	stateChange chan struct{}
+
+	mPaused metrics.StatGauge
+
+	transactions chan types.Transaction
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewPausable wraps an input with the ability to pause and resume the flow of
+// transactions.
+func NewPausable(in Type, stats metrics.Type) *Pausable {
+	p := &Pausable{
+		Type:            in,
+		StatusForwarder: NewStatusForwarder(in),
+		stateChange:     make(chan struct{}),
+		mPaused:         stats.GetGauge("paused"),
+		transactions:    make(chan types.Transaction),
+		closeChan:       make(chan struct{}),
+		closedChan:      make(chan struct{}),
+	}
+	p.mPaused.Set(0)
+	go p.loop()
+	return p
+}
+
+func (p *Pausable) state() (paused bool, changed <-chan struct{}) {
+	p.stateMut.Lock()
+	defer p.stateMut.Unlock()
+	return p.paused, p.stateChange
+}
+
+func (p *Pausable) setPaused(paused bool) bool {
+	p.stateMut.Lock()
+	defer p.stateMut.Unlock()
+	if p.paused == paused {
+		return false
+	}
+	p.paused = paused
+	close(p.stateChange)
+	p.stateChange = make(chan struct{})
+
+	var v int64
+	if paused {
+		v = 1
+	}
+	p.mPaused.Set(v)
+	return true
+}
+
+// waitWhilePaused blocks until the input is not paused, returning the channel
+// that is closed on the next change of state, or false if the input is closed
+// in the meantime.
+func (p *Pausable) waitWhilePaused() (<-chan struct{}, bool) {
+	for {
+		paused, changed := p.state()
+		if !paused {
+			return changed, true
+		}
+		select {
+		case <-changed:
+		case <-p.closeChan:
+			return nil, false
+		}
+	}
+}
+
+// forward sends a transaction downstream, holding onto it for as long as the
+// input is paused.
+func (p *Pausable) forward(tran types.Transaction) bool {
+	for {
+		changed, ok := p.waitWhilePaused()
+		if !ok {
+			p.reject(tran)
+			return false
+		}
+		select {
+		case p.transactions <- tran:
+			return true
+		case <-changed:
+		case <-p.closeChan:
+			p.reject(tran)
+			return false
+		}
+	}
+}
+
+// reject responds to a transaction held during closure with an error so that
+// the input is free to shut down without the message being lost.
+func (p *Pausable) reject(tran types.Transaction) {
+	go func() {
+		tran.ResponseChan <- response.NewError(types.ErrTypeClosed)
+	}()
+}
+
+func (p *Pausable) loop() {
+	defer func() {
+		close(p.transactions)
+		close(p.closedChan)
+	}()
+
+	for {
+		changed, ok := p.waitWhilePaused()
+		if !ok {
+			return
+		}
+
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.Type.TransactionChan():
+			if !open {
+				return
+			}
+		case <-changed:
+			continue
+		case <-p.closeChan:
+			return
+		}
+
+		if !p.forward(tran) {
+			return
+		}
+	}
+}
+
+// Pause stops the flow of transactions from the input, and suspends
+// consumption at the source when supported. Returns false if the input was
+// already paused.
+func (p *Pausable) Pause() bool {
+	if !p.setPaused(true) {
+		return false
+	}
+	if pr, ok := p.Type.(Pauser); ok {
+		pr.PauseConsumption()
+	}
+	return true
+}
+
+// Resume restarts the flow of transactions from the input. Returns false if the
+// input was not paused.
+func (p *Pausable) Resume() bool {
+	if !p.setPaused(false) {
+		return false
+	}
+	if pr, ok := p.Type.(Pauser); ok {
+		pr.ResumeConsumption()
+	}
+	return true
+}
+
+// Paused returns true if the input is currently paused.
+func (p *Pausable) Paused() bool {
+	paused, _ := p.state()
+	return paused
+}
+
+// TransactionChan returns a channel of transactions that is blocked while the
+// input is paused.
+func (p *Pausable) TransactionChan() <-chan types.Transaction {
+	return p.transactions
+}
+
+// CloseAsync shuts down the wrapped input and stops forwarding transactions.
+func (p *Pausable) CloseAsync() {
+	p.Type.CloseAsync()
+	p.closeOnce.Do(func() {
+		close(p.closeChan)
+	})
+}
+
+// WaitForClose blocks until the wrapped input has closed down.
+func (p *Pausable) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	if err := p.Type.WaitForClose(timeout); err != nil {
+		return err
+	}
+	select {
+	case <-p.closedChan:
+	case <-time.After(timeout - time.Since(started)):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPauserInput struct {
+	closeOnce sync.Once
+	ts        chan types.Transaction
+
+	mut     sync.Mutex
+	pauses  int
+	resumes int
+}
+
+func (m *mockPauserInput) TransactionChan() <-chan types.Transaction {
+	return m.ts
+}
+
+func (m *mockPauserInput) Connected() bool {
+	return true
+}
+
+func (m *mockPauserInput) PauseConsumption() {
+	m.mut.Lock()
+	m.pauses++
+	m.mut.Unlock()
+}
+
+func (m *mockPauserInput) ResumeConsumption() {
+	m.mut.Lock()
+	m.resumes++
+	m.mut.Unlock()
+}
+
+func (m *mockPauserInput) CloseAsync() {
+	m.closeOnce.Do(func() {
+		close(m.ts)
+	})
+}
+
+func (m *mockPauserInput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestPausableInput(t *testing.T) {
+	mock := &mockPauserInput{ts: make(chan types.Transaction)}
+	stats := metrics.NewLocal()
+	p := NewPausable(mock, stats)
+
+	resChan := make(chan types.Response)
+	send := func(content string) {
+		select {
+		case mock.ts <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Error("timed out")
+		}
+	}
+	read := func() string {
+		select {
+		case tran, open := <-p.TransactionChan():
+			require.True(t, open)
+			return string(tran.Payload.Get(0).Get())
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return ""
+	}
+
+	go send("foo")
+	assert.Equal(t, "foo", read())
+
+	assert.True(t, p.Pause())
+	assert.False(t, p.Pause())
+	assert.True(t, p.Paused())
+	assert.Equal(t, int64(1), stats.GetCounters()["paused"])
+
+	go send("bar")
+	select {
+	case <-p.TransactionChan():
+		t.Fatal("expected paused input to block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	assert.True(t, p.Resume())
+	assert.False(t, p.Resume())
+	assert.False(t, p.Paused())
+	assert.Equal(t, int64(0), stats.GetCounters()["paused"])
+	assert.Equal(t, "bar", read())
+
+	mock.mut.Lock()
+	assert.Equal(t, 1, mock.pauses)
+	assert.Equal(t, 1, mock.resumes)
+	mock.mut.Unlock()
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+}
+
+func TestPausableInputCloseWhilePaused(t *testing.T) {
+	mock := &mockPauserInput{ts: make(chan types.Transaction)}
+	p := NewPausable(mock, metrics.Noop())
+	p.Pause()
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+
+	_, open := <-p.TransactionChan()
+	assert.False(t, open)
+}
@@ -1,10 +1,12 @@
 package input
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
@@ -206,6 +208,11 @@ func (r *Reader) Connected() bool {
 	return atomic.LoadInt32(&r.connected) == 1
 }
 
+// SelfTest runs the self test of the underlying reader.
+func (r *Reader) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, r.reader)
+}
+
 // CloseAsync shuts down the Reader input and stops processing requests.
 func (r *Reader) CloseAsync() {
 	if atomic.CompareAndSwapInt32(&r.running, 1, 0) {
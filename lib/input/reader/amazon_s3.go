@@ -16,6 +16,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
@@ -166,7 +167,7 @@ func (a *AmazonS3) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession(func(c *aws.Config) {
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")), func(c *aws.Config) {
 		c.S3ForcePathStyle = aws.Bool(a.conf.ForcePathStyleURLs)
 	})
 	if err != nil {
@@ -174,7 +175,8 @@ func (a *AmazonS3) ConnectWithContext(ctx context.Context) error {
 	}
 
 	sThree := s3.New(sess)
-	dler := s3manager.NewDownloader(sess)
+	instrument.Register(&sThree.Handlers, a.stats)
+	dler := s3manager.NewDownloaderWithClient(sThree)
 
 	if a.conf.SQSURL == "" {
 		listInput := &s3.ListObjectsInput{
@@ -203,6 +205,7 @@ func (a *AmazonS3) ConnectWithContext(ctx context.Context) error {
 			sqsSess.Config.Endpoint = &a.conf.SQSEndpoint
 		}
 		a.sqs = sqs.New(sqsSess)
+		instrument.Register(&a.sqs.Handlers, a.stats)
 	}
 
 	a.log.Infof("Receiving Amazon S3 objects from bucket: %s\n", a.conf.Bucket)
@@ -3,12 +3,14 @@ package reader
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -90,12 +92,13 @@ func (a *AmazonSQS) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	a.sqs = sqs.New(sess)
+	instrument.Register(&a.sqs.Handlers, a.stats)
 	a.session = sess
 
 	a.log.Infof("Receiving Amazon SQS messages from URL: %v\n", a.conf.URL)
@@ -109,6 +112,11 @@ func addSQSMetadata(p types.Part, sqsMsg *sqs.Message) {
 	if rCountStr := sqsMsg.Attributes["ApproximateReceiveCount"]; rCountStr != nil {
 		meta.Set("sqs_approximate_receive_count", *rCountStr)
 	}
+	if sentStr := sqsMsg.Attributes["SentTimestamp"]; sentStr != nil {
+		if sentMillis, err := strconv.ParseInt(*sentStr, 10, 64); err == nil {
+			meta.Set("sqs_sent_timestamp_unix", strconv.FormatInt(sentMillis/1000, 10))
+		}
+	}
 	for k, v := range sqsMsg.MessageAttributes {
 		if v.StringValue != nil {
 			meta.Set(k, *v.StringValue)
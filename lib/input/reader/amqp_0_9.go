@@ -44,6 +44,7 @@ type AMQP09Config struct {
 	PrefetchCount   int                      `json:"prefetch_count" yaml:"prefetch_count"`
 	PrefetchSize    int                      `json:"prefetch_size" yaml:"prefetch_size"`
 	TLS             btls.Config              `json:"tls" yaml:"tls"`
+	NackBackoff     NackBackoffConfig        `json:"nack_backoff" yaml:"nack_backoff"`
 
 	// TODO: V4 remove this (maybe in V5 to allow a grace period)
 	Batching batch.PolicyConfig `json:"batching" yaml:"batching"`
@@ -63,6 +64,7 @@ func NewAMQP09Config() AMQP09Config {
 		PrefetchCount:   10,
 		PrefetchSize:    0,
 		TLS:             btls.NewConfig(),
+		NackBackoff:     NewNackBackoffConfig(),
 		Batching:        batch.NewPolicyConfig(),
 		BindingsDeclare: []AMQP09BindingConfig{},
 	}
@@ -20,11 +20,12 @@ import (
 
 // AMQP1Config contains configuration for the AMQP1 input type.
 type AMQP1Config struct {
-	URL            string      `json:"url" yaml:"url"`
-	SourceAddress  string      `json:"source_address" yaml:"source_address"`
-	AzureRenewLock bool        `json:"azure_renew_lock" yaml:"azure_renew_lock"`
-	TLS            btls.Config `json:"tls" yaml:"tls"`
-	SASL           sasl.Config `json:"sasl" yaml:"sasl"`
+	URL            string            `json:"url" yaml:"url"`
+	SourceAddress  string            `json:"source_address" yaml:"source_address"`
+	AzureRenewLock bool              `json:"azure_renew_lock" yaml:"azure_renew_lock"`
+	TLS            btls.Config       `json:"tls" yaml:"tls"`
+	SASL           sasl.Config       `json:"sasl" yaml:"sasl"`
+	NackBackoff    NackBackoffConfig `json:"nack_backoff" yaml:"nack_backoff"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
@@ -34,6 +35,7 @@ func NewAMQP1Config() AMQP1Config {
 		SourceAddress: "",
 		TLS:           btls.NewConfig(),
 		SASL:          sasl.NewConfig(),
+		NackBackoff:   NewNackBackoffConfig(),
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -47,6 +48,11 @@ func (c *AsyncCutOff) ConnectWithContext(ctx context.Context) error {
 	return c.r.ConnectWithContext(ctx)
 }
 
+// SelfTest runs the self test of the underlying reader.
+func (c *AsyncCutOff) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, c.r)
+}
+
 // ReadWithContext attempts to read a new message from the source.
 func (c *AsyncCutOff) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
 	go func() {
@@ -0,0 +1,199 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/shutdown"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+type asyncParallelRes struct {
+	msg   types.Message
+	ackFn AsyncAckFn
+}
+
+// AsyncParallel is a wrapper for multiple reader.Async implementations of the
+// same source that runs a receive loop for each reader concurrently, feeding
+// all messages into a single stream. Acknowledgements are routed back to the
+// reader that produced each message.
+//
+// Messages from different readers are interleaved in the order they are
+// received and therefore ordering across readers is not preserved.
+type AsyncParallel struct {
+	readers []Async
+	resChan chan asyncParallelRes
+
+	connMut sync.Mutex
+	started bool
+
+	closeSignal *shutdown.Signaller
+
+	log        log.Modular
+	mActive    metrics.StatGauge
+	mReceived  []metrics.StatCounter
+	mReconnect metrics.StatCounter
+}
+
+// NewAsyncParallel returns a new AsyncParallel wrapper around a slice of
+// reader.Async implementations.
+func NewAsyncParallel(readers []Async, log log.Modular, stats metrics.Type) *AsyncParallel {
+	p := &AsyncParallel{
+		readers:     readers,
+		resChan:     make(chan asyncParallelRes),
+		closeSignal: shutdown.NewSignaller(),
+		log:         log,
+		mActive:     stats.GetGauge("parallel_reads.active"),
+		mReconnect:  stats.GetCounter("parallel_reads.reconnect"),
+	}
+	for i := range readers {
+		p.mReceived = append(p.mReceived, stats.GetCounter("parallel_reads."+strconv.Itoa(i)+".received"))
+	}
+	return p
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext attempts to establish a connection for each reader, and
+// once all are connected begins their receive loops.
+func (p *AsyncParallel) ConnectWithContext(ctx context.Context) error {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+	if p.started {
+		return nil
+	}
+	for _, r := range p.readers {
+		if err := r.ConnectWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	p.started = true
+
+	var wg sync.WaitGroup
+	wg.Add(len(p.readers))
+	for i, r := range p.readers {
+		go p.loop(i, r, &wg)
+	}
+	go func() {
+		wg.Wait()
+		close(p.resChan)
+		p.closeSignal.ShutdownComplete()
+	}()
+	return nil
+}
+
+func (p *AsyncParallel) loop(index int, r Async, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	p.mActive.Incr(1)
+	defer p.mActive.Decr(1)
+
+	ctx, done := p.closeSignal.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond * 10
+	boff.MaxInterval = time.Second
+	boff.MaxElapsedTime = 0
+
+	for {
+		msg, ackFn, err := r.ReadWithContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, types.ErrTypeClosed) {
+				return
+			}
+			switch {
+			case errors.Is(err, types.ErrTimeout):
+				continue
+			case errors.Is(err, types.ErrNotConnected):
+				p.mReconnect.Incr(1)
+				if err = r.ConnectWithContext(ctx); err == nil {
+					continue
+				}
+				p.log.Errorf("Failed to reconnect parallel reader %v: %v\n", index, err)
+			default:
+				p.log.Errorf("Failed to read message from parallel reader %v: %v\n", index, err)
+			}
+			select {
+			case <-time.After(boff.NextBackOff()):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		boff.Reset()
+		p.mReceived[index].Incr(1)
+
+		select {
+		case p.resChan <- asyncParallelRes{msg: msg, ackFn: ackFn}:
+		case <-ctx.Done():
+			_ = ackFn(context.Background(), response.NewError(types.ErrTypeClosed))
+			return
+		}
+	}
+}
+
+// ReadWithContext attempts to read a new message from any of the readers.
+func (p *AsyncParallel) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	p.connMut.Lock()
+	started := p.started
+	p.connMut.Unlock()
+	if !started {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case res, open := <-p.resChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return res.msg, res.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync triggers the asynchronous closing of the reader.
+func (p *AsyncParallel) CloseAsync() {
+	p.closeSignal.CloseAtLeisure()
+
+	p.connMut.Lock()
+	if !p.started {
+		p.started = true
+		close(p.resChan)
+		p.closeSignal.ShutdownComplete()
+	}
+	p.connMut.Unlock()
+
+	for _, r := range p.readers {
+		r.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until either the reader is finished closing or a timeout
+// occurs.
+func (p *AsyncParallel) WaitForClose(tout time.Duration) error {
+	stopBy := time.Now().Add(tout)
+	select {
+	case <-p.closeSignal.HasClosedChan():
+	case <-time.After(tout):
+		return types.ErrTimeout
+	}
+	for _, r := range p.readers {
+		if err := r.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,129 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parallelTestReader struct {
+	msgs chan string
+
+	ackMut sync.Mutex
+	acks   map[string]error
+}
+
+func newParallelTestReader(contents ...string) *parallelTestReader {
+	msgs := make(chan string, len(contents))
+	for _, c := range contents {
+		msgs <- c
+	}
+	close(msgs)
+	return &parallelTestReader{
+		msgs: msgs,
+		acks: map[string]error{},
+	}
+}
+
+func (r *parallelTestReader) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+func (r *parallelTestReader) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	var content string
+	var open bool
+	select {
+	case content, open = <-r.msgs:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	}
+	return message.New([][]byte{[]byte(content)}), func(ctx context.Context, res types.Response) error {
+		r.ackMut.Lock()
+		r.acks[content] = res.Error()
+		r.ackMut.Unlock()
+		return nil
+	}, nil
+}
+
+func (r *parallelTestReader) CloseAsync() {}
+
+func (r *parallelTestReader) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestAsyncParallelAcks(t *testing.T) {
+	t.Parallel()
+
+	readerOne := newParallelTestReader("foo1", "bar1", "baz1")
+	readerTwo := newParallelTestReader("foo2", "bar2")
+
+	p := NewAsyncParallel([]Async{readerOne, readerTwo}, log.Noop(), metrics.Noop())
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	_, _, err := p.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrNotConnected, err)
+
+	require.NoError(t, p.ConnectWithContext(ctx))
+
+	var received []string
+	for {
+		msg, ackFn, err := p.ReadWithContext(ctx)
+		if errors.Is(err, types.ErrTypeClosed) {
+			break
+		}
+		require.NoError(t, err)
+
+		content := string(msg.Get(0).Get())
+		received = append(received, content)
+
+		var res types.Response = response.NewAck()
+		if content == "bar1" || content == "foo2" {
+			res = response.NewError(errors.New("nope"))
+		}
+		require.NoError(t, ackFn(ctx, res))
+	}
+
+	sort.Strings(received)
+	assert.Equal(t, []string{"bar1", "bar2", "baz1", "foo1", "foo2"}, received)
+
+	assert.Equal(t, map[string]error{
+		"foo1": nil,
+		"bar1": errors.New("nope"),
+		"baz1": nil,
+	}, readerOne.acks)
+	assert.Equal(t, map[string]error{
+		"foo2": errors.New("nope"),
+		"bar2": nil,
+	}, readerTwo.acks)
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+}
+
+func TestAsyncParallelCloseBeforeConnect(t *testing.T) {
+	t.Parallel()
+
+	p := NewAsyncParallel([]Async{
+		newParallelTestReader("foo"),
+		newParallelTestReader("bar"),
+	}, log.Noop(), metrics.Noop())
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+}
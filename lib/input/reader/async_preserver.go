@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component"
 	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -73,6 +74,11 @@ func (p *AsyncPreserver) ConnectWithContext(ctx context.Context) error {
 	return err
 }
 
+// SelfTest runs the self test of the underlying reader.
+func (p *AsyncPreserver) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, p.r)
+}
+
 func (p *AsyncPreserver) wrapAckFn(m asyncPreserverResend) (types.Message, AsyncAckFn) {
 	if m.msg.Len() == 1 {
 		return p.wrapSingleAckFn(m)
@@ -195,6 +201,22 @@ func (p *AsyncPreserver) ReadWithContext(ctx context.Context) (types.Message, As
 	return sendMsg, ackFn, nil
 }
 
+// PauseConsumption suspends consumption at the source when supported by the
+// underlying reader.
+func (p *AsyncPreserver) PauseConsumption() {
+	if pr, ok := p.r.(interface{ PauseConsumption() }); ok {
+		pr.PauseConsumption()
+	}
+}
+
+// ResumeConsumption resumes consumption at the source when supported by the
+// underlying reader.
+func (p *AsyncPreserver) ResumeConsumption() {
+	if pr, ok := p.r.(interface{ ResumeConsumption() }); ok {
+		pr.ResumeConsumption()
+	}
+}
+
 // CloseAsync triggers the asynchronous closing of the reader.
 func (p *AsyncPreserver) CloseAsync() {
 	p.r.CloseAsync()
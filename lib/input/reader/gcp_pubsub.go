@@ -2,6 +2,7 @@ package reader
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -23,6 +24,10 @@ type GCPPubSubConfig struct {
 	SubscriptionID         string `json:"subscription" yaml:"subscription"`
 	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
 	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+	MaxExtension           string `json:"max_extension" yaml:"max_extension"`
+	MaxExtensionPeriod     string `json:"max_extension_period" yaml:"max_extension_period"`
+	Sync                   bool   `json:"sync" yaml:"sync"`
+	ParallelReads          int    `json:"parallel_reads" yaml:"parallel_reads"`
 	// TODO: V4 Remove these.
 	MaxBatchCount int                `json:"max_batch_count" yaml:"max_batch_count"`
 	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
@@ -35,6 +40,10 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		SubscriptionID:         "",
 		MaxOutstandingMessages: pubsub.DefaultReceiveSettings.MaxOutstandingMessages,
 		MaxOutstandingBytes:    pubsub.DefaultReceiveSettings.MaxOutstandingBytes,
+		MaxExtension:           "60m",
+		MaxExtensionPeriod:     "",
+		Sync:                   false,
+		ParallelReads:          1,
 		MaxBatchCount:          1,
 		Batching:               batch.NewPolicyConfig(),
 	}
@@ -47,6 +56,9 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 type GCPPubSub struct {
 	conf GCPPubSubConfig
 
+	maxExtension       time.Duration
+	maxExtensionPeriod time.Duration
+
 	subscription *pubsub.Subscription
 	msgsChan     chan *pubsub.Message
 	closeFunc    context.CancelFunc
@@ -65,16 +77,26 @@ func NewGCPPubSub(
 	log log.Modular,
 	stats metrics.Type,
 ) (*GCPPubSub, error) {
-	client, err := pubsub.NewClient(context.Background(), conf.ProjectID)
-	if err != nil {
+	c := &GCPPubSub{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	var err error
+	if conf.MaxExtension != "" {
+		if c.maxExtension, err = time.ParseDuration(conf.MaxExtension); err != nil {
+			return nil, fmt.Errorf("failed to parse max_extension: %v", err)
+		}
+	}
+	if conf.MaxExtensionPeriod != "" {
+		if c.maxExtensionPeriod, err = time.ParseDuration(conf.MaxExtensionPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse max_extension_period: %v", err)
+		}
+	}
+	if c.client, err = pubsub.NewClient(context.Background(), conf.ProjectID); err != nil {
 		return nil, err
 	}
-	return &GCPPubSub{
-		conf:   conf,
-		log:    log,
-		stats:  stats,
-		client: client,
-	}, nil
+	return c, nil
 }
 
 // Connect attempts to establish a connection to the target subscription.
@@ -94,6 +116,9 @@ func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
 	sub := c.client.Subscription(c.conf.SubscriptionID)
 	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
 	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
+	sub.ReceiveSettings.MaxExtension = c.maxExtension
+	sub.ReceiveSettings.MaxExtensionPeriod = c.maxExtensionPeriod
+	sub.ReceiveSettings.Synchronous = c.conf.Sync
 
 	subCtx, cancel := context.WithCancel(context.Background())
 	msgsChan := make(chan *pubsub.Message, c.conf.MaxBatchCount)
@@ -127,6 +152,16 @@ func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
 	return nil
 }
 
+func gcpPubSubMsgToPart(gmsg *pubsub.Message) types.Part {
+	part := message.NewPart(gmsg.Data)
+	part.SetMetadata(metadata.New(gmsg.Attributes))
+	part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
+	if gmsg.DeliveryAttempt != nil {
+		part.Metadata().Set("gcp_pubsub_delivery_attempt", strconv.Itoa(*gmsg.DeliveryAttempt))
+	}
+	return part
+}
+
 // ReadWithContext attempts to read a new message from the target subscription.
 func (c *GCPPubSub) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
 	c.subMut.Lock()
@@ -149,10 +184,7 @@ func (c *GCPPubSub) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 		return nil, nil, types.ErrNotConnected
 	}
 
-	part := message.NewPart(gmsg.Data)
-	part.SetMetadata(metadata.New(gmsg.Attributes))
-	part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-	msg.Append(part)
+	msg.Append(gcpPubSubMsgToPart(gmsg))
 
 	return msg, func(ctx context.Context, res types.Response) error {
 		if res.Error() != nil {
@@ -180,10 +212,7 @@ func (c *GCPPubSub) Read() (types.Message, error) {
 		return nil, types.ErrNotConnected
 	}
 	c.pendingMsgs = append(c.pendingMsgs, gmsg)
-	part := message.NewPart(gmsg.Data)
-	part.SetMetadata(metadata.New(gmsg.Attributes))
-	part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-	msg.Append(part)
+	msg.Append(gcpPubSubMsgToPart(gmsg))
 
 batchLoop:
 	for msg.Len() < c.conf.MaxBatchCount {
@@ -197,10 +226,7 @@ batchLoop:
 			return nil, types.ErrNotConnected
 		}
 		c.pendingMsgs = append(c.pendingMsgs, gmsg)
-		part := message.NewPart(gmsg.Data)
-		part.SetMetadata(metadata.New(gmsg.Attributes))
-		part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-		msg.Append(part)
+		msg.Append(gcpPubSubMsgToPart(gmsg))
 	}
 
 	return msg, nil
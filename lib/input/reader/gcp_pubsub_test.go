@@ -0,0 +1,31 @@
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPPubSubMsgToPart(t *testing.T) {
+	publishTime := time.Unix(1600000000, 0)
+
+	part := gcpPubSubMsgToPart(&pubsub.Message{
+		Data:        []byte("hello world"),
+		Attributes:  map[string]string{"foo": "bar"},
+		PublishTime: publishTime,
+	})
+	assert.Equal(t, "hello world", string(part.Get()))
+	assert.Equal(t, "bar", part.Metadata().Get("foo"))
+	assert.Equal(t, "1600000000", part.Metadata().Get("gcp_pubsub_publish_time_unix"))
+	assert.Equal(t, "", part.Metadata().Get("gcp_pubsub_delivery_attempt"))
+
+	attempt := 3
+	part = gcpPubSubMsgToPart(&pubsub.Message{
+		Data:            []byte("hello world"),
+		PublishTime:     publishTime,
+		DeliveryAttempt: &attempt,
+	})
+	assert.Equal(t, "3", part.Metadata().Get("gcp_pubsub_delivery_attempt"))
+}
@@ -24,21 +24,23 @@ import (
 
 // KafkaConfig contains configuration fields for the Kafka input type.
 type KafkaConfig struct {
-	Addresses           []string                 `json:"addresses" yaml:"addresses"`
-	Topics              []string                 `json:"topics" yaml:"topics"`
-	ClientID            string                   `json:"client_id" yaml:"client_id"`
-	ConsumerGroup       string                   `json:"consumer_group" yaml:"consumer_group"`
-	Group               KafkaBalancedGroupConfig `json:"group" yaml:"group"`
-	CommitPeriod        string                   `json:"commit_period" yaml:"commit_period"`
-	CheckpointLimit     int                      `json:"checkpoint_limit" yaml:"checkpoint_limit"`
-	ExtractTracingMap   string                   `json:"extract_tracing_map" yaml:"extract_tracing_map"`
-	MaxProcessingPeriod string                   `json:"max_processing_period" yaml:"max_processing_period"`
-	FetchBufferCap      int                      `json:"fetch_buffer_cap" yaml:"fetch_buffer_cap"`
-	StartFromOldest     bool                     `json:"start_from_oldest" yaml:"start_from_oldest"`
-	TargetVersion       string                   `json:"target_version" yaml:"target_version"`
-	TLS                 btls.Config              `json:"tls" yaml:"tls"`
-	SASL                sasl.Config              `json:"sasl" yaml:"sasl"`
-	Batching            batch.PolicyConfig       `json:"batching" yaml:"batching"`
+	Addresses               []string                 `json:"addresses" yaml:"addresses"`
+	Topics                  []string                 `json:"topics" yaml:"topics"`
+	ClientID                string                   `json:"client_id" yaml:"client_id"`
+	ConsumerGroup           string                   `json:"consumer_group" yaml:"consumer_group"`
+	Group                   KafkaBalancedGroupConfig `json:"group" yaml:"group"`
+	CommitPeriod            string                   `json:"commit_period" yaml:"commit_period"`
+	CheckpointLimit         int                      `json:"checkpoint_limit" yaml:"checkpoint_limit"`
+	ExtractTracingMap       string                   `json:"extract_tracing_map" yaml:"extract_tracing_map"`
+	MaxProcessingPeriod     string                   `json:"max_processing_period" yaml:"max_processing_period"`
+	FetchBufferCap          int                      `json:"fetch_buffer_cap" yaml:"fetch_buffer_cap"`
+	StartFromOldest         bool                     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	StartFromTimestamp      string                   `json:"start_from_timestamp" yaml:"start_from_timestamp"`
+	StartFromTimestampForce bool                     `json:"start_from_timestamp_force" yaml:"start_from_timestamp_force"`
+	TargetVersion           string                   `json:"target_version" yaml:"target_version"`
+	TLS                     btls.Config              `json:"tls" yaml:"tls"`
+	SASL                    sasl.Config              `json:"sasl" yaml:"sasl"`
+	Batching                batch.PolicyConfig       `json:"batching" yaml:"batching"`
 
 	// TODO: V4 Remove this.
 	Topic         string `json:"topic" yaml:"topic"`
@@ -57,23 +59,25 @@ func (k KafkaConfig) IsDeprecated() bool {
 // NewKafkaConfig creates a new KafkaConfig with default values.
 func NewKafkaConfig() KafkaConfig {
 	return KafkaConfig{
-		Addresses:           []string{"localhost:9092"},
-		Topics:              []string{},
-		ClientID:            "benthos_kafka_input",
-		ConsumerGroup:       "benthos_consumer_group",
-		Group:               NewKafkaBalancedGroupConfig(),
-		CommitPeriod:        "1s",
-		CheckpointLimit:     1,
-		MaxProcessingPeriod: "100ms",
-		FetchBufferCap:      256,
-		Topic:               "benthos_stream",
-		Partition:           0,
-		StartFromOldest:     true,
-		TargetVersion:       sarama.V1_0_0_0.String(),
-		MaxBatchCount:       1,
-		TLS:                 btls.NewConfig(),
-		SASL:                sasl.NewConfig(),
-		Batching:            batch.NewPolicyConfig(),
+		Addresses:               []string{"localhost:9092"},
+		Topics:                  []string{},
+		ClientID:                "benthos_kafka_input",
+		ConsumerGroup:           "benthos_consumer_group",
+		Group:                   NewKafkaBalancedGroupConfig(),
+		CommitPeriod:            "1s",
+		CheckpointLimit:         1,
+		MaxProcessingPeriod:     "100ms",
+		FetchBufferCap:          256,
+		Topic:                   "benthos_stream",
+		Partition:               0,
+		StartFromOldest:         true,
+		StartFromTimestamp:      "",
+		StartFromTimestampForce: false,
+		TargetVersion:           sarama.V1_0_0_0.String(),
+		MaxBatchCount:           1,
+		TLS:                     btls.NewConfig(),
+		SASL:                    sasl.NewConfig(),
+		Batching:                batch.NewPolicyConfig(),
 	}
 }
 
@@ -438,7 +442,7 @@ func (k *Kafka) commit() error {
 
 	commitReq := sarama.OffsetCommitRequest{}
 	commitReq.ConsumerGroup = k.conf.ConsumerGroup
-	commitReq.AddBlock(k.conf.Topic, k.conf.Partition, k.offset, 0, "")
+	commitReq.AddBlock(k.conf.Topic, k.conf.Partition, k.offset, 0, 0, "")
 
 	commitRes, err := coordinator.CommitOffset(&commitReq)
 	if err == nil {
@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
@@ -28,6 +30,8 @@ type KafkaBalancedGroupConfig struct {
 	SessionTimeout    string `json:"session_timeout" yaml:"session_timeout"`
 	HeartbeatInterval string `json:"heartbeat_interval" yaml:"heartbeat_interval"`
 	RebalanceTimeout  string `json:"rebalance_timeout" yaml:"rebalance_timeout"`
+	InstanceID        string `json:"instance_id" yaml:"instance_id"`
+	RebalanceStrategy string `json:"rebalance_strategy" yaml:"rebalance_strategy"`
 }
 
 // NewKafkaBalancedGroupConfig returns a KafkaBalancedGroupConfig with default
@@ -37,9 +41,57 @@ func NewKafkaBalancedGroupConfig() KafkaBalancedGroupConfig {
 		SessionTimeout:    "10s",
 		HeartbeatInterval: "3s",
 		RebalanceTimeout:  "60s",
+		InstanceID:        "",
+		RebalanceStrategy: "range",
 	}
 }
 
+// KafkaBalancedGroupFieldSpecs returns the field specs for the fields of a
+// consumer group config.
+func KafkaBalancedGroupFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldAdvanced("session_timeout", "A period after which a consumer of the group is kicked after no heartbeats."),
+		docs.FieldAdvanced("heartbeat_interval", "A period in which heartbeats should be sent out."),
+		docs.FieldAdvanced("rebalance_timeout", "A period after which rebalancing is abandoned if unresolved."),
+		docs.FieldAdvanced(
+			"instance_id",
+			"An optional identifier that enables static membership of the consumer group. A static member that restarts within the `session_timeout` reclaims its previous partition assignments without triggering a rebalance of the group, which avoids pausing consumption across all members during rolling restarts. Each member of the group must have a unique identifier that is stable across restarts, and static membership requires a `target_version` of at least `2.3.0`. This field is resolved each time the input connects. There is no default identifier as a hostname is not unique when multiple inputs of the same process, or multiple processes of the same host, consume from the same group, and members sharing an identifier fence each other out of the group.",
+			"${! hostname() }", "${! env(\"POD_NAME\") }",
+		).IsInterpolated().AtVersion("3.50.0"),
+		docs.FieldAdvanced(
+			"rebalance_strategy",
+			"The strategy used for assigning partitions to the members of the group. The `sticky` strategy preserves as many existing assignments as possible when the members of the group change. Since all strategies follow the eager rebalancing protocol every partition is revoked during a rebalance, and therefore the offsets of all partitions are committed rather than only those that were revoked.",
+		).HasOptions("range", "roundrobin", "sticky").AtVersion("3.50.0"),
+	}
+}
+
+// ApplyTo applies the static membership and rebalance strategy fields of the
+// consumer group config to a sarama config, which must have its version set.
+func (g KafkaBalancedGroupConfig) ApplyTo(config *sarama.Config) error {
+	if g.InstanceID != "" {
+		idField, err := bloblang.NewField(g.InstanceID)
+		if err != nil {
+			return fmt.Errorf("failed to parse instance_id expression: %v", err)
+		}
+		if !config.Version.IsAtLeast(sarama.V2_3_0_0) {
+			return fmt.Errorf("static membership requires a target_version of at least 2.3.0, got %v", config.Version)
+		}
+		config.Consumer.Group.InstanceId = idField.String(0, message.New(nil))
+	}
+
+	switch g.RebalanceStrategy {
+	case "range", "":
+		config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRange
+	case "roundrobin":
+		config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	case "sticky":
+		config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategySticky
+	default:
+		return fmt.Errorf("rebalance strategy not recognised: %v", g.RebalanceStrategy)
+	}
+	return nil
+}
+
 // KafkaBalancedConfig contains configuration for the KafkaBalanced input type.
 type KafkaBalancedConfig struct {
 	Addresses           []string                 `json:"addresses" yaml:"addresses"`
@@ -190,6 +242,9 @@ func NewKafkaBalanced(
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if err = conf.Group.ApplyTo(&sarama.Config{Version: k.version}); err != nil {
+		return nil, err
+	}
 	return &k, nil
 }
 
@@ -284,6 +339,9 @@ func (k *KafkaBalanced) Connect() error {
 	config.Consumer.Group.Heartbeat.Interval = k.heartbeatInterval
 	config.Consumer.Group.Rebalance.Timeout = k.rebalanceTimeout
 	config.ChannelBufferSize = k.conf.FetchBufferCap
+	if err := k.conf.Group.ApplyTo(config); err != nil {
+		return err
+	}
 
 	if config.Net.ReadTimeout <= k.sessionTimeout {
 		config.Net.ReadTimeout = k.sessionTimeout * 2
@@ -126,6 +126,9 @@ func NewKafkaCG(
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if err = conf.Group.ApplyTo(&sarama.Config{Version: k.version}); err != nil {
+		return nil, err
+	}
 	return &k, nil
 }
 
@@ -304,6 +307,9 @@ func (k *KafkaCG) ConnectWithContext(ctx context.Context) error {
 	config.Consumer.Group.Heartbeat.Interval = k.heartbeatInterval
 	config.Consumer.Group.Rebalance.Timeout = k.rebalanceTimeout
 	config.ChannelBufferSize = k.conf.FetchBufferCap
+	if err := k.conf.Group.ApplyTo(config); err != nil {
+		return err
+	}
 
 	if config.Net.ReadTimeout <= k.sessionTimeout {
 		config.Net.ReadTimeout = k.sessionTimeout * 2
@@ -1,8 +1,10 @@
 package reader
 
 import (
+	"os"
 	"testing"
 
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -60,3 +62,69 @@ key: fookey`,
 		})
 	}
 }
+
+func TestKafkaGroupApplyTo(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	conf := NewKafkaBalancedGroupConfig()
+	conf.InstanceID = `${! hostname() }-foo`
+	conf.RebalanceStrategy = "sticky"
+
+	sConf := sarama.NewConfig()
+	sConf.Version = sarama.V2_3_0_0
+	require.NoError(t, conf.ApplyTo(sConf))
+	assert.Equal(t, hostname+"-foo", sConf.Consumer.Group.InstanceId)
+	assert.Equal(t, sarama.BalanceStrategySticky, sConf.Consumer.Group.Rebalance.Strategy)
+	require.NoError(t, sConf.Validate())
+
+	sConf = sarama.NewConfig()
+	sConf.Version = sarama.V2_3_0_0
+	require.NoError(t, NewKafkaBalancedGroupConfig().ApplyTo(sConf))
+	assert.Equal(t, "", sConf.Consumer.Group.InstanceId)
+	assert.Equal(t, sarama.BalanceStrategyRange, sConf.Consumer.Group.Rebalance.Strategy)
+}
+
+func TestKafkaGroupApplyToErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  sarama.KafkaVersion
+		id       string
+		strategy string
+		errStr   string
+	}{
+		{
+			name:     "static membership old version",
+			version:  sarama.V1_0_0_0,
+			id:       "foo",
+			strategy: "range",
+			errStr:   "static membership requires a target_version of at least 2.3.0, got 1.0.0",
+		},
+		{
+			name:     "bad instance id",
+			version:  sarama.V2_3_0_0,
+			id:       "${! nope( }",
+			strategy: "range",
+			errStr:   "failed to parse instance_id expression",
+		},
+		{
+			name:     "unknown strategy",
+			version:  sarama.V2_3_0_0,
+			strategy: "nope",
+			errStr:   "rebalance strategy not recognised: nope",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewKafkaBalancedGroupConfig()
+			conf.InstanceID = test.id
+			conf.RebalanceStrategy = test.strategy
+
+			err := conf.ApplyTo(&sarama.Config{Version: test.version})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+}
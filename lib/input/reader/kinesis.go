@@ -11,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -212,13 +213,15 @@ func (k *Kinesis) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := k.conf.GetSession()
+	sess, err := k.conf.GetSessionWithFailover(sess.LogFailover(k.log, k.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	k.dynamo = dynamodb.New(sess)
+	instrument.Register(&k.dynamo.Handlers, k.stats)
 	k.kinesis = kinesis.New(sess)
+	instrument.Register(&k.kinesis.Handlers, k.stats)
 	k.session = sess
 
 	if err = k.getIter(); err != nil {
@@ -76,7 +76,7 @@ func NewKinesisBalanced(
 		stats:   stats,
 		records: records,
 	}
-	sess, err := conf.GetSession()
+	sess, err := conf.GetSessionWithFailover(sess.LogFailover(log, stats.GetCounter("failover")))
 	if err != nil {
 		return nil, err
 	}
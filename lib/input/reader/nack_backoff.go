@@ -0,0 +1,73 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// NackBackoffConfig contains configuration fields for delaying the rejection
+// of messages that could not be delivered downstream, which prevents inputs
+// that redeliver rejected messages immediately from entering hot retry loops.
+type NackBackoffConfig struct {
+	Enabled         bool    `json:"enabled" yaml:"enabled"`
+	InitialInterval string  `json:"initial_interval" yaml:"initial_interval"`
+	MaxInterval     string  `json:"max_interval" yaml:"max_interval"`
+	Multiplier      float64 `json:"multiplier" yaml:"multiplier"`
+}
+
+// NewNackBackoffConfig creates a new NackBackoffConfig with default values.
+func NewNackBackoffConfig() NackBackoffConfig {
+	return NackBackoffConfig{
+		Enabled:         false,
+		InitialInterval: "1s",
+		MaxInterval:     "1m",
+		Multiplier:      2,
+	}
+}
+
+// NackBackoffFieldSpec returns a field spec for a nack backoff config.
+func NackBackoffFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"nack_backoff", "Optionally delay the rejection of messages that fail to be delivered downstream, which prevents messages that are redelivered immediately from being retried in a hot loop. The delay grows exponentially with each consecutive rejection and is reset when a message is successfully delivered. Other messages continue to be consumed while rejections are delayed.",
+	).WithChildren(
+		docs.FieldCommon("enabled", "Whether to delay rejections."),
+		docs.FieldCommon("initial_interval", "The delay applied to the first of consecutive rejections.", "500ms", "1s"),
+		docs.FieldCommon("max_interval", "The maximum delay applied to a rejection.", "30s", "5m"),
+		docs.FieldAdvanced("multiplier", "The factor by which the delay is multiplied for each consecutive rejection.").HasType(docs.FieldTypeFloat),
+	).AtVersion("3.50.0")
+}
+
+// NewBackOff returns a backoff constructed from the config, or nil if nack
+// backoffs are disabled.
+func (n NackBackoffConfig) NewBackOff() (backoff.BackOff, error) {
+	if !n.Enabled {
+		return nil, nil
+	}
+	initial, err := time.ParseDuration(n.InitialInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nack_backoff initial_interval: %w", err)
+	}
+	max, err := time.ParseDuration(n.MaxInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nack_backoff max_interval: %w", err)
+	}
+	if n.Multiplier < 1 {
+		return nil, errors.New("nack_backoff multiplier must be at least 1")
+	}
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = initial
+	boff.MaxInterval = max
+	boff.Multiplier = n.Multiplier
+	boff.RandomizationFactor = 0
+	boff.MaxElapsedTime = 0
+	boff.Reset()
+	return boff, nil
+}
+
+//------------------------------------------------------------------------------
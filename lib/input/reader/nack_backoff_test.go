@@ -0,0 +1,51 @@
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNackBackoffConfig(t *testing.T) {
+	conf := NewNackBackoffConfig()
+
+	boff, err := conf.NewBackOff()
+	require.NoError(t, err)
+	assert.Nil(t, boff)
+
+	conf.Enabled = true
+	conf.InitialInterval = "1s"
+	conf.MaxInterval = "3s"
+	conf.Multiplier = 2
+
+	boff, err = conf.NewBackOff()
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, boff.NextBackOff())
+	assert.Equal(t, time.Second*2, boff.NextBackOff())
+	assert.Equal(t, time.Second*3, boff.NextBackOff())
+	assert.Equal(t, time.Second*3, boff.NextBackOff())
+
+	boff.Reset()
+	assert.Equal(t, time.Second, boff.NextBackOff())
+}
+
+func TestNackBackoffConfigErrors(t *testing.T) {
+	conf := NewNackBackoffConfig()
+	conf.Enabled = true
+
+	conf.InitialInterval = "nope"
+	_, err := conf.NewBackOff()
+	require.Error(t, err)
+
+	conf.InitialInterval = "1s"
+	conf.MaxInterval = "nope"
+	_, err = conf.NewBackOff()
+	require.Error(t, err)
+
+	conf.MaxInterval = "1m"
+	conf.Multiplier = 0.5
+	_, err = conf.NewBackOff()
+	require.Error(t, err)
+}
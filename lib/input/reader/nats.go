@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -21,11 +22,13 @@ import (
 
 // NATSConfig contains configuration fields for the NATS input type.
 type NATSConfig struct {
-	URLs          []string    `json:"urls" yaml:"urls"`
-	Subject       string      `json:"subject" yaml:"subject"`
-	QueueID       string      `json:"queue" yaml:"queue"`
-	PrefetchCount int         `json:"prefetch_count" yaml:"prefetch_count"`
-	TLS           btls.Config `json:"tls" yaml:"tls"`
+	URLs          []string          `json:"urls" yaml:"urls"`
+	Subject       string            `json:"subject" yaml:"subject"`
+	QueueID       string            `json:"queue" yaml:"queue"`
+	PrefetchCount int               `json:"prefetch_count" yaml:"prefetch_count"`
+	TLS           btls.Config       `json:"tls" yaml:"tls"`
+	Auth          auth.Config       `json:"auth" yaml:"auth"`
+	NackBackoff   NackBackoffConfig `json:"nack_backoff" yaml:"nack_backoff"`
 }
 
 // NewNATSConfig creates a new NATSConfig with default values.
@@ -36,6 +39,8 @@ func NewNATSConfig() NATSConfig {
 		QueueID:       "benthos_queue",
 		PrefetchCount: 32,
 		TLS:           btls.NewConfig(),
+		Auth:          auth.NewConfig(),
+		NackBackoff:   NewNackBackoffConfig(),
 	}
 }
 
@@ -55,6 +60,7 @@ type NATS struct {
 	natsChan      chan *nats.Msg
 	interruptChan chan struct{}
 	tlsConf       *tls.Config
+	authOpts      []nats.Option
 }
 
 // NewNATS creates a new NATS input type.
@@ -75,6 +81,9 @@ func NewNATS(conf NATSConfig, log log.Modular, stats metrics.Type) (*NATS, error
 			return nil, err
 		}
 	}
+	if n.authOpts, err = conf.Auth.Options(); err != nil {
+		return nil, err
+	}
 
 	return &n, nil
 }
@@ -103,6 +112,7 @@ func (n *NATS) ConnectWithContext(ctx context.Context) error {
 	if n.tlsConf != nil {
 		opts = append(opts, nats.Secure(n.tlsConf))
 	}
+	opts = append(opts, n.authOpts...)
 
 	if natsConn, err = nats.Connect(n.urls, opts...); err != nil {
 		return err
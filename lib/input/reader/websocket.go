@@ -2,16 +2,23 @@ package reader
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/gorilla/websocket"
 )
 
@@ -19,17 +26,33 @@ import (
 
 // WebsocketConfig contains configuration fields for the Websocket input type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	OpenMsg     string `json:"open_message" yaml:"open_message"`
-	auth.Config `json:",inline" yaml:",inline"`
+	URL                  string               `json:"url" yaml:"url"`
+	OpenMsg              string               `json:"open_message" yaml:"open_message"`
+	Resume               string               `json:"resume" yaml:"resume"`
+	MaxReconnectAttempts int                  `json:"max_reconnect_attempts" yaml:"max_reconnect_attempts"`
+	ReconnectBackoff     retries.Backoff      `json:"reconnect_backoff" yaml:"reconnect_backoff"`
+	ProxyURL             string               `json:"proxy_url" yaml:"proxy_url"`
+	ProxyBasicAuth       auth.BasicAuthConfig `json:"proxy_basic_auth" yaml:"proxy_basic_auth"`
+	NoProxy              []string             `json:"no_proxy" yaml:"no_proxy"`
+	auth.Config          `json:",inline" yaml:",inline"`
 }
 
 // NewWebsocketConfig creates a new WebsocketConfig with default values.
 func NewWebsocketConfig() WebsocketConfig {
 	return WebsocketConfig{
-		URL:     "ws://localhost:4195/get/ws",
-		OpenMsg: "",
-		Config:  auth.NewConfig(),
+		URL:                  "ws://localhost:4195/get/ws",
+		OpenMsg:              "",
+		Resume:               "",
+		MaxReconnectAttempts: 0,
+		ReconnectBackoff: retries.Backoff{
+			InitialInterval: "1s",
+			MaxInterval:     "30s",
+			MaxElapsedTime:  "0s",
+		},
+		ProxyURL:       "",
+		ProxyBasicAuth: auth.NewBasicAuthConfig(),
+		NoProxy:        []string{},
+		Config:         auth.NewConfig(),
 	}
 }
 
@@ -44,6 +67,18 @@ type Websocket struct {
 
 	conf   WebsocketConfig
 	client *websocket.Conn
+	dialer *websocket.Dialer
+
+	resume            *mapping.Executor
+	reconnectBoff     backoff.BackOff
+	attempted         bool
+	reconnectAttempts int
+
+	readSeq   uint64
+	ackedSeq  uint64
+	lastAcked types.Message
+
+	mErrProxy metrics.StatCounter
 }
 
 // NewWebsocket creates a new Websocket input type.
@@ -53,10 +88,29 @@ func NewWebsocket(
 	stats metrics.Type,
 ) (*Websocket, error) {
 	ws := &Websocket{
-		log:   log,
-		stats: stats,
-		lock:  &sync.Mutex{},
-		conf:  conf,
+		log:       log,
+		stats:     stats,
+		lock:      &sync.Mutex{},
+		conf:      conf,
+		mErrProxy: stats.GetCounter("error.proxy"),
+	}
+	var err error
+	if ws.dialer, err = proxy.WebsocketDialer(proxy.Config{
+		URL:       conf.ProxyURL,
+		BasicAuth: conf.ProxyBasicAuth,
+		NoProxy:   conf.NoProxy,
+	}); err != nil {
+		return nil, err
+	}
+	if conf.Resume != "" {
+		if ws.resume, err = bloblang.NewMapping("", conf.Resume); err != nil {
+			return nil, fmt.Errorf("failed to parse resume mapping: %w", err)
+		}
+	}
+	boffConf := retries.NewConfig()
+	boffConf.Backoff = conf.ReconnectBackoff
+	if ws.reconnectBoff, err = boffConf.Get(); err != nil {
+		return nil, err
 	}
 	return ws, nil
 }
@@ -79,16 +133,34 @@ func (w *Websocket) Connect() error {
 
 // ConnectWithContext establishes a connection to a Websocket server.
 func (w *Websocket) ConnectWithContext(ctx context.Context) error {
+	delay, err := w.reconnectDelay()
+	if err != nil || delay < 0 {
+		return err
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return types.ErrTimeout
+		}
+	}
+
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
 	if w.client != nil {
 		return nil
 	}
+	w.attempted = true
+
+	urlStr, openMsg, err := w.connectTarget()
+	if err != nil {
+		return err
+	}
 
 	headers := http.Header{}
 
-	purl, err := url.Parse(w.conf.URL)
+	purl, err := url.Parse(urlStr)
 	if err != nil {
 		return err
 	}
@@ -101,22 +173,101 @@ func (w *Websocket) ConnectWithContext(ctx context.Context) error {
 	}
 
 	var client *websocket.Conn
-	if client, _, err = websocket.DefaultDialer.Dial(w.conf.URL, headers); err != nil {
+	if client, _, err = w.dialer.Dial(urlStr, headers); err != nil {
+		if proxy.IsError(err) {
+			w.mErrProxy.Incr(1)
+		}
 		return err
 	}
 
-	if len(w.conf.OpenMsg) > 0 {
+	if len(openMsg) > 0 {
 		if err := client.WriteMessage(
-			websocket.BinaryMessage, []byte(w.conf.OpenMsg),
+			websocket.BinaryMessage, openMsg,
 		); err != nil {
+			client.Close()
 			return err
 		}
 	}
 
+	w.reconnectAttempts = 0
+	w.reconnectBoff.Reset()
 	w.client = client
 	return nil
 }
 
+// reconnectDelay returns the period to wait before attempting a connection,
+// or a negative duration if already connected.
+func (w *Websocket) reconnectDelay() (time.Duration, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.client != nil {
+		return -1, nil
+	}
+	if !w.attempted {
+		return 0, nil
+	}
+	if w.conf.MaxReconnectAttempts > 0 && w.reconnectAttempts >= w.conf.MaxReconnectAttempts {
+		w.log.Errorf("Failed to reconnect after %v attempts, shutting down\n", w.reconnectAttempts)
+		return 0, types.ErrTypeClosed
+	}
+	delay := w.reconnectBoff.NextBackOff()
+	if delay == backoff.Stop {
+		w.log.Errorln("Reconnection backoff exhausted, shutting down")
+		return 0, types.ErrTypeClosed
+	}
+	w.reconnectAttempts++
+	return delay, nil
+}
+
+// connectTarget returns the URL to connect to and the open message to send,
+// which are derived from the resume mapping when a message has been
+// acknowledged. Must be called whilst holding the lock.
+func (w *Websocket) connectTarget() (string, []byte, error) {
+	urlStr, openMsg := w.conf.URL, []byte(w.conf.OpenMsg)
+	if w.resume == nil || w.lastAcked == nil {
+		return urlStr, openMsg, nil
+	}
+
+	resPart, err := w.resume.MapPart(0, w.lastAcked)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to execute resume mapping: %w", err)
+	}
+	if resPart == nil {
+		return urlStr, openMsg, nil
+	}
+
+	v, err := resPart.JSON()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse resume mapping result: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", nil, query.NewTypeError(v, query.ValueObject)
+	}
+
+	if qParams, exists := obj["query_params"]; exists {
+		qObj, ok := qParams.(map[string]interface{})
+		if !ok {
+			return "", nil, query.NewTypeErrorFrom("query_params", qParams, query.ValueObject)
+		}
+		purl, err := url.Parse(urlStr)
+		if err != nil {
+			return "", nil, err
+		}
+		values := purl.Query()
+		for k, v := range qObj {
+			values.Set(k, query.IToString(v))
+		}
+		purl.RawQuery = values.Encode()
+		urlStr = purl.String()
+	}
+	if msg, exists := obj["open_message"]; exists {
+		openMsg = query.IToBytes(msg)
+	}
+	return urlStr, openMsg, nil
+}
+
 //------------------------------------------------------------------------------
 
 // Read attempts to read a new message from the websocket.
@@ -141,7 +292,31 @@ func (w *Websocket) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 		return nil, nil, err
 	}
 
-	return message.New([][]byte{data}), noopAsyncAckFn, nil
+	msg := message.New([][]byte{data})
+	if w.resume == nil {
+		return msg, noopAsyncAckFn, nil
+	}
+
+	w.lock.Lock()
+	w.readSeq++
+	seq := w.readSeq
+	w.lock.Unlock()
+
+	// Keep a snapshot of the message as it was read, as only the most
+	// recently read message that has been acknowledged is used for resuming.
+	snapshot := msg.DeepCopy()
+	return msg, func(ctx context.Context, res types.Response) error {
+		if res.Error() != nil {
+			return nil
+		}
+		w.lock.Lock()
+		if seq > w.ackedSeq {
+			w.ackedSeq = seq
+			w.lastAcked = snapshot
+		}
+		w.lock.Unlock()
+		return nil
+	}, nil
 }
 
 // Acknowledge instructs whether the pending messages were propagated
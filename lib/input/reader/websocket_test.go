@@ -1,6 +1,8 @@
 package reader
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,8 +12,11 @@ import (
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWebsocketBasic(t *testing.T) {
@@ -192,3 +197,106 @@ func TestWebsocketClose(t *testing.T) {
 	wg.Wait()
 	close(closeChan)
 }
+
+func TestWebsocketResume(t *testing.T) {
+	type connDetails struct {
+		query   string
+		openMsg string
+	}
+	connChan := make(chan connDetails, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		_, openMsg, err := ws.ReadMessage()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		connChan <- connDetails{query: r.URL.RawQuery, openMsg: string(openMsg)}
+
+		for _, msg := range []string{`{"seq":1}`, `{"seq":2}`, `{"seq":3}`} {
+			if err = ws.WriteMessage(websocket.BinaryMessage, []byte(msg)); err != nil {
+				t.Error(err)
+			}
+		}
+	}))
+	defer server.Close()
+
+	conf := NewWebsocketConfig()
+	wsURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	wsURL.Scheme = "ws"
+	wsURL.RawQuery = "foo=bar"
+	conf.URL = wsURL.String()
+	conf.OpenMsg = "hello"
+	conf.Resume = `root.query_params.since = this.seq
+root.open_message = "resume from %v".format(this.seq)`
+	conf.ReconnectBackoff.InitialInterval = "1ms"
+
+	m, err := NewWebsocket(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, m.ConnectWithContext(context.Background()))
+	assert.Equal(t, connDetails{query: "foo=bar", openMsg: "hello"}, <-connChan)
+
+	var ackFns []AsyncAckFn
+	for _, exp := range []string{`{"seq":1}`, `{"seq":2}`, `{"seq":3}`} {
+		msg, ackFn, err := m.ReadWithContext(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(msg.Get(0).Get()))
+		ackFns = append(ackFns, ackFn)
+	}
+
+	_, _, err = m.ReadWithContext(context.Background())
+	require.Equal(t, types.ErrNotConnected, err)
+
+	// Acks out of order, the rejection of the last message means we resume
+	// from the second.
+	require.NoError(t, ackFns[1](context.Background(), response.NewAck()))
+	require.NoError(t, ackFns[0](context.Background(), response.NewAck()))
+	require.NoError(t, ackFns[2](context.Background(), response.NewError(errors.New("nope"))))
+
+	require.NoError(t, m.ConnectWithContext(context.Background()))
+	assert.Equal(t, connDetails{query: "foo=bar&since=2", openMsg: "resume from 2"}, <-connChan)
+
+	m.CloseAsync()
+	require.NoError(t, m.WaitForClose(time.Second))
+}
+
+func TestWebsocketMaxReconnectAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	wsURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	server.Close()
+
+	conf := NewWebsocketConfig()
+	wsURL.Scheme = "ws"
+	conf.URL = wsURL.String()
+	conf.MaxReconnectAttempts = 2
+	conf.ReconnectBackoff.InitialInterval = "1ms"
+
+	m, err := NewWebsocket(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = m.ConnectWithContext(context.Background())
+		require.Error(t, err)
+		require.NotEqual(t, types.ErrTypeClosed, err)
+	}
+	require.Equal(t, types.ErrTypeClosed, m.ConnectWithContext(context.Background()))
+}
+
+func TestWebsocketBadResume(t *testing.T) {
+	conf := NewWebsocketConfig()
+	conf.Resume = `root = this.`
+
+	_, err := NewWebsocket(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package input
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -57,6 +58,10 @@ input_resources:
       subscription: baz
  ` + "```" + `
 
+### Multiple References
+
+When an input resource is referenced more than once, for example by multiple streams, each reference receives every message consumed by the input resource. Each reference acknowledges its copy of a message independently, and the message is only acknowledged at the source once all references have successfully processed it, meaning the slowest reference applies back pressure. If the input resource is replaced at runtime then each reference switches over to the new input.
+
 You can find out more about resources [in this document.](/docs/configuration/resources)`,
 		Categories: []Category{
 			CategoryUtility,
@@ -73,6 +78,12 @@ type Resource struct {
 	name         string
 	log          log.Modular
 	mErrNotFound metrics.StatCounter
+
+	subOnce     sync.Once
+	tChan       <-chan types.Transaction
+	subscribed  bool
+	unsubscribe func()
+	closeOnce   sync.Once
 }
 
 // NewResource returns a resource input.
@@ -87,14 +98,34 @@ func NewResource(
 		name:         conf.Resource,
 		log:          log,
 		mErrNotFound: stats.GetCounter("error_not_found"),
+		unsubscribe:  func() {},
 	}, nil
 }
 
 //------------------------------------------------------------------------------
 
 // TransactionChan returns a transactions channel for consuming messages from
-// this input type.
+// this input type. The input resource is subscribed to the first time this is
+// called, and each resource input referencing the same input resource receives
+// every message that it consumes.
 func (r *Resource) TransactionChan() (tChan <-chan types.Transaction) {
+	r.subOnce.Do(func() {
+		tChan, unsub, err := interop.SubscribeInput(context.Background(), r.mgr, r.name)
+		if err != nil {
+			if err != interop.ErrInputSubscriptionsUnsupported {
+				r.log.Debugf("Failed to obtain input resource '%v': %v", r.name, err)
+				r.mErrNotFound.Incr(1)
+			}
+			return
+		}
+		r.tChan, r.unsubscribe, r.subscribed = tChan, unsub, true
+	})
+	if r.subscribed {
+		return r.tChan
+	}
+
+	// Managers that do not support subscriptions share the messages of the
+	// input resource between each reference to it.
 	if err := interop.AccessInput(context.Background(), r.mgr, r.name, func(i types.Input) {
 		tChan = i.TransactionChan()
 	}); err != nil {
@@ -116,8 +147,14 @@ func (r *Resource) Connected() (isConnected bool) {
 	return
 }
 
-// CloseAsync shuts down the processor and stops processing requests.
+// CloseAsync cancels the subscription to the input resource, the input resource
+// itself remains open.
 func (r *Resource) CloseAsync() {
+	r.closeOnce.Do(func() {
+		// Prevent a subscription from being created after closing.
+		r.subOnce.Do(func() {})
+		r.unsubscribe()
+	})
 }
 
 // WaitForClose blocks until the processor has closed down.
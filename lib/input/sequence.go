@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,7 +29,21 @@ Reads messages from a sequence of child inputs, starting with the first and once
 that input gracefully terminates starts consuming from the next, and so on.`,
 		Description: `
 This input is useful for consuming from inputs that have an explicit end but
-must not be consumed in parallel.`,
+must not be consumed in parallel.
+
+### Progress
+
+Each message is given the metadata field ` + "`sequence_index`" + `, which is the
+index of the child input that it was read from. The index of the active child is
+also reported with the gauge ` + "`active_index`" + `, and the number of messages
+read from each child with the counter ` + "`received`" + ` labelled by ` + "`index`" + `.
+
+### Checkpoints
+
+When a checkpoint cache is configured the number of child inputs that have
+completed is stored in the cache, and when the sequence is restarted those child
+inputs are skipped. A child input is only considered complete once it has
+terminated and all of its messages have been acknowledged.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title:   "End of Stream Message",
@@ -154,6 +169,13 @@ Each message must be structured (JSON or otherwise processed into a structured f
 					"The chosen strategy to use when a data join would otherwise result in a collision of field values. The strategy `array` means non-array colliding values are placed into an array and colliding arrays are merged. The strategy `replace` replaces old values with new values. The strategy `keep` keeps the old value.",
 				).HasOptions("array", "replace", "keep"),
 			).AtVersion("3.40.0"),
+			docs.FieldAdvanced(
+				"checkpoint",
+				"Optionally store the progress of the sequence in a [cache resource](/docs/components/caches/about), allowing a restarted sequence to skip child inputs that had already completed. Checkpoints cannot be combined with sharded joins.",
+			).WithChildren(
+				docs.FieldCommon("cache", "A cache resource to store the checkpoint in, when empty checkpoints are disabled."),
+				docs.FieldCommon("key", "The key under which the checkpoint is stored, which must be unique for each sequence that shares the cache."),
+			).AtVersion("3.50.0"),
 			docs.FieldCommon("inputs", "An array of inputs to read from sequentially.").Array().HasType(docs.FieldTypeInput),
 		},
 		Categories: []Category{
@@ -227,9 +249,27 @@ func (s SequenceShardedJoinConfig) validate() (*messageJoiner, error) {
 	}, nil
 }
 
+// SequenceCheckpointConfig describes an optional cache resource in which the
+// number of completed child inputs of a sequence is stored, allowing a
+// restarted sequence to skip them.
+type SequenceCheckpointConfig struct {
+	Cache string `json:"cache" yaml:"cache"`
+	Key   string `json:"key" yaml:"key"`
+}
+
+// NewSequenceCheckpointConfig creates a new sequence checkpoint configuration
+// with default values.
+func NewSequenceCheckpointConfig() SequenceCheckpointConfig {
+	return SequenceCheckpointConfig{
+		Cache: "",
+		Key:   "benthos_sequence_checkpoint",
+	}
+}
+
 // SequenceConfig contains configuration values for the Sequence input type.
 type SequenceConfig struct {
 	ShardedJoin SequenceShardedJoinConfig `json:"sharded_join" yaml:"sharded_join"`
+	Checkpoint  SequenceCheckpointConfig  `json:"checkpoint" yaml:"checkpoint"`
 	Inputs      []Config                  `json:"inputs" yaml:"inputs"`
 }
 
@@ -237,6 +277,7 @@ type SequenceConfig struct {
 func NewSequenceConfig() SequenceConfig {
 	return SequenceConfig{
 		ShardedJoin: NewSequenceShardedJoinConfig(),
+		Checkpoint:  NewSequenceCheckpointConfig(),
 		Inputs:      []Config{},
 	}
 }
@@ -377,10 +418,11 @@ func (m *messageJoiner) Empty(fn func(types.Message)) bool {
 type Sequence struct {
 	conf SequenceConfig
 
-	targetMut sync.Mutex
-	target    Type
-	remaining []sequenceTarget
-	spent     []sequenceTarget
+	targetMut   sync.Mutex
+	target      Type
+	targetIndex int
+	remaining   []sequenceTarget
+	spent       []sequenceTarget
 
 	joiner *messageJoiner
 
@@ -391,6 +433,9 @@ type Sequence struct {
 	stats metrics.Type
 	log   log.Modular
 
+	mActiveIndex metrics.StatGauge
+	mReceived    metrics.StatCounterVec
+
 	transactions chan types.Transaction
 
 	ctx        context.Context
@@ -434,6 +479,8 @@ func NewSequence(
 
 		log:          rLog,
 		stats:        rStats,
+		mActiveIndex: rStats.GetGauge("active_index"),
+		mReceived:    rStats.GetCounterVec("received", []string{"index"}),
 		transactions: make(chan types.Transaction),
 		closedChan:   make(chan struct{}),
 	}
@@ -444,10 +491,29 @@ func NewSequence(
 		return nil, fmt.Errorf("invalid sharded join config: %w", err)
 	}
 
-	if target, _, err := rdr.createNextTarget(); err != nil {
-		return nil, err
-	} else if target == nil {
-		return nil, errors.New("failed to initialize first input")
+	if rdr.conf.Checkpoint.Cache != "" {
+		if rdr.joiner != nil {
+			return nil, errors.New("checkpoints cannot be combined with sharded joins")
+		}
+		completed, err := rdr.readCheckpoint()
+		if err != nil {
+			return nil, err
+		}
+		if completed > len(rdr.remaining) {
+			completed = len(rdr.remaining)
+		}
+		if completed > 0 {
+			rdr.log.Infof("Skipping %v completed sequence inputs according to checkpoint.\n", completed)
+			rdr.remaining = rdr.remaining[completed:]
+		}
+	}
+
+	if len(rdr.remaining) > 0 {
+		if target, _, err := rdr.createNextTarget(); err != nil {
+			return nil, err
+		} else if target == nil {
+			return nil, errors.New("failed to initialize first input")
+		}
 	}
 
 	go rdr.loop()
@@ -456,6 +522,42 @@ func NewSequence(
 
 //------------------------------------------------------------------------------
 
+func (r *Sequence) readCheckpoint() (int, error) {
+	if err := interop.ProbeCache(context.Background(), r.wrapperMgr, r.conf.Checkpoint.Cache); err != nil {
+		return 0, err
+	}
+	var value []byte
+	var err error
+	if cerr := interop.AccessCache(context.Background(), r.wrapperMgr, r.conf.Checkpoint.Cache, func(c types.Cache) {
+		value, err = c.Get(r.conf.Checkpoint.Key)
+	}); cerr != nil {
+		return 0, fmt.Errorf("failed to access checkpoint cache: %w", cerr)
+	}
+	if err != nil {
+		if errors.Is(err, types.ErrKeyNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	completed, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return completed, nil
+}
+
+func (r *Sequence) writeCheckpoint(completed int) {
+	var err error
+	if cerr := interop.AccessCache(r.ctx, r.wrapperMgr, r.conf.Checkpoint.Cache, func(c types.Cache) {
+		err = c.Set(r.conf.Checkpoint.Key, []byte(strconv.Itoa(completed)))
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		r.log.Errorf("Failed to write sequence checkpoint: %v\n", err)
+	}
+}
+
 func (r *Sequence) getTarget() (Type, bool) {
 	r.targetMut.Lock()
 	target := r.target
@@ -484,8 +586,10 @@ func (r *Sequence) createNextTarget() (Type, bool, error) {
 		}
 	}
 	if target != nil {
-		r.log.Debugf("Initialized sequence input %v.", len(r.spent)-1)
+		r.targetIndex = r.spent[len(r.spent)-1].index
+		r.log.Debugf("Initialized sequence input %v.", r.targetIndex)
 		r.target = target
+		r.mActiveIndex.Set(int64(r.targetIndex))
 	}
 	final := len(r.remaining) == 0
 	r.targetMut.Unlock()
@@ -550,6 +654,7 @@ func (r *Sequence) loop() {
 	}()
 
 	target, finalInSequence := r.getTarget()
+	var targetIndex string
 
 runLoop:
 	for {
@@ -565,6 +670,11 @@ runLoop:
 				continue runLoop
 			}
 		}
+		if target != nil {
+			r.targetMut.Lock()
+			targetIndex = strconv.Itoa(r.targetIndex)
+			r.targetMut.Unlock()
+		}
 		if target == nil {
 			if r.joiner != nil {
 				iteration, _ := r.joiner.GetIteration()
@@ -596,11 +706,18 @@ runLoop:
 			if !open {
 				target.CloseAsync() // For good measure.
 				target = nil
+				if r.conf.Checkpoint.Cache != "" {
+					r.targetMut.Lock()
+					completed := r.targetIndex + 1
+					r.targetMut.Unlock()
+					r.writeCheckpoint(completed)
+				}
 				continue runLoop
 			}
 		case <-r.ctx.Done():
 			return
 		}
+		r.mReceived.With(targetIndex).Incr(int64(tran.Payload.Len()))
 
 		if r.joiner != nil {
 			r.joiner.Add(tran.Payload.DeepCopy(), finalInSequence, func(msg types.Message) {
@@ -612,6 +729,10 @@ runLoop:
 				return
 			}
 		} else {
+			tran.Payload.Iter(func(i int, p types.Part) error {
+				p.Metadata().Set("sequence_index", targetIndex)
+				return nil
+			})
 			select {
 			case r.transactions <- tran:
 			case <-r.ctx.Done():
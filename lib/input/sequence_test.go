@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
@@ -83,6 +84,113 @@ consumeLoop:
 	assert.NoError(t, rdr.WaitForClose(time.Second))
 }
 
+type fakeCacheMgr struct {
+	fakeProcMgr
+	caches map[string]types.Cache
+}
+
+func (f *fakeCacheMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := f.caches[name]; exists {
+		return c, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func TestSequenceCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "benthos_sequence_checkpoint_test")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	writeFiles(t, tmpDir, map[string]string{
+		"f1": "foo\nbar",
+		"f2": "baz\nbuz",
+		"f3": "bev\nqux",
+	})
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, memCache.Set("progress", []byte("1")))
+
+	mgr := &fakeCacheMgr{
+		caches: map[string]types.Cache{"foo": memCache},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.Checkpoint.Cache = "foo"
+	conf.Sequence.Checkpoint.Key = "progress"
+
+	for _, k := range []string{"f1", "f2", "f3"} {
+		inConf := NewConfig()
+		inConf.Type = TypeFile
+		inConf.File.Path = filepath.Join(tmpDir, k)
+		conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+	}
+
+	rdr, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	exp, act := []string{
+		"baz:1", "buz:1", "bev:2", "qux:2",
+	}, []string{}
+
+consumeLoop:
+	for {
+		select {
+		case tran, open := <-rdr.TransactionChan():
+			if !open {
+				break consumeLoop
+			}
+			p := tran.Payload.Get(0)
+			act = append(act, string(p.Get())+":"+p.Metadata().Get("sequence_index"))
+			select {
+			case tran.ResponseChan <- response.NewAck():
+			case <-time.After(time.Minute):
+				t.Fatalf("failed to ack after: %v", act)
+			}
+		case <-time.After(time.Minute):
+			t.Fatalf("Failed to consume message after: %v", act)
+		}
+	}
+
+	assert.Equal(t, exp, act)
+	assert.NoError(t, rdr.WaitForClose(time.Second))
+
+	progress, err := memCache.Get("progress")
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(progress))
+
+	// All inputs are now complete and therefore a restart reads nothing.
+	rdr, err = New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	select {
+	case _, open := <-rdr.TransactionChan():
+		assert.False(t, open)
+	case <-time.After(time.Minute):
+		t.Fatal("timed out")
+	}
+	assert.NoError(t, rdr.WaitForClose(time.Second))
+}
+
+func TestSequenceCheckpointWithJoin(t *testing.T) {
+	t.Parallel()
+
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.Checkpoint.Cache = "foo"
+	conf.Sequence.ShardedJoin.IDPath = "id"
+	conf.Sequence.ShardedJoin.Type = "full-outter"
+	conf.Sequence.Inputs = append(conf.Sequence.Inputs, NewConfig())
+
+	_, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checkpoints cannot be combined with sharded joins")
+}
+
 func TestSequenceJoins(t *testing.T) {
 	t.Parallel()
 
@@ -37,6 +37,7 @@ This input adds the following metadata fields to each message:
 - sqs_message_id
 - sqs_receipt_handle
 - sqs_approximate_receive_count
+- sqs_sent_timestamp_unix
 - All message attributes
 ` + "```" + `
 
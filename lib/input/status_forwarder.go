@@ -0,0 +1,50 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/component/input"
+	"github.com/Jeffail/benthos/v3/lib/broker"
+)
+
+//------------------------------------------------------------------------------
+
+// StatusForwarder implements the status reporting interfaces of inputs by
+// forwarding them to a child input. It is intended to be embedded within
+// components that wrap an input so that the statuses of the child are not
+// hidden by the wrapper.
+type StatusForwarder struct {
+	child func() Type
+}
+
+// NewStatusForwarder returns a StatusForwarder for a child input.
+func NewStatusForwarder(child Type) StatusForwarder {
+	return StatusForwarder{
+		child: func() Type { return child },
+	}
+}
+
+// NewDynamicStatusForwarder returns a StatusForwarder for a child input that
+// may be replaced over time, where the provided function returns the current
+// child.
+func NewDynamicStatusForwarder(child func() Type) StatusForwarder {
+	return StatusForwarder{child: child}
+}
+
+// InFlight returns the stats of messages that have been read and are yet to be
+// acknowledged by the child input, when it reports them.
+func (s StatusForwarder) InFlight() input.InFlightStats {
+	if r, ok := s.child().(input.InFlightReporter); ok {
+		return r.InFlight()
+	}
+	return input.InFlightStats{}
+}
+
+// ChildStatuses returns the connection status of each child of the child input
+// when it is a broker.
+func (s StatusForwarder) ChildStatuses() []broker.ChildStatus {
+	if r, ok := s.child().(broker.ChildStatusReporter); ok {
+		return r.ChildStatuses()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
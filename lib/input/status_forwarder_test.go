@@ -0,0 +1,56 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/component/input"
+	"github.com/Jeffail/benthos/v3/lib/broker"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type statusReportingInput struct {
+	count int64
+}
+
+func (s *statusReportingInput) TransactionChan() <-chan types.Transaction {
+	return nil
+}
+
+func (s *statusReportingInput) Connected() bool {
+	return true
+}
+
+func (s *statusReportingInput) CloseAsync() {}
+
+func (s *statusReportingInput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func (s *statusReportingInput) InFlight() input.InFlightStats {
+	return input.InFlightStats{Count: s.count}
+}
+
+func (s *statusReportingInput) ChildStatuses() []broker.ChildStatus {
+	return []broker.ChildStatus{{Label: "foo", Connected: true}}
+}
+
+func TestStatusForwarder(t *testing.T) {
+	child := &statusReportingInput{count: 5}
+
+	f := NewStatusForwarder(child)
+	assert.Equal(t, int64(5), f.InFlight().Count)
+	assert.Equal(t, []broker.ChildStatus{{Label: "foo", Connected: true}}, f.ChildStatuses())
+
+	var current Type = child
+	f = NewDynamicStatusForwarder(func() Type { return current })
+	assert.Equal(t, int64(5), f.InFlight().Count)
+
+	current = &statusReportingInput{count: 2}
+	assert.Equal(t, int64(2), f.InFlight().Count)
+
+	current = &mockInput{}
+	assert.Equal(t, input.InFlightStats{}, f.InFlight())
+	assert.Nil(t, f.ChildStatuses())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 )
 
 //------------------------------------------------------------------------------
@@ -19,11 +20,56 @@ Connects to a websocket server and continuously receives messages.`,
 		Description: `
 It is possible to configure an ` + "`open_message`" + `, which when set to a
 non-empty string will be sent to the websocket server each time a connection is
-first established.`,
+first established.
+
+### Resuming
+
+When the connection to the server is lost the input attempts to reconnect
+according to the ` + "`reconnect_backoff`" + ` policy, giving up and shutting
+down after ` + "`max_reconnect_attempts`" + ` consecutive failed attempts when
+it is set.
+
+If the server supports resuming a stream from a cursor then a
+` + "[Bloblang mapping](/docs/guides/bloblang/about)" + ` can be specified with
+the field ` + "`resume`" + `, which is executed against the most recently read
+message that has been successfully acknowledged each time a reconnection is
+made. The mapping can result in an object containing the field
+` + "`query_params`" + `, an object of query parameters to set on the URL, and
+the field ` + "`open_message`" + `, which replaces the configured open message.
+Messages that have been read but not yet acknowledged are never used for
+resuming, and the mapping is not executed until at least one message has been
+acknowledged.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Resume From a Sequence Number",
+				Summary: `
+Here the server includes a sequence number in each message, and accepts the
+sequence to resume from as a query parameter:`,
+				Config: `
+input:
+  websocket:
+    url: ws://localhost:4195/feed
+    resume: |
+      root.query_params.since = this.seq
+    max_reconnect_attempts: 10
+`,
+			},
+		},
 		FieldSpecs: append(docs.FieldSpecs{
 			docs.FieldCommon("url", "The URL to connect to.", "ws://localhost:4195/get/ws").HasType("string"),
 			docs.FieldAdvanced("open_message", "An optional message to send to the server upon connection."),
-		}, auth.FieldSpecs()...),
+			docs.FieldAdvanced(
+				"resume", "An optional [Bloblang mapping](/docs/guides/bloblang/about) executed against the most recently acknowledged message upon reconnecting, which can result in an object containing `query_params` and `open_message` fields.",
+				`root.query_params.since = this.seq`,
+				`root.open_message = {"subscribe": "feed", "from": this.seq}.format_json()`,
+			).Linter(docs.LintBloblangMapping).AtVersion("3.50.0"),
+			docs.FieldAdvanced("max_reconnect_attempts", "The maximum number of consecutive failed reconnection attempts before the input shuts down. If set to zero there is no limit.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("reconnect_backoff", "Control time intervals between reconnection attempts.").WithChildren(
+				docs.FieldAdvanced("initial_interval", "The initial period to wait before reconnecting."),
+				docs.FieldAdvanced("max_interval", "The maximum period to wait before reconnecting."),
+				docs.FieldAdvanced("max_elapsed_time", "The maximum period of failed reconnection attempts before the input shuts down. If zero then no limit is used."),
+			).AtVersion("3.50.0"),
+		}.Merge(proxy.FieldSpecs()), auth.FieldSpecs()...),
 		Categories: []Category{
 			CategoryNetwork,
 		},
@@ -1,8 +1,10 @@
 package input
 
 import (
+	"context"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -12,6 +14,8 @@ import (
 // by routing the input through the pipeline, and implements the input.Type
 // interface in order to act like an ordinary input.
 type WithPipeline struct {
+	StatusForwarder
+
 	in   Type
 	pipe types.Pipeline
 }
@@ -28,6 +32,8 @@ func WrapWithPipeline(procs *int, in Type, pipeConstructor types.PipelineConstru
 		return nil, err
 	}
 	return &WithPipeline{
+		StatusForwarder: NewStatusForwarder(in),
+
 		in:   in,
 		pipe: pipe,
 	}, nil
@@ -59,6 +65,27 @@ func (i *WithPipeline) Connected() bool {
 	return i.in.Connected()
 }
 
+// SelfTest runs the self test of the input routed through the pipeline.
+func (i *WithPipeline) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, i.in)
+}
+
+// PauseConsumption suspends consumption at the source when supported by the
+// underlying input.
+func (i *WithPipeline) PauseConsumption() {
+	if p, ok := i.in.(Pauser); ok {
+		p.PauseConsumption()
+	}
+}
+
+// ResumeConsumption resumes consumption at the source when supported by the
+// underlying input.
+func (i *WithPipeline) ResumeConsumption() {
+	if p, ok := i.in.(Pauser); ok {
+		p.ResumeConsumption()
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
@@ -8,13 +8,22 @@ func Spec() docs.FieldSpecs {
 		docs.FieldString("level", "Set the minimum severity level for emitting logs.").HasOptions(
 			"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL",
 		).HasDefault("INFO"),
-		docs.FieldString("format", "Set the format of emitted logs.").HasOptions(
-			"json", "logfmt", "classic",
+		docs.FieldString("format", "Set the format of emitted logs.").HasAnnotatedOptions(
+			"json", "Structured JSON documents.",
+			"logfmt", "Key/value pairs in the logfmt format.",
+			"classic", "Plain text prefixed with the level and component.",
+			"ecs", "Structured JSON documents with field names that conform to the [Elastic Common Schema](https://www.elastic.co/guide/en/ecs/current/index.html), suitable for Logstash and Elasticsearch.",
 		).HasDefault("json"),
 		docs.FieldBool("add_timestamp", "Whether to include timestamps in logs.").HasDefault(true),
 		docs.FieldString("static_fields", "A map of key/value pairs to add to each structured log.").Map().HasDefault(map[string]string{
 			"@service": "benthos",
 		}),
+		docs.FieldString("output", "Where logs are written to.").HasAnnotatedOptions(
+			"stdout", "Write logs to stdout, or stderr when the output of the config is stdout.",
+			"benthos_logs", "Publish logs as structured JSON documents to the [`benthos_logs` input](/docs/components/inputs/benthos_logs) instead of writing them.",
+			"both", "Write logs and also publish them to the `benthos_logs` input.",
+		).HasDefault("stdout").AtVersion("3.50.0"),
+		docs.FieldInt("buffer_size", "The maximum number of logs buffered for each `benthos_logs` input before the oldest logs are dropped, which ensures that logging never blocks.").HasDefault(DefaultEventBufferSize).AtVersion("3.50.0").Advanced(),
 		docs.FieldDeprecated("prefix"),
 		docs.FieldDeprecated("json_format"),
 	}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// DefaultEventBufferSize is the default number of log events buffered for each
+// subscriber of an EventHub before the oldest events are dropped.
+const DefaultEventBufferSize = 1000
+
+// eventRing is a fixed size buffer of events where pushing to a full buffer
+// drops the oldest event.
+type eventRing struct {
+	events  [][]byte
+	head    int
+	count   int
+	dropped int64
+}
+
+func newEventRing(capacity int) *eventRing {
+	if capacity <= 0 {
+		capacity = DefaultEventBufferSize
+	}
+	return &eventRing{
+		events: make([][]byte, capacity),
+	}
+}
+
+func (r *eventRing) push(event []byte) {
+	if r.count == len(r.events) {
+		r.events[r.head] = nil
+		r.head = (r.head + 1) % len(r.events)
+		r.count--
+		r.dropped++
+	}
+	r.events[(r.head+r.count)%len(r.events)] = event
+	r.count++
+}
+
+func (r *eventRing) pop() ([]byte, bool) {
+	if r.count == 0 {
+		return nil, false
+	}
+	event := r.events[r.head]
+	r.events[r.head] = nil
+	r.head = (r.head + 1) % len(r.events)
+	r.count--
+	return event, true
+}
+
+//------------------------------------------------------------------------------
+
+// EventHub distributes structured log events to any number of subscribers
+// without ever blocking the publisher. Each subscriber buffers events up to a
+// fixed size, beyond which the oldest events are dropped.
+//
+// Events published whilst there are no subscribers are buffered and delivered
+// to the first subscriber, so that logs emitted during start up are not lost.
+type EventHub struct {
+	mut      sync.Mutex
+	capacity int
+	backlog  *eventRing
+	subs     map[*EventSubscription]struct{}
+}
+
+// NewEventHub creates a hub where each subscriber buffers a maximum number of
+// events.
+func NewEventHub(capacity int) *EventHub {
+	return &EventHub{
+		capacity: capacity,
+		backlog:  newEventRing(capacity),
+		subs:     map[*EventSubscription]struct{}{},
+	}
+}
+
+var defaultEventHub = NewEventHub(DefaultEventBufferSize)
+
+// DefaultEventHub returns the hub that loggers configured with the output
+// benthos_logs publish events to, and that the benthos_logs input consumes.
+func DefaultEventHub() *EventHub {
+	return defaultEventHub
+}
+
+// SetCapacity changes the number of events buffered for subscribers created
+// after the call.
+func (h *EventHub) SetCapacity(capacity int) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if capacity <= 0 {
+		capacity = DefaultEventBufferSize
+	}
+	if capacity == h.capacity {
+		return
+	}
+	h.capacity = capacity
+	backlog := newEventRing(capacity)
+	for {
+		event, ok := h.backlog.pop()
+		if !ok {
+			break
+		}
+		backlog.push(event)
+	}
+	h.backlog = backlog
+}
+
+// Publish a structured log event to all subscribers.
+func (h *EventHub) Publish(event []byte) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if len(h.subs) == 0 {
+		h.backlog.push(event)
+		return
+	}
+	for s := range h.subs {
+		s.push(event)
+	}
+}
+
+// Subscribe creates a subscription that receives all events published from now
+// on. The subscription must be closed once it is no longer needed.
+func (h *EventHub) Subscribe() *EventSubscription {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	s := &EventSubscription{
+		hub:    h,
+		ring:   newEventRing(h.capacity),
+		notify: make(chan struct{}, 1),
+	}
+	if len(h.subs) == 0 {
+		for {
+			event, ok := h.backlog.pop()
+			if !ok {
+				break
+			}
+			s.ring.push(event)
+		}
+		s.ring.dropped = h.backlog.dropped
+		h.backlog.dropped = 0
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+// EventSubscription receives the events published to an EventHub.
+type EventSubscription struct {
+	hub    *EventHub
+	mut    sync.Mutex
+	ring   *eventRing
+	notify chan struct{}
+}
+
+func (s *EventSubscription) push(event []byte) {
+	s.mut.Lock()
+	s.ring.push(event)
+	s.mut.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Read blocks until an event is available or the context is cancelled. The
+// number of events dropped since the last read, due to the subscriber not
+// keeping up, is also returned.
+func (s *EventSubscription) Read(ctx context.Context) (event []byte, dropped int64, err error) {
+	for {
+		s.mut.Lock()
+		e, ok := s.ring.pop()
+		dropped += s.ring.dropped
+		s.ring.dropped = 0
+		s.mut.Unlock()
+		if ok {
+			return e, dropped, nil
+		}
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return nil, dropped, ctx.Err()
+		}
+	}
+}
+
+// Close removes the subscription from the hub.
+func (s *EventSubscription) Close() {
+	s.hub.mut.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHubFanOut(t *testing.T) {
+	hub := NewEventHub(10)
+
+	hub.Publish([]byte("before"))
+
+	first := hub.Subscribe()
+	second := hub.Subscribe()
+
+	hub.Publish([]byte("after"))
+
+	assert.Equal(t, "before", readEvent(t, first))
+	assert.Equal(t, "after", readEvent(t, first))
+	assert.Equal(t, "after", readEvent(t, second))
+
+	second.Close()
+	hub.Publish([]byte("closed"))
+	assert.Equal(t, "closed", readEvent(t, first))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+	_, _, err := second.Read(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestEventHubDropOldest(t *testing.T) {
+	hub := NewEventHub(3)
+	sub := hub.Subscribe()
+
+	for _, e := range []string{"a", "b", "c", "d", "e"} {
+		hub.Publish([]byte(e))
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	event, dropped, err := sub.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(event))
+	assert.Equal(t, int64(2), dropped)
+
+	event, dropped, err = sub.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "d", string(event))
+	assert.Equal(t, int64(0), dropped)
+
+	assert.Equal(t, "e", readEvent(t, sub))
+}
+
+func TestEventHubBacklogDropOldest(t *testing.T) {
+	hub := NewEventHub(5)
+	hub.SetCapacity(2)
+
+	for _, e := range []string{"a", "b", "c"} {
+		hub.Publish([]byte(e))
+	}
+
+	sub := hub.Subscribe()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	event, dropped, err := sub.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(event))
+	assert.Equal(t, int64(1), dropped)
+	assert.Equal(t, "c", readEvent(t, sub))
+}
+
+func TestEventHubBlockingRead(t *testing.T) {
+	hub := NewEventHub(10)
+	sub := hub.Subscribe()
+
+	go func() {
+		<-time.After(time.Millisecond * 10)
+		hub.Publish([]byte("foo"))
+	}()
+
+	assert.Equal(t, "foo", readEvent(t, sub))
+}
@@ -86,6 +86,8 @@ type Config struct {
 	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
 	JSONFormat   bool              `json:"json_format" yaml:"json_format"`
 	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
+	Output       string            `json:"output" yaml:"output"`
+	BufferSize   int               `json:"buffer_size" yaml:"buffer_size"`
 }
 
 // NewConfig returns a config struct with the default values for each field.
@@ -99,6 +101,8 @@ func NewConfig() Config {
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		Output:     "stdout",
+		BufferSize: DefaultEventBufferSize,
 	}
 }
 
@@ -179,6 +183,9 @@ type Logger struct {
 	addTimestamp bool
 	level        int
 	formatter    logFormatter
+
+	events         *EventHub
+	eventFormatter logFormatter
 }
 
 // New creates and returns a new logger object.
@@ -209,6 +216,7 @@ func New(stream io.Writer, config Config) Modular {
 	if logger.formatter == nil {
 		logger.formatter = deprecatedFormatter(config.Prefix, config.AddTimeStamp)
 	}
+	_ = logger.setOutput(config.Output, config.BufferSize)
 	return &logger
 }
 
@@ -240,9 +248,30 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 	if logger.formatter, err = getFormatter(config.Format, config.Prefix, config.AddTimeStamp, fields); err != nil {
 		return nil, err
 	}
+	if err = logger.setOutput(config.Output, config.BufferSize); err != nil {
+		return nil, err
+	}
 	return &logger, nil
 }
 
+// setOutput configures whether logs are written to the stream of the logger,
+// published to the default event hub, or both.
+func (l *Logger) setOutput(output string, bufferSize int) error {
+	switch output {
+	case "", "stdout":
+		return nil
+	case "benthos_logs":
+		l.stream = nil
+	case "both":
+	default:
+		return fmt.Errorf("log output '%v' not recognized", output)
+	}
+	l.events = DefaultEventHub()
+	l.events.SetCapacity(bufferSize)
+	l.eventFormatter = getEventFormatter(l.format, l.addTimestamp, l.fields)
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 // Noop creates and returns a new logger object that writes nothing.
@@ -288,7 +317,19 @@ func (l *Logger) NewModule(name string) Modular {
 		format:       l.format,
 		addTimestamp: l.addTimestamp,
 		formatter:    formatter,
+
+		events:         l.events,
+		eventFormatter: l.eventFormatterFor(newFields),
+	}
+}
+
+// eventFormatterFor returns a formatter for events published by a logger
+// derived from this one with a new set of fields.
+func (l *Logger) eventFormatterFor(fields map[string]interface{}) logFormatter {
+	if l.events == nil {
+		return nil
 	}
+	return getEventFormatter(l.format, l.addTimestamp, fields)
 }
 
 // WithFields returns a logger with new fields added to the JSON formatted
@@ -315,6 +356,9 @@ func (l *Logger) WithFields(inboundFields map[string]string) Modular {
 		format:       l.format,
 		addTimestamp: l.addTimestamp,
 		formatter:    formatter,
+
+		events:         l.events,
+		eventFormatter: l.eventFormatterFor(newFields),
 	}
 }
 
@@ -351,6 +395,9 @@ func (l *Logger) With(args ...interface{}) Modular {
 		format:       l.format,
 		addTimestamp: l.addTimestamp,
 		formatter:    formatter,
+
+		events:         l.events,
+		eventFormatter: l.eventFormatterFor(newFields),
 	}
 }
 
@@ -448,10 +495,60 @@ func deprecatedFormatter(component string, addTimestamp bool) logFormatter {
 	}
 }
 
+// ecsFieldNames maps the names of standard fields to their equivalent within
+// the Elastic Common Schema.
+var ecsFieldNames = map[string]string{
+	"@service":  "service.name",
+	"component": "log.logger",
+}
+
+// ecsVersion is the version of the Elastic Common Schema that logs formatted
+// as ecs conform to.
+const ecsVersion = "1.6.0"
+
+func ecsFormatter(addTimestamp bool, fields map[string]interface{}) logFormatter {
+	ecsFields := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		if ecsName, exists := ecsFieldNames[k]; exists {
+			k = ecsName
+		}
+		ecsFields[k] = v
+	}
+	ecsFields["ecs.version"] = ecsVersion
+
+	jBytes, _ := json.Marshal(ecsFields)
+	staticFieldsRawJSON := string(jBytes[1:len(jBytes)-1]) + ","
+
+	return func(w io.Writer, message string, level string, other ...interface{}) {
+		message = strings.TrimSuffix(message, "\n")
+		timestampStr := ""
+		if addTimestamp {
+			timestampStr = fmt.Sprintf("\"@timestamp\":\"%v\",", time.Now().Format(time.RFC3339Nano))
+		}
+		fmt.Fprintf(
+			w,
+			"{%v%v\"log.level\":\"%v\",\"message\":%v}\n",
+			timestampStr, staticFieldsRawJSON, strings.ToLower(level),
+			strconv.QuoteToASCII(fmt.Sprintf(message, other...)),
+		)
+	}
+}
+
+// getEventFormatter returns a formatter for events published to an event hub,
+// which are always structured as JSON documents.
+func getEventFormatter(format string, addTimestamp bool, fields map[string]interface{}) logFormatter {
+	if format == "ecs" {
+		return ecsFormatter(addTimestamp, fields)
+	}
+	return jsonFormatter(addTimestamp, fields)
+}
+
 func getFormatter(format, component string, addTimestamp bool, fields map[string]interface{}) (logFormatter, error) {
 	switch format {
 	case "json":
 		return jsonFormatter(addTimestamp, fields), nil
+	case "ecs":
+		return ecsFormatter(addTimestamp, fields), nil
 	case "logfmt":
 		return logfmtFormatter(addTimestamp, fields), nil
 	case "deprecated", "classic":
@@ -462,7 +559,14 @@ func getFormatter(format, component string, addTimestamp bool, fields map[string
 
 // write prints a log message with any configured extras prepended.
 func (l *Logger) write(message, level string, other ...interface{}) {
-	l.formatter(l.stream, message, level, other...)
+	if l.stream != nil {
+		l.formatter(l.stream, message, level, other...)
+	}
+	if l.events != nil {
+		var buf bytes.Buffer
+		l.eventFormatter(&buf, message, level, other...)
+		l.events.Publish(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -2,7 +2,9 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -276,3 +278,92 @@ func TestLogLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggerECS(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "ecs"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{
+		"@service": "benthos_service",
+		"@system":  "foo",
+	}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Warnf("Warning message root module")
+	logger.NewModule(".foo").Warnf("Warning message %v module", "foo")
+	logger.Infof("Info message root module")
+
+	expected := `{"@system":"foo","ecs.version":"1.6.0","log.logger":"benthos","service.name":"benthos_service","log.level":"warn","message":"Warning message root module"}
+{"@system":"foo","ecs.version":"1.6.0","log.logger":"benthos.foo","service.name":"benthos_service","log.level":"warn","message":"Warning message foo module"}
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func drainEvents(t *testing.T, sub *EventSubscription) {
+	t.Helper()
+	for {
+		ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+		_, _, err := sub.Read(ctx)
+		done()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readEvent(t *testing.T, sub *EventSubscription) string {
+	t.Helper()
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	event, _, err := sub.Read(ctx)
+	require.NoError(t, err)
+	return string(event)
+}
+
+func TestLoggerOutputBenthosLogs(t *testing.T) {
+	sub := DefaultEventHub().Subscribe()
+	defer sub.Close()
+	drainEvents(t, sub)
+
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.Output = "benthos_logs"
+	loggerConfig.StaticFields = map[string]string{
+		"@service": "benthos_service",
+	}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Infof("Info message root module")
+	logger.WithFields(map[string]string{"foo": "bar"}).Warnln("Warning message with fields")
+
+	assert.Equal(t, `{"@service":"benthos_service","component":"benthos","level":"INFO","message":"Info message root module"}`, readEvent(t, sub))
+	assert.Equal(t, `{"@service":"benthos_service","component":"benthos","foo":"bar","level":"WARN","message":"Warning message with fields"}`, readEvent(t, sub))
+	assert.Empty(t, buf.String())
+
+	loggerConfig.Output = "both"
+	loggerConfig.Format = "ecs"
+
+	logger, err = NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Infof("Info message root module")
+
+	expected := `{"ecs.version":"1.6.0","log.logger":"benthos","service.name":"benthos_service","log.level":"info","message":"Info message root module"}`
+	assert.Equal(t, expected, readEvent(t, sub))
+	assert.Equal(t, expected+"\n", buf.String())
+
+	loggerConfig.Output = "nope"
+	_, err = NewV2(&buf, loggerConfig)
+	assert.EqualError(t, err, "log output 'nope' not recognized")
+}
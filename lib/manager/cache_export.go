@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Jeffail/benthos/v3/internal/component/cache"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gorilla/mux"
+)
+
+// HandleCacheExport is an HTTP handler that streams the contents of a cache
+// resource, identified by the path parameter name, as newline delimited JSON
+// documents. The handler is intended to be registered under the path
+// /resources/caches/{name}/export.
+func (t *Type) HandleCacheExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+
+	var exportErr error
+	if err := t.AccessCache(r.Context(), name, func(c types.Cache) {
+		if _, ok := c.(cache.Exporter); !ok {
+			exportErr = cache.ErrExportNotSupported
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		exportErr = cache.WriteExport(r.Context(), c, w)
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(exportErr, cache.ErrExportNotSupported) {
+		http.Error(w, exportErr.Error(), http.StatusNotImplemented)
+	} else if exportErr != nil {
+		// The response may already be partially written at this point, and
+		// therefore the best we can do is log the error.
+		t.logger.Errorf("Failed to export cache '%v': %v\n", name, exportErr)
+	}
+}
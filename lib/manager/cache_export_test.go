@@ -0,0 +1,58 @@
+package manager_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerCacheExport(t *testing.T) {
+	memConf := cache.NewConfig()
+	memConf.Type = cache.TypeMemory
+	memConf.Memory.InitValues = map[string]string{
+		"foo": "bar",
+	}
+
+	fileConf := cache.NewConfig()
+	fileConf.Type = cache.TypeFile
+	fileConf.File.Directory = t.TempDir()
+
+	conf := manager.NewConfig()
+	conf.Caches["mem"] = memConf
+	conf.Caches["file"] = fileConf
+
+	mgr, err := manager.New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/resources/caches/{name}/export", mgr.HandleCacheExport)
+
+	req := httptest.NewRequest("GET", "/resources/caches/mem/export", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/x-ndjson", res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"key":"foo","value":"YmFy","value_size":3}`, strings.TrimSpace(res.Body.String()))
+
+	req = httptest.NewRequest("GET", "/resources/caches/file/export", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotImplemented, res.Code)
+
+	req = httptest.NewRequest("GET", "/resources/caches/nope/export", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
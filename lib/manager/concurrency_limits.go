@@ -0,0 +1,42 @@
+package manager
+
+import (
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/internal/budget"
+)
+
+//------------------------------------------------------------------------------
+
+// concurrencyLimits is a registry of semaphores, keyed by stream and then by
+// component label, which allows the instances of a component created for each
+// logical thread of a pipeline to share a single limit.
+type concurrencyLimits struct {
+	mut    sync.Mutex
+	limits map[string]map[string]*budget.Concurrency
+}
+
+func newConcurrencyLimits() *concurrencyLimits {
+	return &concurrencyLimits{
+		limits: map[string]map[string]*budget.Concurrency{},
+	}
+}
+
+// get returns the semaphore of a labelled component, creating it if it does
+// not yet exist. When an existing semaphore was created with a different
+// maximum, such as when a stream has been updated, it is replaced.
+func (c *concurrencyLimits) get(stream, label string, max int) *budget.Concurrency {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	labels, exists := c.limits[stream]
+	if !exists {
+		labels = map[string]*budget.Concurrency{}
+		c.limits[stream] = labels
+	}
+	limit, exists := labels[label]
+	if !exists || limit.Max() != max {
+		limit = budget.NewConcurrency(max)
+		labels[label] = limit
+	}
+	return limit
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -41,6 +42,11 @@ func (w *outputWrapper) Connected() bool {
 	return w.output.Connected()
 }
 
+// SelfTest runs the self test of the wrapped output resource.
+func (w *outputWrapper) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, w.output)
+}
+
 func (w *outputWrapper) CloseAsync() {
 	w.output.CloseAsync()
 }
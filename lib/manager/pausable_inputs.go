@@ -0,0 +1,147 @@
+package manager
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/api"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// pausableInputs is a registry of labelled inputs, keyed by stream and then by
+// label, which can be paused and resumed via the HTTP API.
+type pausableInputs struct {
+	mut    sync.Mutex
+	inputs map[string]map[string]*input.Pausable
+}
+
+func newPausableInputs() *pausableInputs {
+	return &pausableInputs{
+		inputs: map[string]map[string]*input.Pausable{},
+	}
+}
+
+func (p *pausableInputs) set(stream, label string, in *input.Pausable) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	labels, exists := p.inputs[stream]
+	if !exists {
+		labels = map[string]*input.Pausable{}
+		p.inputs[stream] = labels
+	}
+	labels[label] = in
+}
+
+// remove deletes an input from the registry as long as it hasn't since been
+// replaced by another input of the same label.
+func (p *pausableInputs) remove(stream, label string, in *input.Pausable) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	labels := p.inputs[stream]
+	if labels[label] != in {
+		return
+	}
+	delete(labels, label)
+	if len(labels) == 0 {
+		delete(p.inputs, stream)
+	}
+}
+
+func (p *pausableInputs) get(stream, label string) *input.Pausable {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.inputs[stream][label]
+}
+
+func (p *pausableInputs) paused(stream string) []string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	var labels []string
+	for label, in := range p.inputs[stream] {
+		if in.Paused() {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+//------------------------------------------------------------------------------
+
+// registeredInput removes a labelled input from the registry of pausable
+// inputs once it is closed.
+type registeredInput struct {
+	*input.Pausable
+	deregister func()
+}
+
+func (r *registeredInput) CloseAsync() {
+	r.deregister()
+	r.Pausable.CloseAsync()
+}
+
+// pausableInput wraps a labelled input in order to allow it to be paused and
+// resumed at runtime, and registers endpoints for doing so.
+func (t *Type) pausableInput(label string, in types.Input) types.Input {
+	p := input.NewPausable(in, t.stats)
+
+	stream := t.stream
+	t.pausable.set(stream, label, p)
+
+	t.RegisterEndpointSpec(api.NewEndpointSpec(
+		"/inputs/"+label+"/pause",
+		"Pause consuming messages from the input labelled '"+label+"'.",
+		api.EndpointOperation{
+			Method:  "POST",
+			Summary: "Pause the input.",
+		},
+	), t.pauseHandler(label, true))
+	t.RegisterEndpointSpec(api.NewEndpointSpec(
+		"/inputs/"+label+"/resume",
+		"Resume consuming messages from the input labelled '"+label+"'.",
+		api.EndpointOperation{
+			Method:  "POST",
+			Summary: "Resume the input.",
+		},
+	), t.pauseHandler(label, false))
+
+	return &registeredInput{
+		Pausable: p,
+		deregister: func() {
+			t.pausable.remove(stream, label, p)
+		},
+	}
+}
+
+func (t *Type) pauseHandler(label string, pause bool) http.HandlerFunc {
+	stream := t.stream
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		p := t.pausable.get(stream, label)
+		if p == nil {
+			http.Error(w, "Input not found", http.StatusNotFound)
+			return
+		}
+		if pause {
+			if p.Pause() {
+				t.logger.Infof("Pausing input '%v'\n", label)
+			}
+		} else if p.Resume() {
+			t.logger.Infof("Resuming input '%v'\n", label)
+		}
+		w.Write([]byte("OK"))
+	}
+}
+
+// PausedInputs returns the labels of the inputs of the stream of the manager
+// that are currently paused.
+func (t *Type) PausedInputs() []string {
+	return t.pausable.paused(t.stream)
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// PipeState describes the connection state of a named pipe, which connects
+// inproc outputs to inproc inputs.
+type PipeState struct {
+	Producer  bool `json:"producer"`
+	Consumers int  `json:"consumers"`
+}
+
+// PipeStates returns the connection states of all named pipes that either have
+// a producer or connected consumers.
+func (t *Type) PipeStates() map[string]PipeState {
+	t.pipeLock.RLock()
+	defer t.pipeLock.RUnlock()
+
+	states := map[string]PipeState{}
+	for name := range t.pipes {
+		states[name] = PipeState{Producer: true}
+	}
+	for name, consumers := range t.pipeConsumers {
+		state := states[name]
+		state.Consumers = consumers
+		states[name] = state
+	}
+	return states
+}
+
+// HandlePipes is an HTTP handler that returns the connection states of all
+// named pipes as a JSON object keyed by their names.
+func (t *Type) HandlePipes(w http.ResponseWriter, r *http.Request) {
+	resBytes, err := json.Marshal(t.PipeStates())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
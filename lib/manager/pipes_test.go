@@ -0,0 +1,49 @@
+package manager_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerPipeStates(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	fooChan := make(chan types.Transaction)
+	mgr.SetPipe("foo", fooChan)
+	mgr.SetPipe("bar", make(chan types.Transaction))
+	mgr.PipeConsumerConnected("foo")
+	mgr.PipeConsumerConnected("foo")
+	mgr.PipeConsumerConnected("baz")
+
+	assert.Equal(t, map[string]manager.PipeState{
+		"foo": {Producer: true, Consumers: 2},
+		"bar": {Producer: true},
+		"baz": {Consumers: 1},
+	}, mgr.PipeStates())
+
+	mgr.UnsetPipe("foo", fooChan)
+	mgr.PipeConsumerDisconnected("foo")
+	mgr.PipeConsumerDisconnected("baz")
+
+	assert.Equal(t, map[string]manager.PipeState{
+		"foo": {Consumers: 1},
+		"bar": {Producer: true},
+	}, mgr.PipeStates())
+
+	res := httptest.NewRecorder()
+	mgr.HandlePipes(res, httptest.NewRequest("GET", "/debug/inproc", nil))
+
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"foo": {"producer": false, "consumers": 1},
+		"bar": {"producer": true, "consumers": 0}
+	}`, res.Body.String())
+}
@@ -0,0 +1,345 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// sharedInputs is a registry of fan-outs of input resources, keyed by resource
+// name, which allow any number of components to consume every message of an
+// input resource.
+type sharedInputs struct {
+	mut      sync.Mutex
+	fanOuts  map[string]*inputFanOut
+	shutSig  chan struct{}
+	isClosed bool
+}
+
+func newSharedInputs() *sharedInputs {
+	return &sharedInputs{
+		fanOuts: map[string]*inputFanOut{},
+		shutSig: make(chan struct{}),
+	}
+}
+
+func (s *sharedInputs) get(t *Type, name string) *inputFanOut {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	f, exists := s.fanOuts[name]
+	if !exists {
+		f = &inputFanOut{
+			name:    name,
+			mgr:     t,
+			log:     t.logger,
+			shutSig: s.shutSig,
+			subs:    map[*inputSubscriber]struct{}{},
+			changed: make(chan struct{}),
+		}
+		s.fanOuts[name] = f
+	}
+	return f
+}
+
+func (s *sharedInputs) close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if !s.isClosed {
+		s.isClosed = true
+		close(s.shutSig)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// inputSubscriber is a consumer of an input fan-out.
+type inputSubscriber struct {
+	tranChan chan types.Transaction
+
+	// Senders hold a read lock whilst attempting to send to tranChan, which
+	// allows the channel to be closed safely once closedChan is closed.
+	sendMut    sync.RWMutex
+	closeOnce  sync.Once
+	closedChan chan struct{}
+}
+
+// send attempts to send a transaction to the subscriber and returns false if
+// the subscriber or the fan-out is closed before the transaction is accepted.
+func (s *inputSubscriber) send(tran types.Transaction, shutSig <-chan struct{}) bool {
+	s.sendMut.RLock()
+	defer s.sendMut.RUnlock()
+	select {
+	case <-s.closedChan:
+		return false
+	default:
+	}
+	select {
+	case s.tranChan <- tran:
+		return true
+	case <-s.closedChan:
+	case <-shutSig:
+	}
+	return false
+}
+
+func (s *inputSubscriber) close() {
+	s.closeOnce.Do(func() {
+		close(s.closedChan)
+		s.sendMut.Lock()
+		close(s.tranChan)
+		s.sendMut.Unlock()
+	})
+}
+
+// inputFanOut consumes an input resource on behalf of its subscribers for as
+// long as it has at least one. Each message consumed is delivered to every
+// subscriber, and is only acknowledged once all subscribers have successfully
+// processed it, meaning the slowest subscriber applies back pressure.
+type inputFanOut struct {
+	name    string
+	mgr     *Type
+	log     log.Modular
+	shutSig <-chan struct{}
+
+	mut     sync.Mutex
+	subs    map[*inputSubscriber]struct{}
+	changed chan struct{}
+	running bool
+}
+
+func (f *inputFanOut) subscribe() *inputSubscriber {
+	sub := &inputSubscriber{
+		tranChan:   make(chan types.Transaction),
+		closedChan: make(chan struct{}),
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.subs[sub] = struct{}{}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	if !f.running {
+		f.running = true
+		go f.loop()
+	}
+	return sub
+}
+
+func (f *inputFanOut) unsubscribe(sub *inputSubscriber) {
+	f.mut.Lock()
+	if _, exists := f.subs[sub]; exists {
+		delete(f.subs, sub)
+		close(f.changed)
+		f.changed = make(chan struct{})
+	}
+	f.mut.Unlock()
+	sub.close()
+}
+
+// snapshot returns the current subscribers along with a channel that is closed
+// when they change. When there are no subscribers the fan-out is flagged as no
+// longer running and the caller must exit.
+func (f *inputFanOut) snapshot() ([]*inputSubscriber, <-chan struct{}) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if len(f.subs) == 0 {
+		f.running = false
+		return nil, nil
+	}
+	subs := make([]*inputSubscriber, 0, len(f.subs))
+	for s := range f.subs {
+		subs = append(subs, s)
+	}
+	return subs, f.changed
+}
+
+func (f *inputFanOut) loop() {
+	var tranChan, lastClosed <-chan types.Transaction
+	for {
+		subs, changed := f.snapshot()
+		if subs == nil {
+			return
+		}
+
+		if tranChan == nil {
+			// The input resource is obtained again each time its channel
+			// closes, which allows it to be replaced at runtime.
+			if err := f.mgr.AccessInput(context.Background(), f.name, func(i types.Input) {
+				tranChan = i.TransactionChan()
+			}); err != nil || tranChan == lastClosed {
+				if err != nil {
+					f.log.Errorf("Failed to obtain input resource '%v': %v\n", f.name, err)
+				}
+				tranChan = nil
+				select {
+				case <-time.After(time.Second):
+				case <-changed:
+				case <-f.shutSig:
+					f.stopped()
+					return
+				}
+				continue
+			}
+		}
+
+		select {
+		case tran, open := <-tranChan:
+			if !open {
+				lastClosed, tranChan = tranChan, nil
+				continue
+			}
+			// Subscribers may have changed whilst waiting for the transaction,
+			// in which case it is delivered to the current set.
+			select {
+			case <-changed:
+				if subs, _ = f.snapshot(); subs == nil {
+					go f.respond(tran, response.NewError(types.ErrTypeClosed))
+					return
+				}
+			default:
+			}
+			f.dispatch(tran, subs)
+		case <-changed:
+		case <-f.shutSig:
+			f.stopped()
+			return
+		}
+	}
+}
+
+// stopped flags the fan-out as no longer running after a shutdown.
+func (f *inputFanOut) stopped() {
+	f.mut.Lock()
+	f.running = false
+	f.mut.Unlock()
+}
+
+func (f *inputFanOut) respond(tran types.Transaction, res types.Response) {
+	select {
+	case tran.ResponseChan <- res:
+	case <-f.shutSig:
+	}
+}
+
+type fanOutDelivery struct {
+	sub     *inputSubscriber
+	resChan chan types.Response
+}
+
+// dispatch delivers a transaction to each subscriber, blocking until all of
+// them have accepted it, and then awaits their responses asynchronously.
+func (f *inputFanOut) dispatch(tran types.Transaction, subs []*inputSubscriber) {
+	// With only one subscriber the transaction is passed through unchanged,
+	// including its response channel.
+	if len(subs) == 1 {
+		if !subs[0].send(tran, f.shutSig) {
+			go f.respond(tran, response.NewError(types.ErrTypeClosed))
+		}
+		return
+	}
+
+	// Sends are made concurrently so that subscribers are able to consume
+	// their copies in any order.
+	accepted := make([]bool, len(subs))
+	resChans := make([]chan types.Response, len(subs))
+	var wg sync.WaitGroup
+	for i, sub := range subs {
+		wg.Add(1)
+		resChans[i] = make(chan types.Response)
+		go func(i int, sub *inputSubscriber) {
+			defer wg.Done()
+			accepted[i] = sub.send(types.NewTransaction(tran.Payload.Copy(), resChans[i]), f.shutSig)
+		}(i, sub)
+	}
+	wg.Wait()
+
+	deliveries := make([]fanOutDelivery, 0, len(subs))
+	for i, sub := range subs {
+		if accepted[i] {
+			deliveries = append(deliveries, fanOutDelivery{
+				sub:     sub,
+				resChan: resChans[i],
+			})
+		}
+	}
+	go f.await(tran, deliveries)
+}
+
+// await waits for each subscriber to respond to a transaction, delivering it
+// again to subscribers that respond with an error, and finally responds to the
+// input resource once all subscribers have either succeeded or closed.
+func (f *inputFanOut) await(tran types.Transaction, deliveries []fanOutDelivery) {
+	results := make(chan bool, len(deliveries))
+	for _, d := range deliveries {
+		go func(d fanOutDelivery) {
+			results <- f.awaitDelivery(tran, d)
+		}(d)
+	}
+
+	var delivered bool
+	for range deliveries {
+		select {
+		case ok := <-results:
+			delivered = delivered || ok
+		case <-f.shutSig:
+			return
+		}
+	}
+	if !delivered {
+		f.respond(tran, response.NewError(types.ErrTypeClosed))
+		return
+	}
+	f.respond(tran, response.NewAck())
+}
+
+// awaitDelivery waits for a subscriber to successfully process a transaction,
+// and returns false if the subscriber closes before it does.
+func (f *inputFanOut) awaitDelivery(tran types.Transaction, d fanOutDelivery) bool {
+	for {
+		var res types.Response
+		select {
+		case res = <-d.resChan:
+		case <-d.sub.closedChan:
+			return false
+		case <-f.shutSig:
+			return false
+		}
+		if res.Error() == nil {
+			return true
+		}
+		select {
+		case <-time.After(time.Millisecond * 100):
+		case <-d.sub.closedChan:
+			return false
+		case <-f.shutSig:
+			return false
+		}
+		if !d.sub.send(types.NewTransaction(tran.Payload.Copy(), d.resChan), f.shutSig) {
+			return false
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SubscribeInput returns a channel that receives a transaction for each message
+// consumed by an input resource, along with a function that cancels the
+// subscription and closes the channel. When an input resource has multiple
+// subscribers each message is delivered to all of them.
+func (t *Type) SubscribeInput(name string) (<-chan types.Transaction, func(), error) {
+	if err := t.AccessInput(context.Background(), name, func(types.Input) {}); err != nil {
+		return nil, nil, err
+	}
+	f := t.sharedInputs.get(t, name)
+	sub := f.subscribe()
+	return sub.tranChan, func() {
+		f.unsubscribe(sub)
+	}, nil
+}
@@ -0,0 +1,162 @@
+package manager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func storeInprocInput(t *testing.T, mgr *manager.Type, name, pipe string) {
+	t.Helper()
+
+	conf := input.NewConfig()
+	conf.Type = input.TypeInproc
+	conf.Inproc = input.InprocConfig(pipe)
+	require.NoError(t, mgr.StoreInput(context.Background(), name, conf))
+}
+
+func sendTran(t *testing.T, pipeChan chan<- types.Transaction, content string) <-chan types.Response {
+	t.Helper()
+
+	resChan := make(chan types.Response, 1)
+	select {
+	case pipeChan <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return resChan
+}
+
+func readTran(t *testing.T, tChan <-chan types.Transaction) types.Transaction {
+	t.Helper()
+
+	select {
+	case tran, open := <-tChan:
+		require.True(t, open)
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return types.Transaction{}
+}
+
+func respond(t *testing.T, tran types.Transaction, err error) {
+	t.Helper()
+
+	select {
+	case tran.ResponseChan <- response.NewError(err):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
+func TestSubscribeInputFanOut(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mgr.CloseAsync()
+	})
+
+	pipeChan := make(chan types.Transaction)
+	mgr.SetPipe("foopipe", pipeChan)
+	storeInprocInput(t, mgr, "foo", "foopipe")
+
+	_, _, err = mgr.SubscribeInput("bar")
+	require.Error(t, err)
+
+	fooChan, fooUnsub, err := mgr.SubscribeInput("foo")
+	require.NoError(t, err)
+	barChan, barUnsub, err := mgr.SubscribeInput("foo")
+	require.NoError(t, err)
+
+	resChan := sendTran(t, pipeChan, "hello world")
+
+	fooTran := readTran(t, fooChan)
+	barTran := readTran(t, barChan)
+	assert.Equal(t, "hello world", string(fooTran.Payload.Get(0).Get()))
+	assert.Equal(t, "hello world", string(barTran.Payload.Get(0).Get()))
+
+	// A failed subscriber receives the message again, the other does not.
+	respond(t, fooTran, nil)
+	respond(t, barTran, errors.New("nope"))
+
+	barTran = readTran(t, barChan)
+	assert.Equal(t, "hello world", string(barTran.Payload.Get(0).Get()))
+	select {
+	case <-resChan:
+		t.Fatal("responded before all subscribers succeeded")
+	default:
+	}
+
+	respond(t, barTran, nil)
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	// Once a subscriber leaves its channel is closed and the remaining
+	// subscriber receives the original transaction.
+	fooUnsub()
+	_, open := <-fooChan
+	assert.False(t, open)
+
+	resChan = sendTran(t, pipeChan, "second")
+	barTran = readTran(t, barChan)
+	respond(t, barTran, errors.New("nope again"))
+	select {
+	case res := <-resChan:
+		assert.EqualError(t, res.Error(), "nope again")
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	barUnsub()
+	_, open = <-barChan
+	assert.False(t, open)
+}
+
+func TestSubscribeInputHotSwap(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mgr.CloseAsync()
+	})
+
+	firstChan := make(chan types.Transaction)
+	mgr.SetPipe("first", firstChan)
+	secondChan := make(chan types.Transaction)
+	mgr.SetPipe("second", secondChan)
+
+	storeInprocInput(t, mgr, "foo", "first")
+
+	fooChan, fooUnsub, err := mgr.SubscribeInput("foo")
+	require.NoError(t, err)
+	defer fooUnsub()
+
+	resChan := sendTran(t, firstChan, "from first")
+	tran := readTran(t, fooChan)
+	assert.Equal(t, "from first", string(tran.Payload.Get(0).Get()))
+	respond(t, tran, nil)
+	require.NoError(t, (<-resChan).Error())
+
+	storeInprocInput(t, mgr, "foo", "second")
+
+	resChan = sendTran(t, secondChan, "from second")
+	tran = readTran(t, fooChan)
+	assert.Equal(t, "from second", string(tran.Payload.Get(0).Get()))
+	respond(t, tran, nil)
+	require.NoError(t, (<-resChan).Error())
+}
@@ -8,9 +8,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/budget"
 	"github.com/Jeffail/benthos/v3/internal/bundle"
 	imetrics "github.com/Jeffail/benthos/v3/internal/component/metrics"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/internal/tracecapture"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/condition"
@@ -18,6 +22,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/pipeline"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -76,8 +81,30 @@ type Type struct {
 	logger log.Modular
 	stats  *imetrics.Namespaced
 
-	pipes    map[string]<-chan types.Transaction
-	pipeLock *sync.RWMutex
+	pipes         map[string]<-chan types.Transaction
+	pipeConsumers map[string]int
+	pipeLock      *sync.RWMutex
+
+	// Labelled inputs that can be paused and resumed via the HTTP API.
+	pausable *pausableInputs
+
+	// Fan-outs of input resources that are consumed by multiple components.
+	sharedInputs *sharedInputs
+
+	// Semaphores shared by the instances of labelled components that limit
+	// their concurrency.
+	concurrency *concurrencyLimits
+
+	// An optional capture of messages passing through inputs and labelled
+	// processors.
+	capture *tracecapture.Capture
+
+	// An optional redactor applied to message contents before they are
+	// serialised for observability purposes.
+	redactor *redact.Redactor
+
+	// An optional budget of message bytes held in flight by inputs.
+	memBudget *budget.Memory
 
 	// TODO: V4 Remove this
 	conditions map[string]types.Condition
@@ -89,9 +116,38 @@ func New(conf Config, apiReg APIReg, log log.Modular, stats metrics.Type) (*Type
 	return NewV2(ResourceConfig{Manager: conf}, apiReg, log, stats)
 }
 
+// OptFunc applies an option to a manager type during construction.
+type OptFunc func(t *Type)
+
+// OptSetTraceCapture sets a capture that records snapshots of messages after
+// they have been consumed by inputs and after they have passed through
+// labelled processors.
+func OptSetTraceCapture(c *tracecapture.Capture) OptFunc {
+	return func(t *Type) {
+		t.capture = c
+	}
+}
+
+// OptSetRedactor sets a redactor to be applied to message contents before
+// they are serialised for observability purposes, such as by the log
+// processor.
+func OptSetRedactor(r *redact.Redactor) OptFunc {
+	return func(t *Type) {
+		t.redactor = r
+	}
+}
+
+// OptSetMemoryBudget sets a budget of message bytes that inputs are permitted to
+// hold in flight before reads are paused.
+func OptSetMemoryBudget(m *budget.Memory) OptFunc {
+	return func(t *Type) {
+		t.memBudget = m
+	}
+}
+
 // NewV2 returns an instance of manager.Type, which can be shared amongst
 // components and logical threads of a Benthos service.
-func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Type) (*Type, error) {
+func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Type, opts ...OptFunc) (*Type, error) {
 	t := &Type{
 		apiReg: apiReg,
 
@@ -114,11 +170,19 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Ty
 		logger: log,
 		stats:  imetrics.NewNamespaced(stats),
 
-		pipes:    map[string]<-chan types.Transaction{},
-		pipeLock: &sync.RWMutex{},
+		pipes:         map[string]<-chan types.Transaction{},
+		pipeConsumers: map[string]int{},
+		pipeLock:      &sync.RWMutex{},
+
+		pausable:     newPausableInputs(),
+		sharedInputs: newSharedInputs(),
+		concurrency:  newConcurrencyLimits(),
 
 		conditions: map[string]types.Condition{},
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
 
 	conf, err := conf.collapsed()
 	if err != nil {
@@ -311,6 +375,21 @@ func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
 	}
 }
 
+// RegisterEndpointSpec registers a server wide HTTP endpoint along with a spec
+// describing its operations.
+func (t *Type) RegisterEndpointSpec(spec api.EndpointSpec, h http.HandlerFunc) {
+	if len(t.stream) > 0 {
+		spec.Path = path.Join("/", t.stream, spec.Path)
+	}
+	if sReg, ok := t.apiReg.(interface {
+		RegisterEndpointSpec(spec api.EndpointSpec, h http.HandlerFunc)
+	}); ok {
+		sReg.RegisterEndpointSpec(spec, h)
+	} else if t.apiReg != nil {
+		t.apiReg.RegisterEndpoint(spec.Path, spec.Description, h)
+	}
+}
+
 // SetPipe registers a new transaction chan to a named pipe.
 func (t *Type) SetPipe(name string, tran <-chan types.Transaction) {
 	t.pipeLock.Lock()
@@ -338,6 +417,26 @@ func (t *Type) UnsetPipe(name string, tran <-chan types.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// PipeConsumerConnected records that a consumer has begun reading from a named
+// pipe.
+func (t *Type) PipeConsumerConnected(name string) {
+	t.pipeLock.Lock()
+	t.pipeConsumers[name]++
+	t.pipeLock.Unlock()
+}
+
+// PipeConsumerDisconnected records that a consumer has stopped reading from a
+// named pipe.
+func (t *Type) PipeConsumerDisconnected(name string) {
+	t.pipeLock.Lock()
+	if t.pipeConsumers[name] <= 1 {
+		delete(t.pipeConsumers, name)
+	} else {
+		t.pipeConsumers[name]--
+	}
+	t.pipeLock.Unlock()
+}
+
 //------------------------------------------------------------------------------
 
 // WithMetricsMapping returns a manager with the stored metrics exporter wrapped
@@ -348,6 +447,29 @@ func (t *Type) WithMetricsMapping(m *imetrics.Mapping) *Type {
 	return &newT
 }
 
+// Redactor returns the redactor to be applied to message contents before they
+// are serialised for observability purposes, which is nil when no redaction
+// rules are configured.
+func (t *Type) Redactor() *redact.Redactor {
+	return t.redactor
+}
+
+// MemoryBudget returns the budget of message bytes that inputs are permitted to
+// hold in flight, which is nil when no limit is configured.
+func (t *Type) MemoryBudget() *budget.Memory {
+	return t.memBudget
+}
+
+// ConcurrencyLimit returns a semaphore with a maximum number of slots that is
+// shared by all components of the current stream and label. Components without
+// a label are given a semaphore of their own.
+func (t *Type) ConcurrencyLimit(max int) *budget.Concurrency {
+	if t.component == "" {
+		return budget.NewConcurrency(max)
+	}
+	return t.concurrency.get(t.stream, t.component, max)
+}
+
 // Metrics returns an aggregator preset with the current component context.
 func (t *Type) Metrics() metrics.Type {
 	return t.stats
@@ -440,7 +562,11 @@ func (t *Type) NewCache(conf cache.Config) (types.Cache, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.cacheBundle.Init(conf, mgr)
+	c, err := t.cacheBundle.Init(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	return cache.WrapRetries(conf, c, mgr.Logger(), mgr.Metrics())
 }
 
 // StoreCache attempts to store a new cache resource. If an existing resource
@@ -460,6 +586,12 @@ func (t *Type) StoreCache(ctx context.Context, name string, conf cache.Config) e
 		}
 	}
 
+	if !conf.Enabled {
+		t.logger.Infof("Cache resource '%v' is disabled and will be skipped\n", name)
+		delete(t.caches, name)
+		return nil
+	}
+
 	newCache, err := t.forComponent("resource.cache." + name).NewCache(conf)
 	if err != nil {
 		return fmt.Errorf(
@@ -506,7 +638,20 @@ func (t *Type) NewInput(conf input.Config, hasBatchProc bool, pipelines ...types
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.inputBundle.Init(hasBatchProc, conf, mgr, pipelines...)
+	if t.capture != nil {
+		component := mgr.component
+		if component == "" {
+			component = "input"
+		}
+		pipelines = append([]types.PipelineConstructorFunc{func(i *int) (types.Pipeline, error) {
+			return pipeline.NewProcessor(mgr.logger, mgr.stats, t.capture.InputProcessor(component)), nil
+		}}, pipelines...)
+	}
+	in, err := t.inputBundle.Init(hasBatchProc, conf, mgr, pipelines...)
+	if err != nil || len(conf.Label) == 0 {
+		return in, err
+	}
+	return mgr.pausableInput(conf.Label, in), nil
 }
 
 // StoreInput attempts to store a new input resource. If an existing resource
@@ -575,7 +720,15 @@ func (t *Type) NewProcessor(conf processor.Config) (types.Processor, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.processorBundle.Init(conf, mgr)
+	if !conf.Enabled {
+		mgr.Logger().Infof("Processor of type '%v' is disabled and will be skipped\n", conf.Type)
+		return &processor.Noop{}, nil
+	}
+	p, err := t.processorBundle.Init(conf, mgr)
+	if err != nil || t.capture == nil || conf.Label == "" {
+		return p, err
+	}
+	return t.capture.WrapProcessor(conf.Label, p), nil
 }
 
 // StoreProcessor attempts to store a new processor resource. If an existing
@@ -599,13 +752,21 @@ func (t *Type) StoreProcessor(ctx context.Context, name string, conf processor.C
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	newProcessor, err := t.forComponent("resource.processor." + name).NewProcessor(conf)
+	mgr := t.forComponent("resource.processor." + name)
+	newProcessor, err := mgr.NewProcessor(conf)
 	if err != nil {
 		return fmt.Errorf(
 			"failed to create processor resource '%v' of type '%v': %w",
 			name, conf.Type, err,
 		)
 	}
+	if conf.Shared {
+		// Place the shared metrics alongside those of the processor itself.
+		if len(conf.Label) > 0 && mgr.component != conf.Label {
+			mgr = mgr.forComponent(conf.Label)
+		}
+		newProcessor = processor.NewShared(newProcessor, mgr.Metrics())
+	}
 
 	t.processors[name] = newProcessor
 	return nil
@@ -643,6 +804,9 @@ func (t *Type) NewOutput(conf output.Config, pipelines ...types.PipelineConstruc
 		}
 		mgr = t.forComponent(conf.Label)
 	}
+	if !conf.Enabled {
+		return nil, output.ErrOutputDisabled
+	}
 	return t.outputBundle.Init(conf, mgr, pipelines...)
 }
 
@@ -667,6 +831,12 @@ func (t *Type) StoreOutput(ctx context.Context, name string, conf output.Config)
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
+	if !conf.Enabled {
+		t.logger.Infof("Output resource '%v' is disabled and will be skipped\n", name)
+		delete(t.outputs, name)
+		return nil
+	}
+
 	tmpOutput, err := t.forComponent("resource.output." + name).NewOutput(conf)
 	if err == nil {
 		if t.outputs[name], err = wrapOutput(tmpOutput); err != nil {
@@ -735,6 +905,12 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 		}
 	}
 
+	if !conf.Enabled {
+		t.logger.Infof("Rate limit resource '%v' is disabled and will be skipped\n", name)
+		delete(t.rateLimits, name)
+		return nil
+	}
+
 	newRateLimit, err := t.forComponent("resource.rate_limit." + name).NewRateLimit(conf)
 	if err != nil {
 		return fmt.Errorf(
@@ -752,6 +928,8 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 // CloseAsync triggers the shut down of all resource types that implement the
 // lifetime interface types.Closable.
 func (t *Type) CloseAsync() {
+	t.sharedInputs.close()
+
 	t.resourceLock.Lock()
 	defer t.resourceLock.Unlock()
 
@@ -2,9 +2,15 @@ package manager_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/tracecapture"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/condition"
 	"github.com/Jeffail/benthos/v3/lib/input"
@@ -14,6 +20,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -243,6 +250,90 @@ func TestManagerCondition(t *testing.T) {
 	}
 }
 
+func TestManagerDisabledResources(t *testing.T) {
+	conf := manager.NewConfig()
+
+	cacheConf := cache.NewConfig()
+	cacheConf.Enabled = false
+	conf.Caches["foo"] = cacheConf
+	conf.Caches["bar"] = cache.NewConfig()
+
+	rlConf := ratelimit.NewConfig()
+	rlConf.Enabled = false
+	conf.RateLimits["foo"] = rlConf
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Bloblang = "root = deleted()"
+	procConf.Enabled = false
+	conf.Processors["foo"] = procConf
+
+	outConf := output.NewConfig()
+	outConf.Type = output.TypeDrop
+	outConf.Enabled = false
+	conf.Outputs["foo"] = outConf
+
+	mgr, err := manager.New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = mgr.GetOutput("foo")
+	assert.Error(t, err)
+
+	_, err = mgr.NewOutput(outConf)
+	assert.Equal(t, output.ErrOutputDisabled, err)
+
+	_, err = mgr.GetCache("foo")
+	assert.Error(t, err)
+	_, err = mgr.GetCache("bar")
+	assert.NoError(t, err)
+
+	_, err = mgr.GetRateLimit("foo")
+	assert.Error(t, err)
+
+	require.NoError(t, mgr.AccessProcessor(context.Background(), "foo", func(p types.Processor) {
+		assert.IsType(t, &processor.Noop{}, p)
+	}))
+}
+
+func TestManagerProcessorShared(t *testing.T) {
+	conf := manager.NewConfig()
+
+	sharedConf := processor.NewConfig()
+	sharedConf.Type = processor.TypeBloblang
+	sharedConf.Bloblang = "root = this"
+	sharedConf.Shared = true
+	conf.Processors["foo"] = sharedConf
+
+	conf.Processors["bar"] = processor.NewConfig()
+
+	mgr, err := manager.New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.AccessProcessor(context.Background(), "foo", func(p types.Processor) {
+		assert.IsType(t, &processor.Shared{}, p)
+	}))
+	require.NoError(t, mgr.AccessProcessor(context.Background(), "bar", func(p types.Processor) {
+		assert.NotEqual(t, "*processor.Shared", fmt.Sprintf("%T", p))
+	}))
+}
+
+func TestManagerConcurrencyLimit(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	fooA := interop.GetConcurrencyLimit(mgr.ForComponent("foo"), 2)
+	fooB := interop.GetConcurrencyLimit(mgr.ForComponent("foo"), 2)
+	assert.Same(t, fooA, fooB)
+
+	assert.NotSame(t, fooA, interop.GetConcurrencyLimit(mgr.ForComponent("bar"), 2))
+	assert.NotSame(t, fooA, interop.GetConcurrencyLimit(mgr.ForStream("baz").(*manager.Type).ForComponent("foo"), 2))
+	assert.NotSame(t, interop.GetConcurrencyLimit(mgr, 2), interop.GetConcurrencyLimit(mgr, 2))
+
+	fooC := interop.GetConcurrencyLimit(mgr.ForComponent("foo"), 3)
+	assert.NotSame(t, fooA, fooC)
+	assert.Equal(t, 3, fooC.Max())
+}
+
 func TestManagerProcessor(t *testing.T) {
 	conf := manager.NewConfig()
 	conf.Processors["foo"] = processor.NewConfig()
@@ -515,3 +606,135 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerTraceCapture(t *testing.T) {
+	captureConf := tracecapture.NewConfig()
+	captureConf.Limit = 1
+
+	capture, err := tracecapture.New(captureConf)
+	require.NoError(t, err)
+
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop(), manager.OptSetTraceCapture(capture))
+	require.NoError(t, err)
+
+	inConf := input.NewConfig()
+	inConf.Type = input.TypeGenerate
+	inConf.Label = "foo"
+	inConf.Generate.Mapping = `root = "hello world"`
+	inConf.Generate.Interval = ""
+	inConf.Generate.Count = 1
+
+	in, err := mgr.NewInput(inConf, false)
+	require.NoError(t, err)
+
+	var msg types.Message
+	select {
+	case tran, open := <-in.TransactionChan():
+		require.True(t, open)
+		msg = tran.Payload
+		go func() {
+			tran.ResponseChan <- response.NewAck()
+		}()
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	for _, label := range []string{"bar", ""} {
+		procConf := processor.NewConfig()
+		procConf.Type = processor.TypeBloblang
+		procConf.Bloblang = `root = content().uppercase()`
+		procConf.Label = label
+
+		proc, err := mgr.NewProcessor(procConf)
+		require.NoError(t, err)
+
+		msgs, res := proc.ProcessMessage(msg)
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		msg = msgs[0]
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+
+	traces := capture.Traces()
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0].Snapshots, 2)
+	assert.Equal(t, "foo", traces[0].Snapshots[0].Component)
+	assert.Equal(t, "hello world", traces[0].Snapshots[0].Content)
+	assert.Equal(t, "bar", traces[0].Snapshots[1].Component)
+	assert.Equal(t, "HELLO WORLD", traces[0].Snapshots[1].Content)
+}
+
+//------------------------------------------------------------------------------
+
+type mockAPIReg struct {
+	handlers map[string]http.HandlerFunc
+}
+
+func (m *mockAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	m.handlers[path] = h
+}
+
+func TestManagerInputPauseResume(t *testing.T) {
+	apiReg := &mockAPIReg{handlers: map[string]http.HandlerFunc{}}
+
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), apiReg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	strmMgr := mgr.ForStream("baz").(*manager.Type)
+
+	inConf := input.NewConfig()
+	inConf.Type = input.TypeGenerate
+	inConf.Label = "foo"
+	inConf.Generate.Mapping = `root = "hello world"`
+	inConf.Generate.Interval = ""
+
+	in, err := strmMgr.NewInput(inConf, false)
+	require.NoError(t, err)
+
+	readTran := func(expected bool) {
+		t.Helper()
+		select {
+		case tran, open := <-in.TransactionChan():
+			require.True(t, open)
+			require.True(t, expected, "unexpected transaction")
+			go func() {
+				tran.ResponseChan <- response.NewAck()
+			}()
+		case <-time.After(time.Millisecond * 200):
+			require.False(t, expected, "timed out")
+		}
+	}
+
+	call := func(path, method string) int {
+		t.Helper()
+		h, exists := apiReg.handlers[path]
+		require.True(t, exists, path)
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	readTran(true)
+	assert.Empty(t, strmMgr.PausedInputs())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, call("/baz/inputs/foo/pause", "GET"))
+	assert.Equal(t, http.StatusOK, call("/baz/inputs/foo/pause", "POST"))
+	assert.Equal(t, []string{"foo"}, strmMgr.PausedInputs())
+	assert.Empty(t, mgr.PausedInputs())
+
+	readTran(false)
+
+	assert.Equal(t, http.StatusOK, call("/baz/inputs/foo/resume", "POST"))
+	assert.Empty(t, strmMgr.PausedInputs())
+
+	readTran(true)
+
+	assert.Equal(t, http.StatusOK, call("/baz/inputs/foo/pause", "POST"))
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+
+	assert.Empty(t, strmMgr.PausedInputs())
+	assert.Equal(t, http.StatusNotFound, call("/baz/inputs/foo/resume", "POST"))
+}
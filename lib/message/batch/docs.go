@@ -44,6 +44,10 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 				"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should end a batch.",
 				`this.type == "end_of_transaction"`,
 			).HasDefault("").Linter(docs.LintBloblangMapping),
+			docs.FieldAdvanced(
+				"flush_priority",
+				"A [priority](/docs/components/processors/priority) at or above which a message causes the batch it is added to be flushed immediately, allowing high priority messages to skip the wait for a batch to fill. If `0` disables priority based flushing.",
+			).HasDefault(0).AtVersion("3.50.0"),
 			docs.FieldDeprecated("condition").HasType(docs.FieldTypeCondition).OmitWhen(func(v, _ interface{}) (string, bool) {
 				m, ok := v.(map[string]interface{})
 				if !ok {
@@ -23,6 +23,7 @@ func TestBatchPolicySanit(t *testing.T) {
 byte_size: 0
 period: ""
 check: ""
+flush_priority: 0
 processors: []
 `
 
@@ -8,6 +8,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/interop"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/condition"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -27,11 +28,12 @@ func SanitisePolicyConfig(policy PolicyConfig) (interface{}, error) {
 		}
 	}
 	bSanit := map[string]interface{}{
-		"byte_size":  policy.ByteSize,
-		"count":      policy.Count,
-		"check":      policy.Check,
-		"period":     policy.Period,
-		"processors": procConfs,
+		"byte_size":      policy.ByteSize,
+		"count":          policy.Count,
+		"check":          policy.Check,
+		"period":         policy.Period,
+		"flush_priority": policy.FlushPriority,
+		"processors":     procConfs,
 	}
 	if !isNoopCondition(policy.Condition) {
 		condSanit, err := condition.SanitiseConfig(policy.Condition)
@@ -51,12 +53,13 @@ func isNoopCondition(conf condition.Config) bool {
 
 // PolicyConfig contains configuration parameters for a batch policy.
 type PolicyConfig struct {
-	ByteSize   int                `json:"byte_size" yaml:"byte_size"`
-	Count      int                `json:"count" yaml:"count"`
-	Condition  condition.Config   `json:"condition" yaml:"condition"`
-	Check      string             `json:"check" yaml:"check"`
-	Period     string             `json:"period" yaml:"period"`
-	Processors []processor.Config `json:"processors" yaml:"processors"`
+	ByteSize      int                `json:"byte_size" yaml:"byte_size"`
+	Count         int                `json:"count" yaml:"count"`
+	Condition     condition.Config   `json:"condition" yaml:"condition"`
+	Check         string             `json:"check" yaml:"check"`
+	Period        string             `json:"period" yaml:"period"`
+	FlushPriority int                `json:"flush_priority" yaml:"flush_priority"`
+	Processors    []processor.Config `json:"processors" yaml:"processors"`
 }
 
 // NewPolicyConfig creates a default PolicyConfig.
@@ -65,12 +68,13 @@ func NewPolicyConfig() PolicyConfig {
 	cond.Type = "static"
 	cond.Static = false
 	return PolicyConfig{
-		ByteSize:   0,
-		Count:      0,
-		Condition:  cond,
-		Check:      "",
-		Period:     "",
-		Processors: []processor.Config{},
+		ByteSize:      0,
+		Count:         0,
+		Condition:     cond,
+		Check:         "",
+		Period:        "",
+		FlushPriority: 0,
+		Processors:    []processor.Config{},
 	}
 }
 
@@ -91,6 +95,9 @@ func (p PolicyConfig) IsNoop() bool {
 	if len(p.Period) > 0 {
 		return false
 	}
+	if p.FlushPriority > 0 {
+		return false
+	}
 	if len(p.Processors) > 0 {
 		return false
 	}
@@ -113,6 +120,9 @@ func (p PolicyConfig) isLimited() bool {
 	if len(p.Check) > 0 {
 		return true
 	}
+	if p.FlushPriority > 0 {
+		return true
+	}
 	return false
 }
 
@@ -136,23 +146,25 @@ func (p PolicyConfig) isHardLimited() bool {
 type Policy struct {
 	log log.Modular
 
-	byteSize  int
-	count     int
-	period    time.Duration
-	cond      condition.Type
-	check     *mapping.Executor
-	procs     []types.Processor
-	sizeTally int
-	parts     []types.Part
+	byteSize      int
+	count         int
+	period        time.Duration
+	flushPriority int
+	cond          condition.Type
+	check         *mapping.Executor
+	procs         []types.Processor
+	sizeTally     int
+	parts         []types.Part
 
 	triggered bool
 	lastBatch time.Time
 
-	mSizeBatch   metrics.StatCounter
-	mCountBatch  metrics.StatCounter
-	mPeriodBatch metrics.StatCounter
-	mCheckBatch  metrics.StatCounter
-	mCondBatch   metrics.StatCounter
+	mSizeBatch     metrics.StatCounter
+	mCountBatch    metrics.StatCounter
+	mPeriodBatch   metrics.StatCounter
+	mCheckBatch    metrics.StatCounter
+	mCondBatch     metrics.StatCounter
+	mPriorityBatch metrics.StatCounter
 }
 
 // NewPolicy creates an empty policy with default rules.
@@ -162,6 +174,9 @@ func NewPolicy(
 	log log.Modular,
 	stats metrics.Type,
 ) (*Policy, error) {
+	if conf.FlushPriority < 0 {
+		return nil, fmt.Errorf("flush_priority must not be negative, got %v", conf.FlushPriority)
+	}
 	if !conf.isLimited() {
 		return nil, errors.New("batch policy must have at least one active trigger")
 	}
@@ -200,20 +215,22 @@ func NewPolicy(
 	return &Policy{
 		log: log,
 
-		byteSize: conf.ByteSize,
-		count:    conf.Count,
-		period:   period,
-		cond:     cond,
-		check:    check,
-		procs:    procs,
+		byteSize:      conf.ByteSize,
+		count:         conf.Count,
+		period:        period,
+		flushPriority: conf.FlushPriority,
+		cond:          cond,
+		check:         check,
+		procs:         procs,
 
 		lastBatch: time.Now(),
 
-		mSizeBatch:   stats.GetCounter("on_size"),
-		mCountBatch:  stats.GetCounter("on_count"),
-		mPeriodBatch: stats.GetCounter("on_period"),
-		mCheckBatch:  stats.GetCounter("on_check"),
-		mCondBatch:   stats.GetCounter("on_condition"),
+		mSizeBatch:     stats.GetCounter("on_size"),
+		mCountBatch:    stats.GetCounter("on_count"),
+		mPeriodBatch:   stats.GetCounter("on_period"),
+		mCheckBatch:    stats.GetCounter("on_check"),
+		mCondBatch:     stats.GetCounter("on_condition"),
+		mPriorityBatch: stats.GetCounter("on_priority"),
 	}, nil
 }
 
@@ -235,6 +252,11 @@ func (p *Policy) Add(part types.Part) bool {
 		p.mSizeBatch.Incr(1)
 		p.log.Traceln("Batching based on byte_size")
 	}
+	if !p.triggered && p.flushPriority > 0 && imessage.GetPriority(part) >= p.flushPriority {
+		p.triggered = true
+		p.mPriorityBatch.Incr(1)
+		p.log.Traceln("Batching based on priority")
+	}
 	tmpMsg := message.New(nil)
 	tmpMsg.Append(part)
 	if p.cond != nil && !p.triggered && p.cond.Check(tmpMsg) {
@@ -261,29 +283,52 @@ func (p *Policy) Add(part types.Part) bool {
 // Flush clears all messages stored by this batch policy. Returns nil if the
 // policy is currently empty.
 func (p *Policy) Flush() types.Message {
-	var newMsg types.Message
+	return mergeMessages(p.FlushAny())
+}
 
-	resultMsgs := p.FlushAny()
-	if len(resultMsgs) == 1 {
-		newMsg = resultMsgs[0]
-	} else if len(resultMsgs) > 1 {
-		newMsg = message.New(nil)
-		var parts []types.Part
-		for _, m := range resultMsgs {
-			m.Iter(func(_ int, p types.Part) error {
-				parts = append(parts, p)
-				return nil
-			})
-		}
-		newMsg.SetAll(parts)
+// FlushWithError clears all messages stored by this batch policy and returns
+// them as a single batch along with any error returned by the processors of the
+// policy, in which case the batch is dropped. Returns a nil message and error if
+// the policy is currently empty or the processors filtered all messages.
+func (p *Policy) FlushWithError() (types.Message, error) {
+	resultMsgs, err := p.flushAny()
+	if err != nil {
+		return nil, err
 	}
-	return newMsg
+	return mergeMessages(resultMsgs), nil
 }
 
 // FlushAny clears all messages stored by this batch policy and returns any
 // number of discrete message batches. Returns nil if the policy is currently
 // empty.
 func (p *Policy) FlushAny() []types.Message {
+	resultMsgs, err := p.flushAny()
+	if err != nil {
+		p.log.Errorf("Batch processors resulted in error: %v, the batch has been dropped.", err)
+	}
+	return resultMsgs
+}
+
+func mergeMessages(msgs []types.Message) types.Message {
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	newMsg := message.New(nil)
+	var parts []types.Part
+	for _, m := range msgs {
+		m.Iter(func(_ int, p types.Part) error {
+			parts = append(parts, p)
+			return nil
+		})
+	}
+	newMsg.SetAll(parts)
+	return newMsg
+}
+
+func (p *Policy) flushAny() ([]types.Message, error) {
 	var newMsg types.Message
 	if len(p.parts) > 0 {
 		if !p.triggered && p.period > 0 && time.Since(p.lastBatch) > p.period {
@@ -299,21 +344,18 @@ func (p *Policy) FlushAny() []types.Message {
 	p.triggered = false
 
 	if newMsg == nil {
-		return nil
+		return nil, nil
 	}
 
 	if len(p.procs) > 0 {
 		resultMsgs, res := processor.ExecuteAll(p.procs, newMsg)
 		if res != nil {
-			if err := res.Error(); err != nil {
-				p.log.Errorf("Batch processors resulted in error: %v, the batch has been dropped.", err)
-			}
-			return nil
+			return nil, res.Error()
 		}
-		return resultMsgs
+		return resultMsgs, nil
 	}
 
-	return []types.Message{newMsg}
+	return []types.Message{newMsg}, nil
 }
 
 // Count returns the number of currently buffered message parts within this
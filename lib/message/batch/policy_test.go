@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/condition"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -37,6 +38,10 @@ func TestPolicyNoop(t *testing.T) {
 	conf = NewPolicyConfig()
 	conf.Period = "10s"
 	assert.False(t, conf.IsNoop())
+
+	conf = NewPolicyConfig()
+	conf.FlushPriority = 5
+	assert.False(t, conf.IsNoop())
 }
 
 func TestPolicyBasic(t *testing.T) {
@@ -157,6 +162,34 @@ func TestPolicySize(t *testing.T) {
 	}
 }
 
+func TestPolicyFlushPriority(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Count = 10
+	conf.FlushPriority = 5
+
+	pol, err := NewPolicy(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pol.CloseAsync()
+		require.NoError(t, pol.WaitForClose(time.Second))
+	})
+
+	assert.False(t, pol.Add(message.NewPart([]byte("foo"))))
+	assert.False(t, pol.Add(imessage.WithPriority(message.NewPart([]byte("bar")), 4)))
+	assert.True(t, pol.Add(imessage.WithPriority(message.NewPart([]byte("baz")), 5)))
+
+	msg := pol.Flush()
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, message.GetAllBytes(msg))
+	assert.Equal(t, 5, imessage.GetPriority(msg.Get(2)))
+
+	assert.False(t, pol.Add(message.NewPart([]byte("qux"))))
+
+	conf.FlushPriority = -1
+	_, err = NewPolicy(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
+
 func TestPolicyCheck(t *testing.T) {
 	conf := NewPolicyConfig()
 	conf.Check = `content() == "bar"`
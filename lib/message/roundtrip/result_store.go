@@ -99,14 +99,48 @@ func AddResultStore(msg types.Message, store ResultStore) {
 // SetAsResponse takes a mutated message and stores it as a response message,
 // this action fails if the message does not contain a valid ResultStore within
 // its context.
+//
+// Only the message parts that share the ResultStore of the first part are
+// stored, as parts that originated from other requests cannot be returned to
+// the same origin. Use StoreResponse in order to obtain a count of the ignored
+// parts.
 func SetAsResponse(msg types.Message) error {
+	_, err := StoreResponse(msg)
+	return err
+}
+
+// StoreResponse takes a mutated message and stores the parts that share the
+// ResultStore of the first part as a response message, returning the number of
+// parts that were ignored due to originating from a different ResultStore.
+// This action fails if the first part of the message does not contain a valid
+// ResultStore within its context.
+func StoreResponse(msg types.Message) (int, error) {
 	ctx := message.GetContext(msg.Get(0))
 	store, ok := ctx.Value(ResultStoreKey).(ResultStore)
 	if !ok {
-		return ErrNoStore
+		return 0, ErrNoStore
+	}
+
+	var ignored int
+	var parts []types.Part
+	msg.Iter(func(i int, p types.Part) error {
+		if i > 0 {
+			if pStore, ok := message.GetContext(p).Value(ResultStoreKey).(ResultStore); ok && pStore != store {
+				ignored++
+				return nil
+			}
+		}
+		parts = append(parts, p)
+		return nil
+	})
+
+	if ignored > 0 {
+		filtered := msg.Copy()
+		filtered.SetAll(parts)
+		msg = filtered
 	}
 	store.Add(msg)
-	return nil
+	return ignored, nil
 }
 
 //------------------------------------------------------------------------------
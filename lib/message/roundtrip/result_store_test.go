@@ -40,3 +40,49 @@ func TestResultStore(t *testing.T) {
 		t.Errorf("Unexpected count of stored messages: %v != %v", act, exp)
 	}
 }
+
+func TestResultStoreIgnoresOtherOrigins(t *testing.T) {
+	impl := &resultStoreImpl{}
+	other := &resultStoreImpl{}
+
+	msg := message.New(nil)
+	for _, v := range []struct {
+		content string
+		store   ResultStore
+	}{
+		{"foo", impl},
+		{"bar", other},
+		{"baz", impl},
+	} {
+		ctx := context.WithValue(context.Background(), ResultStoreKey, v.store)
+		msg.Append(message.WithContext(ctx, message.NewPart([]byte(v.content))))
+	}
+
+	ignored, err := StoreResponse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 1, ignored; exp != act {
+		t.Errorf("Wrong count of ignored parts: %v != %v", act, exp)
+	}
+	if exp, act := 3, msg.Len(); exp != act {
+		t.Errorf("Original message was modified: %v != %v", act, exp)
+	}
+
+	results := impl.Get()
+	if len(results) != 1 {
+		t.Fatalf("Wrong count of result batches: %v", len(results))
+	}
+	if results[0].Len() != 2 {
+		t.Fatalf("Wrong count of messages: %v", results[0].Len())
+	}
+	if exp, act := "foo", string(results[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong message contents: %v != %v", act, exp)
+	}
+	if exp, act := "baz", string(results[0].Get(1).Get()); exp != act {
+		t.Errorf("Wrong message contents: %v != %v", act, exp)
+	}
+	if exp, act := 0, len(other.Get()); exp != act {
+		t.Errorf("Unexpected count of stored messages: %v != %v", act, exp)
+	}
+}
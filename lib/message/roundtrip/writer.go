@@ -3,6 +3,7 @@ package roundtrip
 import (
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -12,7 +13,16 @@ import (
 // in the context of the first message part of each batch. This is essentially a
 // mechanism that returns the result of a pipeline directly back to the origin
 // of the message.
-type Writer struct{}
+type Writer struct {
+	log log.Modular
+}
+
+// NewWriter returns a Writer that logs a warning whenever message parts are
+// ignored due to originating from a different request than the first part of
+// their batch.
+func NewWriter(log log.Modular) Writer {
+	return Writer{log: log}
+}
 
 // Connect is a noop.
 func (s Writer) Connect() error {
@@ -22,7 +32,11 @@ func (s Writer) Connect() error {
 // Write a message batch to a ResultStore located in the first message of the
 // batch.
 func (s Writer) Write(msg types.Message) error {
-	return SetAsResponse(msg)
+	ignored, err := StoreResponse(msg)
+	if ignored > 0 && s.log != nil {
+		s.log.Warnf("Ignoring %v message parts of a sync response batch as they originated from a different request\n", ignored)
+	}
+	return err
 }
 
 // CloseAsync is a noop.
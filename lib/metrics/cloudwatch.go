@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -315,7 +316,8 @@ func newCloudWatch(config CloudWatchConfig, opts ...func(Type)) (Type, error) {
 		return nil, fmt.Errorf("failed to init path mapping: %v", err)
 	}
 
-	sess, err := config.GetSession()
+	// Failovers are only logged as this metrics type cannot count its own.
+	sess, err := config.GetSessionWithFailover(session.LogFailover(c.log, DudStat{}))
 	if err != nil {
 		return nil, err
 	}
@@ -331,6 +333,22 @@ func newCloudWatch(config CloudWatchConfig, opts ...func(Type)) (Type, error) {
 
 //------------------------------------------------------------------------------
 
+const maxCloudWatchDimensionValueLen = 1024
+
+// SanitiseLabelValue ensures that a label value is a valid CloudWatch
+// dimension value, which must be valid UTF-8, non-empty and no longer than
+// 1024 characters.
+func (c *CloudWatch) SanitiseLabelValue(v string) string {
+	v = strings.ToValidUTF8(v, "�")
+	if v == "" {
+		return "none"
+	}
+	if runes := []rune(v); len(runes) > maxCloudWatchDimensionValueLen {
+		v = string(runes[:maxCloudWatchDimensionValueLen])
+	}
+	return v
+}
+
 func (c *CloudWatch) toCMName(dotSepName string) (outPath string, labelNames, labelValues []string) {
 	return c.pathMapping.mapPathWithTags(dotSepName)
 }
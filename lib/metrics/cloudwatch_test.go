@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -535,3 +536,15 @@ func TestCloudWatchTagsMoreThan20(t *testing.T) {
 		},
 	}, checkInput(mockSvc.inputs[0]))
 }
+
+func TestCloudWatchSanitiseLabelValue(t *testing.T) {
+	cw := &CloudWatch{}
+
+	assert.Equal(t, "foo", SanitiseLabelValue(cw, "foo"))
+	assert.Equal(t, "none", SanitiseLabelValue(cw, ""))
+	assert.Equal(t, "foo�bar", SanitiseLabelValue(cw, "foo\xffbar"))
+	assert.Len(t, SanitiseLabelValue(cw, strings.Repeat("a", 2000)), 1024)
+
+	assert.Equal(t, "foo�bar", SanitiseLabelValue(Noop(), "foo\xffbar"))
+	assert.Equal(t, "none", SanitiseLabelValue(Namespaced(cw, "foo"), ""))
+}
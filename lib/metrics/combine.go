@@ -30,6 +30,11 @@ func unwrapMetric(t Type) Type {
 	return t
 }
 
+// SanitiseLabelValue applies the label value rules of both metrics types.
+func (c *combinedWrapper) SanitiseLabelValue(v string) string {
+	return SanitiseLabelValue(c.t2, SanitiseLabelValue(c.t1, v))
+}
+
 // Unwrap to the underlying metrics type.
 func (c *combinedWrapper) Unwrap() Type {
 	t1 := unwrapMetric(c.t1)
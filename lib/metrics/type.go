@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 )
@@ -100,3 +101,22 @@ type WithHandlerFunc interface {
 }
 
 //------------------------------------------------------------------------------
+
+// LabelValueSanitiser is an interface for metrics types that place
+// restrictions on the values of labels. Label values derived from message
+// contents should be passed through SanitiseLabelValue before use.
+type LabelValueSanitiser interface {
+	SanitiseLabelValue(v string) string
+}
+
+// SanitiseLabelValue returns a label value that is safe to use with a metrics
+// type, using the rules of the type when it implements LabelValueSanitiser and
+// otherwise ensuring that the value is valid UTF-8.
+func SanitiseLabelValue(t Type, v string) string {
+	if s, ok := unwrapMetric(t).(LabelValueSanitiser); ok {
+		return s.SanitiseLabelValue(v)
+	}
+	return strings.ToValidUTF8(v, "�")
+}
+
+//------------------------------------------------------------------------------
@@ -42,6 +42,7 @@ Please use [` + "`amqp_0_9`" + `](/docs/components/outputs/amqp_0_9) instead.`,
 			docs.FieldAdvanced("persistent", "Whether message delivery should be persistent (transient by default)."),
 			docs.FieldAdvanced("mandatory", "Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned."),
 			docs.FieldAdvanced("immediate", "Whether to set the immediate flag on published messages. When set if there are no ready consumers of a queue then the message is dropped instead of waiting."),
+			docs.FieldDeprecated("keepalive_interval", "Keepalive checks are not supported by this output, use `amqp_0_9` instead."),
 			tls.FieldSpec(),
 		},
 	}
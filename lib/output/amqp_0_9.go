@@ -54,6 +54,7 @@ The fields 'key' and 'type' can be dynamically set using function interpolations
 			docs.FieldAdvanced("persistent", "Whether message delivery should be persistent (transient by default)."),
 			docs.FieldAdvanced("mandatory", "Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned."),
 			docs.FieldAdvanced("immediate", "Whether to set the immediate flag on published messages. When set if there are no ready consumers of a queue then the message is dropped instead of waiting."),
+			docs.FieldAdvanced("keepalive_interval", "An optional period of inactivity after which the connection is checked for heartbeat failures, reconnecting proactively when it has been closed. The timestamp of the last successful check is exposed as the gauge `connection.last_ping`.", "30s").AtVersion("3.50.0"),
 			tls.FieldSpec(),
 		},
 		Categories: []Category{
@@ -76,6 +77,9 @@ func NewAMQP09(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 	if err != nil {
 		return nil, err
 	}
+	if err = setKeepaliveInterval(w, conf.AMQP09.KeepaliveInterval); err != nil {
+		return nil, err
+	}
 	return OnlySinglePayloads(w), nil
 }
 
@@ -2,6 +2,7 @@ package output
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/component"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/correlation"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -37,15 +41,28 @@ type AsyncSink interface {
 	types.Closable
 }
 
+// AsyncPinger is an optional interface for an AsyncSink that is able to check
+// the health of its connection whilst idle.
+type AsyncPinger interface {
+	// PingWithContext checks whether the connection of the sink is healthy and
+	// returns an error if it is not. When the connection is found to be broken
+	// the sink should also discard it, so that a subsequent call to
+	// ConnectWithContext establishes a new one.
+	PingWithContext(ctx context.Context) error
+}
+
 // AsyncWriter is an output type that writes messages to a writer.Type.
 type AsyncWriter struct {
 	isConnected int32
+	lastWrite   int64
 
 	typeStr     string
 	maxInflight int
 	noCancel    bool
 	writer      AsyncSink
 
+	keepaliveInterval time.Duration
+
 	injectTracingMap *mapping.Executor
 
 	log   log.Modular
@@ -93,6 +110,14 @@ func (w *AsyncWriter) SetNoCancel() {
 	w.noCancel = true
 }
 
+// SetKeepaliveInterval configures the async writer to ping the connection of
+// its writer after it has been idle for a given period, reconnecting when the
+// ping fails. This has no effect when the writer does not implement
+// AsyncPinger, and a period of zero disables keepalive pings.
+func (w *AsyncWriter) SetKeepaliveInterval(interval time.Duration) {
+	w.keepaliveInterval = interval
+}
+
 //------------------------------------------------------------------------------
 
 func (w *AsyncWriter) latencyMeasuringWrite(msg types.Message) (latencyNs int64, err error) {
@@ -107,6 +132,7 @@ func (w *AsyncWriter) latencyMeasuringWrite(msg types.Message) (latencyNs int64,
 	}
 	err = w.writer.WriteWithContext(ctx, msg)
 	latencyNs = time.Since(t0).Nanoseconds()
+	atomic.StoreInt64(&w.lastWrite, t0.Add(time.Duration(latencyNs)).UnixNano())
 	return latencyNs, err
 }
 
@@ -165,6 +191,7 @@ func (w *AsyncWriter) loop() {
 		mConn       = w.stats.GetCounter("connection.up")
 		mFailedConn = w.stats.GetCounter("connection.failed")
 		mLostConn   = w.stats.GetCounter("connection.lost")
+		mLastPing   = w.stats.GetGauge("connection.last_ping")
 	)
 
 	defer func() {
@@ -245,6 +272,13 @@ func (w *AsyncWriter) loop() {
 	writerLoop := func() {
 		defer wg.Done()
 
+		// Writes rejected due to throttling are not acknowledged until after a
+		// backoff in order to avoid hammering the target with reattempts.
+		throttleBackoff := backoff.NewExponentialBackOff()
+		throttleBackoff.InitialInterval = time.Millisecond * 100
+		throttleBackoff.MaxInterval = time.Second * 5
+		throttleBackoff.MaxElapsedTime = 0
+
 		for {
 			var ts types.Transaction
 			var open bool
@@ -278,11 +312,12 @@ func (w *AsyncWriter) loop() {
 				if w.typeStr != TypeReject {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
-					w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+					correlation.WithFields(w.log, ts.Payload).Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
 				} else {
 					w.log.Debugf("Rejecting message: %v\n", err)
 				}
 			} else {
+				throttleBackoff.Reset()
 				mSent.Incr(1)
 				mPartsSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
 				mBytesSent.Incr(int64(message.GetAllBytesLen(ts.Payload)))
@@ -294,6 +329,14 @@ func (w *AsyncWriter) loop() {
 				s.Finish()
 			}
 
+			if err != nil && output.ClassifyError(err) == output.ErrorClassThrottled {
+				select {
+				case <-time.After(throttleBackoff.NextBackOff()):
+				case <-w.shutSig.CloseAtLeisureChan():
+					return
+				}
+			}
+
 			select {
 			case ts.ResponseChan <- response.NewError(err):
 			case <-w.shutSig.CloseAtLeisureChan():
@@ -302,10 +345,76 @@ func (w *AsyncWriter) loop() {
 		}
 	}
 
+	// Pings the connection of the writer whenever it has been idle for the
+	// keepalive interval, and reconnects proactively when the ping fails.
+	keepaliveStopChan := make(chan struct{})
+	keepaliveLoop := func(pinger AsyncPinger, doneChan chan<- struct{}) {
+		defer close(doneChan)
+
+		ticker := time.NewTicker(w.keepaliveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-keepaliveStopChan:
+				return
+			case <-w.shutSig.CloseAtLeisureChan():
+				return
+			}
+
+			lastWrite := time.Unix(0, atomic.LoadInt64(&w.lastWrite))
+			if time.Since(lastWrite) < w.keepaliveInterval || atomic.LoadInt32(&w.isConnected) == 0 {
+				continue
+			}
+
+			pingCtx, pingDone := w.shutSig.CloseAtLeisureCtx(context.Background())
+			pingCtx, pingTimeout := context.WithTimeout(pingCtx, w.keepaliveInterval)
+			err := pinger.PingWithContext(pingCtx)
+			pingTimeout()
+			pingDone()
+
+			if err == nil {
+				mLastPing.Set(time.Now().Unix())
+				continue
+			}
+			if w.shutSig.ShouldCloseAtLeisure() {
+				return
+			}
+
+			w.log.Warnf("Keepalive ping to %v failed, reconnecting: %v\n", w.typeStr, err)
+			atomic.StoreInt32(&w.isConnected, 0)
+
+			connectMut.Lock()
+			mLostConn.Incr(1)
+			connected := initConnection()
+			if connected {
+				atomic.StoreInt32(&w.isConnected, 1)
+				mConn.Incr(1)
+			}
+			connectMut.Unlock()
+
+			if !connected {
+				return
+			}
+		}
+	}
+
+	var keepaliveDoneChan chan struct{}
+	if pinger, ok := w.writer.(AsyncPinger); ok && w.keepaliveInterval > 0 {
+		keepaliveDoneChan = make(chan struct{})
+		go keepaliveLoop(pinger, keepaliveDoneChan)
+	}
+
 	for i := 0; i < w.maxInflight; i++ {
 		go writerLoop()
 	}
 	wg.Wait()
+
+	if keepaliveDoneChan != nil {
+		close(keepaliveStopChan)
+		<-keepaliveDoneChan
+	}
 }
 
 // Consume assigns a messages channel for the output to read.
@@ -324,6 +433,12 @@ func (w *AsyncWriter) Connected() bool {
 	return atomic.LoadInt32(&w.isConnected) == 1
 }
 
+// SelfTest runs the self test of the underlying writer, which verifies that it
+// is able to reach and authenticate with its target without writing messages.
+func (w *AsyncWriter) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, w.writer)
+}
+
 // MaxInFlight returns the maximum number of in flight messages permitted by the
 // output. This value can be used to determine a sensible value for parent
 // outputs, but should not be relied upon as part of dispatcher logic.
@@ -345,3 +460,23 @@ func (w *AsyncWriter) WaitForClose(timeout time.Duration) error {
 	}
 	return nil
 }
+
+//------------------------------------------------------------------------------
+
+// setKeepaliveInterval parses a keepalive_interval config field and applies it
+// to an output, which must be an AsyncWriter unless the field is empty.
+func setKeepaliveInterval(w Type, interval string) error {
+	if interval == "" {
+		return nil
+	}
+	aw, ok := w.(*AsyncWriter)
+	if !ok {
+		return fmt.Errorf("unable to set a keepalive_interval due to wrong type: %T", w)
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return fmt.Errorf("failed to parse keepalive_interval: %w", err)
+	}
+	aw.SetKeepaliveInterval(d)
+	return nil
+}
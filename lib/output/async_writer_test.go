@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -635,3 +638,95 @@ func TestAsyncWriterSadPath(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+type mockPingWriter struct {
+	mockAsyncWriter
+
+	pings   int32
+	pingErr atomic.Value
+}
+
+func (w *mockPingWriter) ConnectWithContext(ctx context.Context) error {
+	select {
+	case err := <-w.connChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *mockPingWriter) PingWithContext(ctx context.Context) error {
+	atomic.AddInt32(&w.pings, 1)
+	if err, _ := w.pingErr.Swap(errNoPing).(error); err != errNoPing {
+		return err
+	}
+	return nil
+}
+
+var errNoPing = errors.New("no ping error")
+
+func TestAsyncWriterKeepalive(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := &mockPingWriter{mockAsyncWriter: *newAsyncMockWriter()}
+	writerImpl.pingErr.Store(errNoPing)
+
+	stats := metrics.NewLocal()
+	w, err := NewAsyncWriter("foo", 1, writerImpl, log.Noop(), stats)
+	require.NoError(t, err)
+	w.(*AsyncWriter).SetKeepaliveInterval(time.Millisecond * 10)
+
+	require.NoError(t, w.Consume(make(chan types.Transaction)))
+
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&writerImpl.pings) > 1
+	}, time.Second, time.Millisecond*5)
+	assert.Greater(t, stats.GetCounters()["connection.last_ping"], int64(0))
+
+	// A failed ping results in a reconnect.
+	writerImpl.pingErr.Store(errors.New("ping failed"))
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	assert.Eventually(t, func() bool {
+		return w.Connected()
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, int64(1), stats.GetCounters()["connection.lost"])
+	assert.Equal(t, int64(2), stats.GetCounters()["connection.up"])
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestAsyncWriterKeepaliveClosedChan(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := &mockPingWriter{mockAsyncWriter: *newAsyncMockWriter()}
+	writerImpl.pingErr.Store(errNoPing)
+
+	w, err := NewAsyncWriter("foo", 1, writerImpl, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	w.(*AsyncWriter).SetKeepaliveInterval(time.Millisecond * 10)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, w.Consume(tChan))
+
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// Closing the transactions channel also stops keepalive pings.
+	close(tChan)
+	require.NoError(t, w.WaitForClose(time.Second))
+}
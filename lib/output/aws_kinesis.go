@@ -25,6 +25,21 @@ fields can be dynamically set using function interpolations described
 [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages the
 interpolations are performed per message part.
 
+### Grouping
+
+Throughput limits are applied by Kinesis to each shard of a stream, and so a
+batch of records spanning many partition keys can be throttled by a single hot
+shard. Setting the field ` + "`group_by`" + ` to ` + "`partition_key`" + ` or
+` + "`shard`" + ` splits each batch into groups that are sent and retried
+independently, such that throttled records of one group do not delay the
+others. When grouping by shard the shards of the stream are listed on connect,
+and again whenever records are observed to land on an unexpected shard.
+
+Only records that fail are retried, and records rejected for reasons other than
+throttling fail individually. The counter metric ` + "`shard.send.throttled`" + `
+tracks throttled records with the label ` + "`shard`" + `, which can be used
+to detect hot shards.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -37,6 +52,11 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("stream", "The stream to publish messages to."),
 			docs.FieldCommon("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldAdvanced("hash_key", "A optional hash key for partitioning messages.").IsInterpolated(),
+			docs.FieldAdvanced("group_by", "Whether to split batches into groups that are sent independently.").HasAnnotatedOptions(
+				"none", "Send each batch as a whole.",
+				"partition_key", "Send the records of each partition key independently.",
+				"shard", "Send the records of each shard independently, where the shard of a record is determined by its hash key or partition key.",
+			).AtVersion("3.50.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
@@ -61,6 +81,21 @@ fields can be dynamically set using function interpolations described
 [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages the
 interpolations are performed per message part.
 
+### Grouping
+
+Throughput limits are applied by Kinesis to each shard of a stream, and so a
+batch of records spanning many partition keys can be throttled by a single hot
+shard. Setting the field ` + "`group_by`" + ` to ` + "`partition_key`" + ` or
+` + "`shard`" + ` splits each batch into groups that are sent and retried
+independently, such that throttled records of one group do not delay the
+others. When grouping by shard the shards of the stream are listed on connect,
+and again whenever records are observed to land on an unexpected shard.
+
+Only records that fail are retried, and records rejected for reasons other than
+throttling fail individually. The counter metric ` + "`shard.send.throttled`" + `
+tracks throttled records with the label ` + "`shard`" + `, which can be used
+to detect hot shards.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -73,6 +108,11 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("stream", "The stream to publish messages to."),
 			docs.FieldCommon("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldAdvanced("hash_key", "A optional hash key for partitioning messages.").IsInterpolated(),
+			docs.FieldAdvanced("group_by", "Whether to split batches into groups that are sent independently.").HasAnnotatedOptions(
+				"none", "Send each batch as a whole.",
+				"partition_key", "Send the records of each partition key independently.",
+				"shard", "Send the records of each shard independently, where the shard of a record is determined by its hash key or partition key.",
+			).AtVersion("3.50.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
@@ -1,8 +1,10 @@
 package output
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -18,17 +20,51 @@ func init() {
 		Summary: `
 Sends messages to an AWS SNS topic.`,
 		Description: `
+### Message Attributes
+
+Metadata values are sent along with the payload as message attributes with the
+data type String, and can be filtered with the field ` + "`metadata`" + `. Typed
+attributes can also be set with a
+[Bloblang mapping](/docs/guides/bloblang/about/) in the field
+` + "`message_attributes`" + `, which should result in an object where string
+values are sent with the data type String, numbers with the data type Number
+and bytes with the data type Binary. Attributes from the mapping take precedence
+over metadata values of the same name.
+
+### FIFO Topics
+
+The fields ` + "`message_group_id` and `message_deduplication_id`" + ` can be
+set dynamically using
+[function interpolations](/docs/configuration/interpolation#bloblang-queries), which are
+resolved individually for each message of a batch.
+
+### Batching
+
+Batches of messages are published using the PublishBatch API, with up to ten
+messages per call. Messages that are rejected, or that exceed the SNS payload
+limit of 256KB, fail individually rather than failing the whole batch.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
 services. It's also possible to set them explicitly at the component level,
 allowing you to transfer data across accounts. You can find out more
 [in this document](/docs/guides/aws).`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("topic_arn", "The topic to publish to."),
+			docs.FieldCommon("message_group_id", "An optional group ID to set for messages, which is required by FIFO topics.").IsInterpolated().AtVersion("3.50.0"),
+			docs.FieldCommon("message_deduplication_id", "An optional deduplication ID to set for messages.").IsInterpolated().AtVersion("3.50.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent as message attributes.").WithChildren(output.MetadataFields()...).AtVersion("3.50.0"),
+			docs.FieldString(
+				"message_attributes", "An optional [Bloblang mapping](/docs/guides/bloblang/about/) that results in an object of typed message attributes to send with each message.",
+				`root.event_type = this.type`,
+				`root = {"priority": this.priority, "signature": this.sig.decode("base64")}`,
+			).Advanced().Linter(docs.LintBloblangMapping).AtVersion("3.50.0"),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()),
 		Categories: []Category{
 			CategoryServices,
@@ -52,11 +88,21 @@ By default Benthos will use a shared credentials file when connecting to AWS
 services. It's also possible to set them explicitly at the component level,
 allowing you to transfer data across accounts. You can find out more
 [in this document](/docs/guides/aws).`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("topic_arn", "The topic to publish to."),
+			docs.FieldCommon("message_group_id", "An optional group ID to set for messages, which is required by FIFO topics.").IsInterpolated().AtVersion("3.50.0"),
+			docs.FieldCommon("message_deduplication_id", "An optional deduplication ID to set for messages.").IsInterpolated().AtVersion("3.50.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent as message attributes.").WithChildren(output.MetadataFields()...).AtVersion("3.50.0"),
+			docs.FieldString(
+				"message_attributes", "An optional [Bloblang mapping](/docs/guides/bloblang/about/) that results in an object of typed message attributes to send with each message.",
+				`root.event_type = this.type`,
+				`root = {"priority": this.priority, "signature": this.sig.decode("base64")}`,
+			).Advanced().Linter(docs.LintBloblangMapping).AtVersion("3.50.0"),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()),
 		Categories: []Category{
 			CategoryServices,
@@ -82,11 +128,16 @@ func newAmazonSNS(name string, conf writer.SNSConfig, mgr types.Manager, log log
 	if err != nil {
 		return nil, err
 	}
-	a, err := NewAsyncWriter(name, conf.MaxInFlight, s, log, stats)
+	var w Type
+	if conf.MaxInFlight == 1 {
+		w, err = NewWriter(name, s, log, stats)
+	} else {
+		w, err = NewAsyncWriter(name, conf.MaxInFlight, s, log, stats)
+	}
 	if err != nil {
-		return nil, err
+		return w, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.Batching, w, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
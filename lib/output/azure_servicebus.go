@@ -0,0 +1,103 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	imetadata "github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureServiceBus] = TypeSpec{
+		constructor: fromSimpleConstructor(NewAzureServiceBus),
+		Status:      docs.StatusExperimental,
+		Version:     "3.50.0",
+		Summary: `
+Sends messages to Azure Service Bus queues or topics.`,
+		Description: `
+### Authentication
+
+When a ` + "`connection_string`" + ` is set it is used for authenticating with
+either a shared access key or a shared access signature, and if it contains an
+` + "`EntityPath`" + ` then that entity is used when ` + "`target`" + ` is
+empty. Otherwise the ` + "`namespace`" + ` is accessed with the managed identity
+of the host, where a user assigned identity can be chosen with
+` + "`managed_identity_client_id`" + `.
+
+### Targets
+
+Messages can be routed to a different queue or topic each using
+[function interpolations](/docs/configuration/interpolation#bloblang-queries)
+within the ` + "`target`" + ` field, in which case a sender link is opened for
+each distinct entity, and once the number of open links exceeds
+` + "`max_senders`" + ` the least recently used link is closed.
+
+### Sessions and Scheduling
+
+The ` + "`session_id`" + ` field sets the session ID of messages, which is
+required by session enabled queues and subscriptions, and messages of a session
+are delivered in the order in which they were sent. The
+` + "`scheduled_enqueue_time`" + ` field is a [Bloblang query](/docs/guides/bloblang/about)
+that returns the time at which a message becomes available to consumers, either
+as a unix timestamp in seconds or an RFC 3339 timestamp string.
+
+Metadata values that match the ` + "`application_properties`" + ` filter are
+sent as application properties of each message.
+
+### Batching
+
+The messages of a batch that share a target and session are sent together,
+split into as few sends as possible without exceeding ` + "`max_batch_bytes`" + `,
+which should be set to the maximum message size of the namespace: 256KB for
+the standard tier and 1MB for the premium tier. A message that exceeds the limit
+by itself is rejected without being sent, as it would never succeed.
+
+When a send fails the remaining messages of the same target and session are
+also failed so that their order is preserved when they are retried.`,
+		Async:   true,
+		Batches: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("connection_string", "A connection string for the namespace, which is required unless `namespace` is set.", "Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=bar"),
+			docs.FieldCommon("namespace", "The namespace to connect to using the managed identity of the host, either as a name or a fully qualified host. This field is ignored if `connection_string` is set.", "foo", "foo.servicebus.windows.net"),
+			docs.FieldAdvanced("managed_identity_client_id", "The client ID of a user assigned managed identity to use when connecting to `namespace`. When empty the system assigned identity is used."),
+			docs.FieldCommon("target", "The queue or topic to send messages to. When empty the `EntityPath` of the connection string is used.", "foo", `${! meta("tenant") }-events`).IsInterpolated(),
+			docs.FieldAdvanced("message_id", "An optional message ID to set for each message, which is used by entities with duplicate detection enabled.", `${! meta("id") }`).IsInterpolated(),
+			docs.FieldAdvanced("content_type", "An optional content type to set for each message.", "application/json").IsInterpolated(),
+			docs.FieldCommon("session_id", "An optional session ID to set for each message.", `${! json("customer_id") }`).IsInterpolated(),
+			docs.FieldAdvanced("scheduled_enqueue_time", "An optional [Bloblang query](/docs/guides/bloblang/about) that returns the time at which each message becomes available to consumers.", `timestamp_unix() + 300`, `this.deliver_at`).Linter(docs.LintBloblangMapping),
+			docs.FieldAdvanced("application_properties", "Specify criteria for which metadata values are sent as application properties of messages.").WithChildren(imetadata.IncludeFilterDocs()...),
+			docs.FieldAdvanced("max_batch_bytes", "The maximum size in bytes of a single send, which should match the maximum message size of the namespace."),
+			docs.FieldAdvanced("max_senders", "The maximum number of sender links to keep open at a given time when the target is dynamic."),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureServiceBus creates a new AzureServiceBus output type.
+func NewAzureServiceBus(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewAzureServiceBus(conf.AzureServiceBus, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewAsyncWriter(
+		TypeAzureServiceBus, conf.AzureServiceBus.MaxInFlight, s, log, stats,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatcherFromConfig(conf.AzureServiceBus.Batching, w, mgr, log, stats)
+}
+
+//------------------------------------------------------------------------------
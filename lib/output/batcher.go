@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
@@ -135,8 +136,18 @@ func (m *Batcher) loop() {
 			continue
 		}
 
-		sendMsg := m.batcher.Flush()
+		sendMsg, err := m.batcher.FlushWithError()
+		if err != nil {
+			m.log.Errorf("Batch processors resulted in error: %v, the batch has been rejected.\n", err)
+		}
 		if sendMsg == nil {
+			// Either the batch processors failed, in which case the original
+			// messages are rejected so that they can be redelivered, or they
+			// filtered all messages, in which case the originals are acked.
+			if len(pendingTrans) > 0 {
+				go m.ackTransactions(pendingTrans, err)
+				pendingTrans = nil
+			}
 			continue
 		}
 
@@ -155,26 +166,36 @@ func (m *Batcher) loop() {
 				if !open {
 					return
 				}
-				fullyCloseCtx, done := m.shutSig.CloseNowCtx(context.Background())
-				for _, t := range upstreamTrans {
-					if err := t.Ack(fullyCloseCtx, res.Error()); err != nil {
-						done()
-						return
-					}
-				}
-				done()
+				m.ackTransactions(upstreamTrans, res.Error())
 			}
 		}(resChan, pendingTrans)
 		pendingTrans = nil
 	}
 }
 
+// ackTransactions responds to each of the original transactions of a batch,
+// where the parts of each transaction are matched to any per-part errors.
+func (m *Batcher) ackTransactions(trans []*transaction.Tracked, err error) {
+	fullyCloseCtx, done := m.shutSig.CloseNowCtx(context.Background())
+	defer done()
+	for _, t := range trans {
+		if aerr := t.Ack(fullyCloseCtx, err); aerr != nil {
+			return
+		}
+	}
+}
+
 // Connected returns a boolean indicating whether this output is currently
 // connected to its target.
 func (m *Batcher) Connected() bool {
 	return m.child.Connected()
 }
 
+// SelfTest runs the self test of the child output.
+func (m *Batcher) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, m.child)
+}
+
 // MaxInFlight returns the maximum number of in flight messages permitted by the
 // output. This value can be used to determine a sensible value for parent
 // outputs, but should not be relied upon as part of dispatcher logic.
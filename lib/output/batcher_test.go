@@ -13,6 +13,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
@@ -356,3 +357,151 @@ func TestBatcherTimed(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func sendBatcherTransactions(t *testing.T, tInChan chan<- types.Transaction, resChan chan types.Response, contents ...string) {
+	t.Helper()
+	for _, c := range contents {
+		select {
+		case tInChan <- types.NewTransaction(message.New([][]byte{[]byte(c)}), resChan):
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+}
+
+func readBatcherResponses(t *testing.T, resChan <-chan types.Response, n int) []error {
+	t.Helper()
+	var errs []error
+	for i := 0; i < n; i++ {
+		select {
+		case res := <-resChan:
+			errs = append(errs, res.Error())
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	return errs
+}
+
+func TestBatcherCollapsedBatch(t *testing.T) {
+	tInChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	archiveConf := processor.NewConfig()
+	archiveConf.Type = processor.TypeArchive
+	archiveConf.Archive.Format = "lines"
+
+	policyConf := batch.NewPolicyConfig()
+	policyConf.Count = 3
+	policyConf.Processors = append(policyConf.Processors, archiveConf)
+	batcher, err := batch.NewPolicy(policyConf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	out := &mockOutput{}
+
+	b := NewBatcher(batcher, out, log.Noop(), metrics.Noop())
+	require.NoError(t, b.Consume(tInChan))
+
+	readOutput := func() types.Transaction {
+		select {
+		case outTr := <-out.ts:
+			return outTr
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return types.Transaction{}
+	}
+
+	// A failure of the synthetic part must be attributed to every original.
+	go sendBatcherTransactions(t, tInChan, resChan, "foo0", "foo1", "foo2")
+	outTr := readOutput()
+	assert.Equal(t, [][]byte{[]byte("foo0\nfoo1\nfoo2")}, message.GetAllBytes(outTr.Payload))
+
+	go func() {
+		outTr.ResponseChan <- response.NewError(batchInternal.NewError(outTr.Payload, errors.New("nope")).Failed(0, errors.New("nope")))
+	}()
+	for _, err := range readBatcherResponses(t, resChan, 3) {
+		assert.EqualError(t, err, "nope")
+	}
+
+	// And a success of the synthetic part acknowledges every original.
+	go sendBatcherTransactions(t, tInChan, resChan, "foo0", "foo1", "foo2")
+	outTr = readOutput()
+	go func() {
+		outTr.ResponseChan <- response.NewAck()
+	}()
+	for _, err := range readBatcherResponses(t, resChan, 3) {
+		assert.NoError(t, err)
+	}
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second*5))
+}
+
+type rejectProcessor struct{}
+
+func (r *rejectProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	if string(msg.Get(0).Get()) == "reject" {
+		return nil, response.NewError(errors.New("rejected"))
+	}
+	return []types.Message{msg}, nil
+}
+
+func (r *rejectProcessor) CloseAsync() {}
+
+func (r *rejectProcessor) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestBatcherProcessorRejectsAndFilters(t *testing.T) {
+	tInChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	mgr := &fakeProcMgr{
+		procs: map[string]types.Processor{
+			"reject": &rejectProcessor{},
+		},
+	}
+
+	rejectConf := processor.NewConfig()
+	rejectConf.Type = processor.TypeResource
+	rejectConf.Resource = "reject"
+
+	filterConf := processor.NewConfig()
+	filterConf.Type = processor.TypeBloblang
+	filterConf.Bloblang = `root = if content().has_prefix("drop") { deleted() }`
+
+	policyConf := batch.NewPolicyConfig()
+	policyConf.Count = 2
+	policyConf.Processors = append(policyConf.Processors, rejectConf, filterConf)
+	batcher, err := batch.NewPolicy(policyConf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	out := &mockOutput{}
+
+	b := NewBatcher(batcher, out, log.Noop(), metrics.Noop())
+	require.NoError(t, b.Consume(tInChan))
+
+	// Batches rejected by the processors are nacked rather than being carried
+	// over to the next batch.
+	go sendBatcherTransactions(t, tInChan, resChan, "reject", "foo")
+	for _, err := range readBatcherResponses(t, resChan, 2) {
+		assert.EqualError(t, err, "rejected")
+	}
+
+	// Batches filtered entirely by the processors are acked.
+	go sendBatcherTransactions(t, tInChan, resChan, "drop0", "drop1")
+	for _, err := range readBatcherResponses(t, resChan, 2) {
+		assert.NoError(t, err)
+	}
+
+	select {
+	case <-out.ts:
+		t.Error("unexpected batch")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second*5))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
@@ -88,16 +89,54 @@ potentially disproportionate message allocations to those outputs. Each message
 is sent to a single output, which is determined by allowing outputs to claim
 messages as soon as they are able to process them. This results in certain
 faster outputs potentially processing more messages at the cost of slower
-outputs.`,
+outputs.
+
+### ` + "`sharded`" + `
+
+With the sharded pattern each message is sent to a single output chosen by
+consistently hashing the key resolved from ` + "`partition_by`" + `, and
+therefore messages that share a key are always written to the same output.
+Outputs are identified by their position in the list, and so appending an
+output to the list, or removing the last one, only remaps roughly 1/N of the
+keys.
+
+` + "```yaml" + `
+output:
+  broker:
+    pattern: sharded
+    partition_by: ${! json("user.id") }
+    outputs:
+      - resource: foo
+      - resource: bar
+      - resource: baz
+` + "```" + `
+
+When an output is not connected its keys are spilled to the next output chosen
+for them until it recovers, this can be changed with the field
+` + "`on_unavailable`" + ` in order to block until the output recovers instead.
+
+The number of messages routed to each output is tracked with the counter metric
+` + "`broker.outputs.N.routed`" + `, which can be used to observe the key share
+of each output when outputs are added or removed.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldAdvanced("copies", "The number of copies of each configured output to spawn."),
 			docs.FieldCommon("pattern", "The brokering pattern to use.").HasOptions(
-				"fan_out", "fan_out_sequential", "round_robin", "greedy",
+				"fan_out", "fan_out_sequential", "round_robin", "greedy", "sharded",
 			),
 			docs.FieldAdvanced(
 				"max_in_flight",
-				"The maximum number of parallel message batches to have in flight at any given time. Note that if a child output has a higher `max_in_flight` then the switch output will automatically match it, therefore this value is the minimum `max_in_flight` to set in cases where the child values can't be inferred (such as when using resource outputs as children). Only relevant for `fan_out`, `fan_out_sequential` brokers.",
+				"The maximum number of parallel message batches to have in flight at any given time. Note that if a child output has a higher `max_in_flight` then the switch output will automatically match it, therefore this value is the minimum `max_in_flight` to set in cases where the child values can't be inferred (such as when using resource outputs as children). Only relevant for `fan_out`, `fan_out_sequential` and `sharded` brokers.",
 			),
+			docs.FieldCommon(
+				"partition_by", "A key used to choose the output of each message. Only relevant for the `sharded` pattern.",
+				`${! meta("kafka_key") }`, `${! json("user.id") }`,
+			).IsInterpolated().AtVersion("3.50.0"),
+			docs.FieldAdvanced(
+				"on_unavailable", "Determines what happens to messages of a key when its output is not connected. Only relevant for the `sharded` pattern.",
+			).HasAnnotatedOptions(
+				"spill", "Send the messages to the next output chosen for the key until the output recovers.",
+				"block", "Send the messages to the output regardless, blocking until it recovers.",
+			).AtVersion("3.50.0"),
 			docs.FieldCommon("outputs", "A list of child outputs to broker.").Array().HasType(docs.FieldTypeOutput),
 			batch.FieldSpec(),
 		},
@@ -111,21 +150,25 @@ outputs.`,
 
 // BrokerConfig contains configuration fields for the Broker output type.
 type BrokerConfig struct {
-	Copies      int                `json:"copies" yaml:"copies"`
-	Pattern     string             `json:"pattern" yaml:"pattern"`
-	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
-	Outputs     brokerOutputList   `json:"outputs" yaml:"outputs"`
-	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Copies        int                `json:"copies" yaml:"copies"`
+	Pattern       string             `json:"pattern" yaml:"pattern"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	PartitionBy   string             `json:"partition_by" yaml:"partition_by"`
+	OnUnavailable string             `json:"on_unavailable" yaml:"on_unavailable"`
+	Outputs       brokerOutputList   `json:"outputs" yaml:"outputs"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies:      1,
-		Pattern:     "fan_out",
-		MaxInFlight: 1,
-		Outputs:     brokerOutputList{},
-		Batching:    batch.NewPolicyConfig(),
+		Copies:        1,
+		Pattern:       "fan_out",
+		MaxInFlight:   1,
+		PartitionBy:   "",
+		OnUnavailable: "spill",
+		Outputs:       brokerOutputList{},
+		Batching:      batch.NewPolicyConfig(),
 	}
 }
 
@@ -142,7 +185,18 @@ func NewBroker(
 ) (Type, error) {
 	pipelines = AppendProcessorsFromConfig(conf, mgr, log, stats, pipelines...)
 
-	outputConfs := conf.Broker.Outputs
+	// Disabled outputs are skipped, but the remaining outputs keep the index
+	// of their original position for observability purposes.
+	var outputConfs []Config
+	var outputIndexes []int
+	for i, oConf := range conf.Broker.Outputs {
+		if !oConf.Enabled {
+			log.Infof("Output '%v' of type '%v' is disabled and will be skipped\n", i, oConf.Type)
+			continue
+		}
+		outputConfs = append(outputConfs, oConf)
+		outputIndexes = append(outputIndexes, i)
+	}
 
 	lOutputs := len(outputConfs) * conf.Broker.Copies
 
@@ -174,10 +228,11 @@ func NewBroker(
 			if isThreaded {
 				pipes = pipelines
 			}
-			oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("broker.outputs.%v", i), mgr, log, stats)
+			index := outputIndexes[i]
+			oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("broker.outputs.%v", index), mgr, log, stats)
 			oStats = metrics.Combine(stats, oStats)
 			if outputs[j*len(outputConfs)+i], err = New(oConf, oMgr, oLog, oStats, pipes...); err != nil {
-				return nil, fmt.Errorf("failed to create output '%v' type '%v': %v", i, oConf.Type, err)
+				return nil, fmt.Errorf("failed to create output '%v' type '%v': %v", index, oConf.Type, err)
 			}
 		}
 	}
@@ -205,6 +260,8 @@ func NewBroker(
 		b, err = broker.NewRoundRobin(outputs, stats)
 	case "greedy":
 		b, err = broker.NewGreedy(outputs)
+	case "sharded":
+		b, err = newShardedBroker(conf.Broker, outputs, maxInFlight, stats)
 	case "try":
 		b, err = broker.NewTry(outputs, stats)
 	default:
@@ -226,3 +283,30 @@ func NewBroker(
 }
 
 //------------------------------------------------------------------------------
+
+func newShardedBroker(conf BrokerConfig, outputs []types.Output, maxInFlight int, stats metrics.Type) (Type, error) {
+	if conf.PartitionBy == "" {
+		return nil, errors.New("a partition_by key must be specified for the sharded pattern")
+	}
+	key, err := bloblang.NewField(conf.PartitionBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse partition_by expression: %v", err)
+	}
+	var spill bool
+	switch conf.OnUnavailable {
+	case "spill":
+		spill = true
+	case "block":
+	default:
+		return nil, fmt.Errorf("on_unavailable value not recognised: %v", conf.OnUnavailable)
+	}
+	s, err := broker.NewSharded(outputs, func(i int, msg types.Message) string {
+		return key.String(i, msg)
+	}, stats)
+	if err != nil {
+		return nil, err
+	}
+	return s.WithMaxInFlight(maxInFlight).WithSpill(spill), nil
+}
+
+//------------------------------------------------------------------------------
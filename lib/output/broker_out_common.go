@@ -34,9 +34,22 @@ func (b *brokerOutputList) UnmarshalJSON(bytes []byte) error {
 
 // UnmarshalYAML ensures that when parsing configs that are in a map or slice
 // the default values are still applied.
-func (b *brokerOutputList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+func (b *brokerOutputList) UnmarshalYAML(value *yaml.Node) error {
+	// When there are no ditto configs each child is decoded directly from its
+	// node so that errors are reported at their original positions.
+	if value.Kind == yaml.SequenceNode && !broker.HasDittoNode(value.Content) {
+		confs := make([]Config, len(value.Content))
+		for i, child := range value.Content {
+			if err := child.Decode(&confs[i]); err != nil {
+				return err
+			}
+		}
+		*b = confs
+		return nil
+	}
+
 	genericOutputs := []interface{}{}
-	if err := unmarshal(&genericOutputs); err != nil {
+	if err := value.Decode(&genericOutputs); err != nil {
 		return err
 	}
 
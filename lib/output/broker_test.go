@@ -375,3 +375,56 @@ func TestTryBroker(t *testing.T) {
 		}
 	}
 }
+
+func TestBrokerDisabledOutputs(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBroker
+	conf.Broker.Pattern = "fan_out"
+
+	rejectConf := NewConfig()
+	rejectConf.Type = TypeReject
+	rejectConf.Reject = "nope"
+	rejectConf.Enabled = false
+
+	dropConf := NewConfig()
+	dropConf.Type = TypeDrop
+
+	conf.Broker.Outputs = append(conf.Broker.Outputs, rejectConf, dropConf, dropConf)
+
+	b, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	if err = b.Consume(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-resChan:
+		if err := res.Error(); err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	b.CloseAsync()
+	if err = b.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+
+	for i := range conf.Broker.Outputs {
+		conf.Broker.Outputs[i].Enabled = false
+	}
+	if _, err = New(conf, nil, log.Noop(), metrics.Noop()); err != ErrBrokerNoOutputs {
+		t.Errorf("Wrong error returned: %v != %v", err, ErrBrokerNoOutputs)
+	}
+}
@@ -0,0 +1,430 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeChaos] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			var wrapped Type
+			if conf.Chaos.Output != nil {
+				var err error
+				if wrapped, err = New(*conf.Chaos.Output, mgr, log, stats); err != nil {
+					return nil, fmt.Errorf("failed to create output '%v': %v", conf.Chaos.Output.Type, err)
+				}
+			}
+			return newChaos(conf.Chaos.ChaosFaults, wrapped, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Injects faults such as ack latency, random nacks and periodic outages into the
+writes of a child output, or acts as a faulty sink when no child is configured.`,
+		Description: `
+This output is intended for testing how a pipeline copes with a slow or
+unreliable downstream service, such as validating the retry and fallback
+behaviour of a config, without having to stand up infrastructure that misbehaves
+on demand.
+
+Messages are handled one batch at a time, and for each batch the following
+faults are applied in order:
+
+1. If the output is within an outage window the batch fails immediately.
+2. Otherwise the batch is nacked with a probability of ` + "`nack_probability`" + `
+   without being written.
+3. Otherwise the batch is written to the child output, or acknowledged when there
+   is no child.
+
+The acknowledgement (or error) of each batch is then delayed by
+` + "`ack_latency`" + ` plus a random duration of up to ` + "`ack_jitter`" + `.
+
+All random decisions are driven by a generator initialised with ` + "`seed`" + `,
+and therefore a sequence of batches will experience the same nacks and latencies
+across runs with the same seed. Outage windows are based on the time elapsed
+since the output was created.
+
+### Metrics
+
+Injected faults are recorded separately from errors returned by the child output
+with the following metrics:
+
+` + "```text" + `
+- chaos.injected.nack
+- chaos.injected.outage
+- chaos.injected.latency
+- chaos.error
+` + "```" + ``,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("ack_latency", "A fixed duration to wait before returning the acknowledgement of each batch.", "100ms", "1s"),
+			docs.FieldCommon("ack_jitter", "The maximum of a random duration to wait in addition to `ack_latency` before returning the acknowledgement of each batch.", "50ms"),
+			docs.FieldFloat("nack_probability", "The probability, between `0` and `1`, that a batch is rejected with an error instead of being written.", 0.1),
+			docs.FieldCommon("outage_interval", "An optional period after which an outage begins, and repeats every period thereafter. Requires `outage_duration` to be set.", "1m"),
+			docs.FieldCommon("outage_duration", "The length of each outage, during which all writes fail. Must be less than `outage_interval`.", "10s"),
+			docs.FieldAdvanced("seed", "A seed for the random generator that drives injected faults, set to `0` to use a random seed. The seed used is logged at start up so that runs can be reproduced."),
+			docs.FieldCommon("output", "An optional child output to write messages to, when omitted messages that are not failed are dropped.").HasType(docs.FieldTypeOutput),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Flaky Downstream",
+				Summary: `
+Here we test the retry and fallback behaviour of a config by wrapping an HTTP
+client output with one that adds up to 200ms of latency, nacks 5% of batches and
+fails all writes for 10 seconds of every minute:`,
+				Config: `
+output:
+  try:
+    - retry:
+        max_retries: 3
+        output:
+          chaos:
+            seed: 42
+            ack_latency: 100ms
+            ack_jitter: 100ms
+            nack_probability: 0.05
+            outage_interval: 1m
+            outage_duration: 10s
+            output:
+              http_client:
+                url: http://localhost:4195/post
+    - file:
+        path: ./dead_letters.jsonl
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ChaosFaults is a config struct representing the faults injected by a chaos
+// output.
+type ChaosFaults struct {
+	Seed            int64   `json:"seed" yaml:"seed"`
+	AckLatency      string  `json:"ack_latency" yaml:"ack_latency"`
+	AckJitter       string  `json:"ack_jitter" yaml:"ack_jitter"`
+	NackProbability float64 `json:"nack_probability" yaml:"nack_probability"`
+	OutageInterval  string  `json:"outage_interval" yaml:"outage_interval"`
+	OutageDuration  string  `json:"outage_duration" yaml:"outage_duration"`
+}
+
+// ChaosConfig contains configuration values for the Chaos output type.
+type ChaosConfig struct {
+	ChaosFaults `json:",inline" yaml:",inline"`
+	Output      *Config `json:"output" yaml:"output"`
+}
+
+// NewChaosConfig creates a new ChaosConfig with default values.
+func NewChaosConfig() ChaosConfig {
+	return ChaosConfig{
+		ChaosFaults: ChaosFaults{
+			Seed:            0,
+			AckLatency:      "",
+			AckJitter:       "",
+			NackProbability: 0,
+			OutageInterval:  "",
+			OutageDuration:  "",
+		},
+		Output: nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyChaosConfig struct {
+	ChaosFaults `json:",inline" yaml:",inline"`
+	Output      interface{} `json:"output" yaml:"output"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (c ChaosConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyChaosConfig{
+		Output:      c.Output,
+		ChaosFaults: c.ChaosFaults,
+	}
+	if c.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (c ChaosConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyChaosConfig{
+		Output:      c.Output,
+		ChaosFaults: c.ChaosFaults,
+	}
+	if c.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy, nil
+}
+
+// UnmarshalYAML treats an empty child output as no child, since otherwise it
+// would be parsed as an output of the default type.
+func (c *ChaosConfig) UnmarshalYAML(value *yaml.Node) error {
+	type confAlias ChaosConfig
+	aliased := confAlias(NewChaosConfig())
+
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	for i := 0; i < len(value.Content)-1; i += 2 {
+		if value.Content[i].Value != "output" {
+			continue
+		}
+		if child := value.Content[i+1]; child.Kind == yaml.MappingNode && len(child.Content) == 0 {
+			aliased.Output = nil
+		}
+	}
+
+	*c = ChaosConfig(aliased)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	errChaosNack   = errors.New("chaos: injected nack")
+	errChaosOutage = errors.New("chaos: injected outage")
+)
+
+// chaos forwards messages to an optional child output and injects faults into
+// the responses.
+type chaos struct {
+	stats metrics.Type
+	log   log.Modular
+
+	rng             *rand.Rand
+	ackLatency      time.Duration
+	ackJitter       time.Duration
+	nackProbability float64
+	outageInterval  time.Duration
+	outageDuration  time.Duration
+	started         time.Time
+
+	wrapped         Type
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+}
+
+func newChaos(conf ChaosFaults, wrapped Type, log log.Modular, stats metrics.Type) (*chaos, error) {
+	parseDuration := func(name, str string) (time.Duration, error) {
+		if str == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %v duration: %w", name, err)
+		}
+		return d, nil
+	}
+
+	c := &chaos{
+		log:             log,
+		stats:           stats,
+		nackProbability: conf.NackProbability,
+		wrapped:         wrapped,
+		transactionsOut: make(chan types.Transaction),
+		closedChan:      make(chan struct{}),
+	}
+
+	var err error
+	if c.ackLatency, err = parseDuration("ack_latency", conf.AckLatency); err != nil {
+		return nil, err
+	}
+	if c.ackJitter, err = parseDuration("ack_jitter", conf.AckJitter); err != nil {
+		return nil, err
+	}
+	if c.outageInterval, err = parseDuration("outage_interval", conf.OutageInterval); err != nil {
+		return nil, err
+	}
+	if c.outageDuration, err = parseDuration("outage_duration", conf.OutageDuration); err != nil {
+		return nil, err
+	}
+	if c.nackProbability < 0 || c.nackProbability > 1 {
+		return nil, fmt.Errorf("nack_probability must be between 0 and 1, got %v", c.nackProbability)
+	}
+	if c.outageDuration > 0 && c.outageDuration >= c.outageInterval {
+		return nil, errors.New("outage_duration must be less than outage_interval")
+	}
+
+	seed := conf.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	log.Infof("Injecting faults with seed: %v\n", seed)
+	c.rng = rand.New(rand.NewSource(seed))
+
+	c.started = time.Now()
+	c.ctx, c.done = context.WithCancel(context.Background())
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// inOutage returns whether writes should fail due to an injected outage at a
+// given time.
+func (c *chaos) inOutage(t time.Time) bool {
+	if c.outageDuration <= 0 {
+		return false
+	}
+	elapsed := t.Sub(c.started)
+	return elapsed >= c.outageInterval && elapsed%c.outageInterval < c.outageDuration
+}
+
+// latency returns the duration to wait before returning the response of a
+// batch.
+func (c *chaos) latency() time.Duration {
+	latency := c.ackLatency
+	if c.ackJitter > 0 {
+		latency += time.Duration(c.rng.Int63n(int64(c.ackJitter) + 1))
+	}
+	return latency
+}
+
+func (c *chaos) loop() {
+	// Metrics paths
+	var (
+		mNack    = c.stats.GetCounter("chaos.injected.nack")
+		mOutage  = c.stats.GetCounter("chaos.injected.outage")
+		mLatency = c.stats.GetTimer("chaos.injected.latency")
+		mErr     = c.stats.GetCounter("chaos.error")
+	)
+
+	defer func() {
+		close(c.transactionsOut)
+		if c.wrapped != nil {
+			c.wrapped.CloseAsync()
+			err := c.wrapped.WaitForClose(time.Second)
+			for ; err != nil; err = c.wrapped.WaitForClose(time.Second) {
+			}
+		}
+		close(c.closedChan)
+	}()
+
+	resChan := make(chan types.Response)
+
+	for {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-c.transactionsIn:
+			if !open {
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+
+		var res types.Response
+		if c.inOutage(time.Now()) {
+			mOutage.Incr(int64(ts.Payload.Len()))
+			res = response.NewError(errChaosOutage)
+		} else if c.nackProbability > 0 && c.rng.Float64() < c.nackProbability {
+			mNack.Incr(int64(ts.Payload.Len()))
+			res = response.NewError(errChaosNack)
+		} else if c.wrapped != nil {
+			select {
+			case c.transactionsOut <- types.NewTransaction(ts.Payload, resChan):
+			case <-c.ctx.Done():
+				return
+			}
+			select {
+			case res = <-resChan:
+			case <-c.ctx.Done():
+				return
+			}
+			if res.Error() != nil {
+				mErr.Incr(int64(ts.Payload.Len()))
+			}
+		} else {
+			res = response.NewAck()
+		}
+
+		if latency := c.latency(); latency > 0 {
+			mLatency.Timing(latency.Nanoseconds())
+			select {
+			case <-time.After(latency):
+			case <-c.ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case ts.ResponseChan <- res:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (c *chaos) Consume(ts <-chan types.Transaction) error {
+	if c.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if c.wrapped != nil {
+		if err := c.wrapped.Consume(c.transactionsOut); err != nil {
+			return err
+		}
+	}
+	c.transactionsIn = ts
+	go c.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (c *chaos) Connected() bool {
+	if c.wrapped == nil {
+		return true
+	}
+	return c.wrapped.Connected()
+}
+
+func (c *chaos) MaxInFlight() (int, bool) {
+	if c.wrapped == nil {
+		return 0, false
+	}
+	return output.GetMaxInFlight(c.wrapped)
+}
+
+// CloseAsync shuts down the Chaos output and stops processing requests.
+func (c *chaos) CloseAsync() {
+	c.done()
+}
+
+// WaitForClose blocks until the Chaos output has closed down.
+func (c *chaos) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-c.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,188 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func sendChaosMessages(t *testing.T, c Type, n int) []error {
+	t.Helper()
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, c.Consume(tChan))
+
+	rChan := make(chan types.Response)
+	var errs []error
+	for i := 0; i < n; i++ {
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), rChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-rChan:
+			errs = append(errs, res.Error())
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+	return errs
+}
+
+func TestChaosSeededNacks(t *testing.T) {
+	conf := NewChaosConfig()
+	conf.Seed = 10
+	conf.NackProbability = 0.5
+
+	run := func() ([]error, *metrics.Local) {
+		stats := metrics.NewLocal()
+		c, err := newChaos(conf.ChaosFaults, nil, log.Noop(), stats)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			c.CloseAsync()
+			assert.NoError(t, c.WaitForClose(time.Second*5))
+		})
+		return sendChaosMessages(t, c, 50), stats
+	}
+
+	firstErrs, stats := run()
+	secondErrs, _ := run()
+	assert.Equal(t, firstErrs, secondErrs)
+
+	var nacks int64
+	for _, err := range firstErrs {
+		if err != nil {
+			assert.True(t, errors.Is(err, errChaosNack))
+			nacks++
+		}
+	}
+	assert.Greater(t, nacks, int64(0))
+	assert.Less(t, nacks, int64(50))
+	assert.Equal(t, nacks, stats.GetCounters()["chaos.injected.nack"])
+}
+
+func TestChaosChildErrors(t *testing.T) {
+	childConf := NewConfig()
+	childConf.Type = TypeReject
+	childConf.Reject = "nope"
+
+	child, err := New(childConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	c, err := newChaos(NewChaosConfig().ChaosFaults, child, log.Noop(), stats)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second*5))
+	})
+
+	for _, err := range sendChaosMessages(t, c, 3) {
+		assert.EqualError(t, err, "nope")
+	}
+	assert.Equal(t, int64(3), stats.GetCounters()["chaos.error"])
+	assert.Equal(t, int64(0), stats.GetCounters()["chaos.injected.nack"])
+}
+
+func TestChaosOutage(t *testing.T) {
+	conf := NewChaosConfig()
+	conf.OutageInterval = "1m"
+	conf.OutageDuration = "10s"
+
+	c, err := newChaos(conf.ChaosFaults, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second*5))
+	})
+
+	start := c.started
+	assert.False(t, c.inOutage(start))
+	assert.False(t, c.inOutage(start.Add(time.Second*30)))
+	assert.True(t, c.inOutage(start.Add(time.Minute)))
+	assert.True(t, c.inOutage(start.Add(time.Minute+time.Second*9)))
+	assert.False(t, c.inOutage(start.Add(time.Minute+time.Second*10)))
+	assert.True(t, c.inOutage(start.Add(time.Minute*5+time.Second)))
+
+	// Shift the start so that the output is currently within an outage.
+	c.started = time.Now().Add(-time.Minute)
+	for _, err := range sendChaosMessages(t, c, 2) {
+		assert.True(t, errors.Is(err, errChaosOutage))
+	}
+}
+
+func TestChaosLatency(t *testing.T) {
+	conf := NewChaosConfig()
+	conf.AckLatency = "10ms"
+	conf.AckJitter = "5ms"
+
+	c, err := newChaos(conf.ChaosFaults, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second*5))
+	})
+
+	for i := 0; i < 10; i++ {
+		latency := c.latency()
+		assert.GreaterOrEqual(t, int64(latency), int64(time.Millisecond*10))
+		assert.LessOrEqual(t, int64(latency), int64(time.Millisecond*15))
+	}
+
+	start := time.Now()
+	for _, err := range sendChaosMessages(t, c, 2) {
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Millisecond*20))
+}
+
+func TestChaosBadConfig(t *testing.T) {
+	tests := map[string]func(c *ChaosFaults){
+		"bad latency":     func(c *ChaosFaults) { c.AckLatency = "nope" },
+		"bad probability": func(c *ChaosFaults) { c.NackProbability = 1.5 },
+		"long outage": func(c *ChaosFaults) {
+			c.OutageInterval = "10s"
+			c.OutageDuration = "1m"
+		},
+		"outage without interval": func(c *ChaosFaults) { c.OutageDuration = "1m" },
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewChaosConfig()
+			test(&conf.ChaosFaults)
+			_, err := newChaos(conf.ChaosFaults, nil, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestChaosConfigEmptyChild(t *testing.T) {
+	conf := NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+chaos:
+  nack_probability: 0.2
+  output: {}
+`), &conf))
+	assert.Equal(t, TypeChaos, conf.Type)
+	assert.Equal(t, 0.2, conf.Chaos.NackProbability)
+	assert.Nil(t, conf.Chaos.Output)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+chaos:
+  output:
+    drop: {}
+`), &conf))
+	require.NotNil(t, conf.Chaos.Output)
+	assert.Equal(t, TypeDrop, conf.Chaos.Output.Type)
+}
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Jeffail/benthos/v3/internal/component/output"
@@ -166,11 +167,13 @@ const (
 	TypeAWSSQS             = "aws_sqs"
 	TypeAzureBlobStorage   = "azure_blob_storage"
 	TypeAzureQueueStorage  = "azure_queue_storage"
+	TypeAzureServiceBus    = "azure_servicebus"
 	TypeAzureTableStorage  = "azure_table_storage"
 	TypeBlobStorage        = "blob_storage"
 	TypeBroker             = "broker"
 	TypeCache              = "cache"
 	TypeCassandra          = "cassandra"
+	TypeChaos              = "chaos"
 	TypeDrop               = "drop"
 	TypeDropOn             = "drop_on"
 	TypeDropOnError        = "drop_on_error"
@@ -181,10 +184,12 @@ const (
 	TypeFiles              = "files"
 	TypeGCPCloudStorage    = "gcp_cloud_storage"
 	TypeGCPPubSub          = "gcp_pubsub"
+	TypeGRPCClient         = "grpc_client"
 	TypeHDFS               = "hdfs"
 	TypeHTTPClient         = "http_client"
 	TypeHTTPServer         = "http_server"
 	TypeInproc             = "inproc"
+	TypeInprocBuffered     = "inproc_buffered"
 	TypeKafka              = "kafka"
 	TypeKinesis            = "kinesis"
 	TypeKinesisFirehose    = "kinesis_firehose"
@@ -214,6 +219,7 @@ const (
 	TypeSyncResponse       = "sync_response"
 	TypeTableStorage       = "table_storage"
 	TypeTCP                = "tcp"
+	TypeTee                = "tee"
 	TypeTry                = "try"
 	TypeUDP                = "udp"
 	TypeSocket             = "socket"
@@ -238,25 +244,30 @@ type Config struct {
 	AWSSQS             writer.AmazonSQSConfig         `json:"aws_sqs" yaml:"aws_sqs"`
 	AzureBlobStorage   writer.AzureBlobStorageConfig  `json:"azure_blob_storage" yaml:"azure_blob_storage"`
 	AzureQueueStorage  writer.AzureQueueStorageConfig `json:"azure_queue_storage" yaml:"azure_queue_storage"`
+	AzureServiceBus    writer.AzureServiceBusConfig   `json:"azure_servicebus" yaml:"azure_servicebus"`
 	AzureTableStorage  writer.AzureTableStorageConfig `json:"azure_table_storage" yaml:"azure_table_storage"`
 	BlobStorage        writer.AzureBlobStorageConfig  `json:"blob_storage" yaml:"blob_storage"`
 	Broker             BrokerConfig                   `json:"broker" yaml:"broker"`
 	Cache              writer.CacheConfig             `json:"cache" yaml:"cache"`
 	Cassandra          CassandraConfig                `json:"cassandra" yaml:"cassandra"`
+	Chaos              ChaosConfig                    `json:"chaos" yaml:"chaos"`
 	Drop               writer.DropConfig              `json:"drop" yaml:"drop"`
 	DropOn             DropOnConfig                   `json:"drop_on" yaml:"drop_on"`
 	DropOnError        DropOnErrorConfig              `json:"drop_on_error" yaml:"drop_on_error"`
 	Dynamic            DynamicConfig                  `json:"dynamic" yaml:"dynamic"`
 	DynamoDB           writer.DynamoDBConfig          `json:"dynamodb" yaml:"dynamodb"`
 	Elasticsearch      writer.ElasticsearchConfig     `json:"elasticsearch" yaml:"elasticsearch"`
+	Enabled            bool                           `json:"enabled" yaml:"enabled"`
 	File               FileConfig                     `json:"file" yaml:"file"`
 	Files              writer.FilesConfig             `json:"files" yaml:"files"`
 	GCPCloudStorage    GCPCloudStorageConfig          `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
 	GCPPubSub          writer.GCPPubSubConfig         `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	GRPCClient         GRPCClientConfig               `json:"grpc_client" yaml:"grpc_client"`
 	HDFS               writer.HDFSConfig              `json:"hdfs" yaml:"hdfs"`
 	HTTPClient         writer.HTTPClientConfig        `json:"http_client" yaml:"http_client"`
 	HTTPServer         HTTPServerConfig               `json:"http_server" yaml:"http_server"`
 	Inproc             InprocConfig                   `json:"inproc" yaml:"inproc"`
+	InprocBuffered     InprocBufferedConfig           `json:"inproc_buffered" yaml:"inproc_buffered"`
 	Kafka              writer.KafkaConfig             `json:"kafka" yaml:"kafka"`
 	Kinesis            writer.KinesisConfig           `json:"kinesis" yaml:"kinesis"`
 	KinesisFirehose    writer.KinesisFirehoseConfig   `json:"kinesis_firehose" yaml:"kinesis_firehose"`
@@ -287,6 +298,7 @@ type Config struct {
 	SyncResponse       struct{}                       `json:"sync_response" yaml:"sync_response"`
 	TableStorage       writer.AzureTableStorageConfig `json:"table_storage" yaml:"table_storage"`
 	TCP                writer.TCPConfig               `json:"tcp" yaml:"tcp"`
+	Tee                TeeConfig                      `json:"tee" yaml:"tee"`
 	Try                TryConfig                      `json:"try" yaml:"try"`
 	UDP                writer.UDPConfig               `json:"udp" yaml:"udp"`
 	Socket             writer.SocketConfig            `json:"socket" yaml:"socket"`
@@ -311,25 +323,30 @@ func NewConfig() Config {
 		AWSSQS:             writer.NewAmazonSQSConfig(),
 		AzureBlobStorage:   writer.NewAzureBlobStorageConfig(),
 		AzureQueueStorage:  writer.NewAzureQueueStorageConfig(),
+		AzureServiceBus:    writer.NewAzureServiceBusConfig(),
 		AzureTableStorage:  writer.NewAzureTableStorageConfig(),
 		BlobStorage:        writer.NewAzureBlobStorageConfig(),
 		Broker:             NewBrokerConfig(),
 		Cache:              writer.NewCacheConfig(),
 		Cassandra:          NewCassandraConfig(),
+		Chaos:              NewChaosConfig(),
 		Drop:               writer.NewDropConfig(),
 		DropOn:             NewDropOnConfig(),
 		DropOnError:        NewDropOnErrorConfig(),
 		Dynamic:            NewDynamicConfig(),
 		DynamoDB:           writer.NewDynamoDBConfig(),
 		Elasticsearch:      writer.NewElasticsearchConfig(),
+		Enabled:            true,
 		File:               NewFileConfig(),
 		Files:              writer.NewFilesConfig(),
 		GCPCloudStorage:    NewGCPCloudStorageConfig(),
 		GCPPubSub:          writer.NewGCPPubSubConfig(),
+		GRPCClient:         NewGRPCClientConfig(),
 		HDFS:               writer.NewHDFSConfig(),
 		HTTPClient:         writer.NewHTTPClientConfig(),
 		HTTPServer:         NewHTTPServerConfig(),
 		Inproc:             NewInprocConfig(),
+		InprocBuffered:     NewInprocBufferedConfig(),
 		Kafka:              writer.NewKafkaConfig(),
 		Kinesis:            writer.NewKinesisConfig(),
 		KinesisFirehose:    writer.NewKinesisFirehoseConfig(),
@@ -360,6 +377,7 @@ func NewConfig() Config {
 		SyncResponse:       struct{}{},
 		TableStorage:       writer.NewAzureTableStorageConfig(),
 		TCP:                writer.NewTCPConfig(),
+		Tee:                NewTeeConfig(),
 		Try:                NewTryConfig(),
 		UDP:                writer.NewUDPConfig(),
 		Socket:             writer.NewSocketConfig(),
@@ -410,23 +428,23 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var spec docs.ComponentSpec
 	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeOutput, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if spec.Plugin {
 		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 			conf := spec.confConstructor()
 			if err = pluginNode.Decode(conf); err != nil {
-				return fmt.Errorf("line %v: %v", value.Line, err)
+				return docs.ErrorAtNode(value, err)
 			}
 			aliased.Plugin = conf
 		} else {
@@ -442,6 +460,12 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 //------------------------------------------------------------------------------
 
+// ErrOutputDisabled is returned when attempting to create an output that is
+// disabled. Disabled outputs can only be skipped by a parent that is able to
+// continue without them, such as a broker, switch or try output, or when they
+// are output resources.
+var ErrOutputDisabled = errors.New("output is disabled, which is only supported for the children of broker, switch and try outputs, or for output resources")
+
 // New creates an output type based on an output configuration.
 func New(
 	conf Config,
@@ -455,6 +479,9 @@ func New(
 	}); ok {
 		return mgrV2.NewOutput(conf, pipelines...)
 	}
+	if !conf.Enabled {
+		return nil, ErrOutputDisabled
+	}
 	if c, ok := Constructors[conf.Type]; ok {
 		return c.constructor(conf, mgr, log, stats, pipelines...)
 	}
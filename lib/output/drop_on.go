@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -38,6 +42,7 @@ Regular Benthos outputs will apply back pressure when downstream services aren't
 		},
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("error", "Whether messages should be dropped when the child output returns an error. For example, this could be when an http_client output gets a 4XX response code."),
+			docs.FieldString("error_matches", "A list of [Bloblang queries](/docs/guides/bloblang/about) that are executed against errors returned by the child output when `error` is `false`. When any query resolves to `true` the message is dropped, otherwise the error is returned as normal. The queries are executed against a document containing the error string as `error`, the class of the error (`retryable`, `throttled` or `terminal`) as `class` and, when the error was caused by an unexpected HTTP response, the status code as `http_status` and the leading bytes of the body as `http_error_body`. The metadata of the first message of the batch is also available. Messages dropped by a rule are counted by the metric `drop_on.error_matches.dropped`, labelled by the index of the rule.", []string{`this.http_status == 409`}, []string{`this.error.contains("payload too large")`}).Array().AtVersion("3.50.0"),
 			docs.FieldCommon("back_pressure", "An optional duration string that determines the maximum length of time to wait for a given message to be accepted by the child output before the message should be dropped instead. The most common reason for an output to block is when waiting for a lost connection to be re-established. Once a message has been dropped due to back pressure all subsequent messages are dropped immediately until the output is ready to process them again. Note that if `error` is set to `false` and this field is specified then messages dropped due to back pressure will return an error response.", "30s", "1m"),
 			docs.FieldCommon("output", "A child output.").HasType(docs.FieldTypeOutput),
 		},
@@ -59,6 +64,21 @@ output:
             http_client:
               url: http://example.com/foo/messages
               verb: POST
+`,
+			},
+			{
+				Title:   "Dropping specific errors",
+				Summary: "In this example requests that result in a 409 Conflict response are considered a success, as are messages that are too large for the target, whereas other errors are retried as normal.",
+				Config: `
+output:
+  drop_on:
+    error_matches:
+      - this.http_status == 409
+      - this.error.contains("payload too large")
+    output:
+      http_client:
+        url: http://example.com/foo/messages
+        verb: POST
 `,
 			},
 			{
@@ -82,8 +102,9 @@ output:
 // DropOnConditions is a config struct representing the different circumstances
 // under which messages should be dropped.
 type DropOnConditions struct {
-	Error        bool   `json:"error" yaml:"error"`
-	BackPressure string `json:"back_pressure" yaml:"back_pressure"`
+	Error        bool     `json:"error" yaml:"error"`
+	ErrorMatches []string `json:"error_matches" yaml:"error_matches"`
+	BackPressure string   `json:"back_pressure" yaml:"back_pressure"`
 }
 
 // DropOnConfig contains configuration values for the DropOn output type.
@@ -97,6 +118,7 @@ func NewDropOnConfig() DropOnConfig {
 	return DropOnConfig{
 		DropOnConditions: DropOnConditions{
 			Error:        false,
+			ErrorMatches: []string{},
 			BackPressure: "",
 		},
 		Output: nil,
@@ -143,6 +165,7 @@ type dropOn struct {
 	log   log.Modular
 
 	onError        bool
+	onErrorMatches []*mapping.Executor
 	onBackpressure time.Duration
 	wrapped        Type
 
@@ -163,6 +186,15 @@ func newDropOn(conf DropOnConditions, wrapped Type, log log.Modular, stats metri
 		}
 	}
 
+	var errorMatches []*mapping.Executor
+	for i, m := range conf.ErrorMatches {
+		exec, err := bloblang.NewMapping("", m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error_matches rule %v: %w", i, err)
+		}
+		errorMatches = append(errorMatches, exec)
+	}
+
 	ctx, done := context.WithCancel(context.Background())
 	return &dropOn{
 		log:             log,
@@ -171,6 +203,7 @@ func newDropOn(conf DropOnConditions, wrapped Type, log log.Modular, stats metri
 		transactionsOut: make(chan types.Transaction),
 
 		onError:        conf.Error,
+		onErrorMatches: errorMatches,
 		onBackpressure: backPressure,
 
 		ctx:        ctx,
@@ -186,6 +219,7 @@ func (d *dropOn) loop() {
 	var (
 		mDropped      = d.stats.GetCounter("drop_on.dropped")
 		mDroppedBatch = d.stats.GetCounter("drop_on.batch.dropped")
+		mRuleDropped  = d.stats.GetCounterVec("drop_on.error_matches.dropped", []string{"rule"})
 	)
 
 	defer func() {
@@ -277,11 +311,19 @@ func (d *dropOn) loop() {
 			}
 		}
 
-		if res.Error() != nil && d.onError {
-			mDropped.Incr(int64(ts.Payload.Len()))
-			mDroppedBatch.Incr(1)
-			d.log.Warnf("Message dropped due to: %v\n", res.Error())
-			res = response.NewAck()
+		if res.Error() != nil {
+			if d.onError {
+				mDropped.Incr(int64(ts.Payload.Len()))
+				mDroppedBatch.Incr(1)
+				d.log.Warnf("Message dropped due to: %v\n", res.Error())
+				res = response.NewAck()
+			} else if rule := d.matchError(ts.Payload, res.Error()); rule >= 0 {
+				mDropped.Incr(int64(ts.Payload.Len()))
+				mDroppedBatch.Incr(1)
+				mRuleDropped.With(strconv.Itoa(rule)).Incr(int64(ts.Payload.Len()))
+				d.log.Warnf("Message dropped by error_matches rule %v due to: %v\n", rule, res.Error())
+				res = response.NewAck()
+			}
 		}
 
 		select {
@@ -292,6 +334,58 @@ func (d *dropOn) loop() {
 	}
 }
 
+// dropOnErrorDoc returns the document that error_matches rules are executed
+// against for an error.
+func dropOnErrorDoc(err error) map[string]interface{} {
+	doc := map[string]interface{}{
+		"error": err.Error(),
+		"class": output.ClassifyError(err).String(),
+	}
+	var resErr types.ErrUnexpectedHTTPRes
+	if errors.As(err, &resErr) {
+		doc["http_status"] = int64(resErr.Code)
+		if len(resErr.Body) > 0 {
+			doc["http_error_body"] = resErr.BodyString()
+		}
+	}
+	return doc
+}
+
+// matchError returns the index of the first error_matches rule that matches an
+// error returned for a message, or -1 if none match.
+func (d *dropOn) matchError(msg types.Message, err error) int {
+	if len(d.onErrorMatches) == 0 {
+		return -1
+	}
+
+	// Rules are executed against the error with the metadata of the first
+	// message of the batch.
+	var part types.Part
+	if msg.Len() > 0 {
+		part = msg.Get(0).Copy()
+	} else {
+		part = message.NewPart(nil)
+	}
+	if setErr := part.SetJSON(dropOnErrorDoc(err)); setErr != nil {
+		d.log.Errorf("Failed to create error_matches document: %v\n", setErr)
+		return -1
+	}
+	errMsg := message.New(nil)
+	errMsg.Append(part)
+
+	for i, exec := range d.onErrorMatches {
+		matched, qErr := exec.QueryPart(0, errMsg)
+		if qErr != nil {
+			d.log.Errorf("Failed to execute error_matches rule %v: %v\n", i, qErr)
+			continue
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
 // Consume assigns a messages channel for the output to read.
 func (d *dropOn) Consume(ts <-chan types.Transaction) error {
 	if d.transactionsIn != nil {
@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -66,7 +67,7 @@ func TestDropOnNothing(t *testing.T) {
 		t.Fatal("timed out")
 	}
 
-	assert.EqualError(t, res.Error(), fmt.Sprintf("%s: HTTP request returned unexpected response code (403): 403 Forbidden", ts.URL))
+	assert.EqualError(t, res.Error(), fmt.Sprintf("%s: HTTP request returned unexpected response code (403): 403 Forbidden: test error", ts.URL))
 }
 
 func TestDropOnError(t *testing.T) {
@@ -120,6 +121,92 @@ func TestDropOnError(t *testing.T) {
 	assert.NoError(t, res.Error())
 }
 
+func TestDropOnErrorMatches(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		switch string(b) {
+		case "conflict":
+			http.Error(w, "already exists", http.StatusConflict)
+		case "big":
+			http.Error(w, "payload too large", http.StatusBadRequest)
+		default:
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	childConf := NewConfig()
+	childConf.Type = TypeHTTPClient
+	childConf.HTTPClient.URL = ts.URL
+	childConf.HTTPClient.NumRetries = 0
+
+	child, err := New(childConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		child.CloseAsync()
+		assert.NoError(t, child.WaitForClose(time.Second*5))
+	})
+
+	dropConf := NewDropOnConfig()
+	dropConf.ErrorMatches = []string{
+		`this.http_status == 409 && meta("allow_conflicts") == "true"`,
+		`this.http_error_body.or("").contains("payload too large") && this.class == "terminal"`,
+	}
+
+	stats := metrics.NewLocal()
+	d, err := newDropOn(dropConf.DropOnConditions, child, log.Noop(), stats)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		d.CloseAsync()
+		assert.NoError(t, d.WaitForClose(time.Second*5))
+	})
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, d.Consume(tChan))
+
+	send := func(content string, meta ...string) error {
+		t.Helper()
+		msg := message.New([][]byte{[]byte(content)})
+		for i := 0; i < len(meta); i += 2 {
+			msg.Get(0).Metadata().Set(meta[i], meta[i+1])
+		}
+		rChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(msg, rChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-rChan:
+			return res.Error()
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	assert.NoError(t, send("conflict", "allow_conflicts", "true"))
+	assert.Error(t, send("conflict"))
+	assert.NoError(t, send("big"))
+	assert.Error(t, send("other"))
+
+	counters := stats.GetCountersWithLabels()
+	assert.Equal(t, int64(2), stats.GetCounters()["drop_on.dropped"])
+	assert.Equal(t, int64(2), stats.GetCounters()["drop_on.error_matches.dropped"])
+	ruleStat := counters["drop_on.error_matches.dropped"]
+	assert.True(t, ruleStat.HasLabelWithValue("rule", "1"))
+}
+
+func TestDropOnErrorMatchesBadRule(t *testing.T) {
+	dropConf := NewDropOnConfig()
+	dropConf.ErrorMatches = []string{`this.error ==`}
+
+	_, err := newDropOn(dropConf.DropOnConditions, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
 func TestDropOnBackpressureWithErrors(t *testing.T) {
 	// Skip this test in most runs as it relies on awkward timers.
 	t.Skip()
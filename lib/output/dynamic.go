@@ -160,17 +160,33 @@ func NewDynamic(
 		return err
 	})
 
-	mgr.RegisterEndpoint(
+	interop.RegisterEndpointSpec(mgr, api.NewEndpointSpec(
 		path.Join(conf.Dynamic.Prefix, "/outputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic outputs. For"+
 			" more information read the `dynamic` output type documentation.",
-		dynAPI.HandleCRUD,
-	)
-	mgr.RegisterEndpoint(
+		api.EndpointOperation{
+			Method:              "POST",
+			Summary:             "Create or replace a dynamic output.",
+			RequestContentTypes: []string{"application/json", "application/yaml"},
+		},
+		api.EndpointOperation{
+			Method:               "GET",
+			Summary:              "Read the configuration of a dynamic output.",
+			ResponseContentTypes: []string{"application/json"},
+		},
+		api.EndpointOperation{
+			Method:  "DELETE",
+			Summary: "Delete a dynamic output.",
+		},
+	), dynAPI.HandleCRUD)
+	interop.RegisterEndpointSpec(mgr, api.NewEndpointSpec(
 		path.Join(conf.Dynamic.Prefix, "/outputs"),
 		"Get a map of running output identifiers with their current uptimes.",
-		dynAPI.HandleList,
-	)
+		api.EndpointOperation{
+			Method:               "GET",
+			ResponseContentTypes: []string{"application/json"},
+		},
+	), dynAPI.HandleList)
 
 	return fanOut, nil
 }
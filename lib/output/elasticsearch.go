@@ -9,6 +9,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 )
@@ -46,11 +47,12 @@ false for connections to succeed.`,
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		}.Merge(retries.FieldSpecs()).Add(
 			auth.BasicAuthFieldSpec(),
+		).Merge(proxy.FieldSpecs()).Add(
 			batch.FieldSpec(),
 			docs.FieldAdvanced("aws", "Enables and customises connectivity to Amazon Elastic Service.").WithChildren(
 				docs.FieldSpecs{
 					docs.FieldCommon("enabled", "Whether to connect to Amazon Elastic Service."),
-				}.Merge(sess.FieldSpecs())...,
+				}.Merge(sess.SigningFieldSpecs())...,
 			),
 		),
 		Categories: []Category{
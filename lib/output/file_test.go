@@ -0,0 +1,41 @@
+package output
+
+import (
+	"context"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func binaryPayload(b *testing.B, size int) []byte {
+	b.Helper()
+
+	payload := make([]byte, size)
+	_, err := rand.Read(payload)
+	require.NoError(b, err)
+	return payload
+}
+
+func BenchmarkFileOutput10MB(b *testing.B) {
+	dir := b.TempDir()
+
+	w, err := newFileWriter(filepath.Join(dir, `${! meta("name") }.bin`), "all-bytes", log.Noop(), metrics.Noop())
+	require.NoError(b, err)
+
+	payload := binaryPayload(b, 10*1024*1024)
+	msg := message.New([][]byte{payload})
+	msg.Get(0).Metadata().Set("name", "foo")
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, w.WriteWithContext(context.Background(), msg))
+	}
+}
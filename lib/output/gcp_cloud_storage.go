@@ -12,6 +12,7 @@ type GCPCloudStorageConfig struct {
 	Path            string             `json:"path" yaml:"path"`
 	ContentType     string             `json:"content_type" yaml:"content_type"`
 	ContentEncoding string             `json:"content_encoding" yaml:"content_encoding"`
+	CollisionMode   string             `json:"collision_mode" yaml:"collision_mode"`
 	ChunkSize       int                `json:"chunk_size" yaml:"chunk_size"`
 	MaxInFlight     int                `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching        batch.PolicyConfig `json:"batching" yaml:"batching"`
@@ -24,6 +25,7 @@ func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
 		Path:            `${!count("files")}-${!timestamp_unix_nano()}.txt`,
 		ContentType:     "application/octet-stream",
 		ContentEncoding: "",
+		CollisionMode:   "overwrite",
 		ChunkSize:       googleapi.DefaultUploadChunkSize,
 		MaxInFlight:     1,
 		Batching:        batch.NewPolicyConfig(),
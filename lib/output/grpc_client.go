@@ -0,0 +1,31 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/impl/grpc/keepalive"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+// GRPCClientConfig contains configuration fields for the gRPC client output
+// type.
+type GRPCClientConfig struct {
+	Address           string                 `json:"address" yaml:"address"`
+	Timeout           string                 `json:"timeout" yaml:"timeout"`
+	TLS               tls.Config             `json:"tls" yaml:"tls"`
+	MaxMessageSize    int                    `json:"max_message_size" yaml:"max_message_size"`
+	Keepalive         keepalive.ClientConfig `json:"keepalive" yaml:"keepalive"`
+	MaxInFlight       int                    `json:"max_in_flight" yaml:"max_in_flight"`
+	PropagateResponse bool                   `json:"propagate_response" yaml:"propagate_response"`
+}
+
+// NewGRPCClientConfig creates a new GRPCClientConfig with default values.
+func NewGRPCClientConfig() GRPCClientConfig {
+	return GRPCClientConfig{
+		Address:           "localhost:4196",
+		Timeout:           "5s",
+		TLS:               tls.NewConfig(),
+		MaxMessageSize:    4194304,
+		Keepalive:         keepalive.NewClientConfig(),
+		MaxInFlight:       1,
+		PropagateResponse: false,
+	}
+}
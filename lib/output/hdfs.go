@@ -7,6 +7,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
 )
 
 //------------------------------------------------------------------------------
@@ -19,19 +20,62 @@ Sends message parts as files to a HDFS directory.`,
 		Description: `
 Each file is written with the path specified with the 'path' field, in order to
 have a different path for each object you should use function interpolations
-described [here](/docs/configuration/interpolation#bloblang-queries).`,
+described [here](/docs/configuration/interpolation#bloblang-queries).
+
+### Appending
+
+When ` + "`append`" + ` is set to ` + "`true`" + ` messages are appended to the
+file at the target path, which is created when it does not yet exist. This
+allows you to write to daily files with a path such as
+` + "`${! now().format_timestamp(\"2006-01-02\") }.jsonl`" + `. HDFS only allows a single
+client to write to a file at a time, and appends that fail due to the lease of
+the file being held by another client are retried according to the
+` + "`max_retries`" + ` and ` + "`backoff`" + ` fields.
+
+### Transports
+
+By default the output connects to the namenode RPC address of the cluster. For
+clusters where only the REST gateway is reachable set ` + "`transport`" + ` to
+` + "`webhdfs`" + `, in which case ` + "`hosts`" + ` should be the HTTP
+addresses of the namenodes (or an HttpFS gateway) such as
+` + "`namenode:9870`" + `, optionally prefixed with ` + "`https://`" + `. When
+multiple namenodes are listed requests fail over from those in standby.
+
+### Kerberos
+
+Kerberos authentication is currently only supported with the ` + "`webhdfs`" + `
+transport, where requests to the namenode are authenticated with SPNEGO. Either
+a ` + "`keytab_path`" + ` along with a ` + "`principal`" + ` and
+` + "`realm`" + `, or the ` + "`ccache_path`" + ` of an existing credentials
+cache, must be provided.`,
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("hosts", "A list of hosts to connect to.", "localhost:9000").Array(),
-			docs.FieldCommon("user", "A user identifier."),
+			docs.FieldCommon("user", "A user identifier. When using Kerberos authentication the user is instead determined by the principal."),
+			docs.FieldAdvanced("transport", "The protocol used to communicate with the cluster.").HasAnnotatedOptions(
+				"rpc", "Connect to the namenode RPC address of the cluster.",
+				"webhdfs", "Connect to the WebHDFS REST API of the cluster.",
+			).AtVersion("3.50.0"),
+			docs.FieldAdvanced("kerberos", "Configure Kerberos authentication, which is only supported with the `webhdfs` transport.").WithChildren(
+				docs.FieldAdvanced("enabled", "Whether to authenticate with Kerberos."),
+				docs.FieldAdvanced("principal", "The principal name to authenticate as when using a keytab.", "benthos", "benthos@EXAMPLE.COM"),
+				docs.FieldAdvanced("realm", "The realm of the principal, which can be omitted when included in the principal."),
+				docs.FieldAdvanced("keytab_path", "The path of a keytab file containing the key of the principal."),
+				docs.FieldAdvanced("ccache_path", "The path of a credentials cache to authenticate with instead of a keytab.", "/tmp/krb5cc_1000"),
+				docs.FieldAdvanced("config_path", "The path of the Kerberos configuration file."),
+				docs.FieldAdvanced("service_principal_name", "The service principal name of the namenodes. When empty it is derived from the host of each request as `HTTP/<host>`."),
+			).AtVersion("3.50.0"),
 			docs.FieldCommon("directory", "A directory to store message files within. If the directory does not exist it will be created."),
 			docs.FieldCommon(
 				"path", "The path to upload messages as, interpolation functions should be used in order to generate unique file paths.",
 				`${!count("files")}-${!timestamp_unix_nano()}.txt`,
 			).IsInterpolated(),
+			docs.FieldCommon("append", "Whether to append messages to the file at the target path rather than creating a new file, the file is created when it does not exist.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("replication", "Override the replication factor of created files, when zero the cluster default is used. With the `rpc` transport a block size override without a replication override uses a replication factor of 3.").AtVersion("3.50.0"),
+			docs.FieldAdvanced("block_size", "Override the block size in bytes of created files, when zero the cluster default is used. With the `rpc` transport a replication override without a block size override uses a block size of 128MiB.", 268435456).AtVersion("3.50.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
-		},
+		}.Merge(retries.FieldSpecs()),
 		Categories: []Category{
 			CategoryServices,
 		},
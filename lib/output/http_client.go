@@ -20,6 +20,28 @@ When the number of retries expires the output will reject the message, the
 behaviour after this will depend on the pipeline but usually this simply means
 the send is attempted again until successful whilst applying back pressure.
 
+Responses with status codes indicating that the request itself was rejected,
+such as ` + "`403` or `413`" + `, and codes listed in ` + "`drop_on`" + ` are
+considered terminal errors, which are not retried by this output or by a
+` + "[`retry`](/docs/components/outputs/retry)" + ` output and can instead be
+routed to a dead letter queue. Terminal errors can be retried regardless by
+listing patterns within the field ` + "`force_retry_on`" + `.
+
+Up to ` + "[`error_body_limit`](#error_body_limit)" + ` bytes of the body of
+failed responses are added to the error. When a rejected message is routed to a
+dead letter queue or the next output of a ` + "[`try`](/docs/components/outputs/try)" + `
+pattern it is given the metadata fields ` + "`http_status`" + `, containing the
+status code of the response, and ` + "`http_error_body`" + `, containing the
+captured body, where bodies that are not valid UTF-8 are base64 encoded.
+
+The number of requests outstanding at any given time, including those of
+parallel batches and ` + "`max_in_flight`" + ` writes, can be capped with the
+field ` + "[`max_concurrency`](#max_concurrency)" + `, where messages waiting
+longer than ` + "[`queue_timeout`](#queue_timeout)" + ` for a free slot fail and
+are retried. The gauge metric ` + "`client.concurrency`" + ` tracks the number
+of outstanding requests and the counter metric ` + "`client.queue_timeout`" + `
+counts requests that timed out waiting for a slot.
+
 The URL and header values of this type can be dynamically set using function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
@@ -28,6 +50,16 @@ message has multiple parts (is a batch) the request will be sent according to
 [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). This
 behaviour can be disabled by setting the field ` + "[`batch_as_multipart`](#batch_as_multipart) to `false`" + `.
 
+### Batch Formats
+
+Batches can instead be sent within a single request in a different format by
+setting the field ` + "[`batch_format`](#batch_format)" + `, where ` + "`json_array`" + `
+sends messages as the elements of a JSON array, ` + "`lines`" + ` sends each
+message on its own line (such as [JSON Lines](https://jsonlines.org/)), and
+` + "`binary_concat`" + ` concatenates the messages. When propagating responses
+the field ` + "[`response_format`](#response_format)" + ` can be used in order
+to split the response back into a message for each message of the batch.
+
 ### Propagating Responses
 
 It's possible to propagate the response from each HTTP request back to the input
@@ -36,11 +68,19 @@ support [synchronous responses](/docs/guides/sync_responses) are able to make us
 these propagated responses.`,
 		Async:   true,
 		Batches: true,
-		FieldSpecs: client.FieldSpecs().Add(
-			docs.FieldAdvanced("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). If disabled messages in batches will be sent as individual requests."),
+		config: client.ComponentSpec(client.FieldSpecs().Add(
+			docs.FieldAdvanced("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). If disabled messages in batches will be sent as individual requests. This field is ignored when `batch_format` is not `multipart`."),
+		).Add(client.BatchFormatFieldSpecs()...).Add(client.ConcurrencyFieldSpecs()...).Add(
 			docs.FieldAdvanced("propagate_response", "Whether responses from the server should be [propagated back](/docs/guides/sync_responses) to the input."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-		).Add(batch.FieldSpec()),
+			docs.FieldAdvanced("keepalive_interval", "An optional period of inactivity after which a `HEAD` request is sent to the `keepalive_url` in order to check that the server is reachable. The timestamp of the last successful request is exposed as the gauge `connection.last_ping`.", "30s").AtVersion("3.50.0"),
+			docs.FieldAdvanced("keepalive_url", "A URL to send keepalive requests to, which is required when `keepalive_interval` is set. Requests use the same TLS, proxy and authentication settings as messages.", "http://localhost:4195/ping").AtVersion("3.50.0"),
+			docs.FieldAdvanced(
+				"force_retry_on",
+				"A list of patterns that, when found within the message of an error classified as terminal, cause the request to be retried anyway. The value `terminal` causes all terminal errors to be retried. Status codes listed in `drop_on` are never retried.",
+				[]string{"403 Forbidden"}, []string{"terminal"},
+			).Array().HasType(docs.FieldTypeString).AtVersion("3.50.0"),
+		).Add(batch.FieldSpec())),
 		Categories: []Category{
 			CategoryNetwork,
 		},
@@ -57,7 +97,10 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 	if err != nil {
 		return w, err
 	}
-	if !conf.HTTPClient.BatchAsMultipart {
+	if err = setKeepaliveInterval(w, conf.HTTPClient.KeepaliveInterval); err != nil {
+		return nil, err
+	}
+	if !conf.HTTPClient.BatchAsMultipart && conf.HTTPClient.BatchFormat == "multipart" {
 		w = OnlySinglePayloads(w)
 	}
 	return NewBatcherFromConfig(conf.HTTPClient.Batching, w, mgr, log, stats)
@@ -14,6 +14,8 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -41,6 +43,10 @@ Three endpoints will be registered at the paths specified by the fields
 single message batch, a continuous stream of line delimited messages, or a
 websocket of messages for each request respectively.
 
+When a custom ` + "`address`" + ` is specified these endpoints are described by
+an [OpenAPI 3](https://swagger.io/specification/) document served at the path
+` + "`/docs/openapi.json`" + `.
+
 When messages are batched the ` + "`path`" + ` endpoint encodes the batch
 according to [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).
 This behaviour can be overridden by
@@ -199,42 +205,72 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		}
 	}
 
+	var getOps, streamOps []api.EndpointOperation
+	for _, verb := range h.conf.HTTPServer.AllowedVerbs {
+		getOps = append(getOps, api.EndpointOperation{
+			Method:               verb,
+			Summary:              "Read a single message, or a batch of messages as a multipart response.",
+			ResponseContentTypes: []string{"*/*"},
+		})
+		streamOps = append(streamOps, api.EndpointOperation{
+			Method:               verb,
+			Summary:              "Read a continuous stream of messages as a chunked response.",
+			ResponseContentTypes: []string{"*/*"},
+		})
+	}
+	getSpec := api.NewEndpointSpec(
+		h.conf.HTTPServer.Path, "Read a single message from Benthos.", getOps...,
+	)
+	streamSpec := api.NewEndpointSpec(
+		h.conf.HTTPServer.StreamPath, "Read a continuous stream of messages from Benthos.", streamOps...,
+	)
+	wsSpec := api.NewEndpointSpec(
+		h.conf.HTTPServer.WSPath, "Read messages from Benthos via websockets.",
+		api.EndpointOperation{
+			Method:  "GET",
+			Summary: "Upgrade to a websocket connection where messages are written as payloads.",
+		},
+	)
+
 	if mux != nil {
-		if len(h.conf.HTTPServer.Path) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.Path, h.getHandler)
+		var specs []api.EndpointSpec
+		var openAPIConflict bool
+		for _, e := range []struct {
+			spec    api.EndpointSpec
+			handler http.HandlerFunc
+		}{
+			{getSpec, h.getHandler},
+			{streamSpec, h.streamHandler},
+			{wsSpec, h.wsHandler},
+		} {
+			if len(e.spec.Path) > 0 {
+				h.mux.HandleFunc(e.spec.Path, e.handler)
+				specs = append(specs, e.spec)
+				openAPIConflict = openAPIConflict || e.spec.Path == httpServerOpenAPIPath
+			}
 		}
-		if len(h.conf.HTTPServer.StreamPath) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.StreamPath, h.streamHandler)
-		}
-		if len(h.conf.HTTPServer.WSPath) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.WSPath, h.wsHandler)
+		if !openAPIConflict {
+			h.mux.HandleFunc(httpServerOpenAPIPath, api.OpenAPIHandler("Benthos http_server output", "", specs...))
 		}
 	} else {
 		if len(h.conf.HTTPServer.Path) > 0 {
-			mgr.RegisterEndpoint(
-				h.conf.HTTPServer.Path, "Read a single message from Benthos.",
-				h.getHandler,
-			)
+			interop.RegisterEndpointSpec(mgr, getSpec, h.getHandler)
 		}
 		if len(h.conf.HTTPServer.StreamPath) > 0 {
-			mgr.RegisterEndpoint(
-				h.conf.HTTPServer.StreamPath,
-				"Read a continuous stream of messages from Benthos.",
-				h.streamHandler,
-			)
+			interop.RegisterEndpointSpec(mgr, streamSpec, h.streamHandler)
 		}
 		if len(h.conf.HTTPServer.WSPath) > 0 {
-			mgr.RegisterEndpoint(
-				h.conf.HTTPServer.WSPath,
-				"Read messages from Benthos via websockets.",
-				h.wsHandler,
-			)
+			interop.RegisterEndpointSpec(mgr, wsSpec, h.wsHandler)
 		}
 	}
 
 	return &h, nil
 }
 
+// httpServerOpenAPIPath is the path that an OpenAPI document is served from
+// when the server is hosted on a custom address.
+const httpServerOpenAPIPath = "/docs/openapi.json"
+
 //------------------------------------------------------------------------------
 
 func (h *HTTPServer) getHandler(w http.ResponseWriter, r *http.Request) {
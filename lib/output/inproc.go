@@ -1,6 +1,8 @@
 package output
 
 import (
+	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -26,7 +29,11 @@ feedback loops can lead to deadlocks in your message flow.
 It is possible to connect multiple inputs to the same inproc ID, resulting in
 messages dispatching in a round-robin fashion to connected inputs. However, only
 one output can assume an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+collision occurs.
+
+In order to queue messages for inputs, or to fail messages that no input
+consumes within a timeout, use the
+` + "[`inproc_buffered`](/docs/components/outputs/inproc_buffered)" + ` output.`,
 		Categories: []Category{
 			CategoryUtility,
 		},
@@ -46,61 +53,74 @@ func NewInprocConfig() InprocConfig {
 
 //------------------------------------------------------------------------------
 
+// ErrInprocTimeout is returned to producers when a message written to an
+// inproc pipe isn't taken by a consumer within the configured timeout.
+var ErrInprocTimeout = errors.New("no inproc consumer took the message in time")
+
+type queuedTransaction struct {
+	ts       types.Transaction
+	queuedAt time.Time
+}
+
 // Inproc is an output type that serves Inproc messages.
 type Inproc struct {
 	running int32
 
-	pipe  string
-	mgr   types.Manager
-	log   log.Modular
-	stats metrics.Type
+	pipe    string
+	timeout time.Duration
+	mgr     types.Manager
+	log     log.Modular
+	stats   metrics.Type
 
 	transactionsOut chan types.Transaction
 	transactionsIn  <-chan types.Transaction
+	queue           chan queuedTransaction
 
+	loops      sync.WaitGroup
 	closedChan chan struct{}
 	closeChan  chan struct{}
 }
 
 // NewInproc creates a new Inproc output type.
 func NewInproc(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	return newInproc(string(conf.Inproc), 0, 0, mgr, log, stats), nil
+}
+
+func newInproc(pipe string, buffer int, timeout time.Duration, mgr types.Manager, log log.Modular, stats metrics.Type) *Inproc {
 	i := &Inproc{
 		running:         1,
-		pipe:            string(conf.Inproc),
+		pipe:            pipe,
+		timeout:         timeout,
 		mgr:             mgr,
 		log:             log,
 		stats:           stats,
 		transactionsOut: make(chan types.Transaction),
+		queue:           make(chan queuedTransaction, buffer),
 		closedChan:      make(chan struct{}),
 		closeChan:       make(chan struct{}),
 	}
 	mgr.SetPipe(i.pipe, i.transactionsOut)
-	return i, nil
+	return i
 }
 
 //------------------------------------------------------------------------------
 
-// loop is an internal loop that brokers incoming messages to output pipe.
+// loop is an internal loop that queues incoming messages for the output pipe.
 func (i *Inproc) loop() {
 	var (
-		mRunning       = i.stats.GetGauge("running")
-		mCount         = i.stats.GetCounter("count")
-		mPartsCount    = i.stats.GetCounter("parts.count")
-		mSendSucc      = i.stats.GetCounter("send.success")
-		mPartsSendSucc = i.stats.GetCounter("parts.send.success")
-		mSent          = i.stats.GetCounter("batch.sent")
-		mPartsSent     = i.stats.GetCounter("sent")
+		mRunning    = i.stats.GetGauge("running")
+		mCount      = i.stats.GetCounter("count")
+		mPartsCount = i.stats.GetCounter("parts.count")
+		mDepth      = i.stats.GetGauge("queue.depth")
+		mBlocked    = i.stats.GetTimer("blocked")
 	)
 
 	defer func() {
 		mRunning.Decr(1)
-		atomic.StoreInt32(&i.running, 0)
-		i.mgr.UnsetPipe(i.pipe, i.transactionsOut)
-		close(i.transactionsOut)
-		close(i.closedChan)
+		close(i.queue)
+		i.loops.Done()
 	}()
 	mRunning.Incr(1)
-
 	i.log.Infof("Sending inproc messages to ID: %s\n", i.pipe)
 
 	var open bool
@@ -114,32 +134,119 @@ func (i *Inproc) loop() {
 		case <-i.closeChan:
 			return
 		}
-
 		mCount.Incr(1)
 		if ts.Payload != nil {
 			mPartsCount.Incr(int64(ts.Payload.Len()))
 		}
+
+		// When the queue is full (or unbuffered) we are blocked until a
+		// consumer takes a message from the pipe.
+		tBlocked := time.Now()
 		select {
-		case i.transactionsOut <- ts:
+		case i.queue <- queuedTransaction{ts: ts, queuedAt: tBlocked}:
+		case <-i.closeChan:
+			return
+		}
+		mBlocked.Timing(time.Since(tBlocked).Nanoseconds())
+		mDepth.Set(int64(len(i.queue)))
+	}
+}
+
+// pipeLoop is an internal loop that brokers queued messages to the output pipe.
+func (i *Inproc) pipeLoop() {
+	var (
+		mSendSucc      = i.stats.GetCounter("send.success")
+		mPartsSendSucc = i.stats.GetCounter("parts.send.success")
+		mSent          = i.stats.GetCounter("batch.sent")
+		mPartsSent     = i.stats.GetCounter("sent")
+		mTimeout       = i.stats.GetCounter("send.timeout")
+		mDepth         = i.stats.GetGauge("queue.depth")
+	)
+
+	defer i.loops.Done()
+
+	stalled := false
+	for {
+		qt, open := <-i.queue
+		if !open {
+			return
+		}
+		mDepth.Set(int64(len(i.queue)))
+
+		ts := qt.ts
+		sent, open := i.send(ts, qt.queuedAt)
+		if !open {
+			return
+		}
+		if sent {
+			stalled = false
 			mSendSucc.Incr(1)
 			mSent.Incr(1)
 			if ts.Payload != nil {
 				mPartsSendSucc.Incr(int64(ts.Payload.Len()))
 				mPartsSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
 			}
+			continue
+		}
+
+		if !stalled {
+			i.log.Warnf("No consumer of inproc ID '%v' took a message within the timeout, failing messages until a consumer connects\n", i.pipe)
+			stalled = true
+		}
+		mTimeout.Incr(1)
+		select {
+		case ts.ResponseChan <- response.NewError(ErrInprocTimeout):
 		case <-i.closeChan:
 			return
 		}
 	}
 }
 
+// send attempts to hand a transaction to a consumer of the pipe, waiting until
+// the timeout has passed since it was queued. Returns false for open when the
+// output is closing.
+func (i *Inproc) send(ts types.Transaction, queuedAt time.Time) (sent, open bool) {
+	var timeoutChan <-chan time.Time
+	if i.timeout > 0 {
+		remaining := i.timeout - time.Since(queuedAt)
+		if remaining <= 0 {
+			// A consumer that is ready right now may still take it.
+			select {
+			case i.transactionsOut <- ts:
+				return true, true
+			default:
+				return false, true
+			}
+		}
+		timer := time.NewTimer(remaining)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+	select {
+	case i.transactionsOut <- ts:
+		return true, true
+	case <-timeoutChan:
+		return false, true
+	case <-i.closeChan:
+		return false, false
+	}
+}
+
 // Consume assigns a messages channel for the output to read.
 func (i *Inproc) Consume(ts <-chan types.Transaction) error {
 	if i.transactionsIn != nil {
 		return types.ErrAlreadyStarted
 	}
 	i.transactionsIn = ts
+	i.loops.Add(2)
 	go i.loop()
+	go i.pipeLoop()
+	go func() {
+		i.loops.Wait()
+		i.mgr.UnsetPipe(i.pipe, i.transactionsOut)
+		close(i.transactionsOut)
+		close(i.closedChan)
+	}()
 	return nil
 }
 
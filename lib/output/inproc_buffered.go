@@ -0,0 +1,98 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeInprocBuffered] = TypeSpec{
+		constructor: fromSimpleConstructor(NewInprocBuffered),
+		Status:      docs.StatusExperimental,
+		Version:     "3.50.0",
+		Summary: `
+A variant of the ` + "[`inproc`](/docs/components/outputs/inproc)" + ` output
+that queues messages for connected inputs, and fails messages that aren't
+consumed within a timeout.`,
+		Description: `
+Inputs connect to this output with an ` + "[`inproc` input](/docs/components/inputs/inproc)" + `
+that references the same ` + "`id`" + `. Up to ` + "`buffer`" + ` messages are
+queued for consumers, which allows a producer stream to continue whilst a
+consumer stream is briefly slow. Messages are still only acknowledged once the
+consuming stream has delivered them.
+
+When a ` + "`timeout`" + ` is set, messages that are not taken by a consumer
+within it are failed, which allows retry or fallback logic of the producer
+stream to act when the consumer stream is removed, rather than waiting
+indefinitely.
+
+### Metrics
+
+The metric ` + "`queue.depth`" + ` reports the number of queued messages, and
+the timing metric ` + "`blocked`" + ` reports how long the output waited to
+queue each message. Messages that timed out are counted by
+` + "`send.timeout`" + `.
+
+The active pipes of a process and the number of connected consumers can be
+viewed at the endpoint ` + "`/debug/inproc`" + ` when debug endpoints are
+enabled.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("id", "The ID that inputs connect to."),
+			docs.FieldCommon("buffer", "The maximum number of messages to queue for consumers before blocking."),
+			docs.FieldCommon("timeout", "An optional period after which a message that hasn't been taken by a consumer fails. When empty messages wait indefinitely.", "5s", "1m"),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InprocBufferedConfig contains configuration fields for the InprocBuffered
+// output type.
+type InprocBufferedConfig struct {
+	ID      string `json:"id" yaml:"id"`
+	Buffer  int    `json:"buffer" yaml:"buffer"`
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// NewInprocBufferedConfig creates a new InprocBufferedConfig with default
+// values.
+func NewInprocBufferedConfig() InprocBufferedConfig {
+	return InprocBufferedConfig{
+		ID:      "",
+		Buffer:  100,
+		Timeout: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewInprocBuffered creates a new InprocBuffered output type.
+func NewInprocBuffered(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.InprocBuffered.ID == "" {
+		return nil, errors.New("an id must be specified")
+	}
+	if conf.InprocBuffered.Buffer < 0 {
+		return nil, errors.New("buffer must not be negative")
+	}
+	var timeout time.Duration
+	if conf.InprocBuffered.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(conf.InprocBuffered.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+	return newInproc(conf.InprocBuffered.ID, conf.InprocBuffered.Buffer, timeout, mgr, log, stats), nil
+}
+
+//------------------------------------------------------------------------------
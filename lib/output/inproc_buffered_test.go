@@ -0,0 +1,142 @@
+package output_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInprocBufferedQueue(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInprocBuffered
+	conf.InprocBuffered.ID = "foo"
+	conf.InprocBuffered.Buffer = 2
+
+	ip, err := output.NewInprocBuffered(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		ip.CloseAsync()
+		assert.NoError(t, ip.WaitForClose(time.Second))
+	}()
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, ip.Consume(tChan))
+
+	// Without a consumer the output should accept messages until the queue,
+	// plus the message waiting to be sent, is full.
+	resChans := make([]chan types.Response, 3)
+	for i := range resChans {
+		resChans[i] = make(chan types.Response, 1)
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte{byte('a' + i)}}), resChans[i]):
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out sending message %v", i)
+		}
+	}
+
+	pipe, err := mgr.GetPipe("foo")
+	require.NoError(t, err)
+
+	for i := range resChans {
+		select {
+		case ts := <-pipe:
+			assert.Equal(t, string([]byte{byte('a' + i)}), string(ts.Payload.Get(0).Get()))
+			ts.ResponseChan <- response.NewAck()
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out receiving message %v", i)
+		}
+		select {
+		case res := <-resChans[i]:
+			assert.NoError(t, res.Error())
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for response %v", i)
+		}
+	}
+}
+
+func TestInprocBufferedTimeout(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInprocBuffered
+	conf.InprocBuffered.ID = "foo"
+	conf.InprocBuffered.Buffer = 10
+	conf.InprocBuffered.Timeout = "50ms"
+
+	ip, err := output.NewInprocBuffered(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		ip.CloseAsync()
+		assert.NoError(t, ip.WaitForClose(time.Second))
+	}()
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, ip.Consume(tChan))
+
+	resChans := make([]chan types.Response, 5)
+	for i := range resChans {
+		resChans[i] = make(chan types.Response, 1)
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChans[i]):
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out sending message %v", i)
+		}
+	}
+
+	// All messages should fail shortly after the timeout as there are no
+	// consumers.
+	for i, resChan := range resChans {
+		select {
+		case res := <-resChan:
+			assert.Equal(t, output.ErrInprocTimeout, res.Error())
+		case <-time.After(time.Millisecond * 500):
+			t.Fatalf("Timed out waiting for response %v", i)
+		}
+	}
+
+	// Consumers that connect afterwards receive new messages.
+	pipe, err := mgr.GetPipe("foo")
+	require.NoError(t, err)
+
+	resChan := make(chan types.Response, 1)
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case ts := <-pipe:
+		assert.Equal(t, "bar", string(ts.Payload.Get(0).Get()))
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+func TestInprocBufferedBadConfig(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInprocBuffered
+
+	_, err = output.NewInprocBuffered(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "an id must be specified")
+
+	conf.InprocBuffered.ID = "foo"
+	conf.InprocBuffered.Timeout = "nope"
+	_, err = output.NewInprocBuffered(conf, mgr, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
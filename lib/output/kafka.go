@@ -27,7 +27,7 @@ The config field ` + "`ack_replicas`" + ` determines whether we wait for acknowl
 
 Both the ` + "`key` and `topic`" + ` fields can be dynamically set using function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
-[Metadata](/docs/configuration/metadata) will be added to each message sent as headers, but can be restricted using the field ` + "[`metadata`](#metadata)" + `.
+[Metadata](/docs/configuration/metadata) will be added to each message sent as headers, but can be restricted using the field ` + "[`metadata`](#metadata)" + `. Metadata values are always strings, and therefore headers with raw byte values or multiple headers sharing the same key can instead be added with the field ` + "[`headers_mapping`](#headers_mapping)" + `.
 
 ### Strict Ordering and Retries
 
@@ -35,7 +35,9 @@ When strict ordering is required for messages written to topic partitions it is
 
 You must also ensure that failed batches are never rerouted back to the same output. This can be done by setting the field ` + "`max_retries` to `0` and `backoff.max_elapsed_time`" + ` to empty, which will apply back pressure indefinitely until the batch is sent successfully.
 
-However, this also means that manual intervention will eventually be required in cases where the batch cannot be sent due to configuration problems such as an incorrect ` + "`max_msg_bytes`" + ` estimate. A less strict but automated alternative would be to route failed batches to a dead letter queue using a ` + "[`try` broker](/docs/components/outputs/try)" + `, but this would allow subsequent batches to be delivered in the meantime whilst those failed batches are dealt with.`,
+However, this also means that manual intervention will eventually be required in cases where the batch cannot be sent due to configuration problems such as an incorrect ` + "`max_msg_bytes`" + ` estimate. A less strict but automated alternative would be to route failed batches to a dead letter queue using a ` + "[`try` broker](/docs/components/outputs/try)" + `, but this would allow subsequent batches to be delivered in the meantime whilst those failed batches are dealt with.
+
+Errors returned by brokers that won't be resolved by sending the batch again, such as a message exceeding the maximum size accepted by the topic or the producer lacking authorization, skip the retries of this output and are returned immediately.`,
 		Async:   true,
 		Batches: true,
 		FieldSpecs: append(docs.FieldSpecs{
@@ -50,6 +52,9 @@ However, this also means that manual intervention will eventually be required in
 			docs.FieldCommon("compression", "The compression algorithm to use.").HasOptions("none", "snappy", "lz4", "gzip"),
 			docs.FieldString("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}).Map(),
 			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent with messages as headers.").WithChildren(output.MetadataFields()...),
+			docs.FieldAdvanced("headers_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) executed for each message that results in an object, where each key is added as a header in addition to metadata and static headers. String values are written as UTF-8 and bytes values, such as those produced by the method [`decode`](/docs/guides/bloblang/methods#decode), are written unchanged. When a value is an array a header is added for each element with the same key, and `null` values are skipped.", `root.trace_id = meta("kafka_header_base64_trace_id").decode("base64")
+root.tag = this.tags`,
+			).AtVersion("3.50.0"),
 			output.InjectTracingSpanMappingDocs,
 			docs.FieldCommon("max_in_flight", "The maximum number of parallel message batches to have in flight at any given time."),
 			docs.FieldAdvanced("ack_replicas", "Ensure that messages have been copied across all replicas before acknowledging receipt."),
@@ -57,11 +62,40 @@ However, this also means that manual intervention will eventually be required in
 			docs.FieldAdvanced("timeout", "The maximum period of time to wait for message sends before abandoning the request and retrying."),
 			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
 			docs.FieldAdvanced("retry_as_batch", "When enabled forces an entire batch of messages to be retried if any individual message fails on a send, otherwise only the individual messages that failed are retried. Disabling this helps to reduce message duplicates during intermittent errors, but also makes it impossible to guarantee strict ordering of messages."),
+			docs.FieldAdvanced("keepalive_interval", "An optional period of inactivity after which the connection is checked by requesting cluster metadata from the brokers, reconnecting if the request fails. The timestamp of the last successful check is exposed as the gauge `connection.last_ping`. Leave empty to disable these checks.", "30s").AtVersion("3.50.0"),
 			batch.FieldSpec(),
 		}, retries.FieldSpecs()...),
 		Categories: []Category{
 			CategoryServices,
 		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Forwarding Binary Headers",
+				Summary: `
+Here we route messages consumed from one topic to a topic chosen by their
+` + "`event_type`" + ` header, and preserve a binary ` + "`trace_id`" + ` header,
+which the ` + "`kafka`" + ` input exposes base64 encoded as it isn't valid UTF-8.
+The ` + "`audit`" + ` headers are added once for each element of an array within
+the payload:`,
+				Config: `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ events ]
+    consumer_group: router
+
+output:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: 'events_${! meta("event_type") }'
+    metadata:
+      exclude_prefixes: [ kafka_, trace_id ]
+    headers_mapping: |
+      root.trace_id = meta("kafka_header_base64_trace_id").decode("base64").catch(meta("trace_id"))
+      root.audit = this.audit_log.map_each(entry -> entry.id)
+`,
+			},
+		},
 	}
 }
 
@@ -80,6 +114,10 @@ func NewKafka(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 		return nil, err
 	}
 
+	if err = setKeepaliveInterval(w, conf.Kafka.KeepaliveInterval); err != nil {
+		return nil, err
+	}
+
 	if conf.Kafka.InjectTracingMap != "" {
 		aw, ok := w.(*AsyncWriter)
 		if !ok {
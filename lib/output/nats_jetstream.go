@@ -1,6 +1,7 @@
 package output
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/nats-io/nats.go"
 )
@@ -8,10 +9,11 @@ import (
 // NATSJetStreamConfig contains configuration fields for the NATS Jetstream
 // input type.
 type NATSJetStreamConfig struct {
-	URLs        []string   `json:"urls" yaml:"urls"`
-	Subject     string     `json:"subject" yaml:"subject"`
-	MaxInFlight int        `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS         tls.Config `json:"tls" yaml:"tls"`
+	URLs        []string    `json:"urls" yaml:"urls"`
+	Subject     string      `json:"subject" yaml:"subject"`
+	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS         tls.Config  `json:"tls" yaml:"tls"`
+	Auth        auth.Config `json:"auth" yaml:"auth"`
 }
 
 // NewNATSJetStreamConfig creates a new NATSJetstreamConfig with default values.
@@ -21,5 +23,6 @@ func NewNATSJetStreamConfig() NATSJetStreamConfig {
 		Subject:     "",
 		MaxInFlight: 1024,
 		TLS:         tls.NewConfig(),
+		Auth:        auth.NewConfig(),
 	}
 }
@@ -45,7 +45,8 @@ func (f *fakeWriter) WaitForClose(time.Duration) error {
 //------------------------------------------------------------------------------
 
 type fakeProcMgr struct {
-	outs map[string]types.OutputWriter
+	outs  map[string]types.OutputWriter
+	procs map[string]types.Processor
 }
 
 func (f *fakeProcMgr) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
@@ -57,6 +58,9 @@ func (f *fakeProcMgr) GetCondition(name string) (types.Condition, error) {
 	return nil, types.ErrConditionNotFound
 }
 func (f *fakeProcMgr) GetProcessor(name string) (types.Processor, error) {
+	if p, exists := f.procs[name]; exists {
+		return p, nil
+	}
 	return nil, types.ErrProcessorNotFound
 }
 func (f *fakeProcMgr) GetOutput(name string) (types.OutputWriter, error) {
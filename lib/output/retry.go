@@ -8,9 +8,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -38,9 +41,45 @@ we want to avoid reapplying to the same message more than once in the pipeline.
 
 Rather than retrying the same output you may wish to retry the send using a
 different output target (a dead letter queue). In which case you should instead
-use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.`,
+use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.
+
+### Terminal Errors
+
+Some outputs classify errors that cannot be resolved by attempting the same
+write again as terminal, such as an authorization failure or a payload that the
+target considers invalid. Terminal errors are not retried by this output, and
+are instead propagated immediately so that they can be handled by a
+` + "[`try`](/docs/components/outputs/try)" + ` output acting as a dead letter
+queue.
+
+Targets that report transient problems in ways that look terminal can be
+retried regardless by listing patterns within the field ` + "`force_retry_on`" + `.
+
+### Partially Failed Batches
+
+Some outputs, such as ` + "`elasticsearch`" + `, ` + "`aws_sqs`" + `,
+` + "`aws_kinesis`" + ` and ` + "`aws_dynamodb`" + `, report exactly which
+messages of a batch failed to send. When this is the case only the failed
+messages are retried, in their original order, and the batch is acknowledged
+once all of its messages have been sent. If retries are exhausted or a terminal
+error is returned then only the messages that were not sent are reported as
+failed, allowing inputs that acknowledge messages individually to avoid
+redelivering messages that were already sent.
+
+Targets that are sensitive to the ordering of messages across retries can set
+the field ` + "`retry_whole_batch`" + ` to ` + "`true`" + `, in which case the
+entire batch is retried whenever any of its messages fail.`,
 		FieldSpecs: retries.FieldSpecs().Add(
 			docs.FieldCommon("output", "A child output.").HasType(docs.FieldTypeOutput),
+			docs.FieldAdvanced(
+				"force_retry_on",
+				"A list of patterns that, when found within the message of an error classified as terminal, cause the error to be retried anyway. The value `terminal` causes all terminal errors to be retried.",
+				[]string{"403 Forbidden"}, []string{"terminal"},
+			).Array().HasType(docs.FieldTypeString).AtVersion("3.50.0"),
+			docs.FieldAdvanced(
+				"retry_whole_batch",
+				"Whether to retry all messages of a batch when only some of them failed to send, rather than only the failed messages. This preserves the ordering of a batch for targets that are sensitive to it, at the cost of sending the successful messages again.",
+			).HasType(docs.FieldTypeBool).HasDefault(false).AtVersion("3.50.0"),
 		),
 		Categories: []Category{
 			CategoryUtility,
@@ -52,8 +91,10 @@ use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.`,
 
 // RetryConfig contains configuration values for the Retry output type.
 type RetryConfig struct {
-	Output         *Config `json:"output" yaml:"output"`
-	retries.Config `json:",inline" yaml:",inline"`
+	Output          *Config  `json:"output" yaml:"output"`
+	ForceRetryOn    []string `json:"force_retry_on" yaml:"force_retry_on"`
+	RetryWholeBatch bool     `json:"retry_whole_batch" yaml:"retry_whole_batch"`
+	retries.Config  `json:",inline" yaml:",inline"`
 }
 
 // NewRetryConfig creates a new RetryConfig with default values.
@@ -64,23 +105,29 @@ func NewRetryConfig() RetryConfig {
 	rConf.Backoff.MaxInterval = "1s"
 	rConf.Backoff.MaxElapsedTime = "0s"
 	return RetryConfig{
-		Output: nil,
-		Config: retries.NewConfig(),
+		Output:          nil,
+		ForceRetryOn:    []string{},
+		RetryWholeBatch: false,
+		Config:          retries.NewConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
 type dummyRetryConfig struct {
-	Output         interface{} `json:"output" yaml:"output"`
-	retries.Config `json:",inline" yaml:",inline"`
+	Output          interface{} `json:"output" yaml:"output"`
+	ForceRetryOn    []string    `json:"force_retry_on" yaml:"force_retry_on"`
+	RetryWholeBatch bool        `json:"retry_whole_batch" yaml:"retry_whole_batch"`
+	retries.Config  `json:",inline" yaml:",inline"`
 }
 
 // MarshalJSON prints an empty object instead of nil.
 func (r RetryConfig) MarshalJSON() ([]byte, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:          r.Output,
+		ForceRetryOn:    r.ForceRetryOn,
+		RetryWholeBatch: r.RetryWholeBatch,
+		Config:          r.Config,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -91,8 +138,10 @@ func (r RetryConfig) MarshalJSON() ([]byte, error) {
 // MarshalYAML prints an empty object instead of nil.
 func (r RetryConfig) MarshalYAML() (interface{}, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:          r.Output,
+		ForceRetryOn:    r.ForceRetryOn,
+		RetryWholeBatch: r.RetryWholeBatch,
+		Config:          r.Config,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -108,8 +157,9 @@ type Retry struct {
 	running int32
 	conf    RetryConfig
 
-	wrapped     Type
-	backoffCtor func() backoff.BackOff
+	wrapped      Type
+	backoffCtor  func() backoff.BackOff
+	forceRetryOn output.ForceRetryOn
 
 	stats metrics.Type
 	log   log.Modular
@@ -150,6 +200,7 @@ func NewRetry(
 		stats:           stats,
 		wrapped:         wrapped,
 		backoffCtor:     boffCtor,
+		forceRetryOn:    output.ForceRetryOn(conf.Retry.ForceRetryOn),
 		transactionsOut: make(chan types.Transaction),
 
 		closeChan:  make(chan struct{}),
@@ -168,6 +219,7 @@ func (r *Retry) loop() {
 		mPartsSuccess = r.stats.GetCounter("retry.parts.send.success")
 		mError        = r.stats.GetCounter("retry.send.error")
 		mEndOfRetries = r.stats.GetCounter("retry.end_of_retries")
+		mTerminal     = r.stats.GetCounter("retry.terminal")
 	)
 
 	wg := sync.WaitGroup{}
@@ -212,18 +264,27 @@ func (r *Retry) loop() {
 			return
 		}
 
+		// The messages of a batch are tagged so that those that failed can be
+		// identified from a batch error and retried on their own.
+		var sortGroup *imessage.SortGroup
+		sendMsg := tran.Payload
+		if !r.conf.RetryWholeBatch && sendMsg.Len() > 1 {
+			sortGroup, sendMsg = imessage.NewSortGroup(sendMsg)
+		}
+
 		rChan := make(chan types.Response)
 		select {
-		case r.transactionsOut <- types.NewTransaction(tran.Payload, rChan):
+		case r.transactionsOut <- types.NewTransaction(sendMsg, rChan):
 		case <-r.closeChan:
 			return
 		}
 
 		wg.Add(1)
-		go func(ts types.Transaction, resChan chan types.Response) {
+		go func(ts types.Transaction, sortGroup *imessage.SortGroup, sendMsg types.Message, resChan chan types.Response) {
 			var backOff backoff.BackOff
 			var resOut types.Response
 			var inErrLoop bool
+			var failedErrs map[int]error
 
 			defer func() {
 				wg.Done()
@@ -255,6 +316,19 @@ func (r *Retry) loop() {
 
 					mError.Incr(1)
 
+					if sortGroup != nil {
+						if failedMsg, errs := failedParts(sortGroup, sendMsg, res.Error()); errs != nil {
+							sendMsg, failedErrs = failedMsg, errs
+						}
+					}
+
+					if r.forceRetryOn.Classify(res.Error()) == output.ErrorClassTerminal {
+						mTerminal.Incr(1)
+						r.log.Errorf("Failed to send message due to terminal error: %v\n", res.Error())
+						resOut = response.NewError(failedPartsError(ts.Payload, failedErrs, res.Error()))
+						break
+					}
+
 					if backOff == nil {
 						backOff = r.backoffCtor()
 					}
@@ -263,7 +337,11 @@ func (r *Retry) loop() {
 					if nextBackoff == backoff.Stop {
 						mEndOfRetries.Incr(1)
 						r.log.Errorf("Failed to send message: %v\n", res.Error())
-						resOut = response.NewNoack()
+						if failedErrs != nil {
+							resOut = response.NewError(failedPartsError(ts.Payload, failedErrs, response.ErrNoAck))
+						} else {
+							resOut = response.NewNoack()
+						}
 						break
 					} else {
 						r.log.Warnf("Failed to send message: %v\n", res.Error())
@@ -275,13 +353,13 @@ func (r *Retry) loop() {
 					}
 
 					select {
-					case r.transactionsOut <- types.NewTransaction(ts.Payload, resChan):
+					case r.transactionsOut <- types.NewTransaction(sendMsg, resChan):
 					case <-r.closeChan:
 						return
 					}
 				} else {
 					mSuccess.Incr(1)
-					mPartsSuccess.Incr(int64(ts.Payload.Len()))
+					mPartsSuccess.Incr(int64(sendMsg.Len()))
 					resOut = response.NewAck()
 					break
 				}
@@ -292,8 +370,65 @@ func (r *Retry) loop() {
 			case <-r.closeChan:
 				return
 			}
-		}(tran, rChan)
+		}(tran, sortGroup, sendMsg, rChan)
+	}
+}
+
+// failedParts returns the messages of a batch that failed according to a batch
+// error, in their original order, along with the errors of each keyed by their
+// index within the original batch. If the error does not identify which
+// messages failed then the errors returned are nil.
+func failedParts(group *imessage.SortGroup, msg types.Message, err error) (types.Message, map[int]error) {
+	var wErr batch.WalkableError
+	if !errors.As(err, &wErr) || wErr.IndexedErrors() == 0 {
+		return msg, nil
+	}
+
+	errs := map[int]error{}
+	linked := true
+	wErr.WalkParts(func(_ int, p types.Part, pErr error) bool {
+		if pErr == nil {
+			return true
+		}
+		index := group.GetIndex(p)
+		if index < 0 {
+			// If we couldn't link the errored part back to an original
+			// message then we need to retry all of them.
+			linked = false
+			return false
+		}
+		errs[index] = pErr
+		return true
+	})
+	if !linked || len(errs) == 0 {
+		return msg, nil
+	}
+
+	failedMsg := message.New(nil)
+	_ = msg.Iter(func(_ int, p types.Part) error {
+		if _, exists := errs[group.GetIndex(p)]; exists {
+			failedMsg.Append(p)
+		}
+		return nil
+	})
+	if failedMsg.Len() == 0 {
+		return msg, nil
+	}
+	return failedMsg, errs
+}
+
+// failedPartsError returns an error for a batch where only the messages with
+// an indexed error are reported as having failed. If there are no indexed
+// errors then the error is returned unchanged.
+func failedPartsError(msg types.Message, errs map[int]error, err error) error {
+	if errs == nil {
+		return err
+	}
+	bErr := batch.NewError(msg, err)
+	for i, pErr := range errs {
+		bErr.Failed(i, pErr)
 	}
+	return bErr
 }
 
 // Consume assigns a messages channel for the output to read.
@@ -1,14 +1,22 @@
 package output
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/transaction"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetryConfigErrs(t *testing.T) {
@@ -28,6 +36,23 @@ func TestRetryConfigErrs(t *testing.T) {
 	}
 }
 
+func TestRetryDisabledChild(t *testing.T) {
+	oConf := NewConfig()
+	oConf.Type = TypeDrop
+	oConf.Enabled = false
+
+	conf := NewConfig()
+	conf.Type = TypeRetry
+	conf.Retry.Output = &oConf
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrOutputDisabled.Error())
+
+	_, err = New(oConf, nil, log.Noop(), metrics.Noop())
+	assert.Equal(t, ErrOutputDisabled, err)
+}
+
 func TestRetryBasic(t *testing.T) {
 	conf := NewConfig()
 
@@ -191,6 +216,65 @@ func TestRetrySadPath(t *testing.T) {
 	}
 }
 
+func TestRetryTerminal(t *testing.T) {
+	terminalErr := output.NewTerminalError(errors.New("access denied"))
+
+	for _, test := range []struct {
+		name         string
+		forceRetryOn []string
+		retried      bool
+	}{
+		{name: "no overrides"},
+		{name: "unmatched override", forceRetryOn: []string{"not found"}},
+		{name: "matched override", forceRetryOn: []string{"denied"}, retried: true},
+		{name: "all terminal", forceRetryOn: []string{"terminal"}, retried: true},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+
+			childConf := NewConfig()
+			conf.Retry.Output = &childConf
+			conf.Retry.Backoff.InitialInterval = "10us"
+			conf.Retry.Backoff.MaxInterval = "10us"
+			conf.Retry.ForceRetryOn = test.forceRetryOn
+
+			retryOut, err := NewRetry(conf, nil, log.Noop(), metrics.Noop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				retryOut.CloseAsync()
+				if err := retryOut.WaitForClose(time.Second); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			ret := retryOut.(*Retry)
+			mOut := &mockOutput{
+				ts: make(chan types.Transaction),
+			}
+			ret.wrapped = mOut
+
+			tChan := make(chan types.Transaction)
+			resChan := make(chan types.Response)
+			if err = ret.Consume(tChan); err != nil {
+				t.Fatal(err)
+			}
+
+			sendForRetry("hello world", tChan, resChan, t)
+			expectFromRetry(response.NewError(terminalErr), mOut.ts, t, "hello world")
+
+			if test.retried {
+				expectFromRetry(response.NewAck(), mOut.ts, t, "hello world")
+				ackForRetry(response.NewAck(), resChan, t)
+			} else {
+				ackForRetry(response.NewError(terminalErr), resChan, t)
+			}
+		})
+	}
+}
+
 func expectFromRetry(
 	resReturn types.Response,
 	tChan <-chan types.Transaction,
@@ -322,3 +406,168 @@ func TestRetryParallel(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func newPartialRetry(t *testing.T, fn func(c *RetryConfig)) (*mockOutput, chan types.Transaction) {
+	t.Helper()
+
+	conf := NewConfig()
+	childConf := NewConfig()
+	conf.Retry.Output = &childConf
+	conf.Retry.Backoff.InitialInterval = "10us"
+	conf.Retry.Backoff.MaxInterval = "10us"
+	fn(&conf.Retry)
+
+	retryOut, err := NewRetry(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		retryOut.CloseAsync()
+		assert.NoError(t, retryOut.WaitForClose(time.Second))
+	})
+
+	ret := retryOut.(*Retry)
+	mOut := &mockOutput{}
+	ret.wrapped = mOut
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, ret.Consume(tChan))
+	return mOut, tChan
+}
+
+// expectBatchFromRetry reads a batch written to the child output, checks its
+// contents and responds with an error where the messages of the batch matching
+// failed are individually failed.
+func expectBatchFromRetry(t *testing.T, tChan <-chan types.Transaction, exp []string, failed ...string) {
+	t.Helper()
+
+	var tran types.Transaction
+	select {
+	case tran = <-tChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, exp, strsFromMsg(tran.Payload))
+
+	var res types.Response = response.NewAck()
+	if len(failed) > 0 {
+		bErr := batch.NewError(tran.Payload, errors.New("partial failure"))
+		_ = tran.Payload.Iter(func(i int, p types.Part) error {
+			for _, f := range failed {
+				if string(p.Get()) == f {
+					bErr.Failed(i, fmt.Errorf("failed %v", f))
+				}
+			}
+			return nil
+		})
+		res = response.NewError(bErr)
+	}
+
+	select {
+	case tran.ResponseChan <- res:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func strsFromMsg(msg types.Message) []string {
+	var strs []string
+	_ = msg.Iter(func(_ int, p types.Part) error {
+		strs = append(strs, string(p.Get()))
+		return nil
+	})
+	return strs
+}
+
+func TestRetryPartialBatch(t *testing.T) {
+	mOut, tChan := newPartialRetry(t, func(c *RetryConfig) {})
+
+	// A batch with a single response, as produced by an input with batch-level
+	// acknowledgements.
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{
+		[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e"),
+	}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	expectBatchFromRetry(t, mOut.ts, []string{"a", "b", "c", "d", "e"}, "b", "d", "e")
+	expectBatchFromRetry(t, mOut.ts, []string{"b", "d", "e"}, "e")
+	expectBatchFromRetry(t, mOut.ts, []string{"e"})
+
+	ackForRetry(response.NewAck(), resChan, t)
+}
+
+func TestRetryWholeBatch(t *testing.T) {
+	mOut, tChan := newPartialRetry(t, func(c *RetryConfig) {
+		c.RetryWholeBatch = true
+	})
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{
+		[]byte("a"), []byte("b"), []byte("c"),
+	}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	expectBatchFromRetry(t, mOut.ts, []string{"a", "b", "c"}, "b")
+	expectBatchFromRetry(t, mOut.ts, []string{"a", "b", "c"})
+
+	ackForRetry(response.NewAck(), resChan, t)
+}
+
+func TestRetryPartialBatchTrackedAcks(t *testing.T) {
+	mOut, tChan := newPartialRetry(t, func(c *RetryConfig) {
+		c.MaxRetries = 1
+	})
+
+	// Two upstream transactions merged into one batch, as produced by an input
+	// with per-message acknowledgements and a batching policy.
+	firstRes, secondRes := make(chan types.Response, 1), make(chan types.Response, 1)
+	firstTran := transaction.NewTracked(message.New([][]byte{[]byte("a"), []byte("b")}), firstRes)
+	secondTran := transaction.NewTracked(message.New([][]byte{[]byte("c"), []byte("d")}), secondRes)
+
+	msg := message.New(nil)
+	for _, tran := range []*transaction.Tracked{firstTran, secondTran} {
+		_ = tran.Message().Iter(func(_ int, p types.Part) error {
+			msg.Append(p)
+			return nil
+		})
+	}
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	expectBatchFromRetry(t, mOut.ts, []string{"a", "b", "c", "d"}, "a", "b", "d")
+	expectBatchFromRetry(t, mOut.ts, []string{"a", "b", "d"}, "b")
+
+	var res types.Response
+	select {
+	case res = <-resChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.Error(t, res.Error())
+
+	var failed []string
+	res.Error().(batch.WalkableError).WalkParts(func(_ int, p types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, string(p.Get()))
+		}
+		return true
+	})
+	assert.Equal(t, []string{"b"}, failed)
+
+	// Only the upstream transaction owning the message that was never sent is
+	// failed, the other is acknowledged.
+	require.NoError(t, firstTran.Ack(context.Background(), res.Error()))
+	require.NoError(t, secondTran.Ack(context.Background(), res.Error()))
+	assert.Error(t, (<-firstRes).Error())
+	assert.NoError(t, (<-secondRes).Error())
+}
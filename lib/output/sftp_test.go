@@ -0,0 +1,71 @@
+package output
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/require"
+)
+
+type discardWriterAt struct{}
+
+func (discardWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+type discardFilePut struct{}
+
+func (discardFilePut) Filewrite(*sftp.Request) (io.WriterAt, error) {
+	return discardWriterAt{}, nil
+}
+
+// discardHandlers returns in memory SFTP handlers where file contents are
+// discarded, so that allocations measured are those of the client.
+func discardHandlers() sftp.Handlers {
+	h := sftp.InMemHandler()
+	h.FilePut = discardFilePut{}
+	return h
+}
+
+func BenchmarkSFTPOutput10MB(b *testing.B) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter}, discardHandlers())
+	go server.Serve()
+	b.Cleanup(func() {
+		server.Close()
+	})
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	require.NoError(b, err)
+
+	conf := NewSFTPConfig()
+	conf.Path = `/${! meta("name") }.bin`
+	w, err := newSFTPWriter(conf, log.Noop(), metrics.Noop())
+	require.NoError(b, err)
+	w.client = client
+	b.Cleanup(func() {
+		w.CloseAsync()
+	})
+
+	payload := binaryPayload(b, 10*1024*1024)
+	msg := message.New([][]byte{payload})
+	msg.Get(0).Metadata().Set("name", "foo")
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, w.WriteWithContext(context.Background(), msg))
+	}
+}
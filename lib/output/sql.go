@@ -45,6 +45,9 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
+			if err = setKeepaliveInterval(w, conf.SQL.KeepaliveInterval); err != nil {
+				return nil, err
+			}
 			return NewBatcherFromConfig(conf.SQL.Batching, w, mgr, log, stats)
 		}),
 		Status:  docs.StatusBeta,
@@ -131,6 +134,7 @@ output:
 				`root = [ uuid_v4() ].merge(this.document.args)`,
 			).Linter(docs.LintBloblangMapping).AtVersion("3.47.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("keepalive_interval", "An optional period of inactivity after which the database is pinged, reopening the connection pool if the ping fails. The timestamp of the last successful ping is exposed as the gauge `connection.last_ping`.", "1m").AtVersion("3.50.0"),
 			batch.FieldSpec(),
 		},
 	}
@@ -140,25 +144,27 @@ output:
 
 // SQLConfig contains configuration fields for the SQL processor.
 type SQLConfig struct {
-	Driver         string             `json:"driver" yaml:"driver"`
-	DataSourceName string             `json:"data_source_name" yaml:"data_source_name"`
-	Query          string             `json:"query" yaml:"query"`
-	Args           []string           `json:"args" yaml:"args"`
-	ArgsMapping    string             `json:"args_mapping" yaml:"args_mapping"`
-	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Driver            string             `json:"driver" yaml:"driver"`
+	DataSourceName    string             `json:"data_source_name" yaml:"data_source_name"`
+	Query             string             `json:"query" yaml:"query"`
+	Args              []string           `json:"args" yaml:"args"`
+	ArgsMapping       string             `json:"args_mapping" yaml:"args_mapping"`
+	MaxInFlight       int                `json:"max_in_flight" yaml:"max_in_flight"`
+	KeepaliveInterval string             `json:"keepalive_interval" yaml:"keepalive_interval"`
+	Batching          batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewSQLConfig returns a SQLConfig with default values.
 func NewSQLConfig() SQLConfig {
 	return SQLConfig{
-		Driver:         "mysql",
-		DataSourceName: "",
-		Query:          "",
-		Args:           []string{},
-		ArgsMapping:    "",
-		MaxInFlight:    1,
-		Batching:       batch.NewPolicyConfig(),
+		Driver:            "mysql",
+		DataSourceName:    "",
+		Query:             "",
+		Args:              []string{},
+		ArgsMapping:       "",
+		MaxInFlight:       1,
+		KeepaliveInterval: "",
+		Batching:          batch.NewPolicyConfig(),
 	}
 }
 
@@ -352,20 +358,39 @@ func (s *sqlWriter) WriteWithContext(ctx context.Context, msg types.Message) err
 	})
 }
 
+// PingWithContext verifies that the target database is still reachable, and
+// closes the connection pool if it is not so that it can be reestablished.
+func (s *sqlWriter) PingWithContext(ctx context.Context) error {
+	s.dbMut.Lock()
+	db := s.db
+	s.dbMut.Unlock()
+
+	if db == nil {
+		return types.ErrNotConnected
+	}
+	if err := db.PingContext(ctx); err != nil {
+		s.disconnect()
+		return err
+	}
+	return nil
+}
+
+func (s *sqlWriter) disconnect() {
+	s.dbMut.Lock()
+	if s.db != nil {
+		s.db.Close()
+	}
+	if s.query != nil {
+		s.query.Close()
+	}
+	s.db = nil
+	s.query = nil
+	s.dbMut.Unlock()
+}
+
 // CloseAsync shuts down the processor and stops processing requests.
 func (s *sqlWriter) CloseAsync() {
-	go func() {
-		s.dbMut.Lock()
-		if s.db != nil {
-			s.db.Close()
-		}
-		if s.query != nil {
-			s.query.Close()
-		}
-		s.db = nil
-		s.query = nil
-		s.dbMut.Unlock()
-	}()
+	go s.disconnect()
 }
 
 // WaitForClose blocks until the processor has closed down.
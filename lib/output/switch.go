@@ -291,6 +291,25 @@ func NewSwitch(
 	if lCases < 2 && lOutputs < 2 {
 		return nil, ErrSwitchNoOutputs
 	}
+
+	// Cases with a disabled output are skipped entirely, but the remaining
+	// cases keep the index of their original position for observability
+	// purposes.
+	var cases []SwitchConfigCase
+	var caseIndexes []int
+	for i, cConf := range conf.Switch.Cases {
+		if !cConf.Output.Enabled {
+			logger.Infof("Output of case '%v' of type '%v' is disabled and the case will be skipped\n", i, cConf.Output.Type)
+			continue
+		}
+		cases = append(cases, cConf)
+		caseIndexes = append(caseIndexes, i)
+	}
+	if lCases > 0 && len(cases) == 0 {
+		return nil, errors.New("all switch cases are disabled")
+	}
+	lCases = len(cases)
+
 	if lCases > 0 {
 		if lOutputs > 0 {
 			return nil, errors.New("combining switch cases with deprecated outputs is not supported")
@@ -320,15 +339,16 @@ func NewSwitch(
 		o.fallthroughs[i] = oConf.Fallthrough
 	}
 
-	for i, cConf := range conf.Switch.Cases {
-		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("switch.%v.output", i), mgr, logger, stats)
+	for i, cConf := range cases {
+		index := caseIndexes[i]
+		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("switch.%v.output", index), mgr, logger, stats)
 		oStats = metrics.Combine(stats, oStats)
 		if o.outputs[i], err = New(cConf.Output, oMgr, oLog, oStats); err != nil {
-			return nil, fmt.Errorf("failed to create case '%v' output type '%v': %v", i, cConf.Output.Type, err)
+			return nil, fmt.Errorf("failed to create case '%v' output type '%v': %v", index, cConf.Output.Type, err)
 		}
 		if len(cConf.Check) > 0 {
 			if o.checks[i], err = bloblang.NewMapping("", cConf.Check); err != nil {
-				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
+				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", index, err)
 			}
 		}
 		o.continues[i] = cConf.Continue
@@ -1130,3 +1130,48 @@ bpLoop:
 }
 
 //------------------------------------------------------------------------------
+
+func TestSwitchDisabledCase(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+	conf.Switch.RetryUntilSuccess = false
+
+	rejectCase := NewSwitchConfigCase()
+	rejectCase.Check = `true`
+	rejectCase.Output.Type = TypeReject
+	rejectCase.Output.Reject = "nope"
+	rejectCase.Output.Enabled = false
+
+	dropCase := NewSwitchConfigCase()
+	dropCase.Output.Type = TypeDrop
+
+	conf.Switch.Cases = append(conf.Switch.Cases, rejectCase, dropCase)
+
+	s, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Len(t, s.(*Switch).outputs, 1)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(readChan))
+
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	s.CloseAsync()
+	assert.NoError(t, s.WaitForClose(time.Second*5))
+
+	conf.Switch.Cases[1].Output.Enabled = false
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all switch cases are disabled")
+}
@@ -0,0 +1,212 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/broker"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// TeePartitionMetaKey is the metadata key that the tee output sets on messages
+// written to its archive, containing the partition of the archive.
+const TeePartitionMetaKey = "tee_partition"
+
+// TeePartitionFormat is the time format of the partitions of a tee archive,
+// which are hourly and always in UTC.
+const TeePartitionFormat = "2006/01/02/15"
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeTee] = TypeSpec{
+		constructor: fromSimpleConstructor(NewTee),
+		Status:      docs.StatusExperimental,
+		Version:     "3.50.0",
+		Summary: `
+Writes messages to a child output and, once the write is successful, writes them
+to an archive output from which they can be replayed with the
+` + "`benthos replay`" + ` subcommand.`,
+		Description: `
+Messages are only acknowledged once they have been written to both the child
+output and the archive, and therefore a failed write to the archive results in
+the message being sent to the child output again.
+
+### Partitions
+
+Messages written to the archive are given the metadata field
+` + "`tee_partition`" + `, which is the hour that the message was archived
+formatted as ` + "`2006/01/02/15`" + ` in UTC. In order for the archive to be
+replayed it must be either an ` + "[`aws_s3`](/docs/components/outputs/aws_s3)" + `
+or ` + "[`gcp_cloud_storage`](/docs/components/outputs/gcp_cloud_storage)" + `
+output with a ` + "`path`" + ` that begins with an optional static prefix
+followed by the partition, e.g.
+` + "`archive/${! meta(\"tee_partition\") }/${! uuid_v4() }.json`" + `.
+
+### Replaying
+
+The ` + "`benthos replay`" + ` subcommand runs a config that contains a
+` + "`tee`" + ` output, but instead of the configured input it reads the archived
+messages of each partition within a time range, and writes them only to the
+child output of the ` + "`tee`" + `:
+
+` + "```sh" + `
+benthos replay -c ./config.yaml --from 2021-04-05T10:00:00Z --to 2021-04-05T14:00:00Z
+` + "```" + `
+
+Replayed messages always have the metadata field ` + "`replayed`" + ` set to
+` + "`true`" + `, and pass through the buffer and pipeline of the config just
+like regular messages. Since partitions are hourly the time range is expanded to
+whole hours, and making sure that processing a message more than once is
+harmless is left up to the config.
+
+In streams mode a stream can be replayed by running the subcommand with the
+config of the stream, and resources can be imported with the
+` + "`--resources`" + ` flag.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Archive to S3",
+				Summary: `
+Here we write messages to Kafka and archive them to S3 in batches, where each
+batch is archived as a single object of newline delimited documents, which can
+then be replayed with ` + "`benthos replay -c ./config.yaml --codec lines --from ... --to ...`" + `:`,
+				Config: `
+output:
+  tee:
+    output:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: events
+    archive:
+      aws_s3:
+        bucket: replay-archive
+        path: events/${! meta("tee_partition") }/${! uuid_v4() }.jsonl
+        batching:
+          count: 1000
+          period: 1m
+          processors:
+            - archive:
+                format: lines
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("output", "The child output to write messages to.").HasType(docs.FieldTypeOutput),
+			docs.FieldCommon("archive", "An output to archive messages to once they have been written to the child output.").HasType(docs.FieldTypeOutput),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TeeConfig contains configuration values for the Tee output type.
+type TeeConfig struct {
+	Output  *Config `json:"output" yaml:"output"`
+	Archive *Config `json:"archive" yaml:"archive"`
+}
+
+// NewTeeConfig creates a new TeeConfig with default values.
+func NewTeeConfig() TeeConfig {
+	return TeeConfig{
+		Output:  nil,
+		Archive: nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyTeeConfig struct {
+	Output  interface{} `json:"output" yaml:"output"`
+	Archive interface{} `json:"archive" yaml:"archive"`
+}
+
+func (t TeeConfig) dummy() dummyTeeConfig {
+	dummy := dummyTeeConfig{
+		Output:  t.Output,
+		Archive: t.Archive,
+	}
+	if t.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	if t.Archive == nil {
+		dummy.Archive = struct{}{}
+	}
+	return dummy
+}
+
+// MarshalJSON prints empty objects instead of nil.
+func (t TeeConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.dummy())
+}
+
+// MarshalYAML prints empty objects instead of nil.
+func (t TeeConfig) MarshalYAML() (interface{}, error) {
+	return t.dummy(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// NewTee creates a new Tee output type, which writes messages to a child output
+// followed by an archive output.
+func NewTee(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.Tee.Output == nil {
+		return nil, errors.New("cannot create tee output without a child output")
+	}
+	if conf.Tee.Archive == nil {
+		return nil, errors.New("cannot create tee output without an archive output")
+	}
+
+	oMgr, oLog, oStats := interop.LabelChild("tee.output", mgr, log, stats)
+	child, err := New(*conf.Tee.Output, oMgr, oLog, oStats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output '%v': %v", conf.Tee.Output.Type, err)
+	}
+
+	partConf := processor.NewConfig()
+	partConf.Type = processor.TypeBloblang
+	partConf.Bloblang = processor.BloblangConfig(fmt.Sprintf(
+		"meta %v = now().format_timestamp(%q, \"UTC\")",
+		TeePartitionMetaKey, TeePartitionFormat,
+	))
+
+	archiveConf := *conf.Tee.Archive
+	archiveConf.Processors = append([]processor.Config{partConf}, archiveConf.Processors...)
+
+	aMgr, aLog, aStats := interop.LabelChild("tee.archive", mgr, log, stats)
+	archive, err := New(archiveConf, aMgr, aLog, aStats)
+	if err != nil {
+		child.CloseAsync()
+		return nil, fmt.Errorf("failed to create archive '%v': %v", conf.Tee.Archive.Type, err)
+	}
+
+	outputs := []types.Output{child, archive}
+	maxInFlight := 1
+	for _, out := range outputs {
+		if mif, ok := output.GetMaxInFlight(out); ok && mif > maxInFlight {
+			maxInFlight = mif
+		}
+	}
+
+	b, err := broker.NewFanOutSequential(outputs, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return b.WithMaxInFlight(maxInFlight), nil
+}
+
+//------------------------------------------------------------------------------
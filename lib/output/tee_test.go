@@ -0,0 +1,95 @@
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeeConfigErrs(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeTee
+
+	_, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "without a child output")
+
+	oConf := NewConfig()
+	conf.Tee.Output = &oConf
+
+	_, err = New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "without an archive output")
+}
+
+func TestTeeArchive(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_tee_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	childConf := NewConfig()
+	childConf.Type = TypeFile
+	childConf.File.Path = filepath.Join(tmpDir, "child.txt")
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Bloblang = `root = content().string() + " " + meta("tee_partition")`
+
+	archiveConf := NewConfig()
+	archiveConf.Type = TypeFile
+	archiveConf.File.Path = filepath.Join(tmpDir, "archive.txt")
+	archiveConf.Processors = append(archiveConf.Processors, procConf)
+
+	conf := NewConfig()
+	conf.Type = TypeTee
+	conf.Tee.Output = &childConf
+	conf.Tee.Archive = &archiveConf
+
+	s, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	sendChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(sendChan))
+
+	t.Cleanup(func() {
+		s.CloseAsync()
+		require.NoError(t, s.WaitForClose(time.Second))
+	})
+
+	before := time.Now().UTC().Format(TeePartitionFormat)
+	for _, content := range []string{"foo", "bar"} {
+		select {
+		case sendChan <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res.Error())
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+	after := time.Now().UTC().Format(TeePartitionFormat)
+
+	childBytes, err := ioutil.ReadFile(childConf.File.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(childBytes))
+
+	archiveBytes, err := ioutil.ReadFile(archiveConf.File.Path)
+	require.NoError(t, err)
+	if before == after {
+		assert.Equal(t, "foo "+before+"\nbar "+before+"\n", string(archiveBytes))
+	}
+}
@@ -46,6 +46,41 @@ output:
       path: /usr/local/benthos/everything_failed.jsonl
 ` + "```" + `
 
+### Metadata
+
+When a given output fails the message routed to the following output will have
+a metadata value named ` + "`fallback_error`" + ` containing a string error
+message outlining the cause of the failure. The content of this string will
+depend on the particular output and can be used to enrich the message or
+provide information used to broker the data to an appropriate output using
+something like a ` + "`switch`" + ` output.
+
+When the failure was caused by an unexpected HTTP response the metadata fields
+` + "`http_status` and `http_error_body`" + ` are also set, containing the
+status code and the leading bytes of the body of the response.
+
+### Retries
+
+Outputs that fail are not retried by this pattern before moving on to the next
+output, and a message is only rejected once every output has failed. In order to
+attempt an output a bounded number of times before falling back it can be
+wrapped within a ` + "[`retry`](/docs/components/outputs/retry)" + ` output:
+
+` + "```yaml" + `
+output:
+  try:
+  - retry:
+      max_retries: 3
+      backoff:
+        initial_interval: 100ms
+        max_interval: 1s
+      output:
+        http_client:
+          url: http://foo:4195/post/might/become/unreachable
+  - file:
+      path: /usr/local/benthos/everything_failed.jsonl
+` + "```" + `
+
 ### Batching
 
 When an output within a try sequence uses batching, like so:
@@ -101,7 +136,18 @@ func NewTry(
 ) (Type, error) {
 	pipelines = AppendProcessorsFromConfig(conf, mgr, log, stats, pipelines...)
 
-	outputConfs := conf.Try
+	// Disabled outputs are skipped, but the remaining outputs keep the index
+	// of their original position for observability purposes.
+	var outputConfs []Config
+	var outputIndexes []int
+	for i, oConf := range conf.Try {
+		if !oConf.Enabled {
+			log.Infof("Output '%v' of type '%v' is disabled and will be skipped\n", i, oConf.Type)
+			continue
+		}
+		outputConfs = append(outputConfs, oConf)
+		outputIndexes = append(outputIndexes, i)
+	}
 
 	if len(outputConfs) == 0 {
 		return nil, ErrBrokerNoOutputs
@@ -112,10 +158,10 @@ func NewTry(
 
 	var err error
 	for i, oConf := range outputConfs {
-		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("try.%v", i), mgr, log, stats)
+		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("try.%v", outputIndexes[i]), mgr, log, stats)
 		oStats = metrics.Combine(stats, oStats)
 		if outputs[i], err = New(oConf, oMgr, oLog, oStats); err != nil {
-			return nil, fmt.Errorf("failed to create output '%v' type '%v': %v", i, oConf.Type, err)
+			return nil, fmt.Errorf("failed to create output '%v' type '%v': %v", outputIndexes[i], oConf.Type, err)
 		}
 		if mif, ok := output.GetMaxInFlight(outputs[i]); ok && mif > maxInFlight {
 			maxInFlight = mif
@@ -14,6 +14,70 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
+func TestTryDisabledOutputs(t *testing.T) {
+	rejectConf := NewConfig()
+	rejectConf.Type = TypeReject
+	rejectConf.Reject = "nope"
+
+	disabledConf := NewConfig()
+	disabledConf.Type = TypeReject
+	disabledConf.Reject = "nope"
+	disabledConf.Enabled = false
+
+	dropConf := NewConfig()
+	dropConf.Type = TypeDrop
+
+	conf := NewConfig()
+	conf.Type = TypeTry
+	conf.Try = append(conf.Try, disabledConf, rejectConf, disabledConf)
+
+	// Only the enabled reject output remains, which means the try fails.
+	s, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAndCheck := func(s Type, expErr bool) {
+		t.Helper()
+
+		sendChan := make(chan types.Transaction)
+		resChan := make(chan types.Response)
+		if err = s.Consume(sendChan); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case sendChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			if err := res.Error(); (err != nil) != expErr {
+				t.Errorf("Unexpected error result: %v", err)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+
+		s.CloseAsync()
+		if err = s.WaitForClose(time.Second * 5); err != nil {
+			t.Error(err)
+		}
+	}
+	sendAndCheck(s, true)
+
+	conf.Try = append(conf.Try, dropConf)
+	if s, err = New(conf, nil, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	sendAndCheck(s, false)
+
+	conf.Try = []Config{disabledConf, disabledConf}
+	if _, err = New(conf, nil, log.Noop(), metrics.Noop()); err != ErrBrokerNoOutputs {
+		t.Errorf("Wrong error returned: %v != %v", err, ErrBrokerNoOutputs)
+	}
+}
+
 func TestTryOutputBasic(t *testing.T) {
 	dir, err := ioutil.TempDir("", "benthos_try_output_tests")
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 )
 
 //------------------------------------------------------------------------------
@@ -18,7 +19,7 @@ func init() {
 Sends messages to an HTTP server via a websocket connection.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("url", "The URL to connect to."),
-		}.Merge(auth.FieldSpecs()),
+		}.Merge(proxy.FieldSpecs()).Merge(auth.FieldSpecs()),
 		Categories: []Category{
 			CategoryNetwork,
 		},
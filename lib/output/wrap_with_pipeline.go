@@ -1,8 +1,10 @@
 package output
 
 import (
+	"context"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -67,6 +69,11 @@ func (i *WithPipeline) Connected() bool {
 	return i.out.Connected()
 }
 
+// SelfTest runs the self test of the output that the pipeline feeds.
+func (i *WithPipeline) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, i.out)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
@@ -1,12 +1,15 @@
 package output
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component"
+	"github.com/Jeffail/benthos/v3/internal/correlation"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
@@ -162,7 +165,7 @@ func (w *Writer) loop() {
 		}
 
 		if err != nil {
-			w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+			correlation.WithFields(w.log, ts.Payload).Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
 			if !throt.Retry() {
 				return
 			}
@@ -209,6 +212,11 @@ func (w *Writer) Connected() bool {
 	return atomic.LoadInt32(&w.isConnected) == 1
 }
 
+// SelfTest runs the self test of the underlying writer.
+func (w *Writer) SelfTest(ctx context.Context) error {
+	return component.SelfTest(ctx, w.writer)
+}
+
 // CloseAsync shuts down the File output and stops processing messages.
 func (w *Writer) CloseAsync() {
 	if atomic.CompareAndSwapInt32(&w.running, 1, 0) {
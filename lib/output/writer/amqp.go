@@ -3,6 +3,7 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -31,19 +32,20 @@ type AMQPExchangeDeclareConfig struct {
 
 // AMQPConfig contains configuration fields for the AMQP output type.
 type AMQPConfig struct {
-	URL             string                    `json:"url" yaml:"url"`
-	MaxInFlight     int                       `json:"max_in_flight" yaml:"max_in_flight"`
-	Exchange        string                    `json:"exchange" yaml:"exchange"`
-	ExchangeDeclare AMQPExchangeDeclareConfig `json:"exchange_declare" yaml:"exchange_declare"`
-	BindingKey      string                    `json:"key" yaml:"key"`
-	Type            string                    `json:"type" yaml:"type"`
-	ContentType     string                    `json:"content_type" yaml:"content_type"`
-	ContentEncoding string                    `json:"content_encoding" yaml:"content_encoding"`
-	Metadata        output.Metadata           `json:"metadata" yaml:"metadata"`
-	Persistent      bool                      `json:"persistent" yaml:"persistent"`
-	Mandatory       bool                      `json:"mandatory" yaml:"mandatory"`
-	Immediate       bool                      `json:"immediate" yaml:"immediate"`
-	TLS             btls.Config               `json:"tls" yaml:"tls"`
+	URL               string                    `json:"url" yaml:"url"`
+	MaxInFlight       int                       `json:"max_in_flight" yaml:"max_in_flight"`
+	Exchange          string                    `json:"exchange" yaml:"exchange"`
+	ExchangeDeclare   AMQPExchangeDeclareConfig `json:"exchange_declare" yaml:"exchange_declare"`
+	BindingKey        string                    `json:"key" yaml:"key"`
+	Type              string                    `json:"type" yaml:"type"`
+	ContentType       string                    `json:"content_type" yaml:"content_type"`
+	ContentEncoding   string                    `json:"content_encoding" yaml:"content_encoding"`
+	Metadata          output.Metadata           `json:"metadata" yaml:"metadata"`
+	Persistent        bool                      `json:"persistent" yaml:"persistent"`
+	Mandatory         bool                      `json:"mandatory" yaml:"mandatory"`
+	Immediate         bool                      `json:"immediate" yaml:"immediate"`
+	TLS               btls.Config               `json:"tls" yaml:"tls"`
+	KeepaliveInterval string                    `json:"keepalive_interval" yaml:"keepalive_interval"`
 }
 
 // NewAMQPConfig creates a new AMQPConfig with default values.
@@ -57,15 +59,16 @@ func NewAMQPConfig() AMQPConfig {
 			Type:    "direct",
 			Durable: true,
 		},
-		BindingKey:      "benthos-key",
-		Type:            "",
-		ContentType:     "application/octet-stream",
-		ContentEncoding: "",
-		Metadata:        output.NewMetadata(),
-		Persistent:      false,
-		Mandatory:       false,
-		Immediate:       false,
-		TLS:             btls.NewConfig(),
+		BindingKey:        "benthos-key",
+		Type:              "",
+		ContentType:       "application/octet-stream",
+		ContentEncoding:   "",
+		Metadata:          output.NewMetadata(),
+		Persistent:        false,
+		Mandatory:         false,
+		Immediate:         false,
+		TLS:               btls.NewConfig(),
+		KeepaliveInterval: "",
 	}
 }
 
@@ -211,6 +214,24 @@ func (a *AMQP) disconnect() error {
 	return nil
 }
 
+// PingWithContext checks whether the connection to the AMQP server has been
+// closed, which happens when the server stops responding to heartbeats, and
+// cleans up the connection if so in order for it to be reestablished.
+func (a *AMQP) PingWithContext(ctx context.Context) error {
+	a.connLock.RLock()
+	conn := a.conn
+	a.connLock.RUnlock()
+
+	if conn == nil {
+		return types.ErrNotConnected
+	}
+	if conn.IsClosed() {
+		a.disconnect()
+		return errors.New("connection closed by server")
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 // WriteWithContext will attempt to write a message over AMQP, wait for
@@ -3,14 +3,19 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	imetadata "github.com/Jeffail/benthos/v3/internal/metadata"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/amqp/sasl"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/senders"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 )
 
@@ -18,21 +23,35 @@ import (
 
 // AMQP1Config contains configuration fields for the AMQP1 output type.
 type AMQP1Config struct {
-	URL           string      `json:"url" yaml:"url"`
-	TargetAddress string      `json:"target_address" yaml:"target_address"`
-	MaxInFlight   int         `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS           btls.Config `json:"tls" yaml:"tls"`
-	SASL          sasl.Config `json:"sasl" yaml:"sasl"`
+	URL                   string                        `json:"url" yaml:"url"`
+	TargetAddress         string                        `json:"target_address" yaml:"target_address"`
+	MaxSenders            int                           `json:"max_senders" yaml:"max_senders"`
+	MessageID             string                        `json:"message_id" yaml:"message_id"`
+	CorrelationID         string                        `json:"correlation_id" yaml:"correlation_id"`
+	ContentType           string                        `json:"content_type" yaml:"content_type"`
+	GroupID               string                        `json:"group_id" yaml:"group_id"`
+	ApplicationProperties imetadata.IncludeFilterConfig `json:"application_properties" yaml:"application_properties"`
+	MessageAnnotations    map[string]string             `json:"message_annotations" yaml:"message_annotations"`
+	MaxInFlight           int                           `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS                   btls.Config                   `json:"tls" yaml:"tls"`
+	SASL                  sasl.Config                   `json:"sasl" yaml:"sasl"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
 func NewAMQP1Config() AMQP1Config {
 	return AMQP1Config{
-		URL:           "",
-		TargetAddress: "",
-		MaxInFlight:   1,
-		TLS:           btls.NewConfig(),
-		SASL:          sasl.NewConfig(),
+		URL:                   "",
+		TargetAddress:         "",
+		MaxSenders:            100,
+		MessageID:             "",
+		CorrelationID:         "",
+		ContentType:           "",
+		GroupID:               "",
+		ApplicationProperties: imetadata.NewIncludeFilterConfig(),
+		MessageAnnotations:    map[string]string{},
+		MaxInFlight:           1,
+		TLS:                   btls.NewConfig(),
+		SASL:                  sasl.NewConfig(),
 	}
 }
 
@@ -42,7 +61,15 @@ func NewAMQP1Config() AMQP1Config {
 type AMQP1 struct {
 	client  *amqp.Client
 	session *amqp.Session
-	sender  *amqp.Sender
+	senders *senders.Cache
+
+	targetAddress *field.Expression
+	messageID     *field.Expression
+	correlationID *field.Expression
+	contentType   *field.Expression
+	groupID       *field.Expression
+	annotations   map[string]*field.Expression
+	appProps      *imetadata.IncludeFilter
 
 	log   log.Modular
 	stats metrics.Type
@@ -56,11 +83,35 @@ type AMQP1 struct {
 // NewAMQP1 creates a new AMQP1 writer type.
 func NewAMQP1(conf AMQP1Config, log log.Modular, stats metrics.Type) (*AMQP1, error) {
 	a := AMQP1{
-		log:   log,
-		stats: stats,
-		conf:  conf,
+		log:         log,
+		stats:       stats,
+		conf:        conf,
+		annotations: map[string]*field.Expression{},
 	}
 	var err error
+	if a.targetAddress, err = bloblang.NewField(conf.TargetAddress); err != nil {
+		return nil, fmt.Errorf("failed to parse target address expression: %v", err)
+	}
+	if a.messageID, err = bloblang.NewField(conf.MessageID); err != nil {
+		return nil, fmt.Errorf("failed to parse message_id expression: %v", err)
+	}
+	if a.correlationID, err = bloblang.NewField(conf.CorrelationID); err != nil {
+		return nil, fmt.Errorf("failed to parse correlation_id expression: %v", err)
+	}
+	if a.contentType, err = bloblang.NewField(conf.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to parse content_type expression: %v", err)
+	}
+	if a.groupID, err = bloblang.NewField(conf.GroupID); err != nil {
+		return nil, fmt.Errorf("failed to parse group_id expression: %v", err)
+	}
+	for k, v := range conf.MessageAnnotations {
+		if a.annotations[k], err = bloblang.NewField(v); err != nil {
+			return nil, fmt.Errorf("failed to parse message annotation '%v' expression: %v", k, err)
+		}
+	}
+	if a.appProps, err = imetadata.NewIncludeFilter(conf.ApplicationProperties); err != nil {
+		return nil, fmt.Errorf("failed to construct application properties filter: %w", err)
+	}
 	if conf.TLS.Enabled {
 		if a.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
@@ -88,7 +139,6 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 	var (
 		client  *amqp.Client
 		session *amqp.Session
-		sender  *amqp.Sender
 		err     error
 	)
 
@@ -111,20 +161,28 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	// Create a sender
-	if sender, err = session.NewSender(
-		amqp.LinkTargetAddress(a.conf.TargetAddress),
-	); err != nil {
-		session.Close(context.Background())
-		client.Close()
-		return err
+	cache := senders.NewCache(a.conf.MaxSenders, func(ctx context.Context, address string) (senders.Sender, error) {
+		return session.NewSender(amqp.LinkTargetAddress(address))
+	}, a.log)
+
+	// Create the sender up front when the target is static so that an invalid
+	// address fails the connection attempt.
+	if a.targetAddress.NumDynamicExpressions() == 0 {
+		entry, err := cache.Acquire(ctx, a.conf.TargetAddress)
+		if err != nil {
+			session.Close(context.Background())
+			client.Close()
+			return err
+		}
+		cache.Release(ctx, entry)
+		a.log.Infof("Sending AMQP 1.0 messages to target: %v\n", a.conf.TargetAddress)
+	} else {
+		a.log.Infof("Sending AMQP 1.0 messages to dynamic targets: %v\n", a.conf.TargetAddress)
 	}
 
 	a.client = client
 	a.session = session
-	a.sender = sender
-
-	a.log.Infof("Sending AMQP 1.0 messages to target: %v\n", a.conf.TargetAddress)
+	a.senders = cache
 	return nil
 }
 
@@ -137,9 +195,7 @@ func (a *AMQP1) disconnect(ctx context.Context) error {
 		return nil
 	}
 
-	if err := a.sender.Close(ctx); err != nil {
-		a.log.Errorf("Failed to cleanly close sender: %v\n", err)
-	}
+	a.senders.CloseAll(ctx)
 	if err := a.session.Close(ctx); err != nil {
 		a.log.Errorf("Failed to cleanly close session: %v\n", err)
 	}
@@ -148,13 +204,53 @@ func (a *AMQP1) disconnect(ctx context.Context) error {
 	}
 	a.client = nil
 	a.session = nil
-	a.sender = nil
+	a.senders = nil
 
 	return nil
 }
 
 //------------------------------------------------------------------------------
 
+// buildMessage creates an AMQP 1.0 message from a message part.
+func (a *AMQP1) buildMessage(i int, msg types.Message) *amqp.Message {
+	p := msg.Get(i)
+	m := amqp.NewMessage(p.Get())
+
+	props := &amqp.MessageProperties{}
+	var hasProps bool
+	if v := a.messageID.String(i, msg); v != "" {
+		props.MessageID, hasProps = v, true
+	}
+	if v := a.correlationID.String(i, msg); v != "" {
+		props.CorrelationID, hasProps = v, true
+	}
+	if v := a.contentType.String(i, msg); v != "" {
+		props.ContentType, hasProps = v, true
+	}
+	if v := a.groupID.String(i, msg); v != "" {
+		props.GroupID, hasProps = v, true
+	}
+	if hasProps {
+		m.Properties = props
+	}
+
+	if len(a.annotations) > 0 {
+		m.Annotations = amqp.Annotations{}
+		for k, v := range a.annotations {
+			m.Annotations[k] = v.String(i, msg)
+		}
+	}
+
+	_ = a.appProps.Iter(p, func(k, v string) error {
+		if m.ApplicationProperties == nil {
+			m.ApplicationProperties = map[string]interface{}{}
+		}
+		m.ApplicationProperties[k] = v
+		return nil
+	})
+	return m
+}
+
 // Write will attempt to write a message over AMQP1, wait for acknowledgement,
 // and returns an error if applicable.
 func (a *AMQP1) Write(msg types.Message) error {
@@ -164,29 +260,38 @@ func (a *AMQP1) Write(msg types.Message) error {
 // WriteWithContext will attempt to write a message over AMQP1, wait for
 // acknowledgement, and returns an error if applicable.
 func (a *AMQP1) WriteWithContext(ctx context.Context, msg types.Message) error {
-	var s *amqp.Sender
 	a.connLock.RLock()
-	if a.sender != nil {
-		s = a.sender
-	}
+	cache := a.senders
 	a.connLock.RUnlock()
 
-	if s == nil {
+	if cache == nil {
 		return types.ErrNotConnected
 	}
 
 	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		m := amqp.NewMessage(p.Get())
-		err := s.Send(ctx, m)
+		address := a.targetAddress.String(i, msg)
+		entry, err := cache.Acquire(ctx, address)
+		if err != nil {
+			a.log.Errorf("Failed to create sender for target '%v': %v\n", address, err)
+			return err
+		}
+
+		err = entry.Sender.Send(ctx, a.buildMessage(i, msg))
+		cache.Release(ctx, entry)
 		if err != nil {
 			if err == amqp.ErrTimeout {
 				err = types.ErrTimeout
-			} else {
-				if dErr, isDetachError := err.(*amqp.DetachError); isDetachError && dErr.RemoteError != nil {
-					a.log.Errorf("Lost connection due to: %v\n", dErr.RemoteError)
+			} else if dErr, isDetachError := err.(*amqp.DetachError); isDetachError {
+				// Only the link of this target was detached, which is
+				// recreated by the next send to the target.
+				if dErr.RemoteError != nil {
+					a.log.Errorf("Sender for target '%v' detached due to: %v\n", address, dErr.RemoteError)
 				} else {
-					a.log.Errorf("Lost connection due to: %v\n", err)
+					a.log.Errorf("Sender for target '%v' detached\n", address)
 				}
+				cache.Remove(ctx, entry)
+			} else {
+				a.log.Errorf("Lost connection due to: %v\n", err)
 				a.disconnect(ctx)
 				err = types.ErrNotConnected
 			}
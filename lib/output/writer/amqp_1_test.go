@@ -0,0 +1,53 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMQP1BuildMessage(t *testing.T) {
+	conf := NewAMQP1Config()
+	conf.TargetAddress = `queue:/${! meta("tenant") }`
+	conf.MessageID = `${! meta("id") }`
+	conf.CorrelationID = `${! json("cid") }`
+	conf.ContentType = "application/json"
+	conf.GroupID = `${! meta("tenant") }`
+	conf.MessageAnnotations = map[string]string{
+		"x-opt-partition-key": `${! meta("tenant") }`,
+	}
+	conf.ApplicationProperties.IncludePrefixes = []string{"app_"}
+
+	a, err := NewAMQP1(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`{"cid":"c1"}`)})
+	msg.Get(0).Metadata().Set("tenant", "foo")
+	msg.Get(0).Metadata().Set("id", "m1")
+	msg.Get(0).Metadata().Set("app_version", "2")
+
+	assert.Equal(t, "queue:/foo", a.targetAddress.String(0, msg))
+
+	m := a.buildMessage(0, msg)
+	assert.Equal(t, [][]byte{[]byte(`{"cid":"c1"}`)}, m.Data)
+	require.NotNil(t, m.Properties)
+	assert.Equal(t, "m1", m.Properties.MessageID)
+	assert.Equal(t, "c1", m.Properties.CorrelationID)
+	assert.Equal(t, "application/json", m.Properties.ContentType)
+	assert.Equal(t, "foo", m.Properties.GroupID)
+	assert.Equal(t, amqp.Annotations{"x-opt-partition-key": "foo"}, m.Annotations)
+	assert.Equal(t, map[string]interface{}{"app_version": "2"}, m.ApplicationProperties)
+
+	a, err = NewAMQP1(NewAMQP1Config(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	m = a.buildMessage(0, msg)
+	assert.Nil(t, m.Properties)
+	assert.Nil(t, m.Annotations)
+	assert.Nil(t, m.ApplicationProperties)
+}
@@ -0,0 +1,498 @@
+// +build !wasm
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/impl/azure"
+	imetadata "github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/senders"
+)
+
+const (
+	// The message format of a batch, where each data section of the message is
+	// an encoded message of the batch.
+	serviceBusBatchFormat uint32 = 0x80013700
+
+	// The encoding overhead of each message within a batch, and the space
+	// reserved for the envelope of a batch.
+	serviceBusSectionOverhead  = 8
+	serviceBusEnvelopeOverhead = 1024
+
+	serviceBusScheduledEnqueueTime = "x-opt-scheduled-enqueue-time"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBus is a benthos writer.Type implementation that writes messages
+// to Azure Service Bus queues and topics.
+type AzureServiceBus struct {
+	conf AzureServiceBusConfig
+	auth *azure.ServiceBusAuth
+
+	target        *field.Expression
+	messageID     *field.Expression
+	contentType   *field.Expression
+	sessionID     *field.Expression
+	scheduledTime *mapping.Executor
+	appProps      *imetadata.IncludeFilter
+
+	log   log.Modular
+	stats metrics.Type
+
+	connLock sync.RWMutex
+	client   *amqp.Client
+	session  *amqp.Session
+	cbs      *azure.ServiceBusCBS
+	senders  *senders.Cache
+}
+
+// NewAzureServiceBus creates a new Azure Service Bus writer type.
+func NewAzureServiceBus(conf AzureServiceBusConfig, log log.Modular, stats metrics.Type) (*AzureServiceBus, error) {
+	auth, err := azure.NewServiceBusAuth(conf.ConnectionString, conf.Namespace, conf.ManagedIdentityClientID)
+	if err != nil {
+		return nil, err
+	}
+	return newAzureServiceBus(conf, auth, log, stats)
+}
+
+func newAzureServiceBus(conf AzureServiceBusConfig, auth *azure.ServiceBusAuth, log log.Modular, stats metrics.Type) (*AzureServiceBus, error) {
+	a := &AzureServiceBus{
+		conf:  conf,
+		auth:  auth,
+		log:   log,
+		stats: stats,
+	}
+
+	if conf.Target == "" {
+		if conf.Target = auth.EntityPath; conf.Target == "" {
+			return nil, errors.New("a target queue or topic must be specified either with the field target or the EntityPath of the connection string")
+		}
+	}
+	if conf.MaxBatchBytes <= serviceBusEnvelopeOverhead {
+		return nil, fmt.Errorf("max_batch_bytes must be greater than %v", serviceBusEnvelopeOverhead)
+	}
+
+	var err error
+	if a.target, err = bloblang.NewField(conf.Target); err != nil {
+		return nil, fmt.Errorf("failed to parse target expression: %v", err)
+	}
+	if a.messageID, err = bloblang.NewField(conf.MessageID); err != nil {
+		return nil, fmt.Errorf("failed to parse message_id expression: %v", err)
+	}
+	if a.contentType, err = bloblang.NewField(conf.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to parse content_type expression: %v", err)
+	}
+	if a.sessionID, err = bloblang.NewField(conf.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to parse session_id expression: %v", err)
+	}
+	if conf.ScheduledEnqueueTime != "" {
+		if a.scheduledTime, err = bloblang.NewMapping("", conf.ScheduledEnqueueTime); err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled_enqueue_time query: %v", err)
+		}
+	}
+	if a.appProps, err = imetadata.NewIncludeFilter(conf.ApplicationProperties); err != nil {
+		return nil, fmt.Errorf("failed to construct application properties filter: %w", err)
+	}
+	a.conf = conf
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to an Azure Service Bus namespace.
+func (a *AzureServiceBus) Connect() error {
+	return a.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to an Azure Service Bus
+// namespace.
+func (a *AzureServiceBus) ConnectWithContext(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client != nil {
+		return nil
+	}
+
+	client, err := a.auth.Dial()
+	if err != nil {
+		return err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	cbs := a.auth.NewCBS(session, a.log)
+	cache := senders.NewCache(a.conf.MaxSenders, func(ctx context.Context, address string) (senders.Sender, error) {
+		if err := cbs.Authorise(ctx, address); err != nil {
+			return nil, err
+		}
+		return session.NewSender(amqp.LinkTargetAddress(address))
+	}, a.log)
+
+	// Create the sender up front when the target is static so that an invalid
+	// entity or insufficient permissions fail the connection attempt.
+	if a.target.NumDynamicExpressions() == 0 {
+		entry, err := cache.Acquire(ctx, a.conf.Target)
+		if err != nil {
+			cbs.Close()
+			session.Close(context.Background())
+			client.Close()
+			return err
+		}
+		cache.Release(ctx, entry)
+		a.log.Infof("Sending Azure Service Bus messages to entity: %v\n", a.conf.Target)
+	} else {
+		a.log.Infof("Sending Azure Service Bus messages to dynamic entities: %v\n", a.conf.Target)
+	}
+
+	a.client = client
+	a.session = session
+	a.cbs = cbs
+	a.senders = cache
+	return nil
+}
+
+// disconnect safely closes a connection to an Azure Service Bus namespace.
+func (a *AzureServiceBus) disconnect(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+
+	a.senders.CloseAll(ctx)
+	a.cbs.Close()
+	if err := a.session.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close session: %v\n", err)
+	}
+	if err := a.client.Close(); err != nil {
+		a.log.Errorf("Failed to cleanly close client: %v\n", err)
+	}
+	a.client = nil
+	a.session = nil
+	a.cbs = nil
+	a.senders = nil
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// serviceBusEventTime converts the result of a timestamp query into a time,
+// which can be a number of seconds since the unix epoch, either as a number or
+// a string, or an RFC 3339 timestamp string.
+func serviceBusEventTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return serviceBusUnixTime(f), nil
+		}
+		ts, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		return ts, nil
+	}
+	f, err := query.IGetNumber(v)
+	if err != nil {
+		return time.Time{}, query.NewTypeErrorFrom("timestamp", v, query.ValueNumber, query.ValueString)
+	}
+	return serviceBusUnixTime(f), nil
+}
+
+func serviceBusUnixTime(f float64) time.Time {
+	secs := math.Floor(f)
+	return time.Unix(int64(secs), int64((f-secs)*float64(time.Second)))
+}
+
+func (a *AzureServiceBus) queryScheduledTime(index int, msg types.Message) (time.Time, error) {
+	v, err := a.scheduledTime.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    index,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(index).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to execute scheduled_enqueue_time query: %w", err)
+	}
+	return serviceBusEventTime(v)
+}
+
+// buildMessage creates a Service Bus message from a message part.
+func (a *AzureServiceBus) buildMessage(i int, msg types.Message) (*amqp.Message, error) {
+	p := msg.Get(i)
+	m := amqp.NewMessage(p.Get())
+
+	props := &amqp.MessageProperties{}
+	var hasProps bool
+	if v := a.messageID.String(i, msg); v != "" {
+		props.MessageID, hasProps = v, true
+	}
+	if v := a.contentType.String(i, msg); v != "" {
+		props.ContentType, hasProps = v, true
+	}
+	if v := a.sessionID.String(i, msg); v != "" {
+		props.GroupID, hasProps = v, true
+	}
+	if hasProps {
+		m.Properties = props
+	}
+
+	if a.scheduledTime != nil {
+		ts, err := a.queryScheduledTime(i, msg)
+		if err != nil {
+			return nil, err
+		}
+		m.Annotations = amqp.Annotations{
+			serviceBusScheduledEnqueueTime: ts.UTC(),
+		}
+	}
+
+	_ = a.appProps.Iter(p, func(k, v string) error {
+		if m.ApplicationProperties == nil {
+			m.ApplicationProperties = map[string]interface{}{}
+		}
+		m.ApplicationProperties[k] = v
+		return nil
+	})
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+type serviceBusPending struct {
+	index   int
+	message *amqp.Message
+	encoded []byte
+}
+
+// serviceBusGroup contains the messages of a batch that share a target entity
+// and session, in the order in which they appeared in the batch.
+type serviceBusGroup struct {
+	target    string
+	sessionID string
+	pending   []serviceBusPending
+}
+
+// chunks splits the messages of a group into chunks that each fit within a
+// single send of maxBytes.
+func (g *serviceBusGroup) chunks(maxBytes int) [][]serviceBusPending {
+	var chunks [][]serviceBusPending
+	var current []serviceBusPending
+	size := serviceBusEnvelopeOverhead
+	for _, p := range g.pending {
+		pSize := len(p.encoded) + serviceBusSectionOverhead
+		if len(current) > 0 && size+pSize > maxBytes {
+			chunks = append(chunks, current)
+			current, size = nil, serviceBusEnvelopeOverhead
+		}
+		current = append(current, p)
+		size += pSize
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// envelope returns the message that sends a chunk, which is the message itself
+// when the chunk contains a single message and a batch otherwise.
+func (g *serviceBusGroup) envelope(chunk []serviceBusPending) *amqp.Message {
+	if len(chunk) == 1 {
+		return chunk[0].message
+	}
+	m := &amqp.Message{
+		Format: serviceBusBatchFormat,
+		Data:   make([][]byte, 0, len(chunk)),
+	}
+	for _, p := range chunk {
+		m.Data = append(m.Data, p.encoded)
+	}
+	if g.sessionID != "" {
+		m.Properties = &amqp.MessageProperties{GroupID: g.sessionID}
+	}
+	return m
+}
+
+// groupMessages builds the messages of a batch and groups them by target
+// entity and session. Messages that can't be built or exceed the maximum size
+// are failed.
+func (a *AzureServiceBus) groupMessages(msg types.Message, failed func(i int, err error)) []*serviceBusGroup {
+	var groups []*serviceBusGroup
+	groupIndexes := map[[2]string]int{}
+
+	_ = msg.Iter(func(i int, p types.Part) error {
+		m, err := a.buildMessage(i, msg)
+		if err != nil {
+			a.log.Errorf("Failed to build message: %v\n", err)
+			failed(i, ioutput.NewTerminalError(err))
+			return nil
+		}
+		encoded, err := m.MarshalBinary()
+		if err != nil {
+			failed(i, ioutput.NewTerminalError(fmt.Errorf("failed to encode message: %w", err)))
+			return nil
+		}
+		if size := len(encoded) + serviceBusSectionOverhead + serviceBusEnvelopeOverhead; size > a.conf.MaxBatchBytes {
+			failed(i, ioutput.NewTerminalError(fmt.Errorf("encoded message size of %v bytes exceeds the max_batch_bytes limit of %v", size, a.conf.MaxBatchBytes)))
+			return nil
+		}
+
+		var sessionID string
+		if m.Properties != nil {
+			sessionID = m.Properties.GroupID
+		}
+		key := [2]string{a.target.String(i, msg), sessionID}
+		gIndex, exists := groupIndexes[key]
+		if !exists {
+			gIndex = len(groups)
+			groupIndexes[key] = gIndex
+			groups = append(groups, &serviceBusGroup{target: key[0], sessionID: sessionID})
+		}
+		groups[gIndex].pending = append(groups[gIndex].pending, serviceBusPending{
+			index:   i,
+			message: m,
+			encoded: encoded,
+		})
+		return nil
+	})
+	return groups
+}
+
+// Write will attempt to write a message to Azure Service Bus, wait for
+// acknowledgement, and returns an error if applicable.
+func (a *AzureServiceBus) Write(msg types.Message) error {
+	return a.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext will attempt to write a message to Azure Service Bus, wait
+// for acknowledgement, and returns an error if applicable.
+func (a *AzureServiceBus) WriteWithContext(ctx context.Context, msg types.Message) error {
+	a.connLock.RLock()
+	cache := a.senders
+	a.connLock.RUnlock()
+
+	if cache == nil {
+		return types.ErrNotConnected
+	}
+
+	var batchErr *ibatch.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = ibatch.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	for _, g := range a.groupMessages(msg, failed) {
+		chunks := g.chunks(a.conf.MaxBatchBytes)
+		for j, chunk := range chunks {
+			err := a.send(ctx, cache, g.target, g.envelope(chunk))
+			if err == nil {
+				continue
+			}
+			if err == types.ErrNotConnected {
+				return err
+			}
+			// The remaining messages of the group are also failed so that the
+			// order of messages within a session is preserved when retried.
+			for _, remaining := range chunks[j:] {
+				for _, p := range remaining {
+					failed(p.index, err)
+				}
+			}
+			break
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+// send writes a message to a target entity and classifies the error, if any.
+func (a *AzureServiceBus) send(ctx context.Context, cache *senders.Cache, target string, m *amqp.Message) error {
+	entry, err := cache.Acquire(ctx, target)
+	if err != nil {
+		a.log.Errorf("Failed to create sender for entity '%v': %v\n", target, err)
+		return err
+	}
+
+	err = entry.Sender.Send(ctx, m)
+	cache.Release(ctx, entry)
+	if err == nil {
+		return nil
+	}
+
+	var aErr *amqp.Error
+	if errors.As(err, &aErr) {
+		// The message was rejected by the broker.
+		if aErr.Condition == amqp.ErrorMessageSizeExceeded {
+			return ioutput.NewTerminalError(aErr)
+		}
+		return aErr
+	}
+	if err == amqp.ErrTimeout {
+		return types.ErrTimeout
+	}
+	if dErr, isDetachError := err.(*amqp.DetachError); isDetachError {
+		// Only the link of this entity was detached, which is recreated by the
+		// next send to the entity.
+		if dErr.RemoteError != nil {
+			a.log.Errorf("Sender for entity '%v' detached due to: %v\n", target, dErr.RemoteError)
+		} else {
+			a.log.Errorf("Sender for entity '%v' detached\n", target)
+		}
+		cache.Remove(ctx, entry)
+		return err
+	}
+
+	a.log.Errorf("Lost connection due to: %v\n", err)
+	a.disconnect(ctx)
+	return types.ErrNotConnected
+}
+
+// CloseAsync shuts down the Azure Service Bus output and stops processing
+// messages.
+func (a *AzureServiceBus) CloseAsync() {
+	a.disconnect(context.Background())
+}
+
+// WaitForClose blocks until the Azure Service Bus output has closed down.
+func (a *AzureServiceBus) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,43 @@
+package writer
+
+import (
+	imetadata "github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
+// AzureServiceBusConfig contains configuration fields for the output Azure
+// Service Bus type.
+type AzureServiceBusConfig struct {
+	ConnectionString        string                        `json:"connection_string" yaml:"connection_string"`
+	Namespace               string                        `json:"namespace" yaml:"namespace"`
+	ManagedIdentityClientID string                        `json:"managed_identity_client_id" yaml:"managed_identity_client_id"`
+	Target                  string                        `json:"target" yaml:"target"`
+	MessageID               string                        `json:"message_id" yaml:"message_id"`
+	ContentType             string                        `json:"content_type" yaml:"content_type"`
+	SessionID               string                        `json:"session_id" yaml:"session_id"`
+	ScheduledEnqueueTime    string                        `json:"scheduled_enqueue_time" yaml:"scheduled_enqueue_time"`
+	ApplicationProperties   imetadata.IncludeFilterConfig `json:"application_properties" yaml:"application_properties"`
+	MaxBatchBytes           int                           `json:"max_batch_bytes" yaml:"max_batch_bytes"`
+	MaxSenders              int                           `json:"max_senders" yaml:"max_senders"`
+	MaxInFlight             int                           `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching                batch.PolicyConfig            `json:"batching" yaml:"batching"`
+}
+
+// NewAzureServiceBusConfig creates a new Config with default values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		ConnectionString:        "",
+		Namespace:               "",
+		ManagedIdentityClientID: "",
+		Target:                  "",
+		MessageID:               "",
+		ContentType:             "",
+		SessionID:               "",
+		ScheduledEnqueueTime:    "",
+		ApplicationProperties:   imetadata.NewIncludeFilterConfig(),
+		MaxBatchBytes:           262144,
+		MaxSenders:              100,
+		MaxInFlight:             1,
+		Batching:                batch.NewPolicyConfig(),
+	}
+}
@@ -0,0 +1,241 @@
+// +build !wasm
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/senders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServiceBusConnString = "Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar;EntityPath=orders"
+
+type serviceBusSent struct {
+	target string
+	msg    *amqp.Message
+}
+
+type mockServiceBusSender struct {
+	target string
+	sent   *[]serviceBusSent
+	sendFn func(target string, msg *amqp.Message) error
+}
+
+func (m *mockServiceBusSender) Send(ctx context.Context, msg *amqp.Message) error {
+	if m.sendFn != nil {
+		if err := m.sendFn(m.target, msg); err != nil {
+			return err
+		}
+	}
+	*m.sent = append(*m.sent, serviceBusSent{target: m.target, msg: msg})
+	return nil
+}
+
+func (m *mockServiceBusSender) Close(ctx context.Context) error {
+	return nil
+}
+
+func newMockServiceBus(t *testing.T, conf AzureServiceBusConfig, sendFn func(target string, msg *amqp.Message) error) (*AzureServiceBus, *[]serviceBusSent) {
+	t.Helper()
+
+	conf.ConnectionString = testServiceBusConnString
+	a, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	sent := &[]serviceBusSent{}
+	a.senders = senders.NewCache(0, func(ctx context.Context, address string) (senders.Sender, error) {
+		return &mockServiceBusSender{target: address, sent: sent, sendFn: sendFn}, nil
+	}, log.Noop())
+	return a, sent
+}
+
+func serviceBusFailedIndexes(t *testing.T, err error) map[int]error {
+	t.Helper()
+
+	var bErr *ibatch.Error
+	require.True(t, errors.As(err, &bErr), err)
+
+	failed := map[int]error{}
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed[i] = err
+		}
+		return true
+	})
+	return failed
+}
+
+func TestAzureServiceBusConfigErrors(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	_, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.ConnectionString = "Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar"
+	_, err = NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Target = "orders"
+	conf.MaxBatchBytes = 10
+	_, err = NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.MaxBatchBytes = 262144
+	a, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, types.ErrNotConnected, a.Write(message.New([][]byte{[]byte("foo")})))
+}
+
+func TestAzureServiceBusBuildMessage(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.MessageID = `${! meta("id") }`
+	conf.ContentType = "application/json"
+	conf.SessionID = `${! json("customer") }`
+	conf.ScheduledEnqueueTime = `this.deliver_at`
+	conf.ApplicationProperties.IncludePrefixes = []string{"app_"}
+
+	a, _ := newMockServiceBus(t, conf, nil)
+	assert.Equal(t, "orders", a.target.String(0, message.New(nil)))
+
+	msg := message.New([][]byte{
+		[]byte(`{"customer":"c1","deliver_at":1700000000}`),
+		[]byte(`{"customer":"c1","deliver_at":"2023-11-14T22:13:20Z"}`),
+		[]byte(`{"customer":"c1","deliver_at":"nope"}`),
+	})
+	msg.Get(0).Metadata().Set("id", "m1")
+	msg.Get(0).Metadata().Set("app_version", "2")
+
+	m, err := a.buildMessage(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"customer":"c1","deliver_at":1700000000}`)}, m.Data)
+	require.NotNil(t, m.Properties)
+	assert.Equal(t, "m1", m.Properties.MessageID)
+	assert.Equal(t, "application/json", m.Properties.ContentType)
+	assert.Equal(t, "c1", m.Properties.GroupID)
+	assert.Equal(t, amqp.Annotations{
+		"x-opt-scheduled-enqueue-time": time.Unix(1700000000, 0).UTC(),
+	}, m.Annotations)
+	assert.Equal(t, map[string]interface{}{"app_version": "2"}, m.ApplicationProperties)
+
+	m, err = a.buildMessage(1, msg)
+	require.NoError(t, err)
+	assert.Equal(t, amqp.Annotations{
+		"x-opt-scheduled-enqueue-time": time.Unix(1700000000, 0).UTC(),
+	}, m.Annotations)
+	assert.Nil(t, m.ApplicationProperties)
+
+	_, err = a.buildMessage(2, msg)
+	assert.Error(t, err)
+
+	a, _ = newMockServiceBus(t, NewAzureServiceBusConfig(), nil)
+	m, err = a.buildMessage(0, msg)
+	require.NoError(t, err)
+	assert.Nil(t, m.Properties)
+	assert.Nil(t, m.Annotations)
+	assert.Nil(t, m.ApplicationProperties)
+}
+
+func TestAzureServiceBusWriteBatches(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.Target = `${! meta("target") }`
+	conf.SessionID = `${! meta("session") }`
+	conf.MaxBatchBytes = 1300
+
+	a, sent := newMockServiceBus(t, conf, nil)
+
+	parts := []struct {
+		target, session, content string
+	}{
+		{"foo", "", "a"},
+		{"bar", "", "b"},
+		{"foo", "", "c"},
+		{"foo", "s1", "d"},
+		{"foo", "", strings.Repeat("e", 200)},
+		{"foo", "", strings.Repeat("f", 200)},
+		{"bar", "", strings.Repeat("g", 400)},
+	}
+	msg := message.New(nil)
+	for _, p := range parts {
+		part := message.NewPart([]byte(p.content))
+		part.Metadata().Set("target", p.target)
+		if p.session != "" {
+			part.Metadata().Set("session", p.session)
+		}
+		msg.Append(part)
+	}
+
+	failed := serviceBusFailedIndexes(t, a.Write(msg))
+	require.Len(t, failed, 1)
+	assert.Equal(t, ioutput.ErrorClassTerminal, ioutput.ClassifyError(failed[6]), failed[6])
+
+	decode := func(m *amqp.Message) []string {
+		if m.Format != serviceBusBatchFormat {
+			return []string{string(m.GetData())}
+		}
+		var contents []string
+		for _, d := range m.Data {
+			var inner amqp.Message
+			require.NoError(t, inner.UnmarshalBinary(d))
+			contents = append(contents, string(inner.GetData()))
+		}
+		return contents
+	}
+
+	require.Len(t, *sent, 4)
+
+	assert.Equal(t, "foo", (*sent)[0].target)
+	assert.Equal(t, []string{"a", "c", strings.Repeat("e", 200)}, decode((*sent)[0].msg))
+
+	assert.Equal(t, "foo", (*sent)[1].target)
+	assert.Equal(t, []string{strings.Repeat("f", 200)}, decode((*sent)[1].msg))
+
+	assert.Equal(t, "bar", (*sent)[2].target)
+	assert.Equal(t, []string{"b"}, decode((*sent)[2].msg))
+
+	assert.Equal(t, "foo", (*sent)[3].target)
+	assert.Equal(t, []string{"d"}, decode((*sent)[3].msg))
+	assert.Equal(t, "s1", (*sent)[3].msg.Properties.GroupID)
+}
+
+func TestAzureServiceBusWriteFailurePreservesOrder(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.Target = `${! meta("target") }`
+	conf.MaxBatchBytes = 1100
+
+	rejectErr := &amqp.Error{Condition: amqp.ErrorNotFound, Description: "entity not found"}
+	a, sent := newMockServiceBus(t, conf, func(target string, msg *amqp.Message) error {
+		if target == "foo" {
+			return rejectErr
+		}
+		return nil
+	})
+
+	msg := message.New(nil)
+	for _, target := range []string{"foo", "bar", "foo"} {
+		part := message.NewPart([]byte(strings.Repeat("x", 40)))
+		part.Metadata().Set("target", target)
+		msg.Append(part)
+	}
+
+	failed := serviceBusFailedIndexes(t, a.Write(msg))
+	assert.Equal(t, map[int]error{0: rejectErr, 2: rejectErr}, failed)
+
+	require.Len(t, *sent, 1)
+	assert.Equal(t, "bar", (*sent)[0].target)
+
+	a, sent = newMockServiceBus(t, conf, nil)
+	require.NoError(t, a.Write(msg))
+	assert.Len(t, *sent, 3)
+}
@@ -0,0 +1,15 @@
+// +build wasm
+
+package writer
+
+import (
+	"errors"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+// NewAzureServiceBus creates a new Azure Service Bus writer type.
+func NewAzureServiceBus(conf AzureServiceBusConfig, log log.Modular, stats metrics.Type) (dummy, error) {
+	return nil, errors.New("Azure Service Bus is disabled in WASM builds")
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/Jeffail/gabs/v2"
@@ -141,12 +142,13 @@ func (d *DynamoDB) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := d.conf.GetSession()
+	sess, err := d.conf.GetSessionWithFailover(session.LogFailover(d.log, d.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	client := dynamodb.New(sess)
+	instrument.Register(&client.Handlers, d.stats)
 	out, err := client.DescribeTable(&dynamodb.DescribeTableInput{
 		TableName: d.table,
 	})
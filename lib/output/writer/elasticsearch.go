@@ -8,14 +8,17 @@ import (
 	"strings"
 	"time"
 
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/cenkalti/backoff/v4"
@@ -28,8 +31,8 @@ import (
 // OptionalAWSConfig contains config fields for AWS authentication with an
 // enable flag.
 type OptionalAWSConfig struct {
-	Enabled     bool `json:"enabled" yaml:"enabled"`
-	sess.Config `json:",inline" yaml:",inline"`
+	Enabled            bool `json:"enabled" yaml:"enabled"`
+	sess.SigningConfig `json:",inline" yaml:",inline"`
 }
 
 //------------------------------------------------------------------------------
@@ -47,6 +50,9 @@ type ElasticsearchConfig struct {
 	Timeout        string               `json:"timeout" yaml:"timeout"`
 	TLS            btls.Config          `json:"tls" yaml:"tls"`
 	Auth           auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	ProxyURL       string               `json:"proxy_url" yaml:"proxy_url"`
+	ProxyBasicAuth auth.BasicAuthConfig `json:"proxy_basic_auth" yaml:"proxy_basic_auth"`
+	NoProxy        []string             `json:"no_proxy" yaml:"no_proxy"`
 	AWS            OptionalAWSConfig    `json:"aws" yaml:"aws"`
 	MaxInFlight    int                  `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
@@ -61,19 +67,22 @@ func NewElasticsearchConfig() ElasticsearchConfig {
 	rConf.Backoff.MaxElapsedTime = "30s"
 
 	return ElasticsearchConfig{
-		URLs:        []string{"http://localhost:9200"},
-		Sniff:       true,
-		Healthcheck: true,
-		ID:          `${!count("elastic_ids")}-${!timestamp_unix()}`,
-		Index:       "benthos_index",
-		Pipeline:    "",
-		Type:        "doc",
-		Timeout:     "5s",
-		TLS:         btls.NewConfig(),
-		Auth:        auth.NewBasicAuthConfig(),
+		URLs:           []string{"http://localhost:9200"},
+		Sniff:          true,
+		Healthcheck:    true,
+		ID:             `${!count("elastic_ids")}-${!timestamp_unix()}`,
+		Index:          "benthos_index",
+		Pipeline:       "",
+		Type:           "doc",
+		Timeout:        "5s",
+		TLS:            btls.NewConfig(),
+		Auth:           auth.NewBasicAuthConfig(),
+		ProxyURL:       "",
+		ProxyBasicAuth: auth.NewBasicAuthConfig(),
+		NoProxy:        []string{},
 		AWS: OptionalAWSConfig{
-			Enabled: false,
-			Config:  sess.NewConfig(),
+			Enabled:       false,
+			SigningConfig: sess.NewSigningConfig(),
 		},
 		MaxInFlight: 1,
 		Config:      rConf,
@@ -96,12 +105,14 @@ type Elasticsearch struct {
 	backoffCtor func() backoff.BackOff
 	timeout     time.Duration
 	tlsConf     *tls.Config
+	proxyDialer *proxy.Dialer
 
 	idStr       *field.Expression
 	indexStr    *field.Expression
 	pipelineStr *field.Expression
 
-	eJSONErr metrics.StatCounter
+	eJSONErr  metrics.StatCounter
+	eProxyErr metrics.StatCounter
 
 	client *elastic.Client
 }
@@ -115,6 +126,7 @@ func NewElasticsearch(conf ElasticsearchConfig, log log.Modular, stats metrics.T
 		sniff:       conf.Sniff,
 		healthcheck: conf.Healthcheck,
 		eJSONErr:    stats.GetCounter("error.json"),
+		eProxyErr:   stats.GetCounter("error.proxy"),
 	}
 
 	var err error
@@ -153,6 +165,13 @@ func NewElasticsearch(conf ElasticsearchConfig, log log.Modular, stats metrics.T
 			return nil, err
 		}
 	}
+	if e.proxyDialer, err = proxy.NewDialer(proxy.Config{
+		URL:       conf.ProxyURL,
+		BasicAuth: conf.ProxyBasicAuth,
+		NoProxy:   conf.NoProxy,
+	}); err != nil {
+		return nil, err
+	}
 	return &e, nil
 }
 
@@ -182,31 +201,34 @@ func (e *Elasticsearch) Connect() error {
 		))
 	}
 
-	if e.conf.TLS.Enabled {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: e.tlsConf,
-			},
-			Timeout: e.timeout,
-		}))
-
-	} else {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Timeout: e.timeout,
-		}))
+	httpClient := &http.Client{
+		Timeout: e.timeout,
+	}
+	if e.conf.TLS.Enabled || e.proxyDialer != nil {
+		tr := &http.Transport{
+			TLSClientConfig: e.tlsConf,
+		}
+		if e.proxyDialer != nil {
+			e.proxyDialer.Transport(tr)
+		}
+		httpClient.Transport = tr
 	}
+	opts = append(opts, elastic.SetHttpClient(httpClient))
 
 	if e.conf.AWS.Enabled {
 		tsess, err := e.conf.AWS.GetSession()
 		if err != nil {
 			return err
 		}
-		signingClient := aws.NewV4SigningClient(tsess.Config.Credentials, e.conf.AWS.Region)
+		signingClient := aws.NewV4SigningClientWithHTTPClient(tsess.Config.Credentials, e.conf.AWS.Region, httpClient)
 		opts = append(opts, elastic.SetHttpClient(signingClient))
 	}
 
 	client, err := elastic.NewClient(opts...)
 	if err != nil {
+		if proxy.IsError(err) {
+			e.eProxyErr.Incr(1)
+		}
 		return err
 	}
 
@@ -215,11 +237,41 @@ func (e *Elasticsearch) Connect() error {
 	return nil
 }
 
-func shouldRetry(s int) bool {
+// classifyElasticsearchStatus returns the class of an error caused by a
+// request or bulk item that was rejected with a status code.
+func classifyElasticsearchStatus(s int) output.ErrorClass {
+	if s == http.StatusTooManyRequests {
+		return output.ErrorClassThrottled
+	}
 	if s >= 500 && s <= 599 {
-		return true
+		return output.ErrorClassRetryable
+	}
+	return output.ErrorClassTerminal
+}
+
+func classifyElasticsearchError(err error) error {
+	if eErr, ok := err.(*elastic.Error); ok {
+		return output.NewClassifiedError(classifyElasticsearchStatus(eErr.Status), err)
+	}
+	return err
+}
+
+type failedBulkIndex struct {
+	id  string
+	err error
+}
+
+// bulkIndexError returns an error that reports each failed document against
+// the messages of the batch it was created from, so that only the failed
+// messages are attempted again.
+func bulkIndexError(msg types.Message, indexes map[string][]int, failed []failedBulkIndex) error {
+	bErr := ibatch.NewError(msg, fmt.Errorf("failed to send %v parts from message: %v", len(failed), failed[0].err))
+	for _, f := range failed {
+		for _, index := range indexes[f.id] {
+			bErr.Failed(index, f.err)
+		}
 	}
-	return false
+	return bErr
 }
 
 type pendingBulkIndex struct {
@@ -242,6 +294,14 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 		return types.ErrNotConnected
 	}
 
+	err := e.write(msg)
+	if err != nil && proxy.IsError(err) {
+		e.eProxyErr.Incr(1)
+	}
+	return err
+}
+
+func (e *Elasticsearch) write(msg types.Message) error {
 	boff := e.backoffCtor()
 
 	if msg.Len() == 1 {
@@ -258,10 +318,11 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 			// Flush to make sure the document got written.
 			_, err = e.client.Flush().Index(index).Do(context.Background())
 		}
-		return err
+		return classifyElasticsearchError(err)
 	}
 
 	requests := map[string]*pendingBulkIndex{}
+	indexes := map[string][]int{}
 	msg.Iter(func(i int, part types.Part) error {
 		jObj, ierr := part.JSON()
 		if ierr != nil {
@@ -269,12 +330,14 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 			e.log.Errorf("Failed to marshal message into JSON document: %v\n", ierr)
 			return nil
 		}
-		requests[e.idStr.String(i, msg)] = &pendingBulkIndex{
+		id := e.idStr.String(i, msg)
+		requests[id] = &pendingBulkIndex{
 			Index:    e.indexStr.String(i, msg),
 			Pipeline: e.pipelineStr.String(i, msg),
 			Type:     e.conf.Type,
 			Doc:      jObj,
 		}
+		indexes[id] = append(indexes[id], i)
 		return nil
 	})
 
@@ -290,41 +353,54 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 		)
 	}
 
+	// Documents rejected with terminal errors are not attempted again, and are
+	// reported once the remaining documents have either been written or have
+	// run out of attempts.
+	var terminal []failedBulkIndex
 	for b.NumberOfActions() != 0 {
 		result, err := b.Do(context.Background())
 		if err != nil {
-			return err
-		}
-
-		failed := result.Failed()
-		if len(failed) == 0 {
-			continue
+			return classifyElasticsearchError(err)
 		}
 
-		wait := boff.NextBackOff()
-		for i := 0; i < len(failed); i++ {
-			if !shouldRetry(failed[i].Status) {
-				e.log.Errorf("Elasticsearch message '%v' rejected with code [%s]: %v\n", failed[i].Id, failed[i].Status, failed[i].Error.Reason)
-				return fmt.Errorf("failed to send %v parts from message: %v", len(failed), failed[0].Error.Reason)
+		var retrying []failedBulkIndex
+		for _, f := range result.Failed() {
+			class := classifyElasticsearchStatus(f.Status)
+			failure := failedBulkIndex{
+				id:  f.Id,
+				err: output.NewClassifiedError(class, fmt.Errorf("failed with code [%v]: %v", f.Status, f.Error.Reason)),
+			}
+			if class == output.ErrorClassTerminal {
+				e.log.Errorf("Elasticsearch message '%v' rejected with code [%v]: %v\n", f.Id, f.Status, f.Error.Reason)
+				terminal = append(terminal, failure)
+				continue
 			}
-			e.log.Errorf("Elasticsearch message '%v' failed with code [%s]: %v\n", failed[i].Id, failed[i].Status, failed[i].Error.Reason)
-			id := failed[i].Id
-			req := requests[id]
+			e.log.Errorf("Elasticsearch message '%v' failed with code [%v]: %v\n", f.Id, f.Status, f.Error.Reason)
+			retrying = append(retrying, failure)
+			req := requests[f.Id]
 			b.Add(
 				elastic.NewBulkIndexRequest().
 					Index(req.Index).
 					Pipeline(req.Pipeline).
 					Type(req.Type).
-					Id(id).
+					Id(f.Id).
 					Doc(req.Doc),
 			)
 		}
+		if len(retrying) == 0 {
+			continue
+		}
+
+		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
-			return fmt.Errorf("failed to send %v parts from message: %v", len(failed), failed[0].Error.Reason)
+			return bulkIndexError(msg, indexes, append(terminal, retrying...))
 		}
 		time.Sleep(wait)
 	}
 
+	if len(terminal) > 0 {
+		return bulkIndexError(msg, indexes, terminal)
+	}
 	return nil
 }
 
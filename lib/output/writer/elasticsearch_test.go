@@ -0,0 +1,99 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchTerminalDocsNotRetried(t *testing.T) {
+	var attemptsMut sync.Mutex
+	attempts := map[string]int{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []map[string]interface{}
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			id := action["index"]["_id"].(string)
+
+			// Skip the document line that follows each action.
+			require.True(t, scanner.Scan())
+
+			attemptsMut.Lock()
+			attempts[id]++
+			n := attempts[id]
+			attemptsMut.Unlock()
+
+			item := map[string]interface{}{"_id": id, "status": 201}
+			switch {
+			case id == "bad":
+				item["status"] = 400
+				item["error"] = map[string]interface{}{"type": "mapper_parsing_exception", "reason": "nope"}
+			case id == "busy" && n == 1:
+				item["status"] = 429
+				item["error"] = map[string]interface{}{"type": "es_rejected_execution_exception", "reason": "too busy"}
+			}
+			items = append(items, map[string]interface{}{"index": item})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"took":   1,
+			"errors": true,
+			"items":  items,
+		}))
+	}))
+	defer ts.Close()
+
+	conf := NewElasticsearchConfig()
+	conf.URLs = []string{ts.URL}
+	conf.Sniff = false
+	conf.Healthcheck = false
+	conf.ID = `${! json("id") }`
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	e, err := NewElasticsearch(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, e.Connect())
+
+	err = e.Write(message.New([][]byte{
+		[]byte(`{"id":"good"}`),
+		[]byte(`{"id":"bad"}`),
+		[]byte(`{"id":"busy"}`),
+	}))
+	require.Error(t, err)
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(err))
+
+	var bErr *ibatch.Error
+	require.True(t, errors.As(err, &bErr))
+
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, pErr error) bool {
+		if pErr != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+
+	attemptsMut.Lock()
+	assert.Equal(t, map[string]int{"good": 1, "bad": 1, "busy": 2}, attempts)
+	attemptsMut.Unlock()
+}
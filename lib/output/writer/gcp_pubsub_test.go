@@ -0,0 +1,69 @@
+package writer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPPubSubWriteWaitsForPublish(t *testing.T) {
+	srv := pstest.NewServer()
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	require.NoError(t, os.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr))
+	t.Cleanup(func() {
+		os.Unsetenv("PUBSUB_EMULATOR_HOST")
+	})
+
+	client, err := pubsub.NewClient(context.Background(), "foo")
+	require.NoError(t, err)
+	_, err = client.CreateTopic(context.Background(), "bar")
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	conf := NewGCPPubSubConfig()
+	conf.ProjectID = "foo"
+	conf.TopicID = "bar"
+	conf.PublishTimeout = "1s"
+
+	w, err := NewGCPPubSub(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.Connect())
+	t.Cleanup(func() {
+		w.CloseAsync()
+		assert.NoError(t, w.WaitForClose(time.Second))
+	})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.WriteWithContext(ctx, message.New([][]byte{
+		[]byte("foo"), []byte("bar"),
+	})))
+
+	var contents []string
+	for _, m := range srv.Messages() {
+		contents = append(contents, string(m.Data))
+	}
+	assert.ElementsMatch(t, []string{"foo", "bar"}, contents)
+
+	// Kill the connection after the topic is resolved, the write must not
+	// succeed until publishes are confirmed, and therefore fails so that the
+	// batch is redelivered upstream.
+	require.NoError(t, srv.Close())
+
+	assert.Error(t, w.WriteWithContext(ctx, message.New([][]byte{
+		[]byte("baz"),
+	})))
+}
@@ -2,9 +2,12 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
@@ -13,35 +16,166 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/colinmarc/hdfs"
 )
 
 //------------------------------------------------------------------------------
 
+// HDFSKerberosConfig contains configuration fields for authenticating with an
+// HDFS cluster using Kerberos.
+type HDFSKerberosConfig struct {
+	Enabled              bool   `json:"enabled" yaml:"enabled"`
+	Principal            string `json:"principal" yaml:"principal"`
+	Realm                string `json:"realm" yaml:"realm"`
+	KeytabPath           string `json:"keytab_path" yaml:"keytab_path"`
+	CCachePath           string `json:"ccache_path" yaml:"ccache_path"`
+	ConfigPath           string `json:"config_path" yaml:"config_path"`
+	ServicePrincipalName string `json:"service_principal_name" yaml:"service_principal_name"`
+}
+
 // HDFSConfig contains configuration fields for the HDFS output type.
 type HDFSConfig struct {
-	Hosts       []string           `json:"hosts" yaml:"hosts"`
-	User        string             `json:"user" yaml:"user"`
-	Directory   string             `json:"directory" yaml:"directory"`
-	Path        string             `json:"path" yaml:"path"`
-	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Hosts          []string           `json:"hosts" yaml:"hosts"`
+	User           string             `json:"user" yaml:"user"`
+	Transport      string             `json:"transport" yaml:"transport"`
+	Kerberos       HDFSKerberosConfig `json:"kerberos" yaml:"kerberos"`
+	Directory      string             `json:"directory" yaml:"directory"`
+	Path           string             `json:"path" yaml:"path"`
+	Append         bool               `json:"append" yaml:"append"`
+	Replication    int                `json:"replication" yaml:"replication"`
+	BlockSize      int64              `json:"block_size" yaml:"block_size"`
+	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
+	retries.Config `json:",inline" yaml:",inline"`
+	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewHDFSConfig creates a new Config with default values.
 func NewHDFSConfig() HDFSConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 5
+	rConf.Backoff.InitialInterval = "1s"
+	rConf.Backoff.MaxInterval = "10s"
+	rConf.Backoff.MaxElapsedTime = "1m"
 	return HDFSConfig{
-		Hosts:       []string{"localhost:9000"},
-		User:        "benthos_hdfs",
+		Hosts:     []string{"localhost:9000"},
+		User:      "benthos_hdfs",
+		Transport: "rpc",
+		Kerberos: HDFSKerberosConfig{
+			Enabled:              false,
+			Principal:            "",
+			Realm:                "",
+			KeytabPath:           "",
+			CCachePath:           "",
+			ConfigPath:           "/etc/krb5.conf",
+			ServicePrincipalName: "",
+		},
 		Directory:   "",
 		Path:        `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		Append:      false,
+		Replication: 0,
+		BlockSize:   0,
 		MaxInFlight: 1,
+		Config:      rConf,
 		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// hdfsFileClient abstracts the file operations of the HDFS writer from the
+// transport used to reach the cluster.
+type hdfsFileClient interface {
+	// MkdirAll creates a directory along with any missing parents.
+	MkdirAll(ctx context.Context, dir string) error
+
+	// Create writes a new file, returning an error that matches os.ErrExist if
+	// it already exists.
+	Create(ctx context.Context, path string, data []byte) error
+
+	// Append writes to the end of an existing file, returning an error that
+	// matches os.ErrNotExist if it does not exist.
+	Append(ctx context.Context, path string, data []byte) error
+
+	Close() error
+}
+
+// isHDFSLeaseConflict returns true if an error was caused by another client
+// holding the lease of a file, or the lease of a previous writer still being
+// recovered, which is resolved by trying again later.
+func isHDFSLeaseConflict(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "AlreadyBeingCreatedException") ||
+		strings.Contains(msg, "RecoveryInProgressException")
+}
+
+//------------------------------------------------------------------------------
+
+// The default replication factor and block size of HDFS, used for whichever of
+// the two isn't set when overriding the other, as the RPC client requires both.
+const (
+	hdfsDefaultReplication = 3
+	hdfsDefaultBlockSize   = 128 * 1024 * 1024
+)
+
+type hdfsRPCClient struct {
+	client      *hdfs.Client
+	replication int
+	blockSize   int64
+}
+
+func (c *hdfsRPCClient) MkdirAll(ctx context.Context, dir string) error {
+	return c.client.MkdirAll(dir, os.ModeDir|0644)
+}
+
+func (c *hdfsRPCClient) create(path string) (*hdfs.FileWriter, error) {
+	if c.replication <= 0 && c.blockSize <= 0 {
+		return c.client.Create(path)
+	}
+	if _, err := c.client.Stat(path); err == nil {
+		return nil, &os.PathError{Op: "create", Path: path, Err: os.ErrExist}
+	}
+	replication, blockSize := c.replication, c.blockSize
+	if replication <= 0 {
+		replication = hdfsDefaultReplication
+	}
+	if blockSize <= 0 {
+		blockSize = hdfsDefaultBlockSize
+	}
+	return c.client.CreateFile(path, replication, blockSize, 0644)
+}
+
+func (c *hdfsRPCClient) Create(ctx context.Context, path string, data []byte) error {
+	fw, err := c.create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		fw.Close()
+		return err
+	}
+	return fw.Close()
+}
+
+func (c *hdfsRPCClient) Append(ctx context.Context, path string, data []byte) error {
+	fw, err := c.client.Append(path)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		fw.Close()
+		return err
+	}
+	return fw.Close()
+}
+
+func (c *hdfsRPCClient) Close() error {
+	return c.client.Close()
+}
+
+//------------------------------------------------------------------------------
+
 // HDFS is a benthos writer.Type implementation that writes messages to a
 // HDFS directory.
 type HDFS struct {
@@ -49,7 +183,10 @@ type HDFS struct {
 
 	path *field.Expression
 
-	client *hdfs.Client
+	backoffCtor func() backoff.BackOff
+	boffPool    sync.Pool
+
+	client hdfsFileClient
 
 	log   log.Modular
 	stats metrics.Type
@@ -61,16 +198,34 @@ func NewHDFS(
 	log log.Modular,
 	stats metrics.Type,
 ) (*HDFS, error) {
+	switch conf.Transport {
+	case "rpc":
+		if conf.Kerberos.Enabled {
+			return nil, errors.New("kerberos authentication is only supported with the webhdfs transport")
+		}
+	case "webhdfs":
+	default:
+		return nil, fmt.Errorf("transport not recognised: %v", conf.Transport)
+	}
 	path, err := bloblang.NewField(conf.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %v", err)
 	}
-	return &HDFS{
+	h := &HDFS{
 		conf:  conf,
 		path:  path,
 		log:   log,
 		stats: stats,
-	}, nil
+	}
+	if h.backoffCtor, err = conf.Config.GetCtor(); err != nil {
+		return nil, err
+	}
+	h.boffPool = sync.Pool{
+		New: func() interface{} {
+			return h.backoffCtor()
+		},
+	}
+	return h, nil
 }
 
 // ConnectWithContext attempts to establish a connection to the target HDFS
@@ -85,16 +240,27 @@ func (h *HDFS) Connect() error {
 		return nil
 	}
 
-	client, err := hdfs.NewClient(hdfs.ClientOptions{
-		Addresses: h.conf.Hosts,
-		User:      h.conf.User,
-	})
-	if err != nil {
-		return err
+	if h.conf.Transport == "webhdfs" {
+		client, err := newWebHDFSClient(h.conf)
+		if err != nil {
+			return err
+		}
+		h.client = client
+	} else {
+		client, err := hdfs.NewClient(hdfs.ClientOptions{
+			Addresses: h.conf.Hosts,
+			User:      h.conf.User,
+		})
+		if err != nil {
+			return err
+		}
+		h.client = &hdfsRPCClient{
+			client:      client,
+			replication: h.conf.Replication,
+			blockSize:   h.conf.BlockSize,
+		}
 	}
 
-	h.client = client
-
 	h.log.Infof("Writing message parts as files to HDFS directory: %v\n", h.conf.Directory)
 	return nil
 }
@@ -102,11 +268,6 @@ func (h *HDFS) Connect() error {
 // WriteWithContext attempts to write message contents to a target HDFS
 // directory as files.
 func (h *HDFS) WriteWithContext(ctx context.Context, msg types.Message) error {
-	return h.Write(msg)
-}
-
-// Write attempts to write message contents to a target HDFS directory as files.
-func (h *HDFS) Write(msg types.Message) error {
 	if h.client == nil {
 		return types.ErrNotConnected
 	}
@@ -115,26 +276,61 @@ func (h *HDFS) Write(msg types.Message) error {
 		path := h.path.String(i, msg)
 		filePath := filepath.Join(h.conf.Directory, path)
 
-		err := h.client.MkdirAll(h.conf.Directory, os.ModeDir|0644)
+		err := h.client.MkdirAll(ctx, h.conf.Directory)
 		if err != nil {
 			return err
 		}
 
-		fw, err := h.client.Create(filePath)
-		if err != nil {
+		if h.conf.Append {
+			return h.appendFile(ctx, filePath, p.Get())
+		}
+		return h.client.Create(ctx, filePath, p.Get())
+	})
+}
+
+// appendFile appends data to a file, creating it when missing, and retries
+// with a backoff when the lease of the file is held by another client.
+func (h *HDFS) appendFile(ctx context.Context, path string, data []byte) error {
+	boff := h.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		h.boffPool.Put(boff)
+	}()
+
+	for {
+		err := h.client.Append(ctx, path, data)
+		if err != nil && errors.Is(err, os.ErrNotExist) {
+			err = h.client.Create(ctx, path, data)
+		}
+		// A file that already exists at this point was created by another
+		// client since our attempt to append, and is retried like a conflict.
+		if err == nil || !(isHDFSLeaseConflict(err) || errors.Is(err, os.ErrExist)) {
 			return err
 		}
 
-		if _, err := fw.Write(p.Get()); err != nil {
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
 			return err
 		}
-		fw.Close()
-		return nil
-	})
+		h.log.Debugf("Retrying append to file '%v' after lease conflict: %v\n", path, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Write attempts to write message contents to a target HDFS directory as files.
+func (h *HDFS) Write(msg types.Message) error {
+	return h.WriteWithContext(context.Background(), msg)
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (h *HDFS) CloseAsync() {
+	if h.client != nil {
+		h.client.Close()
+	}
 }
 
 // WaitForClose will block until either the reader is closed or a specified
@@ -0,0 +1,228 @@
+package writer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebHDFS struct {
+	mut       sync.Mutex
+	files     map[string]string
+	dirs      map[string]bool
+	conflicts int
+	params    map[string]string
+	users     []string
+}
+
+func newFakeWebHDFS() *fakeWebHDFS {
+	return &fakeWebHDFS{
+		files:  map[string]string{},
+		dirs:   map[string]bool{},
+		params: map[string]string{},
+	}
+}
+
+func writeRemoteException(w http.ResponseWriter, status int, exception string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"RemoteException":{"exception":%q,"javaClassName":"org.apache.hadoop.%v","message":"nope"}}`, exception, exception)
+}
+
+func (f *fakeWebHDFS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	q := r.URL.Query()
+	if strings.HasPrefix(r.URL.Path, "/datanode") {
+		path := strings.TrimPrefix(r.URL.Path, "/datanode")
+		data, _ := ioutil.ReadAll(r.Body)
+		switch q.Get("op") {
+		case "CREATE":
+			f.files[path] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		case "APPEND":
+			f.files[path] += string(data)
+		}
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	f.users = append(f.users, q.Get("user.name"))
+	switch q.Get("op") {
+	case "MKDIRS":
+		f.dirs[path] = true
+		w.Write([]byte(`{"boolean":true}`))
+		return
+	case "CREATE":
+		if _, exists := f.files[path]; exists {
+			writeRemoteException(w, http.StatusForbidden, "FileAlreadyExistsException")
+			return
+		}
+		for _, k := range []string{"replication", "blocksize", "overwrite"} {
+			f.params[k] = q.Get(k)
+		}
+	case "APPEND":
+		if _, exists := f.files[path]; !exists {
+			writeRemoteException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		if f.conflicts > 0 {
+			f.conflicts--
+			writeRemoteException(w, http.StatusForbidden, "AlreadyBeingCreatedException")
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Location", "/datanode"+path+"?op="+q.Get("op"))
+	w.WriteHeader(http.StatusTemporaryRedirect)
+}
+
+func (f *fakeWebHDFS) file(path string) string {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.files[path]
+}
+
+// locationPrefixer rewrites relative redirect locations of the fake server into
+// absolute URLs, as returned by real namenodes.
+type locationPrefixer struct {
+	fake   *fakeWebHDFS
+	prefix string
+}
+
+func (l *locationPrefixer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := httptest.NewRecorder()
+	l.fake.ServeHTTP(rec, r)
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	if loc := rec.Header().Get("Location"); loc != "" {
+		w.Header().Set("Location", l.prefix+loc)
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+func newFakeWebHDFSServer(t *testing.T) (*fakeWebHDFS, *httptest.Server) {
+	t.Helper()
+	fake := newFakeWebHDFS()
+	prefixer := &locationPrefixer{fake: fake}
+	server := httptest.NewServer(prefixer)
+	prefixer.prefix = server.URL
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestHDFSWebHDFSCreate(t *testing.T) {
+	fake, server := newFakeWebHDFSServer(t)
+
+	conf := NewHDFSConfig()
+	conf.Transport = "webhdfs"
+	conf.Hosts = []string{strings.TrimPrefix(server.URL, "http://")}
+	conf.Directory = "/foo"
+	conf.Path = `${! content() }.txt`
+	conf.Replication = 2
+
+	w, err := NewHDFS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.Connect())
+
+	require.NoError(t, w.Write(message.New([][]byte{[]byte("bar"), []byte("baz")})))
+
+	assert.Equal(t, "bar", fake.file("/foo/bar.txt"))
+	assert.Equal(t, "baz", fake.file("/foo/baz.txt"))
+	assert.True(t, fake.dirs["/foo"])
+	assert.Equal(t, map[string]string{
+		"replication": "2",
+		"blocksize":   "",
+		"overwrite":   "false",
+	}, fake.params)
+	assert.Equal(t, "benthos_hdfs", fake.users[0])
+
+	err = w.Write(message.New([][]byte{[]byte("bar")}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "FileAlreadyExistsException")
+}
+
+func TestHDFSWebHDFSAppend(t *testing.T) {
+	fake, server := newFakeWebHDFSServer(t)
+
+	conf := NewHDFSConfig()
+	conf.Transport = "webhdfs"
+	conf.Hosts = []string{server.URL}
+	conf.Directory = "/foo"
+	conf.Path = "daily.txt"
+	conf.Append = true
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	w, err := NewHDFS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.Connect())
+
+	require.NoError(t, w.Write(message.New([][]byte{[]byte("first\n")})))
+	require.NoError(t, w.Write(message.New([][]byte{[]byte("second\n")})))
+	assert.Equal(t, "first\nsecond\n", fake.file("/foo/daily.txt"))
+
+	fake.mut.Lock()
+	fake.conflicts = 2
+	fake.mut.Unlock()
+
+	require.NoError(t, w.Write(message.New([][]byte{[]byte("third\n")})))
+	assert.Equal(t, "first\nsecond\nthird\n", fake.file("/foo/daily.txt"))
+
+	fake.mut.Lock()
+	fake.conflicts = 10
+	fake.mut.Unlock()
+
+	err = w.Write(message.New([][]byte{[]byte("fourth\n")}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AlreadyBeingCreatedException")
+	assert.Equal(t, "first\nsecond\nthird\n", fake.file("/foo/daily.txt"))
+}
+
+func TestHDFSWebHDFSStandbyFailover(t *testing.T) {
+	fake, server := newFakeWebHDFSServer(t)
+
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRemoteException(w, http.StatusForbidden, "StandbyException")
+	}))
+	defer standby.Close()
+
+	conf := NewHDFSConfig()
+	conf.Transport = "webhdfs"
+	conf.Hosts = []string{standby.URL + "," + server.URL}
+	conf.Directory = "/foo"
+	conf.Path = "bar.txt"
+
+	w, err := NewHDFS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.Connect())
+
+	require.NoError(t, w.Write(message.New([][]byte{[]byte("hello world")})))
+	assert.Equal(t, "hello world", fake.file("/foo/bar.txt"))
+}
+
+func TestHDFSBadConfig(t *testing.T) {
+	conf := NewHDFSConfig()
+	conf.Kerberos.Enabled = true
+	_, err := NewHDFS(conf, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "kerberos authentication is only supported with the webhdfs transport")
+
+	conf = NewHDFSConfig()
+	conf.Transport = "nope"
+	_, err = NewHDFS(conf, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "transport not recognised: nope")
+}
@@ -0,0 +1,272 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+//------------------------------------------------------------------------------
+
+// webHDFSError is the remote exception returned by a WebHDFS endpoint.
+type webHDFSError struct {
+	StatusCode    int    `json:"-"`
+	Exception     string `json:"exception"`
+	JavaClassName string `json:"javaClassName"`
+	Message       string `json:"message"`
+}
+
+func (e *webHDFSError) Error() string {
+	name := e.JavaClassName
+	if name == "" {
+		name = e.Exception
+	}
+	if name == "" {
+		return fmt.Sprintf("webhdfs request failed with status %v: %v", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("webhdfs request failed with status %v (%v): %v", e.StatusCode, name, e.Message)
+}
+
+// Is maps remote exceptions to their os package equivalents.
+func (e *webHDFSError) Is(target error) bool {
+	switch e.Exception {
+	case "FileNotFoundException":
+		return target == os.ErrNotExist
+	case "FileAlreadyExistsException":
+		return target == os.ErrExist
+	case "AccessControlException":
+		return target == os.ErrPermission
+	}
+	return false
+}
+
+func webHDFSErrorFromResponse(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<16))
+	var wrapper struct {
+		RemoteException *webHDFSError `json:"RemoteException"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil || wrapper.RemoteException == nil {
+		return &webHDFSError{
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(body)),
+		}
+	}
+	wrapper.RemoteException.StatusCode = res.StatusCode
+	return wrapper.RemoteException
+}
+
+//------------------------------------------------------------------------------
+
+// newKerberosClient creates a Kerberos client from either a credentials cache
+// or a keytab.
+func newKerberosClient(conf HDFSKerberosConfig) (*krbclient.Client, error) {
+	cfg, err := krbconfig.Load(conf.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kerberos config: %w", err)
+	}
+
+	if conf.CCachePath != "" {
+		ccache, err := credentials.LoadCCache(conf.CCachePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kerberos credentials cache: %w", err)
+		}
+		return krbclient.NewFromCCache(ccache, cfg, krbclient.DisablePAFXFAST(true))
+	}
+
+	if conf.KeytabPath == "" {
+		return nil, errors.New("kerberos authentication requires either a keytab_path or a ccache_path")
+	}
+	principal, realm := conf.Principal, conf.Realm
+	if i := strings.LastIndex(principal, "@"); i >= 0 && realm == "" {
+		principal, realm = principal[:i], principal[i+1:]
+	}
+	if principal == "" || realm == "" {
+		return nil, errors.New("kerberos authentication with a keytab requires a principal and realm")
+	}
+	kt, err := keytab.Load(conf.KeytabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kerberos keytab: %w", err)
+	}
+	cl := krbclient.NewWithKeytab(principal, realm, kt, cfg, krbclient.DisablePAFXFAST(true))
+	if err := cl.Login(); err != nil {
+		return nil, fmt.Errorf("failed to login with kerberos: %w", err)
+	}
+	return cl, nil
+}
+
+//------------------------------------------------------------------------------
+
+// webHDFSClient writes files through the WebHDFS REST API, where a request is
+// first sent to a namenode which redirects it to a datanode that receives the
+// data.
+type webHDFSClient struct {
+	hosts       []*url.URL
+	user        string
+	replication int
+	blockSize   int64
+
+	http *http.Client
+	krb  *krbclient.Client
+	spn  string
+}
+
+func newWebHDFSClient(conf HDFSConfig) (*webHDFSClient, error) {
+	c := &webHDFSClient{
+		user:        conf.User,
+		replication: conf.Replication,
+		blockSize:   conf.BlockSize,
+		http: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		spn: conf.Kerberos.ServicePrincipalName,
+	}
+	for _, h := range conf.Hosts {
+		for _, splitH := range strings.Split(h, ",") {
+			if splitH = strings.TrimSpace(splitH); splitH == "" {
+				continue
+			}
+			if !strings.Contains(splitH, "://") {
+				splitH = "http://" + splitH
+			}
+			u, err := url.Parse(splitH)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse host '%v': %w", splitH, err)
+			}
+			c.hosts = append(c.hosts, u)
+		}
+	}
+	if len(c.hosts) == 0 {
+		return nil, errors.New("at least one host must be specified")
+	}
+	if conf.Kerberos.Enabled {
+		var err error
+		if c.krb, err = newKerberosClient(conf.Kerberos); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// nameNodeDo sends a request without a body to each namenode in turn until one
+// that isn't in standby responds.
+func (c *webHDFSClient) nameNodeDo(ctx context.Context, method, filePath string, params url.Values) (*http.Response, error) {
+	if c.krb == nil && c.user != "" {
+		params.Set("user.name", c.user)
+	}
+
+	var lastErr error
+	for _, host := range c.hosts {
+		u := *host
+		u.Path = path.Join(u.Path, "/webhdfs/v1", filePath)
+		u.RawQuery = params.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.krb != nil {
+			if err := spnego.SetSPNEGOHeader(c.krb, req, c.spn); err != nil {
+				return nil, fmt.Errorf("failed to set kerberos authentication header: %w", err)
+			}
+		}
+
+		res, err := c.http.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if res.StatusCode >= 400 {
+			err := webHDFSErrorFromResponse(res)
+			res.Body.Close()
+			var wErr *webHDFSError
+			if errors.As(err, &wErr) && wErr.Exception == "StandbyException" {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		return res, nil
+	}
+	return nil, lastErr
+}
+
+// write sends data to the datanode a namenode redirects a request to.
+func (c *webHDFSClient) write(ctx context.Context, method, filePath string, params url.Values, data []byte) error {
+	res, err := c.nameNodeDo(ctx, method, filePath, params)
+	if err != nil {
+		return err
+	}
+	location := res.Header.Get("Location")
+	res.Body.Close()
+	if res.StatusCode != http.StatusTemporaryRedirect || location == "" {
+		return fmt.Errorf("expected a redirect to a datanode, received status %v", res.StatusCode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, location, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if res, err = c.http.Do(req); err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return webHDFSErrorFromResponse(res)
+	}
+	return nil
+}
+
+func (c *webHDFSClient) MkdirAll(ctx context.Context, dir string) error {
+	res, err := c.nameNodeDo(ctx, "PUT", dir, url.Values{"op": []string{"MKDIRS"}})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (c *webHDFSClient) Create(ctx context.Context, filePath string, data []byte) error {
+	params := url.Values{
+		"op":        []string{"CREATE"},
+		"overwrite": []string{"false"},
+	}
+	if c.replication > 0 {
+		params.Set("replication", strconv.Itoa(c.replication))
+	}
+	if c.blockSize > 0 {
+		params.Set("blocksize", strconv.FormatInt(c.blockSize, 10))
+	}
+	return c.write(ctx, "PUT", filePath, params, data)
+}
+
+func (c *webHDFSClient) Append(ctx context.Context, filePath string, data []byte) error {
+	return c.write(ctx, "POST", filePath, url.Values{"op": []string{"APPEND"}}, data)
+}
+
+func (c *webHDFSClient) Close() error {
+	if c.krb != nil {
+		c.krb.Destroy()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
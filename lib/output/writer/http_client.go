@@ -2,6 +2,7 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -19,8 +20,15 @@ import (
 type HTTPClientConfig struct {
 	client.Config     `json:",inline" yaml:",inline"`
 	BatchAsMultipart  bool               `json:"batch_as_multipart" yaml:"batch_as_multipart"`
+	BatchFormat       string             `json:"batch_format" yaml:"batch_format"`
+	ResponseFormat    string             `json:"response_format" yaml:"response_format"`
+	MaxConcurrency    int                `json:"max_concurrency" yaml:"max_concurrency"`
+	QueueTimeout      string             `json:"queue_timeout" yaml:"queue_timeout"`
 	MaxInFlight       int                `json:"max_in_flight" yaml:"max_in_flight"`
 	PropagateResponse bool               `json:"propagate_response" yaml:"propagate_response"`
+	KeepaliveInterval string             `json:"keepalive_interval" yaml:"keepalive_interval"`
+	KeepaliveURL      string             `json:"keepalive_url" yaml:"keepalive_url"`
+	ForceRetryOn      []string           `json:"force_retry_on" yaml:"force_retry_on"`
 	Batching          batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
@@ -29,8 +37,15 @@ func NewHTTPClientConfig() HTTPClientConfig {
 	return HTTPClientConfig{
 		Config:            client.NewConfig(),
 		BatchAsMultipart:  true, // TODO: V4 Set false by default.
-		MaxInFlight:       1,    // TODO: Increase this default?
+		BatchFormat:       "multipart",
+		ResponseFormat:    "auto",
+		MaxConcurrency:    0,
+		QueueTimeout:      "",
+		MaxInFlight:       1, // TODO: Increase this default?
 		PropagateResponse: false,
+		KeepaliveInterval: "",
+		KeepaliveURL:      "",
+		ForceRetryOn:      []string{},
 		Batching:          batch.NewPolicyConfig(),
 	}
 }
@@ -56,6 +71,9 @@ func NewHTTPClient(
 	log log.Modular,
 	stats metrics.Type,
 ) (*HTTPClient, error) {
+	if conf.KeepaliveInterval != "" && conf.KeepaliveURL == "" {
+		return nil, errors.New("a keepalive_url must be specified when keepalive_interval is set")
+	}
 	h := HTTPClient{
 		stats:     stats,
 		log:       log,
@@ -68,6 +86,9 @@ func NewHTTPClient(
 		client.OptSetCloseChan(h.closeChan),
 		client.OptSetLogger(h.log),
 		client.OptSetManager(mgr),
+		client.OptSetBatchFormat(conf.BatchFormat, conf.ResponseFormat),
+		client.OptSetMaxConcurrency(conf.MaxConcurrency, conf.QueueTimeout),
+		client.OptSetTerminalErrors(conf.ForceRetryOn),
 		// TODO: V4 Remove this
 		client.OptSetStats(metrics.Namespaced(h.stats, "client")),
 	); err != nil {
@@ -123,6 +144,15 @@ func (h *HTTPClient) WriteWithContext(ctx context.Context, msg types.Message) er
 	return err
 }
 
+// PingWithContext sends a HEAD request to the configured keepalive URL in order
+// to check that the target server is reachable.
+func (h *HTTPClient) PingWithContext(ctx context.Context) error {
+	if h.conf.KeepaliveURL == "" {
+		return nil
+	}
+	return h.client.PingWithContext(ctx, h.conf.KeepaliveURL)
+}
+
 // CloseAsync shuts down the HTTPClient output and stops processing messages.
 func (h *HTTPClient) CloseAsync() {
 	close(h.closeChan)
@@ -8,11 +8,13 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
@@ -36,6 +38,7 @@ func TestHTTPClientRetries(t *testing.T) {
 	conf.URL = ts.URL + "/testpost"
 	conf.Retry = "1ms"
 	conf.NumRetries = 3
+	conf.ForceRetryOn = []string{"403"}
 
 	h, err := NewHTTPClient(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
 	if err != nil {
@@ -56,6 +59,70 @@ func TestHTTPClientRetries(t *testing.T) {
 	}
 }
 
+func TestHTTPClientTerminalNotRetried(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Retry = "1ms"
+	conf.NumRetries = 3
+
+	h, err := NewHTTPClient(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = h.Write(message.New([][]byte{[]byte("test")}))
+	require.Error(t, err)
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(err))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&reqCount))
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second))
+}
+
+func TestHTTPClientErrorClassification(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		http.Error(w, "test error", code)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		code   int
+		dropOn []int
+		class  output.ErrorClass
+	}{
+		{code: http.StatusForbidden, class: output.ErrorClassTerminal},
+		{code: http.StatusRequestEntityTooLarge, class: output.ErrorClassTerminal},
+		{code: http.StatusTooManyRequests, class: output.ErrorClassThrottled},
+		{code: http.StatusServiceUnavailable, class: output.ErrorClassThrottled},
+		{code: http.StatusRequestTimeout, class: output.ErrorClassRetryable},
+		{code: http.StatusInternalServerError, class: output.ErrorClassRetryable},
+		{code: http.StatusInternalServerError, dropOn: []int{500}, class: output.ErrorClassTerminal},
+	}
+
+	for _, test := range tests {
+		conf := NewHTTPClientConfig()
+		conf.URL = ts.URL + "/" + strconv.Itoa(test.code)
+		conf.NumRetries = 0
+		conf.DropOn = test.dropOn
+
+		h, err := NewHTTPClient(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		err = h.Write(message.New([][]byte{[]byte("test")}))
+		require.Error(t, err)
+		assert.Equal(t, test.class, output.ClassifyError(err), test.code)
+
+		h.CloseAsync()
+		require.NoError(t, h.WaitForClose(time.Second))
+	}
+}
+
 func TestHTTPClientBasic(t *testing.T) {
 	nTestLoops := 1000
 
@@ -3,7 +3,9 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -11,8 +13,11 @@ import (
 	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -28,25 +33,27 @@ import (
 
 // KafkaConfig contains configuration fields for the Kafka output type.
 type KafkaConfig struct {
-	Addresses        []string    `json:"addresses" yaml:"addresses"`
-	ClientID         string      `json:"client_id" yaml:"client_id"`
-	Key              string      `json:"key" yaml:"key"`
-	Partitioner      string      `json:"partitioner" yaml:"partitioner"`
-	Topic            string      `json:"topic" yaml:"topic"`
-	Compression      string      `json:"compression" yaml:"compression"`
-	MaxMsgBytes      int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
-	Timeout          string      `json:"timeout" yaml:"timeout"`
-	AckReplicas      bool        `json:"ack_replicas" yaml:"ack_replicas"`
-	TargetVersion    string      `json:"target_version" yaml:"target_version"`
-	TLS              btls.Config `json:"tls" yaml:"tls"`
-	SASL             sasl.Config `json:"sasl" yaml:"sasl"`
-	MaxInFlight      int         `json:"max_in_flight" yaml:"max_in_flight"`
-	retries.Config   `json:",inline" yaml:",inline"`
-	RetryAsBatch     bool               `json:"retry_as_batch" yaml:"retry_as_batch"`
-	Batching         batch.PolicyConfig `json:"batching" yaml:"batching"`
-	StaticHeaders    map[string]string  `json:"static_headers" yaml:"static_headers"`
-	Metadata         output.Metadata    `json:"metadata" yaml:"metadata"`
-	InjectTracingMap string             `json:"inject_tracing_map" yaml:"inject_tracing_map"`
+	Addresses         []string    `json:"addresses" yaml:"addresses"`
+	ClientID          string      `json:"client_id" yaml:"client_id"`
+	Key               string      `json:"key" yaml:"key"`
+	Partitioner       string      `json:"partitioner" yaml:"partitioner"`
+	Topic             string      `json:"topic" yaml:"topic"`
+	Compression       string      `json:"compression" yaml:"compression"`
+	MaxMsgBytes       int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
+	Timeout           string      `json:"timeout" yaml:"timeout"`
+	AckReplicas       bool        `json:"ack_replicas" yaml:"ack_replicas"`
+	TargetVersion     string      `json:"target_version" yaml:"target_version"`
+	TLS               btls.Config `json:"tls" yaml:"tls"`
+	SASL              sasl.Config `json:"sasl" yaml:"sasl"`
+	MaxInFlight       int         `json:"max_in_flight" yaml:"max_in_flight"`
+	retries.Config    `json:",inline" yaml:",inline"`
+	RetryAsBatch      bool               `json:"retry_as_batch" yaml:"retry_as_batch"`
+	KeepaliveInterval string             `json:"keepalive_interval" yaml:"keepalive_interval"`
+	Batching          batch.PolicyConfig `json:"batching" yaml:"batching"`
+	StaticHeaders     map[string]string  `json:"static_headers" yaml:"static_headers"`
+	HeadersMapping    string             `json:"headers_mapping" yaml:"headers_mapping"`
+	Metadata          output.Metadata    `json:"metadata" yaml:"metadata"`
+	InjectTracingMap  string             `json:"inject_tracing_map" yaml:"inject_tracing_map"`
 
 	// TODO: V4 remove this.
 	RoundRobinPartitions bool `json:"round_robin_partitions" yaml:"round_robin_partitions"`
@@ -72,12 +79,14 @@ func NewKafkaConfig() KafkaConfig {
 		AckReplicas:          false,
 		TargetVersion:        sarama.V1_0_0_0.String(),
 		StaticHeaders:        map[string]string{},
+		HeadersMapping:       "",
 		Metadata:             output.NewMetadata(),
 		TLS:                  btls.NewConfig(),
 		SASL:                 sasl.NewConfig(),
 		MaxInFlight:          1,
 		Config:               rConf,
 		RetryAsBatch:         false,
+		KeepaliveInterval:    "",
 		Batching:             batch.NewPolicyConfig(),
 	}
 }
@@ -102,12 +111,14 @@ type Kafka struct {
 	key   *field.Expression
 	topic *field.Expression
 
+	client      sarama.Client
 	producer    sarama.SyncProducer
 	compression sarama.CompressionCodec
 	partitioner sarama.PartitionerConstructor
 
-	staticHeaders map[string]string
-	metaFilter    *output.MetadataFilter
+	staticHeaders  map[string]string
+	headersMapping *mapping.Executor
+	metaFilter     *output.MetadataFilter
 
 	connMut sync.RWMutex
 }
@@ -144,6 +155,12 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
 
+	if conf.HeadersMapping != "" {
+		if k.headersMapping, err = bloblang.NewMapping("", conf.HeadersMapping); err != nil {
+			return nil, fmt.Errorf("failed to parse headers mapping: %w", err)
+		}
+	}
+
 	if k.key, err = bloblang.NewField(conf.Key); err != nil {
 		return nil, fmt.Errorf("failed to parse key expression: %v", err)
 	}
@@ -258,6 +275,62 @@ func (k *Kafka) buildUserDefinedHeaders(staticHeaders map[string]string) []saram
 	return nil
 }
 
+// buildMappedHeaders executes the headers mapping against a message and returns
+// a header for each key of the resulting object. Byte values are written
+// unchanged and array values result in a header for each element, allowing
+// multiple headers with the same key.
+func (k *Kafka) buildMappedHeaders(index int, msg types.Message) ([]sarama.RecordHeader, error) {
+	if k.headersMapping == nil || !k.version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil, nil
+	}
+
+	res, err := k.headersMapping.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    index,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(index).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := res.(type) {
+	case query.Nothing, query.Delete:
+		return nil, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var out []sarama.RecordHeader
+		for _, key := range keys {
+			values, isArray := t[key].([]interface{})
+			if !isArray {
+				values = []interface{}{t[key]}
+			}
+			for _, v := range values {
+				if v == nil {
+					continue
+				}
+				out = append(out, sarama.RecordHeader{
+					Key:   []byte(key),
+					Value: query.IToBytes(v),
+				})
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("expected headers mapping to result in an object, got %v", query.ITypeOf(res))
+}
+
 //------------------------------------------------------------------------------
 
 // ConnectWithContext attempts to establish a connection to a Kafka broker.
@@ -299,13 +372,79 @@ func (k *Kafka) Connect() error {
 		config.Producer.RequiredAcks = sarama.WaitForLocal
 	}
 
-	var err error
-	k.producer, err = sarama.NewSyncProducer(k.addresses, config)
+	client, err := sarama.NewClient(k.addresses, config)
+	if err != nil {
+		return err
+	}
+	if k.producer, err = sarama.NewSyncProducerFromClient(client); err != nil {
+		client.Close()
+		return err
+	}
+	k.client = client
+
+	k.log.Infof("Sending Kafka messages to addresses: %s\n", k.addresses)
+	return nil
+}
+
+// PingWithContext checks that the Kafka brokers are reachable by refreshing
+// the cluster metadata of the client, and closes the connection if they are
+// not so that it can be reestablished.
+func (k *Kafka) PingWithContext(ctx context.Context) error {
+	k.connMut.RLock()
+	client := k.client
+	k.connMut.RUnlock()
 
-	if err == nil {
-		k.log.Infof("Sending Kafka messages to addresses: %s\n", k.addresses)
+	if client == nil {
+		return types.ErrNotConnected
+	}
+	if err := client.RefreshMetadata(); err != nil {
+		k.disconnect()
+		return err
+	}
+	return nil
+}
+
+// SelfTest checks that the Kafka brokers are reachable by fetching the cluster
+// metadata, and when the topic is static also checks that it has partitions
+// available.
+func (k *Kafka) SelfTest(ctx context.Context) error {
+	k.connMut.RLock()
+	client := k.client
+	k.connMut.RUnlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+	if k.topic.NumDynamicExpressions() > 0 {
+		return client.RefreshMetadata()
+	}
+	topic := k.topic.String(0, message.New(nil))
+	if err := client.RefreshMetadata(topic); err != nil {
+		return err
+	}
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("topic %v has no partitions", topic)
+	}
+	return nil
+}
+
+// disconnect closes the producer and client of the writer.
+func (k *Kafka) disconnect() {
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+
+	if k.producer != nil {
+		k.producer.Close()
+		k.producer = nil
+	}
+	if k.client != nil {
+		k.client.Close()
+		k.client = nil
 	}
-	return err
 }
 
 // Write will attempt to write a message to Kafka, wait for acknowledgement, and
@@ -329,12 +468,17 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 
 	userDefinedHeaders := k.buildUserDefinedHeaders(k.staticHeaders)
 	msgs := []*sarama.ProducerMessage{}
-	msg.Iter(func(i int, p types.Part) error {
+	if err := msg.Iter(func(i int, p types.Part) error {
+		mappedHeaders, err := k.buildMappedHeaders(i, msg)
+		if err != nil {
+			return fmt.Errorf("headers mapping failed: %w", err)
+		}
+		headers := append(k.buildSystemHeaders(p), userDefinedHeaders...)
 		key := k.key.Bytes(i, msg)
 		nextMsg := &sarama.ProducerMessage{
 			Topic:    k.topic.String(i, msg),
 			Value:    sarama.ByteEncoder(p.Get()),
-			Headers:  append(k.buildSystemHeaders(p), userDefinedHeaders...),
+			Headers:  append(headers, mappedHeaders...),
 			Metadata: i, // Store the original index for later reference.
 		}
 		if len(key) > 0 {
@@ -342,7 +486,15 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 		}
 		msgs = append(msgs, nextMsg)
 		return nil
-	})
+	}); err != nil {
+		// Executing the mapping again won't yield a different result.
+		return output.NewTerminalError(err)
+	}
+
+	// Messages that failed with terminal errors are not produced again, and are
+	// reported once the remaining messages have either been sent or have run
+	// out of attempts.
+	var terminalErr *batchInternal.Error
 
 	err := producer.SendMessages(msgs)
 	for err != nil {
@@ -350,15 +502,34 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 			if len(pErrs) == 0 {
 				break
 			}
-			batchErr := batchInternal.NewError(msg, pErrs[0].Err)
+			batchErr := batchInternal.NewError(msg, classifyKafkaError(pErrs[0].Err))
 			msgs = nil
 			for _, pErr := range pErrs {
-				if mIndex, ok := pErr.Msg.Metadata.(int); ok {
-					batchErr.Failed(mIndex, pErr.Err)
+				pErrClassified := classifyKafkaError(pErr.Err)
+				mIndex, ok := pErr.Msg.Metadata.(int)
+				if !ok {
+					msgs = append(msgs, pErr.Msg)
+					continue
+				}
+				batchErr.Failed(mIndex, pErrClassified)
+				if output.ClassifyError(pErrClassified) == output.ErrorClassTerminal {
+					if terminalErr == nil {
+						terminalErr = batchInternal.NewError(msg, pErrClassified)
+					}
+					terminalErr.Failed(mIndex, pErrClassified)
+					continue
 				}
 				msgs = append(msgs, pErr.Msg)
 			}
 			if len(pErrs) == batchErr.IndexedErrors() {
+				if terminalErr != nil {
+					terminalErr.WalkParts(func(i int, _ types.Part, tErr error) bool {
+						if tErr != nil {
+							batchErr.Failed(i, tErr)
+						}
+						return true
+					})
+				}
 				err = batchErr
 			} else {
 				// If these lengths don't match then somehow we failed to obtain
@@ -368,9 +539,15 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 			}
 			k.log.Errorf("Failed to send '%v' messages: %v\n", len(pErrs), err)
 		} else {
+			err = classifyKafkaError(err)
 			k.log.Errorf("Failed to send messages: %v\n", err)
 		}
 
+		// Sending the same messages again won't resolve terminal errors.
+		if output.ClassifyError(err) == output.ErrorClassTerminal {
+			return err
+		}
+
 		tNext := boff.NextBackOff()
 		if tNext == backoff.Stop {
 			return err
@@ -392,19 +569,51 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 		err = producer.SendMessages(msgs)
 	}
 
+	if terminalErr != nil {
+		return terminalErr
+	}
 	return nil
 }
 
+// classifyKafkaError marks errors returned by brokers that will not be resolved
+// by producing the same messages again as terminal. Producer errors are only
+// terminal when all of the messages failed with terminal errors.
+func classifyKafkaError(err error) error {
+	if pErrs, ok := err.(sarama.ProducerErrors); ok {
+		for _, pErr := range pErrs {
+			if output.ClassifyError(classifyKafkaError(pErr.Err)) != output.ErrorClassTerminal {
+				return err
+			}
+		}
+		if len(pErrs) == 0 {
+			return err
+		}
+		return output.NewTerminalError(err)
+	}
+	var kErr sarama.KError
+	if !errors.As(err, &kErr) {
+		return err
+	}
+	switch kErr {
+	case sarama.ErrInvalidMessage,
+		sarama.ErrInvalidMessageSize,
+		sarama.ErrMessageSizeTooLarge,
+		sarama.ErrInvalidTopic,
+		sarama.ErrInvalidRequiredAcks,
+		sarama.ErrTopicAuthorizationFailed,
+		sarama.ErrClusterAuthorizationFailed,
+		sarama.ErrUnsupportedForMessageFormat,
+		sarama.ErrPolicyViolation,
+		sarama.ErrTransactionalIDAuthorizationFailed,
+		sarama.ErrInvalidRecord:
+		return output.NewTerminalError(err)
+	}
+	return err
+}
+
 // CloseAsync shuts down the Kafka writer and stops processing messages.
 func (k *Kafka) CloseAsync() {
-	go func() {
-		k.connMut.Lock()
-		if k.producer != nil {
-			k.producer.Close()
-			k.producer = nil
-		}
-		k.connMut.Unlock()
-	}()
+	go k.disconnect()
 }
 
 // WaitForClose blocks until the Kafka writer has closed down.
@@ -0,0 +1,192 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKafka(t *testing.T, maxRetries uint64) (*Kafka, *mocks.SyncProducer) {
+	t.Helper()
+
+	conf := NewKafkaConfig()
+	conf.Topic = "foo"
+	conf.MaxRetries = maxRetries
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	k.producer = producer
+	t.Cleanup(func() {
+		assert.NoError(t, producer.Close())
+	})
+	return k, producer
+}
+
+func TestKafkaWriteRetriesFailedSends(t *testing.T) {
+	k, producer := newTestKafka(t, 1)
+
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	producer.ExpectSendMessageAndSucceed()
+
+	// The whole batch is sent again after the failure.
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	require.NoError(t, k.WriteWithContext(context.Background(), msg))
+}
+
+func TestKafkaWriteFailsWithoutAck(t *testing.T) {
+	k, producer := newTestKafka(t, 1)
+
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	producer.ExpectSendMessageAndSucceed()
+
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+
+	// When the broker never acknowledges the batch the write must fail so
+	// that the batch is redelivered upstream.
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	assert.EqualError(t, k.WriteWithContext(context.Background(), msg), sarama.ErrOutOfBrokers.Error())
+}
+
+func TestKafkaWriteTerminalErrors(t *testing.T) {
+	k, producer := newTestKafka(t, 3)
+
+	// Terminal errors are returned without sending the batch again.
+	producer.ExpectSendMessageAndFail(sarama.ErrMessageSizeTooLarge)
+
+	err := k.WriteWithContext(context.Background(), message.New([][]byte{[]byte("foo")}))
+	require.Error(t, err)
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(err))
+
+	assert.Equal(t, output.ErrorClassRetryable, output.ClassifyError(classifyKafkaError(sarama.ErrOutOfBrokers)))
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(classifyKafkaError(sarama.ErrTopicAuthorizationFailed)))
+}
+
+type fnSyncProducer struct {
+	sarama.SyncProducer
+	sendMessages func([]*sarama.ProducerMessage) error
+}
+
+func (f *fnSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	return f.sendMessages(msgs)
+}
+
+func TestKafkaWriteTerminalMessagesNotRetried(t *testing.T) {
+	k, _ := newTestKafka(t, 3)
+
+	var attempts [][]string
+	k.producer = &fnSyncProducer{
+		sendMessages: func(msgs []*sarama.ProducerMessage) error {
+			var values []string
+			var pErrs sarama.ProducerErrors
+			for _, m := range msgs {
+				v, _ := m.Value.Encode()
+				values = append(values, string(v))
+				switch {
+				case string(v) == "bar":
+					pErrs = append(pErrs, &sarama.ProducerError{Msg: m, Err: sarama.ErrMessageSizeTooLarge})
+				case string(v) == "baz" && len(attempts) == 0:
+					pErrs = append(pErrs, &sarama.ProducerError{Msg: m, Err: sarama.ErrOutOfBrokers})
+				}
+			}
+			attempts = append(attempts, values)
+			if len(pErrs) > 0 {
+				return pErrs
+			}
+			return nil
+		},
+	}
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	err := k.WriteWithContext(context.Background(), msg)
+	require.Error(t, err)
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(err))
+
+	// Only the message that failed with a retryable error is sent again, and
+	// only the message that failed with a terminal error is reported.
+	assert.Equal(t, [][]string{{"foo", "bar", "baz"}, {"baz"}}, attempts)
+
+	var failed []int
+	var bErr *batchInternal.Error
+	require.True(t, errors.As(err, &bErr))
+	bErr.WalkParts(func(i int, _ types.Part, pErr error) bool {
+		if pErr != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+}
+
+func TestKafkaHeadersMapping(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.HeadersMapping = `
+root.trace_id = meta("trace").decode("hex")
+root.tag = this.tags
+root.count = this.tags.length()
+root.skipped = null
+`
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`{"tags":["foo","bar"]}`)})
+	msg.Get(0).Metadata().Set("trace", "00ff10")
+
+	headers, err := k.buildMappedHeaders(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("count"), Value: []byte("2")},
+		{Key: []byte("tag"), Value: []byte("foo")},
+		{Key: []byte("tag"), Value: []byte("bar")},
+		{Key: []byte("trace_id"), Value: []byte{0x00, 0xff, 0x10}},
+	}, headers)
+}
+
+func TestKafkaHeadersMappingErrors(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.HeadersMapping = `root = this.headers`
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = k.buildMappedHeaders(0, message.New([][]byte{[]byte(`{"headers":"nope"}`)}))
+	assert.EqualError(t, err, "expected headers mapping to result in an object, got string")
+
+	producer := mocks.NewSyncProducer(t, nil)
+	k.producer = producer
+	t.Cleanup(func() {
+		assert.NoError(t, producer.Close())
+	})
+
+	// Messages are not sent when the mapping fails, and the error is terminal.
+	err = k.WriteWithContext(context.Background(), message.New([][]byte{[]byte(`not json`)}))
+	require.Error(t, err)
+	assert.Equal(t, output.ErrorClassTerminal, output.ClassifyError(err))
+
+	conf.HeadersMapping = `root = `
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -2,16 +2,21 @@ package writer
 
 import (
 	"context"
+	"crypto/md5"
 	"errors"
 	"fmt"
+	"math/big"
+	"sync"
 	"time"
 
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/aws/aws-sdk-go/aws"
@@ -38,6 +43,7 @@ type KinesisConfig struct {
 	Stream         string `json:"stream" yaml:"stream"`
 	HashKey        string `json:"hash_key" yaml:"hash_key"`
 	PartitionKey   string `json:"partition_key" yaml:"partition_key"`
+	GroupBy        string `json:"group_by" yaml:"group_by"`
 	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
 	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
@@ -56,6 +62,7 @@ func NewKinesisConfig() KinesisConfig {
 		Stream:       "",
 		HashKey:      "",
 		PartitionKey: "",
+		GroupBy:      "none",
 		MaxInFlight:  1,
 		Config:       rConf,
 		Batching:     batch.NewPolicyConfig(),
@@ -80,10 +87,15 @@ type Kinesis struct {
 	log   log.Modular
 	stats metrics.Type
 
+	shardsMut   sync.Mutex
+	shards      []kinesisShard
+	shardsStale bool
+
 	mThrottled       metrics.StatCounter
 	mThrottledF      metrics.StatCounter
 	mPartsThrottled  metrics.StatCounter
 	mPartsThrottledF metrics.StatCounter
+	mShardThrottled  metrics.StatCounterVec
 }
 
 // NewKinesis creates a new Amazon Kinesis writer.Type.
@@ -102,8 +114,14 @@ func NewKinesis(
 		stats:           stats,
 		mPartsThrottled: stats.GetCounter("parts.send.throttled"),
 		mThrottled:      stats.GetCounter("send.throttled"),
+		mShardThrottled: stats.GetCounterVec("shard.send.throttled", []string{"shard"}),
 		streamName:      aws.String(conf.Stream),
 	}
+	switch conf.GroupBy {
+	case "", "none", "partition_key", "shard":
+	default:
+		return nil, fmt.Errorf("group_by option not recognised: %v", conf.GroupBy)
+	}
 	var err error
 	if k.hashKey, err = bloblang.NewField(conf.HashKey); err != nil {
 		return nil, fmt.Errorf("failed to parse hash key expression: %v", err)
@@ -164,13 +182,15 @@ func (a *Kinesis) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	a.session = sess
-	a.kinesis = kinesis.New(sess)
+	client := kinesis.New(sess)
+	instrument.Register(&client.Handlers, a.stats)
+	a.kinesis = client
 
 	if err := a.kinesis.WaitUntilStreamExists(&kinesis.DescribeStreamInput{
 		StreamName: a.streamName,
@@ -178,10 +198,143 @@ func (a *Kinesis) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
+	if a.conf.GroupBy == "shard" {
+		if err := a.refreshShards(ctx); err != nil {
+			return err
+		}
+	}
+
 	a.log.Infof("Sending messages to Kinesis stream: %v\n", a.conf.Stream)
 	return nil
 }
 
+//------------------------------------------------------------------------------
+
+// kinesisShard describes the range of hash keys that an open shard of a stream
+// is responsible for.
+type kinesisShard struct {
+	id         string
+	start, end *big.Int
+}
+
+// refreshShards lists the open shards of the stream in order to determine the
+// shard that each record is written to.
+func (a *Kinesis) refreshShards(ctx context.Context) error {
+	var shards []kinesisShard
+	input := &kinesis.ListShardsInput{
+		StreamName: a.streamName,
+	}
+	for {
+		output, err := a.kinesis.ListShardsWithContext(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list shards: %w", err)
+		}
+		for _, s := range output.Shards {
+			// Closed shards no longer accept records.
+			if s.SequenceNumberRange != nil && s.SequenceNumberRange.EndingSequenceNumber != nil {
+				continue
+			}
+			start, ok := new(big.Int).SetString(aws.StringValue(s.HashKeyRange.StartingHashKey), 10)
+			if !ok {
+				return fmt.Errorf("failed to parse starting hash key of shard %v", aws.StringValue(s.ShardId))
+			}
+			end, ok := new(big.Int).SetString(aws.StringValue(s.HashKeyRange.EndingHashKey), 10)
+			if !ok {
+				return fmt.Errorf("failed to parse ending hash key of shard %v", aws.StringValue(s.ShardId))
+			}
+			shards = append(shards, kinesisShard{
+				id:    aws.StringValue(s.ShardId),
+				start: start,
+				end:   end,
+			})
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input = &kinesis.ListShardsInput{
+			NextToken: output.NextToken,
+		}
+	}
+
+	a.shardsMut.Lock()
+	a.shards = shards
+	a.shardsStale = false
+	a.shardsMut.Unlock()
+	return nil
+}
+
+// shardFor returns the ID of the shard that a record is written to, which is
+// determined either by the explicit hash key of the record or the MD5 hash of
+// its partition key. An empty string is returned if the shard is unknown.
+func (a *Kinesis) shardFor(entry *kinesis.PutRecordsRequestEntry) string {
+	var hashKey *big.Int
+	if entry.ExplicitHashKey != nil {
+		var ok bool
+		if hashKey, ok = new(big.Int).SetString(*entry.ExplicitHashKey, 10); !ok {
+			return ""
+		}
+	} else {
+		sum := md5.Sum([]byte(aws.StringValue(entry.PartitionKey)))
+		hashKey = new(big.Int).SetBytes(sum[:])
+	}
+
+	a.shardsMut.Lock()
+	defer a.shardsMut.Unlock()
+	for _, s := range a.shards {
+		if hashKey.Cmp(s.start) >= 0 && hashKey.Cmp(s.end) <= 0 {
+			return s.id
+		}
+	}
+	return ""
+}
+
+// kinesisRecord is a record to be sent along with the index of the message it
+// was created from and the shard it is expected to be written to.
+type kinesisRecord struct {
+	index int
+	shard string
+	entry *kinesis.PutRecordsRequestEntry
+}
+
+// groupRecords splits records into groups that are sent independently
+// according to the group_by field, preserving the order of records within each
+// group.
+func (a *Kinesis) groupRecords(entries []*kinesis.PutRecordsRequestEntry) [][]kinesisRecord {
+	records := make([]kinesisRecord, len(entries))
+	for i, e := range entries {
+		records[i] = kinesisRecord{index: i, entry: e}
+	}
+
+	var keyFn func(r *kinesisRecord) string
+	switch a.conf.GroupBy {
+	case "partition_key":
+		keyFn = func(r *kinesisRecord) string {
+			return aws.StringValue(r.entry.PartitionKey)
+		}
+	case "shard":
+		keyFn = func(r *kinesisRecord) string {
+			r.shard = a.shardFor(r.entry)
+			return r.shard
+		}
+	default:
+		return [][]kinesisRecord{records}
+	}
+
+	var groups [][]kinesisRecord
+	groupIndexes := map[string]int{}
+	for _, r := range records {
+		key := keyFn(&r)
+		i, exists := groupIndexes[key]
+		if !exists {
+			i = len(groups)
+			groupIndexes[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
 // Write attempts to write message contents to a target Kinesis stream in
 // batches of 500. If throttling is detected, failed messages are retried
 // according to the configurable backoff settings.
@@ -192,84 +345,193 @@ func (a *Kinesis) Write(msg types.Message) error {
 // WriteWithContext attempts to write message contents to a target Kinesis
 // stream in batches of 500. If throttling is detected, failed messages are
 // retried according to the configurable backoff settings.
+//
+// When records are grouped each group is sent independently, such that the
+// throttling of one shard does not delay the records of other shards.
 func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error {
 	if a.session == nil {
 		return types.ErrNotConnected
 	}
 
-	backOff := a.backoffCtor()
-
 	records, err := a.toRecords(msg)
 	if err != nil {
 		return err
 	}
 
-	input := &kinesis.PutRecordsInput{
-		Records:    records,
-		StreamName: a.streamName,
+	if a.conf.GroupBy == "shard" {
+		a.shardsMut.Lock()
+		stale := a.shardsStale
+		a.shardsMut.Unlock()
+		if stale {
+			if err := a.refreshShards(ctx); err != nil {
+				a.log.Warnf("Failed to refresh shards: %v\n", err)
+			}
+		}
 	}
 
+	var batchErrMut sync.Mutex
+	var batchErr *ibatch.Error
+	failed := func(i int, err error) {
+		batchErrMut.Lock()
+		if batchErr == nil {
+			batchErr = ibatch.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+		batchErrMut.Unlock()
+	}
+
+	groups := a.groupRecords(records)
+	if len(groups) == 1 {
+		a.sendRecords(ctx, groups[0], failed)
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(len(groups))
+		for _, g := range groups {
+			go func(g []kinesisRecord) {
+				defer wg.Done()
+				a.sendRecords(ctx, g, failed)
+			}(g)
+		}
+		wg.Wait()
+	}
+
+	if batchErr == nil {
+		return nil
+	}
+	if msg.Len() == 1 {
+		return batchErr.Unwrap()
+	}
+	return batchErr
+}
+
+func kinesisErrIsThrottling(code string) bool {
+	return code == kinesis.ErrCodeProvisionedThroughputExceededException ||
+		code == kinesis.ErrCodeKMSThrottlingException
+}
+
+// sendRecords writes records to the stream in batches of 500, retrying only
+// the records that were throttled. Records that fail are reported with the
+// failed closure.
+func (a *Kinesis) sendRecords(ctx context.Context, records []kinesisRecord, failed func(int, error)) {
+	backOff := a.backoffCtor()
+
+	var pending []kinesisRecord
+
 	// trim input record length to max kinesis batch size
 	if len(records) > kinesisMaxRecordsCount {
-		input.Records, records = records[:kinesisMaxRecordsCount], records[kinesisMaxRecordsCount:]
+		pending, records = records[:kinesisMaxRecordsCount], records[kinesisMaxRecordsCount:]
 	} else {
-		records = nil
+		pending, records = records, nil
+	}
+
+	failAll := func(err error) {
+		for _, r := range pending {
+			failed(r.index, err)
+		}
+		for _, r := range records {
+			failed(r.index, err)
+		}
+	}
+
+	wait := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-ctx.Done():
+			failAll(types.ErrTimeout)
+			return false
+		}
 	}
 
-	var failed []*kinesis.PutRecordsRequestEntry
 	backOff.Reset()
-	for len(input.Records) > 0 {
-		wait := backOff.NextBackOff()
+	for len(pending) > 0 {
+		input := &kinesis.PutRecordsInput{
+			Records:    make([]*kinesis.PutRecordsRequestEntry, len(pending)),
+			StreamName: a.streamName,
+		}
+		for i, r := range pending {
+			input.Records[i] = r.entry
+		}
+
+		backoffDur := backOff.NextBackOff()
 
 		// batch write to kinesis
 		output, err := a.kinesis.PutRecords(input)
 		if err != nil {
 			a.log.Warnf("kinesis error: %v\n", err)
 			// bail if a message is too large or all retry attempts expired
-			if wait == backoff.Stop {
-				return err
+			if backoffDur == backoff.Stop {
+				failAll(err)
+				return
+			}
+			if !wait(backoffDur) {
+				return
 			}
 			continue
 		}
 
 		// requeue any individual records that failed due to throttling
-		failed = nil
+		var throttled []kinesisRecord
 		if output.FailedRecordCount != nil {
 			for i, entry := range output.Records {
-				if entry.ErrorCode != nil {
-					failed = append(failed, input.Records[i])
-					if *entry.ErrorCode != kinesis.ErrCodeProvisionedThroughputExceededException && *entry.ErrorCode != kinesis.ErrCodeKMSThrottlingException {
-						err = fmt.Errorf("record failed with code [%s] %s: %+v", *entry.ErrorCode, *entry.ErrorMessage, input.Records[i])
-						a.log.Errorf("kinesis record error: %v\n", err)
-						return err
+				if i >= len(pending) {
+					break
+				}
+				r := pending[i]
+				if entry.ErrorCode == nil {
+					if r.shard != "" && entry.ShardId != nil && *entry.ShardId != r.shard {
+						// The stream has been resharded since the shards
+						// were last listed.
+						a.shardsMut.Lock()
+						a.shardsStale = true
+						a.shardsMut.Unlock()
+					}
+					continue
+				}
+				if !kinesisErrIsThrottling(*entry.ErrorCode) {
+					err = fmt.Errorf("record failed with code [%s] %s", *entry.ErrorCode, aws.StringValue(entry.ErrorMessage))
+					a.log.Errorf("kinesis record error: %v\n", err)
+					failed(r.index, err)
+					continue
+				}
+				throttled = append(throttled, r)
+				if a.mShardThrottled != nil {
+					shard := aws.StringValue(entry.ShardId)
+					if shard == "" {
+						shard = r.shard
+					}
+					if shard != "" {
+						a.mShardThrottled.With(shard).Incr(1)
 					}
 				}
 			}
 		}
-		input.Records = failed
+		pending = throttled
 
 		// if throttling errors detected, pause briefly
-		l := len(failed)
+		l := len(pending)
 		if l > 0 {
 			a.mThrottled.Incr(1)
 			a.mPartsThrottled.Incr(int64(l))
 			a.log.Warnf("scheduling retry of throttled records (%d)\n", l)
-			if wait == backoff.Stop {
-				return types.ErrTimeout
+			if backoffDur == backoff.Stop {
+				failAll(types.ErrTimeout)
+				return
+			}
+			if !wait(backoffDur) {
+				return
 			}
-			time.Sleep(wait)
 		}
 
 		// add remaining records to batch
 		if n := len(records); n > 0 && l < kinesisMaxRecordsCount {
 			if remaining := kinesisMaxRecordsCount - l; remaining < n {
-				input.Records, records = append(input.Records, records[:remaining]...), records[remaining:]
+				pending, records = append(pending, records[:remaining]...), records[remaining:]
 			} else {
-				input.Records, records = append(input.Records, records...), nil
+				pending, records = append(pending, records...), nil
 			}
 		}
 	}
-	return err
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
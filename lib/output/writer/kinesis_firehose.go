@@ -9,6 +9,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/aws/aws-sdk-go/aws"
@@ -133,13 +134,15 @@ func (a *KinesisFirehose) Connect() error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	a.session = sess
-	a.firehose = firehose.New(sess)
+	client := firehose.New(sess)
+	instrument.Register(&client.Handlers, a.stats)
+	a.firehose = client
 
 	if _, err := a.firehose.DescribeDeliveryStream(&firehose.DescribeDeliveryStreamInput{
 		DeliveryStreamName: a.streamName,
@@ -1,20 +1,27 @@
 package writer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -30,13 +37,18 @@ var (
 
 type mockKinesis struct {
 	kinesisiface.KinesisAPI
-	fn func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+	fn         func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+	listShards func(input *kinesis.ListShardsInput) (*kinesis.ListShardsOutput, error)
 }
 
 func (m *mockKinesis) PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
 	return m.fn(input)
 }
 
+func (m *mockKinesis) ListShardsWithContext(ctx aws.Context, input *kinesis.ListShardsInput, opts ...request.Option) (*kinesis.ListShardsOutput, error) {
+	return m.listShards(input)
+}
+
 func TestKinesisWriteSinglePartMessage(t *testing.T) {
 	k := Kinesis{
 		backoffCtor: func() backoff.BackOff {
@@ -357,3 +369,192 @@ func TestKinesisWriteBackoffMaxRetriesExceeded(t *testing.T) {
 		t.Errorf("Expected kinesis.PutRecords to have call count %d, got %d", exp, calls)
 	}
 }
+
+func TestKinesisWriteGroupByShard(t *testing.T) {
+	t.Parallel()
+
+	// Hash keys of each shard, where the second shard starts at 2^127.
+	const (
+		hashA = "1"
+		hashB = "170141183460469231731687303715884105729"
+	)
+
+	var listCalls int
+	var mut sync.Mutex
+	calls := map[string][][]string{}
+
+	mock := &mockKinesis{
+		listShards: func(input *kinesis.ListShardsInput) (*kinesis.ListShardsOutput, error) {
+			mut.Lock()
+			listCalls++
+			mut.Unlock()
+			if input.NextToken == nil {
+				return &kinesis.ListShardsOutput{
+					NextToken: aws.String("next"),
+					Shards: []*kinesis.Shard{
+						{
+							ShardId: aws.String("closed"),
+							HashKeyRange: &kinesis.HashKeyRange{
+								StartingHashKey: aws.String("0"),
+								EndingHashKey:   aws.String("340282366920938463463374607431768211455"),
+							},
+							SequenceNumberRange: &kinesis.SequenceNumberRange{
+								EndingSequenceNumber: aws.String("10"),
+							},
+						},
+						{
+							ShardId: aws.String("shard-a"),
+							HashKeyRange: &kinesis.HashKeyRange{
+								StartingHashKey: aws.String("0"),
+								EndingHashKey:   aws.String("170141183460469231731687303715884105727"),
+							},
+							SequenceNumberRange: &kinesis.SequenceNumberRange{},
+						},
+					},
+				}, nil
+			}
+			return &kinesis.ListShardsOutput{
+				Shards: []*kinesis.Shard{
+					{
+						ShardId: aws.String("shard-b"),
+						HashKeyRange: &kinesis.HashKeyRange{
+							StartingHashKey: aws.String("170141183460469231731687303715884105728"),
+							EndingHashKey:   aws.String("340282366920938463463374607431768211455"),
+						},
+						SequenceNumberRange: &kinesis.SequenceNumberRange{},
+					},
+				},
+			}, nil
+		},
+		fn: func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+			mut.Lock()
+			defer mut.Unlock()
+
+			shard := "shard-a"
+			if *input.Records[0].ExplicitHashKey == hashB {
+				shard = "shard-b"
+			}
+
+			var data []string
+			for _, r := range input.Records {
+				require.Equal(t, *input.Records[0].ExplicitHashKey, *r.ExplicitHashKey)
+				data = append(data, string(r.Data))
+			}
+			calls[shard] = append(calls[shard], data)
+
+			var output kinesis.PutRecordsOutput
+			var failed int64
+			for _, r := range input.Records {
+				entry := &kinesis.PutRecordsResultEntry{}
+				switch {
+				case string(r.Data) == "bad":
+					failed++
+					entry.SetErrorCode(kinesis.ErrCodeInvalidArgumentException)
+					entry.SetErrorMessage("nope")
+				case shard == "shard-b" && len(calls[shard]) == 1:
+					failed++
+					entry.SetErrorCode(kinesis.ErrCodeProvisionedThroughputExceededException)
+				case shard == "shard-a":
+					// Records landing on an unexpected shard indicate a
+					// reshard.
+					entry.SetShardId("shard-c")
+				default:
+					entry.SetShardId(shard)
+				}
+				output.Records = append(output.Records, entry)
+			}
+			output.SetFailedRecordCount(failed)
+			return &output, nil
+		},
+	}
+
+	conf := NewKinesisConfig()
+	conf.PartitionKey = "foo"
+	conf.HashKey = `${! meta("hash") }`
+	conf.GroupBy = "shard"
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	stats := metrics.NewLocal()
+	k, err := NewKinesis(conf, log.Noop(), stats)
+	require.NoError(t, err)
+
+	k.session = session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+	}))
+	k.kinesis = mock
+	require.NoError(t, k.refreshShards(context.Background()))
+
+	msg := message.New(nil)
+	for i, d := range []string{"a0", "b0", "a1", "b1", "bad", "b2"} {
+		part := message.NewPart([]byte(d))
+		if i%2 == 0 {
+			part.Metadata().Set("hash", hashA)
+		} else {
+			part.Metadata().Set("hash", hashB)
+		}
+		msg.Append(part)
+	}
+
+	err = k.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok, err.Error())
+	assert.Equal(t, 1, bErr.IndexedErrors())
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if i == 4 {
+			assert.EqualError(t, err, "record failed with code [InvalidArgumentException] nope")
+		} else {
+			assert.NoError(t, err, i)
+		}
+		return true
+	})
+
+	mut.Lock()
+	assert.Equal(t, map[string][][]string{
+		"shard-a": {{"a0", "a1", "bad"}},
+		"shard-b": {{"b0", "b1", "b2"}, {"b0", "b1", "b2"}},
+	}, calls)
+	assert.Equal(t, 2, listCalls)
+	mut.Unlock()
+
+	assert.Equal(t, int64(3), stats.GetCounters()["shard.send.throttled"])
+
+	// The shards are listed again after records landed on an unexpected shard.
+	msg = message.New([][]byte{[]byte("a2")})
+	msg.Get(0).Metadata().Set("hash", hashA)
+	require.NoError(t, k.Write(msg))
+
+	mut.Lock()
+	assert.Equal(t, 4, listCalls)
+	mut.Unlock()
+}
+
+func TestKinesisGroupByPartitionKey(t *testing.T) {
+	conf := NewKinesisConfig()
+	conf.PartitionKey = `${! content() }`
+	conf.GroupBy = "partition_key"
+
+	k, err := NewKinesis(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	records, err := k.toRecords(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("foo"), []byte("baz"), []byte("bar"),
+	}))
+	require.NoError(t, err)
+
+	var groups [][]int
+	for _, g := range k.groupRecords(records) {
+		var indexes []int
+		for _, r := range g {
+			indexes = append(indexes, r.index)
+		}
+		groups = append(groups, indexes)
+	}
+	assert.Equal(t, [][]int{{0, 2}, {1, 4}, {3}}, groups)
+
+	conf.GroupBy = "nope"
+	_, err = NewKinesis(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
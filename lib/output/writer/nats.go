@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
@@ -26,6 +27,7 @@ type NATSConfig struct {
 	Subject     string      `json:"subject" yaml:"subject"`
 	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
 	TLS         btls.Config `json:"tls" yaml:"tls"`
+	Auth        auth.Config `json:"auth" yaml:"auth"`
 }
 
 // NewNATSConfig creates a new NATSConfig with default values.
@@ -35,6 +37,7 @@ func NewNATSConfig() NATSConfig {
 		Subject:     "benthos_messages",
 		MaxInFlight: 1,
 		TLS:         btls.NewConfig(),
+		Auth:        auth.NewConfig(),
 	}
 }
 
@@ -51,6 +54,7 @@ type NATS struct {
 	conf       NATSConfig
 	subjectStr *field.Expression
 	tlsConf    *tls.Config
+	authOpts   []nats.Option
 }
 
 // NewNATS creates a new NATS output type.
@@ -70,6 +74,9 @@ func NewNATS(conf NATSConfig, log log.Modular, stats metrics.Type) (*NATS, error
 			return nil, err
 		}
 	}
+	if n.authOpts, err = conf.Auth.Options(); err != nil {
+		return nil, err
+	}
 
 	return &n, nil
 }
@@ -96,6 +103,7 @@ func (n *NATS) Connect() error {
 	if n.tlsConf != nil {
 		opts = append(opts, nats.Secure(n.tlsConf))
 	}
+	opts = append(opts, n.authOpts...)
 
 	if n.natsConn, err = nats.Connect(n.urls, opts...); err != nil {
 		return err
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/impl/nats/auth"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/nats-io/nats.go"
 
@@ -29,6 +30,7 @@ type NATSStreamConfig struct {
 	Subject     string      `json:"subject" yaml:"subject"`
 	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
 	TLS         btls.Config `json:"tls" yaml:"tls"`
+	Auth        auth.Config `json:"auth" yaml:"auth"`
 }
 
 // NewNATSStreamConfig creates a new NATSStreamConfig with default values.
@@ -40,6 +42,7 @@ func NewNATSStreamConfig() NATSStreamConfig {
 		Subject:     "benthos_messages",
 		MaxInFlight: 1,
 		TLS:         btls.NewConfig(),
+		Auth:        auth.NewConfig(),
 	}
 }
 
@@ -53,9 +56,10 @@ type NATSStream struct {
 	natsConn *nats.Conn
 	connMut  sync.RWMutex
 
-	urls    string
-	conf    NATSStreamConfig
-	tlsConf *tls.Config
+	urls     string
+	conf     NATSStreamConfig
+	tlsConf  *tls.Config
+	authOpts []nats.Option
 }
 
 // NewNATSStream creates a new NATS Stream output type.
@@ -80,6 +84,9 @@ func NewNATSStream(conf NATSStreamConfig, log log.Modular, stats metrics.Type) (
 			return nil, err
 		}
 	}
+	if n.authOpts, err = conf.Auth.Options(); err != nil {
+		return nil, err
+	}
 
 	return &n, nil
 }
@@ -104,6 +111,7 @@ func (n *NATSStream) Connect() error {
 	if n.tlsConf != nil {
 		opts = append(opts, nats.Secure(n.tlsConf))
 	}
+	opts = append(opts, n.authOpts...)
 
 	natsConn, err := nats.Connect(n.urls, opts...)
 	if err != nil {
@@ -171,7 +171,7 @@ func (r *RedisHash) Write(msg types.Message) error {
 			}
 		}
 		for k, v := range r.fields {
-			fields[k] = v.String(i, msg)
+			fields[k] = v.Bytes(i, msg)
 		}
 		if err := client.HMSet(key, fields).Err(); err != nil {
 			r.disconnect()
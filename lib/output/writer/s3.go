@@ -16,9 +16,12 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
@@ -153,7 +156,7 @@ func (a *AmazonS3) Connect() error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession(func(c *aws.Config) {
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")), func(c *aws.Config) {
 		c.S3ForcePathStyle = aws.Bool(a.conf.ForcePathStyleURLs)
 	})
 	if err != nil {
@@ -161,12 +164,26 @@ func (a *AmazonS3) Connect() error {
 	}
 
 	a.session = sess
-	a.uploader = s3manager.NewUploader(sess)
+	client := s3.New(sess)
+	instrument.Register(&client.Handlers, a.stats)
+	a.uploader = s3manager.NewUploaderWithClient(client)
 
 	a.log.Infof("Uploading message parts as objects to Amazon S3 bucket: %v\n", a.conf.Bucket)
 	return nil
 }
 
+// SelfTest checks that the target bucket exists and that the credentials of
+// the writer are permitted to access it.
+func (a *AmazonS3) SelfTest(ctx context.Context) error {
+	if a.session == nil {
+		return types.ErrNotConnected
+	}
+	_, err := s3.New(a.session).HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(a.conf.Bucket),
+	})
+	return err
+}
+
 // Write attempts to write message contents to a target S3 bucket as files.
 func (a *AmazonS3) Write(msg types.Message) error {
 	return a.WriteWithContext(context.Background(), msg)
@@ -221,12 +238,45 @@ func (a *AmazonS3) WriteWithContext(wctx context.Context, msg types.Message) err
 		}
 
 		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
-			return err
+			return classifyS3Error(err)
 		}
 		return nil
 	})
 }
 
+// classifyS3Error marks errors returned from an upload that are caused by
+// throttling as throttled, and errors caused by the request itself, such as
+// missing permissions or a payload that is too large, as terminal.
+func classifyS3Error(err error) error {
+	var code string
+	var status int
+	for e := err; e != nil; {
+		if rErr, ok := e.(awserr.RequestFailure); ok {
+			code, status = rErr.Code(), rErr.StatusCode()
+			break
+		}
+		aErr, ok := e.(awserr.Error)
+		if !ok {
+			break
+		}
+		e = aErr.OrigErr()
+	}
+
+	switch code {
+	case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException":
+		return output.NewThrottledError(err)
+	case "RequestTimeout", "RequestTimeTooSkewed", "ExpiredToken":
+		return err
+	}
+	switch {
+	case status == 429 || status == 503:
+		return output.NewThrottledError(err)
+	case status >= 400 && status < 500:
+		return output.NewTerminalError(err)
+	}
+	return err
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AmazonS3) CloseAsync() {
 }
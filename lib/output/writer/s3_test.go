@@ -0,0 +1,54 @@
+package writer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3ErrorClassification(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class output.ErrorClass
+	}{
+		{
+			name:  "access denied",
+			err:   awserr.NewRequestFailure(awserr.New("AccessDenied", "nope", nil), 403, "foo"),
+			class: output.ErrorClassTerminal,
+		},
+		{
+			name:  "slow down",
+			err:   awserr.NewRequestFailure(awserr.New("SlowDown", "nope", nil), 503, "foo"),
+			class: output.ErrorClassThrottled,
+		},
+		{
+			name:  "request timeout",
+			err:   awserr.NewRequestFailure(awserr.New("RequestTimeout", "nope", nil), 400, "foo"),
+			class: output.ErrorClassRetryable,
+		},
+		{
+			name:  "internal error",
+			err:   awserr.NewRequestFailure(awserr.New("InternalError", "nope", nil), 500, "foo"),
+			class: output.ErrorClassRetryable,
+		},
+		{
+			name: "multipart upload",
+			err: awserr.New("MultipartUpload", "upload multipart failed",
+				awserr.NewRequestFailure(awserr.New("EntityTooLarge", "nope", nil), 400, "foo")),
+			class: output.ErrorClassTerminal,
+		},
+		{
+			name:  "network error",
+			err:   errors.New("connection reset"),
+			class: output.ErrorClassRetryable,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.class, output.ClassifyError(classifyS3Error(test.err)), test.name)
+	}
+}
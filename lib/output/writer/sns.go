@@ -2,26 +2,47 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	snsMaxRecordsCount = 10
+	snsMaxPayloadSize  = 256 * 1024
+)
+
 // SNSConfig contains configuration fields for the output SNS type.
 type SNSConfig struct {
-	TopicArn      string `json:"topic_arn" yaml:"topic_arn"`
-	sessionConfig `json:",inline" yaml:",inline"`
-	Timeout       string `json:"timeout" yaml:"timeout"`
-	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
+	TopicArn               string `json:"topic_arn" yaml:"topic_arn"`
+	MessageGroupID         string `json:"message_group_id" yaml:"message_group_id"`
+	MessageDeduplicationID string `json:"message_deduplication_id" yaml:"message_deduplication_id"`
+	sessionConfig          `json:",inline" yaml:",inline"`
+	Metadata               output.Metadata    `json:"metadata" yaml:"metadata"`
+	MessageAttributes      string             `json:"message_attributes" yaml:"message_attributes"`
+	Timeout                string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight            int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching               batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewSNSConfig creates a new Config with default values.
@@ -30,14 +51,63 @@ func NewSNSConfig() SNSConfig {
 		sessionConfig: sessionConfig{
 			Config: sess.NewConfig(),
 		},
-		TopicArn:    "",
-		Timeout:     "5s",
-		MaxInFlight: 1,
+		TopicArn:               "",
+		MessageGroupID:         "",
+		MessageDeduplicationID: "",
+		Metadata:               output.NewMetadata(),
+		MessageAttributes:      "",
+		Timeout:                "5s",
+		MaxInFlight:            1,
+		Batching:               batch.NewPolicyConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// snsBatchEntry is an entry of a PublishBatch request, which isn't supported
+// by the version of the AWS SDK in use and is therefore defined here.
+type snsBatchEntry struct {
+	_ struct{} `type:"structure"`
+
+	Id                     *string                               `type:"string" required:"true"`
+	Message                *string                               `type:"string" required:"true"`
+	MessageAttributes      map[string]*sns.MessageAttributeValue `locationNameKey:"Name" locationNameValue:"Value" type:"map"`
+	MessageDeduplicationId *string                               `type:"string"`
+	MessageGroupId         *string                               `type:"string"`
+}
+
+type snsBatchInput struct {
+	_ struct{} `type:"structure"`
+
+	PublishBatchRequestEntries []*snsBatchEntry `type:"list" required:"true"`
+	TopicArn                   *string          `type:"string" required:"true"`
+}
+
+type snsBatchResultErrorEntry struct {
+	_ struct{} `type:"structure"`
+
+	Code        *string `type:"string" required:"true"`
+	Id          *string `type:"string" required:"true"`
+	Message     *string `type:"string"`
+	SenderFault *bool   `type:"boolean" required:"true"`
+}
+
+type snsBatchResultEntry struct {
+	_ struct{} `type:"structure"`
+
+	Id        *string `type:"string"`
+	MessageId *string `type:"string"`
+}
+
+type snsBatchOutput struct {
+	_ struct{} `type:"structure"`
+
+	Failed     []*snsBatchResultErrorEntry `type:"list"`
+	Successful []*snsBatchResultEntry      `type:"list"`
+}
+
+//------------------------------------------------------------------------------
+
 // SNS is a benthos writer.Type implementation that writes messages to an
 // Amazon SNS queue.
 type SNS struct {
@@ -46,6 +116,11 @@ type SNS struct {
 	session *session.Session
 	sns     *sns.SNS
 
+	groupID    *field.Expression
+	dedupeID   *field.Expression
+	metaFilter *output.MetadataFilter
+	attributes *mapping.Executor
+
 	tout time.Duration
 
 	log   log.Modular
@@ -59,8 +134,27 @@ func NewSNS(conf SNSConfig, log log.Modular, stats metrics.Type) (*SNS, error) {
 		log:   log,
 		stats: stats,
 	}
+
+	var err error
+	if id := conf.MessageGroupID; len(id) > 0 {
+		if s.groupID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse group ID expression: %v", err)
+		}
+	}
+	if id := conf.MessageDeduplicationID; len(id) > 0 {
+		if s.dedupeID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse dedupe ID expression: %v", err)
+		}
+	}
+	if s.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
+	if m := conf.MessageAttributes; len(m) > 0 {
+		if s.attributes, err = bloblang.NewMapping("", m); err != nil {
+			return nil, fmt.Errorf("failed to parse message attributes mapping: %w", err)
+		}
+	}
 	if tout := conf.Timeout; len(tout) > 0 {
-		var err error
 		if s.tout, err = time.ParseDuration(tout); err != nil {
 			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
 		}
@@ -79,18 +173,122 @@ func (a *SNS) Connect() error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
 	a.session = sess
 	a.sns = sns.New(sess)
+	instrument.Register(&a.sns.Handlers, a.stats)
 
 	a.log.Infof("Sending messages to Amazon SNS ARN: %v\n", a.conf.TopicArn)
 	return nil
 }
 
+//------------------------------------------------------------------------------
+
+// snsAttributeValue converts a value resulting from the message attributes
+// mapping into an SNS attribute, where strings are sent with the data type
+// String, numbers with the data type Number and raw bytes with the data type
+// Binary.
+func snsAttributeValue(v interface{}) (*sns.MessageAttributeValue, error) {
+	switch t := v.(type) {
+	case string:
+		return &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(t),
+		}, nil
+	case []byte:
+		return &sns.MessageAttributeValue{
+			DataType:    aws.String("Binary"),
+			BinaryValue: t,
+		}, nil
+	}
+	if _, err := query.IGetNumber(v); err != nil {
+		return nil, query.NewTypeError(v, query.ValueString, query.ValueNumber, query.ValueBytes)
+	}
+	return &sns.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(query.IToString(v)),
+	}, nil
+}
+
+// getAttributes returns the message attributes of a message, where attributes
+// from the mapping take precedence over metadata values.
+func (a *SNS) getAttributes(msg types.Message, i int) (map[string]*sns.MessageAttributeValue, error) {
+	values := map[string]*sns.MessageAttributeValue{}
+
+	p := msg.Get(i)
+	_ = a.metaFilter.Iter(p.Metadata(), func(k, v string) error {
+		// SNS message attribute names follow the same rules as SQS.
+		if isValidSQSAttribute(k, v) {
+			values[k] = &sns.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+		} else {
+			a.log.Debugf("Rejecting metadata key '%v' due to invalid characters\n", k)
+		}
+		return nil
+	})
+
+	if a.attributes != nil {
+		res, err := a.attributes.Exec(query.FunctionContext{
+			Maps:     map[string]query.Function{},
+			Vars:     map[string]interface{}{},
+			Index:    i,
+			MsgBatch: msg,
+		}.WithValueFunc(func() *interface{} {
+			jObj, err := p.JSON()
+			if err != nil {
+				return nil
+			}
+			return &jObj
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute message attributes mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case map[string]interface{}:
+			for k, v := range t {
+				if !isValidSQSAttribute(k, "") {
+					return nil, fmt.Errorf("message attribute name '%v' contains invalid characters", k)
+				}
+				attr, err := snsAttributeValue(v)
+				if err != nil {
+					return nil, fmt.Errorf("message attribute '%v': %w", k, err)
+				}
+				values[k] = attr
+			}
+		case query.Nothing, query.Delete, nil:
+		default:
+			return nil, fmt.Errorf("message attributes mapping: %w", query.NewTypeError(res, query.ValueObject))
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// snsEntrySize returns the size of a message as counted against the SNS
+// payload limit, which includes the names, data types and values of its
+// attributes.
+func snsEntrySize(e *snsBatchEntry) int {
+	size := len(*e.Message)
+	for k, v := range e.MessageAttributes {
+		size += len(k) + len(*v.DataType) + len(v.BinaryValue)
+		if v.StringValue != nil {
+			size += len(*v.StringValue)
+		}
+	}
+	return size
+}
+
+//------------------------------------------------------------------------------
+
 // Write attempts to write message contents to a target SNS.
 func (a *SNS) Write(msg types.Message) error {
 	return a.WriteWithContext(context.Background(), msg)
@@ -105,14 +303,119 @@ func (a *SNS) WriteWithContext(wctx context.Context, msg types.Message) error {
 	ctx, cancel := context.WithTimeout(wctx, a.tout)
 	defer cancel()
 
-	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		message := &sns.PublishInput{
-			TopicArn: aws.String(a.conf.TopicArn),
-			Message:  aws.String(string(p.Get())),
+	var batchErr *ibatch.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = ibatch.NewError(msg, err)
 		}
-		_, err := a.sns.PublishWithContext(ctx, message)
-		return err
+		batchErr.Failed(i, err)
+	}
+
+	entries := make([]*snsBatchEntry, 0, msg.Len())
+	_ = msg.Iter(func(i int, p types.Part) error {
+		attrs, err := a.getAttributes(msg, i)
+		if err != nil {
+			failed(i, err)
+			return nil
+		}
+		entry := &snsBatchEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			Message:           aws.String(string(p.Get())),
+			MessageAttributes: attrs,
+		}
+		if a.groupID != nil {
+			entry.MessageGroupId = aws.String(a.groupID.String(i, msg))
+		}
+		if a.dedupeID != nil {
+			entry.MessageDeduplicationId = aws.String(a.dedupeID.String(i, msg))
+		}
+		if size := snsEntrySize(entry); size > snsMaxPayloadSize {
+			failed(i, fmt.Errorf("message size of %v bytes exceeds the SNS maximum of %v bytes", size, snsMaxPayloadSize))
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+
+	if msg.Len() == 1 {
+		if batchErr != nil {
+			return batchErr.Unwrap()
+		}
+		return a.publish(ctx, entries[0])
+	}
+
+	// Split the entries into requests that respect both the maximum number of
+	// entries and the maximum total payload size of a PublishBatch call.
+	for len(entries) > 0 {
+		n, size := 0, 0
+		for ; n < len(entries) && n < snsMaxRecordsCount; n++ {
+			eSize := snsEntrySize(entries[n])
+			if n > 0 && size+eSize > snsMaxPayloadSize {
+				break
+			}
+			size += eSize
+		}
+
+		var chunk []*snsBatchEntry
+		chunk, entries = entries[:n], entries[n:]
+
+		if err := a.publishBatch(ctx, chunk, failed); err != nil {
+			if sendErrIsFatal(err) {
+				return err
+			}
+			for _, e := range chunk {
+				i, _ := strconv.Atoi(*e.Id)
+				failed(i, err)
+			}
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (a *SNS) publish(ctx context.Context, e *snsBatchEntry) error {
+	_, err := a.sns.PublishWithContext(ctx, &sns.PublishInput{
+		TopicArn:               aws.String(a.conf.TopicArn),
+		Message:                e.Message,
+		MessageAttributes:      e.MessageAttributes,
+		MessageGroupId:         e.MessageGroupId,
+		MessageDeduplicationId: e.MessageDeduplicationId,
 	})
+	return err
+}
+
+// publishBatch sends entries with a single PublishBatch call, and registers
+// the entries that were rejected with the failed closure. An error is returned
+// if the call as a whole failed.
+func (a *SNS) publishBatch(ctx context.Context, entries []*snsBatchEntry, failed func(int, error)) error {
+	res := &snsBatchOutput{}
+	req := a.sns.NewRequest(&request.Operation{
+		Name:       "PublishBatch",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &snsBatchInput{
+		PublishBatchRequestEntries: entries,
+		TopicArn:                   aws.String(a.conf.TopicArn),
+	}, res)
+	req.SetContext(ctx)
+	if err := req.Send(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return types.ErrTimeout
+		}
+		return err
+	}
+
+	for _, f := range res.Failed {
+		i, err := strconv.Atoi(aws.StringValue(f.Id))
+		if err != nil || i < 0 {
+			return fmt.Errorf("unexpected entry ID in PublishBatch response: %v", aws.StringValue(f.Id))
+		}
+		failed(i, fmt.Errorf("message failed with code: %v, message: %v", aws.StringValue(f.Code), aws.StringValue(f.Message)))
+	}
+	return nil
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
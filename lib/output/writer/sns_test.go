@@ -0,0 +1,233 @@
+package writer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snsTestEntry struct {
+	id, body, groupID string
+	attrs             map[string]string
+}
+
+type snsTestServer struct {
+	mut     sync.Mutex
+	actions []string
+	entries [][]snsTestEntry
+}
+
+// newSNSTestServer creates a fake SNS API that accepts Publish and
+// PublishBatch calls, where entries with the body "reject" are failed.
+func newSNSTestServer(t *testing.T) (*snsTestServer, *httptest.Server) {
+	t.Helper()
+
+	s := &snsTestServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		readEntry := func(prefix string) snsTestEntry {
+			e := snsTestEntry{
+				id:      r.Form.Get(prefix + "Id"),
+				body:    r.Form.Get(prefix + "Message"),
+				groupID: r.Form.Get(prefix + "MessageGroupId"),
+				attrs:   map[string]string{},
+			}
+			for j := 1; ; j++ {
+				aPrefix := fmt.Sprintf("%vMessageAttributes.entry.%v.", prefix, j)
+				name := r.Form.Get(aPrefix + "Name")
+				if name == "" {
+					break
+				}
+				value := r.Form.Get(aPrefix + "Value.StringValue")
+				if b := r.Form.Get(aPrefix + "Value.BinaryValue"); b != "" {
+					raw, err := base64.StdEncoding.DecodeString(b)
+					require.NoError(t, err)
+					value = string(raw)
+				}
+				e.attrs[name] = r.Form.Get(aPrefix+"Value.DataType") + ":" + value
+			}
+			return e
+		}
+
+		action := r.Form.Get("Action")
+		var entries []snsTestEntry
+		switch action {
+		case "Publish":
+			entries = append(entries, readEntry(""))
+			fmt.Fprint(w, `<PublishResponse><PublishResult><MessageId>foo</MessageId></PublishResult></PublishResponse>`)
+		case "PublishBatch":
+			var successful, failed strings.Builder
+			for i := 1; ; i++ {
+				prefix := fmt.Sprintf("PublishBatchRequestEntries.member.%v.", i)
+				if r.Form.Get(prefix+"Id") == "" {
+					break
+				}
+				e := readEntry(prefix)
+				entries = append(entries, e)
+				if e.body == "reject" {
+					fmt.Fprintf(&failed, `<member><Id>%v</Id><Code>InvalidParameter</Code><Message>nope</Message><SenderFault>true</SenderFault></member>`, e.id)
+				} else {
+					fmt.Fprintf(&successful, `<member><Id>%v</Id><MessageId>foo</MessageId></member>`, e.id)
+				}
+			}
+			fmt.Fprintf(w, `<PublishBatchResponse><PublishBatchResult><Successful>%v</Successful><Failed>%v</Failed></PublishBatchResult></PublishBatchResponse>`, successful.String(), failed.String())
+		default:
+			http.Error(w, "unexpected action", http.StatusBadRequest)
+			return
+		}
+
+		s.mut.Lock()
+		s.actions = append(s.actions, action)
+		s.entries = append(s.entries, entries)
+		s.mut.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func testSNSWriter(t *testing.T, url string, mod func(c *SNSConfig)) *SNS {
+	t.Helper()
+
+	conf := NewSNSConfig()
+	conf.TopicArn = "arn:aws:sns:eu-west-1:000000000000:foo.fifo"
+	conf.Endpoint = url
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	if mod != nil {
+		mod(&conf)
+	}
+
+	w, err := NewSNS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.Connect())
+	return w
+}
+
+func TestSNSPublishBatch(t *testing.T) {
+	s, server := newSNSTestServer(t)
+
+	w := testSNSWriter(t, server.URL, func(c *SNSConfig) {
+		c.MessageGroupID = `${! meta("group") }`
+		c.Metadata.ExcludePrefixes = []string{"group"}
+		c.MessageAttributes = `
+root.count = 5
+root.raw = "hello".bytes()
+root.foo = "overridden"
+`
+	})
+
+	var parts [][]byte
+	for i := 0; i < 12; i++ {
+		parts = append(parts, []byte(fmt.Sprintf("msg%v", i)))
+	}
+	parts[3] = []byte("reject")
+	parts[5] = []byte(strings.Repeat("x", snsMaxPayloadSize))
+
+	msg := message.New(parts)
+	_ = msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("group", fmt.Sprintf("g%v", i%2))
+		p.Metadata().Set("foo", "from meta")
+		p.Metadata().Set("bar", "baz")
+		return nil
+	})
+
+	err := w.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok, err.Error())
+	assert.Equal(t, 2, bErr.IndexedErrors())
+
+	failed := map[int]string{}
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed[i] = err.Error()
+		}
+		return true
+	})
+	require.Len(t, failed, 2)
+	assert.Contains(t, failed[3], "InvalidParameter")
+	assert.Contains(t, failed[5], "exceeds the SNS maximum")
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	assert.Equal(t, []string{"PublishBatch", "PublishBatch"}, s.actions)
+	require.Len(t, s.entries, 2)
+	assert.Len(t, s.entries[0], 10)
+	assert.Len(t, s.entries[1], 1)
+
+	first := s.entries[0][0]
+	assert.Equal(t, "0", first.id)
+	assert.Equal(t, "msg0", first.body)
+	assert.Equal(t, "g0", first.groupID)
+	assert.Equal(t, map[string]string{
+		"bar":   "String:baz",
+		"foo":   "String:overridden",
+		"count": "Number:5",
+		"raw":   "Binary:hello",
+	}, first.attrs)
+
+	last := s.entries[1][0]
+	assert.Equal(t, "11", last.id)
+	assert.Equal(t, "g1", last.groupID)
+}
+
+func TestSNSPublishSingle(t *testing.T) {
+	s, server := newSNSTestServer(t)
+
+	w := testSNSWriter(t, server.URL, nil)
+
+	msg := message.New([][]byte{[]byte("hello world")})
+	msg.Get(0).Metadata().Set("foo", "bar")
+	require.NoError(t, w.Write(msg))
+
+	err := w.Write(message.New([][]byte{[]byte(strings.Repeat("x", snsMaxPayloadSize+1))}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the SNS maximum")
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	assert.Equal(t, []string{"Publish"}, s.actions)
+	assert.Equal(t, [][]snsTestEntry{{{
+		body:  "hello world",
+		attrs: map[string]string{"foo": "String:bar"},
+	}}}, s.entries)
+}
+
+func TestSNSBadAttributes(t *testing.T) {
+	_, server := newSNSTestServer(t)
+
+	w := testSNSWriter(t, server.URL, func(c *SNSConfig) {
+		c.MessageAttributes = `root.foo = if content() == "bad" { {"nested": true} } else { "ok" }`
+	})
+
+	err := w.Write(message.New([][]byte{[]byte("good"), []byte("bad")}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok, err.Error())
+	assert.Equal(t, 1, bErr.IndexedErrors())
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if i == 1 {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+		return true
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	ibatch "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
@@ -17,11 +18,13 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/instrument"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/cenkalti/backoff/v4"
 )
 
@@ -74,7 +77,7 @@ type AmazonSQS struct {
 	conf AmazonSQSConfig
 
 	session *session.Session
-	sqs     *sqs.SQS
+	sqs     sqsiface.SQSAPI
 
 	backoffCtor func() backoff.BackOff
 
@@ -135,13 +138,16 @@ func (a *AmazonSQS) Connect() error {
 		return nil
 	}
 
-	sess, err := a.conf.GetSession()
+	sess, err := a.conf.GetSessionWithFailover(sess.LogFailover(a.log, a.stats.GetCounter("failover")))
 	if err != nil {
 		return err
 	}
 
+	client := sqs.New(sess)
+	instrument.Register(&client.Handlers, a.stats)
+
 	a.session = sess
-	a.sqs = sqs.New(sess)
+	a.sqs = client
 
 	a.log.Infof("Sending messages to Amazon SQS URL: %v\n", a.conf.URL)
 	return nil
@@ -215,19 +221,20 @@ func (a *AmazonSQS) WriteWithContext(ctx context.Context, msg types.Message) err
 	backOff := a.backoffCtor()
 
 	entries := []*sqs.SendMessageBatchRequestEntry{}
-	attrMap := map[string]sqsAttributes{}
+	entryMap := map[string]*sqs.SendMessageBatchRequestEntry{}
 	msg.Iter(func(i int, p types.Part) error {
 		id := strconv.FormatInt(int64(i), 10)
 		attrs := a.getSQSAttributes(msg, i)
-		attrMap[id] = attrs
 
-		entries = append(entries, &sqs.SendMessageBatchRequestEntry{
+		entry := &sqs.SendMessageBatchRequestEntry{
 			Id:                     aws.String(id),
 			MessageBody:            aws.String(string(p.Get())),
 			MessageAttributes:      attrs.attrMap,
 			MessageGroupId:         attrs.groupID,
 			MessageDeduplicationId: attrs.dedupeID,
-		})
+		}
+		entryMap[id] = entry
+		entries = append(entries, entry)
 		return nil
 	})
 
@@ -243,6 +250,24 @@ func (a *AmazonSQS) WriteWithContext(ctx context.Context, msg types.Message) err
 		entries = nil
 	}
 
+	// Returns an error where only the messages of entries that have not been
+	// sent are failed, so that the messages already sent aren't retried.
+	failedErr := func(err error, failed []*sqs.SendMessageBatchRequestEntry, entryErrs map[string]error) error {
+		if msg.Len() == 1 {
+			return err
+		}
+		bErr := ibatch.NewError(msg, err)
+		for _, e := range append(failed, entries...) {
+			index, _ := strconv.Atoi(*e.Id)
+			if eErr, exists := entryErrs[*e.Id]; exists {
+				bErr.Failed(index, eErr)
+			} else {
+				bErr.Failed(index, err)
+			}
+		}
+		return bErr
+	}
+
 	var err error
 	for len(input.Entries) > 0 {
 		wait := backOff.NextBackOff()
@@ -252,50 +277,50 @@ func (a *AmazonSQS) WriteWithContext(ctx context.Context, msg types.Message) err
 			a.log.Warnf("SQS error: %v\n", err)
 			// bail if a message is too large or all retry attempts expired
 			if wait == backoff.Stop {
-				return err
+				return failedErr(err, input.Entries, nil)
 			}
 			select {
 			case <-time.After(wait):
 			case <-ctx.Done():
 				return types.ErrTimeout
 			case <-a.closeChan:
-				return err
+				return failedErr(err, input.Entries, nil)
 			}
 			continue
 		}
 
 		if unproc := batchResult.Failed; len(unproc) > 0 {
 			input.Entries = []*sqs.SendMessageBatchRequestEntry{}
+			entryErrs := map[string]error{}
+			var senderFault error
 			for _, v := range unproc {
-				if *v.SenderFault {
-					err = fmt.Errorf("record failed with code: %v, message: %v", *v.Code, *v.Message)
-					a.log.Errorf("SQS record error: %v\n", err)
-					return err
+				eErr := fmt.Errorf("record failed with code: %v, message: %v", *v.Code, *v.Message)
+				if *v.SenderFault && senderFault == nil {
+					senderFault = eErr
+					a.log.Errorf("SQS record error: %v\n", eErr)
 				}
-				aMap := attrMap[*v.Id]
-				input.Entries = append(input.Entries, &sqs.SendMessageBatchRequestEntry{
-					Id:                     v.Id,
-					MessageBody:            v.Message,
-					MessageAttributes:      aMap.attrMap,
-					MessageGroupId:         aMap.groupID,
-					MessageDeduplicationId: aMap.dedupeID,
-				})
+				entryErrs[*v.Id] = eErr
+				input.Entries = append(input.Entries, entryMap[*v.Id])
+			}
+			if senderFault != nil {
+				return failedErr(senderFault, input.Entries, entryErrs)
 			}
 			err = fmt.Errorf("failed to send %v messages", len(unproc))
 		} else {
 			input.Entries = nil
+			err = nil
 		}
 
 		if err != nil {
 			if wait == backoff.Stop {
-				break
+				return failedErr(err, input.Entries, nil)
 			}
 			select {
 			case <-time.After(wait):
 			case <-ctx.Done():
 				return types.ErrTimeout
 			case <-a.closeChan:
-				return err
+				return failedErr(err, input.Entries, nil)
 			}
 		}
 
@@ -1,6 +1,22 @@
 package writer
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 func TestSQSHeaderCheck(t *testing.T) {
 	type testCase struct {
@@ -93,3 +109,89 @@ func TestSQSHeaderCheck(t *testing.T) {
 		}
 	}
 }
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	fn func(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)
+}
+
+func (m *mockSQS) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	return m.fn(input)
+}
+
+func newMockSQSWriter(t *testing.T, conf AmazonSQSConfig, fn func(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)) *AmazonSQS {
+	t.Helper()
+
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	w, err := NewAmazonSQS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	w.session = session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+	}))
+	w.sqs = &mockSQS{fn: fn}
+	return w
+}
+
+func TestSQSWritePartialFailure(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.MaxRetries = 1
+
+	var bodies [][]string
+	w := newMockSQSWriter(t, conf, func(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+		var attempt []string
+		output := &sqs.SendMessageBatchOutput{}
+		for _, e := range input.Entries {
+			attempt = append(attempt, *e.MessageBody)
+			if *e.MessageBody == "b" || *e.MessageBody == "d" {
+				output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+					Id:          e.Id,
+					Code:        aws.String("InternalError"),
+					Message:     aws.String("nope"),
+					SenderFault: aws.Bool(false),
+				})
+			}
+		}
+		bodies = append(bodies, attempt)
+		return output, nil
+	})
+
+	msg := message.New([][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")})
+	err := w.WriteWithContext(context.Background(), msg)
+	require.Error(t, err)
+
+	// Failed entries are resent with their original bodies.
+	assert.Equal(t, [][]string{{"a", "b", "c", "d"}, {"b", "d"}}, bodies)
+
+	var failed []int
+	err.(*batch.Error).WalkParts(func(i int, _ types.Part, pErr error) bool {
+		if pErr != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 3}, failed)
+}
+
+func TestSQSWriteRecoveredFailure(t *testing.T) {
+	attempts := 0
+	w := newMockSQSWriter(t, NewAmazonSQSConfig(), func(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+		attempts++
+		output := &sqs.SendMessageBatchOutput{}
+		if attempts == 1 {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+				Id:          input.Entries[0].Id,
+				Code:        aws.String("InternalError"),
+				Message:     aws.String("nope"),
+				SenderFault: aws.Bool(false),
+			})
+		}
+		return output, nil
+	})
+
+	msg := message.New([][]byte{[]byte("a"), []byte("b")})
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+	assert.Equal(t, 2, attempts)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/gorilla/websocket"
 )
 
@@ -17,15 +18,21 @@ import (
 
 // WebsocketConfig contains configuration fields for the Websocket output type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	auth.Config `json:",inline" yaml:",inline"`
+	URL            string               `json:"url" yaml:"url"`
+	ProxyURL       string               `json:"proxy_url" yaml:"proxy_url"`
+	ProxyBasicAuth auth.BasicAuthConfig `json:"proxy_basic_auth" yaml:"proxy_basic_auth"`
+	NoProxy        []string             `json:"no_proxy" yaml:"no_proxy"`
+	auth.Config    `json:",inline" yaml:",inline"`
 }
 
 // NewWebsocketConfig creates a new WebsocketConfig with default values.
 func NewWebsocketConfig() WebsocketConfig {
 	return WebsocketConfig{
-		URL:    "ws://localhost:4195/post/ws",
-		Config: auth.NewConfig(),
+		URL:            "ws://localhost:4195/post/ws",
+		ProxyURL:       "",
+		ProxyBasicAuth: auth.NewBasicAuthConfig(),
+		NoProxy:        []string{},
+		Config:         auth.NewConfig(),
 	}
 }
 
@@ -40,6 +47,9 @@ type Websocket struct {
 
 	conf   WebsocketConfig
 	client *websocket.Conn
+	dialer *websocket.Dialer
+
+	mErrProxy metrics.StatCounter
 }
 
 // NewWebsocket creates a new Websocket output type.
@@ -49,10 +59,19 @@ func NewWebsocket(
 	stats metrics.Type,
 ) (*Websocket, error) {
 	ws := &Websocket{
-		log:   log,
-		stats: stats,
-		lock:  &sync.Mutex{},
-		conf:  conf,
+		log:       log,
+		stats:     stats,
+		lock:      &sync.Mutex{},
+		conf:      conf,
+		mErrProxy: stats.GetCounter("error.proxy"),
+	}
+	var err error
+	if ws.dialer, err = proxy.WebsocketDialer(proxy.Config{
+		URL:       conf.ProxyURL,
+		BasicAuth: conf.ProxyBasicAuth,
+		NoProxy:   conf.NoProxy,
+	}); err != nil {
+		return nil, err
 	}
 	return ws, nil
 }
@@ -92,7 +111,10 @@ func (w *Websocket) Connect() error {
 	}
 
 	var client *websocket.Conn
-	if client, _, err = websocket.DefaultDialer.Dial(w.conf.URL, headers); err != nil {
+	if client, _, err = w.dialer.Dial(w.conf.URL, headers); err != nil {
+		if proxy.IsError(err) {
+			w.mErrProxy.Incr(1)
+		}
 		return err
 	}
 
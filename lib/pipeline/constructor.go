@@ -3,6 +3,7 @@ package pipeline
 import (
 	"fmt"
 
+	"github.com/Jeffail/benthos/v3/internal/correlation"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -21,15 +22,17 @@ import (
 // number of parallel inputs that matches or surpasses the number of pipeline
 // threads, or use a memory buffer.
 type Config struct {
-	Threads    int                `json:"threads" yaml:"threads"`
-	Processors []processor.Config `json:"processors" yaml:"processors"`
+	Threads       int                `json:"threads" yaml:"threads"`
+	Processors    []processor.Config `json:"processors" yaml:"processors"`
+	CorrelationID correlation.Config `json:"correlation_id" yaml:"correlation_id"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Threads:    1,
-		Processors: []processor.Config{},
+		Threads:       1,
+		Processors:    []processor.Config{},
+		CorrelationID: correlation.NewConfig(),
 	}
 }
 
@@ -51,8 +54,9 @@ func (conf Config) Sanitised(removeDeprecated bool) (interface{}, error) {
 		}
 	}
 	return map[string]interface{}{
-		"threads":    conf.Threads,
-		"processors": procConfs,
+		"threads":        conf.Threads,
+		"processors":     procConfs,
+		"correlation_id": conf.CorrelationID,
 	}, nil
 }
 
@@ -90,6 +94,11 @@ func New(
 	if conf.Threads == 1 {
 		return procCtor(&procs)
 	}
+	for _, procConf := range conf.Processors {
+		if processor.HasThreadLocalState(procConf.Type) {
+			log.Warnf("Processor %v holds state within each of the %v pipeline threads, consider using a shared processor resource instead\n", procConf.Type, conf.Threads)
+		}
+	}
 	return NewPool(procCtor, conf.Threads, log, stats)
 }
 
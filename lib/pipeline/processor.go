@@ -1,11 +1,13 @@
 package pipeline
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
@@ -31,6 +33,11 @@ type Processor struct {
 
 	messagesIn <-chan types.Transaction
 
+	// ctx is provided to processors and is cancelled when the pipeline is
+	// closed.
+	ctx    context.Context
+	cancel func()
+
 	closeChan chan struct{}
 	closed    chan struct{}
 }
@@ -41,15 +48,18 @@ func NewProcessor(
 	stats metrics.Type,
 	msgProcessors ...types.Processor,
 ) *Processor {
-	return &Processor{
+	p := &Processor{
 		running:       1,
 		msgProcessors: msgProcessors,
+		log:           log,
 		stats:         stats,
 		messagesOut:   make(chan types.Transaction),
 		responsesIn:   make(chan types.Response),
 		closeChan:     make(chan struct{}),
 		closed:        make(chan struct{}),
 	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
 }
 
 //------------------------------------------------------------------------------
@@ -78,12 +88,22 @@ func (p *Processor) loop() {
 			return
 		}
 
-		resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, tran.Payload)
+		resultMsgs, resultRes := p.execute(tran.Payload)
 		if len(resultMsgs) == 0 {
 			if resultRes == nil {
 				resultRes = response.NewUnack()
 				p.log.Warnln("Nil response returned with zero messages from processors")
 			}
+			if p.ctx.Err() != nil && resultRes.Error() != nil {
+				// The pipeline is closing and processing was abandoned, we
+				// make a best attempt at rejecting the message so that it can
+				// be redelivered.
+				select {
+				case tran.ResponseChan <- resultRes:
+				default:
+				}
+				return
+			}
 			select {
 			case tran.ResponseChan <- resultRes:
 			case <-p.closeChan:
@@ -104,6 +124,21 @@ func (p *Processor) loop() {
 	}
 }
 
+// execute applies the processors of the pipeline to a message with a context
+// that is cancelled when the pipeline closes, or when the deadline of the
+// context of the message passes.
+func (p *Processor) execute(msg types.Message) ([]types.Message, types.Response) {
+	ctx := p.ctx
+	if msg.Len() > 0 {
+		if deadline, ok := message.GetContext(msg.Get(0)).Deadline(); ok {
+			var done func()
+			ctx, done = context.WithDeadline(ctx, deadline)
+			defer done()
+		}
+	}
+	return processor.ExecuteAllWithContext(ctx, p.msgProcessors, msg)
+}
+
 // dispatchMessages attempts to send a multiple messages results of processors
 // over the shared messages channel. This send is retried until success.
 func (p *Processor) dispatchMessages(msgs []types.Message, ogResChan chan<- types.Response) {
@@ -193,6 +228,7 @@ func (p *Processor) TransactionChan() <-chan types.Transaction {
 func (p *Processor) CloseAsync() {
 	if atomic.CompareAndSwapInt32(&p.running, 1, 0) {
 		close(p.closeChan)
+		p.cancel()
 
 		// Signal all children to close.
 		for _, c := range p.msgProcessors {
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -51,6 +52,89 @@ func (m *mockMsgProcessor) WaitForClose(timeout time.Duration) error {
 	return nil
 }
 
+type blockingCtxProcessor struct{}
+
+func (b *blockingCtxProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return b.ProcessMessageWithContext(context.Background(), msg)
+}
+
+func (b *blockingCtxProcessor) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	<-ctx.Done()
+	return nil, response.NewError(ctx.Err())
+}
+
+func (b *blockingCtxProcessor) CloseAsync() {}
+
+func (b *blockingCtxProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+func TestProcessorPipelineDeadline(t *testing.T) {
+	proc := NewProcessor(log.Noop(), metrics.Noop(), &blockingCtxProcessor{})
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	part := message.WithContext(ctx, message.NewPart([]byte("foo")))
+	msg := message.New(nil)
+	msg.Append(part)
+
+	select {
+	case tChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case res := <-resChan:
+		if res.Error() != context.DeadlineExceeded {
+			t.Errorf("Wrong response: %v", res.Error())
+		}
+	case <-proc.TransactionChan():
+		t.Error("Message was not rejected")
+	case <-time.After(time.Second):
+		t.Error("Timed out")
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProcessorPipelineCloseCancels(t *testing.T) {
+	proc := NewProcessor(log.Noop(), metrics.Noop(), &blockingCtxProcessor{})
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response, 1)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != context.Canceled {
+			t.Errorf("Wrong response: %v", res.Error())
+		}
+	default:
+		t.Error("Expected message to be rejected")
+	}
+}
+
 func TestProcessorPipeline(t *testing.T) {
 	mockProc := &mockMsgProcessor{dropChan: make(chan bool)}
 
@@ -49,6 +49,10 @@ of the batch.`,
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
 			).IsInterpolated(),
+			docs.FieldAdvanced(
+				"zip_method", "The compression method to use for each message when archiving with the `zip` format, which must resolve to either `deflate` or `store`. The `store` method adds files without compression, which is useful for contents that are already compressed.",
+				"store", `${! if meta("filename").has_suffix(".gz") { "store" } else { "deflate" } }`,
+			).IsInterpolated().AtVersion("3.50.0"),
 		},
 		Footnotes: `
 ## Formats
@@ -63,7 +67,8 @@ Archive messages to a unix standard tape archive.
 
 ### ` + "`zip`" + `
 
-Archive messages to a zip file.
+Archive messages to a zip file. Each file is compressed according to the field
+` + "`zip_method`" + `.
 
 ### ` + "`binary`" + `
 
@@ -107,16 +112,18 @@ archive:
 
 // ArchiveConfig contains configuration fields for the Archive processor.
 type ArchiveConfig struct {
-	Format string `json:"format" yaml:"format"`
-	Path   string `json:"path" yaml:"path"`
+	Format    string `json:"format" yaml:"format"`
+	Path      string `json:"path" yaml:"path"`
+	ZipMethod string `json:"zip_method" yaml:"zip_method"`
 }
 
 // NewArchiveConfig returns a ArchiveConfig with default values.
 func NewArchiveConfig() ArchiveConfig {
 	return ArchiveConfig{
 		// TODO: V4 change this default
-		Format: "binary",
-		Path:   `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		Format:    "binary",
+		Path:      `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		ZipMethod: "deflate",
 	}
 }
 
@@ -154,7 +161,23 @@ func tarArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
-func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
+func zipMethod(str string) (uint16, error) {
+	switch str {
+	case "deflate":
+		return zip.Deflate, nil
+	case "store":
+		return zip.Store, nil
+	}
+	return 0, fmt.Errorf("zip method not recognised: %v", str)
+}
+
+func zipArchiver(method *field.Expression) archiveFunc {
+	return func(hFunc headerFunc, msg types.Message) (types.Part, error) {
+		return zipArchive(hFunc, method, msg)
+	}
+}
+
+func zipArchive(hFunc headerFunc, method *field.Expression, msg types.Message) (types.Part, error) {
 	buf := &bytes.Buffer{}
 	zw := zip.NewWriter(buf)
 
@@ -164,7 +187,9 @@ func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 		if err != nil {
 			return err
 		}
-		h.Method = zip.Deflate
+		if h.Method, err = zipMethod(method.String(i, msg)); err != nil {
+			return err
+		}
 
 		w, err := zw.CreateHeader(h)
 		if err != nil {
@@ -236,12 +261,12 @@ func jsonArrayArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
-func strToArchiver(str string) (archiveFunc, error) {
+func strToArchiver(str string, zipMethod *field.Expression) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
 	case "zip":
-		return zipArchive, nil
+		return zipArchiver(zipMethod), nil
 	case "binary":
 		return binaryArchive, nil
 	case "lines":
@@ -282,7 +307,11 @@ func NewArchive(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %v", err)
 	}
-	archiver, err := strToArchiver(conf.Archive.Format)
+	zipMethod, err := bloblang.NewField(conf.Archive.ZipMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zip method expression: %v", err)
+	}
+	archiver, err := strToArchiver(conf.Archive.Format, zipMethod)
 	if err != nil {
 		return nil, err
 	}
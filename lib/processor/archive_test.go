@@ -14,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -305,3 +306,42 @@ func TestArchiveEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestArchiveZipMethod(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "zip"
+	conf.Archive.Path = `${! meta("path") }`
+	conf.Archive.ZipMethod = `${! meta("method") }`
+
+	proc, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte("hello world first part"),
+		[]byte("hello world second part"),
+	})
+	msg.Get(0).Metadata().Set("path", "foo.txt").Set("method", "store")
+	msg.Get(1).Metadata().Set("path", "bar.txt").Set("method", "deflate")
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	archived := msgs[0].Get(0).Get()
+	zr, err := zip.NewReader(bytes.NewReader(archived), int64(len(archived)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+
+	assert.Equal(t, "foo.txt", zr.File[0].Name)
+	assert.Equal(t, zip.Store, zr.File[0].Method)
+	assert.Equal(t, "bar.txt", zr.File[1].Name)
+	assert.Equal(t, zip.Deflate, zr.File[1].Method)
+
+	msg.Get(1).Metadata().Set("method", "nope")
+	msgs, res = proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	assert.Equal(t, "zip method not recognised: nope", GetFail(msgs[0].Get(0)))
+}
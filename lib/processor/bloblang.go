@@ -33,7 +33,9 @@ Executes a [Bloblang](/docs/guides/bloblang/about) mapping on messages.`,
 		Description: `
 Bloblang is a powerful language that enables a wide range of mapping, transformation and filtering tasks. For more information [check out the docs](/docs/guides/bloblang/about).
 
-If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.`,
+If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.
+
+The mapping is executed for each message of a batch individually. In order to execute a mapping across an entire batch, where messages can be reordered, dropped or inserted, use the [` + "`bloblang_batch`" + ` processor](/docs/components/processors/bloblang_batch) instead.`,
 		Footnotes: `
 ## Error Handling
 
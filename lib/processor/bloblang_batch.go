@@ -0,0 +1,281 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeBloblangBatch] = TypeSpec{
+		constructor: NewBloblangBatch,
+		Status:      docs.StatusBeta,
+		Version:     "3.50.0",
+		Categories: []Category{
+			CategoryMapping,
+		},
+		config: docs.FieldComponent().HasType(docs.FieldTypeString).Linter(docs.LintBloblangMapping).HasDefault(""),
+		Summary: `
+Executes a [Bloblang](/docs/guides/bloblang/about) mapping once for an entire
+batch, where the batch is the context of the mapping as an array and the result
+is an array that becomes the new batch.`,
+		Description: `
+This processor is useful for logic that spans the messages of a batch, such as
+sorting, deduplicating or summarising messages, without needing to
+[archive](/docs/components/processors/archive) the batch into a single message
+first.
+
+The context of the mapping (` + "`this`" + `) is an array containing the
+structured contents of each message of the batch in order, where messages that
+cannot be parsed as JSON are provided as strings. The metadata of each message
+is available as the variable ` + "`$metadata`" + `, an array of objects with the
+same order, e.g. ` + "`$metadata.index(0).kafka_key`" + `.
+
+The mapping must result in an array, where each element becomes a message of
+the new batch. This allows messages to be reordered, dropped or inserted. The
+metadata of each resulting message is taken from the object at the same index of
+the variable ` + "`$metadata`" + ` once the mapping has completed, and
+therefore mappings that change the order of messages can reassign the variable
+in order to preserve metadata. Metadata assignments (` + "`meta foo = \"bar\"`" + `)
+are not supported within this processor. Assigning ` + "`deleted()`" + ` to the root drops
+the entire batch, and a mapping that doesn't assign to the root leaves the batch
+unchanged.
+
+Unlike the [` + "`bloblang`" + ` processor](/docs/components/processors/bloblang)
+the mapping is executed once per batch, and therefore functions that target a
+specific message of the batch such as ` + "`meta`" + ` or ` + "`content`" + `
+refer to the first message.`,
+		Footnotes: `
+## Error Handling
+
+When the mapping fails, or results in a value that isn't an array, the batch
+remains unchanged and every message is flagged as having failed with an error
+that describes the position of the failure within the mapping, allowing you to
+use [standard processor error handling patterns](/docs/configuration/error_handling).`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Sorting and Deduplicating",
+				Summary: `
+Here we remove messages of a batch that share an ` + "`id`" + ` and sort the
+remaining messages by a timestamp, carrying the metadata of each message along
+with it:`,
+				Config: `
+pipeline:
+  processors:
+    - bloblang_batch: |
+        let sorted = this.enumerated().
+          fold([], item -> if item.tally.any(t -> t.value.id == item.value.value.id) {
+            item.tally
+          } else {
+            item.tally.append(item.value)
+          }).
+          sort_by(item -> item.value.ts)
+        let metadata = $sorted.map_each(item -> $metadata.index(item.index))
+        root = $sorted.map_each(item -> item.value)
+`,
+			},
+			{
+				Title: "Batch Checksum",
+				Summary: `
+Here we add a message to the end of each batch containing the number of messages
+and a checksum of their contents:`,
+				Config: `
+pipeline:
+  processors:
+    - bloblang_batch: |
+        root = this.append({
+          "count": this.length(),
+          "checksum": this.map_each(doc -> doc.string()).join("").hash("xxhash64").encode("hex")
+        })
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BloblangBatchConfig contains configuration fields for the BloblangBatch
+// processor.
+type BloblangBatchConfig string
+
+// NewBloblangBatchConfig returns a BloblangBatchConfig with default values.
+func NewBloblangBatchConfig() BloblangBatchConfig {
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// BloblangBatch is a processor that performs a Bloblang mapping across an
+// entire batch.
+type BloblangBatch struct {
+	exec *mapping.Executor
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+	mDropped   metrics.StatCounter
+}
+
+// NewBloblangBatch returns a BloblangBatch processor.
+func NewBloblangBatch(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	exec, err := bloblang.NewMapping("", string(conf.BloblangBatch))
+	if err != nil {
+		if perr, ok := err.(*parser.Error); ok {
+			return nil, fmt.Errorf("%v", perr.ErrorAtPosition([]rune(conf.BloblangBatch)))
+		}
+		return nil, err
+	}
+	return &BloblangBatch{
+		exec: exec,
+
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+		mDropped:   stats.GetCounter("dropped"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (b *BloblangBatch) mapBatch(msg types.Message) (types.Message, error) {
+	contents := make([]interface{}, msg.Len())
+	metadata := make([]interface{}, msg.Len())
+	msg.Iter(func(i int, part types.Part) error {
+		if v, err := part.JSON(); err == nil {
+			contents[i] = query.IClone(v)
+		} else {
+			contents[i] = string(part.Get())
+		}
+		meta := map[string]interface{}{}
+		part.Metadata().Iter(func(k, v string) error {
+			meta[k] = v
+			return nil
+		})
+		metadata[i] = meta
+		return nil
+	})
+
+	vars := map[string]interface{}{
+		"metadata": metadata,
+	}
+	result, err := b.exec.Exec(query.FunctionContext{
+		Maps:     b.exec.Maps(),
+		Vars:     vars,
+		MsgBatch: msg,
+	}.WithValue(contents))
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []interface{}
+	switch t := result.(type) {
+	case query.Delete:
+		return nil, nil
+	case query.Nothing:
+		return msg.Copy(), nil
+	case []interface{}:
+		elements = t
+	default:
+		return nil, fmt.Errorf("expected array result, got %v", query.ITypeOf(result))
+	}
+
+	resultMeta, _ := vars["metadata"].([]interface{})
+
+	newMsg := message.New(nil)
+	for i, ele := range elements {
+		part := message.NewPart(nil)
+		switch t := ele.(type) {
+		case string:
+			part.Set([]byte(t))
+		case []byte:
+			part.Set(t)
+		default:
+			if err := part.SetJSON(ele); err != nil {
+				return nil, fmt.Errorf("failed to marshal element %v into message: %w", i, err)
+			}
+		}
+		if i < len(resultMeta) {
+			if meta, ok := resultMeta[i].(map[string]interface{}); ok {
+				for k, v := range meta {
+					part.Metadata().Set(k, query.IToString(v))
+				}
+			}
+		}
+		newMsg.Append(part)
+	}
+	return newMsg, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (b *BloblangBatch) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	b.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypeBloblangBatch, msg)
+	defer func() {
+		for _, s := range spans {
+			s.Finish()
+		}
+	}()
+
+	newMsg, err := b.mapBatch(msg)
+	if err != nil {
+		err = fmt.Errorf("failed to map batch of %v messages: %w", msg.Len(), err)
+		b.mErr.Incr(1)
+		b.log.Errorf("%v\n", err)
+
+		newMsg = msg.Copy()
+		newMsg.Iter(func(i int, p types.Part) error {
+			FlagErr(p, err)
+			spans[i].SetTag("error", true)
+			spans[i].LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		})
+	}
+
+	if newMsg == nil || newMsg.Len() == 0 {
+		b.mDropped.Incr(int64(msg.Len()))
+		return nil, response.NewAck()
+	}
+
+	b.mBatchSent.Incr(1)
+	b.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (b *BloblangBatch) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (b *BloblangBatch) WaitForClose(timeout time.Duration) error {
+	return nil
+}
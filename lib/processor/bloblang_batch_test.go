@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloblangBatch(t *testing.T) {
+	type part struct {
+		content string
+		meta    map[string]string
+	}
+
+	tests := []struct {
+		name    string
+		mapping string
+		input   []part
+		output  []part
+	}{
+		{
+			name: "sort with metadata",
+			mapping: `let sorted = this.enumerated().sort_by(ele -> ele.value.id)
+let metadata = $sorted.map_each(ele -> $metadata.index(ele.index))
+root = $sorted.map_each(ele -> ele.value)`,
+			input: []part{
+				{content: `{"id":3}`, meta: map[string]string{"foo": "c"}},
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `{"id":2}`, meta: map[string]string{"foo": "b"}},
+			},
+			output: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `{"id":2}`, meta: map[string]string{"foo": "b"}},
+				{content: `{"id":3}`, meta: map[string]string{"foo": "c"}},
+			},
+		},
+		{
+			name:    "dedupe",
+			mapping: `root = this.fold([], item -> if item.tally.any(t -> t.id == item.value.id) { item.tally } else { item.tally.append(item.value) })`,
+			input: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `{"id":2}`, meta: map[string]string{"foo": "b"}},
+				{content: `{"id":1}`, meta: map[string]string{"foo": "c"}},
+			},
+			output: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `{"id":2}`, meta: map[string]string{"foo": "b"}},
+			},
+		},
+		{
+			name:    "insert summary",
+			mapping: `root = this.append({"count": this.length(), "keys": $metadata.map_each(m -> m.foo).join(",")})`,
+			input: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `not json`, meta: map[string]string{"foo": "b"}},
+			},
+			output: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+				{content: `not json`, meta: map[string]string{"foo": "b"}},
+				{content: `{"count":2,"keys":"a,b"}`, meta: map[string]string{}},
+			},
+		},
+		{
+			name:    "batch functions",
+			mapping: `root = this.map_each(ele -> {"size": batch_size(), "index": batch_index()})`,
+			input: []part{
+				{content: `{"id":1}`},
+				{content: `{"id":2}`},
+			},
+			output: []part{
+				{content: `{"index":0,"size":2}`, meta: map[string]string{}},
+				{content: `{"index":0,"size":2}`, meta: map[string]string{}},
+			},
+		},
+		{
+			name:    "no assignment",
+			mapping: `let foo = this.length()`,
+			input: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+			},
+			output: []part{
+				{content: `{"id":1}`, meta: map[string]string{"foo": "a"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeBloblangBatch
+			conf.BloblangBatch = BloblangBatchConfig(test.mapping)
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			input := message.New(nil)
+			for _, p := range test.input {
+				newPart := message.NewPart([]byte(p.content))
+				for k, v := range p.meta {
+					newPart.Metadata().Set(k, v)
+				}
+				input.Append(newPart)
+			}
+
+			msgs, res := proc.ProcessMessage(input)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			var output []part
+			msgs[0].Iter(func(i int, p types.Part) error {
+				assert.False(t, HasFailed(p), GetFail(p))
+				meta := map[string]string{}
+				p.Metadata().Iter(func(k, v string) error {
+					meta[k] = v
+					return nil
+				})
+				output = append(output, part{content: string(p.Get()), meta: meta})
+				return nil
+			})
+			assert.Equal(t, test.output, output)
+		})
+	}
+}
+
+func TestBloblangBatchDeleted(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBloblangBatch
+	conf.BloblangBatch = `root = if this.length() > 1 { deleted() } else { this }`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`a`), []byte(`b`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+
+	conf.BloblangBatch = `root = []`
+	proc, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{[]byte(`a`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestBloblangBatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		err     string
+	}{
+		{
+			name:    "not an array",
+			mapping: `root = this.length()`,
+			err:     "failed to map batch of 2 messages: expected array result, got number",
+		},
+		{
+			name: "failed query",
+			mapping: `root = this
+root.foo = this.index(0).nope.number()`,
+			err: "failed to map batch of 2 messages: failed assignment (line 2)",
+		},
+		{
+			name:    "meta assignment",
+			mapping: `meta foo = "bar"`,
+			err:     "failed to map batch of 2 messages: failed to assign result (line 1)",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeBloblangBatch
+			conf.BloblangBatch = BloblangBatchConfig(test.mapping)
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			input := message.New([][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`)})
+			input.Get(0).Metadata().Set("foo", "a")
+
+			msgs, res := proc.ProcessMessage(input)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 2, msgs[0].Len())
+
+			assert.Equal(t, `{"id":1}`, string(msgs[0].Get(0).Get()))
+			assert.Equal(t, "a", msgs[0].Get(0).Metadata().Get("foo"))
+			assert.Equal(t, `{"id":2}`, string(msgs[0].Get(1).Get()))
+			msgs[0].Iter(func(i int, p types.Part) error {
+				assert.Contains(t, GetFail(p), test.err)
+				return nil
+			})
+			assert.Equal(t, "", input.Get(0).Metadata().Get(FailFlagKey))
+		})
+	}
+}
+
+func TestBloblangBatchParseError(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBloblangBatch
+	conf.BloblangBatch = `root = this.`
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1 char")
+}
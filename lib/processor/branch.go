@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
@@ -11,10 +12,12 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/metadata"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -356,6 +359,12 @@ pathLoop:
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return b.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors and the message is rejected if it is cancelled.
+func (b *Branch) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	branchMsg, propSpans := tracing.WithChildSpans(TypeBranch, msg.Copy())
 	defer func() {
 		for _, s := range propSpans {
@@ -371,7 +380,10 @@ func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 		return nil
 	})
 
-	resultParts, mapErrs, err := b.createResult(parts, msg)
+	resultParts, mapErrs, err := b.createResult(ctx, parts, msg)
+	if err != nil && ctx.Err() != nil {
+		return nil, response.NewError(ctx.Err())
+	}
 	if err != nil {
 		result := msg.Copy()
 		// Add general error to all messages.
@@ -381,7 +393,7 @@ func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 		})
 		// And override with mapping specific errors where appropriate.
 		for _, e := range mapErrs {
-			FlagErr(result.Get(e.index), e.err)
+			e.flag(result.Get(e.index))
 		}
 		msgs := [1]types.Message{result}
 		return msgs[:], nil
@@ -389,7 +401,7 @@ func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 
 	result := msg.DeepCopy()
 	for _, e := range mapErrs {
-		FlagErr(result.Get(e.index), e.err)
+		e.flag(result.Get(e.index))
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
@@ -402,7 +414,7 @@ func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 		return msgs[:], nil
 	}
 	for _, e := range mapErrs {
-		FlagErr(result.Get(e.index), e.err)
+		e.flag(result.Get(e.index))
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
@@ -414,10 +426,22 @@ func (b *Branch) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 type branchMapError struct {
 	index int
 	err   error
+
+	// part optionally contains the failed result of the branch, from which
+	// HTTP error metadata is copied.
+	part types.Part
 }
 
 func newBranchMapError(index int, err error) branchMapError {
-	return branchMapError{index, err}
+	return branchMapError{index: index, err: err}
+}
+
+// flag marks a part of the resulting message as failed.
+func (e branchMapError) flag(p types.Part) {
+	FlagErr(p, e.err)
+	if e.part != nil {
+		metadata.CopyHTTPError(p, e.part)
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -426,7 +450,7 @@ func newBranchMapError(index int, err error) branchMapError {
 // of the payload will remain unchanged, where reduced indexes are nil. This
 // result can be overlayed onto the original message in order to complete the
 // map.
-func (b *Branch) createResult(parts []types.Part, referenceMsg types.Message) ([]types.Part, []branchMapError, error) {
+func (b *Branch) createResult(ctx context.Context, parts []types.Part, referenceMsg types.Message) ([]types.Part, []branchMapError, error) {
 	b.mCount.Incr(1)
 
 	originalLen := len(parts)
@@ -471,7 +495,7 @@ func (b *Branch) createResult(parts []types.Part, referenceMsg types.Message) ([
 		var res types.Response
 		msg := message.New(nil)
 		msg.SetAll(parts)
-		if procResults, res = ExecuteAll(b.children, msg); res != nil && res.Error() != nil {
+		if procResults, res = ExecuteAllWithContext(ctx, b.children, msg); res != nil && res.Error() != nil {
 			err = fmt.Errorf("child processors failed: %v", res.Error())
 		}
 		if len(procResults) == 0 {
@@ -500,7 +524,9 @@ func (b *Branch) createResult(parts []types.Part, referenceMsg types.Message) ([
 		}
 		if fail := GetFail(p); len(fail) > 0 {
 			alignedResult[i] = nil
-			mapErrs = append(mapErrs, newBranchMapError(i, fmt.Errorf("processors failed: %v", fail)))
+			mapErr := newBranchMapError(i, fmt.Errorf("processors failed: %v", fail))
+			mapErr.part = p
+			mapErrs = append(mapErrs, mapErr)
 		}
 	}
 
@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -328,3 +330,38 @@ func TestBranchBasic(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchHTTPErrorMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":"bad value"}`))
+	}))
+	defer ts.Close()
+
+	procConf := NewConfig()
+	procConf.Type = TypeHTTP
+	procConf.HTTP.Config.URL = ts.URL
+	procConf.HTTP.Config.NumRetries = 0
+
+	conf := NewConfig()
+	conf.Type = TypeBranch
+	conf.Branch.RequestMap = "root = this"
+	conf.Branch.Processors = append(conf.Branch.Processors, procConf)
+	conf.Branch.ResultMap = "root.result = this"
+
+	proc, err := NewBranch(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`{"value":"foobar"}`)}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+
+	p := outMsgs[0].Get(0)
+	assert.Equal(t, `{"value":"foobar"}`, string(p.Get()))
+	assert.True(t, HasFailed(p))
+	assert.Equal(t, "422", p.Metadata().Get("http_status"))
+	assert.Equal(t, `{"error":"bad value"}`, p.Metadata().Get("http_error_body"))
+
+	proc.CloseAsync()
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
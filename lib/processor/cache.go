@@ -11,9 +11,12 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/opentracing/opentracing-go"
+	"golang.org/x/sync/singleflight"
 )
 
 //------------------------------------------------------------------------------
@@ -38,6 +41,9 @@ This processor will interpolate functions within the ` + "`key` and `value`" + `
 				"ttl", "The TTL of each individual item as a duration string. After this period an item will be eligible for removal during the next compaction. Not all caches support per-key TTLs, and those that do not will fall back to their generally configured TTL setting.",
 				"60s", "5m", "36h",
 			).IsInterpolated().AtVersion("3.33.0"),
+			docs.FieldAdvanced(
+				"on_miss", "A list of [processors](/docs/components/processors/about) to execute on a message when a `get` operation finds no value for its key. The payload resulting from these processors is stored in the cache under the same key, using the `ttl` when specified, and replaces the contents of the message. Concurrent misses for the same key are deduplicated such that the processors are only executed once.",
+			).Array().HasType(docs.FieldTypeProcessor).AtVersion("3.50.0"),
 			PartsFieldSpec,
 		},
 		Examples: []docs.AnnotatedExample{
@@ -58,6 +64,34 @@ pipeline:
         value: "storeme"
     - bloblang: root = if errored() { deleted() }
 
+cache_resources:
+  - label: foocache
+    redis:
+      url: tcp://TODO:6379
+`,
+			},
+			{
+				Title: "Read Through",
+				Summary: `
+A ` + "`get`" + ` operation can populate missing keys with processors listed
+under ` + "`on_miss`" + `, here we fetch documents that are not yet cached
+with an HTTP request and cache them for an hour:`,
+				Config: `
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - cache:
+              resource: foocache
+              operator: get
+              key: '${! json("message.document_id") }'
+              ttl: 1h
+              on_miss:
+                - http:
+                    url: http://example.com/documents/${! json("message.document_id") }
+                    verb: GET
+        result_map: 'root.message.document = this'
+
 cache_resources:
   - label: foocache
     redis:
@@ -111,6 +145,10 @@ Retrieve the contents of a cached key and replace the original message payload
 with the result. If the key does not exist the action fails with an error, which
 can be detected with [processor error handling](/docs/configuration/error_handling).
 
+When ` + "`on_miss`" + ` processors are configured a missing key is instead
+populated with the result of executing them, and the action only fails when
+those processors fail.
+
 ### ` + "`delete`" + `
 
 Delete a key and its contents from the cache.  If the key does not exist the
@@ -122,13 +160,14 @@ action is a no-op and will not fail with an error.`,
 
 // CacheConfig contains configuration fields for the Cache processor.
 type CacheConfig struct {
-	Cache    string `json:"cache" yaml:"cache"`
-	Resource string `json:"resource" yaml:"resource"`
-	Parts    []int  `json:"parts" yaml:"parts"`
-	Operator string `json:"operator" yaml:"operator"`
-	Key      string `json:"key" yaml:"key"`
-	Value    string `json:"value" yaml:"value"`
-	TTL      string `json:"ttl" yaml:"ttl"`
+	Cache    string   `json:"cache" yaml:"cache"`
+	Resource string   `json:"resource" yaml:"resource"`
+	Parts    []int    `json:"parts" yaml:"parts"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Key      string   `json:"key" yaml:"key"`
+	Value    string   `json:"value" yaml:"value"`
+	TTL      string   `json:"ttl" yaml:"ttl"`
+	OnMiss   []Config `json:"on_miss" yaml:"on_miss"`
 }
 
 // NewCacheConfig returns a CacheConfig with default values.
@@ -141,6 +180,7 @@ func NewCacheConfig() CacheConfig {
 		Key:      "",
 		Value:    "",
 		TTL:      "",
+		OnMiss:   []Config{},
 	}
 }
 
@@ -163,9 +203,15 @@ type Cache struct {
 	cacheName string
 	operator  cacheOperator
 
+	onMiss []types.Processor
+	flight singleflight.Group
+
 	mCount            metrics.StatCounter
 	mErr              metrics.StatCounter
 	mKeyAlreadyExists metrics.StatCounter
+	mHit              metrics.StatCounter
+	mPopulated        metrics.StatCounter
+	mPopulateFailed   metrics.StatCounter
 	mSent             metrics.StatCounter
 	mBatchSent        metrics.StatCounter
 }
@@ -206,6 +252,19 @@ func NewCache(
 		return nil, err
 	}
 
+	if len(conf.Cache.OnMiss) > 0 && conf.Cache.Operator != "get" {
+		return nil, errors.New("on_miss processors can only be used with the get operator")
+	}
+	var onMiss []types.Processor
+	for i, pconf := range conf.Cache.OnMiss {
+		pMgr, pLog, pStats := interop.LabelChild(fmt.Sprintf("on_miss.%v", i), mgr, log, stats)
+		proc, err := New(pconf, pMgr, pLog, pStats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create on_miss processor %v: %w", i, err)
+		}
+		onMiss = append(onMiss, proc)
+	}
+
 	return &Cache{
 		conf:  conf,
 		log:   log,
@@ -221,9 +280,14 @@ func NewCache(
 		cacheName: cacheName,
 		operator:  op,
 
+		onMiss: onMiss,
+
 		mCount:            stats.GetCounter("count"),
 		mErr:              stats.GetCounter("error"),
 		mKeyAlreadyExists: stats.GetCounter("key_already_exists"),
+		mHit:              stats.GetCounter("get.hit"),
+		mPopulated:        stats.GetCounter("get.miss.populated"),
+		mPopulateFailed:   stats.GetCounter("get.miss.failed"),
 		mSent:             stats.GetCounter("sent"),
 		mBatchSent:        stats.GetCounter("batch.sent"),
 	}, nil
@@ -290,6 +354,13 @@ func cacheOperatorFromString(operator string) (cacheOperator, error) {
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (c *Cache) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return c.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, cache
+// operations in flight are abandoned and the message rejected if the context is
+// cancelled.
+func (c *Cache) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	c.mCount.Incr(1)
 	newMsg := msg.Copy()
 
@@ -308,13 +379,20 @@ func (c *Cache) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 			ttl = &td
 		}
 
-		var result []byte
-		var useResult bool
-		var err error
-		if cerr := interop.AccessCache(context.Background(), c.mgr, c.cacheName, func(cache types.Cache) {
-			result, useResult, err = c.operator(cache, key, value, ttl)
-		}); cerr != nil {
-			err = cerr
+		result, useResult, err := c.accessCache(ctx, func(cache types.Cache) ([]byte, bool, error) {
+			return c.operator(cache, key, value, ttl)
+		})
+		if len(c.onMiss) > 0 {
+			if err == nil {
+				c.mHit.Incr(1)
+			} else if err == types.ErrKeyNotFound {
+				if result, err = c.populate(ctx, key, ttl, part); err != nil {
+					c.mPopulateFailed.Incr(1)
+					c.log.Debugf("Failed to populate key '%s': %v\n", key, err)
+					return err
+				}
+				c.mPopulated.Incr(1)
+			}
 		}
 		if err != nil {
 			if err != types.ErrKeyAlreadyExists {
@@ -334,6 +412,9 @@ func (c *Cache) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	}
 
 	IteratePartsWithSpan(TypeCache, c.parts, newMsg, proc)
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
 
 	c.mBatchSent.Incr(1)
 	c.mSent.Incr(int64(newMsg.Len()))
@@ -341,12 +422,99 @@ func (c *Cache) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	return msgs[:], nil
 }
 
+// accessCache performs an operation on the cache resource, returning early with
+// the error of the context if it is cancelled before the operation completes.
+// Caches do not support cancelling operations, and therefore an abandoned
+// operation continues in the background until it completes.
+func (c *Cache) accessCache(ctx context.Context, fn func(types.Cache) ([]byte, bool, error)) ([]byte, bool, error) {
+	type opResult struct {
+		value     []byte
+		useResult bool
+		err       error
+	}
+	op := func() (res opResult) {
+		if cerr := interop.AccessCache(ctx, c.mgr, c.cacheName, func(cache types.Cache) {
+			res.value, res.useResult, res.err = fn(cache)
+		}); cerr != nil {
+			res.err = cerr
+		}
+		return
+	}
+	if ctx.Done() == nil {
+		res := op()
+		return res.value, res.useResult, res.err
+	}
+
+	resChan := make(chan opResult, 1)
+	go func() {
+		resChan <- op()
+	}()
+	select {
+	case res := <-resChan:
+		return res.value, res.useResult, res.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// populate executes the on_miss processors on a part in order to obtain a value
+// for a missing key, which is then stored in the cache. Concurrent calls for
+// the same key share the result of a single execution.
+func (c *Cache) populate(ctx context.Context, key string, ttl *time.Duration, part types.Part) ([]byte, error) {
+	res, err, shared := c.flight.Do(key, func() (interface{}, error) {
+		msg := message.New(nil)
+		msg.Append(part.Copy())
+		msgs, res := ExecuteAllWithContext(ctx, c.onMiss, msg)
+		if res != nil && res.Error() != nil {
+			return nil, res.Error()
+		}
+		if len(msgs) == 0 || msgs[0].Len() == 0 {
+			return nil, errors.New("on_miss processors resulted in zero messages")
+		}
+		resPart := msgs[0].Get(0)
+		if HasFailed(resPart) {
+			return nil, fmt.Errorf("on_miss processors failed: %v", GetFail(resPart))
+		}
+		value := resPart.Get()
+
+		_, _, err := c.accessCache(ctx, func(cache types.Cache) ([]byte, bool, error) {
+			if cttl, ok := cache.(types.CacheWithTTL); ok {
+				return nil, false, cttl.SetWithTTL(key, value, ttl)
+			}
+			return nil, false, cache.Set(key, value)
+		})
+		if err != nil {
+			// The value is still usable even though it could not be cached.
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to store populated key '%s': %v\n", key, err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	value := res.([]byte)
+	if shared {
+		value = append([]byte(nil), value...)
+	}
+	return value, nil
+}
+
 // CloseAsync shuts down the processor and stops processing requests.
 func (c *Cache) CloseAsync() {
+	for _, p := range c.onMiss {
+		p.CloseAsync()
+	}
 }
 
 // WaitForClose blocks until the processor has closed down.
-func (c *Cache) WaitForClose(_ time.Duration) error {
+func (c *Cache) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, p := range c.onMiss {
+		if err := p.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
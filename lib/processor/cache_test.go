@@ -2,13 +2,17 @@ package processor
 
 import (
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCacheSetDeprecated(t *testing.T) {
@@ -382,3 +386,124 @@ func TestCacheDelete(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", err, types.ErrKeyNotFound)
 	}
 }
+
+func TestCacheGetOnMiss(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, memCache.Set("foo", []byte("cached foo")))
+
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	blobConf := NewConfig()
+	blobConf.Type = TypeBloblang
+	blobConf.Bloblang = `root = if this.fail == true { throw("nope") } else { "populated " + this.key }`
+
+	conf := NewConfig()
+	conf.Cache.Key = "${!json(\"key\")}"
+	conf.Cache.Resource = "foocache"
+	conf.Cache.Operator = "get"
+	conf.Cache.OnMiss = append(conf.Cache.OnMiss, blobConf)
+
+	stats := metrics.NewLocal()
+	proc, err := NewCache(conf, mgr, log.Noop(), stats)
+	require.NoError(t, err)
+
+	output, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"key":"foo"}`),
+		[]byte(`{"key":"bar"}`),
+		[]byte(`{"key":"baz","fail":true}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, output, 1)
+
+	assert.Equal(t, "cached foo", string(output[0].Get(0).Get()))
+	assert.Equal(t, "populated bar", string(output[0].Get(1).Get()))
+	assert.False(t, HasFailed(output[0].Get(1)))
+	assert.Equal(t, `{"key":"baz","fail":true}`, string(output[0].Get(2).Get()))
+	assert.Contains(t, GetFail(output[0].Get(2)), "nope")
+
+	actBytes, err := memCache.Get("bar")
+	require.NoError(t, err)
+	assert.Equal(t, "populated bar", string(actBytes))
+
+	_, err = memCache.Get("baz")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["get.hit"])
+	assert.Equal(t, int64(1), counters["get.miss.populated"])
+	assert.Equal(t, int64(1), counters["get.miss.failed"])
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestCacheGetOnMissSingleFlight(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	sleepConf := NewConfig()
+	sleepConf.Type = TypeSleep
+	sleepConf.Sleep.Duration = "100ms"
+
+	blobConf := NewConfig()
+	blobConf.Type = TypeBloblang
+	blobConf.Bloblang = `root = count("cache_on_miss_single_flight_test").string()`
+
+	conf := NewConfig()
+	conf.Cache.Key = "foo"
+	conf.Cache.Resource = "foocache"
+	conf.Cache.Operator = "get"
+	conf.Cache.OnMiss = append(conf.Cache.OnMiss, sleepConf, blobConf)
+
+	proc, err := NewCache(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	results := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			output, res := proc.ProcessMessage(message.New([][]byte{[]byte("hello")}))
+			require.Nil(t, res)
+			require.Len(t, output, 1)
+			results[i] = string(output[0].Get(0).Get())
+		}(i)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		assert.Equal(t, "1", r)
+	}
+}
+
+func TestCacheOnMissBadOperator(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Cache.Key = "foo"
+	conf.Cache.Resource = "foocache"
+	conf.Cache.Operator = "set"
+	conf.Cache.OnMiss = append(conf.Cache.OnMiss, NewConfig())
+
+	_, err = NewCache(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "on_miss processors can only be used with the get operator")
+}
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -106,6 +107,12 @@ func NewCatch(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *Catch) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return p.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors.
+func (p *Catch) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
 	resultMsgs := make([]types.Message, msg.Len())
@@ -117,7 +124,7 @@ func (p *Catch) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	})
 
 	var res types.Response
-	if resultMsgs, res = ExecuteCatchAll(p.children, resultMsgs...); res != nil {
+	if resultMsgs, res = ExecuteCatchAllWithContext(ctx, p.children, resultMsgs...); res != nil {
 		return nil, res
 	}
 
@@ -0,0 +1,330 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCDCUnwrap] = TypeSpec{
+		constructor: NewCDCUnwrap,
+		Status:      docs.StatusBeta,
+		Version:     "3.50.0",
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Flattens change data capture events in the
+[Debezium](https://debezium.io/documentation/reference/connectors/) envelope
+format into the row image they describe.`,
+		Description: `
+Events of a create (` + "`c`" + `), update (` + "`u`" + `) or snapshot read
+(` + "`r`" + `) operation are replaced with their ` + "`after`" + ` image, and
+events of a delete (` + "`d`" + `) operation are replaced with their
+` + "`before`" + ` image with the field ` + "`deleted_field`" + ` set to
+` + "`true`" + `. Both the plain JSON envelope and the envelope with an embedded
+schema (` + "`{\"schema\":{...},\"payload\":{...}}`" + `) are supported.
+
+Tombstone events, which have an empty or ` + "`null`" + ` payload and follow
+deletes in order to allow Kafka log compaction, are dropped by default.
+
+### Metadata
+
+This processor adds the following metadata fields to each message when present
+in the envelope:
+
+` + "```text" + `
+- cdc_op
+- cdc_ts_ms
+- cdc_db
+- cdc_table
+- cdc_lsn
+- cdc_offset
+` + "```" + `
+
+The field ` + "`cdc_lsn`" + ` is the log sequence number of the source
+database (e.g. PostgreSQL), and ` + "`cdc_offset`" + ` is the binlog file and
+position of the event in the form ` + "`<file>:<pos>`" + ` (e.g. MySQL).
+Tombstones that are passed through have the metadata field ` + "`cdc_op`" + `
+set to ` + "`tombstone`" + `.
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+Events that cannot be parsed as a Debezium envelope, or that describe an
+unsupported operation such as a truncate, remain unchanged and are flagged as
+having failed, allowing you to use
+[standard processor error handling patterns](/docs/configuration/error_handling).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("deleted_field", "A field to set to `true` within the `before` image of delete events. Set to an empty string in order to emit delete events without a flag."),
+			docs.FieldCommon("tombstones", "Whether tombstone events are dropped or passed through unchanged.").HasOptions("drop", "pass"),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Upserting Rows",
+				Summary: `
+Here we unwrap the events of a Debezium topic and route deletes and upserts of
+rows to different outputs based on the metadata field ` + "`cdc_op`" + `:`,
+				Config: `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ dbserver1.inventory.customers ]
+    consumer_group: benthos_cdc
+
+pipeline:
+  processors:
+    - cdc_unwrap: {}
+
+output:
+  switch:
+    cases:
+      - check: meta("cdc_op") == "d"
+        output:
+          resource: delete_rows
+      - output:
+          resource: upsert_rows
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CDCUnwrapConfig contains configuration fields for the CDCUnwrap processor.
+type CDCUnwrapConfig struct {
+	DeletedField string `json:"deleted_field" yaml:"deleted_field"`
+	Tombstones   string `json:"tombstones" yaml:"tombstones"`
+}
+
+// NewCDCUnwrapConfig returns a CDCUnwrapConfig with default values.
+func NewCDCUnwrapConfig() CDCUnwrapConfig {
+	return CDCUnwrapConfig{
+		DeletedField: "__deleted",
+		Tombstones:   "drop",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CDCUnwrap is a processor that flattens Debezium change event envelopes.
+type CDCUnwrap struct {
+	deletedField   string
+	dropTombstones bool
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewCDCUnwrap returns a CDCUnwrap processor.
+func NewCDCUnwrap(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c := &CDCUnwrap{
+		deletedField: conf.CDCUnwrap.DeletedField,
+
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mDropped:   stats.GetCounter("dropped"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	switch conf.CDCUnwrap.Tombstones {
+	case "drop":
+		c.dropTombstones = true
+	case "pass":
+	default:
+		return nil, fmt.Errorf("tombstones value not recognised: %v", conf.CDCUnwrap.Tombstones)
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+var errCDCNotEnvelope = errors.New("message is not a debezium change event envelope")
+
+func isCDCTombstone(part types.Part) bool {
+	b := bytes.TrimSpace(part.Get())
+	return len(b) == 0 || bytes.Equal(b, []byte("null"))
+}
+
+// unwrap replaces the contents of a part with the row image of its envelope and
+// sets metadata from the envelope.
+func (c *CDCUnwrap) unwrap(part types.Part) error {
+	jRoot, err := part.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+	envelope, ok := jRoot.(map[string]interface{})
+	if !ok {
+		return errCDCNotEnvelope
+	}
+	if payload, exists := envelope["payload"]; exists {
+		if _, hasSchema := envelope["schema"]; hasSchema {
+			if envelope, ok = payload.(map[string]interface{}); !ok {
+				return errCDCNotEnvelope
+			}
+		}
+	}
+
+	op, _ := envelope["op"].(string)
+	var image interface{}
+	switch op {
+	case "c", "r", "u":
+		image = envelope["after"]
+	case "d":
+		image = envelope["before"]
+	case "":
+		return errCDCNotEnvelope
+	default:
+		return fmt.Errorf("unsupported change event operation: %v", op)
+	}
+
+	imageObj, ok := image.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object image for operation '%v', got %T", op, image)
+	}
+	if op == "d" && c.deletedField != "" {
+		// The parsed document might be shared with the original message.
+		imageCopy, err := message.CopyJSON(imageObj)
+		if err != nil {
+			return err
+		}
+		imageObj = imageCopy.(map[string]interface{})
+		imageObj[c.deletedField] = true
+	}
+	if err = part.SetJSON(imageObj); err != nil {
+		return err
+	}
+
+	meta := part.Metadata()
+	meta.Set("cdc_op", op)
+
+	source, _ := envelope["source"].(map[string]interface{})
+	tsMs, exists := source["ts_ms"]
+	if !exists {
+		tsMs, exists = envelope["ts_ms"]
+	}
+	if exists && tsMs != nil {
+		meta.Set("cdc_ts_ms", cdcMetaString(tsMs))
+	}
+	for k, metaKey := range map[string]string{
+		"db":    "cdc_db",
+		"table": "cdc_table",
+		"lsn":   "cdc_lsn",
+	} {
+		if v, exists := source[k]; exists && v != nil {
+			meta.Set(metaKey, cdcMetaString(v))
+		}
+	}
+	if pos, exists := source["pos"]; exists && pos != nil {
+		file, _ := source["file"].(string)
+		meta.Set("cdc_offset", file+":"+cdcMetaString(pos))
+	}
+	return nil
+}
+
+func cdcMetaString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *CDCUnwrap) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	newParts := make([]types.Part, 0, msg.Len())
+
+	msg.Iter(func(i int, part types.Part) error {
+		if isCDCTombstone(part) {
+			if c.dropTombstones {
+				c.mDropped.Incr(1)
+				return nil
+			}
+			p := part.Copy()
+			p.Metadata().Set("cdc_op", "tombstone")
+			newParts = append(newParts, p)
+			return nil
+		}
+
+		span := tracing.GetSpan(part)
+		if span == nil {
+			span = opentracing.StartSpan(TypeCDCUnwrap)
+		} else {
+			span = opentracing.StartSpan(
+				TypeCDCUnwrap,
+				opentracing.ChildOf(span.Context()),
+			)
+		}
+
+		p := part.Copy()
+		if err := c.unwrap(p); err != nil {
+			p = part.Copy()
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to unwrap change event: %v\n", err)
+			FlagErr(p, err)
+			span.SetTag("error", true)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+		}
+		span.Finish()
+
+		newParts = append(newParts, p)
+		return nil
+	})
+
+	if len(newParts) == 0 {
+		return nil, response.NewAck()
+	}
+
+	newMsg := message.New(nil)
+	newMsg.SetAll(newParts)
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *CDCUnwrap) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *CDCUnwrap) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCDCUnwrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		output   string
+		metadata map[string]string
+		failed   bool
+	}{
+		{
+			name:   "create",
+			input:  `{"before":null,"after":{"id":1,"name":"foo"},"op":"c","ts_ms":1620000000001,"source":{"db":"inventory","table":"customers","ts_ms":1620000000000,"lsn":33842}}`,
+			output: `{"id":1,"name":"foo"}`,
+			metadata: map[string]string{
+				"cdc_op":    "c",
+				"cdc_ts_ms": "1620000000000",
+				"cdc_db":    "inventory",
+				"cdc_table": "customers",
+				"cdc_lsn":   "33842",
+			},
+		},
+		{
+			name:   "update with schema",
+			input:  `{"schema":{"type":"struct"},"payload":{"before":{"id":1,"name":"foo"},"after":{"id":1,"name":"bar"},"op":"u","source":{"db":"inventory","table":"customers","ts_ms":1620000000000,"file":"mysql-bin.000003","pos":154}}}`,
+			output: `{"id":1,"name":"bar"}`,
+			metadata: map[string]string{
+				"cdc_op":     "u",
+				"cdc_ts_ms":  "1620000000000",
+				"cdc_db":     "inventory",
+				"cdc_table":  "customers",
+				"cdc_offset": "mysql-bin.000003:154",
+			},
+		},
+		{
+			name:   "delete",
+			input:  `{"before":{"id":1,"name":"bar"},"after":null,"op":"d","ts_ms":1620000000001}`,
+			output: `{"__deleted":true,"id":1,"name":"bar"}`,
+			metadata: map[string]string{
+				"cdc_op":    "d",
+				"cdc_ts_ms": "1620000000001",
+			},
+		},
+		{
+			name:   "truncate",
+			input:  `{"before":null,"after":null,"op":"t"}`,
+			output: `{"before":null,"after":null,"op":"t"}`,
+			failed: true,
+		},
+		{
+			name:   "not an envelope",
+			input:  `{"id":1,"name":"foo"}`,
+			output: `{"id":1,"name":"foo"}`,
+			failed: true,
+		},
+		{
+			name:   "not json",
+			input:  `nope`,
+			output: `nope`,
+			failed: true,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeCDCUnwrap
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 1, msgs[0].Len())
+
+			part := msgs[0].Get(0)
+			assert.Equal(t, test.output, string(part.Get()))
+			assert.Equal(t, test.failed, HasFailed(part))
+			for k, v := range test.metadata {
+				assert.Equal(t, v, part.Metadata().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCDCUnwrapTombstones(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCDCUnwrap
+	conf.CDCUnwrap.DeletedField = ""
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := [][]byte{
+		[]byte(`{"before":{"id":1},"after":null,"op":"d"}`),
+		[]byte(``),
+		[]byte(`null`),
+	}
+
+	msgs, res := proc.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte(`{"id":1}`)}, message.GetAllBytes(msgs[0]))
+
+	msgs, res = proc.ProcessMessage(message.New(input[1:]))
+	require.Len(t, msgs, 0)
+	require.NotNil(t, res)
+	require.NoError(t, res.Error())
+
+	conf.CDCUnwrap.Tombstones = "pass"
+	proc, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res = proc.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(``),
+		[]byte(`null`),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "d", msgs[0].Get(0).Metadata().Get("cdc_op"))
+	assert.Equal(t, "tombstone", msgs[0].Get(1).Metadata().Get("cdc_op"))
+	assert.Equal(t, "tombstone", msgs[0].Get(2).Metadata().Get("cdc_op"))
+
+	conf.CDCUnwrap.Tombstones = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
@@ -132,212 +132,249 @@ func Block(typeStr, reason string) {
 
 // String constants representing each processor type.
 const (
-	TypeArchive      = "archive"
-	TypeAvro         = "avro"
-	TypeAWK          = "awk"
-	TypeAWSLambda    = "aws_lambda"
-	TypeBatch        = "batch"
-	TypeBloblang     = "bloblang"
-	TypeBoundsCheck  = "bounds_check"
-	TypeBranch       = "branch"
-	TypeCache        = "cache"
-	TypeCatch        = "catch"
-	TypeCompress     = "compress"
-	TypeConditional  = "conditional"
-	TypeDecode       = "decode"
-	TypeDecompress   = "decompress"
-	TypeDedupe       = "dedupe"
-	TypeEncode       = "encode"
-	TypeFilter       = "filter"
-	TypeFilterParts  = "filter_parts"
-	TypeForEach      = "for_each"
-	TypeGrok         = "grok"
-	TypeGroupBy      = "group_by"
-	TypeGroupByValue = "group_by_value"
-	TypeHash         = "hash"
-	TypeHashSample   = "hash_sample"
-	TypeHTTP         = "http"
-	TypeInsertPart   = "insert_part"
-	TypeJMESPath     = "jmespath"
-	TypeJQ           = "jq"
-	TypeJSON         = "json"
-	TypeJSONSchema   = "json_schema"
-	TypeLambda       = "lambda"
-	TypeLog          = "log"
-	TypeMergeJSON    = "merge_json"
-	TypeMetadata     = "metadata"
-	TypeMetric       = "metric"
-	TypeMongoDB      = "mongodb"
-	TypeNoop         = "noop"
-	TypeNumber       = "number"
-	TypeParallel     = "parallel"
-	TypeParseLog     = "parse_log"
-	TypeProcessBatch = "process_batch"
-	TypeProcessDAG   = "process_dag"
-	TypeProcessField = "process_field"
-	TypeProcessMap   = "process_map"
-	TypeProtobuf     = "protobuf"
-	TypeRateLimit    = "rate_limit"
-	TypeRedis        = "redis"
-	TypeResource     = "resource"
-	TypeSample       = "sample"
-	TypeSelectParts  = "select_parts"
-	TypeSleep        = "sleep"
-	TypeSplit        = "split"
-	TypeSQL          = "sql"
-	TypeSubprocess   = "subprocess"
-	TypeSwitch       = "switch"
-	TypeSyncResponse = "sync_response"
-	TypeText         = "text"
-	TypeTry          = "try"
-	TypeThrottle     = "throttle"
-	TypeUnarchive    = "unarchive"
-	TypeWhile        = "while"
-	TypeWorkflow     = "workflow"
-	TypeXML          = "xml"
+	TypeArchive         = "archive"
+	TypeAvro            = "avro"
+	TypeAWK             = "awk"
+	TypeAWSLambda       = "aws_lambda"
+	TypeBatch           = "batch"
+	TypeBloblang        = "bloblang"
+	TypeBloblangBatch   = "bloblang_batch"
+	TypeBoundsCheck     = "bounds_check"
+	TypeBranch          = "branch"
+	TypeCache           = "cache"
+	TypeCatch           = "catch"
+	TypeCDCUnwrap       = "cdc_unwrap"
+	TypeCompress        = "compress"
+	TypeContract        = "contract"
+	TypeConditional     = "conditional"
+	TypeDecode          = "decode"
+	TypeDecompress      = "decompress"
+	TypeDecryptEnvelope = "decrypt_envelope"
+	TypeDedupe          = "dedupe"
+	TypeEncode          = "encode"
+	TypeEncryptEnvelope = "encrypt_envelope"
+	TypeExplode         = "explode"
+	TypeFilter          = "filter"
+	TypeFilterParts     = "filter_parts"
+	TypeForEach         = "for_each"
+	TypeGrok            = "grok"
+	TypeGroupBy         = "group_by"
+	TypeGroupByValue    = "group_by_value"
+	TypeHash            = "hash"
+	TypeHashSample      = "hash_sample"
+	TypeHTTP            = "http"
+	TypeInsertPart      = "insert_part"
+	TypeJMESPath        = "jmespath"
+	TypeJQ              = "jq"
+	TypeJSON            = "json"
+	TypeJSONSchema      = "json_schema"
+	TypeLambda          = "lambda"
+	TypeLog             = "log"
+	TypeMergeJSON       = "merge_json"
+	TypeMetadata        = "metadata"
+	TypeMetric          = "metric"
+	TypeMongoDB         = "mongodb"
+	TypeNoop            = "noop"
+	TypeNumber          = "number"
+	TypeParallel        = "parallel"
+	TypeParseLog        = "parse_log"
+	TypePriority        = "priority"
+	TypeProcessBatch    = "process_batch"
+	TypeProcessDAG      = "process_dag"
+	TypeProcessField    = "process_field"
+	TypeProcessMap      = "process_map"
+	TypeProtobuf        = "protobuf"
+	TypeRateLimit       = "rate_limit"
+	TypeRedis           = "redis"
+	TypeResource        = "resource"
+	TypeRetry           = "retry"
+	TypeSample          = "sample"
+	TypeSelect          = "select"
+	TypeSelectParts     = "select_parts"
+	TypeSleep           = "sleep"
+	TypeSplit           = "split"
+	TypeSplitStream     = "split_stream"
+	TypeSQL             = "sql"
+	TypeSubprocess      = "subprocess"
+	TypeSwitch          = "switch"
+	TypeSyncResponse    = "sync_response"
+	TypeText            = "text"
+	TypeTry             = "try"
+	TypeThrottle        = "throttle"
+	TypeTTL             = "ttl"
+	TypeUnarchive       = "unarchive"
+	TypeWhile           = "while"
+	TypeWorkflow        = "workflow"
+	TypeXML             = "xml"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all processor types.
 type Config struct {
-	Label        string             `json:"label" yaml:"label"`
-	Type         string             `json:"type" yaml:"type"`
-	Archive      ArchiveConfig      `json:"archive" yaml:"archive"`
-	Avro         AvroConfig         `json:"avro" yaml:"avro"`
-	AWK          AWKConfig          `json:"awk" yaml:"awk"`
-	AWSLambda    LambdaConfig       `json:"aws_lambda" yaml:"aws_lambda"`
-	Batch        BatchConfig        `json:"batch" yaml:"batch"`
-	Bloblang     BloblangConfig     `json:"bloblang" yaml:"bloblang"`
-	BoundsCheck  BoundsCheckConfig  `json:"bounds_check" yaml:"bounds_check"`
-	Branch       BranchConfig       `json:"branch" yaml:"branch"`
-	Cache        CacheConfig        `json:"cache" yaml:"cache"`
-	Catch        CatchConfig        `json:"catch" yaml:"catch"`
-	Compress     CompressConfig     `json:"compress" yaml:"compress"`
-	Conditional  ConditionalConfig  `json:"conditional" yaml:"conditional"`
-	Decode       DecodeConfig       `json:"decode" yaml:"decode"`
-	Decompress   DecompressConfig   `json:"decompress" yaml:"decompress"`
-	Dedupe       DedupeConfig       `json:"dedupe" yaml:"dedupe"`
-	Encode       EncodeConfig       `json:"encode" yaml:"encode"`
-	Filter       FilterConfig       `json:"filter" yaml:"filter"`
-	FilterParts  FilterPartsConfig  `json:"filter_parts" yaml:"filter_parts"`
-	ForEach      ForEachConfig      `json:"for_each" yaml:"for_each"`
-	Grok         GrokConfig         `json:"grok" yaml:"grok"`
-	GroupBy      GroupByConfig      `json:"group_by" yaml:"group_by"`
-	GroupByValue GroupByValueConfig `json:"group_by_value" yaml:"group_by_value"`
-	Hash         HashConfig         `json:"hash" yaml:"hash"`
-	HashSample   HashSampleConfig   `json:"hash_sample" yaml:"hash_sample"`
-	HTTP         HTTPConfig         `json:"http" yaml:"http"`
-	InsertPart   InsertPartConfig   `json:"insert_part" yaml:"insert_part"`
-	JMESPath     JMESPathConfig     `json:"jmespath" yaml:"jmespath"`
-	JQ           JQConfig           `json:"jq" yaml:"jq"`
-	JSON         JSONConfig         `json:"json" yaml:"json"`
-	JSONSchema   JSONSchemaConfig   `json:"json_schema" yaml:"json_schema"`
-	Lambda       LambdaConfig       `json:"lambda" yaml:"lambda"`
-	Log          LogConfig          `json:"log" yaml:"log"`
-	MergeJSON    MergeJSONConfig    `json:"merge_json" yaml:"merge_json"`
-	Metadata     MetadataConfig     `json:"metadata" yaml:"metadata"`
-	Metric       MetricConfig       `json:"metric" yaml:"metric"`
-	MongoDB      MongoDBConfig      `json:"mongodb" yaml:"mongodb"`
-	Noop         NoopConfig         `json:"noop" yaml:"noop"`
-	Number       NumberConfig       `json:"number" yaml:"number"`
-	Plugin       interface{}        `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	Parallel     ParallelConfig     `json:"parallel" yaml:"parallel"`
-	ParseLog     ParseLogConfig     `json:"parse_log" yaml:"parse_log"`
-	ProcessBatch ForEachConfig      `json:"process_batch" yaml:"process_batch"`
-	ProcessDAG   ProcessDAGConfig   `json:"process_dag" yaml:"process_dag"`
-	ProcessField ProcessFieldConfig `json:"process_field" yaml:"process_field"`
-	ProcessMap   ProcessMapConfig   `json:"process_map" yaml:"process_map"`
-	Protobuf     ProtobufConfig     `json:"protobuf" yaml:"protobuf"`
-	RateLimit    RateLimitConfig    `json:"rate_limit" yaml:"rate_limit"`
-	Redis        RedisConfig        `json:"redis" yaml:"redis"`
-	Resource     string             `json:"resource" yaml:"resource"`
-	Sample       SampleConfig       `json:"sample" yaml:"sample"`
-	SelectParts  SelectPartsConfig  `json:"select_parts" yaml:"select_parts"`
-	Sleep        SleepConfig        `json:"sleep" yaml:"sleep"`
-	Split        SplitConfig        `json:"split" yaml:"split"`
-	SQL          SQLConfig          `json:"sql" yaml:"sql"`
-	Subprocess   SubprocessConfig   `json:"subprocess" yaml:"subprocess"`
-	Switch       SwitchConfig       `json:"switch" yaml:"switch"`
-	SyncResponse SyncResponseConfig `json:"sync_response" yaml:"sync_response"`
-	Text         TextConfig         `json:"text" yaml:"text"`
-	Try          TryConfig          `json:"try" yaml:"try"`
-	Throttle     ThrottleConfig     `json:"throttle" yaml:"throttle"`
-	Unarchive    UnarchiveConfig    `json:"unarchive" yaml:"unarchive"`
-	While        WhileConfig        `json:"while" yaml:"while"`
-	Workflow     WorkflowConfig     `json:"workflow" yaml:"workflow"`
-	XML          XMLConfig          `json:"xml" yaml:"xml"`
+	Label           string                `json:"label" yaml:"label"`
+	Type            string                `json:"type" yaml:"type"`
+	Archive         ArchiveConfig         `json:"archive" yaml:"archive"`
+	Avro            AvroConfig            `json:"avro" yaml:"avro"`
+	AWK             AWKConfig             `json:"awk" yaml:"awk"`
+	AWSLambda       LambdaConfig          `json:"aws_lambda" yaml:"aws_lambda"`
+	Batch           BatchConfig           `json:"batch" yaml:"batch"`
+	Bloblang        BloblangConfig        `json:"bloblang" yaml:"bloblang"`
+	BloblangBatch   BloblangBatchConfig   `json:"bloblang_batch" yaml:"bloblang_batch"`
+	BoundsCheck     BoundsCheckConfig     `json:"bounds_check" yaml:"bounds_check"`
+	Branch          BranchConfig          `json:"branch" yaml:"branch"`
+	Cache           CacheConfig           `json:"cache" yaml:"cache"`
+	Catch           CatchConfig           `json:"catch" yaml:"catch"`
+	CDCUnwrap       CDCUnwrapConfig       `json:"cdc_unwrap" yaml:"cdc_unwrap"`
+	Compress        CompressConfig        `json:"compress" yaml:"compress"`
+	Contract        ContractConfig        `json:"contract" yaml:"contract"`
+	Conditional     ConditionalConfig     `json:"conditional" yaml:"conditional"`
+	Decode          DecodeConfig          `json:"decode" yaml:"decode"`
+	Decompress      DecompressConfig      `json:"decompress" yaml:"decompress"`
+	DecryptEnvelope DecryptEnvelopeConfig `json:"decrypt_envelope" yaml:"decrypt_envelope"`
+	Dedupe          DedupeConfig          `json:"dedupe" yaml:"dedupe"`
+	Enabled         bool                  `json:"enabled" yaml:"enabled"`
+	Encode          EncodeConfig          `json:"encode" yaml:"encode"`
+	EncryptEnvelope EncryptEnvelopeConfig `json:"encrypt_envelope" yaml:"encrypt_envelope"`
+	Explode         ExplodeConfig         `json:"explode" yaml:"explode"`
+	Filter          FilterConfig          `json:"filter" yaml:"filter"`
+	FilterParts     FilterPartsConfig     `json:"filter_parts" yaml:"filter_parts"`
+	ForEach         ForEachConfig         `json:"for_each" yaml:"for_each"`
+	Grok            GrokConfig            `json:"grok" yaml:"grok"`
+	GroupBy         GroupByConfig         `json:"group_by" yaml:"group_by"`
+	GroupByValue    GroupByValueConfig    `json:"group_by_value" yaml:"group_by_value"`
+	Hash            HashConfig            `json:"hash" yaml:"hash"`
+	HashSample      HashSampleConfig      `json:"hash_sample" yaml:"hash_sample"`
+	HTTP            HTTPConfig            `json:"http" yaml:"http"`
+	InsertPart      InsertPartConfig      `json:"insert_part" yaml:"insert_part"`
+	JMESPath        JMESPathConfig        `json:"jmespath" yaml:"jmespath"`
+	JQ              JQConfig              `json:"jq" yaml:"jq"`
+	JSON            JSONConfig            `json:"json" yaml:"json"`
+	JSONSchema      JSONSchemaConfig      `json:"json_schema" yaml:"json_schema"`
+	Lambda          LambdaConfig          `json:"lambda" yaml:"lambda"`
+	Log             LogConfig             `json:"log" yaml:"log"`
+	MergeJSON       MergeJSONConfig       `json:"merge_json" yaml:"merge_json"`
+	Metadata        MetadataConfig        `json:"metadata" yaml:"metadata"`
+	Metric          MetricConfig          `json:"metric" yaml:"metric"`
+	MongoDB         MongoDBConfig         `json:"mongodb" yaml:"mongodb"`
+	Noop            NoopConfig            `json:"noop" yaml:"noop"`
+	Number          NumberConfig          `json:"number" yaml:"number"`
+	Plugin          interface{}           `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Parallel        ParallelConfig        `json:"parallel" yaml:"parallel"`
+	ParseLog        ParseLogConfig        `json:"parse_log" yaml:"parse_log"`
+	Priority        PriorityConfig        `json:"priority" yaml:"priority"`
+	ProcessBatch    ForEachConfig         `json:"process_batch" yaml:"process_batch"`
+	ProcessDAG      ProcessDAGConfig      `json:"process_dag" yaml:"process_dag"`
+	ProcessField    ProcessFieldConfig    `json:"process_field" yaml:"process_field"`
+	ProcessMap      ProcessMapConfig      `json:"process_map" yaml:"process_map"`
+	Protobuf        ProtobufConfig        `json:"protobuf" yaml:"protobuf"`
+	RateLimit       RateLimitConfig       `json:"rate_limit" yaml:"rate_limit"`
+	Redis           RedisConfig           `json:"redis" yaml:"redis"`
+	Resource        string                `json:"resource" yaml:"resource"`
+	Retry           RetryConfig           `json:"retry" yaml:"retry"`
+	Sample          SampleConfig          `json:"sample" yaml:"sample"`
+	Select          SelectConfig          `json:"select" yaml:"select"`
+	SelectParts     SelectPartsConfig     `json:"select_parts" yaml:"select_parts"`
+	Shared          bool                  `json:"shared" yaml:"shared"`
+	Sleep           SleepConfig           `json:"sleep" yaml:"sleep"`
+	Split           SplitConfig           `json:"split" yaml:"split"`
+	SplitStream     SplitStreamConfig     `json:"split_stream" yaml:"split_stream"`
+	SQL             SQLConfig             `json:"sql" yaml:"sql"`
+	Subprocess      SubprocessConfig      `json:"subprocess" yaml:"subprocess"`
+	Switch          SwitchConfig          `json:"switch" yaml:"switch"`
+	SyncResponse    SyncResponseConfig    `json:"sync_response" yaml:"sync_response"`
+	Text            TextConfig            `json:"text" yaml:"text"`
+	Try             TryConfig             `json:"try" yaml:"try"`
+	Throttle        ThrottleConfig        `json:"throttle" yaml:"throttle"`
+	TTL             TTLConfig             `json:"ttl" yaml:"ttl"`
+	Unarchive       UnarchiveConfig       `json:"unarchive" yaml:"unarchive"`
+	While           WhileConfig           `json:"while" yaml:"while"`
+	Workflow        WorkflowConfig        `json:"workflow" yaml:"workflow"`
+	XML             XMLConfig             `json:"xml" yaml:"xml"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Label:        "",
-		Type:         "bounds_check",
-		Archive:      NewArchiveConfig(),
-		Avro:         NewAvroConfig(),
-		AWK:          NewAWKConfig(),
-		AWSLambda:    NewLambdaConfig(),
-		Batch:        NewBatchConfig(),
-		Bloblang:     NewBloblangConfig(),
-		BoundsCheck:  NewBoundsCheckConfig(),
-		Branch:       NewBranchConfig(),
-		Cache:        NewCacheConfig(),
-		Catch:        NewCatchConfig(),
-		Compress:     NewCompressConfig(),
-		Conditional:  NewConditionalConfig(),
-		Decode:       NewDecodeConfig(),
-		Decompress:   NewDecompressConfig(),
-		Dedupe:       NewDedupeConfig(),
-		Encode:       NewEncodeConfig(),
-		Filter:       NewFilterConfig(),
-		FilterParts:  NewFilterPartsConfig(),
-		ForEach:      NewForEachConfig(),
-		Grok:         NewGrokConfig(),
-		GroupBy:      NewGroupByConfig(),
-		GroupByValue: NewGroupByValueConfig(),
-		Hash:         NewHashConfig(),
-		HashSample:   NewHashSampleConfig(),
-		HTTP:         NewHTTPConfig(),
-		InsertPart:   NewInsertPartConfig(),
-		JMESPath:     NewJMESPathConfig(),
-		JQ:           NewJQConfig(),
-		JSON:         NewJSONConfig(),
-		JSONSchema:   NewJSONSchemaConfig(),
-		Lambda:       NewLambdaConfig(),
-		Log:          NewLogConfig(),
-		MergeJSON:    NewMergeJSONConfig(),
-		Metadata:     NewMetadataConfig(),
-		Metric:       NewMetricConfig(),
-		MongoDB:      NewMongoDBConfig(),
-		Noop:         NewNoopConfig(),
-		Number:       NewNumberConfig(),
-		Plugin:       nil,
-		Parallel:     NewParallelConfig(),
-		ParseLog:     NewParseLogConfig(),
-		ProcessBatch: NewForEachConfig(),
-		ProcessDAG:   NewProcessDAGConfig(),
-		ProcessField: NewProcessFieldConfig(),
-		ProcessMap:   NewProcessMapConfig(),
-		Protobuf:     NewProtobufConfig(),
-		RateLimit:    NewRateLimitConfig(),
-		Redis:        NewRedisConfig(),
-		Resource:     "",
-		Sample:       NewSampleConfig(),
-		SelectParts:  NewSelectPartsConfig(),
-		Sleep:        NewSleepConfig(),
-		Split:        NewSplitConfig(),
-		SQL:          NewSQLConfig(),
-		Subprocess:   NewSubprocessConfig(),
-		Switch:       NewSwitchConfig(),
-		SyncResponse: NewSyncResponseConfig(),
-		Text:         NewTextConfig(),
-		Try:          NewTryConfig(),
-		Throttle:     NewThrottleConfig(),
-		Unarchive:    NewUnarchiveConfig(),
-		While:        NewWhileConfig(),
-		Workflow:     NewWorkflowConfig(),
-		XML:          NewXMLConfig(),
+		Label:           "",
+		Type:            "bounds_check",
+		Archive:         NewArchiveConfig(),
+		Avro:            NewAvroConfig(),
+		AWK:             NewAWKConfig(),
+		AWSLambda:       NewLambdaConfig(),
+		Batch:           NewBatchConfig(),
+		Bloblang:        NewBloblangConfig(),
+		BloblangBatch:   NewBloblangBatchConfig(),
+		BoundsCheck:     NewBoundsCheckConfig(),
+		Branch:          NewBranchConfig(),
+		Cache:           NewCacheConfig(),
+		Catch:           NewCatchConfig(),
+		CDCUnwrap:       NewCDCUnwrapConfig(),
+		Compress:        NewCompressConfig(),
+		Contract:        NewContractConfig(),
+		Conditional:     NewConditionalConfig(),
+		Decode:          NewDecodeConfig(),
+		Decompress:      NewDecompressConfig(),
+		DecryptEnvelope: NewDecryptEnvelopeConfig(),
+		Dedupe:          NewDedupeConfig(),
+		Enabled:         true,
+		Encode:          NewEncodeConfig(),
+		EncryptEnvelope: NewEncryptEnvelopeConfig(),
+		Explode:         NewExplodeConfig(),
+		Filter:          NewFilterConfig(),
+		FilterParts:     NewFilterPartsConfig(),
+		ForEach:         NewForEachConfig(),
+		Grok:            NewGrokConfig(),
+		GroupBy:         NewGroupByConfig(),
+		GroupByValue:    NewGroupByValueConfig(),
+		Hash:            NewHashConfig(),
+		HashSample:      NewHashSampleConfig(),
+		HTTP:            NewHTTPConfig(),
+		InsertPart:      NewInsertPartConfig(),
+		JMESPath:        NewJMESPathConfig(),
+		JQ:              NewJQConfig(),
+		JSON:            NewJSONConfig(),
+		JSONSchema:      NewJSONSchemaConfig(),
+		Lambda:          NewLambdaConfig(),
+		Log:             NewLogConfig(),
+		MergeJSON:       NewMergeJSONConfig(),
+		Metadata:        NewMetadataConfig(),
+		Metric:          NewMetricConfig(),
+		MongoDB:         NewMongoDBConfig(),
+		Noop:            NewNoopConfig(),
+		Number:          NewNumberConfig(),
+		Plugin:          nil,
+		Parallel:        NewParallelConfig(),
+		ParseLog:        NewParseLogConfig(),
+		Priority:        NewPriorityConfig(),
+		ProcessBatch:    NewForEachConfig(),
+		ProcessDAG:      NewProcessDAGConfig(),
+		ProcessField:    NewProcessFieldConfig(),
+		ProcessMap:      NewProcessMapConfig(),
+		Protobuf:        NewProtobufConfig(),
+		RateLimit:       NewRateLimitConfig(),
+		Redis:           NewRedisConfig(),
+		Resource:        "",
+		Retry:           NewRetryConfig(),
+		Sample:          NewSampleConfig(),
+		Select:          NewSelectConfig(),
+		SelectParts:     NewSelectPartsConfig(),
+		Shared:          false,
+		Sleep:           NewSleepConfig(),
+		Split:           NewSplitConfig(),
+		SplitStream:     NewSplitStreamConfig(),
+		SQL:             NewSQLConfig(),
+		Subprocess:      NewSubprocessConfig(),
+		Switch:          NewSwitchConfig(),
+		SyncResponse:    NewSyncResponseConfig(),
+		Text:            NewTextConfig(),
+		Try:             NewTryConfig(),
+		Throttle:        NewThrottleConfig(),
+		TTL:             NewTTLConfig(),
+		Unarchive:       NewUnarchiveConfig(),
+		While:           NewWhileConfig(),
+		Workflow:        NewWorkflowConfig(),
+		XML:             NewXMLConfig(),
 	}
 }
 
@@ -380,26 +417,23 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "line ") {
-			return err
-		}
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var spec docs.ComponentSpec
 	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeProcessor, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if spec.Plugin {
 		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 			conf := spec.confConstructor()
 			if err = pluginNode.Decode(conf); err != nil {
-				return fmt.Errorf("line %v: %v", value.Line, err)
+				return docs.ErrorAtNode(value, err)
 			}
 			aliased.Plugin = conf
 		} else {
@@ -546,6 +580,10 @@ func New(
 	}); ok {
 		return mgrV2.NewProcessor(conf)
 	}
+	if !conf.Enabled {
+		log.Infof("Processor of type '%v' is disabled and will be skipped\n", conf.Type)
+		return &Noop{}, nil
+	}
 	if c, ok := Constructors[conf.Type]; ok {
 		return c.constructor(conf, mgr, log, stats)
 	}
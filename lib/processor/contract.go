@@ -0,0 +1,282 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+	jsonschema "github.com/xeipuuv/gojsonschema"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeContract] = TypeSpec{
+		constructor: NewContract,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Asserts that messages satisfy a contract made of named rules, either flagging
+violating messages as failed or only counting violations in metrics.`,
+		Description: `
+A contract consists of a list of rules, each being a
+[Bloblang query](/docs/guides/bloblang/about/) that should return a boolean,
+and optionally a [JSON Schema](https://json-schema.org/) which is checked as a
+rule named ` + "`schema`" + `. Rules are checked in order and a message violates
+the contract at the first rule that either returns ` + "`false`" + ` or fails
+to execute. The payload of messages is never changed.
+
+### Modes
+
+In ` + "`enforce`" + ` mode messages that violate the contract are flagged as
+having failed, where they can be handled using the patterns outlined
+[here](/docs/configuration/error_handling). This is useful for failing fast in
+staging environments.
+
+In ` + "`monitor`" + ` mode violations are only counted and logged at the debug
+level, and messages continue through the pipeline unaffected. This is useful for
+observing contracts in production without risking the flow of data.
+
+### Metrics
+
+The counter metric ` + "`violation`" + `, labelled by ` + "`contract`" + ` (the
+field ` + "`name`" + `) and ` + "`rule`" + ` (the first rule violated), tracks
+violations in both modes. The counter ` + "`passed`" + `, labelled by
+` + "`contract`" + `, tracks messages that satisfied every rule.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("name", "A name identifying the contract, which is used as the label `contract` of metrics."),
+			docs.FieldCommon("mode", "Whether violations should flag messages as failed or only be counted.").HasOptions("enforce", "monitor"),
+			docs.FieldCommon("rules", "A list of named rules that messages must satisfy.").Array().WithChildren(
+				docs.FieldString("name", "A name identifying the rule, which is used as the label `rule` of metrics.").HasDefault(""),
+				docs.FieldString(
+					"check",
+					"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean indicating whether the message satisfies the rule.",
+					`this.id.type() == "string"`,
+					`this.amount.type() == "number" && this.amount >= 0`,
+				).HasDefault("").Linter(docs.LintBloblangMapping),
+			),
+			docs.FieldAdvanced("schema", "An optional JSON Schema that messages must satisfy, which is checked after all other rules as a rule named `schema`."),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Monitoring Orders",
+				Summary: `
+Here we assert that after parsing every order has a string ` + "`id`" + ` and a
+numeric ` + "`amount`" + `. In production we only wish to count violations per
+rule, but by setting the environment variable ` + "`CONTRACT_MODE`" + ` to
+` + "`enforce`" + ` in staging violating orders are flagged as failed and can
+be routed elsewhere:`,
+				Config: `
+pipeline:
+  processors:
+    - contract:
+        name: orders
+        mode: ${CONTRACT_MODE:monitor}
+        rules:
+          - name: has_id
+            check: this.id.type() == "string"
+          - name: numeric_amount
+            check: this.amount.type() == "number"
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ContractRuleConfig contains configuration fields for a rule of a contract.
+type ContractRuleConfig struct {
+	Name  string `json:"name" yaml:"name"`
+	Check string `json:"check" yaml:"check"`
+}
+
+// ContractConfig contains configuration fields for the Contract processor.
+type ContractConfig struct {
+	Name   string               `json:"name" yaml:"name"`
+	Mode   string               `json:"mode" yaml:"mode"`
+	Rules  []ContractRuleConfig `json:"rules" yaml:"rules"`
+	Schema string               `json:"schema" yaml:"schema"`
+}
+
+// NewContractConfig returns a ContractConfig with default values.
+func NewContractConfig() ContractConfig {
+	return ContractConfig{
+		Name:   "",
+		Mode:   "enforce",
+		Rules:  []ContractRuleConfig{},
+		Schema: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type contractRule struct {
+	name  string
+	check *mapping.Executor
+}
+
+// Contract is a processor that checks messages against a list of rules.
+type Contract struct {
+	name    string
+	enforce bool
+	rules   []contractRule
+	schema  *jsonschema.Schema
+	log     log.Modular
+
+	mCount     metrics.StatCounter
+	mPassed    metrics.StatCounter
+	mViolation metrics.StatCounterVec
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewContract returns a Contract processor.
+func NewContract(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c := &Contract{
+		name: conf.Contract.Name,
+		log:  log,
+
+		mCount:     stats.GetCounter("count"),
+		mPassed:    stats.GetCounterVec("passed", []string{"contract"}).With(conf.Contract.Name),
+		mViolation: stats.GetCounterVec("violation", []string{"contract", "rule"}),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	switch conf.Contract.Mode {
+	case "enforce":
+		c.enforce = true
+	case "monitor":
+	default:
+		return nil, fmt.Errorf("mode not recognised: %v", conf.Contract.Mode)
+	}
+
+	for i, ruleConf := range conf.Contract.Rules {
+		if ruleConf.Name == "" {
+			return nil, fmt.Errorf("rule %v must have a name", i)
+		}
+		check, err := bloblang.NewMapping("", ruleConf.Check)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rule '%v' check: %w", ruleConf.Name, err)
+		}
+		c.rules = append(c.rules, contractRule{
+			name:  ruleConf.Name,
+			check: check,
+		})
+	}
+
+	if conf.Contract.Schema != "" {
+		var err error
+		if c.schema, err = jsonschema.NewSchema(jsonschema.NewStringLoader(conf.Contract.Schema)); err != nil {
+			return nil, fmt.Errorf("failed to load JSON schema definition: %v", err)
+		}
+	}
+
+	if len(c.rules) == 0 && c.schema == nil {
+		return nil, errors.New("at least one rule or a schema must be provided")
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// violation returns the name of the first rule violated by a message along
+// with a description of the violation, or an empty rule name if the message
+// satisfies the contract.
+func (c *Contract) violation(index int, msg types.Message) (string, error) {
+	for _, r := range c.rules {
+		passed, err := r.check.QueryPart(index, msg)
+		if err != nil {
+			return r.name, fmt.Errorf("contract '%v' rule '%v' failed: %w", c.name, r.name, err)
+		}
+		if !passed {
+			return r.name, fmt.Errorf("contract '%v' rule '%v' violated", c.name, r.name)
+		}
+	}
+	if c.schema == nil {
+		return "", nil
+	}
+
+	jsonPart, err := msg.Get(index).JSON()
+	if err != nil {
+		return "schema", fmt.Errorf("contract '%v' rule 'schema' failed: %w", c.name, err)
+	}
+	result, err := c.schema.Validate(jsonschema.NewGoLoader(jsonPart))
+	if err != nil {
+		return "schema", fmt.Errorf("contract '%v' rule 'schema' failed: %w", c.name, err)
+	}
+	if !result.Valid() {
+		descs := make([]string, 0, len(result.Errors()))
+		for _, desc := range result.Errors() {
+			descs = append(descs, desc.String())
+		}
+		return "schema", fmt.Errorf("contract '%v' rule 'schema' violated: %v", c.name, strings.Join(descs, ", "))
+	}
+	return "", nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *Contract) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypeContract, msg)
+	defer func() {
+		for _, s := range spans {
+			s.Finish()
+		}
+	}()
+
+	newMsg := msg.Copy()
+	_ = newMsg.Iter(func(i int, part types.Part) error {
+		rule, err := c.violation(i, msg)
+		if rule == "" {
+			c.mPassed.Incr(1)
+			return nil
+		}
+		c.mViolation.With(c.name, rule).Incr(1)
+		c.log.Debugf("Message violated contract: %v\n", err)
+		if !c.enforce {
+			return nil
+		}
+		FlagErr(part, err)
+		spans[i].SetTag("error", true)
+		spans[i].LogFields(
+			olog.String("event", "error"),
+			olog.String("type", err.Error()),
+		)
+		return nil
+	})
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *Contract) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *Contract) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractModes(t *testing.T) {
+	for _, mode := range []string{"enforce", "monitor"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			mockStats := &mockLabelledMetric{
+				Type:  metrics.Noop(),
+				incrs: map[string][]int64{},
+			}
+
+			conf := NewConfig()
+			conf.Type = TypeContract
+			conf.Contract.Name = "orders"
+			conf.Contract.Mode = mode
+			conf.Contract.Rules = []ContractRuleConfig{
+				{Name: "has_id", Check: `this.id.type() == "string"`},
+				{Name: "numeric_amount", Check: `this.amount.type() == "number"`},
+			}
+			conf.Contract.Schema = `{"type":"object","required":["currency"]}`
+
+			proc, err := New(conf, nil, log.Noop(), mockStats)
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New([][]byte{
+				[]byte(`{"id":"a","amount":10,"currency":"GBP"}`),
+				[]byte(`{"id":5,"amount":10}`),
+				[]byte(`{"id":"c","amount":"ten"}`),
+				[]byte(`{"id":"d","amount":10}`),
+				[]byte(`not json`),
+			}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 5, msgs[0].Len())
+
+			assert.False(t, HasFailed(msgs[0].Get(0)))
+			for i := 1; i < 5; i++ {
+				assert.Equal(t, mode == "enforce", HasFailed(msgs[0].Get(i)), i)
+			}
+			if mode == "enforce" {
+				assert.Equal(t, "contract 'orders' rule 'has_id' violated", GetFail(msgs[0].Get(1)))
+				assert.Equal(t, "contract 'orders' rule 'numeric_amount' violated", GetFail(msgs[0].Get(2)))
+				assert.Contains(t, GetFail(msgs[0].Get(3)), "contract 'orders' rule 'schema' violated")
+			}
+
+			assert.Equal(t, map[string][]int64{
+				"orders":                {1},
+				"orders,has_id":         {1, 1},
+				"orders,numeric_amount": {1},
+				"orders,schema":         {1},
+			}, mockStats.incrs)
+		})
+	}
+}
+
+func TestContractBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeContract
+	conf.Contract.Mode = "nope"
+	conf.Contract.Rules = []ContractRuleConfig{{Name: "foo", Check: "true"}}
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Contract.Mode = "monitor"
+	conf.Contract.Rules = nil
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Contract.Rules = []ContractRuleConfig{{Check: "true"}}
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
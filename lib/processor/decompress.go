@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"compress/flate"
-	"compress/gzip"
 	"compress/zlib"
 	"fmt"
 	"io"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -31,8 +31,23 @@ func init() {
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
 decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
+		Description: `
+## Gzip Members
+
+A gzip message may consist of multiple concatenated members, as is common with
+files produced by log rotation, in which case the contents of all members are
+decompressed. The field ` + "`on_corruption`" + ` determines how a member that
+fails to decompress is handled, where ` + "`truncate`" + ` keeps the data
+decompressed up to the point of corruption and ` + "`skip_member`" + ` discards the
+corrupt member and resumes from the next member found. Each corrupt member that
+is truncated or skipped increments the metric ` + "`corrupt_members`" + `.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4"),
+			docs.FieldAdvanced("on_corruption", "How to handle a corrupt member of a gzip message. This field is only used by the `gzip` algorithm.").HasAnnotatedOptions(
+				"fail", "Fail the message part.",
+				"truncate", "Keep the data decompressed up to the point of corruption and ignore the remainder of the message part.",
+				"skip_member", "Discard the corrupt member and continue with the next member of the message part.",
+			).HasDefault("fail").AtVersion("3.50.0"),
 			PartsFieldSpec,
 		},
 	}
@@ -42,15 +57,17 @@ decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
 
 // DecompressConfig contains configuration fields for the Decompress processor.
 type DecompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Parts     []int  `json:"parts" yaml:"parts"`
+	Algorithm    string `json:"algorithm" yaml:"algorithm"`
+	OnCorruption string `json:"on_corruption" yaml:"on_corruption"`
+	Parts        []int  `json:"parts" yaml:"parts"`
 }
 
 // NewDecompressConfig returns a DecompressConfig with default values.
 func NewDecompressConfig() DecompressConfig {
 	return DecompressConfig{
-		Algorithm: "gzip",
-		Parts:     []int{},
+		Algorithm:    "gzip",
+		OnCorruption: codec.GzipCorruptionFail,
+		Parts:        []int{},
 	}
 }
 
@@ -58,19 +75,24 @@ func NewDecompressConfig() DecompressConfig {
 
 type decompressFunc func(bytes []byte) ([]byte, error)
 
-func gzipDecompress(b []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewBuffer(b))
-	if err != nil {
-		return nil, err
+func gzipDecompressor(onCorruption string, onCorruptMember func()) (decompressFunc, error) {
+	switch onCorruption {
+	case codec.GzipCorruptionFail, codec.GzipCorruptionTruncate, codec.GzipCorruptionSkipMember:
+	default:
+		return nil, fmt.Errorf("on_corruption value not recognised: %v", onCorruption)
 	}
+	return func(b []byte) ([]byte, error) {
+		r, err := codec.NewGzipReader(bytes.NewBuffer(b), onCorruption, onCorruptMember)
+		if err != nil {
+			return nil, err
+		}
 
-	outBuf := bytes.Buffer{}
-	if _, err = io.Copy(&outBuf, r); err != nil {
-		r.Close()
-		return nil, err
-	}
-	r.Close()
-	return outBuf.Bytes(), nil
+		outBuf := bytes.Buffer{}
+		if _, err = io.Copy(&outBuf, r); err != nil {
+			return nil, err
+		}
+		return outBuf.Bytes(), nil
+	}, nil
 }
 
 func snappyDecompress(b []byte) ([]byte, error) {
@@ -128,8 +150,6 @@ func lz4Decompress(b []byte) ([]byte, error) {
 
 func strToDecompressor(str string) (decompressFunc, error) {
 	switch str {
-	case "gzip":
-		return gzipDecompress, nil
 	case "zlib":
 		return zlibDecompress, nil
 	case "flate":
@@ -155,31 +175,41 @@ type Decompress struct {
 	log   log.Modular
 	stats metrics.Type
 
-	mCount     metrics.StatCounter
-	mErr       metrics.StatCounter
-	mSent      metrics.StatCounter
-	mBatchSent metrics.StatCounter
+	mCount          metrics.StatCounter
+	mErr            metrics.StatCounter
+	mCorruptMembers metrics.StatCounter
+	mSent           metrics.StatCounter
+	mBatchSent      metrics.StatCounter
 }
 
 // NewDecompress returns a Decompress processor.
 func NewDecompress(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	dcor, err := strToDecompressor(conf.Decompress.Algorithm)
+	d := &Decompress{
+		conf:  conf.Decompress,
+		log:   log,
+		stats: stats,
+
+		mCount:          stats.GetCounter("count"),
+		mErr:            stats.GetCounter("error"),
+		mCorruptMembers: stats.GetCounter("corrupt_members"),
+		mSent:           stats.GetCounter("sent"),
+		mBatchSent:      stats.GetCounter("batch.sent"),
+	}
+
+	var err error
+	if conf.Decompress.Algorithm == "gzip" {
+		d.decomp, err = gzipDecompressor(conf.Decompress.OnCorruption, func() {
+			d.mCorruptMembers.Incr(1)
+		})
+	} else {
+		d.decomp, err = strToDecompressor(conf.Decompress.Algorithm)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &Decompress{
-		conf:   conf.Decompress,
-		decomp: dcor,
-		log:    log,
-		stats:  stats,
-
-		mCount:     stats.GetCounter("count"),
-		mErr:       stats.GetCounter("error"),
-		mSent:      stats.GetCounter("sent"),
-		mBatchSent: stats.GetCounter("batch.sent"),
-	}, nil
+	return d, nil
 }
 
 //------------------------------------------------------------------------------
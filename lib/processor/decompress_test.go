@@ -13,6 +13,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/golang/snappy"
 	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecompressBadAlgo(t *testing.T) {
@@ -353,3 +355,60 @@ func TestDecompressIndexBounds(t *testing.T) {
 		}
 	}
 }
+
+func TestDecompressGZIPCorruptMember(t *testing.T) {
+	members := [][]byte{}
+	for _, data := range []string{"first member", "second member", "third member"} {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(data))
+		zw.Close()
+		members = append(members, buf.Bytes())
+	}
+
+	// Corrupt the checksum of the middle member.
+	members[1][len(members[1])-8] ^= 0xff
+	input := bytes.Join(members, nil)
+
+	tests := map[string]struct {
+		output  string
+		failed  bool
+		corrupt int64
+	}{
+		"fail":        {failed: true},
+		"truncate":    {output: "first membersecond member", corrupt: 1},
+		"skip_member": {output: "first memberthird member", corrupt: 1},
+	}
+
+	for mode, test := range tests {
+		mode, test := mode, test
+		t.Run(mode, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Decompress.OnCorruption = mode
+
+			stats := metrics.NewLocal()
+			proc, err := NewDecompress(conf, nil, log.Noop(), stats)
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			if test.failed {
+				assert.True(t, HasFailed(msgs[0].Get(0)))
+			} else {
+				assert.False(t, HasFailed(msgs[0].Get(0)))
+				assert.Equal(t, test.output, string(msgs[0].Get(0).Get()))
+			}
+			assert.Equal(t, test.corrupt, stats.GetCounters()["corrupt_members"])
+		})
+	}
+}
+
+func TestDecompressBadOnCorruption(t *testing.T) {
+	conf := NewConfig()
+	conf.Decompress.OnCorruption = "nope"
+
+	_, err := NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
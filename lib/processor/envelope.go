@@ -0,0 +1,612 @@
+package processor
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeEncryptEnvelope] = TypeSpec{
+		constructor: NewEncryptEnvelope,
+		Categories: []Category{
+			CategoryIntegration,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Encrypts messages client-side with AES-GCM using data keys generated by AWS KMS,
+replacing the contents of each message with a JSON envelope that contains the
+ciphertext along with the encrypted data key.`,
+		Description: `
+Data keys are obtained with the KMS ` + "`GenerateDataKey`" + ` operation and
+the resulting envelope has the following structure, where binary fields are
+base64 encoded:
+
+` + "```json" + `
+{
+  "ciphertext": "...",
+  "encrypted_key": "...",
+  "key_id": "arn:aws:kms:us-east-1:123456789012:key/...",
+  "nonce": "..."
+}
+` + "```" + `
+
+Envelopes can be decrypted with the
+` + "[`decrypt_envelope` processor](/docs/components/processors/decrypt_envelope)" + `,
+or by any other client that decrypts the encrypted key with KMS and opens the
+ciphertext using AES-GCM with the given nonce. Metadata is left unchanged.
+
+### Data Keys
+
+In order to avoid a KMS request for every message a data key is cached and
+reused for a duration of ` + "`data_key_ttl`" + `, and optionally for a maximum
+number of messages ` + "`data_key_max_messages`" + `, after which a new key is
+generated. The plaintext of a data key is discarded as soon as a cipher has
+been created from it. Setting ` + "`data_key_ttl`" + ` to ` + "`0s`" + `
+disables caching, in which case a new data key is generated for each batch of
+messages.
+
+### Error Handling
+
+If a data key cannot be obtained then the batch is rejected rather than being
+allowed to continue through the pipeline unencrypted, which results in the
+messages being nacked at the input.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](/docs/guides/aws).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("key_id", "The ID, ARN or alias of the KMS key used to generate data keys.", "alias/benthos", "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			docs.FieldAdvanced("encryption_context", "A map of key/value pairs bound to each data key, which must be provided again in order to decrypt it.", map[string]string{"purpose": "archive"}),
+			docs.FieldCommon("data_key_ttl", "The maximum period for which a data key is reused to encrypt messages. Set to `0s` in order to generate a new data key for each batch.", "5m", "1h", "0s"),
+			docs.FieldAdvanced("data_key_max_messages", "The maximum number of messages to encrypt with a single data key, or `0` for no limit."),
+		}.Merge(session.FieldSpecs()),
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Encrypted S3 Archive",
+				Summary: `
+Here we encrypt each message with a data key that is rotated every ten minutes
+before writing it to S3:`,
+				Config: `
+pipeline:
+  processors:
+    - encrypt_envelope:
+        key_id: alias/benthos-archive
+        data_key_ttl: 10m
+
+output:
+  aws_s3:
+    bucket: example-archive
+    path: ${! timestamp_unix_nano() }.json.enc
+`,
+			},
+		},
+	}
+
+	Constructors[TypeDecryptEnvelope] = TypeSpec{
+		constructor: NewDecryptEnvelope,
+		Categories: []Category{
+			CategoryIntegration,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Decrypts messages that were encrypted by the
+` + "[`encrypt_envelope` processor](/docs/components/processors/encrypt_envelope)" + `,
+replacing each envelope with the original contents of the message.`,
+		Description: `
+The encrypted data key of each envelope is decrypted with the KMS
+` + "`Decrypt`" + ` operation. Only a single request is made for each distinct
+data key within a batch, and decrypted keys are cached for a duration of
+` + "`data_key_ttl`" + `. Setting ` + "`data_key_ttl`" + ` to ` + "`0s`" + `
+disables caching across batches.
+
+If a message cannot be decrypted it passes through unchanged but is flagged as
+having failed, where it can be handled using the patterns outlined
+[here](/docs/configuration/error_handling).
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](/docs/guides/aws).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldAdvanced("key_id", "An optional ID, ARN or alias of the KMS key that data keys must have been generated with, decryption of data keys generated by any other key fails."),
+			docs.FieldAdvanced("encryption_context", "A map of key/value pairs that data keys were bound to when they were generated.", map[string]string{"purpose": "archive"}),
+			docs.FieldCommon("data_key_ttl", "The maximum period for which a decrypted data key is cached. Set to `0s` in order to disable caching across batches.", "5m", "1h", "0s"),
+		}.Merge(session.FieldSpecs()),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// EncryptEnvelopeConfig contains configuration fields for the EncryptEnvelope
+// processor.
+type EncryptEnvelopeConfig struct {
+	session.Config     `json:",inline" yaml:",inline"`
+	KeyID              string            `json:"key_id" yaml:"key_id"`
+	EncryptionContext  map[string]string `json:"encryption_context" yaml:"encryption_context"`
+	DataKeyTTL         string            `json:"data_key_ttl" yaml:"data_key_ttl"`
+	DataKeyMaxMessages int64             `json:"data_key_max_messages" yaml:"data_key_max_messages"`
+}
+
+// NewEncryptEnvelopeConfig returns a EncryptEnvelopeConfig with default values.
+func NewEncryptEnvelopeConfig() EncryptEnvelopeConfig {
+	return EncryptEnvelopeConfig{
+		Config:             session.NewConfig(),
+		KeyID:              "",
+		EncryptionContext:  map[string]string{},
+		DataKeyTTL:         "5m",
+		DataKeyMaxMessages: 0,
+	}
+}
+
+// DecryptEnvelopeConfig contains configuration fields for the DecryptEnvelope
+// processor.
+type DecryptEnvelopeConfig struct {
+	session.Config    `json:",inline" yaml:",inline"`
+	KeyID             string            `json:"key_id" yaml:"key_id"`
+	EncryptionContext map[string]string `json:"encryption_context" yaml:"encryption_context"`
+	DataKeyTTL        string            `json:"data_key_ttl" yaml:"data_key_ttl"`
+}
+
+// NewDecryptEnvelopeConfig returns a DecryptEnvelopeConfig with default values.
+func NewDecryptEnvelopeConfig() DecryptEnvelopeConfig {
+	return DecryptEnvelopeConfig{
+		Config:            session.NewConfig(),
+		KeyID:             "",
+		EncryptionContext: map[string]string{},
+		DataKeyTTL:        "5m",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// gcmMaxMessages is the number of messages after which a data key is always
+// rotated, keeping well within the safe limit for random GCM nonces.
+const gcmMaxMessages = 1 << 32
+
+// envelopeKeyCacheMax caps the number of decrypted data keys that are cached.
+const envelopeKeyCacheMax = 1024
+
+// envelope is the structure emitted by encrypt_envelope, byte slices are base64
+// encoded when marshalled.
+type envelope struct {
+	Ciphertext   []byte `json:"ciphertext"`
+	EncryptedKey []byte `json:"encrypted_key"`
+	KeyID        string `json:"key_id"`
+	Nonce        []byte `json:"nonce"`
+}
+
+// newEnvelopeCipher creates an AES-GCM cipher from a plaintext data key, which
+// is zeroed once the cipher has been created.
+func newEnvelopeCipher(plaintext []byte) (cipher.AEAD, error) {
+	defer func() {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+	}()
+	block, err := aes.NewCipher(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher from data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func envelopeContext(m map[string]string) map[string]*string {
+	if len(m) == 0 {
+		return nil
+	}
+	return aws.StringMap(m)
+}
+
+func parseDataKeyTTL(s string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse data_key_ttl: %w", err)
+	}
+	if ttl < 0 {
+		return 0, errors.New("data_key_ttl must not be negative")
+	}
+	return ttl, nil
+}
+
+//------------------------------------------------------------------------------
+
+type envelopeDataKey struct {
+	aead      cipher.AEAD
+	encrypted []byte
+	keyID     string
+	expires   time.Time
+	uses      int64
+}
+
+// EncryptEnvelope is a processor that encrypts messages with data keys
+// generated by AWS KMS.
+type EncryptEnvelope struct {
+	kms         kmsiface.KMSAPI
+	keyID       string
+	encCtx      map[string]*string
+	keyTTL      time.Duration
+	maxMessages int64
+	log         log.Modular
+
+	keyMut sync.Mutex
+	key    *envelopeDataKey
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mKeys      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewEncryptEnvelope returns an EncryptEnvelope processor.
+func NewEncryptEnvelope(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	sess, err := conf.EncryptEnvelope.GetSessionWithFailover(session.LogFailover(log, stats.GetCounter("failover")))
+	if err != nil {
+		return nil, err
+	}
+	return newEncryptEnvelope(conf.EncryptEnvelope, kms.New(sess), log, stats)
+}
+
+func newEncryptEnvelope(
+	conf EncryptEnvelopeConfig, client kmsiface.KMSAPI, log log.Modular, stats metrics.Type,
+) (*EncryptEnvelope, error) {
+	if conf.KeyID == "" {
+		return nil, errors.New("a key_id must be provided")
+	}
+	ttl, err := parseDataKeyTTL(conf.DataKeyTTL)
+	if err != nil {
+		return nil, err
+	}
+	if conf.DataKeyMaxMessages < 0 {
+		return nil, errors.New("data_key_max_messages must not be negative")
+	}
+	maxMessages := conf.DataKeyMaxMessages
+	if maxMessages == 0 || maxMessages > gcmMaxMessages {
+		maxMessages = gcmMaxMessages
+	}
+	return &EncryptEnvelope{
+		kms:         client,
+		keyID:       conf.KeyID,
+		encCtx:      envelopeContext(conf.EncryptionContext),
+		keyTTL:      ttl,
+		maxMessages: maxMessages,
+		log:         log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mKeys:      stats.GetCounter("data_key.generated"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (e *EncryptEnvelope) generateKey() (*envelopeDataKey, error) {
+	out, err := e.kms.GenerateDataKeyWithContext(context.Background(), &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(e.keyID),
+		KeySpec:           aws.String(kms.DataKeySpecAes256),
+		EncryptionContext: e.encCtx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newEnvelopeCipher(out.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	e.mKeys.Incr(1)
+	return &envelopeDataKey{
+		aead:      aead,
+		encrypted: out.CiphertextBlob,
+		keyID:     aws.StringValue(out.KeyId),
+		expires:   time.Now().Add(e.keyTTL),
+	}, nil
+}
+
+func (e *EncryptEnvelope) usable(k *envelopeDataKey) bool {
+	return k != nil && k.uses < e.maxMessages && (e.keyTTL == 0 || time.Now().Before(k.expires))
+}
+
+// keyFor returns a data key for encrypting a single message. When caching is
+// disabled keys are scoped to the batch pointed to by batchKey.
+func (e *EncryptEnvelope) keyFor(batchKey **envelopeDataKey) (*envelopeDataKey, error) {
+	if e.keyTTL == 0 {
+		if !e.usable(*batchKey) {
+			k, err := e.generateKey()
+			if err != nil {
+				return nil, err
+			}
+			*batchKey = k
+		}
+		(*batchKey).uses++
+		return *batchKey, nil
+	}
+
+	e.keyMut.Lock()
+	defer e.keyMut.Unlock()
+	if !e.usable(e.key) {
+		e.key = nil
+		k, err := e.generateKey()
+		if err != nil {
+			return nil, err
+		}
+		e.key = k
+	}
+	e.key.uses++
+	return e.key, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (e *EncryptEnvelope) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	e.mCount.Incr(1)
+
+	var batchKey *envelopeDataKey
+	var keyErr error
+
+	newMsg := msg.Copy()
+	IteratePartsWithSpan(TypeEncryptEnvelope, nil, newMsg, func(i int, span opentracing.Span, part types.Part) error {
+		if keyErr != nil {
+			return nil
+		}
+		key, err := e.keyFor(&batchKey)
+		if err != nil {
+			keyErr = err
+			return nil
+		}
+		nonce := make([]byte, key.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			keyErr = fmt.Errorf("failed to generate nonce: %w", err)
+			return nil
+		}
+		env, err := json.Marshal(envelope{
+			Ciphertext:   key.aead.Seal(nil, nonce, part.Get(), nil),
+			EncryptedKey: key.encrypted,
+			KeyID:        key.keyID,
+			Nonce:        nonce,
+		})
+		if err != nil {
+			keyErr = err
+			return nil
+		}
+		part.Set(env)
+		return nil
+	})
+
+	if keyErr != nil {
+		e.mErr.Incr(1)
+		e.log.Errorf("Failed to encrypt messages: %v\n", keyErr)
+		return nil, response.NewError(keyErr)
+	}
+
+	e.mBatchSent.Incr(1)
+	e.mSent.Incr(int64(newMsg.Len()))
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (e *EncryptEnvelope) CloseAsync() {
+	e.keyMut.Lock()
+	e.key = nil
+	e.keyMut.Unlock()
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (e *EncryptEnvelope) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// DecryptEnvelope is a processor that decrypts messages encrypted by the
+// EncryptEnvelope processor.
+type DecryptEnvelope struct {
+	kms    kmsiface.KMSAPI
+	keyID  *string
+	encCtx map[string]*string
+	keyTTL time.Duration
+	log    log.Modular
+
+	cacheMut sync.Mutex
+	cache    map[string]*envelopeDataKey
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mKeys      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewDecryptEnvelope returns a DecryptEnvelope processor.
+func NewDecryptEnvelope(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	sess, err := conf.DecryptEnvelope.GetSessionWithFailover(session.LogFailover(log, stats.GetCounter("failover")))
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptEnvelope(conf.DecryptEnvelope, kms.New(sess), log, stats)
+}
+
+func newDecryptEnvelope(
+	conf DecryptEnvelopeConfig, client kmsiface.KMSAPI, log log.Modular, stats metrics.Type,
+) (*DecryptEnvelope, error) {
+	ttl, err := parseDataKeyTTL(conf.DataKeyTTL)
+	if err != nil {
+		return nil, err
+	}
+	var keyID *string
+	if conf.KeyID != "" {
+		keyID = aws.String(conf.KeyID)
+	}
+	return &DecryptEnvelope{
+		kms:    client,
+		keyID:  keyID,
+		encCtx: envelopeContext(conf.EncryptionContext),
+		keyTTL: ttl,
+		log:    log,
+		cache:  map[string]*envelopeDataKey{},
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mKeys:      stats.GetCounter("data_key.decrypted"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *DecryptEnvelope) cached(encrypted string) cipher.AEAD {
+	d.cacheMut.Lock()
+	defer d.cacheMut.Unlock()
+	if k, exists := d.cache[encrypted]; exists && time.Now().Before(k.expires) {
+		return k.aead
+	}
+	return nil
+}
+
+func (d *DecryptEnvelope) store(encrypted string, aead cipher.AEAD) {
+	d.cacheMut.Lock()
+	defer d.cacheMut.Unlock()
+	now := time.Now()
+	for k, v := range d.cache {
+		if !now.Before(v.expires) {
+			delete(d.cache, k)
+		}
+	}
+	if len(d.cache) >= envelopeKeyCacheMax {
+		return
+	}
+	d.cache[encrypted] = &envelopeDataKey{
+		aead:    aead,
+		expires: now.Add(d.keyTTL),
+	}
+}
+
+// keyFor returns the cipher for an encrypted data key, which is decrypted with
+// KMS at most once per batch.
+func (d *DecryptEnvelope) keyFor(encrypted []byte, batchKeys map[string]cipher.AEAD) (cipher.AEAD, error) {
+	k := string(encrypted)
+	if aead, exists := batchKeys[k]; exists {
+		return aead, nil
+	}
+	if d.keyTTL > 0 {
+		if aead := d.cached(k); aead != nil {
+			batchKeys[k] = aead
+			return aead, nil
+		}
+	}
+
+	out, err := d.kms.DecryptWithContext(context.Background(), &kms.DecryptInput{
+		CiphertextBlob:    encrypted,
+		EncryptionContext: d.encCtx,
+		KeyId:             d.keyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	aead, err := newEnvelopeCipher(out.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	d.mKeys.Incr(1)
+
+	batchKeys[k] = aead
+	if d.keyTTL > 0 {
+		d.store(k, aead)
+	}
+	return aead, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (d *DecryptEnvelope) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	d.mCount.Incr(1)
+
+	batchKeys := map[string]cipher.AEAD{}
+
+	newMsg := msg.Copy()
+	IteratePartsWithSpan(TypeDecryptEnvelope, nil, newMsg, func(i int, span opentracing.Span, part types.Part) error {
+		var env envelope
+		if err := json.Unmarshal(part.Get(), &env); err != nil {
+			d.mErr.Incr(1)
+			d.log.Debugf("Failed to parse envelope: %v\n", err)
+			return fmt.Errorf("failed to parse envelope: %w", err)
+		}
+		if len(env.EncryptedKey) == 0 {
+			d.mErr.Incr(1)
+			return errors.New("envelope is missing an encrypted_key")
+		}
+		aead, err := d.keyFor(env.EncryptedKey, batchKeys)
+		if err != nil {
+			d.mErr.Incr(1)
+			d.log.Debugf("Failed to decrypt data key: %v\n", err)
+			return err
+		}
+		if len(env.Nonce) != aead.NonceSize() {
+			d.mErr.Incr(1)
+			return fmt.Errorf("envelope nonce has length %v, expected %v", len(env.Nonce), aead.NonceSize())
+		}
+		plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+		if err != nil {
+			d.mErr.Incr(1)
+			d.log.Debugf("Failed to decrypt message: %v\n", err)
+			return fmt.Errorf("failed to decrypt message: %w", err)
+		}
+		part.Set(plaintext)
+		return nil
+	})
+
+	d.mBatchSent.Incr(1)
+	d.mSent.Incr(int64(newMsg.Len()))
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (d *DecryptEnvelope) CloseAsync() {
+	d.cacheMut.Lock()
+	d.cache = map[string]*envelopeDataKey{}
+	d.cacheMut.Unlock()
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (d *DecryptEnvelope) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,256 @@
+package processor
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockKMS struct {
+	kmsiface.KMSAPI
+
+	mut       sync.Mutex
+	keys      map[string][]byte
+	generated int
+	decrypted int
+	fail      bool
+}
+
+func newMockKMS() *mockKMS {
+	return &mockKMS{keys: map[string][]byte{}}
+}
+
+func (m *mockKMS) GenerateDataKeyWithContext(ctx aws.Context, in *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.fail {
+		return nil, errors.New("kms unavailable")
+	}
+	m.generated++
+
+	plaintext := make([]byte, 32)
+	encrypted := make([]byte, 16)
+	_, _ = rand.Read(plaintext)
+	_, _ = rand.Read(encrypted)
+	m.keys[string(encrypted)+contextKey(in.EncryptionContext)] = append([]byte(nil), plaintext...)
+
+	return &kms.GenerateDataKeyOutput{
+		CiphertextBlob: encrypted,
+		KeyId:          aws.String("arn:" + aws.StringValue(in.KeyId)),
+		Plaintext:      plaintext,
+	}, nil
+}
+
+func (m *mockKMS) DecryptWithContext(ctx aws.Context, in *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.decrypted++
+
+	plaintext, exists := m.keys[string(in.CiphertextBlob)+contextKey(in.EncryptionContext)]
+	if !exists {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kms.DecryptOutput{
+		Plaintext: append([]byte(nil), plaintext...),
+	}, nil
+}
+
+func contextKey(c map[string]*string) string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	client := newMockKMS()
+
+	encConf := NewEncryptEnvelopeConfig()
+	encConf.KeyID = "alias/foo"
+	encConf.EncryptionContext = map[string]string{"purpose": "test"}
+	enc, err := newEncryptEnvelope(encConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	decConf := NewDecryptEnvelopeConfig()
+	decConf.EncryptionContext = map[string]string{"purpose": "test"}
+	dec, err := newDecryptEnvelope(decConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}
+	inMsg := message.New(input)
+	inMsg.Get(0).Metadata().Set("key", "value")
+
+	msgs, res := enc.ProcessMessage(inMsg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "foo", string(inMsg.Get(0).Get()), "input message should be unchanged")
+
+	var nonces []string
+	for _, p := range message.GetAllBytes(msgs[0]) {
+		var env envelope
+		require.NoError(t, json.Unmarshal(p, &env))
+		assert.Equal(t, "arn:alias/foo", env.KeyID)
+		assert.NotEmpty(t, env.EncryptedKey)
+		assert.Len(t, env.Nonce, 12)
+		nonces = append(nonces, string(env.Nonce))
+	}
+	assert.NotEqual(t, nonces[0], nonces[1])
+	assert.Equal(t, "value", msgs[0].Get(0).Metadata().Get("key"))
+
+	msgs, res = dec.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, input, message.GetAllBytes(msgs[0]))
+	for i := 0; i < msgs[0].Len(); i++ {
+		assert.False(t, HasFailed(msgs[0].Get(i)))
+	}
+
+	assert.Equal(t, 1, client.generated)
+	assert.Equal(t, 1, client.decrypted)
+}
+
+func TestEncryptEnvelopeKeyCaching(t *testing.T) {
+	client := newMockKMS()
+
+	conf := NewEncryptEnvelopeConfig()
+	conf.KeyID = "foo"
+	conf.DataKeyMaxMessages = 2
+	enc, err := newEncryptEnvelope(conf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	keys := map[string]struct{}{}
+	for i := 0; i < 3; i++ {
+		msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+		require.Nil(t, res)
+		var env envelope
+		require.NoError(t, json.Unmarshal(msgs[0].Get(0).Get(), &env))
+		keys[string(env.EncryptedKey)] = struct{}{}
+	}
+	assert.Len(t, keys, 2)
+	assert.Equal(t, 2, client.generated)
+}
+
+func TestEncryptEnvelopeKeyPerBatch(t *testing.T) {
+	client := newMockKMS()
+
+	conf := NewEncryptEnvelopeConfig()
+	conf.KeyID = "foo"
+	conf.DataKeyTTL = "0s"
+	enc, err := newEncryptEnvelope(conf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+		require.Nil(t, res)
+
+		keys := map[string]struct{}{}
+		for _, p := range message.GetAllBytes(msgs[0]) {
+			var env envelope
+			require.NoError(t, json.Unmarshal(p, &env))
+			keys[string(env.EncryptedKey)] = struct{}{}
+		}
+		assert.Len(t, keys, 1)
+	}
+	assert.Equal(t, 3, client.generated)
+}
+
+func TestEncryptEnvelopeKMSFailure(t *testing.T) {
+	client := newMockKMS()
+	client.fail = true
+
+	conf := NewEncryptEnvelopeConfig()
+	conf.KeyID = "foo"
+	enc, err := newEncryptEnvelope(conf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.EqualError(t, res.Error(), "failed to generate data key: kms unavailable")
+}
+
+func TestDecryptEnvelopeErrors(t *testing.T) {
+	client := newMockKMS()
+
+	encConf := NewEncryptEnvelopeConfig()
+	encConf.KeyID = "foo"
+	encConf.EncryptionContext = map[string]string{"purpose": "test"}
+	enc, err := newEncryptEnvelope(encConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+	require.Nil(t, res)
+
+	var tampered envelope
+	require.NoError(t, json.Unmarshal(msgs[0].Get(1).Get(), &tampered))
+	tampered.Ciphertext[0] ^= 0xff
+	tamperedBytes, err := json.Marshal(tampered)
+	require.NoError(t, err)
+
+	// Decrypting without the encryption context fails
+	decConf := NewDecryptEnvelopeConfig()
+	dec, err := newDecryptEnvelope(decConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	outMsgs, res := dec.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	assert.Equal(t, message.GetAllBytes(msgs[0]), message.GetAllBytes(outMsgs[0]))
+	assert.Equal(t, "failed to decrypt data key: invalid ciphertext", GetFail(outMsgs[0].Get(0)))
+
+	decConf.EncryptionContext = map[string]string{"purpose": "test"}
+	dec, err = newDecryptEnvelope(decConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	outMsgs, res = dec.ProcessMessage(message.New([][]byte{
+		msgs[0].Get(0).Get(),
+		tamperedBytes,
+		[]byte("not an envelope"),
+	}))
+	require.Nil(t, res)
+	assert.Equal(t, "foo", string(outMsgs[0].Get(0).Get()))
+	assert.False(t, HasFailed(outMsgs[0].Get(0)))
+	assert.Equal(t, "failed to decrypt message: cipher: message authentication failed", GetFail(outMsgs[0].Get(1)))
+	assert.True(t, HasFailed(outMsgs[0].Get(2)))
+}
+
+func TestDecryptEnvelopeKeyCaching(t *testing.T) {
+	client := newMockKMS()
+
+	encConf := NewEncryptEnvelopeConfig()
+	encConf.KeyID = "foo"
+	enc, err := newEncryptEnvelope(encConf, client, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, ttl := range []string{"5m", "0s"} {
+		client.decrypted = 0
+
+		decConf := NewDecryptEnvelopeConfig()
+		decConf.DataKeyTTL = ttl
+		dec, err := newDecryptEnvelope(decConf, client, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+			require.Nil(t, res)
+			msgs, res = dec.ProcessMessage(msgs[0])
+			require.Nil(t, res)
+			assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar")}, message.GetAllBytes(msgs[0]))
+		}
+
+		if ttl == "0s" {
+			assert.Equal(t, 3, client.decrypted, ttl)
+		} else {
+			assert.Equal(t, 1, client.decrypted, ttl)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeExplode] = TypeSpec{
+		constructor: NewExplode,
+		Status:      docs.StatusBeta,
+		Version:     "3.50.0",
+		Categories: []Category{
+			CategoryMapping, CategoryUtility,
+		},
+		Summary: `
+Explodes an array within each message into a message per element, where the
+fields of the original document can be kept alongside each element.`,
+		Description: `
+Each message is parsed as a JSON document and the array found at the
+[field path](/docs/configuration/field_paths) ` + "`path`" + ` is exploded,
+with the resulting messages replacing the original message in the batch. By
+default each resulting message is a copy of the original document where the
+array is replaced with a single element, matching the behaviour of the
+Bloblang method [` + "`explode`" + `](/docs/guides/bloblang/methods#explode).
+
+The resulting messages can instead be shaped with the field
+` + "`result_map`" + `, a [Bloblang mapping](/docs/guides/bloblang/about)
+executed for each element where the element is the context (` + "`this`" + `)
+and the original document is available as the variable ` + "`$parent`" + `.
+Elements deleted by the mapping (` + "`root = deleted()`" + `) are dropped, and
+a mapping that doesn't assign to ` + "`root`" + ` results in the default
+exploded document.
+
+The metadata of the original message is copied to each of the resulting
+messages.
+
+### Error Handling
+
+Messages that cannot be parsed as JSON, do not contain an array at the target
+path or fail the mapping remain unchanged in the batch and are flagged as
+having failed, allowing you to
+[error handle them](/docs/configuration/error_handling).
+
+Messages with an empty array at the target path are dropped by default, or can
+be kept unchanged and flagged as having failed by setting ` + "`on_empty`" + `
+to ` + "`flag`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("path", "A [field path](/docs/configuration/field_paths) to the array to explode, where an empty path targets the root of the document.", "items", "order.lines"),
+			docs.FieldString(
+				"result_map",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) executed for each element in order to create the resulting message, where the element is the context and the original document is available as the variable `$parent`.",
+				`root.order_id = $parent.id
+root.item = this`,
+				`root = this.merge({"customer": $parent.customer})`,
+			).HasDefault("").Linter(docs.LintBloblangMapping),
+			docs.FieldAdvanced("on_empty", "What to do with messages where the array at the target path is empty.").HasAnnotatedOptions(
+				"drop", "Drop the message.",
+				"flag", "Keep the message unchanged and flag it as having failed.",
+			),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Exploding Order Lines",
+				Summary: `
+Here we explode the lines of each order into their own messages, keeping the
+identifier of the order and the name of the customer on each line:`,
+				Config: `
+pipeline:
+  processors:
+    - explode:
+        path: lines
+        result_map: |
+          root = this
+          root.order_id = $parent.id
+          root.customer = $parent.customer.name
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ExplodeConfig contains configuration fields for the Explode processor.
+type ExplodeConfig struct {
+	Path      string `json:"path" yaml:"path"`
+	ResultMap string `json:"result_map" yaml:"result_map"`
+	OnEmpty   string `json:"on_empty" yaml:"on_empty"`
+}
+
+// NewExplodeConfig returns a ExplodeConfig with default values.
+func NewExplodeConfig() ExplodeConfig {
+	return ExplodeConfig{
+		Path:      "",
+		ResultMap: "",
+		OnEmpty:   "drop",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Explode is a processor that explodes an array within each message into a
+// message per element.
+type Explode struct {
+	path      []string
+	pathStr   string
+	resultMap *mapping.Executor
+	flagEmpty bool
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewExplode returns a Explode processor.
+func NewExplode(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	e := &Explode{
+		path:    gabs.DotPathToSlice(conf.Explode.Path),
+		pathStr: conf.Explode.Path,
+		log:     log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mDropped:   stats.GetCounter("dropped"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	if conf.Explode.Path == "" {
+		e.path = nil
+	}
+
+	switch conf.Explode.OnEmpty {
+	case "drop":
+	case "flag":
+		e.flagEmpty = true
+	default:
+		return nil, fmt.Errorf("on_empty value not recognised: %v", conf.Explode.OnEmpty)
+	}
+
+	if conf.Explode.ResultMap != "" {
+		var err error
+		if e.resultMap, err = bloblang.NewMapping("", conf.Explode.ResultMap); err != nil {
+			return nil, fmt.Errorf("failed to parse result_map: %w", err)
+		}
+	}
+	return e, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (e *Explode) explodePart(index int, msg types.Message) ([]types.Part, error) {
+	part := msg.Get(index)
+	doc, err := part.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+
+	elements, ok := gabs.Wrap(doc).Search(e.path...).Data().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected array value at path '%v', found: %v", e.pathStr, query.ITypeOf(gabs.Wrap(doc).Search(e.path...).Data()))
+	}
+	if len(elements) == 0 && e.flagEmpty {
+		return nil, fmt.Errorf("array at path '%v' is empty", e.pathStr)
+	}
+
+	parts := make([]types.Part, 0, len(elements))
+	for _, ele := range elements {
+		var result interface{}
+		if len(e.path) == 0 {
+			result = ele
+		} else {
+			gExploded := gabs.Wrap(query.IClone(doc))
+			if _, err := gExploded.Set(ele, e.path...); err != nil {
+				return nil, fmt.Errorf("failed to set element: %w", err)
+			}
+			result = gExploded.Data()
+		}
+
+		if e.resultMap != nil {
+			// Both values are cloned as assignments within the mapping could
+			// otherwise modify the results of other elements.
+			mapped, err := e.resultMap.Exec(query.FunctionContext{
+				Maps:     e.resultMap.Maps(),
+				Vars:     map[string]interface{}{"parent": query.IClone(doc)},
+				Index:    index,
+				MsgBatch: msg,
+			}.WithValue(query.IClone(ele)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to execute result_map: %w", err)
+			}
+			switch mapped.(type) {
+			case query.Delete:
+				continue
+			case query.Nothing:
+			default:
+				result = mapped
+			}
+		}
+
+		newPart := part.Copy()
+		switch t := result.(type) {
+		case string:
+			newPart.Set([]byte(t))
+		case []byte:
+			newPart.Set(t)
+		default:
+			if err := newPart.SetJSON(result); err != nil {
+				return nil, fmt.Errorf("failed to marshal element into new message: %w", err)
+			}
+		}
+		parts = append(parts, newPart)
+	}
+	return parts, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (e *Explode) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	e.mCount.Incr(1)
+
+	newMsg := message.New(nil)
+	msg.Iter(func(i int, part types.Part) error {
+		span := tracing.CreateChildSpan(TypeExplode, part)
+		defer span.Finish()
+
+		newParts, err := e.explodePart(i, msg)
+		if err != nil {
+			e.mErr.Incr(1)
+			e.log.Debugf("Failed to explode message: %v\n", err)
+			newMsg.Append(part.Copy())
+			FlagErr(newMsg.Get(-1), err)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		}
+		if len(newParts) == 0 {
+			e.mDropped.Incr(1)
+		}
+		newMsg.Append(newParts...)
+		return nil
+	})
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	e.mBatchSent.Incr(1)
+	e.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (e *Explode) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (e *Explode) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
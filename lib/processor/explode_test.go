@@ -0,0 +1,163 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplode(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		resultMap string
+		onEmpty   string
+		input     []string
+		output    []string
+		errored   []bool
+	}{
+		{
+			name:   "nested array",
+			path:   "order.lines",
+			input:  []string{`{"id":1,"order":{"lines":["a","b"]}}`},
+			output: []string{`{"id":1,"order":{"lines":"a"}}`, `{"id":1,"order":{"lines":"b"}}`},
+		},
+		{
+			name:   "root array",
+			input:  []string{`[{"a":1},"b"]`, `[3]`},
+			output: []string{`{"a":1}`, `b`, `3`},
+		},
+		{
+			name: "result map with parent",
+			path: "items",
+			resultMap: `root.order = $parent.id
+root.item = this
+root.count = $parent.items.length()`,
+			input: []string{`{"id":"foo","items":[{"sku":1},{"sku":2}]}`},
+			output: []string{
+				`{"count":2,"item":{"sku":1},"order":"foo"}`,
+				`{"count":2,"item":{"sku":2},"order":"foo"}`,
+			},
+		},
+		{
+			name:      "result map modifying values",
+			path:      "items",
+			resultMap: `root = this.merge({"id": $parent.id})`,
+			input:     []string{`{"id":"foo","items":[{"sku":1},{"sku":2}]}`},
+			output:    []string{`{"id":"foo","sku":1}`, `{"id":"foo","sku":2}`},
+		},
+		{
+			name:      "result map deletes and defaults",
+			path:      "items",
+			resultMap: `root = if this == "skip" { deleted() }`,
+			input:     []string{`{"items":["a","skip","b"]}`},
+			output:    []string{`{"items":"a"}`, `{"items":"b"}`},
+		},
+		{
+			name:   "empty array dropped",
+			path:   "items",
+			input:  []string{`{"items":[]}`, `{"items":["a"]}`},
+			output: []string{`{"items":"a"}`},
+		},
+		{
+			name:    "empty array flagged",
+			path:    "items",
+			onEmpty: "flag",
+			input:   []string{`{"items":[]}`},
+			output:  []string{`{"items":[]}`},
+			errored: []bool{true},
+		},
+		{
+			name:    "not an array",
+			path:    "items",
+			input:   []string{`{"items":"a"}`, `not json`, `{"items":["a"]}`},
+			output:  []string{`{"items":"a"}`, `not json`, `{"items":"a"}`},
+			errored: []bool{true, true, false},
+		},
+		{
+			name:      "failed mapping",
+			path:      "items",
+			resultMap: `root = this.number()`,
+			input:     []string{`{"items":["1","nope"]}`},
+			output:    []string{`{"items":["1","nope"]}`},
+			errored:   []bool{true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeExplode
+			conf.Explode.Path = test.path
+			conf.Explode.ResultMap = test.resultMap
+			if test.onEmpty != "" {
+				conf.Explode.OnEmpty = test.onEmpty
+			}
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			input := message.New(nil)
+			for _, s := range test.input {
+				part := message.NewPart([]byte(s))
+				part.Metadata().Set("foo", "bar")
+				input.Append(part)
+			}
+
+			msgs, res := proc.ProcessMessage(input)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			var output []string
+			var errored []bool
+			msgs[0].Iter(func(i int, p types.Part) error {
+				output = append(output, string(p.Get()))
+				errored = append(errored, HasFailed(p))
+				assert.Equal(t, "bar", p.Metadata().Get("foo"))
+				return nil
+			})
+			assert.Equal(t, test.output, output)
+			if test.errored == nil {
+				test.errored = make([]bool, len(test.output))
+			}
+			assert.Equal(t, test.errored, errored)
+		})
+	}
+}
+
+func TestExplodeAllDropped(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeExplode
+	conf.Explode.Path = "items"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`{"items":[]}`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestExplodeBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeExplode
+	conf.Explode.OnEmpty = "nope"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "on_empty value not recognised: nope")
+
+	conf = NewConfig()
+	conf.Type = TypeExplode
+	conf.Explode.ResultMap = "root = "
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse result_map")
+}
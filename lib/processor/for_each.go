@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -123,6 +124,12 @@ func NewProcessBatch(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *ForEach) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return p.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors.
+func (p *ForEach) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
 	individualMsgs := make([]types.Message, msg.Len())
@@ -135,7 +142,7 @@ func (p *ForEach) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 
 	resMsg := message.New(nil)
 	for _, tmpMsg := range individualMsgs {
-		resultMsgs, res := ExecuteAll(p.children, tmpMsg)
+		resultMsgs, res := ExecuteAllWithContext(ctx, p.children, tmpMsg)
 		if res != nil && res.Error() != nil {
 			return nil, res
 		}
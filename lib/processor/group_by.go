@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -207,6 +208,13 @@ func NewGroupBy(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (g *GroupBy) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return g.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to the processors of each group and the message is rejected if it is
+// cancelled.
+func (g *GroupBy) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	g.mCount.Incr(1)
 
 	if msg.Len() == 0 {
@@ -275,7 +283,7 @@ func (g *GroupBy) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 			continue
 		}
 
-		resultMsgs, res := ExecuteAll(g.groups[i].Processors, gmsg)
+		resultMsgs, res := ExecuteAllWithContext(ctx, g.groups[i].Processors, gmsg)
 		if len(resultMsgs) > 0 {
 			msgs = append(msgs, resultMsgs...)
 		}
@@ -286,6 +294,10 @@ func (g *GroupBy) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
+
 	if groupless.Len() > 0 {
 		msgs = append(msgs, groupless)
 	}
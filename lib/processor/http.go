@@ -1,12 +1,14 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/metadata"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -32,7 +34,10 @@ Performs an HTTP request using a message batch as the request body, and replaces
 the original message parts with the body of the response.`,
 		Description: `
 If a processed message batch contains more than one message they will be sent in
-a single request as a [multipart message](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).
+a single request as a [multipart message](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html),
+or in a format set with the field ` + "`batch_format`" + `, such as a JSON array
+or one message per line. The field ` + "`response_format`" + ` can be used in
+order to split such responses back into a message for each message of the batch.
 Alternatively, message batches can be sent in parallel by setting the field
 ` + "`parallel` to `true`" + `.
 
@@ -40,6 +45,14 @@ The ` + "`rate_limit`" + ` field can be used to specify a rate limit
 [resource](/docs/components/rate_limits/about) to cap the rate of requests
 across all parallel components service wide.
 
+The ` + "`max_concurrency`" + ` field caps the number of requests that are
+outstanding at any given time across all [pipeline threads](/docs/configuration/processing_pipelines),
+where messages wait for a free slot for up to the duration of
+` + "`queue_timeout`" + ` before failing. The gauge metric
+` + "`client.concurrency`" + ` tracks the number of outstanding requests and the
+counter metric ` + "`client.queue_timeout`" + ` counts requests that timed out
+waiting for a slot.
+
 The URL and header values of this type can be dynamically set using function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
@@ -61,11 +74,28 @@ will not be reattempted and is immediately considered a failed request.
 
 ## Adding Metadata
 
-If the request returns an error response code this processor sets a metadata
-field ` + "`http_status_code`" + ` on the resulting message.
+If the request returns an error response code this processor sets the metadata
+fields ` + "`http_status_code`" + ` and ` + "`http_status`" + ` on the resulting
+message, as well as ` + "`http_error_body`" + ` containing up to
+` + "`error_body_limit`" + ` bytes of the body of the response, where bodies that
+are not valid UTF-8 are base64 encoded.
 
 If the field ` + "`copy_response_headers` is set to `true`" + ` then any headers
 in the response will also be set in the resulting message as metadata.
+
+## Caching
+
+Responses can be cached by setting ` + "`cache.resource`" + ` to the name of a
+[cache resource](/docs/components/caches/about), where before each request the
+` + "`cache.key`" + ` of the message is used to look up a previous response.
+Responses are only stored when their status code is listed in
+` + "`cache.status_codes`" + `, and failed requests are only stored when
+` + "`cache.cache_errors`" + ` is set to ` + "`true`" + `. Concurrent requests of
+the same key are only sent once, and failures to read from or write to the cache
+are logged without blocking requests.
+
+The counter metrics ` + "`cache.hit`, `cache.miss` and `cache.error`" + ` track
+the usage of the cache.
  
 ## Error Handling
 
@@ -73,7 +103,7 @@ When all retry attempts for a message are exhausted the processor cancels the
 attempt. These failed messages will continue through the pipeline unchanged, but
 can be dropped or placed in a dead letter queue according to your config, you
 can read about these patterns [here](/docs/configuration/error_handling).`,
-		FieldSpecs: append(docs.FieldSpecs{
+		config: client.ComponentSpec(append(docs.FieldSpecs{
 			docs.FieldCommon("parallel", "When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent within a single request."),
 			docs.FieldDeprecated("max_parallel"),
 			docs.FieldDeprecated("request").OmitWhen(func(v, _ interface{}) (string, bool) {
@@ -87,7 +117,7 @@ can read about these patterns [here](/docs/configuration/error_handling).`,
 				}
 				return "field request is deprecated", cmp.Equal(v, iDefault)
 			}),
-		}, client.FieldSpecs()...),
+		}, client.FieldSpecs()...).Add(client.BatchFormatFieldSpecs()...).Add(client.ConcurrencyFieldSpecs()...).Add(httpCacheFieldSpec())),
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Branched Request",
@@ -113,19 +143,29 @@ pipeline:
 
 // HTTPConfig contains configuration fields for the HTTP processor.
 type HTTPConfig struct {
-	Parallel      bool          `json:"parallel" yaml:"parallel"`
-	MaxParallel   int           `json:"max_parallel" yaml:"max_parallel"`
-	Client        client.Config `json:"request" yaml:"request"`
-	client.Config `json:",inline" yaml:",inline"`
+	Parallel       bool          `json:"parallel" yaml:"parallel"`
+	MaxParallel    int           `json:"max_parallel" yaml:"max_parallel"`
+	Client         client.Config `json:"request" yaml:"request"`
+	client.Config  `json:",inline" yaml:",inline"`
+	BatchFormat    string          `json:"batch_format" yaml:"batch_format"`
+	ResponseFormat string          `json:"response_format" yaml:"response_format"`
+	MaxConcurrency int             `json:"max_concurrency" yaml:"max_concurrency"`
+	QueueTimeout   string          `json:"queue_timeout" yaml:"queue_timeout"`
+	Cache          HTTPCacheConfig `json:"cache" yaml:"cache"`
 }
 
 // NewHTTPConfig returns a HTTPConfig with default values.
 func NewHTTPConfig() HTTPConfig {
 	return HTTPConfig{
-		Client:      client.NewConfig(),
-		Parallel:    false,
-		MaxParallel: 0,
-		Config:      client.NewConfig(),
+		Client:         client.NewConfig(),
+		Parallel:       false,
+		MaxParallel:    0,
+		Config:         client.NewConfig(),
+		BatchFormat:    "multipart",
+		ResponseFormat: "auto",
+		MaxConcurrency: 0,
+		QueueTimeout:   "",
+		Cache:          NewHTTPCacheConfig(),
 	}
 }
 
@@ -135,6 +175,7 @@ func NewHTTPConfig() HTTPConfig {
 // request body, and returns the response.
 type HTTP struct {
 	client *client.Type
+	cache  *httpResponseCache
 
 	parallel bool
 	max      int
@@ -179,26 +220,50 @@ func NewHTTP(
 	if g.client, err = client.New(
 		conf.HTTP.Config,
 		client.OptSetLogger(g.log),
+		client.OptSetBatchFormat(conf.HTTP.BatchFormat, conf.HTTP.ResponseFormat),
+		client.OptSetMaxConcurrency(conf.HTTP.MaxConcurrency, conf.HTTP.QueueTimeout),
 		// TODO: V4 Remove this
 		client.OptSetStats(metrics.Namespaced(g.stats, "client")),
 		client.OptSetManager(mgr),
 	); err != nil {
 		return nil, err
 	}
+	if g.cache, err = newHTTPResponseCache(conf.HTTP.Cache, mgr, log, stats); err != nil {
+		return nil, err
+	}
 	return g, nil
 }
 
+func (h *HTTP) send(ctx context.Context, msg types.Message) (types.Message, error) {
+	sendFn := func(m types.Message) (types.Message, error) {
+		return h.client.SendWithContext(ctx, m)
+	}
+	if h.cache == nil {
+		return sendFn(msg)
+	}
+	return h.cache.send(msg, sendFn)
+}
+
 //------------------------------------------------------------------------------
 
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return h.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, requests in
+// flight are abandoned and the message rejected if the context is cancelled.
+func (h *HTTP) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	h.mCount.Incr(1)
 	var responseMsg types.Message
 
 	if !h.parallel || msg.Len() == 1 {
 		// Easy, just do a single request.
-		resultMsg, err := h.client.Send(msg)
+		resultMsg, err := h.send(ctx, msg)
+		if err != nil && ctx.Err() != nil {
+			return nil, response.NewError(ctx.Err())
+		}
 		if err != nil {
 			var codeStr string
 			var hErr types.ErrUnexpectedHTTPRes
@@ -213,6 +278,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 				if len(codeStr) > 0 {
 					p.Metadata().Set("http_status_code", codeStr)
 				}
+				metadata.SetHTTPError(p, err)
 				FlagErr(p, err)
 				return nil
 			})
@@ -251,7 +317,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		for i := 0; i < max; i++ {
 			go func() {
 				for index := range reqChan {
-					result, err := h.client.Send(message.Lock(msg, index))
+					result, err := h.send(ctx, message.Lock(msg, index))
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -266,6 +332,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 						if ok := errors.As(err, &hErr); ok {
 							results[index].Metadata().Set("http_status_code", strconv.Itoa(hErr.Code))
 						}
+						metadata.SetHTTPError(results[index], err)
 						FlagErr(results[index], err)
 					}
 					resChan <- err
@@ -286,6 +353,9 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		}
 
 		close(reqChan)
+		if err := ctx.Err(); err != nil {
+			return nil, response.NewError(err)
+		}
 		responseMsg = message.New(nil)
 		responseMsg.Append(results...)
 	}
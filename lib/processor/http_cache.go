@@ -0,0 +1,260 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"golang.org/x/sync/singleflight"
+)
+
+//------------------------------------------------------------------------------
+
+func httpCacheFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"cache", "Optionally cache the responses of requests within a [cache resource](/docs/components/caches/about), where subsequent requests with a matching key are served from the cache instead of being sent.",
+	).WithChildren(
+		docs.FieldCommon("resource", "The name of a cache resource to store responses in. Caching is disabled when this is empty."),
+		docs.FieldCommon(
+			"key", "A key to identify the response of a request by. When a single request is sent for a batch of multiple messages the key is resolved for each message of the batch and combined, and therefore a cached response is only served for a batch where every message resolves to the same key in the same order.",
+			`${! content() }`, `${! json("user.id") }`,
+		).IsInterpolated(),
+		docs.FieldCommon("ttl", "An optional TTL to set for cached responses, this is ignored by caches that do not support TTLs.", "60s", "5m"),
+		docs.FieldAdvanced("status_codes", "A list of status codes whereby a successful response is stored in the cache.").Array(),
+		docs.FieldAdvanced("cache_errors", "Whether requests that fail with an unexpected response code should also be cached, in which case they are replayed as failures until the entry expires."),
+	).AtVersion("3.50.0")
+}
+
+// HTTPCacheConfig contains configuration fields for caching the responses of
+// the HTTP processor.
+type HTTPCacheConfig struct {
+	Resource    string `json:"resource" yaml:"resource"`
+	Key         string `json:"key" yaml:"key"`
+	TTL         string `json:"ttl" yaml:"ttl"`
+	StatusCodes []int  `json:"status_codes" yaml:"status_codes"`
+	CacheErrors bool   `json:"cache_errors" yaml:"cache_errors"`
+}
+
+// NewHTTPCacheConfig returns a HTTPCacheConfig with default values.
+func NewHTTPCacheConfig() HTTPCacheConfig {
+	return HTTPCacheConfig{
+		Resource:    "",
+		Key:         "${! content() }",
+		TTL:         "",
+		StatusCodes: []int{200},
+		CacheErrors: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// httpCacheEntry is the serialised form of a response stored in a cache.
+type httpCacheEntry struct {
+	StatusCode int                  `json:"status_code"`
+	Error      string               `json:"error,omitempty"`
+	Parts      []httpCacheEntryPart `json:"parts,omitempty"`
+}
+
+type httpCacheEntryPart struct {
+	Body     []byte            `json:"body"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (e httpCacheEntry) response() (types.Message, error) {
+	if e.Error != "" {
+		return nil, types.ErrUnexpectedHTTPRes{Code: e.StatusCode, S: e.Error}
+	}
+	msg := message.New(nil)
+	for _, p := range e.Parts {
+		part := message.NewPart(p.Body)
+		for k, v := range p.Metadata {
+			part.Metadata().Set(k, v)
+		}
+		msg.Append(part)
+	}
+	return msg, nil
+}
+
+//------------------------------------------------------------------------------
+
+// httpResponseCache wraps the requests of an HTTP processor with a cache
+// resource, where responses are looked up by a key before a request is sent.
+// Failures to access the cache are logged and otherwise ignored, and
+// concurrent requests of the same key are deduplicated.
+type httpResponseCache struct {
+	mgr         types.Manager
+	resource    string
+	key         *field.Expression
+	ttl         *time.Duration
+	statusCodes map[int]struct{}
+	cacheErrors bool
+	log         log.Modular
+
+	flight singleflight.Group
+
+	mHit  metrics.StatCounter
+	mMiss metrics.StatCounter
+	mErr  metrics.StatCounter
+}
+
+func newHTTPResponseCache(
+	conf HTTPCacheConfig, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (*httpResponseCache, error) {
+	if conf.Resource == "" {
+		return nil, nil
+	}
+	key, err := bloblang.NewField(conf.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cache key expression: %v", err)
+	}
+	var ttl *time.Duration
+	if conf.TTL != "" {
+		td, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cache ttl: %v", err)
+		}
+		ttl = &td
+	}
+	if err := interop.ProbeCache(context.Background(), mgr, conf.Resource); err != nil {
+		return nil, err
+	}
+	statusCodes := map[int]struct{}{}
+	for _, c := range conf.StatusCodes {
+		statusCodes[c] = struct{}{}
+	}
+	return &httpResponseCache{
+		mgr:         mgr,
+		resource:    conf.Resource,
+		key:         key,
+		ttl:         ttl,
+		statusCodes: statusCodes,
+		cacheErrors: conf.CacheErrors,
+		log:         log,
+
+		mHit:  stats.GetCounter("cache.hit"),
+		mMiss: stats.GetCounter("cache.miss"),
+		mErr:  stats.GetCounter("cache.error"),
+	}, nil
+}
+
+func (c *httpResponseCache) get(key string) (entry httpCacheEntry, ok bool) {
+	var value []byte
+	var err error
+	if cerr := interop.AccessCache(context.Background(), c.mgr, c.resource, func(cache types.Cache) {
+		value, err = cache.Get(key)
+	}); cerr != nil {
+		err = cerr
+	}
+	if err == nil {
+		if err = json.Unmarshal(value, &entry); err == nil {
+			return entry, true
+		}
+	}
+	if !errors.Is(err, types.ErrKeyNotFound) {
+		c.mErr.Incr(1)
+		c.log.Debugf("Failed to read cached response for key '%s': %v\n", key, err)
+	}
+	return entry, false
+}
+
+func (c *httpResponseCache) set(key string, resMsg types.Message, resErr error) {
+	var entry httpCacheEntry
+	if resErr != nil {
+		var hErr types.ErrUnexpectedHTTPRes
+		if !c.cacheErrors || !errors.As(resErr, &hErr) {
+			return
+		}
+		entry.StatusCode = hErr.Code
+		entry.Error = hErr.S
+	} else {
+		if resMsg.Len() > 0 {
+			entry.StatusCode, _ = strconv.Atoi(resMsg.Get(0).Metadata().Get("http_status_code"))
+		}
+		if _, exists := c.statusCodes[entry.StatusCode]; !exists {
+			return
+		}
+		_ = resMsg.Iter(func(i int, p types.Part) error {
+			part := httpCacheEntryPart{Body: p.Get()}
+			_ = p.Metadata().Iter(func(k, v string) error {
+				if part.Metadata == nil {
+					part.Metadata = map[string]string{}
+				}
+				part.Metadata[k] = v
+				return nil
+			})
+			entry.Parts = append(entry.Parts, part)
+			return nil
+		})
+	}
+
+	value, err := json.Marshal(entry)
+	if err == nil {
+		if cerr := interop.AccessCache(context.Background(), c.mgr, c.resource, func(cache types.Cache) {
+			if cttl, ok := cache.(types.CacheWithTTL); ok {
+				err = cttl.SetWithTTL(key, value, c.ttl)
+			} else {
+				err = cache.Set(key, value)
+			}
+		}); cerr != nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		c.mErr.Incr(1)
+		c.log.Debugf("Failed to cache response for key '%s': %v\n", key, err)
+	}
+}
+
+// cacheKey resolves the key of a request from every message of the batch it is
+// sent with, where the key of a single message is used as is.
+func (c *httpResponseCache) cacheKey(msg types.Message) string {
+	if msg.Len() <= 1 {
+		return c.key.String(0, msg)
+	}
+	keys := make([]string, msg.Len())
+	for i := range keys {
+		keys[i] = c.key.String(i, msg)
+	}
+	// The keys are combined as a JSON array so that the boundaries between
+	// them are unambiguous.
+	keyBytes, _ := json.Marshal(keys)
+	return string(keyBytes)
+}
+
+// send attempts to obtain the response to a request from the cache, and
+// otherwise calls sendFn and caches the result.
+func (c *httpResponseCache) send(msg types.Message, sendFn func(types.Message) (types.Message, error)) (types.Message, error) {
+	key := c.cacheKey(msg)
+	if entry, ok := c.get(key); ok {
+		c.mHit.Incr(1)
+		return entry.response()
+	}
+	c.mMiss.Incr(1)
+
+	res, err, shared := c.flight.Do(key, func() (interface{}, error) {
+		resMsg, err := sendFn(msg)
+		c.set(key, resMsg, err)
+		return resMsg, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	resMsg := res.(types.Message)
+	if shared {
+		resMsg = resMsg.DeepCopy()
+	}
+	return resMsg, nil
+}
+
+//------------------------------------------------------------------------------
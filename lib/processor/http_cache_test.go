@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHTTPCacheTestMgr(t *testing.T) *fakeMgr {
+	t.Helper()
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	return &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+}
+
+func TestHTTPCacheHits(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		reqBytes, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Add("foobar", "baz")
+		w.Write(append([]byte("echo: "), reqBytes...))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.CopyResponseHeaders = true
+	conf.HTTP.Cache.Resource = "foocache"
+	conf.HTTP.Cache.Key = `${! json("id") }`
+
+	h, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		input  string
+		output string
+	}{
+		{input: `{"id":"a","n":1}`, output: `echo: {"id":"a","n":1}`},
+		{input: `{"id":"a","n":2}`, output: `echo: {"id":"a","n":1}`},
+		{input: `{"id":"b","n":3}`, output: `echo: {"id":"b","n":3}`},
+		{input: `{"id":"a","n":4}`, output: `echo: {"id":"a","n":1}`},
+	} {
+		inMsg := message.New([][]byte{[]byte(test.input)})
+		inMsg.Get(0).Metadata().Set("foo", "bar")
+
+		msgs, res := h.ProcessMessage(inMsg)
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len())
+
+		part := msgs[0].Get(0)
+		assert.Equal(t, test.output, string(part.Get()))
+		assert.Equal(t, "bar", part.Metadata().Get("foo"))
+		assert.Equal(t, "baz", part.Metadata().Get("foobar"))
+		assert.Equal(t, "200", part.Metadata().Get("http_status_code"))
+		assert.False(t, HasFailed(part))
+	}
+
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPCacheBatchKeys(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Cache.Resource = "foocache"
+	conf.HTTP.Cache.Key = `${! json("id") }`
+
+	h, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i, test := range []struct {
+		input    []string
+		requests uint32
+	}{
+		{input: []string{`{"id":"a"}`, `{"id":"b"}`}, requests: 1},
+		{input: []string{`{"id":"a"}`, `{"id":"c"}`}, requests: 2},
+		{input: []string{`{"id":"a"}`}, requests: 3},
+		{input: []string{`{"id":"a"}`, `{"id":"b"}`}, requests: 3},
+		{input: []string{`{"id":"b"}`, `{"id":"a"}`}, requests: 4},
+	} {
+		var parts [][]byte
+		for _, p := range test.input {
+			parts = append(parts, []byte(p))
+		}
+		msgs, res := h.ProcessMessage(message.New(parts))
+		require.Nil(t, res, i)
+		require.Len(t, msgs, 1, i)
+		assert.Equal(t, test.requests, atomic.LoadUint32(&reqCount), i)
+	}
+}
+
+func TestHTTPCacheParallel(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		reqBytes, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Write(append([]byte("echo: "), reqBytes...))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Parallel = true
+	conf.HTTP.Cache.Resource = "foocache"
+
+	h, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := h.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("foo"), []byte("baz"), []byte("foo"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte("echo: foo"), []byte("echo: bar"), []byte("echo: foo"), []byte("echo: baz"), []byte("echo: foo"),
+	}, message.GetAllBytes(msgs[0]))
+
+	reqsAfterFirst := atomic.LoadUint32(&reqCount)
+	assert.LessOrEqual(t, reqsAfterFirst, uint32(5))
+
+	msgs, res = h.ProcessMessage(message.New([][]byte{
+		[]byte("bar"), []byte("baz"), []byte("foo"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte("echo: bar"), []byte("echo: baz"), []byte("echo: foo"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, reqsAfterFirst, atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPCacheStatusCodes(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Cache.Resource = "foocache"
+
+	h, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		msgs, res := h.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "created", string(msgs[0].Get(0).Get()))
+	}
+	assert.Equal(t, uint32(3), atomic.LoadUint32(&reqCount))
+
+	conf.HTTP.Cache.StatusCodes = []int{200, 201}
+	h, err = NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		msgs, res := h.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "created", string(msgs[0].Get(0).Get()))
+		assert.Equal(t, "201", msgs[0].Get(0).Metadata().Get("http_status_code"))
+	}
+	assert.Equal(t, uint32(4), atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPCacheErrors(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.DropOn = []int{404}
+	conf.HTTP.Cache.Resource = "foocache"
+
+	h, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		msgs, res := h.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		assert.True(t, HasFailed(msgs[0].Get(0)))
+	}
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+
+	conf.HTTP.Cache.CacheErrors = true
+	h, err = NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		msgs, res := h.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		assert.True(t, HasFailed(msgs[0].Get(0)))
+		assert.Equal(t, "404", msgs[0].Get(0).Metadata().Get("http_status_code"))
+	}
+	assert.Equal(t, uint32(3), atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPCacheBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.HTTP.Config.URL = "http://localhost:4195"
+	conf.HTTP.Cache.Resource = "barcache"
+
+	_, err := NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.HTTP.Cache.Resource = "foocache"
+	conf.HTTP.Cache.TTL = "not a duration"
+
+	_, err = NewHTTP(conf, newHTTPCacheTestMgr(t), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
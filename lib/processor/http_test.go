@@ -14,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -221,6 +222,31 @@ func TestHTTPClientEmpty404Response(t *testing.T) {
 	}
 }
 
+func TestHTTPClientErrorBodyMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid foo"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.NumRetries = 0
+
+	h, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := h.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	p := msgs[0].Get(0)
+	assert.True(t, HasFailed(p))
+	assert.Contains(t, GetFail(p), "invalid foo")
+	assert.Equal(t, "400", p.Metadata().Get("http_status"))
+	assert.Equal(t, "invalid foo", p.Metadata().Get("http_error_body"))
+}
+
 func TestHTTPClientBasicWithMetadata(t *testing.T) {
 	i := 0
 	expPayloads := []string{"foo", "bar", "baz"}
@@ -262,6 +288,61 @@ func TestHTTPClientBasicWithMetadata(t *testing.T) {
 	}
 }
 
+func TestHTTPClientBatchFormatLines(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBytes, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Write([]byte(strings.ToUpper(string(reqBytes))))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.BatchFormat = "lines"
+	conf.HTTP.ResponseFormat = "lines"
+
+	h, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	inMsg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	inMsg.Get(1).Metadata().Set("index", "1")
+
+	msgs, res := h.ProcessMessage(inMsg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("FOO"), []byte("BAR"), []byte("BAZ")}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "1", msgs[0].Get(1).Metadata().Get("index"))
+	msgs[0].Iter(func(i int, p types.Part) error {
+		assert.Equal(t, "", GetFail(p))
+		return nil
+	})
+}
+
+func TestHTTPClientBatchFormatMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[\"foo\"]"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.BatchFormat = "json_array"
+	conf.HTTP.ResponseFormat = "json_array"
+	conf.HTTP.Config.NumRetries = 0
+
+	h, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := h.ProcessMessage(message.New([][]byte{[]byte(`"foo"`), []byte(`"bar"`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	msgs[0].Iter(func(i int, p types.Part) error {
+		assert.Equal(t, "response contained 1 messages but the request batch contained 2", GetFail(p))
+		return nil
+	})
+}
+
 func TestHTTPClientParallel(t *testing.T) {
 	wg := sync.WaitGroup{}
 	wg.Add(5)
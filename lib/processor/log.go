@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -10,7 +11,10 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/correlation"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/redact"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -81,6 +85,18 @@ pipeline:
           root.age = this.user.age
           root.kafka_topic = meta("kafka_topic")
 ` + "```" + `
+
+### Correlation IDs
+
+When [correlation IDs](/docs/configuration/metadata#correlation-ids) are enabled
+the ID of each message is added to the log as a field, which is overridden by a
+field of the same name set with ` + "`fields`" + `.
+
+### Redaction
+
+When [redaction rules](/docs/configuration/redaction) are configured the log
+message and the values of fields are redacted before they are printed, whereas
+the messages passing through this processor remain unchanged.
 `,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("level", "The log level to use.").HasOptions("FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL"),
@@ -131,6 +147,7 @@ type Log struct {
 	message *field.Expression
 	fields  map[string]*field.Expression
 	printFn func(logger log.Modular, msg string)
+	redact  *redact.Redactor
 
 	loggerWith    logWith
 	fieldsMapping *mapping.Executor
@@ -149,6 +166,7 @@ func NewLog(
 		level:   conf.Log.Level,
 		fields:  map[string]*field.Expression{},
 		message: message,
+		redact:  interop.GetRedactor(mgr),
 	}
 	if len(conf.Log.Fields) > 0 {
 		for k, v := range conf.Log.Fields {
@@ -239,6 +257,8 @@ func (l *Log) ProcessMessage(msg types.Message) ([]types.Message, types.Response
 			return []types.Message{resMsg}, nil
 		}
 
+		vObj = l.redactFields(vObj)
+
 		keys := make([]string, 0, len(vObj))
 		for k := range vObj {
 			keys = append(keys, k)
@@ -251,17 +271,35 @@ func (l *Log) ProcessMessage(msg types.Message) ([]types.Message, types.Response
 		}
 		targetLog = l.loggerWith.With(args...)
 	}
+	targetLog = correlation.WithFields(targetLog, msg)
 	if len(l.fields) > 0 {
 		interpFields := make(map[string]string, len(l.fields))
 		for k, vi := range l.fields {
-			interpFields[k] = vi.String(0, msg)
+			interpFields[k] = l.redact.String(vi.String(0, msg))
 		}
 		targetLog = log.WithFields(targetLog, interpFields)
 	}
-	l.printFn(targetLog, l.message.String(0, msg))
+	l.printFn(targetLog, l.redact.String(l.message.String(0, msg)))
 	return []types.Message{msg}, nil
 }
 
+// redactFields returns a redacted copy of the fields obtained from the fields
+// mapping, where the fields are redacted as a single structured document.
+func (l *Log) redactFields(fields map[string]interface{}) map[string]interface{} {
+	if l.redact == nil {
+		return fields
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return fields
+	}
+	var redacted map[string]interface{}
+	if err = json.Unmarshal(l.redact.Bytes(b), &redacted); err != nil {
+		return fields
+	}
+	return redacted
+}
+
 // CloseAsync shuts down the processor and stops processing requests.
 func (l *Log) CloseAsync() {
 }
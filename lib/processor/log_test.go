@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/Jeffail/benthos/v3/internal/correlation"
+	"github.com/Jeffail/benthos/v3/internal/redact"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -220,3 +222,78 @@ root.is_cool = this.is_cool`
 }
 
 //------------------------------------------------------------------------------
+
+type redactMgr struct {
+	types.DudMgr
+	r *redact.Redactor
+}
+
+func (m redactMgr) Redactor() *redact.Redactor {
+	return m.r
+}
+
+func TestLogRedaction(t *testing.T) {
+	redactor, err := redact.New([]redact.RuleConfig{
+		{Path: "user.password", Mode: redact.ModeMask},
+		{Regex: `\d{4}-\d{4}-\d{4}-\d{4}`, Mode: redact.ModePartial},
+	})
+	require.NoError(t, err)
+
+	conf := NewConfig()
+	conf.Type = TypeLog
+	conf.Log.Message = `${! content() }`
+	conf.Log.Fields = map[string]string{
+		"card": `${! json("card") }`,
+	}
+	conf.Log.FieldsMapping = `root.user = this.user
+root.card = this.card`
+
+	logMock := &mockLog{}
+	l, err := New(conf, redactMgr{r: redactor}, logMock, metrics.Noop())
+	require.NoError(t, err)
+
+	input := `{"card":"1234-5678-9012-3456","user":{"name":"foo","password":"hunter2"}}`
+	inMsg := message.New([][]byte{[]byte(input)})
+
+	msgs, res := l.ProcessMessage(inMsg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, input, string(msgs[0].Get(0).Get()))
+	jObj, err := msgs[0].Get(0).JSON()
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", jObj.(map[string]interface{})["user"].(map[string]interface{})["password"])
+
+	assert.Equal(t, []string{
+		`{"card":"***************3456","user":{"name":"foo","password":"********"}}`,
+	}, logMock.infos)
+	assert.Equal(t, []map[string]string{
+		{"card": "***************3456"},
+	}, logMock.fields)
+	assert.Equal(t, []interface{}{
+		"card", "***************3456",
+		"user", map[string]interface{}{"name": "foo", "password": "********"},
+	}, logMock.mappingFields)
+}
+
+func TestLogWithCorrelationID(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeLog
+	conf.Log.Message = "${!content()}"
+	conf.Log.Level = "INFO"
+
+	logMock := &mockLog{}
+	l, err := New(conf, nil, logMock, metrics.Noop())
+	require.NoError(t, err)
+
+	raw := message.New([][]byte{[]byte(`hello world`)})
+	raw.Get(0).Metadata().Set("cid", "foo")
+	input, err := correlation.Stamp("cid", raw)
+	require.NoError(t, err)
+
+	_, res := l.ProcessMessage(input)
+	require.Nil(t, res)
+
+	assert.Equal(t, []string{"hello world"}, logMock.infos)
+	assert.Equal(t, []map[string]string{{"cid": "foo"}}, logMock.fields)
+}
@@ -6,10 +6,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -28,6 +31,8 @@ func init() {
 		Description: `
 This processor works by evaluating an [interpolated field ` + "`value`" + `](/docs/configuration/interpolation#bloblang-queries) for each message and updating a emitted metric according to the [type](#types).
 
+Label values that are invalid for the configured metrics destination are sanitised rather than resulting in an error, for example by replacing invalid UTF-8 characters.
+
 Custom metrics such as these are emitted along with Benthos internal metrics, where you can customize where metrics are sent, which metric names are emitted and rename them as/when appropriate. For more information check out the [metrics docs here](/docs/components/metrics/about).`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("type", "The metric [type](#types) to create.").HasOptions(
@@ -45,7 +50,12 @@ Custom metrics such as these are emitted along with Benthos internal metrics, wh
 					"topic": "${! meta(\"kafka_topic\") }",
 				},
 			).IsInterpolated().Map(),
+			docs.FieldString(
+				"by", "An optional [Bloblang mapping](/docs/guides/bloblang/about) executed for each message that returns an object of label names and values, which are added to any labels specified with `labels`. The label names of the metric are determined by the first result of the mapping, subsequent results that are missing a label are given an empty value and any extra labels are ignored.",
+				`root.country = this.customer.country`,
+			).Linter(docs.LintBloblangMapping).Advanced().AtVersion("3.50.0"),
 			docs.FieldCommon("value", "For some metric types specifies a value to set, increment.").IsInterpolated(),
+			docs.FieldBool("aggregate_batch", "Whether to aggregate the values of each batch of messages by their label values before emitting them, which reduces contention within the metrics aggregator. Counter types are incremented by the sum of the values within a batch, gauges are set to the last value within a batch, and timings are not aggregated.").Advanced().AtVersion("3.50.0"),
 			PartsFieldSpec,
 		},
 		Examples: []docs.AnnotatedExample{
@@ -91,6 +101,19 @@ pipeline:
 metrics:
   prometheus:
     path_mapping: 'if this != "FooSize" { deleted() }'
+`,
+			},
+			{
+				Title:   "Counter By Field",
+				Summary: "In this example we emit a counter metric called `OrdersByCountry`, which is labelled with the country of each order as determined by a Bloblang mapping. Since our messages are consumed in batches we aggregate the counts of each batch before emitting them.",
+				Config: `
+pipeline:
+  processors:
+    - metric:
+        name: OrdersByCountry
+        type: counter
+        by: 'root.country = this.order.country.or("unknown")'
+        aggregate_batch: true
 `,
 			},
 		},
@@ -142,23 +165,27 @@ Equivalent to ` + "`gauge`" + ` where instead the metric is a timing.`,
 
 // MetricConfig contains configuration fields for the Metric processor.
 type MetricConfig struct {
-	Parts  []int             `json:"parts" yaml:"parts"`
-	Type   string            `json:"type" yaml:"type"`
-	Path   string            `json:"path" yaml:"path"`
-	Name   string            `json:"name" yaml:"name"`
-	Labels map[string]string `json:"labels" yaml:"labels"`
-	Value  string            `json:"value" yaml:"value"`
+	Parts          []int             `json:"parts" yaml:"parts"`
+	Type           string            `json:"type" yaml:"type"`
+	Path           string            `json:"path" yaml:"path"`
+	Name           string            `json:"name" yaml:"name"`
+	Labels         map[string]string `json:"labels" yaml:"labels"`
+	By             string            `json:"by" yaml:"by"`
+	Value          string            `json:"value" yaml:"value"`
+	AggregateBatch bool              `json:"aggregate_batch" yaml:"aggregate_batch"`
 }
 
 // NewMetricConfig returns a MetricConfig with default values.
 func NewMetricConfig() MetricConfig {
 	return MetricConfig{
-		Parts:  []int{},
-		Type:   "counter",
-		Path:   "",
-		Name:   "",
-		Labels: map[string]string{},
-		Value:  "",
+		Parts:          []int{},
+		Type:           "counter",
+		Path:           "",
+		Name:           "",
+		Labels:         map[string]string{},
+		By:             "",
+		Value:          "",
+		AggregateBatch: false,
 	}
 }
 
@@ -173,8 +200,17 @@ type Metric struct {
 	log   log.Modular
 	stats metrics.Type
 
+	name   string
 	value  *field.Expression
 	labels labels
+	by     *mapping.Executor
+
+	// When a `by` mapping is used the label names are resolved from the first
+	// result of the mapping, and therefore the vector metrics are lazily
+	// created.
+	vecMut         sync.Mutex
+	labelsResolved bool
+	labelNames     []string
 
 	mCounter metrics.StatCounter
 	mGauge   metrics.StatGauge
@@ -184,7 +220,9 @@ type Metric struct {
 	mGaugeVec   metrics.StatGaugeVec
 	mTimerVec   metrics.StatTimerVec
 
-	handler func(string, int, types.Message) error
+	handler   func(string, int, types.Message) (int64, bool, error)
+	emit      func(labelValues []string, amount int64)
+	aggregate func(prev, next int64) int64
 }
 
 type labels []label
@@ -205,6 +243,15 @@ func (l labels) names() []string {
 	return names
 }
 
+func (l labels) get(name string) *label {
+	for i := range l {
+		if l[i].name == name {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
 func (l labels) values(index int, msg types.Message) []string {
 	var values []string
 	for i := range l {
@@ -236,7 +283,6 @@ func NewMetric(
 		parts: conf.Metric.Parts,
 		conf:  conf,
 		log:   log,
-		stats: stats,
 		value: value,
 	}
 
@@ -258,6 +304,8 @@ func NewMetric(
 		// Remove any namespaces from the metric type.
 		stats = unwrapMetric(stats)
 	}
+	m.name = name
+	m.stats = stats
 
 	labelNames := make([]string, 0, len(conf.Metric.Labels))
 	for n := range conf.Metric.Labels {
@@ -276,137 +324,271 @@ func NewMetric(
 		})
 	}
 
+	if len(conf.Metric.By) > 0 {
+		if m.by, err = bloblang.NewMapping("", conf.Metric.By); err != nil {
+			return nil, fmt.Errorf("failed to parse by mapping: %w", err)
+		}
+	}
+
 	switch strings.ToLower(conf.Metric.Type) {
 	case "counter":
-		if len(m.labels) > 0 {
-			m.mCounterVec = stats.GetCounterVec(name, m.labels.names())
-		} else {
-			m.mCounter = stats.GetCounter(name)
-		}
 		m.handler = m.handleCounter
+		m.emit = m.emitCounter
+		m.aggregate = sumAggregate
 	case "counter_parts":
-		if len(m.labels) > 0 {
-			m.mCounterVec = stats.GetCounterVec(name, m.labels.names())
-		} else {
-			m.mCounter = stats.GetCounter(name)
-		}
 		m.handler = m.handleCounterParts
+		m.emit = m.emitCounter
+		m.aggregate = sumAggregate
 	case "counter_by":
-		if len(m.labels) > 0 {
-			m.mCounterVec = stats.GetCounterVec(name, m.labels.names())
-		} else {
-			m.mCounter = stats.GetCounter(name)
-		}
 		m.handler = m.handleCounterBy
+		m.emit = m.emitCounter
+		m.aggregate = sumAggregate
 	case "gauge":
-		if len(m.labels) > 0 {
-			m.mGaugeVec = stats.GetGaugeVec(name, m.labels.names())
-		} else {
-			m.mGauge = stats.GetGauge(name)
-		}
 		m.handler = m.handleGauge
+		m.emit = m.emitGauge
+		m.aggregate = lastAggregate
 	case "timing":
-		if len(m.labels) > 0 {
-			m.mTimerVec = stats.GetTimerVec(name, m.labels.names())
-		} else {
-			m.mTimer = stats.GetTimer(name)
-		}
 		m.handler = m.handleTimer
+		m.emit = m.emitTimer
 	default:
 		return nil, fmt.Errorf("metric type unrecognised: %v", conf.Metric.Type)
 	}
 
+	if m.by == nil {
+		m.initStats(m.labels.names())
+	}
 	return m, nil
 }
 
-func (m *Metric) handleCounter(val string, index int, msg types.Message) error {
-	if len(m.labels) > 0 {
-		m.mCounterVec.With(m.labels.values(index, msg)...).Incr(1)
-	} else {
-		m.mCounter.Incr(1)
+func (m *Metric) initStats(labelNames []string) {
+	m.labelsResolved = true
+	m.labelNames = labelNames
+	switch strings.ToLower(m.conf.Metric.Type) {
+	case "counter", "counter_parts", "counter_by":
+		if len(labelNames) > 0 {
+			m.mCounterVec = m.stats.GetCounterVec(m.name, labelNames)
+		} else {
+			m.mCounter = m.stats.GetCounter(m.name)
+		}
+	case "gauge":
+		if len(labelNames) > 0 {
+			m.mGaugeVec = m.stats.GetGaugeVec(m.name, labelNames)
+		} else {
+			m.mGauge = m.stats.GetGauge(m.name)
+		}
+	case "timing":
+		if len(labelNames) > 0 {
+			m.mTimerVec = m.stats.GetTimerVec(m.name, labelNames)
+		} else {
+			m.mTimer = m.stats.GetTimer(m.name)
+		}
 	}
-	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func sumAggregate(prev, next int64) int64 {
+	return prev + next
+}
+
+func lastAggregate(prev, next int64) int64 {
+	return next
+}
+
+func (m *Metric) handleCounter(val string, index int, msg types.Message) (int64, bool, error) {
+	return 1, true, nil
 }
 
 // TODO: V4 Remove this
-func (m *Metric) handleCounterParts(val string, index int, msg types.Message) error {
+func (m *Metric) handleCounterParts(val string, index int, msg types.Message) (int64, bool, error) {
 	if msg.Len() == 0 {
-		return nil
-	}
-	if len(m.labels) > 0 {
-		m.mCounterVec.With(m.labels.values(index, msg)...).Incr(int64(msg.Len()))
-	} else {
-		m.mCounter.Incr(int64(msg.Len()))
+		return 0, false, nil
 	}
-	return nil
+	return int64(msg.Len()), true, nil
 }
 
-func (m *Metric) handleCounterBy(val string, index int, msg types.Message) error {
+func parsePositiveMetricValue(val string) (int64, bool, error) {
 	i, err := strconv.ParseInt(val, 10, 64)
 	if err != nil {
-		return err
+		return 0, false, err
 	}
 	if i < 0 {
-		return errors.New("value is negative")
+		return 0, false, errors.New("value is negative")
+	}
+	return i, true, nil
+}
+
+func (m *Metric) handleCounterBy(val string, index int, msg types.Message) (int64, bool, error) {
+	return parsePositiveMetricValue(val)
+}
+
+func (m *Metric) handleGauge(val string, index int, msg types.Message) (int64, bool, error) {
+	return parsePositiveMetricValue(val)
+}
+
+func (m *Metric) handleTimer(val string, index int, msg types.Message) (int64, bool, error) {
+	return parsePositiveMetricValue(val)
+}
+
+func (m *Metric) emitCounter(labelValues []string, amount int64) {
+	if m.mCounterVec != nil {
+		m.mCounterVec.With(labelValues...).Incr(amount)
+	} else {
+		m.mCounter.Incr(amount)
 	}
-	if len(m.labels) > 0 {
-		m.mCounterVec.With(m.labels.values(index, msg)...).Incr(i)
+}
+
+func (m *Metric) emitGauge(labelValues []string, amount int64) {
+	if m.mGaugeVec != nil {
+		m.mGaugeVec.With(labelValues...).Set(amount)
 	} else {
-		m.mCounter.Incr(i)
+		m.mGauge.Set(amount)
 	}
-	return nil
 }
 
-func (m *Metric) handleGauge(val string, index int, msg types.Message) error {
-	i, err := strconv.ParseInt(val, 10, 64)
-	if err != nil {
-		return err
+func (m *Metric) emitTimer(labelValues []string, amount int64) {
+	if m.mTimerVec != nil {
+		m.mTimerVec.With(labelValues...).Timing(amount)
+	} else {
+		m.mTimer.Timing(amount)
 	}
-	if i < 0 {
-		return errors.New("value is negative")
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Metric) byLabels(index int, msg types.Message) (map[string]interface{}, error) {
+	v, err := m.by.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    index,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(index).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute by mapping: %w", err)
 	}
-	if len(m.labels) > 0 {
-		m.mGaugeVec.With(m.labels.values(index, msg)...).Set(i)
-	} else {
-		m.mGauge.Set(i)
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("by mapping yielded a non-object result: %T", v)
 	}
-	return nil
+	return obj, nil
 }
 
-func (m *Metric) handleTimer(val string, index int, msg types.Message) error {
-	i, err := strconv.ParseInt(val, 10, 64)
+// labelValues returns the sanitised values of each label of the metric for a
+// message part.
+func (m *Metric) labelValues(index int, msg types.Message) ([]string, error) {
+	if m.by == nil {
+		if len(m.labels) == 0 {
+			return nil, nil
+		}
+		values := m.labels.values(index, msg)
+		for i, v := range values {
+			values[i] = metrics.SanitiseLabelValue(m.stats, v)
+		}
+		return values, nil
+	}
+
+	byObj, err := m.byLabels(index, msg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if i < 0 {
-		return errors.New("value is negative")
+
+	m.vecMut.Lock()
+	if !m.labelsResolved {
+		names := m.labels.names()
+		for k := range byObj {
+			if _, exists := m.conf.Metric.Labels[k]; !exists {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		m.initStats(names)
 	}
-	if len(m.labels) > 0 {
-		m.mTimerVec.With(m.labels.values(index, msg)...).Timing(i)
-	} else {
-		m.mTimer.Timing(i)
+	labelNames := m.labelNames
+	m.vecMut.Unlock()
+
+	values := make([]string, len(labelNames))
+	for i, n := range labelNames {
+		var v string
+		if l := m.labels.get(n); l != nil {
+			v = l.val(index, msg)
+		} else if byV, exists := byObj[n]; exists {
+			v = query.IToString(byV)
+		}
+		values[i] = metrics.SanitiseLabelValue(m.stats, v)
 	}
-	return nil
+	return values, nil
+}
+
+//------------------------------------------------------------------------------
+
+type metricAggregation struct {
+	labelValues []string
+	amount      int64
 }
 
 // ProcessMessage applies the processor to a message
 func (m *Metric) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	if m.deprecated {
 		value := m.value.String(0, msg)
-		if err := m.handler(value, 0, msg); err != nil {
+		amount, ok, err := m.handler(value, 0, msg)
+		if err != nil {
 			m.log.Errorf("Handler error: %v\n", err)
+		} else if ok {
+			m.emit(m.labels.values(0, msg), amount)
 		}
 		return []types.Message{msg}, nil
 	}
+
+	var aggregated map[string]*metricAggregation
+	var aggregatedKeys []string
+	if m.conf.Metric.AggregateBatch && m.aggregate != nil {
+		aggregated = map[string]*metricAggregation{}
+	}
+
 	if err := iterateParts(m.parts, msg, func(index int, p types.Part) error {
 		value := m.value.String(index, msg)
-		if err := m.handler(value, index, msg); err != nil {
+		amount, ok, err := m.handler(value, index, msg)
+		if err != nil {
 			m.log.Errorf("Handler error: %v\n", err)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		labelValues, err := m.labelValues(index, msg)
+		if err != nil {
+			m.log.Errorf("Handler error: %v\n", err)
+			return nil
+		}
+		if aggregated == nil {
+			m.emit(labelValues, amount)
+			return nil
+		}
+		key := strings.Join(labelValues, "\x00")
+		if agg, exists := aggregated[key]; exists {
+			agg.amount = m.aggregate(agg.amount, amount)
+		} else {
+			aggregated[key] = &metricAggregation{
+				labelValues: labelValues,
+				amount:      amount,
+			}
+			aggregatedKeys = append(aggregatedKeys, key)
 		}
 		return nil
 	}); err != nil {
 		m.log.Errorf("Failed to iterate parts: %v\n", err)
 	}
+
+	for _, k := range aggregatedKeys {
+		agg := aggregated[k]
+		m.emit(agg.labelValues, agg.amount)
+	}
 	return []types.Message{msg}, nil
 }
 
@@ -1,11 +1,13 @@
 package processor
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -250,3 +252,109 @@ func TestMetricTiming(t *testing.T) {
 
 	assert.Equal(t, expMetrics, mockStats.values)
 }
+
+type mockLabelledMetric struct {
+	metrics.Type
+
+	labelNames []string
+	incrs      map[string][]int64
+}
+
+type mockLabelledCounter struct {
+	m   *mockLabelledMetric
+	key string
+}
+
+func (c mockLabelledCounter) Incr(count int64) error {
+	c.m.incrs[c.key] = append(c.m.incrs[c.key], count)
+	return nil
+}
+
+type mockLabelledCounterVec struct {
+	m *mockLabelledMetric
+}
+
+func (v mockLabelledCounterVec) With(labelValues ...string) metrics.StatCounter {
+	return mockLabelledCounter{m: v.m, key: strings.Join(labelValues, ",")}
+}
+
+func (m *mockLabelledMetric) GetCounterVec(path string, labelNames []string) metrics.StatCounterVec {
+	m.labelNames = labelNames
+	return mockLabelledCounterVec{m: m}
+}
+
+func TestMetricCounterByMapping(t *testing.T) {
+	mockStats := &mockLabelledMetric{
+		Type:  metrics.Noop(),
+		incrs: map[string][]int64{},
+	}
+
+	conf := NewConfig()
+	conf.Type = "metric"
+	conf.Metric.Type = "counter"
+	conf.Metric.Name = "orders"
+	conf.Metric.Labels = map[string]string{
+		"source": "${! meta(\"source\") }",
+	}
+	conf.Metric.By = `root.country = this.country
+root.tier = this.tier`
+
+	proc, err := New(conf, nil, log.Noop(), mockStats)
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"country":"UK","tier":"gold"}`),
+		[]byte(`{"country":"US","tier":"gold"}`),
+		[]byte(`{"country":"UK","tier":"gold"}`),
+		[]byte(`{"country":"UK"}`),
+		[]byte("{\"country\":\"\xff\",\"tier\":\"silver\"}"),
+	})
+	msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("source", "shop")
+		return nil
+	})
+
+	msgs, res := proc.ProcessMessage(msg)
+	assert.Len(t, msgs, 1)
+	assert.Nil(t, res)
+
+	assert.Equal(t, []string{"country", "source", "tier"}, mockStats.labelNames)
+	assert.Equal(t, map[string][]int64{
+		"UK,shop,gold":  {1, 1},
+		"US,shop,gold":  {1},
+		"UK,shop,null":  {1},
+		"�,shop,silver": {1},
+	}, mockStats.incrs)
+}
+
+func TestMetricCounterAggregateBatch(t *testing.T) {
+	mockStats := &mockLabelledMetric{
+		Type:  metrics.Noop(),
+		incrs: map[string][]int64{},
+	}
+
+	conf := NewConfig()
+	conf.Type = "metric"
+	conf.Metric.Type = "counter_by"
+	conf.Metric.Name = "orders"
+	conf.Metric.By = `root.country = this.country`
+	conf.Metric.Value = `${! json("count") }`
+	conf.Metric.AggregateBatch = true
+
+	proc, err := New(conf, nil, log.Noop(), mockStats)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"country":"UK","count":2}`),
+		[]byte(`{"country":"US","count":1}`),
+		[]byte(`{"country":"UK","count":3}`),
+		[]byte(`{"country":"UK","count":-3}`),
+	}))
+	assert.Len(t, msgs, 1)
+	assert.Nil(t, res)
+
+	assert.Equal(t, map[string][]int64{
+		"UK": {5},
+		"US": {1},
+	}, mockStats.incrs)
+}
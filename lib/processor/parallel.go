@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -102,6 +103,12 @@ func NewParallel(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return p.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors and the message is rejected if it is cancelled.
+func (p *Parallel) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
 	resultMsgs := make([]types.Message, msg.Len())
@@ -125,7 +132,7 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 	for i := 0; i < max; i++ {
 		go func() {
 			for index := range reqChan {
-				resMsgs, res := ExecuteAll(p.children, resultMsgs[index])
+				resMsgs, res := ExecuteAllWithContext(ctx, p.children, resultMsgs[index])
 				if res != nil && res.SkipAck() {
 					atomic.AddInt32(&unAcks, 1)
 				}
@@ -147,6 +154,10 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 	close(reqChan)
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
+
 	resMsg := message.New(nil)
 	for _, m := range resultMsgs {
 		m.Iter(func(i int, p types.Part) error {
@@ -0,0 +1,231 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePriority] = TypeSpec{
+		constructor: NewPriority,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Assigns an integer priority to each message of a batch by executing a
+[Bloblang query](/docs/guides/bloblang/about/).`,
+		Description: `
+Priorities are stored internally alongside each message and are not visible as
+metadata. Components that support priorities, such as the
+` + "[`memory` buffer](/docs/components/buffers/memory)" + ` when its
+` + "`priority`" + ` field is enabled, prefer messages of a higher priority over
+those of a lower priority when multiple messages are queued. Messages that have
+not been assigned a priority default to zero.
+
+Buffers sit between the inputs and the pipeline of a stream, and therefore in
+order for priorities to affect the order in which a buffer flushes messages
+they must be assigned before the buffer, using this processor within the
+` + "`processors`" + ` of an input.
+
+Priorities are clamped to the range set by the fields ` + "`min_priority`" + ` and
+` + "`max_priority`" + `, which keeps the number of distinct priorities, and
+therefore the number of metric series labelled by priority, bounded.
+
+If the query fails, or does not result in an integer, the message is flagged as
+having failed and its priority is not changed, where it can be handled using the
+patterns outlined [here](/docs/configuration/error_handling).
+
+### Metrics
+
+The counter metric ` + "`assigned`" + `, labelled by ` + "`priority`" + `,
+tracks the number of messages assigned each priority after clamping.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"value",
+				"A [Bloblang query](/docs/guides/bloblang/about/) that should return an integer priority for each message.",
+				`if this.type == "control" { 10 } else { 0 }`,
+				`meta("priority").number().catch(0)`,
+			).HasDefault("0").Linter(docs.LintBloblangMapping),
+			docs.FieldAdvanced("min_priority", "The lowest priority that can be assigned, lower priorities are raised to this value.").HasDefault(0),
+			docs.FieldAdvanced("max_priority", "The highest priority that can be assigned, higher priorities are lowered to this value.").HasDefault(10),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Control Messages First",
+				Summary: `
+Here we have a stream that mixes small control messages with bulk data, and we
+wish for control messages to jump ahead of any bulk data that is buffered. The
+priorities are assigned within the processors of the input so that they're set
+before messages reach the buffer:`,
+				Config: `
+input:
+  http_server:
+    path: /post
+  processors:
+    - priority:
+        value: 'if this.type == "control" { 10 } else { 0 }'
+
+buffer:
+  memory:
+    priority:
+      enabled: true
+      starvation_ratio: 100
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// PriorityConfig contains configuration fields for the Priority processor.
+type PriorityConfig struct {
+	Value       string `json:"value" yaml:"value"`
+	MinPriority int    `json:"min_priority" yaml:"min_priority"`
+	MaxPriority int    `json:"max_priority" yaml:"max_priority"`
+}
+
+// NewPriorityConfig returns a PriorityConfig with default values.
+func NewPriorityConfig() PriorityConfig {
+	return PriorityConfig{
+		Value:       "0",
+		MinPriority: 0,
+		MaxPriority: 10,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Priority is a processor that assigns integer priorities to messages.
+type Priority struct {
+	value    *mapping.Executor
+	min, max int
+	log      log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mAssigned  metrics.StatCounterVec
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewPriority returns a Priority processor.
+func NewPriority(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.Priority.MinPriority > conf.Priority.MaxPriority {
+		return nil, fmt.Errorf("min_priority %v must not be greater than max_priority %v", conf.Priority.MinPriority, conf.Priority.MaxPriority)
+	}
+	value, err := bloblang.NewMapping("", conf.Priority.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse value query: %w", err)
+	}
+	return &Priority{
+		value: value,
+		min:   conf.Priority.MinPriority,
+		max:   conf.Priority.MaxPriority,
+		log:   log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mAssigned:  stats.GetCounterVec("assigned", []string{"priority"}),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (p *Priority) queryPriority(index int, msg types.Message) (int, error) {
+	resPart, err := p.value.MapPart(index, msg)
+	if err != nil {
+		return 0, err
+	}
+	if resPart == nil {
+		return 0, fmt.Errorf("query resulted in a deleted message")
+	}
+	v, err := resPart.JSON()
+	if err != nil {
+		return 0, err
+	}
+	i, err := query.IGetInt(v)
+	if err != nil {
+		return 0, query.NewTypeErrorFrom("value", v, query.ValueNumber)
+	}
+	if i < int64(p.min) {
+		return p.min, nil
+	}
+	if i > int64(p.max) {
+		return p.max, nil
+	}
+	return int(i), nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *Priority) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypePriority, msg)
+	defer func() {
+		for _, s := range spans {
+			s.Finish()
+		}
+	}()
+
+	newMsg := message.New(nil)
+	parts := make([]types.Part, msg.Len())
+	_ = msg.Iter(func(i int, part types.Part) error {
+		priority, err := p.queryPriority(i, msg)
+		if err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Failed to assign priority: %v\n", err)
+			parts[i] = part.Copy()
+			FlagErr(parts[i], err)
+			spans[i].SetTag("error", true)
+			spans[i].LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		}
+		p.mAssigned.With(strconv.Itoa(priority)).Incr(1)
+		parts[i] = imessage.WithPriority(part, priority)
+		return nil
+	})
+	newMsg.SetAll(parts)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *Priority) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *Priority) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"testing"
+
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePriority
+	conf.Priority.Value = `if this.type == "control" { 10 } else { this.priority | 0 }`
+	conf.Priority.MinPriority = -5
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"type":"control"}`),
+		[]byte(`{"type":"data"}`),
+		[]byte(`{"type":"data","priority":-3}`),
+		[]byte(`{"type":"data","priority":"nope"}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 5, msgs[0].Len())
+
+	for i, exp := range []int{10, 0, -3} {
+		assert.Equal(t, exp, imessage.GetPriority(msgs[0].Get(i)), i)
+		assert.False(t, HasFailed(msgs[0].Get(i)), i)
+	}
+	for _, i := range []int{3, 4} {
+		assert.Equal(t, 0, imessage.GetPriority(msgs[0].Get(i)), i)
+		assert.True(t, HasFailed(msgs[0].Get(i)), i)
+	}
+	assert.Equal(t, 10, imessage.GetBatchPriority(msgs[0]))
+}
+
+func TestPriorityClamped(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePriority
+	conf.Priority.Value = `this.priority`
+	conf.Priority.MaxPriority = 5
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"priority":-3}`),
+		[]byte(`{"priority":3}`),
+		[]byte(`{"priority":1000000}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	for i, exp := range []int{0, 3, 5} {
+		assert.Equal(t, exp, imessage.GetPriority(msgs[0].Get(i)), i)
+		assert.False(t, HasFailed(msgs[0].Get(i)), i)
+	}
+
+	conf.Priority.MinPriority = 6
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
+
+func TestPriorityBadQuery(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePriority
+	conf.Priority.Value = `this.foo.(`
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
@@ -88,9 +88,15 @@ func NewResource(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (r *Resource) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	return r.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor resource to a message, the
+// context is provided to the resource.
+func (r *Resource) ProcessMessageWithContext(ctx context.Context, msg types.Message) (msgs []types.Message, res types.Response) {
 	r.mCount.Incr(1)
-	if err := interop.AccessProcessor(context.Background(), r.mgr, r.name, func(p types.Processor) {
-		msgs, res = p.ProcessMessage(msg)
+	if err := interop.AccessProcessor(ctx, r.mgr, r.name, func(p types.Processor) {
+		msgs, res = ProcessWithContext(ctx, p, msg)
 	}); err != nil {
 		r.log.Debugf("Failed to obtain processor resource '%v': %v", r.name, err)
 		r.mErrNotFound.Incr(1)
@@ -0,0 +1,323 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/opentracing/opentracing-go"
+	opentracinglog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeRetry] = TypeSpec{
+		constructor: NewRetry,
+		Categories: []Category{
+			CategoryComposition,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Executes child processors on each message of a batch individually, and if a
+processing step fails the message is restored to its original state and the
+processors are executed again, with an exponential backoff between attempts.`,
+		Description: `
+This processor is useful for wrapping processors that are prone to transient
+failures, such as an ` + "[`http`](/docs/components/processors/http)" + `
+processor calling a rate limited API, where we wish to retry the work before
+flagging the message as failed.
+
+Each attempt is made against a copy of the message as it was before reaching
+this processor, and therefore any changes made by child processors during a
+failed attempt are discarded.
+
+The field ` + "`check`" + `, if set, is a [Bloblang query](/docs/guides/bloblang/about/)
+executed against the failed message that determines whether the error is worth
+retrying, where the functions ` + "`error()` and `errored()`" + ` can be used in
+order to inspect the failure.
+
+Once the retry attempts have been exhausted, or the check does not pass, the
+message continues down the pipeline flagged with the error from the last
+attempt, where it can be handled using the patterns outlined
+[here](/docs/configuration/error_handling).
+
+### Metrics
+
+The metric ` + "`retry`" + ` counts the number of retry attempts made, and
+` + "`error`" + ` counts the messages that failed after all attempts were made.`,
+		FieldSpecs: retries.FieldSpecs().Add(
+			docs.FieldCommon(
+				"check",
+				"An optional [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a failed message should be retried. If left empty all errors are retried.",
+				`error().contains("429")`,
+				`!error().contains("invalid")`,
+			).HasDefault("").Linter(docs.LintBloblangMapping),
+			docs.FieldCommon("processors", "A list of child processors to execute on each message.").Array().HasType(docs.FieldTypeProcessor),
+		),
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Retry Rate Limited Enrichments",
+				Summary: `
+Here we enrich documents with the response of an HTTP API, and retry the
+request only when the API reports that we've been rate limited:`,
+				Config: `
+pipeline:
+  processors:
+    - retry:
+        max_retries: 5
+        backoff:
+          initial_interval: 1s
+          max_interval: 30s
+        check: 'error().contains("429")'
+        processors:
+          - branch:
+              request_map: 'root.id = this.user.id'
+              processors:
+                - http:
+                    url: http://example.com/users
+                    verb: POST
+              result_map: 'root.user.profile = this'
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RetryConfig is a config struct containing fields for the Retry processor.
+type RetryConfig struct {
+	retries.Config `json:",inline" yaml:",inline"`
+	Check          string   `json:"check" yaml:"check"`
+	Processors     []Config `json:"processors" yaml:"processors"`
+}
+
+// NewRetryConfig returns a default RetryConfig.
+func NewRetryConfig() RetryConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 3
+	rConf.Backoff.InitialInterval = "500ms"
+	rConf.Backoff.MaxInterval = "10s"
+	rConf.Backoff.MaxElapsedTime = "1m"
+	return RetryConfig{
+		Config:     rConf,
+		Check:      "",
+		Processors: []Config{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Retry is a processor that executes child processors on messages and retries
+// them with a backoff when they fail.
+type Retry struct {
+	running   int32
+	children  []types.Processor
+	check     *mapping.Executor
+	backoff   func() backoff.BackOff
+	closeChan chan struct{}
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mRetry     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewRetry returns a Retry processor.
+func NewRetry(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	boffCtor, err := conf.Retry.GetCtor()
+	if err != nil {
+		return nil, err
+	}
+
+	var check *mapping.Executor
+	if len(conf.Retry.Check) > 0 {
+		if check, err = bloblang.NewMapping("", conf.Retry.Check); err != nil {
+			return nil, fmt.Errorf("failed to parse check query: %w", err)
+		}
+	}
+
+	var children []types.Processor
+	for i, pconf := range conf.Retry.Processors {
+		pMgr, pLog, pStats := interop.LabelChild(fmt.Sprintf("retry.%v", i), mgr, log, stats)
+		var proc Type
+		if proc, err = New(pconf, pMgr, pLog, pStats); err != nil {
+			return nil, err
+		}
+		children = append(children, proc)
+	}
+
+	return &Retry{
+		running:   1,
+		children:  children,
+		check:     check,
+		backoff:   boffCtor,
+		closeChan: make(chan struct{}),
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mRetry:     stats.GetCounter("retry"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// checkResult returns whether any message of a result has failed, and if so
+// whether the first failed message should be retried.
+func (r *Retry) checkResult(msgs []types.Message) (failed, retry bool) {
+	for _, m := range msgs {
+		failedIndex := -1
+		_ = m.Iter(func(i int, p types.Part) error {
+			if failedIndex == -1 && HasFailed(p) {
+				failedIndex = i
+			}
+			return nil
+		})
+		if failedIndex == -1 {
+			continue
+		}
+		if r.check == nil {
+			return true, true
+		}
+		c, err := r.check.QueryPart(failedIndex, m)
+		if err != nil {
+			r.log.Errorf("Query failed for retry check: %v\n", err)
+			return true, false
+		}
+		return true, c
+	}
+	return false, false
+}
+
+func (r *Retry) processPart(ctx context.Context, p types.Part, span opentracing.Span) ([]types.Message, types.Response) {
+	boff := r.backoff()
+
+	for {
+		attemptMsg := message.New(nil)
+		attemptMsg.Append(p.DeepCopy())
+
+		resMsgs, res := ExecuteTryAllWithContext(ctx, r.children, attemptMsg)
+		if res != nil {
+			return resMsgs, res
+		}
+
+		failed, retry := r.checkResult(resMsgs)
+		if !failed {
+			return resMsgs, nil
+		}
+
+		var nextSleep time.Duration
+		if retry {
+			nextSleep = boff.NextBackOff()
+		}
+		if !retry || nextSleep == backoff.Stop {
+			r.mErr.Incr(1)
+			return resMsgs, nil
+		}
+
+		r.mRetry.Incr(1)
+		r.log.Debugf("Retrying failed message in %v\n", nextSleep)
+		span.LogFields(opentracinglog.Event("retry"))
+
+		select {
+		case <-time.After(nextSleep):
+		case <-r.closeChan:
+			return nil, response.NewError(types.ErrTypeClosed)
+		case <-ctx.Done():
+			return nil, response.NewError(ctx.Err())
+		}
+	}
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (r *Retry) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return r.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors and cancelling it aborts any pending retries.
+func (r *Retry) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	r.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypeRetry, msg)
+	defer func() {
+		for _, s := range spans {
+			s.Finish()
+		}
+	}()
+
+	resMsg := message.New(nil)
+	for i := 0; i < msg.Len(); i++ {
+		if atomic.LoadInt32(&r.running) != 1 {
+			return nil, response.NewError(types.ErrTypeClosed)
+		}
+		resMsgs, res := r.processPart(ctx, msg.Get(i), spans[i])
+		if res != nil && res.Error() != nil {
+			return nil, res
+		}
+		for _, m := range resMsgs {
+			_ = m.Iter(func(_ int, p types.Part) error {
+				resMsg.Append(p)
+				return nil
+			})
+		}
+	}
+
+	if resMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	r.mBatchSent.Incr(1)
+	r.mSent.Incr(int64(resMsg.Len()))
+
+	resMsgs := [1]types.Message{resMsg}
+	return resMsgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (r *Retry) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&r.running, 1, 0) {
+		close(r.closeChan)
+	}
+	for _, p := range r.children {
+		p.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (r *Retry) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, p := range r.children {
+		if err := p.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func retryTestConfig(mappings ...string) Config {
+	conf := NewConfig()
+	conf.Type = TypeRetry
+	conf.Retry.Backoff.InitialInterval = "1ms"
+	conf.Retry.Backoff.MaxInterval = "1ms"
+
+	for _, m := range mappings {
+		procConf := NewConfig()
+		procConf.Type = TypeBloblang
+		procConf.Bloblang = BloblangConfig(m)
+		conf.Retry.Processors = append(conf.Retry.Processors, procConf)
+	}
+	return conf
+}
+
+func TestRetryEventualSuccess(t *testing.T) {
+	conf := retryTestConfig(
+		`root = content().uppercase()`,
+		`root = if count("retry_test_eventual") < 3 { throw("nope") } else { content() }`,
+	)
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("hello world")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	// The uppercase processor should only be applied once as the original
+	// message is restored between attempts.
+	assert.Equal(t, [][]byte{[]byte("HELLO WORLD")}, message.GetAllBytes(msgs[0]))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+}
+
+func TestRetryExhausted(t *testing.T) {
+	conf := retryTestConfig(
+		`root = count("retry_test_exhausted")`,
+		`root = throw("nope")`,
+	)
+	conf.Retry.MaxRetries = 2
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("hello world")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	// One initial attempt plus two retries.
+	assert.Equal(t, [][]byte{[]byte("3")}, message.GetAllBytes(msgs[0]))
+	assert.Contains(t, GetFail(msgs[0].Get(0)), "nope")
+}
+
+func TestRetryCheck(t *testing.T) {
+	conf := retryTestConfig(
+		`root = count("retry_test_check_" + content())`,
+		`root = if content() == "1" { throw("retryable") } else if content() == "2" { throw("terminal") } else { content() }`,
+	)
+	conf.Retry.Check = `!error().contains("terminal")`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	// First attempt is retried, the second attempt fails with a terminal error
+	// and is therefore not.
+	assert.Equal(t, [][]byte{[]byte("2")}, message.GetAllBytes(msgs[0]))
+	assert.Contains(t, GetFail(msgs[0].Get(0)), "terminal")
+}
+
+func TestRetryBatchIndividually(t *testing.T) {
+	conf := retryTestConfig(
+		`root = if content() == "bad" { throw("nope") } else { content().uppercase() }`,
+	)
+	conf.Retry.MaxRetries = 1
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bad"), []byte("bar"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte("FOO"), []byte("bad"), []byte("BAR"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.True(t, HasFailed(msgs[0].Get(1)))
+	assert.False(t, HasFailed(msgs[0].Get(2)))
+}
+
+func TestRetryFiltered(t *testing.T) {
+	conf := retryTestConfig(`root = deleted()`)
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestRetryClose(t *testing.T) {
+	conf := retryTestConfig(`root = throw("nope")`)
+	conf.Retry.MaxRetries = 0
+	conf.Retry.Backoff.MaxElapsedTime = "0s"
+	conf.Retry.Backoff.InitialInterval = "10s"
+	conf.Retry.Backoff.MaxInterval = "10s"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		proc.CloseAsync()
+	}()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.Error(t, res.Error())
+	require.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	conf := retryTestConfig(`root = throw("nope")`)
+	conf.Retry.MaxRetries = 0
+	conf.Retry.Backoff.MaxElapsedTime = "0s"
+	conf.Retry.Backoff.InitialInterval = "10s"
+	conf.Retry.Backoff.MaxInterval = "10s"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	msgs, res := ProcessWithContext(ctx, proc, message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.Equal(t, context.DeadlineExceeded, res.Error())
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+}
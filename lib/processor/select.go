@@ -0,0 +1,301 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	imessage "github.com/Jeffail/benthos/v3/internal/message"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSelect] = TypeSpec{
+		constructor: NewSelect,
+		Status:      docs.StatusExperimental,
+		Version:     "3.50.0",
+		Categories: []Category{
+			CategoryComposition,
+		},
+		Summary: `
+Executes child processors only on the messages of a batch that are selected by
+their index or a [Bloblang query](/docs/guides/bloblang/about/), where all
+other messages pass through untouched.`,
+		Description: `
+A message is selected when its index is within the field ` + "`parts`" + ` and
+the query ` + "`check`" + ` resolves to true, where a field left empty is
+ignored. The query is executed in the context of the whole batch, and therefore
+functions such as ` + "`batch_index()`" + ` and ` + "`batch_size()`" + ` refer to
+the batch that the ` + "`select`" + ` processor received.
+
+Unlike the ` + "[`select_parts`](/docs/components/processors/select_parts)" + `
+processor the messages that aren't selected remain in the batch, and unlike the
+` + "[`switch`](/docs/components/processors/switch)" + ` processor the order of
+messages is preserved.
+
+## Batching
+
+The selected messages are processed as a batch by the child processors. The
+Bloblang functions ` + "[`origin_batch_index`](/docs/guides/bloblang/functions#origin_batch_index)" + `,
+` + "[`origin_batch_size`](/docs/guides/bloblang/functions#origin_batch_size)" + ` and
+` + "[`origin_batch_bytes`](/docs/guides/bloblang/functions#origin_batch_bytes)" + `
+can be used within the child processors in order to reference the whole batch
+that the ` + "`select`" + ` processor received.
+
+Messages resulting from the child processors take the position of the selected
+message that they originated from. Messages created by the child processors,
+such as those added by ` + "[`bloblang_batch`](/docs/components/processors/bloblang_batch)" + `,
+are positioned after the message that precedes them in the result of the child
+processors, or before all other results when they come first.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldInt(
+				"parts",
+				"An optional array of message indexes of a batch to select. Indexes can be negative, and if so the part will be selected from the end counting backwards starting from -1.",
+				[]int{0}, []int{-1},
+			).Array().HasDefault([]interface{}{}),
+			docs.FieldString(
+				"check",
+				"An optional [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should be selected. If the query fails the message is flagged [as having failed](/docs/configuration/error_handling) and is not selected.",
+				`batch_index() == batch_size() - 1`,
+				`this.type == "header"`,
+			).HasDefault("").Linter(docs.LintBloblangMapping),
+			docs.FieldCommon(
+				"processors",
+				"A list of [processors](/docs/components/processors/about/) to execute on the selected messages.",
+			).HasDefault([]interface{}{}).Array().HasType(docs.FieldTypeProcessor),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Headers and Trailers",
+				Summary: `
+Here we add a header message to the start of each batch and a trailer message
+containing the number of records and their total size to the end, which is
+useful for writing files with an envelope:`,
+				Config: `
+pipeline:
+  processors:
+    - select:
+        parts: [ 0 ]
+        processors:
+          - bloblang_batch: |
+              root = [ { "type": "header", "created_at": now() } ].merge(this)
+    - select:
+        parts: [ -1 ]
+        processors:
+          - bloblang_batch: |
+              root = this.append({
+                "type": "trailer",
+                "count": origin_batch_size(),
+                "bytes": origin_batch_bytes()
+              })
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SelectConfig is a config struct containing fields for the Select processor.
+type SelectConfig struct {
+	Parts      []int    `json:"parts" yaml:"parts"`
+	Check      string   `json:"check" yaml:"check"`
+	Processors []Config `json:"processors" yaml:"processors"`
+}
+
+// NewSelectConfig returns a default SelectConfig.
+func NewSelectConfig() SelectConfig {
+	return SelectConfig{
+		Parts:      []int{},
+		Check:      "",
+		Processors: []Config{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Select is a processor that applies child processors to a selection of the
+// messages of a batch.
+type Select struct {
+	parts    []int
+	check    *mapping.Executor
+	children []types.Processor
+
+	log log.Modular
+
+	mCount    metrics.StatCounter
+	mSelected metrics.StatCounter
+	mErr      metrics.StatCounter
+	mSent     metrics.StatCounter
+}
+
+// NewSelect returns a Select processor.
+func NewSelect(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var check *mapping.Executor
+	var err error
+	if len(conf.Select.Check) > 0 {
+		if check, err = bloblang.NewMapping("", conf.Select.Check); err != nil {
+			return nil, fmt.Errorf("failed to parse check query: %w", err)
+		}
+	}
+
+	if len(conf.Select.Parts) == 0 && check == nil {
+		return nil, errors.New("at least one of parts or check must be specified")
+	}
+
+	var children []types.Processor
+	for i, pconf := range conf.Select.Processors {
+		pMgr, pLog, pStats := interop.LabelChild(fmt.Sprintf("select.%v", i), mgr, log, stats)
+		var proc Type
+		if proc, err = New(pconf, pMgr, pLog, pStats); err != nil {
+			return nil, err
+		}
+		children = append(children, proc)
+	}
+
+	return &Select{
+		parts:    conf.Select.Parts,
+		check:    check,
+		children: children,
+
+		log: log,
+
+		mCount:    stats.GetCounter("count"),
+		mSelected: stats.GetCounter("selected"),
+		mErr:      stats.GetCounter("error"),
+		mSent:     stats.GetCounter("sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Select) isSelected(index int, msg types.Message) bool {
+	if len(s.parts) > 0 {
+		found := false
+		for _, i := range s.parts {
+			if i < 0 {
+				i = msg.Len() + i
+			}
+			if i == index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if s.check == nil {
+		return true
+	}
+	selected, err := s.check.QueryPart(index, msg)
+	if err != nil {
+		s.mErr.Incr(1)
+		s.log.Errorf("Failed to test message %v: %v\n", index, err)
+		FlagErr(msg.Get(index), err)
+		return false
+	}
+	return selected
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (s *Select) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return s.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors.
+func (s *Select) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	s.mCount.Incr(1)
+
+	sortGroup, sortMsg := imessage.NewSortGroup(msg)
+
+	// The results of each message of the batch by index, where a selected
+	// message is replaced with the results of the child processors.
+	results := make([][]types.Part, sortMsg.Len())
+	var selected []types.Part
+	var firstSelected = -1
+
+	sortMsg.Iter(func(i int, p types.Part) error {
+		if !s.isSelected(i, sortMsg) {
+			results[i] = []types.Part{p}
+			return nil
+		}
+		if firstSelected == -1 {
+			firstSelected = i
+		}
+		selected = append(selected, imessage.WithOriginBatch(p, sortMsg, i))
+		return nil
+	})
+
+	if len(selected) > 0 {
+		s.mSelected.Incr(int64(len(selected)))
+
+		execMsg := message.New(nil)
+		execMsg.SetAll(selected)
+
+		msgs, res := ExecuteAllWithContext(ctx, s.children, execMsg)
+		if res != nil && res.Error() != nil {
+			return nil, res
+		}
+
+		current := firstSelected
+		for _, m := range msgs {
+			m.Iter(func(_ int, p types.Part) error {
+				if i := sortGroup.GetIndex(p); i >= 0 {
+					current = i
+				}
+				results[current] = append(results[current], imessage.WithoutOriginBatch(p))
+				return nil
+			})
+		}
+	}
+
+	var resultParts []types.Part
+	for _, parts := range results {
+		resultParts = append(resultParts, parts...)
+	}
+	if len(resultParts) == 0 {
+		return nil, response.NewAck()
+	}
+
+	resMsg := message.New(nil)
+	resMsg.SetAll(resultParts)
+
+	s.mSent.Incr(int64(resMsg.Len()))
+	return []types.Message{resMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (s *Select) CloseAsync() {
+	for _, c := range s.children {
+		c.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (s *Select) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, c := range s.children {
+		if err := c.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
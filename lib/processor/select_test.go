@@ -0,0 +1,198 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectMessages(t *testing.T) {
+	blobl := func(mapping string) Config {
+		conf := NewConfig()
+		conf.Type = TypeBloblang
+		conf.Bloblang = BloblangConfig(mapping)
+		return conf
+	}
+	blobBatch := func(mapping string) Config {
+		conf := NewConfig()
+		conf.Type = TypeBloblangBatch
+		conf.BloblangBatch = BloblangBatchConfig(mapping)
+		return conf
+	}
+
+	tests := []struct {
+		name       string
+		parts      []int
+		check      string
+		processors []Config
+		input      []string
+		output     []string
+	}{
+		{
+			name:       "first message",
+			parts:      []int{0},
+			processors: []Config{blobl(`root = content().uppercase()`)},
+			input:      []string{"foo", "bar", "baz"},
+			output:     []string{"FOO", "bar", "baz"},
+		},
+		{
+			name:       "last message",
+			parts:      []int{-1},
+			processors: []Config{blobl(`root = content().uppercase()`)},
+			input:      []string{"foo", "bar", "baz"},
+			output:     []string{"foo", "bar", "BAZ"},
+		},
+		{
+			name:       "out of range",
+			parts:      []int{5, -5},
+			processors: []Config{blobl(`root = content().uppercase()`)},
+			input:      []string{"foo", "bar"},
+			output:     []string{"foo", "bar"},
+		},
+		{
+			name:       "check",
+			check:      `batch_index() > 0 && batch_index() < batch_size() - 1`,
+			processors: []Config{blobl(`root = content().uppercase()`)},
+			input:      []string{"foo", "bar", "baz", "buz"},
+			output:     []string{"foo", "BAR", "BAZ", "buz"},
+		},
+		{
+			name:       "parts and check",
+			parts:      []int{0, 2},
+			check:      `content() != "baz"`,
+			processors: []Config{blobl(`root = content().uppercase()`)},
+			input:      []string{"foo", "bar", "baz"},
+			output:     []string{"FOO", "bar", "baz"},
+		},
+		{
+			name:       "dropped",
+			parts:      []int{1},
+			processors: []Config{blobl(`root = deleted()`)},
+			input:      []string{"foo", "bar", "baz"},
+			output:     []string{"foo", "baz"},
+		},
+		{
+			name:  "header",
+			parts: []int{0},
+			processors: []Config{
+				blobBatch(`root = [ "header %v".format(origin_batch_size()) ].merge(this.map_each(ele -> ele.uppercase()))`),
+			},
+			input:  []string{"foo", "bar"},
+			output: []string{"header 2", "FOO", "bar"},
+		},
+		{
+			name:  "trailer",
+			parts: []int{-1},
+			processors: []Config{
+				blobBatch(`root = this.append("count %v bytes %v".format(origin_batch_size(), origin_batch_bytes()))`),
+			},
+			input:  []string{"foo", "bar", "bazz"},
+			output: []string{"foo", "bar", "bazz", "count 3 bytes 10"},
+		},
+		{
+			name:  "origin index",
+			check: `batch_index() != 1`,
+			processors: []Config{
+				blobl(`root = "%v: %v of %v".format(content().string(), origin_batch_index(), origin_batch_size())`),
+			},
+			input:  []string{"foo", "bar", "baz"},
+			output: []string{"foo: 0 of 3", "bar", "baz: 2 of 3"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeSelect
+			conf.Select.Parts = test.parts
+			conf.Select.Check = test.check
+			conf.Select.Processors = test.processors
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			var input [][]byte
+			for _, s := range test.input {
+				input = append(input, []byte(s))
+			}
+
+			msgs, res := proc.ProcessMessage(message.New(input))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			var output []string
+			for _, b := range message.GetAllBytes(msgs[0]) {
+				output = append(output, string(b))
+			}
+			assert.Equal(t, test.output, output)
+
+			proc.CloseAsync()
+			assert.NoError(t, proc.WaitForClose(time.Second))
+		})
+	}
+}
+
+func TestSelectOriginRemoved(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSelect
+	conf.Select.Parts = []int{0}
+
+	procConf := NewConfig()
+	procConf.Type = TypeNoop
+	conf.Select.Processors = []Config{procConf}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	afterConf := NewConfig()
+	afterConf.Type = TypeBloblang
+	afterConf.Bloblang = `root = "%v of %v".format(origin_batch_index(), origin_batch_size())`
+
+	after, err := New(afterConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := ExecuteAll([]types.Processor{proc, after}, message.New([][]byte{
+		[]byte("foo"), []byte("bar"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("0 of 2"), []byte("1 of 2")}, message.GetAllBytes(msgs[0]))
+}
+
+func TestSelectAllDropped(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSelect
+	conf.Select.Parts = []int{0}
+
+	procConf := NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root = deleted()`
+	conf.Select.Processors = []Config{procConf}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	assert.Equal(t, response.NewAck(), res)
+}
+
+func TestSelectBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSelect
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "at least one of parts or check must be specified")
+
+	conf.Select.Check = `this.foo.`
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// threadLocalStateTypes are processor types that hold state within each
+// instance, and therefore behave differently depending on the number of
+// pipeline threads they're executed across.
+var threadLocalStateTypes = map[string]struct{}{
+	TypeBatch:    {},
+	TypeThrottle: {},
+}
+
+// HasThreadLocalState returns true if a processor type holds state within each
+// instance, meaning its behaviour changes with the number of pipeline threads.
+func HasThreadLocalState(typeStr string) bool {
+	_, exists := threadLocalStateTypes[typeStr]
+	return exists
+}
+
+//------------------------------------------------------------------------------
+
+// Shared wraps a processor so that messages are processed by it one at a time
+// regardless of how many components access it in parallel.
+type Shared struct {
+	p     types.Processor
+	sem   chan struct{}
+	inUse int64
+
+	mWait      metrics.StatTimer
+	mContended metrics.StatCounter
+}
+
+// NewShared returns a processor that serialises access to a child processor.
+func NewShared(p types.Processor, stats metrics.Type) *Shared {
+	return &Shared{
+		p:          p,
+		sem:        make(chan struct{}, 1),
+		mWait:      stats.GetTimer("shared.wait"),
+		mContended: stats.GetCounter("shared.contended"),
+	}
+}
+
+// ProcessMessage waits until no other messages are being processed by the
+// child processor and then applies it to the message.
+func (s *Shared) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return s.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext waits until no other messages are being processed
+// by the child processor, or until the context is cancelled, and then applies
+// it to the message with the context.
+func (s *Shared) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	if atomic.AddInt64(&s.inUse, 1) > 1 {
+		s.mContended.Incr(1)
+	}
+	defer atomic.AddInt64(&s.inUse, -1)
+
+	tStarted := time.Now()
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, response.NewError(ctx.Err())
+	}
+	defer func() {
+		<-s.sem
+	}()
+	s.mWait.Timing(time.Since(tStarted).Nanoseconds())
+
+	return ProcessWithContext(ctx, s.p, msg)
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (s *Shared) CloseAsync() {
+	s.p.CloseAsync()
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (s *Shared) WaitForClose(timeout time.Duration) error {
+	return s.p.WaitForClose(timeout)
+}
+
+//------------------------------------------------------------------------------
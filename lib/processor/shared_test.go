@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type concurrencyProc struct {
+	active    int64
+	maxActive int64
+}
+
+func (c *concurrencyProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	n := atomic.AddInt64(&c.active, 1)
+	defer atomic.AddInt64(&c.active, -1)
+	for {
+		prev := atomic.LoadInt64(&c.maxActive)
+		if n <= prev || atomic.CompareAndSwapInt64(&c.maxActive, prev, n) {
+			break
+		}
+	}
+	<-time.After(time.Millisecond)
+	return []types.Message{msg}, nil
+}
+
+func (c *concurrencyProc) CloseAsync() {}
+
+func (c *concurrencyProc) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestSharedSerialisesAccess(t *testing.T) {
+	child := &concurrencyProc{}
+	stats := metrics.NewLocal()
+	s := NewShared(child, stats)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				msgs, res := s.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+				assert.Nil(t, res)
+				assert.Len(t, msgs, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&child.maxActive))
+	assert.Greater(t, stats.GetCounters()["shared.contended"], int64(0))
+	assert.Contains(t, stats.GetTimings(), "shared.wait")
+}
+
+type blockingProc struct {
+	started chan struct{}
+	release chan struct{}
+	ctxs    chan context.Context
+}
+
+func (b *blockingProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return b.ProcessMessageWithContext(context.Background(), msg)
+}
+
+func (b *blockingProc) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	b.ctxs <- ctx
+	close(b.started)
+	<-b.release
+	return []types.Message{msg}, nil
+}
+
+func (b *blockingProc) CloseAsync() {}
+
+func (b *blockingProc) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestSharedContextCancelledWhileWaiting(t *testing.T) {
+	child := &blockingProc{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		ctxs:    make(chan context.Context, 1),
+	}
+	s := NewShared(child, metrics.Noop())
+
+	type ctxKey struct{}
+	firstCtx := context.WithValue(context.Background(), ctxKey{}, "first")
+
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		msgs, res := s.ProcessMessageWithContext(firstCtx, message.New([][]byte{[]byte("foo")}))
+		assert.Nil(t, res)
+		assert.Len(t, msgs, 1)
+	}()
+	<-child.started
+
+	// The context reaches the child processor.
+	assert.Equal(t, "first", (<-child.ctxs).Value(ctxKey{}))
+
+	ctx, done := context.WithCancel(context.Background())
+	secondDone := make(chan struct{})
+	go func() {
+		defer close(secondDone)
+		msgs, res := s.ProcessMessageWithContext(ctx, message.New([][]byte{[]byte("bar")}))
+		assert.Nil(t, msgs)
+		if assert.NotNil(t, res) {
+			assert.Equal(t, context.Canceled, res.Error())
+		}
+	}()
+
+	// The second caller is queued behind the first, and cancelling its
+	// context unblocks it without the child processor being called.
+	<-time.After(time.Millisecond * 10)
+	done()
+	select {
+	case <-secondDone:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for cancelled caller")
+	}
+
+	close(child.release)
+	<-firstDone
+}
+
+func TestThreadLocalStateTypes(t *testing.T) {
+	assert.True(t, HasThreadLocalState(TypeThrottle))
+	assert.False(t, HasThreadLocalState(TypeBloblang))
+}
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -107,6 +109,12 @@ func NewSleep(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *Sleep) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return s.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the sleep is
+// abandoned and the message rejected if the context is cancelled.
+func (s *Sleep) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	s.mCount.Incr(1)
 
 	spans := tracing.CreateChildSpans(TypeSleep, msg)
@@ -124,6 +132,8 @@ func (s *Sleep) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	select {
 	case <-time.After(period):
 	case <-s.closeChan:
+	case <-ctx.Done():
+		return nil, response.NewError(ctx.Err())
 	}
 
 	s.mBatchSent.Incr(1)
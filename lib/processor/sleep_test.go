@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -55,6 +56,28 @@ func TestSleepExit(t *testing.T) {
 	}
 }
 
+func TestSleepCancelled(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSleep
+	conf.Sleep.Duration = "10s"
+
+	slp, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	msgs, res := ProcessWithContext(ctx, slp, message.New(nil))
+	if len(msgs) > 0 {
+		t.Error("received messages after cancellation")
+	}
+	if res == nil || res.Error() != context.DeadlineExceeded {
+		t.Errorf("Wrong response: %v", res)
+	}
+}
+
 func TestSleep200Millisecond(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = TypeSleep
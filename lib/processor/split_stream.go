@@ -0,0 +1,266 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSplitStream] = TypeSpec{
+		constructor: NewSplitStream,
+		Status:      docs.StatusBeta,
+		Version:     "3.50.0",
+		Categories: []Category{
+			CategoryParsing, CategoryUtility,
+		},
+		Summary: `
+Splits the payload of each message into multiple messages by reading it as a
+stream with a [codec](#codec).`,
+		Description: `
+This processor is intended for very large messages, such as a single file
+containing millions of lines or one giant JSON array, that would otherwise
+exhaust memory when processed as a whole. The payload is read incrementally by
+the chosen codec, which creates each resulting message as it is read, and
+therefore the payload is never decoded in its entirety. For example, with
+the ` + "`json_array`" + ` codec the elements of an array are decoded one at a
+time rather than parsing the entire array into a structured document as the
+` + "[`unarchive`](/docs/components/processors/unarchive)" + ` processor does.
+
+Each resulting message inherits the metadata of the message it was split from.
+When ` + "`batch_size`" + ` is greater than zero the resulting messages are
+emitted as multiple batches of at most that size, and each batch flows through
+the remaining processors and to the output separately, which bounds the size of
+the data that subsequent processors work with at a given time. Otherwise all
+messages resulting from a batch are emitted as a single batch.
+
+Messages that fail to be split, for example due to invalid JSON, remain
+unchanged in the resulting batch but are flagged as having failed, allowing you
+to [error handle them](/docs/configuration/error_handling).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"codec", "The codec used to split the payload of messages.", "lines", "json_array", "chunker:1024", "gzip/lines",
+			).HasAnnotatedOptions(
+				"chunker:x", "Split the payload into chunks of a given number of bytes.",
+				"csv", "Split the payload into structured rows as comma separated values, the first row must be a header row.",
+				"delim:x", "Split the payload into segments divided by a custom delimiter.",
+				"gzip", "Decompress a gzip payload, this codec should precede another codec, e.g. `gzip/lines`.",
+				"json_array", "Split a JSON array into a message for each element, decoding one element at a time.",
+				"lines", "Split the payload into segments divided by linebreaks.",
+				"tar", "Split a tar archive into a message for each file.",
+				"zip", "Split a zip archive into a message for each file.",
+			).HasType(docs.FieldTypeString).HasDefault("lines"),
+			docs.FieldAdvanced("max_buffer", "The largest token size expected when splitting delimited payloads.").HasType(docs.FieldTypeInt).HasDefault(1000000),
+			docs.FieldCommon("batch_size", "The maximum number of messages within each resulting batch. Set to `0` in order to emit all messages resulting from a batch as a single batch.").HasType(docs.FieldTypeInt).HasDefault(0),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Giant JSON Arrays",
+				Summary: `
+Here we consume files that each contain a single JSON array of documents, where
+each element of the array is processed as a message in batches of 100:`,
+				Config: `
+input:
+  file:
+    paths: [ ./data/*.json ]
+    codec: all-bytes
+
+pipeline:
+  processors:
+    - split_stream:
+        codec: json_array
+        batch_size: 100
+    - bloblang: |
+        root = this
+        root.processed_at = now()
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SplitStreamConfig contains configuration fields for the SplitStream
+// processor.
+type SplitStreamConfig struct {
+	Codec     string `json:"codec" yaml:"codec"`
+	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+	BatchSize int    `json:"batch_size" yaml:"batch_size"`
+}
+
+// NewSplitStreamConfig returns a SplitStreamConfig with default values.
+func NewSplitStreamConfig() SplitStreamConfig {
+	return SplitStreamConfig{
+		Codec:     "lines",
+		MaxBuffer: 1000000,
+		BatchSize: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SplitStream is a processor that splits the payloads of messages into multiple
+// messages by reading them incrementally with a codec.
+type SplitStream struct {
+	ctor      codec.ReaderConstructor
+	batchSize int
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewSplitStream returns a SplitStream processor.
+func NewSplitStream(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	codecConf := codec.NewReaderConfig()
+	codecConf.MaxScanTokenSize = conf.SplitStream.MaxBuffer
+
+	ctor, err := codec.GetReader(conf.SplitStream.Codec, codecConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create codec: %w", err)
+	}
+	if conf.SplitStream.BatchSize < 0 {
+		return nil, fmt.Errorf("batch_size must not be negative: %v", conf.SplitStream.BatchSize)
+	}
+
+	return &SplitStream{
+		ctor:      ctor,
+		batchSize: conf.SplitStream.BatchSize,
+
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mDropped:   stats.GetCounter("dropped"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// split reads the payload of a part with the codec and returns the resulting
+// parts. Parts are only returned once the payload is fully read so that a
+// payload that fails part way through can be emitted unchanged instead.
+func (s *SplitStream) split(part types.Part) ([]types.Part, error) {
+	ctx := context.Background()
+
+	var readErr error
+	r, err := s.ctor("", ioutil.NopCloser(bytes.NewReader(part.Get())), func(_ context.Context, err error) error {
+		readErr = err
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+
+	var results []types.Part
+	for {
+		parts, ackFn, err := r.Next(ctx)
+		if err == io.EOF {
+			return results, readErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parts {
+			part.Metadata().Iter(func(k, v string) error {
+				if p.Metadata().Get(k) == "" {
+					p.Metadata().Set(k, v)
+				}
+				return nil
+			})
+			results = append(results, p)
+		}
+		_ = ackFn(ctx, nil)
+	}
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (s *SplitStream) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	s.mCount.Incr(1)
+
+	var msgs []types.Message
+	nextMsg := message.New(nil)
+	push := func(p types.Part) {
+		if s.batchSize > 0 && nextMsg.Len() >= s.batchSize {
+			msgs = append(msgs, nextMsg)
+			nextMsg = message.New(nil)
+		}
+		nextMsg.Append(p)
+	}
+
+	msg.Iter(func(i int, part types.Part) error {
+		span := tracing.CreateChildSpan(TypeSplitStream, part)
+		defer span.Finish()
+
+		parts, err := s.split(part)
+		if err != nil {
+			s.mErr.Incr(1)
+			s.log.Errorf("Failed to split message: %v\n", err)
+			failed := part.Copy()
+			FlagErr(failed, err)
+			push(failed)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		}
+		for _, p := range parts {
+			push(p)
+		}
+		return nil
+	})
+
+	if nextMsg.Len() > 0 {
+		msgs = append(msgs, nextMsg)
+	}
+	if len(msgs) == 0 {
+		s.mDropped.Incr(1)
+		return nil, response.NewAck()
+	}
+
+	s.mBatchSent.Incr(int64(len(msgs)))
+	for _, m := range msgs {
+		s.mSent.Incr(int64(m.Len()))
+	}
+	return msgs, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (s *SplitStream) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (s *SplitStream) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStream(t *testing.T) {
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	_, _ = zw.Write([]byte("foo\nbar\nbaz"))
+	require.NoError(t, zw.Close())
+
+	tests := map[string]struct {
+		codec     string
+		batchSize int
+		input     []string
+		output    [][]string
+	}{
+		"lines": {
+			codec:  "lines",
+			input:  []string{"foo\nbar\nbaz", "qux"},
+			output: [][]string{{"foo", "bar", "baz", "qux"}},
+		},
+		"lines batched": {
+			codec:     "lines",
+			batchSize: 2,
+			input:     []string{"foo\nbar\nbaz", "qux\nquz"},
+			output:    [][]string{{"foo", "bar"}, {"baz", "qux"}, {"quz"}},
+		},
+		"json array": {
+			codec:  "json_array",
+			input:  []string{`[{"id":1},{"id":2}]`, `["foo"]`},
+			output: [][]string{{`{"id":1}`, `{"id":2}`, `"foo"`}},
+		},
+		"chunker": {
+			codec:     "chunker:3",
+			batchSize: 3,
+			input:     []string{"foobarbaz"},
+			output:    [][]string{{"foo", "bar", "baz"}},
+		},
+		"gzip lines": {
+			codec:  "gzip/lines",
+			input:  []string{gzipBuf.String()},
+			output: [][]string{{"foo", "bar", "baz"}},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeSplitStream
+			conf.SplitStream.Codec = test.codec
+			conf.SplitStream.BatchSize = test.batchSize
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			input := message.New(nil)
+			for _, s := range test.input {
+				part := message.NewPart([]byte(s))
+				part.Metadata().Set("source", s)
+				input.Append(part)
+			}
+
+			msgs, res := proc.ProcessMessage(input)
+			require.Nil(t, res)
+			require.Len(t, msgs, len(test.output))
+			for i, exp := range test.output {
+				var act []string
+				for _, b := range message.GetAllBytes(msgs[i]) {
+					act = append(act, string(b))
+				}
+				assert.Equal(t, exp, act)
+				msgs[i].Iter(func(_ int, p types.Part) error {
+					assert.NotEmpty(t, p.Metadata().Get("source"))
+					assert.False(t, HasFailed(p))
+					return nil
+				})
+			}
+		})
+	}
+}
+
+func TestSplitStreamErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSplitStream
+	conf.SplitStream.Codec = "json_array"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := message.New([][]byte{
+		[]byte(`["foo","bar"]`),
+		[]byte(`["baz",{"id":`),
+		[]byte(`{"not":"an array"}`),
+	})
+
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 4, msgs[0].Len())
+
+	assert.Equal(t, `"foo"`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, `"bar"`, string(msgs[0].Get(1).Get()))
+	assert.Equal(t, `["baz",{"id":`, string(msgs[0].Get(2).Get()))
+	assert.True(t, HasFailed(msgs[0].Get(2)))
+	assert.Equal(t, `{"not":"an array"}`, string(msgs[0].Get(3).Get()))
+	assert.True(t, HasFailed(msgs[0].Get(3)))
+	assert.False(t, HasFailed(input.Get(2)))
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{[]byte(`[]`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+
+	conf.SplitStream.Codec = "not a codec"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/opentracing/opentracing-go"
 )
@@ -98,7 +99,7 @@ type Subprocess struct {
 
 	conf     SubprocessConfig
 	subproc  *subprocWrapper
-	procFunc func(index int, span opentracing.Span, part types.Part) error
+	procFunc func(ctx context.Context, part types.Part) error
 	mut      sync.Mutex
 
 	mCount     metrics.StatCounter
@@ -138,17 +139,17 @@ func newSubprocess(
 
 //------------------------------------------------------------------------------
 
-func (e *Subprocess) getSendSubprocessorFunc(codec string) (func(index int, span opentracing.Span, part types.Part) error, error) {
+func (e *Subprocess) getSendSubprocessorFunc(codec string) (func(ctx context.Context, part types.Part) error, error) {
 	switch codec {
 	case "length_prefixed_uint32_be":
-		return func(_ int, _ opentracing.Span, part types.Part) error {
+		return func(ctx context.Context, part types.Part) error {
 			const prefixBytes int = 4
 
 			lenBuf := make([]byte, prefixBytes)
 			m := part.Get()
 			binary.BigEndian.PutUint32(lenBuf, uint32(len(m)))
 
-			res, err := e.subproc.Send(lenBuf, m, nil)
+			res, err := e.subproc.Send(ctx, lenBuf, m, nil)
 			if err != nil {
 				e.log.Errorf("Failed to send message to subprocess: %v\n", err)
 				e.mErr.Incr(1)
@@ -160,11 +161,11 @@ func (e *Subprocess) getSendSubprocessorFunc(codec string) (func(index int, span
 			return nil
 		}, nil
 	case "netstring":
-		return func(_ int, _ opentracing.Span, part types.Part) error {
+		return func(ctx context.Context, part types.Part) error {
 			lenBuf := make([]byte, 0)
 			m := part.Get()
 			lenBuf = append(strconv.AppendUint(lenBuf, uint64(len(m)), 10), ':')
-			res, err := e.subproc.Send(lenBuf, m, commaBytes)
+			res, err := e.subproc.Send(ctx, lenBuf, m, commaBytes)
 			if err != nil {
 				e.log.Errorf("Failed to send message to subprocess: %v\n", err)
 				e.mErr.Incr(1)
@@ -176,7 +177,7 @@ func (e *Subprocess) getSendSubprocessorFunc(codec string) (func(index int, span
 			return nil
 		}, nil
 	case "lines":
-		return func(_ int, _ opentracing.Span, part types.Part) error {
+		return func(ctx context.Context, part types.Part) error {
 			results := [][]byte{}
 			splitMsg := bytes.Split(part.Get(), newLineBytes)
 			for j, p := range splitMsg {
@@ -184,7 +185,7 @@ func (e *Subprocess) getSendSubprocessorFunc(codec string) (func(index int, span
 					results = append(results, []byte(""))
 					continue
 				}
-				res, err := e.subproc.Send(nil, p, newLineBytes)
+				res, err := e.subproc.Send(ctx, nil, p, newLineBytes)
 				if err != nil {
 					e.log.Errorf("Failed to send message to subprocess: %v\n", err)
 					e.mErr.Incr(1)
@@ -430,7 +431,15 @@ func (s *subprocWrapper) stop() error {
 	return err
 }
 
-func (s *subprocWrapper) Send(prolog, payload, epilog []byte) ([]byte, error) {
+// Send writes a payload to the subprocess and waits for its response. If the
+// context is cancelled whilst waiting the subprocess is killed, as the response
+// would otherwise be read as the response to the next payload, and is
+// restarted by the monitor loop.
+func (s *subprocWrapper) Send(ctx context.Context, prolog, payload, epilog []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.cmdMut.Lock()
 	stdin := s.cmdStdin
 	outChan := s.stdoutChan
@@ -457,6 +466,13 @@ func (s *subprocWrapper) Send(prolog, payload, epilog []byte) ([]byte, error) {
 	var outBytes, errBytes []byte
 	var open bool
 	select {
+	case <-ctx.Done():
+		s.cmdMut.Lock()
+		if s.cmdStdin == stdin {
+			s.cmdCancelFn()
+		}
+		s.cmdMut.Unlock()
+		return nil, ctx.Err()
 	case outBytes, open = <-outChan:
 	case errBytes, open = <-errChan:
 		tout := time.After(time.Second)
@@ -490,13 +506,25 @@ var commaBytes = []byte(",")
 
 // ProcessMessage logs an event and returns the message unchanged.
 func (e *Subprocess) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return e.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext sends each message to the subprocess, if the
+// context is cancelled whilst waiting for a response the subprocess is
+// restarted and the message rejected.
+func (e *Subprocess) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	e.mCount.Incr(1)
 	e.mut.Lock()
 	defer e.mut.Unlock()
 
 	result := msg.Copy()
 
-	IteratePartsWithSpan(TypeSubprocess, e.conf.Parts, result, e.procFunc)
+	IteratePartsWithSpan(TypeSubprocess, e.conf.Parts, result, func(_ int, _ opentracing.Span, part types.Part) error {
+		return e.procFunc(ctx, part)
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
 
 	e.mSent.Incr(int64(result.Len()))
 	e.mBatchSent.Incr(1)
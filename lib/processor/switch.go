@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -262,6 +263,12 @@ func reorderFromGroup(group *imessage.SortGroup, parts []types.Part) {
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *Switch) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	return s.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to the processors of each case.
+func (s *Switch) ProcessMessageWithContext(ctx context.Context, msg types.Message) (msgs []types.Message, res types.Response) {
 	s.mCount.Incr(1)
 
 	var result []types.Part
@@ -309,7 +316,7 @@ func (s *Switch) ProcessMessage(msg types.Message) (msgs []types.Message, res ty
 			execMsg := message.New(nil)
 			execMsg.SetAll(passed)
 
-			msgs, res := ExecuteAll(switchCase.processors, execMsg)
+			msgs, res := ExecuteAllWithContext(ctx, switchCase.processors, execMsg)
 			if res != nil && res.Error() != nil {
 				return nil, res
 			}
@@ -64,8 +64,11 @@ func NewSyncResponse(
 
 // ProcessMessage logs an event and returns the message unchanged.
 func (s *SyncResponse) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
-	if err := roundtrip.SetAsResponse(msg); err != nil {
+	ignored, err := roundtrip.StoreResponse(msg)
+	if err != nil {
 		s.log.Debugf("Failed to store message as a sync response: %v\n", err)
+	} else if ignored > 0 {
+		s.log.Warnf("Ignoring %v message parts of a sync response batch as they originated from a different request\n", ignored)
 	}
 	return []types.Message{msg}, nil
 }
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -103,6 +104,12 @@ func NewTry(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *Try) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return p.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors.
+func (p *Try) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
 	resultMsgs := make([]types.Message, msg.Len())
@@ -114,7 +121,7 @@ func (p *Try) ProcessMessage(msg types.Message) ([]types.Message, types.Response
 	})
 
 	var res types.Response
-	if resultMsgs, res = ExecuteTryAll(p.children, resultMsgs...); res != nil {
+	if resultMsgs, res = ExecuteTryAllWithContext(ctx, p.children, resultMsgs...); res != nil {
 		return nil, res
 	}
 
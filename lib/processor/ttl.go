@@ -0,0 +1,329 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeTTL] = TypeSpec{
+		constructor: NewTTL,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.50.0",
+		Summary: `
+Drops, flags or fails messages that are older than a maximum age, where the age
+of a message is calculated from an event timestamp obtained with a
+[Bloblang query](/docs/guides/bloblang/about/).`,
+		Description: `
+This is useful for skipping events that are too old to be worth processing, for
+example when recovering from a large backlog. The timestamp query can target a
+field of the message or the metadata added by an input, such as
+` + "`kafka_timestamp_unix`" + ` from the ` + "`kafka`" + ` input,
+` + "`gcp_pubsub_publish_time_unix`" + ` from the ` + "`gcp_pubsub`" + ` input
+or ` + "`sqs_sent_timestamp_unix`" + ` from the ` + "`aws_sqs`" + ` input.
+
+The query should result in either a number of seconds since the unix epoch, or
+a string containing either a number of seconds since the unix epoch or an
+RFC 3339 timestamp. If the query fails the message is flagged as having failed
+and passes through unchanged, where it can be handled using the patterns
+outlined [here](/docs/configuration/error_handling).
+
+### Actions
+
+The field ` + "`action`" + ` determines what happens to expired messages:
+
+- ` + "`drop`" + ` removes the message. Dropped messages are acknowledged so
+  that they are removed from the source.
+- ` + "`flag`" + ` sets the metadata field ` + "`ttl_expired`" + ` to
+  ` + "`true`" + `, which can be used to route expired messages elsewhere.
+- ` + "`fail`" + ` flags the message as having failed, where it can be handled
+  using the patterns outlined [here](/docs/configuration/error_handling).
+
+### Metrics
+
+The counter metric ` + "`expired`" + ` tracks the number of expired messages,
+and the timing metric ` + "`age`" + ` tracks the observed age of all messages.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"timestamp",
+				"A [Bloblang query](/docs/guides/bloblang/about/) that should return the event time of each message.",
+				`meta("kafka_timestamp_unix")`,
+				`this.created_at`,
+			).Linter(docs.LintBloblangMapping),
+			docs.FieldCommon("max_age", "The maximum age of a message before it is considered to have expired.", "1h", "30s"),
+			docs.FieldCommon("action", "The action to take for expired messages.").HasOptions("drop", "flag", "fail"),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Skip Stale Kafka Events",
+				Summary: `
+Here we drop any Kafka messages that were produced more than an hour ago, which
+prevents a consumer recovering from a backlog from spending time on events that
+no longer matter:`,
+				Config: `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ events ]
+    consumer_group: benthos
+
+pipeline:
+  processors:
+    - ttl:
+        timestamp: meta("kafka_timestamp_unix")
+        max_age: 1h
+        action: drop
+`,
+			},
+			{
+				Title: "Route Expired Events",
+				Summary: `
+Here we flag events that are older than five minutes according to a field of
+the message, and route them to a separate output rather than dropping them:`,
+				Config: `
+pipeline:
+  processors:
+    - ttl:
+        timestamp: this.created_at
+        max_age: 5m
+        action: flag
+
+output:
+  switch:
+    cases:
+      - check: meta("ttl_expired") == "true"
+        output:
+          file:
+            path: ./expired.jsonl
+            codec: lines
+      - output:
+          stdout: {}
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TTLConfig contains configuration fields for the TTL processor.
+type TTLConfig struct {
+	Timestamp string `json:"timestamp" yaml:"timestamp"`
+	MaxAge    string `json:"max_age" yaml:"max_age"`
+	Action    string `json:"action" yaml:"action"`
+}
+
+// NewTTLConfig returns a TTLConfig with default values.
+func NewTTLConfig() TTLConfig {
+	return TTLConfig{
+		Timestamp: "",
+		MaxAge:    "1h",
+		Action:    "drop",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TTL is a processor that drops, flags or fails messages that are older than a
+// maximum age.
+type TTL struct {
+	timestamp *mapping.Executor
+	maxAge    time.Duration
+	action    string
+	log       log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mExpired   metrics.StatCounter
+	mAge       metrics.StatTimer
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewTTL returns a TTL processor.
+func NewTTL(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.TTL.Timestamp == "" {
+		return nil, errors.New("a timestamp query must be provided")
+	}
+	timestamp, err := bloblang.NewMapping("", conf.TTL.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp query: %w", err)
+	}
+	maxAge, err := time.ParseDuration(conf.TTL.MaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max_age: %w", err)
+	}
+	if maxAge <= 0 {
+		return nil, errors.New("max_age must be greater than zero")
+	}
+	switch conf.TTL.Action {
+	case "drop", "flag", "fail":
+	default:
+		return nil, fmt.Errorf("action not recognised: %v", conf.TTL.Action)
+	}
+	return &TTL{
+		timestamp: timestamp,
+		maxAge:    maxAge,
+		action:    conf.TTL.Action,
+		log:       log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mExpired:   stats.GetCounter("expired"),
+		mAge:       stats.GetTimer("age"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ttlEventTime converts the result of a timestamp query into a time, which can
+// be a number of seconds since the unix epoch, either as a number or a string,
+// or an RFC 3339 timestamp string.
+func ttlEventTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return ttlUnixTime(f), nil
+		}
+		ts, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		return ts, nil
+	}
+	f, err := query.IGetNumber(v)
+	if err != nil {
+		return time.Time{}, query.NewTypeErrorFrom("timestamp", v, query.ValueNumber, query.ValueString)
+	}
+	return ttlUnixTime(f), nil
+}
+
+func ttlUnixTime(f float64) time.Time {
+	secs := math.Floor(f)
+	return time.Unix(int64(secs), int64((f-secs)*float64(time.Second)))
+}
+
+func (t *TTL) queryEventTime(index int, msg types.Message) (time.Time, error) {
+	v, err := t.timestamp.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    index,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(index).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to execute timestamp query: %w", err)
+	}
+	return ttlEventTime(v)
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (t *TTL) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypeTTL, msg)
+	defer func() {
+		for _, s := range spans {
+			s.Finish()
+		}
+	}()
+
+	now := time.Now()
+	newMsg := message.New(nil)
+	_ = msg.Iter(func(i int, part types.Part) error {
+		eventTime, err := t.queryEventTime(i, msg)
+		if err != nil {
+			t.mErr.Incr(1)
+			t.log.Debugf("Failed to obtain event time: %v\n", err)
+			part = part.Copy()
+			FlagErr(part, err)
+			spans[i].SetTag("error", true)
+			spans[i].LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			newMsg.Append(part)
+			return nil
+		}
+
+		age := now.Sub(eventTime)
+		if age < 0 {
+			age = 0
+		}
+		t.mAge.Timing(int64(age))
+		if age <= t.maxAge {
+			newMsg.Append(part.Copy())
+			return nil
+		}
+
+		t.mExpired.Incr(1)
+		spans[i].LogFields(
+			olog.String("event", "expired"),
+			olog.String("age", age.String()),
+		)
+		switch t.action {
+		case "flag":
+			part = part.Copy()
+			part.Metadata().Set("ttl_expired", "true")
+			newMsg.Append(part)
+		case "fail":
+			part = part.Copy()
+			FlagErr(part, fmt.Errorf("message age %v exceeds max_age %v", age, t.maxAge))
+			newMsg.Append(part)
+		}
+		return nil
+	})
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	t.mBatchSent.Incr(1)
+	t.mSent.Incr(int64(newMsg.Len()))
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (t *TTL) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (t *TTL) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
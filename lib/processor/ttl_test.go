@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ttlTestBatch() [][]byte {
+	now := time.Now()
+	return [][]byte{
+		[]byte(`{"id":"fresh","ts":` + strconv.FormatInt(now.Add(-time.Minute).Unix(), 10) + `}`),
+		[]byte(`{"id":"stale","ts":` + strconv.FormatInt(now.Add(-time.Hour*2).Unix(), 10) + `}`),
+		[]byte(`{"id":"stale_str","ts":"` + now.Add(-time.Hour*3).Format(time.RFC3339) + `"}`),
+		[]byte(`{"id":"future","ts":"` + now.Add(time.Hour).Format(time.RFC3339Nano) + `"}`),
+		[]byte(`{"id":"bad","ts":"nope"}`),
+	}
+}
+
+func TestTTLActions(t *testing.T) {
+	tests := []struct {
+		action      string
+		expectedIDs []string
+		expired     []bool
+		failed      []bool
+	}{
+		{
+			action:      "drop",
+			expectedIDs: []string{"fresh", "future", "bad"},
+			expired:     []bool{false, false, false},
+			failed:      []bool{false, false, true},
+		},
+		{
+			action:      "flag",
+			expectedIDs: []string{"fresh", "stale", "stale_str", "future", "bad"},
+			expired:     []bool{false, true, true, false, false},
+			failed:      []bool{false, false, false, false, true},
+		},
+		{
+			action:      "fail",
+			expectedIDs: []string{"fresh", "stale", "stale_str", "future", "bad"},
+			expired:     []bool{false, false, false, false, false},
+			failed:      []bool{false, true, true, false, true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.action, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeTTL
+			conf.TTL.Timestamp = `this.ts`
+			conf.TTL.MaxAge = "1h"
+			conf.TTL.Action = test.action
+
+			stats := metrics.NewLocal()
+			proc, err := New(conf, nil, log.Noop(), stats)
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New(ttlTestBatch()))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, len(test.expectedIDs), msgs[0].Len())
+
+			for i, id := range test.expectedIDs {
+				part := msgs[0].Get(i)
+				v, err := part.JSON()
+				require.NoError(t, err)
+				assert.Equal(t, id, v.(map[string]interface{})["id"], i)
+				assert.Equal(t, test.expired[i], part.Metadata().Get("ttl_expired") == "true", id)
+				assert.Equal(t, test.failed[i], HasFailed(part), id)
+			}
+
+			assert.Equal(t, int64(2), stats.GetCounters()["expired"])
+		})
+	}
+}
+
+func TestTTLDropAll(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeTTL
+	conf.TTL.Timestamp = `meta("kafka_timestamp_unix")`
+	conf.TTL.MaxAge = "1m"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(0).Metadata().Set("kafka_timestamp_unix", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	msg.Get(1).Metadata().Set("kafka_timestamp_unix", "1")
+
+	msgs, res := proc.ProcessMessage(msg)
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.Equal(t, response.NewAck(), res)
+}
+
+func TestTTLBadConfig(t *testing.T) {
+	for _, mod := range []func(c *TTLConfig){
+		func(c *TTLConfig) { c.Timestamp = "" },
+		func(c *TTLConfig) { c.Timestamp = "this.foo.(" },
+		func(c *TTLConfig) { c.MaxAge = "nope" },
+		func(c *TTLConfig) { c.MaxAge = "0s" },
+		func(c *TTLConfig) { c.Action = "nope" },
+	} {
+		conf := NewConfig()
+		conf.Type = TypeTTL
+		conf.TTL.Timestamp = "this.ts"
+		mod(&conf.TTL)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.Error(t, err)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -55,7 +57,25 @@ Extract messages from a unix standard tape archive.
 
 ### ` + "`zip`" + `
 
-Extract messages from a zip file.
+Extract messages from a zip file. In addition to ` + "`archive_filename`" + `
+the following metadata fields are added to each message:
+
+` + "``` text" + `
+- archive_path
+- archive_mod_time
+- archive_compressed_size
+- archive_uncompressed_size
+` + "```" + `
+
+The field ` + "`archive_path`" + ` is the full path of the file within the
+archive and ` + "`archive_mod_time`" + ` is the modification time of the file
+formatted as RFC 3339. Password protected files are not supported and result
+in an error.
+
+Files are extracted one at a time. The contents of files stored without
+compression reference the original archive rather than being copied, and
+compressed files are decompressed directly into a message of their declared
+size rather than being buffered and copied.
 
 ### ` + "`binary`" + `
 
@@ -146,30 +166,93 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 	return newParts, nil
 }
 
+// The general purpose bit flag of a zip file header indicating that the file
+// is encrypted.
+const zipFlagEncrypted = 0x1
+
+// The maximum compression ratio achievable with deflate, which bounds the
+// uncompressed size that a compressed zip entry can honestly declare.
+const zipMaxDeflateRatio = 1032
+
+// zipEntryBytes returns the contents of a zip entry. Entries stored without
+// compression are referenced directly from the archive rather than copied, and
+// compressed entries are decompressed straight into a buffer of their declared
+// size rather than being accumulated in a growing buffer.
+func zipEntryBytes(archive []byte, f *zip.File) ([]byte, error) {
+	if f.Method == zip.Store {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		end := offset + int64(f.CompressedSize64)
+		if f.CompressedSize64 != f.UncompressedSize64 || end < offset || end > int64(len(archive)) {
+			return nil, zip.ErrFormat
+		}
+		data := archive[offset:end:end]
+		if f.CRC32 != 0 && crc32.ChecksumIEEE(data) != f.CRC32 {
+			return nil, zip.ErrChecksum
+		}
+		return data, nil
+	}
+
+	if f.UncompressedSize64 > (f.CompressedSize64+1)*zipMaxDeflateRatio {
+		return nil, zip.ErrFormat
+	}
+
+	fr, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fr.Close()
+
+	data := make([]byte, f.UncompressedSize64)
+	if _, err = io.ReadFull(fr, data); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = zip.ErrFormat
+		}
+		return nil, err
+	}
+
+	// Reading until EOF verifies the checksum of the entry.
+	if n, err := fr.Read(make([]byte, 1)); n > 0 {
+		return nil, zip.ErrFormat
+	} else if err != io.EOF {
+		if err == nil {
+			err = zip.ErrFormat
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
 func zipUnarchive(part types.Part) ([]types.Part, error) {
-	buf := bytes.NewReader(part.Get())
-	zr, err := zip.NewReader(buf, int64(buf.Len()))
+	archive := part.Get()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
 	if err != nil {
 		return nil, err
 	}
 
-	var newParts []types.Part
+	newParts := make([]types.Part, 0, len(zr.File))
 
-	// Iterate through the files in the archive.
+	// Iterate through the files in the archive, reading each one at a time.
 	for _, f := range zr.File {
-		fr, err := f.Open()
-		if err != nil {
-			return nil, err
+		if f.Flags&zipFlagEncrypted != 0 {
+			return nil, fmt.Errorf("zip file '%v' is password protected, which is not supported", f.Name)
 		}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(fr); err != nil {
-			return nil, err
+		data, err := zipEntryBytes(archive, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip file '%v': %w", f.Name, err)
 		}
 
 		newPart := part.Copy()
-		newPart.Set(newPartBuf.Bytes())
-		newPart.Metadata().Set("archive_filename", f.Name)
+		newPart.Set(data)
+		meta := newPart.Metadata()
+		meta.Set("archive_filename", f.Name)
+		meta.Set("archive_path", f.Name)
+		meta.Set("archive_mod_time", f.Modified.Format(time.RFC3339))
+		meta.Set("archive_compressed_size", strconv.FormatUint(f.CompressedSize64, 10))
+		meta.Set("archive_uncompressed_size", strconv.FormatUint(f.UncompressedSize64, 10))
 		newParts = append(newParts, newPart)
 	}
 
@@ -8,10 +8,13 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnarchiveBadAlgo(t *testing.T) {
@@ -503,3 +506,131 @@ func TestUnarchiveCSV(t *testing.T) {
 		}
 	}
 }
+
+func TestUnarchiveZipMetadata(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "zip"
+
+	modTime := time.Date(2021, 7, 14, 11, 30, 0, 0, time.UTC)
+	content := bytes.Repeat([]byte("hello world "), 100)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, method := range []uint16{zip.Store, zip.Deflate} {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("foo/%v.txt", method),
+			Method:   method,
+			Modified: modTime,
+		})
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{buf.Bytes()}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+
+	for i, exp := range []struct {
+		path           string
+		compressedSize string
+	}{
+		{path: "foo/0.txt", compressedSize: "1200"},
+		{path: "foo/8.txt"},
+	} {
+		part := msgs[0].Get(i)
+		require.False(t, HasFailed(part))
+		assert.Equal(t, content, part.Get())
+
+		meta := part.Metadata()
+		assert.Equal(t, exp.path, meta.Get("archive_filename"))
+		assert.Equal(t, exp.path, meta.Get("archive_path"))
+		assert.Equal(t, "2021-07-14T11:30:00Z", meta.Get("archive_mod_time"))
+		assert.Equal(t, "1200", meta.Get("archive_uncompressed_size"))
+		if exp.compressedSize != "" {
+			assert.Equal(t, exp.compressedSize, meta.Get("archive_compressed_size"))
+		} else {
+			assert.NotEqual(t, "1200", meta.Get("archive_compressed_size"))
+		}
+	}
+}
+
+func TestUnarchiveZipEncrypted(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "zip"
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:   "secret.txt",
+		Method: zip.Store,
+		Flags:  0x1,
+	})
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("not really encrypted"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{buf.Bytes()}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	assert.Equal(t, "zip file 'secret.txt' is password protected, which is not supported", GetFail(msgs[0].Get(0)))
+}
+
+func TestUnarchiveZipCorrupted(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "zip"
+
+	content := []byte("hello world")
+
+	for _, method := range []uint16{zip.Store, zip.Deflate} {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   "foo.txt",
+			Method: method,
+		})
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		archive := buf.Bytes()
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+
+		offset, err := zr.File[0].DataOffset()
+		require.NoError(t, err)
+
+		proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		msgs, res := proc.ProcessMessage(message.New([][]byte{archive}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len())
+		require.False(t, HasFailed(msgs[0].Get(0)))
+		assert.Equal(t, content, msgs[0].Get(0).Get())
+
+		// Flip a bit of the stored or compressed contents of the entry.
+		corrupted := make([]byte, len(archive))
+		copy(corrupted, archive)
+		corrupted[offset+int64(zr.File[0].CompressedSize64)/2] ^= 0x1
+
+		msgs, res = proc.ProcessMessage(message.New([][]byte{corrupted}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len())
+		assert.True(t, HasFailed(msgs[0].Get(0)), method)
+		assert.Contains(t, GetFail(msgs[0].Get(0)), "failed to read zip file 'foo.txt'")
+	}
+}
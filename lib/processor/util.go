@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"context"
+
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -14,31 +16,15 @@ import (
 // N resulting messages or a response. The response may indicate either a NoAck
 // in the event of the message being buffered or an unrecoverable error.
 func ExecuteAll(procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
-	resultMsgs := make([]types.Message, len(msgs))
-	copy(resultMsgs, msgs)
-
-	var resultRes types.Response
-	for i := 0; len(resultMsgs) > 0 && i < len(procs); i++ {
-		var nextResultMsgs []types.Message
-		for _, m := range resultMsgs {
-			var rMsgs []types.Message
-			if rMsgs, resultRes = procs[i].ProcessMessage(m); resultRes != nil && resultRes.Error() != nil {
-				// We immediately return if a processor hits an unrecoverable
-				// error on a message.
-				return nil, resultRes
-			}
-			nextResultMsgs = append(nextResultMsgs, rMsgs...)
-		}
-		resultMsgs = nextResultMsgs
-	}
+	return ExecuteAllWithContext(context.Background(), procs, msgs...)
+}
 
-	if len(resultMsgs) == 0 {
-		if resultRes == nil {
-			resultRes = response.NewUnack()
-		}
-		return nil, resultRes
-	}
-	return resultMsgs, nil
+// ExecuteAllWithContext is the same as ExecuteAll except processors that
+// implement types.ProcessorWithContext are provided a context. If the context
+// is cancelled before all processors have been executed an error response is
+// returned with the error of the context.
+func ExecuteAllWithContext(ctx context.Context, procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
+	return executeAll(ctx, procs, nil, msgs...)
 }
 
 // ExecuteTryAll attempts to execute a slice of processors to messages, if a
@@ -47,36 +33,16 @@ func ExecuteAll(procs []types.Processor, msgs ...types.Message) ([]types.Message
 // response may indicate either a NoAck in the event of the message being
 // buffered or an unrecoverable error.
 func ExecuteTryAll(procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
-	resultMsgs := make([]types.Message, len(msgs))
-	copy(resultMsgs, msgs)
-
-	var resultRes types.Response
-	for i := 0; len(resultMsgs) > 0 && i < len(procs); i++ {
-		var nextResultMsgs []types.Message
-		for _, m := range resultMsgs {
-			// Skip messages that failed a prior stage.
-			if HasFailed(m.Get(0)) {
-				nextResultMsgs = append(nextResultMsgs, m)
-				continue
-			}
-			var rMsgs []types.Message
-			if rMsgs, resultRes = procs[i].ProcessMessage(m); resultRes != nil && resultRes.Error() != nil {
-				// We immediately return if a processor hits an unrecoverable
-				// error on a message.
-				return nil, resultRes
-			}
-			nextResultMsgs = append(nextResultMsgs, rMsgs...)
-		}
-		resultMsgs = nextResultMsgs
-	}
+	return ExecuteTryAllWithContext(context.Background(), procs, msgs...)
+}
 
-	if len(resultMsgs) == 0 {
-		if resultRes == nil {
-			resultRes = response.NewUnack()
-		}
-		return nil, resultRes
-	}
-	return resultMsgs, nil
+// ExecuteTryAllWithContext is the same as ExecuteTryAll except processors that
+// implement types.ProcessorWithContext are provided a context.
+func ExecuteTryAllWithContext(ctx context.Context, procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
+	// Skip messages that failed a prior stage.
+	return executeAll(ctx, procs, func(m types.Message) bool {
+		return HasFailed(m.Get(0))
+	}, msgs...)
 }
 
 // ExecuteCatchAll attempts to execute a slice of processors to only messages
@@ -84,6 +50,19 @@ func ExecuteTryAll(procs []types.Processor, msgs ...types.Message) ([]types.Mess
 // response. The response may indicate either a NoAck in the event of the
 // message being buffered or an unrecoverable error.
 func ExecuteCatchAll(procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
+	return ExecuteCatchAllWithContext(context.Background(), procs, msgs...)
+}
+
+// ExecuteCatchAllWithContext is the same as ExecuteCatchAll except processors
+// that implement types.ProcessorWithContext are provided a context.
+func ExecuteCatchAllWithContext(ctx context.Context, procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
+	// Skip messages that haven't failed a prior stage.
+	return executeAll(ctx, procs, func(m types.Message) bool {
+		return !HasFailed(m.Get(0))
+	}, msgs...)
+}
+
+func executeAll(ctx context.Context, procs []types.Processor, skip func(types.Message) bool, msgs ...types.Message) ([]types.Message, types.Response) {
 	resultMsgs := make([]types.Message, len(msgs))
 	copy(resultMsgs, msgs)
 
@@ -91,13 +70,12 @@ func ExecuteCatchAll(procs []types.Processor, msgs ...types.Message) ([]types.Me
 	for i := 0; len(resultMsgs) > 0 && i < len(procs); i++ {
 		var nextResultMsgs []types.Message
 		for _, m := range resultMsgs {
-			// Skip messages that haven't failed a prior stage.
-			if !HasFailed(m.Get(0)) {
+			if skip != nil && skip(m) {
 				nextResultMsgs = append(nextResultMsgs, m)
 				continue
 			}
 			var rMsgs []types.Message
-			if rMsgs, resultRes = procs[i].ProcessMessage(m); resultRes != nil && resultRes.Error() != nil {
+			if rMsgs, resultRes = ProcessWithContext(ctx, procs[i], m); resultRes != nil && resultRes.Error() != nil {
 				// We immediately return if a processor hits an unrecoverable
 				// error on a message.
 				return nil, resultRes
@@ -116,6 +94,20 @@ func ExecuteCatchAll(procs []types.Processor, msgs ...types.Message) ([]types.Me
 	return resultMsgs, nil
 }
 
+// ProcessWithContext applies a processor to a message, providing the context
+// if the processor implements types.ProcessorWithContext and otherwise calling
+// ProcessMessage. If the context is already cancelled the processor is not
+// called and an error response is returned with the error of the context.
+func ProcessWithContext(ctx context.Context, proc types.Processor, msg types.Message) ([]types.Message, types.Response) {
+	if err := ctx.Err(); err != nil {
+		return nil, response.NewError(err)
+	}
+	if cproc, ok := proc.(types.ProcessorWithContext); ok {
+		return cproc.ProcessMessageWithContext(ctx, msg)
+	}
+	return proc.ProcessMessage(msg)
+}
+
 //------------------------------------------------------------------------------
 
 // FailFlagKey is a metadata key used for flagging processor errors in Benthos.
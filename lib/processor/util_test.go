@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -214,3 +215,26 @@ func TestExecuteAllErrored(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestExecuteAllWithContextCancelled(t *testing.T) {
+	procs := []types.Processor{
+		&passthrough{},
+		&passthrough{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	msgs, res := ExecuteAllWithContext(ctx, procs, message.New([][]byte{[]byte("foo")}))
+	if len(msgs) > 0 {
+		t.Fatal("received messages after cancellation")
+	}
+	if res == nil || res.Error() != context.Canceled {
+		t.Fatalf("Wrong response: %v", res)
+	}
+	if exp, act := 0, procs[0].(*passthrough).called; exp != act {
+		t.Errorf("Wrong call count from processor: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
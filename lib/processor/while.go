@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync/atomic"
@@ -179,6 +180,12 @@ func (w *While) checkMsg(msg types.Message) bool {
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (w *While) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	return w.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies the processor to a message, the context is
+// provided to child processors and looping stops once it is cancelled.
+func (w *While) ProcessMessageWithContext(ctx context.Context, msg types.Message) (msgs []types.Message, res types.Response) {
 	w.mCount.Incr(1)
 
 	spans := tracing.CreateChildSpans(TypeWhile, msg)
@@ -201,7 +208,7 @@ func (w *While) ProcessMessage(msg types.Message) (msgs []types.Message, res typ
 			s.LogFields(opentracinglog.Event("loop"))
 		}
 
-		msgs, res = ExecuteAll(w.children, msgs...)
+		msgs, res = ExecuteAllWithContext(ctx, w.children, msgs...)
 		if len(msgs) == 0 {
 			return
 		}
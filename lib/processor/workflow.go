@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -10,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/gabs/v2"
 )
@@ -512,6 +514,13 @@ func (w *Workflow) skipFromMeta(root interface{}) map[string]struct{} {
 
 // ProcessMessage applies workflow stages to each part of a message type.
 func (w *Workflow) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return w.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext applies workflow stages to each part of a message,
+// the context is provided to the processors of each branch and the message is
+// rejected if it is cancelled.
+func (w *Workflow) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	w.mCount.Incr(1)
 
 	payload := msg.DeepCopy()
@@ -573,7 +582,7 @@ func (w *Workflow) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 				})
 
 				var mapErrs []branchMapError
-				results[index], mapErrs, errors[index] = children[id].createResult(branchParts, propMsg)
+				results[index], mapErrs, errors[index] = children[id].createResult(ctx, branchParts, propMsg)
 				for _, s := range branchSpans {
 					s.Finish()
 				}
@@ -590,6 +599,10 @@ func (w *Workflow) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		}
 		wg.Wait()
 
+		if err := ctx.Err(); err != nil {
+			return nil, response.NewError(err)
+		}
+
 		for i, id := range layer {
 			var failed []branchMapError
 			err := errors[i]
@@ -75,19 +75,21 @@ const (
 
 // Config is the all encompassing configuration struct for all cache types.
 type Config struct {
-	Label  string      `json:"label" yaml:"label"`
-	Type   string      `json:"type" yaml:"type"`
-	Local  LocalConfig `json:"local" yaml:"local"`
-	Plugin interface{} `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Label   string      `json:"label" yaml:"label"`
+	Type    string      `json:"type" yaml:"type"`
+	Enabled bool        `json:"enabled" yaml:"enabled"`
+	Local   LocalConfig `json:"local" yaml:"local"`
+	Plugin  interface{} `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Label:  "",
-		Type:   "local",
-		Local:  NewLocalConfig(),
-		Plugin: nil,
+		Label:   "",
+		Type:    "local",
+		Enabled: true,
+		Local:   NewLocalConfig(),
+		Plugin:  nil,
 	}
 }
 
@@ -131,23 +133,23 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 
 	err := value.Decode(&aliased)
 	if err != nil {
-		return fmt.Errorf("line %v: %v", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	var spec docs.ComponentSpec
 	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeRateLimit, aliased.Type, value); err != nil {
-		return fmt.Errorf("line %v: %w", value.Line, err)
+		return docs.ErrorAtNode(value, err)
 	}
 
 	if spec.Plugin {
 		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
 		if err != nil {
-			return fmt.Errorf("line %v: %v", value.Line, err)
+			return docs.ErrorAtNode(value, err)
 		}
 		if spec, exists := pluginSpecs[aliased.Type]; exists && spec.confConstructor != nil {
 			conf := spec.confConstructor()
 			if err = pluginNode.Decode(conf); err != nil {
-				return fmt.Errorf("line %v: %v", value.Line, err)
+				return docs.ErrorAtNode(value, err)
 			}
 			aliased.Plugin = conf
 		} else {
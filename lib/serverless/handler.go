@@ -34,7 +34,7 @@ func init() {
 			return &s
 		},
 		func(_ interface{}, _ types.Manager, logger log.Modular, stats metrics.Type) (types.Output, error) {
-			return output.NewWriter(ServerlessResponseType, roundtrip.Writer{}, logger, stats)
+			return output.NewWriter(ServerlessResponseType, roundtrip.NewWriter(logger), logger, stats)
 		},
 	)
 	output.DocumentPlugin(ServerlessResponseType, "", func(conf interface{}) interface{} { return nil })
@@ -2,6 +2,7 @@ package blobl
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -64,6 +65,30 @@ func CliCommand() *cli.Command {
 		},
 		Action: run,
 		Subcommands: []*cli.Command{
+			{
+				Name:  "lint",
+				Usage: "Lint Bloblang mapping files for potential problems",
+				Description: `
+   Parses one or more mapping files and reports potential problems that would
+   not prevent the mapping from running, such as unused variables and
+   statements that are unreachable.
+
+   benthos blobl lint ./mapping.blobl
+
+   When a sample input document is provided paths referenced by the mappings
+   that are not present within the document are also reported:
+
+   benthos blobl lint --input ./sample.json ./mapping.blobl`[4:],
+				Action: runLint,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "input",
+						Value:   "",
+						Aliases: []string{"i"},
+						Usage:   "an optional path to a JSON document to check referenced paths against.",
+					},
+				},
+			},
 			{
 				Name:        "server",
 				Usage:       "EXPERIMENTAL: Run a web server that hosts a Bloblang app",
@@ -289,3 +314,64 @@ func run(c *cli.Context) error {
 	os.Exit(0)
 	return nil
 }
+
+func runLint(c *cli.Context) error {
+	var sample interface{}
+	var hasSample bool
+	if inputPath := c.String("input"); len(inputPath) > 0 {
+		inputBytes, err := ioutil.ReadFile(inputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, red("failed to read input file: %v\n"), err)
+			os.Exit(1)
+		}
+		if err = json.Unmarshal(inputBytes, &sample); err != nil {
+			fmt.Fprintf(os.Stderr, red("failed to parse input file as JSON: %v\n"), err)
+			os.Exit(1)
+		}
+		hasSample = true
+	}
+
+	if c.Args().Len() == 0 {
+		fmt.Fprintln(os.Stderr, red("at least one mapping file must be specified"))
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range c.Args().Slice() {
+		mappingBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, red("failed to read mapping file: %v\n"), err)
+			failed = true
+			continue
+		}
+
+		exec, err := bloblang.NewMapping(path, string(mappingBytes))
+		if err != nil {
+			if perr, ok := err.(*parser.Error); ok {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", path, perr.ErrorAtPositionStructured("", []rune(string(mappingBytes))))
+			} else {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", path, err)
+			}
+			failed = true
+			continue
+		}
+
+		var lints []mapping.Lint
+		if hasSample {
+			lints = exec.LintSample(sample)
+		} else {
+			lints = exec.Lint()
+		}
+		for _, l := range lints {
+			fmt.Fprintf(os.Stderr, "%v: line %v: %v\n", path, l.Line, l.What)
+		}
+		if len(lints) > 0 {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -365,7 +365,7 @@ func deprecatedExecute(configPath, testSuffix string) {
 	}
 
 	if depFlags.lintConfig {
-		lints := readConfig(configPath, nil, nil)
+		_, lints := readConfig(configPath, nil, nil)
 		cmdDeprecatedLintConfig(lints)
 	}
 
@@ -405,6 +405,6 @@ func deprecatedExecute(configPath, testSuffix string) {
 		if len(depFlags.streamsDir) > 0 {
 			dirs = append(dirs, depFlags.streamsDir)
 		}
-		os.Exit(cmdService(configPath, nil, nil, "", depFlags.strictConfig, depFlags.streamsMode, dirs))
+		os.Exit(cmdService(configPath, nil, false, nil, "", depFlags.strictConfig, depFlags.streamsMode, dirs, true, traceCaptureOpts{}))
 	}
 }
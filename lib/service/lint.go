@@ -14,7 +14,6 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 )
 
 var red = color.New(color.FgRed).SprintFunc()
@@ -91,7 +90,7 @@ func lintMDSnippets(path string) (pathLints []pathLint) {
 		conf := config.New()
 		configBytes := rawBytes[nextSnippet : endOfSnippet-len(endTag)]
 
-		if err := yaml.Unmarshal(configBytes, &conf); err != nil {
+		if err := config.Unmarshal(configBytes, &conf); err != nil {
 			pathLints = append(pathLints, pathLint{
 				source: path,
 				line:   snippetLine,
@@ -14,7 +14,10 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+// fullSchema is the structure printed by `benthos list --format json-full`,
+// which is consumed by tooling and therefore fields should only ever be added.
 type fullSchema struct {
+	Version           string               `json:"version"`
 	Config            docs.FieldSpecs      `json:"config,omitempty"`
 	Buffers           []docs.ComponentSpec `json:"buffers,omitempty"`
 	Caches            []docs.ComponentSpec `json:"caches,omitempty"`
@@ -24,6 +27,8 @@ type fullSchema struct {
 	RateLimits        []docs.ComponentSpec `json:"rate-limits,omitempty"`
 	Metrics           []docs.ComponentSpec `json:"metrics,omitempty"`
 	Tracers           []docs.ComponentSpec `json:"tracers,omitempty"`
+	BloblangFunctions []query.FunctionSpec `json:"bloblang-functions,omitempty"`
+	BloblangMethods   []query.MethodSpec   `json:"bloblang-methods,omitempty"`
 	conditions        []string
 	bloblangFunctions []string
 	bloblangMethods   []string
@@ -54,6 +59,79 @@ func (f *fullSchema) flattened() map[string][]string {
 	}
 }
 
+// filtered returns a copy of the schema containing only the listed types, or
+// all of them if none are listed.
+func (f fullSchema) filtered(ofTypes map[string]struct{}) fullSchema {
+	if len(ofTypes) == 0 {
+		return f
+	}
+	has := func(k string) bool {
+		_, exists := ofTypes[k]
+		return exists
+	}
+	filtered := fullSchema{Version: f.Version}
+	if has("config") {
+		filtered.Config = f.Config
+	}
+	if has("buffers") {
+		filtered.Buffers = f.Buffers
+	}
+	if has("caches") {
+		filtered.Caches = f.Caches
+	}
+	if has("inputs") {
+		filtered.Inputs = f.Inputs
+	}
+	if has("outputs") {
+		filtered.Outputs = f.Outputs
+	}
+	if has("processors") {
+		filtered.Processors = f.Processors
+	}
+	if has("rate-limits") {
+		filtered.RateLimits = f.RateLimits
+	}
+	if has("metrics") {
+		filtered.Metrics = f.Metrics
+	}
+	if has("tracers") {
+		filtered.Tracers = f.Tracers
+	}
+	if has("bloblang-functions") {
+		filtered.BloblangFunctions = f.BloblangFunctions
+	}
+	if has("bloblang-methods") {
+		filtered.BloblangMethods = f.BloblangMethods
+	}
+	return filtered
+}
+
+func bloblangFunctionDocs() []query.FunctionSpec {
+	var specs []query.FunctionSpec
+	for _, spec := range query.FunctionDocs() {
+		if spec.Status != query.StatusHidden {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+func bloblangMethodDocs() []query.MethodSpec {
+	var specs []query.MethodSpec
+	for _, spec := range query.MethodDocs() {
+		if spec.Status != query.StatusHidden {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
 func listComponents(c *cli.Context) {
 	ofTypes := map[string]struct{}{}
 	for _, k := range c.Args().Slice() {
@@ -61,6 +139,7 @@ func listComponents(c *cli.Context) {
 	}
 
 	schema := fullSchema{
+		Version:           Version,
 		Config:            config.Spec(),
 		Buffers:           bundle.AllBuffers.Docs(),
 		Caches:            bundle.AllCaches.Docs(),
@@ -70,6 +149,8 @@ func listComponents(c *cli.Context) {
 		RateLimits:        bundle.AllRateLimits.Docs(),
 		Metrics:           bundle.AllMetrics.Docs(),
 		Tracers:           bundle.AllTracers.Docs(),
+		BloblangFunctions: bloblangFunctionDocs(),
+		BloblangMethods:   bloblangMethodDocs(),
 		bloblangFunctions: query.ListFunctions(),
 		bloblangMethods:   query.ListMethods(),
 	}
@@ -123,7 +204,7 @@ func listComponents(c *cli.Context) {
 		}
 		fmt.Println(string(jsonBytes))
 	case "json-full":
-		jsonBytes, err := json.Marshal(schema)
+		jsonBytes, err := json.Marshal(schema.filtered(ofTypes))
 		if err != nil {
 			panic(err)
 		}
@@ -7,10 +7,16 @@ import (
 	"runtime/debug"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	clibench "github.com/Jeffail/benthos/v3/internal/cli/bench"
+	clicheck "github.com/Jeffail/benthos/v3/internal/cli/check"
+	clidiff "github.com/Jeffail/benthos/v3/internal/cli/diff"
+	clireplay "github.com/Jeffail/benthos/v3/internal/cli/replay"
+	cliresources "github.com/Jeffail/benthos/v3/internal/cli/resources"
 	clitemplate "github.com/Jeffail/benthos/v3/internal/cli/template"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/filepath"
 	"github.com/Jeffail/benthos/v3/internal/template"
+	"github.com/Jeffail/benthos/v3/internal/tracecapture"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/service/blobl"
 	"github.com/Jeffail/benthos/v3/lib/service/test"
@@ -93,6 +99,24 @@ func cmdVersion() {
 
 //------------------------------------------------------------------------------
 
+type traceCaptureOpts struct {
+	conf tracecapture.Config
+	file string
+}
+
+func traceCaptureFromFlags(c *cli.Context) traceCaptureOpts {
+	conf := tracecapture.NewConfig()
+	conf.Limit = c.Int("trace-capture")
+	conf.Mode = c.String("trace-capture-mode")
+	conf.MaxBytes = c.Int("trace-capture-max-bytes")
+	return traceCaptureOpts{
+		conf: conf,
+		file: c.String("trace-capture-file"),
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // RunWithOpts runs the Benthos service after first applying opt funcs, which
 // are used for specify service customisations.
 func RunWithOpts(opts ...func()) {
@@ -138,7 +162,13 @@ func Run() {
 		&cli.StringSliceFlag{
 			Name:    "resources",
 			Aliases: []string{"r"},
-			Usage:   "pull in extra resources from a file, which can be referenced the same as resources defined in the main config, supports glob patterns (requires quotes)",
+			Usage:   "pull in extra resources from a file, which can be referenced the same as resources defined in the main config, supports glob patterns (requires quotes) and directories",
+		},
+		&cli.BoolFlag{
+			Name:    "watch",
+			Aliases: []string{"w"},
+			Value:   false,
+			Usage:   "EXPERIMENTAL: watch resource files for changes and replace the cache, processor and rate limit resources they define without restarting",
 		},
 		&cli.StringSliceFlag{
 			Name:    "templates",
@@ -150,6 +180,26 @@ func Run() {
 			Value: false,
 			Usage: "continue to execute a config containing linter errors",
 		},
+		&cli.IntFlag{
+			Name:  "trace-capture",
+			Value: 0,
+			Usage: "EXPERIMENTAL: capture snapshots of a number of messages after they are consumed by inputs and after each labelled processor, which are served as JSON at the endpoint /debug/traces",
+		},
+		&cli.StringFlag{
+			Name:  "trace-capture-mode",
+			Value: tracecapture.ModeFirst,
+			Usage: "whether to capture the first messages consumed or to keep the most recently consumed messages, options are: first, last",
+		},
+		&cli.IntFlag{
+			Name:  "trace-capture-max-bytes",
+			Value: tracecapture.NewConfig().MaxBytes,
+			Usage: "the maximum number of bytes of each captured message to store, where larger messages are truncated, set to zero for no limit",
+		},
+		&cli.StringFlag{
+			Name:  "trace-capture-file",
+			Value: "",
+			Usage: "an optional file path to write captured traces to as JSON when the service shuts down",
+		},
 	}
 	if len(customFlags) > 0 {
 		flags = append(flags, customFlags...)
@@ -164,7 +214,8 @@ func Run() {
    benthos list inputs
    benthos create kafka//file > ./config.yaml
    benthos -c ./config.yaml
-   benthos -r "./production/*.yaml" -c ./config.yaml`[4:],
+   benthos -r "./production/*.yaml" -c ./config.yaml
+   benthos -r ./resources/ --watch -c ./config.yaml`[4:],
 		Flags: flags,
 		Before: func(c *cli.Context) error {
 			if dotEnvFile := c.String("env-file"); dotEnvFile != "" {
@@ -212,11 +263,14 @@ func Run() {
 			os.Exit(cmdService(
 				c.String("config"),
 				c.StringSlice("resources"),
+				c.Bool("watch"),
 				c.StringSlice("set"),
 				c.String("log.level"),
 				!c.Bool("chilled"),
 				false,
 				nil,
+				true,
+				traceCaptureFromFlags(c),
 			))
 			return nil
 		},
@@ -229,16 +283,33 @@ func Run() {
    behaving as expected, as it shows you a normalised version after environment
    variables have been resolved:
 
-   benthos -c ./config.yaml echo | less`[4:],
+   benthos -c ./config.yaml echo | less
+
+   When the flag --expand-templates is set any template components within the
+   config are replaced with the configs that they expand into:
+
+   benthos -t "./templates/*.yaml" -c ./config.yaml echo --expand-templates`[4:],
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "expand-templates",
+						Value: false,
+						Usage: "Replace template components with the configs they expand into.",
+					},
+				},
 				Action: func(c *cli.Context) error {
 					readConfig(c.String("config"), c.StringSlice("resources"), c.StringSlice("set"))
 
+					sanitConf := docs.SanitiseConfig{
+						RemoveTypeField: true,
+					}
+					if c.Bool("expand-templates") {
+						sanitConf.ExpandComponent = template.ExpandYAML
+					}
+
 					var node yaml.Node
 					err := node.Encode(conf)
 					if err == nil {
-						err = config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
-							RemoveTypeField: true,
-						})
+						err = config.Spec().SanitiseYAML(&node, sanitConf)
 					}
 					if err == nil {
 						var configYAML []byte
@@ -254,6 +325,9 @@ func Run() {
 				},
 			},
 			lintCliCommand(),
+			clidiff.CliCommand(testSuffix),
+			clibench.CliCommand(),
+			clicheck.CliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -265,6 +339,7 @@ func Run() {
    benthos streams ./path/to/stream/configs ./and/some/more
    benthos -c ./root_config.yaml streams ./path/to/stream/configs
    benthos -c ./root_config.yaml streams
+   benthos streams --no-api ./path/to/stream/configs
 
    In streams mode the stream fields of a root target config (input, buffer,
    pipeline, output) will be ignored. Other fields will be shared across all
@@ -272,15 +347,25 @@ func Run() {
 
    For more information check out the docs at:
    https://benthos.dev/docs/guides/streams_mode/about`[4:],
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "no-api",
+						Value: false,
+						Usage: "Disable the HTTP API for creating, updating and removing streams. Service wide endpoints such as /ping, /metrics and the debug endpoints remain available.",
+					},
+				},
 				Action: func(c *cli.Context) error {
 					os.Exit(cmdService(
 						c.String("config"),
 						c.StringSlice("resources"),
+						c.Bool("watch"),
 						c.StringSlice("set"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
 						true,
 						c.Args().Slice(),
+						!c.Bool("no-api"),
+						traceCaptureFromFlags(c),
 					))
 					return nil
 				},
@@ -292,14 +377,19 @@ func Run() {
    If any component types are explicitly listed then only types of those
    components will be shown.
 
+   The format json-full prints the documentation of each component, including
+   its status and the type, default value and examples of each field, as well
+   as the documentation of Bloblang functions and methods.
+
    benthos list
    benthos list --format json inputs output
+   benthos list --format json-full bloblang-functions
    benthos list rate-limits buffers`[4:],
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "format",
 						Value: "text",
-						Usage: "Print the component list in a specific format. Options are text or json.",
+						Usage: "Print the component list in a specific format. Options are text, json or json-full.",
 					},
 				},
 				Action: func(c *cli.Context) error {
@@ -311,6 +401,8 @@ func Run() {
 			createCliCommand(),
 			test.CliCommand(testSuffix),
 			clitemplate.CliCommand(),
+			cliresources.CliCommand(),
+			clireplay.CliCommand(),
 			blobl.CliCommand(),
 		},
 	}
@@ -340,7 +432,7 @@ func Run() {
 		}
 
 		deprecatedExecute(*configPath, testSuffix)
-		os.Exit(cmdService(*configPath, nil, nil, "", false, false, nil))
+		os.Exit(cmdService(*configPath, nil, false, nil, "", false, false, nil, true, traceCaptureOpts{}))
 		return nil
 	}
 
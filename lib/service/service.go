@@ -12,9 +12,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/budget"
 	iconfig "github.com/Jeffail/benthos/v3/internal/config"
 	"github.com/Jeffail/benthos/v3/internal/docs"
-	"github.com/Jeffail/benthos/v3/internal/filepath"
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/internal/runtimestats"
+	"github.com/Jeffail/benthos/v3/internal/tracecapture"
 	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -32,6 +35,14 @@ import (
 var conf = config.New()
 var testSuffix = "_benthos_test"
 
+// resourceWatchPeriod is the period between each check of resource files for
+// changes when they're being watched.
+const resourceWatchPeriod = time.Second
+
+// runtimeStatsPeriod is the period between each sample of the Go runtime that
+// is emitted as metrics.
+const runtimeStatsPeriod = time.Second * 5
+
 // OptSetServiceName creates an opt func that allows the default service name
 // config fields such as metrics and logging prefixes to be overridden.
 func OptSetServiceName(name string) func() {
@@ -94,7 +105,7 @@ func OptOnManagerInit(fn ManagerInitFunc) func() {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, resourcesPaths, overrides []string) (lints []string) {
+func readConfig(path string, resourcesPaths, overrides []string) (rdr *iconfig.Reader, lints []string) {
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -111,7 +122,8 @@ func readConfig(path string, resourcesPaths, overrides []string) (lints []string
 	}
 
 	var err error
-	if lints, err = iconfig.NewReader(path, resourcesPaths, iconfig.OptAddOverrides(overrides...)).Read(&conf); err != nil {
+	rdr = iconfig.NewReader(path, resourcesPaths, iconfig.OptAddOverrides(overrides...))
+	if lints, err = rdr.Read(&conf); err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 		os.Exit(1)
 	}
@@ -123,18 +135,17 @@ func readConfig(path string, resourcesPaths, overrides []string) (lints []string
 func cmdService(
 	confPath string,
 	resourcesPaths []string,
+	watchResources bool,
 	confOverrides []string,
 	overrideLogLevel string,
 	strict bool,
 	streamsMode bool,
 	streamsConfigs []string,
+	streamsAPI bool,
+	captureOpts traceCaptureOpts,
 ) int {
 	var err error
-	if resourcesPaths, err = filepath.Globs(resourcesPaths); err != nil {
-		fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
-		return 1
-	}
-	lints := readConfig(confPath, resourcesPaths, confOverrides)
+	confReader, lints := readConfig(confPath, resourcesPaths, confOverrides)
 	if strict && len(lints) > 0 {
 		for _, lint := range lints {
 			fmt.Fprintln(os.Stderr, lint)
@@ -183,6 +194,10 @@ func cmdService(
 		}
 	}()
 
+	// Emit metrics describing the Go runtime.
+	runtimeStats := runtimestats.New(stats, runtimeStatsPeriod)
+	defer runtimeStats.Close()
+
 	// Create our tracer type.
 	var trac tracer.Type
 	if trac, err = tracer.New(conf.Tracer); err != nil {
@@ -208,17 +223,86 @@ func cmdService(
 		return 1
 	}
 
+	// Create an optional redactor of message contents.
+	var mgrOpts []manager.OptFunc
+	var redactor *redact.Redactor
+	if len(conf.Redaction) > 0 {
+		if redactor, err = redact.New(conf.Redaction); err != nil {
+			logger.Errorf("Failed to initialise redaction rules: %v\n", err)
+			return 1
+		}
+		mgrOpts = append(mgrOpts, manager.OptSetRedactor(redactor))
+	}
+
+	// Create an optional budget of message bytes held in flight.
+	if conf.ResourceLimits.MaxMemoryBytes > 0 {
+		mgrOpts = append(mgrOpts, manager.OptSetMemoryBudget(budget.NewMemory(
+			conf.ResourceLimits.MaxMemoryBytes, stats.GetGauge("resource_limits.memory_used_bytes"),
+		)))
+	}
+
+	// Create an optional capture of message traces.
+	if captureOpts.conf.Limit > 0 {
+		capture, err := tracecapture.New(captureOpts.conf)
+		if err != nil {
+			logger.Errorf("Failed to initialise trace capture: %v\n", err)
+			return 1
+		}
+		capture = capture.WithRedactor(redactor)
+		httpServer.RegisterEndpoint(
+			"/debug/traces", "DEBUG: Returns captured message traces as JSON.",
+			capture.Handler,
+		)
+		if captureOpts.file != "" {
+			defer func() {
+				if err := capture.WriteFile(captureOpts.file); err != nil {
+					logger.Errorf("Failed to write captured traces: %v\n", err)
+				}
+			}()
+		}
+		mgrOpts = append(mgrOpts, manager.OptSetTraceCapture(capture))
+	}
+
 	// Create resource manager.
-	manager, err := manager.NewV2(conf.ResourceConfig, httpServer, logger, stats)
+	manager, err := manager.NewV2(conf.ResourceConfig, httpServer, logger, stats, mgrOpts...)
 	if err != nil {
 		logger.Errorf("Failed to create resource: %v\n", err)
 		return 1
 	}
+	httpServer.RegisterEndpointSpec(api.NewEndpointSpec(
+		"/resources/caches/{name}/export",
+		"Returns the contents of a cache resource as newline delimited JSON documents.",
+		api.EndpointOperation{
+			Method:               "GET",
+			Summary:              "Export the keys, values and remaining TTLs of a cache resource.",
+			ResponseContentTypes: []string{"application/x-ndjson"},
+		},
+	), manager.HandleCacheExport)
+	if conf.HTTP.DebugEndpoints {
+		httpServer.RegisterEndpoint(
+			"/debug/inproc", "DEBUG: Returns the active inproc pipes and the number of connected consumers as JSON.",
+			manager.HandlePipes,
+		)
+	}
 	if err = onManagerInit(manager, logger, stats); err != nil {
 		logger.Errorf("Failed to initialise manager: %v\n", err)
 		return 1
 	}
 
+	stopWatchingResources := func() {}
+	if watchResources {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		watchDone := make(chan struct{})
+		go func() {
+			defer close(watchDone)
+			confReader.WatchResources(watchCtx, resourceWatchPeriod, manager, logger.NewModule(".resources"))
+		}()
+		stopWatchingResources = func() {
+			cancel()
+			<-watchDone
+		}
+	}
+
 	var dataStream stoppableStreams
 	dataStreamClosedChan := make(chan struct{})
 
@@ -226,9 +310,11 @@ func cmdService(
 	if streamsMode {
 		streamMgr := strmmgr.New(
 			strmmgr.OptSetAPITimeout(time.Second*5),
+			strmmgr.OptSetDefaultQuota(conf.Quota),
 			strmmgr.OptSetLogger(logger),
 			strmmgr.OptSetManager(manager),
 			strmmgr.OptSetStats(stats),
+			strmmgr.OptSetAPIEnabled(streamsAPI),
 		)
 		streamConfs := map[string]stream.Config{}
 		var streamLints []string
@@ -303,6 +389,8 @@ func cmdService(
 
 	// Defer clean up.
 	defer func() {
+		stopWatchingResources()
+
 		go func() {
 			httpServer.Shutdown(context.Background())
 			select {
@@ -0,0 +1,31 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// budgetStage accounts for the approximate size of the transactions produced
+// by an input against a memory budget until they are acknowledged. Whilst the
+// budget is exhausted transactions are not forwarded, which results in back
+// pressure on the input.
+type budgetStage struct {
+	budget *budget.Memory
+}
+
+func newBudgetStage(mem *budget.Memory) *budgetStage {
+	return &budgetStage{budget: mem}
+}
+
+func (b *budgetStage) process(ctx context.Context, tran types.Transaction) (types.Transaction, func(), error) {
+	if err := b.budget.Wait(ctx); err != nil {
+		return tran, nil, err
+	}
+	return tran, b.budget.Add(budget.MessageSize(tran.Payload)), nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetInputBackPressure(t *testing.T) {
+	mem := budget.NewMemory(budget.PartOverhead+1, metrics.Noop().GetGauge("used"))
+
+	mock := &mockQuotaInput{ts: make(chan types.Transaction)}
+	b := newStagedInput(mock, newBudgetStage(mem))
+
+	send := func(content string) <-chan types.Response {
+		resChan := make(chan types.Response, 1)
+		go func() {
+			mock.ts <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan)
+		}()
+		return resChan
+	}
+	read := func() types.Transaction {
+		select {
+		case tran, open := <-b.TransactionChan():
+			require.True(t, open)
+			return tran
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return types.Transaction{}
+	}
+
+	resChanA := send("foo")
+	tranA := read()
+	assert.Equal(t, "foo", string(tranA.Payload.Get(0).Get()))
+	assert.Equal(t, int64(budget.PartOverhead+3), mem.Used())
+
+	// The budget is exhausted and so the next message is not consumed until
+	// the first is acknowledged.
+	resChanB := send("bar")
+	select {
+	case <-b.TransactionChan():
+		t.Fatal("received message whilst budget is exhausted")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	tranA.ResponseChan <- response.NewAck()
+	select {
+	case res := <-resChanA:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	tranB := read()
+	assert.Equal(t, "bar", string(tranB.Payload.Get(0).Get()))
+	tranB.ResponseChan <- response.NewAck()
+	<-resChanB
+
+	require.Eventually(t, func() bool {
+		return mem.Used() == 0
+	}, time.Second*5, time.Millisecond*10)
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
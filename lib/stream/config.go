@@ -14,19 +14,26 @@ import (
 // Config is a configuration struct representing all four layers of a Benthos
 // stream.
 type Config struct {
-	Input    input.Config    `json:"input" yaml:"input"`
-	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
-	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
-	Output   output.Config   `json:"output" yaml:"output"`
+	Input      input.Config    `json:"input" yaml:"input"`
+	Buffer     buffer.Config   `json:"buffer" yaml:"buffer"`
+	Pipeline   pipeline.Config `json:"pipeline" yaml:"pipeline"`
+	Output     output.Config   `json:"output" yaml:"output"`
+	DeadLetter *output.Config  `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty"`
+	Quota      QuotaConfig     `json:"quota" yaml:"quota"`
+	Lifecycle  LifecycleConfig `json:"lifecycle" yaml:"lifecycle"`
+	Watchdog   WatchdogConfig  `json:"watchdog" yaml:"watchdog"`
 }
 
 // NewConfig returns a new configuration with default values.
 func NewConfig() Config {
 	return Config{
-		Input:    input.NewConfig(),
-		Buffer:   buffer.NewConfig(),
-		Pipeline: pipeline.NewConfig(),
-		Output:   output.NewConfig(),
+		Input:     input.NewConfig(),
+		Buffer:    buffer.NewConfig(),
+		Pipeline:  pipeline.NewConfig(),
+		Output:    output.NewConfig(),
+		Quota:     NewQuotaConfig(),
+		Lifecycle: NewLifecycleConfig(),
+		Watchdog:  NewWatchdogConfig(),
 	}
 }
 
@@ -0,0 +1,33 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/Jeffail/benthos/v3/internal/correlation"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// correlationStage stamps each message produced by an input with a
+// correlation ID.
+type correlationStage struct {
+	key string
+	log log.Modular
+}
+
+func newCorrelationStage(key string, log log.Modular) *correlationStage {
+	return &correlationStage{key: key, log: log}
+}
+
+func (c *correlationStage) process(ctx context.Context, tran types.Transaction) (types.Transaction, func(), error) {
+	if msg, err := correlation.Stamp(c.key, tran.Payload); err != nil {
+		c.log.Errorf("Failed to generate correlation ID: %v\n", err)
+	} else {
+		tran = types.NewTransaction(msg, tran.ResponseChan)
+	}
+	return tran, nil, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/correlation"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationInputStamps(t *testing.T) {
+	mock := &mockQuotaInput{ts: make(chan types.Transaction)}
+	c := newStagedInput(mock, newCorrelationStage("cid", log.Noop()))
+
+	resChan := make(chan types.Response, 1)
+	go func() {
+		mock.ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+
+	var tran types.Transaction
+	select {
+	case tran = <-c.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	key, id := correlation.Get(tran.Payload.Get(0))
+	assert.Equal(t, "cid", key)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, tran.Payload.Get(0).Metadata().Get("cid"))
+
+	// Responses are passed directly to the underlying input.
+	tran.ResponseChan <- response.NewAck()
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	c.CloseAsync()
+	require.NoError(t, c.WaitForClose(time.Second*5))
+}
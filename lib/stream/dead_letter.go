@@ -0,0 +1,177 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/metadata"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// deadLetterOutput wraps the output layer of a stream and routes any messages
+// that the output rejects to a dead letter output. When the dead letter output
+// acknowledges a message the original transaction is acknowledged, otherwise
+// the original rejection is propagated upstream.
+type deadLetterOutput struct {
+	output.Type
+
+	deadLetter output.Type
+
+	log      log.Modular
+	mCount   metrics.StatCounter
+	mSuccess metrics.StatCounter
+	mFailed  metrics.StatCounter
+
+	transactions   chan types.Transaction
+	dlTransactions chan types.Transaction
+
+	pending sync.WaitGroup
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newDeadLetterOutput(out, deadLetter output.Type, log log.Modular, stats metrics.Type) *deadLetterOutput {
+	return &deadLetterOutput{
+		Type:           out,
+		deadLetter:     deadLetter,
+		log:            log,
+		mCount:         stats.GetCounter("route.count"),
+		mSuccess:       stats.GetCounter("route.success"),
+		mFailed:        stats.GetCounter("route.failed"),
+		transactions:   make(chan types.Transaction),
+		dlTransactions: make(chan types.Transaction),
+		closeChan:      make(chan struct{}),
+		closedChan:     make(chan struct{}),
+	}
+}
+
+// Consume begins feeding transactions into both the wrapped output and the
+// dead letter output.
+func (d *deadLetterOutput) Consume(ts <-chan types.Transaction) error {
+	if err := d.Type.Consume(d.transactions); err != nil {
+		return err
+	}
+	if err := d.deadLetter.Consume(d.dlTransactions); err != nil {
+		return err
+	}
+	go d.loop(ts)
+	return nil
+}
+
+func (d *deadLetterOutput) loop(ts <-chan types.Transaction) {
+	defer func() {
+		close(d.transactions)
+		d.pending.Wait()
+		close(d.dlTransactions)
+		close(d.closedChan)
+	}()
+
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-ts:
+			if !open {
+				return
+			}
+		case <-d.closeChan:
+			return
+		}
+
+		resChan := make(chan types.Response)
+		select {
+		case d.transactions <- types.NewTransaction(tran.Payload, resChan):
+		case <-d.closeChan:
+			return
+		}
+
+		d.pending.Add(1)
+		go func(tran types.Transaction) {
+			defer d.pending.Done()
+
+			var res types.Response
+			select {
+			case res = <-resChan:
+			case <-d.closeChan:
+				return
+			}
+			if res.Error() != nil {
+				res = d.route(tran.Payload, res)
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-d.closeChan:
+			}
+		}(tran)
+	}
+}
+
+// route attempts to write a rejected message to the dead letter output and
+// returns the response that should be given to the origin of the message.
+func (d *deadLetterOutput) route(msg types.Message, res types.Response) types.Response {
+	d.mCount.Incr(1)
+
+	dlMsg := msg.Copy()
+	dlMsg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("dead_letter_error", res.Error().Error())
+		metadata.SetHTTPError(p, res.Error())
+		return nil
+	})
+
+	dlResChan := make(chan types.Response)
+	select {
+	case d.dlTransactions <- types.NewTransaction(dlMsg, dlResChan):
+	case <-d.closeChan:
+		d.mFailed.Incr(1)
+		return res
+	}
+
+	var dlRes types.Response
+	select {
+	case dlRes = <-dlResChan:
+	case <-d.closeChan:
+		d.mFailed.Incr(1)
+		return res
+	}
+	if err := dlRes.Error(); err != nil {
+		d.mFailed.Incr(1)
+		d.log.Errorf("Failed to write rejected message to dead letter output: %v\n", err)
+		return res
+	}
+	d.mSuccess.Incr(1)
+	return response.NewAck()
+}
+
+// CloseAsync shuts down both the wrapped output and the dead letter output.
+func (d *deadLetterOutput) CloseAsync() {
+	d.Type.CloseAsync()
+	d.closeOnce.Do(func() {
+		close(d.closeChan)
+	})
+	d.deadLetter.CloseAsync()
+}
+
+// WaitForClose blocks until both the wrapped output and the dead letter output
+// have closed down.
+func (d *deadLetterOutput) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	if err := d.Type.WaitForClose(timeout); err != nil {
+		return err
+	}
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout - time.Since(started)):
+		return types.ErrTimeout
+	}
+	return d.deadLetter.WaitForClose(timeout - time.Since(started))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,169 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type respondingOutput struct {
+	respond func(tran types.Transaction) types.Response
+	done    chan struct{}
+}
+
+func (r *respondingOutput) Consume(ts <-chan types.Transaction) error {
+	go func() {
+		defer close(r.done)
+		for tran := range ts {
+			tran.ResponseChan <- r.respond(tran)
+		}
+	}()
+	return nil
+}
+
+func (r *respondingOutput) Connected() bool {
+	return true
+}
+
+func (r *respondingOutput) CloseAsync() {}
+
+func (r *respondingOutput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-r.done:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+func newRespondingOutput(fn func(tran types.Transaction) types.Response) *respondingOutput {
+	return &respondingOutput{respond: fn, done: make(chan struct{})}
+}
+
+func TestDeadLetterOutputRoutes(t *testing.T) {
+	out := newRespondingOutput(func(tran types.Transaction) types.Response {
+		if string(tran.Payload.Get(0).Get()) == "bad" {
+			return response.NewError(errors.New("nope"))
+		}
+		return response.NewAck()
+	})
+
+	var dlContents, dlErrors []string
+	dl := newRespondingOutput(func(tran types.Transaction) types.Response {
+		dlContents = append(dlContents, string(tran.Payload.Get(0).Get()))
+		dlErrors = append(dlErrors, tran.Payload.Get(0).Metadata().Get("dead_letter_error"))
+		return response.NewAck()
+	})
+
+	d := newDeadLetterOutput(out, dl, log.Noop(), metrics.Noop())
+
+	ts := make(chan types.Transaction)
+	require.NoError(t, d.Consume(ts))
+
+	for _, content := range []string{"good", "bad"} {
+		resChan := make(chan types.Response)
+		select {
+		case ts <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			assert.NoError(t, res.Error())
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(ts)
+	require.NoError(t, d.WaitForClose(time.Second))
+
+	assert.Equal(t, []string{"bad"}, dlContents)
+	assert.Equal(t, []string{"nope"}, dlErrors)
+}
+
+func TestDeadLetterOutputHTTPError(t *testing.T) {
+	out := newRespondingOutput(func(tran types.Transaction) types.Response {
+		return response.NewError(types.ErrUnexpectedHTTPRes{
+			Code: 400,
+			S:    "400 Bad Request",
+			Body: []byte("missing field foo"),
+		})
+	})
+
+	dlMeta := make(chan map[string]string, 1)
+	dl := newRespondingOutput(func(tran types.Transaction) types.Response {
+		meta := map[string]string{}
+		tran.Payload.Get(0).Metadata().Iter(func(k, v string) error {
+			meta[k] = v
+			return nil
+		})
+		dlMeta <- meta
+		return response.NewAck()
+	})
+
+	d := newDeadLetterOutput(out, dl, log.Noop(), metrics.Noop())
+
+	ts := make(chan types.Transaction)
+	require.NoError(t, d.Consume(ts))
+
+	resChan := make(chan types.Response)
+	select {
+	case ts <- types.NewTransaction(message.New([][]byte{[]byte("bad")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	close(ts)
+	require.NoError(t, d.WaitForClose(time.Second))
+
+	assert.Equal(t, map[string]string{
+		"dead_letter_error": "HTTP request returned unexpected response code (400): 400 Bad Request: missing field foo",
+		"http_status":       "400",
+		"http_error_body":   "missing field foo",
+	}, <-dlMeta)
+}
+
+func TestDeadLetterOutputFails(t *testing.T) {
+	out := newRespondingOutput(func(tran types.Transaction) types.Response {
+		return response.NewError(errors.New("nope"))
+	})
+	dl := newRespondingOutput(func(tran types.Transaction) types.Response {
+		return response.NewError(errors.New("also nope"))
+	})
+
+	d := newDeadLetterOutput(out, dl, log.Noop(), metrics.Noop())
+
+	ts := make(chan types.Transaction)
+	require.NoError(t, d.Consume(ts))
+
+	resChan := make(chan types.Response)
+	select {
+	case ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-resChan:
+		assert.EqualError(t, res.Error(), "nope")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	close(ts)
+	require.NoError(t, d.WaitForClose(time.Second))
+}
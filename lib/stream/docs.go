@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/correlation"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 )
 
@@ -12,7 +13,28 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("pipeline", "Describes optional processing pipelines used for mutating messages.").WithChildren(
 			docs.FieldInt("threads", "The number of threads to execute processing pipelines across.").HasDefault(1),
 			docs.FieldCommon("processors", "A list of processors to apply to messages.").Array().HasType(docs.FieldTypeProcessor),
+			docs.FieldAdvanced("correlation_id", "Optionally stamp each message with a generated [ULID](https://github.com/ulid/spec) as it is read by the input, stored as a metadata key. Correlation IDs are added automatically to the output of the [`log` processor](/docs/components/processors/log) and to errors logged by outputs, and can be propagated downstream by outputs that write metadata, such as headers.").WithChildren(correlation.Spec()...).AtVersion("3.50.0"),
 		),
 		docs.FieldCommon("output", "An output to sink messages to.").HasType(docs.FieldTypeOutput),
+		docs.FieldAdvanced("dead_letter", "An optional output to route messages to when they are rejected by the output, either because retries were exhausted or due to an error that cannot be retried. Messages written to the dead letter output have the metadata field `dead_letter_error` set to the reason for the rejection, and once written the original message is acknowledged at the input. If the dead letter output also fails then the original rejection is propagated to the input.").HasType(docs.FieldTypeOutput).Optional().AtVersion("3.50.0"),
+		docs.FieldAdvanced("quota", "Optional limits on the rate at which messages are consumed from the input of the stream. When a limit is reached the input experiences back pressure until the quota is replenished, and messages are never dropped. In streams mode the quota of the main config is used for streams that do not specify their own.").WithChildren(
+			docs.FieldInt("messages_per_second", "The maximum number of messages to consume per second, or zero for no limit.").HasDefault(0),
+			docs.FieldInt("bytes_per_second", "The maximum number of message bytes to consume per second, or zero for no limit.").HasDefault(0),
+		).AtVersion("3.50.0"),
+		docs.FieldAdvanced("lifecycle", "Optional conditions under which a stream is removed automatically in streams mode, which has the same effect as deleting it via the streams API: the input is closed and messages in flight are drained before the stream is removed. These fields have no effect outside of streams mode.").WithChildren(
+			docs.FieldString("ttl", "A period after which the stream is removed, measured from when it was created. Leave empty to disable.", "1h", "30m").HasDefault(""),
+			docs.FieldString("idle_timeout", "A period after which the stream is removed when no messages have been consumed by its input and none are in flight. Leave empty to disable.", "10m").HasDefault(""),
+			docs.FieldString("webhook_url", "An optional URL to send a POST request to when the stream is removed automatically, where the body is a JSON object describing the final status of the stream.", "http://localhost:8080/stream_removed").HasDefault(""),
+		).AtVersion("3.50.0"),
+		docs.FieldAdvanced("watchdog", "Optional detection of an input or output that reports being connected but has stopped making progress. When a component stalls a warning is logged and the metric `watchdog.stalled` is incremented, after which the component can be closed and re-established a number of times. Once restarts are exhausted the stream is reported as not ready until the component makes progress again. Each component is configured separately, as some inputs are legitimately idle for long periods.").WithChildren(
+			docs.FieldCommon("input", "Stall detection for the input, which is considered stalled when it has not produced a message for the stall timeout.").WithChildren(
+				docs.FieldString("stall_timeout", "A period after which the connected input is considered stalled when no messages have been read. Leave empty to disable.", "5m").HasDefault(""),
+				docs.FieldInt("max_restarts", "The maximum number of consecutive times to close and re-establish a stalled input before the stream is reported as not ready.").HasDefault(0),
+			),
+			docs.FieldCommon("output", "Stall detection for the output, which is considered stalled when messages are pending and none have been acknowledged for the stall timeout. An output with no pending messages is never considered stalled. Messages pending within an output when it is restarted are rejected so that they can be retried.").WithChildren(
+				docs.FieldString("stall_timeout", "A period after which the connected output is considered stalled when no pending messages have been written. Leave empty to disable.", "5m").HasDefault(""),
+				docs.FieldInt("max_restarts", "The maximum number of consecutive times to close and re-establish a stalled output before the stream is reported as not ready.").HasDefault(0),
+			),
+		).AtVersion("3.50.0"),
 	}
 }
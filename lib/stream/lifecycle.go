@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// LifecycleConfig contains configuration fields for automatically removing a
+// stream in streams mode.
+type LifecycleConfig struct {
+	TTL         string `json:"ttl" yaml:"ttl"`
+	IdleTimeout string `json:"idle_timeout" yaml:"idle_timeout"`
+	WebhookURL  string `json:"webhook_url" yaml:"webhook_url"`
+}
+
+// NewLifecycleConfig returns a LifecycleConfig with default values.
+func NewLifecycleConfig() LifecycleConfig {
+	return LifecycleConfig{
+		TTL:         "",
+		IdleTimeout: "",
+		WebhookURL:  "",
+	}
+}
+
+// Durations parses the TTL and idle timeout of the config, where a duration of
+// zero indicates that it is disabled.
+func (l LifecycleConfig) Durations() (ttl, idleTimeout time.Duration, err error) {
+	if l.TTL != "" {
+		if ttl, err = time.ParseDuration(l.TTL); err != nil {
+			return 0, 0, fmt.Errorf("failed to parse ttl: %v", err)
+		}
+	}
+	if l.IdleTimeout != "" {
+		if idleTimeout, err = time.ParseDuration(l.IdleTimeout); err != nil {
+			return 0, 0, fmt.Errorf("failed to parse idle_timeout: %v", err)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// activityStage records the time at which an input last produced a
+// transaction.
+type activityStage struct {
+	lastActivity int64
+}
+
+func newActivityStage() *activityStage {
+	return &activityStage{lastActivity: time.Now().UnixNano()}
+}
+
+func (a *activityStage) process(ctx context.Context, tran types.Transaction) (types.Transaction, func(), error) {
+	atomic.StoreInt64(&a.lastActivity, time.Now().UnixNano())
+	return tran, nil, nil
+}
+
+// LastActivity returns the time at which the input last produced a
+// transaction, or the time at which the stage was created if it has not yet
+// produced any.
+func (a *activityStage) LastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&a.lastActivity))
+}
+
+//------------------------------------------------------------------------------
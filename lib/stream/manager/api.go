@@ -13,7 +13,10 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bundle"
+	inputInternal "github.com/Jeffail/benthos/v3/internal/component/input"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/input"
@@ -31,35 +34,80 @@ import (
 //------------------------------------------------------------------------------
 
 func (m *Type) registerEndpoints() {
-	m.manager.RegisterEndpoint(
+	configTypes := []string{"application/json", "application/yaml"}
+	jsonTypes := []string{"application/json"}
+
+	interop.RegisterEndpointSpec(m.manager, api.NewEndpointSpec(
+		"/ready",
+		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned.",
+	), m.HandleStreamReady)
+	if !m.apiEnabled {
+		return
+	}
+
+	interop.RegisterEndpointSpec(m.manager, api.NewEndpointSpec(
 		"/streams",
 		"GET: List all streams along with their status and uptimes."+
 			" POST: Post an object of stream ids to stream configs, all"+
 			" streams will be replaced by this new set.",
-		m.HandleStreamsCRUD,
-	)
-	m.manager.RegisterEndpoint(
+		api.EndpointOperation{
+			Method:               "GET",
+			Summary:              "List all streams along with their status and uptimes.",
+			ResponseContentTypes: jsonTypes,
+		},
+		api.EndpointOperation{
+			Method:              "POST",
+			Summary:             "Replace all streams with an object of stream ids to stream configs.",
+			RequestContentTypes: configTypes,
+		},
+	), m.HandleStreamsCRUD)
+	interop.RegisterEndpointSpec(m.manager, api.NewEndpointSpec(
 		"/streams/{id}",
 		"Perform CRUD operations on streams, supporting POST (Create),"+
 			" GET (Read), PUT (Update), PATCH (Patch update)"+
 			" and DELETE (Delete).",
-		m.HandleStreamCRUD,
-	)
-	m.manager.RegisterEndpoint(
+		api.EndpointOperation{
+			Method:              "POST",
+			Summary:             "Create a stream.",
+			RequestContentTypes: configTypes,
+		},
+		api.EndpointOperation{
+			Method:               "GET",
+			Summary:              "Read a stream config along with its status and uptime.",
+			ResponseContentTypes: jsonTypes,
+		},
+		api.EndpointOperation{
+			Method:              "PUT",
+			Summary:             "Update a stream.",
+			RequestContentTypes: configTypes,
+		},
+		api.EndpointOperation{
+			Method:              "PATCH",
+			Summary:             "Patch the config of a stream.",
+			RequestContentTypes: configTypes,
+		},
+		api.EndpointOperation{
+			Method:  "DELETE",
+			Summary: "Delete a stream.",
+		},
+	), m.HandleStreamCRUD)
+	interop.RegisterEndpointSpec(m.manager, api.NewEndpointSpec(
 		"/streams/{id}/stats",
 		"GET a structured JSON object containing metrics for the stream.",
-		m.HandleStreamStats,
-	)
-	m.manager.RegisterEndpoint(
+		api.EndpointOperation{
+			Method:               "GET",
+			ResponseContentTypes: jsonTypes,
+		},
+	), m.HandleStreamStats)
+	interop.RegisterEndpointSpec(m.manager, api.NewEndpointSpec(
 		"/resources/{type}/{id}",
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
-		m.HandleResourceCRUD,
-	)
-	m.manager.RegisterEndpoint(
-		"/ready",
-		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned.",
-		m.HandleStreamReady,
-	)
+		api.EndpointOperation{
+			Method:              "POST",
+			Summary:             "Create or replace a resource configuration.",
+			RequestContentTypes: configTypes,
+		},
+	), m.HandleResourceCRUD)
 }
 
 // ConfigSet is a map of stream configurations mapped by ID, which can be YAML
@@ -111,6 +159,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 
 	type confInfo struct {
 		Active    bool    `json:"active"`
+		Throttled bool    `json:"throttled"`
 		Uptime    float64 `json:"uptime"`
 		UptimeStr string  `json:"uptime_str"`
 	}
@@ -120,6 +169,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, strInfo := range m.streams {
 		infos[id] = confInfo{
 			Active:    strInfo.IsRunning(),
+			Throttled: strInfo.IsThrottled(),
 			Uptime:    strInfo.Uptime().Seconds(),
 			UptimeStr: strInfo.Uptime().String(),
 		}
@@ -366,15 +416,19 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 
 			var bodyBytes []byte
 			if bodyBytes, serverErr = json.Marshal(struct {
-				Active    bool        `json:"active"`
-				Uptime    float64     `json:"uptime"`
-				UptimeStr string      `json:"uptime_str"`
-				Config    interface{} `json:"config"`
+				Active        bool        `json:"active"`
+				Throttled     bool        `json:"throttled"`
+				Uptime        float64     `json:"uptime"`
+				UptimeStr     string      `json:"uptime_str"`
+				RemovalReason string      `json:"removal_reason,omitempty"`
+				Config        interface{} `json:"config"`
 			}{
-				Active:    info.IsRunning(),
-				Uptime:    info.Uptime().Seconds(),
-				UptimeStr: info.Uptime().String(),
-				Config:    sanit,
+				Active:        info.IsRunning(),
+				Throttled:     info.IsThrottled(),
+				Uptime:        info.Uptime().Seconds(),
+				UptimeStr:     info.Uptime().String(),
+				RemovalReason: info.RemovalReason(),
+				Config:        sanit,
 			}); serverErr != nil {
 				return
 			}
@@ -601,23 +655,43 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
 // all streams.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
-	var notReady []string
+	var notReady, paused, inFlight []string
+	var totalInFlight inputInternal.InFlightStats
+	verbose := r.URL.Query().Get("verbose") == "true"
 
 	m.lock.Lock()
 	for k, v := range m.streams {
+		if verbose {
+			stats := v.InFlight()
+			totalInFlight = totalInFlight.Merge(stats)
+			inFlight = append(inFlight, fmt.Sprintf("stream '%v' in flight: %v, oldest age: %v", k, stats.Count, stats.OldestAge().Round(time.Millisecond)))
+		}
 		if !v.IsReady() {
 			notReady = append(notReady, k)
 		}
+		for _, label := range v.PausedInputs() {
+			paused = append(paused, fmt.Sprintf("input '%v' of stream '%v' paused", label, k))
+		}
 	}
 	m.lock.Unlock()
+	sort.Strings(paused)
+	sort.Strings(inFlight)
 
 	if len(notReady) == 0 {
 		w.Write([]byte("OK"))
-		return
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(fmt.Sprintf("streams %v are not connected\n", strings.Join(notReady, ", "))))
+	}
+	for _, p := range paused {
+		fmt.Fprintf(w, "\n%v", p)
+	}
+	if verbose {
+		for _, f := range inFlight {
+			fmt.Fprintf(w, "\n%v", f)
+		}
+		fmt.Fprintf(w, "\ntotal in flight: %v, oldest age: %v", totalInFlight.Count, totalInFlight.OldestAge().Round(time.Millisecond))
 	}
-
-	w.WriteHeader(http.StatusServiceUnavailable)
-	w.Write([]byte(fmt.Sprintf("streams %v are not connected\n", strings.Join(notReady, ", "))))
 }
 
 //------------------------------------------------------------------------------
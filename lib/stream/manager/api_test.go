@@ -802,6 +802,92 @@ func TestTypeAPIGetStats(t *testing.T) {
 	assert.Equal(t, 1.0, stats.S("input", "running").Data(), response.Body.String())
 }
 
+type routerAPIReg struct {
+	*mux.Router
+}
+
+func (r routerAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	r.HandleFunc(path, h)
+}
+
+func TestTypeAPIPauseInput(t *testing.T) {
+	r := mux.NewRouter()
+
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), routerAPIReg{r}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(
+		manager.OptSetLogger(log.Noop()),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetManager(mgr),
+		manager.OptSetAPITimeout(time.Millisecond*100),
+	)
+	r.HandleFunc("/ready", smgr.HandleStreamReady)
+
+	conf := harmlessConf()
+	conf.Input.Label = "bar"
+	require.NoError(t, smgr.Create("foo", conf))
+
+	readyBody := func(path string) string {
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, genRequest("GET", path, nil))
+		return response.Body.String()
+	}
+
+	assert.NotContains(t, readyBody("/ready"), "paused")
+	assert.NotContains(t, readyBody("/foo/ready"), "paused")
+
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, genRequest("POST", "/foo/inputs/bar/pause", nil))
+	assert.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.Contains(t, readyBody("/ready"), "input 'bar' of stream 'foo' paused")
+	assert.Contains(t, readyBody("/foo/ready"), "input 'bar' paused")
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, genRequest("POST", "/foo/inputs/bar/resume", nil))
+	assert.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.NotContains(t, readyBody("/ready"), "paused")
+
+	require.NoError(t, smgr.Stop(time.Second*5))
+}
+
+func TestTypeAPIReadyVerbose(t *testing.T) {
+	r := mux.NewRouter()
+
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), routerAPIReg{r}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(
+		manager.OptSetLogger(log.Noop()),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetManager(mgr),
+		manager.OptSetAPITimeout(time.Millisecond*100),
+	)
+	r.HandleFunc("/ready", smgr.HandleStreamReady)
+
+	require.NoError(t, smgr.Create("foo", harmlessConf()))
+	require.NoError(t, smgr.Create("bar", harmlessConf()))
+
+	readyBody := func(path string) string {
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, genRequest("GET", path, nil))
+		return response.Body.String()
+	}
+
+	assert.NotContains(t, readyBody("/ready"), "in flight")
+
+	body := readyBody("/ready?verbose=true")
+	assert.Contains(t, body, "stream 'bar' in flight: 0, oldest age: 0s\nstream 'foo' in flight: 0, oldest age: 0s")
+	assert.Contains(t, body, "total in flight: 0, oldest age: 0s")
+
+	assert.NotContains(t, readyBody("/foo/ready"), "in flight")
+	assert.Contains(t, readyBody("/foo/ready?verbose=true"), "input in flight: 0, oldest age: 0s")
+
+	require.NoError(t, smgr.Stop(time.Second*5))
+}
+
 func TestTypeAPISetResources(t *testing.T) {
 	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
 	require.NoError(t, err)
@@ -898,3 +984,28 @@ func TestTypeAPISetResources(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPIDisabled(t *testing.T) {
+	r := mux.NewRouter()
+
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), routerAPIReg{r}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(
+		manager.OptSetLogger(log.Noop()),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetManager(mgr),
+		manager.OptSetAPIEnabled(false),
+	)
+	require.NoError(t, smgr.Create("foo", harmlessConf()))
+
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, genRequest("GET", "/streams", nil))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, genRequest("GET", "/ready", nil))
+	assert.NotEqual(t, http.StatusNotFound, response.Code)
+
+	require.NoError(t, smgr.Stop(time.Second*5))
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Reasons for which a stream is removed automatically.
+const (
+	RemovalReasonTTL         = "ttl"
+	RemovalReasonIdleTimeout = "idle_timeout"
+)
+
+// lifecycleRetryPeriod is the period to wait before attempting to remove a
+// stream again after a failed attempt.
+const lifecycleRetryPeriod = time.Second * 5
+
+// watchLifecycle removes a stream once its TTL has passed or once it has been
+// idle for longer than its idle timeout, and exits early if the stream is
+// removed by other means.
+func (m *Type) watchLifecycle(id string, wrapper *StreamStatus, ttl, idleTimeout time.Duration) {
+	var ttlChan <-chan time.Time
+	if ttl > 0 {
+		ttlTimer := time.NewTimer(ttl)
+		defer ttlTimer.Stop()
+		ttlChan = ttlTimer.C
+	}
+
+	var idleChan <-chan time.Time
+	if idleTimeout > 0 {
+		checkPeriod := idleTimeout / 4
+		if checkPeriod <= 0 {
+			checkPeriod = idleTimeout
+		}
+		idleTicker := time.NewTicker(checkPeriod)
+		defer idleTicker.Stop()
+		idleChan = idleTicker.C
+	}
+
+	for {
+		var reason, description string
+		select {
+		case <-ttlChan:
+			reason, description = RemovalReasonTTL, fmt.Sprintf("ttl of %v has passed", ttl)
+		case <-idleChan:
+			if !wrapper.IsIdle(idleTimeout) {
+				continue
+			}
+			reason, description = RemovalReasonIdleTimeout, fmt.Sprintf("no messages have flowed for %v", idleTimeout)
+		case <-wrapper.removedChan:
+			return
+		}
+
+		wrapper.logger.Infof("Removing stream automatically as the %v\n", description)
+		wrapper.setRemovalReason(reason)
+
+		err := m.delete(id, wrapper, m.apiTimeout)
+		if err == nil {
+			m.notifyRemoval(id, wrapper)
+			return
+		}
+		if err == ErrStreamDoesNotExist {
+			return
+		}
+
+		// Idle streams are checked again on the next tick, whereas the TTL
+		// needs to be rearmed.
+		wrapper.logger.Errorf("Failed to remove stream automatically: %v\n", err)
+		if reason == RemovalReasonTTL {
+			ttlChan = time.After(lifecycleRetryPeriod)
+		}
+	}
+}
+
+// notifyRemoval sends the final status of an automatically removed stream to
+// the webhook URL of the stream, if one is configured.
+func (m *Type) notifyRemoval(id string, wrapper *StreamStatus) {
+	url := wrapper.Config().Lifecycle.WebhookURL
+	if url == "" {
+		return
+	}
+
+	body, err := json.Marshal(struct {
+		ID        string  `json:"id"`
+		Reason    string  `json:"removal_reason"`
+		Uptime    float64 `json:"uptime"`
+		UptimeStr string  `json:"uptime_str"`
+		RemovedAt string  `json:"removed_at"`
+	}{
+		ID:        id,
+		Reason:    wrapper.RemovalReason(),
+		Uptime:    wrapper.Uptime().Seconds(),
+		UptimeStr: wrapper.Uptime().String(),
+		RemovedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		wrapper.logger.Errorf("Failed to encode stream removal event: %v\n", err)
+		return
+	}
+
+	client := http.Client{Timeout: m.apiTimeout}
+	res, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		wrapper.logger.Errorf("Failed to send stream removal event: %v\n", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		wrapper.logger.Errorf("Failed to send stream removal event: unexpected status code %v\n", res.StatusCode)
+	}
+}
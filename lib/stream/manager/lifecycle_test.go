@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForRemoval(t *testing.T, mgr *Type, id string) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		_, err := mgr.Read(id)
+		return err == ErrStreamDoesNotExist
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestLifecycleTTL(t *testing.T) {
+	events := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &event))
+		events <- event
+	}))
+	defer server.Close()
+
+	mgr := New()
+	defer func() {
+		assert.NoError(t, mgr.Stop(time.Second))
+	}()
+
+	conf := harmlessConf()
+	conf.Lifecycle.TTL = "50ms"
+	conf.Lifecycle.WebhookURL = server.URL
+	require.NoError(t, mgr.Create("foo", conf))
+
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+
+	waitForRemoval(t, mgr, "foo")
+	assert.Equal(t, RemovalReasonTTL, info.RemovalReason())
+	assert.Eventually(t, func() bool {
+		return !info.IsRunning()
+	}, time.Second*5, time.Millisecond*10)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "foo", event["id"])
+		assert.Equal(t, RemovalReasonTTL, event["removal_reason"])
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for webhook")
+	}
+}
+
+func TestLifecycleIdleTimeout(t *testing.T) {
+	mgr := New()
+	defer func() {
+		assert.NoError(t, mgr.Stop(time.Second))
+	}()
+
+	idleConf := harmlessConf()
+	idleConf.Lifecycle.IdleTimeout = "100ms"
+	require.NoError(t, mgr.Create("idle", idleConf))
+
+	activeConf := stream.NewConfig()
+	activeConf.Input.Type = "generate"
+	activeConf.Input.Generate.Mapping = `root = "hello world"`
+	activeConf.Input.Generate.Interval = "10ms"
+	activeConf.Output.Type = "drop"
+	activeConf.Lifecycle.IdleTimeout = "100ms"
+	require.NoError(t, mgr.Create("active", activeConf))
+
+	info, err := mgr.Read("idle")
+	require.NoError(t, err)
+
+	waitForRemoval(t, mgr, "idle")
+	assert.Equal(t, RemovalReasonIdleTimeout, info.RemovalReason())
+
+	<-time.After(time.Millisecond * 200)
+	_, err = mgr.Read("active")
+	assert.NoError(t, err)
+}
+
+func TestLifecycleBadDurations(t *testing.T) {
+	mgr := New()
+	defer func() {
+		assert.NoError(t, mgr.Stop(time.Second))
+	}()
+
+	conf := harmlessConf()
+	conf.Lifecycle.TTL = "nope"
+	assert.Error(t, mgr.Create("foo", conf))
+
+	conf = harmlessConf()
+	conf.Lifecycle.IdleTimeout = "nope"
+	assert.Error(t, mgr.Create("foo", conf))
+}
+
+func TestLifecycleManualDelete(t *testing.T) {
+	mgr := New()
+	defer func() {
+		assert.NoError(t, mgr.Stop(time.Second))
+	}()
+
+	conf := harmlessConf()
+	conf.Lifecycle.TTL = "50ms"
+	require.NoError(t, mgr.Create("foo", conf))
+
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+	require.NoError(t, mgr.Delete("foo", time.Second))
+
+	// A stream created under the same ID is not removed by the watcher of the
+	// deleted stream.
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+	<-time.After(time.Millisecond * 150)
+
+	_, err = mgr.Read("foo")
+	assert.NoError(t, err)
+	assert.Empty(t, info.RemovalReason())
+}
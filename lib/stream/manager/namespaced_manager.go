@@ -1,10 +1,15 @@
 package manager
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"path"
 
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/redact"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -24,6 +29,13 @@ func (n *NamespacedManager) RegisterEndpoint(p, desc string, h http.HandlerFunc)
 	n.mgr.RegisterEndpoint(path.Join(n.ns, p), desc, h)
 }
 
+// RegisterEndpointSpec registers a server wide HTTP endpoint along with a spec
+// describing its operations.
+func (n *NamespacedManager) RegisterEndpointSpec(spec api.EndpointSpec, h http.HandlerFunc) {
+	spec.Path = path.Join(n.ns, spec.Path)
+	interop.RegisterEndpointSpec(n.mgr, spec, h)
+}
+
 // GetOutput attempts to find a service wide output by its name.
 func (n *NamespacedManager) GetOutput(name string) (types.OutputWriter, error) {
 	// TODO: V4 Simplify this.
@@ -46,6 +58,12 @@ func (n *NamespacedManager) GetInput(name string) (types.Input, error) {
 	return nil, errors.New("wrapped manager does not support input resources")
 }
 
+// SubscribeInput subscribes to every message consumed by a service wide input
+// by its name.
+func (n *NamespacedManager) SubscribeInput(name string) (<-chan types.Transaction, func(), error) {
+	return interop.SubscribeInput(context.Background(), n.mgr, name)
+}
+
 // GetCache attempts to find a service wide cache by its name.
 func (n *NamespacedManager) GetCache(name string) (types.Cache, error) {
 	return n.mgr.GetCache(name)
@@ -77,6 +95,17 @@ func (n *NamespacedManager) GetPlugin(name string) (interface{}, error) {
 	return n.mgr.GetPlugin(name)
 }
 
+// Redactor returns the redactor of message contents of the wrapped manager.
+func (n *NamespacedManager) Redactor() *redact.Redactor {
+	return interop.GetRedactor(n.mgr)
+}
+
+// MemoryBudget returns the budget of message bytes held in flight of the
+// wrapped manager.
+func (n *NamespacedManager) MemoryBudget() *budget.Memory {
+	return interop.GetMemoryBudget(n.mgr)
+}
+
 // GetPipe returns a named pipe transaction channel.
 func (n *NamespacedManager) GetPipe(name string) (<-chan types.Transaction, error) {
 	// Pipes are always absolute.
@@ -95,6 +124,27 @@ func (n *NamespacedManager) UnsetPipe(name string, t <-chan types.Transaction) {
 	n.mgr.UnsetPipe(name, t)
 }
 
+// PipeConsumerConnected records that a consumer has begun reading from a named
+// pipe.
+func (n *NamespacedManager) PipeConsumerConnected(name string) {
+	if t, ok := n.mgr.(pipeConsumerTracker); ok {
+		t.PipeConsumerConnected(name)
+	}
+}
+
+// PipeConsumerDisconnected records that a consumer has stopped reading from a
+// named pipe.
+func (n *NamespacedManager) PipeConsumerDisconnected(name string) {
+	if t, ok := n.mgr.(pipeConsumerTracker); ok {
+		t.PipeConsumerDisconnected(name)
+	}
+}
+
+type pipeConsumerTracker interface {
+	PipeConsumerConnected(name string)
+	PipeConsumerDisconnected(name string)
+}
+
 // GetUnderlying returns the underlying types.Manager implementation.
 func (n *NamespacedManager) GetUnderlying() types.Manager {
 	return n.mgr
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/input"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
@@ -26,6 +27,10 @@ type StreamStatus struct {
 	logger       log.Modular
 	metrics      *metrics.Local
 	createdAt    time.Time
+
+	removalReason atomic.Value
+	removedOnce   sync.Once
+	removedChan   chan struct{}
 }
 
 // NewStreamStatus creates a new StreamStatus.
@@ -36,11 +41,12 @@ func NewStreamStatus(
 	stats *metrics.Local,
 ) *StreamStatus {
 	return &StreamStatus{
-		config:    conf,
-		strm:      strm,
-		logger:    logger,
-		metrics:   stats,
-		createdAt: time.Now(),
+		config:      conf,
+		strm:        strm,
+		logger:      logger,
+		metrics:     stats,
+		createdAt:   time.Now(),
+		removedChan: make(chan struct{}),
 	}
 }
 
@@ -56,6 +62,24 @@ func (s *StreamStatus) IsReady() bool {
 	return s.strm.IsReady()
 }
 
+// IsThrottled returns a boolean indicating whether the input of the stream is
+// currently experiencing back pressure due to the stream quota.
+func (s *StreamStatus) IsThrottled() bool {
+	return s.strm.IsThrottled()
+}
+
+// PausedInputs returns the labels of the inputs of the stream that have been
+// paused via the HTTP API.
+func (s *StreamStatus) PausedInputs() []string {
+	return s.strm.PausedInputs()
+}
+
+// InFlight returns the stats of messages that have been read by the input of
+// the stream and are yet to be acknowledged.
+func (s *StreamStatus) InFlight() input.InFlightStats {
+	return s.strm.InFlight()
+}
+
 // Uptime returns a time.Duration indicating the current uptime of the stream.
 func (s *StreamStatus) Uptime() time.Duration {
 	if stoppedAfter := atomic.LoadInt64(&s.stoppedAfter); stoppedAfter > 0 {
@@ -79,6 +103,35 @@ func (s *StreamStatus) Logger() log.Modular {
 	return s.logger
 }
 
+// IsIdle returns true if the input of the stream has not produced a message
+// within a period and no messages are in flight. Only streams with an idle
+// timeout track their activity, and other streams are never considered idle.
+func (s *StreamStatus) IsIdle(period time.Duration) bool {
+	lastActivity := s.strm.LastActivity()
+	if lastActivity.IsZero() || time.Since(lastActivity) < period {
+		return false
+	}
+	return s.strm.InFlight().Count == 0
+}
+
+// RemovalReason returns the reason that the stream was removed automatically,
+// or an empty string if it has not been.
+func (s *StreamStatus) RemovalReason() string {
+	reason, _ := s.removalReason.Load().(string)
+	return reason
+}
+
+func (s *StreamStatus) setRemovalReason(reason string) {
+	s.removalReason.Store(reason)
+}
+
+// setRemoved signals that the stream has been removed from its manager.
+func (s *StreamStatus) setRemoved() {
+	s.removedOnce.Do(func() {
+		close(s.removedChan)
+	})
+}
+
 // setClosed sets the flag indicating that the stream is closed.
 func (s *StreamStatus) setClosed() {
 	atomic.SwapInt64(&s.stoppedAfter, int64(time.Since(s.createdAt)))
@@ -102,6 +155,9 @@ type Type struct {
 	stats      metrics.Type
 	logger     log.Modular
 	apiTimeout time.Duration
+	apiEnabled bool
+
+	defaultQuota stream.QuotaConfig
 
 	pipelineProcCtors []StreamProcConstructorFunc
 
@@ -115,6 +171,7 @@ func New(opts ...func(*Type)) *Type {
 		manager:    types.DudMgr{},
 		stats:      metrics.Noop(),
 		apiTimeout: time.Second * 5,
+		apiEnabled: true,
 		logger:     log.Noop(),
 	}
 	for _, opt := range opts {
@@ -158,6 +215,23 @@ func OptSetAPITimeout(tout time.Duration) func(*Type) {
 	}
 }
 
+// OptSetAPIEnabled sets whether the HTTP API for creating, reading, updating
+// and deleting streams and resources is registered with the service manager,
+// which is enabled by default. The /ready endpoint is registered regardless.
+func OptSetAPIEnabled(enabled bool) func(*Type) {
+	return func(t *Type) {
+		t.apiEnabled = enabled
+	}
+}
+
+// OptSetDefaultQuota sets a quota to be applied to all streams that do not
+// specify their own.
+func OptSetDefaultQuota(quota stream.QuotaConfig) func(*Type) {
+	return func(t *Type) {
+		t.defaultQuota = quota
+	}
+}
+
 // OptAddProcessors adds processor constructors that will be called for every
 // new stream and attached to the processor pipelines. The constructor is given
 // the name of the stream as an argument.
@@ -211,9 +285,19 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	sStats = metrics.Combine(sStats, strmFlatMetrics)
 	sMgr = manager.SwapMetrics(sMgr, sStats)
 
+	strmConf := conf
+	if strmConf.Quota.IsNoop() {
+		strmConf.Quota = m.defaultQuota
+	}
+
+	ttl, idleTimeout, err := conf.Lifecycle.Durations()
+	if err != nil {
+		return err
+	}
+
 	var wrapper *StreamStatus
 	strm, err := stream.New(
-		conf,
+		strmConf,
 		stream.OptAddProcessors(procCtors...),
 		stream.OptSetLogger(sLog),
 		stream.OptSetStats(sStats),
@@ -228,6 +312,9 @@ func (m *Type) Create(id string, conf stream.Config) error {
 
 	wrapper = NewStreamStatus(conf, strm, sLog, strmFlatMetrics)
 	m.streams[id] = wrapper
+	if ttl > 0 || idleTimeout > 0 {
+		go m.watchLifecycle(id, wrapper, ttl, idleTimeout)
+	}
 	return nil
 }
 
@@ -278,6 +365,12 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 // the stream was not found, or if clean shutdown fails in the specified period
 // of time.
 func (m *Type) Delete(id string, timeout time.Duration) error {
+	return m.delete(id, nil, timeout)
+}
+
+// delete stops and removes a stream by its ID. When expected is non-nil the
+// stream is only removed if it has not since been replaced.
+func (m *Type) delete(id string, expected *StreamStatus, timeout time.Duration) error {
 	m.lock.Lock()
 	if m.closed {
 		m.lock.Unlock()
@@ -286,7 +379,7 @@ func (m *Type) Delete(id string, timeout time.Duration) error {
 
 	wrapper, exists := m.streams[id]
 	m.lock.Unlock()
-	if !exists {
+	if !exists || (expected != nil && wrapper != expected) {
 		return ErrStreamDoesNotExist
 	}
 
@@ -295,9 +388,12 @@ func (m *Type) Delete(id string, timeout time.Duration) error {
 	}
 
 	m.lock.Lock()
-	delete(m.streams, id)
+	if m.streams[id] == wrapper {
+		delete(m.streams, id)
+	}
 	m.lock.Unlock()
 
+	wrapper.setRemoved()
 	return nil
 }
 
@@ -313,6 +409,7 @@ func (m *Type) Stop(timeout time.Duration) error {
 
 	for k, v := range m.streams {
 		go func(id string, strm *StreamStatus) {
+			defer strm.setRemoved()
 			if err := strm.strm.Stop(timeout); err != nil {
 				resultChan <- id
 			} else {
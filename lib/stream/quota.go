@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// QuotaConfig contains configuration fields for limiting the rate at which a
+// stream consumes messages from its input.
+type QuotaConfig struct {
+	MessagesPerSecond int `json:"messages_per_second" yaml:"messages_per_second"`
+	BytesPerSecond    int `json:"bytes_per_second" yaml:"bytes_per_second"`
+}
+
+// NewQuotaConfig returns a QuotaConfig with default values.
+func NewQuotaConfig() QuotaConfig {
+	return QuotaConfig{
+		MessagesPerSecond: 0,
+		BytesPerSecond:    0,
+	}
+}
+
+// IsNoop returns true if the quota config does not limit a stream.
+func (q QuotaConfig) IsNoop() bool {
+	return q.MessagesPerSecond <= 0 && q.BytesPerSecond <= 0
+}
+
+//------------------------------------------------------------------------------
+
+// tokenBucket is a token bucket that allows a second worth of tokens to
+// accumulate. Takes that exceed the available tokens are permitted but result
+// in a debt that must be waited out before the next take.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// take removes n tokens from the bucket and returns the duration to wait
+// before the tokens can be considered spent.
+func (b *tokenBucket) take(n int) time.Duration {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+//------------------------------------------------------------------------------
+
+// quotaStage throttles the transactions produced by an input in order to
+// enforce a quota. Throttling results in back pressure on the input and
+// messages are never dropped.
+type quotaStage struct {
+	msgBucket  *tokenBucket
+	byteBucket *tokenBucket
+	throttled  int32
+
+	mThrottled metrics.StatCounter
+	mWait      metrics.StatTimer
+	mState     metrics.StatGauge
+}
+
+func newQuotaStage(conf QuotaConfig, stats metrics.Type) *quotaStage {
+	q := &quotaStage{
+		mThrottled: stats.GetCounter("quota.throttled"),
+		mWait:      stats.GetTimer("quota.wait"),
+		mState:     stats.GetGauge("quota.throttled_state"),
+	}
+	if conf.MessagesPerSecond > 0 {
+		q.msgBucket = newTokenBucket(conf.MessagesPerSecond)
+	}
+	if conf.BytesPerSecond > 0 {
+		q.byteBucket = newTokenBucket(conf.BytesPerSecond)
+	}
+	return q
+}
+
+func (q *quotaStage) process(ctx context.Context, tran types.Transaction) (types.Transaction, func(), error) {
+	var wait time.Duration
+	if q.msgBucket != nil {
+		wait = q.msgBucket.take(tran.Payload.Len())
+	}
+	if q.byteBucket != nil {
+		var size int
+		tran.Payload.Iter(func(i int, p types.Part) error {
+			size += len(p.Get())
+			return nil
+		})
+		if bWait := q.byteBucket.take(size); bWait > wait {
+			wait = bWait
+		}
+	}
+
+	if wait > 0 {
+		q.mThrottled.Incr(1)
+		q.mWait.Timing(wait.Nanoseconds())
+		q.setThrottled(true)
+		defer q.setThrottled(false)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return tran, nil, ctx.Err()
+		}
+	}
+	return tran, nil, nil
+}
+
+func (q *quotaStage) setThrottled(throttled bool) {
+	var v int32
+	if throttled {
+		v = 1
+	}
+	atomic.StoreInt32(&q.throttled, v)
+	q.mState.Set(int64(v))
+}
+
+// Throttled returns true if the input is currently waiting for quota.
+func (q *quotaStage) Throttled() bool {
+	return atomic.LoadInt32(&q.throttled) == 1
+}
+
+//------------------------------------------------------------------------------
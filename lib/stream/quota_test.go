@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockQuotaInput struct {
+	ts chan types.Transaction
+}
+
+func (m *mockQuotaInput) TransactionChan() <-chan types.Transaction {
+	return m.ts
+}
+
+func (m *mockQuotaInput) Connected() bool {
+	return true
+}
+
+func (m *mockQuotaInput) CloseAsync() {
+	close(m.ts)
+}
+
+func (m *mockQuotaInput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10)
+
+	assert.Equal(t, time.Duration(0), b.take(5))
+	assert.Equal(t, time.Duration(0), b.take(5))
+
+	wait := b.take(5)
+	assert.Greater(t, int64(wait), int64(400*time.Millisecond))
+	assert.LessOrEqual(t, int64(wait), int64(500*time.Millisecond))
+}
+
+func TestQuotaInputThrottles(t *testing.T) {
+	conf := NewQuotaConfig()
+	conf.MessagesPerSecond = 2
+
+	mock := &mockQuotaInput{ts: make(chan types.Transaction)}
+	q := newQuotaStage(conf, metrics.Noop())
+	in := newStagedInput(mock, q)
+
+	resChan := make(chan types.Response)
+	sendAndRead := func() time.Duration {
+		started := time.Now()
+		go func() {
+			mock.ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+		}()
+		select {
+		case tran, open := <-in.TransactionChan():
+			require.True(t, open)
+			assert.Equal(t, "foo", string(tran.Payload.Get(0).Get()))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return time.Since(started)
+	}
+
+	assert.Less(t, int64(sendAndRead()), int64(100*time.Millisecond))
+	assert.Less(t, int64(sendAndRead()), int64(100*time.Millisecond))
+	assert.False(t, q.Throttled())
+
+	go func() {
+		<-time.After(100 * time.Millisecond)
+		assert.True(t, q.Throttled())
+	}()
+	assert.Greater(t, int64(sendAndRead()), int64(300*time.Millisecond))
+	assert.False(t, q.Throttled())
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+
+	_, open := <-in.TransactionChan()
+	assert.False(t, open)
+}
+
+func TestTypeQuota(t *testing.T) {
+	conf := NewConfig()
+	conf.Input.Type = "http_server"
+	conf.Output.Type = "drop"
+	conf.Quota.BytesPerSecond = 10
+
+	strm, err := New(conf)
+	require.NoError(t, err)
+
+	assert.NotNil(t, strm.quotaStage)
+	assert.False(t, strm.IsThrottled())
+	assert.NoError(t, strm.Stop(time.Minute))
+}
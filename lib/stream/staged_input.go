@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// inputStage is applied by a stagedInput to each transaction consumed from the
+// input layer of a stream.
+type inputStage interface {
+	// process is called with each transaction in the order that they are
+	// consumed, and may block until the context is cancelled in order to apply
+	// back pressure. Returns the transaction to forward, an optional function
+	// to be called once the transaction has been acknowledged, and an error if
+	// the context was cancelled whilst blocked.
+	process(ctx context.Context, tran types.Transaction) (types.Transaction, func(), error)
+}
+
+// stagedInput wraps an input and applies a series of stages to each
+// transaction it produces. Features of a stream that inspect or throttle the
+// transactions of its input are implemented as stages of this single wrapper
+// rather than as wrappers of their own, so that regardless of how many are
+// enabled transactions pass through one additional goroutine and channel, and
+// acknowledgements are awaited by at most one goroutine per transaction.
+type stagedInput struct {
+	input.Type
+	input.StatusForwarder
+
+	stages []inputStage
+
+	transactions chan types.Transaction
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+}
+
+func newStagedInput(in input.Type, stages ...inputStage) *stagedInput {
+	s := &stagedInput{
+		Type:            in,
+		StatusForwarder: input.NewStatusForwarder(in),
+		stages:          stages,
+		transactions:    make(chan types.Transaction),
+		closedChan:      make(chan struct{}),
+	}
+	s.ctx, s.done = context.WithCancel(context.Background())
+	go s.loop()
+	return s
+}
+
+func (s *stagedInput) loop() {
+	defer func() {
+		close(s.transactions)
+		close(s.closedChan)
+	}()
+
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-s.Type.TransactionChan():
+			if !open {
+				return
+			}
+		case <-s.ctx.Done():
+			return
+		}
+
+		var acked []func()
+		var err error
+		for _, stage := range s.stages {
+			var onAck func()
+			if tran, onAck, err = stage.process(s.ctx, tran); err != nil {
+				break
+			}
+			if onAck != nil {
+				acked = append(acked, onAck)
+			}
+		}
+
+		if err == nil {
+			fwdTran := tran
+			var resChan chan types.Response
+			if len(acked) > 0 {
+				resChan = make(chan types.Response)
+				fwdTran = types.NewTransaction(tran.Payload, resChan)
+			}
+			select {
+			case s.transactions <- fwdTran:
+				if resChan != nil {
+					go awaitStagedAck(resChan, tran.ResponseChan, acked)
+				}
+				continue
+			case <-s.ctx.Done():
+			}
+		}
+
+		// The transaction is rejected so that the input is able to close
+		// without waiting for it to be acknowledged.
+		for _, fn := range acked {
+			fn()
+		}
+		go func(resChan chan<- types.Response) {
+			resChan <- response.NewNoack()
+		}(tran.ResponseChan)
+		return
+	}
+}
+
+func awaitStagedAck(resChan <-chan types.Response, upstream chan<- types.Response, acked []func()) {
+	res, open := <-resChan
+	for _, fn := range acked {
+		fn()
+	}
+	if !open {
+		return
+	}
+	upstream <- res
+}
+
+// TransactionChan returns a channel of transactions from the wrapped input.
+func (s *stagedInput) TransactionChan() <-chan types.Transaction {
+	return s.transactions
+}
+
+// CloseAsync shuts down the wrapped input and stops forwarding transactions.
+func (s *stagedInput) CloseAsync() {
+	s.Type.CloseAsync()
+	s.done()
+}
+
+// WaitForClose blocks until the wrapped input has closed down.
+func (s *stagedInput) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	if err := s.Type.WaitForClose(timeout); err != nil {
+		return err
+	}
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout - time.Since(started)):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
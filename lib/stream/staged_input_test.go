@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	"github.com/Jeffail/benthos/v3/internal/correlation"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagedInputStages(t *testing.T) {
+	mem := budget.NewMemory(1<<20, metrics.Noop().GetGauge("used"))
+	activity := newActivityStage()
+	created := activity.LastActivity()
+
+	mock := &mockQuotaInput{ts: make(chan types.Transaction)}
+	s := newStagedInput(mock,
+		newCorrelationStage("cid", log.Noop()),
+		newBudgetStage(mem),
+		activity,
+	)
+
+	resChan := make(chan types.Response, 1)
+	go func() {
+		mock.ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+
+	var tran types.Transaction
+	select {
+	case tran = <-s.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	_, id := correlation.Get(tran.Payload.Get(0))
+	assert.NotEmpty(t, id)
+	assert.Equal(t, int64(budget.PartOverhead+3), mem.Used())
+	assert.False(t, activity.LastActivity().Before(created))
+
+	tran.ResponseChan <- response.NewAck()
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, int64(0), mem.Used())
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+}
+
+func TestStagedInputCloseRejects(t *testing.T) {
+	mem := budget.NewMemory(1, metrics.Noop().GetGauge("used"))
+	release := mem.Add(1)
+
+	mock := &mockQuotaInput{ts: make(chan types.Transaction)}
+	s := newStagedInput(mock, newBudgetStage(mem))
+
+	// The budget is exhausted and so the transaction is held by the stage
+	// until the input is closed, at which point it is rejected.
+	resChan := make(chan types.Response, 1)
+	mock.ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+
+	s.CloseAsync()
+	select {
+	case res := <-resChan:
+		assert.Error(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.NoError(t, s.WaitForClose(time.Second*5))
+
+	release()
+	assert.Equal(t, int64(0), mem.Used())
+}
@@ -2,11 +2,14 @@ package stream
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
 	"time"
 
+	inputInternal "github.com/Jeffail/benthos/v3/internal/component/input"
 	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/broker"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -26,10 +29,14 @@ import (
 type Type struct {
 	conf Config
 
-	inputLayer    input.Type
-	bufferLayer   buffer.Type
-	pipelineLayer pipeline.Type
-	outputLayer   output.Type
+	inputLayer     input.Type
+	inputWatchdog  *watchdogInput
+	quotaStage     *quotaStage
+	activityStage  *activityStage
+	bufferLayer    buffer.Type
+	pipelineLayer  pipeline.Type
+	outputLayer    output.Type
+	outputWatchdog *watchdogOutput
 
 	complementaryProcs []types.ProcessorConstructorFunc
 
@@ -68,13 +75,37 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			w.Write([]byte("output not connected\n"))
 		}
+		if t.inputWatchdog != nil && t.inputWatchdog.Stalled() {
+			connected = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("input stalled\n"))
+		}
+		if t.outputWatchdog != nil && t.outputWatchdog.Stalled() {
+			connected = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("output stalled\n"))
+		}
 		if connected {
 			w.Write([]byte("OK"))
 		}
+		if r, ok := t.inputLayer.(broker.ChildStatusReporter); ok {
+			for _, s := range r.ChildStatuses() {
+				if !s.Connected {
+					fmt.Fprintf(w, "\ninput child '%v' not connected", s.Label)
+				}
+			}
+		}
+		for _, label := range t.PausedInputs() {
+			fmt.Fprintf(w, "\ninput '%v' paused", label)
+		}
+		if r.URL.Query().Get("verbose") == "true" {
+			inFlight := t.InFlight()
+			fmt.Fprintf(w, "\ninput in flight: %v, oldest age: %v", inFlight.Count, inFlight.OldestAge().Round(time.Millisecond))
+		}
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all inputs and outputs are connected and have not stalled, otherwise a 503 is returned. Add the query parameter verbose=true in order to also show the messages in flight.",
 		healthCheck,
 	)
 	return t, nil
@@ -133,17 +164,98 @@ func OptOnClose(onClose func()) func(*Type) {
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
-// of the stream are connected.
+// of the stream are connected, and neither has been flagged as stalled by a
+// watchdog.
 func (t *Type) IsReady() bool {
+	if t.inputWatchdog != nil && t.inputWatchdog.Stalled() {
+		return false
+	}
+	if t.outputWatchdog != nil && t.outputWatchdog.Stalled() {
+		return false
+	}
 	return t.inputLayer.Connected() && t.outputLayer.Connected()
 }
 
+// PausedInputs returns the labels of the inputs of the stream that have been
+// paused via the HTTP API.
+func (t *Type) PausedInputs() []string {
+	if p, ok := t.manager.(interface {
+		PausedInputs() []string
+	}); ok {
+		return p.PausedInputs()
+	}
+	return nil
+}
+
+// InFlight returns the stats of messages that have been read by the input layer
+// of the stream and are yet to be acknowledged.
+func (t *Type) InFlight() inputInternal.InFlightStats {
+	if r, ok := t.inputLayer.(inputInternal.InFlightReporter); ok {
+		return r.InFlight()
+	}
+	return inputInternal.InFlightStats{}
+}
+
+// IsThrottled returns a boolean indicating whether the input layer of the
+// stream is currently experiencing back pressure due to the stream quota.
+func (t *Type) IsThrottled() bool {
+	return t.quotaStage != nil && t.quotaStage.Throttled()
+}
+
+// LastActivity returns the time at which the input layer of the stream last
+// produced a message, which is only tracked when the stream has an idle
+// timeout, and is otherwise zero.
+func (t *Type) LastActivity() time.Time {
+	if t.activityStage == nil {
+		return time.Time{}
+	}
+	return t.activityStage.LastActivity()
+}
+
 func (t *Type) start() (err error) {
+	var idleTimeout time.Duration
+	if _, idleTimeout, err = t.conf.Lifecycle.Durations(); err != nil {
+		return
+	}
+	var inputStallTimeout, outputStallTimeout time.Duration
+	if inputStallTimeout, err = t.conf.Watchdog.Input.StallTimeoutDuration(); err != nil {
+		return fmt.Errorf("failed to parse input watchdog: %v", err)
+	}
+	if outputStallTimeout, err = t.conf.Watchdog.Output.StallTimeoutDuration(); err != nil {
+		return fmt.Errorf("failed to parse output watchdog: %v", err)
+	}
+
 	// Constructors
 	iMgr, iLog, iStats := interop.LabelChild("input", t.manager, t.logger, t.stats)
-	if t.inputLayer, err = input.New(t.conf.Input, iMgr, iLog, iStats); err != nil {
+	if inputStallTimeout > 0 {
+		dog := newWatchdog("input", inputStallTimeout, t.conf.Watchdog.Input.MaxRestarts, iLog, iStats)
+		if t.inputWatchdog, err = newWatchdogInput(dog, func() (input.Type, error) {
+			return input.New(t.conf.Input, iMgr, iLog, iStats)
+		}); err != nil {
+			return
+		}
+		t.inputLayer = t.inputWatchdog
+	} else if t.inputLayer, err = input.New(t.conf.Input, iMgr, iLog, iStats); err != nil {
 		return
 	}
+	var stages []inputStage
+	if t.conf.Pipeline.CorrelationID.Enabled {
+		stages = append(stages, newCorrelationStage(t.conf.Pipeline.CorrelationID.Key, iLog))
+	}
+	if !t.conf.Quota.IsNoop() {
+		t.quotaStage = newQuotaStage(t.conf.Quota, iStats)
+		stages = append(stages, t.quotaStage)
+	}
+	if mem := interop.GetMemoryBudget(t.manager); mem != nil {
+		stages = append(stages, newBudgetStage(mem))
+	}
+	if idleTimeout > 0 {
+		t.activityStage = newActivityStage()
+		stages = append(stages, t.activityStage)
+	}
+	if len(stages) > 0 {
+		t.inputLayer = newStagedInput(t.inputLayer, stages...)
+	}
 	if t.conf.Buffer.Type != buffer.TypeNone {
 		bMgr, bLog, bStats := interop.LabelChild("buffer", t.manager, t.logger, t.stats)
 		if t.bufferLayer, err = buffer.New(t.conf.Buffer, bMgr, bLog, bStats); err != nil {
@@ -157,9 +269,25 @@ func (t *Type) start() (err error) {
 		}
 	}
 	oMgr, oLog, oStats := interop.LabelChild("output", t.manager, t.logger, t.stats)
-	if t.outputLayer, err = output.New(t.conf.Output, oMgr, oLog, oStats); err != nil {
+	if outputStallTimeout > 0 {
+		dog := newWatchdog("output", outputStallTimeout, t.conf.Watchdog.Output.MaxRestarts, oLog, oStats)
+		if t.outputWatchdog, err = newWatchdogOutput(dog, func() (output.Type, error) {
+			return output.New(t.conf.Output, oMgr, oLog, oStats)
+		}); err != nil {
+			return
+		}
+		t.outputLayer = t.outputWatchdog
+	} else if t.outputLayer, err = output.New(t.conf.Output, oMgr, oLog, oStats); err != nil {
 		return
 	}
+	if t.conf.DeadLetter != nil {
+		dMgr, dLog, dStats := interop.LabelChild("dead_letter", t.manager, t.logger, t.stats)
+		var deadLetter output.Type
+		if deadLetter, err = output.New(*t.conf.DeadLetter, dMgr, dLog, dStats); err != nil {
+			return
+		}
+		t.outputLayer = newDeadLetterOutput(t.outputLayer, deadLetter, dLog, dStats)
+	}
 
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
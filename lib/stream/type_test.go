@@ -116,3 +116,20 @@ func TestTypeCloseUnordered(t *testing.T) {
 	require.NoError(t, err)
 	assert.NoError(t, strm.stopUnordered(time.Minute))
 }
+
+func TestTypeWatchdogUnready(t *testing.T) {
+	conf := NewConfig()
+	conf.Input.Type = input.TypeHTTPServer
+	conf.Output.Type = output.TypeHTTPServer
+	conf.Watchdog.Input.StallTimeout = "40ms"
+
+	strm, err := New(conf)
+	require.NoError(t, err)
+
+	assert.True(t, strm.IsReady())
+	assert.Eventually(t, func() bool {
+		return !strm.IsReady()
+	}, time.Second*5, time.Millisecond*10)
+
+	assert.NoError(t, strm.stopGracefully(time.Minute))
+}
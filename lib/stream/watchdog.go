@@ -0,0 +1,494 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// WatchdogConfig contains configuration fields for detecting inputs and
+// outputs of a stream that have stalled.
+type WatchdogConfig struct {
+	Input  WatchdogComponentConfig `json:"input" yaml:"input"`
+	Output WatchdogComponentConfig `json:"output" yaml:"output"`
+}
+
+// NewWatchdogConfig returns a WatchdogConfig with default values.
+func NewWatchdogConfig() WatchdogConfig {
+	return WatchdogConfig{
+		Input:  NewWatchdogComponentConfig(),
+		Output: NewWatchdogComponentConfig(),
+	}
+}
+
+// WatchdogComponentConfig contains configuration fields for detecting a stalled
+// component.
+type WatchdogComponentConfig struct {
+	StallTimeout string `json:"stall_timeout" yaml:"stall_timeout"`
+	MaxRestarts  int    `json:"max_restarts" yaml:"max_restarts"`
+}
+
+// NewWatchdogComponentConfig returns a WatchdogComponentConfig with default
+// values.
+func NewWatchdogComponentConfig() WatchdogComponentConfig {
+	return WatchdogComponentConfig{
+		StallTimeout: "",
+		MaxRestarts:  0,
+	}
+}
+
+// StallTimeoutDuration parses the stall timeout of the config, where a duration
+// of zero indicates that the watchdog is disabled.
+func (w WatchdogComponentConfig) StallTimeoutDuration() (time.Duration, error) {
+	if w.MaxRestarts < 0 {
+		return 0, fmt.Errorf("max_restarts must not be negative: %v", w.MaxRestarts)
+	}
+	if w.StallTimeout == "" {
+		return 0, nil
+	}
+	tout, err := time.ParseDuration(w.StallTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stall_timeout: %v", err)
+	}
+	return tout, nil
+}
+
+//------------------------------------------------------------------------------
+
+// watchdogCloseTimeout is the maximum period to wait for a stalled component
+// to close before it is replaced.
+const watchdogCloseTimeout = time.Second * 10
+
+// errWatchdogRestart is the response given to messages that were pending
+// within an output when it was restarted.
+var errWatchdogRestart = errors.New("output was restarted by the watchdog whilst the message was pending")
+
+// watchdog tracks the stall state of a component and decides when it should be
+// restarted. All methods other than Stalled must be called from a single
+// goroutine.
+type watchdog struct {
+	name         string
+	stallTimeout time.Duration
+	maxRestarts  int
+
+	restarts  int
+	stalled   bool
+	exhausted int32
+
+	log         log.Modular
+	mStalled    metrics.StatCounter
+	mRestart    metrics.StatCounter
+	mRestartErr metrics.StatCounter
+}
+
+func newWatchdog(name string, stallTimeout time.Duration, maxRestarts int, log log.Modular, stats metrics.Type) *watchdog {
+	return &watchdog{
+		name:         name,
+		stallTimeout: stallTimeout,
+		maxRestarts:  maxRestarts,
+		log:          log,
+		mStalled:     stats.GetCounter("watchdog.stalled"),
+		mRestart:     stats.GetCounter("watchdog.restart"),
+		mRestartErr:  stats.GetCounter("watchdog.restart.error"),
+	}
+}
+
+// checkPeriod returns the interval at which a component should be checked.
+func (w *watchdog) checkPeriod() time.Duration {
+	return w.stallTimeout / 4
+}
+
+// progress resets the stall state of the component after it has made
+// progress.
+func (w *watchdog) progress() {
+	w.restarts = 0
+	w.stalled = false
+	atomic.StoreInt32(&w.exhausted, 0)
+}
+
+// check determines whether a connected component has stalled since a given
+// time, in which case it is restarted until the maximum number of consecutive
+// restarts is reached. Returns true if the component was restarted.
+func (w *watchdog) check(connected bool, since time.Time, restart func() error) bool {
+	if !connected || w.stalled || time.Since(since) < w.stallTimeout {
+		return false
+	}
+
+	w.stalled = true
+	w.mStalled.Incr(1)
+	w.log.Warnf("The %v has reported being connected without making progress for %v\n", w.name, time.Since(since).Round(time.Millisecond))
+
+	if w.restarts >= w.maxRestarts {
+		atomic.StoreInt32(&w.exhausted, 1)
+		if w.maxRestarts > 0 {
+			w.log.Errorf("The %v remains stalled after %v restarts, the stream is no longer considered ready\n", w.name, w.restarts)
+		}
+		return false
+	}
+
+	w.restarts++
+	w.mRestart.Incr(1)
+	w.log.Warnf("Restarting the %v (attempt %v of %v)\n", w.name, w.restarts, w.maxRestarts)
+	if err := restart(); err != nil {
+		w.mRestartErr.Incr(1)
+		w.log.Errorf("Failed to restart the %v: %v\n", w.name, err)
+	}
+	w.stalled = false
+	return true
+}
+
+// Stalled returns true if the component has stalled and will not be restarted.
+func (w *watchdog) Stalled() bool {
+	return atomic.LoadInt32(&w.exhausted) == 1
+}
+
+//------------------------------------------------------------------------------
+
+// watchdogInput wraps an input and replaces it with a new instance when it
+// reports being connected but has not produced a transaction for longer than
+// the stall timeout.
+type watchdogInput struct {
+	input.StatusForwarder
+
+	ctor func() (input.Type, error)
+	dog  *watchdog
+
+	inMut sync.RWMutex
+	in    input.Type
+
+	lastActivity time.Time
+
+	transactions chan types.Transaction
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newWatchdogInput(dog *watchdog, ctor func() (input.Type, error)) (*watchdogInput, error) {
+	in, err := ctor()
+	if err != nil {
+		return nil, err
+	}
+	w := &watchdogInput{
+		ctor:         ctor,
+		dog:          dog,
+		in:           in,
+		lastActivity: time.Now(),
+		transactions: make(chan types.Transaction),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}
+	w.StatusForwarder = input.NewDynamicStatusForwarder(w.current)
+	go w.loop()
+	return w, nil
+}
+
+func (w *watchdogInput) current() input.Type {
+	w.inMut.RLock()
+	defer w.inMut.RUnlock()
+	return w.in
+}
+
+func (w *watchdogInput) restart() error {
+	old := w.current()
+	old.CloseAsync()
+	if err := old.WaitForClose(watchdogCloseTimeout); err != nil {
+		w.dog.log.Errorf("Failed to close stalled input: %v\n", err)
+	}
+
+	in, err := w.ctor()
+	if err != nil {
+		return err
+	}
+
+	w.inMut.Lock()
+	w.in = in
+	w.inMut.Unlock()
+	return nil
+}
+
+func (w *watchdogInput) loop() {
+	defer func() {
+		close(w.transactions)
+		close(w.closedChan)
+	}()
+
+	ticker := time.NewTicker(w.dog.checkPeriod())
+	defer ticker.Stop()
+
+	for {
+		in := w.current()
+
+		select {
+		case tran, open := <-in.TransactionChan():
+			if !open {
+				return
+			}
+			w.lastActivity = time.Now()
+			w.dog.progress()
+			select {
+			case w.transactions <- tran:
+			case <-w.closeChan:
+				return
+			}
+		case <-ticker.C:
+			if w.dog.check(in.Connected(), w.lastActivity, w.restart) {
+				w.lastActivity = time.Now()
+			}
+		case <-w.closeChan:
+			return
+		}
+	}
+}
+
+// Stalled returns true if the input has stalled and restarts are exhausted.
+func (w *watchdogInput) Stalled() bool {
+	return w.dog.Stalled()
+}
+
+// TransactionChan returns a channel of transactions from the wrapped input.
+func (w *watchdogInput) TransactionChan() <-chan types.Transaction {
+	return w.transactions
+}
+
+// Connected returns a boolean indicating whether the wrapped input is
+// connected.
+func (w *watchdogInput) Connected() bool {
+	return w.current().Connected()
+}
+
+// CloseAsync shuts down the wrapped input and stops forwarding transactions.
+func (w *watchdogInput) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
+	w.current().CloseAsync()
+}
+
+// WaitForClose blocks until the wrapped input has closed down.
+func (w *watchdogInput) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	select {
+	case <-w.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	// The input may have been replaced before the loop exited.
+	in := w.current()
+	in.CloseAsync()
+	return in.WaitForClose(timeout - time.Since(started))
+}
+
+//------------------------------------------------------------------------------
+
+// watchdogOutput wraps an output and replaces it with a new instance when it
+// reports being connected but has not responded to pending transactions for
+// longer than the stall timeout. Transactions pending within a replaced output
+// are rejected so that they can be retried.
+type watchdogOutput struct {
+	ctor func() (output.Type, error)
+	dog  *watchdog
+
+	outMut       sync.RWMutex
+	out          output.Type
+	transactions chan types.Transaction
+	replaced     chan struct{}
+
+	pending      int64
+	lastProgress int64
+
+	pendingWG sync.WaitGroup
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newWatchdogOutput(dog *watchdog, ctor func() (output.Type, error)) (*watchdogOutput, error) {
+	out, err := ctor()
+	if err != nil {
+		return nil, err
+	}
+	return &watchdogOutput{
+		ctor:         ctor,
+		dog:          dog,
+		out:          out,
+		transactions: make(chan types.Transaction),
+		replaced:     make(chan struct{}),
+		lastProgress: time.Now().UnixNano(),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}, nil
+}
+
+func (w *watchdogOutput) current() (output.Type, chan types.Transaction, chan struct{}) {
+	w.outMut.RLock()
+	defer w.outMut.RUnlock()
+	return w.out, w.transactions, w.replaced
+}
+
+func (w *watchdogOutput) restart() error {
+	out, err := w.ctor()
+	if err != nil {
+		return err
+	}
+	transactions := make(chan types.Transaction)
+	if err = out.Consume(transactions); err != nil {
+		out.CloseAsync()
+		return err
+	}
+
+	w.outMut.Lock()
+	old, oldTransactions, oldReplaced := w.out, w.transactions, w.replaced
+	w.out, w.transactions, w.replaced = out, transactions, make(chan struct{})
+	w.outMut.Unlock()
+
+	// Reject the transactions pending within the old output before closing it
+	// so that they are retried upstream.
+	close(oldReplaced)
+	close(oldTransactions)
+	old.CloseAsync()
+	if err := old.WaitForClose(watchdogCloseTimeout); err != nil {
+		w.dog.log.Errorf("Failed to close stalled output: %v\n", err)
+	}
+	return nil
+}
+
+// Consume begins feeding transactions into the wrapped output.
+func (w *watchdogOutput) Consume(ts <-chan types.Transaction) error {
+	out, transactions, _ := w.current()
+	if err := out.Consume(transactions); err != nil {
+		return err
+	}
+	go w.loop(ts)
+	return nil
+}
+
+func (w *watchdogOutput) markProgress() {
+	atomic.StoreInt64(&w.lastProgress, time.Now().UnixNano())
+}
+
+func (w *watchdogOutput) check() {
+	out, _, _ := w.current()
+	connected := out.Connected() && atomic.LoadInt64(&w.pending) > 0
+	since := time.Unix(0, atomic.LoadInt64(&w.lastProgress))
+	if w.dog.check(connected, since, w.restart) {
+		w.markProgress()
+	}
+}
+
+func (w *watchdogOutput) loop(ts <-chan types.Transaction) {
+	defer func() {
+		_, transactions, _ := w.current()
+		close(transactions)
+		w.pendingWG.Wait()
+		close(w.closedChan)
+	}()
+
+	ticker := time.NewTicker(w.dog.checkPeriod())
+	defer ticker.Stop()
+
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-ts:
+			if !open {
+				return
+			}
+		case <-ticker.C:
+			w.check()
+			continue
+		case <-w.closeChan:
+			return
+		}
+
+		if atomic.AddInt64(&w.pending, 1) == 1 {
+			w.markProgress()
+		}
+
+		resChan := make(chan types.Response)
+		var replaced chan struct{}
+	sendLoop:
+		for {
+			var transactions chan types.Transaction
+			_, transactions, replaced = w.current()
+			select {
+			case transactions <- types.NewTransaction(tran.Payload, resChan):
+				break sendLoop
+			case <-ticker.C:
+				w.check()
+			case <-w.closeChan:
+				return
+			}
+		}
+
+		w.pendingWG.Add(1)
+		go func(tran types.Transaction, replaced chan struct{}) {
+			defer w.pendingWG.Done()
+			defer atomic.AddInt64(&w.pending, -1)
+
+			var res types.Response
+			select {
+			case res = <-resChan:
+				w.markProgress()
+				w.dog.progress()
+			case <-replaced:
+				res = response.NewError(errWatchdogRestart)
+			case <-w.closeChan:
+				return
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-w.closeChan:
+			}
+		}(tran, replaced)
+	}
+}
+
+// Stalled returns true if the output has stalled and restarts are exhausted.
+func (w *watchdogOutput) Stalled() bool {
+	return w.dog.Stalled()
+}
+
+// Connected returns a boolean indicating whether the wrapped output is
+// connected.
+func (w *watchdogOutput) Connected() bool {
+	out, _, _ := w.current()
+	return out.Connected()
+}
+
+// CloseAsync shuts down the wrapped output.
+func (w *watchdogOutput) CloseAsync() {
+	out, _, _ := w.current()
+	out.CloseAsync()
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
+}
+
+// WaitForClose blocks until the wrapped output has closed down.
+func (w *watchdogOutput) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	select {
+	case <-w.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	out, _, _ := w.current()
+	return out.WaitForClose(timeout - time.Since(started))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,213 @@
+package stream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stalledInput struct {
+	ts        chan types.Transaction
+	closeOnce sync.Once
+}
+
+func (s *stalledInput) TransactionChan() <-chan types.Transaction {
+	return s.ts
+}
+
+func (s *stalledInput) Connected() bool {
+	return true
+}
+
+func (s *stalledInput) CloseAsync() {
+	s.closeOnce.Do(func() {
+		close(s.ts)
+	})
+}
+
+func (s *stalledInput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type stalledOutput struct {
+	done chan struct{}
+}
+
+func (s *stalledOutput) Consume(ts <-chan types.Transaction) error {
+	go func() {
+		defer close(s.done)
+		for range ts {
+		}
+	}()
+	return nil
+}
+
+func (s *stalledOutput) Connected() bool {
+	return true
+}
+
+func (s *stalledOutput) CloseAsync() {}
+
+func (s *stalledOutput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+func TestWatchdogComponentConfig(t *testing.T) {
+	conf := NewWatchdogComponentConfig()
+
+	tout, err := conf.StallTimeoutDuration()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), tout)
+
+	conf.StallTimeout = "1m"
+	tout, err = conf.StallTimeoutDuration()
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, tout)
+
+	conf.StallTimeout = "nope"
+	_, err = conf.StallTimeoutDuration()
+	require.Error(t, err)
+
+	conf.StallTimeout = "1m"
+	conf.MaxRestarts = -1
+	_, err = conf.StallTimeoutDuration()
+	require.Error(t, err)
+}
+
+func TestWatchdogInputRestarts(t *testing.T) {
+	var mut sync.Mutex
+	var inputs []*stalledInput
+
+	dog := newWatchdog("input", time.Millisecond*40, 2, log.Noop(), metrics.Noop())
+	w, err := newWatchdogInput(dog, func() (input.Type, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		in := &stalledInput{ts: make(chan types.Transaction)}
+		inputs = append(inputs, in)
+		return in, nil
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, w.Stalled, time.Second*5, time.Millisecond*10)
+
+	mut.Lock()
+	assert.Len(t, inputs, 3)
+	latest := inputs[len(inputs)-1]
+	mut.Unlock()
+
+	resChan := make(chan types.Response)
+	go func() {
+		latest.ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+	select {
+	case tran, open := <-w.TransactionChan():
+		require.True(t, open)
+		assert.Equal(t, "foo", string(tran.Payload.Get(0).Get()))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.False(t, w.Stalled())
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestWatchdogInputNoRestarts(t *testing.T) {
+	var constructed int
+
+	dog := newWatchdog("input", time.Millisecond*40, 0, log.Noop(), metrics.Noop())
+	w, err := newWatchdogInput(dog, func() (input.Type, error) {
+		constructed++
+		return &stalledInput{ts: make(chan types.Transaction)}, nil
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, w.Stalled, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, 1, constructed)
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestWatchdogOutputRestarts(t *testing.T) {
+	var mut sync.Mutex
+	var constructed int
+
+	dog := newWatchdog("output", time.Millisecond*40, 1, log.Noop(), metrics.Noop())
+	w, err := newWatchdogOutput(dog, func() (output.Type, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		constructed++
+		if constructed == 1 {
+			return &stalledOutput{done: make(chan struct{})}, nil
+		}
+		return newRespondingOutput(func(tran types.Transaction) types.Response {
+			return response.NewAck()
+		}), nil
+	})
+	require.NoError(t, err)
+
+	ts := make(chan types.Transaction)
+	require.NoError(t, w.Consume(ts))
+
+	send := func() types.Response {
+		resChan := make(chan types.Response)
+		select {
+		case ts <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			return res
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	assert.Equal(t, errWatchdogRestart, send().Error())
+	assert.NoError(t, send().Error())
+	assert.False(t, w.Stalled())
+
+	mut.Lock()
+	assert.Equal(t, 2, constructed)
+	mut.Unlock()
+
+	close(ts)
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestWatchdogOutputIdle(t *testing.T) {
+	dog := newWatchdog("output", time.Millisecond*20, 0, log.Noop(), metrics.Noop())
+	w, err := newWatchdogOutput(dog, func() (output.Type, error) {
+		return &stalledOutput{done: make(chan struct{})}, nil
+	})
+	require.NoError(t, err)
+
+	ts := make(chan types.Transaction)
+	require.NoError(t, w.Consume(ts))
+
+	<-time.After(time.Millisecond * 100)
+	assert.False(t, w.Stalled())
+
+	close(ts)
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,51 @@ func createKinesisShards(ctx context.Context, awsPort, id string, numShards int)
 	})
 }
 
+// createKCLLeaseTable creates a lease table with the schema used by KCL v2
+// containing a lease for shard 0 owned by a KCL worker that has stopped renewing
+// it.
+func createKCLLeaseTable(ctx context.Context, awsPort, id string) error {
+	endpoint := fmt.Sprintf("http://localhost:%v", awsPort)
+
+	client := dynamodb.New(session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("us-east-1"),
+	})))
+
+	table := "kcl-" + id
+	if _, err := client.CreateTableWithContext(ctx, &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("leaseKey"), AttributeType: aws.String("S")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("leaseKey"), KeyType: aws.String("HASH")},
+		},
+		TableName: aws.String(table),
+	}); err != nil {
+		return err
+	}
+	if err := client.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(table),
+	}); err != nil {
+		return err
+	}
+
+	_, err := client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"leaseKey":                     {S: aws.String("shardId-000000000000")},
+			"leaseOwner":                   {S: aws.String("dead-kcl-worker")},
+			"leaseCounter":                 {N: aws.String("10")},
+			"checkpoint":                   {S: aws.String("TRIM_HORIZON")},
+			"checkpointSubSequenceNumber":  {N: aws.String("0")},
+			"ownerSwitchesSinceCheckpoint": {N: aws.String("0")},
+		},
+	})
+	return err
+}
+
 var _ = registerIntegrationTest("aws_kinesis", func(t *testing.T) {
 	t.Parallel()
 
@@ -129,6 +175,58 @@ input:
 		)
 	})
 
+	t.Run("with kcl compatibility", func(t *testing.T) {
+		kclTemplate := `
+output:
+  aws_kinesis:
+    endpoint: http://localhost:$PORT
+    region: us-east-1
+    stream: stream-$ID
+    partition_key: ${! uuid_v4() }
+    max_in_flight: $MAX_IN_FLIGHT
+    credentials:
+      id: xxxxx
+      secret: xxxxx
+      token: xxxxx
+    batching:
+      count: $OUTPUT_BATCH_COUNT
+
+input:
+  aws_kinesis:
+    endpoint: http://localhost:$PORT
+    streams: [ stream-$ID ]
+    checkpoint_limit: 10
+    commit_period: 500ms
+    lease_period: 1s
+    rebalance_period: 1s
+    dynamodb:
+      table: kcl-$ID
+      kcl_compatibility: true
+    start_from_oldest: true
+    region: us-east-1
+    credentials:
+      id: xxxxx
+      secret: xxxxx
+      token: xxxxx
+`
+		// The lease of shard 0 is held by a KCL worker that is no longer
+		// renewing it, and therefore these tests only pass when the lease is
+		// stolen.
+		integrationTests(
+			integrationTestOpenClose(),
+			integrationTestSendBatch(10),
+			integrationTestStreamSequential(200),
+		).Run(
+			t, kclTemplate,
+			testOptPreTest(func(t *testing.T, env *testEnvironment) {
+				require.NoError(t, createKinesisShards(env.ctx, resource.GetPort("4566/tcp"), env.configVars.id, 2))
+				require.NoError(t, createKCLLeaseTable(env.ctx, resource.GetPort("4566/tcp"), env.configVars.id))
+			}),
+			testOptPort(resource.GetPort("4566/tcp")),
+			testOptAllowDupes(),
+		)
+	})
+
 	t.Run("single shard", func(t *testing.T) {
 		integrationTests(
 			integrationTestCheckpointCapture(),
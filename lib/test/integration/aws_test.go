@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/gofrs/uuid"
 	"github.com/ory/dockertest/v3"
@@ -98,6 +99,63 @@ func createBucketQueue(s3Port, sqsPort, id string) error {
 	return nil
 }
 
+func createBucketTopicQueue(port, id string) error {
+	if err := createBucketQueue(port, port, id); err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("http://localhost:%v", port)
+	bucket := "bucket-" + id
+	sqsQueueURL := fmt.Sprintf("%v/queue/queue-%v", endpoint, id)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String("eu-west-1"),
+	}))
+	s3Client, snsClient, sqsClient := s3.New(sess), sns.New(sess), sqs.New(sess)
+
+	topic, err := snsClient.CreateTopic(&sns.CreateTopicInput{
+		Name: aws.String("topic-" + id),
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := sqsClient.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       &sqsQueueURL,
+		AttributeNames: []*string{aws.String("QueueArn")},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = snsClient.Subscribe(&sns.SubscribeInput{
+		TopicArn: topic.TopicArn,
+		Protocol: aws.String("sqs"),
+		Endpoint: res.Attributes["QueueArn"],
+	}); err != nil {
+		return err
+	}
+
+	// Replaces the queue notification set by createBucketQueue.
+	_, err = s3Client.PutBucketNotificationConfiguration(&s3.PutBucketNotificationConfigurationInput{
+		Bucket: &bucket,
+		NotificationConfiguration: &s3.NotificationConfiguration{
+			TopicConfigurations: []*s3.TopicConfiguration{
+				{
+					Events: []*string{
+						aws.String("s3:ObjectCreated:*"),
+					},
+					TopicArn: topic.TopicArn,
+				},
+			},
+		},
+	})
+	return err
+}
+
 var _ = registerIntegrationTest("aws", func(t *testing.T) {
 	t.Parallel()
 
@@ -109,7 +167,7 @@ var _ = registerIntegrationTest("aws", func(t *testing.T) {
 	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
 		Repository:   "localstack/localstack",
 		ExposedPorts: []string{"4566/tcp"},
-		Env:          []string{"SERVICES=s3,sqs"},
+		Env:          []string{"SERVICES=s3,sns,sqs"},
 	})
 	require.NoError(t, err)
 	t.Cleanup(func() {
@@ -233,6 +291,56 @@ input:
 		)
 	})
 
+	t.Run("s3_to_sns_to_sqs", func(t *testing.T) {
+		template := `
+output:
+  aws_s3:
+    bucket: bucket-$ID
+    endpoint: http://localhost:$PORT
+    force_path_style_urls: true
+    region: eu-west-1
+    path: ${!count("$ID")}.txt
+    tags:
+      tenant: acme
+    credentials:
+      id: xxxxx
+      secret: xxxxx
+      token: xxxxx
+    batching:
+      count: $OUTPUT_BATCH_COUNT
+
+input:
+  aws_s3:
+    bucket: bucket-$ID
+    endpoint: http://localhost:$PORT
+    force_path_style_urls: true
+    region: eu-west-1
+    delete_objects: true
+    fetch_object_tags: true
+    sqs:
+      url: http://localhost:$PORT/queue/queue-$ID
+      key_path: Records.*.s3.object.key
+      endpoint: http://localhost:$PORT
+    credentials:
+      id: xxxxx
+      secret: xxxxx
+      token: xxxxx
+  processors:
+    - bloblang: 'root = if meta("tenant") != "acme" { deleted() }'
+`
+		integrationTests(
+			integrationTestOpenClose(),
+			integrationTestStreamSequential(10),
+		).Run(
+			t, template,
+			testOptPreTest(func(t *testing.T, env *testEnvironment) {
+				require.NoError(t, createBucketTopicQueue(servicePort, env.configVars.id))
+			}),
+			testOptPort(servicePort),
+			testOptAllowDupes(),
+		)
+	})
+
 	t.Run("s3", func(t *testing.T) {
 		template := `
 output:
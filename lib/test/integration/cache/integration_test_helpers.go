@@ -3,8 +3,18 @@ package cache
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -186,3 +196,46 @@ func closeCache(t *testing.T, cache types.Cache) {
 	cache.CloseAsync()
 	require.NoError(t, cache.WaitForClose(time.Second*10))
 }
+
+//------------------------------------------------------------------------------
+
+// createTLSCertFiles writes a self signed certificate and key for localhost to
+// a temporary directory that is readable by docker containers, and returns the
+// path of the directory. The certificate is written to cert.pem and the key to
+// key.pem.
+func createTLSCertFiles(t *testing.T) string {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"Benthos"}},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o755))
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o644))
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o644))
+
+	return dir
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -67,3 +68,89 @@ cache_resources:
 		testOptPort(resource.GetPort("11211/tcp")),
 	)
 })
+
+var _ = registerIntegrationTest("memcached_tls", func(t *testing.T) {
+	t.Parallel()
+
+	certDir := createTLSCertFiles(t)
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "memcached",
+		Tag:        "latest",
+		Cmd: []string{
+			"memcached", "-Z",
+			"-o", "ssl_chain_cert=/certs/cert.pem,ssl_key=/certs/key.pem",
+		},
+		Mounts: []string{certDir + ":/certs"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		conf := cache.NewConfig()
+		conf.Memcached.Addresses = []string{fmt.Sprintf("localhost:%v", resource.GetPort("11211/tcp"))}
+		conf.Memcached.TLS.Enabled = true
+		conf.Memcached.TLS.RootCAsFile = filepath.Join(certDir, "cert.pem")
+
+		mCache, cErr := cache.NewMemcached(conf, nil, log.Noop(), metrics.Noop())
+		if cErr != nil {
+			return cErr
+		}
+		return mCache.Set("testkey", []byte("testvalue"))
+	}))
+
+	t.Run("without tls", func(t *testing.T) {
+		conf := cache.NewConfig()
+		conf.Memcached.Addresses = []string{fmt.Sprintf("localhost:%v", resource.GetPort("11211/tcp"))}
+		conf.Memcached.Retries = 0
+
+		mCache, err := cache.NewMemcached(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		require.Error(t, mCache.Set("testkey", []byte("testvalue")))
+	})
+
+	t.Run("unknown authority", func(t *testing.T) {
+		conf := cache.NewConfig()
+		conf.Memcached.Addresses = []string{fmt.Sprintf("localhost:%v", resource.GetPort("11211/tcp"))}
+		conf.Memcached.Retries = 0
+		conf.Memcached.TLS.Enabled = true
+
+		mCache, err := cache.NewMemcached(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		err = mCache.Set("testkey", []byte("testvalue"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to establish TLS connection")
+	})
+
+	template := `
+cache_resources:
+  - label: testcache
+    memcached:
+      addresses: [ localhost:$PORT ]
+      prefix: $ID
+      tls:
+        enabled: true
+        root_cas_file: $VAR1
+`
+	suite := integrationTests(
+		integrationTestOpenClose(),
+		integrationTestMissingKey(),
+		integrationTestDoubleAdd(),
+		integrationTestDelete(),
+		integrationTestGetAndSet(50),
+	)
+	suite.Run(
+		t, template,
+		testOptPort(resource.GetPort("11211/tcp")),
+		testOptVarOne(filepath.Join(certDir, "cert.pem")),
+	)
+})
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -60,3 +61,70 @@ cache_resources:
 		testOptPort(resource.GetPort("6379/tcp")),
 	)
 })
+
+var _ = registerIntegrationTest("redis_tls", func(t *testing.T) {
+	t.Parallel()
+
+	certDir := createTLSCertFiles(t)
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "latest",
+		Cmd: []string{
+			"redis-server",
+			"--port", "0",
+			"--tls-port", "6379",
+			"--tls-cert-file", "/certs/cert.pem",
+			"--tls-key-file", "/certs/key.pem",
+			"--tls-ca-cert-file", "/certs/cert.pem",
+			"--tls-auth-clients", "no",
+		},
+		Mounts: []string{certDir + ":/certs"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		conf := cache.NewConfig()
+		conf.Redis.URL = fmt.Sprintf("tcp://localhost:%v/1", resource.GetPort("6379/tcp"))
+		conf.Redis.TLS.Enabled = true
+		conf.Redis.TLS.RootCAsFile = filepath.Join(certDir, "cert.pem")
+
+		r, cErr := cache.NewRedis(conf, nil, log.Noop(), metrics.Noop())
+		if cErr != nil {
+			return cErr
+		}
+		return r.Set("benthos_test_redis_connect", []byte("foo bar"))
+	}))
+
+	template := `
+cache_resources:
+  - label: testcache
+    redis:
+      url: tcp://localhost:$PORT/1
+      prefix: $ID
+      tls:
+        enabled: true
+        root_cas_file: $VAR1
+`
+	suite := integrationTests(
+		integrationTestOpenClose(),
+		integrationTestMissingKey(),
+		integrationTestDoubleAdd(),
+		integrationTestDelete(),
+		integrationTestGetAndSet(50),
+	)
+	suite.Run(
+		t, template,
+		testOptPort(resource.GetPort("6379/tcp")),
+		testOptVarOne(filepath.Join(certDir, "cert.pem")),
+	)
+})
@@ -2,11 +2,14 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/pubsub"
 	"github.com/Jeffail/benthos/v3/internal/impl/gcp"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,4 +106,184 @@ input:
 			testOptVarTwo(dummyPathPrefix),
 		)
 	})
+
+	t.Run("gcs append", func(t *testing.T) {
+		template := `
+output:
+  gcp_cloud_storage:
+    bucket: $VAR1-$ID
+    path: $VAR2/appended.txt
+    collision_mode: append
+    max_in_flight: 1
+
+input:
+  gcp_cloud_storage:
+    bucket: $VAR1-$ID
+    prefix: $VAR2
+    codec: lines
+`
+		integrationTests(
+			namedTest("can append to objects", func(t *testing.T, env *testEnvironment) {
+				t.Parallel()
+
+				tranChan := make(chan types.Transaction)
+				output := initOutput(t, tranChan, env)
+				t.Cleanup(func() {
+					closeConnectors(t, nil, output)
+				})
+				for i := 0; i < 3; i++ {
+					require.NoError(t, sendMessage(env.ctx, t, tranChan, fmt.Sprintf("hello world %v\n", i)))
+				}
+
+				client, err := gcp.NewStorageClient(env.ctx)
+				require.NoError(t, err)
+				defer client.Close()
+
+				var names []string
+				it := client.Bucket(env.configVars.var1+"-"+env.configVars.id).Objects(env.ctx, nil)
+				for {
+					attrs, err := it.Next()
+					if err == iterator.Done {
+						break
+					}
+					require.NoError(t, err)
+					names = append(names, attrs.Name)
+				}
+				assert.Equal(t, []string{env.configVars.var2 + "/appended.txt"}, names)
+
+				input := initInput(t, env)
+				t.Cleanup(func() {
+					closeConnectors(t, input, nil)
+				})
+				for i := 0; i < 3; i++ {
+					messageMatch(t, receiveMessage(env.ctx, t, input.TransactionChan(), nil), fmt.Sprintf("hello world %v", i))
+				}
+			}),
+		).Run(
+			t, template,
+			testOptPreTest(func(t *testing.T, env *testEnvironment) {
+				require.NoError(t, createGCPCloudStorageBucket(env.configVars.var1, env.configVars.id))
+			}),
+			testOptVarOne(dummyBucketPrefix),
+			testOptVarTwo(dummyPathPrefix),
+		)
+	})
+
+	t.Run("gcs pubsub", func(t *testing.T) {
+		psResource, err := pool.RunWithOptions(&dockertest.RunOptions{
+			Repository:   "singularities/pubsub-emulator",
+			Tag:          "latest",
+			ExposedPorts: []string{"8432/tcp"},
+			Env: []string{
+				"PUBSUB_LISTEN_ADDRESS=0.0.0.0:8432",
+				"PUBSUB_PROJECT_ID=benthos-test-project",
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, pool.Purge(psResource))
+		})
+		psResource.Expire(900)
+
+		require.NoError(t, os.Setenv("PUBSUB_EMULATOR_HOST", fmt.Sprintf("localhost:%v", psResource.GetPort("8432/tcp"))))
+		require.NoError(t, pool.Retry(func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			client, err := pubsub.NewClient(ctx, "benthos-test-project")
+			if err != nil {
+				return err
+			}
+			_, err = client.CreateTopic(ctx, "test-probe-topic-name")
+			client.Close()
+			return err
+		}))
+
+		template := `
+output:
+  gcp_cloud_storage:
+    bucket: $VAR1-$ID
+    path: $VAR2/${!count("$ID")}.txt
+    max_in_flight: 1
+
+input:
+  gcp_cloud_storage:
+    prefix: $VAR2
+    pubsub:
+      project: benthos-test-project
+      subscription: sub-$ID
+`
+		integrationTests(
+			namedTest("can consume object notifications", func(t *testing.T, env *testEnvironment) {
+				t.Parallel()
+
+				tranChan := make(chan types.Transaction)
+				output := initOutput(t, tranChan, env)
+				t.Cleanup(func() {
+					closeConnectors(t, nil, output)
+				})
+				require.NoError(t, sendMessage(env.ctx, t, tranChan, "hello world 1"))
+				require.NoError(t, sendMessage(env.ctx, t, tranChan, "hello world 2"))
+
+				client, err := pubsub.NewClient(env.ctx, "benthos-test-project")
+				require.NoError(t, err)
+				defer client.Close()
+
+				bucket := env.configVars.var1 + "-" + env.configVars.id
+				topic := client.Topic(fmt.Sprintf("topic-%v", env.configVars.id))
+				defer topic.Stop()
+				for _, n := range []struct {
+					event, key string
+				}{
+					{"OBJECT_DELETE", env.configVars.var2 + "/1.txt"},
+					{"OBJECT_FINALIZE", "other/1.txt"},
+					{"OBJECT_FINALIZE", env.configVars.var2 + "/1.txt"},
+					{"OBJECT_FINALIZE", env.configVars.var2 + "/2.txt"},
+				} {
+					_, err = topic.Publish(env.ctx, &pubsub.Message{
+						Data: []byte(`{}`),
+						Attributes: map[string]string{
+							"eventType":     n.event,
+							"bucketId":      bucket,
+							"objectId":      n.key,
+							"payloadFormat": "JSON_API_V1",
+						},
+					}).Get(env.ctx)
+					require.NoError(t, err)
+				}
+
+				input := initInput(t, env)
+				t.Cleanup(func() {
+					closeConnectors(t, input, nil)
+				})
+
+				set := map[string][]string{
+					"hello world 1": nil,
+					"hello world 2": nil,
+				}
+				for len(set) > 0 {
+					messageInSet(t, true, false, receiveMessage(env.ctx, t, input.TransactionChan(), nil), set)
+				}
+			}),
+		).Run(
+			t, template,
+			testOptPreTest(func(t *testing.T, env *testEnvironment) {
+				require.NoError(t, createGCPCloudStorageBucket(env.configVars.var1, env.configVars.id))
+
+				client, err := pubsub.NewClient(env.ctx, "benthos-test-project")
+				require.NoError(t, err)
+				defer client.Close()
+
+				topic, err := client.CreateTopic(env.ctx, fmt.Sprintf("topic-%v", env.configVars.id))
+				require.NoError(t, err)
+
+				_, err = client.CreateSubscription(env.ctx, fmt.Sprintf("sub-%v", env.configVars.id), pubsub.SubscriptionConfig{
+					AckDeadline: time.Second * 10,
+					Topic:       topic,
+				})
+				require.NoError(t, err)
+			}),
+			testOptVarOne(dummyBucketPrefix),
+			testOptVarTwo(dummyPathPrefix),
+		)
+	})
 })
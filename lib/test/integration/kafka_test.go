@@ -150,6 +150,27 @@ input:
 			)
 		})
 
+		t.Run("static membership", func(t *testing.T) {
+			t.Parallel()
+			// Restarting a static member within the session timeout reclaims
+			// its partitions, and committed offsets must not be re-consumed.
+			staticTemplate := template + `    target_version: 2.3.0
+    group:
+      instance_id: ${! hostname() }-$ID
+      rebalance_strategy: sticky
+`
+			suiteSingleCheckpointedStream.Run(
+				t, staticTemplate,
+				testOptPreTest(func(t *testing.T, env *testEnvironment) {
+					env.configVars.var4 = "group" + env.configVars.id
+					require.NoError(t, createKafkaTopic("localhost:"+kafkaPortStr, env.configVars.id, 1))
+				}),
+				testOptPort(kafkaPortStr),
+				testOptVarTwo("1000"),
+				testOptVarThree("false"),
+			)
+		})
+
 		t.Run("retry as batch", func(t *testing.T) {
 			t.Parallel()
 			suite.Run(
@@ -1,8 +1,11 @@
 package types
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 //------------------------------------------------------------------------------
@@ -110,11 +113,26 @@ var (
 type ErrUnexpectedHTTPRes struct {
 	Code int
 	S    string
+
+	// Body optionally contains the leading bytes of the body of the response.
+	Body []byte
 }
 
 // Error returns the Error string.
 func (e ErrUnexpectedHTTPRes) Error() string {
+	if body := strings.TrimSpace(e.BodyString()); body != "" {
+		return fmt.Sprintf("HTTP request returned unexpected response code (%v): %v: %v", e.Code, e.S, body)
+	}
 	return fmt.Sprintf("HTTP request returned unexpected response code (%v): %v", e.Code, e.S)
 }
 
+// BodyString returns the captured body of the response as a string, where
+// bodies that are not valid UTF-8 are base64 encoded.
+func (e ErrUnexpectedHTTPRes) BodyString() string {
+	if utf8.Valid(e.Body) {
+		return string(e.Body)
+	}
+	return base64.StdEncoding.EncodeToString(e.Body)
+}
+
 //------------------------------------------------------------------------------
@@ -13,3 +13,21 @@ func TestHTTPError(t *testing.T) {
 		t.Errorf("Wrong Error() from ErrUnexpectedHTTPRes: %v != %v", exp, act)
 	}
 }
+
+func TestHTTPErrorBody(t *testing.T) {
+	err := ErrUnexpectedHTTPRes{
+		Code: 400,
+		S:    "400 Bad Request",
+		Body: []byte(`{"error":"missing field foo"}`),
+	}
+
+	exp, act := `HTTP request returned unexpected response code (400): 400 Bad Request: {"error":"missing field foo"}`, err.Error()
+	if exp != act {
+		t.Errorf("Wrong Error() from ErrUnexpectedHTTPRes: %v != %v", exp, act)
+	}
+
+	err.Body = []byte{0xff, 0xfe, 0x00}
+	if exp, act := "//4A", err.BodyString(); exp != act {
+		t.Errorf("Wrong BodyString() from ErrUnexpectedHTTPRes: %v != %v", exp, act)
+	}
+}
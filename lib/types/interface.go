@@ -103,6 +103,19 @@ type Processor interface {
 	Closable
 }
 
+// ProcessorWithContext is an optional interface implemented by processors that
+// are able to abandon processing when a context is cancelled, which happens
+// when the pipeline executing the processor is shutting down or the deadline
+// of the message being processed has passed. Processors that do not implement
+// this interface are called with ProcessMessage instead.
+//
+// When the context is cancelled before processing has completed the processor
+// should return a response with the error of the context, which results in the
+// message being rejected by the pipeline so that it can be redelivered.
+type ProcessorWithContext interface {
+	ProcessMessageWithContext(ctx context.Context, msg Message) ([]Message, Response)
+}
+
 //------------------------------------------------------------------------------
 
 // Manager is an interface expected by Benthos components that allows them to
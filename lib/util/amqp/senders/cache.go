@@ -0,0 +1,128 @@
+package senders
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+)
+
+// Sender is the subset of an AMQP 1.0 sender link used by outputs.
+type Sender interface {
+	Send(ctx context.Context, msg *amqp.Message) error
+	Close(ctx context.Context) error
+}
+
+// Entry is a cached sender link of a target address.
+type Entry struct {
+	Address string
+	Sender  Sender
+
+	refs    int
+	evicted bool
+}
+
+// Cache caches a sender link for each distinct target address. Once the cache
+// exceeds its capacity the least recently used link is closed, which is
+// deferred until any sends that are in progress on the link are released.
+type Cache struct {
+	capacity int
+	newFn    func(ctx context.Context, address string) (Sender, error)
+	log      log.Modular
+
+	mut     sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// NewCache creates a cache of sender links with a capacity, where a capacity
+// of zero or less is unbounded.
+func NewCache(
+	capacity int,
+	newFn func(ctx context.Context, address string) (Sender, error),
+	log log.Modular,
+) *Cache {
+	return &Cache{
+		capacity: capacity,
+		newFn:    newFn,
+		log:      log,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// Acquire returns the sender of an address, creating it if it does not yet
+// exist. The returned entry must be released once the send is complete.
+func (c *Cache) Acquire(ctx context.Context, address string) (*Entry, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if e, exists := c.entries[address]; exists {
+		c.lru.MoveToFront(e)
+		entry := e.Value.(*Entry)
+		entry.refs++
+		return entry, nil
+	}
+
+	sender, err := c.newFn(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{Address: address, Sender: sender, refs: 1}
+	c.entries[address] = c.lru.PushFront(entry)
+
+	for c.capacity > 0 && c.lru.Len() > c.capacity {
+		c.evict(ctx, c.lru.Back())
+	}
+	return entry, nil
+}
+
+// Release marks a send on the sender of an entry as complete.
+func (c *Cache) Release(ctx context.Context, entry *Entry) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		c.closeSender(ctx, entry)
+	}
+}
+
+// Remove closes and removes the sender of an entry, which is used when a send
+// fails and the link can no longer be trusted.
+func (c *Cache) Remove(ctx context.Context, entry *Entry) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if e, exists := c.entries[entry.Address]; exists && e.Value == entry {
+		c.evict(ctx, e)
+	}
+}
+
+func (c *Cache) evict(ctx context.Context, e *list.Element) {
+	entry := e.Value.(*Entry)
+	c.lru.Remove(e)
+	delete(c.entries, entry.Address)
+	entry.evicted = true
+	if entry.refs == 0 {
+		c.closeSender(ctx, entry)
+	}
+}
+
+func (c *Cache) closeSender(ctx context.Context, entry *Entry) {
+	if err := entry.Sender.Close(ctx); err != nil {
+		c.log.Errorf("Failed to cleanly close sender for target '%v': %v\n", entry.Address, err)
+	}
+}
+
+// CloseAll closes every sender within the cache.
+func (c *Cache) CloseAll(ctx context.Context) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for e := c.lru.Front(); e != nil; e = c.lru.Front() {
+		c.evict(ctx, e)
+	}
+}
@@ -0,0 +1,83 @@
+package senders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSender struct {
+	address string
+	closed  *[]string
+}
+
+func (m *mockSender) Send(ctx context.Context, msg *amqp.Message) error {
+	return nil
+}
+
+func (m *mockSender) Close(ctx context.Context) error {
+	*m.closed = append(*m.closed, m.address)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	var created, closed []string
+	c := NewCache(2, func(ctx context.Context, address string) (Sender, error) {
+		if address == "bad" {
+			return nil, errors.New("nope")
+		}
+		created = append(created, address)
+		return &mockSender{address: address, closed: &closed}, nil
+	}, log.Noop())
+
+	for _, addr := range []string{"a", "b", "a"} {
+		e, err := c.Acquire(ctx, addr)
+		require.NoError(t, err)
+		c.Release(ctx, e)
+	}
+	assert.Equal(t, []string{"a", "b"}, created)
+	assert.Empty(t, closed)
+
+	// Adding c evicts b as the least recently used, but a send that is in
+	// progress on b delays closing it.
+	eB, err := c.Acquire(ctx, "b")
+	require.NoError(t, err)
+	eA, err := c.Acquire(ctx, "a")
+	require.NoError(t, err)
+	c.Release(ctx, eA)
+
+	eC, err := c.Acquire(ctx, "c")
+	require.NoError(t, err)
+	c.Release(ctx, eC)
+	assert.Empty(t, closed)
+
+	c.Release(ctx, eB)
+	assert.Equal(t, []string{"b"}, closed)
+
+	_, err = c.Acquire(ctx, "bad")
+	assert.EqualError(t, err, "nope")
+
+	eA, err = c.Acquire(ctx, "a")
+	require.NoError(t, err)
+	c.Release(ctx, eA)
+	c.Remove(ctx, eA)
+	assert.Equal(t, []string{"b", "a"}, closed)
+
+	// Removing a stale entry has no effect on its replacement.
+	eA2, err := c.Acquire(ctx, "a")
+	require.NoError(t, err)
+	c.Release(ctx, eA2)
+	c.Remove(ctx, eA)
+	assert.Equal(t, []string{"b", "a"}, closed)
+
+	c.CloseAll(ctx)
+	assert.ElementsMatch(t, []string{"b", "a", "c", "a"}, closed)
+	assert.Equal(t, []string{"a", "b", "c", "a"}, created)
+}
@@ -0,0 +1,138 @@
+package instrument
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// HandlerName is the name of the handler added to AWS clients by Register.
+const HandlerName = "benthos.instrument"
+
+type operationStats struct {
+	latency   metrics.StatTimer
+	errors    metrics.StatCounterVec
+	throttled metrics.StatCounterVec
+}
+
+type recorder struct {
+	stats metrics.Type
+
+	mut        sync.RWMutex
+	operations map[string]*operationStats
+}
+
+// Register adds a handler to the request handlers of an AWS client that
+// records the following metrics for each attempt at an API call, where the
+// service and operation names are converted to snake case:
+//
+//   - aws.<service>.<operation>.latency: A timer of the duration of the attempt.
+//   - aws.<service>.<operation>.error: A counter of failed attempts labelled by
+//     the AWS error code.
+//   - aws.<service>.<operation>.throttled: A counter of failed attempts that were
+//     throttled by AWS labelled by the AWS error code, these are also counted as
+//     errors.
+//
+// Handlers are copied into clients when they are created, and therefore this
+// should be called with the handlers of the client rather than the session,
+// which is shared between components.
+func Register(handlers *request.Handlers, stats metrics.Type) {
+	r := &recorder{
+		stats:      stats,
+		operations: map[string]*operationStats{},
+	}
+	handlers.CompleteAttempt.PushBackNamed(request.NamedHandler{
+		Name: HandlerName,
+		Fn:   r.record,
+	})
+}
+
+func (r *recorder) getOperation(service, operation string) *operationStats {
+	key := service + "." + operation
+
+	r.mut.RLock()
+	op, exists := r.operations[key]
+	r.mut.RUnlock()
+	if exists {
+		return op
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if op, exists = r.operations[key]; exists {
+		return op
+	}
+	path := "aws." + snakeCase(service) + "." + snakeCase(operation)
+	op = &operationStats{
+		latency:   r.stats.GetTimer(path + ".latency"),
+		errors:    r.stats.GetCounterVec(path+".error", []string{"code"}),
+		throttled: r.stats.GetCounterVec(path+".throttled", []string{"code"}),
+	}
+	r.operations[key] = op
+	return op
+}
+
+func (r *recorder) record(req *request.Request) {
+	if req.Operation == nil {
+		return
+	}
+
+	op := r.getOperation(req.ClientInfo.ServiceName, req.Operation.Name)
+	op.latency.Timing(time.Since(req.AttemptTime).Nanoseconds())
+	if req.Error == nil {
+		return
+	}
+
+	code := errorCode(req)
+	op.errors.With(code).Incr(1)
+	if isThrottled(req) {
+		op.throttled.With(code).Incr(1)
+	}
+}
+
+// errorCode returns the AWS error code of a failed request, or the HTTP status
+// text when the error has no code.
+func errorCode(req *request.Request) string {
+	if aErr, ok := req.Error.(awserr.Error); ok && aErr.Code() != "" {
+		return aErr.Code()
+	}
+	if req.HTTPResponse != nil && req.HTTPResponse.StatusCode >= 400 {
+		return snakeCase(strings.ReplaceAll(http.StatusText(req.HTTPResponse.StatusCode), " ", ""))
+	}
+	return "unknown"
+}
+
+// isThrottled returns whether a failed request was rejected due to exceeding
+// a rate limit or provisioned capacity. Unlike the retry logic of the SDK this
+// excludes server errors, which aren't useful for capacity planning.
+func isThrottled(req *request.Request) bool {
+	if request.IsErrorThrottle(req.Error) {
+		return true
+	}
+	return req.HTTPResponse != nil && req.HTTPResponse.StatusCode == http.StatusTooManyRequests
+}
+
+// snakeCase converts names such as PutObject or SendMessageBatch into
+// put_object and send_message_batch. Consecutive upper case letters are treated
+// as a single word, such that GetQueueURL becomes get_queue_url.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package instrument
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"PutObject":        "put_object",
+		"SendMessageBatch": "send_message_batch",
+		"GetQueueURL":      "get_queue_url",
+		"ListObjectsV2":    "list_objects_v2",
+		"sqs":              "sqs",
+	}
+	for input, exp := range tests {
+		assert.Equal(t, exp, snakeCase(input), input)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var status int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(status)
+		switch status {
+		case http.StatusOK:
+			w.Write([]byte(`<DeleteMessageResponse><ResponseMetadata><RequestId>foo</RequestId></ResponseMetadata></DeleteMessageResponse>`))
+		case http.StatusBadRequest:
+			w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>ThrottlingException</Code><Message>slow down</Message></Error><RequestId>foo</RequestId></ErrorResponse>`))
+		default:
+			w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>ReceiptHandleIsInvalid</Code><Message>nope</Message></Error><RequestId>foo</RequestId></ErrorResponse>`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithRegion("eu-west-1").
+		WithEndpoint(ts.URL).
+		WithMaxRetries(0).
+		WithCredentials(credentials.NewStaticCredentials("foo", "bar", "")))
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	client := sqs.New(sess)
+	Register(&client.Handlers, stats)
+
+	input := &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(ts.URL + "/queue"),
+		ReceiptHandle: aws.String("foo"),
+	}
+
+	status = http.StatusOK
+	_, err = client.DeleteMessage(input)
+	require.NoError(t, err)
+
+	status = http.StatusBadRequest
+	_, err = client.DeleteMessage(input)
+	require.Error(t, err)
+
+	status = http.StatusNotFound
+	_, err = client.DeleteMessage(input)
+	require.Error(t, err)
+
+	timings := stats.GetTimings()
+	assert.Contains(t, timings, "aws.sqs.delete_message.latency")
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(2), counters["aws.sqs.delete_message.error"])
+	assert.Equal(t, int64(1), counters["aws.sqs.delete_message.throttled"])
+
+	labelled := stats.GetCountersWithLabels()
+	errStat := labelled["aws.sqs.delete_message.error"]
+	assert.True(t, errStat.HasLabelWithValue("code", "ReceiptHandleIsInvalid"))
+	throttledStat := labelled["aws.sqs.delete_message.throttled"]
+	assert.True(t, throttledStat.HasLabelWithValue("code", "ThrottlingException"))
+}
@@ -0,0 +1,2 @@
+// Package instrument records metrics for the requests made by AWS clients.
+package instrument
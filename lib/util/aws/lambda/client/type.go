@@ -94,7 +94,7 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 	l.mLimitErr = l.stats.GetCounter("rate_limit.error")
 	l.mLatency = l.stats.GetTimer("latency")
 
-	sess, err := l.conf.GetSession()
+	sess, err := l.conf.GetSessionWithFailover(session.LogFailover(l.log, l.stats.GetCounter("failover")))
 	if err != nil {
 		return nil, err
 	}
@@ -4,16 +4,46 @@ import "github.com/Jeffail/benthos/v3/internal/docs"
 
 // FieldSpecs returns documentation specs for AWS session fields.
 func FieldSpecs() docs.FieldSpecs {
+	return SigningFieldSpecs().Add(
+		docs.FieldAdvanced("failover_regions", "An optional list of regions to fail over to, in order, when requests to the current region repeatedly fail with connection or server errors. Each region can specify its own endpoint and credentials, otherwise the credentials of the primary region are used.").Array().WithChildren(
+			docs.FieldString("region", "The AWS region to fail over to.", "us-west-2").HasDefault(""),
+			docs.FieldString("endpoint", "An optional custom endpoint to use for the region.").HasDefault(""),
+			docs.FieldAdvanced("credentials", "Optional manual configuration of AWS credentials to use for the region.").WithChildren(failoverCredentialsFieldSpecs()...),
+		).AtVersion("3.50.0"),
+		docs.FieldAdvanced("failover_threshold", "The number of consecutive failed requests after which the next failover region is targeted.").AtVersion("3.50.0"),
+		docs.FieldAdvanced("failback_interval", "The period after failing over at which a request is routed to the primary region as a probe, where a successful probe fails back to the primary region. Set to an empty string in order to disable failing back.").AtVersion("3.50.0"),
+	)
+}
+
+// SigningFieldSpecs returns documentation specs for AWS session fields used
+// only for obtaining credentials in order to sign requests, where requests are
+// not made with an AWS SDK client and therefore cannot fail over to other
+// regions.
+func SigningFieldSpecs() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldCommon("region", "The AWS region to target."),
 		docs.FieldAdvanced("endpoint", "Allows you to specify a custom endpoint for the AWS API."),
-		docs.FieldAdvanced("credentials", "Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/aws).").WithChildren(
-			docs.FieldAdvanced("profile", "A profile from `~/.aws/credentials` to use."),
-			docs.FieldAdvanced("id", "The ID of credentials to use."),
-			docs.FieldAdvanced("secret", "The secret for the credentials being used."),
-			docs.FieldAdvanced("token", "The token for the credentials being used, required when using short term credentials."),
-			docs.FieldAdvanced("role", "A role ARN to assume."),
-			docs.FieldAdvanced("role_external_id", "An external ID to provide when assuming a role."),
-		),
+		docs.FieldAdvanced("credentials", "Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/aws).").WithChildren(credentialsFieldSpecs()...),
+	}
+}
+
+func credentialsFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldAdvanced("profile", "A profile from `~/.aws/credentials` to use."),
+		docs.FieldAdvanced("id", "The ID of credentials to use."),
+		docs.FieldAdvanced("secret", "The secret for the credentials being used."),
+		docs.FieldAdvanced("token", "The token for the credentials being used, required when using short term credentials."),
+		docs.FieldAdvanced("role", "A role ARN to assume."),
+		docs.FieldAdvanced("role_external_id", "An external ID to provide when assuming a role."),
+	}
+}
+
+// Fields of array elements cannot be inferred from a config struct and must
+// therefore declare their types and defaults explicitly.
+func failoverCredentialsFieldSpecs() docs.FieldSpecs {
+	specs := credentialsFieldSpecs()
+	for i := range specs {
+		specs[i] = specs[i].HasType(docs.FieldTypeString).HasDefault("")
 	}
+	return specs
 }
@@ -0,0 +1,221 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// FailoverFunc is called whenever a session changes the region that requests
+// are routed to. The error that triggered the change is provided when failing
+// over, and is nil when failing back to the primary region.
+type FailoverFunc func(from, to string, err error)
+
+// LogFailover returns a FailoverFunc that logs each change of region and
+// increments a counter.
+func LogFailover(logger log.Modular, counter interface{ Incr(count int64) error }) FailoverFunc {
+	return func(from, to string, err error) {
+		counter.Incr(1)
+		if err != nil {
+			logger.Warnf("Failing over from region %v to %v due to error: %v\n", from, to, err)
+		} else {
+			logger.Infof("Failing back from region %v to primary region %v\n", from, to)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type failoverTarget struct {
+	region   string
+	endpoint string
+	creds    *credentials.Credentials
+}
+
+// apply routes a request to the target by rewriting the endpoint, signing
+// region and credentials that were resolved when the client was created.
+func (t failoverTarget) apply(r *request.Request) {
+	endpoint := t.endpoint
+	if endpoint == "" {
+		resolved, err := endpoints.DefaultResolver().EndpointFor(r.ClientInfo.ServiceName, t.region)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		endpoint = resolved.URL
+	}
+	endpoint = endpoints.AddScheme(endpoint, aws.BoolValue(r.Config.DisableSSL))
+
+	u, err := r.HTTPRequest.URL.Parse(endpoint)
+	if err != nil {
+		r.Error = awserr.New("InvalidEndpointURL", "invalid failover endpoint uri", err)
+		return
+	}
+	r.HTTPRequest.URL.Scheme = u.Scheme
+	r.HTTPRequest.URL.Host = u.Host
+
+	r.ClientInfo.Endpoint = endpoint
+	r.ClientInfo.SigningRegion = t.region
+	r.Config.Region = aws.String(t.region)
+	if t.creds != nil {
+		r.Config.Credentials = t.creds
+	}
+}
+
+// failover tracks the health of the region currently targeted by the requests
+// of a session, switching to the next region after a threshold of consecutive
+// failures, and periodically probing the primary region in order to fail back.
+type failover struct {
+	targets   []failoverTarget
+	threshold int
+	failback  time.Duration
+	onSwitch  FailoverFunc
+
+	mut       sync.Mutex
+	current   int
+	failures  int
+	lastProbe time.Time
+}
+
+func (c Config) newFailover(fn FailoverFunc, opts ...func(*aws.Config)) (*failover, error) {
+	if c.Region == "" {
+		return nil, errors.New("a primary region must be specified in order to use failover regions")
+	}
+	if c.FailoverThreshold <= 0 {
+		return nil, fmt.Errorf("failover threshold must be greater than zero, got %v", c.FailoverThreshold)
+	}
+
+	f := &failover{
+		targets: []failoverTarget{
+			{region: c.Region},
+		},
+		threshold: c.FailoverThreshold,
+		onSwitch:  fn,
+	}
+	if c.FailbackInterval != "" {
+		var err error
+		if f.failback, err = time.ParseDuration(c.FailbackInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse failback interval: %v", err)
+		}
+	}
+
+	seen := map[string]struct{}{c.Region: {}}
+	for i, rConf := range c.FailoverRegions {
+		if rConf.Region == "" {
+			return nil, fmt.Errorf("failover region %v: a region must be specified", i)
+		}
+		if _, exists := seen[rConf.Region]; exists {
+			return nil, fmt.Errorf("failover region %v: region %v is specified more than once", i, rConf.Region)
+		}
+		seen[rConf.Region] = struct{}{}
+
+		regionConf := Config{
+			Credentials: rConf.Credentials,
+			Endpoint:    rConf.Endpoint,
+			Region:      rConf.Region,
+		}
+		if regionConf.Credentials == (CredentialsConfig{}) {
+			regionConf.Credentials = c.Credentials
+		}
+		regionSess, err := regionConf.newSession(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failover region %v: %w", i, err)
+		}
+		f.targets = append(f.targets, failoverTarget{
+			region:   rConf.Region,
+			endpoint: rConf.Endpoint,
+			creds:    regionSess.Config.Credentials,
+		})
+	}
+	return f, nil
+}
+
+func (f *failover) register(h *request.Handlers) {
+	h.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "benthos.failover.Route",
+		Fn:   f.route,
+	})
+	h.Complete.PushBackNamed(request.NamedHandler{
+		Name: "benthos.failover.Complete",
+		Fn:   f.complete,
+	})
+}
+
+// route directs a request to the current target, or to the primary region when
+// it is due to be probed.
+func (f *failover) route(r *request.Request) {
+	f.mut.Lock()
+	i := f.current
+	if i != 0 && f.failback > 0 && time.Since(f.lastProbe) >= f.failback {
+		f.lastProbe = time.Now()
+		i = 0
+	}
+	f.mut.Unlock()
+
+	if i != 0 {
+		f.targets[i].apply(r)
+	}
+}
+
+func (f *failover) targetIndex(region string) int {
+	for i, t := range f.targets {
+		if t.region == region {
+			return i
+		}
+	}
+	return 0
+}
+
+// isFailoverError returns whether the error of a request indicates that the
+// region it targeted is unavailable, which is the case for connection errors
+// and server errors.
+func isFailoverError(r *request.Request) bool {
+	if r.Error == nil {
+		return false
+	}
+	if aerr, ok := r.Error.(awserr.Error); ok && aerr.Code() == request.CanceledErrorCode {
+		return false
+	}
+	return r.HTTPResponse == nil || r.HTTPResponse.StatusCode == 0 || r.HTTPResponse.StatusCode >= 500
+}
+
+// complete records the outcome of a request once all of its attempts have
+// finished.
+func (f *failover) complete(r *request.Request) {
+	i := f.targetIndex(aws.StringValue(r.Config.Region))
+	failed := isFailoverError(r)
+
+	f.mut.Lock()
+	from := f.current
+	var switched bool
+	var reason error
+	if i != f.current {
+		// Only a successful probe of the primary region results in a change,
+		// the outcomes of requests routed to previous targets are ignored.
+		if i == 0 && !failed {
+			f.current, f.failures = 0, 0
+			switched = true
+		}
+	} else if !failed {
+		f.failures = 0
+	} else if f.failures++; f.failures >= f.threshold {
+		f.current = (f.current + 1) % len(f.targets)
+		f.failures = 0
+		f.lastProbe = time.Now()
+		switched, reason = true, r.Error
+	}
+	to := f.current
+	f.mut.Unlock()
+
+	if switched && f.onSwitch != nil {
+		f.onSwitch(f.targets[from].region, f.targets[to].region, reason)
+	}
+}
@@ -0,0 +1,207 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testQueueURLResponse = `<GetQueueUrlResponse><GetQueueUrlResult><QueueUrl>foo</QueueUrl></GetQueueUrlResult></GetQueueUrlResponse>`
+
+type regionServer struct {
+	*httptest.Server
+	healthy int32
+	hits    int32
+
+	mut       sync.Mutex
+	lastAuths []string
+}
+
+func newRegionServer(t *testing.T, healthy bool) *regionServer {
+	t.Helper()
+
+	s := &regionServer{}
+	if healthy {
+		s.healthy = 1
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.hits, 1)
+		s.mut.Lock()
+		s.lastAuths = append(s.lastAuths, r.Header.Get("Authorization"))
+		s.mut.Unlock()
+		if atomic.LoadInt32(&s.healthy) == 0 {
+			http.Error(w, "nope", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testQueueURLResponse))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+type switchEvent struct {
+	from, to string
+	failed   bool
+}
+
+func failoverTestClient(t *testing.T, conf Config) (*sqs.SQS, func() []switchEvent) {
+	t.Helper()
+
+	var eventsMut sync.Mutex
+	var events []switchEvent
+	sess, err := conf.GetSessionWithFailover(func(from, to string, err error) {
+		eventsMut.Lock()
+		events = append(events, switchEvent{from: from, to: to, failed: err != nil})
+		eventsMut.Unlock()
+	}, func(c *aws.Config) {
+		c.MaxRetries = aws.Int(0)
+	})
+	require.NoError(t, err)
+
+	return sqs.New(sess), func() []switchEvent {
+		eventsMut.Lock()
+		defer eventsMut.Unlock()
+		return append([]switchEvent{}, events...)
+	}
+}
+
+func TestFailoverSwitchesRegion(t *testing.T) {
+	primary := newRegionServer(t, false)
+	secondary := newRegionServer(t, true)
+
+	conf := NewConfig()
+	conf.Region = "eu-west-1"
+	conf.Endpoint = primary.URL
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	conf.FailoverThreshold = 2
+	conf.FailbackInterval = ""
+	conf.FailoverRegions = []FailoverRegionConfig{
+		{Region: "us-west-2", Endpoint: secondary.URL},
+	}
+
+	client, events := failoverTestClient(t, conf)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+		require.Error(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&primary.hits))
+	assert.Equal(t, []switchEvent{{from: "eu-west-1", to: "us-west-2", failed: true}}, events())
+
+	res, err := client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", aws.StringValue(res.QueueUrl))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&primary.hits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&secondary.hits))
+
+	secondary.mut.Lock()
+	assert.Contains(t, secondary.lastAuths[0], "/us-west-2/sqs/")
+	secondary.mut.Unlock()
+}
+
+func TestFailoverFailsBack(t *testing.T) {
+	primary := newRegionServer(t, false)
+	secondary := newRegionServer(t, true)
+
+	conf := NewConfig()
+	conf.Region = "eu-west-1"
+	conf.Endpoint = primary.URL
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	conf.FailoverThreshold = 1
+	conf.FailbackInterval = "50ms"
+	conf.FailoverRegions = []FailoverRegionConfig{
+		{Region: "us-west-2", Endpoint: secondary.URL},
+	}
+
+	client, events := failoverTestClient(t, conf)
+
+	_, err := client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.Error(t, err)
+
+	_, err = client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&secondary.hits))
+
+	// A failed probe of the primary region keeps the secondary region.
+	<-time.After(time.Millisecond * 60)
+	_, err = client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&primary.hits))
+	assert.Len(t, events(), 1)
+
+	_, err = client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&secondary.hits))
+
+	atomic.StoreInt32(&primary.healthy, 1)
+	<-time.After(time.Millisecond * 60)
+	_, err = client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&primary.hits))
+
+	_, err = client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&primary.hits))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&secondary.hits))
+
+	assert.Equal(t, []switchEvent{
+		{from: "eu-west-1", to: "us-west-2", failed: true},
+		{from: "us-west-2", to: "eu-west-1", failed: false},
+	}, events())
+}
+
+func TestFailoverIgnoresClientErrors(t *testing.T) {
+	var hits int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	t.Cleanup(primary.Close)
+
+	conf := NewConfig()
+	conf.Region = "eu-west-1"
+	conf.Endpoint = primary.URL
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	conf.FailoverThreshold = 1
+	conf.FailoverRegions = []FailoverRegionConfig{
+		{Region: "us-west-2", Endpoint: "http://localhost:1"},
+	}
+
+	client, events := failoverTestClient(t, conf)
+	for i := 0; i < 3; i++ {
+		_, err := client.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String("foo")})
+		require.Error(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	assert.Empty(t, events())
+}
+
+func TestFailoverConfigErrors(t *testing.T) {
+	for _, fn := range []func(c *Config){
+		func(c *Config) { c.Region = "" },
+		func(c *Config) { c.FailoverThreshold = 0 },
+		func(c *Config) { c.FailbackInterval = "nope" },
+		func(c *Config) { c.FailoverRegions = append(c.FailoverRegions, FailoverRegionConfig{}) },
+		func(c *Config) { c.FailoverRegions[0].Region = c.Region },
+	} {
+		conf := NewConfig()
+		conf.FailoverRegions = []FailoverRegionConfig{{Region: "us-west-2"}}
+		fn(&conf)
+
+		_, err := conf.GetSession()
+		require.Error(t, err)
+		assert.False(t, strings.Contains(err.Error(), "%!"), err.Error())
+	}
+}
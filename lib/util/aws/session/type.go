@@ -1,6 +1,8 @@
 package session
 
 import (
+	"sync"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -19,12 +21,23 @@ type CredentialsConfig struct {
 	ExternalID string `json:"role_external_id" yaml:"role_external_id"`
 }
 
+// FailoverRegionConfig contains configuration fields for a region that a
+// session can fail over to.
+type FailoverRegionConfig struct {
+	Region      string            `json:"region" yaml:"region"`
+	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
+	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
+}
+
 // Config contains configuration fields for an AWS session. This config is
 // common across any AWS components.
 type Config struct {
-	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
-	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
-	Region      string            `json:"region" yaml:"region"`
+	Credentials       CredentialsConfig      `json:"credentials" yaml:"credentials"`
+	Endpoint          string                 `json:"endpoint" yaml:"endpoint"`
+	Region            string                 `json:"region" yaml:"region"`
+	FailoverRegions   []FailoverRegionConfig `json:"failover_regions" yaml:"failover_regions"`
+	FailoverThreshold int                    `json:"failover_threshold" yaml:"failover_threshold"`
+	FailbackInterval  string                 `json:"failback_interval" yaml:"failback_interval"`
 }
 
 // NewConfig returns a Config with default values.
@@ -38,15 +51,117 @@ func NewConfig() Config {
 			Role:       "",
 			ExternalID: "",
 		},
-		Endpoint: "",
-		Region:   "eu-west-1", // TODO: V4 empty by default
+		Endpoint:          "",
+		Region:            "eu-west-1", // TODO: V4 empty by default
+		FailoverRegions:   []FailoverRegionConfig{},
+		FailoverThreshold: 5,
+		FailbackInterval:  "5m",
 	}
 }
 
+// SigningConfig contains configuration fields for an AWS session that is only
+// used for obtaining credentials in order to sign requests, which are not made
+// with an AWS SDK client and therefore cannot fail over to other regions.
+type SigningConfig struct {
+	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
+	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
+	Region      string            `json:"region" yaml:"region"`
+}
+
+// NewSigningConfig returns a SigningConfig with default values.
+func NewSigningConfig() SigningConfig {
+	conf := NewConfig()
+	return SigningConfig{
+		Credentials: conf.Credentials,
+		Endpoint:    conf.Endpoint,
+		Region:      conf.Region,
+	}
+}
+
+// GetSession attempts to create an AWS session based on SigningConfig. The
+// session returned may be shared with other callers and must therefore not be
+// modified.
+func (c SigningConfig) GetSession() (*session.Session, error) {
+	return Config{
+		Credentials: c.Credentials,
+		Endpoint:    c.Endpoint,
+		Region:      c.Region,
+	}.GetSession()
+}
+
 //------------------------------------------------------------------------------
 
-// GetSession attempts to create an AWS session based on Config.
+// Sessions created without custom options are cached for the lifetime of the
+// process and shared between components with matching configuration, which
+// allows them to reuse credential caches and avoids repeatedly assuming roles.
+var (
+	sharedSessions    = map[sessionKey]*session.Session{}
+	sharedSessionsMut sync.Mutex
+)
+
+type sessionKey struct {
+	credentials CredentialsConfig
+	endpoint    string
+	region      string
+}
+
+// GetSession attempts to create an AWS session based on Config. When no
+// options are provided the session returned may be shared with other callers
+// and must therefore not be modified.
+//
+// Sessions with failover regions configured are never shared.
 func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error) {
+	if len(c.FailoverRegions) > 0 {
+		return c.GetSessionWithFailover(nil, opts...)
+	}
+	if len(opts) > 0 {
+		return c.newSession(opts...)
+	}
+
+	key := sessionKey{
+		credentials: c.Credentials,
+		endpoint:    c.Endpoint,
+		region:      c.Region,
+	}
+
+	sharedSessionsMut.Lock()
+	defer sharedSessionsMut.Unlock()
+
+	if sess, exists := sharedSessions[key]; exists {
+		return sess, nil
+	}
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, err
+	}
+	sharedSessions[key] = sess
+	return sess, nil
+}
+
+// GetSessionWithFailover attempts to create an AWS session based on Config,
+// where requests are routed to the failover regions of the config after
+// repeated failures, and fn is called whenever the region being targeted
+// changes. When no failover regions are configured this is equivalent to
+// GetSession.
+func (c Config) GetSessionWithFailover(fn FailoverFunc, opts ...func(*aws.Config)) (*session.Session, error) {
+	if len(c.FailoverRegions) == 0 {
+		return c.GetSession(opts...)
+	}
+
+	f, err := c.newFailover(fn, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := c.newSession(opts...)
+	if err != nil {
+		return nil, err
+	}
+	f.register(&sess.Handlers)
+	return sess, nil
+}
+
+func (c Config) newSession(opts ...func(*aws.Config)) (*session.Session, error) {
 	awsConf := aws.NewConfig()
 	if len(c.Region) > 0 {
 		awsConf = awsConf.WithRegion(c.Region)
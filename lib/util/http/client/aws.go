@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/aws/aws-sdk-go/aws"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// AWSConfig contains configuration fields for signing requests with AWS
+// Signature Version 4.
+type AWSConfig struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+	Service            string `json:"service" yaml:"service"`
+	sess.SigningConfig `json:",inline" yaml:",inline"`
+}
+
+// NewAWSConfig returns an AWSConfig with default values.
+func NewAWSConfig() AWSConfig {
+	return AWSConfig{
+		Enabled:       false,
+		Service:       "execute-api",
+		SigningConfig: sess.NewSigningConfig(),
+	}
+}
+
+func awsFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"aws", "Sign requests with [AWS Signature Version 4](https://docs.aws.amazon.com/general/latest/gr/signature-version-4.html) using AWS credentials, which allows you to call AWS services and API Gateway endpoints that use IAM authentication. Requests are signed after the body has been constructed, and are signed again for each retry attempt. This option cannot be used alongside other authentication methods.",
+	).WithChildren(
+		docs.FieldCommon("enabled", "Whether to sign requests with AWS Signature Version 4.").HasType(docs.FieldTypeBool),
+		docs.FieldCommon("service", "The name of the AWS service that requests are signed for.", "execute-api", "es", "lambda").HasType(docs.FieldTypeString),
+	).WithChildren(sess.SigningFieldSpecs()...).AtVersion("3.50.0")
+}
+
+// lintAuth returns a linting error when AWS request signing is enabled
+// alongside another authentication method.
+func lintAuth(ctx docs.LintContext, line, col int, value interface{}) []docs.Lint {
+	gObj := gabs.Wrap(value)
+	if enabled, _ := gObj.S("aws", "enabled").Data().(bool); !enabled {
+		return nil
+	}
+	for _, k := range []string{"oauth", "oauth2", "basic_auth", "jwt"} {
+		if enabled, _ := gObj.S(k, "enabled").Data().(bool); enabled {
+			return []docs.Lint{
+				docs.NewLintError(line, fmt.Sprintf("field aws cannot be enabled at the same time as %v", k)),
+			}
+		}
+	}
+	return nil
+}
+
+// ComponentSpec returns a component config spec containing the fields
+// provided, which should include those of an HTTP client, with a linter that
+// checks the combination of authentication methods.
+func ComponentSpec(fields docs.FieldSpecs) docs.FieldSpec {
+	return docs.FieldComponent().WithChildren(fields...).Linter(lintAuth)
+}
+
+//------------------------------------------------------------------------------
+
+func (c Config) otherAuthEnabled() (string, bool) {
+	switch {
+	case c.OAuth.Enabled:
+		return "oauth", true
+	case c.OAuth2.Enabled:
+		return "oauth2", true
+	case c.BasicAuth.Enabled:
+		return "basic_auth", true
+	case c.JWT.Enabled:
+		return "jwt", true
+	}
+	return "", false
+}
+
+type awsSigner struct {
+	signer  *v4.Signer
+	service string
+	region  string
+}
+
+func newAWSSigner(conf Config) (*awsSigner, error) {
+	if !conf.AWS.Enabled {
+		return nil, nil
+	}
+	if k, enabled := conf.otherAuthEnabled(); enabled {
+		return nil, fmt.Errorf("aws request signing cannot be enabled at the same time as %v", k)
+	}
+	if conf.AWS.Service == "" {
+		return nil, errors.New("aws service must not be empty")
+	}
+	awsSess, err := conf.AWS.GetSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %w", err)
+	}
+	return &awsSigner{
+		signer:  v4.NewSigner(awsSess.Config.Credentials),
+		service: conf.AWS.Service,
+		region:  aws.StringValue(awsSess.Config.Region),
+	}, nil
+}
+
+// sign adds AWS Signature Version 4 headers to a request, the body provided
+// must match the body of the request.
+func (a *awsSigner) sign(req *http.Request, body []byte) error {
+	var bodyReader io.ReadSeeker
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+	if _, err := a.signer.Sign(req, bodyReader, a.service, a.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func awsTestConfig(url string) Config {
+	conf := NewConfig()
+	conf.URL = url
+	conf.Retry = "1ms"
+	conf.AWS.Enabled = true
+	conf.AWS.Region = "eu-west-1"
+	conf.AWS.Credentials.ID = "foo"
+	conf.AWS.Credentials.Secret = "bar"
+	return conf
+}
+
+func TestHTTPClientAWSSigning(t *testing.T) {
+	var mut sync.Mutex
+	var authHeaders, expAuthHeaders []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+
+		// Sign an identical request in order to verify that the signature
+		// covers the body that was received.
+		signTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		expReq, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.String(), nil)
+		require.NoError(t, err)
+		expReq.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		_, err = v4.NewSigner(credentials.NewStaticCredentials("foo", "bar", "")).
+			Sign(expReq, bytes.NewReader(body), "execute-api", "eu-west-1", signTime)
+		require.NoError(t, err)
+
+		mut.Lock()
+		authHeaders = append(authHeaders, authHeader)
+		expAuthHeaders = append(expAuthHeaders, expReq.Header.Get("Authorization"))
+		mut.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	h, err := New(awsTestConfig(ts.URL + "/testpost"))
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("hello world")}))
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+	require.NoError(t, err)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	require.Len(t, authHeaders, 2)
+	for _, v := range authHeaders {
+		assert.True(t, strings.HasPrefix(v, "AWS4-HMAC-SHA256 Credential=foo/"), v)
+		assert.Contains(t, v, "/eu-west-1/execute-api/aws4_request")
+	}
+	assert.Equal(t, expAuthHeaders, authHeaders)
+}
+
+func TestHTTPClientAWSSigningRetries(t *testing.T) {
+	var mut sync.Mutex
+	var authHeaders []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mut.Unlock()
+		http.Error(w, "test error", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	conf := awsTestConfig(ts.URL + "/testpost")
+	conf.NumRetries = 2
+
+	h, err := New(conf)
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("hello world")}))
+	require.Error(t, err)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	require.Len(t, authHeaders, 3)
+	for _, v := range authHeaders {
+		assert.True(t, strings.HasPrefix(v, "AWS4-HMAC-SHA256 "), v)
+	}
+}
+
+func TestHTTPClientAWSConflictingAuth(t *testing.T) {
+	conf := awsTestConfig("http://localhost:4195")
+	conf.BasicAuth.Enabled = true
+
+	_, err := New(conf)
+	require.EqualError(t, err, "aws request signing cannot be enabled at the same time as basic_auth")
+}
+
+func TestHTTPClientAWSLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		conf  map[string]interface{}
+		lints []docs.Lint
+	}{
+		{
+			name: "aws only",
+			conf: map[string]interface{}{
+				"aws": map[string]interface{}{"enabled": true},
+			},
+		},
+		{
+			name: "oauth only",
+			conf: map[string]interface{}{
+				"aws":   map[string]interface{}{"enabled": false},
+				"oauth": map[string]interface{}{"enabled": true},
+			},
+		},
+		{
+			name: "aws and jwt",
+			conf: map[string]interface{}{
+				"aws": map[string]interface{}{"enabled": true},
+				"jwt": map[string]interface{}{"enabled": true},
+			},
+			lints: []docs.Lint{
+				docs.NewLintError(5, "field aws cannot be enabled at the same time as jwt"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			lints := lintAuth(docs.LintContext{}, 5, 0, test.conf)
+			assert.Equal(t, test.lints, lints)
+		})
+	}
+}
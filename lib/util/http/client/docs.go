@@ -2,7 +2,9 @@ package client
 
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	httputil "github.com/Jeffail/benthos/v3/lib/util/http"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 )
 
@@ -18,6 +20,7 @@ func FieldSpecs() docs.FieldSpecs {
 		}),
 	}
 	httpSpecs = append(httpSpecs, auth.FieldSpecsExpanded()...)
+	httpSpecs = append(httpSpecs, awsFieldSpec())
 	httpSpecs = append(httpSpecs, tls.FieldSpec(),
 		docs.FieldBool("copy_response_headers", "Sets whether to copy the headers from the response to the resulting payload.").Advanced(),
 		docs.FieldString("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by."),
@@ -28,8 +31,31 @@ func FieldSpecs() docs.FieldSpecs {
 		docs.FieldInt("backoff_on", "A list of status codes whereby the request should be considered to have failed and retries should be attempted, but the period between them should be increased gradually.").Array().Advanced(),
 		docs.FieldInt("drop_on", "A list of status codes whereby the request should be considered to have failed but retries should not be attempted. This is useful for preventing wasted retries for requests that will never succeed. Note that with these status codes the _request_ is dropped, but _message_ that caused the request will not be dropped.").Array().Advanced(),
 		docs.FieldInt("successful_on", "A list of status codes whereby the attempt should be considered successful, this is useful for dropping requests that return non-2XX codes indicating that the message has been dealt with, such as a 303 See Other or a 409 Conflict. All 2XX codes are considered successful unless they are present within `backoff_on` or `drop_on`, regardless of this field.").Array().Advanced(),
-		docs.FieldString("proxy_url", "An optional HTTP proxy URL.").Advanced(),
+		docs.FieldInt("error_body_limit", "The maximum number of bytes of the body of a failed response to capture and add to the error, as well as to the `http_error_body` metadata of messages routed through fallback or dead letter paths. Bodies that are not valid UTF-8 are base64 encoded. Set to `0` in order to disable capturing response bodies.").HasDefault(1024).Advanced().AtVersion("3.50.0"),
+	)
+	httpSpecs = append(httpSpecs, proxy.FieldSpecs()...)
+	httpSpecs = append(httpSpecs,
+		docs.FieldString("compression", "An optional algorithm to compress request bodies with, the `Content-Encoding` header of requests is set accordingly. When sending batches as multipart requests the entire multipart body is compressed.").HasOptions(httputil.CompressionAlgorithms...).Advanced().AtVersion("3.50.0"),
 	)
 
 	return httpSpecs
 }
+
+// BatchFormatFieldSpecs returns the field specs for the formats used to send
+// message batches within a single request and to split the response back into
+// messages.
+func BatchFormatFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("batch_format", "The format used to serialise a message batch into the body of a single request. With `multipart` a batch of more than one message is sent as a [multipart message](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html), `json_array` sends messages as the elements of a JSON array, `lines` sends each message on its own line (such as [JSON Lines](https://jsonlines.org/)) and `binary_concat` concatenates messages. All formats other than `multipart` are applied to single messages as well.").HasOptions("multipart", "json_array", "lines", "binary_concat").Advanced().AtVersion("3.50.0"),
+		docs.FieldString("response_format", "The format used to split the body of a response into messages, which are mapped to the messages of the request by their index. With `auto` multipart responses are split into their parts and any other response becomes a single message. With `json_array` or `lines` the response must contain exactly one element or line for each message of the request, otherwise the request fails.").HasOptions("auto", "json_array", "lines").Advanced().AtVersion("3.50.0"),
+	}
+}
+
+// ConcurrencyFieldSpecs returns the field specs for limiting the number of
+// outstanding requests of a component.
+func ConcurrencyFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldInt("max_concurrency", "The maximum number of requests that may be outstanding at any given time, where zero disables the limit. The limit is shared by all instances of the component created for each thread of a pipeline, and therefore bounds the concurrency of the component as a whole. A request holds its slot until it succeeds or until all retry attempts have failed.").HasDefault(0).AtVersion("3.50.0"),
+		docs.FieldString("queue_timeout", "An optional maximum period to wait for a request slot when `max_concurrency` is reached, after which the request fails with an error that can be [handled](/docs/configuration/error_handling). When empty requests wait indefinitely.", "10s").HasDefault("").Advanced().AtVersion("3.50.0"),
+	}
+}
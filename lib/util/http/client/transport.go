@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// Transports are shared between all clients that have matching TLS and proxy
+// configuration, which allows them to reuse a common pool of idle connections
+// and avoids repeating TLS handshakes. Each transport is reference counted and
+// is released once the last client using it has closed.
+var (
+	sharedTransports    = map[string]*sharedTransport{}
+	sharedTransportsMut sync.Mutex
+)
+
+type sharedTransport struct {
+	tr   *http.Transport
+	refs int
+}
+
+// SharedTransport returns an HTTP transport for a TLS config and proxy config,
+// or nil if the default transport should be used, along with a function that
+// must be called once the transport is no longer used. The transport is shared
+// with all other callers that provide matching configuration and must
+// therefore not be modified.
+//
+// TLS configs that reference certificate files are never shared, so that
+// certificates rotated at the same path are read again each time a component
+// is created.
+func SharedTransport(tlsConf tls.Config, proxyConf proxy.Config) (*http.Transport, func(), error) {
+	if !tlsConf.Enabled && proxyConf.URL == "" {
+		return nil, func() {}, nil
+	}
+
+	if tlsConf.Enabled && tlsReferencesFiles(tlsConf) {
+		tr, err := newTransport(tlsConf, proxyConf)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tr, tr.CloseIdleConnections, nil
+	}
+
+	keyBytes, err := json.Marshal(struct {
+		TLS   tls.Config
+		Proxy proxy.Config
+	}{tlsConf, proxyConf})
+	if err != nil {
+		return nil, nil, err
+	}
+	key := string(keyBytes)
+
+	sharedTransportsMut.Lock()
+	defer sharedTransportsMut.Unlock()
+
+	shared, exists := sharedTransports[key]
+	if !exists {
+		tr, err := newTransport(tlsConf, proxyConf)
+		if err != nil {
+			return nil, nil, err
+		}
+		shared = &sharedTransport{tr: tr}
+		sharedTransports[key] = shared
+	}
+	shared.refs++
+
+	var releaseOnce sync.Once
+	return shared.tr, func() {
+		releaseOnce.Do(func() {
+			sharedTransportsMut.Lock()
+			defer sharedTransportsMut.Unlock()
+
+			if shared.refs--; shared.refs > 0 {
+				return
+			}
+			delete(sharedTransports, key)
+			shared.tr.CloseIdleConnections()
+		})
+	}, nil
+}
+
+func tlsReferencesFiles(conf tls.Config) bool {
+	if conf.RootCAsFile != "" {
+		return true
+	}
+	for _, c := range conf.ClientCertificates {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func newTransport(tlsConf tls.Config, proxyConf proxy.Config) (*http.Transport, error) {
+	var tr *http.Transport
+	if c, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr = c.Clone()
+	} else {
+		tr = &http.Transport{}
+	}
+
+	if tlsConf.Enabled {
+		goTLSConf, err := tlsConf.Get()
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = goTLSConf
+	}
+
+	dialer, err := proxy.NewDialer(proxyConf)
+	if err != nil {
+		return nil, err
+	}
+	if dialer != nil {
+		dialer.Transport(tr)
+	}
+	return tr, nil
+}
+
+//------------------------------------------------------------------------------
+
+// withConnTrace adds a trace to a request that records whether the connection
+// used was taken from the idle pool and how long it took to obtain.
+func (h *Type) withConnTrace(req *http.Request) *http.Request {
+	var getConnAt time.Time
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			getConnAt = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				h.mConnReused.Incr(1)
+			} else {
+				h.mConnNew.Incr(1)
+			}
+			if !getConnAt.IsZero() {
+				h.mConnWait.Timing(int64(time.Since(getConnAt)))
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedTransport(t *testing.T) {
+	tr, release, err := SharedTransport(tls.NewConfig(), proxy.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr != nil {
+		t.Error("Expected nil transport for default config")
+	}
+	release()
+
+	trA, releaseA, err := SharedTransport(tls.NewConfig(), proxy.Config{URL: "http://foo:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trB, releaseB, err := SharedTransport(tls.NewConfig(), proxy.Config{URL: "http://foo:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trA != trB {
+		t.Error("Expected matching configs to share a transport")
+	}
+
+	trC, releaseC, err := SharedTransport(tls.NewConfig(), proxy.Config{URL: "http://bar:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trA == trC {
+		t.Error("Expected differing configs to use separate transports")
+	}
+	releaseA()
+	releaseB()
+	releaseC()
+}
+
+func TestSharedTransportReleased(t *testing.T) {
+	proxyConf := proxy.Config{URL: "http://baz:8080"}
+
+	trA, releaseA, err := SharedTransport(tls.NewConfig(), proxyConf)
+	require.NoError(t, err)
+
+	trB, releaseB, err := SharedTransport(tls.NewConfig(), proxyConf)
+	require.NoError(t, err)
+	assert.Same(t, trA, trB)
+
+	// Releasing more than once has no further effect.
+	releaseA()
+	releaseA()
+
+	trC, releaseC, err := SharedTransport(tls.NewConfig(), proxyConf)
+	require.NoError(t, err)
+	assert.Same(t, trA, trC)
+
+	releaseB()
+	releaseC()
+
+	trD, releaseD, err := SharedTransport(tls.NewConfig(), proxyConf)
+	require.NoError(t, err)
+	defer releaseD()
+	assert.NotSame(t, trA, trD, "expected a new transport once all clients were released")
+}
+
+func TestSharedTransportCertFilesNotShared(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	})
+	tsA := httptest.NewTLSServer(handler)
+	defer tsA.Close()
+	tsB := httptest.NewTLSServer(handler)
+	defer tsB.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	writeCA := func(ts *httptest.Server) {
+		t.Helper()
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+		require.NoError(t, ioutil.WriteFile(caPath, caPEM, 0o600))
+	}
+
+	tlsConf := tls.NewConfig()
+	tlsConf.Enabled = true
+	tlsConf.RootCAsFile = caPath
+
+	writeCA(tsA)
+	trA, releaseA, err := SharedTransport(tlsConf, proxy.Config{})
+	require.NoError(t, err)
+	defer releaseA()
+
+	res, err := (&http.Client{Transport: trA}).Get(tsA.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	// The certificate is rotated at the same path, which must be read again by
+	// transports created afterwards.
+	writeCA(tsB)
+	trB, releaseB, err := SharedTransport(tlsConf, proxy.Config{})
+	require.NoError(t, err)
+	defer releaseB()
+	assert.NotSame(t, trA, trB)
+
+	res, err = (&http.Client{Transport: trB}).Get(tsB.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+}
@@ -3,14 +3,16 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/textproto"
-	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,12 +20,16 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/budget"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	httputil "github.com/Jeffail/benthos/v3/lib/util/http"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/http/proxy"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/opentracing/opentracing-go"
@@ -34,22 +40,27 @@ import (
 
 // Config is a configuration struct for an HTTP client.
 type Config struct {
-	URL                 string            `json:"url" yaml:"url"`
-	Verb                string            `json:"verb" yaml:"verb"`
-	Headers             map[string]string `json:"headers" yaml:"headers"`
-	CopyResponseHeaders bool              `json:"copy_response_headers" yaml:"copy_response_headers"`
-	RateLimit           string            `json:"rate_limit" yaml:"rate_limit"`
-	Timeout             string            `json:"timeout" yaml:"timeout"`
-	Retry               string            `json:"retry_period" yaml:"retry_period"`
-	MaxBackoff          string            `json:"max_retry_backoff" yaml:"max_retry_backoff"`
-	NumRetries          int               `json:"retries" yaml:"retries"`
-	BackoffOn           []int             `json:"backoff_on" yaml:"backoff_on"`
-	DropOn              []int             `json:"drop_on" yaml:"drop_on"`
-	SuccessfulOn        []int             `json:"successful_on" yaml:"successful_on"`
-	TLS                 tls.Config        `json:"tls" yaml:"tls"`
-	ProxyURL            string            `json:"proxy_url" yaml:"proxy_url"`
+	URL                 string               `json:"url" yaml:"url"`
+	Verb                string               `json:"verb" yaml:"verb"`
+	Headers             map[string]string    `json:"headers" yaml:"headers"`
+	CopyResponseHeaders bool                 `json:"copy_response_headers" yaml:"copy_response_headers"`
+	RateLimit           string               `json:"rate_limit" yaml:"rate_limit"`
+	Timeout             string               `json:"timeout" yaml:"timeout"`
+	Retry               string               `json:"retry_period" yaml:"retry_period"`
+	MaxBackoff          string               `json:"max_retry_backoff" yaml:"max_retry_backoff"`
+	NumRetries          int                  `json:"retries" yaml:"retries"`
+	BackoffOn           []int                `json:"backoff_on" yaml:"backoff_on"`
+	DropOn              []int                `json:"drop_on" yaml:"drop_on"`
+	SuccessfulOn        []int                `json:"successful_on" yaml:"successful_on"`
+	ErrorBodyLimit      int                  `json:"error_body_limit" yaml:"error_body_limit"`
+	TLS                 tls.Config           `json:"tls" yaml:"tls"`
+	ProxyURL            string               `json:"proxy_url" yaml:"proxy_url"`
+	ProxyBasicAuth      auth.BasicAuthConfig `json:"proxy_basic_auth" yaml:"proxy_basic_auth"`
+	NoProxy             []string             `json:"no_proxy" yaml:"no_proxy"`
+	Compression         string               `json:"compression" yaml:"compression"`
 	auth.Config         `json:",inline" yaml:",inline"`
 	OAuth2              auth.OAuth2Config `json:"oauth2" yaml:"oauth2"`
+	AWS                 AWSConfig         `json:"aws" yaml:"aws"`
 }
 
 // NewConfig creates a new Config with default values.
@@ -69,9 +80,23 @@ func NewConfig() Config {
 		BackoffOn:           []int{429},
 		DropOn:              []int{},
 		SuccessfulOn:        []int{},
+		ErrorBodyLimit:      1024,
 		TLS:                 tls.NewConfig(),
+		ProxyBasicAuth:      auth.NewBasicAuthConfig(),
+		NoProxy:             []string{},
+		Compression:         "none",
 		Config:              auth.NewConfig(),
 		OAuth2:              auth.NewOAuth2Config(),
+		AWS:                 NewAWSConfig(),
+	}
+}
+
+// Proxy returns the proxy config of the client.
+func (c Config) Proxy() proxy.Config {
+	return proxy.Config{
+		URL:       c.ProxyURL,
+		BasicAuth: c.ProxyBasicAuth,
+		NoProxy:   c.NoProxy,
 	}
 }
 
@@ -85,12 +110,22 @@ type Type struct {
 	dropOn    map[int]struct{}
 	successOn map[int]struct{}
 
+	classifyErrors bool
+	forceRetryOn   ioutput.ForceRetryOn
+
 	url     *field.Expression
 	headers map[string]*field.Expression
 	host    *field.Expression
 
-	conf          Config
-	retryThrottle *throttle.Type
+	conf           Config
+	batchFormat    string
+	responseFormat string
+	awsSigner      *awsSigner
+	retryThrottle  *throttle.Type
+	maxConcurrency int
+	queueTimeout   string
+	concurrency    *budget.Concurrency
+	queueFor       time.Duration
 
 	log   log.Modular
 	stats metrics.Type
@@ -100,19 +135,27 @@ type Type struct {
 	mErr           metrics.StatCounter
 	mErrReq        metrics.StatCounter
 	mErrReqTimeout metrics.StatCounter
+	mErrProxy      metrics.StatCounter
 	mErrRes        metrics.StatCounter
 	mLimited       metrics.StatCounter
 	mLimitFor      metrics.StatCounter
 	mLimitErr      metrics.StatCounter
+	mConcurrency   metrics.StatGauge
+	mQueueTimeout  metrics.StatCounter
 	mSucc          metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mConnReused    metrics.StatCounter
+	mConnNew       metrics.StatCounter
+	mConnWait      metrics.StatTimer
 
 	mCodes   map[int]metrics.StatCounter
 	codesMut sync.RWMutex
 
-	ctx       context.Context
-	done      func()
-	closeChan <-chan struct{}
+	ctx  context.Context
+	done func()
+
+	releaseTransport func()
+	closeChan        <-chan struct{}
 }
 
 // New creates a new Type.
@@ -133,7 +176,17 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		successOn: map[int]struct{}{},
 		headers:   map[string]*field.Expression{},
 		host:      nil,
+
+		batchFormat:    "multipart",
+		responseFormat: "auto",
 	}
+	if h.awsSigner, err = newAWSSigner(conf); err != nil {
+		return nil, err
+	}
+	if err = httputil.ValidateCompression(conf.Compression); err != nil {
+		return nil, err
+	}
+
 	h.ctx, h.done = context.WithCancel(context.Background())
 	h.client = conf.OAuth2.Client(h.ctx)
 
@@ -144,41 +197,22 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		}
 	}
 
-	if h.conf.TLS.Enabled {
-		tlsConf, err := h.conf.TLS.Get()
-		if err != nil {
-			return nil, err
-		}
-		if tlsConf != nil {
-			if c, ok := http.DefaultTransport.(*http.Transport); ok {
-				cloned := c.Clone()
-				cloned.TLSClientConfig = tlsConf
-				h.client.Transport = cloned
-			} else {
-				h.client.Transport = &http.Transport{
-					TLSClientConfig: tlsConf,
-				}
-			}
-		}
+	tr, releaseTransport, err := SharedTransport(h.conf.TLS, h.conf.Proxy())
+	if err != nil {
+		return nil, err
+	}
+	if tr != nil {
+		h.client.Transport = tr
 	}
+	h.releaseTransport = releaseTransport
 
-	if h.conf.ProxyURL != "" {
-		proxyURL, err := url.Parse(h.conf.ProxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
-		}
-		if h.client.Transport != nil {
-			if tr, ok := h.client.Transport.(*http.Transport); ok {
-				tr.Proxy = http.ProxyURL(proxyURL)
-			} else {
-				return nil, fmt.Errorf("unable to apply proxy_url to transport, unexpected type %T", h.client.Transport)
-			}
-		} else {
-			h.client.Transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
+	// The transport is released if the client fails to build.
+	built := false
+	defer func() {
+		if !built {
+			releaseTransport()
 		}
-	}
+	}()
 
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
@@ -206,16 +240,33 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		opt(&h)
 	}
 
+	switch h.batchFormat {
+	case "multipart", "json_array", "lines", "binary_concat":
+	default:
+		return nil, fmt.Errorf("batch format not recognised: %v", h.batchFormat)
+	}
+	switch h.responseFormat {
+	case "auto", "json_array", "lines":
+	default:
+		return nil, fmt.Errorf("response format not recognised: %v", h.responseFormat)
+	}
+
 	h.mCount = h.stats.GetCounter("count")
 	h.mErr = h.stats.GetCounter("error")
 	h.mErrReq = h.stats.GetCounter("error.request")
 	h.mErrReqTimeout = h.stats.GetCounter("request_timeout")
+	h.mErrProxy = h.stats.GetCounter("error.proxy")
 	h.mErrRes = h.stats.GetCounter("error.response")
 	h.mLimited = h.stats.GetCounter("rate_limit.count")
 	h.mLimitFor = h.stats.GetCounter("rate_limit.total_ms")
 	h.mLimitErr = h.stats.GetCounter("rate_limit.error")
+	h.mConcurrency = h.stats.GetGauge("concurrency")
+	h.mQueueTimeout = h.stats.GetCounter("queue_timeout")
 	h.mLatency = h.stats.GetTimer("latency")
 	h.mSucc = h.stats.GetCounter("success")
+	h.mConnReused = h.stats.GetCounter("connection.reused")
+	h.mConnNew = h.stats.GetCounter("connection.new")
+	h.mConnWait = h.stats.GetTimer("connection.wait")
 	h.mCodes = map[int]metrics.StatCounter{}
 
 	var retry, maxBackoff time.Duration
@@ -232,6 +283,19 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		}
 	}
 
+	if h.maxConcurrency < 0 {
+		return nil, fmt.Errorf("max_concurrency must not be negative: %v", h.maxConcurrency)
+	}
+	if h.maxConcurrency > 0 {
+		h.concurrency = interop.GetConcurrencyLimit(h.mgr, h.maxConcurrency)
+	}
+	if tout := h.queueTimeout; len(tout) > 0 {
+		var err error
+		if h.queueFor, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse queue timeout duration string: %v", err)
+		}
+	}
+
 	if conf.RateLimit != "" {
 		if err := interop.ProbeRateLimit(context.Background(), h.mgr, conf.RateLimit); err != nil {
 			return nil, err
@@ -245,6 +309,7 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		throttle.OptMaxExponentPeriod(maxBackoff),
 	)
 
+	built = true
 	return &h, nil
 }
 
@@ -298,8 +363,53 @@ func OptSetRoundTripper(rt http.RoundTripper) func(*Type) {
 	}
 }
 
+// OptSetBatchFormat sets the format used for serialising messages into the body
+// of a request, and the format used for splitting the body of a response back
+// into messages.
+func OptSetBatchFormat(batchFormat, responseFormat string) func(*Type) {
+	return func(t *Type) {
+		t.batchFormat = batchFormat
+		t.responseFormat = responseFormat
+	}
+}
+
+// OptSetMaxConcurrency sets the maximum number of requests that may be
+// outstanding at any given time, which is shared by all clients of the same
+// component label, and an optional period to wait for a free slot before a
+// request fails. A maximum of zero disables the limit.
+func OptSetMaxConcurrency(max int, queueTimeout string) func(*Type) {
+	return func(t *Type) {
+		t.maxConcurrency = max
+		t.queueTimeout = queueTimeout
+	}
+}
+
+// OptSetTerminalErrors enables classifying the errors of failed requests by
+// their response code, where requests rejected with codes indicating that the
+// request itself is invalid, such as 403 or 413, are marked as terminal and are
+// not retried unless the error contains one of the forceRetryOn patterns. This
+// is intended for outputs, where terminal errors can be routed to a dead letter
+// queue.
+func OptSetTerminalErrors(forceRetryOn []string) func(*Type) {
+	return func(t *Type) {
+		t.classifyErrors = true
+		t.forceRetryOn = ioutput.ForceRetryOn(forceRetryOn)
+	}
+}
+
 //------------------------------------------------------------------------------
 
+// incrErrType increments the metric of a request error that occurred before a
+// response was received, where failures of a proxy are counted separately
+// from timeouts.
+func (h *Type) incrErrType(err error) {
+	if proxy.IsError(err) {
+		h.mErrProxy.Incr(1)
+	} else if nErr, ok := err.(net.Error); ok && nErr.Timeout() {
+		h.mErrReqTimeout.Incr(1)
+	}
+}
+
 func (h *Type) incrCode(code int) {
 	h.codesMut.RLock()
 	ctr, exists := h.mCodes[code]
@@ -318,14 +428,14 @@ func (h *Type) incrCode(code int) {
 	h.codesMut.Unlock()
 }
 
-func (h *Type) waitForAccess() bool {
+func (h *Type) waitForAccess(ctx context.Context) bool {
 	if h.conf.RateLimit == "" {
 		return true
 	}
 	for {
 		var period time.Duration
 		var err error
-		if rerr := interop.AccessRateLimit(context.Background(), h.mgr, h.conf.RateLimit, func(rl types.RateLimit) {
+		if rerr := interop.AccessRateLimit(ctx, h.mgr, h.conf.RateLimit, func(rl types.RateLimit) {
 			period, err = rl.Access()
 		}); rerr != nil {
 			err = rerr
@@ -344,6 +454,8 @@ func (h *Type) waitForAccess() bool {
 			case <-time.After(period):
 			case <-h.closeChan:
 				return false
+			case <-ctx.Done():
+				return false
 			}
 		} else {
 			return true
@@ -351,10 +463,64 @@ func (h *Type) waitForAccess() bool {
 	}
 }
 
+// ErrQueueTimeout is returned when a request could not be sent because the
+// maximum number of concurrent requests remained outstanding for longer than
+// the configured queue timeout.
+var ErrQueueTimeout = errors.New("timed out waiting for concurrent requests to complete")
+
+// acquireSlot blocks until the number of outstanding requests is below the
+// configured maximum, returning a function that releases the acquired slot.
+func (h *Type) acquireSlot(ctx context.Context) (func(), error) {
+	if h.concurrency == nil {
+		return func() {}, nil
+	}
+
+	waitCtx := ctx
+	if h.queueFor > 0 {
+		var done func()
+		waitCtx, done = context.WithTimeout(ctx, h.queueFor)
+		defer done()
+	}
+
+	release, err := h.concurrency.Acquire(waitCtx)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			h.mQueueTimeout.Incr(1)
+			return nil, ErrQueueTimeout
+		}
+		return nil, types.ErrTypeClosed
+	}
+	h.mConcurrency.Set(int64(h.concurrency.InUse()))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+			h.mConcurrency.Set(int64(h.concurrency.InUse()))
+		})
+	}, nil
+}
+
+// releaseOnClose wraps the body of a response in order to release a request
+// slot once the body has been consumed and closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
+
 // CreateRequest creates an HTTP request out of a single message.
 func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 	url := h.url.String(0, msg)
 
+	// The final body of the request, which is required for signing.
+	var bodyBytes []byte
+
 	if msg == nil || msg.Len() == 0 {
 		if req, err = http.NewRequest(h.conf.Verb, url, nil); err == nil {
 			for k, v := range h.headers {
@@ -364,10 +530,25 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 				req.Host = h.host.String(0, msg)
 			}
 		}
+	} else if h.batchFormat != "multipart" {
+		if bodyBytes, err = encodeBatch(h.batchFormat, msg); err == nil {
+			var body io.Reader
+			if len(bodyBytes) > 0 {
+				body = bytes.NewBuffer(bodyBytes)
+			}
+			if req, err = http.NewRequest(h.conf.Verb, url, body); err == nil {
+				for k, v := range h.headers {
+					req.Header.Add(k, v.String(0, msg))
+				}
+				if h.host != nil {
+					req.Host = h.host.String(0, msg)
+				}
+			}
+		}
 	} else if msg.Len() == 1 {
 		var body io.Reader
-		if msgBytes := msg.Get(0).Get(); len(msgBytes) > 0 {
-			body = bytes.NewBuffer(msgBytes)
+		if bodyBytes = msg.Get(0).Get(); len(bodyBytes) > 0 {
+			body = bytes.NewBuffer(bodyBytes)
 		}
 		if req, err = http.NewRequest(h.conf.Verb, url, body); err == nil {
 			for k, v := range h.headers {
@@ -395,6 +576,7 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 		}
 
 		writer.Close()
+		bodyBytes = body.Bytes()
 		if err == nil {
 			if req, err = http.NewRequest(h.conf.Verb, url, body); err == nil {
 				for k, v := range h.headers {
@@ -409,12 +591,100 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 		}
 	}
 
+	if err == nil && len(bodyBytes) > 0 {
+		bodyBytes, err = CompressRequestBody(h.conf.Compression, req, bodyBytes)
+	}
 	if err == nil {
 		err = h.conf.Config.Sign(req)
 	}
+	if err == nil && h.awsSigner != nil {
+		err = h.awsSigner.sign(req, bodyBytes)
+	}
 	return
 }
 
+// encodeBatch serialises all messages of a batch into a single request body
+// according to a batch format other than multipart.
+func encodeBatch(format string, msg types.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "json_array":
+		buf.WriteByte('[')
+	}
+	err := msg.Iter(func(i int, p types.Part) error {
+		b := p.Get()
+		switch format {
+		case "json_array":
+			if !json.Valid(b) {
+				return fmt.Errorf("message %v is not valid JSON", i)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+		case "lines":
+			if bytes.ContainsAny(b, "\r\n") {
+				return fmt.Errorf("message %v contains a line break", i)
+			}
+		}
+		buf.Write(b)
+		if format == "lines" {
+			buf.WriteByte('\n')
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if format == "json_array" {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBatch splits the body of a response into message payloads according
+// to a response format other than auto.
+func decodeBatch(format string, body []byte) ([][]byte, error) {
+	var parts [][]byte
+	switch format {
+	case "json_array":
+		var elements []json.RawMessage
+		if err := json.Unmarshal(body, &elements); err != nil {
+			return nil, fmt.Errorf("failed to parse response as a JSON array: %w", err)
+		}
+		for _, e := range elements {
+			parts = append(parts, []byte(e))
+		}
+	case "lines":
+		body = bytes.TrimSuffix(body, []byte("\n"))
+		if len(body) == 0 {
+			return nil, nil
+		}
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			parts = append(parts, bytes.TrimSuffix(line, []byte("\r")))
+		}
+	}
+	return parts, nil
+}
+
+// CompressRequestBody replaces the body of a request with a compressed version
+// and sets the Content-Encoding header, returning the new body.
+func CompressRequestBody(algorithm string, req *http.Request, body []byte) ([]byte, error) {
+	if algorithm == "" || algorithm == "none" {
+		return body, nil
+	}
+	compressed, err := httputil.Compress(algorithm, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", algorithm)
+	return compressed, nil
+}
+
 // ParseResponse attempts to parse an HTTP response into a 2D slice of bytes.
 func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err error) {
 	resMsg = message.New(nil)
@@ -477,18 +747,32 @@ func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err erro
 				h.log.Errorf("Failed to read response: %v\n", err)
 				return
 			}
-			if bytesRead > 0 {
+			if h.responseFormat != "auto" {
+				var parts [][]byte
+				if parts, err = decodeBatch(h.responseFormat, buffer.Bytes()[:bytesRead]); err != nil {
+					h.mErrRes.Incr(1)
+					h.mErr.Incr(1)
+					h.log.Errorf("Failed to parse response: %v\n", err)
+					return
+				}
+				for _, p := range parts {
+					resMsg.Append(message.NewPart(p))
+				}
+			} else if bytesRead > 0 {
 				resMsg.Append(message.NewPart(buffer.Bytes()[:bytesRead]))
 			} else {
 				resMsg.Append(message.NewPart(nil))
 			}
 			if h.conf.CopyResponseHeaders {
-				meta := resMsg.Get(0).Metadata()
-				for k, values := range res.Header {
-					if len(values) > 0 {
-						meta.Set(strings.ToLower(k), values[0])
+				resMsg.Iter(func(i int, p types.Part) error {
+					meta := p.Metadata()
+					for k, values := range res.Header {
+						if len(values) > 0 {
+							meta.Set(strings.ToLower(k), values[0])
+						}
 					}
-				}
+					return nil
+				})
 			}
 		}
 	} else {
@@ -529,6 +813,35 @@ func (h *Type) checkStatus(code int) (succeeded bool, retStrat retryStrategy) {
 	return true, noRetry
 }
 
+// classifyStatus marks an error caused by a response code that indicates the
+// target is throttling requests as throttled, and codes that indicate the
+// request itself was rejected as terminal.
+func (h *Type) classifyStatus(code int, err error) error {
+	if _, exists := h.dropOn[code]; exists {
+		return ioutput.NewTerminalError(err)
+	}
+	if _, exists := h.backoffOn[code]; exists {
+		return ioutput.NewThrottledError(err)
+	}
+	switch code {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return ioutput.NewThrottledError(err)
+	case http.StatusBadRequest,
+		http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusMethodNotAllowed,
+		http.StatusGone,
+		http.StatusLengthRequired,
+		http.StatusRequestEntityTooLarge,
+		http.StatusRequestURITooLong,
+		http.StatusUnsupportedMediaType,
+		http.StatusUnprocessableEntity:
+		return ioutput.NewTerminalError(err)
+	}
+	return err
+}
+
 // Do attempts to create and perform an HTTP request from a message payload.
 // This attempt may include retries, and if all retries fail an error is
 // returned.
@@ -576,28 +889,45 @@ func (h *Type) DoWithContext(ctx context.Context, msg types.Message) (res *http.
 		}
 	}()
 
+	// A slot is held until the body of a successful response is closed, or
+	// until all attempts of the request have failed, including the periods
+	// between retries.
+	release, err := h.acquireSlot(ctx)
+	if err != nil {
+		h.mErr.Incr(1)
+		logErr(err)
+		return nil, err
+	}
+	defer func() {
+		if err != nil || res == nil || res.Body == nil {
+			release()
+		} else {
+			res.Body = &releaseOnClose{ReadCloser: res.Body, release: release}
+		}
+	}()
+
 	startedAt := time.Now()
 
-	if !h.waitForAccess() {
+	if !h.waitForAccess(ctx) {
 		return nil, types.ErrTypeClosed
 	}
 
 	rateLimited := false
 	numRetries := h.conf.NumRetries
-	if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
+	if res, err = h.client.Do(h.withConnTrace(req.WithContext(ctx))); err == nil {
 		h.incrCode(res.StatusCode)
 		if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
 			rateLimited = retryStrat == retryBackoff
-			if retryStrat == noRetry {
-				numRetries = 0
+			err = UnexpectedResponse(res, h.conf.ErrorBodyLimit)
+			if h.classifyErrors {
+				err = h.classifyStatus(res.StatusCode, err)
 			}
-			err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-			if res.Body != nil {
-				res.Body.Close()
+			if retryStrat == noRetry || h.forceRetryOn.Classify(err) == ioutput.ErrorClassTerminal {
+				numRetries = 0
 			}
 		}
-	} else if err, ok := err.(net.Error); ok && err.Timeout() {
-		h.mErrReqTimeout.Incr(1)
+	} else {
+		h.incrErrType(err)
 	}
 
 	i, j := 0, numRetries
@@ -614,32 +944,32 @@ func (h *Type) DoWithContext(ctx context.Context, msg types.Message) (res *http.
 			continue
 		}
 		if rateLimited {
-			if !h.retryThrottle.ExponentialRetry() {
+			if !h.retryThrottle.ExponentialRetryWithContext(ctx) {
 				return nil, types.ErrTypeClosed
 			}
 		} else {
-			if !h.retryThrottle.Retry() {
+			if !h.retryThrottle.RetryWithContext(ctx) {
 				return nil, types.ErrTypeClosed
 			}
 		}
-		if !h.waitForAccess() {
+		if !h.waitForAccess(ctx) {
 			return nil, types.ErrTypeClosed
 		}
 		rateLimited = false
-		if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
+		if res, err = h.client.Do(h.withConnTrace(req.WithContext(ctx))); err == nil {
 			h.incrCode(res.StatusCode)
 			if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
 				rateLimited = retryStrat == retryBackoff
-				if retryStrat == noRetry {
-					j = 0
+				err = UnexpectedResponse(res, h.conf.ErrorBodyLimit)
+				if h.classifyErrors {
+					err = h.classifyStatus(res.StatusCode, err)
 				}
-				err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-				if res.Body != nil {
-					res.Body.Close()
+				if retryStrat == noRetry || h.forceRetryOn.Classify(err) == ioutput.ErrorClassTerminal {
+					j = 0
 				}
 			}
-		} else if err, ok := err.(net.Error); ok && err.Timeout() {
-			h.mErrReqTimeout.Incr(1)
+		} else {
+			h.incrErrType(err)
 		}
 		i++
 	}
@@ -657,6 +987,23 @@ func (h *Type) DoWithContext(ctx context.Context, msg types.Message) (res *http.
 	return res, nil
 }
 
+// UnexpectedResponse returns a types.ErrUnexpectedHTTPRes for a response,
+// capturing up to limit bytes of its body, and closes the body. A limit of zero
+// or less disables capturing the body.
+func UnexpectedResponse(res *http.Response, limit int) error {
+	resErr := types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
+	if res.Body == nil {
+		return resErr
+	}
+	if limit > 0 {
+		if body, err := ioutil.ReadAll(io.LimitReader(res.Body, int64(limit))); err == nil && len(body) > 0 {
+			resErr.Body = body
+		}
+	}
+	res.Body.Close()
+	return resErr
+}
+
 // Send attempts to send a message to an HTTP server, this attempt may include
 // retries, and if all retries fail an error is returned. The message payload
 // can be nil, in which case an empty body is sent. The response will be parsed
@@ -673,12 +1020,48 @@ func (h *Type) SendWithContext(ctx context.Context, msg types.Message) (types.Me
 	if err != nil {
 		return nil, err
 	}
-	return h.ParseResponse(res)
+	resMsg, err := h.ParseResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	if h.responseFormat != "auto" && msg != nil && msg.Len() > 0 && resMsg.Len() != msg.Len() {
+		return nil, fmt.Errorf("response contained %v messages but the request batch contained %v", resMsg.Len(), msg.Len())
+	}
+	return resMsg, nil
+}
+
+// PingWithContext performs a HEAD request against a URL using the transport and
+// authentication of the client, and returns an error if the request fails or
+// the server responds with an error status code.
+func (h *Type) PingWithContext(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	if err = h.conf.Config.Sign(req); err != nil {
+		return err
+	}
+	if h.awsSigner != nil {
+		if err = h.awsSigner.sign(req, nil); err != nil {
+			return err
+		}
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 400 {
+		return types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
+	}
+	return nil
 }
 
 // CloseAsync closes the HTTP client and all managed resources.
 func (h *Type) CloseAsync() {
 	h.done()
+	h.releaseTransport()
 }
 
 //------------------------------------------------------------------------------
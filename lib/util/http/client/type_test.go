@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -16,10 +17,13 @@ import (
 	"testing"
 	"time"
 
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -51,6 +55,93 @@ func TestHTTPClientRetries(t *testing.T) {
 	}
 }
 
+func TestHTTPClientTerminalNotRetried(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		forceRetryOn []string
+		attempts     uint32
+	}{
+		{attempts: 1},
+		{forceRetryOn: []string{"404"}, attempts: 1},
+		{forceRetryOn: []string{"403"}, attempts: 4},
+		{forceRetryOn: []string{"terminal"}, attempts: 4},
+	}
+
+	for _, test := range tests {
+		atomic.StoreUint32(&reqCount, 0)
+
+		conf := NewConfig()
+		conf.URL = ts.URL + "/testpost"
+		conf.Retry = "1ms"
+		conf.NumRetries = 3
+
+		h, err := New(conf, OptSetTerminalErrors(test.forceRetryOn))
+		require.NoError(t, err)
+
+		_, err = h.Send(message.New([][]byte{[]byte("test")}))
+		require.Error(t, err)
+		assert.Equal(t, ioutput.ErrorClassTerminal, ioutput.ClassifyError(err))
+		assert.Equal(t, test.attempts, atomic.LoadUint32(&reqCount), test.forceRetryOn)
+	}
+}
+
+func TestHTTPClientUnclassifiedByDefault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test error", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.NumRetries = 0
+
+	h, err := New(conf)
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("test")}))
+	require.Error(t, err)
+	assert.Equal(t, ioutput.ErrorClassRetryable, ioutput.ClassifyError(err))
+}
+
+func TestHTTPClientErrorBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"missing field foo"}`))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.NumRetries = 0
+	conf.ErrorBodyLimit = 10
+
+	h, err := New(conf)
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("test")}))
+	require.Error(t, err)
+
+	var resErr types.ErrUnexpectedHTTPRes
+	require.True(t, errors.As(err, &resErr))
+	assert.Equal(t, http.StatusBadRequest, resErr.Code)
+	assert.Equal(t, `{"error":"`, string(resErr.Body))
+	assert.Contains(t, err.Error(), `400 Bad Request: {"error":"`)
+
+	conf.ErrorBodyLimit = 0
+	h, err = New(conf)
+	require.NoError(t, err)
+
+	_, err = h.Send(message.New([][]byte{[]byte("test")}))
+	require.True(t, errors.As(err, &resErr))
+	assert.Empty(t, resErr.Body)
+}
+
 func TestHTTPClientBadRequest(t *testing.T) {
 	conf := NewConfig()
 	conf.URL = "htp://notvalid:1111"
@@ -574,4 +665,225 @@ func TestHTTPClientReceiveMultipartWithHeaders(t *testing.T) {
 	}
 }
 
+func TestHTTPClientSendBatchFormats(t *testing.T) {
+	tests := []struct {
+		name           string
+		batchFormat    string
+		responseFormat string
+		response       string
+		input          []string
+		expBody        string
+		expResults     []string
+		expErr         string
+	}{
+		{
+			name:           "lines",
+			batchFormat:    "lines",
+			responseFormat: "lines",
+			response:       "{\"id\":1}\r\n{\"id\":2}\n",
+			input:          []string{`{"a":1}`, `{"a":2}`},
+			expBody:        "{\"a\":1}\n{\"a\":2}\n",
+			expResults:     []string{`{"id":1}`, `{"id":2}`},
+		},
+		{
+			name:           "json array",
+			batchFormat:    "json_array",
+			responseFormat: "json_array",
+			response:       `[{"id":1}, "two"]`,
+			input:          []string{`{"a":1}`, `"b"`},
+			expBody:        `[{"a":1},"b"]`,
+			expResults:     []string{`{"id":1}`, `"two"`},
+		},
+		{
+			name:        "binary concat",
+			batchFormat: "binary_concat",
+			response:    "foobar",
+			input:       []string{"foo", "bar"},
+			expBody:     "foobar",
+			expResults:  []string{"foobar"},
+		},
+		{
+			name:           "single message lines",
+			batchFormat:    "lines",
+			responseFormat: "lines",
+			response:       "bar",
+			input:          []string{"foo"},
+			expBody:        "foo\n",
+			expResults:     []string{"bar"},
+		},
+		{
+			name:           "mismatched response count",
+			batchFormat:    "lines",
+			responseFormat: "lines",
+			response:       "foo\n",
+			input:          []string{"foo", "bar"},
+			expBody:        "foo\nbar\n",
+			expErr:         "response contained 1 messages but the request batch contained 2",
+		},
+		{
+			name:           "bad json response",
+			batchFormat:    "json_array",
+			responseFormat: "json_array",
+			response:       `{"not":"an array"}`,
+			input:          []string{`"foo"`},
+			expBody:        `["foo"]`,
+			expErr:         "failed to parse response as a JSON array",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var reqBody string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				reqBody = string(b)
+				assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+				w.Write([]byte(test.response))
+			}))
+			defer ts.Close()
+
+			conf := NewConfig()
+			conf.URL = ts.URL + "/testpost"
+
+			responseFormat := test.responseFormat
+			if responseFormat == "" {
+				responseFormat = "auto"
+			}
+			h, err := New(conf, OptSetBatchFormat(test.batchFormat, responseFormat))
+			require.NoError(t, err)
+
+			inMsg := message.New(nil)
+			for _, p := range test.input {
+				inMsg.Append(message.NewPart([]byte(p)))
+			}
+
+			resMsg, err := h.Send(inMsg)
+			assert.Equal(t, test.expBody, reqBody)
+			if test.expErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var results []string
+			for _, b := range message.GetAllBytes(resMsg) {
+				results = append(results, string(b))
+			}
+			assert.Equal(t, test.expResults, results)
+		})
+	}
+}
+
+func TestHTTPClientBatchFormatErrors(t *testing.T) {
+	_, err := New(NewConfig(), OptSetBatchFormat("nope", "auto"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "batch format not recognised")
+
+	_, err = New(NewConfig(), OptSetBatchFormat("lines", "nope"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "response format not recognised")
+
+	h, err := New(NewConfig(), OptSetBatchFormat("lines", "lines"))
+	require.NoError(t, err)
+
+	_, err = h.CreateRequest(message.New([][]byte{[]byte("foo\nbar")}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "message 0 contains a line break")
+
+	h, err = New(NewConfig(), OptSetBatchFormat("json_array", "json_array"))
+	require.NoError(t, err)
+
+	_, err = h.CreateRequest(message.New([][]byte{[]byte(`{}`), []byte("not json")}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "message 1 is not valid JSON")
+}
+
 //------------------------------------------------------------------------------
+
+func TestHTTPClientMaxConcurrency(t *testing.T) {
+	var outstanding, maxOutstanding int32
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&outstanding, 1)
+		for {
+			m := atomic.LoadInt32(&maxOutstanding)
+			if n <= m || atomic.CompareAndSwapInt32(&maxOutstanding, m, n) {
+				break
+			}
+		}
+		<-unblock
+		atomic.AddInt32(&outstanding, -1)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL
+
+	stats := metrics.NewLocal()
+	h, err := New(conf, OptSetStats(stats), OptSetMaxConcurrency(2, ""))
+	require.NoError(t, err)
+
+	results := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := h.Send(message.New([][]byte{[]byte("hello")}))
+			results <- err
+		}()
+	}
+
+	<-time.After(time.Millisecond * 50)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&outstanding))
+	assert.Equal(t, int64(2), stats.GetCounters()["concurrency"])
+
+	close(unblock)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-results)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxOutstanding))
+	assert.Equal(t, int64(0), stats.GetCounters()["concurrency"])
+}
+
+func TestHTTPClientQueueTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL
+
+	stats := metrics.NewLocal()
+	h, err := New(conf, OptSetStats(stats), OptSetMaxConcurrency(1, "10ms"))
+	require.NoError(t, err)
+
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := h.Send(message.New([][]byte{[]byte("first")}))
+		blocked <- err
+	}()
+	<-time.After(time.Millisecond * 50)
+
+	_, err = h.Send(message.New([][]byte{[]byte("second")}))
+	assert.True(t, errors.Is(err, ErrQueueTimeout), err)
+	assert.Equal(t, int64(1), stats.GetCounters()["queue_timeout"])
+
+	close(unblock)
+	require.NoError(t, <-blocked)
+
+	_, err = h.Send(message.New([][]byte{[]byte("third")}))
+	require.NoError(t, err)
+}
+
+func TestHTTPClientBadMaxConcurrency(t *testing.T) {
+	_, err := New(NewConfig(), OptSetMaxConcurrency(-1, ""))
+	assert.EqualError(t, err, "max_concurrency must not be negative: -1")
+
+	_, err = New(NewConfig(), OptSetMaxConcurrency(1, "nope"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+//------------------------------------------------------------------------------
+
+// CompressionAlgorithms lists the content encodings that can be used to
+// compress HTTP bodies.
+var CompressionAlgorithms = []string{"none", "gzip", "zstd"}
+
+// ValidateCompression returns an error if a compression algorithm isn't
+// supported.
+func ValidateCompression(algorithm string) error {
+	for _, a := range CompressionAlgorithms {
+		if a == algorithm {
+			return nil
+		}
+	}
+	if algorithm == "" {
+		return nil
+	}
+	return fmt.Errorf("compression algorithm not recognised: %v", algorithm)
+}
+
+// Compress a body with an algorithm, which is returned unchanged if the
+// algorithm is empty or none.
+func Compress(algorithm string, b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch algorithm {
+	case "", "none":
+		return b, nil
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zstd":
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("compression algorithm not recognised: %v", algorithm)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress wraps a reader of a body with a decompressor matching a
+// Content-Encoding header value.
+func Decompress(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return ioutil.NopCloser(r), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "zstd":
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("content encoding not supported: %v", encoding)
+}
+
+// AcceptsEncoding returns true if an Accept-Encoding header value permits a
+// given content encoding.
+func AcceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, spec := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(spec, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != encoding && name != "*" {
+			continue
+		}
+		for _, p := range params[1:] {
+			if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressDecompress(t *testing.T) {
+	for _, algo := range []string{"gzip", "zstd"} {
+		compressed, err := Compress(algo, []byte("hello world"))
+		require.NoError(t, err, algo)
+		assert.NotEqual(t, "hello world", string(compressed), algo)
+
+		r, err := Decompress(algo, bytes.NewReader(compressed))
+		require.NoError(t, err, algo)
+		res, err := ioutil.ReadAll(r)
+		require.NoError(t, err, algo)
+		assert.Equal(t, "hello world", string(res), algo)
+	}
+
+	_, err := Compress("nope", []byte("hello world"))
+	assert.Error(t, err)
+
+	_, err = Decompress("nope", bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		encoding string
+		accepted bool
+	}{
+		{header: "gzip, deflate", encoding: "gzip", accepted: true},
+		{header: "gzip, deflate", encoding: "zstd", accepted: false},
+		{header: "zstd;q=0.5, gzip", encoding: "zstd", accepted: true},
+		{header: "zstd;q=0, gzip", encoding: "zstd", accepted: false},
+		{header: "*", encoding: "zstd", accepted: true},
+		{header: "", encoding: "gzip", accepted: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.accepted, AcceptsEncoding(test.header, test.encoding), test.header)
+	}
+}
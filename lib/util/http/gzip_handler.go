@@ -5,16 +5,18 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 //------------------------------------------------------------------------------
 
-type gzipResponseWriter struct {
+type compressResponseWriter struct {
 	io.Writer
 	http.ResponseWriter
 }
 
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
+func (w compressResponseWriter) Write(b []byte) (int, error) {
 	if w.Header().Get("Content-Type") == "" {
 		// If no content type, apply sniffing algorithm to un-gzipped body.
 		w.Header().Set("Content-Type", http.DetectContentType(b))
@@ -33,9 +35,35 @@ func GzipHandler(fn http.HandlerFunc) http.HandlerFunc {
 		w.Header().Set("Content-Encoding", "gzip")
 		gz := gzip.NewWriter(w)
 		defer gz.Close()
-		gzr := gzipResponseWriter{Writer: gz, ResponseWriter: w}
+		gzr := compressResponseWriter{Writer: gz, ResponseWriter: w}
 		fn(gzr, r)
 	}
 }
 
+// CompressHandler wraps a handlerfunc with a handler that automatically
+// compresses outbound messages with an algorithm when it is accepted by the
+// client.
+func CompressHandler(algorithm string, fn http.HandlerFunc) http.HandlerFunc {
+	switch algorithm {
+	case "", "none":
+		return fn
+	case "gzip":
+		return GzipHandler(fn)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !AcceptsEncoding(r.Header.Get("Accept-Encoding"), algorithm) {
+			fn(w, r)
+			return
+		}
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			fn(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", algorithm)
+		defer zw.Close()
+		fn(compressResponseWriter{Writer: zw, ResponseWriter: w}, r)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -0,0 +1,27 @@
+package proxy
+
+import "github.com/Jeffail/benthos/v3/internal/docs"
+
+// FieldSpecs returns the field specs for routing connections through a proxy.
+func FieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString(
+			"proxy_url", "An optional URL of a proxy to route connections through, which overrides proxies configured with environment variables. The schemes `http`, `https` and `socks5` are supported. Failures to connect to the proxy, or to tunnel through it, are reported separately from those of the target and counted by the metric `error.proxy`.",
+			"http://proxy.example.com:3128", "socks5://localhost:1080",
+		).Advanced(),
+		docs.FieldAdvanced("proxy_basic_auth",
+			"Allows you to specify basic authentication with the proxy, which overrides any credentials within `proxy_url`.",
+		).WithChildren(
+			docs.FieldCommon(
+				"enabled", "Whether to authenticate with the proxy.",
+			).HasType(docs.FieldTypeBool).HasDefault(false),
+
+			docs.FieldString("username", "A username to authenticate as.").HasDefault(""),
+			docs.FieldString("password", "A password to authenticate with.").HasDefault(""),
+		).AtVersion("3.50.0"),
+		docs.FieldString(
+			"no_proxy", "A list of hosts that are connected to directly rather than through the proxy. Each entry can be a domain, which also matches its subdomains, an IP address, a CIDR range or `*` to match all hosts. Domains and IP addresses can be followed by a port.",
+			[]string{"localhost", ".internal.example.com", "10.0.0.0/8"},
+		).Array().Advanced().AtVersion("3.50.0"),
+	}
+}
@@ -0,0 +1,362 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	xproxy "golang.org/x/net/proxy"
+)
+
+//------------------------------------------------------------------------------
+
+// Config describes a proxy to route the connections of a component through.
+type Config struct {
+	URL       string
+	BasicAuth auth.BasicAuthConfig
+	NoProxy   []string
+}
+
+// Error is returned when a connection could not be established with, or
+// tunnelled through, a proxy. This allows failures of the proxy to be
+// distinguished from those of the target.
+type Error struct {
+	URL string
+	Err error
+}
+
+// Error returns a human readable error string.
+func (e *Error) Error() string {
+	return fmt.Sprintf("failed to connect through proxy %v: %v", e.URL, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsError returns true if an error, or any error it wraps, is a proxy Error.
+func IsError(err error) bool {
+	var pErr *Error
+	return errors.As(err, &pErr)
+}
+
+//------------------------------------------------------------------------------
+
+// Dialer establishes connections through a proxy, or directly for addresses
+// that match the no proxy list.
+type Dialer struct {
+	url      *url.URL
+	addr     string
+	redacted string
+	noProxy  []noProxyRule
+
+	forward *net.Dialer
+	socks   xproxy.ContextDialer
+}
+
+// NewDialer creates a dialer from a proxy config, or returns nil if a proxy
+// URL has not been configured.
+func NewDialer(conf Config) (*Dialer, error) {
+	if conf.URL == "" {
+		return nil, nil
+	}
+
+	pURL, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
+	}
+	if conf.BasicAuth.Enabled {
+		pURL.User = url.UserPassword(conf.BasicAuth.Username, conf.BasicAuth.Password)
+	}
+
+	var defaultPort string
+	switch pURL.Scheme {
+	case "http":
+		defaultPort = "80"
+	case "https":
+		defaultPort = "443"
+	case "socks5", "socks5h":
+		defaultPort = "1080"
+	default:
+		return nil, fmt.Errorf("proxy_url scheme not supported: %v", pURL.Scheme)
+	}
+	if pURL.Hostname() == "" {
+		return nil, fmt.Errorf("proxy_url does not specify a host: %v", conf.URL)
+	}
+
+	port := pURL.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	d := &Dialer{
+		url:      pURL,
+		addr:     net.JoinHostPort(pURL.Hostname(), port),
+		redacted: pURL.Redacted(),
+		forward: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+
+	for _, entry := range conf.NoProxy {
+		for _, e := range strings.Split(entry, ",") {
+			if e = strings.TrimSpace(e); e == "" {
+				continue
+			}
+			rule, err := parseNoProxyRule(e)
+			if err != nil {
+				return nil, err
+			}
+			d.noProxy = append(d.noProxy, rule)
+		}
+	}
+
+	if pURL.Scheme == "socks5" || pURL.Scheme == "socks5h" {
+		var sAuth *xproxy.Auth
+		if pURL.User != nil {
+			sAuth = &xproxy.Auth{User: pURL.User.Username()}
+			sAuth.Password, _ = pURL.User.Password()
+		}
+		sDialer, err := xproxy.SOCKS5("tcp", d.addr, sAuth, d.forward)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if d.socks, ok = sDialer.(xproxy.ContextDialer); !ok {
+			return nil, errors.New("socks5 dialer does not support contexts")
+		}
+	}
+	return d, nil
+}
+
+// ProxyForRequest is a function for the Proxy field of an http.Transport,
+// which returns the proxy URL for plain HTTP requests so that they are
+// forwarded by an HTTP proxy. All other requests are tunnelled by DialContext.
+func (d *Dialer) ProxyForRequest(req *http.Request) (*url.URL, error) {
+	if req.URL.Scheme != "http" || (d.url.Scheme != "http" && d.url.Scheme != "https") {
+		return nil, nil
+	}
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+	}
+	if d.bypass(req.URL.Hostname(), port) {
+		return nil, nil
+	}
+	return d.url, nil
+}
+
+// Transport applies the dialer to an HTTP transport.
+func (d *Dialer) Transport(tr *http.Transport) {
+	tr.Proxy = d.ProxyForRequest
+	tr.DialContext = d.DialContext
+}
+
+// DialContext connects to an address through the proxy. Any failure to
+// establish a connection with the proxy, or to tunnel through it, results in
+// an *Error.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	// Plain HTTP requests forwarded by the proxy result in a dial of the proxy
+	// itself.
+	if addr == d.addr {
+		conn, err := d.forward.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, d.wrapErr(err)
+		}
+		return conn, nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if d.bypass(host, port) {
+		return d.forward.DialContext(ctx, network, addr)
+	}
+
+	if d.socks != nil {
+		conn, err := d.socks.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, d.wrapErr(err)
+		}
+		return conn, nil
+	}
+
+	conn, err := d.forward.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, d.wrapErr(err)
+	}
+	if conn, err = d.tunnel(ctx, conn, addr); err != nil {
+		return nil, d.wrapErr(err)
+	}
+	return conn, nil
+}
+
+func (d *Dialer) wrapErr(err error) error {
+	return &Error{URL: d.redacted, Err: err}
+}
+
+// tunnel establishes a tunnel to an address through an HTTP proxy with a
+// CONNECT request, and closes the connection on failure.
+func (d *Dialer) tunnel(ctx context.Context, conn net.Conn, addr string) (tConn net.Conn, err error) {
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	// Abort the exchange by expiring the connection deadline when the context
+	// ends. The watcher must exit before the connection is returned, otherwise
+	// it could expire a connection that is in use.
+	exchangeDone, watchDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Unix(1, 0))
+		case <-exchangeDone:
+		}
+	}()
+	defer func() {
+		close(exchangeDone)
+		<-watchDone
+		if err == nil && ctx.Err() != nil {
+			tConn, err = nil, ctx.Err()
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if d.url.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName: d.url.Hostname(),
+		})
+		if err = tlsConn.Handshake(); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if u := d.url.User; u != nil {
+		password, _ := u.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString(
+			[]byte(u.Username()+":"+password),
+		))
+	}
+	if err = req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("CONNECT to %v rejected with status: %v", addr, res.Status)
+	}
+	if br.Buffered() > 0 {
+		return nil, fmt.Errorf("unexpected data received from proxy after CONNECT to %v", addr)
+	}
+
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+//------------------------------------------------------------------------------
+
+// noProxyRule matches hosts that should be connected to directly.
+type noProxyRule struct {
+	all    bool
+	ipNet  *net.IPNet
+	ip     net.IP
+	domain string
+	port   string
+}
+
+// parseNoProxyRule parses an entry of a no proxy list, which can be a domain,
+// where subdomains also match, an IP address, a CIDR range, or a wildcard
+// matching all hosts. Domains and IP addresses can specify a port.
+func parseNoProxyRule(entry string) (noProxyRule, error) {
+	entry = strings.ToLower(entry)
+	if entry == "*" {
+		return noProxyRule{all: true}, nil
+	}
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return noProxyRule{}, fmt.Errorf("failed to parse no_proxy entry '%v': %v", entry, err)
+		}
+		return noProxyRule{ipNet: ipNet}, nil
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		return noProxyRule{ip: ip}, nil
+	}
+
+	var rule noProxyRule
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		rule.port = port
+		entry = host
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		rule.ip = ip
+		return rule, nil
+	}
+	rule.domain = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+	if rule.domain == "" {
+		return noProxyRule{}, fmt.Errorf("failed to parse no_proxy entry '%v': empty domain", entry)
+	}
+	return rule, nil
+}
+
+func (r noProxyRule) matches(host, port string) bool {
+	if r.all {
+		return true
+	}
+	if r.port != "" && r.port != port {
+		return false
+	}
+	if r.ipNet != nil || r.ip != nil {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return false
+		}
+		if r.ipNet != nil {
+			return r.ipNet.Contains(ip)
+		}
+		return r.ip.Equal(ip)
+	}
+	return host == r.domain || strings.HasSuffix(host, "."+r.domain)
+}
+
+// bypass returns true if a host should be connected to directly.
+func (d *Dialer) bypass(host, port string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, r := range d.noProxy {
+		if r.matches(host, port) {
+			return true
+		}
+	}
+	return false
+}
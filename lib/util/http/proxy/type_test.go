@@ -0,0 +1,274 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProxy struct {
+	mut       sync.Mutex
+	forwarded []string
+	connected []string
+
+	server *httptest.Server
+}
+
+// newTestProxy creates an HTTP proxy that tunnels CONNECT requests and
+// forwards all others, requiring basic authentication when a username is
+// provided.
+func newTestProxy(t *testing.T, username, password string) *testProxy {
+	t.Helper()
+
+	p := &testProxy{}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username != "" {
+			pReq := &http.Request{Header: http.Header{
+				"Authorization": r.Header["Proxy-Authorization"],
+			}}
+			if u, pw, ok := pReq.BasicAuth(); !ok || u != username || pw != password {
+				http.Error(w, "nope", http.StatusProxyAuthRequired)
+				return
+			}
+		}
+
+		if r.Method != http.MethodConnect {
+			p.mut.Lock()
+			p.forwarded = append(p.forwarded, r.Host)
+			p.mut.Unlock()
+
+			r.RequestURI = ""
+			r.Header.Del("Proxy-Authorization")
+			res, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			defer res.Body.Close()
+			w.WriteHeader(res.StatusCode)
+			_, _ = io.Copy(w, res.Body)
+			return
+		}
+
+		p.mut.Lock()
+		p.connected = append(p.connected, r.Host)
+		p.mut.Unlock()
+
+		tConn, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			tConn.Close()
+			return
+		}
+		if _, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			tConn.Close()
+			conn.Close()
+			return
+		}
+		go func() {
+			_, _ = io.Copy(tConn, conn)
+			tConn.Close()
+		}()
+		_, _ = io.Copy(conn, tConn)
+		conn.Close()
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *testProxy) seen() (forwarded, connected []string) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return append([]string(nil), p.forwarded...), append([]string(nil), p.connected...)
+}
+
+func testClient(t *testing.T, conf Config) *http.Client {
+	t.Helper()
+
+	d, err := NewDialer(conf)
+	require.NoError(t, err)
+	require.NotNil(t, d)
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	d.Transport(tr)
+	return &http.Client{Transport: tr}
+}
+
+func getBody(t *testing.T, client *http.Client, url string) (string, error) {
+	t.Helper()
+
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(b), nil
+}
+
+func TestProxyHTTPTargets(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plain"))
+	}))
+	t.Cleanup(target.Close)
+
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	t.Cleanup(tlsTarget.Close)
+
+	p := newTestProxy(t, "foo", "bar")
+
+	basicAuth := auth.NewBasicAuthConfig()
+	basicAuth.Enabled = true
+	basicAuth.Username = "foo"
+	basicAuth.Password = "bar"
+
+	client := testClient(t, Config{
+		URL:       p.server.URL,
+		BasicAuth: basicAuth,
+	})
+
+	body, err := getBody(t, client, target.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "plain", body)
+
+	body, err = getBody(t, client, tlsTarget.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "secure", body)
+
+	forwarded, connected := p.seen()
+	assert.Equal(t, []string{target.Listener.Addr().String()}, forwarded)
+	assert.Equal(t, []string{tlsTarget.Listener.Addr().String()}, connected)
+}
+
+func TestProxyErrors(t *testing.T) {
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	t.Cleanup(tlsTarget.Close)
+
+	p := newTestProxy(t, "foo", "bar")
+
+	// Missing credentials result in the CONNECT being rejected.
+	_, err := getBody(t, testClient(t, Config{URL: p.server.URL}), tlsTarget.URL)
+	require.Error(t, err)
+	assert.True(t, IsError(err), err.Error())
+	assert.Contains(t, err.Error(), "407")
+
+	// Credentials within the URL are used.
+	pURLWithCreds := "http://foo:bar@" + p.server.Listener.Addr().String()
+	body, err := getBody(t, testClient(t, Config{URL: pURLWithCreds}), tlsTarget.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "secure", body)
+
+	// A target that cannot be reached through the proxy.
+	deadTarget := httptest.NewTLSServer(http.NotFoundHandler())
+	deadURL := deadTarget.URL
+	deadTarget.Close()
+
+	_, err = getBody(t, testClient(t, Config{URL: pURLWithCreds}), deadURL)
+	require.Error(t, err)
+	assert.True(t, IsError(err), err.Error())
+
+	// A proxy that cannot be reached, for both forwarded and tunnelled
+	// requests. Credentials are redacted from the error.
+	p.server.Close()
+	for _, u := range []string{"http://" + tlsTarget.Listener.Addr().String(), tlsTarget.URL} {
+		_, err = getBody(t, testClient(t, Config{URL: pURLWithCreds}), u)
+		require.Error(t, err)
+		assert.True(t, IsError(err), err.Error())
+		assert.NotContains(t, err.Error(), "bar@")
+	}
+
+	// Errors of the target are not proxy errors.
+	tlsTarget.Close()
+	_, err = getBody(t, &http.Client{}, tlsTarget.URL)
+	require.Error(t, err)
+	assert.False(t, IsError(err))
+}
+
+func TestProxyNoProxy(t *testing.T) {
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	t.Cleanup(tlsTarget.Close)
+
+	p := newTestProxy(t, "", "")
+
+	client := testClient(t, Config{
+		URL:     p.server.URL,
+		NoProxy: []string{"example.com, 127.0.0.0/8"},
+	})
+
+	body, err := getBody(t, client, tlsTarget.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "secure", body)
+
+	forwarded, connected := p.seen()
+	assert.Empty(t, forwarded)
+	assert.Empty(t, connected)
+}
+
+func TestNoProxyRules(t *testing.T) {
+	tests := []struct {
+		rule    string
+		host    string
+		port    string
+		matches bool
+	}{
+		{rule: "*", host: "example.com", port: "443", matches: true},
+		{rule: "example.com", host: "example.com", port: "443", matches: true},
+		{rule: "example.com", host: "foo.example.com", port: "443", matches: true},
+		{rule: ".example.com", host: "foo.example.com", port: "443", matches: true},
+		{rule: "*.example.com", host: "foo.example.com", port: "443", matches: true},
+		{rule: "example.com", host: "badexample.com", port: "443", matches: false},
+		{rule: "example.com:8080", host: "example.com", port: "8080", matches: true},
+		{rule: "example.com:8080", host: "example.com", port: "443", matches: false},
+		{rule: "10.0.0.1", host: "10.0.0.1", port: "443", matches: true},
+		{rule: "10.0.0.1", host: "10.0.0.2", port: "443", matches: false},
+		{rule: "10.0.0.1:80", host: "10.0.0.1", port: "443", matches: false},
+		{rule: "10.0.0.0/8", host: "10.1.2.3", port: "443", matches: true},
+		{rule: "10.0.0.0/8", host: "11.1.2.3", port: "443", matches: false},
+		{rule: "10.0.0.0/8", host: "example.com", port: "443", matches: false},
+		{rule: "::1", host: "::1", port: "443", matches: true},
+		{rule: "[::1]:80", host: "::1", port: "80", matches: true},
+	}
+
+	for _, test := range tests {
+		rule, err := parseNoProxyRule(test.rule)
+		require.NoError(t, err, test.rule)
+		assert.Equal(t, test.matches, rule.matches(test.host, test.port), "%v: %v:%v", test.rule, test.host, test.port)
+	}
+
+	_, err := parseNoProxyRule("10.0.0.0/nope")
+	assert.Error(t, err)
+}
+
+func TestNewDialerErrors(t *testing.T) {
+	d, err := NewDialer(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	for _, u := range []string{"ftp://foo:21", "http://", "%%"} {
+		_, err = NewDialer(Config{URL: u})
+		assert.Error(t, err, u)
+	}
+}
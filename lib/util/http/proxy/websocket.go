@@ -0,0 +1,16 @@
+package proxy
+
+import "github.com/gorilla/websocket"
+
+// WebsocketDialer returns a websocket dialer that connects through a proxy, or
+// the default dialer if a proxy URL is not configured.
+func WebsocketDialer(conf Config) (*websocket.Dialer, error) {
+	d, err := NewDialer(conf)
+	if err != nil || d == nil {
+		return websocket.DefaultDialer, err
+	}
+	return &websocket.Dialer{
+		NetDialContext:   d.DialContext,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+	}, nil
+}
@@ -19,10 +19,42 @@ func FieldSpec() docs.FieldSpec {
 			"enable_renegotiation", "Whether to allow the remote server to repeatedly request renegotiation. Enable this option if you're seeing the error message `local error: tls: no renegotiation`.",
 		).AtVersion("3.45.0").HasType(docs.FieldTypeBool).HasDefault(false),
 
+		docs.FieldString(
+			"root_cas", "An optional root certificate authority to use. This is a string, representing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate.",
+			"-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----",
+		).HasDefault("").AtVersion("3.50.0"),
+
 		docs.FieldString(
 			"root_cas_file", "An optional path of a root certificate authority file to use. This is a file, often with a .pem extension, containing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate.", "./root_cas.pem",
 		).HasDefault(""),
 
+		docs.FieldAdvanced(
+			"server_name", "An optional server name used to verify the hostname of the returned certificate, and which is sent to the server as part of the TLS handshake for virtual hosting (SNI). By default the hostname of the target address is used.",
+			"redis.example.com",
+		).HasType(docs.FieldTypeString).HasDefault("").AtVersion("3.50.0"),
+
+		docs.FieldAdvanced(
+			"min_version", "The minimum TLS version to accept, one of `1.0`, `1.1`, `1.2` or `1.3`. Defaults to `1.2` when left empty.", "1.3",
+		).HasType(docs.FieldTypeString).HasDefault("").AtVersion("3.50.0"),
+
+		docs.FieldAdvanced(
+			"max_version", "The maximum TLS version to accept, one of `1.0`, `1.1`, `1.2` or `1.3`. Defaults to the highest version supported when left empty.", "1.2",
+		).HasType(docs.FieldTypeString).HasDefault("").AtVersion("3.50.0"),
+
+		docs.FieldAdvanced(
+			"alpn_protocols", "An optional list of application protocols to offer during the TLS handshake with application-layer protocol negotiation (ALPN), in order of preference.",
+			[]string{"h2", "http/1.1"},
+		).Array().HasType(docs.FieldTypeString).HasDefault([]interface{}{}).AtVersion("3.50.0"),
+
+		docs.FieldAdvanced(
+			"curve_preferences", "An optional list of elliptic curves to use for key exchanges, in order of preference. Valid values are `X25519`, `P256`, `P384` and `P521`. By default the curves preferred by Go are used.",
+			[]string{"X25519", "P256"},
+		).Array().HasType(docs.FieldTypeString).HasDefault([]interface{}{}).AtVersion("3.50.0"),
+
+		docs.FieldAdvanced(
+			"disable_session_tickets", "Whether to disable session resumption with session tickets, which forces a full TLS handshake for each connection.",
+		).HasType(docs.FieldTypeBool).HasDefault(false).AtVersion("3.50.0"),
+
 		docs.FieldCommon(
 			"client_certs", "A list of client certificates to use. For each certificate either the fields `cert` and `key`, or `cert_file` and `key_file` should be specified, but not both.",
 			[]interface{}{
@@ -43,5 +75,31 @@ func FieldSpec() docs.FieldSpec {
 			docs.FieldString("cert_file", "The path to a certificate to use.").HasDefault(""),
 			docs.FieldString("key_file", "The path of a certificate key to use.").HasDefault(""),
 		),
-	)
+	).Linter(lintConfig)
+}
+
+func lintConfig(ctx docs.LintContext, line, col int, value interface{}) []docs.Lint {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var lints []docs.Lint
+	minStr, _ := obj["min_version"].(string)
+	maxStr, _ := obj["max_version"].(string)
+	if _, _, err := versionRange(minStr, maxStr); err != nil {
+		lints = append(lints, docs.NewLintError(line, err.Error()))
+	}
+
+	if curveArr, ok := obj["curve_preferences"].([]interface{}); ok {
+		var names []string
+		for _, c := range curveArr {
+			name, _ := c.(string)
+			names = append(names, name)
+		}
+		if _, err := curveIDs(names); err != nil {
+			lints = append(lints, docs.NewLintError(line, err.Error()))
+		}
+	}
+	return lints
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io/ioutil"
 )
 
@@ -40,26 +41,98 @@ type ClientCertConfig struct {
 
 // Config contains configuration params for TLS.
 type Config struct {
-	Enabled             bool               `json:"enabled" yaml:"enabled"`
-	RootCAsFile         string             `json:"root_cas_file" yaml:"root_cas_file"`
-	InsecureSkipVerify  bool               `json:"skip_cert_verify" yaml:"skip_cert_verify"`
-	ClientCertificates  []ClientCertConfig `json:"client_certs" yaml:"client_certs"`
-	EnableRenegotiation bool               `json:"enable_renegotiation" yaml:"enable_renegotiation"`
+	Enabled               bool               `json:"enabled" yaml:"enabled"`
+	RootCAs               string             `json:"root_cas" yaml:"root_cas"`
+	RootCAsFile           string             `json:"root_cas_file" yaml:"root_cas_file"`
+	ServerName            string             `json:"server_name" yaml:"server_name"`
+	InsecureSkipVerify    bool               `json:"skip_cert_verify" yaml:"skip_cert_verify"`
+	ClientCertificates    []ClientCertConfig `json:"client_certs" yaml:"client_certs"`
+	EnableRenegotiation   bool               `json:"enable_renegotiation" yaml:"enable_renegotiation"`
+	MinVersion            string             `json:"min_version" yaml:"min_version"`
+	MaxVersion            string             `json:"max_version" yaml:"max_version"`
+	ALPNProtocols         []string           `json:"alpn_protocols" yaml:"alpn_protocols"`
+	CurvePreferences      []string           `json:"curve_preferences" yaml:"curve_preferences"`
+	DisableSessionTickets bool               `json:"disable_session_tickets" yaml:"disable_session_tickets"`
 }
 
 // NewConfig creates a new Config with default values.
 func NewConfig() Config {
 	return Config{
-		Enabled:             false,
-		RootCAsFile:         "",
-		InsecureSkipVerify:  false,
-		ClientCertificates:  []ClientCertConfig{},
-		EnableRenegotiation: false,
+		Enabled:               false,
+		RootCAs:               "",
+		RootCAsFile:           "",
+		ServerName:            "",
+		InsecureSkipVerify:    false,
+		ClientCertificates:    []ClientCertConfig{},
+		EnableRenegotiation:   false,
+		MinVersion:            "",
+		MaxVersion:            "",
+		ALPNProtocols:         []string{},
+		CurvePreferences:      []string{},
+		DisableSessionTickets: false,
 	}
 }
 
 //------------------------------------------------------------------------------
 
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var curves = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+}
+
+// defaultMinVersion is the minimum TLS version used when min_version is not
+// specified.
+const defaultMinVersion = tls.VersionTLS12
+
+// versionRange returns the minimum and maximum TLS versions described by a pair
+// of version strings, where an empty maximum is returned as zero in order to
+// indicate the highest version supported.
+func versionRange(minStr, maxStr string) (min, max uint16, err error) {
+	min = defaultMinVersion
+	if minStr != "" {
+		var exists bool
+		if min, exists = versions[minStr]; !exists {
+			return 0, 0, fmt.Errorf("min_version not recognised: %v", minStr)
+		}
+	}
+	if maxStr != "" {
+		var exists bool
+		if max, exists = versions[maxStr]; !exists {
+			return 0, 0, fmt.Errorf("max_version not recognised: %v", maxStr)
+		}
+		if max < min {
+			if minStr == "" {
+				minStr = "1.2"
+			}
+			return 0, 0, fmt.Errorf("max_version %v must not be lower than min_version %v", maxStr, minStr)
+		}
+	}
+	return min, max, nil
+}
+
+func curveIDs(names []string) ([]tls.CurveID, error) {
+	ids := make([]tls.CurveID, 0, len(names))
+	for _, name := range names {
+		id, exists := curves[name]
+		if !exists {
+			return nil, fmt.Errorf("curve_preferences value not recognised: %v", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+//------------------------------------------------------------------------------
+
 // Get returns a valid *tls.Config based on the configuration values of Config.
 // If none of the config fields are set then a nil config is returned.
 func (c *Config) Get() (*tls.Config, error) {
@@ -73,6 +146,20 @@ func (c *Config) Get() (*tls.Config, error) {
 		}
 	}
 
+	minVersion, maxVersion, err := versionRange(c.MinVersion, c.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	curvePrefs, err := curveIDs(c.CurvePreferences)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.RootCAs) > 0 && len(c.RootCAsFile) > 0 {
+		return nil, errors.New("only one field between root_cas and root_cas_file can be specified")
+	}
+
 	if len(c.RootCAsFile) > 0 {
 		caCert, err := ioutil.ReadFile(c.RootCAsFile)
 		if err != nil {
@@ -83,6 +170,14 @@ func (c *Config) Get() (*tls.Config, error) {
 		tlsConf.RootCAs.AppendCertsFromPEM(caCert)
 	}
 
+	if len(c.RootCAs) > 0 {
+		initConf()
+		tlsConf.RootCAs = x509.NewCertPool()
+		if !tlsConf.RootCAs.AppendCertsFromPEM([]byte(c.RootCAs)) {
+			return nil, errors.New("failed to parse any certificates from root_cas")
+		}
+	}
+
 	for _, conf := range c.ClientCertificates {
 		cert, err := conf.Load()
 		if err != nil {
@@ -102,6 +197,32 @@ func (c *Config) Get() (*tls.Config, error) {
 		tlsConf.InsecureSkipVerify = true
 	}
 
+	if len(c.ServerName) > 0 {
+		initConf()
+		tlsConf.ServerName = c.ServerName
+	}
+
+	if c.MinVersion != "" || c.MaxVersion != "" {
+		initConf()
+		tlsConf.MinVersion = minVersion
+		tlsConf.MaxVersion = maxVersion
+	}
+
+	if len(c.ALPNProtocols) > 0 {
+		initConf()
+		tlsConf.NextProtos = append([]string{}, c.ALPNProtocols...)
+	}
+
+	if len(curvePrefs) > 0 {
+		initConf()
+		tlsConf.CurvePreferences = curvePrefs
+	}
+
+	if c.DisableSessionTickets {
+		initConf()
+		tlsConf.SessionTicketsDisabled = true
+	}
+
 	return tlsConf, nil
 }
 
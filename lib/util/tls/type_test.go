@@ -0,0 +1,213 @@
+package tls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   func(c *Config)
+		exp    *tls.Config
+		errStr string
+	}{
+		{
+			name: "empty",
+			conf: func(c *Config) {},
+			exp:  nil,
+		},
+		{
+			name: "server name",
+			conf: func(c *Config) {
+				c.ServerName = "foo.example.com"
+			},
+			exp: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				ServerName: "foo.example.com",
+			},
+		},
+		{
+			name: "skip cert verify",
+			conf: func(c *Config) {
+				c.InsecureSkipVerify = true
+			},
+			exp: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: true,
+			},
+		},
+		{
+			name: "renegotiation",
+			conf: func(c *Config) {
+				c.EnableRenegotiation = true
+			},
+			exp: &tls.Config{
+				MinVersion:    tls.VersionTLS12,
+				Renegotiation: tls.RenegotiateFreelyAsClient,
+			},
+		},
+		{
+			name: "min version",
+			conf: func(c *Config) {
+				c.MinVersion = "1.3"
+			},
+			exp: &tls.Config{
+				MinVersion: tls.VersionTLS13,
+			},
+		},
+		{
+			name: "min and max version",
+			conf: func(c *Config) {
+				c.MinVersion = "1.0"
+				c.MaxVersion = "1.2"
+			},
+			exp: &tls.Config{
+				MinVersion: tls.VersionTLS10,
+				MaxVersion: tls.VersionTLS12,
+			},
+		},
+		{
+			name: "max version",
+			conf: func(c *Config) {
+				c.MaxVersion = "1.3"
+			},
+			exp: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				MaxVersion: tls.VersionTLS13,
+			},
+		},
+		{
+			name: "max lower than min",
+			conf: func(c *Config) {
+				c.MinVersion = "1.3"
+				c.MaxVersion = "1.2"
+			},
+			errStr: "max_version 1.2 must not be lower than min_version 1.3",
+		},
+		{
+			name: "max lower than default min",
+			conf: func(c *Config) {
+				c.MaxVersion = "1.1"
+			},
+			errStr: "max_version 1.1 must not be lower than min_version 1.2",
+		},
+		{
+			name: "bad version",
+			conf: func(c *Config) {
+				c.MinVersion = "1.4"
+			},
+			errStr: "min_version not recognised: 1.4",
+		},
+		{
+			name: "alpn protocols",
+			conf: func(c *Config) {
+				c.ALPNProtocols = []string{"h2", "http/1.1"}
+			},
+			exp: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				NextProtos: []string{"h2", "http/1.1"},
+			},
+		},
+		{
+			name: "curve preferences",
+			conf: func(c *Config) {
+				c.CurvePreferences = []string{"X25519", "P384"}
+			},
+			exp: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP384},
+			},
+		},
+		{
+			name: "bad curve",
+			conf: func(c *Config) {
+				c.CurvePreferences = []string{"P224"}
+			},
+			errStr: "curve_preferences value not recognised: P224",
+		},
+		{
+			name: "disable session tickets",
+			conf: func(c *Config) {
+				c.DisableSessionTickets = true
+			},
+			exp: &tls.Config{
+				MinVersion:             tls.VersionTLS12,
+				SessionTicketsDisabled: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			test.conf(&conf)
+
+			tlsConf, err := conf.Get()
+			if test.errStr != "" {
+				assert.EqualError(t, err, test.errStr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, tlsConf)
+		})
+	}
+}
+
+func TestConfigLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lints []string
+	}{
+		{
+			name: "valid",
+			input: `
+tls:
+  enabled: true
+  min_version: "1.2"
+  max_version: "1.3"
+  curve_preferences: [ X25519 ]
+`,
+		},
+		{
+			name: "max lower than min",
+			input: `
+tls:
+  enabled: true
+  min_version: "1.3"
+  max_version: "1.2"
+`,
+			lints: []string{"max_version 1.2 must not be lower than min_version 1.3"},
+		},
+		{
+			name: "bad curve",
+			input: `
+tls:
+  curve_preferences: [ X25519, nope ]
+`,
+			lints: []string{"curve_preferences value not recognised: nope"},
+		},
+	}
+
+	spec := docs.FieldSpecs{FieldSpec()}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			var lints []string
+			for _, l := range spec.LintYAML(docs.NewLintContext(), node.Content[0]) {
+				lints = append(lints, l.What)
+			}
+			assert.Equal(t, test.lints, lints)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package bloblang
 
 import (
+	"fmt"
+
 	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 )
@@ -105,6 +107,81 @@ func (e *Environment) RegisterFunction(name string, ctor FunctionConstructor) er
 	)
 }
 
+// RegisterMethodV2 adds a new Bloblang method to the environment using a
+// provided PluginSpec to define the name of the method and its parameters.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case), and must not collide with existing methods.
+func (e *Environment) RegisterMethodV2(name string, spec *PluginSpec, ctor MethodConstructorV2) error {
+	qSpec, qCtor, err := methodPlugin(name, spec, ctor)
+	if err != nil {
+		return err
+	}
+	return e.methods.Add(qSpec, qCtor, true)
+}
+
+// RegisterFunctionV2 adds a new Bloblang function to the environment using a
+// provided PluginSpec to define the name of the function and its parameters.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case), and must not collide with existing functions.
+func (e *Environment) RegisterFunctionV2(name string, spec *PluginSpec, ctor FunctionConstructorV2) error {
+	qSpec, qCtor, err := functionPlugin(name, spec, ctor)
+	if err != nil {
+		return err
+	}
+	return e.functions.Add(qSpec, qCtor, true)
+}
+
+//------------------------------------------------------------------------------
+
+func methodPlugin(name string, spec *PluginSpec, ctor MethodConstructorV2) (query.MethodSpec, query.MethodCtor, error) {
+	if err := spec.validate(); err != nil {
+		return query.MethodSpec{}, nil, fmt.Errorf("method %v: %w", name, err)
+	}
+	qSpec := query.NewMethodSpec(name, spec.docsDescription(), spec.examples...).
+		InCategory(query.MethodCategoryPlugin, "")
+	qSpec.Params = spec.paramSpecs()
+	return qSpec, func(target query.Function, args ...interface{}) (query.Function, error) {
+		parsedArgs, err := spec.parseArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := ctor(parsedArgs)
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("method "+name, func(ctx query.FunctionContext) (interface{}, error) {
+			v, err := target.Exec(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return fn(v)
+		}, target.QueryTargets), nil
+	}, nil
+}
+
+func functionPlugin(name string, spec *PluginSpec, ctor FunctionConstructorV2) (query.FunctionSpec, query.FunctionCtor, error) {
+	if err := spec.validate(); err != nil {
+		return query.FunctionSpec{}, nil, fmt.Errorf("function %v: %w", name, err)
+	}
+	qSpec := query.NewFunctionSpec(query.FunctionCategoryPlugin, name, spec.docsDescription(), spec.examples...)
+	qSpec.Params = spec.paramSpecs()
+	return qSpec, func(args ...interface{}) (query.Function, error) {
+		parsedArgs, err := spec.parseArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := ctor(parsedArgs)
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function "+name, func(ctx query.FunctionContext) (interface{}, error) {
+			return fn()
+		}, nil), nil
+	}, nil
+}
+
 //------------------------------------------------------------------------------
 
 // Parse a Bloblang mapping allowing the use of the globally accessible range of
@@ -161,3 +238,35 @@ func RegisterFunction(name string, ctor FunctionConstructor) error {
 		true,
 	)
 }
+
+// RegisterMethodV2 adds a new Bloblang method to the global environment using
+// a provided PluginSpec to define the name of the method and its parameters.
+// Once registered the method can be used within all mappings and
+// interpolations of the service, and is also validated when linting configs.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case), and must not collide with existing methods, including those
+// that are built in.
+func RegisterMethodV2(name string, spec *PluginSpec, ctor MethodConstructorV2) error {
+	qSpec, qCtor, err := methodPlugin(name, spec, ctor)
+	if err != nil {
+		return err
+	}
+	return query.AllMethods.Add(qSpec, qCtor, true)
+}
+
+// RegisterFunctionV2 adds a new Bloblang function to the global environment
+// using a provided PluginSpec to define the name of the function and its
+// parameters. Once registered the function can be used within all mappings and
+// interpolations of the service, and is also validated when linting configs.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case), and must not collide with existing functions, including those
+// that are built in.
+func RegisterFunctionV2(name string, spec *PluginSpec, ctor FunctionConstructorV2) error {
+	qSpec, qCtor, err := functionPlugin(name, spec, ctor)
+	if err != nil {
+		return err
+	}
+	return query.AllFunctions.Add(qSpec, qCtor, true)
+}
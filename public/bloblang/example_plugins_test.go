@@ -134,3 +134,78 @@ root.buz = this.buz.content.custom_thing()
 	fmt.Println(string(jsonBytes))
 	// Output: {"bar":"first bit second bit","buz":"SOME NESTED CONTENT","foo":"50"}
 }
+
+// This example demonstrates how to create Bloblang methods and functions with
+// typed and documented parameters.
+func Example_bloblangPluginsV2() {
+	multiplyWrongSpec := bloblang.NewPluginSpec().
+		Description("Multiplies two numbers, but gets it slightly wrong.").
+		Param(bloblang.NewFloat64Param("left").Description("The first number.")).
+		Param(bloblang.NewFloat64Param("right").Description("The second number."))
+
+	if err := bloblang.RegisterFunctionV2("multiply_but_always_slightly_wrong", multiplyWrongSpec, func(args *bloblang.ParsedParams) (bloblang.Function, error) {
+		left, err := args.GetFloat64("left")
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := args.GetFloat64("right")
+		if err != nil {
+			return nil, err
+		}
+
+		return func() (interface{}, error) {
+			return left*right + 0.02, nil
+		}, nil
+	}); err != nil {
+		panic(err)
+	}
+
+	hugSpec := bloblang.NewPluginSpec().
+		Description("Wraps a string with a prefix and suffix.").
+		Param(bloblang.NewStringParam("prefix")).
+		Param(bloblang.NewStringParam("suffix"))
+
+	if err := bloblang.RegisterMethodV2("hug", hugSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		prefix, err := args.GetString("prefix")
+		if err != nil {
+			return nil, err
+		}
+
+		suffix, err := args.GetString("suffix")
+		if err != nil {
+			return nil, err
+		}
+
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return prefix + s + suffix, nil
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	mapping := `
+root.num = multiply_but_always_slightly_wrong(2, 3)
+root.hugged = this.summary.hug("(", ")")
+`
+
+	exe, err := bloblang.Parse(mapping)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := exe.Query(map[string]interface{}{
+		"summary": "quack",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(jsonBytes))
+	// Output: {"hugged":"(quack)","num":6.02}
+}
@@ -17,3 +17,8 @@ type Function func() (interface{}, error)
 // For a convenient way to perform type checking and coercion on the arguments
 // use an ArgSpec.
 type FunctionConstructor func(args ...interface{}) (Function, error)
+
+// FunctionConstructorV2 defines a constructor for a Bloblang function where
+// parameters are parsed using a PluginSpec provided when registering the
+// function.
+type FunctionConstructorV2 func(args *ParsedParams) (Function, error)
@@ -26,6 +26,11 @@ type Method func(v interface{}) (interface{}, error)
 // use an ArgSpec.
 type MethodConstructor func(args ...interface{}) (Method, error)
 
+// MethodConstructorV2 defines a constructor for a Bloblang method where
+// parameters are parsed using a PluginSpec provided when registering the
+// method.
+type MethodConstructorV2 func(args *ParsedParams) (Method, error)
+
 //------------------------------------------------------------------------------
 
 // StringMethod creates a general method signature from a string method by
@@ -0,0 +1,282 @@
+package bloblang
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+)
+
+var paramNameRegexpRaw = `^[a-z0-9]+(_[a-z0-9]+)*$`
+var paramNameRegexp = regexp.MustCompile(paramNameRegexpRaw)
+
+// ParamDefinition describes a single parameter for a function or method.
+type ParamDefinition struct {
+	name        string
+	description string
+	kind        reflect.Kind
+}
+
+// NewStringParam creates a new string typed parameter. Parameter names must
+// match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/.
+func NewStringParam(name string) ParamDefinition {
+	return ParamDefinition{name: name, kind: reflect.String}
+}
+
+// NewInt64Param creates a new 64-bit integer typed parameter. Parameter names
+// must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/.
+func NewInt64Param(name string) ParamDefinition {
+	return ParamDefinition{name: name, kind: reflect.Int64}
+}
+
+// NewFloat64Param creates a new float64 typed parameter. Parameter names must
+// match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/.
+func NewFloat64Param(name string) ParamDefinition {
+	return ParamDefinition{name: name, kind: reflect.Float64}
+}
+
+// NewBoolParam creates a new bool typed parameter. Parameter names must match
+// the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/.
+func NewBoolParam(name string) ParamDefinition {
+	return ParamDefinition{name: name, kind: reflect.Bool}
+}
+
+// NewAnyParam creates a new parameter that can be any type. Parameter names
+// must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/.
+func NewAnyParam(name string) ParamDefinition {
+	return ParamDefinition{name: name, kind: reflect.Interface}
+}
+
+// Description adds an optional description to the parameter definition, this
+// is used when generating documentation for the parameter to describe what the
+// parameter is for.
+func (d ParamDefinition) Description(str string) ParamDefinition {
+	d.description = str
+	return d
+}
+
+func (d ParamDefinition) kindStr() string {
+	if d.kind == reflect.Interface {
+		return "unknown"
+	}
+	return d.kind.String()
+}
+
+func (d ParamDefinition) extract(index int, v interface{}) (interface{}, error) {
+	switch d.kind {
+	case reflect.String:
+		s, err := query.IGetString(v)
+		if err != nil {
+			return nil, newArgError(index, d.kind, v)
+		}
+		return s, nil
+	case reflect.Int64:
+		i, err := query.IGetInt(v)
+		if err != nil {
+			return nil, newArgError(index, d.kind, v)
+		}
+		return i, nil
+	case reflect.Float64:
+		f, err := query.IGetNumber(v)
+		if err != nil {
+			return nil, newArgError(index, d.kind, v)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := query.IGetBool(v)
+		if err != nil {
+			return nil, newArgError(index, d.kind, v)
+		}
+		return b, nil
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
+// PluginSpec documents and defines the parameters of a function or method and
+// the way in which it should be used.
+//
+// Using a plugin spec with explicit parameters means that arguments provided
+// to instantiations of the plugin are type checked and coerced at parse time
+// when possible, and are accessible by name.
+type PluginSpec struct {
+	description string
+	params      []ParamDefinition
+	examples    []query.ExampleSpec
+}
+
+// NewPluginSpec creates a new plugin definition for a function or method
+// plugin that describes the arguments that the plugin expects.
+func NewPluginSpec() *PluginSpec {
+	return &PluginSpec{}
+}
+
+// Description adds an optional description to the plugin spec, this is used
+// when generating documentation for the plugin.
+func (p *PluginSpec) Description(str string) *PluginSpec {
+	p.description = str
+	return p
+}
+
+// Example adds an optional example to the plugin spec, consisting of a summary,
+// a mapping, and zero or more pairs of input and output documents that the
+// mapping results in.
+func (p *PluginSpec) Example(summary, mapping string, inputOutputs ...[2]string) *PluginSpec {
+	results := make([]string, 0, len(inputOutputs)*2)
+	for _, io := range inputOutputs {
+		results = append(results, io[0], io[1])
+	}
+	p.examples = append(p.examples, query.NewExampleSpec(summary, mapping, results...))
+	return p
+}
+
+// Param adds a parameter to the spec. Instantiations of the plugin with
+// arguments will be validated against the parameters in the order in which
+// they were added.
+func (p *PluginSpec) Param(def ParamDefinition) *PluginSpec {
+	p.params = append(p.params, def)
+	return p
+}
+
+func (p *PluginSpec) validate() error {
+	seen := map[string]struct{}{}
+	for _, param := range p.params {
+		if !paramNameRegexp.MatchString(param.name) {
+			return fmt.Errorf("parameter name '%v' does not match the required regular expression /%v/", param.name, paramNameRegexpRaw)
+		}
+		if _, exists := seen[param.name]; exists {
+			return fmt.Errorf("duplicate parameter name: %v", param.name)
+		}
+		seen[param.name] = struct{}{}
+	}
+	return nil
+}
+
+// docsDescription returns the description of the plugin along with a markdown
+// formatted list of its parameters.
+func (p *PluginSpec) docsDescription() string {
+	if len(p.params) == 0 {
+		return p.description
+	}
+
+	var buf strings.Builder
+	if p.description != "" {
+		buf.WriteString(p.description)
+		buf.WriteString("\n\n")
+	}
+	buf.WriteString("#### Parameters\n\n")
+	for _, param := range p.params {
+		fmt.Fprintf(&buf, "**`%v`** &lt;%v&gt; %v  \n", param.name, param.kindStr(), param.description)
+	}
+	return buf.String()
+}
+
+// paramSpecs returns a structured description of the parameters of the plugin.
+func (p *PluginSpec) paramSpecs() []query.ParamSpec {
+	if len(p.params) == 0 {
+		return nil
+	}
+	specs := make([]query.ParamSpec, len(p.params))
+	for i, param := range p.params {
+		specs[i] = query.ParamSpec{
+			Name:        param.name,
+			Description: param.description,
+			Type:        param.kindStr(),
+		}
+	}
+	return specs
+}
+
+func (p *PluginSpec) parseArgs(args []interface{}) (*ParsedParams, error) {
+	if len(args) != len(p.params) {
+		return nil, fmt.Errorf("expected %v arguments, received %v", len(p.params), len(args))
+	}
+	values := make([]interface{}, len(args))
+	for i, param := range p.params {
+		v, err := param.extract(i, args[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return &ParsedParams{spec: p, values: values}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ParsedParams is a reference to the arguments of a method or function
+// instantiation.
+type ParsedParams struct {
+	spec   *PluginSpec
+	values []interface{}
+}
+
+func (p *ParsedParams) get(name string) (interface{}, error) {
+	for i, param := range p.spec.params {
+		if param.name == name {
+			return p.values[i], nil
+		}
+	}
+	return nil, fmt.Errorf("parameter %v was not found", name)
+}
+
+// Get an argument value with a given name and return it boxed within an empty
+// interface.
+func (p *ParsedParams) Get(name string) (interface{}, error) {
+	return p.get(name)
+}
+
+// GetString returns a string argument value with a given name.
+func (p *ParsedParams) GetString(name string) (string, error) {
+	v, err := p.get(name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %v is not a string", name)
+	}
+	return s, nil
+}
+
+// GetInt64 returns an integer argument value with a given name.
+func (p *ParsedParams) GetInt64(name string) (int64, error) {
+	v, err := p.get(name)
+	if err != nil {
+		return 0, err
+	}
+	i, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("parameter %v is not an int64", name)
+	}
+	return i, nil
+}
+
+// GetFloat64 returns a float argument value with a given name.
+func (p *ParsedParams) GetFloat64(name string) (float64, error) {
+	v, err := p.get(name)
+	if err != nil {
+		return 0, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("parameter %v is not a float64", name)
+	}
+	return f, nil
+}
+
+// GetBool returns a boolean argument value with a given name.
+func (p *ParsedParams) GetBool(name string) (bool, error) {
+	v, err := p.get(name)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("parameter %v is not a bool", name)
+	}
+	return b, nil
+}
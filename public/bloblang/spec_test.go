@@ -0,0 +1,154 @@
+package bloblang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginSpecTypedParams(t *testing.T) {
+	env := NewEnvironment()
+
+	require.NoError(t, env.RegisterMethodV2("wrap_n",
+		NewPluginSpec().
+			Description("Wraps a string n times.").
+			Param(NewStringParam("prefix").Description("A prefix.")).
+			Param(NewInt64Param("n").Description("How many times.")),
+		func(args *ParsedParams) (Method, error) {
+			prefix, err := args.GetString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			n, err := args.GetInt64("n")
+			if err != nil {
+				return nil, err
+			}
+			return StringMethod(func(s string) (interface{}, error) {
+				return strings.Repeat(prefix, int(n)) + s, nil
+			}), nil
+		}))
+
+	require.NoError(t, env.RegisterFunctionV2("scaled",
+		NewPluginSpec().
+			Param(NewFloat64Param("value")).
+			Param(NewBoolParam("double")),
+		func(args *ParsedParams) (Function, error) {
+			v, err := args.GetFloat64("value")
+			if err != nil {
+				return nil, err
+			}
+			double, err := args.GetBool("double")
+			if err != nil {
+				return nil, err
+			}
+			return func() (interface{}, error) {
+				if double {
+					return v * 2, nil
+				}
+				return v, nil
+			}, nil
+		}))
+
+	exe, err := env.Parse(`
+root.a = this.name.wrap_n("-", 3)
+root.b = scaled(this.num, true)
+root.c = scaled(2.5, false)
+`)
+	require.NoError(t, err)
+
+	v, err := exe.Query(map[string]interface{}{
+		"name": "foo",
+		"num":  5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": "---foo",
+		"b": float64(10),
+		"c": 2.5,
+	}, v)
+
+	_, err = env.Parse(`root = "foo".wrap_n("-", "nope")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected int64 value")
+
+	_, err = env.Parse(`root = "foo".wrap_n("-")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 2 arguments, received 1")
+
+	// Dynamic arguments are only validated at execution.
+	exe, err = env.Parse(`root = scaled(this.num, true)`)
+	require.NoError(t, err)
+
+	_, err = exe.Query(map[string]interface{}{"num": "not a number"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected float64 value")
+}
+
+func TestPluginSpecCollisions(t *testing.T) {
+	env := NewEnvironment()
+
+	noopMethod := func(args *ParsedParams) (Method, error) {
+		return func(v interface{}) (interface{}, error) {
+			return v, nil
+		}, nil
+	}
+	noopFunction := func(args *ParsedParams) (Function, error) {
+		return func() (interface{}, error) {
+			return nil, nil
+		}, nil
+	}
+
+	err := env.RegisterMethodV2("uppercase", NewPluginSpec(), noopMethod)
+	require.EqualError(t, err, "conflicting method name: uppercase")
+
+	err = env.RegisterFunctionV2("uuid_v4", NewPluginSpec(), noopFunction)
+	require.EqualError(t, err, "conflicting function name: uuid_v4")
+
+	err = RegisterMethodV2("uppercase", NewPluginSpec(), noopMethod)
+	require.EqualError(t, err, "conflicting method name: uppercase")
+
+	err = RegisterFunctionV2("uuid_v4", NewPluginSpec(), noopFunction)
+	require.EqualError(t, err, "conflicting function name: uuid_v4")
+
+	err = env.RegisterMethodV2("foo", NewPluginSpec().
+		Param(NewStringParam("a")).
+		Param(NewStringParam("a")), noopMethod)
+	require.EqualError(t, err, "method foo: duplicate parameter name: a")
+
+	err = env.RegisterFunctionV2("foo", NewPluginSpec().Param(NewStringParam("Not Valid")), noopFunction)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match the required regular expression")
+}
+
+func TestPluginSpecDocs(t *testing.T) {
+	spec := NewPluginSpec().
+		Description("Does a thing.").
+		Param(NewStringParam("foo").Description("The foo.")).
+		Param(NewAnyParam("bar").Description("The bar."))
+
+	assert.Equal(t, "Does a thing.\n\n#### Parameters\n\n**`foo`** &lt;string&gt; The foo.  \n**`bar`** &lt;unknown&gt; The bar.  \n", spec.docsDescription())
+}
+
+func TestPluginSpecParamSpecs(t *testing.T) {
+	spec := NewPluginSpec().
+		Param(NewInt64Param("foo").Description("The foo.")).
+		Param(NewAnyParam("bar"))
+
+	qSpec, _, err := functionPlugin("foo", spec, func(*ParsedParams) (Function, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []query.ParamSpec{
+		{Name: "foo", Description: "The foo.", Type: "int64"},
+		{Name: "bar", Type: "unknown"},
+	}, qSpec.Params)
+
+	mSpec, _, err := methodPlugin("foo", NewPluginSpec(), func(*ParsedParams) (Method, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, mSpec.Params)
+}
@@ -11,7 +11,9 @@ import (
 	_ "github.com/Jeffail/benthos/v3/internal/impl/aws"
 	_ "github.com/Jeffail/benthos/v3/internal/impl/confluent"
 	_ "github.com/Jeffail/benthos/v3/internal/impl/gcp"
+	_ "github.com/Jeffail/benthos/v3/internal/impl/grpc"
 	_ "github.com/Jeffail/benthos/v3/internal/impl/mongodb"
 	_ "github.com/Jeffail/benthos/v3/internal/impl/nats"
 	_ "github.com/Jeffail/benthos/v3/internal/impl/pulsar"
+	_ "github.com/Jeffail/benthos/v3/internal/impl/wasm"
 )
@@ -183,6 +183,16 @@ func (c *ConfigSpec) Beta() *ConfigSpec {
 	return c
 }
 
+// Experimental sets a documentation label on the component indicating that its
+// configuration spec is experimental and therefore subject to change or
+// removal outside of major version releases. This is the default for plugins,
+// but can be set explicitly for components that are not yet ready for beta
+// testing.
+func (c *ConfigSpec) Experimental() *ConfigSpec {
+	c.component.Status = docs.StatusExperimental
+	return c
+}
+
 // Categories adds one or more string tags to the component, these are used for
 // arbitrarily grouping components in documentation.
 func (c *ConfigSpec) Categories(categories ...string) *ConfigSpec {
@@ -4,6 +4,9 @@ import (
 	"context"
 
 	"github.com/Jeffail/benthos/v3/internal/bundle"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -16,6 +19,17 @@ func newResourcesFromManager(nm bundle.NewManagement) *Resources {
 	return &Resources{mgr: nm}
 }
 
+// MockResources returns an instantiation of a resources struct that provides
+// valid but ineffective methods and observability components. This is useful
+// for testing components that interact with common resources.
+func MockResources() *Resources {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		panic(err)
+	}
+	return newResourcesFromManager(mgr)
+}
+
 // Label returns a label that identifies the component instantiation. This could
 // be an explicit label set in config, or is otherwise a generated label based
 // on the position of the component within a config.
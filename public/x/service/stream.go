@@ -21,6 +21,12 @@ type Stream struct {
 	shutSig *shutdown.Signaller
 	onStart func()
 
+	onConnected func()
+	onClosing   func()
+	onClosed    func()
+	closingOnce sync.Once
+	closedOnce  sync.Once
+
 	conf   stream.Config
 	mgr    *manager.Type
 	stats  metrics.Type
@@ -59,6 +65,9 @@ func (s *Stream) Run(ctx context.Context) (err error) {
 	}
 
 	go s.onStart()
+	if s.onConnected != nil {
+		go s.awaitConnected()
+	}
 	select {
 	case <-s.shutSig.HasClosedChan():
 		for {
@@ -89,6 +98,11 @@ func (s *Stream) StopWithin(timeout time.Duration) error {
 		return errors.New("stream has not been run yet")
 	}
 
+	s.shutSig.CloseAtLeisure()
+	if s.onClosing != nil {
+		s.closingOnce.Do(s.onClosing)
+	}
+
 	stopAt := time.Now().Add(timeout)
 	if err := strm.Stop(timeout); err != nil {
 		// Still attempt to shut down other resources but do not block.
@@ -106,5 +120,31 @@ func (s *Stream) StopWithin(timeout time.Duration) error {
 		return err
 	}
 
-	return s.stats.Close()
+	if err := s.stats.Close(); err != nil {
+		return err
+	}
+	if s.onClosed != nil {
+		s.closedOnce.Do(s.onClosed)
+	}
+	return nil
+}
+
+func (s *Stream) awaitConnected() {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+
+	for {
+		if strm.IsReady() {
+			s.onConnected()
+			return
+		}
+		select {
+		case <-time.After(time.Millisecond * 50):
+		case <-s.shutSig.CloseAtLeisureChan():
+			return
+		case <-s.shutSig.HasClosedChan():
+			return
+		}
+	}
 }
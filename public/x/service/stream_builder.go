@@ -53,8 +53,13 @@ type StreamBuilder struct {
 	consumerFunc MessageHandlerFunc
 	consumerID   string
 
-	apiMut       manager.APIReg
-	customLogger log.Modular
+	apiMut        manager.APIReg
+	customLogger  log.Modular
+	customMetrics MetricsExporter
+
+	onConnected func()
+	onClosing   func()
+	onClosed    func()
 }
 
 // NewStreamBuilder creates a new StreamBuilder.
@@ -90,6 +95,31 @@ func (s *StreamBuilder) SetPrintLogger(l PrintLogger) {
 	s.customLogger = log.Wrap(l)
 }
 
+// SetMetricsExporter sets a custom metrics exporter to be used by stream
+// components. This custom exporter will override any metrics fields set via
+// config.
+func (s *StreamBuilder) SetMetricsExporter(e MetricsExporter) {
+	s.customMetrics = e
+}
+
+// OnConnected registers a closure to be called once the stream has been run
+// and both its inputs and outputs are connected for the first time.
+func (s *StreamBuilder) OnConnected(fn func()) {
+	s.onConnected = fn
+}
+
+// OnClosing registers a closure to be called when the stream begins to shut
+// down, either because it was stopped or because its inputs were exhausted.
+func (s *StreamBuilder) OnClosing(fn func()) {
+	s.onClosing = fn
+}
+
+// OnClosed registers a closure to be called once the stream and all of its
+// resources have been successfully shut down.
+func (s *StreamBuilder) OnClosed(fn func()) {
+	s.onClosed = fn
+}
+
 // HTTPMultiplexer is an interface supported by most HTTP multiplexers.
 type HTTPMultiplexer interface {
 	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
@@ -469,9 +499,14 @@ func (s *StreamBuilder) Build() (*Stream, error) {
 		}
 	}
 
-	stats, err := metrics.New(s.metrics, metrics.OptSetLogger(logger))
-	if err != nil {
-		return nil, err
+	var stats metrics.Type
+	if s.customMetrics != nil {
+		stats = newAirGapMetrics(s.customMetrics)
+	} else {
+		var err error
+		if stats, err = metrics.New(s.metrics, metrics.OptSetLogger(logger)); err != nil {
+			return nil, err
+		}
 	}
 
 	apiMut := s.apiMut
@@ -508,19 +543,23 @@ func (s *StreamBuilder) Build() (*Stream, error) {
 		mgr.SetPipe(s.producerID, s.producerChan)
 	}
 
-	return newStream(conf.Config, mgr, stats, logger, func() {
+	strm := newStream(conf.Config, mgr, stats, logger, func() {
 		if err := s.runConsumerFunc(mgr); err != nil {
 			logger.Errorf("Failed to run func consumer: %v", err)
 		}
-	}), nil
+	})
+	strm.onConnected = s.onConnected
+	strm.onClosing = s.onClosing
+	strm.onClosed = s.onClosed
+	return strm, nil
 }
 
 type builderConfig struct {
 	HTTP                   *api.Config `yaml:"http,omitempty"`
 	stream.Config          `yaml:",inline"`
 	manager.ResourceConfig `yaml:",inline"`
-	Metrics                metrics.Config `yaml:"metrics"`
-	Logger                 *log.Config    `yaml:"logger,omitempty"`
+	Metrics                *metrics.Config `yaml:"metrics,omitempty"`
+	Logger                 *log.Config     `yaml:"logger,omitempty"`
 }
 
 func (s *StreamBuilder) buildConfig() builderConfig {
@@ -552,7 +591,9 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 	}
 
 	conf.ResourceConfig = s.resources
-	conf.Metrics = s.metrics
+	if s.customMetrics == nil {
+		conf.Metrics = &s.metrics
+	}
 	if s.customLogger == nil {
 		conf.Logger = &s.logger
 	}
@@ -6,10 +6,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/public/bloblang"
 	"github.com/Jeffail/benthos/v3/public/x/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -167,6 +169,141 @@ func TestStreamBuilderCustomLogger(t *testing.T) {
 	assert.NotContains(t, act, exp)
 }
 
+type mockMetricsExporter struct {
+	mut      sync.Mutex
+	counters map[string]int64
+	gauges   map[string]int64
+	closed   bool
+}
+
+type mockMetric func(v int64)
+
+func (m mockMetric) Incr(v int64)   { m(v) }
+func (m mockMetric) Timing(v int64) {}
+func (m mockMetric) Set(v int64)    { m(v) }
+
+func (m *mockMetricsExporter) NewCounterCtor(name string, labelKeys ...string) service.MetricsExporterCounterCtor {
+	return func(labelValues ...string) service.MetricsExporterCounter {
+		return mockMetric(func(v int64) {
+			m.mut.Lock()
+			m.counters[name] += v
+			m.mut.Unlock()
+		})
+	}
+}
+
+func (m *mockMetricsExporter) NewTimerCtor(name string, labelKeys ...string) service.MetricsExporterTimerCtor {
+	return func(labelValues ...string) service.MetricsExporterTimer {
+		return mockMetric(nil)
+	}
+}
+
+func (m *mockMetricsExporter) NewGaugeCtor(name string, labelKeys ...string) service.MetricsExporterGaugeCtor {
+	return func(labelValues ...string) service.MetricsExporterGauge {
+		return mockMetric(func(v int64) {
+			m.mut.Lock()
+			m.gauges[name] = v
+			m.mut.Unlock()
+		})
+	}
+}
+
+func (m *mockMetricsExporter) Close(ctx context.Context) error {
+	m.mut.Lock()
+	m.closed = true
+	m.mut.Unlock()
+	return nil
+}
+
+func TestStreamBuilderMetricsExporter(t *testing.T) {
+	exporter := &mockMetricsExporter{
+		counters: map[string]int64{},
+		gauges:   map[string]int64{},
+	}
+
+	b := service.NewStreamBuilder()
+	b.SetMetricsExporter(exporter)
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+generate:
+  count: 3
+  interval: ""
+  mapping: 'root = "hello world"'
+`))
+	require.NoError(t, b.AddOutputYAML(`drop: {}`))
+
+	act, err := b.AsYAML()
+	require.NoError(t, err)
+	assert.NotContains(t, act, "metrics:")
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	exporter.mut.Lock()
+	defer exporter.mut.Unlock()
+
+	assert.Equal(t, int64(3), exporter.counters["input.received"])
+	assert.Equal(t, int64(3), exporter.counters["output.sent"])
+	assert.True(t, exporter.closed)
+}
+
+func TestStreamBuilderLifecycle(t *testing.T) {
+	var eventsMut sync.Mutex
+	var events []string
+	addEvent := func(e string) func() {
+		return func() {
+			eventsMut.Lock()
+			events = append(events, e)
+			eventsMut.Unlock()
+		}
+	}
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+generate:
+  interval: 1ms
+  mapping: 'root = "hello world"'
+`))
+	require.NoError(t, b.AddOutputYAML(`drop: {}`))
+
+	connectedChan := make(chan struct{})
+	b.OnConnected(func() {
+		addEvent("connected")()
+		close(connectedChan)
+	})
+	b.OnClosing(addEvent("closing"))
+	b.OnClosed(addEvent("closed"))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-connectedChan:
+		case <-time.After(time.Second * 10):
+			t.Error("timed out waiting for connected event")
+		}
+		assert.NoError(t, strm.StopWithin(time.Second*5))
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+	wg.Wait()
+
+	eventsMut.Lock()
+	assert.Equal(t, []string{"connected", "closing", "closed"}, events)
+	eventsMut.Unlock()
+}
+
 func TestStreamBuilderSetYAML(t *testing.T) {
 	b := service.NewStreamBuilder()
 	b.SetThreads(10)
@@ -300,6 +437,8 @@ func TestStreamBuilderSetYAMLBrokers(t *testing.T) {
         copies: 1
         pattern: fan_out
         max_in_flight: 1
+        partition_by: ""
+        on_unavailable: spill
         outputs:`,
 		`            - label: ""
               nats:`,
@@ -397,3 +536,55 @@ output:
 		assert.Contains(t, act, str)
 	}
 }
+
+func TestStreamBuilderBloblangPlugins(t *testing.T) {
+	require.NoError(t, bloblang.RegisterMethodV2("stream_builder_test_id_decode",
+		bloblang.NewPluginSpec().
+			Description("Decodes an internal ID.").
+			Param(bloblang.NewStringParam("prefix")),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			prefix, err := args.GetString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			return bloblang.StringMethod(func(s string) (interface{}, error) {
+				return strings.TrimPrefix(s, prefix), nil
+			}), nil
+		}))
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+generate:
+  count: 2
+  interval: ""
+  mapping: 'root.id = "id-" + count("stream_builder_test_id_decode").string()'
+`))
+	require.NoError(t, b.AddProcessorYAML(`bloblang: 'root.id = this.id.stream_builder_test_id_decode("id-")'`))
+	require.NoError(t, b.AddProcessorYAML(`
+metadata:
+  operator: set
+  key: decoded
+  value: '${! json("id").stream_builder_test_id_decode("") }'
+`))
+
+	// Linting should catch bad arguments.
+	err := b.AddProcessorYAML(`bloblang: 'root = this.id.stream_builder_test_id_decode()'`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 1 arguments, received 0")
+
+	var outMsgs []string
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		meta, _ := m.MetaGet("decoded")
+		outMsgs = append(outMsgs, string(b)+" "+meta)
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+	require.NoError(t, strm.Run(context.Background()))
+
+	assert.Equal(t, []string{`{"id":"1"} 1`, `{"id":"2"} 2`}, outMsgs)
+}
@@ -27,6 +27,7 @@ module.exports = {
         'configuration/error_handling',
         'configuration/interpolation',
         'configuration/field_paths',
+        'configuration/redaction',
         'configuration/processing_pipelines',
         'configuration/unit_testing',
         'configuration/templating',